@@ -160,9 +160,17 @@ func (x *PruneRequest) GetMinFreeSpace() int64 {
 }
 
 type DiskUsageRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filter        []string               `protobuf:"bytes,1,rep,name=filter,proto3" json:"filter,omitempty"`
-	AgeLimit      int64                  `protobuf:"varint,2,opt,name=ageLimit,proto3" json:"ageLimit,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Filter   []string               `protobuf:"bytes,1,rep,name=filter,proto3" json:"filter,omitempty"`
+	AgeLimit int64                  `protobuf:"varint,2,opt,name=ageLimit,proto3" json:"ageLimit,omitempty"`
+	// pageSize limits the number of records in the response. All records are
+	// returned if unset.
+	PageSize int32 `protobuf:"varint,3,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	// pageToken is the nextPageToken of the previous response.
+	PageToken string `protobuf:"bytes,4,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	// breakdown requests the usage of all the records matching the filter
+	// by record type and age. It is returned with the first page.
+	Breakdown     bool `protobuf:"varint,5,opt,name=breakdown,proto3" json:"breakdown,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -211,9 +219,33 @@ func (x *DiskUsageRequest) GetAgeLimit() int64 {
 	return 0
 }
 
+func (x *DiskUsageRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *DiskUsageRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *DiskUsageRequest) GetBreakdown() bool {
+	if x != nil {
+		return x.Breakdown
+	}
+	return false
+}
+
 type DiskUsageResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Record        []*UsageRecord         `protobuf:"bytes,1,rep,name=record,proto3" json:"record,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Record []*UsageRecord         `protobuf:"bytes,1,rep,name=record,proto3" json:"record,omitempty"`
+	// nextPageToken is set if there may be more records than pageSize.
+	NextPageToken string            `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	Breakdown     []*UsageBreakdown `protobuf:"bytes,3,rep,name=breakdown,proto3" json:"breakdown,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -255,6 +287,100 @@ func (x *DiskUsageResponse) GetRecord() []*UsageRecord {
 	return nil
 }
 
+func (x *DiskUsageResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *DiskUsageResponse) GetBreakdown() []*UsageBreakdown {
+	if x != nil {
+		return x.Breakdown
+	}
+	return nil
+}
+
+// UsageBreakdown is the usage of the records of a type last used in an age
+// bucket.
+type UsageBreakdown struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	RecordType string                 `protobuf:"bytes,1,opt,name=recordType,proto3" json:"recordType,omitempty"`
+	// age is the upper bound of the time since the records were last used
+	// in nanoseconds. It is 0 for the records older than all the buckets.
+	Age           int64 `protobuf:"varint,2,opt,name=age,proto3" json:"age,omitempty"`
+	Count         int64 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	Size          int64 `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	Reclaimable   int64 `protobuf:"varint,5,opt,name=reclaimable,proto3" json:"reclaimable,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UsageBreakdown) Reset() {
+	*x = UsageBreakdown{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UsageBreakdown) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UsageBreakdown) ProtoMessage() {}
+
+func (x *UsageBreakdown) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UsageBreakdown.ProtoReflect.Descriptor instead.
+func (*UsageBreakdown) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UsageBreakdown) GetRecordType() string {
+	if x != nil {
+		return x.RecordType
+	}
+	return ""
+}
+
+func (x *UsageBreakdown) GetAge() int64 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
+}
+
+func (x *UsageBreakdown) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *UsageBreakdown) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *UsageBreakdown) GetReclaimable() int64 {
+	if x != nil {
+		return x.Reclaimable
+	}
+	return 0
+}
+
 type UsageRecord struct {
 	state   protoimpl.MessageState `protogen:"open.v1"`
 	ID      string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
@@ -262,21 +388,23 @@ type UsageRecord struct {
 	InUse   bool                   `protobuf:"varint,3,opt,name=InUse,proto3" json:"InUse,omitempty"`
 	Size    int64                  `protobuf:"varint,4,opt,name=Size,proto3" json:"Size,omitempty"`
 	// Deprecated: Marked as deprecated in github.com/moby/buildkit/api/services/control/control.proto.
-	Parent        string               `protobuf:"bytes,5,opt,name=Parent,proto3" json:"Parent,omitempty"`
-	CreatedAt     *timestamp.Timestamp `protobuf:"bytes,6,opt,name=CreatedAt,proto3" json:"CreatedAt,omitempty"`
-	LastUsedAt    *timestamp.Timestamp `protobuf:"bytes,7,opt,name=LastUsedAt,proto3" json:"LastUsedAt,omitempty"`
-	UsageCount    int64                `protobuf:"varint,8,opt,name=UsageCount,proto3" json:"UsageCount,omitempty"`
-	Description   string               `protobuf:"bytes,9,opt,name=Description,proto3" json:"Description,omitempty"`
-	RecordType    string               `protobuf:"bytes,10,opt,name=RecordType,proto3" json:"RecordType,omitempty"`
-	Shared        bool                 `protobuf:"varint,11,opt,name=Shared,proto3" json:"Shared,omitempty"`
-	Parents       []string             `protobuf:"bytes,12,rep,name=Parents,proto3" json:"Parents,omitempty"`
+	Parent      string               `protobuf:"bytes,5,opt,name=Parent,proto3" json:"Parent,omitempty"`
+	CreatedAt   *timestamp.Timestamp `protobuf:"bytes,6,opt,name=CreatedAt,proto3" json:"CreatedAt,omitempty"`
+	LastUsedAt  *timestamp.Timestamp `protobuf:"bytes,7,opt,name=LastUsedAt,proto3" json:"LastUsedAt,omitempty"`
+	UsageCount  int64                `protobuf:"varint,8,opt,name=UsageCount,proto3" json:"UsageCount,omitempty"`
+	Description string               `protobuf:"bytes,9,opt,name=Description,proto3" json:"Description,omitempty"`
+	RecordType  string               `protobuf:"bytes,10,opt,name=RecordType,proto3" json:"RecordType,omitempty"`
+	Shared      bool                 `protobuf:"varint,11,opt,name=Shared,proto3" json:"Shared,omitempty"`
+	Parents     []string             `protobuf:"bytes,12,rep,name=Parents,proto3" json:"Parents,omitempty"`
+	// Client is the name of the client identity that created the record
+	Client        string `protobuf:"bytes,13,opt,name=Client,proto3" json:"Client,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UsageRecord) Reset() {
 	*x = UsageRecord{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[3]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -288,7 +416,7 @@ func (x *UsageRecord) String() string {
 func (*UsageRecord) ProtoMessage() {}
 
 func (x *UsageRecord) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[3]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -301,7 +429,7 @@ func (x *UsageRecord) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UsageRecord.ProtoReflect.Descriptor instead.
 func (*UsageRecord) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{3}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UsageRecord) GetID() string {
@@ -389,6 +517,13 @@ func (x *UsageRecord) GetParents() []string {
 	return nil
 }
 
+func (x *UsageRecord) GetClient() string {
+	if x != nil {
+		return x.Client
+	}
+	return ""
+}
+
 type SolveRequest struct {
 	state      protoimpl.MessageState `protogen:"open.v1"`
 	Ref        string                 `protobuf:"bytes,1,opt,name=Ref,proto3" json:"Ref,omitempty"`
@@ -408,13 +543,32 @@ type SolveRequest struct {
 	SourcePolicy            *pb1.Policy               `protobuf:"bytes,12,opt,name=SourcePolicy,proto3" json:"SourcePolicy,omitempty"`
 	Exporters               []*Exporter               `protobuf:"bytes,13,rep,name=Exporters,proto3" json:"Exporters,omitempty"`
 	EnableSessionExporter   bool                      `protobuf:"varint,14,opt,name=EnableSessionExporter,proto3" json:"EnableSessionExporter,omitempty"`
-	unknownFields           protoimpl.UnknownFields
-	sizeCache               protoimpl.SizeCache
+	// ConcurrencyLimits overrides the concurrency limits of the daemon for
+	// the operations of this build
+	ConcurrencyLimits *ConcurrencyLimits `protobuf:"bytes,15,opt,name=ConcurrencyLimits,proto3" json:"ConcurrencyLimits,omitempty"`
+	// WorkerConstraints select the worker of the build, in the
+	// "worker.label.<key>=<value>" and "worker.id=<id>" forms
+	WorkerConstraints []string `protobuf:"bytes,16,rep,name=WorkerConstraints,proto3" json:"WorkerConstraints,omitempty"`
+	// Lockfile returns the lockfile of the resolved sources of the build in
+	// the exporter response
+	Lockfile bool `protobuf:"varint,17,opt,name=Lockfile,proto3" json:"Lockfile,omitempty"`
+	// SessionRef retains the result of the build as a named ref that the
+	// later builds of the same session can use with llb.SessionRef
+	SessionRef string `protobuf:"bytes,18,opt,name=SessionRef,proto3" json:"SessionRef,omitempty"`
+	// ResultLeaseTTL takes a lease keeping the result refs of the build from
+	// being pruned for this duration in nanoseconds. The ID of the lease is
+	// returned in the exporter response.
+	ResultLeaseTTL int64 `protobuf:"varint,19,opt,name=ResultLeaseTTL,proto3" json:"ResultLeaseTTL,omitempty"`
+	// Proxy is the HTTP proxy configuration of the git, http and registry
+	// fetches of this build, instead of the proxy environment of the daemon
+	Proxy         *ProxyConfig `protobuf:"bytes,20,opt,name=Proxy,proto3" json:"Proxy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SolveRequest) Reset() {
 	*x = SolveRequest{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[4]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -426,7 +580,7 @@ func (x *SolveRequest) String() string {
 func (*SolveRequest) ProtoMessage() {}
 
 func (x *SolveRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[4]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -439,7 +593,7 @@ func (x *SolveRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SolveRequest.ProtoReflect.Descriptor instead.
 func (*SolveRequest) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{4}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *SolveRequest) GetRef() string {
@@ -540,6 +694,190 @@ func (x *SolveRequest) GetEnableSessionExporter() bool {
 	return false
 }
 
+func (x *SolveRequest) GetConcurrencyLimits() *ConcurrencyLimits {
+	if x != nil {
+		return x.ConcurrencyLimits
+	}
+	return nil
+}
+
+func (x *SolveRequest) GetWorkerConstraints() []string {
+	if x != nil {
+		return x.WorkerConstraints
+	}
+	return nil
+}
+
+func (x *SolveRequest) GetLockfile() bool {
+	if x != nil {
+		return x.Lockfile
+	}
+	return false
+}
+
+func (x *SolveRequest) GetSessionRef() string {
+	if x != nil {
+		return x.SessionRef
+	}
+	return ""
+}
+
+func (x *SolveRequest) GetResultLeaseTTL() int64 {
+	if x != nil {
+		return x.ResultLeaseTTL
+	}
+	return 0
+}
+
+func (x *SolveRequest) GetProxy() *ProxyConfig {
+	if x != nil {
+		return x.Proxy
+	}
+	return nil
+}
+
+// ProxyConfig is the HTTP proxy configuration of a build, in the format of
+// the proxy environment variables
+type ProxyConfig struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	HTTPProxy  string                 `protobuf:"bytes,1,opt,name=HTTPProxy,proto3" json:"HTTPProxy,omitempty"`
+	HTTPSProxy string                 `protobuf:"bytes,2,opt,name=HTTPSProxy,proto3" json:"HTTPSProxy,omitempty"`
+	NoProxy    string                 `protobuf:"bytes,3,opt,name=NoProxy,proto3" json:"NoProxy,omitempty"`
+	AllProxy   string                 `protobuf:"bytes,4,opt,name=AllProxy,proto3" json:"AllProxy,omitempty"`
+	// Exec also sets the proxy environment variables of the exec ops of the
+	// build that don't set their own
+	Exec          bool `protobuf:"varint,5,opt,name=Exec,proto3" json:"Exec,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProxyConfig) Reset() {
+	*x = ProxyConfig{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProxyConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProxyConfig) ProtoMessage() {}
+
+func (x *ProxyConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProxyConfig.ProtoReflect.Descriptor instead.
+func (*ProxyConfig) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ProxyConfig) GetHTTPProxy() string {
+	if x != nil {
+		return x.HTTPProxy
+	}
+	return ""
+}
+
+func (x *ProxyConfig) GetHTTPSProxy() string {
+	if x != nil {
+		return x.HTTPSProxy
+	}
+	return ""
+}
+
+func (x *ProxyConfig) GetNoProxy() string {
+	if x != nil {
+		return x.NoProxy
+	}
+	return ""
+}
+
+func (x *ProxyConfig) GetAllProxy() string {
+	if x != nil {
+		return x.AllProxy
+	}
+	return ""
+}
+
+func (x *ProxyConfig) GetExec() bool {
+	if x != nil {
+		return x.Exec
+	}
+	return false
+}
+
+// ConcurrencyLimits is the maximum number of operations of each type that
+// can run at the same time. Zero values are not limited.
+type ConcurrencyLimits struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Exec          int32                  `protobuf:"varint,1,opt,name=Exec,proto3" json:"Exec,omitempty"`
+	ImagePull     int32                  `protobuf:"varint,2,opt,name=ImagePull,proto3" json:"ImagePull,omitempty"`
+	LocalSync     int32                  `protobuf:"varint,3,opt,name=LocalSync,proto3" json:"LocalSync,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConcurrencyLimits) Reset() {
+	*x = ConcurrencyLimits{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConcurrencyLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConcurrencyLimits) ProtoMessage() {}
+
+func (x *ConcurrencyLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConcurrencyLimits.ProtoReflect.Descriptor instead.
+func (*ConcurrencyLimits) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ConcurrencyLimits) GetExec() int32 {
+	if x != nil {
+		return x.Exec
+	}
+	return 0
+}
+
+func (x *ConcurrencyLimits) GetImagePull() int32 {
+	if x != nil {
+		return x.ImagePull
+	}
+	return 0
+}
+
+func (x *ConcurrencyLimits) GetLocalSync() int32 {
+	if x != nil {
+		return x.LocalSync
+	}
+	return 0
+}
+
 type CacheOptions struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// ExportRefDeprecated is deprecated in favor or the new Exports since BuildKit v0.4.0.
@@ -565,7 +903,7 @@ type CacheOptions struct {
 
 func (x *CacheOptions) Reset() {
 	*x = CacheOptions{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[5]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -577,7 +915,7 @@ func (x *CacheOptions) String() string {
 func (*CacheOptions) ProtoMessage() {}
 
 func (x *CacheOptions) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[5]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -590,7 +928,7 @@ func (x *CacheOptions) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CacheOptions.ProtoReflect.Descriptor instead.
 func (*CacheOptions) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{5}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *CacheOptions) GetExportRefDeprecated() string {
@@ -641,7 +979,7 @@ type CacheOptionsEntry struct {
 
 func (x *CacheOptionsEntry) Reset() {
 	*x = CacheOptionsEntry{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[6]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -653,7 +991,7 @@ func (x *CacheOptionsEntry) String() string {
 func (*CacheOptionsEntry) ProtoMessage() {}
 
 func (x *CacheOptionsEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[6]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -666,7 +1004,7 @@ func (x *CacheOptionsEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CacheOptionsEntry.ProtoReflect.Descriptor instead.
 func (*CacheOptionsEntry) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{6}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *CacheOptionsEntry) GetType() string {
@@ -686,13 +1024,16 @@ func (x *CacheOptionsEntry) GetAttrs() map[string]string {
 type SolveResponse struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	ExporterResponse map[string]string      `protobuf:"bytes,1,rep,name=ExporterResponse,proto3" json:"ExporterResponse,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	// ExporterResponses are the responses of each exporter, in the order of
+	// the exporters of the request
+	ExporterResponses []*ExporterResponse `protobuf:"bytes,2,rep,name=ExporterResponses,proto3" json:"ExporterResponses,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *SolveResponse) Reset() {
 	*x = SolveResponse{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[7]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -704,7 +1045,7 @@ func (x *SolveResponse) String() string {
 func (*SolveResponse) ProtoMessage() {}
 
 func (x *SolveResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[7]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -717,7 +1058,7 @@ func (x *SolveResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SolveResponse.ProtoReflect.Descriptor instead.
 func (*SolveResponse) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{7}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *SolveResponse) GetExporterResponse() map[string]string {
@@ -727,16 +1068,247 @@ func (x *SolveResponse) GetExporterResponse() map[string]string {
 	return nil
 }
 
-type StatusRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Ref           string                 `protobuf:"bytes,1,opt,name=Ref,proto3" json:"Ref,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
+func (x *SolveResponse) GetExporterResponses() []*ExporterResponse {
+	if x != nil {
+		return x.ExporterResponses
+	}
+	return nil
+}
+
+type ValidateSolveResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Diagnostics are the problems found in the request. The request is
+	// valid if none of them is an error.
+	Diagnostics   []*SolveDiagnostic `protobuf:"bytes,1,rep,name=Diagnostics,proto3" json:"Diagnostics,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValidateSolveResponse) Reset() {
+	*x = ValidateSolveResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValidateSolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidateSolveResponse) ProtoMessage() {}
+
+func (x *ValidateSolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidateSolveResponse.ProtoReflect.Descriptor instead.
+func (*ValidateSolveResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ValidateSolveResponse) GetDiagnostics() []*SolveDiagnostic {
+	if x != nil {
+		return x.Diagnostics
+	}
+	return nil
+}
+
+type SolveDiagnostic struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Check is the validation step that found the problem: "request",
+	// "worker", "quota", "frontend", "llb", "entitlements", "source-policy",
+	// "emulators", "exporter", "cache-export" or "cache-import"
+	Check   string `protobuf:"bytes,1,opt,name=Check,proto3" json:"Check,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=Message,proto3" json:"Message,omitempty"`
+	// Warning is set for the problems that don't fail the solve
+	Warning bool `protobuf:"varint,3,opt,name=Warning,proto3" json:"Warning,omitempty"`
+	// Vertex is the digest of the vertex of the problem, if any
+	Vertex        string `protobuf:"bytes,4,opt,name=Vertex,proto3" json:"Vertex,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SolveDiagnostic) Reset() {
+	*x = SolveDiagnostic{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SolveDiagnostic) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolveDiagnostic) ProtoMessage() {}
+
+func (x *SolveDiagnostic) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SolveDiagnostic.ProtoReflect.Descriptor instead.
+func (*SolveDiagnostic) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SolveDiagnostic) GetCheck() string {
+	if x != nil {
+		return x.Check
+	}
+	return ""
+}
+
+func (x *SolveDiagnostic) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SolveDiagnostic) GetWarning() bool {
+	if x != nil {
+		return x.Warning
+	}
+	return false
+}
+
+func (x *SolveDiagnostic) GetVertex() string {
+	if x != nil {
+		return x.Vertex
+	}
+	return ""
+}
+
+type ExporterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metadata      *ExporterMetadata      `protobuf:"bytes,1,opt,name=Metadata,proto3" json:"Metadata,omitempty"`
+	Data          map[string]string      `protobuf:"bytes,2,rep,name=Data,proto3" json:"Data,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExporterResponse) Reset() {
+	*x = ExporterResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExporterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExporterResponse) ProtoMessage() {}
+
+func (x *ExporterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExporterResponse.ProtoReflect.Descriptor instead.
+func (*ExporterResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ExporterResponse) GetMetadata() *ExporterMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ExporterResponse) GetData() map[string]string {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ExporterMetadata struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID is the index of the exporter in the request
+	ID            string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Type          string `protobuf:"bytes,2,opt,name=Type,proto3" json:"Type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExporterMetadata) Reset() {
+	*x = ExporterMetadata{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExporterMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExporterMetadata) ProtoMessage() {}
+
+func (x *ExporterMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExporterMetadata.ProtoReflect.Descriptor instead.
+func (*ExporterMetadata) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ExporterMetadata) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *ExporterMetadata) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ref           string                 `protobuf:"bytes,1,opt,name=Ref,proto3" json:"Ref,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
 func (x *StatusRequest) Reset() {
 	*x = StatusRequest{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[8]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -748,7 +1320,7 @@ func (x *StatusRequest) String() string {
 func (*StatusRequest) ProtoMessage() {}
 
 func (x *StatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[8]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -761,7 +1333,7 @@ func (x *StatusRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
 func (*StatusRequest) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{8}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *StatusRequest) GetRef() string {
@@ -783,7 +1355,7 @@ type StatusResponse struct {
 
 func (x *StatusResponse) Reset() {
 	*x = StatusResponse{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[9]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -795,7 +1367,7 @@ func (x *StatusResponse) String() string {
 func (*StatusResponse) ProtoMessage() {}
 
 func (x *StatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[9]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -808,7 +1380,7 @@ func (x *StatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
 func (*StatusResponse) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{9}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *StatusResponse) GetVertexes() []*Vertex {
@@ -849,13 +1421,20 @@ type Vertex struct {
 	Completed     *timestamp.Timestamp   `protobuf:"bytes,6,opt,name=completed,proto3" json:"completed,omitempty"`
 	Error         string                 `protobuf:"bytes,7,opt,name=error,proto3" json:"error,omitempty"` // typed errors?
 	ProgressGroup *pb.ProgressGroup      `protobuf:"bytes,8,opt,name=progressGroup,proto3" json:"progressGroup,omitempty"`
+	// resourceUsage is set on the completion of the steps that ran
+	// processes, if the worker records their cgroup stats
+	ResourceUsage *ResourceUsage `protobuf:"bytes,9,opt,name=resourceUsage,proto3" json:"resourceUsage,omitempty"`
+	// cacheSource is the cache the result of a cached step was loaded from:
+	// the cache of the daemon or the name of a cache import, e.g.
+	// "registry:docker.io/org/app:cache"
+	CacheSource   string `protobuf:"bytes,10,opt,name=cacheSource,proto3" json:"cacheSource,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Vertex) Reset() {
 	*x = Vertex{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[10]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -867,7 +1446,7 @@ func (x *Vertex) String() string {
 func (*Vertex) ProtoMessage() {}
 
 func (x *Vertex) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[10]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -880,7 +1459,7 @@ func (x *Vertex) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Vertex.ProtoReflect.Descriptor instead.
 func (*Vertex) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{10}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *Vertex) GetDigest() string {
@@ -939,6 +1518,91 @@ func (x *Vertex) GetProgressGroup() *pb.ProgressGroup {
 	return nil
 }
 
+func (x *Vertex) GetResourceUsage() *ResourceUsage {
+	if x != nil {
+		return x.ResourceUsage
+	}
+	return nil
+}
+
+func (x *Vertex) GetCacheSource() string {
+	if x != nil {
+		return x.CacheSource
+	}
+	return ""
+}
+
+// ResourceUsage is the resources used by the processes of a step
+type ResourceUsage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// cpuNanos is the CPU time of the processes in nanoseconds
+	CpuNanos uint64 `protobuf:"varint,1,opt,name=cpuNanos,proto3" json:"cpuNanos,omitempty"`
+	// memoryPeakBytes is the peak memory usage of the processes
+	MemoryPeakBytes uint64 `protobuf:"varint,2,opt,name=memoryPeakBytes,proto3" json:"memoryPeakBytes,omitempty"`
+	IoReadBytes     uint64 `protobuf:"varint,3,opt,name=ioReadBytes,proto3" json:"ioReadBytes,omitempty"`
+	IoWriteBytes    uint64 `protobuf:"varint,4,opt,name=ioWriteBytes,proto3" json:"ioWriteBytes,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ResourceUsage) Reset() {
+	*x = ResourceUsage{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResourceUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResourceUsage) ProtoMessage() {}
+
+func (x *ResourceUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResourceUsage.ProtoReflect.Descriptor instead.
+func (*ResourceUsage) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ResourceUsage) GetCpuNanos() uint64 {
+	if x != nil {
+		return x.CpuNanos
+	}
+	return 0
+}
+
+func (x *ResourceUsage) GetMemoryPeakBytes() uint64 {
+	if x != nil {
+		return x.MemoryPeakBytes
+	}
+	return 0
+}
+
+func (x *ResourceUsage) GetIoReadBytes() uint64 {
+	if x != nil {
+		return x.IoReadBytes
+	}
+	return 0
+}
+
+func (x *ResourceUsage) GetIoWriteBytes() uint64 {
+	if x != nil {
+		return x.IoWriteBytes
+	}
+	return 0
+}
+
 type VertexStatus struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ID            string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
@@ -955,7 +1619,7 @@ type VertexStatus struct {
 
 func (x *VertexStatus) Reset() {
 	*x = VertexStatus{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[11]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -967,7 +1631,7 @@ func (x *VertexStatus) String() string {
 func (*VertexStatus) ProtoMessage() {}
 
 func (x *VertexStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[11]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -980,7 +1644,7 @@ func (x *VertexStatus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VertexStatus.ProtoReflect.Descriptor instead.
 func (*VertexStatus) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{11}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *VertexStatus) GetID() string {
@@ -1040,18 +1704,21 @@ func (x *VertexStatus) GetCompleted() *timestamp.Timestamp {
 }
 
 type VertexLog struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Vertex        string                 `protobuf:"bytes,1,opt,name=vertex,proto3" json:"vertex,omitempty"`
-	Timestamp     *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	Stream        int64                  `protobuf:"varint,3,opt,name=stream,proto3" json:"stream,omitempty"`
-	Msg           []byte                 `protobuf:"bytes,4,opt,name=msg,proto3" json:"msg,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Vertex    string                 `protobuf:"bytes,1,opt,name=vertex,proto3" json:"vertex,omitempty"`
+	Timestamp *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Stream    int64                  `protobuf:"varint,3,opt,name=stream,proto3" json:"stream,omitempty"`
+	Msg       []byte                 `protobuf:"bytes,4,opt,name=msg,proto3" json:"msg,omitempty"`
+	// truncated is set on the message logged when output of the stream was
+	// dropped because of the step log limits
+	Truncated     bool `protobuf:"varint,5,opt,name=truncated,proto3" json:"truncated,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *VertexLog) Reset() {
 	*x = VertexLog{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[12]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1063,7 +1730,7 @@ func (x *VertexLog) String() string {
 func (*VertexLog) ProtoMessage() {}
 
 func (x *VertexLog) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[12]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1076,7 +1743,7 @@ func (x *VertexLog) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VertexLog.ProtoReflect.Descriptor instead.
 func (*VertexLog) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{12}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *VertexLog) GetVertex() string {
@@ -1107,6 +1774,13 @@ func (x *VertexLog) GetMsg() []byte {
 	return nil
 }
 
+func (x *VertexLog) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
 type VertexWarning struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Vertex        string                 `protobuf:"bytes,1,opt,name=vertex,proto3" json:"vertex,omitempty"`
@@ -1122,7 +1796,7 @@ type VertexWarning struct {
 
 func (x *VertexWarning) Reset() {
 	*x = VertexWarning{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[13]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1134,7 +1808,7 @@ func (x *VertexWarning) String() string {
 func (*VertexWarning) ProtoMessage() {}
 
 func (x *VertexWarning) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[13]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1147,7 +1821,7 @@ func (x *VertexWarning) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VertexWarning.ProtoReflect.Descriptor instead.
 func (*VertexWarning) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{13}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *VertexWarning) GetVertex() string {
@@ -1171,56 +1845,1061 @@ func (x *VertexWarning) GetShort() []byte {
 	return nil
 }
 
-func (x *VertexWarning) GetDetail() [][]byte {
-	if x != nil {
-		return x.Detail
-	}
-	return nil
+func (x *VertexWarning) GetDetail() [][]byte {
+	if x != nil {
+		return x.Detail
+	}
+	return nil
+}
+
+func (x *VertexWarning) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *VertexWarning) GetInfo() *pb.SourceInfo {
+	if x != nil {
+		return x.Info
+	}
+	return nil
+}
+
+func (x *VertexWarning) GetRanges() []*pb.Range {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+type BytesMessage struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BytesMessage) Reset() {
+	*x = BytesMessage{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BytesMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BytesMessage) ProtoMessage() {}
+
+func (x *BytesMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BytesMessage.ProtoReflect.Descriptor instead.
+func (*BytesMessage) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *BytesMessage) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ListWorkersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        []string               `protobuf:"bytes,1,rep,name=filter,proto3" json:"filter,omitempty"` // containerd style
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkersRequest) Reset() {
+	*x = ListWorkersRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkersRequest) ProtoMessage() {}
+
+func (x *ListWorkersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkersRequest.ProtoReflect.Descriptor instead.
+func (*ListWorkersRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListWorkersRequest) GetFilter() []string {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type ListWorkersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Record        []*types.WorkerRecord  `protobuf:"bytes,1,rep,name=record,proto3" json:"record,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkersResponse) Reset() {
+	*x = ListWorkersResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkersResponse) ProtoMessage() {}
+
+func (x *ListWorkersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkersResponse.ProtoReflect.Descriptor instead.
+func (*ListWorkersResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListWorkersResponse) GetRecord() []*types.WorkerRecord {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+type InfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InfoRequest) Reset() {
+	*x = InfoRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoRequest) ProtoMessage() {}
+
+func (x *InfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
+func (*InfoRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{25}
+}
+
+type InfoResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	BuildkitVersion *types.BuildkitVersion `protobuf:"bytes,1,opt,name=buildkitVersion,proto3" json:"buildkitVersion,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *InfoResponse) Reset() {
+	*x = InfoResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoResponse) ProtoMessage() {}
+
+func (x *InfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
+func (*InfoResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *InfoResponse) GetBuildkitVersion() *types.BuildkitVersion {
+	if x != nil {
+		return x.BuildkitVersion
+	}
+	return nil
+}
+
+type VerifyCacheRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Repair        bool                   `protobuf:"varint,1,opt,name=repair,proto3" json:"repair,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyCacheRequest) Reset() {
+	*x = VerifyCacheRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyCacheRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyCacheRequest) ProtoMessage() {}
+
+func (x *VerifyCacheRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyCacheRequest.ProtoReflect.Descriptor instead.
+func (*VerifyCacheRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *VerifyCacheRequest) GetRepair() bool {
+	if x != nil {
+		return x.Repair
+	}
+	return false
+}
+
+type VerifyCacheResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Issues        []*CacheIssue          `protobuf:"bytes,1,rep,name=issues,proto3" json:"issues,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyCacheResponse) Reset() {
+	*x = VerifyCacheResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyCacheResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyCacheResponse) ProtoMessage() {}
+
+func (x *VerifyCacheResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyCacheResponse.ProtoReflect.Descriptor instead.
+func (*VerifyCacheResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *VerifyCacheResponse) GetIssues() []*CacheIssue {
+	if x != nil {
+		return x.Issues
+	}
+	return nil
+}
+
+type CacheIssue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ID            string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=Type,proto3" json:"Type,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=Description,proto3" json:"Description,omitempty"`
+	Repaired      bool                   `protobuf:"varint,4,opt,name=Repaired,proto3" json:"Repaired,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CacheIssue) Reset() {
+	*x = CacheIssue{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CacheIssue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CacheIssue) ProtoMessage() {}
+
+func (x *CacheIssue) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CacheIssue.ProtoReflect.Descriptor instead.
+func (*CacheIssue) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *CacheIssue) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *CacheIssue) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *CacheIssue) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CacheIssue) GetRepaired() bool {
+	if x != nil {
+		return x.Repaired
+	}
+	return false
+}
+
+type ResultLease struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	ID    string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// Ref is the ref of the build that took the lease
+	Ref           string               `protobuf:"bytes,2,opt,name=Ref,proto3" json:"Ref,omitempty"`
+	CreatedAt     *timestamp.Timestamp `protobuf:"bytes,3,opt,name=CreatedAt,proto3" json:"CreatedAt,omitempty"`
+	ExpiresAt     *timestamp.Timestamp `protobuf:"bytes,4,opt,name=ExpiresAt,proto3" json:"ExpiresAt,omitempty"`
+	Records       []*ResultLeaseRecord `protobuf:"bytes,5,rep,name=Records,proto3" json:"Records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultLease) Reset() {
+	*x = ResultLease{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultLease) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultLease) ProtoMessage() {}
+
+func (x *ResultLease) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultLease.ProtoReflect.Descriptor instead.
+func (*ResultLease) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ResultLease) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *ResultLease) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *ResultLease) GetCreatedAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *ResultLease) GetExpiresAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *ResultLease) GetRecords() []*ResultLeaseRecord {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+type ResultLeaseRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Key is the key of the ref in the result, empty for the default ref
+	Key string `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	// ID is the ID of the cache record of the ref
+	ID            string `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	WorkerID      string `protobuf:"bytes,3,opt,name=WorkerID,proto3" json:"WorkerID,omitempty"`
+	SnapshotID    string `protobuf:"bytes,4,opt,name=SnapshotID,proto3" json:"SnapshotID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultLeaseRecord) Reset() {
+	*x = ResultLeaseRecord{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultLeaseRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultLeaseRecord) ProtoMessage() {}
+
+func (x *ResultLeaseRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultLeaseRecord.ProtoReflect.Descriptor instead.
+func (*ResultLeaseRecord) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ResultLeaseRecord) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ResultLeaseRecord) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *ResultLeaseRecord) GetWorkerID() string {
+	if x != nil {
+		return x.WorkerID
+	}
+	return ""
+}
+
+func (x *ResultLeaseRecord) GetSnapshotID() string {
+	if x != nil {
+		return x.SnapshotID
+	}
+	return ""
+}
+
+type ListResultLeasesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResultLeasesRequest) Reset() {
+	*x = ListResultLeasesRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResultLeasesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResultLeasesRequest) ProtoMessage() {}
+
+func (x *ListResultLeasesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResultLeasesRequest.ProtoReflect.Descriptor instead.
+func (*ListResultLeasesRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{32}
+}
+
+type ListResultLeasesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Leases        []*ResultLease         `protobuf:"bytes,1,rep,name=leases,proto3" json:"leases,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResultLeasesResponse) Reset() {
+	*x = ListResultLeasesResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResultLeasesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResultLeasesResponse) ProtoMessage() {}
+
+func (x *ListResultLeasesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResultLeasesResponse.ProtoReflect.Descriptor instead.
+func (*ListResultLeasesResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListResultLeasesResponse) GetLeases() []*ResultLease {
+	if x != nil {
+		return x.Leases
+	}
+	return nil
+}
+
+type RenewResultLeaseRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	ID    string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// TTL is the new duration of the lease from now in nanoseconds
+	TTL           int64 `protobuf:"varint,2,opt,name=TTL,proto3" json:"TTL,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewResultLeaseRequest) Reset() {
+	*x = RenewResultLeaseRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewResultLeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewResultLeaseRequest) ProtoMessage() {}
+
+func (x *RenewResultLeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewResultLeaseRequest.ProtoReflect.Descriptor instead.
+func (*RenewResultLeaseRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *RenewResultLeaseRequest) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *RenewResultLeaseRequest) GetTTL() int64 {
+	if x != nil {
+		return x.TTL
+	}
+	return 0
+}
+
+type RenewResultLeaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lease         *ResultLease           `protobuf:"bytes,1,opt,name=lease,proto3" json:"lease,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewResultLeaseResponse) Reset() {
+	*x = RenewResultLeaseResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewResultLeaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewResultLeaseResponse) ProtoMessage() {}
+
+func (x *RenewResultLeaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewResultLeaseResponse.ProtoReflect.Descriptor instead.
+func (*RenewResultLeaseResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RenewResultLeaseResponse) GetLease() *ResultLease {
+	if x != nil {
+		return x.Lease
+	}
+	return nil
+}
+
+type ReleaseResultLeaseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ID            string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseResultLeaseRequest) Reset() {
+	*x = ReleaseResultLeaseRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseResultLeaseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseResultLeaseRequest) ProtoMessage() {}
+
+func (x *ReleaseResultLeaseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseResultLeaseRequest.ProtoReflect.Descriptor instead.
+func (*ReleaseResultLeaseRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *ReleaseResultLeaseRequest) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+type ReleaseResultLeaseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReleaseResultLeaseResponse) Reset() {
+	*x = ReleaseResultLeaseResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReleaseResultLeaseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReleaseResultLeaseResponse) ProtoMessage() {}
+
+func (x *ReleaseResultLeaseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReleaseResultLeaseResponse.ProtoReflect.Descriptor instead.
+func (*ReleaseResultLeaseResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{37}
+}
+
+type CancelPlatformsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Ref is the ref of the build
+	Ref string `protobuf:"bytes,1,opt,name=Ref,proto3" json:"Ref,omitempty"`
+	// Platforms are the platforms of the multi-platform result of the build
+	// to cancel, e.g. linux/arm64
+	Platforms     []string `protobuf:"bytes,2,rep,name=Platforms,proto3" json:"Platforms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelPlatformsRequest) Reset() {
+	*x = CancelPlatformsRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelPlatformsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelPlatformsRequest) ProtoMessage() {}
+
+func (x *CancelPlatformsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelPlatformsRequest.ProtoReflect.Descriptor instead.
+func (*CancelPlatformsRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *CancelPlatformsRequest) GetRef() string {
+	if x != nil {
+		return x.Ref
+	}
+	return ""
+}
+
+func (x *CancelPlatformsRequest) GetPlatforms() []string {
+	if x != nil {
+		return x.Platforms
+	}
+	return nil
+}
+
+type CancelPlatformsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelPlatformsResponse) Reset() {
+	*x = CancelPlatformsResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelPlatformsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelPlatformsResponse) ProtoMessage() {}
+
+func (x *CancelPlatformsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelPlatformsResponse.ProtoReflect.Descriptor instead.
+func (*CancelPlatformsResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{39}
+}
+
+type ReloadConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadConfigRequest) Reset() {
+	*x = ReloadConfigRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigRequest) ProtoMessage() {}
+
+func (x *ReloadConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigRequest.ProtoReflect.Descriptor instead.
+func (*ReloadConfigRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{40}
+}
+
+type ReloadConfigResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Applied are the changed settings applied to the running daemon
+	Applied []string `protobuf:"bytes,1,rep,name=Applied,proto3" json:"Applied,omitempty"`
+	// RequiresRestart are the changed settings that only apply after the
+	// daemon restarts
+	RequiresRestart []string `protobuf:"bytes,2,rep,name=RequiresRestart,proto3" json:"RequiresRestart,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ReloadConfigResponse) Reset() {
+	*x = ReloadConfigResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadConfigResponse) ProtoMessage() {}
+
+func (x *ReloadConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadConfigResponse.ProtoReflect.Descriptor instead.
+func (*ReloadConfigResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ReloadConfigResponse) GetApplied() []string {
+	if x != nil {
+		return x.Applied
+	}
+	return nil
+}
+
+func (x *ReloadConfigResponse) GetRequiresRestart() []string {
+	if x != nil {
+		return x.RequiresRestart
+	}
+	return nil
+}
+
+type DrainRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Timeout is the duration in nanoseconds the running solves are waited
+	// for before they are canceled. The drain timeout of the daemon is used
+	// if it is 0.
+	Timeout       int64 `protobuf:"varint,1,opt,name=Timeout,proto3" json:"Timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DrainRequest) Reset() {
+	*x = DrainRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DrainRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *VertexWarning) GetUrl() string {
+func (*DrainRequest) ProtoMessage() {}
+
+func (x *DrainRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[42]
 	if x != nil {
-		return x.Url
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *VertexWarning) GetInfo() *pb.SourceInfo {
-	if x != nil {
-		return x.Info
-	}
-	return nil
+// Deprecated: Use DrainRequest.ProtoReflect.Descriptor instead.
+func (*DrainRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{42}
 }
 
-func (x *VertexWarning) GetRanges() []*pb.Range {
+func (x *DrainRequest) GetTimeout() int64 {
 	if x != nil {
-		return x.Ranges
+		return x.Timeout
 	}
-	return nil
+	return 0
 }
 
-type BytesMessage struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+type DrainResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Completed is the number of running solves that finished during the
+	// drain
+	Completed int32 `protobuf:"varint,1,opt,name=Completed,proto3" json:"Completed,omitempty"`
+	// Canceled is the number of running solves canceled after the timeout
+	Canceled      int32 `protobuf:"varint,2,opt,name=Canceled,proto3" json:"Canceled,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *BytesMessage) Reset() {
-	*x = BytesMessage{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[14]
+func (x *DrainResponse) Reset() {
+	*x = DrainResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *BytesMessage) String() string {
+func (x *DrainResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*BytesMessage) ProtoMessage() {}
+func (*DrainResponse) ProtoMessage() {}
 
-func (x *BytesMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[14]
+func (x *DrainResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1231,40 +2910,46 @@ func (x *BytesMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use BytesMessage.ProtoReflect.Descriptor instead.
-func (*BytesMessage) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use DrainResponse.ProtoReflect.Descriptor instead.
+func (*DrainResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{43}
 }
 
-func (x *BytesMessage) GetData() []byte {
+func (x *DrainResponse) GetCompleted() int32 {
 	if x != nil {
-		return x.Data
+		return x.Completed
 	}
-	return nil
+	return 0
 }
 
-type ListWorkersRequest struct {
+func (x *DrainResponse) GetCanceled() int32 {
+	if x != nil {
+		return x.Canceled
+	}
+	return 0
+}
+
+type ListSessionsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Filter        []string               `protobuf:"bytes,1,rep,name=filter,proto3" json:"filter,omitempty"` // containerd style
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListWorkersRequest) Reset() {
-	*x = ListWorkersRequest{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[15]
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListWorkersRequest) String() string {
+func (x *ListSessionsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListWorkersRequest) ProtoMessage() {}
+func (*ListSessionsRequest) ProtoMessage() {}
 
-func (x *ListWorkersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[15]
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1275,40 +2960,33 @@ func (x *ListWorkersRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListWorkersRequest.ProtoReflect.Descriptor instead.
-func (*ListWorkersRequest) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{15}
-}
-
-func (x *ListWorkersRequest) GetFilter() []string {
-	if x != nil {
-		return x.Filter
-	}
-	return nil
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{44}
 }
 
-type ListWorkersResponse struct {
+type ListSessionsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Record        []*types.WorkerRecord  `protobuf:"bytes,1,rep,name=record,proto3" json:"record,omitempty"`
+	Record        []*SessionRecord       `protobuf:"bytes,1,rep,name=record,proto3" json:"record,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListWorkersResponse) Reset() {
-	*x = ListWorkersResponse{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[16]
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListWorkersResponse) String() string {
+func (x *ListSessionsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListWorkersResponse) ProtoMessage() {}
+func (*ListSessionsResponse) ProtoMessage() {}
 
-func (x *ListWorkersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[16]
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1319,39 +2997,53 @@ func (x *ListWorkersResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListWorkersResponse.ProtoReflect.Descriptor instead.
-func (*ListWorkersResponse) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{45}
 }
 
-func (x *ListWorkersResponse) GetRecord() []*types.WorkerRecord {
+func (x *ListSessionsResponse) GetRecord() []*SessionRecord {
 	if x != nil {
 		return x.Record
 	}
 	return nil
 }
 
-type InfoRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+type SessionRecord struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ID        string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	SharedKey string                 `protobuf:"bytes,2,opt,name=SharedKey,proto3" json:"SharedKey,omitempty"`
+	CreatedAt *timestamp.Timestamp   `protobuf:"bytes,3,opt,name=CreatedAt,proto3" json:"CreatedAt,omitempty"`
+	// Methods are the gRPC methods exposed by the attachables of the session
+	Methods []string `protobuf:"bytes,4,rep,name=Methods,proto3" json:"Methods,omitempty"`
+	// Resources are the named resources (synced dirs, secrets, ssh agents)
+	// announced by the attachables of the session
+	Resources []*SessionResource `protobuf:"bytes,5,rep,name=Resources,proto3" json:"Resources,omitempty"`
+	// BytesSent is the number of bytes sent from the daemon to the client
+	BytesSent int64 `protobuf:"varint,6,opt,name=BytesSent,proto3" json:"BytesSent,omitempty"`
+	// BytesReceived is the number of bytes received by the daemon from the client
+	BytesReceived int64 `protobuf:"varint,7,opt,name=BytesReceived,proto3" json:"BytesReceived,omitempty"`
+	// Builds are the refs of the active builds using the session
+	Builds        []string `protobuf:"bytes,8,rep,name=Builds,proto3" json:"Builds,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *InfoRequest) Reset() {
-	*x = InfoRequest{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[17]
+func (x *SessionRecord) Reset() {
+	*x = SessionRecord{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InfoRequest) String() string {
+func (x *SessionRecord) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InfoRequest) ProtoMessage() {}
+func (*SessionRecord) ProtoMessage() {}
 
-func (x *InfoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[17]
+func (x *SessionRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1362,33 +3054,91 @@ func (x *InfoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
-func (*InfoRequest) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use SessionRecord.ProtoReflect.Descriptor instead.
+func (*SessionRecord) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{46}
 }
 
-type InfoResponse struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	BuildkitVersion *types.BuildkitVersion `protobuf:"bytes,1,opt,name=buildkitVersion,proto3" json:"buildkitVersion,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+func (x *SessionRecord) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
 }
 
-func (x *InfoResponse) Reset() {
-	*x = InfoResponse{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[18]
+func (x *SessionRecord) GetSharedKey() string {
+	if x != nil {
+		return x.SharedKey
+	}
+	return ""
+}
+
+func (x *SessionRecord) GetCreatedAt() *timestamp.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *SessionRecord) GetMethods() []string {
+	if x != nil {
+		return x.Methods
+	}
+	return nil
+}
+
+func (x *SessionRecord) GetResources() []*SessionResource {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *SessionRecord) GetBytesSent() int64 {
+	if x != nil {
+		return x.BytesSent
+	}
+	return 0
+}
+
+func (x *SessionRecord) GetBytesReceived() int64 {
+	if x != nil {
+		return x.BytesReceived
+	}
+	return 0
+}
+
+func (x *SessionRecord) GetBuilds() []string {
+	if x != nil {
+		return x.Builds
+	}
+	return nil
+}
+
+type SessionResource struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Type is like "filesync", "secrets" or "ssh"
+	Type          string `protobuf:"bytes,1,opt,name=Type,proto3" json:"Type,omitempty"`
+	ID            string `protobuf:"bytes,2,opt,name=ID,proto3" json:"ID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionResource) Reset() {
+	*x = SessionResource{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *InfoResponse) String() string {
+func (x *SessionResource) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*InfoResponse) ProtoMessage() {}
+func (*SessionResource) ProtoMessage() {}
 
-func (x *InfoResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[18]
+func (x *SessionResource) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1399,16 +3149,23 @@ func (x *InfoResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
-func (*InfoResponse) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use SessionResource.ProtoReflect.Descriptor instead.
+func (*SessionResource) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{47}
 }
 
-func (x *InfoResponse) GetBuildkitVersion() *types.BuildkitVersion {
+func (x *SessionResource) GetType() string {
 	if x != nil {
-		return x.BuildkitVersion
+		return x.Type
 	}
-	return nil
+	return ""
+}
+
+func (x *SessionResource) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
 }
 
 type BuildHistoryRequest struct {
@@ -1424,7 +3181,7 @@ type BuildHistoryRequest struct {
 
 func (x *BuildHistoryRequest) Reset() {
 	*x = BuildHistoryRequest{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[19]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1436,7 +3193,7 @@ func (x *BuildHistoryRequest) String() string {
 func (*BuildHistoryRequest) ProtoMessage() {}
 
 func (x *BuildHistoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[19]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1449,7 +3206,7 @@ func (x *BuildHistoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildHistoryRequest.ProtoReflect.Descriptor instead.
 func (*BuildHistoryRequest) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{19}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *BuildHistoryRequest) GetActiveOnly() bool {
@@ -1497,7 +3254,7 @@ type BuildHistoryEvent struct {
 
 func (x *BuildHistoryEvent) Reset() {
 	*x = BuildHistoryEvent{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[20]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1509,7 +3266,7 @@ func (x *BuildHistoryEvent) String() string {
 func (*BuildHistoryEvent) ProtoMessage() {}
 
 func (x *BuildHistoryEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[20]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1522,7 +3279,7 @@ func (x *BuildHistoryEvent) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildHistoryEvent.ProtoReflect.Descriptor instead.
 func (*BuildHistoryEvent) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{20}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *BuildHistoryEvent) GetType() BuildHistoryEventType {
@@ -1560,13 +3317,22 @@ type BuildHistoryRecord struct {
 	NumCompletedSteps int32                       `protobuf:"varint,17,opt,name=numCompletedSteps,proto3" json:"numCompletedSteps,omitempty"`
 	ExternalError     *Descriptor                 `protobuf:"bytes,18,opt,name=externalError,proto3" json:"externalError,omitempty"`
 	NumWarnings       int32                       `protobuf:"varint,19,opt,name=numWarnings,proto3" json:"numWarnings,omitempty"`
-	unknownFields     protoimpl.UnknownFields
-	sizeCache         protoimpl.SizeCache
+	ClientIdentity    *ClientIdentity             `protobuf:"bytes,20,opt,name=clientIdentity,proto3" json:"clientIdentity,omitempty"`
+	// stepLogs is a tar archive with the gzip compressed logs of every step
+	StepLogs *Descriptor `protobuf:"bytes,21,opt,name=stepLogs,proto3" json:"stepLogs,omitempty"`
+	// resourceUsage is the total of the resources used by the steps of the
+	// build. The memory peak is the largest peak of a single step.
+	ResourceUsage *ResourceUsage `protobuf:"bytes,22,opt,name=resourceUsage,proto3" json:"resourceUsage,omitempty"`
+	// cacheSources is the number of cached steps loaded from every cache, see
+	// Vertex.cacheSource
+	CacheSources  map[string]int32 `protobuf:"bytes,23,rep,name=cacheSources,proto3" json:"cacheSources,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *BuildHistoryRecord) Reset() {
 	*x = BuildHistoryRecord{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[21]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1578,7 +3344,7 @@ func (x *BuildHistoryRecord) String() string {
 func (*BuildHistoryRecord) ProtoMessage() {}
 
 func (x *BuildHistoryRecord) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[21]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1591,7 +3357,7 @@ func (x *BuildHistoryRecord) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildHistoryRecord.ProtoReflect.Descriptor instead.
 func (*BuildHistoryRecord) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{21}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *BuildHistoryRecord) GetRef() string {
@@ -1727,6 +3493,88 @@ func (x *BuildHistoryRecord) GetNumWarnings() int32 {
 	return 0
 }
 
+func (x *BuildHistoryRecord) GetClientIdentity() *ClientIdentity {
+	if x != nil {
+		return x.ClientIdentity
+	}
+	return nil
+}
+
+func (x *BuildHistoryRecord) GetStepLogs() *Descriptor {
+	if x != nil {
+		return x.StepLogs
+	}
+	return nil
+}
+
+func (x *BuildHistoryRecord) GetResourceUsage() *ResourceUsage {
+	if x != nil {
+		return x.ResourceUsage
+	}
+	return nil
+}
+
+func (x *BuildHistoryRecord) GetCacheSources() map[string]int32 {
+	if x != nil {
+		return x.CacheSources
+	}
+	return nil
+}
+
+type ClientIdentity struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name is the principal name of the client
+	Name string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	// Method is the authentication method, e.g. "tls"
+	Method        string `protobuf:"bytes,2,opt,name=Method,proto3" json:"Method,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClientIdentity) Reset() {
+	*x = ClientIdentity{}
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClientIdentity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientIdentity) ProtoMessage() {}
+
+func (x *ClientIdentity) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientIdentity.ProtoReflect.Descriptor instead.
+func (*ClientIdentity) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *ClientIdentity) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ClientIdentity) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
 type UpdateBuildHistoryRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ref           string                 `protobuf:"bytes,1,opt,name=Ref,proto3" json:"Ref,omitempty"`
@@ -1739,7 +3587,7 @@ type UpdateBuildHistoryRequest struct {
 
 func (x *UpdateBuildHistoryRequest) Reset() {
 	*x = UpdateBuildHistoryRequest{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[22]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1751,7 +3599,7 @@ func (x *UpdateBuildHistoryRequest) String() string {
 func (*UpdateBuildHistoryRequest) ProtoMessage() {}
 
 func (x *UpdateBuildHistoryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[22]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1764,7 +3612,7 @@ func (x *UpdateBuildHistoryRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateBuildHistoryRequest.ProtoReflect.Descriptor instead.
 func (*UpdateBuildHistoryRequest) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{22}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{52}
 }
 
 func (x *UpdateBuildHistoryRequest) GetRef() string {
@@ -1803,7 +3651,7 @@ type UpdateBuildHistoryResponse struct {
 
 func (x *UpdateBuildHistoryResponse) Reset() {
 	*x = UpdateBuildHistoryResponse{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[23]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1815,7 +3663,7 @@ func (x *UpdateBuildHistoryResponse) String() string {
 func (*UpdateBuildHistoryResponse) ProtoMessage() {}
 
 func (x *UpdateBuildHistoryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[23]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1828,7 +3676,7 @@ func (x *UpdateBuildHistoryResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateBuildHistoryResponse.ProtoReflect.Descriptor instead.
 func (*UpdateBuildHistoryResponse) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{23}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{53}
 }
 
 type Descriptor struct {
@@ -1843,7 +3691,7 @@ type Descriptor struct {
 
 func (x *Descriptor) Reset() {
 	*x = Descriptor{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[24]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1855,7 +3703,7 @@ func (x *Descriptor) String() string {
 func (*Descriptor) ProtoMessage() {}
 
 func (x *Descriptor) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[24]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1868,7 +3716,7 @@ func (x *Descriptor) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Descriptor.ProtoReflect.Descriptor instead.
 func (*Descriptor) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{24}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{54}
 }
 
 func (x *Descriptor) GetMediaType() string {
@@ -1910,7 +3758,7 @@ type BuildResultInfo struct {
 
 func (x *BuildResultInfo) Reset() {
 	*x = BuildResultInfo{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[25]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1922,7 +3770,7 @@ func (x *BuildResultInfo) String() string {
 func (*BuildResultInfo) ProtoMessage() {}
 
 func (x *BuildResultInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[25]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1935,7 +3783,7 @@ func (x *BuildResultInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildResultInfo.ProtoReflect.Descriptor instead.
 func (*BuildResultInfo) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{25}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{55}
 }
 
 func (x *BuildResultInfo) GetResultDeprecated() *Descriptor {
@@ -1972,7 +3820,7 @@ type Exporter struct {
 
 func (x *Exporter) Reset() {
 	*x = Exporter{}
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[26]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1984,7 +3832,7 @@ func (x *Exporter) String() string {
 func (*Exporter) ProtoMessage() {}
 
 func (x *Exporter) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[26]
+	mi := &file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1997,7 +3845,7 @@ func (x *Exporter) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Exporter.ProtoReflect.Descriptor instead.
 func (*Exporter) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{26}
+	return file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *Exporter) GetType() string {
@@ -2025,12 +3873,25 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\fkeepDuration\x18\x03 \x01(\x03R\fkeepDuration\x12$\n" +
 	"\rreservedSpace\x18\x04 \x01(\x03R\rreservedSpace\x12\"\n" +
 	"\fmaxUsedSpace\x18\x05 \x01(\x03R\fmaxUsedSpace\x12\"\n" +
-	"\fminFreeSpace\x18\x06 \x01(\x03R\fminFreeSpace\"F\n" +
+	"\fminFreeSpace\x18\x06 \x01(\x03R\fminFreeSpace\"\x9e\x01\n" +
 	"\x10DiskUsageRequest\x12\x16\n" +
 	"\x06filter\x18\x01 \x03(\tR\x06filter\x12\x1a\n" +
-	"\bageLimit\x18\x02 \x01(\x03R\bageLimit\"J\n" +
+	"\bageLimit\x18\x02 \x01(\x03R\bageLimit\x12\x1a\n" +
+	"\bpageSize\x18\x03 \x01(\x05R\bpageSize\x12\x1c\n" +
+	"\tpageToken\x18\x04 \x01(\tR\tpageToken\x12\x1c\n" +
+	"\tbreakdown\x18\x05 \x01(\bR\tbreakdown\"\xb0\x01\n" +
 	"\x11DiskUsageResponse\x125\n" +
-	"\x06record\x18\x01 \x03(\v2\x1d.moby.buildkit.v1.UsageRecordR\x06record\"\x87\x03\n" +
+	"\x06record\x18\x01 \x03(\v2\x1d.moby.buildkit.v1.UsageRecordR\x06record\x12$\n" +
+	"\rnextPageToken\x18\x02 \x01(\tR\rnextPageToken\x12>\n" +
+	"\tbreakdown\x18\x03 \x03(\v2 .moby.buildkit.v1.UsageBreakdownR\tbreakdown\"\x8e\x01\n" +
+	"\x0eUsageBreakdown\x12\x1e\n" +
+	"\n" +
+	"recordType\x18\x01 \x01(\tR\n" +
+	"recordType\x12\x10\n" +
+	"\x03age\x18\x02 \x01(\x03R\x03age\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x03R\x05count\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12 \n" +
+	"\vreclaimable\x18\x05 \x01(\x03R\vreclaimable\"\x9f\x03\n" +
 	"\vUsageRecord\x12\x0e\n" +
 	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x18\n" +
 	"\aMutable\x18\x02 \x01(\bR\aMutable\x12\x14\n" +
@@ -2050,7 +3911,9 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	" \x01(\tR\n" +
 	"RecordType\x12\x16\n" +
 	"\x06Shared\x18\v \x01(\bR\x06Shared\x12\x18\n" +
-	"\aParents\x18\f \x03(\tR\aParents\"\xf4\a\n" +
+	"\aParents\x18\f \x03(\tR\aParents\x12\x16\n" +
+	"\x06Client\x18\r \x01(\tR\x06Client\"\x8e\n" +
+	"\n" +
 	"\fSolveRequest\x12\x10\n" +
 	"\x03Ref\x18\x01 \x01(\tR\x03Ref\x12.\n" +
 	"\n" +
@@ -2068,7 +3931,15 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\bInternal\x18\v \x01(\bR\bInternal\x12I\n" +
 	"\fSourcePolicy\x18\f \x01(\v2%.moby.buildkit.v1.sourcepolicy.PolicyR\fSourcePolicy\x128\n" +
 	"\tExporters\x18\r \x03(\v2\x1a.moby.buildkit.v1.ExporterR\tExporters\x124\n" +
-	"\x15EnableSessionExporter\x18\x0e \x01(\bR\x15EnableSessionExporter\x1aJ\n" +
+	"\x15EnableSessionExporter\x18\x0e \x01(\bR\x15EnableSessionExporter\x12Q\n" +
+	"\x11ConcurrencyLimits\x18\x0f \x01(\v2#.moby.buildkit.v1.ConcurrencyLimitsR\x11ConcurrencyLimits\x12,\n" +
+	"\x11WorkerConstraints\x18\x10 \x03(\tR\x11WorkerConstraints\x12\x1a\n" +
+	"\bLockfile\x18\x11 \x01(\bR\bLockfile\x12\x1e\n" +
+	"\n" +
+	"SessionRef\x18\x12 \x01(\tR\n" +
+	"SessionRef\x12&\n" +
+	"\x0eResultLeaseTTL\x18\x13 \x01(\x03R\x0eResultLeaseTTL\x123\n" +
+	"\x05Proxy\x18\x14 \x01(\v2\x1d.moby.buildkit.v1.ProxyConfigR\x05Proxy\x1aJ\n" +
 	"\x1cExporterAttrsDeprecatedEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a@\n" +
@@ -2077,7 +3948,19 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aQ\n" +
 	"\x13FrontendInputsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12$\n" +
-	"\x05value\x18\x02 \x01(\v2\x0e.pb.DefinitionR\x05value:\x028\x01\"\xad\x03\n" +
+	"\x05value\x18\x02 \x01(\v2\x0e.pb.DefinitionR\x05value:\x028\x01\"\x95\x01\n" +
+	"\vProxyConfig\x12\x1c\n" +
+	"\tHTTPProxy\x18\x01 \x01(\tR\tHTTPProxy\x12\x1e\n" +
+	"\n" +
+	"HTTPSProxy\x18\x02 \x01(\tR\n" +
+	"HTTPSProxy\x12\x18\n" +
+	"\aNoProxy\x18\x03 \x01(\tR\aNoProxy\x12\x1a\n" +
+	"\bAllProxy\x18\x04 \x01(\tR\bAllProxy\x12\x12\n" +
+	"\x04Exec\x18\x05 \x01(\bR\x04Exec\"c\n" +
+	"\x11ConcurrencyLimits\x12\x12\n" +
+	"\x04Exec\x18\x01 \x01(\x05R\x04Exec\x12\x1c\n" +
+	"\tImagePull\x18\x02 \x01(\x05R\tImagePull\x12\x1c\n" +
+	"\tLocalSync\x18\x03 \x01(\x05R\tLocalSync\"\xad\x03\n" +
 	"\fCacheOptions\x120\n" +
 	"\x13ExportRefDeprecated\x18\x01 \x01(\tR\x13ExportRefDeprecated\x122\n" +
 	"\x14ImportRefsDeprecated\x18\x02 \x03(\tR\x14ImportRefsDeprecated\x12o\n" +
@@ -2093,19 +3976,36 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\n" +
 	"AttrsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb7\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x89\x02\n" +
 	"\rSolveResponse\x12a\n" +
-	"\x10ExporterResponse\x18\x01 \x03(\v25.moby.buildkit.v1.SolveResponse.ExporterResponseEntryR\x10ExporterResponse\x1aC\n" +
+	"\x10ExporterResponse\x18\x01 \x03(\v25.moby.buildkit.v1.SolveResponse.ExporterResponseEntryR\x10ExporterResponse\x12P\n" +
+	"\x11ExporterResponses\x18\x02 \x03(\v2\".moby.buildkit.v1.ExporterResponseR\x11ExporterResponses\x1aC\n" +
 	"\x15ExporterResponseEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"!\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\\\n" +
+	"\x15ValidateSolveResponse\x12C\n" +
+	"\vDiagnostics\x18\x01 \x03(\v2!.moby.buildkit.v1.SolveDiagnosticR\vDiagnostics\"s\n" +
+	"\x0fSolveDiagnostic\x12\x14\n" +
+	"\x05Check\x18\x01 \x01(\tR\x05Check\x12\x18\n" +
+	"\aMessage\x18\x02 \x01(\tR\aMessage\x12\x18\n" +
+	"\aWarning\x18\x03 \x01(\bR\aWarning\x12\x16\n" +
+	"\x06Vertex\x18\x04 \x01(\tR\x06Vertex\"\xcd\x01\n" +
+	"\x10ExporterResponse\x12>\n" +
+	"\bMetadata\x18\x01 \x01(\v2\".moby.buildkit.v1.ExporterMetadataR\bMetadata\x12@\n" +
+	"\x04Data\x18\x02 \x03(\v2,.moby.buildkit.v1.ExporterResponse.DataEntryR\x04Data\x1a7\n" +
+	"\tDataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"6\n" +
+	"\x10ExporterMetadata\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x12\n" +
+	"\x04Type\x18\x02 \x01(\tR\x04Type\"!\n" +
 	"\rStatusRequest\x12\x10\n" +
 	"\x03Ref\x18\x01 \x01(\tR\x03Ref\"\xf0\x01\n" +
 	"\x0eStatusResponse\x124\n" +
 	"\bvertexes\x18\x01 \x03(\v2\x18.moby.buildkit.v1.VertexR\bvertexes\x12:\n" +
 	"\bstatuses\x18\x02 \x03(\v2\x1e.moby.buildkit.v1.VertexStatusR\bstatuses\x12/\n" +
 	"\x04logs\x18\x03 \x03(\v2\x1b.moby.buildkit.v1.VertexLogR\x04logs\x12;\n" +
-	"\bwarnings\x18\x04 \x03(\v2\x1f.moby.buildkit.v1.VertexWarningR\bwarnings\"\xa3\x02\n" +
+	"\bwarnings\x18\x04 \x03(\v2\x1f.moby.buildkit.v1.VertexWarningR\bwarnings\"\x8c\x03\n" +
 	"\x06Vertex\x12\x16\n" +
 	"\x06digest\x18\x01 \x01(\tR\x06digest\x12\x16\n" +
 	"\x06inputs\x18\x02 \x03(\tR\x06inputs\x12\x12\n" +
@@ -2114,7 +4014,15 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\astarted\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\astarted\x128\n" +
 	"\tcompleted\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcompleted\x12\x14\n" +
 	"\x05error\x18\a \x01(\tR\x05error\x127\n" +
-	"\rprogressGroup\x18\b \x01(\v2\x11.pb.ProgressGroupR\rprogressGroup\"\xa4\x02\n" +
+	"\rprogressGroup\x18\b \x01(\v2\x11.pb.ProgressGroupR\rprogressGroup\x12E\n" +
+	"\rresourceUsage\x18\t \x01(\v2\x1f.moby.buildkit.v1.ResourceUsageR\rresourceUsage\x12 \n" +
+	"\vcacheSource\x18\n" +
+	" \x01(\tR\vcacheSource\"\x9b\x01\n" +
+	"\rResourceUsage\x12\x1a\n" +
+	"\bcpuNanos\x18\x01 \x01(\x04R\bcpuNanos\x12(\n" +
+	"\x0fmemoryPeakBytes\x18\x02 \x01(\x04R\x0fmemoryPeakBytes\x12 \n" +
+	"\vioReadBytes\x18\x03 \x01(\x04R\vioReadBytes\x12\"\n" +
+	"\fioWriteBytes\x18\x04 \x01(\x04R\fioWriteBytes\"\xa4\x02\n" +
 	"\fVertexStatus\x12\x0e\n" +
 	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x16\n" +
 	"\x06vertex\x18\x02 \x01(\tR\x06vertex\x12\x12\n" +
@@ -2123,12 +4031,13 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\x05total\x18\x05 \x01(\x03R\x05total\x128\n" +
 	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x124\n" +
 	"\astarted\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\astarted\x128\n" +
-	"\tcompleted\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcompleted\"\x87\x01\n" +
+	"\tcompleted\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcompleted\"\xa5\x01\n" +
 	"\tVertexLog\x12\x16\n" +
 	"\x06vertex\x18\x01 \x01(\tR\x06vertex\x128\n" +
 	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x16\n" +
 	"\x06stream\x18\x03 \x01(\x03R\x06stream\x12\x10\n" +
-	"\x03msg\x18\x04 \x01(\fR\x03msg\"\xc4\x01\n" +
+	"\x03msg\x18\x04 \x01(\fR\x03msg\x12\x1c\n" +
+	"\ttruncated\x18\x05 \x01(\bR\ttruncated\"\xc4\x01\n" +
 	"\rVertexWarning\x12\x16\n" +
 	"\x06vertex\x18\x01 \x01(\tR\x06vertex\x12\x14\n" +
 	"\x05level\x18\x02 \x01(\x03R\x05level\x12\x14\n" +
@@ -2145,7 +4054,69 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\x06record\x18\x01 \x03(\v2$.moby.buildkit.v1.types.WorkerRecordR\x06record\"\r\n" +
 	"\vInfoRequest\"a\n" +
 	"\fInfoResponse\x12Q\n" +
-	"\x0fbuildkitVersion\x18\x01 \x01(\v2'.moby.buildkit.v1.types.BuildkitVersionR\x0fbuildkitVersion\"\x93\x01\n" +
+	"\x0fbuildkitVersion\x18\x01 \x01(\v2'.moby.buildkit.v1.types.BuildkitVersionR\x0fbuildkitVersion\",\n" +
+	"\x12VerifyCacheRequest\x12\x16\n" +
+	"\x06repair\x18\x01 \x01(\bR\x06repair\"K\n" +
+	"\x13VerifyCacheResponse\x124\n" +
+	"\x06issues\x18\x01 \x03(\v2\x1c.moby.buildkit.v1.CacheIssueR\x06issues\"n\n" +
+	"\n" +
+	"CacheIssue\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x12\n" +
+	"\x04Type\x18\x02 \x01(\tR\x04Type\x12 \n" +
+	"\vDescription\x18\x03 \x01(\tR\vDescription\x12\x1a\n" +
+	"\bRepaired\x18\x04 \x01(\bR\bRepaired\"\xe2\x01\n" +
+	"\vResultLease\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x10\n" +
+	"\x03Ref\x18\x02 \x01(\tR\x03Ref\x128\n" +
+	"\tCreatedAt\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tCreatedAt\x128\n" +
+	"\tExpiresAt\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tExpiresAt\x12=\n" +
+	"\aRecords\x18\x05 \x03(\v2#.moby.buildkit.v1.ResultLeaseRecordR\aRecords\"q\n" +
+	"\x11ResultLeaseRecord\x12\x10\n" +
+	"\x03Key\x18\x01 \x01(\tR\x03Key\x12\x0e\n" +
+	"\x02ID\x18\x02 \x01(\tR\x02ID\x12\x1a\n" +
+	"\bWorkerID\x18\x03 \x01(\tR\bWorkerID\x12\x1e\n" +
+	"\n" +
+	"SnapshotID\x18\x04 \x01(\tR\n" +
+	"SnapshotID\"\x19\n" +
+	"\x17ListResultLeasesRequest\"Q\n" +
+	"\x18ListResultLeasesResponse\x125\n" +
+	"\x06leases\x18\x01 \x03(\v2\x1d.moby.buildkit.v1.ResultLeaseR\x06leases\";\n" +
+	"\x17RenewResultLeaseRequest\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x10\n" +
+	"\x03TTL\x18\x02 \x01(\x03R\x03TTL\"O\n" +
+	"\x18RenewResultLeaseResponse\x123\n" +
+	"\x05lease\x18\x01 \x01(\v2\x1d.moby.buildkit.v1.ResultLeaseR\x05lease\"+\n" +
+	"\x19ReleaseResultLeaseRequest\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\"\x1c\n" +
+	"\x1aReleaseResultLeaseResponse\"H\n" +
+	"\x16CancelPlatformsRequest\x12\x10\n" +
+	"\x03Ref\x18\x01 \x01(\tR\x03Ref\x12\x1c\n" +
+	"\tPlatforms\x18\x02 \x03(\tR\tPlatforms\"\x19\n" +
+	"\x17CancelPlatformsResponse\"\x15\n" +
+	"\x13ReloadConfigRequest\"Z\n" +
+	"\x14ReloadConfigResponse\x12\x18\n" +
+	"\aApplied\x18\x01 \x03(\tR\aApplied\x12(\n" +
+	"\x0fRequiresRestart\x18\x02 \x03(\tR\x0fRequiresRestart\"(\n" +
+	"\fDrainRequest\x12\x18\n" +
+	"\aTimeout\x18\x01 \x01(\x03R\aTimeout\"I\n" +
+	"\rDrainResponse\x12\x1c\n" +
+	"\tCompleted\x18\x01 \x01(\x05R\tCompleted\x12\x1a\n" +
+	"\bCanceled\x18\x02 \x01(\x05R\bCanceled\"\x15\n" +
+	"\x13ListSessionsRequest\"O\n" +
+	"\x14ListSessionsResponse\x127\n" +
+	"\x06record\x18\x01 \x03(\v2\x1f.moby.buildkit.v1.SessionRecordR\x06record\"\xae\x02\n" +
+	"\rSessionRecord\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x1c\n" +
+	"\tSharedKey\x18\x02 \x01(\tR\tSharedKey\x128\n" +
+	"\tCreatedAt\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tCreatedAt\x12\x18\n" +
+	"\aMethods\x18\x04 \x03(\tR\aMethods\x12?\n" +
+	"\tResources\x18\x05 \x03(\v2!.moby.buildkit.v1.SessionResourceR\tResources\x12\x1c\n" +
+	"\tBytesSent\x18\x06 \x01(\x03R\tBytesSent\x12$\n" +
+	"\rBytesReceived\x18\a \x01(\x03R\rBytesReceived\x12\x16\n" +
+	"\x06Builds\x18\b \x03(\tR\x06Builds\"5\n" +
+	"\x0fSessionResource\x12\x12\n" +
+	"\x04Type\x18\x01 \x01(\tR\x04Type\x12\x0e\n" +
+	"\x02ID\x18\x02 \x01(\tR\x02ID\"\x93\x01\n" +
 	"\x13BuildHistoryRequest\x12\x1e\n" +
 	"\n" +
 	"ActiveOnly\x18\x01 \x01(\bR\n" +
@@ -2156,7 +4127,7 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\x05Limit\x18\x05 \x01(\x05R\x05Limit\"\x8e\x01\n" +
 	"\x11BuildHistoryEvent\x12;\n" +
 	"\x04type\x18\x01 \x01(\x0e2'.moby.buildkit.v1.BuildHistoryEventTypeR\x04type\x12<\n" +
-	"\x06record\x18\x02 \x01(\v2$.moby.buildkit.v1.BuildHistoryRecordR\x06record\"\xd3\t\n" +
+	"\x06record\x18\x02 \x01(\v2$.moby.buildkit.v1.BuildHistoryRecordR\x06record\"\xbb\f\n" +
 	"\x12BuildHistoryRecord\x12\x10\n" +
 	"\x03Ref\x18\x01 \x01(\tR\x03Ref\x12\x1a\n" +
 	"\bFrontend\x18\x02 \x01(\tR\bFrontend\x12]\n" +
@@ -2179,7 +4150,11 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\rnumTotalSteps\x18\x10 \x01(\x05R\rnumTotalSteps\x12,\n" +
 	"\x11numCompletedSteps\x18\x11 \x01(\x05R\x11numCompletedSteps\x12B\n" +
 	"\rexternalError\x18\x12 \x01(\v2\x1c.moby.buildkit.v1.DescriptorR\rexternalError\x12 \n" +
-	"\vnumWarnings\x18\x13 \x01(\x05R\vnumWarnings\x1a@\n" +
+	"\vnumWarnings\x18\x13 \x01(\x05R\vnumWarnings\x12H\n" +
+	"\x0eclientIdentity\x18\x14 \x01(\v2 .moby.buildkit.v1.ClientIdentityR\x0eclientIdentity\x128\n" +
+	"\bstepLogs\x18\x15 \x01(\v2\x1c.moby.buildkit.v1.DescriptorR\bstepLogs\x12E\n" +
+	"\rresourceUsage\x18\x16 \x01(\v2\x1f.moby.buildkit.v1.ResourceUsageR\rresourceUsage\x12Z\n" +
+	"\fcacheSources\x18\x17 \x03(\v26.moby.buildkit.v1.BuildHistoryRecord.CacheSourcesEntryR\fcacheSources\x1a@\n" +
 	"\x12FrontendAttrsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aC\n" +
@@ -2188,7 +4163,13 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a]\n" +
 	"\fResultsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x127\n" +
-	"\x05value\x18\x02 \x01(\v2!.moby.buildkit.v1.BuildResultInfoR\x05value:\x028\x01\"y\n" +
+	"\x05value\x18\x02 \x01(\v2!.moby.buildkit.v1.BuildResultInfoR\x05value:\x028\x01\x1a?\n" +
+	"\x11CacheSourcesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"<\n" +
+	"\x0eClientIdentity\x12\x12\n" +
+	"\x04Name\x18\x01 \x01(\tR\x04Name\x12\x16\n" +
+	"\x06Method\x18\x02 \x01(\tR\x06Method\"y\n" +
 	"\x19UpdateBuildHistoryRequest\x12\x10\n" +
 	"\x03Ref\x18\x01 \x01(\tR\x03Ref\x12\x16\n" +
 	"\x06Pinned\x18\x02 \x01(\bR\x06Pinned\x12\x16\n" +
@@ -2222,15 +4203,24 @@ const file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc =
 	"\x15BuildHistoryEventType\x12\v\n" +
 	"\aSTARTED\x10\x00\x12\f\n" +
 	"\bCOMPLETE\x10\x01\x12\v\n" +
-	"\aDELETED\x10\x022\x89\x06\n" +
+	"\aDELETED\x10\x022\xf6\f\n" +
 	"\aControl\x12T\n" +
 	"\tDiskUsage\x12\".moby.buildkit.v1.DiskUsageRequest\x1a#.moby.buildkit.v1.DiskUsageResponse\x12H\n" +
 	"\x05Prune\x12\x1e.moby.buildkit.v1.PruneRequest\x1a\x1d.moby.buildkit.v1.UsageRecord0\x01\x12H\n" +
-	"\x05Solve\x12\x1e.moby.buildkit.v1.SolveRequest\x1a\x1f.moby.buildkit.v1.SolveResponse\x12M\n" +
+	"\x05Solve\x12\x1e.moby.buildkit.v1.SolveRequest\x1a\x1f.moby.buildkit.v1.SolveResponse\x12X\n" +
+	"\rValidateSolve\x12\x1e.moby.buildkit.v1.SolveRequest\x1a'.moby.buildkit.v1.ValidateSolveResponse\x12M\n" +
 	"\x06Status\x12\x1f.moby.buildkit.v1.StatusRequest\x1a .moby.buildkit.v1.StatusResponse0\x01\x12M\n" +
 	"\aSession\x12\x1e.moby.buildkit.v1.BytesMessage\x1a\x1e.moby.buildkit.v1.BytesMessage(\x010\x01\x12Z\n" +
 	"\vListWorkers\x12$.moby.buildkit.v1.ListWorkersRequest\x1a%.moby.buildkit.v1.ListWorkersResponse\x12E\n" +
-	"\x04Info\x12\x1d.moby.buildkit.v1.InfoRequest\x1a\x1e.moby.buildkit.v1.InfoResponse\x12b\n" +
+	"\x04Info\x12\x1d.moby.buildkit.v1.InfoRequest\x1a\x1e.moby.buildkit.v1.InfoResponse\x12]\n" +
+	"\fListSessions\x12%.moby.buildkit.v1.ListSessionsRequest\x1a&.moby.buildkit.v1.ListSessionsResponse\x12Z\n" +
+	"\vVerifyCache\x12$.moby.buildkit.v1.VerifyCacheRequest\x1a%.moby.buildkit.v1.VerifyCacheResponse\x12i\n" +
+	"\x10ListResultLeases\x12).moby.buildkit.v1.ListResultLeasesRequest\x1a*.moby.buildkit.v1.ListResultLeasesResponse\x12i\n" +
+	"\x10RenewResultLease\x12).moby.buildkit.v1.RenewResultLeaseRequest\x1a*.moby.buildkit.v1.RenewResultLeaseResponse\x12o\n" +
+	"\x12ReleaseResultLease\x12+.moby.buildkit.v1.ReleaseResultLeaseRequest\x1a,.moby.buildkit.v1.ReleaseResultLeaseResponse\x12f\n" +
+	"\x0fCancelPlatforms\x12(.moby.buildkit.v1.CancelPlatformsRequest\x1a).moby.buildkit.v1.CancelPlatformsResponse\x12]\n" +
+	"\fReloadConfig\x12%.moby.buildkit.v1.ReloadConfigRequest\x1a&.moby.buildkit.v1.ReloadConfigResponse\x12H\n" +
+	"\x05Drain\x12\x1e.moby.buildkit.v1.DrainRequest\x1a\x1f.moby.buildkit.v1.DrainResponse\x12b\n" +
 	"\x12ListenBuildHistory\x12%.moby.buildkit.v1.BuildHistoryRequest\x1a#.moby.buildkit.v1.BuildHistoryEvent0\x01\x12o\n" +
 	"\x12UpdateBuildHistory\x12+.moby.buildkit.v1.UpdateBuildHistoryRequest\x1a,.moby.buildkit.v1.UpdateBuildHistoryResponseB@Z>github.com/moby/buildkit/api/services/control;moby_buildkit_v1b\x06proto3"
 
@@ -2247,133 +4237,204 @@ func file_github_com_moby_buildkit_api_services_control_control_proto_rawDescGZI
 }
 
 var file_github_com_moby_buildkit_api_services_control_control_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes = make([]protoimpl.MessageInfo, 39)
+var file_github_com_moby_buildkit_api_services_control_control_proto_msgTypes = make([]protoimpl.MessageInfo, 71)
 var file_github_com_moby_buildkit_api_services_control_control_proto_goTypes = []any{
 	(BuildHistoryEventType)(0),         // 0: moby.buildkit.v1.BuildHistoryEventType
 	(*PruneRequest)(nil),               // 1: moby.buildkit.v1.PruneRequest
 	(*DiskUsageRequest)(nil),           // 2: moby.buildkit.v1.DiskUsageRequest
 	(*DiskUsageResponse)(nil),          // 3: moby.buildkit.v1.DiskUsageResponse
-	(*UsageRecord)(nil),                // 4: moby.buildkit.v1.UsageRecord
-	(*SolveRequest)(nil),               // 5: moby.buildkit.v1.SolveRequest
-	(*CacheOptions)(nil),               // 6: moby.buildkit.v1.CacheOptions
-	(*CacheOptionsEntry)(nil),          // 7: moby.buildkit.v1.CacheOptionsEntry
-	(*SolveResponse)(nil),              // 8: moby.buildkit.v1.SolveResponse
-	(*StatusRequest)(nil),              // 9: moby.buildkit.v1.StatusRequest
-	(*StatusResponse)(nil),             // 10: moby.buildkit.v1.StatusResponse
-	(*Vertex)(nil),                     // 11: moby.buildkit.v1.Vertex
-	(*VertexStatus)(nil),               // 12: moby.buildkit.v1.VertexStatus
-	(*VertexLog)(nil),                  // 13: moby.buildkit.v1.VertexLog
-	(*VertexWarning)(nil),              // 14: moby.buildkit.v1.VertexWarning
-	(*BytesMessage)(nil),               // 15: moby.buildkit.v1.BytesMessage
-	(*ListWorkersRequest)(nil),         // 16: moby.buildkit.v1.ListWorkersRequest
-	(*ListWorkersResponse)(nil),        // 17: moby.buildkit.v1.ListWorkersResponse
-	(*InfoRequest)(nil),                // 18: moby.buildkit.v1.InfoRequest
-	(*InfoResponse)(nil),               // 19: moby.buildkit.v1.InfoResponse
-	(*BuildHistoryRequest)(nil),        // 20: moby.buildkit.v1.BuildHistoryRequest
-	(*BuildHistoryEvent)(nil),          // 21: moby.buildkit.v1.BuildHistoryEvent
-	(*BuildHistoryRecord)(nil),         // 22: moby.buildkit.v1.BuildHistoryRecord
-	(*UpdateBuildHistoryRequest)(nil),  // 23: moby.buildkit.v1.UpdateBuildHistoryRequest
-	(*UpdateBuildHistoryResponse)(nil), // 24: moby.buildkit.v1.UpdateBuildHistoryResponse
-	(*Descriptor)(nil),                 // 25: moby.buildkit.v1.Descriptor
-	(*BuildResultInfo)(nil),            // 26: moby.buildkit.v1.BuildResultInfo
-	(*Exporter)(nil),                   // 27: moby.buildkit.v1.Exporter
-	nil,                                // 28: moby.buildkit.v1.SolveRequest.ExporterAttrsDeprecatedEntry
-	nil,                                // 29: moby.buildkit.v1.SolveRequest.FrontendAttrsEntry
-	nil,                                // 30: moby.buildkit.v1.SolveRequest.FrontendInputsEntry
-	nil,                                // 31: moby.buildkit.v1.CacheOptions.ExportAttrsDeprecatedEntry
-	nil,                                // 32: moby.buildkit.v1.CacheOptionsEntry.AttrsEntry
-	nil,                                // 33: moby.buildkit.v1.SolveResponse.ExporterResponseEntry
-	nil,                                // 34: moby.buildkit.v1.BuildHistoryRecord.FrontendAttrsEntry
-	nil,                                // 35: moby.buildkit.v1.BuildHistoryRecord.ExporterResponseEntry
-	nil,                                // 36: moby.buildkit.v1.BuildHistoryRecord.ResultsEntry
-	nil,                                // 37: moby.buildkit.v1.Descriptor.AnnotationsEntry
-	nil,                                // 38: moby.buildkit.v1.BuildResultInfo.ResultsEntry
-	nil,                                // 39: moby.buildkit.v1.Exporter.AttrsEntry
-	(*timestamp.Timestamp)(nil),        // 40: google.protobuf.Timestamp
-	(*pb.Definition)(nil),              // 41: pb.Definition
-	(*pb1.Policy)(nil),                 // 42: moby.buildkit.v1.sourcepolicy.Policy
-	(*pb.ProgressGroup)(nil),           // 43: pb.ProgressGroup
-	(*pb.SourceInfo)(nil),              // 44: pb.SourceInfo
-	(*pb.Range)(nil),                   // 45: pb.Range
-	(*types.WorkerRecord)(nil),         // 46: moby.buildkit.v1.types.WorkerRecord
-	(*types.BuildkitVersion)(nil),      // 47: moby.buildkit.v1.types.BuildkitVersion
-	(*status.Status)(nil),              // 48: google.rpc.Status
+	(*UsageBreakdown)(nil),             // 4: moby.buildkit.v1.UsageBreakdown
+	(*UsageRecord)(nil),                // 5: moby.buildkit.v1.UsageRecord
+	(*SolveRequest)(nil),               // 6: moby.buildkit.v1.SolveRequest
+	(*ProxyConfig)(nil),                // 7: moby.buildkit.v1.ProxyConfig
+	(*ConcurrencyLimits)(nil),          // 8: moby.buildkit.v1.ConcurrencyLimits
+	(*CacheOptions)(nil),               // 9: moby.buildkit.v1.CacheOptions
+	(*CacheOptionsEntry)(nil),          // 10: moby.buildkit.v1.CacheOptionsEntry
+	(*SolveResponse)(nil),              // 11: moby.buildkit.v1.SolveResponse
+	(*ValidateSolveResponse)(nil),      // 12: moby.buildkit.v1.ValidateSolveResponse
+	(*SolveDiagnostic)(nil),            // 13: moby.buildkit.v1.SolveDiagnostic
+	(*ExporterResponse)(nil),           // 14: moby.buildkit.v1.ExporterResponse
+	(*ExporterMetadata)(nil),           // 15: moby.buildkit.v1.ExporterMetadata
+	(*StatusRequest)(nil),              // 16: moby.buildkit.v1.StatusRequest
+	(*StatusResponse)(nil),             // 17: moby.buildkit.v1.StatusResponse
+	(*Vertex)(nil),                     // 18: moby.buildkit.v1.Vertex
+	(*ResourceUsage)(nil),              // 19: moby.buildkit.v1.ResourceUsage
+	(*VertexStatus)(nil),               // 20: moby.buildkit.v1.VertexStatus
+	(*VertexLog)(nil),                  // 21: moby.buildkit.v1.VertexLog
+	(*VertexWarning)(nil),              // 22: moby.buildkit.v1.VertexWarning
+	(*BytesMessage)(nil),               // 23: moby.buildkit.v1.BytesMessage
+	(*ListWorkersRequest)(nil),         // 24: moby.buildkit.v1.ListWorkersRequest
+	(*ListWorkersResponse)(nil),        // 25: moby.buildkit.v1.ListWorkersResponse
+	(*InfoRequest)(nil),                // 26: moby.buildkit.v1.InfoRequest
+	(*InfoResponse)(nil),               // 27: moby.buildkit.v1.InfoResponse
+	(*VerifyCacheRequest)(nil),         // 28: moby.buildkit.v1.VerifyCacheRequest
+	(*VerifyCacheResponse)(nil),        // 29: moby.buildkit.v1.VerifyCacheResponse
+	(*CacheIssue)(nil),                 // 30: moby.buildkit.v1.CacheIssue
+	(*ResultLease)(nil),                // 31: moby.buildkit.v1.ResultLease
+	(*ResultLeaseRecord)(nil),          // 32: moby.buildkit.v1.ResultLeaseRecord
+	(*ListResultLeasesRequest)(nil),    // 33: moby.buildkit.v1.ListResultLeasesRequest
+	(*ListResultLeasesResponse)(nil),   // 34: moby.buildkit.v1.ListResultLeasesResponse
+	(*RenewResultLeaseRequest)(nil),    // 35: moby.buildkit.v1.RenewResultLeaseRequest
+	(*RenewResultLeaseResponse)(nil),   // 36: moby.buildkit.v1.RenewResultLeaseResponse
+	(*ReleaseResultLeaseRequest)(nil),  // 37: moby.buildkit.v1.ReleaseResultLeaseRequest
+	(*ReleaseResultLeaseResponse)(nil), // 38: moby.buildkit.v1.ReleaseResultLeaseResponse
+	(*CancelPlatformsRequest)(nil),     // 39: moby.buildkit.v1.CancelPlatformsRequest
+	(*CancelPlatformsResponse)(nil),    // 40: moby.buildkit.v1.CancelPlatformsResponse
+	(*ReloadConfigRequest)(nil),        // 41: moby.buildkit.v1.ReloadConfigRequest
+	(*ReloadConfigResponse)(nil),       // 42: moby.buildkit.v1.ReloadConfigResponse
+	(*DrainRequest)(nil),               // 43: moby.buildkit.v1.DrainRequest
+	(*DrainResponse)(nil),              // 44: moby.buildkit.v1.DrainResponse
+	(*ListSessionsRequest)(nil),        // 45: moby.buildkit.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),       // 46: moby.buildkit.v1.ListSessionsResponse
+	(*SessionRecord)(nil),              // 47: moby.buildkit.v1.SessionRecord
+	(*SessionResource)(nil),            // 48: moby.buildkit.v1.SessionResource
+	(*BuildHistoryRequest)(nil),        // 49: moby.buildkit.v1.BuildHistoryRequest
+	(*BuildHistoryEvent)(nil),          // 50: moby.buildkit.v1.BuildHistoryEvent
+	(*BuildHistoryRecord)(nil),         // 51: moby.buildkit.v1.BuildHistoryRecord
+	(*ClientIdentity)(nil),             // 52: moby.buildkit.v1.ClientIdentity
+	(*UpdateBuildHistoryRequest)(nil),  // 53: moby.buildkit.v1.UpdateBuildHistoryRequest
+	(*UpdateBuildHistoryResponse)(nil), // 54: moby.buildkit.v1.UpdateBuildHistoryResponse
+	(*Descriptor)(nil),                 // 55: moby.buildkit.v1.Descriptor
+	(*BuildResultInfo)(nil),            // 56: moby.buildkit.v1.BuildResultInfo
+	(*Exporter)(nil),                   // 57: moby.buildkit.v1.Exporter
+	nil,                                // 58: moby.buildkit.v1.SolveRequest.ExporterAttrsDeprecatedEntry
+	nil,                                // 59: moby.buildkit.v1.SolveRequest.FrontendAttrsEntry
+	nil,                                // 60: moby.buildkit.v1.SolveRequest.FrontendInputsEntry
+	nil,                                // 61: moby.buildkit.v1.CacheOptions.ExportAttrsDeprecatedEntry
+	nil,                                // 62: moby.buildkit.v1.CacheOptionsEntry.AttrsEntry
+	nil,                                // 63: moby.buildkit.v1.SolveResponse.ExporterResponseEntry
+	nil,                                // 64: moby.buildkit.v1.ExporterResponse.DataEntry
+	nil,                                // 65: moby.buildkit.v1.BuildHistoryRecord.FrontendAttrsEntry
+	nil,                                // 66: moby.buildkit.v1.BuildHistoryRecord.ExporterResponseEntry
+	nil,                                // 67: moby.buildkit.v1.BuildHistoryRecord.ResultsEntry
+	nil,                                // 68: moby.buildkit.v1.BuildHistoryRecord.CacheSourcesEntry
+	nil,                                // 69: moby.buildkit.v1.Descriptor.AnnotationsEntry
+	nil,                                // 70: moby.buildkit.v1.BuildResultInfo.ResultsEntry
+	nil,                                // 71: moby.buildkit.v1.Exporter.AttrsEntry
+	(*timestamp.Timestamp)(nil),        // 72: google.protobuf.Timestamp
+	(*pb.Definition)(nil),              // 73: pb.Definition
+	(*pb1.Policy)(nil),                 // 74: moby.buildkit.v1.sourcepolicy.Policy
+	(*pb.ProgressGroup)(nil),           // 75: pb.ProgressGroup
+	(*pb.SourceInfo)(nil),              // 76: pb.SourceInfo
+	(*pb.Range)(nil),                   // 77: pb.Range
+	(*types.WorkerRecord)(nil),         // 78: moby.buildkit.v1.types.WorkerRecord
+	(*types.BuildkitVersion)(nil),      // 79: moby.buildkit.v1.types.BuildkitVersion
+	(*status.Status)(nil),              // 80: google.rpc.Status
 }
 var file_github_com_moby_buildkit_api_services_control_control_proto_depIdxs = []int32{
-	4,  // 0: moby.buildkit.v1.DiskUsageResponse.record:type_name -> moby.buildkit.v1.UsageRecord
-	40, // 1: moby.buildkit.v1.UsageRecord.CreatedAt:type_name -> google.protobuf.Timestamp
-	40, // 2: moby.buildkit.v1.UsageRecord.LastUsedAt:type_name -> google.protobuf.Timestamp
-	41, // 3: moby.buildkit.v1.SolveRequest.Definition:type_name -> pb.Definition
-	28, // 4: moby.buildkit.v1.SolveRequest.ExporterAttrsDeprecated:type_name -> moby.buildkit.v1.SolveRequest.ExporterAttrsDeprecatedEntry
-	29, // 5: moby.buildkit.v1.SolveRequest.FrontendAttrs:type_name -> moby.buildkit.v1.SolveRequest.FrontendAttrsEntry
-	6,  // 6: moby.buildkit.v1.SolveRequest.Cache:type_name -> moby.buildkit.v1.CacheOptions
-	30, // 7: moby.buildkit.v1.SolveRequest.FrontendInputs:type_name -> moby.buildkit.v1.SolveRequest.FrontendInputsEntry
-	42, // 8: moby.buildkit.v1.SolveRequest.SourcePolicy:type_name -> moby.buildkit.v1.sourcepolicy.Policy
-	27, // 9: moby.buildkit.v1.SolveRequest.Exporters:type_name -> moby.buildkit.v1.Exporter
-	31, // 10: moby.buildkit.v1.CacheOptions.ExportAttrsDeprecated:type_name -> moby.buildkit.v1.CacheOptions.ExportAttrsDeprecatedEntry
-	7,  // 11: moby.buildkit.v1.CacheOptions.Exports:type_name -> moby.buildkit.v1.CacheOptionsEntry
-	7,  // 12: moby.buildkit.v1.CacheOptions.Imports:type_name -> moby.buildkit.v1.CacheOptionsEntry
-	32, // 13: moby.buildkit.v1.CacheOptionsEntry.Attrs:type_name -> moby.buildkit.v1.CacheOptionsEntry.AttrsEntry
-	33, // 14: moby.buildkit.v1.SolveResponse.ExporterResponse:type_name -> moby.buildkit.v1.SolveResponse.ExporterResponseEntry
-	11, // 15: moby.buildkit.v1.StatusResponse.vertexes:type_name -> moby.buildkit.v1.Vertex
-	12, // 16: moby.buildkit.v1.StatusResponse.statuses:type_name -> moby.buildkit.v1.VertexStatus
-	13, // 17: moby.buildkit.v1.StatusResponse.logs:type_name -> moby.buildkit.v1.VertexLog
-	14, // 18: moby.buildkit.v1.StatusResponse.warnings:type_name -> moby.buildkit.v1.VertexWarning
-	40, // 19: moby.buildkit.v1.Vertex.started:type_name -> google.protobuf.Timestamp
-	40, // 20: moby.buildkit.v1.Vertex.completed:type_name -> google.protobuf.Timestamp
-	43, // 21: moby.buildkit.v1.Vertex.progressGroup:type_name -> pb.ProgressGroup
-	40, // 22: moby.buildkit.v1.VertexStatus.timestamp:type_name -> google.protobuf.Timestamp
-	40, // 23: moby.buildkit.v1.VertexStatus.started:type_name -> google.protobuf.Timestamp
-	40, // 24: moby.buildkit.v1.VertexStatus.completed:type_name -> google.protobuf.Timestamp
-	40, // 25: moby.buildkit.v1.VertexLog.timestamp:type_name -> google.protobuf.Timestamp
-	44, // 26: moby.buildkit.v1.VertexWarning.info:type_name -> pb.SourceInfo
-	45, // 27: moby.buildkit.v1.VertexWarning.ranges:type_name -> pb.Range
-	46, // 28: moby.buildkit.v1.ListWorkersResponse.record:type_name -> moby.buildkit.v1.types.WorkerRecord
-	47, // 29: moby.buildkit.v1.InfoResponse.buildkitVersion:type_name -> moby.buildkit.v1.types.BuildkitVersion
-	0,  // 30: moby.buildkit.v1.BuildHistoryEvent.type:type_name -> moby.buildkit.v1.BuildHistoryEventType
-	22, // 31: moby.buildkit.v1.BuildHistoryEvent.record:type_name -> moby.buildkit.v1.BuildHistoryRecord
-	34, // 32: moby.buildkit.v1.BuildHistoryRecord.FrontendAttrs:type_name -> moby.buildkit.v1.BuildHistoryRecord.FrontendAttrsEntry
-	27, // 33: moby.buildkit.v1.BuildHistoryRecord.Exporters:type_name -> moby.buildkit.v1.Exporter
-	48, // 34: moby.buildkit.v1.BuildHistoryRecord.error:type_name -> google.rpc.Status
-	40, // 35: moby.buildkit.v1.BuildHistoryRecord.CreatedAt:type_name -> google.protobuf.Timestamp
-	40, // 36: moby.buildkit.v1.BuildHistoryRecord.CompletedAt:type_name -> google.protobuf.Timestamp
-	25, // 37: moby.buildkit.v1.BuildHistoryRecord.logs:type_name -> moby.buildkit.v1.Descriptor
-	35, // 38: moby.buildkit.v1.BuildHistoryRecord.ExporterResponse:type_name -> moby.buildkit.v1.BuildHistoryRecord.ExporterResponseEntry
-	26, // 39: moby.buildkit.v1.BuildHistoryRecord.Result:type_name -> moby.buildkit.v1.BuildResultInfo
-	36, // 40: moby.buildkit.v1.BuildHistoryRecord.Results:type_name -> moby.buildkit.v1.BuildHistoryRecord.ResultsEntry
-	25, // 41: moby.buildkit.v1.BuildHistoryRecord.trace:type_name -> moby.buildkit.v1.Descriptor
-	25, // 42: moby.buildkit.v1.BuildHistoryRecord.externalError:type_name -> moby.buildkit.v1.Descriptor
-	37, // 43: moby.buildkit.v1.Descriptor.annotations:type_name -> moby.buildkit.v1.Descriptor.AnnotationsEntry
-	25, // 44: moby.buildkit.v1.BuildResultInfo.ResultDeprecated:type_name -> moby.buildkit.v1.Descriptor
-	25, // 45: moby.buildkit.v1.BuildResultInfo.Attestations:type_name -> moby.buildkit.v1.Descriptor
-	38, // 46: moby.buildkit.v1.BuildResultInfo.Results:type_name -> moby.buildkit.v1.BuildResultInfo.ResultsEntry
-	39, // 47: moby.buildkit.v1.Exporter.Attrs:type_name -> moby.buildkit.v1.Exporter.AttrsEntry
-	41, // 48: moby.buildkit.v1.SolveRequest.FrontendInputsEntry.value:type_name -> pb.Definition
-	26, // 49: moby.buildkit.v1.BuildHistoryRecord.ResultsEntry.value:type_name -> moby.buildkit.v1.BuildResultInfo
-	25, // 50: moby.buildkit.v1.BuildResultInfo.ResultsEntry.value:type_name -> moby.buildkit.v1.Descriptor
-	2,  // 51: moby.buildkit.v1.Control.DiskUsage:input_type -> moby.buildkit.v1.DiskUsageRequest
-	1,  // 52: moby.buildkit.v1.Control.Prune:input_type -> moby.buildkit.v1.PruneRequest
-	5,  // 53: moby.buildkit.v1.Control.Solve:input_type -> moby.buildkit.v1.SolveRequest
-	9,  // 54: moby.buildkit.v1.Control.Status:input_type -> moby.buildkit.v1.StatusRequest
-	15, // 55: moby.buildkit.v1.Control.Session:input_type -> moby.buildkit.v1.BytesMessage
-	16, // 56: moby.buildkit.v1.Control.ListWorkers:input_type -> moby.buildkit.v1.ListWorkersRequest
-	18, // 57: moby.buildkit.v1.Control.Info:input_type -> moby.buildkit.v1.InfoRequest
-	20, // 58: moby.buildkit.v1.Control.ListenBuildHistory:input_type -> moby.buildkit.v1.BuildHistoryRequest
-	23, // 59: moby.buildkit.v1.Control.UpdateBuildHistory:input_type -> moby.buildkit.v1.UpdateBuildHistoryRequest
-	3,  // 60: moby.buildkit.v1.Control.DiskUsage:output_type -> moby.buildkit.v1.DiskUsageResponse
-	4,  // 61: moby.buildkit.v1.Control.Prune:output_type -> moby.buildkit.v1.UsageRecord
-	8,  // 62: moby.buildkit.v1.Control.Solve:output_type -> moby.buildkit.v1.SolveResponse
-	10, // 63: moby.buildkit.v1.Control.Status:output_type -> moby.buildkit.v1.StatusResponse
-	15, // 64: moby.buildkit.v1.Control.Session:output_type -> moby.buildkit.v1.BytesMessage
-	17, // 65: moby.buildkit.v1.Control.ListWorkers:output_type -> moby.buildkit.v1.ListWorkersResponse
-	19, // 66: moby.buildkit.v1.Control.Info:output_type -> moby.buildkit.v1.InfoResponse
-	21, // 67: moby.buildkit.v1.Control.ListenBuildHistory:output_type -> moby.buildkit.v1.BuildHistoryEvent
-	24, // 68: moby.buildkit.v1.Control.UpdateBuildHistory:output_type -> moby.buildkit.v1.UpdateBuildHistoryResponse
-	60, // [60:69] is the sub-list for method output_type
-	51, // [51:60] is the sub-list for method input_type
-	51, // [51:51] is the sub-list for extension type_name
-	51, // [51:51] is the sub-list for extension extendee
-	0,  // [0:51] is the sub-list for field type_name
+	5,  // 0: moby.buildkit.v1.DiskUsageResponse.record:type_name -> moby.buildkit.v1.UsageRecord
+	4,  // 1: moby.buildkit.v1.DiskUsageResponse.breakdown:type_name -> moby.buildkit.v1.UsageBreakdown
+	72, // 2: moby.buildkit.v1.UsageRecord.CreatedAt:type_name -> google.protobuf.Timestamp
+	72, // 3: moby.buildkit.v1.UsageRecord.LastUsedAt:type_name -> google.protobuf.Timestamp
+	73, // 4: moby.buildkit.v1.SolveRequest.Definition:type_name -> pb.Definition
+	58, // 5: moby.buildkit.v1.SolveRequest.ExporterAttrsDeprecated:type_name -> moby.buildkit.v1.SolveRequest.ExporterAttrsDeprecatedEntry
+	59, // 6: moby.buildkit.v1.SolveRequest.FrontendAttrs:type_name -> moby.buildkit.v1.SolveRequest.FrontendAttrsEntry
+	9,  // 7: moby.buildkit.v1.SolveRequest.Cache:type_name -> moby.buildkit.v1.CacheOptions
+	60, // 8: moby.buildkit.v1.SolveRequest.FrontendInputs:type_name -> moby.buildkit.v1.SolveRequest.FrontendInputsEntry
+	74, // 9: moby.buildkit.v1.SolveRequest.SourcePolicy:type_name -> moby.buildkit.v1.sourcepolicy.Policy
+	57, // 10: moby.buildkit.v1.SolveRequest.Exporters:type_name -> moby.buildkit.v1.Exporter
+	8,  // 11: moby.buildkit.v1.SolveRequest.ConcurrencyLimits:type_name -> moby.buildkit.v1.ConcurrencyLimits
+	7,  // 12: moby.buildkit.v1.SolveRequest.Proxy:type_name -> moby.buildkit.v1.ProxyConfig
+	61, // 13: moby.buildkit.v1.CacheOptions.ExportAttrsDeprecated:type_name -> moby.buildkit.v1.CacheOptions.ExportAttrsDeprecatedEntry
+	10, // 14: moby.buildkit.v1.CacheOptions.Exports:type_name -> moby.buildkit.v1.CacheOptionsEntry
+	10, // 15: moby.buildkit.v1.CacheOptions.Imports:type_name -> moby.buildkit.v1.CacheOptionsEntry
+	62, // 16: moby.buildkit.v1.CacheOptionsEntry.Attrs:type_name -> moby.buildkit.v1.CacheOptionsEntry.AttrsEntry
+	63, // 17: moby.buildkit.v1.SolveResponse.ExporterResponse:type_name -> moby.buildkit.v1.SolveResponse.ExporterResponseEntry
+	14, // 18: moby.buildkit.v1.SolveResponse.ExporterResponses:type_name -> moby.buildkit.v1.ExporterResponse
+	13, // 19: moby.buildkit.v1.ValidateSolveResponse.Diagnostics:type_name -> moby.buildkit.v1.SolveDiagnostic
+	15, // 20: moby.buildkit.v1.ExporterResponse.Metadata:type_name -> moby.buildkit.v1.ExporterMetadata
+	64, // 21: moby.buildkit.v1.ExporterResponse.Data:type_name -> moby.buildkit.v1.ExporterResponse.DataEntry
+	18, // 22: moby.buildkit.v1.StatusResponse.vertexes:type_name -> moby.buildkit.v1.Vertex
+	20, // 23: moby.buildkit.v1.StatusResponse.statuses:type_name -> moby.buildkit.v1.VertexStatus
+	21, // 24: moby.buildkit.v1.StatusResponse.logs:type_name -> moby.buildkit.v1.VertexLog
+	22, // 25: moby.buildkit.v1.StatusResponse.warnings:type_name -> moby.buildkit.v1.VertexWarning
+	72, // 26: moby.buildkit.v1.Vertex.started:type_name -> google.protobuf.Timestamp
+	72, // 27: moby.buildkit.v1.Vertex.completed:type_name -> google.protobuf.Timestamp
+	75, // 28: moby.buildkit.v1.Vertex.progressGroup:type_name -> pb.ProgressGroup
+	19, // 29: moby.buildkit.v1.Vertex.resourceUsage:type_name -> moby.buildkit.v1.ResourceUsage
+	72, // 30: moby.buildkit.v1.VertexStatus.timestamp:type_name -> google.protobuf.Timestamp
+	72, // 31: moby.buildkit.v1.VertexStatus.started:type_name -> google.protobuf.Timestamp
+	72, // 32: moby.buildkit.v1.VertexStatus.completed:type_name -> google.protobuf.Timestamp
+	72, // 33: moby.buildkit.v1.VertexLog.timestamp:type_name -> google.protobuf.Timestamp
+	76, // 34: moby.buildkit.v1.VertexWarning.info:type_name -> pb.SourceInfo
+	77, // 35: moby.buildkit.v1.VertexWarning.ranges:type_name -> pb.Range
+	78, // 36: moby.buildkit.v1.ListWorkersResponse.record:type_name -> moby.buildkit.v1.types.WorkerRecord
+	79, // 37: moby.buildkit.v1.InfoResponse.buildkitVersion:type_name -> moby.buildkit.v1.types.BuildkitVersion
+	30, // 38: moby.buildkit.v1.VerifyCacheResponse.issues:type_name -> moby.buildkit.v1.CacheIssue
+	72, // 39: moby.buildkit.v1.ResultLease.CreatedAt:type_name -> google.protobuf.Timestamp
+	72, // 40: moby.buildkit.v1.ResultLease.ExpiresAt:type_name -> google.protobuf.Timestamp
+	32, // 41: moby.buildkit.v1.ResultLease.Records:type_name -> moby.buildkit.v1.ResultLeaseRecord
+	31, // 42: moby.buildkit.v1.ListResultLeasesResponse.leases:type_name -> moby.buildkit.v1.ResultLease
+	31, // 43: moby.buildkit.v1.RenewResultLeaseResponse.lease:type_name -> moby.buildkit.v1.ResultLease
+	47, // 44: moby.buildkit.v1.ListSessionsResponse.record:type_name -> moby.buildkit.v1.SessionRecord
+	72, // 45: moby.buildkit.v1.SessionRecord.CreatedAt:type_name -> google.protobuf.Timestamp
+	48, // 46: moby.buildkit.v1.SessionRecord.Resources:type_name -> moby.buildkit.v1.SessionResource
+	0,  // 47: moby.buildkit.v1.BuildHistoryEvent.type:type_name -> moby.buildkit.v1.BuildHistoryEventType
+	51, // 48: moby.buildkit.v1.BuildHistoryEvent.record:type_name -> moby.buildkit.v1.BuildHistoryRecord
+	65, // 49: moby.buildkit.v1.BuildHistoryRecord.FrontendAttrs:type_name -> moby.buildkit.v1.BuildHistoryRecord.FrontendAttrsEntry
+	57, // 50: moby.buildkit.v1.BuildHistoryRecord.Exporters:type_name -> moby.buildkit.v1.Exporter
+	80, // 51: moby.buildkit.v1.BuildHistoryRecord.error:type_name -> google.rpc.Status
+	72, // 52: moby.buildkit.v1.BuildHistoryRecord.CreatedAt:type_name -> google.protobuf.Timestamp
+	72, // 53: moby.buildkit.v1.BuildHistoryRecord.CompletedAt:type_name -> google.protobuf.Timestamp
+	55, // 54: moby.buildkit.v1.BuildHistoryRecord.logs:type_name -> moby.buildkit.v1.Descriptor
+	66, // 55: moby.buildkit.v1.BuildHistoryRecord.ExporterResponse:type_name -> moby.buildkit.v1.BuildHistoryRecord.ExporterResponseEntry
+	56, // 56: moby.buildkit.v1.BuildHistoryRecord.Result:type_name -> moby.buildkit.v1.BuildResultInfo
+	67, // 57: moby.buildkit.v1.BuildHistoryRecord.Results:type_name -> moby.buildkit.v1.BuildHistoryRecord.ResultsEntry
+	55, // 58: moby.buildkit.v1.BuildHistoryRecord.trace:type_name -> moby.buildkit.v1.Descriptor
+	55, // 59: moby.buildkit.v1.BuildHistoryRecord.externalError:type_name -> moby.buildkit.v1.Descriptor
+	52, // 60: moby.buildkit.v1.BuildHistoryRecord.clientIdentity:type_name -> moby.buildkit.v1.ClientIdentity
+	55, // 61: moby.buildkit.v1.BuildHistoryRecord.stepLogs:type_name -> moby.buildkit.v1.Descriptor
+	19, // 62: moby.buildkit.v1.BuildHistoryRecord.resourceUsage:type_name -> moby.buildkit.v1.ResourceUsage
+	68, // 63: moby.buildkit.v1.BuildHistoryRecord.cacheSources:type_name -> moby.buildkit.v1.BuildHistoryRecord.CacheSourcesEntry
+	69, // 64: moby.buildkit.v1.Descriptor.annotations:type_name -> moby.buildkit.v1.Descriptor.AnnotationsEntry
+	55, // 65: moby.buildkit.v1.BuildResultInfo.ResultDeprecated:type_name -> moby.buildkit.v1.Descriptor
+	55, // 66: moby.buildkit.v1.BuildResultInfo.Attestations:type_name -> moby.buildkit.v1.Descriptor
+	70, // 67: moby.buildkit.v1.BuildResultInfo.Results:type_name -> moby.buildkit.v1.BuildResultInfo.ResultsEntry
+	71, // 68: moby.buildkit.v1.Exporter.Attrs:type_name -> moby.buildkit.v1.Exporter.AttrsEntry
+	73, // 69: moby.buildkit.v1.SolveRequest.FrontendInputsEntry.value:type_name -> pb.Definition
+	56, // 70: moby.buildkit.v1.BuildHistoryRecord.ResultsEntry.value:type_name -> moby.buildkit.v1.BuildResultInfo
+	55, // 71: moby.buildkit.v1.BuildResultInfo.ResultsEntry.value:type_name -> moby.buildkit.v1.Descriptor
+	2,  // 72: moby.buildkit.v1.Control.DiskUsage:input_type -> moby.buildkit.v1.DiskUsageRequest
+	1,  // 73: moby.buildkit.v1.Control.Prune:input_type -> moby.buildkit.v1.PruneRequest
+	6,  // 74: moby.buildkit.v1.Control.Solve:input_type -> moby.buildkit.v1.SolveRequest
+	6,  // 75: moby.buildkit.v1.Control.ValidateSolve:input_type -> moby.buildkit.v1.SolveRequest
+	16, // 76: moby.buildkit.v1.Control.Status:input_type -> moby.buildkit.v1.StatusRequest
+	23, // 77: moby.buildkit.v1.Control.Session:input_type -> moby.buildkit.v1.BytesMessage
+	24, // 78: moby.buildkit.v1.Control.ListWorkers:input_type -> moby.buildkit.v1.ListWorkersRequest
+	26, // 79: moby.buildkit.v1.Control.Info:input_type -> moby.buildkit.v1.InfoRequest
+	45, // 80: moby.buildkit.v1.Control.ListSessions:input_type -> moby.buildkit.v1.ListSessionsRequest
+	28, // 81: moby.buildkit.v1.Control.VerifyCache:input_type -> moby.buildkit.v1.VerifyCacheRequest
+	33, // 82: moby.buildkit.v1.Control.ListResultLeases:input_type -> moby.buildkit.v1.ListResultLeasesRequest
+	35, // 83: moby.buildkit.v1.Control.RenewResultLease:input_type -> moby.buildkit.v1.RenewResultLeaseRequest
+	37, // 84: moby.buildkit.v1.Control.ReleaseResultLease:input_type -> moby.buildkit.v1.ReleaseResultLeaseRequest
+	39, // 85: moby.buildkit.v1.Control.CancelPlatforms:input_type -> moby.buildkit.v1.CancelPlatformsRequest
+	41, // 86: moby.buildkit.v1.Control.ReloadConfig:input_type -> moby.buildkit.v1.ReloadConfigRequest
+	43, // 87: moby.buildkit.v1.Control.Drain:input_type -> moby.buildkit.v1.DrainRequest
+	49, // 88: moby.buildkit.v1.Control.ListenBuildHistory:input_type -> moby.buildkit.v1.BuildHistoryRequest
+	53, // 89: moby.buildkit.v1.Control.UpdateBuildHistory:input_type -> moby.buildkit.v1.UpdateBuildHistoryRequest
+	3,  // 90: moby.buildkit.v1.Control.DiskUsage:output_type -> moby.buildkit.v1.DiskUsageResponse
+	5,  // 91: moby.buildkit.v1.Control.Prune:output_type -> moby.buildkit.v1.UsageRecord
+	11, // 92: moby.buildkit.v1.Control.Solve:output_type -> moby.buildkit.v1.SolveResponse
+	12, // 93: moby.buildkit.v1.Control.ValidateSolve:output_type -> moby.buildkit.v1.ValidateSolveResponse
+	17, // 94: moby.buildkit.v1.Control.Status:output_type -> moby.buildkit.v1.StatusResponse
+	23, // 95: moby.buildkit.v1.Control.Session:output_type -> moby.buildkit.v1.BytesMessage
+	25, // 96: moby.buildkit.v1.Control.ListWorkers:output_type -> moby.buildkit.v1.ListWorkersResponse
+	27, // 97: moby.buildkit.v1.Control.Info:output_type -> moby.buildkit.v1.InfoResponse
+	46, // 98: moby.buildkit.v1.Control.ListSessions:output_type -> moby.buildkit.v1.ListSessionsResponse
+	29, // 99: moby.buildkit.v1.Control.VerifyCache:output_type -> moby.buildkit.v1.VerifyCacheResponse
+	34, // 100: moby.buildkit.v1.Control.ListResultLeases:output_type -> moby.buildkit.v1.ListResultLeasesResponse
+	36, // 101: moby.buildkit.v1.Control.RenewResultLease:output_type -> moby.buildkit.v1.RenewResultLeaseResponse
+	38, // 102: moby.buildkit.v1.Control.ReleaseResultLease:output_type -> moby.buildkit.v1.ReleaseResultLeaseResponse
+	40, // 103: moby.buildkit.v1.Control.CancelPlatforms:output_type -> moby.buildkit.v1.CancelPlatformsResponse
+	42, // 104: moby.buildkit.v1.Control.ReloadConfig:output_type -> moby.buildkit.v1.ReloadConfigResponse
+	44, // 105: moby.buildkit.v1.Control.Drain:output_type -> moby.buildkit.v1.DrainResponse
+	50, // 106: moby.buildkit.v1.Control.ListenBuildHistory:output_type -> moby.buildkit.v1.BuildHistoryEvent
+	54, // 107: moby.buildkit.v1.Control.UpdateBuildHistory:output_type -> moby.buildkit.v1.UpdateBuildHistoryResponse
+	90, // [90:108] is the sub-list for method output_type
+	72, // [72:90] is the sub-list for method input_type
+	72, // [72:72] is the sub-list for extension type_name
+	72, // [72:72] is the sub-list for extension extendee
+	0,  // [0:72] is the sub-list for field type_name
 }
 
 func init() { file_github_com_moby_buildkit_api_services_control_control_proto_init() }
@@ -2387,7 +4448,7 @@ func file_github_com_moby_buildkit_api_services_control_control_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc), len(file_github_com_moby_buildkit_api_services_control_control_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   39,
+			NumMessages:   71,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
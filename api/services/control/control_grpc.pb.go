@@ -22,10 +22,19 @@ const (
 	Control_DiskUsage_FullMethodName          = "/moby.buildkit.v1.Control/DiskUsage"
 	Control_Prune_FullMethodName              = "/moby.buildkit.v1.Control/Prune"
 	Control_Solve_FullMethodName              = "/moby.buildkit.v1.Control/Solve"
+	Control_ValidateSolve_FullMethodName      = "/moby.buildkit.v1.Control/ValidateSolve"
 	Control_Status_FullMethodName             = "/moby.buildkit.v1.Control/Status"
 	Control_Session_FullMethodName            = "/moby.buildkit.v1.Control/Session"
 	Control_ListWorkers_FullMethodName        = "/moby.buildkit.v1.Control/ListWorkers"
 	Control_Info_FullMethodName               = "/moby.buildkit.v1.Control/Info"
+	Control_ListSessions_FullMethodName       = "/moby.buildkit.v1.Control/ListSessions"
+	Control_VerifyCache_FullMethodName        = "/moby.buildkit.v1.Control/VerifyCache"
+	Control_ListResultLeases_FullMethodName   = "/moby.buildkit.v1.Control/ListResultLeases"
+	Control_RenewResultLease_FullMethodName   = "/moby.buildkit.v1.Control/RenewResultLease"
+	Control_ReleaseResultLease_FullMethodName = "/moby.buildkit.v1.Control/ReleaseResultLease"
+	Control_CancelPlatforms_FullMethodName    = "/moby.buildkit.v1.Control/CancelPlatforms"
+	Control_ReloadConfig_FullMethodName       = "/moby.buildkit.v1.Control/ReloadConfig"
+	Control_Drain_FullMethodName              = "/moby.buildkit.v1.Control/Drain"
 	Control_ListenBuildHistory_FullMethodName = "/moby.buildkit.v1.Control/ListenBuildHistory"
 	Control_UpdateBuildHistory_FullMethodName = "/moby.buildkit.v1.Control/UpdateBuildHistory"
 )
@@ -37,10 +46,24 @@ type ControlClient interface {
 	DiskUsage(ctx context.Context, in *DiskUsageRequest, opts ...grpc.CallOption) (*DiskUsageResponse, error)
 	Prune(ctx context.Context, in *PruneRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UsageRecord], error)
 	Solve(ctx context.Context, in *SolveRequest, opts ...grpc.CallOption) (*SolveResponse, error)
+	// ValidateSolve validates a solve request without executing it
+	ValidateSolve(ctx context.Context, in *SolveRequest, opts ...grpc.CallOption) (*ValidateSolveResponse, error)
 	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StatusResponse], error)
 	Session(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[BytesMessage, BytesMessage], error)
 	ListWorkers(ctx context.Context, in *ListWorkersRequest, opts ...grpc.CallOption) (*ListWorkersResponse, error)
 	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	VerifyCache(ctx context.Context, in *VerifyCacheRequest, opts ...grpc.CallOption) (*VerifyCacheResponse, error)
+	ListResultLeases(ctx context.Context, in *ListResultLeasesRequest, opts ...grpc.CallOption) (*ListResultLeasesResponse, error)
+	RenewResultLease(ctx context.Context, in *RenewResultLeaseRequest, opts ...grpc.CallOption) (*RenewResultLeaseResponse, error)
+	ReleaseResultLease(ctx context.Context, in *ReleaseResultLeaseRequest, opts ...grpc.CallOption) (*ReleaseResultLeaseResponse, error)
+	CancelPlatforms(ctx context.Context, in *CancelPlatformsRequest, opts ...grpc.CallOption) (*CancelPlatformsResponse, error)
+	// ReloadConfig reloads the config file of the daemon and applies the
+	// settings that can change without a restart
+	ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error)
+	// Drain stops accepting solves, waits for the running ones to finish and
+	// then stops the daemon
+	Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*DrainResponse, error)
 	ListenBuildHistory(ctx context.Context, in *BuildHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BuildHistoryEvent], error)
 	UpdateBuildHistory(ctx context.Context, in *UpdateBuildHistoryRequest, opts ...grpc.CallOption) (*UpdateBuildHistoryResponse, error)
 }
@@ -92,6 +115,16 @@ func (c *controlClient) Solve(ctx context.Context, in *SolveRequest, opts ...grp
 	return out, nil
 }
 
+func (c *controlClient) ValidateSolve(ctx context.Context, in *SolveRequest, opts ...grpc.CallOption) (*ValidateSolveResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ValidateSolveResponse)
+	err := c.cc.Invoke(ctx, Control_ValidateSolve_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *controlClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StatusResponse], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &Control_ServiceDesc.Streams[1], Control_Status_FullMethodName, cOpts...)
@@ -144,6 +177,86 @@ func (c *controlClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.
 	return out, nil
 }
 
+func (c *controlClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, Control_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) VerifyCache(ctx context.Context, in *VerifyCacheRequest, opts ...grpc.CallOption) (*VerifyCacheResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyCacheResponse)
+	err := c.cc.Invoke(ctx, Control_VerifyCache_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ListResultLeases(ctx context.Context, in *ListResultLeasesRequest, opts ...grpc.CallOption) (*ListResultLeasesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListResultLeasesResponse)
+	err := c.cc.Invoke(ctx, Control_ListResultLeases_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) RenewResultLease(ctx context.Context, in *RenewResultLeaseRequest, opts ...grpc.CallOption) (*RenewResultLeaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenewResultLeaseResponse)
+	err := c.cc.Invoke(ctx, Control_RenewResultLease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ReleaseResultLease(ctx context.Context, in *ReleaseResultLeaseRequest, opts ...grpc.CallOption) (*ReleaseResultLeaseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReleaseResultLeaseResponse)
+	err := c.cc.Invoke(ctx, Control_ReleaseResultLease_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) CancelPlatforms(ctx context.Context, in *CancelPlatformsRequest, opts ...grpc.CallOption) (*CancelPlatformsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelPlatformsResponse)
+	err := c.cc.Invoke(ctx, Control_CancelPlatforms_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) ReloadConfig(ctx context.Context, in *ReloadConfigRequest, opts ...grpc.CallOption) (*ReloadConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReloadConfigResponse)
+	err := c.cc.Invoke(ctx, Control_ReloadConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlClient) Drain(ctx context.Context, in *DrainRequest, opts ...grpc.CallOption) (*DrainResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DrainResponse)
+	err := c.cc.Invoke(ctx, Control_Drain_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *controlClient) ListenBuildHistory(ctx context.Context, in *BuildHistoryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BuildHistoryEvent], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &Control_ServiceDesc.Streams[3], Control_ListenBuildHistory_FullMethodName, cOpts...)
@@ -180,10 +293,24 @@ type ControlServer interface {
 	DiskUsage(context.Context, *DiskUsageRequest) (*DiskUsageResponse, error)
 	Prune(*PruneRequest, grpc.ServerStreamingServer[UsageRecord]) error
 	Solve(context.Context, *SolveRequest) (*SolveResponse, error)
+	// ValidateSolve validates a solve request without executing it
+	ValidateSolve(context.Context, *SolveRequest) (*ValidateSolveResponse, error)
 	Status(*StatusRequest, grpc.ServerStreamingServer[StatusResponse]) error
 	Session(grpc.BidiStreamingServer[BytesMessage, BytesMessage]) error
 	ListWorkers(context.Context, *ListWorkersRequest) (*ListWorkersResponse, error)
 	Info(context.Context, *InfoRequest) (*InfoResponse, error)
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	VerifyCache(context.Context, *VerifyCacheRequest) (*VerifyCacheResponse, error)
+	ListResultLeases(context.Context, *ListResultLeasesRequest) (*ListResultLeasesResponse, error)
+	RenewResultLease(context.Context, *RenewResultLeaseRequest) (*RenewResultLeaseResponse, error)
+	ReleaseResultLease(context.Context, *ReleaseResultLeaseRequest) (*ReleaseResultLeaseResponse, error)
+	CancelPlatforms(context.Context, *CancelPlatformsRequest) (*CancelPlatformsResponse, error)
+	// ReloadConfig reloads the config file of the daemon and applies the
+	// settings that can change without a restart
+	ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error)
+	// Drain stops accepting solves, waits for the running ones to finish and
+	// then stops the daemon
+	Drain(context.Context, *DrainRequest) (*DrainResponse, error)
 	ListenBuildHistory(*BuildHistoryRequest, grpc.ServerStreamingServer[BuildHistoryEvent]) error
 	UpdateBuildHistory(context.Context, *UpdateBuildHistoryRequest) (*UpdateBuildHistoryResponse, error)
 }
@@ -204,6 +331,9 @@ func (UnimplementedControlServer) Prune(*PruneRequest, grpc.ServerStreamingServe
 func (UnimplementedControlServer) Solve(context.Context, *SolveRequest) (*SolveResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Solve not implemented")
 }
+func (UnimplementedControlServer) ValidateSolve(context.Context, *SolveRequest) (*ValidateSolveResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateSolve not implemented")
+}
 func (UnimplementedControlServer) Status(*StatusRequest, grpc.ServerStreamingServer[StatusResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method Status not implemented")
 }
@@ -216,6 +346,30 @@ func (UnimplementedControlServer) ListWorkers(context.Context, *ListWorkersReque
 func (UnimplementedControlServer) Info(context.Context, *InfoRequest) (*InfoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
 }
+func (UnimplementedControlServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedControlServer) VerifyCache(context.Context, *VerifyCacheRequest) (*VerifyCacheResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyCache not implemented")
+}
+func (UnimplementedControlServer) ListResultLeases(context.Context, *ListResultLeasesRequest) (*ListResultLeasesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListResultLeases not implemented")
+}
+func (UnimplementedControlServer) RenewResultLease(context.Context, *RenewResultLeaseRequest) (*RenewResultLeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewResultLease not implemented")
+}
+func (UnimplementedControlServer) ReleaseResultLease(context.Context, *ReleaseResultLeaseRequest) (*ReleaseResultLeaseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseResultLease not implemented")
+}
+func (UnimplementedControlServer) CancelPlatforms(context.Context, *CancelPlatformsRequest) (*CancelPlatformsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelPlatforms not implemented")
+}
+func (UnimplementedControlServer) ReloadConfig(context.Context, *ReloadConfigRequest) (*ReloadConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadConfig not implemented")
+}
+func (UnimplementedControlServer) Drain(context.Context, *DrainRequest) (*DrainResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Drain not implemented")
+}
 func (UnimplementedControlServer) ListenBuildHistory(*BuildHistoryRequest, grpc.ServerStreamingServer[BuildHistoryEvent]) error {
 	return status.Errorf(codes.Unimplemented, "method ListenBuildHistory not implemented")
 }
@@ -289,6 +443,24 @@ func _Control_Solve_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Control_ValidateSolve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SolveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ValidateSolve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ValidateSolve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ValidateSolve(ctx, req.(*SolveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Control_Status_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(StatusRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -343,6 +515,150 @@ func _Control_Info_Handler(srv interface{}, ctx context.Context, dec func(interf
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Control_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_VerifyCache_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyCacheRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).VerifyCache(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_VerifyCache_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).VerifyCache(ctx, req.(*VerifyCacheRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_ListResultLeases_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListResultLeasesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ListResultLeases(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ListResultLeases_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ListResultLeases(ctx, req.(*ListResultLeasesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_RenewResultLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewResultLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).RenewResultLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_RenewResultLease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).RenewResultLease(ctx, req.(*RenewResultLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_ReleaseResultLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseResultLeaseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ReleaseResultLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ReleaseResultLease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ReleaseResultLease(ctx, req.(*ReleaseResultLeaseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_CancelPlatforms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelPlatformsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).CancelPlatforms(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_CancelPlatforms_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).CancelPlatforms(ctx, req.(*CancelPlatformsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_ReloadConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).ReloadConfig(ctx, req.(*ReloadConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Control_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DrainRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Control_Drain_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlServer).Drain(ctx, req.(*DrainRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Control_ListenBuildHistory_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(BuildHistoryRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -387,6 +703,10 @@ var Control_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Solve",
 			Handler:    _Control_Solve_Handler,
 		},
+		{
+			MethodName: "ValidateSolve",
+			Handler:    _Control_ValidateSolve_Handler,
+		},
 		{
 			MethodName: "ListWorkers",
 			Handler:    _Control_ListWorkers_Handler,
@@ -395,6 +715,38 @@ var Control_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Info",
 			Handler:    _Control_Info_Handler,
 		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _Control_ListSessions_Handler,
+		},
+		{
+			MethodName: "VerifyCache",
+			Handler:    _Control_VerifyCache_Handler,
+		},
+		{
+			MethodName: "ListResultLeases",
+			Handler:    _Control_ListResultLeases_Handler,
+		},
+		{
+			MethodName: "RenewResultLease",
+			Handler:    _Control_RenewResultLease_Handler,
+		},
+		{
+			MethodName: "ReleaseResultLease",
+			Handler:    _Control_ReleaseResultLease_Handler,
+		},
+		{
+			MethodName: "CancelPlatforms",
+			Handler:    _Control_CancelPlatforms_Handler,
+		},
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _Control_ReloadConfig_Handler,
+		},
+		{
+			MethodName: "Drain",
+			Handler:    _Control_Drain_Handler,
+		},
 		{
 			MethodName: "UpdateBuildHistory",
 			Handler:    _Control_UpdateBuildHistory_Handler,
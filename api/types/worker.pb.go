@@ -7,6 +7,7 @@
 package moby_buildkit_v1_types
 
 import (
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	pb "github.com/moby/buildkit/solver/pb"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
@@ -30,8 +31,17 @@ type WorkerRecord struct {
 	GCPolicy        []*GCPolicy            `protobuf:"bytes,4,rep,name=GCPolicy,proto3" json:"GCPolicy,omitempty"`
 	BuildkitVersion *BuildkitVersion       `protobuf:"bytes,5,opt,name=BuildkitVersion,proto3" json:"BuildkitVersion,omitempty"`
 	CDIDevices      []*CDIDevice           `protobuf:"bytes,6,rep,name=CDIDevices,proto3" json:"CDIDevices,omitempty"`
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	// ActiveBuilds is the number of builds running on the worker
+	ActiveBuilds int64 `protobuf:"varint,7,opt,name=ActiveBuilds,proto3" json:"ActiveBuilds,omitempty"`
+	// Status is the current resource usage of the worker. It is only set
+	// in the responses of ListWorkers.
+	Status *WorkerStatus `protobuf:"bytes,8,opt,name=Status,proto3" json:"Status,omitempty"`
+	// Emulators are the emulators of the platforms the worker runs
+	// processes of in addition to its native platform. They are only set
+	// in the responses of ListWorkers.
+	Emulators     []*Emulator `protobuf:"bytes,9,rep,name=Emulators,proto3" json:"Emulators,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *WorkerRecord) Reset() {
@@ -106,6 +116,321 @@ func (x *WorkerRecord) GetCDIDevices() []*CDIDevice {
 	return nil
 }
 
+func (x *WorkerRecord) GetActiveBuilds() int64 {
+	if x != nil {
+		return x.ActiveBuilds
+	}
+	return 0
+}
+
+func (x *WorkerRecord) GetStatus() *WorkerStatus {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+func (x *WorkerRecord) GetEmulators() []*Emulator {
+	if x != nil {
+		return x.Emulators
+	}
+	return nil
+}
+
+type Emulator struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Platform *pb.Platform           `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"`
+	// path of the QEMU user emulator binary, empty for the platforms run by
+	// the binfmt_misc handlers registered in the kernel
+	Path          string `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Emulator) Reset() {
+	*x = Emulator{}
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Emulator) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Emulator) ProtoMessage() {}
+
+func (x *Emulator) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Emulator.ProtoReflect.Descriptor instead.
+func (*Emulator) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Emulator) GetPlatform() *pb.Platform {
+	if x != nil {
+		return x.Platform
+	}
+	return nil
+}
+
+func (x *Emulator) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type WorkerStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// disk usage of the filesystem of the worker state directory
+	DiskTotal     int64 `protobuf:"varint,1,opt,name=diskTotal,proto3" json:"diskTotal,omitempty"`
+	DiskFree      int64 `protobuf:"varint,2,opt,name=diskFree,proto3" json:"diskFree,omitempty"`
+	DiskAvailable int64 `protobuf:"varint,3,opt,name=diskAvailable,proto3" json:"diskAvailable,omitempty"`
+	// runningExecs is the number of processes started by the worker
+	// executor that are still running
+	RunningExecs int64 `protobuf:"varint,4,opt,name=runningExecs,proto3" json:"runningExecs,omitempty"`
+	// pressure stall information of the host, unset if not supported
+	CpuPressure    *Pressure `protobuf:"bytes,5,opt,name=cpuPressure,proto3" json:"cpuPressure,omitempty"`
+	MemoryPressure *Pressure `protobuf:"bytes,6,opt,name=memoryPressure,proto3" json:"memoryPressure,omitempty"`
+	IoPressure     *Pressure `protobuf:"bytes,7,opt,name=ioPressure,proto3" json:"ioPressure,omitempty"`
+	Gc             *GCStatus `protobuf:"bytes,8,opt,name=gc,proto3" json:"gc,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WorkerStatus) Reset() {
+	*x = WorkerStatus{}
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkerStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkerStatus) ProtoMessage() {}
+
+func (x *WorkerStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkerStatus.ProtoReflect.Descriptor instead.
+func (*WorkerStatus) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *WorkerStatus) GetDiskTotal() int64 {
+	if x != nil {
+		return x.DiskTotal
+	}
+	return 0
+}
+
+func (x *WorkerStatus) GetDiskFree() int64 {
+	if x != nil {
+		return x.DiskFree
+	}
+	return 0
+}
+
+func (x *WorkerStatus) GetDiskAvailable() int64 {
+	if x != nil {
+		return x.DiskAvailable
+	}
+	return 0
+}
+
+func (x *WorkerStatus) GetRunningExecs() int64 {
+	if x != nil {
+		return x.RunningExecs
+	}
+	return 0
+}
+
+func (x *WorkerStatus) GetCpuPressure() *Pressure {
+	if x != nil {
+		return x.CpuPressure
+	}
+	return nil
+}
+
+func (x *WorkerStatus) GetMemoryPressure() *Pressure {
+	if x != nil {
+		return x.MemoryPressure
+	}
+	return nil
+}
+
+func (x *WorkerStatus) GetIoPressure() *Pressure {
+	if x != nil {
+		return x.IoPressure
+	}
+	return nil
+}
+
+func (x *WorkerStatus) GetGc() *GCStatus {
+	if x != nil {
+		return x.Gc
+	}
+	return nil
+}
+
+type Pressure struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Avg10         float64                `protobuf:"fixed64,1,opt,name=avg10,proto3" json:"avg10,omitempty"`
+	Avg60         float64                `protobuf:"fixed64,2,opt,name=avg60,proto3" json:"avg60,omitempty"`
+	Avg300        float64                `protobuf:"fixed64,3,opt,name=avg300,proto3" json:"avg300,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pressure) Reset() {
+	*x = Pressure{}
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pressure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pressure) ProtoMessage() {}
+
+func (x *Pressure) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pressure.ProtoReflect.Descriptor instead.
+func (*Pressure) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Pressure) GetAvg10() float64 {
+	if x != nil {
+		return x.Avg10
+	}
+	return 0
+}
+
+func (x *Pressure) GetAvg60() float64 {
+	if x != nil {
+		return x.Avg60
+	}
+	return 0
+}
+
+func (x *Pressure) GetAvg300() float64 {
+	if x != nil {
+		return x.Avg300
+	}
+	return 0
+}
+
+type GCStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Running       bool                   `protobuf:"varint,1,opt,name=running,proto3" json:"running,omitempty"`
+	LastStarted   *timestamp.Timestamp   `protobuf:"bytes,2,opt,name=lastStarted,proto3" json:"lastStarted,omitempty"`
+	LastCompleted *timestamp.Timestamp   `protobuf:"bytes,3,opt,name=lastCompleted,proto3" json:"lastCompleted,omitempty"`
+	LastFreed     int64                  `protobuf:"varint,4,opt,name=lastFreed,proto3" json:"lastFreed,omitempty"`
+	LastError     string                 `protobuf:"bytes,5,opt,name=lastError,proto3" json:"lastError,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GCStatus) Reset() {
+	*x = GCStatus{}
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GCStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GCStatus) ProtoMessage() {}
+
+func (x *GCStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GCStatus.ProtoReflect.Descriptor instead.
+func (*GCStatus) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GCStatus) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *GCStatus) GetLastStarted() *timestamp.Timestamp {
+	if x != nil {
+		return x.LastStarted
+	}
+	return nil
+}
+
+func (x *GCStatus) GetLastCompleted() *timestamp.Timestamp {
+	if x != nil {
+		return x.LastCompleted
+	}
+	return nil
+}
+
+func (x *GCStatus) GetLastFreed() int64 {
+	if x != nil {
+		return x.LastFreed
+	}
+	return 0
+}
+
+func (x *GCStatus) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
 type GCPolicy struct {
 	state        protoimpl.MessageState `protogen:"open.v1"`
 	All          bool                   `protobuf:"varint,1,opt,name=all,proto3" json:"all,omitempty"`
@@ -121,7 +446,7 @@ type GCPolicy struct {
 
 func (x *GCPolicy) Reset() {
 	*x = GCPolicy{}
-	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[1]
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -133,7 +458,7 @@ func (x *GCPolicy) String() string {
 func (*GCPolicy) ProtoMessage() {}
 
 func (x *GCPolicy) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[1]
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -146,7 +471,7 @@ func (x *GCPolicy) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GCPolicy.ProtoReflect.Descriptor instead.
 func (*GCPolicy) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{1}
+	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GCPolicy) GetAll() bool {
@@ -202,7 +527,7 @@ type BuildkitVersion struct {
 
 func (x *BuildkitVersion) Reset() {
 	*x = BuildkitVersion{}
-	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[2]
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -214,7 +539,7 @@ func (x *BuildkitVersion) String() string {
 func (*BuildkitVersion) ProtoMessage() {}
 
 func (x *BuildkitVersion) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[2]
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -227,7 +552,7 @@ func (x *BuildkitVersion) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildkitVersion.ProtoReflect.Descriptor instead.
 func (*BuildkitVersion) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{2}
+	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *BuildkitVersion) GetPackage() string {
@@ -263,7 +588,7 @@ type CDIDevice struct {
 
 func (x *CDIDevice) Reset() {
 	*x = CDIDevice{}
-	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[3]
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -275,7 +600,7 @@ func (x *CDIDevice) String() string {
 func (*CDIDevice) ProtoMessage() {}
 
 func (x *CDIDevice) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[3]
+	mi := &file_github_com_moby_buildkit_api_types_worker_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -288,7 +613,7 @@ func (x *CDIDevice) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CDIDevice.ProtoReflect.Descriptor instead.
 func (*CDIDevice) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{3}
+	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CDIDevice) GetName() string {
@@ -323,7 +648,7 @@ var File_github_com_moby_buildkit_api_types_worker_proto protoreflect.FileDescri
 
 const file_github_com_moby_buildkit_api_types_worker_proto_rawDesc = "" +
 	"\n" +
-	"/github.com/moby/buildkit/api/types/worker.proto\x12\x16moby.buildkit.v1.types\x1a,github.com/moby/buildkit/solver/pb/ops.proto\"\xa3\x03\n" +
+	"/github.com/moby/buildkit/api/types/worker.proto\x12\x16moby.buildkit.v1.types\x1a,github.com/moby/buildkit/solver/pb/ops.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc5\x04\n" +
 	"\fWorkerRecord\x12\x0e\n" +
 	"\x02ID\x18\x01 \x01(\tR\x02ID\x12H\n" +
 	"\x06Labels\x18\x02 \x03(\v20.moby.buildkit.v1.types.WorkerRecord.LabelsEntryR\x06Labels\x12*\n" +
@@ -332,10 +657,37 @@ const file_github_com_moby_buildkit_api_types_worker_proto_rawDesc = "" +
 	"\x0fBuildkitVersion\x18\x05 \x01(\v2'.moby.buildkit.v1.types.BuildkitVersionR\x0fBuildkitVersion\x12A\n" +
 	"\n" +
 	"CDIDevices\x18\x06 \x03(\v2!.moby.buildkit.v1.types.CDIDeviceR\n" +
-	"CDIDevices\x1a9\n" +
+	"CDIDevices\x12\"\n" +
+	"\fActiveBuilds\x18\a \x01(\x03R\fActiveBuilds\x12<\n" +
+	"\x06Status\x18\b \x01(\v2$.moby.buildkit.v1.types.WorkerStatusR\x06Status\x12>\n" +
+	"\tEmulators\x18\t \x03(\v2 .moby.buildkit.v1.types.EmulatorR\tEmulators\x1a9\n" +
 	"\vLabelsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc8\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"H\n" +
+	"\bEmulator\x12(\n" +
+	"\bplatform\x18\x01 \x01(\v2\f.pb.PlatformR\bplatform\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\"\x94\x03\n" +
+	"\fWorkerStatus\x12\x1c\n" +
+	"\tdiskTotal\x18\x01 \x01(\x03R\tdiskTotal\x12\x1a\n" +
+	"\bdiskFree\x18\x02 \x01(\x03R\bdiskFree\x12$\n" +
+	"\rdiskAvailable\x18\x03 \x01(\x03R\rdiskAvailable\x12\"\n" +
+	"\frunningExecs\x18\x04 \x01(\x03R\frunningExecs\x12B\n" +
+	"\vcpuPressure\x18\x05 \x01(\v2 .moby.buildkit.v1.types.PressureR\vcpuPressure\x12H\n" +
+	"\x0ememoryPressure\x18\x06 \x01(\v2 .moby.buildkit.v1.types.PressureR\x0ememoryPressure\x12@\n" +
+	"\n" +
+	"ioPressure\x18\a \x01(\v2 .moby.buildkit.v1.types.PressureR\n" +
+	"ioPressure\x120\n" +
+	"\x02gc\x18\b \x01(\v2 .moby.buildkit.v1.types.GCStatusR\x02gc\"N\n" +
+	"\bPressure\x12\x14\n" +
+	"\x05avg10\x18\x01 \x01(\x01R\x05avg10\x12\x14\n" +
+	"\x05avg60\x18\x02 \x01(\x01R\x05avg60\x12\x16\n" +
+	"\x06avg300\x18\x03 \x01(\x01R\x06avg300\"\xe0\x01\n" +
+	"\bGCStatus\x12\x18\n" +
+	"\arunning\x18\x01 \x01(\bR\arunning\x12<\n" +
+	"\vlastStarted\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\vlastStarted\x12@\n" +
+	"\rlastCompleted\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\rlastCompleted\x12\x1c\n" +
+	"\tlastFreed\x18\x04 \x01(\x03R\tlastFreed\x12\x1c\n" +
+	"\tlastError\x18\x05 \x01(\tR\tlastError\"\xc8\x01\n" +
 	"\bGCPolicy\x12\x10\n" +
 	"\x03all\x18\x01 \x01(\bR\x03all\x12\"\n" +
 	"\fkeepDuration\x18\x02 \x01(\x03R\fkeepDuration\x12\x18\n" +
@@ -368,28 +720,42 @@ func file_github_com_moby_buildkit_api_types_worker_proto_rawDescGZIP() []byte {
 	return file_github_com_moby_buildkit_api_types_worker_proto_rawDescData
 }
 
-var file_github_com_moby_buildkit_api_types_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_github_com_moby_buildkit_api_types_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
 var file_github_com_moby_buildkit_api_types_worker_proto_goTypes = []any{
-	(*WorkerRecord)(nil),    // 0: moby.buildkit.v1.types.WorkerRecord
-	(*GCPolicy)(nil),        // 1: moby.buildkit.v1.types.GCPolicy
-	(*BuildkitVersion)(nil), // 2: moby.buildkit.v1.types.BuildkitVersion
-	(*CDIDevice)(nil),       // 3: moby.buildkit.v1.types.CDIDevice
-	nil,                     // 4: moby.buildkit.v1.types.WorkerRecord.LabelsEntry
-	nil,                     // 5: moby.buildkit.v1.types.CDIDevice.AnnotationsEntry
-	(*pb.Platform)(nil),     // 6: pb.Platform
+	(*WorkerRecord)(nil),        // 0: moby.buildkit.v1.types.WorkerRecord
+	(*Emulator)(nil),            // 1: moby.buildkit.v1.types.Emulator
+	(*WorkerStatus)(nil),        // 2: moby.buildkit.v1.types.WorkerStatus
+	(*Pressure)(nil),            // 3: moby.buildkit.v1.types.Pressure
+	(*GCStatus)(nil),            // 4: moby.buildkit.v1.types.GCStatus
+	(*GCPolicy)(nil),            // 5: moby.buildkit.v1.types.GCPolicy
+	(*BuildkitVersion)(nil),     // 6: moby.buildkit.v1.types.BuildkitVersion
+	(*CDIDevice)(nil),           // 7: moby.buildkit.v1.types.CDIDevice
+	nil,                         // 8: moby.buildkit.v1.types.WorkerRecord.LabelsEntry
+	nil,                         // 9: moby.buildkit.v1.types.CDIDevice.AnnotationsEntry
+	(*pb.Platform)(nil),         // 10: pb.Platform
+	(*timestamp.Timestamp)(nil), // 11: google.protobuf.Timestamp
 }
 var file_github_com_moby_buildkit_api_types_worker_proto_depIdxs = []int32{
-	4, // 0: moby.buildkit.v1.types.WorkerRecord.Labels:type_name -> moby.buildkit.v1.types.WorkerRecord.LabelsEntry
-	6, // 1: moby.buildkit.v1.types.WorkerRecord.platforms:type_name -> pb.Platform
-	1, // 2: moby.buildkit.v1.types.WorkerRecord.GCPolicy:type_name -> moby.buildkit.v1.types.GCPolicy
-	2, // 3: moby.buildkit.v1.types.WorkerRecord.BuildkitVersion:type_name -> moby.buildkit.v1.types.BuildkitVersion
-	3, // 4: moby.buildkit.v1.types.WorkerRecord.CDIDevices:type_name -> moby.buildkit.v1.types.CDIDevice
-	5, // 5: moby.buildkit.v1.types.CDIDevice.Annotations:type_name -> moby.buildkit.v1.types.CDIDevice.AnnotationsEntry
-	6, // [6:6] is the sub-list for method output_type
-	6, // [6:6] is the sub-list for method input_type
-	6, // [6:6] is the sub-list for extension type_name
-	6, // [6:6] is the sub-list for extension extendee
-	0, // [0:6] is the sub-list for field type_name
+	8,  // 0: moby.buildkit.v1.types.WorkerRecord.Labels:type_name -> moby.buildkit.v1.types.WorkerRecord.LabelsEntry
+	10, // 1: moby.buildkit.v1.types.WorkerRecord.platforms:type_name -> pb.Platform
+	5,  // 2: moby.buildkit.v1.types.WorkerRecord.GCPolicy:type_name -> moby.buildkit.v1.types.GCPolicy
+	6,  // 3: moby.buildkit.v1.types.WorkerRecord.BuildkitVersion:type_name -> moby.buildkit.v1.types.BuildkitVersion
+	7,  // 4: moby.buildkit.v1.types.WorkerRecord.CDIDevices:type_name -> moby.buildkit.v1.types.CDIDevice
+	2,  // 5: moby.buildkit.v1.types.WorkerRecord.Status:type_name -> moby.buildkit.v1.types.WorkerStatus
+	1,  // 6: moby.buildkit.v1.types.WorkerRecord.Emulators:type_name -> moby.buildkit.v1.types.Emulator
+	10, // 7: moby.buildkit.v1.types.Emulator.platform:type_name -> pb.Platform
+	3,  // 8: moby.buildkit.v1.types.WorkerStatus.cpuPressure:type_name -> moby.buildkit.v1.types.Pressure
+	3,  // 9: moby.buildkit.v1.types.WorkerStatus.memoryPressure:type_name -> moby.buildkit.v1.types.Pressure
+	3,  // 10: moby.buildkit.v1.types.WorkerStatus.ioPressure:type_name -> moby.buildkit.v1.types.Pressure
+	4,  // 11: moby.buildkit.v1.types.WorkerStatus.gc:type_name -> moby.buildkit.v1.types.GCStatus
+	11, // 12: moby.buildkit.v1.types.GCStatus.lastStarted:type_name -> google.protobuf.Timestamp
+	11, // 13: moby.buildkit.v1.types.GCStatus.lastCompleted:type_name -> google.protobuf.Timestamp
+	9,  // 14: moby.buildkit.v1.types.CDIDevice.Annotations:type_name -> moby.buildkit.v1.types.CDIDevice.AnnotationsEntry
+	15, // [15:15] is the sub-list for method output_type
+	15, // [15:15] is the sub-list for method input_type
+	15, // [15:15] is the sub-list for extension type_name
+	15, // [15:15] is the sub-list for extension extendee
+	0,  // [0:15] is the sub-list for field type_name
 }
 
 func init() { file_github_com_moby_buildkit_api_types_worker_proto_init() }
@@ -403,7 +769,7 @@ func file_github_com_moby_buildkit_api_types_worker_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_api_types_worker_proto_rawDesc), len(file_github_com_moby_buildkit_api_types_worker_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   10,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -114,6 +114,16 @@ func computeBlobChain(ctx context.Context, sr *immutableRef, createIfNeeded bool
 				}()
 
 				compressorFunc, finalize := comp.Type.Compress(ctx, comp)
+				if comp.Type != compression.Uncompressed {
+					release, err := compression.Acquire(ctx)
+					if err != nil {
+						return nil, err
+					}
+					defer release()
+					var done func()
+					compressorFunc, done = compression.WithProgress(ctx, "compressing layer "+sr.ID(), "compressing", compressorFunc)
+					defer done()
+				}
 
 				var lowerRef *immutableRef
 				switch sr.kind() {
@@ -245,6 +255,15 @@ func computeBlobChain(ctx context.Context, sr *immutableRef, createIfNeeded bool
 					}
 				}
 
+				if comp.Type == compression.Auto {
+					// the compression of the blob was chosen from its content
+					desc.MediaType, err = compression.DetectLayerMediaType(ctx, sr.cm.ContentStore, desc.Digest, true)
+					if err != nil {
+						return nil, err
+					}
+					mediaType = desc.MediaType
+				}
+
 				if desc.Annotations == nil {
 					desc.Annotations = map[string]string{}
 				}
@@ -449,7 +468,7 @@ func isTypeWindows(sr *immutableRef) bool {
 
 // ensureCompression ensures the specified ref has the blob of the specified compression Type.
 func ensureCompression(ctx context.Context, ref *immutableRef, comp compression.Config, s session.Group) error {
-	l, err := g.Do(ctx, fmt.Sprintf("ensureComp-%s-%s", ref.ID(), comp.Type), func(ctx context.Context) (_ *leaseutil.LeaseRef, err error) {
+	l, err := g.Do(ctx, fmt.Sprintf("ensureComp-%s-%s-%s-%t", ref.ID(), comp.Type, comp.Nydus, comp.CanonicalGzip), func(ctx context.Context) (_ *leaseutil.LeaseRef, err error) {
 		desc, err := ref.ociDesc(ctx, ref.descHandlers, true)
 		if err != nil {
 			return nil, err
@@ -483,7 +502,7 @@ func ensureCompression(ctx context.Context, ref *immutableRef, comp compression.
 		}
 
 		// First, lookup local content store
-		if _, err := ref.getBlobWithCompression(ctx, comp.Type); err == nil {
+		if _, err := ref.getBlobWithCompression(ctx, comp); err == nil {
 			return l, nil // found the compression variant. no need to convert.
 		}
 
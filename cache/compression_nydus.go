@@ -6,6 +6,7 @@ import (
 	"compress/gzip"
 	"context"
 	"io"
+	"slices"
 
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/pkg/labels"
@@ -30,14 +31,16 @@ func init() {
 // 1. Extracts nydus bootstrap from nydus format (nydus blob + nydus bootstrap) for each layer.
 // 2. Merge all nydus bootstraps into a final bootstrap (will as an extra layer).
 // The nydus bootstrap size is very small, so the merge operation is fast.
-func MergeNydus(ctx context.Context, ref ImmutableRef, comp compression.Config, s session.Group) (*ocispecs.Descriptor, error) {
+// It also returns the blobs of the chunk dict that the bootstrap references,
+// which need to be added to the image as layers too.
+func MergeNydus(ctx context.Context, ref ImmutableRef, comp compression.Config, s session.Group) (*ocispecs.Descriptor, []ocispecs.Descriptor, error) {
 	iref, ok := ref.(*immutableRef)
 	if !ok {
-		return nil, errors.Errorf("unsupported ref type %T", ref)
+		return nil, nil, errors.Errorf("unsupported ref type %T", ref)
 	}
 	refs := iref.layerChain()
 	if len(refs) == 0 {
-		return nil, errors.Errorf("refs can't be empty")
+		return nil, nil, errors.Errorf("refs can't be empty")
 	}
 
 	// Extracts nydus bootstrap from nydus format for each layer.
@@ -46,11 +49,11 @@ func MergeNydus(ctx context.Context, ref ImmutableRef, comp compression.Config,
 	for _, ref := range refs {
 		blobDesc, err := getBlobWithCompressionWithRetry(ctx, ref, comp, s)
 		if err != nil {
-			return nil, errors.Wrapf(err, "get compression blob %q", comp.Type)
+			return nil, nil, errors.Wrapf(err, "get compression blob %q", comp.Type)
 		}
 		ra, err := ref.cm.ContentStore.ReaderAt(ctx, blobDesc)
 		if err != nil {
-			return nil, errors.Wrapf(err, "get reader for compression blob %q", comp.Type)
+			return nil, nil, errors.Wrapf(err, "get reader for compression blob %q", comp.Type)
 		}
 		defer ra.Close()
 		if cm == nil {
@@ -64,19 +67,25 @@ func MergeNydus(ctx context.Context, ref ImmutableRef, comp compression.Config,
 
 	// Merge all nydus bootstraps into a final nydus bootstrap.
 	pr, pw := io.Pipe()
+	blobDigestsCh := make(chan []digest.Digest, 1)
 	go func() {
 		defer pw.Close()
-		if _, err := converter.Merge(ctx, layers, pw, converter.MergeOption{
-			WithTar: true,
-		}); err != nil {
+		packOpt := compression.NydusPackOption(comp)
+		blobDigests, err := converter.Merge(ctx, layers, pw, converter.MergeOption{
+			WithTar:       true,
+			FsVersion:     packOpt.FsVersion,
+			ChunkDictPath: packOpt.ChunkDictPath,
+		})
+		if err != nil {
 			pw.CloseWithError(errors.Wrapf(err, "merge nydus bootstrap"))
 		}
+		blobDigestsCh <- blobDigests
 	}()
 
 	// Compress final nydus bootstrap to tar.gz and write into content store.
 	cw, err := content.OpenWriter(ctx, cm.ContentStore, content.WithRef("nydus-merge-"+iref.getChainID().String()))
 	if err != nil {
-		return nil, errors.Wrap(err, "open content store writer")
+		return nil, nil, errors.Wrap(err, "open content store writer")
 	}
 	defer cw.Close()
 
@@ -84,10 +93,10 @@ func MergeNydus(ctx context.Context, ref ImmutableRef, comp compression.Config,
 	uncompressedDgst := digest.SHA256.Digester()
 	compressed := io.MultiWriter(gw, uncompressedDgst.Hash())
 	if _, err := io.Copy(compressed, pr); err != nil {
-		return nil, errors.Wrapf(err, "copy bootstrap targz into content store")
+		return nil, nil, errors.Wrapf(err, "copy bootstrap targz into content store")
 	}
 	if err := gw.Close(); err != nil {
-		return nil, errors.Wrap(err, "close gzip writer")
+		return nil, nil, errors.Wrap(err, "close gzip writer")
 	}
 
 	compressedDgst := cw.Digest()
@@ -95,16 +104,16 @@ func MergeNydus(ctx context.Context, ref ImmutableRef, comp compression.Config,
 		labels.LabelUncompressed: uncompressedDgst.Digest().String(),
 	})); err != nil {
 		if !cerrdefs.IsAlreadyExists(err) {
-			return nil, errors.Wrap(err, "commit to content store")
+			return nil, nil, errors.Wrap(err, "commit to content store")
 		}
 	}
 	if err := cw.Close(); err != nil {
-		return nil, errors.Wrap(err, "close content store writer")
+		return nil, nil, errors.Wrap(err, "close content store writer")
 	}
 
 	info, err := cm.ContentStore.Info(ctx, compressedDgst)
 	if err != nil {
-		return nil, errors.Wrap(err, "get info from content store")
+		return nil, nil, errors.Wrap(err, "get info from content store")
 	}
 
 	desc := ocispecs.Descriptor{
@@ -118,5 +127,27 @@ func MergeNydus(ctx context.Context, ref ImmutableRef, comp compression.Config,
 		},
 	}
 
-	return &desc, nil
+	// The bootstrap references the blobs of the layers and the blobs of the
+	// chunk dict that contain the deduplicated chunks.
+	var dictBlobs []ocispecs.Descriptor
+	for _, dgst := range <-blobDigestsCh {
+		if slices.ContainsFunc(layers, func(l converter.Layer) bool { return l.Digest == dgst }) {
+			continue
+		}
+		info, err := cm.ContentStore.Info(ctx, dgst)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "get info of chunk dict blob %s", dgst)
+		}
+		dictBlobs = append(dictBlobs, ocispecs.Descriptor{
+			Digest:    dgst,
+			Size:      info.Size,
+			MediaType: converter.MediaTypeNydusBlob,
+			Annotations: map[string]string{
+				labels.LabelUncompressed:           dgst.String(),
+				converter.LayerAnnotationNydusBlob: "true",
+			},
+		})
+	}
+
+	return &desc, dictBlobs, nil
 }
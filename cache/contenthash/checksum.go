@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"maps"
 	"os"
 	"path"
 	"path/filepath"
@@ -15,8 +16,10 @@ import (
 	iradix "github.com/hashicorp/go-immutable-radix/v2"
 	simplelru "github.com/hashicorp/golang-lru/v2/simplelru"
 	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/snapshot"
+	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/cachedigest"
 	"github.com/moby/locker"
 	"github.com/moby/patternmatcher"
@@ -148,10 +151,11 @@ func (cm *cacheManager) SetCacheContext(ctx context.Context, md cache.RefMetadat
 	}
 	if md.ID() != cc.md.ID() {
 		cc = &cacheContext{
-			md:       cacheMetadata{md},
-			tree:     cci.(*cacheContext).tree,
-			dirtyMap: map[string]struct{}{},
-			linkMap:  map[string][][]byte{},
+			md:        cacheMetadata{md},
+			tree:      cci.(*cacheContext).tree,
+			dirtyMap:  map[string]struct{}{},
+			linkMap:   map[string][][]byte{},
+			selectors: cc.cloneSelectors(),
 		}
 	} else {
 		if err := cc.save(); err != nil {
@@ -181,13 +185,24 @@ type cacheContext struct {
 	node     *iradix.Node[*CacheRecord]
 	dirtyMap map[string]struct{}
 	linkMap  map[string][][]byte
+
+	// checksums of wildcard and filtered selectors, and the number of
+	// changes used to detect concurrent updates
+	selectors map[string]*selectorEntry
+	changes   uint64
+
+	// syncID is the last sync included in the records
+	syncID string
 }
 
 type cacheMetadata struct {
 	cache.RefMetadata
 }
 
-const keyContentHash = "buildkit.contenthash.v0"
+const (
+	keyContentHash     = "buildkit.contenthash.v0"
+	keyContentHashSync = "buildkit.contenthash.sync.v0"
+)
 
 func (md cacheMetadata) GetContentHash() ([]byte, error) {
 	return md.GetExternal(keyContentHash)
@@ -197,6 +212,20 @@ func (md cacheMetadata) SetContentHash(dt []byte) error {
 	return md.SetExternal(keyContentHash, dt)
 }
 
+// GetContentHashSync returns the ID of the last sync that started to modify
+// the data of the ref
+func (md cacheMetadata) GetContentHashSync() string {
+	dt, err := md.GetExternal(keyContentHashSync)
+	if err != nil {
+		return ""
+	}
+	return string(dt)
+}
+
+func (md cacheMetadata) SetContentHashSync(id string) error {
+	return md.SetExternal(keyContentHashSync, []byte(id))
+}
+
 type mount struct {
 	mountable cache.Mountable
 	mountPath string
@@ -237,10 +266,11 @@ func (m *mount) clean() error {
 
 func newCacheContext(md cache.RefMetadata) (*cacheContext, error) {
 	cc := &cacheContext{
-		md:       cacheMetadata{md},
-		tree:     iradix.New[*CacheRecord](),
-		dirtyMap: map[string]struct{}{},
-		linkMap:  map[string][][]byte{},
+		md:        cacheMetadata{md},
+		tree:      iradix.New[*CacheRecord](),
+		dirtyMap:  map[string]struct{}{},
+		linkMap:   map[string][][]byte{},
+		selectors: map[string]*selectorEntry{},
 	}
 	if err := cc.load(); err != nil {
 		return nil, err
@@ -259,11 +289,32 @@ func (cc *cacheContext) load() error {
 		return err
 	}
 
+	// The records are only valid if they were saved for the current snapshot
+	// after the last sync. A daemon that was stopped while a local source was
+	// transferred leaves records that don't match the data and need to be
+	// computed again. Records saved by older versions are not validated.
+	if l.SnapshotID != "" && l.SnapshotID != cc.md.GetSnapshotID() {
+		bklog.L.Debugf("discarding content hash of %s saved for snapshot %s", cc.md.ID(), l.SnapshotID)
+		return nil
+	}
+	if l.SyncID != cc.md.GetContentHashSync() {
+		bklog.L.Debugf("discarding content hash of %s saved before an interrupted sync", cc.md.ID())
+		return nil
+	}
+	cc.syncID = l.SyncID
+
 	txn := cc.tree.Txn()
 	for _, p := range l.Paths {
 		txn.Insert([]byte(p.Path), p.Record)
 	}
 	cc.tree = txn.Commit()
+	for _, rec := range l.Selectors {
+		e, err := newSelectorEntry(rec)
+		if err != nil {
+			continue
+		}
+		cc.selectors[rec.Key] = e
+	}
 	return nil
 }
 
@@ -275,7 +326,10 @@ func (cc *cacheContext) save() error {
 		cc.commitActiveTransaction()
 	}
 
-	var l CacheRecords
+	l := CacheRecords{
+		SnapshotID: cc.md.GetSnapshotID(),
+		SyncID:     cc.syncID,
+	}
 	node := cc.tree.Root()
 	node.Walk(func(k []byte, v *CacheRecord) bool {
 		l.Paths = append(l.Paths, &CacheRecordWithPath{
@@ -284,6 +338,9 @@ func (cc *cacheContext) save() error {
 		})
 		return false
 	})
+	for _, k := range slices.Sorted(maps.Keys(cc.selectors)) {
+		l.Selectors = append(l.Selectors, cc.selectors[k].SelectorRecord)
+	}
 
 	dt, err := l.MarshalVT()
 	if err != nil {
@@ -318,6 +375,13 @@ func (cc *cacheContext) HandleChange(kind fsutil.ChangeKind, p string, fi os.Fil
 	cc.mu.Lock()
 	defer cc.mu.Unlock()
 	if cc.txn == nil {
+		// mark the persisted records as stale until the changes are saved
+		id := identity.NewID()
+		if err := cc.md.SetContentHashSync(id); err != nil {
+			return err
+		}
+		cc.syncID = id
+
 		cc.txn = cc.tree.Txn()
 		cc.node = cc.tree.Root()
 
@@ -343,6 +407,7 @@ func (cc *cacheContext) HandleChange(kind fsutil.ChangeKind, p string, fi os.Fil
 			d = ""
 		}
 		cc.dirtyMap[d] = struct{}{}
+		cc.invalidateSelectors(p, true)
 		return
 	}
 
@@ -399,6 +464,7 @@ func (cc *cacheContext) HandleChange(kind fsutil.ChangeKind, p string, fi os.Fil
 			for _, l := range links {
 				pp := convertKeyToPath(l)
 				cc.txn.Insert(l, cr)
+				cc.invalidateSelectors(pp, false)
 				d := path.Dir(pp)
 				if d == "/" {
 					d = ""
@@ -414,6 +480,7 @@ func (cc *cacheContext) HandleChange(kind fsutil.ChangeKind, p string, fi os.Fil
 		d = ""
 	}
 	cc.dirtyMap[d] = struct{}{}
+	cc.invalidateSelectors(strings.TrimSuffix(p, "/"), fi.IsDir() || cr.Type == CacheRecordTypeSymlink)
 
 	return nil
 }
@@ -426,11 +493,20 @@ func (cc *cacheContext) Checksum(ctx context.Context, mountable cache.Mountable,
 		return cc.lazyChecksum(ctx, m, p, opts.FollowLinks)
 	}
 
+	key := selectorKey(p, opts)
+	dgst, changes, ok := cc.selectorChecksum(key)
+	if ok {
+		return dgst, nil
+	}
+
 	includedPaths, err := cc.includedPaths(ctx, m, p, opts)
 	if err != nil {
 		return "", err
 	}
 
+	// Links can point outside of the paths matched by the selector so the
+	// checksum is only recorded if none of them were followed.
+	followed := false
 	if opts.FollowLinks {
 		for i, w := range includedPaths {
 			if w.record.Type == CacheRecordTypeSymlink {
@@ -439,15 +515,25 @@ func (cc *cacheContext) Checksum(ctx context.Context, mountable cache.Mountable,
 					return "", err
 				}
 				includedPaths[i].record = &CacheRecord{Digest: string(dgst)}
+				followed = true
 			}
 		}
 	}
+
+	dgst = includedPathsChecksum(p, includedPaths)
+	if !followed {
+		cc.addSelector(p, opts, dgst, changes)
+	}
+	return dgst, nil
+}
+
+func includedPathsChecksum(p string, includedPaths []*includedPath) digest.Digest {
 	if len(includedPaths) == 0 {
-		return digest.FromBytes([]byte{}), nil
+		return digest.FromBytes([]byte{})
 	}
 
 	if len(includedPaths) == 1 && path.Base(p) == path.Base(includedPaths[0].path) {
-		return digest.Digest(includedPaths[0].record.Digest), nil
+		return digest.Digest(includedPaths[0].record.Digest)
 	}
 
 	h := cachedigest.NewHash(cachedigest.TypeFileList)
@@ -458,7 +544,7 @@ func (cc *cacheContext) Checksum(ctx context.Context, mountable cache.Mountable,
 		h.Write([]byte(path.Base(w.path)))
 		h.Write([]byte(w.record.Digest))
 	}
-	return h.Sum(), nil
+	return h.Sum()
 }
 
 func (cc *cacheContext) includedPaths(ctx context.Context, m *mount, p string, opts ChecksumOpts) ([]*includedPath, error) {
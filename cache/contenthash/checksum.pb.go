@@ -186,8 +186,13 @@ func (x *CacheRecordWithPath) GetRecord() *CacheRecord {
 }
 
 type CacheRecords struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Paths         []*CacheRecordWithPath `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Paths     []*CacheRecordWithPath `protobuf:"bytes,1,rep,name=paths,proto3" json:"paths,omitempty"`
+	Selectors []*SelectorRecord      `protobuf:"bytes,2,rep,name=selectors,proto3" json:"selectors,omitempty"`
+	// snapshotID is the snapshot the records were computed for
+	SnapshotID string `protobuf:"bytes,3,opt,name=snapshotID,proto3" json:"snapshotID,omitempty"`
+	// syncID is the last sync of the snapshot that the records include
+	SyncID        string `protobuf:"bytes,4,opt,name=syncID,proto3" json:"syncID,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -229,6 +234,106 @@ func (x *CacheRecords) GetPaths() []*CacheRecordWithPath {
 	return nil
 }
 
+func (x *CacheRecords) GetSelectors() []*SelectorRecord {
+	if x != nil {
+		return x.Selectors
+	}
+	return nil
+}
+
+func (x *CacheRecords) GetSnapshotID() string {
+	if x != nil {
+		return x.SnapshotID
+	}
+	return ""
+}
+
+func (x *CacheRecords) GetSyncID() string {
+	if x != nil {
+		return x.SyncID
+	}
+	return ""
+}
+
+// SelectorRecord is the checksum of the files matched by a wildcard or
+// filtered selector. It stays valid until a path in its prefix that can match
+// the selector is changed.
+type SelectorRecord struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Key             string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Digest          string                 `protobuf:"bytes,2,opt,name=digest,proto3" json:"digest,omitempty"`
+	Prefix          string                 `protobuf:"bytes,3,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	Pattern         string                 `protobuf:"bytes,4,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	IncludePatterns []string               `protobuf:"bytes,5,rep,name=includePatterns,proto3" json:"includePatterns,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *SelectorRecord) Reset() {
+	*x = SelectorRecord{}
+	mi := &file_github_com_moby_buildkit_cache_contenthash_checksum_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SelectorRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelectorRecord) ProtoMessage() {}
+
+func (x *SelectorRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_cache_contenthash_checksum_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelectorRecord.ProtoReflect.Descriptor instead.
+func (*SelectorRecord) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_cache_contenthash_checksum_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SelectorRecord) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SelectorRecord) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+func (x *SelectorRecord) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *SelectorRecord) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *SelectorRecord) GetIncludePatterns() []string {
+	if x != nil {
+		return x.IncludePatterns
+	}
+	return nil
+}
+
 var File_github_com_moby_buildkit_cache_contenthash_checksum_proto protoreflect.FileDescriptor
 
 const file_github_com_moby_buildkit_cache_contenthash_checksum_proto_rawDesc = "" +
@@ -240,9 +345,20 @@ const file_github_com_moby_buildkit_cache_contenthash_checksum_proto_rawDesc = "
 	"\blinkname\x18\x03 \x01(\tR\blinkname\"[\n" +
 	"\x13CacheRecordWithPath\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x120\n" +
-	"\x06record\x18\x02 \x01(\v2\x18.contenthash.CacheRecordR\x06record\"F\n" +
+	"\x06record\x18\x02 \x01(\v2\x18.contenthash.CacheRecordR\x06record\"\xb9\x01\n" +
 	"\fCacheRecords\x126\n" +
-	"\x05paths\x18\x01 \x03(\v2 .contenthash.CacheRecordWithPathR\x05paths*A\n" +
+	"\x05paths\x18\x01 \x03(\v2 .contenthash.CacheRecordWithPathR\x05paths\x129\n" +
+	"\tselectors\x18\x02 \x03(\v2\x1b.contenthash.SelectorRecordR\tselectors\x12\x1e\n" +
+	"\n" +
+	"snapshotID\x18\x03 \x01(\tR\n" +
+	"snapshotID\x12\x16\n" +
+	"\x06syncID\x18\x04 \x01(\tR\x06syncID\"\x96\x01\n" +
+	"\x0eSelectorRecord\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06digest\x18\x02 \x01(\tR\x06digest\x12\x16\n" +
+	"\x06prefix\x18\x03 \x01(\tR\x06prefix\x12\x18\n" +
+	"\apattern\x18\x04 \x01(\tR\apattern\x12(\n" +
+	"\x0fincludePatterns\x18\x05 \x03(\tR\x0fincludePatterns*A\n" +
 	"\x0fCacheRecordType\x12\b\n" +
 	"\x04FILE\x10\x00\x12\a\n" +
 	"\x03DIR\x10\x01\x12\x0e\n" +
@@ -263,22 +379,24 @@ func file_github_com_moby_buildkit_cache_contenthash_checksum_proto_rawDescGZIP(
 }
 
 var file_github_com_moby_buildkit_cache_contenthash_checksum_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_github_com_moby_buildkit_cache_contenthash_checksum_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_github_com_moby_buildkit_cache_contenthash_checksum_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_github_com_moby_buildkit_cache_contenthash_checksum_proto_goTypes = []any{
 	(CacheRecordType)(0),        // 0: contenthash.CacheRecordType
 	(*CacheRecord)(nil),         // 1: contenthash.CacheRecord
 	(*CacheRecordWithPath)(nil), // 2: contenthash.CacheRecordWithPath
 	(*CacheRecords)(nil),        // 3: contenthash.CacheRecords
+	(*SelectorRecord)(nil),      // 4: contenthash.SelectorRecord
 }
 var file_github_com_moby_buildkit_cache_contenthash_checksum_proto_depIdxs = []int32{
 	0, // 0: contenthash.CacheRecord.type:type_name -> contenthash.CacheRecordType
 	1, // 1: contenthash.CacheRecordWithPath.record:type_name -> contenthash.CacheRecord
 	2, // 2: contenthash.CacheRecords.paths:type_name -> contenthash.CacheRecordWithPath
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	4, // 3: contenthash.CacheRecords.selectors:type_name -> contenthash.SelectorRecord
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_github_com_moby_buildkit_cache_contenthash_checksum_proto_init() }
@@ -292,7 +410,7 @@ func file_github_com_moby_buildkit_cache_contenthash_checksum_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_cache_contenthash_checksum_proto_rawDesc), len(file_github_com_moby_buildkit_cache_contenthash_checksum_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   3,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -452,6 +452,82 @@ func TestChecksumWildcardOrFilter(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestChecksumSelectorIndex(t *testing.T) {
+	t.Parallel()
+	tmpdir := t.TempDir()
+
+	snapshotter, err := native.NewSnapshotter(filepath.Join(tmpdir, "snapshots"))
+	require.NoError(t, err)
+	cm, cleanup := setupCacheManager(t, tmpdir, "native", snapshotter)
+	t.Cleanup(cleanup)
+
+	ch := []string{
+		"ADD README file data0",
+		"ADD src dir",
+		"ADD src/a.go file data1",
+		"ADD src/b.go file data2",
+		"ADD src/b.txt file data3",
+	}
+
+	ref := createRef(t, cm, nil)
+
+	cc, err := newCacheContext(ref)
+	require.NoError(t, err)
+
+	err = emit(cc.HandleChange, changeStream(ch))
+	require.NoError(t, err)
+
+	wildcard := ChecksumOpts{Wildcard: true}
+	include := ChecksumOpts{IncludePatterns: []string{"*.go"}}
+
+	dgstWildcard, err := cc.Checksum(context.TODO(), ref, "src/*.go", wildcard, nil)
+	require.NoError(t, err)
+	dgstInclude, err := cc.Checksum(context.TODO(), ref, "src", include, nil)
+	require.NoError(t, err)
+	require.Len(t, cc.selectors, 2)
+
+	// changes to files that are not matched keep the checksums
+	err = emit(cc.HandleChange, changeStream([]string{
+		"CHG README file data1",
+		"CHG src/b.txt file data0",
+	}))
+	require.NoError(t, err)
+	require.Len(t, cc.selectors, 2)
+
+	dgst, err := cc.Checksum(context.TODO(), ref, "src/*.go", wildcard, nil)
+	require.NoError(t, err)
+	require.Equal(t, dgstWildcard, dgst)
+
+	require.NoError(t, cc.save())
+	cc, err = newCacheContext(ref)
+	require.NoError(t, err)
+	require.Len(t, cc.selectors, 2)
+
+	// a matched file invalidates both selectors
+	err = emit(cc.HandleChange, changeStream([]string{
+		"CHG src/a.go file data0",
+	}))
+	require.NoError(t, err)
+	require.Empty(t, cc.selectors)
+
+	dgst, err = cc.Checksum(context.TODO(), ref, "src/*.go", wildcard, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, dgstWildcard, dgst)
+	dgst, err = cc.Checksum(context.TODO(), ref, "src", include, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, dgstInclude, dgst)
+
+	// new matching files invalidate the selectors
+	err = emit(cc.HandleChange, changeStream([]string{
+		"ADD src/c.go file data0",
+	}))
+	require.NoError(t, err)
+	require.Empty(t, cc.selectors)
+
+	err = ref.Release(context.TODO())
+	require.NoError(t, err)
+}
+
 func TestChecksumWildcardWithBadMountable(t *testing.T) {
 	t.Parallel()
 	tmpdir := t.TempDir()
@@ -1400,6 +1476,47 @@ func TestPersistence(t *testing.T) {
 	require.Equal(t, dgstFileData0, dgst)
 }
 
+func TestPersistenceInterruptedSync(t *testing.T) {
+	t.Parallel()
+	tmpdir := t.TempDir()
+
+	snapshotter, err := native.NewSnapshotter(filepath.Join(tmpdir, "snapshots"))
+	require.NoError(t, err)
+	cm, cleanup := setupCacheManager(t, tmpdir, "native", snapshotter)
+	t.Cleanup(cleanup)
+
+	ref := createRef(t, cm, nil)
+
+	cc, err := newCacheContext(ref)
+	require.NoError(t, err)
+
+	err = emit(cc.HandleChange, changeStream([]string{
+		"ADD foo file data0",
+	}))
+	require.NoError(t, err)
+	require.NoError(t, cc.save())
+
+	cc, err = newCacheContext(ref)
+	require.NoError(t, err)
+	dgst, err := cc.Checksum(context.TODO(), ref, "foo", ChecksumOpts{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, dgstFileData0, dgst)
+
+	// changes that were never saved invalidate the persisted records
+	err = emit(cc.HandleChange, changeStream([]string{
+		"CHG foo file data1",
+	}))
+	require.NoError(t, err)
+
+	cc, err = newCacheContext(ref)
+	require.NoError(t, err)
+	_, ok := cc.tree.Root().Get(convertPathToKey("/foo"))
+	require.False(t, ok)
+
+	err = ref.Release(context.TODO())
+	require.NoError(t, err)
+}
+
 func TestChecksumUpdateDirectory(t *testing.T) {
 	t.Parallel()
 	tmpdir := t.TempDir()
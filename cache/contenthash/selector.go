@@ -0,0 +1,152 @@
+package contenthash
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"path"
+	"strings"
+
+	iradix "github.com/hashicorp/go-immutable-radix/v2"
+	"github.com/moby/patternmatcher"
+	digest "github.com/opencontainers/go-digest"
+)
+
+// maxSelectorRecords is the maximum number of selector checksums kept for a
+// single cache context
+const maxSelectorRecords = 1000
+
+// selectorEntry is a checksum of a wildcard or filtered selector. It saves
+// walking the whole tree of a large context when only the files matched by
+// the selector are hashed and none of them have changed.
+type selectorEntry struct {
+	*SelectorRecord
+	includePatternMatcher *patternmatcher.PatternMatcher
+}
+
+func newSelectorEntry(rec *SelectorRecord) (*selectorEntry, error) {
+	e := &selectorEntry{SelectorRecord: rec}
+	if len(rec.IncludePatterns) != 0 {
+		pm, err := patternmatcher.New(rec.IncludePatterns)
+		if err != nil {
+			return nil, err
+		}
+		e.includePatternMatcher = pm
+	}
+	return e, nil
+}
+
+func selectorKey(p string, opts ChecksumOpts) string {
+	dt, _ := json.Marshal(struct {
+		Path string
+		Opts ChecksumOpts
+	}{p, opts})
+	return digest.FromBytes(dt).String()
+}
+
+// newSelectorRecord returns the record for a selector or false if its
+// checksum can't be reused. Selectors with a prefix that resolves through
+// symlinks are not recorded as changes to the links would not be detected.
+func newSelectorRecord(root *iradix.Node[*CacheRecord], p string, opts ChecksumOpts, dgst digest.Digest) (*SelectorRecord, bool) {
+	prefix, pattern := keyPath(p), ""
+	if opts.Wildcard {
+		d1, d2 := splitWildcards(prefix)
+		prefix, pattern = keyPath(d1), d2
+	}
+	k := convertPathToKey(prefix)
+	rk, _, err := getFollowLinks(root, k, true)
+	if err != nil || !bytes.Equal(rk, k) {
+		return nil, false
+	}
+	return &SelectorRecord{
+		Key:             selectorKey(p, opts),
+		Digest:          string(dgst),
+		Prefix:          prefix,
+		Pattern:         pattern,
+		IncludePatterns: opts.IncludePatterns,
+	}, true
+}
+
+// affectedBy returns true if a change to p can modify the set or the content
+// of the files matched by the selector. dir is true for directories, symlinks
+// and deleted paths, that can contain matching paths.
+func (e *selectorEntry) affectedBy(p string, dir bool) bool {
+	if e.Prefix != "" && p != e.Prefix && !strings.HasPrefix(p, e.Prefix+"/") {
+		// parents of the prefix can replace it
+		return p == "" || strings.HasPrefix(e.Prefix, p+"/")
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(p, e.Prefix), "/")
+	if rel == "" {
+		return true
+	}
+	if e.Pattern != "" {
+		n := strings.Count(e.Pattern, "/") + 1
+		parts := strings.Split(rel, "/")
+		if len(parts) < n {
+			return true
+		}
+		ok, err := path.Match(e.Pattern, strings.Join(parts[:n], "/"))
+		if err != nil {
+			return true
+		}
+		if !ok {
+			return false
+		}
+		rel = strings.Join(parts[n:], "/")
+	}
+	if rel == "" || dir || e.includePatternMatcher == nil {
+		return true
+	}
+	m, err := e.includePatternMatcher.MatchesOrParentMatches(rel)
+	return err != nil || m
+}
+
+func (cc *cacheContext) selectorChecksum(key string) (digest.Digest, uint64, bool) {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	if e, ok := cc.selectors[key]; ok {
+		return digest.Digest(e.Digest), cc.changes, true
+	}
+	return "", cc.changes, false
+}
+
+// addSelector records the checksum of a selector unless the context has
+// changed since changes was read
+func (cc *cacheContext) addSelector(p string, opts ChecksumOpts, dgst digest.Digest, changes uint64) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.changes != changes || cc.txn != nil {
+		return
+	}
+	rec, ok := newSelectorRecord(cc.tree.Root(), p, opts, dgst)
+	if !ok {
+		return
+	}
+	e, err := newSelectorEntry(rec)
+	if err != nil {
+		return
+	}
+	if len(cc.selectors) >= maxSelectorRecords {
+		for k := range cc.selectors {
+			delete(cc.selectors, k)
+			break
+		}
+	}
+	cc.selectors[rec.Key] = e
+	go cc.save()
+}
+
+func (cc *cacheContext) invalidateSelectors(p string, dir bool) {
+	cc.changes++
+	for k, e := range cc.selectors {
+		if e.affectedBy(p, dir) {
+			delete(cc.selectors, k)
+		}
+	}
+}
+
+func (cc *cacheContext) cloneSelectors() map[string]*selectorEntry {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	return maps.Clone(cc.selectors)
+}
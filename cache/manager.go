@@ -34,6 +34,7 @@ import (
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
 )
 
 var (
@@ -55,6 +56,10 @@ type ManagerOpt struct {
 	MetadataStore   *metadata.Store
 	Root            string
 	MountPoolRoot   string
+	// UnpackConcurrency is the maximum number of layers decompressed at the
+	// same time while the layers below them are still being extracted. Zero
+	// extracts the layers of an image one by one.
+	UnpackConcurrency int
 }
 
 type Accessor interface {
@@ -73,6 +78,7 @@ type Accessor interface {
 type Controller interface {
 	DiskUsage(ctx context.Context, info client.DiskUsageInfo) ([]*client.UsageInfo, error)
 	Prune(ctx context.Context, ch chan client.UsageInfo, info ...client.PruneInfo) error
+	Verify(ctx context.Context, repair bool) ([]client.CacheIssue, error)
 }
 
 type Manager interface {
@@ -105,6 +111,9 @@ type cacheManager struct {
 
 	muPrune sync.Mutex // make sure parallel prune is not allowed so there will not be inconsistent results
 	unlazyG flightcontrol.Group[struct{}]
+
+	// unpackSem limits the layers decompressed ahead of their extraction
+	unpackSem *semaphore.Weighted
 }
 
 func NewManager(opt ManagerOpt) (Manager, error) {
@@ -120,6 +129,12 @@ func NewManager(opt ManagerOpt) (Manager, error) {
 		root:            opt.Root,
 		records:         make(map[string]*cacheRecord),
 	}
+	if opt.UnpackConcurrency < 0 {
+		return nil, errors.Errorf("invalid negative unpack concurrency %d", opt.UnpackConcurrency)
+	}
+	if opt.UnpackConcurrency > 0 {
+		cm.unpackSem = semaphore.NewWeighted(int64(opt.UnpackConcurrency))
+	}
 
 	if err := cm.init(context.TODO()); err != nil {
 		return nil, err
@@ -286,7 +301,7 @@ func (cm *cacheManager) GetByBlob(ctx context.Context, desc ocispecs.Descriptor,
 		cacheMetadata: md,
 	}
 
-	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs, opts...); err != nil {
+	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs, withContextClient(ctx, opts)...); err != nil {
 		return nil, err
 	}
 
@@ -656,7 +671,7 @@ func (cm *cacheManager) New(ctx context.Context, s ImmutableRef, sess session.Gr
 	}
 
 	opts = append(opts, withSnapshotID(snapshotID))
-	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs, opts...); err != nil {
+	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs, withContextClient(ctx, opts)...); err != nil {
 		return nil, err
 	}
 
@@ -794,7 +809,7 @@ func (cm *cacheManager) createMergeRef(ctx context.Context, parents parentRefs,
 		refs:          make(map[ref]struct{}),
 	}
 
-	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs, opts...); err != nil {
+	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs, withContextClient(ctx, opts)...); err != nil {
 		return nil, err
 	}
 
@@ -997,7 +1012,7 @@ func (cm *cacheManager) createDiffRef(ctx context.Context, parents parentRefs, d
 		refs:          make(map[ref]struct{}),
 	}
 
-	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs, opts...); err != nil {
+	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs, withContextClient(ctx, opts)...); err != nil {
 		return nil, err
 	}
 
@@ -1167,6 +1182,7 @@ func (cm *cacheManager) pruneOnce(ctx context.Context, ch chan client.UsageInfo,
 				ID:          cr.ID(),
 				Mutable:     cr.mutable,
 				RecordType:  recordType,
+				Client:      cr.GetClient(),
 				Shared:      shared,
 				Description: cr.GetDescription(),
 			}
@@ -1368,6 +1384,7 @@ type cacheUsageInfo struct {
 	description string
 	doubleRef   bool
 	recordType  client.UsageRecordType
+	client      string
 	shared      bool
 	parentChain []digest.Digest
 }
@@ -1402,6 +1419,7 @@ func (cm *cacheManager) DiskUsage(ctx context.Context, opt client.DiskUsageInfo)
 			description: cr.GetDescription(),
 			doubleRef:   cr.equalImmutable != nil,
 			recordType:  cr.GetRecordType(),
+			client:      cr.GetClient(),
 			parentChain: cr.layerDigestChain(),
 		}
 		if c.recordType == "" {
@@ -1467,6 +1485,7 @@ func (cm *cacheManager) DiskUsage(ctx context.Context, opt client.DiskUsageInfo)
 			LastUsedAt:  cr.lastUsedAt,
 			UsageCount:  cr.usageCount,
 			RecordType:  cr.recordType,
+			Client:      cr.client,
 			Shared:      cr.shared,
 		}
 		if !filter.Match(adaptUsageInfo(c)) {
@@ -1480,6 +1499,24 @@ func (cm *cacheManager) DiskUsage(ctx context.Context, opt client.DiskUsageInfo)
 		du = append(du, c)
 	}
 
+	if opt.PageSize > 0 || opt.After != "" {
+		slices.SortFunc(du, func(a, b *client.UsageInfo) int {
+			return strings.Compare(a.ID, b.ID)
+		})
+		if opt.After != "" {
+			i, _ := slices.BinarySearchFunc(du, opt.After, func(u *client.UsageInfo, id string) int {
+				return strings.Compare(u.ID, id)
+			})
+			if i < len(du) && du[i].ID == opt.After {
+				i++
+			}
+			du = du[i:]
+		}
+		if opt.PageSize > 0 && len(du) > opt.PageSize {
+			du = du[:opt.PageSize]
+		}
+	}
+
 	eg, ctx := errgroup.WithContext(ctx)
 
 	for _, d := range du {
@@ -1549,6 +1586,26 @@ func WithRecordType(t client.UsageRecordType) RefOption {
 	}
 }
 
+type clientKey struct{}
+
+// WithClient returns a context that attributes the refs created with it to
+// the named client identity
+func WithClient(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, clientKey{}, name)
+}
+
+// withContextClient adds the client identity of ctx to the options of a new
+// record
+func withContextClient(ctx context.Context, opts []RefOption) []RefOption {
+	name, _ := ctx.Value(clientKey{}).(string)
+	if name == "" {
+		return opts
+	}
+	return append(slices.Clip(opts), func(m *cacheMetadata) error {
+		return m.queueClient(name)
+	})
+}
+
 func WithCreationTime(tm time.Time) RefOption {
 	return func(m *cacheMetadata) error {
 		return m.queueCreatedAt(tm)
@@ -1652,6 +1709,8 @@ func adaptUsageInfo(info *client.UsageInfo) filters.Adaptor {
 			return "", !info.Mutable
 		case "type":
 			return string(info.RecordType), info.RecordType != ""
+		case "client":
+			return info.Client, info.Client != ""
 		case "shared":
 			return "", info.Shared
 		case "private":
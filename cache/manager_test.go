@@ -12,6 +12,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -62,12 +63,15 @@ type cmOpt struct {
 	snapshotterName string
 	snapshotter     snapshots.Snapshotter
 	tmpdir          string
+
+	unpackConcurrency int
 }
 
 type cmOut struct {
-	manager Manager
-	lm      leases.Manager
-	cs      content.Store
+	manager     Manager
+	lm          leases.Manager
+	cs          content.Store
+	snapshotter snapshots.Snapshotter
 }
 
 func newCacheManager(ctx context.Context, t *testing.T, opt cmOpt) (co *cmOut, cleanup func(), err error) {
@@ -154,6 +158,8 @@ func newCacheManager(ctx context.Context, t *testing.T, opt cmOpt) (co *cmOut, c
 		Differ:         differ,
 		Root:           tmpdir,
 		MountPoolRoot:  filepath.Join(tmpdir, "cachemounts"),
+
+		UnpackConcurrency: opt.unpackConcurrency,
 	})
 	if err != nil {
 		return nil, nil, err
@@ -163,9 +169,10 @@ func newCacheManager(ctx context.Context, t *testing.T, opt cmOpt) (co *cmOut, c
 	})
 
 	return &cmOut{
-		manager: cm,
-		lm:      lm,
-		cs:      store,
+		manager:     cm,
+		lm:          lm,
+		cs:          store,
+		snapshotter: mdb.Snapshotter(opt.snapshotterName),
 	}, cleanup, nil
 }
 
@@ -857,6 +864,92 @@ func TestSetBlob(t *testing.T) {
 	// snap.SetBlob()
 }
 
+func TestVerify(t *testing.T) {
+	t.Parallel()
+	ctx := namespaces.WithNamespace(context.Background(), "buildkit-test")
+
+	tmpdir := t.TempDir()
+
+	snapshotter, err := native.NewSnapshotter(filepath.Join(tmpdir, "snapshots"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, snapshotter.Close())
+	})
+
+	co, cleanup, err := newCacheManager(ctx, t, cmOpt{
+		snapshotter:     snapshotter,
+		snapshotterName: "native",
+	})
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+
+	cm := co.manager
+
+	active, err := cm.New(ctx, nil, nil, CachePolicyRetain)
+	require.NoError(t, err)
+	id := active.ID()
+	sid := active.(*mutableRef).getSnapshotID()
+	require.NoError(t, active.Release(ctx))
+
+	active, err = cm.New(ctx, nil, nil, CachePolicyRetain)
+	require.NoError(t, err)
+	require.NoError(t, active.Release(ctx))
+
+	issues, err := cm.Verify(ctx, false)
+	require.NoError(t, err)
+	require.Empty(t, issues)
+
+	require.NoError(t, co.snapshotter.Remove(ctx, sid))
+
+	issues, err = cm.Verify(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	require.Equal(t, id, issues[0].ID)
+	require.Equal(t, client.CacheIssueMissingSnapshot, issues[0].Type)
+	require.False(t, issues[0].Repaired)
+	checkDiskUsage(ctx, t, cm, 0, 2)
+
+	issues, err = cm.Verify(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	require.True(t, issues[0].Repaired)
+	checkDiskUsage(ctx, t, cm, 0, 1)
+
+	_, err = cm.GetMutable(ctx, id)
+	require.Error(t, err)
+
+	issues, err = cm.Verify(ctx, true)
+	require.NoError(t, err)
+	require.Empty(t, issues)
+
+	// records with a removed blob are repaired by removing them
+	blobBytes, desc, err := mapToBlob(map[string]string{"foo": "1"}, false)
+	require.NoError(t, err)
+	err = content.WriteBlob(ctx, co.cs, "test-blob", bytes.NewReader(blobBytes), desc)
+	require.NoError(t, err)
+	ref, err := cm.GetByBlob(ctx, desc, nil)
+	require.NoError(t, err)
+	require.NoError(t, ref.Extract(ctx, nil))
+	blobID := ref.ID()
+	require.NoError(t, ref.Release(ctx))
+
+	issues, err = cm.Verify(ctx, false)
+	require.NoError(t, err)
+	require.Empty(t, issues)
+
+	require.NoError(t, co.cs.(*containerdsnapshot.Store).Store.Delete(ctx, desc.Digest))
+
+	issues, err = cm.Verify(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, issues, 1)
+	require.Equal(t, blobID, issues[0].ID)
+	require.Equal(t, client.CacheIssueMissingBlob, issues[0].Type)
+	require.True(t, issues[0].Repaired)
+
+	_, err = cm.Get(ctx, blobID, nil)
+	require.Error(t, err)
+}
+
 func TestPrune(t *testing.T) {
 	t.Parallel()
 	ctx := namespaces.WithNamespace(context.Background(), "buildkit-test")
@@ -1401,9 +1494,9 @@ func testSharingCompressionVariant(ctx context.Context, t *testing.T, co *cmOut,
 			}
 		}
 		for _, c := range allCompressions {
-			aDesc, err := aRef.(*immutableRef).getBlobWithCompression(ctx, c)
+			aDesc, err := aRef.(*immutableRef).getBlobWithCompression(ctx, compression.New(c))
 			require.NoError(t, err, "compression: %v", c)
-			bDesc, err := bRef.(*immutableRef).getBlobWithCompression(ctx, c)
+			bDesc, err := bRef.(*immutableRef).getBlobWithCompression(ctx, compression.New(c))
 			require.NoError(t, err, "compression: %v", c)
 			checkCompression(aDesc, c)
 			checkCompression(bDesc, c)
@@ -1415,14 +1508,14 @@ func testSharingCompressionVariant(ctx context.Context, t *testing.T, co *cmOut,
 			ensurePrune(ctx, t, cm, 1, 10)
 			checkDiskUsage(ctx, t, co.manager, 1, 0)
 			for _, c := range allCompressions {
-				_, err = bRef.(*immutableRef).getBlobWithCompression(ctx, c)
+				_, err = bRef.(*immutableRef).getBlobWithCompression(ctx, compression.New(c))
 				require.NoError(t, err)
 			}
 		}
 
 		// check if contents are valid
 		for _, c := range allCompressions {
-			bDesc, err := bRef.(*immutableRef).getBlobWithCompression(ctx, c)
+			bDesc, err := bRef.(*immutableRef).getBlobWithCompression(ctx, compression.New(c))
 			require.NoError(t, err, "compression: %v", c)
 			uDgst := bDesc.Digest
 			if c != compression.Uncompressed {
@@ -1784,7 +1877,7 @@ func TestGetRemotes(t *testing.T) {
 						if needs {
 							require.False(t, isLazy, "layer %q requires conversion so it must be unlazied", desc.Digest)
 						}
-						bDesc, err := r.getBlobWithCompression(egctx, compressionType)
+						bDesc, err := r.getBlobWithCompression(egctx, compression.New(compressionType))
 						if isLazy {
 							require.Error(t, err)
 						} else {
@@ -2843,3 +2936,218 @@ func isReadOnly(mnt mount.Mount) bool {
 	}
 	return false
 }
+
+func TestUnlazyFetchLimiter(t *testing.T) {
+	t.Parallel()
+	// windows fails when lazy blob is being extracted with "invalid windows mount type: 'bind'"
+	if runtime.GOOS != "linux" {
+		t.Skipf("unsupported GOOS: %s", runtime.GOOS)
+	}
+
+	ctx := namespaces.WithNamespace(context.Background(), "buildkit-test")
+
+	tmpdir := t.TempDir()
+
+	snapshotter, err := native.NewSnapshotter(filepath.Join(tmpdir, "snapshots"))
+	require.NoError(t, err)
+	co, cleanup, err := newCacheManager(ctx, t, cmOpt{
+		snapshotter:     snapshotter,
+		snapshotterName: "native",
+	})
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	cm := co.manager
+
+	blobBytes, desc, err := mapToBlob(map[string]string{"foo": "1"}, false)
+	require.NoError(t, err)
+	contentBuffer := contentutil.NewBuffer()
+	err = content.WriteBlob(ctx, contentBuffer, "test-blob", bytes.NewReader(blobBytes), desc)
+	require.NoError(t, err)
+	descHandlers := DescHandlers(map[digest.Digest]*DescHandler{
+		desc.Digest: {
+			Provider: func(_ session.Group) content.Provider { return contentBuffer },
+		},
+	})
+
+	ref, err := cm.GetByBlob(ctx, desc, nil, descHandlers)
+	require.NoError(t, err)
+	defer ref.Release(context.WithoutCancel(ctx))
+
+	// the download waits for the limiter
+	limitErr := errors.New("limited")
+	err = ref.Extract(WithFetchLimiter(ctx, func(context.Context) (func(), error) {
+		return nil, limitErr
+	}), nil)
+	require.ErrorIs(t, err, limitErr)
+
+	var acquired, released int
+	err = ref.Extract(WithFetchLimiter(ctx, func(context.Context) (func(), error) {
+		acquired++
+		return func() { released++ }, nil
+	}), nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, acquired)
+	require.Equal(t, 1, released)
+
+	_, err = co.cs.Info(ctx, desc.Digest)
+	require.NoError(t, err)
+}
+
+func TestDiskUsagePageSize(t *testing.T) {
+	t.Parallel()
+
+	ctx := namespaces.WithNamespace(context.Background(), "buildkit-test")
+
+	tmpdir := t.TempDir()
+
+	snapshotter, err := native.NewSnapshotter(filepath.Join(tmpdir, "snapshots"))
+	require.NoError(t, err)
+	co, cleanup, err := newCacheManager(ctx, t, cmOpt{
+		snapshotter:     snapshotter,
+		snapshotterName: "native",
+	})
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	cm := co.manager
+
+	for range 3 {
+		active, err := cm.New(ctx, nil, nil, CachePolicyRetain)
+		require.NoError(t, err)
+		snap, err := active.Commit(ctx)
+		require.NoError(t, err)
+		require.NoError(t, snap.Release(ctx))
+	}
+
+	all, err := cm.DiskUsage(ctx, client.DiskUsageInfo{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	var ids []string
+	for _, r := range all {
+		ids = append(ids, r.ID)
+	}
+	slices.Sort(ids)
+
+	du, err := cm.DiskUsage(ctx, client.DiskUsageInfo{PageSize: 2})
+	require.NoError(t, err)
+	require.Len(t, du, 2)
+	require.Equal(t, ids[0], du[0].ID)
+	require.Equal(t, ids[1], du[1].ID)
+
+	du, err = cm.DiskUsage(ctx, client.DiskUsageInfo{PageSize: 2, After: du[1].ID})
+	require.NoError(t, err)
+	require.Len(t, du, 1)
+	require.Equal(t, ids[2], du[0].ID)
+
+	du, err = cm.DiskUsage(ctx, client.DiskUsageInfo{PageSize: 2, After: ids[2]})
+	require.NoError(t, err)
+	require.Empty(t, du)
+}
+
+func TestDiskUsageClient(t *testing.T) {
+	t.Parallel()
+
+	ctx := namespaces.WithNamespace(context.Background(), "buildkit-test")
+
+	tmpdir := t.TempDir()
+
+	snapshotter, err := native.NewSnapshotter(filepath.Join(tmpdir, "snapshots"))
+	require.NoError(t, err)
+	co, cleanup, err := newCacheManager(ctx, t, cmOpt{
+		snapshotter:     snapshotter,
+		snapshotterName: "native",
+	})
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	cm := co.manager
+
+	for _, name := range []string{"team-a", "team-b", ""} {
+		active, err := cm.New(WithClient(ctx, name), nil, nil, CachePolicyRetain)
+		require.NoError(t, err)
+		snap, err := active.Commit(ctx)
+		require.NoError(t, err)
+		require.NoError(t, snap.Release(ctx))
+	}
+
+	all, err := cm.DiskUsage(ctx, client.DiskUsageInfo{})
+	require.NoError(t, err)
+	var clients []string
+	for _, r := range all {
+		clients = append(clients, r.Client)
+	}
+	require.ElementsMatch(t, []string{"team-a", "team-b", ""}, clients)
+
+	du, err := cm.DiskUsage(ctx, client.DiskUsageInfo{Filter: []string{"client==team-a"}})
+	require.NoError(t, err)
+	require.Len(t, du, 1)
+	require.Equal(t, "team-a", du[0].Client)
+}
+
+func TestUnlazyDecompressAhead(t *testing.T) {
+	t.Parallel()
+	// windows fails when lazy blob is being extracted with "invalid windows mount type: 'bind'"
+	if runtime.GOOS != "linux" {
+		t.Skipf("unsupported GOOS: %s", runtime.GOOS)
+	}
+
+	ctx := namespaces.WithNamespace(context.Background(), "buildkit-test")
+
+	tmpdir := t.TempDir()
+
+	snapshotter, err := native.NewSnapshotter(filepath.Join(tmpdir, "snapshots"))
+	require.NoError(t, err)
+	co, cleanup, err := newCacheManager(ctx, t, cmOpt{
+		snapshotter:       snapshotter,
+		snapshotterName:   "native",
+		unpackConcurrency: 1,
+	})
+	require.NoError(t, err)
+	t.Cleanup(cleanup)
+	cm := co.manager
+
+	b, desc, err := mapToBlob(map[string]string{"foo": "1"}, true)
+	require.NoError(t, err)
+	b2, desc2, err := mapToBlob(map[string]string{"bar": "2"}, true)
+	require.NoError(t, err)
+	contentBuffer := contentutil.NewBuffer()
+	require.NoError(t, content.WriteBlob(ctx, contentBuffer, "ref1", bytes.NewReader(b), desc))
+	require.NoError(t, content.WriteBlob(ctx, contentBuffer, "ref2", bytes.NewReader(b2), desc2))
+	descHandlers := DescHandlers(map[digest.Digest]*DescHandler{
+		desc.Digest: {
+			Provider: func(_ session.Group) content.Provider { return contentBuffer },
+		},
+		desc2.Digest: {
+			Provider: func(_ session.Group) content.Provider { return contentBuffer },
+		},
+	})
+
+	ref, err := cm.GetByBlob(ctx, desc, nil, descHandlers)
+	require.NoError(t, err)
+	defer ref.Release(context.WithoutCancel(ctx))
+	ref2, err := cm.GetByBlob(ctx, desc2, ref, descHandlers)
+	require.NoError(t, err)
+	defer ref2.Release(context.WithoutCancel(ctx))
+
+	require.True(t, ref2.(*immutableRef).canDecompressAhead())
+
+	err = ref2.Extract(ctx, nil)
+	require.NoError(t, err)
+	require.False(t, ref.(*immutableRef).getBlobOnly())
+	require.False(t, ref2.(*immutableRef).getBlobOnly())
+
+	// the child layer was extracted from its decompressed blob
+	diffID, err := diffIDFromDescriptor(desc2)
+	require.NoError(t, err)
+	_, err = co.cs.Info(ctx, diffID)
+	require.NoError(t, err)
+
+	// the lease of the decompressed blob is released after the extraction
+	ls, err := co.lm.List(ctx)
+	require.NoError(t, err)
+	for _, l := range ls {
+		res, err := co.lm.ListResources(ctx, l)
+		require.NoError(t, err)
+		for _, r := range res {
+			require.NotEqual(t, diffID.String(), r.ID, "lease %s", l.ID)
+		}
+	}
+}
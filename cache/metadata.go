@@ -22,6 +22,7 @@ const keyLastUsedAt = "cache.lastUsedAt"
 const keyUsageCount = "cache.usageCount"
 const keyLayerType = "cache.layerType"
 const keyRecordType = "cache.recordType"
+const keyClient = "cache.client"
 const keyCommitted = "snapshot.committed"
 const keyParent = "cache.parent"
 const keyMergeParents = "cache.mergeParents"
@@ -67,8 +68,14 @@ type RefMetadata interface {
 	GetRecordType() client.UsageRecordType
 	SetRecordType(client.UsageRecordType) error
 
+	// GetClient returns the name of the client identity that created the ref
+	GetClient() string
+
 	GetEqualMutable() (RefMetadata, bool)
 
+	// GetSnapshotID returns the ID of the snapshot holding the data of the ref
+	GetSnapshotID() string
+
 	// generic getters/setters for external packages
 	GetString(string) string
 	Get(string) *metadata.Value
@@ -180,6 +187,14 @@ func (md *cacheMetadata) queueRecordType(value client.UsageRecordType) error {
 	return md.queueValue(keyRecordType, value, "")
 }
 
+func (md *cacheMetadata) GetClient() string {
+	return md.GetString(keyClient)
+}
+
+func (md *cacheMetadata) queueClient(name string) error {
+	return md.queueValue(keyClient, name, "")
+}
+
 func (md *cacheMetadata) SetCreatedAt(tm time.Time) error {
 	return md.setTime(keyCreatedAt, tm, "")
 }
@@ -251,6 +266,10 @@ func (md *cacheMetadata) queueMediaType(str string) error {
 	return md.queueValue(keyMediaType, str, "")
 }
 
+func (md *cacheMetadata) GetSnapshotID() string {
+	return md.getSnapshotID()
+}
+
 func (md *cacheMetadata) getSnapshotID() string {
 	sid := md.GetString(keySnapshot)
 	// Note that historic buildkit releases did not always set the snapshot ID.
@@ -820,7 +820,7 @@ func (sr *immutableRef) linkBlob(ctx context.Context, desc ocispecs.Descriptor)
 	return err
 }
 
-func (sr *immutableRef) getBlobWithCompression(ctx context.Context, compressionType compression.Type) (ocispecs.Descriptor, error) {
+func (sr *immutableRef) getBlobWithCompression(ctx context.Context, comp compression.Config) (ocispecs.Descriptor, error) {
 	if _, err := sr.cm.ContentStore.Info(ctx, sr.getBlob()); err != nil {
 		return ocispecs.Descriptor{}, err
 	}
@@ -828,13 +828,13 @@ func (sr *immutableRef) getBlobWithCompression(ctx context.Context, compressionT
 	if err != nil {
 		return ocispecs.Descriptor{}, err
 	}
-	return getBlobWithCompression(ctx, sr.cm.ContentStore, desc, compressionType)
+	return getBlobWithCompression(ctx, sr.cm.ContentStore, desc, comp)
 }
 
-func getBlobWithCompression(ctx context.Context, cs content.Store, desc ocispecs.Descriptor, compressionType compression.Type) (ocispecs.Descriptor, error) {
+func getBlobWithCompression(ctx context.Context, cs content.Store, desc ocispecs.Descriptor, comp compression.Config) (ocispecs.Descriptor, error) {
 	var target *ocispecs.Descriptor
 	if err := walkBlob(ctx, cs, desc, func(desc ocispecs.Descriptor) bool {
-		if needs, err := compressionType.NeedsConversion(ctx, cs, desc); err == nil && !needs {
+		if needs, err := comp.Type.NeedsConversion(ctx, cs, desc); err == nil && !needs && comp.MatchesOptions(ctx, cs, desc) {
 			target = &desc
 			return false
 		}
@@ -1331,7 +1331,13 @@ func (sr *immutableRef) unlazyLayer(ctx context.Context, dhs DescHandlers, pg pr
 	eg, egctx := errgroup.WithContext(ctx)
 
 	parentID := ""
+	// the blob is decompressed while the parent is extracted
+	decompressAhead := false
 	if sr.layerParent != nil {
+		if sr.canDecompressAhead() {
+			_, err := sr.cm.Snapshotter.Stat(ctx, sr.layerParent.getSnapshotID())
+			decompressAhead = err != nil
+		}
 		eg.Go(func() error {
 			if err := sr.layerParent.unlazy(egctx, dhs, pg, s, false, ensureContentStore); err != nil {
 				return err
@@ -1347,14 +1353,29 @@ func (sr *immutableRef) unlazyLayer(ctx context.Context, dhs DescHandlers, pg pr
 	}
 	dh := dhs[desc.Digest]
 
+	applyDesc := desc
+	releaseApplyDesc := func() {}
+	defer func() {
+		releaseApplyDesc()
+	}()
 	eg.Go(func() error {
 		// unlazies if needed, otherwise a no-op
-		return lazyRefProvider{
+		if err := (lazyRefProvider{
 			ref:     sr,
 			desc:    desc,
 			dh:      dh,
 			session: s,
-		}.Unlazy(egctx)
+		}.Unlazy(egctx)); err != nil {
+			return err
+		}
+		if decompressAhead {
+			d, release, err := sr.decompress(egctx, desc)
+			if err != nil {
+				return err
+			}
+			applyDesc, releaseApplyDesc = d, release
+		}
+		return nil
 	})
 
 	if err := eg.Wait(); err != nil {
@@ -1396,7 +1417,7 @@ func (sr *immutableRef) unlazyLayer(ctx context.Context, dhs DescHandlers, pg pr
 	if err != nil {
 		return err
 	}
-	_, err = sr.cm.Applier.Apply(ctx, desc, mounts)
+	_, err = sr.cm.Applier.Apply(ctx, applyDesc, mounts)
 	if err != nil {
 		unmount()
 		return err
@@ -1554,6 +1575,11 @@ func (sr *mutableRef) commit() (_ *immutableRef, rerr error) {
 			return nil, err
 		}
 	}
+	if c := sr.GetClient(); c != "" {
+		if err := md.queueClient(c); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := initializeMetadata(rec.cacheMetadata, rec.parentRefs); err != nil {
 		return nil, err
@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
 	"github.com/containerd/containerd/v2/pkg/reference"
 	cerrdefs "github.com/containerd/errdefs"
 	"github.com/moby/buildkit/cache/config"
@@ -57,7 +58,7 @@ func (sr *immutableRef) GetRemotes(ctx context.Context, createIfNeeded bool, ref
 	// compression with all combination of compressions
 	res := []*solver.Remote{remote}
 	topmost, parentChain := remote.Descriptors[len(remote.Descriptors)-1], remote.Descriptors[:len(remote.Descriptors)-1]
-	vDesc, err := getBlobWithCompression(ctx, sr.cm.ContentStore, topmost, refCfg.Compression.Type)
+	vDesc, err := getBlobWithCompression(ctx, sr.cm.ContentStore, topmost, refCfg.Compression)
 	if err != nil {
 		return res, nil // compression variant doesn't exist. return the main blob only.
 	}
@@ -219,7 +220,7 @@ func (sr *immutableRef) getRemote(ctx context.Context, createIfNeeded bool, refC
 		if refCfg.Compression.Force {
 			if needs, err := refCfg.Compression.Type.NeedsConversion(ctx, sr.cm.ContentStore, desc); err != nil {
 				return nil, err
-			} else if needs {
+			} else if needs || !refCfg.Compression.MatchesOptions(ctx, sr.cm.ContentStore, desc) {
 				// ensure the compression type.
 				// compressed blob must be created and stored in the content store.
 				blobDesc, err := getBlobWithCompressionWithRetry(ctx, ref, refCfg.Compression, s)
@@ -251,17 +252,62 @@ func (sr *immutableRef) getRemote(ctx context.Context, createIfNeeded bool, refC
 			session: s,
 		})
 	}
+	remote.Composition = sr.remoteComposition(ctx, createIfNeeded, refCfg, s, remote.Descriptors, mprovider)
 	return remote, nil
 }
 
+// remoteComposition returns how a merge or a diff with its own computed blob
+// was built from its parents so that importers can reconstruct the ref from
+// the remotes of the parents.
+func (sr *immutableRef) remoteComposition(ctx context.Context, createIfNeeded bool, refCfg config.RefConfig, s session.Group, descs []ocispecs.Descriptor, provider content.InfoReaderProvider) *solver.RemoteComposition {
+	switch sr.kind() {
+	case Merge:
+		comp := &solver.RemoteComposition{Kind: solver.RemoteMerge}
+		for _, parent := range sr.mergeParents {
+			n := len(parent.layerChain())
+			if n > len(descs) {
+				return nil
+			}
+			in := &solver.Remote{
+				Descriptors: slices.Clone(descs[:n]),
+				Provider:    provider,
+			}
+			in.Composition = parent.remoteComposition(ctx, createIfNeeded, refCfg, s, in.Descriptors, provider)
+			comp.Inputs = append(comp.Inputs, in)
+			descs = descs[n:]
+		}
+		return comp
+	case Diff:
+		// diffs that reuse the blob of upper are already described by it
+		if chain := sr.layerChain(); len(chain) != 1 || chain[0].ID() != sr.ID() {
+			return nil
+		}
+		comp := &solver.RemoteComposition{Kind: solver.RemoteDiff}
+		for _, parent := range []*immutableRef{sr.diffParents.lower, sr.diffParents.upper} {
+			if parent == nil {
+				comp.Inputs = append(comp.Inputs, &solver.Remote{})
+				continue
+			}
+			r, err := parent.getRemote(ctx, createIfNeeded, refCfg, s)
+			if err != nil {
+				bklog.G(ctx).WithError(err).Debugf("failed to get remote of diff parent %s", parent.ID())
+				return nil
+			}
+			comp.Inputs = append(comp.Inputs, r)
+		}
+		return comp
+	}
+	return nil
+}
+
 func getBlobWithCompressionWithRetry(ctx context.Context, ref *immutableRef, comp compression.Config, s session.Group) (ocispecs.Descriptor, error) {
-	if blobDesc, err := ref.getBlobWithCompression(ctx, comp.Type); err == nil {
+	if blobDesc, err := ref.getBlobWithCompression(ctx, comp); err == nil {
 		return blobDesc, nil
 	}
 	if err := ensureCompression(ctx, ref, comp, s); err != nil {
 		return ocispecs.Descriptor{}, errors.Wrapf(err, "failed to get and ensure compression type of %q", comp.Type)
 	}
-	return ref.getBlobWithCompression(ctx, comp.Type)
+	return ref.getBlobWithCompression(ctx, comp)
 }
 
 type lazyMultiProvider struct {
@@ -331,6 +377,17 @@ func (p lazyRefProvider) Info(ctx context.Context, dgst digest.Digest) (content.
 	}, nil
 }
 
+type fetchLimiterKey struct{}
+
+// FetchLimiter waits until a lazy blob may be downloaded
+type FetchLimiter func(context.Context) (release func(), err error)
+
+// WithFetchLimiter returns a context that limits the downloads of lazy blobs
+// unlazied with it
+func WithFetchLimiter(ctx context.Context, l FetchLimiter) context.Context {
+	return context.WithValue(ctx, fetchLimiterKey{}, l)
+}
+
 func (p lazyRefProvider) Unlazy(ctx context.Context) error {
 	_, err := p.ref.cm.unlazyG.Do(ctx, string(p.desc.Digest), func(ctx context.Context) (_ struct{}, rerr error) {
 		if isLazy, err := p.ref.isLazy(ctx); err != nil {
@@ -350,6 +407,14 @@ func (p lazyRefProvider) Unlazy(ctx context.Context) error {
 			return struct{}{}, errors.New("unexpected nil descriptor handler")
 		}
 
+		if l, ok := ctx.Value(fetchLimiterKey{}).(FetchLimiter); ok && l != nil {
+			release, err := l(ctx)
+			if err != nil {
+				return struct{}{}, err
+			}
+			defer release()
+		}
+
 		if p.dh.Progress != nil {
 			var stopProgress func(error)
 			ctx, stopProgress = p.dh.Progress.Start(ctx)
@@ -367,7 +432,20 @@ func (p lazyRefProvider) Unlazy(ctx context.Context) error {
 			return struct{}{}, err
 		}
 
-		if imageRefs := p.ref.getImageRefs(); len(imageRefs) > 0 {
+		// record where the blob was pulled from so that pushes to the same
+		// registry can mount it instead of uploading it again
+		imageRefs := p.ref.getImageRefs()
+		for _, imageRef := range imageRefs {
+			dslHandler, err := docker.AppendDistributionSourceLabel(p.ref.cm.ContentStore, imageRef)
+			if err == nil {
+				_, err = dslHandler(ctx, p.desc)
+			}
+			if err != nil {
+				bklog.G(ctx).Warnf("failed to update distribution source for layer %v: %v", p.desc.Digest, err)
+			}
+		}
+
+		if len(imageRefs) > 0 {
 			// just use the first image ref, it's arbitrary
 			imageRef := imageRefs[0]
 			if p.ref.GetDescription() == "" {
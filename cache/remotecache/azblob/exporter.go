@@ -17,6 +17,7 @@ import (
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/pkg/labels"
 	"github.com/moby/buildkit/cache/remotecache"
+	"github.com/moby/buildkit/cache/remotecache/encryption"
 	v1 "github.com/moby/buildkit/cache/remotecache/v1"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
@@ -28,14 +29,17 @@ import (
 )
 
 // ResolveCacheExporterFunc for "azblob" cache exporter.
-func ResolveCacheExporterFunc() remotecache.ResolveCacheExporterFunc {
+func ResolveCacheExporterFunc(sm *session.Manager) remotecache.ResolveCacheExporterFunc {
 	return func(ctx context.Context, g session.Group, attrs map[string]string) (remotecache.Exporter, error) {
 		config, err := getConfig(attrs)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create azblob config")
 		}
+		if config.key, err = encryption.FromSession(ctx, sm, g, attrs); err != nil {
+			return nil, err
+		}
 
-		containerClient, err := createContainerClient(ctx, config)
+		containerClient, err := createContainerClient(ctx, config, sm, g)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create container client")
 		}
@@ -104,7 +108,13 @@ func (ce *exporter) Finalize(ctx context.Context) (map[string]string, error) {
 				err = errors.Wrapf(err, "failed to get reader for %s", dgstPair.Descriptor.Digest)
 				return nil, layerDone(err)
 			}
-			if err := ce.uploadBlobIfNotExists(ctx, key, content.NewReader(ra)); err != nil {
+			var r io.Reader = content.NewReader(ra)
+			if ce.config.key != nil {
+				if r, err = ce.config.key.Encrypt(r, ra.Size(), dgstPair.Descriptor.Digest.String()); err != nil {
+					return nil, layerDone(err)
+				}
+			}
+			if err := ce.uploadBlobIfNotExists(ctx, key, r); err != nil {
 				return nil, layerDone(err)
 			}
 			layerDone(nil)
@@ -131,7 +141,13 @@ func (ce *exporter) Finalize(ctx context.Context) (map[string]string, error) {
 	}
 
 	for _, name := range ce.config.Names {
-		if innerError := ce.uploadManifest(ctx, manifestKey(ce.config, name), bytesToReadSeekCloser(dt)); innerError != nil {
+		mdt := dt
+		if ce.config.key != nil {
+			if mdt, err = ce.config.key.EncryptBytes(dt, manifestID(name)); err != nil {
+				return nil, err
+			}
+		}
+		if innerError := ce.uploadManifest(ctx, manifestKey(ce.config, name), bytesToReadSeekCloser(mdt)); innerError != nil {
 			return nil, errors.Wrapf(innerError, "error writing manifest %s", name)
 		}
 	}
@@ -176,8 +192,8 @@ func (ce *exporter) uploadBlobIfNotExists(ctx context.Context, blobKey string, r
 
 	// Only upload if the blob doesn't exist
 	_, err := blobClient.UploadStream(uploadCtx, reader, &blockblob.UploadStreamOptions{
-		BlockSize:   IOChunkSize,
-		Concurrency: IOConcurrency,
+		BlockSize:   ce.config.UploadBlockSize,
+		Concurrency: ce.config.UploadParallelism,
 		AccessConditions: &blob.AccessConditions{
 			ModifiedAccessConditions: &blob.ModifiedAccessConditions{
 				IfNoneMatch: to.Ptr(azcore.ETagAny),
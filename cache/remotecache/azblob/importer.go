@@ -12,6 +12,7 @@ import (
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/pkg/labels"
 	"github.com/moby/buildkit/cache/remotecache"
+	"github.com/moby/buildkit/cache/remotecache/encryption"
 	v1 "github.com/moby/buildkit/cache/remotecache/v1"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
@@ -26,14 +27,17 @@ import (
 )
 
 // ResolveCacheImporterFunc for "azblob" cache importer.
-func ResolveCacheImporterFunc() remotecache.ResolveCacheImporterFunc {
+func ResolveCacheImporterFunc(sm *session.Manager) remotecache.ResolveCacheImporterFunc {
 	return func(ctx context.Context, g session.Group, attrs map[string]string) (remotecache.Importer, ocispecs.Descriptor, error) {
 		config, err := getConfig(attrs)
 		if err != nil {
 			return nil, ocispecs.Descriptor{}, errors.Wrap(err, "failed to create azblob config")
 		}
+		if config.key, err = encryption.FromSession(ctx, sm, g, attrs); err != nil {
+			return nil, ocispecs.Descriptor{}, err
+		}
 
-		containerClient, err := createContainerClient(ctx, config)
+		containerClient, err := createContainerClient(ctx, config, sm, g)
 		if err != nil {
 			return nil, ocispecs.Descriptor{}, errors.Wrap(err, "failed to create container client")
 		}
@@ -114,6 +118,11 @@ func (ci *importer) loadManifest(ctx context.Context, name string) (*v1.CacheCha
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
+	if ci.config.key != nil {
+		if bytes, err = ci.config.key.DecryptBytes(bytes, manifestID(name)); err != nil {
+			return nil, err
+		}
+	}
 
 	bklog.G(ctx).Debugf("imported config: %s", string(bytes))
 
@@ -200,9 +209,20 @@ func (f *fetcher) Fetch(ctx context.Context, desc ocispecs.Descriptor) (io.ReadC
 		return nil, err
 	}
 
+	if f.config.key != nil {
+		return &decryptingReadCloser{
+			Reader: f.config.key.Decrypt(res.Body, desc.Digest.String()),
+			Closer: res.Body,
+		}, nil
+	}
 	return res.Body, nil
 }
 
+type decryptingReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
 type ciProvider struct {
 	content.Provider
 	desc            ocispecs.Descriptor
@@ -5,29 +5,42 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	units "github.com/docker/go-units"
+	"github.com/moby/buildkit/cache/remotecache/encryption"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets"
 	digest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 )
 
 const (
-	attrSecretAccessKey = "secret_access_key"
-	attrAccountName     = "account_name"
-	attrAccountURL      = "account_url"
-	attrPrefix          = "prefix"
-	attrManifestsPrefix = "manifests_prefix"
-	attrBlobsPrefix     = "blobs_prefix"
-	attrName            = "name"
-	attrContainer       = "container"
-	IOConcurrency       = 4
-	IOChunkSize         = 32 * 1024 * 1024
+	attrSecretAccessKey      = "secret_access_key"
+	attrAccountName          = "account_name"
+	attrAccountURL           = "account_url"
+	attrPrefix               = "prefix"
+	attrManifestsPrefix      = "manifests_prefix"
+	attrBlobsPrefix          = "blobs_prefix"
+	attrName                 = "name"
+	attrContainer            = "container"
+	attrSASTokenSecret       = "sas_token_secret"
+	attrFederatedTokenSecret = "federated_token_secret"
+	attrClientID             = "client_id"
+	attrTenantID             = "tenant_id"
+	attrManagedIdentityID    = "managed_identity_client_id"
+	attrUploadBlockSize      = "upload_block_size"
+	attrUploadParallelism    = "upload_parallelism"
+	IOConcurrency            = 4
+	IOChunkSize              = 32 * 1024 * 1024
 )
 
 type Config struct {
@@ -38,7 +51,19 @@ type Config struct {
 	BlobsPrefix     string
 	Names           []string
 	AccountName     string
-	secretAccessKey string
+	// ClientID and TenantID identify the app a federated token is exchanged
+	// for
+	ClientID string
+	TenantID string
+	// ManagedIdentityClientID selects a user-assigned managed identity
+	ManagedIdentityClientID string
+	UploadBlockSize         int64
+	UploadParallelism       int
+	secretAccessKey         string
+	sasTokenSecret          string
+	federatedTokenSecret    string
+	// key encrypts the blobs and manifests if set
+	key *encryption.Key
 }
 
 func getConfig(attrs map[string]string) (*Config, error) {
@@ -100,23 +125,79 @@ func getConfig(attrs map[string]string) (*Config, error) {
 
 	secretAccessKey := attrs[attrSecretAccessKey]
 
+	clientID, ok := attrs[attrClientID]
+	if !ok {
+		clientID = os.Getenv("AZURE_CLIENT_ID")
+	}
+	tenantID, ok := attrs[attrTenantID]
+	if !ok {
+		tenantID = os.Getenv("AZURE_TENANT_ID")
+	}
+	federatedTokenSecret := attrs[attrFederatedTokenSecret]
+	if federatedTokenSecret != "" && (clientID == "" || tenantID == "") {
+		return &Config{}, errors.Errorf("%s requires %s and %s for azblob cache", attrFederatedTokenSecret, attrClientID, attrTenantID)
+	}
+
+	uploadBlockSize := int64(IOChunkSize)
+	if v, ok := attrs[attrUploadBlockSize]; ok {
+		n, err := units.RAMInBytes(v)
+		if err != nil || n <= 0 {
+			return &Config{}, errors.Errorf("%s must be a positive size", attrUploadBlockSize)
+		}
+		// block blobs have a limit of 4000MiB per block
+		if n > 4000*1024*1024 {
+			return &Config{}, errors.Errorf("%s must not be larger than 4000MiB", attrUploadBlockSize)
+		}
+		uploadBlockSize = n
+	}
+	uploadParallelism := IOConcurrency
+	if v, ok := attrs[attrUploadParallelism]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return &Config{}, errors.Errorf("%s must be a positive integer", attrUploadParallelism)
+		}
+		uploadParallelism = n
+	}
+
 	config := Config{
-		AccountURL:      accountURLString,
-		AccountName:     accountName,
-		Container:       container,
-		Prefix:          prefix,
-		Names:           names,
-		ManifestsPrefix: manifestsPrefix,
-		BlobsPrefix:     blobsPrefix,
-		secretAccessKey: secretAccessKey,
+		AccountURL:              accountURLString,
+		AccountName:             accountName,
+		Container:               container,
+		Prefix:                  prefix,
+		Names:                   names,
+		ManifestsPrefix:         manifestsPrefix,
+		BlobsPrefix:             blobsPrefix,
+		ClientID:                clientID,
+		TenantID:                tenantID,
+		ManagedIdentityClientID: attrs[attrManagedIdentityID],
+		UploadBlockSize:         uploadBlockSize,
+		UploadParallelism:       uploadParallelism,
+		secretAccessKey:         secretAccessKey,
+		sasTokenSecret:          attrs[attrSASTokenSecret],
+		federatedTokenSecret:    federatedTokenSecret,
 	}
 
 	return &config, nil
 }
 
-func createContainerClient(ctx context.Context, config *Config) (*container.Client, error) {
+func createContainerClient(ctx context.Context, config *Config, sm *session.Manager, g session.Group) (*container.Client, error) {
 	var client *azblob.Client
-	if config.secretAccessKey != "" {
+	switch {
+	case config.sasTokenSecret != "":
+		sas, err := secrets.GetSecretFromGroup(ctx, sm, g, config.sasTokenSecret)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get SAS token")
+		}
+		u, err := url.Parse(config.AccountURL)
+		if err != nil {
+			return nil, errors.Wrap(err, "azure storage account url provided is not a valid url")
+		}
+		u.RawQuery = strings.TrimPrefix(strings.TrimSpace(string(sas)), "?")
+		client, err = azblob.NewClientWithNoCredential(u.String(), &azblob.ClientOptions{})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create service client from SAS token")
+		}
+	case config.secretAccessKey != "":
 		sharedKey, err := azblob.NewSharedKeyCredential(config.AccountName, config.secretAccessKey)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create shared key")
@@ -125,10 +206,10 @@ func createContainerClient(ctx context.Context, config *Config) (*container.Clie
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to created service client from shared key")
 		}
-	} else {
-		cred, err := azidentity.NewDefaultAzureCredential(nil)
+	default:
+		cred, err := newTokenCredential(config, sm, g)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to create default azure credentials")
+			return nil, err
 		}
 
 		client, err = azblob.NewClient(config.AccountURL, cred, &azblob.ClientOptions{})
@@ -162,6 +243,41 @@ func createContainerClient(ctx context.Context, config *Config) (*container.Clie
 	return nil, errors.Wrapf(err, "failed to get properties of cache container %s", config.Container)
 }
 
+// newTokenCredential returns the credential used to get access tokens. The
+// credentials refresh the tokens when they expire, so that uploads outliving
+// a token keep working. Federated tokens are read again from the session
+// when a new access token is needed.
+func newTokenCredential(config *Config, sm *session.Manager, g session.Group) (azcore.TokenCredential, error) {
+	switch {
+	case config.federatedTokenSecret != "":
+		cred, err := azidentity.NewClientAssertionCredential(config.TenantID, config.ClientID, func(ctx context.Context) (string, error) {
+			dt, err := secrets.GetSecretFromGroup(ctx, sm, g, config.federatedTokenSecret)
+			if err != nil {
+				return "", errors.Wrap(err, "failed to get federated token")
+			}
+			return strings.TrimSpace(string(dt)), nil
+		}, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create federated identity credentials")
+		}
+		return cred, nil
+	case config.ManagedIdentityClientID != "":
+		cred, err := azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{
+			ID: azidentity.ClientID(config.ManagedIdentityClientID),
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create managed identity credentials")
+		}
+		return cred, nil
+	default:
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create default azure credentials")
+		}
+		return cred, nil
+	}
+}
+
 func manifestKey(config *Config, name string) string {
 	key := filepath.Join(config.Prefix, config.ManifestsPrefix, name)
 	return key
@@ -169,9 +285,17 @@ func manifestKey(config *Config, name string) string {
 
 func blobKey(config *Config, digest digest.Digest) string {
 	key := filepath.Join(config.Prefix, config.BlobsPrefix, digest.String())
+	if config.key != nil {
+		key += encryption.BlobSuffix
+	}
 	return key
 }
 
+// manifestID binds an encrypted manifest to its name
+func manifestID(name string) string {
+	return "manifests/" + name
+}
+
 func blobExists(ctx context.Context, containerClient *container.Client, blobKey string) (bool, error) {
 	blobClient := containerClient.NewBlobClient(blobKey)
 	ctx, cnclFn := context.WithCancelCause(ctx)
@@ -0,0 +1,337 @@
+// Package encryption implements the client-side encryption of the blobs and
+// manifests written to the object store cache backends.
+//
+// An encrypted object starts with a header containing a random nonce prefix
+// and the size of the plaintext, followed by the plaintext sealed with
+// AES-256-GCM in chunks of 64KiB. Every chunk is authenticated together with
+// the header and the ID of the object, so chunks can't be reordered, dropped
+// or moved to other objects. The chunks allow reading a range of an object
+// without downloading it completely.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+)
+
+// AttrKeySecret is the attribute of a cache exporter or importer with the ID
+// of the session secret the encryption key is derived from
+const AttrKeySecret = "encryption_key_secret"
+
+// BlobSuffix is appended to the names of encrypted blobs so that they are
+// not confused with the blobs of unencrypted caches in the same location
+const BlobSuffix = ".enc"
+
+const (
+	chunkSize  = 64 * 1024
+	headerSize = 24
+)
+
+var magic = []byte("bkenc\x00\x00\x01")
+
+// Key encrypts and decrypts cache objects
+type Key struct {
+	aead cipher.AEAD
+}
+
+// NewKey derives an AES-256 key from secret
+func NewKey(secret []byte) (*Key, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("empty cache encryption key")
+	}
+	k := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte("buildkit cache encryption v1")), k); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Key{aead: aead}, nil
+}
+
+// FromSession returns the key of the secret set in attrs, or nil if the cache
+// is not encrypted
+func FromSession(ctx context.Context, sm *session.Manager, g session.Group, attrs map[string]string) (*Key, error) {
+	id, ok := attrs[AttrKeySecret]
+	if !ok {
+		return nil, nil
+	}
+	if id == "" {
+		return nil, errors.Errorf("%s requires a secret ID", AttrKeySecret)
+	}
+	secret, err := secrets.GetSecretFromGroup(ctx, sm, g, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get cache encryption key %s", id)
+	}
+	return NewKey(secret)
+}
+
+func numChunks(size int64) int64 {
+	if size == 0 {
+		// empty objects have one empty chunk to authenticate the header
+		return 1
+	}
+	return (size + chunkSize - 1) / chunkSize
+}
+
+// EncryptedSize returns the size of an encrypted object of size bytes
+func (k *Key) EncryptedSize(size int64) int64 {
+	return headerSize + size + numChunks(size)*int64(k.aead.Overhead())
+}
+
+// Encrypt returns a reader of the encrypted object of the size bytes read
+// from r
+func (k *Key) Encrypt(r io.Reader, size int64, id string) (io.Reader, error) {
+	hdr := make([]byte, headerSize)
+	copy(hdr, magic)
+	if _, err := rand.Read(hdr[8:16]); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	binary.BigEndian.PutUint64(hdr[16:], uint64(size))
+	return &encryptReader{
+		k:    k,
+		r:    r,
+		hdr:  hdr,
+		aad:  append(hdr[:headerSize:headerSize], id...),
+		size: size,
+		buf:  hdr,
+	}, nil
+}
+
+// EncryptBytes encrypts dt as the object id
+func (k *Key) EncryptBytes(dt []byte, id string) ([]byte, error) {
+	r, err := k.Encrypt(bytes.NewReader(dt), int64(len(dt)), id)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r)
+}
+
+// DecryptBytes decrypts the object id
+func (k *Key) DecryptBytes(dt []byte, id string) ([]byte, error) {
+	out, err := io.ReadAll(k.Decrypt(bytes.NewReader(dt), id))
+	if err != nil {
+		return nil, err
+	}
+	if k.EncryptedSize(int64(len(out))) != int64(len(dt)) {
+		return nil, errors.Errorf("unexpected data after encrypted object %s", id)
+	}
+	return out, nil
+}
+
+type encryptReader struct {
+	k     *Key
+	r     io.Reader
+	hdr   []byte
+	aad   []byte
+	size  int64
+	chunk int64
+	buf   []byte
+	err   error
+}
+
+func (e *encryptReader) Read(p []byte) (int, error) {
+	for len(e.buf) == 0 {
+		if e.err != nil {
+			return 0, e.err
+		}
+		e.err = e.next()
+	}
+	n := copy(p, e.buf)
+	e.buf = e.buf[n:]
+	return n, nil
+}
+
+func (e *encryptReader) next() error {
+	if e.chunk == numChunks(e.size) {
+		return io.EOF
+	}
+	n := min(int64(chunkSize), e.size-e.chunk*chunkSize)
+	dt := make([]byte, n, n+int64(e.k.aead.Overhead()))
+	if _, err := io.ReadFull(e.r, dt); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return errors.Wrap(err, "failed to read data to encrypt")
+	}
+	e.buf = e.k.aead.Seal(dt[:0], nonce(e.hdr, e.chunk), dt, e.aad)
+	e.chunk++
+	return nil
+}
+
+func nonce(hdr []byte, chunk int64) []byte {
+	n := make([]byte, 12)
+	copy(n, hdr[8:16])
+	binary.BigEndian.PutUint32(n[8:], uint32(chunk))
+	return n
+}
+
+// Decrypt returns a reader of the plaintext of the object id read from r
+func (k *Key) Decrypt(r io.Reader, id string) io.Reader {
+	return &decryptReader{k: k, r: r, id: id}
+}
+
+type decryptReader struct {
+	k     *Key
+	r     io.Reader
+	id    string
+	hdr   []byte
+	aad   []byte
+	size  int64
+	chunk int64
+	buf   []byte
+	err   error
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.buf) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.err = d.next()
+	}
+	n := copy(p, d.buf)
+	d.buf = d.buf[n:]
+	return n, nil
+}
+
+func (d *decryptReader) next() error {
+	if d.hdr == nil {
+		hdr := make([]byte, headerSize)
+		if _, err := io.ReadFull(d.r, hdr); err != nil {
+			return errors.Wrapf(err, "failed to read header of encrypted object %s", d.id)
+		}
+		size, err := parseHeader(hdr, d.id)
+		if err != nil {
+			return err
+		}
+		d.hdr = hdr
+		d.aad = append(hdr[:headerSize:headerSize], d.id...)
+		d.size = size
+	}
+	if d.chunk == numChunks(d.size) {
+		return io.EOF
+	}
+	overhead := int64(d.k.aead.Overhead())
+	buf := make([]byte, min(int64(chunkSize), d.size-d.chunk*chunkSize)+overhead)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return errors.Wrap(err, "failed to read encrypted data")
+	}
+	dt, err := d.k.aead.Open(buf[:0], nonce(d.hdr, d.chunk), buf, d.aad)
+	if err != nil {
+		return errors.Wrap(err, "failed to decrypt cache object")
+	}
+	d.buf = dt
+	d.chunk++
+	return nil
+}
+
+func parseHeader(hdr []byte, id string) (int64, error) {
+	if !bytes.Equal(hdr[:8], magic) {
+		return 0, errors.Errorf("%s is not an encrypted cache object", id)
+	}
+	size := binary.BigEndian.Uint64(hdr[16:])
+	if size > 1<<32*chunkSize {
+		return 0, errors.Errorf("invalid size of encrypted object %s", id)
+	}
+	return int64(size), nil
+}
+
+// ReaderAt decrypts byte ranges of the object id read from ra
+type ReaderAt struct {
+	k    *Key
+	ra   io.ReaderAt
+	hdr  []byte
+	aad  []byte
+	size int64
+
+	mu       sync.Mutex
+	chunk    int64
+	chunkBuf []byte
+}
+
+// ReaderAt returns a reader of the plaintext of the object id read from ra
+func (k *Key) ReaderAt(ra io.ReaderAt, id string) (*ReaderAt, error) {
+	hdr := make([]byte, headerSize)
+	if _, err := ra.ReadAt(hdr, 0); err != nil {
+		return nil, errors.Wrapf(err, "failed to read header of encrypted object %s", id)
+	}
+	size, err := parseHeader(hdr, id)
+	if err != nil {
+		return nil, err
+	}
+	return &ReaderAt{
+		k:     k,
+		ra:    ra,
+		hdr:   hdr,
+		aad:   append(hdr[:headerSize:headerSize], id...),
+		size:  size,
+		chunk: -1,
+	}, nil
+}
+
+// Size returns the size of the plaintext
+func (r *ReaderAt) Size() int64 {
+	return r.size
+}
+
+func (r *ReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var n int
+	for len(p) > 0 {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		chunk := off / chunkSize
+		if err := r.load(chunk); err != nil {
+			return n, err
+		}
+		nn := copy(p, r.chunkBuf[off-chunk*chunkSize:])
+		n += nn
+		off += int64(nn)
+		p = p[nn:]
+	}
+	return n, nil
+}
+
+func (r *ReaderAt) load(chunk int64) error {
+	if r.chunk == chunk {
+		return nil
+	}
+	overhead := int64(r.k.aead.Overhead())
+	n := min(int64(chunkSize), r.size-chunk*chunkSize) + overhead
+	buf := make([]byte, n)
+	if _, err := r.ra.ReadAt(buf, headerSize+chunk*(chunkSize+overhead)); err != nil && !errors.Is(err, io.EOF) {
+		return errors.Wrap(err, "failed to read encrypted data")
+	}
+	dt, err := r.k.aead.Open(buf[:0], nonce(r.hdr, chunk), buf, r.aad)
+	if err != nil {
+		r.chunk = -1
+		return errors.Wrap(err, "failed to decrypt cache object")
+	}
+	r.chunk = chunk
+	r.chunkBuf = dt
+	return nil
+}
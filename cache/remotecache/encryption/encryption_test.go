@@ -0,0 +1,85 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	t.Parallel()
+
+	k, err := NewKey([]byte("secret"))
+	require.NoError(t, err)
+
+	for _, size := range []int{0, 1, chunkSize - 1, chunkSize, 3*chunkSize + 100} {
+		dt := make([]byte, size)
+		_, err := rand.Read(dt)
+		require.NoError(t, err)
+
+		r, err := k.Encrypt(bytes.NewReader(dt), int64(size), "blob")
+		require.NoError(t, err)
+		enc, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.Equal(t, k.EncryptedSize(int64(size)), int64(len(enc)))
+
+		out, err := io.ReadAll(k.Decrypt(bytes.NewReader(enc), "blob"))
+		require.NoError(t, err)
+		require.Equal(t, dt, out)
+
+		out, err = k.DecryptBytes(enc, "blob")
+		require.NoError(t, err)
+		require.Equal(t, dt, out)
+
+		ra, err := k.ReaderAt(bytes.NewReader(enc), "blob")
+		require.NoError(t, err)
+		require.Equal(t, int64(size), ra.Size())
+		if size > 10 {
+			off := size / 2
+			buf := make([]byte, min(size-off, chunkSize+10))
+			n, err := ra.ReadAt(buf, int64(off))
+			require.NoError(t, err)
+			require.Equal(t, dt[off:off+n], buf)
+		}
+
+		// objects can't be decrypted as other objects
+		_, err = k.DecryptBytes(enc, "other")
+		require.Error(t, err)
+	}
+}
+
+func TestDecryptTampered(t *testing.T) {
+	t.Parallel()
+
+	k, err := NewKey([]byte("secret"))
+	require.NoError(t, err)
+	k2, err := NewKey([]byte("other"))
+	require.NoError(t, err)
+
+	dt := bytes.Repeat([]byte("a"), 2*chunkSize+1)
+	enc, err := k.EncryptBytes(dt, "blob")
+	require.NoError(t, err)
+
+	_, err = k2.DecryptBytes(enc, "blob")
+	require.Error(t, err)
+
+	_, err = io.ReadAll(k.Decrypt(bytes.NewReader(enc[:len(enc)-1]), "blob"))
+	require.Error(t, err)
+
+	modified := bytes.Clone(enc)
+	modified[headerSize+chunkSize+20]++
+	_, err = io.ReadAll(k.Decrypt(bytes.NewReader(modified), "blob"))
+	require.Error(t, err)
+
+	// the plaintext size is authenticated with the chunks
+	modified = bytes.Clone(enc)
+	modified[headerSize-1]--
+	_, err = io.ReadAll(k.Decrypt(bytes.NewReader(modified), "blob"))
+	require.Error(t, err)
+
+	_, err = k.DecryptBytes(dt, "blob")
+	require.Error(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/cacheevents"
 	"github.com/moby/buildkit/util/compression"
 	"github.com/moby/buildkit/util/contentutil"
 	"github.com/moby/buildkit/util/progress"
@@ -196,6 +197,7 @@ func (ce *contentCacheExporter) Finalize(ctx context.Context) (map[string]string
 		return nil, err
 	}
 
+	var size int64
 	for _, l := range config.Layers {
 		dgstPair, ok := descs[l.Blob]
 		if !ok {
@@ -207,6 +209,7 @@ func (ce *contentCacheExporter) Finalize(ctx context.Context) (map[string]string
 		}
 		layerDone(nil)
 		cache.AddCacheBlob(dgstPair.Descriptor)
+		size += dgstPair.Descriptor.Size
 	}
 
 	cache.FinalizeCache(ctx)
@@ -256,5 +259,15 @@ func (ce *contentCacheExporter) Finalize(ctx context.Context) (map[string]string
 	res[ExporterResponseManifestDesc] = string(descJSON)
 	mfstDone(nil)
 
+	if cacheevents.Enabled() {
+		cacheevents.Record(cacheevents.Event{
+			Type:   cacheevents.Export,
+			Digest: dgst,
+			Size:   size,
+			Layers: len(config.Layers),
+			Source: ce.ref,
+		})
+	}
+
 	return res, nil
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/cacheevents"
 	"github.com/moby/buildkit/util/imageutil"
 	"github.com/moby/buildkit/util/progress"
 	"github.com/moby/buildkit/worker"
@@ -108,7 +109,19 @@ func (ci *contentCacheImporter) Resolve(ctx context.Context, desc ocispecs.Descr
 	}
 
 	if configDesc.Digest == "" {
-		return ci.importInlineCache(ctx, dt, id, w)
+		cm, err := ci.importInlineCache(ctx, dt, id, w)
+		if err != nil {
+			return nil, err
+		}
+		if cacheevents.Enabled() {
+			cacheevents.Record(cacheevents.Event{
+				Type:        cacheevents.Import,
+				Digest:      desc.Digest,
+				Source:      id,
+				Description: "inline cache",
+			})
+		}
+		return cm, nil
 	}
 
 	dt, err = readBlob(ctx, ci.provider, configDesc)
@@ -116,8 +129,16 @@ func (ci *contentCacheImporter) Resolve(ctx context.Context, desc ocispecs.Descr
 		return nil, err
 	}
 
+	var config v1.CacheConfig
+	if err := json.Unmarshal(dt, &config); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	for _, m := range solver.CheckCacheKeyVersions(config.KeyVersions) {
+		bklog.G(ctx).Warnf("imported cache %s won't match for %s", id, m)
+	}
+
 	cc := v1.NewCacheChains()
-	if err := v1.Parse(dt, allLayers, cc); err != nil {
+	if err := v1.ParseConfig(config, allLayers, cc); err != nil {
 		return nil, err
 	}
 
@@ -125,6 +146,20 @@ func (ci *contentCacheImporter) Resolve(ctx context.Context, desc ocispecs.Descr
 	if err != nil {
 		return nil, err
 	}
+
+	if cacheevents.Enabled() {
+		var size int64
+		for _, l := range allLayers {
+			size += l.Descriptor.Size
+		}
+		cacheevents.Record(cacheevents.Event{
+			Type:   cacheevents.Import,
+			Digest: desc.Digest,
+			Size:   size,
+			Layers: len(allLayers),
+			Source: id,
+		})
+	}
 	return solver.NewCacheManager(ctx, id, keysStorage, resultStorage), nil
 }
 
@@ -143,6 +143,9 @@ func (ce *exporter) ExportForLayers(ctx context.Context, layers []digest.Digest)
 			r.Results = filteredResults
 			cfg.Records[i] = r
 		}
+		// the layer indexes of the inline cache refer to the layers of the
+		// image, composed results can't be expressed with them
+		cfg.Records[i].ComposedResults = nil
 	}
 
 	dt, err := json.Marshal(cfg.Records)
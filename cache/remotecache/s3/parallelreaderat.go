@@ -0,0 +1,120 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// parallelReaderAt reads a blob with concurrent ranged requests. The parts
+// following the last read are prefetched, so for the sequential reads of a
+// blob copy up to concurrency requests are in flight.
+type parallelReaderAt struct {
+	ctx         context.Context
+	cancel      context.CancelCauseFunc
+	open        func(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+	size        int64
+	partSize    int64
+	concurrency int
+
+	mu    sync.Mutex
+	parts map[int64]*part
+}
+
+type part struct {
+	done chan struct{}
+	dt   []byte
+	err  error
+}
+
+func newParallelReaderAt(ctx context.Context, size, partSize int64, concurrency int, open func(ctx context.Context, offset, length int64) (io.ReadCloser, error)) *parallelReaderAt {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &parallelReaderAt{
+		ctx:         ctx,
+		cancel:      cancel,
+		open:        open,
+		size:        size,
+		partSize:    partSize,
+		concurrency: concurrency,
+		parts:       map[int64]*part{},
+	}
+}
+
+func (r *parallelReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if r.ctx.Err() != nil {
+		return 0, context.Cause(r.ctx)
+	}
+	var n int
+	for len(p) > 0 {
+		if off >= r.size {
+			return n, io.EOF
+		}
+		idx := off / r.partSize
+		pt := r.get(idx)
+		select {
+		case <-pt.done:
+		case <-r.ctx.Done():
+			return n, context.Cause(r.ctx)
+		}
+		if pt.err != nil {
+			r.mu.Lock()
+			delete(r.parts, idx)
+			r.mu.Unlock()
+			return n, pt.err
+		}
+		nn := copy(p, pt.dt[off-idx*r.partSize:])
+		n += nn
+		off += int64(nn)
+		p = p[nn:]
+	}
+	return n, nil
+}
+
+// get returns the part idx and starts fetching the parts after it. The parts
+// before idx are dropped.
+func (r *parallelReaderAt) get(idx int64) *part {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.parts {
+		if i < idx {
+			delete(r.parts, i)
+		}
+	}
+	for i := idx; i < idx+int64(r.concurrency) && i*r.partSize < r.size; i++ {
+		if _, ok := r.parts[i]; ok {
+			continue
+		}
+		pt := &part{done: make(chan struct{})}
+		r.parts[i] = pt
+		go r.fetch(i, pt)
+	}
+	return r.parts[idx]
+}
+
+func (r *parallelReaderAt) fetch(idx int64, pt *part) {
+	defer close(pt.done)
+	offset := idx * r.partSize
+	length := min(r.partSize, r.size-offset)
+	rc, err := r.open(r.ctx, offset, length)
+	if err != nil {
+		pt.err = err
+		return
+	}
+	defer rc.Close()
+	dt := make([]byte, length)
+	if _, err := io.ReadFull(rc, dt); err != nil {
+		pt.err = errors.Wrapf(err, "failed to read range %d-%d", offset, offset+length-1)
+		return
+	}
+	pt.dt = dt
+}
+
+func (r *parallelReaderAt) Close() error {
+	r.cancel(errors.WithStack(context.Canceled))
+	r.mu.Lock()
+	r.parts = map[int64]*part{}
+	r.mu.Unlock()
+	return nil
+}
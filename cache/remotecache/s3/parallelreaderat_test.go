@@ -0,0 +1,72 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParallelReaderAt(t *testing.T) {
+	t.Parallel()
+
+	dt := make([]byte, 10*1024+7)
+	_, err := rand.Read(dt)
+	require.NoError(t, err)
+
+	var requests atomic.Int64
+	ra := newParallelReaderAt(context.TODO(), int64(len(dt)), 1024, 3, func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		requests.Add(1)
+		return io.NopCloser(bytes.NewReader(dt[offset : offset+length])), nil
+	})
+	defer ra.Close()
+
+	out, err := io.ReadAll(io.NewSectionReader(ra, 0, int64(len(dt))))
+	require.NoError(t, err)
+	require.Equal(t, dt, out)
+	require.Equal(t, int64(11), requests.Load())
+
+	buf := make([]byte, 2000)
+	n, err := ra.ReadAt(buf, 500)
+	require.NoError(t, err)
+	require.Equal(t, 2000, n)
+	require.Equal(t, dt[500:2500], buf)
+
+	n, err = ra.ReadAt(buf, int64(len(dt)-10))
+	require.ErrorIs(t, err, io.EOF)
+	require.Equal(t, 10, n)
+	require.Equal(t, dt[len(dt)-10:], buf[:n])
+}
+
+func TestParallelReaderAtError(t *testing.T) {
+	t.Parallel()
+
+	var fail atomic.Bool
+	fail.Store(true)
+	ra := newParallelReaderAt(context.TODO(), 4096, 1024, 2, func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+		if offset == 2048 && fail.Load() {
+			return nil, errors.New("unavailable")
+		}
+		return io.NopCloser(bytes.NewReader(make([]byte, length))), nil
+	})
+	defer ra.Close()
+
+	buf := make([]byte, 4096)
+	_, err := ra.ReadAt(buf, 0)
+	require.ErrorContains(t, err, "unavailable")
+
+	// failed parts are requested again
+	fail.Store(false)
+	n, err := ra.ReadAt(buf, 0)
+	require.NoError(t, err)
+	require.Equal(t, 4096, n)
+
+	require.NoError(t, ra.Close())
+	_, err = ra.ReadAt(buf, 0)
+	require.ErrorIs(t, err, context.Canceled)
+}
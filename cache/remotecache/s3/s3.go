@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -19,10 +22,13 @@ import (
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/pkg/labels"
+	units "github.com/docker/go-units"
 	"github.com/moby/buildkit/cache/remotecache"
+	"github.com/moby/buildkit/cache/remotecache/encryption"
 	v1 "github.com/moby/buildkit/cache/remotecache/v1"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/compression"
 	"github.com/moby/buildkit/util/progress"
 	"github.com/moby/buildkit/worker"
@@ -33,20 +39,34 @@ import (
 )
 
 const (
-	attrBucket            = "bucket"
-	attrRegion            = "region"
-	attrPrefix            = "prefix"
-	attrManifestsPrefix   = "manifests_prefix"
-	attrBlobsPrefix       = "blobs_prefix"
-	attrName              = "name"
-	attrTouchRefresh      = "touch_refresh"
-	attrEndpointURL       = "endpoint_url"
-	attrAccessKeyID       = "access_key_id"
-	attrSecretAccessKey   = "secret_access_key"
-	attrSessionToken      = "session_token"
-	attrUsePathStyle      = "use_path_style"
-	attrUploadParallelism = "upload_parallelism"
-	maxCopyObjectSize     = 5 * 1024 * 1024 * 1024
+	attrBucket              = "bucket"
+	attrRegion              = "region"
+	attrPrefix              = "prefix"
+	attrManifestsPrefix     = "manifests_prefix"
+	attrBlobsPrefix         = "blobs_prefix"
+	attrName                = "name"
+	attrTouchRefresh        = "touch_refresh"
+	attrEndpointURL         = "endpoint_url"
+	attrAccessKeyID         = "access_key_id"
+	attrSecretAccessKey     = "secret_access_key"
+	attrSessionToken        = "session_token"
+	attrUsePathStyle        = "use_path_style"
+	attrUploadParallelism   = "upload_parallelism"
+	attrTouchOnRead         = "touch_on_read"
+	attrPartitionByDate     = "partition_by_date"
+	attrUploadPartSize      = "upload_part_size"
+	attrDownloadPartSize    = "download_part_size"
+	attrDownloadConcurrency = "download_concurrency"
+	attrUseAccelerate       = "use_accelerate_endpoint"
+	maxCopyObjectSize       = 5 * 1024 * 1024 * 1024
+
+	defaultDownloadPartSize    = 8 * 1024 * 1024
+	defaultDownloadConcurrency = 4
+
+	// metadataBlobsPartition is the metadata of a manifest with the
+	// partition of the blobs prefix its blobs are stored in
+	metadataBlobsPartition = "blobs-partition"
+	partitionDateFormat    = "2006-01-02"
 )
 
 type Config struct {
@@ -63,6 +83,20 @@ type Config struct {
 	SessionToken      string
 	UsePathStyle      bool
 	UploadParallelism int
+	// TouchOnRead refreshes the last modification time of the imported
+	// manifests and blobs that are used
+	TouchOnRead bool
+	// PartitionByDate writes the blobs under a prefix with the date of the
+	// export, copying the blobs of older exports
+	PartitionByDate bool
+	// UploadPartSize is the size of the parts of the multipart upload of a
+	// single blob, UploadParallelism parts are uploaded at the same time
+	UploadPartSize int64
+	// DownloadPartSize and DownloadConcurrency configure the parallel ranged
+	// requests reading a single blob
+	DownloadPartSize    int64
+	DownloadConcurrency int
+	UseAccelerate       bool
 }
 
 func getConfig(attrs map[string]string) (Config, error) {
@@ -140,32 +174,109 @@ func getConfig(attrs map[string]string) (Config, error) {
 		uploadParallelism = uploadParallelismInt
 	}
 
+	uploadPartSize, err := parseSize(attrs, attrUploadPartSize, manager.DefaultUploadPartSize, manager.MinUploadPartSize)
+	if err != nil {
+		return Config{}, err
+	}
+	downloadPartSize, err := parseSize(attrs, attrDownloadPartSize, defaultDownloadPartSize, 1024*1024)
+	if err != nil {
+		return Config{}, err
+	}
+	downloadConcurrency, err := parseConcurrency(attrs, attrDownloadConcurrency, defaultDownloadConcurrency)
+	if err != nil {
+		return Config{}, err
+	}
+
+	useAccelerate := false
+	if v, ok := attrs[attrUseAccelerate]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, errors.Wrapf(err, "failed to parse %s", attrUseAccelerate)
+		}
+		useAccelerate = b
+	}
+
+	touchOnRead := false
+	if v, ok := attrs[attrTouchOnRead]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, errors.Wrapf(err, "failed to parse %s", attrTouchOnRead)
+		}
+		touchOnRead = b
+	}
+
+	partitionByDate := false
+	if v, ok := attrs[attrPartitionByDate]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return Config{}, errors.Wrapf(err, "failed to parse %s", attrPartitionByDate)
+		}
+		partitionByDate = b
+	}
+
 	return Config{
-		Bucket:            bucket,
-		Region:            region,
-		Prefix:            prefix,
-		ManifestsPrefix:   manifestsPrefix,
-		BlobsPrefix:       blobsPrefix,
-		Names:             names,
-		TouchRefresh:      touchRefresh,
-		EndpointURL:       endpointURL,
-		AccessKeyID:       accessKeyID,
-		SecretAccessKey:   secretAccessKey,
-		SessionToken:      sessionToken,
-		UsePathStyle:      usePathStyle,
-		UploadParallelism: uploadParallelism,
+		Bucket:              bucket,
+		Region:              region,
+		Prefix:              prefix,
+		ManifestsPrefix:     manifestsPrefix,
+		BlobsPrefix:         blobsPrefix,
+		Names:               names,
+		TouchRefresh:        touchRefresh,
+		EndpointURL:         endpointURL,
+		AccessKeyID:         accessKeyID,
+		SecretAccessKey:     secretAccessKey,
+		SessionToken:        sessionToken,
+		UsePathStyle:        usePathStyle,
+		UploadParallelism:   uploadParallelism,
+		TouchOnRead:         touchOnRead,
+		PartitionByDate:     partitionByDate,
+		UploadPartSize:      uploadPartSize,
+		DownloadPartSize:    downloadPartSize,
+		DownloadConcurrency: downloadConcurrency,
+		UseAccelerate:       useAccelerate,
 	}, nil
 }
 
+func parseSize(attrs map[string]string, attr string, def, minSize int64) (int64, error) {
+	v, ok := attrs[attr]
+	if !ok {
+		return def, nil
+	}
+	n, err := units.RAMInBytes(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse %s", attr)
+	}
+	if n < minSize {
+		return 0, errors.Errorf("%s must be at least %s", attr, units.BytesSize(float64(minSize)))
+	}
+	return n, nil
+}
+
+func parseConcurrency(attrs map[string]string, attr string, def int) (int, error) {
+	v, ok := attrs[attr]
+	if !ok {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, errors.Errorf("%s must be a positive integer", attr)
+	}
+	return n, nil
+}
+
 // ResolveCacheExporterFunc for s3 cache exporter.
-func ResolveCacheExporterFunc() remotecache.ResolveCacheExporterFunc {
+func ResolveCacheExporterFunc(sm *session.Manager) remotecache.ResolveCacheExporterFunc {
 	return func(ctx context.Context, g session.Group, attrs map[string]string) (remotecache.Exporter, error) {
 		config, err := getConfig(attrs)
 		if err != nil {
 			return nil, err
 		}
+		key, err := encryption.FromSession(ctx, sm, g, attrs)
+		if err != nil {
+			return nil, err
+		}
 
-		s3Client, err := newS3Client(ctx, config)
+		s3Client, err := newS3Client(ctx, config, key)
 		if err != nil {
 			return nil, err
 		}
@@ -203,6 +314,25 @@ func (e *exporter) Finalize(ctx context.Context) (map[string]string, error) {
 		return nil, err
 	}
 
+	var metadata map[string]string
+	var prevPartitions []string
+	if e.config.PartitionByDate {
+		e.s3Client.partition = time.Now().UTC().Format(partitionDateFormat)
+		metadata = map[string]string{metadataBlobsPartition: e.s3Client.partition}
+		// the blobs of the previous exports of all the names are copied
+		// instead of uploaded again if they are not in the partition of
+		// today yet
+		for _, name := range e.config.Names {
+			p, err := e.s3Client.manifestPartition(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if p != e.s3Client.partition && !slices.Contains(prevPartitions, p) {
+				prevPartitions = append(prevPartitions, p)
+			}
+		}
+	}
+
 	eg, groupCtx := errgroup.WithContext(ctx)
 	tasks := make(chan int, e.config.UploadParallelism)
 
@@ -240,11 +370,13 @@ func (e *exporter) Finalize(ctx context.Context) (map[string]string, error) {
 				}
 				if exists != nil {
 					if time.Since(*exists) > e.config.TouchRefresh {
-						err = e.s3Client.touch(groupCtx, key, size)
+						err = e.s3Client.touch(groupCtx, key, size, nil)
 						if err != nil {
 							return errors.Wrapf(err, "failed to touch file")
 						}
 					}
+				} else if p, ok := e.s3Client.copyBlobFrom(groupCtx, prevPartitions, dgstPair.Descriptor.Digest); ok {
+					bklog.G(groupCtx).Debugf("copied layer %s from partition %q", blob, p)
 				} else {
 					layerDone := progress.OneOff(groupCtx, fmt.Sprintf("writing layer %s", blob))
 					// TODO: once buildkit uses v2, start using
@@ -255,7 +387,13 @@ func (e *exporter) Finalize(ctx context.Context) (map[string]string, error) {
 						return layerDone(errors.Wrap(err, "error reading layer blob from provider"))
 					}
 					defer ra.Close()
-					if err := e.s3Client.saveMutableAt(groupCtx, key, &nopCloserSectionReader{io.NewSectionReader(ra, 0, ra.Size())}); err != nil {
+					var body io.Reader = &nopCloserSectionReader{io.NewSectionReader(ra, 0, ra.Size())}
+					if e.s3Client.key != nil {
+						if body, err = e.s3Client.key.Encrypt(body, ra.Size(), dgstPair.Descriptor.Digest.String()); err != nil {
+							return layerDone(err)
+						}
+					}
+					if err := e.s3Client.saveMutableAt(groupCtx, key, body, nil); err != nil {
 						return layerDone(errors.Wrap(err, "error writing layer blob"))
 					}
 					layerDone(nil)
@@ -289,7 +427,13 @@ func (e *exporter) Finalize(ctx context.Context) (map[string]string, error) {
 	}
 
 	for _, name := range e.config.Names {
-		if err := e.s3Client.saveMutableAt(ctx, e.s3Client.manifestKey(name), bytes.NewReader(dt)); err != nil {
+		mdt := dt
+		if e.s3Client.key != nil {
+			if mdt, err = e.s3Client.key.EncryptBytes(dt, manifestID(name)); err != nil {
+				return nil, err
+			}
+		}
+		if err := e.s3Client.saveMutableAt(ctx, e.s3Client.manifestKey(name), bytes.NewReader(mdt), metadata); err != nil {
 			return nil, errors.Wrapf(err, "error writing manifest: %s", name)
 		}
 	}
@@ -297,23 +441,37 @@ func (e *exporter) Finalize(ctx context.Context) (map[string]string, error) {
 }
 
 // ResolveCacheImporterFunc for s3 cache importer.
-func ResolveCacheImporterFunc() remotecache.ResolveCacheImporterFunc {
-	return func(ctx context.Context, _ session.Group, attrs map[string]string) (remotecache.Importer, ocispecs.Descriptor, error) {
+func ResolveCacheImporterFunc(sm *session.Manager) remotecache.ResolveCacheImporterFunc {
+	return func(ctx context.Context, g session.Group, attrs map[string]string) (remotecache.Importer, ocispecs.Descriptor, error) {
 		config, err := getConfig(attrs)
 		if err != nil {
 			return nil, ocispecs.Descriptor{}, err
 		}
-		s3Client, err := newS3Client(ctx, config)
+		key, err := encryption.FromSession(ctx, sm, g, attrs)
 		if err != nil {
 			return nil, ocispecs.Descriptor{}, err
 		}
-		return &importer{s3Client, config}, ocispecs.Descriptor{}, nil
+		s3Client, err := newS3Client(ctx, config, key)
+		if err != nil {
+			return nil, ocispecs.Descriptor{}, err
+		}
+		return &importer{
+			s3Client: s3Client,
+			config:   config,
+			touchSem: make(chan struct{}, config.UploadParallelism),
+		}, ocispecs.Descriptor{}, nil
 	}
 }
 
 type importer struct {
 	s3Client *s3Client
 	config   Config
+
+	// touched are the keys of the objects that were already checked by
+	// touch_on_read
+	touched sync.Map
+	// touchSem limits the objects that are touched at the same time
+	touchSem chan struct{}
 }
 
 func (i *importer) makeDescriptorProviderPair(l v1.CacheLayer) (*v1.DescriptorProviderPair, error) {
@@ -332,7 +490,7 @@ func (i *importer) makeDescriptorProviderPair(l v1.CacheLayer) (*v1.DescriptorPr
 		}
 		annotations["buildkit/createdat"] = string(txt)
 	}
-	return &v1.DescriptorProviderPair{
+	dpp := &v1.DescriptorProviderPair{
 		Provider: i.s3Client,
 		Descriptor: ocispecs.Descriptor{
 			MediaType:   l.Annotations.MediaType,
@@ -340,18 +498,77 @@ func (i *importer) makeDescriptorProviderPair(l v1.CacheLayer) (*v1.DescriptorPr
 			Size:        l.Annotations.Size,
 			Annotations: annotations,
 		},
+	}
+	if i.config.TouchOnRead {
+		// Info is called for the blobs of the cache results that are
+		// loaded, including the blobs that are not pulled
+		dpp.InfoProvider = &touchingInfoProvider{importer: i, desc: dpp.Descriptor}
+	}
+	return dpp, nil
+}
+
+type touchingInfoProvider struct {
+	importer *importer
+	desc     ocispecs.Descriptor
+}
+
+func (p *touchingInfoProvider) Info(ctx context.Context, dgst digest.Digest) (content.Info, error) {
+	if dgst != p.desc.Digest {
+		return content.Info{}, errors.Errorf("content not found %s", dgst)
+	}
+	p.importer.touchInBackground(ctx, p.importer.s3Client.blobKey(dgst))
+	return content.Info{
+		Digest: p.desc.Digest,
+		Size:   p.desc.Size,
 	}, nil
 }
 
+// touchInBackground calls touchIfOld for key without blocking the caller.
+// Every object is only checked once by the importer.
+func (i *importer) touchInBackground(ctx context.Context, key string) {
+	if _, loaded := i.touched.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		i.touchSem <- struct{}{}
+		defer func() { <-i.touchSem }()
+		i.touchIfOld(ctx, key, nil)
+	}()
+}
+
+// touchIfOld refreshes the last modification time of an object that was not
+// modified for touch_refresh. Errors are only logged as the credentials of
+// the importer don't need write access.
+func (i *importer) touchIfOld(ctx context.Context, key string, metadata map[string]string) {
+	modified, size, err := i.s3Client.exists(ctx, key)
+	if err != nil || modified == nil {
+		if err != nil {
+			bklog.G(ctx).Warnf("failed to check s3 cache object %s: %v", key, err)
+		}
+		return
+	}
+	if time.Since(*modified) <= i.config.TouchRefresh {
+		return
+	}
+	if err := i.s3Client.touch(ctx, key, size, metadata); err != nil {
+		bklog.G(ctx).Warnf("failed to touch s3 cache object %s: %v", key, err)
+	}
+}
+
 func (i *importer) load(ctx context.Context) (*v1.CacheChains, error) {
 	var config v1.CacheConfig
-	found, err := i.s3Client.getManifest(ctx, i.s3Client.manifestKey(i.config.Names[0]), &config)
+	found, metadata, err := i.s3Client.getManifest(ctx, i.config.Names[0], &config)
 	if err != nil {
 		return nil, err
 	}
 	if !found {
 		return v1.NewCacheChains(), nil
 	}
+	i.s3Client.partition = metadata[metadataBlobsPartition]
+	if i.config.TouchOnRead {
+		i.touchIfOld(ctx, i.s3Client.manifestKey(i.config.Names[0]), metadata)
+	}
 
 	allLayers := v1.DescriptorProvider{}
 
@@ -400,9 +617,17 @@ type s3Client struct {
 	prefix          string
 	blobsPrefix     string
 	manifestsPrefix string
+	// key encrypts the blobs and manifests if set
+	key *encryption.Key
+	// partition is the date prefix of the blobs, if the blobs are
+	// partitioned by date
+	partition string
+
+	downloadPartSize    int64
+	downloadConcurrency int
 }
 
-func newS3Client(ctx context.Context, config Config) (*s3Client, error) {
+func newS3Client(ctx context.Context, config Config, key *encryption.Key) (*s3Client, error) {
 	cfg, err := aws_config.LoadDefaultConfig(ctx, aws_config.WithRegion(config.Region))
 	if err != nil {
 		return nil, errors.Errorf("Unable to load AWS SDK config, %v", err)
@@ -415,19 +640,28 @@ func newS3Client(ctx context.Context, config Config) (*s3Client, error) {
 			options.UsePathStyle = config.UsePathStyle
 			options.BaseEndpoint = aws.String(config.EndpointURL)
 		}
+		options.UseAccelerate = config.UseAccelerate
 	})
 
 	return &s3Client{
-		Client:          client,
-		Uploader:        manager.NewUploader(client),
+		Client: client,
+		Uploader: manager.NewUploader(client, func(u *manager.Uploader) {
+			u.PartSize = config.UploadPartSize
+			u.Concurrency = config.UploadParallelism
+		}),
 		bucket:          config.Bucket,
 		prefix:          config.Prefix,
 		blobsPrefix:     config.BlobsPrefix,
 		manifestsPrefix: config.ManifestsPrefix,
+		key:             key,
+
+		downloadPartSize:    config.DownloadPartSize,
+		downloadConcurrency: config.DownloadConcurrency,
 	}, nil
 }
 
-func (s3Client *s3Client) getManifest(ctx context.Context, key string, config *v1.CacheConfig) (bool, error) {
+func (s3Client *s3Client) getManifest(ctx context.Context, name string, config *v1.CacheConfig) (bool, map[string]string, error) {
+	key := s3Client.manifestKey(name)
 	input := &s3.GetObjectInput{
 		Bucket: &s3Client.bucket,
 		Key:    &key,
@@ -436,21 +670,25 @@ func (s3Client *s3Client) getManifest(ctx context.Context, key string, config *v
 	output, err := s3Client.GetObject(ctx, input)
 	if err != nil {
 		if isNotFound(err) {
-			return false, nil
+			return false, nil, nil
 		}
-		return false, err
+		return false, nil, err
 	}
 	defer output.Body.Close()
 
-	decoder := json.NewDecoder(output.Body)
+	var body io.Reader = output.Body
+	if s3Client.key != nil {
+		body = s3Client.key.Decrypt(body, manifestID(name))
+	}
+	decoder := json.NewDecoder(body)
 	if err := decoder.Decode(config); err != nil {
-		return false, errors.WithStack(err)
+		return false, nil, errors.WithStack(err)
 	}
 	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
-		return false, errors.Errorf("unexpected data after JSON object")
+		return false, nil, errors.Errorf("unexpected data after JSON object")
 	}
 
-	return true, nil
+	return true, output.Metadata, nil
 }
 
 func (s3Client *s3Client) getReader(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
@@ -469,11 +707,24 @@ func (s3Client *s3Client) getReader(ctx context.Context, key string, offset int6
 	return output.Body, nil
 }
 
-func (s3Client *s3Client) saveMutableAt(ctx context.Context, key string, body io.Reader) error {
-	input := &s3.PutObjectInput{
+func (s3Client *s3Client) getRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	output, err := s3Client.GetObject(ctx, &s3.GetObjectInput{
 		Bucket: &s3Client.bucket,
 		Key:    &key,
-		Body:   body,
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (s3Client *s3Client) saveMutableAt(ctx context.Context, key string, body io.Reader, metadata map[string]string) error {
+	input := &s3.PutObjectInput{
+		Bucket:   &s3Client.bucket,
+		Key:      &key,
+		Body:     body,
+		Metadata: metadata,
 	}
 	_, err := s3Client.Upload(ctx, input)
 	return err
@@ -495,6 +746,54 @@ func (s3Client *s3Client) exists(ctx context.Context, key string) (*time.Time, *
 	return head.LastModified, head.ContentLength, nil
 }
 
+// manifestPartition returns the partition of the blobs of the manifest name
+func (s3Client *s3Client) manifestPartition(ctx context.Context, name string) (string, error) {
+	key := s3Client.manifestKey(name)
+	head, err := s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &s3Client.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return head.Metadata[metadataBlobsPartition], nil
+}
+
+// copyBlobFrom copies a blob from the first of the partitions that has it to
+// the current partition
+func (s3Client *s3Client) copyBlobFrom(ctx context.Context, partitions []string, dgst digest.Digest) (string, bool) {
+	for _, p := range partitions {
+		if s3Client.copyBlob(ctx, p, dgst) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// copyBlob copies a blob from another partition to the current partition.
+// False is returned if the blob can't be copied and needs to be uploaded.
+func (s3Client *s3Client) copyBlob(ctx context.Context, partition string, dgst digest.Digest) bool {
+	src := s3Client.partitionBlobKey(partition, dgst)
+	exists, size, err := s3Client.exists(ctx, src)
+	if err != nil || exists == nil || size == nil || *size >= maxCopyObjectSize {
+		return false
+	}
+	copySource := fmt.Sprintf("%s/%s", s3Client.bucket, src)
+	key := s3Client.blobKey(dgst)
+	if _, err := s3Client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     &s3Client.bucket,
+		CopySource: &copySource,
+		Key:        &key,
+	}); err != nil {
+		bklog.G(ctx).Debugf("failed to copy %s to %s: %v", src, key, err)
+		return false
+	}
+	return true
+}
+
 func buildCopySourceRange(start int64, objectSize int64) string {
 	end := start + maxCopyObjectSize - 1
 	if end > objectSize {
@@ -505,8 +804,15 @@ func buildCopySourceRange(start int64, objectSize int64) string {
 	return "bytes=" + startRange + "-" + stopRange
 }
 
-func (s3Client *s3Client) touch(ctx context.Context, key string, size *int64) (err error) {
+// touch copies an object in place to refresh its last modification time. The
+// metadata replaces the metadata of the object.
+func (s3Client *s3Client) touch(ctx context.Context, key string, size *int64, metadata map[string]string) (err error) {
 	copySource := fmt.Sprintf("%s/%s", s3Client.bucket, key)
+	metadata = maps.Clone(metadata)
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+	metadata["updated-at"] = time.Now().String()
 
 	// CopyObject does not support files > 5GB
 	if *size < maxCopyObjectSize {
@@ -514,7 +820,7 @@ func (s3Client *s3Client) touch(ctx context.Context, key string, size *int64) (e
 			Bucket:            &s3Client.bucket,
 			CopySource:        &copySource,
 			Key:               &key,
-			Metadata:          map[string]string{"updated-at": time.Now().String()},
+			Metadata:          metadata,
 			MetadataDirective: "REPLACE",
 		}
 
@@ -523,8 +829,9 @@ func (s3Client *s3Client) touch(ctx context.Context, key string, size *int64) (e
 		return err
 	}
 	input := &s3.CreateMultipartUploadInput{
-		Bucket: &s3Client.bucket,
-		Key:    &key,
+		Bucket:   &s3Client.bucket,
+		Key:      &key,
+		Metadata: metadata,
 	}
 
 	output, err := s3Client.CreateMultipartUpload(ctx, input)
@@ -589,18 +896,64 @@ func (s3Client *s3Client) touch(ctx context.Context, key string, size *int64) (e
 }
 
 func (s3Client *s3Client) ReaderAt(ctx context.Context, desc ocispecs.Descriptor) (content.ReaderAt, error) {
-	readerAtCloser := toReaderAtCloser(func(offset int64) (io.ReadCloser, error) {
-		return s3Client.getReader(ctx, s3Client.blobKey(desc.Digest), offset)
-	})
+	key := s3Client.blobKey(desc.Digest)
+	size := desc.Size
+	if s3Client.key != nil {
+		size = s3Client.key.EncryptedSize(desc.Size)
+	}
+	var readerAtCloser ReaderAtCloser
+	if s3Client.downloadConcurrency > 1 && size > s3Client.downloadPartSize {
+		readerAtCloser = newParallelReaderAt(ctx, size, s3Client.downloadPartSize, s3Client.downloadConcurrency, func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+			return s3Client.getRange(ctx, key, offset, length)
+		})
+	} else {
+		readerAtCloser = toReaderAtCloser(func(offset int64) (io.ReadCloser, error) {
+			return s3Client.getReader(ctx, key, offset)
+		})
+	}
+	if s3Client.key != nil {
+		ra, err := s3Client.key.ReaderAt(readerAtCloser, desc.Digest.String())
+		if err != nil {
+			readerAtCloser.Close()
+			return nil, err
+		}
+		if ra.Size() != desc.Size {
+			readerAtCloser.Close()
+			return nil, errors.Errorf("unexpected size of encrypted blob %s: %d != %d", desc.Digest, ra.Size(), desc.Size)
+		}
+		return &readerAt{ReaderAtCloser: &decryptingReaderAt{ReaderAt: ra, Closer: readerAtCloser}, size: desc.Size}, nil
+	}
 	return &readerAt{ReaderAtCloser: readerAtCloser, size: desc.Size}, nil
 }
 
+type decryptingReaderAt struct {
+	io.ReaderAt
+	io.Closer
+}
+
 func (s3Client *s3Client) manifestKey(name string) string {
 	return s3Client.prefix + s3Client.manifestsPrefix + name
 }
 
 func (s3Client *s3Client) blobKey(dgst digest.Digest) string {
-	return s3Client.prefix + s3Client.blobsPrefix + dgst.String()
+	return s3Client.partitionBlobKey(s3Client.partition, dgst)
+}
+
+func (s3Client *s3Client) partitionBlobKey(partition string, dgst digest.Digest) string {
+	key := s3Client.prefix + s3Client.blobsPrefix
+	if partition != "" {
+		key += partition + "/"
+	}
+	key += dgst.String()
+	if s3Client.key != nil {
+		key += encryption.BlobSuffix
+	}
+	return key
+}
+
+// manifestID binds an encrypted manifest to its name
+func manifestID(name string) string {
+	return "manifests/" + name
 }
 
 func isNotFound(err error) bool {
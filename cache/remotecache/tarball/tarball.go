@@ -0,0 +1,231 @@
+package tarball
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/containerd/containerd/v2/core/content"
+	contentlocal "github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/moby/buildkit/cache/remotecache"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/util/compression"
+	"github.com/moby/buildkit/util/contentutil"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	attrPath          = "path"
+	attrDigest        = "digest"
+	attrTag           = "tag"
+	attrImageManifest = "image-manifest"
+	attrOCIMediatypes = "oci-mediatypes"
+)
+
+type exporter struct {
+	remotecache.Exporter
+	store *lazyStore
+	dest  string
+	tag   string
+}
+
+func (*exporter) Name() string {
+	return "exporting cache to tarball"
+}
+
+func (e *exporter) Finalize(ctx context.Context) (map[string]string, error) {
+	defer e.store.cleanup()
+
+	res, err := e.Exporter.Finalize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dt, ok := res[remotecache.ExporterResponseManifestDesc]
+	if !ok {
+		return res, nil
+	}
+	var desc ocispecs.Descriptor
+	if err := json.Unmarshal([]byte(dt), &desc); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(e.dest), ".tmp-"+filepath.Base(e.dest))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer os.Remove(f.Name())
+	err = contentutil.WriteOCILayoutTar(ctx, e.store, desc, e.tag, f)
+	if err1 := f.Close(); err == nil {
+		err = errors.WithStack(err1)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write cache tarball %s", e.dest)
+	}
+	if err := os.Rename(f.Name(), e.dest); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return res, nil
+}
+
+// ResolveCacheExporterFunc for "tarball" cache exporter. The tarballs are
+// written to paths relative to root on the daemon host.
+func ResolveCacheExporterFunc(root string) remotecache.ResolveCacheExporterFunc {
+	return func(ctx context.Context, g session.Group, attrs map[string]string) (remotecache.Exporter, error) {
+		dest, err := resolvePath(root, attrs[attrPath])
+		if err != nil {
+			return nil, err
+		}
+		compressionConfig, err := compression.ParseAttributes(attrs)
+		if err != nil {
+			return nil, err
+		}
+		ociMediatypes := true
+		if v, ok := attrs[attrOCIMediatypes]; ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse %s", attrOCIMediatypes)
+			}
+			ociMediatypes = b
+		}
+		imageManifest := true
+		if v, ok := attrs[attrImageManifest]; ok {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse %s", attrImageManifest)
+			}
+			imageManifest = b
+		} else if !ociMediatypes {
+			imageManifest = false
+		}
+		tag := "latest"
+		if t, ok := attrs[attrTag]; ok {
+			tag = t
+		}
+
+		if err := os.MkdirAll(filepath.Dir(dest), 0700); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		store := &lazyStore{root: filepath.Dir(dest)}
+		return &exporter{
+			Exporter: remotecache.NewExporter(store, "", ociMediatypes, imageManifest, compressionConfig),
+			store:    store,
+			dest:     dest,
+			tag:      tag,
+		}, nil
+	}
+}
+
+// ResolveCacheImporterFunc for "tarball" cache importer. The tarballs are
+// read from paths relative to root on the daemon host.
+func ResolveCacheImporterFunc(root string) remotecache.ResolveCacheImporterFunc {
+	return func(ctx context.Context, _ session.Group, attrs map[string]string) (remotecache.Importer, ocispecs.Descriptor, error) {
+		src, err := resolvePath(root, attrs[attrPath])
+		if err != nil {
+			return nil, ocispecs.Descriptor{}, err
+		}
+		t, err := contentutil.OpenOCILayoutTar(src)
+		if err != nil {
+			return nil, ocispecs.Descriptor{}, err
+		}
+
+		var desc ocispecs.Descriptor
+		if dgstStr := attrs[attrDigest]; dgstStr != "" {
+			dgst, err := digest.Parse(dgstStr)
+			if err != nil {
+				return nil, ocispecs.Descriptor{}, errors.Wrapf(err, "invalid %s", attrDigest)
+			}
+			info, err := t.Info(ctx, dgst)
+			if err != nil {
+				return nil, ocispecs.Descriptor{}, err
+			}
+			desc = ocispecs.Descriptor{
+				Digest: dgst,
+				Size:   info.Size,
+			}
+		} else {
+			tag := "latest"
+			if v, ok := attrs[attrTag]; ok {
+				tag = v
+			}
+			d, err := t.Get(tag)
+			if err != nil {
+				return nil, ocispecs.Descriptor{}, err
+			}
+			desc = *d
+		}
+		return remotecache.NewImporter(t), desc, nil
+	}
+}
+
+// resolvePath returns the path of a tarball on the daemon host. Paths can't
+// point outside of root.
+func resolvePath(root, p string) (string, error) {
+	if p == "" {
+		return "", errors.New("tarball cache exporter/importer requires path")
+	}
+	if root == "" {
+		return "", errors.New("tarball cache exporter/importer is not enabled")
+	}
+	return filepath.Join(root, filepath.Clean("/"+p)), nil
+}
+
+// lazyStore is a temporary content store created on the first write. The
+// blobs of the cache are collected in it before the tarball is written.
+type lazyStore struct {
+	root string
+
+	mu    sync.Mutex
+	dir   string
+	store content.Store
+}
+
+func (s *lazyStore) get() (content.Store, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store != nil {
+		return s.store, nil
+	}
+	dir, err := os.MkdirTemp(s.root, ".tmp-cache-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	store, err := contentlocal.NewStore(dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	s.dir = dir
+	s.store = store
+	return store, nil
+}
+
+func (s *lazyStore) Writer(ctx context.Context, opts ...content.WriterOpt) (content.Writer, error) {
+	store, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+	return store.Writer(ctx, opts...)
+}
+
+func (s *lazyStore) ReaderAt(ctx context.Context, desc ocispecs.Descriptor) (content.ReaderAt, error) {
+	store, err := s.get()
+	if err != nil {
+		return nil, err
+	}
+	return store.ReaderAt(ctx, desc)
+}
+
+func (s *lazyStore) cleanup() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir != "" {
+		os.RemoveAll(s.dir)
+	}
+	s.dir = ""
+	s.store = nil
+}
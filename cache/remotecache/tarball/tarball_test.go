@@ -0,0 +1,100 @@
+package tarball
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/moby/buildkit/cache/remotecache"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/util/cachedigest"
+	"github.com/moby/buildkit/util/contentutil"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImport(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+	root := t.TempDir()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte("layer"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	layer := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageLayerGzip,
+		Digest:    digest.FromBytes(buf.Bytes()),
+		Size:      int64(buf.Len()),
+	}
+	provider := contentutil.NewBuffer()
+	require.NoError(t, content.WriteBlob(ctx, provider, "layer", bytes.NewReader(buf.Bytes()), layer))
+
+	exp, err := ResolveCacheExporterFunc(root)(ctx, nil, map[string]string{attrPath: "caches/cache.tar", attrTag: "v1"})
+	require.NoError(t, err)
+	// the records of the cache are added by the root keys of the vertices,
+	// like the solver does
+	key := digest.FromString("key")
+	rootKey, err := cachedigest.FromBytes(fmt.Appendf(nil, "%s@%d", key, 0), cachedigest.TypeString)
+	require.NoError(t, err)
+	exp.Add(rootKey).AddResult(digest.FromString("vtx"), 0, time.Now(), &solver.Remote{
+		Descriptors: []ocispecs.Descriptor{layer},
+		Provider:    provider,
+	})
+	res, err := exp.Finalize(ctx)
+	require.NoError(t, err)
+	var mfst ocispecs.Descriptor
+	require.NoError(t, json.Unmarshal([]byte(res[remotecache.ExporterResponseManifestDesc]), &mfst))
+
+	// the temporary content store is removed once the tarball is written
+	entries, err := os.ReadDir(filepath.Join(root, "caches"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "cache.tar", entries[0].Name())
+
+	t.Run("tag", func(t *testing.T) {
+		im, desc, err := ResolveCacheImporterFunc(root)(ctx, nil, map[string]string{attrPath: "caches/cache.tar", attrTag: "v1"})
+		require.NoError(t, err)
+		require.Equal(t, mfst.Digest, desc.Digest)
+
+		cm, err := im.Resolve(ctx, desc, "test", nil)
+		require.NoError(t, err)
+		keys, err := cm.Query(nil, 0, key, 0)
+		require.NoError(t, err)
+		require.Len(t, keys, 1)
+	})
+
+	t.Run("digest", func(t *testing.T) {
+		_, desc, err := ResolveCacheImporterFunc(root)(ctx, nil, map[string]string{attrPath: "caches/cache.tar", attrDigest: mfst.Digest.String()})
+		require.NoError(t, err)
+		require.Equal(t, mfst.Digest, desc.Digest)
+		require.Equal(t, mfst.Size, desc.Size)
+	})
+
+	t.Run("missing tag", func(t *testing.T) {
+		_, _, err := ResolveCacheImporterFunc(root)(ctx, nil, map[string]string{attrPath: "caches/cache.tar"})
+		require.Error(t, err)
+	})
+}
+
+func TestResolvePath(t *testing.T) {
+	t.Parallel()
+
+	p, err := resolvePath("/var/lib/buildkit/cache-tarballs", "../../etc/cache.tar")
+	require.NoError(t, err)
+	require.Equal(t, "/var/lib/buildkit/cache-tarballs/etc/cache.tar", p)
+
+	_, err = resolvePath("/var/lib/buildkit/cache-tarballs", "")
+	require.ErrorContains(t, err, "requires path")
+	_, err = resolvePath("", "cache.tar")
+	require.ErrorContains(t, err, "not enabled")
+}
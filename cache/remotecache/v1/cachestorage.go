@@ -2,12 +2,16 @@ package cacheimport
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"slices"
 	"time"
 
+	"github.com/moby/buildkit/cache"
 	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/compression"
 	"github.com/moby/buildkit/worker"
 	digest "github.com/opencontainers/go-digest"
@@ -259,7 +263,17 @@ func (cs *cacheResultStorage) LoadWithParents(ctx context.Context, res solver.Ca
 				if !ok {
 					return nil
 				}
-				if isSubRemote(*i.result, *v.result) {
+				switch {
+				case i == v.item:
+					ref, err := cs.loadRemote(ctx, i.result)
+					if err != nil {
+						return err
+					}
+					m[id] = worker.NewWorkerRefResult(ref, cs.w)
+				case i.result.Composition != nil && len(i.result.Descriptors) == 0:
+					// results that only exist as a composition are not
+					// part of the layers of v
+				case isSubRemote(*i.result, *v.result):
 					ref, err := cs.w.FromRemote(ctx, i.result)
 					if err != nil {
 						return err
@@ -285,16 +299,69 @@ func (cs *cacheResultStorage) Load(ctx context.Context, res solver.CacheResult)
 		return nil, errors.WithStack(solver.ErrNotFound)
 	}
 
-	ref, err := cs.w.FromRemote(ctx, item.result)
+	ref, err := cs.loadRemote(ctx, item.result)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to load result from remote")
 	}
 	return worker.NewWorkerRefResult(ref, cs.w), nil
 }
 
+// loadRemote loads the layers of a remote. Merges and diffs whose layers are
+// not available are rebuilt from their inputs.
+func (cs *cacheResultStorage) loadRemote(ctx context.Context, r *solver.Remote) (cache.ImmutableRef, error) {
+	if r.Composition == nil {
+		return cs.w.FromRemote(ctx, r)
+	}
+	if len(r.Descriptors) > 0 {
+		ref, err := cs.w.FromRemote(ctx, r)
+		if err == nil {
+			return ref, nil
+		}
+		bklog.G(ctx).WithError(err).Debugf("failed to load %s result from remote, loading from its inputs", r.Composition.Kind)
+	}
+
+	refs := make([]cache.ImmutableRef, 0, len(r.Composition.Inputs))
+	defer func() {
+		for _, ref := range refs {
+			if ref != nil {
+				ref.Release(context.WithoutCancel(ctx))
+			}
+		}
+	}()
+	for _, in := range r.Composition.Inputs {
+		ref, err := cs.loadRemote(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		refs = append(refs, ref)
+	}
+
+	pg := solver.ProgressControllerFromContext(ctx)
+	switch r.Composition.Kind {
+	case solver.RemoteMerge:
+		return cs.w.CacheManager().Merge(ctx, refs, pg)
+	case solver.RemoteDiff:
+		if len(refs) != 2 {
+			return nil, errors.Errorf("invalid number of diff inputs %d", len(refs))
+		}
+		lower, upper := refs[0], refs[1]
+		switch {
+		case lower == nil && upper == nil:
+			return nil, nil
+		case lower == nil:
+			// the diff of scratch and upper is upper
+			return upper.Clone(), nil
+		case upper != nil && lower.ID() == upper.ID():
+			return nil, nil
+		}
+		return cs.w.CacheManager().Diff(ctx, lower, upper, pg)
+	}
+	return nil, errors.Errorf("invalid composition kind %q", r.Composition.Kind)
+}
+
 func (cs *cacheResultStorage) LoadRemotes(ctx context.Context, res solver.CacheResult, compressionopts *compression.Config, _ session.Group) ([]*solver.Remote, error) {
 	if r := cs.byResultID(res.ID); r != nil && r.result != nil {
-		if compressionopts == nil {
+		if compressionopts == nil || (len(r.result.Descriptors) == 0 && r.result.Composition != nil) {
 			return []*solver.Remote{r.result}, nil
 		}
 		// Any of blobs in the remote must meet the specified compression option.
@@ -338,8 +405,20 @@ func (cs *cacheResultStorage) byResultID(resultID string) *itemWithOutgoingLinks
 // unique ID per remote. this ID is not stable.
 func remoteID(r *solver.Remote) string {
 	dgstr := digest.Canonical.Digester()
+	writeRemoteID(dgstr.Hash(), r)
+	return dgstr.Digest().String()
+}
+
+func writeRemoteID(w io.Writer, r *solver.Remote) {
 	for _, desc := range r.Descriptors {
-		dgstr.Hash().Write([]byte(desc.Digest))
+		w.Write([]byte(desc.Digest))
+	}
+	if c := r.Composition; c != nil {
+		fmt.Fprintf(w, "%s(", c.Kind)
+		for _, in := range c.Inputs {
+			writeRemoteID(w, in)
+			w.Write([]byte(","))
+		}
+		w.Write([]byte(")"))
 	}
-	return dgstr.Digest().String()
 }
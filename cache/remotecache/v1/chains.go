@@ -107,8 +107,9 @@ func (c *CacheChains) Marshal(ctx context.Context) (*CacheConfig, DescriptorProv
 	}
 
 	cc := CacheConfig{
-		Layers:  st.layers,
-		Records: st.records,
+		Layers:      st.layers,
+		Records:     st.records,
+		KeyVersions: solver.CacheKeyVersions(),
 	}
 	sortConfig(&cc)
 
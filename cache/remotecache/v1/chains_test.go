@@ -95,3 +95,102 @@ func TestSimpleMarshal(t *testing.T) {
 func dgst(s string) digest.Digest {
 	return digest.FromBytes([]byte(s))
 }
+
+func TestMarshalComposed(t *testing.T) {
+	cc := NewCacheChains()
+
+	base := &solver.Remote{Descriptors: []ocispecs.Descriptor{{Digest: dgst("d0")}}}
+	upper := &solver.Remote{Descriptors: []ocispecs.Descriptor{{Digest: dgst("d0")}, {Digest: dgst("d1")}}}
+	diff := &solver.Remote{
+		Descriptors: []ocispecs.Descriptor{{Digest: dgst("d2")}},
+		Composition: &solver.RemoteComposition{
+			Kind:   solver.RemoteDiff,
+			Inputs: []*solver.Remote{base, upper},
+		},
+	}
+	merge := &solver.Remote{
+		Descriptors: []ocispecs.Descriptor{{Digest: dgst("d3")}, {Digest: dgst("d2")}},
+		Composition: &solver.RemoteComposition{
+			Kind: solver.RemoteMerge,
+			Inputs: []*solver.Remote{
+				{Descriptors: []ocispecs.Descriptor{{Digest: dgst("d3")}}},
+				diff,
+			},
+		},
+	}
+
+	foo := cc.Add(outputKey(dgst("foo"), 0))
+	foo.AddResult("", 0, time.Now(), diff)
+	bar := cc.Add(outputKey(dgst("bar"), 0))
+	bar.LinkFrom(foo, 0, "")
+	bar.AddResult("", 0, time.Now(), merge)
+
+	cfg, descPairs, err := cc.Marshal(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, 2, len(cfg.Records))
+
+	blobs := func(idx int) []digest.Digest {
+		var ds []digest.Digest
+		for ; idx != -1; idx = cfg.Layers[idx].ParentIndex {
+			ds = append([]digest.Digest{cfg.Layers[idx].Blob}, ds...)
+		}
+		return ds
+	}
+
+	record := func(cfg *CacheConfig, key string) CacheRecord {
+		for _, rec := range cfg.Records {
+			if rec.Digest == outputKey(dgst(key), 0) {
+				return rec
+			}
+		}
+		require.Fail(t, "record not found", key)
+		return CacheRecord{}
+	}
+
+	require.Equal(t, 1, len(record(cfg, "bar").ComposedResults))
+	cr := record(cfg, "bar").ComposedResults[0]
+	require.Equal(t, "merge", cr.Kind)
+	require.Equal(t, 2, len(cr.Inputs))
+	require.Nil(t, cr.Inputs[0].Composed)
+	require.Equal(t, []digest.Digest{dgst("d3")}, blobs(cr.Inputs[0].LayerIndex))
+	require.NotNil(t, cr.Inputs[1].Composed)
+	require.Equal(t, "diff", cr.Inputs[1].Composed.Kind)
+
+	require.Equal(t, 1, len(record(cfg, "foo").ComposedResults))
+	cr = record(cfg, "foo").ComposedResults[0]
+	require.Equal(t, "diff", cr.Kind)
+	require.Equal(t, 2, len(cr.Inputs))
+	require.Equal(t, []digest.Digest{dgst("d0")}, blobs(cr.Inputs[0].LayerIndex))
+	require.Equal(t, []digest.Digest{dgst("d0"), dgst("d1")}, blobs(cr.Inputs[1].LayerIndex))
+
+	// without the blob of the diff the results can only be loaded from
+	// their inputs
+	delete(descPairs, dgst("d2"))
+	dt, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	newChains := NewCacheChains()
+	require.NoError(t, Parse(dt, descPairs, newChains))
+
+	cfg2, _, err := newChains.Marshal(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, 2, len(cfg2.Records))
+	for _, rec := range cfg2.Records {
+		require.Equal(t, 0, len(rec.Results))
+		require.Equal(t, 1, len(rec.ComposedResults))
+	}
+	require.Equal(t, "merge", record(cfg2, "bar").ComposedResults[0].Kind)
+	require.Equal(t, "diff", record(cfg2, "foo").ComposedResults[0].Kind)
+	for _, l := range cfg2.Layers {
+		require.NotEqual(t, dgst("d2"), l.Blob)
+	}
+
+	// without a blob of an input the composed result is dropped
+	delete(descPairs, dgst("d1"))
+	newChains = NewCacheChains()
+	require.NoError(t, Parse(dt, descPairs, newChains))
+	cfg3, _, err := newChains.Marshal(context.TODO())
+	require.NoError(t, err)
+	for _, rec := range cfg3.Records {
+		require.Equal(t, 0, len(rec.ComposedResults))
+	}
+}
@@ -24,6 +24,10 @@ package cacheimport
 //    }
 //  ],
 //
+//  "keyVersions": {                   <- versions of the cache keys by op type, 0 if missing
+//    "exec": 0
+//  },
+//
 //  "records": [                       <- records contains chains of cache keys
 //    {
 //      "digest": "sha256:deadbeef",   <- base digest for the record
@@ -42,6 +46,21 @@ package cacheimport
 //          "layers": [1],             <- indexes to the layers array, all layers are loaded in specified order without parents
 //        }
 //      ],
+//      "composed": [                  <- optional array of merge and diff results described by their inputs
+//        {
+//          "createdat": "",
+//          "kind": "diff",            <- "merge" or "diff", the inputs of a diff are lower and upper
+//          "inputs": [
+//            {
+//              "layer": -1,           <- index to the layers array like in "layers", -1 for scratch
+//            },
+//            {
+//              "layer": -1,
+//              "composed": {...}      <- optional nested composed result used instead of "layer"
+//            }
+//          ]
+//        }
+//      ],
 //      "inputs": [                    <- dependant records, this is how cache keys are linked together
 //        [                            <- index of the dependency (0)
 //          {
@@ -2,6 +2,7 @@ package cacheimport
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/util/contentutil"
@@ -54,6 +55,20 @@ func parseRecord(cc CacheConfig, idx int, provider DescriptorProvider, t solver.
 		}
 	}
 
+	var composed *solver.Remote
+	var composedAt time.Time
+	for _, res := range rec.ComposedResults {
+		remote, err := parseComposedResult(cc, res, provider)
+		if err != nil {
+			return nil, err
+		}
+		if remote != nil {
+			composed, composedAt = remote, res.CreatedAt
+			break
+		}
+	}
+	var added bool
+
 	for _, res := range rec.Results {
 		visited := map[int]struct{}{}
 		remote, err := getRemoteChain(cc.Layers, res.LayerIndex, provider, visited)
@@ -61,7 +76,11 @@ func parseRecord(cc CacheConfig, idx int, provider DescriptorProvider, t solver.
 			return nil, err
 		}
 		if remote != nil {
+			if composed != nil {
+				remote.Composition = composed.Composition
+			}
 			r.AddResult("", 0, res.CreatedAt, remote)
+			added = true
 		}
 	}
 
@@ -86,14 +105,57 @@ func parseRecord(cc CacheConfig, idx int, provider DescriptorProvider, t solver.
 		}
 		if remote != nil {
 			remote.Provider = mp
+			if composed != nil {
+				remote.Composition = composed.Composition
+			}
 			r.AddResult("", 0, res.CreatedAt, remote)
+			added = true
 		}
 	}
 
+	if composed != nil && !added {
+		r.AddResult("", 0, composedAt, composed)
+	}
+
 	cache[idx] = r
 	return r, nil
 }
 
+// parseComposedResult returns a remote without descriptors that can only be
+// loaded from its composition. It returns nil if the blobs of any of the
+// inputs are missing or the kind of the result is unknown.
+func parseComposedResult(cc CacheConfig, res ComposedResult, provider DescriptorProvider) (*solver.Remote, error) {
+	kind := solver.RemoteCompositionKind(res.Kind)
+	switch kind {
+	case solver.RemoteMerge:
+	case solver.RemoteDiff:
+		if len(res.Inputs) != 2 {
+			return nil, errors.Errorf("invalid number of diff inputs %d", len(res.Inputs))
+		}
+	default:
+		return nil, nil
+	}
+
+	comp := &solver.RemoteComposition{Kind: kind}
+	for _, in := range res.Inputs {
+		var r *solver.Remote
+		var err error
+		switch {
+		case in.Composed != nil:
+			r, err = parseComposedResult(cc, *in.Composed, provider)
+		case in.LayerIndex != -1:
+			r, err = getRemoteChain(cc.Layers, in.LayerIndex, provider, map[int]struct{}{})
+		default:
+			r = &solver.Remote{}
+		}
+		if err != nil || r == nil {
+			return nil, err
+		}
+		comp.Inputs = append(comp.Inputs, r)
+	}
+	return &solver.Remote{Composition: comp}, nil
+}
+
 func getRemoteChain(layers []CacheLayer, idx int, provider DescriptorProvider, visited map[int]struct{}) (*solver.Remote, error) {
 	if _, ok := visited[idx]; ok {
 		return nil, errors.Errorf("invalid looping layer")
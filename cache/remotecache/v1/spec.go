@@ -11,6 +11,9 @@ const CacheConfigMediaTypeV0 = "application/vnd.buildkit.cacheconfig.v0"
 type CacheConfig struct {
 	Layers  []CacheLayer  `json:"layers,omitempty"`
 	Records []CacheRecord `json:"records,omitempty"`
+	// KeyVersions are the versions of the cache keys of the records by op
+	// type. Cache without versions was created with version 0 of all ops.
+	KeyVersions map[string]int `json:"keyVersions,omitempty"`
 }
 
 type CacheLayer struct {
@@ -27,10 +30,11 @@ type LayerAnnotations struct {
 }
 
 type CacheRecord struct {
-	Results        []CacheResult   `json:"layers,omitempty"`
-	ChainedResults []ChainedResult `json:"chains,omitempty"`
-	Digest         digest.Digest   `json:"digest,omitempty"`
-	Inputs         [][]CacheInput  `json:"inputs,omitempty"`
+	Results         []CacheResult    `json:"layers,omitempty"`
+	ChainedResults  []ChainedResult  `json:"chains,omitempty"`
+	ComposedResults []ComposedResult `json:"composed,omitempty"`
+	Digest          digest.Digest    `json:"digest,omitempty"`
+	Inputs          [][]CacheInput   `json:"inputs,omitempty"`
 }
 
 type CacheResult struct {
@@ -43,6 +47,21 @@ type ChainedResult struct {
 	CreatedAt    time.Time `json:"createdAt,omitempty"`
 }
 
+// ComposedResult is a result of a merge or a diff described by its inputs.
+// The inputs of a diff are the lower and upper results.
+type ComposedResult struct {
+	Kind      string          `json:"kind"`
+	Inputs    []ComposedInput `json:"inputs"`
+	CreatedAt time.Time       `json:"createdAt,omitempty"`
+}
+
+// ComposedInput is the layer chain at LayerIndex, another composed result or
+// scratch if LayerIndex is -1 and Composed is not set.
+type ComposedInput struct {
+	LayerIndex int             `json:"layer"`
+	Composed   *ComposedResult `json:"composed,omitempty"`
+}
+
 type CacheInput struct {
 	Selector  string `json:"selector,omitempty"`
 	LinkIndex int    `json:"link"`
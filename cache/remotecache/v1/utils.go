@@ -89,11 +89,26 @@ func sortConfig(cc *CacheConfig) {
 		l.newIndex = i
 	}
 
+	var remapComposed func(cr *ComposedResult)
+	remapComposed = func(cr *ComposedResult) {
+		for i := range cr.Inputs {
+			in := &cr.Inputs[i]
+			if in.Composed != nil {
+				remapComposed(in.Composed)
+			} else if in.LayerIndex != -1 {
+				in.LayerIndex = unsortedLayers[in.LayerIndex].newIndex
+			}
+		}
+	}
+
 	records := make([]CacheRecord, len(sortedRecords))
 	for i, r := range sortedRecords {
 		for j := range r.r.Results {
 			r.r.Results[j].LayerIndex = unsortedLayers[r.r.Results[j].LayerIndex].newIndex
 		}
+		for j := range r.r.ComposedResults {
+			remapComposed(&r.r.ComposedResults[j])
+		}
 		for j, inputs := range r.r.Inputs {
 			for k := range inputs {
 				r.r.Inputs[j][k].LinkIndex = unsortedRecords[r.r.Inputs[j][k].LinkIndex].newIndex
@@ -354,6 +369,16 @@ func marshalItem(ctx context.Context, it *item, state *marshalState) error {
 			}
 			rec.Results = append(rec.Results, CacheResult{LayerIndex: idx, CreatedAt: it.resultTime})
 		}
+		if it.result.Composition != nil {
+			cr, err := marshalComposition(ctx, it.result.Composition, state)
+			if err != nil {
+				return err
+			}
+			if cr != nil {
+				cr.CreatedAt = it.resultTime
+				rec.ComposedResults = append(rec.ComposedResults, *cr)
+			}
+		}
 	}
 
 	state.recordsByItem[it] = len(state.records)
@@ -361,6 +386,37 @@ func marshalItem(ctx context.Context, it *item, state *marshalState) error {
 	return nil
 }
 
+// marshalComposition returns nil if the blobs of any of the inputs are not
+// available.
+func marshalComposition(ctx context.Context, c *solver.RemoteComposition, state *marshalState) (*ComposedResult, error) {
+	cr := &ComposedResult{Kind: string(c.Kind)}
+	for _, in := range c.Inputs {
+		ci := ComposedInput{LayerIndex: -1}
+		if in.Composition != nil {
+			sub, err := marshalComposition(ctx, in.Composition, state)
+			if err != nil {
+				return nil, err
+			}
+			ci.Composed = sub
+		}
+		if ci.Composed == nil && len(in.Descriptors) > 0 {
+			id := marshalRemote(ctx, in, state)
+			if id == "" {
+				return nil, nil
+			}
+			idx, ok := state.chainsByID[id]
+			if !ok {
+				return nil, errors.Errorf("parent chainid not found")
+			}
+			ci.LayerIndex = idx
+		} else if ci.Composed == nil && in.Composition != nil {
+			return nil, nil
+		}
+		cr.Inputs = append(cr.Inputs, ci)
+	}
+	return cr, nil
+}
+
 func isSubRemote(sub, main solver.Remote) bool {
 	if len(sub.Descriptors) > len(main.Descriptors) {
 		return false
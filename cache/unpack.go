@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/leases"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/compression"
+	"github.com/moby/buildkit/util/leaseutil"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// canDecompressAhead returns true if the blob of the layer can be
+// decompressed before its parent is extracted. Remote snapshotters and
+// windows layers are always extracted from the original blob.
+func (sr *immutableRef) canDecompressAhead() bool {
+	if sr.cm.unpackSem == nil || sr.GetLayerType() == "windows" {
+		return false
+	}
+	switch sr.cm.Snapshotter.Name() {
+	case "stargz", "overlaybd", "nydus":
+		return false
+	}
+	return sr.getDiffID() != ""
+}
+
+// decompress writes the uncompressed blob of the layer to the content store
+// so that the layer can be extracted without decompressing it once its parent
+// is extracted. The original descriptor is returned if the blob is not
+// compressed or all slots of the unpack limiter are taken. The release
+// function needs to be called once the returned blob was applied.
+func (sr *immutableRef) decompress(ctx context.Context, desc ocispecs.Descriptor) (ocispecs.Descriptor, func(), error) {
+	noop := func() {}
+	ct, err := compression.FromMediaType(desc.MediaType)
+	if err != nil || ct == compression.Uncompressed || ct == compression.EStargz {
+		return desc, noop, nil
+	}
+	if !sr.cm.unpackSem.TryAcquire(1) {
+		return desc, noop, nil
+	}
+	defer sr.cm.unpackSem.Release(1)
+
+	// the uncompressed blob is only kept until the layer is extracted
+	lr, ctx, err := leaseutil.NewLease(ctx, sr.cm.LeaseManager, leaseutil.MakeTemporary)
+	if err != nil {
+		return desc, noop, err
+	}
+	release := func() {
+		if err := lr.Discard(); err != nil {
+			bklog.G(ctx).WithError(err).Warn("failed to release decompressed layer")
+		}
+	}
+
+	udesc := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageLayer,
+		Digest:    sr.getDiffID(),
+	}
+	cs := sr.cm.ContentStore
+	if info, err := cs.Info(ctx, udesc.Digest); err == nil {
+		// the blob only needs to be added to the lease
+		leaseID, _ := leases.FromContext(ctx)
+		if err := sr.cm.LeaseManager.AddResource(ctx, leases.Lease{ID: leaseID}, leases.Resource{
+			ID:   info.Digest.String(),
+			Type: "content",
+		}); err != nil {
+			release()
+			return desc, noop, err
+		}
+		udesc.Size = info.Size
+		return udesc, release, nil
+	} else if !cerrdefs.IsNotFound(err) {
+		release()
+		return desc, noop, err
+	}
+
+	r, err := ct.Decompress(ctx, cs, desc)
+	if err != nil {
+		release()
+		return desc, noop, err
+	}
+	defer r.Close()
+	if err := content.WriteBlob(ctx, cs, "decompress-"+udesc.Digest.String(), r, udesc); err != nil {
+		release()
+		return desc, noop, errors.Wrapf(err, "failed to decompress layer %s", desc.Digest)
+	}
+	info, err := cs.Info(ctx, udesc.Digest)
+	if err != nil {
+		release()
+		return desc, noop, err
+	}
+	udesc.Size = info.Size
+	return udesc, release, nil
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/leases"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/bklog"
+)
+
+// orphanedLeaseAge is the minimum age of a lease without a cache record
+// before it is considered orphaned. Younger leases can belong to records that
+// are still being created.
+const orphanedLeaseAge = time.Hour
+
+// Verify checks the cache records against the snapshotter and the content
+// store. If repair is true, records without a snapshot or blob and orphaned
+// leases that are not in use are removed.
+func (cm *cacheManager) Verify(ctx context.Context, repair bool) ([]client.CacheIssue, error) {
+	cm.mu.Lock()
+	records := make([]*cacheRecord, 0, len(cm.records))
+	for _, cr := range cm.records {
+		records = append(records, cr)
+	}
+	cm.mu.Unlock()
+
+	var issues []client.CacheIssue
+	for _, cr := range records {
+		if err := ctx.Err(); err != nil {
+			return nil, context.Cause(ctx)
+		}
+		issue, err := cm.verifyRecord(ctx, cr)
+		if err != nil {
+			return nil, err
+		}
+		if issue == nil {
+			continue
+		}
+		if repair {
+			if issue.Repaired, err = cm.repairRecord(ctx, cr); err != nil {
+				return nil, err
+			}
+		}
+		issues = append(issues, *issue)
+	}
+
+	leaseIssues, err := cm.verifyLeases(ctx, repair)
+	if err != nil {
+		return nil, err
+	}
+	return append(issues, leaseIssues...), nil
+}
+
+func (cm *cacheManager) verifyRecord(ctx context.Context, cr *cacheRecord) (*client.CacheIssue, error) {
+	cr.mu.Lock()
+	skip := cr.isDead() || cr.equalMutable != nil
+	cr.mu.Unlock()
+	// Immutable records that are not finalized use the snapshot of their
+	// mutable record, that is checked separately.
+	if skip {
+		return nil, nil
+	}
+	// Lazy records and records that were never unpacked don't have a
+	// snapshot, and the snapshots of merges and diffs are created on demand.
+	if cr.getBlobOnly() || cr.kind() == Merge || cr.kind() == Diff {
+		return nil, nil
+	}
+
+	if _, err := cm.Snapshotter.Stat(ctx, cr.getSnapshotID()); err != nil {
+		if !cerrdefs.IsNotFound(err) {
+			return nil, err
+		}
+		return &client.CacheIssue{
+			ID:          cr.ID(),
+			Type:        client.CacheIssueMissingSnapshot,
+			Description: fmt.Sprintf("snapshot %s not found", cr.getSnapshotID()),
+		}, nil
+	}
+
+	if dgst := cr.getBlob(); dgst != "" {
+		if _, err := cm.ContentStore.Info(ctx, dgst); err != nil {
+			if !cerrdefs.IsNotFound(err) {
+				return nil, err
+			}
+			return &client.CacheIssue{
+				ID:          cr.ID(),
+				Type:        client.CacheIssueMissingBlob,
+				Description: fmt.Sprintf("blob %s not found", dgst),
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// repairRecord removes a record that can't be used anymore. The record is
+// checked again with pruning blocked as it may have changed since it was
+// verified.
+func (cm *cacheManager) repairRecord(ctx context.Context, cr *cacheRecord) (bool, error) {
+	cm.muPrune.Lock()
+	defer cm.muPrune.Unlock()
+
+	issue, err := cm.verifyRecord(ctx, cr)
+	if err != nil || issue == nil {
+		return false, err
+	}
+	return cm.removeInconsistent(ctx, cr, issue)
+}
+
+// removeInconsistent removes a record that can't be used anymore. Records
+// that are in use, including the parents of other records, are kept.
+func (cm *cacheManager) removeInconsistent(ctx context.Context, cr *cacheRecord, issue *client.CacheIssue) (bool, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	if cr.isDead() || len(cr.refs) != 0 {
+		return false, nil
+	}
+	if cr.equalImmutable != nil && len(cr.equalImmutable.refs) != 0 {
+		return false, nil
+	}
+
+	cr.dead = true
+	if cr.equalImmutable != nil {
+		if err := cr.equalImmutable.remove(ctx, false); err != nil {
+			return false, err
+		}
+	}
+	if err := cr.remove(ctx, true); err != nil {
+		return false, err
+	}
+	bklog.G(ctx).Warnf("removed cache record %s: %s", cr.ID(), issue.Description)
+	return true, nil
+}
+
+// verifyLeases finds the leases of cache records that don't exist anymore
+func (cm *cacheManager) verifyLeases(ctx context.Context, repair bool) ([]client.CacheIssue, error) {
+	ls, err := cm.LeaseManager.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []client.CacheIssue
+	for _, l := range ls {
+		if _, ok := l.Labels["buildkit/lease.temporary"]; ok {
+			continue
+		}
+		id, variants := strings.CutSuffix(l.ID, "-variants")
+		if _, ok := l.Labels["containerd.io/gc.flat"]; !ok && !variants {
+			continue
+		}
+		if time.Since(l.CreatedAt) < orphanedLeaseAge {
+			continue
+		}
+
+		cm.mu.Lock()
+		_, ok := cm.records[id]
+		if !ok {
+			_, ok = cm.MetadataStore.Get(id)
+		}
+		cm.mu.Unlock()
+		if ok {
+			continue
+		}
+
+		issue := client.CacheIssue{
+			ID:          l.ID,
+			Type:        client.CacheIssueOrphanedLease,
+			Description: fmt.Sprintf("lease of missing cache record %s", id),
+		}
+		if repair {
+			if issue.Repaired, err = cm.deleteOrphanedLease(ctx, l, id); err != nil {
+				return nil, err
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// deleteOrphanedLease deletes the lease of the record id unless the record
+// was created after the leases were listed
+func (cm *cacheManager) deleteOrphanedLease(ctx context.Context, l leases.Lease, id string) (bool, error) {
+	cm.muPrune.Lock()
+	defer cm.muPrune.Unlock()
+
+	cm.mu.Lock()
+	_, ok := cm.records[id]
+	if !ok {
+		_, ok = cm.MetadataStore.Get(id)
+	}
+	cm.mu.Unlock()
+	if ok {
+		return false, nil
+	}
+	if err := cm.LeaseManager.Delete(ctx, l); err != nil && !cerrdefs.IsNotFound(err) {
+		return false, err
+	}
+	return true, nil
+}
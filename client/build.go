@@ -192,3 +192,13 @@ func (g *gatewayClientForBuild) Warn(ctx context.Context, in *gatewayapi.WarnReq
 	ctx = buildid.AppendToOutgoingContext(ctx, g.buildID)
 	return g.gateway.Warn(ctx, in)
 }
+
+func (g *gatewayClientForBuild) CheckSession(ctx context.Context, in *gatewayapi.CheckSessionRequest, opts ...grpc.CallOption) (*gatewayapi.CheckSessionResponse, error) {
+	if g.caps != nil {
+		if err := g.caps.Supports(gatewayapi.CapGatewayCheckSession); err != nil {
+			return nil, err
+		}
+	}
+	ctx = buildid.AppendToOutgoingContext(ctx, g.buildID)
+	return g.gateway.CheckSession(ctx, in, opts...)
+}
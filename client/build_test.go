@@ -62,6 +62,7 @@ func TestClientGatewayIntegration(t *testing.T) {
 		testWarnings,
 		testClientGatewayNilResult,
 		testClientGatewayEmptyImageExec,
+		testClientGatewayExecArtifacts,
 	), integration.WithMirroredImages(integration.OfficialImages("busybox:latest")))
 
 	integration.Run(t, integration.TestFuncs(
@@ -1566,6 +1567,68 @@ func testClientGatewayExecError(t *testing.T, sb integration.Sandbox) {
 	checkAllReleasable(t, c, sb, true)
 }
 
+// testClientGatewayExecArtifacts is testing the artifacts captured from a
+// failed exec
+func testClientGatewayExecArtifacts(t *testing.T, sb integration.Sandbox) {
+	requiresLinux(t)
+
+	ctx := sb.Context()
+
+	c, err := New(ctx, sb.Address())
+	require.NoError(t, err)
+	defer c.Close()
+
+	b := func(ctx context.Context, c client.Client) (*client.Result, error) {
+		st := llb.Image("busybox:latest").Run(
+			llb.Shlex(`sh -c "mkdir -p /out && echo failed > /out/report.txt && exit 1"`),
+			llb.CaptureArtifacts("/out/report.txt", "/out/missing.txt"),
+		).Root()
+		def, err := st.Marshal(ctx)
+		require.NoError(t, err)
+
+		_, solveErr := c.Solve(ctx, client.SolveRequest{
+			Evaluate:   true,
+			Definition: def.ToPB(),
+		})
+		require.Error(t, solveErr)
+
+		var se *errdefs.SolveError
+		require.ErrorAs(t, solveErr, &se)
+		require.NotEmpty(t, se.ArtifactsID)
+
+		ctr, err := c.NewContainer(ctx, client.NewContainerRequest{
+			Mounts: []client.Mount{{
+				Dest:      "/",
+				ResultID:  se.MountIDs[0],
+				MountType: pb.MountType_BIND,
+			}, {
+				Dest:      "/artifacts",
+				ResultID:  se.ArtifactsID,
+				Readonly:  true,
+				MountType: pb.MountType_BIND,
+			}},
+		})
+		require.NoError(t, err)
+		defer ctr.Release(ctx)
+
+		output := bytes.NewBuffer(nil)
+		proc, err := ctr.Start(ctx, client.StartRequest{
+			Args:   []string{"sh", "-c", "cat /artifacts/out/report.txt && ! test -e /artifacts/out/missing.txt"},
+			Stdout: &nopCloser{output},
+		})
+		require.NoError(t, err)
+		require.NoError(t, proc.Wait())
+		require.Equal(t, "failed", strings.TrimSpace(output.String()))
+
+		return client.NewResult(), nil
+	}
+
+	_, err = c.Build(ctx, SolveOpt{}, "buildkit_test", b, nil)
+	require.NoError(t, err)
+
+	checkAllReleasable(t, c, sb, true)
+}
+
 // testClientGatewaySlowCacheExecError is testing gateway exec into the ref
 // that failed to mount during an execop.
 func testClientGatewaySlowCacheExecError(t *testing.T, sb integration.Sandbox) {
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+	"strings"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+)
+
+// CancelPlatforms cancels the platforms of the multi-platform result of the
+// running build ref, e.g. linux/arm64. The other platforms are still built and
+// exported. The index of an exported image lists the canceled platforms in
+// its moby.buildkit.platforms.missing annotation. The build fails if all of
+// its platforms are canceled.
+func (c *Client) CancelPlatforms(ctx context.Context, ref string, platforms ...string) error {
+	_, err := c.ControlClient().CancelPlatforms(ctx, &controlapi.CancelPlatformsRequest{
+		Ref:       ref,
+		Platforms: platforms,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to cancel platforms %s of build %s", strings.Join(platforms, ","), ref)
+	}
+	return nil
+}
@@ -395,3 +395,26 @@ func (*withGRPCDialOption) isClientOpt() {}
 func WithGRPCDialOption(opt grpc.DialOption) ClientOpt {
 	return &withGRPCDialOption{opt}
 }
+
+// WithBearerToken sends a bearer token obtained from fn with every request.
+// The token is called for each request so that short-lived tokens can be
+// refreshed.
+func WithBearerToken(fn func(context.Context) (string, error)) ClientOpt {
+	return &withGRPCDialOption{grpc.WithPerRPCCredentials(&bearerToken{fn: fn})}
+}
+
+type bearerToken struct {
+	fn func(context.Context) (string, error)
+}
+
+func (t *bearerToken) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	token, err := t.fn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get bearer token")
+	}
+	return map[string]string{"authorization": "Bearer " + token}, nil
+}
+
+func (t *bearerToken) RequireTransportSecurity() bool {
+	return false
+}
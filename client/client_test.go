@@ -201,6 +201,8 @@ var allTests = []func(t *testing.T, sb integration.Sandbox){
 	testPullWithLayerLimit,
 	testExportAnnotations,
 	testExportAnnotationsMediaTypes,
+	testExportOCIArtifact,
+	testArtifactExporter,
 	testExportAttestationsOCIArtifact,
 	testExportAttestationsImageManifest,
 	testExportedImageLabels,
@@ -211,6 +213,7 @@ var allTests = []func(t *testing.T, sb integration.Sandbox){
 	testSourceDateEpochLocalExporter,
 	testSourceDateEpochTarExporter,
 	testSourceDateEpochImageExporter,
+	testImageExporterContainerdNamespace,
 	testAttestationBundle,
 	testSBOMScan,
 	testSBOMScanSingleRef,
@@ -236,6 +239,8 @@ var allTests = []func(t *testing.T, sb integration.Sandbox){
 	testRunValidExitCodes,
 	testFileOpSymlink,
 	testMetadataOnlyLocal,
+	testSessionRef,
+	testResultLease,
 }
 
 func TestIntegration(t *testing.T) {
@@ -3523,6 +3528,20 @@ func testMultipleExporters(t *testing.T, sb integration.Sandbox) {
 		require.Equal(t, target1+","+target2, resp.ExporterResponse[exptypes.ExporterImageNameKey])
 	} else {
 		require.Equal(t, target2, resp.ExporterResponse[exptypes.ExporterImageNameKey])
+
+		require.Len(t, resp.ExporterResponses, 6)
+		for i, r := range resp.ExporterResponses {
+			require.Equal(t, strconv.Itoa(i), r.ID)
+			require.Equal(t, exporters[i].Type, r.Type)
+		}
+		require.Equal(t, []string{target1}, resp.ExporterResponses[0].Image.Names)
+		require.Equal(t, []string{target2}, resp.ExporterResponses[1].Image.Names)
+		require.Nil(t, resp.ExporterResponses[1].Image.Pushed)
+		require.Len(t, resp.ExporterResponses[1].Image.Manifests, 1)
+		require.Equal(t, resp.ExporterResponses[1].Image.Digest, resp.ExporterResponses[1].Image.Manifests[0].Digest)
+		require.Nil(t, resp.ExporterResponses[2].Image)
+		require.Equal(t, destDir, resp.ExporterResponses[2].OutputDir)
+		require.Equal(t, destDir2, resp.ExporterResponses[3].OutputDir)
 	}
 	require.FileExists(t, filepath.Join(destDir, "out.tar"))
 	require.FileExists(t, filepath.Join(destDir, "out2.tar"))
@@ -4282,6 +4301,94 @@ func testSourceDateEpochImageExporter(t *testing.T, sb integration.Sandbox) {
 	checkAllReleasable(t, c, sb, true)
 }
 
+func testImageExporterContainerdNamespace(t *testing.T, sb integration.Sandbox) {
+	integration.SkipOnPlatform(t, "windows")
+	cdAddress := sb.ContainerdAddress()
+	if cdAddress == "" {
+		t.SkipNow()
+	}
+	requiresLinux(t)
+	c, err := New(sb.Context(), sb.Address())
+	require.NoError(t, err)
+	defer c.Close()
+
+	st := llb.Image("busybox:latest").Run(llb.Shlex(`sh -c "echo -n hello > /foo"`)).Root()
+	def, err := st.Marshal(sb.Context())
+	require.NoError(t, err)
+
+	const ns = "buildkit-test-namespace"
+	name := strings.ToLower(path.Base(t.Name()))
+
+	_, err = c.Solve(sb.Context(), def, SolveOpt{
+		Exports: []ExportEntry{
+			{
+				Type: ExporterImage,
+				Attrs: map[string]string{
+					"name":                 name,
+					"containerd-namespace": ns,
+					"unpack":               "true",
+					"rewrite-timestamp":    "true",
+				},
+			},
+		},
+	}, nil)
+	require.ErrorContains(t, err, `exporter option "rewrite-timestamp" conflicts with "unpack"`)
+
+	res, err := c.Solve(sb.Context(), def, SolveOpt{
+		Exports: []ExportEntry{
+			{
+				Type: ExporterImage,
+				Attrs: map[string]string{
+					"name":                 name,
+					"containerd-namespace": ns,
+					"unpack":               "true",
+					"name-canonical":       "true",
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	dgst := res.ExporterResponse[exptypes.ExporterImageDigestKey]
+
+	client, err := newContainerd(cdAddress)
+	require.NoError(t, err)
+	defer client.Close()
+
+	ctx := namespaces.WithNamespace(sb.Context(), ns)
+	defer func() {
+		imgs, err := client.ImageService().List(ctx)
+		require.NoError(t, err)
+		for _, img := range imgs {
+			require.NoError(t, client.ImageService().Delete(ctx, img.Name, images.SynchronousDelete()))
+		}
+	}()
+
+	img, err := client.GetImage(ctx, name)
+	require.NoError(t, err)
+	require.Equal(t, dgst, img.Target().Digest.String())
+	_, err = client.ImageService().Get(ctx, name+"@"+dgst)
+	require.NoError(t, err)
+
+	// the blobs keep their labels in the namespace
+	info, err := client.ContentStore().Info(ctx, img.Target().Digest)
+	require.NoError(t, err)
+	require.NotEmpty(t, info.Labels)
+
+	unpacked, err := img.IsUnpacked(ctx, sb.Snapshotter())
+	require.NoError(t, err)
+	require.True(t, unpacked)
+
+	// the lease of the export is released once the image references the
+	// blobs and the snapshots
+	leases, err := client.LeasesService().List(ctx)
+	require.NoError(t, err)
+	for _, l := range leases {
+		_, isTemp := l.Labels["buildkit/lease.temporary"]
+		_, isExpire := l.Labels["containerd.io/gc.expire"]
+		require.True(t, isTemp && isExpire, "lease %v", l)
+	}
+}
+
 func testFrontendMetadataReturn(t *testing.T, sb integration.Sandbox) {
 	c, err := New(sb.Context(), sb.Address())
 	require.NoError(t, err)
@@ -9446,6 +9553,183 @@ func testExportAnnotationsMediaTypes(t *testing.T, sb integration.Sandbox) {
 	require.Equal(t, ocispecs.MediaTypeImageIndex, imgs2.Index.MediaType)
 }
 
+func testExportOCIArtifact(t *testing.T, sb integration.Sandbox) {
+	workers.CheckFeatureCompat(t, sb, workers.FeatureDirectPush)
+	c, err := New(sb.Context(), sb.Address())
+	require.NoError(t, err)
+	defer c.Close()
+
+	registry, err := sb.NewRegistry()
+	if errors.Is(err, integration.ErrRequirements) {
+		t.Skip(err.Error())
+	}
+	require.NoError(t, err)
+
+	def, err := llb.Scratch().File(llb.Mkfile("module.wasm", 0644, []byte("\x00asm"))).Marshal(sb.Context())
+	require.NoError(t, err)
+
+	target := registry + "/buildkit/testociartifact:image"
+	_, err = c.Solve(sb.Context(), def, SolveOpt{
+		Exports: []ExportEntry{
+			{
+				Type: ExporterImage,
+				Attrs: map[string]string{
+					"name":           target,
+					"push":           "true",
+					"oci-mediatypes": "true",
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+	subject, _, err := contentutil.ProviderFromRef(target)
+	require.NoError(t, err)
+	subjectJSON, err := json.Marshal(ocispecs.Descriptor{
+		MediaType: subject.MediaType,
+		Digest:    subject.Digest,
+		Size:      subject.Size,
+	})
+	require.NoError(t, err)
+
+	target2 := registry + "/buildkit/testociartifact:artifact"
+	_, err = c.Solve(sb.Context(), def, SolveOpt{
+		Exports: []ExportEntry{
+			{
+				Type: ExporterImage,
+				Attrs: map[string]string{
+					"name":          target2,
+					"push":          "true",
+					"artifact-type": "application/vnd.wasm.module.v1",
+					"subject":       string(subjectJSON),
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	desc, provider, err := contentutil.ProviderFromRef(target2)
+	require.NoError(t, err)
+	require.Equal(t, ocispecs.MediaTypeImageManifest, desc.MediaType)
+	dt, err := content.ReadBlob(sb.Context(), provider, desc)
+	require.NoError(t, err)
+	var mfst ocispecs.Manifest
+	require.NoError(t, json.Unmarshal(dt, &mfst))
+	require.Equal(t, "application/vnd.wasm.module.v1", mfst.ArtifactType)
+	require.Equal(t, ocispecs.MediaTypeEmptyJSON, mfst.Config.MediaType)
+	require.Equal(t, ocispecs.DescriptorEmptyJSON.Digest, mfst.Config.Digest)
+	require.NotNil(t, mfst.Subject)
+	require.Equal(t, subject.Digest, mfst.Subject.Digest)
+	require.Len(t, mfst.Layers, 1)
+
+	// index annotations wrap the single manifest in an index
+	target3 := registry + "/buildkit/testociartifact:index"
+	_, err = c.Solve(sb.Context(), def, SolveOpt{
+		Exports: []ExportEntry{
+			{
+				Type: ExporterImage,
+				Attrs: map[string]string{
+					"name":                   target3,
+					"push":                   "true",
+					"artifact-type":          "application/vnd.wasm.module.v1",
+					"annotation-index.title": "module",
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	desc, provider, err = contentutil.ProviderFromRef(target3)
+	require.NoError(t, err)
+	require.Equal(t, ocispecs.MediaTypeImageIndex, desc.MediaType)
+	dt, err = content.ReadBlob(sb.Context(), provider, desc)
+	require.NoError(t, err)
+	var idx ocispecs.Index
+	require.NoError(t, json.Unmarshal(dt, &idx))
+	require.Equal(t, "module", idx.Annotations["title"])
+	require.Equal(t, "application/vnd.wasm.module.v1", idx.ArtifactType)
+	require.Len(t, idx.Manifests, 1)
+}
+
+func testArtifactExporter(t *testing.T, sb integration.Sandbox) {
+	workers.CheckFeatureCompat(t, sb, workers.FeatureDirectPush)
+	c, err := New(sb.Context(), sb.Address())
+	require.NoError(t, err)
+	defer c.Close()
+
+	registry, err := sb.NewRegistry()
+	if errors.Is(err, integration.ErrRequirements) {
+		t.Skip(err.Error())
+	}
+	require.NoError(t, err)
+
+	st := llb.Scratch().
+		File(llb.Mkfile("config.json", 0644, []byte(`{"name":"chart"}`))).
+		File(llb.Mkdir("charts", 0755)).
+		File(llb.Mkfile("charts/chart.tgz", 0644, []byte("chart"))).
+		File(llb.Mkfile("README.md", 0644, []byte("readme")))
+	def, err := st.Marshal(sb.Context())
+	require.NoError(t, err)
+
+	target := registry + "/buildkit/testartifactexporter:latest"
+	res, err := c.Solve(sb.Context(), def, SolveOpt{
+		Exports: []ExportEntry{
+			{
+				Type: ExporterArtifact,
+				Attrs: map[string]string{
+					"name":                        target,
+					"artifact-type":               "application/vnd.cncf.helm.config.v1+json",
+					"files":                       "charts",
+					"config":                      "config.json",
+					"config-media-type":           "application/vnd.cncf.helm.config.v1+json",
+					"media-type.charts/chart.tgz": "application/vnd.cncf.helm.chart.content.v1.tar+gzip",
+					"annotation.org.opencontainers.image.version": "1.0.0",
+					"source-date-epoch":                           "1700000000",
+				},
+			},
+		},
+	}, nil)
+	require.NoError(t, err)
+
+	desc, provider, err := contentutil.ProviderFromRef(target)
+	require.NoError(t, err)
+	require.Equal(t, ocispecs.MediaTypeImageManifest, desc.MediaType)
+	require.Equal(t, desc.Digest.String(), res.ExporterResponse[exptypes.ExporterImageDigestKey])
+
+	dt, err := content.ReadBlob(sb.Context(), provider, desc)
+	require.NoError(t, err)
+	var mfst ocispecs.Manifest
+	require.NoError(t, json.Unmarshal(dt, &mfst))
+	require.Equal(t, "application/vnd.cncf.helm.config.v1+json", mfst.ArtifactType)
+	require.Equal(t, "1.0.0", mfst.Annotations[ocispecs.AnnotationVersion])
+	require.Equal(t, "2023-11-14T22:13:20Z", mfst.Annotations[ocispecs.AnnotationCreated])
+
+	require.Equal(t, "application/vnd.cncf.helm.config.v1+json", mfst.Config.MediaType)
+	dt, err = content.ReadBlob(sb.Context(), provider, mfst.Config)
+	require.NoError(t, err)
+	require.Equal(t, `{"name":"chart"}`, string(dt))
+
+	require.Len(t, mfst.Layers, 1)
+	require.Equal(t, "application/vnd.cncf.helm.chart.content.v1.tar+gzip", mfst.Layers[0].MediaType)
+	require.Equal(t, "charts/chart.tgz", mfst.Layers[0].Annotations[ocispecs.AnnotationTitle])
+	dt, err = content.ReadBlob(sb.Context(), provider, mfst.Layers[0])
+	require.NoError(t, err)
+	require.Equal(t, "chart", string(dt))
+
+	_, err = c.Solve(sb.Context(), def, SolveOpt{
+		Exports: []ExportEntry{
+			{
+				Type: ExporterArtifact,
+				Attrs: map[string]string{
+					"name":          target,
+					"artifact-type": "application/vnd.example+type",
+					"files":         "*.wasm",
+				},
+			},
+		},
+	}, nil)
+	require.ErrorContains(t, err, "no files of the result match *.wasm")
+}
+
 func testExportAttestationsOCIArtifact(t *testing.T, sb integration.Sandbox) {
 	testExportAttestations(t, sb, true)
 }
@@ -11733,3 +12017,143 @@ func parseFSMetadata(t *testing.T, dt []byte) []fsutiltypes.Stat {
 	}
 	return m
 }
+
+func testSessionRef(t *testing.T, sb integration.Sandbox) {
+	integration.SkipOnPlatform(t, "windows")
+	requiresLinux(t)
+	c, err := New(sb.Context(), sb.Address())
+	require.NoError(t, err)
+	defer c.Close()
+
+	newSession := func() *session.Session {
+		s, err := session.NewSession(sb.Context(), "")
+		require.NoError(t, err)
+		go s.Run(sb.Context(), c.Dialer())
+		t.Cleanup(func() { s.Close() })
+		return s
+	}
+
+	readFile := func(ctx context.Context, c gateway.Client, st llb.State, name string) ([]byte, error) {
+		def, err := st.Marshal(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res, err := c.Solve(ctx, gateway.SolveRequest{Definition: def.ToPB()})
+		if err != nil {
+			return nil, err
+		}
+		ref, err := res.SingleRef()
+		if err != nil {
+			return nil, err
+		}
+		return ref.ReadFile(ctx, gateway.ReadRequest{Filename: name})
+	}
+
+	s := newSession()
+
+	// the exec is not cached so that a new solve would produce new content
+	st := llb.Image("busybox:latest").
+		Run(llb.Shlex(`sh -c "head -c 32 /dev/urandom | od -x > /out"`), llb.IgnoreCache).Root()
+	var out []byte
+	_, err = c.Build(sb.Context(), SolveOpt{
+		SharedSession:         s,
+		SessionPreInitialized: true,
+		SessionRef:            "step1",
+	}, "", func(ctx context.Context, c gateway.Client) (*gateway.Result, error) {
+		def, err := st.Marshal(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res, err := c.Solve(ctx, gateway.SolveRequest{Definition: def.ToPB()})
+		if err != nil {
+			return nil, err
+		}
+		ref, err := res.SingleRef()
+		if err != nil {
+			return nil, err
+		}
+		out, err = ref.ReadFile(ctx, gateway.ReadRequest{Filename: "out"})
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, out)
+
+	_, err = c.Build(sb.Context(), SolveOpt{
+		SharedSession:         s,
+		SessionPreInitialized: true,
+	}, "", func(ctx context.Context, c gateway.Client) (*gateway.Result, error) {
+		st := llb.Scratch().File(llb.Copy(llb.SessionRef("step1"), "/out", "/copy"))
+		dt, err := readFile(ctx, c, st, "copy")
+		require.NoError(t, err)
+		require.Equal(t, out, dt)
+
+		_, err = readFile(ctx, c, llb.SessionRef("missing"), "out")
+		require.ErrorContains(t, err, `session ref "missing" not found`)
+		return gateway.NewResult(), nil
+	}, nil)
+	require.NoError(t, err)
+
+	// the refs are not shared with the other sessions
+	_, err = c.Build(sb.Context(), SolveOpt{
+		SharedSession:         newSession(),
+		SessionPreInitialized: true,
+	}, "", func(ctx context.Context, c gateway.Client) (*gateway.Result, error) {
+		_, err := readFile(ctx, c, llb.SessionRef("step1"), "out")
+		require.ErrorContains(t, err, `session ref "step1" not found`)
+		return gateway.NewResult(), nil
+	}, nil)
+	require.NoError(t, err)
+}
+
+func testResultLease(t *testing.T, sb integration.Sandbox) {
+	integration.SkipOnPlatform(t, "windows")
+	requiresLinux(t)
+	workers.CheckFeatureCompat(t, sb, workers.FeatureCacheExport)
+	c, err := New(sb.Context(), sb.Address())
+	require.NoError(t, err)
+	defer c.Close()
+
+	st := llb.Image("busybox:latest").
+		Run(llb.Shlex(`sh -c "echo -n leased > /out"`)).Root()
+	def, err := st.Marshal(sb.Context())
+	require.NoError(t, err)
+
+	resp, err := c.Solve(sb.Context(), def, SolveOpt{
+		ResultLeaseTTL: time.Hour,
+	}, nil)
+	require.NoError(t, err)
+	id := resp.ExporterResponse[ExporterResponseResultLease]
+	require.NotEmpty(t, id)
+
+	leases, err := c.ListResultLeases(sb.Context())
+	require.NoError(t, err)
+	require.Len(t, leases, 1)
+	require.Equal(t, id, leases[0].ID)
+	require.Len(t, leases[0].Records, 1)
+	recordID := leases[0].Records[0].ID
+	require.NotEmpty(t, leases[0].Records[0].SnapshotID)
+
+	// the leased result is not pruned
+	require.NoError(t, c.Prune(sb.Context(), nil, PruneAll))
+	du, err := c.DiskUsage(sb.Context(), WithFilter([]string{"id==" + recordID}))
+	require.NoError(t, err)
+	require.Len(t, du, 1)
+
+	l, err := c.RenewResultLease(sb.Context(), id, 2*time.Hour)
+	require.NoError(t, err)
+	require.True(t, l.ExpiresAt.After(leases[0].ExpiresAt))
+
+	require.NoError(t, c.ReleaseResultLease(sb.Context(), id))
+	leases, err = c.ListResultLeases(sb.Context())
+	require.NoError(t, err)
+	require.Empty(t, leases)
+	require.Error(t, c.ReleaseResultLease(sb.Context(), id))
+
+	require.NoError(t, c.Prune(sb.Context(), nil, PruneAll))
+	du, err = c.DiskUsage(sb.Context(), WithFilter([]string{"id==" + recordID}))
+	require.NoError(t, err)
+	require.Empty(t, du)
+}
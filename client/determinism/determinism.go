@@ -0,0 +1,345 @@
+// Package determinism checks whether the vertices of a build produce the same
+// output when they run again.
+package determinism
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	gwclient "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+const (
+	// defaultMaxDiffs is the default number of file diffs reported per vertex
+	defaultMaxDiffs = 100
+	// readChunkSize is the size of the chunks the files are read in
+	readChunkSize = 1024 * 1024
+)
+
+// Opt configures a determinism check
+type Opt struct {
+	// Filter selects the vertices that run again by their name. All the exec
+	// and file vertices are selected if nil.
+	Filter func(name string) bool
+	// MaxDiffs is the maximum number of file diffs reported per vertex
+	MaxDiffs int
+}
+
+// DiffType is the type of a file diff
+type DiffType string
+
+const (
+	// DiffAdded is a file only produced by the second run
+	DiffAdded DiffType = "added"
+	// DiffRemoved is a file only produced by the first run
+	DiffRemoved DiffType = "removed"
+	// DiffModified is a file with different content or metadata
+	DiffModified DiffType = "modified"
+)
+
+// Diff is a file that differs between the outputs of the two runs
+type Diff struct {
+	Path string   `json:"path"`
+	Type DiffType `json:"type"`
+	// Fields are the file attributes that differ for a modified file
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Result is the result of the check of a vertex output
+type Result struct {
+	Vertex digest.Digest `json:"vertex"`
+	Name   string        `json:"name"`
+	Output int64         `json:"output"`
+	// Digests are the digests of the content of the output of each run
+	Digests [2]digest.Digest `json:"digests"`
+	Diffs   []Diff           `json:"diffs,omitempty"`
+	// Truncated is set if more diffs than reported were found
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Deterministic returns true if both runs produced the same output
+func (r *Result) Deterministic() bool {
+	return r.Digests[0] == r.Digests[1]
+}
+
+// Check builds the outputs of the selected vertices of def from cache and then
+// again with the cache of the vertex disabled, keeping the cached inputs. The
+// outputs of both runs are compared file by file so that the vertex that made
+// the build non-deterministic is reported rather than all the vertices
+// depending on it.
+func Check(ctx context.Context, c gwclient.Client, def *pb.Definition, opt Opt) ([]Result, error) {
+	if len(def.Def) == 0 {
+		return nil, nil
+	}
+	if opt.MaxDiffs <= 0 {
+		opt.MaxDiffs = defaultMaxDiffs
+	}
+
+	type vertex struct {
+		dgst digest.Digest
+		op   *pb.Op
+	}
+	var vertexes []vertex
+	outputs := map[digest.Digest][]int64{}
+	for _, dt := range def.Def {
+		var op pb.Op
+		if err := op.UnmarshalVT(dt); err != nil {
+			return nil, errors.Wrap(err, "failed to parse llb definition op")
+		}
+		vertexes = append(vertexes, vertex{dgst: digest.FromBytes(dt), op: &op})
+		for _, inp := range op.Inputs {
+			dgst := digest.Digest(inp.Digest)
+			if !slices.Contains(outputs[dgst], inp.Index) {
+				outputs[dgst] = append(outputs[dgst], inp.Index)
+			}
+		}
+	}
+
+	var results []Result
+	for _, v := range vertexes {
+		switch v.op.Op.(type) {
+		case *pb.Op_Exec, *pb.Op_File:
+		default:
+			continue
+		}
+		name := vertexName(def, v.dgst)
+		if opt.Filter != nil && !opt.Filter(name) {
+			continue
+		}
+		idxs := outputs[v.dgst]
+		slices.Sort(idxs)
+		for _, idx := range idxs {
+			res, err := checkOutput(ctx, c, def, v.dgst, idx, opt.MaxDiffs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to check %s", name)
+			}
+			res.Name = name
+			results = append(results, *res)
+		}
+	}
+	return results, nil
+}
+
+func vertexName(def *pb.Definition, dgst digest.Digest) string {
+	if md, ok := def.Metadata[string(dgst)]; ok {
+		if name := md.Description["llb.customname"]; name != "" {
+			return name
+		}
+	}
+	return string(dgst)
+}
+
+// outputDefinition returns the definition of the output idx of the vertex
+// dgst. The cache of the vertex is disabled if ignoreCache is set.
+func outputDefinition(def *pb.Definition, dgst digest.Digest, idx int64, ignoreCache bool) (*pb.Definition, error) {
+	term := &pb.Op{Inputs: []*pb.Input{{Digest: string(dgst), Index: idx}}}
+	dt, err := term.MarshalVT()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	out := &pb.Definition{
+		Def:      append(slices.Clone(def.Def[:len(def.Def)-1]), dt),
+		Metadata: make(map[string]*pb.OpMetadata, len(def.Metadata)),
+		Source:   def.Source,
+	}
+	for k, md := range def.Metadata {
+		out.Metadata[k] = md
+	}
+	if ignoreCache {
+		md := &pb.OpMetadata{}
+		if v, ok := def.Metadata[string(dgst)]; ok {
+			md = v.CloneVT()
+		}
+		md.IgnoreCache = true
+		out.Metadata[string(dgst)] = md
+	}
+	return out, nil
+}
+
+func checkOutput(ctx context.Context, c gwclient.Client, def *pb.Definition, dgst digest.Digest, idx int64, maxDiffs int) (*Result, error) {
+	var files [2]map[string]*file
+	for i := range files {
+		odef, err := outputDefinition(def, dgst, idx, i == 1)
+		if err != nil {
+			return nil, err
+		}
+		res, err := c.Solve(ctx, gwclient.SolveRequest{
+			Definition: odef,
+			Evaluate:   true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ref, err := res.SingleRef()
+		if err != nil {
+			return nil, err
+		}
+		files[i] = map[string]*file{}
+		if ref != nil {
+			if err := walk(ctx, ref, "/", files[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	res := &Result{
+		Vertex: dgst,
+		Output: idx,
+	}
+	for i, m := range files {
+		res.Digests[i] = contentDigest(m)
+	}
+	if res.Deterministic() {
+		return res, nil
+	}
+	for _, d := range diff(files[0], files[1]) {
+		if len(res.Diffs) == maxDiffs {
+			res.Truncated = true
+			break
+		}
+		res.Diffs = append(res.Diffs, d)
+	}
+	return res, nil
+}
+
+type file struct {
+	stat   *fstypes.Stat
+	digest digest.Digest
+}
+
+func walk(ctx context.Context, ref gwclient.Reference, dir string, files map[string]*file) error {
+	ents, err := ref.ReadDir(ctx, gwclient.ReadDirRequest{Path: dir})
+	if err != nil {
+		return err
+	}
+	for _, st := range ents {
+		p := path.Join(dir, st.Path)
+		f := &file{stat: st}
+		files[p] = f
+		mode := os.FileMode(st.Mode)
+		switch {
+		case mode.IsDir():
+			if err := walk(ctx, ref, p, files); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			f.digest, err = readDigest(ctx, ref, p, st.Size)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readDigest(ctx context.Context, ref gwclient.Reference, p string, size int64) (digest.Digest, error) {
+	dgstr := digest.Canonical.Digester()
+	for off := int64(0); off < size; off += readChunkSize {
+		dt, err := ref.ReadFile(ctx, gwclient.ReadRequest{
+			Filename: p,
+			Range: &gwclient.FileRange{
+				Offset: int(off),
+				Length: readChunkSize,
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		dgstr.Hash().Write(dt)
+		if len(dt) < readChunkSize {
+			break
+		}
+	}
+	return dgstr.Digest(), nil
+}
+
+// contentDigest returns the digest of the paths, metadata and content of
+// the files of an output
+func contentDigest(files map[string]*file) digest.Digest {
+	var buf bytes.Buffer
+	for _, p := range slices.Sorted(maps.Keys(files)) {
+		f := files[p]
+		fmt.Fprintf(&buf, "%s %s\n", p, strings.Join(attrs(f), " "))
+	}
+	return digest.FromBytes(buf.Bytes())
+}
+
+func attrs(f *file) []string {
+	st := f.stat
+	out := []string{
+		fmt.Sprintf("mode=%o", st.Mode),
+		fmt.Sprintf("uid=%d", st.Uid),
+		fmt.Sprintf("gid=%d", st.Gid),
+		fmt.Sprintf("size=%d", st.Size),
+		fmt.Sprintf("mtime=%d", st.ModTime),
+	}
+	if st.Linkname != "" {
+		out = append(out, "link="+st.Linkname)
+	}
+	if st.Devmajor != 0 || st.Devminor != 0 {
+		out = append(out, fmt.Sprintf("dev=%d:%d", st.Devmajor, st.Devminor))
+	}
+	for _, k := range slices.Sorted(maps.Keys(st.Xattrs)) {
+		out = append(out, fmt.Sprintf("xattr.%s=%x", k, st.Xattrs[k]))
+	}
+	if f.digest != "" {
+		out = append(out, "content="+f.digest.String())
+	}
+	return out
+}
+
+func diff(a, b map[string]*file) []Diff {
+	paths := slices.Sorted(maps.Keys(a))
+	for p := range b {
+		if _, ok := a[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	slices.Sort(paths)
+
+	var diffs []Diff
+	for _, p := range paths {
+		fa, okA := a[p]
+		fb, okB := b[p]
+		switch {
+		case !okB:
+			diffs = append(diffs, Diff{Path: p, Type: DiffRemoved})
+		case !okA:
+			diffs = append(diffs, Diff{Path: p, Type: DiffAdded})
+		default:
+			if fields := diffFields(fa, fb); len(fields) > 0 {
+				diffs = append(diffs, Diff{Path: p, Type: DiffModified, Fields: fields})
+			}
+		}
+	}
+	return diffs
+}
+
+// diffFields returns the names of the attributes that differ between the
+// two versions of a file
+func diffFields(a, b *file) []string {
+	attrsB := map[string]string{}
+	for _, attr := range attrs(b) {
+		k, v, _ := strings.Cut(attr, "=")
+		attrsB[k] = v
+	}
+	var fields []string
+	for _, attr := range attrs(a) {
+		k, v, _ := strings.Cut(attr, "=")
+		if vb, ok := attrsB[k]; !ok || vb != v {
+			fields = append(fields, k)
+		}
+		delete(attrsB, k)
+	}
+	return append(fields, slices.Sorted(maps.Keys(attrsB))...)
+}
@@ -0,0 +1,90 @@
+package determinism
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+func TestOutputDefinition(t *testing.T) {
+	src := &pb.Op{Op: &pb.Op_Source{Source: &pb.SourceOp{Identifier: "docker-image://docker.io/library/busybox:latest"}}}
+	srcDt, err := src.MarshalVT()
+	require.NoError(t, err)
+	srcDgst := digest.FromBytes(srcDt)
+
+	exec := &pb.Op{
+		Inputs: []*pb.Input{{Digest: string(srcDgst)}},
+		Op: &pb.Op_Exec{Exec: &pb.ExecOp{
+			Meta:   &pb.Meta{Args: []string{"date"}},
+			Mounts: []*pb.Mount{{Dest: "/", Output: 0}},
+		}},
+	}
+	execDt, err := exec.MarshalVT()
+	require.NoError(t, err)
+	execDgst := digest.FromBytes(execDt)
+
+	term := &pb.Op{Inputs: []*pb.Input{{Digest: string(execDgst)}}}
+	termDt, err := term.MarshalVT()
+	require.NoError(t, err)
+
+	def := &pb.Definition{
+		Def: [][]byte{srcDt, execDt, termDt},
+		Metadata: map[string]*pb.OpMetadata{
+			string(execDgst): {Description: map[string]string{"llb.customname": "RUN date"}},
+		},
+	}
+	require.Equal(t, "RUN date", vertexName(def, execDgst))
+	require.Equal(t, string(srcDgst), vertexName(def, srcDgst))
+
+	out, err := outputDefinition(def, srcDgst, 0, false)
+	require.NoError(t, err)
+	require.Len(t, out.Def, 3)
+	require.Equal(t, srcDt, out.Def[0])
+	var op pb.Op
+	require.NoError(t, op.UnmarshalVT(out.Def[2]))
+	require.Equal(t, string(srcDgst), op.Inputs[0].Digest)
+	require.NotContains(t, out.Metadata, string(srcDgst))
+
+	out, err = outputDefinition(def, execDgst, 0, true)
+	require.NoError(t, err)
+	require.Equal(t, termDt, out.Def[2])
+	require.True(t, out.Metadata[string(execDgst)].IgnoreCache)
+	require.Equal(t, "RUN date", out.Metadata[string(execDgst)].Description["llb.customname"])
+	// the original definition is not modified
+	require.False(t, def.Metadata[string(execDgst)].IgnoreCache)
+}
+
+func TestDiff(t *testing.T) {
+	a := map[string]*file{
+		"/bin":      {stat: &fstypes.Stat{Path: "bin", Mode: 0o40755}},
+		"/bin/foo":  {stat: &fstypes.Stat{Path: "foo", Mode: 0o755, Size: 3}, digest: digest.FromString("foo")},
+		"/etc":      {stat: &fstypes.Stat{Path: "etc", Mode: 0o40755, ModTime: 1}},
+		"/etc/date": {stat: &fstypes.Stat{Path: "date", Mode: 0o644, Size: 10, ModTime: 1}, digest: digest.FromString("1")},
+		"/tmp.1":    {stat: &fstypes.Stat{Path: "tmp.1", Mode: 0o644}, digest: digest.FromString("")},
+	}
+	b := map[string]*file{
+		"/bin":      {stat: &fstypes.Stat{Path: "bin", Mode: 0o40755}},
+		"/bin/foo":  {stat: &fstypes.Stat{Path: "foo", Mode: 0o755, Size: 3}, digest: digest.FromString("foo")},
+		"/etc":      {stat: &fstypes.Stat{Path: "etc", Mode: 0o40755, ModTime: 2}},
+		"/etc/date": {stat: &fstypes.Stat{Path: "date", Mode: 0o644, Size: 10, ModTime: 2, Xattrs: map[string][]byte{"user.foo": []byte("bar")}}, digest: digest.FromString("2")},
+		"/tmp.2":    {stat: &fstypes.Stat{Path: "tmp.2", Mode: 0o644}, digest: digest.FromString("")},
+	}
+	require.NotEqual(t, contentDigest(a), contentDigest(b))
+	require.Equal(t, contentDigest(a), contentDigest(map[string]*file{
+		"/tmp.1":    a["/tmp.1"],
+		"/etc/date": a["/etc/date"],
+		"/etc":      a["/etc"],
+		"/bin/foo":  a["/bin/foo"],
+		"/bin":      a["/bin"],
+	}))
+
+	require.Equal(t, []Diff{
+		{Path: "/etc", Type: DiffModified, Fields: []string{"mtime"}},
+		{Path: "/etc/date", Type: DiffModified, Fields: []string{"mtime", "content", "xattr.user.foo"}},
+		{Path: "/tmp.1", Type: DiffRemoved},
+		{Path: "/tmp.2", Type: DiffAdded},
+	}, diff(a, b))
+}
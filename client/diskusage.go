@@ -22,6 +22,7 @@ type UsageInfo struct {
 	Parents     []string        `json:"parents"`
 	Description string          `json:"description"`
 	RecordType  UsageRecordType `json:"recordType"`
+	Client      string          `json:"client,omitempty"`
 	Shared      bool            `json:"shared"`
 }
 
@@ -31,15 +32,23 @@ func (c *Client) DiskUsage(ctx context.Context, opts ...DiskUsageOption) ([]*Usa
 		o.SetDiskUsageOption(info)
 	}
 
-	req := &controlapi.DiskUsageRequest{Filter: info.Filter, AgeLimit: int64(info.AgeLimit)}
-	resp, err := c.ControlClient().DiskUsage(ctx, req)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to call diskusage")
+	var records []*controlapi.UsageRecord
+	req := &controlapi.DiskUsageRequest{Filter: info.Filter, AgeLimit: int64(info.AgeLimit), PageSize: int32(info.PageSize)}
+	for {
+		resp, err := c.ControlClient().DiskUsage(ctx, req)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to call diskusage")
+		}
+		records = append(records, resp.Record...)
+		if resp.NextPageToken == "" {
+			break
+		}
+		req.PageToken = resp.NextPageToken
 	}
 
 	var du []*UsageInfo
 
-	for _, d := range resp.Record {
+	for _, d := range records {
 		du = append(du, &UsageInfo{
 			ID:          d.ID,
 			Mutable:     d.Mutable,
@@ -57,6 +66,7 @@ func (c *Client) DiskUsage(ctx context.Context, opts ...DiskUsageOption) ([]*Usa
 				return nil
 			}(),
 			RecordType: UsageRecordType(d.RecordType),
+			Client:     d.Client,
 			Shared:     d.Shared,
 		})
 	}
@@ -67,6 +77,50 @@ func (c *Client) DiskUsage(ctx context.Context, opts ...DiskUsageOption) ([]*Usa
 	return du, nil
 }
 
+// UsageBreakdown is the usage of the records of a type last used in an age
+// bucket
+type UsageBreakdown struct {
+	RecordType UsageRecordType `json:"recordType"`
+	// Age is the upper bound of the time since the records were last used.
+	// It is 0 for the records older than all the buckets.
+	Age         time.Duration `json:"age"`
+	Count       int           `json:"count"`
+	Size        int64         `json:"size"`
+	Reclaimable int64         `json:"reclaimable"`
+}
+
+// DiskUsageBreakdown returns the usage of the records by type and by the time
+// since they were last used.
+func (c *Client) DiskUsageBreakdown(ctx context.Context, opts ...DiskUsageOption) ([]*UsageBreakdown, error) {
+	info := &DiskUsageInfo{}
+	for _, o := range opts {
+		o.SetDiskUsageOption(info)
+	}
+
+	// the breakdown comes with the first page, the records are not needed
+	resp, err := c.ControlClient().DiskUsage(ctx, &controlapi.DiskUsageRequest{
+		Filter:    info.Filter,
+		AgeLimit:  int64(info.AgeLimit),
+		PageSize:  1,
+		Breakdown: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to call diskusage")
+	}
+
+	out := make([]*UsageBreakdown, 0, len(resp.Breakdown))
+	for _, b := range resp.Breakdown {
+		out = append(out, &UsageBreakdown{
+			RecordType:  UsageRecordType(b.RecordType),
+			Age:         time.Duration(b.Age),
+			Count:       int(b.Count),
+			Size:        b.Size,
+			Reclaimable: b.Reclaimable,
+		})
+	}
+	return out, nil
+}
+
 type DiskUsageOption interface {
 	SetDiskUsageOption(*DiskUsageInfo)
 }
@@ -74,6 +128,11 @@ type DiskUsageOption interface {
 type DiskUsageInfo struct {
 	Filter   []string
 	AgeLimit time.Duration
+	// PageSize limits the number of records the daemon computes and returns
+	// at once. The records are returned sorted by ID after the record with
+	// the ID After.
+	PageSize int
+	After    string
 }
 
 type UsageRecordType string
@@ -98,3 +157,12 @@ func WithAgeLimit(age time.Duration) DiskUsageOption {
 		info.AgeLimit = age
 	})
 }
+
+// WithPageSize requests the records from the daemon in pages of size records
+// so that the daemon doesn't need to compute the sizes of all the records
+// before responding.
+func WithPageSize(size int) DiskUsageOption {
+	return diskUsageOptionFunc(func(info *DiskUsageInfo) {
+		info.PageSize = size
+	})
+}
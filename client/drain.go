@@ -0,0 +1,32 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+)
+
+// DrainResult is the result of draining the daemon
+type DrainResult struct {
+	// Completed is the number of running builds that finished during the
+	// drain
+	Completed int
+	// Canceled is the number of running builds canceled after the timeout
+	Canceled int
+}
+
+// Drain stops the daemon from accepting new builds, waits for the running
+// builds to finish and then stops the daemon. The running builds are canceled
+// after the timeout, the drain timeout of the daemon is used if it is 0.
+func (c *Client) Drain(ctx context.Context, timeout time.Duration) (*DrainResult, error) {
+	resp, err := c.ControlClient().Drain(ctx, &controlapi.DrainRequest{Timeout: int64(timeout)})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to drain")
+	}
+	return &DrainResult{
+		Completed: int(resp.Completed),
+		Canceled:  int(resp.Canceled),
+	}, nil
+}
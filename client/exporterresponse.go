@@ -0,0 +1,148 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/util/attestation"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// ExporterResponse is the response of a single exporter of the solve
+type ExporterResponse struct {
+	// ID is the index of the exporter in SolveOpt.Exports
+	ID   string
+	Type string
+	// Data is the raw response of the exporter
+	Data map[string]string
+
+	// Image is the result of the image, oci, docker and artifact exporters
+	Image *ImageExportResult
+	// OutputDir is the directory written by the exporter, see
+	// ExportEntry.OutputDir
+	OutputDir string
+}
+
+// ImageExportResult is the image or artifact created by an exporter
+type ImageExportResult struct {
+	// Digest is the digest of the manifest or of the index of a
+	// multi-platform image
+	Digest       digest.Digest
+	ConfigDigest digest.Digest
+	Descriptor   *ocispecs.Descriptor
+	// DockerCompatDigest is the digest of the variant with Docker media
+	// types, see the docker-compat-name option of the image exporter
+	DockerCompatDigest digest.Digest
+	// Names are the names of the image
+	Names []string
+	// Pushed are the names the image was pushed to
+	Pushed []string
+	// Manifests are the manifests of each platform of the image
+	Manifests []ImageManifest
+}
+
+// ImageManifest is the manifest of a single platform of an image
+type ImageManifest struct {
+	// Platform is not set for the manifest of a single-platform image
+	// that is not wrapped in an index
+	Platform *ocispecs.Platform
+	Digest   digest.Digest
+	// AttestationDigest is the digest of the attestation manifest of the
+	// platform, if any
+	AttestationDigest digest.Digest
+}
+
+// parseExporterResponse fills the typed results of resp from its data
+func parseExporterResponse(resp *ExporterResponse, ex *ExportEntry) error {
+	if ex != nil {
+		resp.OutputDir = ex.OutputDir
+	}
+	switch resp.Type {
+	case ExporterImage, ExporterOCI, ExporterDocker, ExporterArtifact:
+	default:
+		return nil
+	}
+	res, err := parseImageExportResult(resp.Data)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse response of %s exporter", resp.Type)
+	}
+	resp.Image = res
+	return nil
+}
+
+func parseImageExportResult(data map[string]string) (*ImageExportResult, error) {
+	res := &ImageExportResult{}
+	var err error
+	if v, ok := data[exptypes.ExporterImageDigestKey]; ok {
+		if res.Digest, err = digest.Parse(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := data[exptypes.ExporterImageConfigDigestKey]; ok {
+		if res.ConfigDigest, err = digest.Parse(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := data[exptypes.ExporterImageDockerCompatDigestKey]; ok {
+		if res.DockerCompatDigest, err = digest.Parse(v); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := data[exptypes.ExporterImageDescriptorKey]; ok {
+		dt, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid image descriptor")
+		}
+		var desc ocispecs.Descriptor
+		if err := json.Unmarshal(dt, &desc); err != nil {
+			return nil, errors.Wrap(err, "invalid image descriptor")
+		}
+		res.Descriptor = &desc
+	}
+	res.Names = splitNames(data[exptypes.ExporterImageNameKey])
+	res.Pushed = splitNames(data[exptypes.ExporterImagePushedKey])
+
+	v, ok := data[exptypes.ExporterImageManifestsKey]
+	if !ok {
+		if res.Digest != "" {
+			res.Manifests = []ImageManifest{{Digest: res.Digest}}
+		}
+		return res, nil
+	}
+	dt, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid image manifests")
+	}
+	var descs []ocispecs.Descriptor
+	if err := json.Unmarshal(dt, &descs); err != nil {
+		return nil, errors.Wrap(err, "invalid image manifests")
+	}
+	attestations := map[digest.Digest]digest.Digest{}
+	for _, desc := range descs {
+		if desc.Annotations[attestation.DockerAnnotationReferenceType] == attestation.DockerAnnotationReferenceTypeDefault {
+			attestations[digest.Digest(desc.Annotations[attestation.DockerAnnotationReferenceDigest])] = desc.Digest
+		}
+	}
+	for _, desc := range descs {
+		if _, ok := desc.Annotations[attestation.DockerAnnotationReferenceType]; ok {
+			continue
+		}
+		res.Manifests = append(res.Manifests, ImageManifest{
+			Platform:          desc.Platform,
+			Digest:            desc.Digest,
+			AttestationDigest: attestations[desc.Digest],
+		})
+	}
+	return res, nil
+}
+
+func splitNames(v string) []string {
+	if v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/util/attestation"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExporterResponse(t *testing.T) {
+	idx := digest.FromString("index")
+	amd64 := digest.FromString("amd64")
+	arm64 := digest.FromString("arm64")
+	att := digest.FromString("attestation")
+
+	manifests, err := json.Marshal([]ocispecs.Descriptor{
+		{Digest: amd64, Platform: &ocispecs.Platform{OS: "linux", Architecture: "amd64"}},
+		{Digest: arm64, Platform: &ocispecs.Platform{OS: "linux", Architecture: "arm64"}},
+		{Digest: att, Annotations: map[string]string{
+			attestation.DockerAnnotationReferenceType:   attestation.DockerAnnotationReferenceTypeDefault,
+			attestation.DockerAnnotationReferenceDigest: arm64.String(),
+		}},
+	})
+	require.NoError(t, err)
+	desc, err := json.Marshal(ocispecs.Descriptor{MediaType: ocispecs.MediaTypeImageIndex, Digest: idx})
+	require.NoError(t, err)
+
+	resp := ExporterResponse{
+		ID:   "0",
+		Type: ExporterImage,
+		Data: map[string]string{
+			exptypes.ExporterImageDigestKey:     idx.String(),
+			exptypes.ExporterImageNameKey:       "docker.io/library/foo:latest,docker.io/library/foo:v1",
+			exptypes.ExporterImagePushedKey:     "docker.io/library/foo:latest,docker.io/library/foo:v1",
+			exptypes.ExporterImageManifestsKey:  base64.StdEncoding.EncodeToString(manifests),
+			exptypes.ExporterImageDescriptorKey: base64.StdEncoding.EncodeToString(desc),
+		},
+	}
+	require.NoError(t, parseExporterResponse(&resp, &ExportEntry{Type: ExporterImage}))
+	require.NotNil(t, resp.Image)
+	require.Equal(t, idx, resp.Image.Digest)
+	require.Equal(t, ocispecs.MediaTypeImageIndex, resp.Image.Descriptor.MediaType)
+	require.Equal(t, []string{"docker.io/library/foo:latest", "docker.io/library/foo:v1"}, resp.Image.Names)
+	require.Equal(t, resp.Image.Names, resp.Image.Pushed)
+	require.Len(t, resp.Image.Manifests, 2)
+	require.Equal(t, "amd64", resp.Image.Manifests[0].Platform.Architecture)
+	require.Equal(t, amd64, resp.Image.Manifests[0].Digest)
+	require.Equal(t, digest.Digest(""), resp.Image.Manifests[0].AttestationDigest)
+	require.Equal(t, arm64, resp.Image.Manifests[1].Digest)
+	require.Equal(t, att, resp.Image.Manifests[1].AttestationDigest)
+
+	// single-platform image
+	resp = ExporterResponse{
+		Type: ExporterOCI,
+		Data: map[string]string{
+			exptypes.ExporterImageDigestKey: amd64.String(),
+		},
+	}
+	require.NoError(t, parseExporterResponse(&resp, nil))
+	require.Nil(t, resp.Image.Pushed)
+	require.Equal(t, []ImageManifest{{Digest: amd64}}, resp.Image.Manifests)
+
+	resp = ExporterResponse{Type: ExporterLocal}
+	require.NoError(t, parseExporterResponse(&resp, &ExportEntry{Type: ExporterLocal, OutputDir: "/out"}))
+	require.Nil(t, resp.Image)
+	require.Equal(t, "/out", resp.OutputDir)
+
+	resp = ExporterResponse{
+		Type: ExporterImage,
+		Data: map[string]string{
+			exptypes.ExporterImageDigestKey: "sha256:invalid",
+		},
+	}
+	require.ErrorContains(t, parseExporterResponse(&resp, nil), "failed to parse response of image exporter")
+}
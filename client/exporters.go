@@ -6,4 +6,6 @@ const (
 	ExporterTar    = "tar"
 	ExporterOCI    = "oci"
 	ExporterDocker = "docker"
+
+	ExporterArtifact = "artifact"
 )
@@ -16,6 +16,21 @@ type Vertex struct {
 	Cached        bool              `json:"cached,omitempty"`
 	Error         string            `json:"error,omitempty"`
 	ProgressGroup *pb.ProgressGroup `json:"progressGroup,omitempty"`
+	// ResourceUsage is set on the completion of the steps that ran
+	// processes, if the worker records their cgroup stats
+	ResourceUsage *ResourceUsage `json:"resourceUsage,omitempty"`
+	// CacheSource is the cache the result of a cached step was loaded from:
+	// the cache of the daemon or the name of a cache import, e.g.
+	// "registry:docker.io/org/app:cache"
+	CacheSource string `json:"cacheSource,omitempty"`
+}
+
+// ResourceUsage is the resources used by the processes of a step
+type ResourceUsage struct {
+	CPUNanos        uint64 `json:"cpuNanos,omitempty"`
+	MemoryPeakBytes uint64 `json:"memoryPeakBytes,omitempty"`
+	IOReadBytes     uint64 `json:"ioReadBytes,omitempty"`
+	IOWriteBytes    uint64 `json:"ioWriteBytes,omitempty"`
 }
 
 type VertexStatus struct {
@@ -34,6 +49,17 @@ type VertexLog struct {
 	Stream    int           `json:"stream,omitempty"`
 	Data      []byte        `json:"data"`
 	Timestamp time.Time     `json:"timestamp"`
+	// Truncated is set on the message logged when output of the stream was
+	// dropped because of the step log limits.
+	Truncated bool `json:"truncated,omitempty"`
+	// Spill is set on a message without data when the output of the stream
+	// from the point it was clipped because of the step log limits is kept in
+	// the spill file with this ID for the step logs of the build history. It
+	// is never sent to clients.
+	Spill string `json:"-"`
+	// Replay is set on output that is already kept in a spill file. It isn't
+	// added to the step logs again.
+	Replay bool `json:"-"`
 }
 
 type VertexWarning struct {
@@ -55,6 +81,24 @@ type SolveStatus struct {
 }
 
 type SolveResponse struct {
-	// ExporterResponse is also used for CacheExporter
+	// ExporterResponse is also used for CacheExporter. It merges the
+	// responses of all exporters.
 	ExporterResponse map[string]string
+	// ExporterResponses are the responses of each exporter
+	ExporterResponses []ExporterResponse
+	// Diagnostics are the problems found in the request of a solve with
+	// SolveOpt.Validate
+	Diagnostics []SolveDiagnostic
+}
+
+// SolveDiagnostic is a problem found by validating a solve request
+type SolveDiagnostic struct {
+	// Check is the validation step that found the problem, e.g. "llb" or
+	// "entitlements"
+	Check   string
+	Message string
+	// Warning is set for the problems that don't fail the solve
+	Warning bool
+	// Vertex is the digest of the vertex of the problem, if any
+	Vertex digest.Digest
 }
@@ -7,6 +7,7 @@ import (
 	"net"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/system"
@@ -51,16 +52,23 @@ type mount struct {
 }
 
 type ExecOp struct {
-	cache       MarshalCache
-	proxyEnv    *ProxyEnv
-	root        Output
-	mounts      []*mount
-	base        State
-	constraints Constraints
-	isValidated bool
-	secrets     []SecretInfo
-	ssh         []SSHInfo
-	cdiDevices  []CDIDeviceInfo
+	cache        MarshalCache
+	proxyEnv     *ProxyEnv
+	root         Output
+	mounts       []*mount
+	base         State
+	constraints  Constraints
+	isValidated  bool
+	secrets      []SecretInfo
+	ssh          []SSHInfo
+	localExecs   []LocalExecInfo
+	hostMounts   []HostMountInfo
+	cdiDevices   []CDIDeviceInfo
+	hermetic     *HermeticInfo
+	timeout      time.Duration
+	retry        *RetryInfo
+	artifacts    []string
+	artifactsOut Output
 }
 
 func (e *ExecOp) AddMount(target string, source Output, opt ...MountOption) Output {
@@ -93,6 +101,23 @@ func (e *ExecOp) AddMount(target string, source Output, opt ...MountOption) Outp
 	return m.output
 }
 
+// Artifacts returns the output with the artifacts captured from the exec,
+// see [CaptureArtifacts]
+func (e *ExecOp) Artifacts() Output {
+	if e.artifactsOut == nil {
+		if len(e.artifacts) == 0 {
+			e.artifactsOut = &output{vertex: e, err: errors.Errorf("exec has no artifacts")}
+		} else {
+			o := &output{vertex: e, getIndex: e.getArtifactsIndexFn()}
+			if p := e.constraints.Platform; p != nil {
+				o.platform = p
+			}
+			e.artifactsOut = o
+		}
+	}
+	return e.artifactsOut
+}
+
 func (e *ExecOp) GetMount(target string) Output {
 	for _, m := range e.mounts {
 		if m.target == target {
@@ -317,7 +342,9 @@ func (e *ExecOp) Marshal(ctx context.Context, c *Constraints) (digest.Digest, []
 		for _, s := range e.secrets {
 			if s.Env != nil {
 				addCap(&e.constraints, pb.CapExecSecretEnv)
-				break
+			}
+			if s.Directory {
+				addCap(&e.constraints, pb.CapExecMountSecretDirectory)
 			}
 		}
 	}
@@ -326,6 +353,46 @@ func (e *ExecOp) Marshal(ctx context.Context, c *Constraints) (digest.Digest, []
 		addCap(&e.constraints, pb.CapExecMountSSH)
 	}
 
+	if len(e.localExecs) > 0 {
+		addCap(&e.constraints, pb.CapExecMountLocalExec)
+	}
+
+	if len(e.hostMounts) > 0 {
+		addCap(&e.constraints, pb.CapExecMountHostBind)
+	}
+
+	if h := e.hermetic; h != nil {
+		addCap(&e.constraints, pb.CapExecHermetic)
+		env := slices.Clone(h.Env)
+		slices.Sort(env)
+		peo.Hermetic = &pb.HermeticOpt{
+			Env:    slices.Compact(env),
+			Strict: h.Strict,
+		}
+	}
+
+	if e.timeout > 0 {
+		addCap(&e.constraints, pb.CapExecTimeout)
+		peo.Timeout = int64(e.timeout)
+	}
+
+	if r := e.retry; r != nil && r.Attempts > 0 {
+		addCap(&e.constraints, pb.CapExecRetry)
+		rp := &pb.RetryPolicy{
+			Attempts: int32(r.Attempts),
+			Backoff:  int64(r.Backoff),
+		}
+		for _, code := range r.ExitCodes {
+			rp.ExitCodes = append(rp.ExitCodes, int32(code))
+		}
+		peo.Retry = rp
+	}
+
+	if len(e.artifacts) > 0 {
+		addCap(&e.constraints, pb.CapExecArtifacts)
+		peo.Artifacts = e.artifacts
+	}
+
 	if len(e.cdiDevices) > 0 {
 		addCap(&e.constraints, pb.CapExecMetaCDI)
 		cd := make([]*pb.CDIDevice, len(e.cdiDevices))
@@ -438,11 +505,12 @@ func (e *ExecOp) Marshal(ctx context.Context, c *Constraints) (digest.Digest, []
 				Dest:      *s.Target,
 				MountType: pb.MountType_SECRET,
 				SecretOpt: &pb.SecretOpt{
-					ID:       s.ID,
-					Uid:      uint32(s.UID),
-					Gid:      uint32(s.GID),
-					Optional: s.Optional,
-					Mode:     uint32(s.Mode),
+					ID:        s.ID,
+					Uid:       uint32(s.UID),
+					Gid:       uint32(s.GID),
+					Optional:  s.Optional,
+					Mode:      uint32(s.Mode),
+					Directory: s.Directory,
 				},
 			}
 			peo.Mounts = append(peo.Mounts, pm)
@@ -465,6 +533,35 @@ func (e *ExecOp) Marshal(ctx context.Context, c *Constraints) (digest.Digest, []
 		peo.Mounts = append(peo.Mounts, pm)
 	}
 
+	for _, l := range e.localExecs {
+		pm := &pb.Mount{
+			Input:     int64(pb.Empty),
+			Dest:      l.Target,
+			MountType: pb.MountType_LOCALEXEC,
+			LocalExecOpt: &pb.LocalExecOpt{
+				ID:       l.ID,
+				Uid:      uint32(l.UID),
+				Gid:      uint32(l.GID),
+				Mode:     uint32(l.Mode),
+				Optional: l.Optional,
+			},
+		}
+		peo.Mounts = append(peo.Mounts, pm)
+	}
+
+	for _, h := range e.hostMounts {
+		peo.Mounts = append(peo.Mounts, &pb.Mount{
+			Input:     int64(pb.Empty),
+			Dest:      h.Target,
+			Readonly:  true,
+			Output:    int64(pb.SkipOutput),
+			MountType: pb.MountType_HOSTBIND,
+			HostBindOpt: &pb.HostBindOpt{
+				Path: h.Path,
+			},
+		})
+	}
+
 	dt, err := deterministicMarshal(pop)
 	if err != nil {
 		return "", nil, nil, nil, err
@@ -517,6 +614,21 @@ func (e *ExecOp) getMountIndexFn(m *mount) func() (pb.OutputIndex, error) {
 	}
 }
 
+// getArtifactsIndexFn returns the index of the artifacts output, that follows
+// the outputs of the mounts
+func (e *ExecOp) getArtifactsIndexFn() func() (pb.OutputIndex, error) {
+	return func() (pb.OutputIndex, error) {
+		i := 0
+		for _, m := range e.mounts {
+			if m.noOutput || m.readonly || m.tmpfs || m.cacheID != "" {
+				continue
+			}
+			i++
+		}
+		return pb.OutputIndex(i), nil
+	}
+}
+
 type ExecState struct {
 	State
 	exec *ExecOp
@@ -534,6 +646,12 @@ func (e ExecState) Root() State {
 	return e.State
 }
 
+// Artifacts returns the state with the artifacts captured from the exec, see
+// [CaptureArtifacts]
+func (e ExecState) Artifacts() State {
+	return NewState(e.exec.Artifacts())
+}
+
 type MountOption func(*mount)
 
 func Readonly(m *mount) {
@@ -755,6 +873,68 @@ type SSHInfo struct {
 	Optional bool
 }
 
+// AddLocalExec is a RunOption that mounts the output of the command id, run
+// on the client before the exec starts, as a file at dest. The command needs
+// to be registered by the client with a local exec provider and the build
+// needs the local.exec entitlement. The output is not part of the cache key of
+// the exec.
+func AddLocalExec(dest, id string, opts ...LocalExecOption) RunOption {
+	return runOptionFunc(func(ei *ExecInfo) {
+		l := &LocalExecInfo{ID: id, Target: dest, Mode: 0400}
+		for _, opt := range opts {
+			opt.SetLocalExecOption(l)
+		}
+		ei.LocalExecs = append(ei.LocalExecs, *l)
+	})
+}
+
+type LocalExecOption interface {
+	SetLocalExecOption(*LocalExecInfo)
+}
+
+type localExecOptionFunc func(*LocalExecInfo)
+
+func (fn localExecOptionFunc) SetLocalExecOption(li *LocalExecInfo) {
+	fn(li)
+}
+
+type LocalExecInfo struct {
+	ID       string
+	Target   string
+	Mode     int
+	UID      int
+	GID      int
+	Optional bool
+}
+
+var LocalExecOptional = localExecOptionFunc(func(li *LocalExecInfo) {
+	li.Optional = true
+})
+
+// LocalExecFileOpt sets the uid, gid and permissions of the output file
+func LocalExecFileOpt(uid, gid, mode int) LocalExecOption {
+	return localExecOptionFunc(func(li *LocalExecInfo) {
+		li.UID = uid
+		li.GID = gid
+		li.Mode = mode
+	})
+}
+
+// AddHostMount is a RunOption that bind mounts the directory or file path of
+// the host of the daemon read-only at dest. The build needs the mount.host
+// entitlement. The content of the path is not part of the cache key of the
+// exec.
+func AddHostMount(dest, path string) RunOption {
+	return runOptionFunc(func(ei *ExecInfo) {
+		ei.HostMounts = append(ei.HostMounts, HostMountInfo{Target: dest, Path: path})
+	})
+}
+
+type HostMountInfo struct {
+	Target string
+	Path   string
+}
+
 // AddSecret is a RunOption that adds a secret to the exec.
 func AddSecret(dest string, opts ...SecretOption) RunOption {
 	return runOptionFunc(func(ei *ExecInfo) {
@@ -798,6 +978,8 @@ type SecretInfo struct {
 	UID      int
 	GID      int
 	Optional bool
+	// Directory mounts the secret as a directory of files
+	Directory bool
 }
 
 var SecretOptional = secretOptionFunc(func(si *SecretInfo) {
@@ -834,6 +1016,14 @@ func SecretAsEnvName(v string) SecretOption {
 	})
 }
 
+// SecretDirectory mounts the secret as a directory. The value of the secret
+// needs to be a tar archive of the files of the directory, e.g. the secret of a
+// directory source of the secrets provider. The uid and gid of [SecretFileOpt]
+// apply to all files, the mode only to the directory.
+var SecretDirectory = secretOptionFunc(func(si *SecretInfo) {
+	si.Directory = true
+})
+
 // SecretFileOpt sets the secret's target file uid, gid and permissions.
 func SecretFileOpt(uid, gid, mode int) SecretOption {
 	return secretOptionFunc(func(si *SecretInfo) {
@@ -843,6 +1033,75 @@ func SecretFileOpt(uid, gid, mode int) SecretOption {
 	})
 }
 
+// Hermetic declares the exec a pure function of its mounts, arguments and the
+// environment variables named in env. Other environment variables, the
+// hostname and extra hosts don't change the cache key of the exec so its
+// result can be reused by builds that only differ in those.
+//
+// The exec still runs with the full environment, hostname, extra hosts and
+// network. Nothing checks that the undeclared inputs don't change the
+// result, a cached result may have been created with different values for
+// them. Use [HermeticStrict] to run the exec without the undeclared inputs.
+func Hermetic(env ...string) RunOption {
+	return runOptionFunc(func(ei *ExecInfo) {
+		ei.Hermetic = &HermeticInfo{Env: env}
+	})
+}
+
+// HermeticStrict is like [Hermetic] but also blocks the inputs that are not
+// declared. Undeclared environment variables are not set for the process, the
+// hostname and extra hosts are not applied, the network is disabled and
+// secrets, SSH sockets, cache mounts and insecure security mode are rejected.
+func HermeticStrict(env ...string) RunOption {
+	return runOptionFunc(func(ei *ExecInfo) {
+		ei.Hermetic = &HermeticInfo{Env: env, Strict: true}
+	})
+}
+
+type HermeticInfo struct {
+	Env    []string
+	Strict bool
+}
+
+// Timeout stops the process if it runs for longer than d. With [Retry] the
+// timeout applies to every attempt.
+func Timeout(d time.Duration) RunOption {
+	return runOptionFunc(func(ei *ExecInfo) {
+		ei.Timeout = d
+	})
+}
+
+// Retry reruns the exec from its inputs up to attempts times if it fails. The
+// first retry is delayed by backoff and the delay doubles for every following
+// retry, up to 5 minutes. If exitCodes are set, only processes exiting with
+// one of the codes are retried. The daemon allows at most 10 attempts.
+func Retry(attempts int, backoff time.Duration, exitCodes ...int) RunOption {
+	return runOptionFunc(func(ei *ExecInfo) {
+		ei.Retry = &RetryInfo{
+			Attempts:  attempts,
+			Backoff:   backoff,
+			ExitCodes: exitCodes,
+		}
+	})
+}
+
+// CaptureArtifacts copies the files and directories at paths, such as test
+// reports, to the artifacts output of the exec after the process exits. The
+// artifacts are captured even if the process fails, in which case they are
+// available to gateway clients from the error of the solve. Missing paths are
+// skipped.
+func CaptureArtifacts(paths ...string) RunOption {
+	return runOptionFunc(func(ei *ExecInfo) {
+		ei.Artifacts = append(ei.Artifacts, paths...)
+	})
+}
+
+type RetryInfo struct {
+	Attempts  int
+	Backoff   time.Duration
+	ExitCodes []int
+}
+
 // ReadonlyRootFS sets the execs's root filesystem to be read-only.
 func ReadonlyRootFS() RunOption {
 	return runOptionFunc(func(ei *ExecInfo) {
@@ -866,7 +1125,13 @@ type ExecInfo struct {
 	ProxyEnv       *ProxyEnv
 	Secrets        []SecretInfo
 	SSH            []SSHInfo
+	LocalExecs     []LocalExecInfo
+	HostMounts     []HostMountInfo
 	CDIDevices     []CDIDeviceInfo
+	Hermetic       *HermeticInfo
+	Timeout        time.Duration
+	Retry          *RetryInfo
+	Artifacts      []string
 }
 
 type MountInfo struct {
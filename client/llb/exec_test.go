@@ -3,8 +3,10 @@ package llb
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/require"
 )
 
@@ -70,3 +72,117 @@ func TestExecOpMarshalConsistency(t *testing.T) {
 		prevDef = def.Def
 	}
 }
+
+func TestExecOpHermetic(t *testing.T) {
+	t.Parallel()
+
+	st := Image("foo").Run(Shlex("make"), HermeticStrict("CC", "CFLAGS", "CC")).Root()
+	def, err := st.Marshal(context.TODO())
+	require.NoError(t, err)
+
+	_, arr := parseDef(t, def.Def)
+	exec := arr[1].Op.(*pb.Op_Exec).Exec
+	require.Equal(t, []string{"CC", "CFLAGS"}, exec.Hermetic.Env)
+	require.True(t, exec.Hermetic.Strict)
+	require.True(t, def.Metadata[digest.FromBytes(def.Def[1])].Caps[pb.CapExecHermetic])
+}
+
+func TestExecOpTimeoutRetry(t *testing.T) {
+	t.Parallel()
+
+	st := Image("foo").Run(Shlex("apt-get update"), Timeout(time.Minute), Retry(3, time.Second, 100)).Root()
+	def, err := st.Marshal(context.TODO())
+	require.NoError(t, err)
+
+	_, arr := parseDef(t, def.Def)
+	exec := arr[1].Op.(*pb.Op_Exec).Exec
+	require.Equal(t, int64(time.Minute), exec.Timeout)
+	require.Equal(t, int32(3), exec.Retry.Attempts)
+	require.Equal(t, int64(time.Second), exec.Retry.Backoff)
+	require.Equal(t, []int32{100}, exec.Retry.ExitCodes)
+	caps := def.Metadata[digest.FromBytes(def.Def[1])].Caps
+	require.True(t, caps[pb.CapExecTimeout])
+	require.True(t, caps[pb.CapExecRetry])
+}
+
+func TestExecOpLocalExec(t *testing.T) {
+	t.Parallel()
+
+	st := Image("foo").Run(Shlex("verify /run/sig"), AddLocalExec("/run/sig", "sign", LocalExecFileOpt(1000, 1000, 0440), LocalExecOptional)).Root()
+	def, err := st.Marshal(context.TODO())
+	require.NoError(t, err)
+
+	_, arr := parseDef(t, def.Def)
+	exec := arr[1].Op.(*pb.Op_Exec).Exec
+	m := exec.Mounts[len(exec.Mounts)-1]
+	require.Equal(t, pb.MountType_LOCALEXEC, m.MountType)
+	require.Equal(t, "/run/sig", m.Dest)
+	require.Equal(t, int64(pb.Empty), m.Input)
+	require.Equal(t, &pb.LocalExecOpt{ID: "sign", Uid: 1000, Gid: 1000, Mode: 0440, Optional: true}, m.LocalExecOpt)
+	require.True(t, def.Metadata[digest.FromBytes(def.Def[1])].Caps[pb.CapExecMountLocalExec])
+}
+
+func TestExecOpHostMount(t *testing.T) {
+	t.Parallel()
+
+	st := Image("foo").Run(Shlex("make"), AddHostMount("/opt/toolchain", "/srv/toolchains/gcc")).Root()
+	def, err := st.Marshal(context.TODO())
+	require.NoError(t, err)
+
+	_, arr := parseDef(t, def.Def)
+	exec := arr[1].Op.(*pb.Op_Exec).Exec
+	m := exec.Mounts[len(exec.Mounts)-1]
+	require.Equal(t, pb.MountType_HOSTBIND, m.MountType)
+	require.Equal(t, "/opt/toolchain", m.Dest)
+	require.Equal(t, "/srv/toolchains/gcc", m.HostBindOpt.Path)
+	require.True(t, m.Readonly)
+	require.Equal(t, int64(pb.Empty), m.Input)
+	require.Equal(t, int64(pb.SkipOutput), m.Output)
+	require.True(t, def.Metadata[digest.FromBytes(def.Def[1])].Caps[pb.CapExecMountHostBind])
+}
+
+func TestExecOpArtifacts(t *testing.T) {
+	t.Parallel()
+
+	es := Image("foo").Run(Shlex("go test ./..."), AddMount("/src", Scratch()), CaptureArtifacts("/out/junit.xml"))
+	def, err := es.Artifacts().Marshal(context.TODO())
+	require.NoError(t, err)
+
+	_, arr := parseDef(t, def.Def)
+	exec := arr[1].Op.(*pb.Op_Exec).Exec
+	require.Equal(t, []string{"/out/junit.xml"}, exec.Artifacts)
+	require.True(t, def.Metadata[digest.FromBytes(def.Def[1])].Caps[pb.CapExecArtifacts])
+	// the artifacts follow the outputs of the root and /src mounts
+	require.Equal(t, int64(2), arr[2].Inputs[0].Index)
+
+	_, err = Image("foo").Run(Shlex("true")).Artifacts().Marshal(context.TODO())
+	require.ErrorContains(t, err, "exec has no artifacts")
+}
+
+func TestExecWeakMount(t *testing.T) {
+	t.Parallel()
+
+	prev := Image("example.com/app:previous")
+	st := Image("busybox").Run(
+		Shlex("true"),
+		AddMount("/prev", prev.Weak(), Readonly),
+		AddMount("/same", prev, Readonly),
+	).Root()
+	def, err := st.Marshal(context.TODO())
+	require.NoError(t, err)
+
+	m, arr := parseDef(t, def.Def)
+	dgst, idx := last(t, arr)
+	exec := m[dgst].Op.(*pb.Op_Exec).Exec
+	require.Equal(t, 0, idx)
+	inputs := m[dgst].Inputs
+	require.Len(t, inputs, 3)
+	require.False(t, inputs[0].Weak)
+	require.True(t, inputs[1].Weak)
+	require.False(t, inputs[2].Weak)
+	require.Equal(t, inputs[1].Digest, inputs[2].Digest)
+	require.Equal(t, int64(1), exec.Mounts[1].Input)
+	require.Equal(t, int64(2), exec.Mounts[2].Input)
+
+	require.Equal(t, Scratch().Output(), Scratch().Weak().Output())
+}
@@ -0,0 +1,143 @@
+package llb
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// jsonDefinition is the JSON representation of a Definition. The ops are
+// encoded with the JSON mapping of protobuf, in the order of the definition.
+type jsonDefinition struct {
+	Ops    []jsonOp        `json:"ops"`
+	Source json.RawMessage `json:"source,omitempty"`
+}
+
+type jsonOp struct {
+	// Digest is the digest of the op. When the definition is decoded, the
+	// digest only names the op for the inputs of the following ops and is
+	// recomputed, so that ops can be edited and written by hand.
+	Digest   digest.Digest   `json:"digest,omitempty"`
+	Op       json.RawMessage `json:"op"`
+	Metadata json.RawMessage `json:"metadata,omitempty"`
+}
+
+// MarshalJSON encodes the definition to JSON. The encoding is stable: the
+// same definition is always encoded to the same bytes.
+func (def *Definition) MarshalJSON() ([]byte, error) {
+	out := jsonDefinition{
+		Ops: make([]jsonOp, 0, len(def.Def)),
+	}
+	for _, dt := range def.Def {
+		var op pb.Op
+		if err := op.UnmarshalVT(dt); err != nil {
+			return nil, errors.Wrap(err, "failed to parse llb definition op")
+		}
+		dgst := digest.FromBytes(dt)
+		opJSON, err := marshalProtoJSON(&op)
+		if err != nil {
+			return nil, err
+		}
+		jop := jsonOp{Digest: dgst, Op: opJSON}
+		if md, ok := def.Metadata[dgst]; ok {
+			jop.Metadata, err = marshalProtoJSON(md.ToPB())
+			if err != nil {
+				return nil, err
+			}
+		}
+		out.Ops = append(out.Ops, jop)
+	}
+	if def.Source != nil {
+		var err error
+		out.Source, err = marshalProtoJSON(def.Source)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON decodes a definition encoded by MarshalJSON. The inputs of
+// the ops may refer to the ops by the digests of the JSON, which are
+// replaced by the digests of the decoded ops.
+func (def *Definition) UnmarshalJSON(dt []byte) error {
+	var in jsonDefinition
+	if err := json.Unmarshal(dt, &in); err != nil {
+		return errors.WithStack(err)
+	}
+
+	digests := make(map[digest.Digest]digest.Digest, len(in.Ops))
+	out := Definition{
+		Def:      make([][]byte, 0, len(in.Ops)),
+		Metadata: make(map[digest.Digest]OpMetadata, len(in.Ops)),
+	}
+	for i, jop := range in.Ops {
+		var op pb.Op
+		if err := protojson.Unmarshal(jop.Op, &op); err != nil {
+			return errors.Wrapf(err, "invalid op %d", i)
+		}
+		for _, inp := range op.Inputs {
+			dgst, ok := digests[digest.Digest(inp.Digest)]
+			if !ok {
+				return errors.Errorf("invalid op %d: input %s is not a previous op", i, inp.Digest)
+			}
+			inp.Digest = string(dgst)
+		}
+		opDt, err := deterministicMarshal(&op)
+		if err != nil {
+			return err
+		}
+		dgst := digest.FromBytes(opDt)
+		if jop.Digest != "" {
+			if _, ok := digests[jop.Digest]; ok {
+				return errors.Errorf("invalid op %d: duplicate digest %s", i, jop.Digest)
+			}
+			digests[jop.Digest] = dgst
+		}
+		out.Def = append(out.Def, opDt)
+		if len(jop.Metadata) > 0 {
+			var md pb.OpMetadata
+			if err := protojson.Unmarshal(jop.Metadata, &md); err != nil {
+				return errors.Wrapf(err, "invalid metadata of op %d", i)
+			}
+			out.Metadata[dgst] = NewOpMetadata(&md)
+		}
+	}
+	if len(in.Source) > 0 {
+		var src pb.Source
+		if err := protojson.Unmarshal(in.Source, &src); err != nil {
+			return errors.Wrap(err, "invalid source")
+		}
+		locs := make(map[string]*pb.Locations, len(src.Locations))
+		for k, l := range src.Locations {
+			dgst, ok := digests[digest.Digest(k)]
+			if !ok {
+				return errors.Errorf("invalid source: locations of unknown op %s", k)
+			}
+			locs[string(dgst)] = l
+		}
+		src.Locations = locs
+		out.Source = &src
+	}
+	*def = out
+	return nil
+}
+
+// marshalProtoJSON returns the compacted JSON mapping of m as protojson
+// randomizes its whitespace
+func marshalProtoJSON(m proto.Message) (json.RawMessage, error) {
+	dt, err := protojson.Marshal(m)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, dt); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,72 @@
+package llb
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefinitionJSON(t *testing.T) {
+	t.Parallel()
+
+	sm := NewSourceMap(nil, "Dockerfile", "Dockerfile", []byte("RUN echo foo"))
+	st := Image("busybox").
+		Run(Shlex("echo foo"), IgnoreCache, sm.Location([]*pb.Range{{Start: &pb.Position{Line: 1}, End: &pb.Position{Line: 1}}})).
+		File(Mkfile("/foo", 0644, []byte("foo")), WithCustomName("write foo"))
+	def, err := st.Marshal(context.TODO())
+	require.NoError(t, err)
+
+	dt, err := json.Marshal(def)
+	require.NoError(t, err)
+	for range 10 {
+		dt2, err := json.Marshal(def)
+		require.NoError(t, err)
+		require.Equal(t, string(dt), string(dt2))
+	}
+
+	var def2 Definition
+	require.NoError(t, json.Unmarshal(dt, &def2))
+	require.Equal(t, def.Def, def2.Def)
+	require.Equal(t, def.ToPB().Metadata, def2.ToPB().Metadata)
+	require.Equal(t, def.Source.Locations, def2.Source.Locations)
+	require.Equal(t, def.Source.Infos[0].Filename, def2.Source.Infos[0].Filename)
+}
+
+func TestDefinitionJSONAuthored(t *testing.T) {
+	t.Parallel()
+
+	// the ops are named instead of using their digests
+	dt := []byte(`{
+		"ops": [
+			{"digest": "base", "op": {"source": {"identifier": "docker-image://docker.io/library/busybox:latest"}, "platform": {"OS": "linux", "Architecture": "amd64"}}},
+			{"digest": "run", "op": {"inputs": [{"digest": "base"}], "exec": {"meta": {"args": ["echo", "foo"], "cwd": "/"}, "mounts": [{"dest": "/"}]}, "platform": {"OS": "linux", "Architecture": "amd64"}}, "metadata": {"description": {"llb.customname": "echo"}}},
+			{"op": {"inputs": [{"digest": "run"}]}}
+		]
+	}`)
+	var def Definition
+	require.NoError(t, json.Unmarshal(dt, &def))
+	require.Len(t, def.Def, 3)
+
+	var base, run, term pb.Op
+	require.NoError(t, base.UnmarshalVT(def.Def[0]))
+	require.NoError(t, run.UnmarshalVT(def.Def[1]))
+	require.NoError(t, term.UnmarshalVT(def.Def[2]))
+	require.Equal(t, "docker-image://docker.io/library/busybox:latest", base.GetSource().Identifier)
+	require.Equal(t, string(digest.FromBytes(def.Def[0])), run.Inputs[0].Digest)
+	require.Equal(t, []string{"echo", "foo"}, run.GetExec().Meta.Args)
+	require.Equal(t, string(digest.FromBytes(def.Def[1])), term.Inputs[0].Digest)
+	require.Equal(t, "echo", def.Metadata[digest.FromBytes(def.Def[1])].Description["llb.customname"])
+
+	head, err := def.Head()
+	require.NoError(t, err)
+	require.Equal(t, digest.FromBytes(def.Def[1]), head)
+
+	err = json.Unmarshal([]byte(`{"ops": [{"op": {"inputs": [{"digest": "missing"}]}}]}`), &def)
+	require.ErrorContains(t, err, "input missing is not a previous op")
+	err = json.Unmarshal([]byte(`{"ops": [{"digest": "a", "op": {}}, {"digest": "a", "op": {}}]}`), &def)
+	require.ErrorContains(t, err, "duplicate digest a")
+}
@@ -0,0 +1,80 @@
+package llbutil
+
+import (
+	"path"
+	"strings"
+
+	"github.com/moby/buildkit/client/llb"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// GoBuildOpt configures a Go build
+type GoBuildOpt struct {
+	// Packages are the packages built, "." by default
+	Packages []string
+	// Dir is the directory of the Go module in the source state
+	Dir string
+	// Platform is the platform the binaries are built for. The binaries are
+	// built for the platform of the Go state if unset.
+	Platform *ocispecs.Platform
+	// CGO enables cgo, it is disabled by default
+	CGO     bool
+	Tags    []string
+	Ldflags string
+	// CacheID prefixes the IDs of the module and build cache mounts
+	CacheID string
+	// RunOptions are added to the exec running the build
+	RunOptions []llb.RunOption
+}
+
+const (
+	goSrcDir = "/src"
+	goOutDir = "/out"
+)
+
+// GoBuild builds the Go packages of src with the go toolchain of the state
+// golang, e.g. a golang image, and returns the state containing the
+// binaries. The modules and the build cache are kept in shared cache mounts.
+func GoBuild(golang, src llb.State, opt GoBuildOpt) llb.State {
+	pkgs := opt.Packages
+	if len(pkgs) == 0 {
+		pkgs = []string{"."}
+	}
+	args := []string{"go", "build", "-trimpath", "-o", goOutDir + "/"}
+	if len(opt.Tags) > 0 {
+		args = append(args, "-tags", strings.Join(opt.Tags, ","))
+	}
+	if opt.Ldflags != "" {
+		args = append(args, "-ldflags", opt.Ldflags)
+	}
+	args = append(args, pkgs...)
+
+	cacheID := func(name string) string {
+		if opt.CacheID == "" {
+			return name
+		}
+		return opt.CacheID + "-" + name
+	}
+	cgo := "0"
+	if opt.CGO {
+		cgo = "1"
+	}
+	ro := []llb.RunOption{
+		llb.Args(args),
+		llb.Dir(path.Join(goSrcDir, opt.Dir)),
+		llb.AddEnv("CGO_ENABLED", cgo),
+		llb.AddEnv("GOCACHE", "/root/.cache/go-build"),
+		llb.AddEnv("GOMODCACHE", "/go/pkg/mod"),
+		llb.AddMount(goSrcDir, src, llb.Readonly),
+		llb.AddMount("/root/.cache/go-build", llb.Scratch(), llb.AsPersistentCacheDir(cacheID("go-build"), llb.CacheMountShared)),
+		llb.AddMount("/go/pkg/mod", llb.Scratch(), llb.AsPersistentCacheDir(cacheID("go-mod"), llb.CacheMountShared)),
+		llb.WithCustomNamef("go build %s", strings.Join(pkgs, " ")),
+	}
+	if p := opt.Platform; p != nil {
+		ro = append(ro, llb.AddEnv("GOOS", p.OS), llb.AddEnv("GOARCH", p.Architecture))
+		if p.Architecture == "arm" && p.Variant != "" {
+			ro = append(ro, llb.AddEnv("GOARM", strings.TrimPrefix(p.Variant, "v")))
+		}
+	}
+	return golang.Run(append(ro, opt.RunOptions...)...).AddMount(goOutDir, llb.Scratch())
+}
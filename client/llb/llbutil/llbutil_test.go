@@ -0,0 +1,95 @@
+package llbutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/solver/pb"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func execOp(t *testing.T, st llb.State) (*pb.ExecOp, map[string]*pb.Mount) {
+	def, err := st.Marshal(context.TODO())
+	require.NoError(t, err)
+	var exec *pb.ExecOp
+	for _, dt := range def.Def {
+		var op pb.Op
+		require.NoError(t, op.UnmarshalVT(dt))
+		if e := op.GetExec(); e != nil {
+			require.Nil(t, exec, "multiple execs")
+			exec = e
+		}
+	}
+	require.NotNil(t, exec)
+	mounts := map[string]*pb.Mount{}
+	for _, m := range exec.Mounts {
+		mounts[m.Dest] = m
+	}
+	return exec, mounts
+}
+
+func TestAptInstall(t *testing.T) {
+	st := llb.Image("debian").With(AptInstall([]string{"curl", "git"}, InstallOpt{
+		Pins:    map[string]string{"curl": "7.88.1-10+deb12u5"},
+		CacheID: "bookworm",
+	}))
+	exec, mounts := execOp(t, st)
+	require.Equal(t, "/bin/sh", exec.Meta.Args[0])
+	require.Contains(t, exec.Meta.Args[2], "apt-get install -y --no-install-recommends curl=7.88.1-10+deb12u5 git")
+	require.Equal(t, "bookworm-apt-cache", mounts["/var/cache/apt"].CacheOpt.ID)
+	require.Equal(t, pb.CacheSharingOpt_LOCKED, mounts["/var/cache/apt"].CacheOpt.Sharing)
+	require.Equal(t, "bookworm-apt-lib", mounts["/var/lib/apt"].CacheOpt.ID)
+}
+
+func TestApkAdd(t *testing.T) {
+	st := llb.Image("alpine").With(ApkAdd([]string{"curl", "$(evil)"}, InstallOpt{
+		Pins: map[string]string{"curl": "8.5.0-r0"},
+	}))
+	exec, mounts := execOp(t, st)
+	require.Equal(t, "apk add --update-cache --cache-dir /var/cache/apk curl=8.5.0-r0 '$(evil)'", exec.Meta.Args[2])
+	require.Equal(t, "apk", mounts["/var/cache/apk"].CacheOpt.ID)
+}
+
+func TestPipInstall(t *testing.T) {
+	reqs := llb.Local("context")
+	st := llb.Image("python").With(PipInstall([]string{"requests"}, PipOpt{
+		InstallOpt:       InstallOpt{Pins: map[string]string{"requests": "2.31.0"}},
+		Requirements:     &reqs,
+		RequirementsPath: "app/requirements.txt",
+		RequireHashes:    true,
+	}))
+	exec, mounts := execOp(t, st)
+	require.Equal(t, "pip install --require-hashes -r /run/llbutil/requirements/requirements.txt requests==2.31.0", exec.Meta.Args[2])
+	require.Equal(t, "/app/", mounts[pipRequirementsDir].Selector)
+	require.True(t, mounts[pipRequirementsDir].Readonly)
+	require.Equal(t, pb.CacheSharingOpt_SHARED, mounts["/root/.cache/pip"].CacheOpt.Sharing)
+}
+
+func TestAddUser(t *testing.T) {
+	gid := 1001
+	st := llb.Image("busybox").With(AddUser("app", 1000, UserOpt{GID: &gid}))
+	exec, _ := execOp(t, st)
+	require.Contains(t, exec.Meta.Args[2], "echo app:x:1001: >> /etc/group")
+	require.Contains(t, exec.Meta.Args[2], "echo app:x:1000:1001::/home/app:/bin/sh >> /etc/passwd")
+	require.Contains(t, exec.Meta.Args[2], "chown 1000:1001 /home/app")
+}
+
+func TestGoBuild(t *testing.T) {
+	st := GoBuild(llb.Image("golang"), llb.Local("context"), GoBuildOpt{
+		Packages: []string{"./cmd/app"},
+		Dir:      "app",
+		Platform: &ocispecs.Platform{OS: "linux", Architecture: "arm", Variant: "v7"},
+		Tags:     []string{"netgo", "osusergo"},
+	})
+	exec, mounts := execOp(t, st)
+	require.Equal(t, []string{"go", "build", "-trimpath", "-o", "/out/", "-tags", "netgo,osusergo", "./cmd/app"}, exec.Meta.Args)
+	require.Equal(t, "/src/app", exec.Meta.Cwd)
+	require.Subset(t, exec.Meta.Env, []string{"CGO_ENABLED=0", "GOOS=linux", "GOARCH=arm", "GOARM=7"})
+	require.True(t, mounts["/src"].Readonly)
+	require.Equal(t, "go-mod", mounts["/go/pkg/mod"].CacheOpt.ID)
+	require.Equal(t, "go-build", mounts["/root/.cache/go-build"].CacheOpt.ID)
+	require.Equal(t, int64(pb.SkipOutput), mounts["/src"].Output)
+	require.NotEqual(t, int64(pb.SkipOutput), mounts["/out"].Output)
+}
@@ -0,0 +1,127 @@
+// Package llbutil provides helpers generating the LLB of common build steps
+// with the cache mounts and options recommended for them, so that frontends
+// don't have to repeat them.
+package llbutil
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/client/llb"
+)
+
+// InstallOpt configures the installation of packages
+type InstallOpt struct {
+	// Pins are the versions the packages are installed at by package name,
+	// e.g. read from a lockfile
+	Pins map[string]string
+	// CacheID prefixes the IDs of the cache mounts of the package manager,
+	// so that different distributions don't share their caches
+	CacheID string
+	// RunOptions are added to the exec installing the packages
+	RunOptions []llb.RunOption
+}
+
+func (opt InstallOpt) cacheID(name string) string {
+	if opt.CacheID == "" {
+		return name
+	}
+	return opt.CacheID + "-" + name
+}
+
+func (opt InstallOpt) packages(pkgs []string, sep string) []string {
+	out := make([]string, 0, len(pkgs))
+	for _, p := range pkgs {
+		if v, ok := opt.Pins[p]; ok && v != "" {
+			p += sep + v
+		}
+		out = append(out, shellQuote(p))
+	}
+	return out
+}
+
+// AptInstall installs the Debian packages pkgs with apt-get. The package
+// lists and archives are kept in locked cache mounts instead of the image.
+func AptInstall(pkgs []string, opt InstallOpt) llb.StateOption {
+	return func(s llb.State) llb.State {
+		script := strings.Join([]string{
+			"set -e",
+			// the docker images remove the downloaded archives after every install
+			"rm -f /etc/apt/apt.conf.d/docker-clean",
+			"apt-get update",
+			"DEBIAN_FRONTEND=noninteractive apt-get install -y --no-install-recommends " + strings.Join(opt.packages(pkgs, "="), " "),
+		}, "\n")
+		ro := []llb.RunOption{
+			llb.Args([]string{"/bin/sh", "-c", script}),
+			llb.AddMount("/var/cache/apt", llb.Scratch(), llb.AsPersistentCacheDir(opt.cacheID("apt-cache"), llb.CacheMountLocked)),
+			llb.AddMount("/var/lib/apt", llb.Scratch(), llb.AsPersistentCacheDir(opt.cacheID("apt-lib"), llb.CacheMountLocked)),
+			llb.WithCustomNamef("apt-get install %s", strings.Join(pkgs, " ")),
+		}
+		return s.Run(append(ro, opt.RunOptions...)...).Root()
+	}
+}
+
+// ApkAdd installs the Alpine packages pkgs with apk. The downloaded packages
+// are kept in a locked cache mount instead of the image.
+func ApkAdd(pkgs []string, opt InstallOpt) llb.StateOption {
+	return func(s llb.State) llb.State {
+		args := append([]string{"apk", "add", "--update-cache", "--cache-dir", "/var/cache/apk"}, opt.packages(pkgs, "=")...)
+		ro := []llb.RunOption{
+			llb.Args([]string{"/bin/sh", "-c", strings.Join(args, " ")}),
+			llb.AddMount("/var/cache/apk", llb.Scratch(), llb.AsPersistentCacheDir(opt.cacheID("apk"), llb.CacheMountLocked)),
+			llb.WithCustomNamef("apk add %s", strings.Join(pkgs, " ")),
+		}
+		return s.Run(append(ro, opt.RunOptions...)...).Root()
+	}
+}
+
+// PipOpt configures the installation of Python packages
+type PipOpt struct {
+	InstallOpt
+	// Requirements is the state containing the requirements file at
+	// RequirementsPath. It is installed with the packages.
+	Requirements     *llb.State
+	RequirementsPath string
+	// RequireHashes only installs packages with a hash in the requirements
+	RequireHashes bool
+}
+
+const pipRequirementsDir = "/run/llbutil/requirements"
+
+// PipInstall installs the Python packages pkgs and the packages of the
+// requirements file of opt with pip. The downloaded packages are kept in a
+// shared cache mount instead of the image.
+func PipInstall(pkgs []string, opt PipOpt) llb.StateOption {
+	return func(s llb.State) llb.State {
+		args := []string{"pip", "install"}
+		if opt.RequireHashes {
+			args = append(args, "--require-hashes")
+		}
+		ro := []llb.RunOption{
+			llb.AddMount("/root/.cache/pip", llb.Scratch(), llb.AsPersistentCacheDir(opt.cacheID("pip"), llb.CacheMountShared)),
+		}
+		name := slices.Clone(pkgs)
+		if opt.Requirements != nil {
+			dir, file := path.Split(path.Clean("/" + opt.RequirementsPath))
+			ro = append(ro, llb.AddMount(pipRequirementsDir, *opt.Requirements, llb.SourcePath(dir), llb.Readonly))
+			args = append(args, "-r", shellQuote(path.Join(pipRequirementsDir, file)))
+			name = append(name, "-r "+opt.RequirementsPath)
+		}
+		args = append(args, opt.packages(pkgs, "==")...)
+		ro = append(ro,
+			llb.Args([]string{"/bin/sh", "-c", strings.Join(args, " ")}),
+			llb.WithCustomNamef("pip install %s", strings.Join(name, " ")),
+		)
+		return s.Run(append(ro, opt.RunOptions...)...).Root()
+	}
+}
+
+// shellQuote quotes s for sh unless it only contains characters that are
+// never interpreted
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._-+=:~/@,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
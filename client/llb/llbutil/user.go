@@ -0,0 +1,54 @@
+package llbutil
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moby/buildkit/client/llb"
+)
+
+// UserOpt configures the creation of a user
+type UserOpt struct {
+	// GID is the ID of the primary group of the user, named after the user.
+	// It defaults to the UID.
+	GID *int
+	// Home is the home directory, /home/<name> by default
+	Home string
+	// Shell is the login shell, /bin/sh by default
+	Shell string
+	// RunOptions are added to the exec creating the user
+	RunOptions []llb.RunOption
+}
+
+// AddUser creates the user name with uid and its primary group. The user
+// and group databases are edited directly so that it works on images without
+// useradd or adduser, e.g. busybox and distroless images with a shell.
+func AddUser(name string, uid int, opt UserOpt) llb.StateOption {
+	return func(s llb.State) llb.State {
+		gid := uid
+		if opt.GID != nil {
+			gid = *opt.GID
+		}
+		home := opt.Home
+		if home == "" {
+			home = "/home/" + name
+		}
+		shell := opt.Shell
+		if shell == "" {
+			shell = "/bin/sh"
+		}
+		script := strings.Join([]string{
+			"set -e",
+			fmt.Sprintf("echo %s >> /etc/group", shellQuote(fmt.Sprintf("%s:x:%d:", name, gid))),
+			fmt.Sprintf("echo %s >> /etc/passwd", shellQuote(fmt.Sprintf("%s:x:%d:%d::%s:%s", name, uid, gid, home, shell))),
+			"if [ -f /etc/shadow ]; then echo " + shellQuote(name+":!::0:::::") + " >> /etc/shadow; fi",
+			fmt.Sprintf("mkdir -p %s", shellQuote(home)),
+			fmt.Sprintf("chown %d:%d %s", uid, gid, shellQuote(home)),
+		}, "\n")
+		ro := []llb.RunOption{
+			llb.Args([]string{"/bin/sh", "-c", script}),
+			llb.WithCustomNamef("add user %s", name),
+		}
+		return s.Run(append(ro, opt.RunOptions...)...).Root()
+	}
+}
@@ -0,0 +1,129 @@
+package llb
+
+import (
+	"context"
+
+	"github.com/containerd/platforms"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// PlatformArgs returns the args describing the build and the target platforms
+// the way the Dockerfile frontend defines them: BUILDPLATFORM, BUILDOS,
+// BUILDOSVERSION, BUILDARCH, BUILDVARIANT, TARGETPLATFORM, TARGETOS,
+// TARGETOSVERSION, TARGETARCH and TARGETVARIANT.
+func PlatformArgs(build, target ocispecs.Platform) *EnvList {
+	kvs := [...][2]string{
+		{"BUILDPLATFORM", platforms.Format(build)},
+		{"BUILDOS", build.OS},
+		{"BUILDOSVERSION", build.OSVersion},
+		{"BUILDARCH", build.Architecture},
+		{"BUILDVARIANT", build.Variant},
+		{"TARGETPLATFORM", platforms.FormatAll(target)},
+		{"TARGETOS", target.OS},
+		{"TARGETOSVERSION", target.OSVersion},
+		{"TARGETARCH", target.Architecture},
+		{"TARGETVARIANT", target.Variant},
+	}
+	env := &EnvList{}
+	for _, kv := range kvs {
+		env = env.AddOrReplace(kv[0], kv[1])
+	}
+	return env
+}
+
+// ArgsGetter is a list of args, like [EnvList]
+type ArgsGetter interface {
+	Get(string) (string, bool)
+	Keys() []string
+}
+
+// PlatformFromArgs returns the target platform described by the TARGET* args,
+// like the Dockerfile frontend does for the platform of a stage. A valid
+// TARGETPLATFORM takes precedence over the args following it. It returns nil
+// if none of the args are set.
+func PlatformFromArgs(args ArgsGetter) *ocispecs.Platform {
+	var p ocispecs.Platform
+	var set bool
+	for _, key := range args.Keys() {
+		switch key {
+		case "TARGETPLATFORM":
+			v, _ := args.Get(key)
+			p, err := platforms.Parse(v)
+			if err != nil {
+				continue
+			}
+			return &p
+		case "TARGETOS":
+			p.OS, _ = args.Get(key)
+			set = true
+		case "TARGETARCH":
+			p.Architecture, _ = args.Get(key)
+			set = true
+		case "TARGETVARIANT":
+			p.Variant, _ = args.Get(key)
+			set = true
+		}
+	}
+	if !set {
+		return nil
+	}
+	return &p
+}
+
+// ForPlatform returns a [StateOption] which applies the [StateOption] returned
+// by f for the platform of the state. The platform of the constraints the
+// state is marshaled with is used if the state has no platform.
+func ForPlatform(f func(ocispecs.Platform) StateOption) StateOption {
+	return func(s State) State {
+		return s.Async(func(ctx context.Context, s State, c *Constraints) (State, error) {
+			p, err := statePlatform(s)(ctx, c)
+			if err != nil {
+				return State{}, err
+			}
+			return s.With(f(p)), nil
+		})
+	}
+}
+
+// WithPlatformArgs returns a [StateOption] which adds the [PlatformArgs] of
+// the build platform and the platform of the state to the environment of the
+// state. The platform of the constraints is used as target platform if the
+// state has no platform, like [ForPlatform].
+func WithPlatformArgs(build ocispecs.Platform) StateOption {
+	return func(s State) State {
+		return s.withValue(keyEnv, func(ctx context.Context, c *Constraints) (any, error) {
+			env, err := getEnv(s)(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			p, err := statePlatform(s)(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			args := PlatformArgs(build, p)
+			for _, k := range args.Keys() {
+				v, _ := args.Get(k)
+				env = env.AddOrReplace(k, v)
+			}
+			return env, nil
+		})
+	}
+}
+
+// statePlatform returns the platform of the state, or of the constraints or
+// the default platform if the state has none
+func statePlatform(s State) func(context.Context, *Constraints) (ocispecs.Platform, error) {
+	return func(ctx context.Context, c *Constraints) (ocispecs.Platform, error) {
+		p, err := getPlatform(s)(ctx, c)
+		if err != nil {
+			return ocispecs.Platform{}, err
+		}
+		if p != nil {
+			return *p, nil
+		}
+		if c != nil && c.Platform != nil {
+			return platforms.Normalize(*c.Platform), nil
+		}
+		return platforms.Normalize(platforms.DefaultSpec()), nil
+	}
+}
@@ -0,0 +1,80 @@
+package llb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/buildkit/solver/pb"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlatformArgs(t *testing.T) {
+	build := ocispecs.Platform{OS: "linux", Architecture: "amd64"}
+	target := ocispecs.Platform{OS: "linux", Architecture: "arm", Variant: "v7"}
+
+	args := PlatformArgs(build, target)
+	require.Equal(t, []string{
+		"BUILDPLATFORM=linux/amd64",
+		"BUILDOS=linux",
+		"BUILDOSVERSION=",
+		"BUILDARCH=amd64",
+		"BUILDVARIANT=",
+		"TARGETPLATFORM=linux/arm/v7",
+		"TARGETOS=linux",
+		"TARGETOSVERSION=",
+		"TARGETARCH=arm",
+		"TARGETVARIANT=v7",
+	}, args.ToArray())
+	require.Equal(t, &target, PlatformFromArgs(args))
+
+	args = (&EnvList{}).AddOrReplace("TARGETARCH", "riscv64")
+	require.Equal(t, &ocispecs.Platform{Architecture: "riscv64"}, PlatformFromArgs(args))
+	require.Nil(t, PlatformFromArgs(&EnvList{}))
+}
+
+func TestWithPlatformArgs(t *testing.T) {
+	ctx := context.TODO()
+	build := ocispecs.Platform{OS: "linux", Architecture: "amd64"}
+
+	st := Scratch().Platform(ocispecs.Platform{OS: "linux", Architecture: "arm64"}).With(WithPlatformArgs(build))
+	v, ok, err := st.GetEnv(ctx, "TARGETARCH")
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, "arm64", v)
+	v, _, err = st.GetEnv(ctx, "BUILDPLATFORM")
+	require.NoError(t, err)
+	require.Equal(t, "linux/amd64", v)
+
+	// the platform of the constraints applies to states without platform
+	st = Scratch().With(WithPlatformArgs(build))
+	v, _, err = st.GetEnv(ctx, "TARGETPLATFORM", LinuxS390x)
+	require.NoError(t, err)
+	require.Equal(t, "linux/s390x", v)
+}
+
+func TestForPlatform(t *testing.T) {
+	ctx := context.TODO()
+	cc := ForPlatform(func(p ocispecs.Platform) StateOption {
+		if p.Architecture == "arm64" {
+			return AddEnv("CC", "aarch64-linux-gnu-gcc")
+		}
+		return AddEnv("CC", "gcc")
+	})
+
+	st := Scratch().With(cc)
+	v, _, err := st.GetEnv(ctx, "CC", LinuxArm64)
+	require.NoError(t, err)
+	require.Equal(t, "aarch64-linux-gnu-gcc", v)
+
+	// the platform of the state takes precedence over the constraints
+	v, _, err = Scratch().Platform(ocispecs.Platform{OS: "linux", Architecture: "amd64"}).With(cc).GetEnv(ctx, "CC", LinuxArm64)
+	require.NoError(t, err)
+	require.Equal(t, "gcc", v)
+
+	def, err := Image("alpine").With(cc).Run(Shlex("sh -c '$CC main.c'")).Root().Marshal(ctx, LinuxArm64)
+	require.NoError(t, err)
+	_, arr := parseDef(t, def.Def)
+	exec := arr[len(arr)-2].Op.(*pb.Op_Exec).Exec
+	require.Contains(t, exec.Meta.Env, "CC=aarch64-linux-gnu-gcc")
+}
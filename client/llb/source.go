@@ -600,6 +600,19 @@ func OCILayout(ref string, opts ...OCILayoutOption) State {
 	return NewState(source.Output())
 }
 
+// SessionRef returns a state that represents the ref retained as name by a
+// previous solve of the same session, see client.SolveOpt.SessionRef.
+func SessionRef(name string, opts ...ConstraintsOpt) State {
+	var c Constraints
+	for _, o := range opts {
+		o.SetConstraintsOption(&c)
+	}
+	addCap(&c, pb.CapSourceSessionRef)
+
+	source := NewSource("session-ref://"+name, nil, c)
+	return NewState(source.Output())
+}
+
 type OCILayoutOption interface {
 	SetOCILayoutOption(*OCILayoutInfo)
 }
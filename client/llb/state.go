@@ -296,7 +296,13 @@ func (s State) Run(ro ...RunOption) ExecState {
 	}
 	exec.secrets = ei.Secrets
 	exec.ssh = ei.SSH
+	exec.localExecs = ei.LocalExecs
+	exec.hostMounts = ei.HostMounts
 	exec.cdiDevices = ei.CDIDevices
+	exec.hermetic = ei.Hermetic
+	exec.timeout = ei.Timeout
+	exec.retry = ei.Retry
+	exec.artifacts = ei.Artifacts
 
 	return ExecState{
 		State: s.WithOutput(exec.Output()),
@@ -485,8 +491,35 @@ func (s State) WithCgroupParent(cp string) State {
 	return cgroupParent(cp)(s)
 }
 
+// Weak returns a copy of the state that is a best-effort input of the
+// operations it is used in. If the state fails to build, the operations get
+// an empty input instead of failing, and the processes of exec operations get
+// the mount points of the failed inputs in BUILDKIT_MISSING_MOUNTS.
+// Daemons without the [pb.CapInputWeak] capability treat it as a regular
+// input.
+func (s State) Weak() State {
+	if s.Output() == nil {
+		return s
+	}
+	return s.WithOutput(&weakOutput{Output: s.Output()})
+}
+
 func (s State) isFileOpCopyInput() {}
 
+type weakOutput struct {
+	Output
+}
+
+func (o *weakOutput) ToInput(ctx context.Context, c *Constraints) (*pb.Input, error) {
+	inp, err := o.Output.ToInput(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	inp = inp.CloneVT()
+	inp.Weak = true
+	return inp, nil
+}
+
 type output struct {
 	vertex   Vertex
 	getIndex func() (pb.OutputIndex, error)
@@ -0,0 +1,305 @@
+// Package lockfile implements build lockfiles. A lockfile captures the image
+// digests, Git commits and HTTP checksums that the sources of a build were
+// resolved to, so that repeated builds can use the same sources.
+package lockfile
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/moby/buildkit/solver/pb"
+	srctypes "github.com/moby/buildkit/source/types"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	"github.com/moby/buildkit/util/gitutil"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// ExporterResponseKey is the key of the base64 encoded lockfile in the
+// exporter response of a build that requested it
+const ExporterResponseKey = "buildkit.lockfile"
+
+// Version is the version of the lockfile format
+const Version = 1
+
+// Lockfile is the list of the resolved sources of a build
+type Lockfile struct {
+	Version int     `json:"version"`
+	Images  []Image `json:"images,omitempty"`
+	Git     []Git   `json:"git,omitempty"`
+	HTTP    []HTTP  `json:"http,omitempty"`
+}
+
+// Image is an image reference resolved to a digest
+type Image struct {
+	// Ref is the normalized reference of the image without a digest
+	Ref      string             `json:"ref"`
+	Platform *ocispecs.Platform `json:"platform,omitempty"`
+	Digest   digest.Digest      `json:"digest"`
+}
+
+// Git is a Git URL resolved to a commit
+type Git struct {
+	// URL is the remote URL of the repository with the ref as its fragment
+	URL    string `json:"url"`
+	Commit string `json:"commit"`
+}
+
+// HTTP is an HTTP URL with the checksum of its content
+type HTTP struct {
+	URL      string        `json:"url"`
+	Checksum digest.Digest `json:"checksum"`
+}
+
+// Parse parses a lockfile
+func Parse(dt []byte) (*Lockfile, error) {
+	var l Lockfile
+	if err := json.Unmarshal(dt, &l); err != nil {
+		return nil, errors.Wrap(err, "failed to parse lockfile")
+	}
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// FromExporterResponse returns the lockfile of a build response. A nil lockfile
+// is returned if the build did not produce one.
+func FromExporterResponse(resp map[string]string) (*Lockfile, error) {
+	v, ok := resp[ExporterResponseKey]
+	if !ok {
+		return nil, nil
+	}
+	dt, err := base64.StdEncoding.DecodeString(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode lockfile")
+	}
+	return Parse(dt)
+}
+
+// Validate checks that all the sources of the lockfile are resolved
+func (l *Lockfile) Validate() error {
+	if l.Version != Version {
+		return errors.Errorf("unsupported lockfile version %d", l.Version)
+	}
+	for _, img := range l.Images {
+		if img.Ref == "" {
+			return errors.Errorf("lockfile image missing ref")
+		}
+		if err := img.Digest.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid digest for image %s", img.Ref)
+		}
+	}
+	for _, g := range l.Git {
+		if g.URL == "" {
+			return errors.Errorf("lockfile git source missing url")
+		}
+		if !gitutil.IsCommitSHA(g.Commit) {
+			return errors.Errorf("invalid commit %q for git source %s", g.Commit, g.URL)
+		}
+	}
+	for _, h := range l.HTTP {
+		if h.URL == "" {
+			return errors.Errorf("lockfile http source missing url")
+		}
+		if err := h.Checksum.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid checksum for http source %s", h.URL)
+		}
+	}
+	return nil
+}
+
+// Sort sorts the sources of the lockfile so that the same sources always
+// marshal to the same lockfile
+func (l *Lockfile) Sort() {
+	slices.SortFunc(l.Images, func(a, b Image) int {
+		if c := cmp.Compare(a.Ref, b.Ref); c != 0 {
+			return c
+		}
+		return cmp.Compare(platformString(a.Platform), platformString(b.Platform))
+	})
+	slices.SortFunc(l.Git, func(a, b Git) int {
+		return cmp.Compare(a.URL, b.URL)
+	})
+	slices.SortFunc(l.HTTP, func(a, b HTTP) int {
+		return cmp.Compare(a.URL, b.URL)
+	})
+}
+
+// Marshal returns the sorted lockfile as indented JSON
+func (l *Lockfile) Marshal() ([]byte, error) {
+	l.Sort()
+	dt, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return append(dt, '\n'), nil
+}
+
+// SourcePolicy returns the source policy that pins the sources of a build to
+// the sources of the lockfile. If locked is set, the policy also denies the
+// image, Git and HTTP sources that are not in the lockfile, except for the
+// images that are referenced by digest.
+func (l *Lockfile) SourcePolicy(locked bool) (*spb.Policy, error) {
+	pol := &spb.Policy{}
+	if locked {
+		for _, scheme := range []string{srctypes.DockerImageScheme, srctypes.GitScheme, srctypes.HTTPScheme, srctypes.HTTPSScheme} {
+			pol.Rules = append(pol.Rules, &spb.Rule{
+				Action: spb.PolicyAction_DENY,
+				Selector: &spb.Selector{
+					Identifier: scheme + "://*",
+					MatchType:  spb.MatchType_WILDCARD,
+				},
+			})
+		}
+		pol.Rules = append(pol.Rules, &spb.Rule{
+			Action: spb.PolicyAction_ALLOW,
+			Selector: &spb.Selector{
+				Identifier: srctypes.DockerImageScheme + "://*@*",
+				MatchType:  spb.MatchType_WILDCARD,
+			},
+		})
+	}
+
+	images := map[string]digest.Digest{}
+	for _, img := range l.Images {
+		if dgst, ok := images[img.Ref]; ok {
+			if dgst != img.Digest {
+				return nil, errors.Errorf("conflicting digests %s and %s for image %s", dgst, img.Digest, img.Ref)
+			}
+			continue
+		}
+		images[img.Ref] = img.Digest
+
+		id := srctypes.DockerImageScheme + "://" + img.Ref
+		pinned, err := pinImage(img.Ref, img.Digest)
+		if err != nil {
+			return nil, err
+		}
+		// the pinned identifier is allowed by the rule for the images
+		// referenced by digest
+		pol.Rules = append(pol.Rules, &spb.Rule{
+			Action: spb.PolicyAction_CONVERT,
+			Selector: &spb.Selector{
+				Identifier: id,
+			},
+			Updates: &spb.Update{
+				Identifier: pinned,
+			},
+		})
+	}
+
+	for _, g := range l.Git {
+		sels, err := gitSelectors(g.URL)
+		if err != nil {
+			return nil, err
+		}
+		for _, sel := range sels {
+			if locked {
+				pol.Rules = append(pol.Rules, &spb.Rule{
+					Action:   spb.PolicyAction_ALLOW,
+					Selector: sel.selector,
+				})
+			}
+			pol.Rules = append(pol.Rules, &spb.Rule{
+				Action:   spb.PolicyAction_CONVERT,
+				Selector: sel.selector,
+				Updates: &spb.Update{
+					Identifier: sel.identifier,
+					Attrs:      map[string]string{pb.AttrGitChecksum: g.Commit},
+				},
+			})
+		}
+	}
+
+	for _, h := range l.HTTP {
+		if locked {
+			pol.Rules = append(pol.Rules, allowRule(h.URL))
+		}
+		pol.Rules = append(pol.Rules, &spb.Rule{
+			Action: spb.PolicyAction_CONVERT,
+			Selector: &spb.Selector{
+				Identifier: h.URL,
+				MatchType:  spb.MatchType_EXACT,
+			},
+			Updates: &spb.Update{
+				Attrs: map[string]string{pb.AttrHTTPChecksum: h.Checksum.String()},
+			},
+		})
+	}
+	return pol, nil
+}
+
+func allowRule(id string) *spb.Rule {
+	return &spb.Rule{
+		Action: spb.PolicyAction_ALLOW,
+		Selector: &spb.Selector{
+			Identifier: id,
+			MatchType:  spb.MatchType_EXACT,
+		},
+	}
+}
+
+// pinImage returns the source identifier of ref with the digest
+func pinImage(ref string, dgst digest.Digest) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid image reference %s", ref)
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		return "", errors.Errorf("lockfile image reference %s must not contain a digest", ref)
+	}
+	pinned, err := reference.WithDigest(named, dgst)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return srctypes.DockerImageScheme + "://" + pinned.String(), nil
+}
+
+// gitSelector matches the source identifiers that llb.Git creates for a URL.
+// identifier is the identifier the matched source keeps.
+type gitSelector struct {
+	selector   *spb.Selector
+	identifier string
+}
+
+// gitSelectors returns the selectors for the sources of url, with and without
+// a subdirectory
+func gitSelectors(url string) ([]gitSelector, error) {
+	remote, err := gitutil.ParseURL(url)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid git url %s", url)
+	}
+	id := srctypes.GitScheme + "://" + remote.Host + path.Join("/", remote.Path)
+	if remote.Opts != nil && remote.Opts.Ref != "" {
+		id += "#" + remote.Opts.Ref
+	}
+	subdir := id + ":"
+	if !strings.Contains(id, "#") {
+		subdir = id + "#:"
+	}
+	return []gitSelector{
+		{
+			selector:   &spb.Selector{Identifier: id, MatchType: spb.MatchType_EXACT},
+			identifier: id,
+		},
+		{
+			selector:   &spb.Selector{Identifier: subdir + "*", MatchType: spb.MatchType_WILDCARD},
+			identifier: subdir + "${1}",
+		},
+	}, nil
+}
+
+func platformString(p *ocispecs.Platform) string {
+	if p == nil {
+		return ""
+	}
+	return platforms.Format(*p)
+}
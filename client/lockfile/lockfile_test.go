@@ -0,0 +1,148 @@
+package lockfile
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/sourcepolicy"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+const testCommit = "6b1c3fe1c8b8e0a5c6a0b6b4a2f1b3c0e3c7c0de"
+
+var (
+	testImageDigest = digest.FromString("image")
+	testHTTPDigest  = digest.FromString("http")
+)
+
+func testLockfile() *Lockfile {
+	return &Lockfile{
+		Version: Version,
+		Images: []Image{
+			{Ref: "docker.io/library/busybox:latest", Digest: testImageDigest},
+			{Ref: "docker.io/library/alpine:latest", Platform: &ocispecs.Platform{OS: "linux", Architecture: "arm64"}, Digest: testImageDigest},
+			{Ref: "docker.io/library/alpine:latest", Platform: &ocispecs.Platform{OS: "linux", Architecture: "amd64"}, Digest: testImageDigest},
+		},
+		Git: []Git{
+			{URL: "https://github.com/moby/buildkit.git#master", Commit: testCommit},
+		},
+		HTTP: []HTTP{
+			{URL: "https://example.com/foo.tar.gz", Checksum: testHTTPDigest},
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	dt, err := testLockfile().Marshal()
+	require.NoError(t, err)
+
+	l, err := Parse(dt)
+	require.NoError(t, err)
+	// the sources are sorted
+	require.Equal(t, "docker.io/library/alpine:latest", l.Images[0].Ref)
+	require.Equal(t, "amd64", l.Images[0].Platform.Architecture)
+	require.Equal(t, "arm64", l.Images[1].Platform.Architecture)
+	require.Equal(t, "docker.io/library/busybox:latest", l.Images[2].Ref)
+
+	resp := map[string]string{ExporterResponseKey: base64.StdEncoding.EncodeToString(dt)}
+	l2, err := FromExporterResponse(resp)
+	require.NoError(t, err)
+	require.Equal(t, l, l2)
+
+	l2, err = FromExporterResponse(map[string]string{})
+	require.NoError(t, err)
+	require.Nil(t, l2)
+
+	_, err = Parse([]byte(`{"version":2}`))
+	require.ErrorContains(t, err, "unsupported lockfile version 2")
+	_, err = Parse([]byte(`{"version":1,"images":[{"ref":"alpine","digest":"sha256:foo"}]}`))
+	require.ErrorContains(t, err, "invalid digest for image alpine")
+	_, err = Parse([]byte(`{"version":1,"git":[{"url":"https://github.com/moby/buildkit.git","commit":"master"}]}`))
+	require.ErrorContains(t, err, `invalid commit "master"`)
+	_, err = Parse([]byte(`{"version":1,"http":[{"url":"https://example.com/foo"}]}`))
+	require.ErrorContains(t, err, "invalid checksum for http source")
+}
+
+func TestSourcePolicy(t *testing.T) {
+	ctx := context.TODO()
+
+	pol, err := testLockfile().SourcePolicy(false)
+	require.NoError(t, err)
+
+	op := &pb.SourceOp{Identifier: "docker-image://docker.io/library/alpine:latest"}
+	mut, err := sourcepolicy.NewEngine([]*spb.Policy{pol}).Evaluate(ctx, op)
+	require.NoError(t, err)
+	require.True(t, mut)
+	require.Equal(t, "docker-image://docker.io/library/alpine:latest@"+testImageDigest.String(), op.Identifier)
+
+	op = &pb.SourceOp{Identifier: "git://github.com/moby/buildkit.git#master:docs"}
+	mut, err = sourcepolicy.NewEngine([]*spb.Policy{pol}).Evaluate(ctx, op)
+	require.NoError(t, err)
+	require.True(t, mut)
+	require.Equal(t, "git://github.com/moby/buildkit.git#master:docs", op.Identifier)
+	require.Equal(t, testCommit, op.Attrs[pb.AttrGitChecksum])
+
+	op = &pb.SourceOp{Identifier: "git://github.com/moby/buildkit.git#v0.20"}
+	mut, err = sourcepolicy.NewEngine([]*spb.Policy{pol}).Evaluate(ctx, op)
+	require.NoError(t, err)
+	require.False(t, mut)
+
+	op = &pb.SourceOp{Identifier: "https://example.com/foo.tar.gz"}
+	mut, err = sourcepolicy.NewEngine([]*spb.Policy{pol}).Evaluate(ctx, op)
+	require.NoError(t, err)
+	require.True(t, mut)
+	require.Equal(t, testHTTPDigest.String(), op.Attrs[pb.AttrHTTPChecksum])
+
+	// sources missing from the lockfile are allowed if not locked
+	op = &pb.SourceOp{Identifier: "docker-image://docker.io/library/debian:latest"}
+	mut, err = sourcepolicy.NewEngine([]*spb.Policy{pol}).Evaluate(ctx, op)
+	require.NoError(t, err)
+	require.False(t, mut)
+}
+
+func TestSourcePolicyLocked(t *testing.T) {
+	ctx := context.TODO()
+
+	pol, err := testLockfile().SourcePolicy(true)
+	require.NoError(t, err)
+
+	for _, id := range []string{
+		"docker-image://docker.io/library/busybox:latest",
+		"docker-image://docker.io/library/debian@" + testImageDigest.String(),
+		"git://github.com/moby/buildkit.git#master",
+		"https://example.com/foo.tar.gz",
+		"local://context",
+	} {
+		op := &pb.SourceOp{Identifier: id}
+		_, err := sourcepolicy.NewEngine([]*spb.Policy{pol}).Evaluate(ctx, op)
+		require.NoError(t, err, id)
+	}
+
+	for _, id := range []string{
+		"docker-image://docker.io/library/debian:latest",
+		"git://github.com/moby/buildkit.git#v0.20",
+		"https://example.com/bar.tar.gz",
+		"http://example.com/foo.tar.gz",
+	} {
+		op := &pb.SourceOp{Identifier: id}
+		_, err := sourcepolicy.NewEngine([]*spb.Policy{pol}).Evaluate(ctx, op)
+		require.ErrorIs(t, err, sourcepolicy.ErrSourceDenied, id)
+	}
+}
+
+func TestSourcePolicyConflict(t *testing.T) {
+	l := testLockfile()
+	l.Images = append(l.Images, Image{Ref: "docker.io/library/busybox:latest", Digest: testHTTPDigest})
+	_, err := l.SourcePolicy(false)
+	require.ErrorContains(t, err, "conflicting digests")
+
+	l = testLockfile()
+	l.Images = []Image{{Ref: "docker.io/library/busybox:latest@" + testImageDigest.String(), Digest: testImageDigest}}
+	_, err = l.SourcePolicy(false)
+	require.ErrorContains(t, err, "must not contain a digest")
+}
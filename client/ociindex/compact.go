@@ -0,0 +1,294 @@
+package ociindex
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/gofrs/flock"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// CompactOpt configures the compaction of an OCI layout
+type CompactOpt struct {
+	// TTL is the minimum age of the untagged manifests and the unreferenced
+	// blobs that are removed.
+	TTL time.Duration
+	// LinkDirs are OCI layouts on the same filesystem. The blobs of the
+	// store that also exist in them are replaced with hardlinks.
+	LinkDirs []string
+}
+
+// CompactResult summarizes the changes made by Compact
+type CompactResult struct {
+	// Skipped is true if the store was not compacted because it was in use
+	Skipped          bool
+	RemovedManifests int
+	RemovedBlobs     int
+	RemovedSize      int64
+	LinkedBlobs      int
+}
+
+// LockWriter marks the store as written to until release is called. The
+// blobs added by a writer are not referenced by the index until the writer
+// updates it, so Compact skips the store while it has writers.
+func (s StoreIndex) LockWriter() (release func(), _ error) {
+	lock := flock.New(s.writersLockPath)
+	if err := lock.RLock(); err != nil {
+		return nil, errors.Wrapf(err, "could not lock %s", s.writersLockPath)
+	}
+	return func() {
+		lock.Unlock()
+	}, nil
+}
+
+// Compact rewrites the index of the store without the duplicate entries, the
+// entries of missing manifests and the untagged manifests older than the TTL,
+// then removes the blobs that are not referenced by the index anymore. The
+// store is skipped if it has writers or its index is locked.
+func (s StoreIndex) Compact(opt CompactOpt) (*CompactResult, error) {
+	// the writers lock file is not removed as other writers may wait on it
+	wlock := flock.New(s.writersLockPath)
+	locked, err := wlock.TryLock()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lock %s", s.writersLockPath)
+	}
+	if !locked {
+		return &CompactResult{Skipped: true}, nil
+	}
+	defer wlock.Unlock()
+
+	lock := flock.New(s.lockPath)
+	locked, err = lock.TryLock()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not lock %s", s.lockPath)
+	}
+	if !locked {
+		return &CompactResult{Skipped: true}, nil
+	}
+	defer func() {
+		lock.Unlock()
+		os.RemoveAll(s.lockPath)
+	}()
+
+	dt, err := os.ReadFile(s.indexPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read %s", s.indexPath)
+	}
+	var idx ocispecs.Index
+	if err := json.Unmarshal(dt, &idx); err != nil {
+		return nil, errors.Wrapf(err, "could not unmarshal %s (%q)", s.indexPath, string(dt))
+	}
+
+	root := filepath.Dir(s.indexPath)
+	expired := time.Now().Add(-opt.TTL)
+	res := &CompactResult{}
+
+	type entryKey struct {
+		dgst    digest.Digest
+		name    string
+		refName string
+	}
+	seen := map[entryKey]struct{}{}
+	manifests := make([]ocispecs.Descriptor, 0, len(idx.Manifests))
+	for _, m := range idx.Manifests {
+		k := entryKey{m.Digest, m.Annotations[annotationImageName], m.Annotations[ocispecs.AnnotationRefName]}
+		if _, ok := seen[k]; ok {
+			continue
+		}
+		fi, err := os.Stat(blobPath(root, m.Digest))
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				return nil, errors.WithStack(err)
+			}
+			res.RemovedManifests++
+			continue
+		}
+		if k.name == "" && k.refName == "" && fi.ModTime().Before(expired) {
+			res.RemovedManifests++
+			continue
+		}
+		seen[k] = struct{}{}
+		manifests = append(manifests, m)
+	}
+
+	used := map[digest.Digest]struct{}{}
+	for _, m := range manifests {
+		if err := walkBlobs(root, m, used); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := removeUnused(filepath.Join(root, ocispecs.ImageBlobsDir), used, expired, res); err != nil {
+		return nil, err
+	}
+	// abandoned ingests of interrupted exports
+	ingests, err := os.ReadDir(filepath.Join(root, "ingest"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WithStack(err)
+	}
+	for _, e := range ingests {
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if fi.ModTime().Before(expired) {
+			if err := os.RemoveAll(filepath.Join(root, "ingest", e.Name())); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+	}
+
+	for _, dir := range opt.LinkDirs {
+		for dgst := range used {
+			linked, err := linkBlob(blobPath(root, dgst), blobPath(dir, dgst))
+			if err != nil {
+				return nil, err
+			}
+			if linked {
+				res.LinkedBlobs++
+			}
+		}
+	}
+
+	idx.Manifests = manifests
+	setOCIIndexDefaults(&idx)
+	dt, err = json.Marshal(idx)
+	if err != nil {
+		return nil, err
+	}
+	tmp := s.indexPath + ".tmp"
+	if err := os.WriteFile(tmp, dt, 0644); err != nil {
+		return nil, errors.Wrapf(err, "could not write %s", tmp)
+	}
+	if err := os.Rename(tmp, s.indexPath); err != nil {
+		os.Remove(tmp)
+		return nil, errors.Wrapf(err, "could not write %s", s.indexPath)
+	}
+	return res, nil
+}
+
+func blobPath(root string, dgst digest.Digest) string {
+	return filepath.Join(root, ocispecs.ImageBlobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// walkBlobs adds the digests of desc and the blobs it references to used.
+// Missing blobs are ignored, as an export may skip the blobs that are lazy in
+// the daemon.
+func walkBlobs(root string, desc ocispecs.Descriptor, used map[digest.Digest]struct{}) error {
+	if err := desc.Digest.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid digest %q", desc.Digest)
+	}
+	if _, ok := used[desc.Digest]; ok {
+		return nil
+	}
+	used[desc.Digest] = struct{}{}
+
+	if desc.MediaType != "" && !images.IsIndexType(desc.MediaType) && !images.IsManifestType(desc.MediaType) {
+		return nil
+	}
+	dt, err := os.ReadFile(blobPath(root, desc.Digest))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	var children struct {
+		MediaType string                `json:"mediaType"`
+		Config    *ocispecs.Descriptor  `json:"config,omitempty"`
+		Manifests []ocispecs.Descriptor `json:"manifests,omitempty"`
+		Layers    []ocispecs.Descriptor `json:"layers,omitempty"`
+	}
+	if err := json.Unmarshal(dt, &children); err != nil {
+		if desc.MediaType == "" {
+			// not a manifest
+			return nil
+		}
+		return errors.Wrapf(err, "failed to parse %s", desc.Digest)
+	}
+	if desc.MediaType == "" && !images.IsIndexType(children.MediaType) && !images.IsManifestType(children.MediaType) {
+		return nil
+	}
+	descs := append(children.Manifests, children.Layers...)
+	if children.Config != nil {
+		descs = append(descs, *children.Config)
+	}
+	for _, d := range descs {
+		if err := walkBlobs(root, d, used); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func removeUnused(dir string, used map[digest.Digest]struct{}, expired time.Time, res *CompactResult) error {
+	err := filepath.WalkDir(dir, func(p string, e fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if e.IsDir() {
+			return nil
+		}
+		alg, err := filepath.Rel(dir, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+		dgst := digest.NewDigestFromEncoded(digest.Algorithm(alg), e.Name())
+		if _, ok := used[dgst]; ok {
+			return nil
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return err
+		}
+		if !fi.ModTime().Before(expired) {
+			return nil
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		res.RemovedBlobs++
+		res.RemovedSize += fi.Size()
+		return nil
+	})
+	return errors.Wrap(err, "failed to remove unused blobs")
+}
+
+// linkBlob replaces p with a hardlink to target if both are the same blob
+func linkBlob(p, target string) (bool, error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	tfi, err := os.Stat(target)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	if os.SameFile(fi, tfi) || fi.Size() != tfi.Size() {
+		return false, nil
+	}
+	tmp := p + ".link"
+	if err := os.Link(target, tmp); err != nil {
+		return false, errors.Wrapf(err, "failed to link %s", target)
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		os.Remove(tmp)
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
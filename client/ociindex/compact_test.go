@@ -0,0 +1,143 @@
+package ociindex
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/flock"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+
+	writeBlob := func(dir, mediaType string, dt []byte, mtime time.Time) ocispecs.Descriptor {
+		desc := ocispecs.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(dt),
+			Size:      int64(len(dt)),
+		}
+		p := blobPath(dir, desc.Digest)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, dt, 0644))
+		require.NoError(t, os.Chtimes(p, mtime, mtime))
+		return desc
+	}
+	writeManifest := func(layers ...ocispecs.Descriptor) ocispecs.Descriptor {
+		config := writeBlob(dir, "application/vnd.buildkit.cacheconfig.v0", []byte(layers[len(layers)-1].Digest), old)
+		dt, err := json.Marshal(ocispecs.Manifest{
+			Versioned: specs.Versioned{SchemaVersion: 2},
+			MediaType: ocispecs.MediaTypeImageManifest,
+			Config:    config,
+			Layers:    layers,
+		})
+		require.NoError(t, err)
+		return writeBlob(dir, ocispecs.MediaTypeImageManifest, dt, old)
+	}
+
+	shared := writeBlob(dir, ocispecs.MediaTypeImageLayerGzip, []byte("shared"), old)
+	layer1 := writeBlob(dir, ocispecs.MediaTypeImageLayerGzip, []byte("layer1"), old)
+	layer2 := writeBlob(dir, ocispecs.MediaTypeImageLayerGzip, []byte("layer2"), old)
+	mfst1 := writeManifest(shared, layer1)
+	mfst2 := writeManifest(shared, layer2)
+	// written by an export that hasn't updated the index yet
+	pending := writeBlob(dir, ocispecs.MediaTypeImageLayerGzip, []byte("pending"), time.Now())
+	// abandoned
+	unused := writeBlob(dir, ocispecs.MediaTypeImageLayerGzip, []byte("unused"), old)
+
+	store := NewStoreIndex(dir)
+	require.NoError(t, store.Put(mfst1, Tag("latest")))
+	require.NoError(t, store.Put(mfst2))
+	require.NoError(t, store.Put(mfst1, Tag("latest")))
+	require.NoError(t, store.Put(randDescriptor("missing"), Tag("missing")))
+
+	// another cache with the same blobs
+	other := t.TempDir()
+	writeBlob(other, ocispecs.MediaTypeImageLayerGzip, []byte("shared"), old)
+	writeBlob(other, ocispecs.MediaTypeImageLayerGzip, []byte("otherlayer"), old)
+
+	res, err := store.Compact(CompactOpt{TTL: 24 * time.Hour, LinkDirs: []string{other}})
+	require.NoError(t, err)
+	require.Equal(t, &CompactResult{
+		RemovedManifests: 2,
+		RemovedBlobs:     4,
+		RemovedSize:      int64(len("layer2")+len("unused")) + mfst2.Size + int64(len(layer2.Digest)),
+		LinkedBlobs:      1,
+	}, res)
+
+	idx, err := store.Read()
+	require.NoError(t, err)
+	require.Len(t, idx.Manifests, 1)
+	require.Equal(t, mfst1.Digest, idx.Manifests[0].Digest)
+
+	for _, d := range []ocispecs.Descriptor{mfst1, shared, layer1, pending} {
+		require.FileExists(t, blobPath(dir, d.Digest))
+	}
+	for _, d := range []ocispecs.Descriptor{mfst2, layer2, unused} {
+		require.NoFileExists(t, blobPath(dir, d.Digest))
+	}
+
+	fi1, err := os.Stat(blobPath(dir, shared.Digest))
+	require.NoError(t, err)
+	fi2, err := os.Stat(blobPath(other, shared.Digest))
+	require.NoError(t, err)
+	require.True(t, os.SameFile(fi1, fi2))
+
+	// compacting again doesn't change anything
+	res, err = store.Compact(CompactOpt{TTL: 24 * time.Hour, LinkDirs: []string{other}})
+	require.NoError(t, err)
+	require.Equal(t, &CompactResult{}, res)
+}
+
+func TestCompactSkipsWriters(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+
+	// an old blob that a running export references again
+	dt := []byte("layer")
+	p := blobPath(dir, digest.FromBytes(dt))
+	require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.NoError(t, os.WriteFile(p, dt, 0644))
+	require.NoError(t, os.Chtimes(p, old, old))
+
+	store := NewStoreIndex(dir)
+	require.NoError(t, store.Put(randDescriptor("missing"), Tag("missing")))
+
+	release, err := store.LockWriter()
+	require.NoError(t, err)
+	release2, err := store.LockWriter()
+	require.NoError(t, err)
+
+	res, err := store.Compact(CompactOpt{TTL: 24 * time.Hour})
+	require.NoError(t, err)
+	require.Equal(t, &CompactResult{Skipped: true}, res)
+	require.FileExists(t, p)
+
+	release()
+	res, err = store.Compact(CompactOpt{TTL: 24 * time.Hour})
+	require.NoError(t, err)
+	require.True(t, res.Skipped)
+
+	release2()
+	res, err = store.Compact(CompactOpt{TTL: 24 * time.Hour})
+	require.NoError(t, err)
+	require.Equal(t, &CompactResult{RemovedManifests: 1, RemovedBlobs: 1, RemovedSize: int64(len(dt))}, res)
+	require.NoFileExists(t, p)
+
+	// a locked index skips the compaction
+	lock := flock.New(store.lockPath)
+	locked, err := lock.TryLock()
+	require.NoError(t, err)
+	require.True(t, locked)
+	defer lock.Unlock()
+	res, err = store.Compact(CompactOpt{TTL: 24 * time.Hour})
+	require.NoError(t, err)
+	require.True(t, res.Skipped)
+}
@@ -17,14 +17,18 @@ import (
 const (
 	// lockFileSuffix is the suffix of the lock file
 	lockFileSuffix = ".lock"
+	// writersLockFileSuffix is the suffix of the lock file shared by the
+	// writers of the store while they add blobs
+	writersLockFileSuffix = ".writers.lock"
 
 	annotationImageName = "io.containerd.image.name"
 )
 
 type StoreIndex struct {
-	indexPath  string
-	lockPath   string
-	layoutPath string
+	indexPath       string
+	lockPath        string
+	writersLockPath string
+	layoutPath      string
 }
 
 type NameOrTag struct {
@@ -44,9 +48,10 @@ func NewStoreIndex(storePath string) StoreIndex {
 	indexPath := path.Join(storePath, ocispecs.ImageIndexFile)
 	layoutPath := path.Join(storePath, ocispecs.ImageLayoutFile)
 	return StoreIndex{
-		indexPath:  indexPath,
-		lockPath:   indexPath + lockFileSuffix,
-		layoutPath: layoutPath,
+		indexPath:       indexPath,
+		lockPath:        indexPath + lockFileSuffix,
+		writersLockPath: indexPath + writersLockFileSuffix,
+		layoutPath:      layoutPath,
 	}
 }
 
@@ -56,6 +56,7 @@ func (c *Client) Prune(ctx context.Context, ch chan UsageInfo, opts ...PruneOpti
 					return nil
 				}(),
 				RecordType: UsageRecordType(d.RecordType),
+				Client:     d.Client,
 				Shared:     d.Shared,
 			}
 		}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"context"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+)
+
+// ConfigReload is the result of reloading the config of the daemon
+type ConfigReload struct {
+	// Applied are the changed settings applied to the running daemon
+	Applied []string
+	// RequiresRestart are the changed settings that only apply after the
+	// daemon restarts
+	RequiresRestart []string
+}
+
+// ReloadConfig reloads the config file of the daemon. The registries, GC
+// policies, entitlements and worker labels are applied without interrupting
+// the running builds. The config is not applied at all if it is invalid.
+func (c *Client) ReloadConfig(ctx context.Context) (*ConfigReload, error) {
+	resp, err := c.ControlClient().ReloadConfig(ctx, &controlapi.ReloadConfigRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to reload config")
+	}
+	return &ConfigReload{
+		Applied:         resp.Applied,
+		RequiresRestart: resp.RequiresRestart,
+	}, nil
+}
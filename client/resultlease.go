@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+)
+
+// ExporterResponseResultLease is the key of the ID of the result lease in the
+// exporter response of a build that set SolveOpt.ResultLeaseTTL
+const ExporterResponseResultLease = "buildkit.resultlease"
+
+// ResultLease is a lease keeping the result refs of a build from being pruned
+type ResultLease struct {
+	ID string `json:"id"`
+	// Ref is the ref of the build that took the lease
+	Ref       string              `json:"ref"`
+	CreatedAt time.Time           `json:"createdAt"`
+	ExpiresAt time.Time           `json:"expiresAt"`
+	Records   []ResultLeaseRecord `json:"records"`
+}
+
+// ResultLeaseRecord is a result ref held by a lease
+type ResultLeaseRecord struct {
+	// Key is the key of the ref in the result, empty for the default ref
+	Key string `json:"key,omitempty"`
+	// ID is the ID of the cache record of the ref, as listed by DiskUsage
+	ID         string `json:"id"`
+	WorkerID   string `json:"workerID"`
+	SnapshotID string `json:"snapshotID"`
+}
+
+// ListResultLeases lists the result leases taken by the builds
+func (c *Client) ListResultLeases(ctx context.Context) ([]*ResultLease, error) {
+	resp, err := c.ControlClient().ListResultLeases(ctx, &controlapi.ListResultLeasesRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list result leases")
+	}
+	var out []*ResultLease
+	for _, l := range resp.Leases {
+		out = append(out, resultLeaseFromPB(l))
+	}
+	return out, nil
+}
+
+// RenewResultLease extends a result lease to expire after ttl from now
+func (c *Client) RenewResultLease(ctx context.Context, id string, ttl time.Duration) (*ResultLease, error) {
+	resp, err := c.ControlClient().RenewResultLease(ctx, &controlapi.RenewResultLeaseRequest{
+		ID:  id,
+		TTL: int64(ttl),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to renew result lease %s", id)
+	}
+	return resultLeaseFromPB(resp.Lease), nil
+}
+
+// ReleaseResultLease releases a result lease before it expires
+func (c *Client) ReleaseResultLease(ctx context.Context, id string) error {
+	_, err := c.ControlClient().ReleaseResultLease(ctx, &controlapi.ReleaseResultLeaseRequest{ID: id})
+	if err != nil {
+		return errors.Wrapf(err, "failed to release result lease %s", id)
+	}
+	return nil
+}
+
+func resultLeaseFromPB(l *controlapi.ResultLease) *ResultLease {
+	out := &ResultLease{
+		ID:        l.ID,
+		Ref:       l.Ref,
+		CreatedAt: l.CreatedAt.AsTime(),
+		ExpiresAt: l.ExpiresAt.AsTime(),
+	}
+	for _, r := range l.Records {
+		out.Records = append(out.Records, ResultLeaseRecord{
+			Key:        r.Key,
+			ID:         r.ID,
+			WorkerID:   r.WorkerID,
+			SnapshotID: r.SnapshotID,
+		})
+	}
+	return out
+}
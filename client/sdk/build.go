@@ -0,0 +1,198 @@
+// Package sdk builds with buildkitd from Go programs with the defaults of
+// buildctl: it connects to the daemon, attaches the docker credentials, the
+// secrets and the SSH agents of the build to its session, syncs the local
+// directories and renders the progress of the build.
+package sdk
+
+import (
+	"context"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/auth/authprovider"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/moby/buildkit/session/sshforward/sshprovider"
+	"github.com/moby/buildkit/util/progress/progressui"
+	"github.com/pkg/errors"
+	"github.com/tonistiigi/fsutil"
+	"golang.org/x/sync/errgroup"
+)
+
+// DefaultFrontend is the frontend of the builds without a definition
+const DefaultFrontend = "dockerfile.v0"
+
+// Options are the options of a build
+type Options struct {
+	// Client is the client of the daemon. A client is created for the build
+	// if it is nil.
+	Client *client.Client
+	// Address is the address of the daemon the client is created for, the
+	// BUILDKIT_HOST environment variable or the default address of the
+	// daemon if it is empty
+	Address string
+
+	// Frontend is the frontend of the build, DefaultFrontend if the build
+	// has no definition. FrontendAttrs are its options.
+	Frontend      string
+	FrontendAttrs map[string]string
+	// Definition is the LLB built without a frontend
+	Definition *llb.Definition
+
+	// ContextDir is the directory of the build context. It is also the
+	// directory of the Dockerfile if Dockerfile is empty.
+	ContextDir string
+	// Dockerfile is the path of the Dockerfile
+	Dockerfile string
+	// LocalDirs are the other local directories of the build by name
+	LocalDirs map[string]string
+
+	Exports      []client.ExportEntry
+	CacheImports []client.CacheOptionsEntry
+	CacheExports []client.CacheOptionsEntry
+
+	// Secrets are the secrets of the build
+	Secrets []secretsprovider.Source
+	// SSH are the SSH agents forwarded to the build
+	SSH []sshprovider.AgentConfig
+	// DockerConfig holds the registry credentials, the default docker
+	// config file is loaded if it is nil
+	DockerConfig *configfile.ConfigFile
+	// Session are attached to the session of the build in addition
+	Session []session.Attachable
+
+	AllowedEntitlements []string
+
+	// Progress is the display mode of the progress, auto if it is empty
+	Progress progressui.DisplayMode
+	// ProgressOutput is where the progress is written, os.Stderr if it is
+	// nil
+	ProgressOutput io.Writer
+}
+
+// Build builds with the options and returns the response of the exporters
+func Build(ctx context.Context, opt Options) (*client.SolveResponse, error) {
+	out := opt.ProgressOutput
+	if out == nil {
+		out = os.Stderr
+	}
+	solveOpt, err := newSolveOpt(opt, out)
+	if err != nil {
+		return nil, err
+	}
+
+	c := opt.Client
+	if c == nil {
+		addr := opt.Address
+		if addr == "" {
+			addr = os.Getenv("BUILDKIT_HOST")
+		}
+		c, err = client.New(ctx, addr)
+		if err != nil {
+			return nil, err
+		}
+		defer c.Close()
+	}
+
+	mode := opt.Progress
+	if mode == progressui.DefaultMode {
+		mode = progressui.AutoMode
+	}
+	d, err := progressui.NewDisplay(out, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp *client.SolveResponse
+	ch := make(chan *client.SolveStatus)
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		var err error
+		resp, err = c.Solve(ctx, opt.Definition, solveOpt, ch)
+		return err
+	})
+	eg.Go(func() error {
+		// the display is not canceled with the build so that it reports the
+		// error
+		_, err := d.UpdateFrom(context.WithoutCancel(ctx), ch)
+		return err
+	})
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// newSolveOpt returns the solve options of the build, with its session
+func newSolveOpt(opt Options, out io.Writer) (client.SolveOpt, error) {
+	if opt.Definition != nil && opt.Frontend != "" {
+		return client.SolveOpt{}, errors.New("the build cannot have both a definition and a frontend")
+	}
+	solveOpt := client.SolveOpt{
+		Frontend:            opt.Frontend,
+		FrontendAttrs:       maps.Clone(opt.FrontendAttrs),
+		Exports:             opt.Exports,
+		CacheImports:        opt.CacheImports,
+		CacheExports:        opt.CacheExports,
+		AllowedEntitlements: opt.AllowedEntitlements,
+		LocalMounts:         map[string]fsutil.FS{},
+	}
+	if opt.Definition == nil && solveOpt.Frontend == "" {
+		solveOpt.Frontend = DefaultFrontend
+	}
+	if solveOpt.FrontendAttrs == nil {
+		solveOpt.FrontendAttrs = map[string]string{}
+	}
+
+	dirs := maps.Clone(opt.LocalDirs)
+	if dirs == nil {
+		dirs = map[string]string{}
+	}
+	if opt.ContextDir != "" {
+		dirs["context"] = opt.ContextDir
+		if _, ok := dirs["dockerfile"]; !ok {
+			dirs["dockerfile"] = opt.ContextDir
+		}
+	}
+	if opt.Dockerfile != "" {
+		dirs["dockerfile"] = filepath.Dir(opt.Dockerfile)
+		solveOpt.FrontendAttrs["filename"] = filepath.Base(opt.Dockerfile)
+	}
+	for name, dir := range dirs {
+		fs, err := fsutil.NewFS(dir)
+		if err != nil {
+			return client.SolveOpt{}, errors.Wrapf(err, "invalid local directory %s", name)
+		}
+		solveOpt.LocalMounts[name] = fs
+	}
+
+	dockerConfig := opt.DockerConfig
+	if dockerConfig == nil {
+		dockerConfig = config.LoadDefaultConfigFile(out)
+	}
+	solveOpt.Session = append(solveOpt.Session, authprovider.NewDockerAuthProvider(authprovider.DockerAuthProviderConfig{
+		ConfigFile: dockerConfig,
+	}))
+	if len(opt.Secrets) > 0 {
+		store, err := secretsprovider.NewStore(opt.Secrets)
+		if err != nil {
+			return client.SolveOpt{}, err
+		}
+		solveOpt.Session = append(solveOpt.Session, secretsprovider.NewSecretProvider(store))
+	}
+	if len(opt.SSH) > 0 {
+		sp, err := sshprovider.NewSSHAgentProvider(opt.SSH)
+		if err != nil {
+			return client.SolveOpt{}, err
+		}
+		solveOpt.Session = append(solveOpt.Session, sp)
+	}
+	solveOpt.Session = append(solveOpt.Session, opt.Session...)
+	return solveOpt, nil
+}
@@ -0,0 +1,59 @@
+package sdk
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSolveOpt(t *testing.T) {
+	ctxDir := t.TempDir()
+	dfDir := t.TempDir()
+	df := filepath.Join(dfDir, "build.Dockerfile")
+	require.NoError(t, os.WriteFile(df, []byte("FROM scratch\n"), 0600))
+
+	opt, err := newSolveOpt(Options{
+		ContextDir:    ctxDir,
+		Dockerfile:    df,
+		FrontendAttrs: map[string]string{"target": "release"},
+		Secrets:       []secretsprovider.Source{{ID: "token", Env: "TOKEN"}},
+		DockerConfig:  &configfile.ConfigFile{},
+	}, io.Discard)
+	require.NoError(t, err)
+	require.Equal(t, DefaultFrontend, opt.Frontend)
+	require.Equal(t, map[string]string{"target": "release", "filename": "build.Dockerfile"}, opt.FrontendAttrs)
+	require.Len(t, opt.LocalMounts, 2)
+	require.Contains(t, opt.LocalMounts, "context")
+	require.Contains(t, opt.LocalMounts, "dockerfile")
+	// the docker auth and the secrets
+	require.Len(t, opt.Session, 2)
+
+	opt, err = newSolveOpt(Options{
+		ContextDir:   ctxDir,
+		DockerConfig: &configfile.ConfigFile{},
+	}, io.Discard)
+	require.NoError(t, err)
+	require.Empty(t, opt.FrontendAttrs)
+	require.Len(t, opt.LocalMounts, 2)
+	require.Len(t, opt.Session, 1)
+
+	def, err := llb.Scratch().Marshal(context.TODO())
+	require.NoError(t, err)
+	opt, err = newSolveOpt(Options{Definition: def, DockerConfig: &configfile.ConfigFile{}}, io.Discard)
+	require.NoError(t, err)
+	require.Empty(t, opt.Frontend)
+	require.Empty(t, opt.LocalMounts)
+
+	_, err = newSolveOpt(Options{Definition: def, Frontend: "gateway.v0"}, io.Discard)
+	require.ErrorContains(t, err, "both a definition and a frontend")
+
+	_, err = newSolveOpt(Options{ContextDir: filepath.Join(ctxDir, "missing"), DockerConfig: &configfile.ConfigFile{}}, io.Discard)
+	require.ErrorContains(t, err, "invalid local directory")
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+)
+
+// SessionInfo contains information about an active session
+type SessionInfo struct {
+	ID            string            `json:"id"`
+	SharedKey     string            `json:"sharedKey"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	Methods       []string          `json:"methods"`
+	Resources     []SessionResource `json:"resources"`
+	BytesSent     int64             `json:"bytesSent"`
+	BytesReceived int64             `json:"bytesReceived"`
+	Builds        []string          `json:"builds"`
+}
+
+// SessionResource is a named resource exposed by a session attachable
+type SessionResource struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// ListSessions lists all sessions currently connected to the daemon
+func (c *Client) ListSessions(ctx context.Context) ([]*SessionInfo, error) {
+	resp, err := c.ControlClient().ListSessions(ctx, &controlapi.ListSessionsRequest{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list sessions")
+	}
+
+	var si []*SessionInfo
+	for _, s := range resp.Record {
+		info := &SessionInfo{
+			ID:            s.ID,
+			SharedKey:     s.SharedKey,
+			CreatedAt:     s.CreatedAt.AsTime(),
+			Methods:       s.Methods,
+			BytesSent:     s.BytesSent,
+			BytesReceived: s.BytesReceived,
+			Builds:        s.Builds,
+		}
+		for _, r := range s.Resources {
+			info.Resources = append(info.Resources, SessionResource{
+				Type: r.Type,
+				ID:   r.ID,
+			})
+		}
+		si = append(si, info)
+	}
+	return si, nil
+}
@@ -8,6 +8,7 @@ import (
 	"maps"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,6 +26,8 @@ import (
 	"github.com/moby/buildkit/solver/pb"
 	spb "github.com/moby/buildkit/sourcepolicy/pb"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/contentutil"
+	digest "github.com/opencontainers/go-digest"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/tonistiigi/fsutil"
@@ -51,7 +54,30 @@ type SolveOpt struct {
 	SessionPreInitialized bool             // TODO: refactor to better session syncing
 	Internal              bool
 	SourcePolicy          *spb.Policy
-	Ref                   string
+	// ConcurrencyLimits overrides the concurrency limits of the daemon for
+	// the operations of the build
+	ConcurrencyLimits *controlapi.ConcurrencyLimits
+	// WorkerConstraints select the worker of the build, in the
+	// "worker.label.<key>=<value>" and "worker.id=<id>" forms
+	WorkerConstraints []string
+	// Lockfile requests the lockfile of the resolved sources of the build,
+	// see lockfile.FromExporterResponse
+	Lockfile bool
+	// SessionRef retains the result of the build under this name until the
+	// session closes. The later builds sharing the session use it with
+	// llb.SessionRef without solving or exporting it again.
+	SessionRef string
+	// ResultLeaseTTL takes a lease keeping the result refs of the build from
+	// being pruned for this duration. The ID of the lease is returned in the
+	// exporter response, see ExporterResponseResultLease.
+	ResultLeaseTTL time.Duration
+	// Proxy is the HTTP proxy configuration of the git, http and registry
+	// fetches of the build, instead of the proxy environment of the daemon
+	Proxy *controlapi.ProxyConfig
+	// Validate checks the request without building it. The problems found
+	// are returned in SolveResponse.Diagnostics.
+	Validate bool
+	Ref      string
 }
 
 type ExportEntry struct {
@@ -65,6 +91,12 @@ type ExportEntry struct {
 type CacheOptionsEntry struct {
 	Type  string
 	Attrs map[string]string
+	// Output writes the tarball cache exported to the client instead of the
+	// dest file
+	Output func() (io.WriteCloser, error)
+	// Input reads the tarball cache imported from the client instead of the
+	// src file
+	Input func() (io.ReadCloser, error)
 }
 
 // Solve calls Solve on the controller.
@@ -131,6 +163,10 @@ func (c *Client) solve(ctx context.Context, def *llb.Definition, runGateway runG
 	if err != nil {
 		return nil, err
 	}
+	defer cacheOpt.cleanup()
+	if err := cacheOpt.lockWriters(); err != nil {
+		return nil, err
+	}
 
 	storesToUpdate := []string{}
 
@@ -275,7 +311,7 @@ func (c *Client) solve(ctx context.Context, def *llb.Definition, runGateway runG
 			})
 		}
 
-		resp, err := c.ControlClient().Solve(ctx, &controlapi.SolveRequest{
+		req := &controlapi.SolveRequest{
 			Ref:                     ref,
 			Definition:              pbd,
 			Exporters:               exports,
@@ -290,17 +326,64 @@ func (c *Client) solve(ctx context.Context, def *llb.Definition, runGateway runG
 			Entitlements:            slices.Clone(opt.AllowedEntitlements),
 			Internal:                opt.Internal,
 			SourcePolicy:            opt.SourcePolicy,
-		})
+			ConcurrencyLimits:       opt.ConcurrencyLimits,
+			WorkerConstraints:       opt.WorkerConstraints,
+			Lockfile:                opt.Lockfile,
+			SessionRef:              opt.SessionRef,
+			ResultLeaseTTL:          int64(opt.ResultLeaseTTL),
+			Proxy:                   opt.Proxy,
+		}
+		if opt.Validate {
+			resp, err := c.ControlClient().ValidateSolve(ctx, req)
+			if err != nil {
+				return errors.Wrap(err, "failed to validate solve")
+			}
+			res = &SolveResponse{}
+			for _, d := range resp.Diagnostics {
+				res.Diagnostics = append(res.Diagnostics, SolveDiagnostic{
+					Check:   d.Check,
+					Message: d.Message,
+					Warning: d.Warning,
+					Vertex:  digest.Digest(d.Vertex),
+				})
+			}
+			return nil
+		}
+		resp, err := c.ControlClient().Solve(ctx, req)
 		if err != nil {
 			return errors.Wrap(err, "failed to solve")
 		}
 		res = &SolveResponse{
 			ExporterResponse: resp.ExporterResponse,
 		}
+		exporterResponses := resp.ExporterResponses
+		if len(exporterResponses) == 0 && len(opt.Exports) == 1 {
+			// daemons that don't return the response of each exporter
+			exporterResponses = []*controlapi.ExporterResponse{{
+				Metadata: &controlapi.ExporterMetadata{ID: "0", Type: opt.Exports[0].Type},
+				Data:     resp.ExporterResponse,
+			}}
+		}
+		for _, r := range exporterResponses {
+			er := ExporterResponse{
+				ID:   r.GetMetadata().GetID(),
+				Type: r.GetMetadata().GetType(),
+				Data: r.Data,
+			}
+			var ex *ExportEntry
+			if i, err := strconv.Atoi(er.ID); err == nil && i >= 0 && i < len(opt.Exports) {
+				ex = &opt.Exports[i]
+			}
+			if err := parseExporterResponse(&er, ex); err != nil {
+				return err
+			}
+			res.ExporterResponses = append(res.ExporterResponses, er)
+		}
 		return nil
 	})
 
-	if runGateway != nil {
+	// nothing runs for a validation, so there is no gateway or status
+	if runGateway != nil && !opt.Validate {
 		eg.Go(func() error {
 			err := runGateway(ref, s, frontendAttrs)
 			if err == nil {
@@ -322,26 +405,28 @@ func (c *Client) solve(ctx context.Context, def *llb.Definition, runGateway runG
 		})
 	}
 
-	eg.Go(func() error {
-		stream, err := c.ControlClient().Status(statusContext, &controlapi.StatusRequest{
-			Ref: ref,
-		})
-		if err != nil {
-			return errors.Wrap(err, "failed to get status")
-		}
-		for {
-			resp, err := stream.Recv()
+	if !opt.Validate {
+		eg.Go(func() error {
+			stream, err := c.ControlClient().Status(statusContext, &controlapi.StatusRequest{
+				Ref: ref,
+			})
 			if err != nil {
-				if errors.Is(err, io.EOF) {
-					return nil
-				}
-				return errors.Wrap(err, "failed to receive status")
+				return errors.Wrap(err, "failed to get status")
 			}
-			if statusChan != nil {
-				statusChan <- NewSolveStatus(resp)
+			for {
+				resp, err := stream.Recv()
+				if err != nil {
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					return errors.Wrap(err, "failed to receive status")
+				}
+				if statusChan != nil {
+					statusChan <- NewSolveStatus(resp)
+				}
 			}
-		}
-	})
+		})
+	}
 
 	if err := eg.Wait(); err != nil {
 		return nil, err
@@ -358,6 +443,37 @@ func (c *Client) solve(ctx context.Context, def *llb.Definition, runGateway runG
 			if err := idx.Put(manifestDesc, ociindex.Tag(tag)); err != nil {
 				return nil, err
 			}
+			if opt, ok := cacheOpt.storesToCompact[storePath]; ok {
+				cacheOpt.unlockWriter(storePath)
+				// compaction is best effort and doesn't fail the build
+				res, err := idx.Compact(opt)
+				switch {
+				case err != nil:
+					bklog.G(ctx).Warnf("failed to compact local cache %s: %v", storePath, err)
+				case res.Skipped:
+					bklog.G(ctx).Debugf("skipped compaction of local cache %s in use by another build", storePath)
+				default:
+					bklog.G(ctx).Debugf("compacted local cache %s: removed %d manifests and %d blobs (%d bytes), linked %d blobs", storePath, res.RemovedManifests, res.RemovedBlobs, res.RemovedSize, res.LinkedBlobs)
+				}
+			}
+		}
+		for storePath, ex := range cacheOpt.tarballsToWrite {
+			if err := writeCacheTarball(ctx, cacheOpt.contentStores["local:"+storePath], manifestDesc, ex); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for id, store := range cacheOpt.storesToBackfill {
+		manifestDescJSON := res.ExporterResponse["cache.backfill."+id+".manifest"]
+		if manifestDescJSON == "" {
+			continue
+		}
+		var manifestDesc ocispecs.Descriptor
+		if err = json.Unmarshal([]byte(manifestDescJSON), &manifestDesc); err != nil {
+			return nil, err
+		}
+		if err := ociindex.NewStoreIndex(store.path).Put(manifestDesc, ociindex.Tag(store.tag)); err != nil {
+			return nil, err
 		}
 	}
 	if manifestDescDt := res.ExporterResponse[exptypes.ExporterImageDescriptorKey]; manifestDescDt != "" {
@@ -435,18 +551,112 @@ type cacheOptions struct {
 	options        controlapi.CacheOptions
 	contentStores  map[string]content.Store // key: ID of content store ("local:" + csDir)
 	storesToUpdate map[string]string        // key: path to content store, value: tag
-	frontendAttrs  map[string]string
+	// storesToCompact are the local cache exports compacted after the
+	// index is updated. key: path to content store
+	storesToCompact map[string]ociindex.CompactOpt
+	// storesToBackfill are the local tiered cache imports written by the
+	// build if they missed records. key: ID of the import
+	storesToBackfill map[string]cacheBackfillStore
+	frontendAttrs    map[string]string
+	// tarballsToWrite are the tarball cache exports collected in temporary
+	// content stores. key: path to content store
+	tarballsToWrite map[string]CacheOptionsEntry
+	tempDirs        []string
+	// writerLocks release the writer locks of the local stores written by
+	// the build. key: path to content store
+	writerLocks map[string]func()
+}
+
+type cacheBackfillStore struct {
+	path string
+	tag  string
+}
+
+// isCacheBackfill returns true for the tiered cache imports written to when
+// they miss records found in a slower tier
+func isCacheBackfill(im CacheOptionsEntry) bool {
+	if _, ok := im.Attrs["tier"]; !ok {
+		return false
+	}
+	b, _ := strconv.ParseBool(im.Attrs["backfill"])
+	return b
+}
+
+func (c *cacheOptions) cleanup() {
+	for _, release := range c.writerLocks {
+		release()
+	}
+	for _, dir := range c.tempDirs {
+		os.RemoveAll(dir)
+	}
+}
+
+// lockWriters prevents the compaction of the local stores written by the
+// build until their index is updated
+func (c *cacheOptions) lockWriters() error {
+	c.writerLocks = map[string]func(){}
+	paths := slices.Collect(maps.Keys(c.storesToUpdate))
+	for _, store := range c.storesToBackfill {
+		paths = append(paths, store.path)
+	}
+	for _, p := range paths {
+		if _, ok := c.writerLocks[p]; ok {
+			continue
+		}
+		release, err := ociindex.NewStoreIndex(p).LockWriter()
+		if err != nil {
+			return err
+		}
+		c.writerLocks[p] = release
+	}
+	return nil
 }
 
-func parseCacheOptions(ctx context.Context, isGateway bool, opt SolveOpt) (*cacheOptions, error) {
+// unlockWriter releases the writer lock of the store at p
+func (c *cacheOptions) unlockWriter(p string) {
+	if release, ok := c.writerLocks[p]; ok {
+		release()
+		delete(c.writerLocks, p)
+	}
+}
+
+func parseCacheOptions(ctx context.Context, isGateway bool, opt SolveOpt) (_ *cacheOptions, retErr error) {
 	var (
 		cacheExports []*controlapi.CacheOptionsEntry
 		cacheImports []*controlapi.CacheOptionsEntry
 	)
 	contentStores := make(map[string]content.Store)
 	storesToUpdate := make(map[string]string)
+	storesToCompact := make(map[string]ociindex.CompactOpt)
+	storesToBackfill := make(map[string]cacheBackfillStore)
 	frontendAttrs := make(map[string]string)
+	tarballsToWrite := make(map[string]CacheOptionsEntry)
+	var tempDirs []string
+	defer func() {
+		if retErr != nil {
+			for _, dir := range tempDirs {
+				os.RemoveAll(dir)
+			}
+		}
+	}()
 	for _, ex := range opt.CacheExports {
+		// tarball caches without a path on the daemon host are sent to
+		// the client as a local cache and written to dest after the build
+		if _, ok := ex.Attrs["path"]; ex.Type == "tarball" && !ok {
+			if ex.Attrs["dest"] == "" && ex.Output == nil {
+				return nil, errors.New("tarball cache exporter requires dest or path")
+			}
+			csDir, err := os.MkdirTemp("", "buildkit-cache-export-")
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			tempDirs = append(tempDirs, csDir)
+			tarballsToWrite[csDir] = ex
+
+			attrs := maps.Clone(ex.Attrs)
+			attrs["dest"] = csDir
+			ex = CacheOptionsEntry{Type: "local", Attrs: attrs}
+		}
 		if ex.Type == "local" {
 			csDir := ex.Attrs["dest"]
 			if csDir == "" {
@@ -467,6 +677,14 @@ func parseCacheOptions(ctx context.Context, isGateway bool, opt SolveOpt) (*cach
 			}
 			// TODO(AkihiroSuda): support custom index JSON path and tag
 			storesToUpdate[csDir] = tag
+
+			compactOpt, ok, err := parseCompactOpt(ex.Attrs)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				storesToCompact[csDir] = compactOpt
+			}
 		}
 		if ex.Type == "registry" {
 			regRef := ex.Attrs["ref"]
@@ -480,11 +698,38 @@ func parseCacheOptions(ctx context.Context, isGateway bool, opt SolveOpt) (*cach
 		})
 	}
 	for _, im := range opt.CacheImports {
+		if _, ok := im.Attrs["path"]; im.Type == "tarball" && !ok {
+			if im.Attrs["src"] == "" && im.Input == nil {
+				return nil, errors.New("tarball cache importer requires src or path")
+			}
+			csDir, err := os.MkdirTemp("", "buildkit-cache-import-")
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			tempDirs = append(tempDirs, csDir)
+			if err := extractCacheTarball(im, csDir); err != nil {
+				return nil, err
+			}
+			attrs := maps.Clone(im.Attrs)
+			attrs["src"] = csDir
+			im = CacheOptionsEntry{Type: "local", Attrs: attrs}
+		}
 		if im.Type == "local" {
 			csDir := im.Attrs["src"]
 			if csDir == "" {
 				return nil, errors.New("local cache importer requires src")
 			}
+			tag := "latest"
+			if t, ok := im.Attrs["tag"]; ok {
+				tag = t
+			}
+			// a missing tier that is backfilled is created by the build
+			backfill := isCacheBackfill(im)
+			if backfill {
+				if err := os.MkdirAll(csDir, 0755); err != nil {
+					return nil, errors.WithStack(err)
+				}
+			}
 			cs, err := contentlocal.NewStore(csDir)
 			if err != nil {
 				bklog.G(ctx).Warning("local cache import at " + csDir + " not found due to err: " + err.Error())
@@ -492,14 +737,9 @@ func parseCacheOptions(ctx context.Context, isGateway bool, opt SolveOpt) (*cach
 			}
 			// if digest is not specified, attempt to load from tag
 			if im.Attrs["digest"] == "" {
-				tag := "latest"
-				if t, ok := im.Attrs["tag"]; ok {
-					tag = t
-				}
-
 				idx := ociindex.NewStoreIndex(csDir)
 				desc, err := idx.Get(tag)
-				if err != nil {
+				if err != nil && !backfill {
 					bklog.G(ctx).Warning("local cache import at " + csDir + " not found due to err: " + err.Error())
 					continue
 				}
@@ -507,10 +747,13 @@ func parseCacheOptions(ctx context.Context, isGateway bool, opt SolveOpt) (*cach
 					im.Attrs["digest"] = desc.Digest.String()
 				}
 			}
-			if im.Attrs["digest"] == "" {
+			if im.Attrs["digest"] == "" && !backfill {
 				return nil, errors.New("local cache importer requires either explicit digest, \"latest\" tag or custom tag on index.json")
 			}
 			contentStores["local:"+csDir] = cs
+			if backfill {
+				storesToBackfill[strconv.Itoa(len(cacheImports))] = cacheBackfillStore{path: csDir, tag: tag}
+			}
 		}
 		if im.Type == "registry" {
 			regRef := im.Attrs["ref"]
@@ -537,13 +780,81 @@ func parseCacheOptions(ctx context.Context, isGateway bool, opt SolveOpt) (*cach
 			Exports: cacheExports,
 			Imports: cacheImports,
 		},
-		contentStores:  contentStores,
-		storesToUpdate: storesToUpdate,
-		frontendAttrs:  frontendAttrs,
+		contentStores:    contentStores,
+		storesToUpdate:   storesToUpdate,
+		storesToCompact:  storesToCompact,
+		storesToBackfill: storesToBackfill,
+		frontendAttrs:    frontendAttrs,
+		tarballsToWrite:  tarballsToWrite,
+		tempDirs:         tempDirs,
 	}
 	return &res, nil
 }
 
+// parseCompactOpt parses the compaction attributes of a local cache export
+func parseCompactOpt(attrs map[string]string) (ociindex.CompactOpt, bool, error) {
+	opt := ociindex.CompactOpt{TTL: 7 * 24 * time.Hour}
+	v, ok := attrs["compact"]
+	if !ok {
+		return opt, false, nil
+	}
+	compact, err := strconv.ParseBool(v)
+	if err != nil {
+		return opt, false, errors.Wrap(err, "failed to parse compact")
+	}
+	if v, ok := attrs["compact-ttl"]; ok {
+		opt.TTL, err = time.ParseDuration(v)
+		if err != nil {
+			return opt, false, errors.Wrap(err, "failed to parse compact-ttl")
+		}
+	}
+	if v, ok := attrs["compact-link-dirs"]; ok && v != "" {
+		opt.LinkDirs = strings.Split(v, ";")
+	}
+	return opt, compact, nil
+}
+
+// extractCacheTarball extracts the tarball cache read from the input of im,
+// or from its src file, to dir
+func extractCacheTarball(im CacheOptionsEntry, dir string) error {
+	open := im.Input
+	if open == nil {
+		open = func() (io.ReadCloser, error) {
+			return os.Open(im.Attrs["src"])
+		}
+	}
+	r, err := open()
+	if err != nil {
+		return errors.Wrap(err, "failed to open cache tarball")
+	}
+	defer r.Close()
+	return contentutil.ExtractOCILayoutTar(r, dir)
+}
+
+// writeCacheTarball writes the cache exported to cs as a tarball to the
+// output of ex, or to its dest file
+func writeCacheTarball(ctx context.Context, cs content.Store, desc ocispecs.Descriptor, ex CacheOptionsEntry) error {
+	tag := "latest"
+	if t, ok := ex.Attrs["tag"]; ok {
+		tag = t
+	}
+	create := ex.Output
+	if create == nil {
+		create = func() (io.WriteCloser, error) {
+			return os.Create(ex.Attrs["dest"])
+		}
+	}
+	w, err := create()
+	if err != nil {
+		return errors.Wrap(err, "failed to create cache tarball")
+	}
+	if err := contentutil.WriteOCILayoutTar(ctx, cs, desc, tag, w); err != nil {
+		w.Close()
+		return err
+	}
+	return errors.WithStack(w.Close())
+}
+
 func prepareMounts(opt *SolveOpt) (map[string]fsutil.FS, error) {
 	// merge local mounts and fallback local directories together
 	mounts := make(map[string]fsutil.FS)
@@ -27,6 +27,8 @@ func NewSolveStatus(resp *controlapi.StatusResponse) *SolveStatus {
 			Error:         v.Error,
 			Cached:        v.Cached,
 			ProgressGroup: v.ProgressGroup,
+			ResourceUsage: ResourceUsageFromPB(v.ResourceUsage),
+			CacheSource:   v.CacheSource,
 		})
 	}
 	for _, v := range resp.Statuses {
@@ -47,6 +49,7 @@ func NewSolveStatus(resp *controlapi.StatusResponse) *SolveStatus {
 			Stream:    int(v.Stream),
 			Data:      v.Msg,
 			Timestamp: v.Timestamp.AsTime(),
+			Truncated: v.Truncated,
 		})
 	}
 	for _, v := range resp.Warnings {
@@ -78,6 +81,8 @@ func (ss *SolveStatus) Marshal() (out []*controlapi.StatusResponse) {
 				Error:         v.Error,
 				Cached:        v.Cached,
 				ProgressGroup: v.ProgressGroup,
+				ResourceUsage: v.ResourceUsage.ToPB(),
+				CacheSource:   v.CacheSource,
 			})
 		}
 		for _, v := range ss.Statuses {
@@ -93,11 +98,15 @@ func (ss *SolveStatus) Marshal() (out []*controlapi.StatusResponse) {
 			})
 		}
 		for i, v := range ss.Logs {
+			if v.Spill != "" {
+				continue
+			}
 			sr.Logs = append(sr.Logs, &controlapi.VertexLog{
 				Vertex:    string(v.Vertex),
 				Stream:    int64(v.Stream),
 				Msg:       v.Data,
 				Timestamp: timestamppb.New(v.Timestamp),
+				Truncated: v.Truncated,
 			})
 			logSize += len(v.Data) + emptyLogVertexSize
 			// avoid logs growing big and split apart if they do
@@ -158,3 +167,42 @@ func timestampToPB(ts *time.Time) *timestamppb.Timestamp {
 	}
 	return nil
 }
+
+// ResourceUsageFromPB converts the resource usage of a step or a build from
+// its protobuf form
+func ResourceUsageFromPB(ru *controlapi.ResourceUsage) *ResourceUsage {
+	if ru == nil {
+		return nil
+	}
+	return &ResourceUsage{
+		CPUNanos:        ru.CpuNanos,
+		MemoryPeakBytes: ru.MemoryPeakBytes,
+		IOReadBytes:     ru.IoReadBytes,
+		IOWriteBytes:    ru.IoWriteBytes,
+	}
+}
+
+// ToPB converts the resource usage to its protobuf form
+func (ru *ResourceUsage) ToPB() *controlapi.ResourceUsage {
+	if ru == nil {
+		return nil
+	}
+	return &controlapi.ResourceUsage{
+		CpuNanos:        ru.CPUNanos,
+		MemoryPeakBytes: ru.MemoryPeakBytes,
+		IoReadBytes:     ru.IOReadBytes,
+		IoWriteBytes:    ru.IOWriteBytes,
+	}
+}
+
+// Add adds the usage of another step. The memory peak is the largest peak
+// of the steps.
+func (ru *ResourceUsage) Add(other *ResourceUsage) {
+	if other == nil {
+		return
+	}
+	ru.CPUNanos += other.CPUNanos
+	ru.MemoryPeakBytes = max(ru.MemoryPeakBytes, other.MemoryPeakBytes)
+	ru.IOReadBytes += other.IOReadBytes
+	ru.IOWriteBytes += other.IOWriteBytes
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+const (
+	// StepLogsMediaType is the media type of the archive with the logs of
+	// every step that is saved with a build history record.
+	StepLogsMediaType = "application/vnd.buildkit.steplogs.v0.tar"
+
+	// StepLogsIndexPath is the path of the index in the step logs archive.
+	// The index is always the first entry of the archive.
+	StepLogsIndexPath = "index.json"
+)
+
+// StepLogsIndex lists the steps that have logs in a step logs archive.
+type StepLogsIndex struct {
+	Steps []StepLog `json:"steps"`
+}
+
+// StepLog describes the logs of a single step in a step logs archive.
+type StepLog struct {
+	Vertex digest.Digest `json:"vertex"`
+	Name   string        `json:"name"`
+	// Path is the path of the gzip compressed logs in the archive.
+	Path string `json:"path"`
+	// Size is the uncompressed size of the logs.
+	Size int64 `json:"size"`
+	// Truncated is set if output of the step was dropped because of the
+	// step log limits.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ReadStepLogs reads a step logs archive and calls fn with the uncompressed
+// logs of every step in the order they are stored in the archive.
+func ReadStepLogs(r io.Reader, fn func(StepLog, io.Reader) error) error {
+	tr := tar.NewReader(r)
+	hdr, err := tr.Next()
+	if err != nil {
+		return errors.Wrap(err, "failed to read step logs index")
+	}
+	if hdr.Name != StepLogsIndexPath {
+		return errors.Errorf("invalid step logs archive: expected %s, got %s", StepLogsIndexPath, hdr.Name)
+	}
+	var idx StepLogsIndex
+	if err := json.NewDecoder(tr).Decode(&idx); err != nil {
+		return errors.Wrap(err, "failed to decode step logs index")
+	}
+	steps := make(map[string]StepLog, len(idx.Steps))
+	for _, st := range idx.Steps {
+		steps[st.Path] = st
+	}
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		st, ok := steps[hdr.Name]
+		if !ok {
+			continue
+		}
+		gz, err := gzip.NewReader(tr)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read logs of %s", st.Vertex)
+		}
+		err = fn(st, gz)
+		gz.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+)
+
+// CacheIssueType is the kind of inconsistency found by a cache verification
+type CacheIssueType string
+
+const (
+	// CacheIssueMissingSnapshot is a cache record whose snapshot was removed
+	// from the snapshotter
+	CacheIssueMissingSnapshot CacheIssueType = "missing-snapshot"
+	// CacheIssueMissingBlob is a cache record whose blob was removed from the
+	// content store
+	CacheIssueMissingBlob CacheIssueType = "missing-blob"
+	// CacheIssueOrphanedLease is a lease of a cache record that doesn't exist
+	CacheIssueOrphanedLease CacheIssueType = "orphaned-lease"
+)
+
+// CacheIssue is an inconsistency between the cache metadata and the content
+// store or the snapshotter
+type CacheIssue struct {
+	ID          string         `json:"id"`
+	Type        CacheIssueType `json:"type"`
+	Description string         `json:"description"`
+	Repaired    bool           `json:"repaired"`
+}
+
+// VerifyCache checks the cache records of all workers against their content
+// stores and snapshotters. If repair is true the inconsistent records that are
+// not in use are removed.
+func (c *Client) VerifyCache(ctx context.Context, repair bool) ([]*CacheIssue, error) {
+	resp, err := c.ControlClient().VerifyCache(ctx, &controlapi.VerifyCacheRequest{Repair: repair})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to verify cache")
+	}
+
+	var issues []*CacheIssue
+	for _, i := range resp.Issues {
+		issues = append(issues, &CacheIssue{
+			ID:          i.ID,
+			Type:        CacheIssueType(i.Type),
+			Description: i.Description,
+			Repaired:    i.Repaired,
+		})
+	}
+	return issues, nil
+}
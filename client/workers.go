@@ -19,6 +19,47 @@ type WorkerInfo struct {
 	GCPolicy        []PruneInfo         `json:"gcPolicy"`
 	BuildkitVersion BuildkitVersion     `json:"buildkitVersion"`
 	CDIDevices      []CDIDevice         `json:"cdiDevices"`
+	ActiveBuilds    int                 `json:"activeBuilds"`
+	Status          *WorkerStatus       `json:"status,omitempty"`
+	Emulators       []Emulator          `json:"emulators,omitempty"`
+}
+
+// Emulator is an emulator of a platform the processes of a worker can run
+// on in addition to its native platform
+type Emulator struct {
+	Platform ocispecs.Platform `json:"platform"`
+	// Path is the QEMU user emulator binary, empty for the platforms run by
+	// the binfmt_misc handlers registered in the kernel
+	Path string `json:"path,omitempty"`
+}
+
+// WorkerStatus contains the current resource usage of a worker
+type WorkerStatus struct {
+	DiskTotal      int64         `json:"diskTotal"`
+	DiskFree       int64         `json:"diskFree"`
+	DiskAvailable  int64         `json:"diskAvailable"`
+	RunningExecs   int           `json:"runningExecs"`
+	CPUPressure    *PressureInfo `json:"cpuPressure,omitempty"`
+	MemoryPressure *PressureInfo `json:"memoryPressure,omitempty"`
+	IOPressure     *PressureInfo `json:"ioPressure,omitempty"`
+	GC             GCStatus      `json:"gc"`
+}
+
+// PressureInfo contains the share of time some tasks were stalled on a
+// resource, averaged over 10, 60 and 300 seconds
+type PressureInfo struct {
+	Avg10  float64 `json:"avg10"`
+	Avg60  float64 `json:"avg60"`
+	Avg300 float64 `json:"avg300"`
+}
+
+// GCStatus contains the state of the automatic garbage collection of a worker
+type GCStatus struct {
+	Running       bool       `json:"running"`
+	LastStarted   *time.Time `json:"lastStarted,omitempty"`
+	LastCompleted *time.Time `json:"lastCompleted,omitempty"`
+	LastFreed     int64      `json:"lastFreed"`
+	LastError     string     `json:"lastError,omitempty"`
 }
 
 // ListWorkers lists all active workers
@@ -44,6 +85,9 @@ func (c *Client) ListWorkers(ctx context.Context, opts ...ListWorkersOption) ([]
 			GCPolicy:        fromAPIGCPolicy(w.GCPolicy),
 			BuildkitVersion: fromAPIBuildkitVersion(w.BuildkitVersion),
 			CDIDevices:      fromAPICDIDevices(w.CDIDevices),
+			ActiveBuilds:    int(w.ActiveBuilds),
+			Status:          fromAPIWorkerStatus(w.Status),
+			Emulators:       fromAPIEmulators(w.Emulators),
 		})
 	}
 
@@ -74,3 +118,56 @@ func fromAPIGCPolicy(in []*apitypes.GCPolicy) []PruneInfo {
 	}
 	return out
 }
+
+func fromAPIEmulators(in []*apitypes.Emulator) []Emulator {
+	var out []Emulator
+	for _, e := range in {
+		out = append(out, Emulator{
+			Platform: e.Platform.Spec(),
+			Path:     e.Path,
+		})
+	}
+	return out
+}
+
+func fromAPIWorkerStatus(in *apitypes.WorkerStatus) *WorkerStatus {
+	if in == nil {
+		return nil
+	}
+	out := &WorkerStatus{
+		DiskTotal:      in.DiskTotal,
+		DiskFree:       in.DiskFree,
+		DiskAvailable:  in.DiskAvailable,
+		RunningExecs:   int(in.RunningExecs),
+		CPUPressure:    fromAPIPressure(in.CpuPressure),
+		MemoryPressure: fromAPIPressure(in.MemoryPressure),
+		IOPressure:     fromAPIPressure(in.IoPressure),
+	}
+	if gc := in.Gc; gc != nil {
+		out.GC = GCStatus{
+			Running:   gc.Running,
+			LastFreed: gc.LastFreed,
+			LastError: gc.LastError,
+		}
+		if gc.LastStarted != nil {
+			t := gc.LastStarted.AsTime()
+			out.GC.LastStarted = &t
+		}
+		if gc.LastCompleted != nil {
+			t := gc.LastCompleted.AsTime()
+			out.GC.LastCompleted = &t
+		}
+	}
+	return out
+}
+
+func fromAPIPressure(in *apitypes.Pressure) *PressureInfo {
+	if in == nil {
+		return nil
+	}
+	return &PressureInfo{
+		Avg10:  in.Avg10,
+		Avg60:  in.Avg60,
+		Avg300: in.Avg300,
+	}
+}
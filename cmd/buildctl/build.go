@@ -13,6 +13,7 @@ import (
 	"github.com/containerd/continuity"
 	"github.com/docker/cli/cli/config"
 	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/client/determinism"
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/cmd/buildctl/build"
 	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
@@ -25,6 +26,7 @@ import (
 	"github.com/moby/buildkit/solver/pb"
 	spb "github.com/moby/buildkit/sourcepolicy/pb"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/progress/progressui"
 	"github.com/moby/buildkit/util/progress/progresswriter"
 	digest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
@@ -48,9 +50,13 @@ var buildCommand = cli.Command{
 		},
 		cli.StringFlag{
 			Name:  "progress",
-			Usage: "Set type of progress (auto, plain, tty, rawjson). Use plain to show container output",
+			Usage: "Set type of progress (auto, plain, tty, rawjson, ci). Use plain to show container output",
 			Value: "auto",
 		},
+		cli.StringFlag{
+			Name:  "progress-history",
+			Usage: "Build ref of a previous build to estimate remaining time of the build and its steps from",
+		},
 		cli.StringFlag{
 			Name:  "trace",
 			Usage: "Path to trace file. Defaults to no tracing.",
@@ -87,9 +93,13 @@ var buildCommand = cli.Command{
 			Name:  "secret",
 			Usage: "Secret value exposed to the build. Format id=secretname,src=filepath",
 		},
+		cli.StringSliceFlag{
+			Name:  "local-exec",
+			Usage: "Command the build can run on the client with the local.exec entitlement. Format id=name,cmd=path[,arg=value...][,dir=path]",
+		},
 		cli.StringSliceFlag{
 			Name:  "allow",
-			Usage: "Allow extra privileged entitlement, e.g. network.host, security.insecure, device",
+			Usage: "Allow extra privileged entitlement, e.g. network.host, security.insecure, device, local.exec, mount.host",
 		},
 		cli.StringSliceFlag{
 			Name:  "ssh",
@@ -103,6 +113,38 @@ var buildCommand = cli.Command{
 			Name:  "source-policy-file",
 			Usage: "Read source policy file from a JSON file",
 		},
+		cli.StringFlag{
+			Name:  "lockfile",
+			Usage: "Pin the image, Git and HTTP sources to the lockfile and update it with the resolved sources",
+		},
+		cli.BoolFlag{
+			Name:  "locked",
+			Usage: "Deny the sources missing from the lockfile and don't update it",
+		},
+		cli.DurationFlag{
+			Name:  "result-lease-ttl",
+			Usage: "Keep the build result from being pruned for the duration, e.g. --result-lease-ttl 1h. The lease ID is written to the metadata file",
+		},
+		cli.StringFlag{
+			Name:  "check-determinism",
+			Usage: "Build the vertices again without cache and report the ones producing different files, e.g. --check-determinism all or --check-determinism '^RUN '",
+		},
+		cli.BoolFlag{
+			Name:  "validate",
+			Usage: "Check the build request without building it and print the problems found",
+		},
+		cli.StringFlag{
+			Name:  "concurrency-limit",
+			Usage: "Limit the number of operations of each type running at the same time, e.g. --concurrency-limit exec=2,image-pull=1,local-sync=1",
+		},
+		cli.StringFlag{
+			Name:  "proxy",
+			Usage: "Fetch the sources through HTTP proxies instead of the proxies of the daemon, e.g. --proxy http=http://proxy:3128,https=http://proxy:3128,no=localhost,exec. exec also sets the proxies in the environment of the exec ops",
+		},
+		cli.StringSliceFlag{
+			Name:  "worker-constraint",
+			Usage: "Run the build on a worker matching the constraint, e.g. --worker-constraint worker.label.gpu=true",
+		},
 		cli.StringFlag{
 			Name:  "ref-file",
 			Usage: "Write build ref to a file",
@@ -213,6 +255,14 @@ func buildAction(clicontext *cli.Context) error {
 		attachable = append(attachable, secretProvider)
 	}
 
+	if cmds := clicontext.StringSlice("local-exec"); len(cmds) > 0 {
+		p, err := build.ParseLocalExec(cmds)
+		if err != nil {
+			return err
+		}
+		attachable = append(attachable, p)
+	}
+
 	if err := build.ValidateAllow(clicontext.StringSlice("allow")); err != nil {
 		return err
 	}
@@ -244,6 +294,27 @@ func buildAction(clicontext *cli.Context) error {
 		srcPol = &srcPolStruct
 	}
 
+	lockfilePath := clicontext.String("lockfile")
+	locked := clicontext.Bool("locked")
+	if locked && lockfilePath == "" {
+		return errors.New("--locked requires --lockfile")
+	}
+	if lockfilePath != "" {
+		srcPol, err = build.LockfilePolicy(lockfilePath, locked, srcPol)
+		if err != nil {
+			return err
+		}
+	}
+
+	concurrencyLimits, err := build.ParseConcurrencyLimits(clicontext.String("concurrency-limit"))
+	if err != nil {
+		return err
+	}
+	proxyCfg, err := build.ParseProxy(clicontext.String("proxy"))
+	if err != nil {
+		return err
+	}
+
 	eg, ctx := errgroup.WithContext(bccommon.CommandContext(clicontext))
 
 	ref := identity.NewID()
@@ -259,6 +330,11 @@ func buildAction(clicontext *cli.Context) error {
 		Session:             attachable,
 		AllowedEntitlements: clicontext.StringSlice("allow"),
 		SourcePolicy:        srcPol,
+		ConcurrencyLimits:   concurrencyLimits,
+		Proxy:               proxyCfg,
+		WorkerConstraints:   clicontext.StringSlice("worker-constraint"),
+		Lockfile:            lockfilePath != "" && !locked,
+		ResultLeaseTTL:      clicontext.Duration("result-lease-ttl"),
 		Ref:                 ref,
 	}
 
@@ -293,6 +369,18 @@ func buildAction(clicontext *cli.Context) error {
 		solveOpt.FrontendAttrs["no-cache"] = ""
 	}
 
+	if clicontext.Bool("validate") {
+		solveOpt.Validate = true
+		resp, err := c.Solve(ctx, def, solveOpt, nil)
+		if err != nil {
+			return err
+		}
+		if n := build.PrintDiagnostics(os.Stdout, resp.Diagnostics); n > 0 {
+			return errors.Errorf("%d problems found in the build request", n)
+		}
+		return nil
+	}
+
 	refFile := clicontext.String("ref-file")
 	if refFile != "" {
 		defer func() {
@@ -300,8 +388,17 @@ func buildAction(clicontext *cli.Context) error {
 		}()
 	}
 
+	var progressOpts []progressui.DisplayOpt
+	if historyRef := clicontext.String("progress-history"); historyRef != "" {
+		h, err := progressui.LoadHistory(ctx, c, historyRef)
+		if err != nil {
+			return err
+		}
+		progressOpts = append(progressOpts, progressui.WithHistory(h))
+	}
+
 	// not using shared context to not disrupt display but let is finish reporting errors
-	pw, err := progresswriter.NewPrinter(context.TODO(), os.Stderr, clicontext.String("progress"))
+	pw, err := progresswriter.NewPrinter(context.TODO(), os.Stderr, clicontext.String("progress"), progressOpts...)
 	if err != nil {
 		return err
 	}
@@ -352,6 +449,16 @@ func buildAction(clicontext *cli.Context) error {
 
 	var subMetadata map[string][]byte
 
+	var determinismOpt *determinism.Opt
+	var determinismResults []determinism.Result
+	if v := clicontext.String("check-determinism"); v != "" {
+		opt, err := build.DeterminismOpt(v)
+		if err != nil {
+			return err
+		}
+		determinismOpt = &opt
+	}
+
 	eg.Go(func() error {
 		defer func() {
 			for _, w := range writers {
@@ -389,6 +496,12 @@ func buildAction(clicontext *cli.Context) error {
 			if isSubRequest && res != nil {
 				subMetadata = res.Metadata
 			}
+			if determinismOpt != nil && !isSubRequest {
+				determinismResults, err = build.CheckDeterminism(ctx, c, sreq.Definition, res, *determinismOpt)
+				if err != nil {
+					return nil, errors.Wrap(err, "failed to check determinism")
+				}
+			}
 			return res, err
 		}, progresswriter.ResetTime(mw.WithPrefix("", false)).Status())
 		if err != nil {
@@ -398,6 +511,12 @@ func buildAction(clicontext *cli.Context) error {
 			bklog.G(ctx).Debugf("exporter response: %s=%s", k, v)
 		}
 
+		if solveOpt.Lockfile {
+			if err := build.WriteLockfile(lockfilePath, resp.ExporterResponse); err != nil {
+				return err
+			}
+		}
+
 		metadataFile := clicontext.String("metadata-file")
 		if metadataFile != "" && resp.ExporterResponse != nil {
 			if err := writeMetadataFile(metadataFile, resp.ExporterResponse); err != nil {
@@ -429,6 +548,12 @@ func buildAction(clicontext *cli.Context) error {
 
 	meg.Wait()
 
+	if determinismOpt != nil {
+		if n := build.PrintDeterminismReport(os.Stderr, determinismResults); n > 0 {
+			return errors.Errorf("%d vertex outputs are not deterministic", n)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,44 @@
+package build
+
+import (
+	"strconv"
+	"strings"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+	"github.com/tonistiigi/go-csvvalue"
+)
+
+// ParseConcurrencyLimits parses --concurrency-limit, e.g.
+// exec=2,image-pull=1,local-sync=1
+func ParseConcurrencyLimits(s string) (*controlapi.ConcurrencyLimits, error) {
+	if s == "" {
+		return nil, nil
+	}
+	fields, err := csvvalue.Fields(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	limits := &controlapi.ConcurrencyLimits{}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid concurrency limit %s", field)
+		}
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || n < 0 {
+			return nil, errors.Errorf("invalid concurrency limit %s", field)
+		}
+		switch strings.ToLower(key) {
+		case "exec":
+			limits.Exec = int32(n)
+		case "image-pull":
+			limits.ImagePull = int32(n)
+		case "local-sync":
+			limits.LocalSync = int32(n)
+		default:
+			return nil, errors.Errorf("unknown concurrency limit %s", key)
+		}
+	}
+	return limits, nil
+}
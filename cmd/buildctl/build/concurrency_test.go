@@ -0,0 +1,30 @@
+package build
+
+import (
+	"testing"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConcurrencyLimits(t *testing.T) {
+	limits, err := ParseConcurrencyLimits("")
+	require.NoError(t, err)
+	require.Nil(t, limits)
+
+	limits, err = ParseConcurrencyLimits("exec=4,image-pull=1,local-sync=2")
+	require.NoError(t, err)
+	require.Equal(t, int32(4), limits.Exec)
+	require.Equal(t, int32(1), limits.ImagePull)
+	require.Equal(t, int32(2), limits.LocalSync)
+
+	limits, err = ParseConcurrencyLimits("image-pull=2")
+	require.NoError(t, err)
+	require.True(t, limits.EqualVT(&controlapi.ConcurrencyLimits{ImagePull: 2}))
+
+	_, err = ParseConcurrencyLimits("exec=-1")
+	require.ErrorContains(t, err, "invalid concurrency limit")
+
+	_, err = ParseConcurrencyLimits("git=1")
+	require.ErrorContains(t, err, "unknown concurrency limit")
+}
@@ -0,0 +1,90 @@
+package build
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+
+	"github.com/moby/buildkit/client/determinism"
+	gateway "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/pkg/errors"
+)
+
+// DeterminismOpt returns the options of the determinism check for the value of
+// --check-determinism. "all" checks all the vertices, any other value is a
+// regular expression matching the names of the vertices to check.
+func DeterminismOpt(v string) (determinism.Opt, error) {
+	if v == "all" {
+		return determinism.Opt{}, nil
+	}
+	re, err := regexp.Compile(v)
+	if err != nil {
+		return determinism.Opt{}, errors.Wrapf(err, "invalid --check-determinism pattern %q", v)
+	}
+	return determinism.Opt{Filter: re.MatchString}, nil
+}
+
+// CheckDeterminism runs the determinism check for the definition of the build
+// or, for frontend builds, for the definitions of the refs of the result
+func CheckDeterminism(ctx context.Context, c gateway.Client, def *pb.Definition, res *gateway.Result, opt determinism.Opt) ([]determinism.Result, error) {
+	if def != nil {
+		return determinism.Check(ctx, c, def, opt)
+	}
+	var refs []gateway.Reference
+	if res.Ref != nil {
+		refs = append(refs, res.Ref)
+	}
+	for _, k := range slices.Sorted(maps.Keys(res.Refs)) {
+		if r := res.Refs[k]; r != nil {
+			refs = append(refs, r)
+		}
+	}
+	var results []determinism.Result
+	for _, ref := range refs {
+		st, err := ref.ToState()
+		if err != nil {
+			return nil, err
+		}
+		rdef, err := st.Marshal(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rres, err := determinism.Check(ctx, c, rdef.ToPB(), opt)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, rres...)
+	}
+	return results, nil
+}
+
+// PrintDeterminismReport writes the non-deterministic vertices and their file
+// diffs to w. It returns the number of non-deterministic vertex outputs.
+func PrintDeterminismReport(w io.Writer, results []determinism.Result) int {
+	var n int
+	for _, r := range results {
+		if r.Deterministic() {
+			continue
+		}
+		n++
+		fmt.Fprintf(w, "non-deterministic: %s (output %d)\n", r.Name, r.Output)
+		fmt.Fprintf(w, "  vertex: %s\n", r.Vertex)
+		fmt.Fprintf(w, "  digests: %s %s\n", r.Digests[0], r.Digests[1])
+		for _, d := range r.Diffs {
+			if len(d.Fields) > 0 {
+				fmt.Fprintf(w, "  %s %s %v\n", d.Type, d.Path, d.Fields)
+			} else {
+				fmt.Fprintf(w, "  %s %s\n", d.Type, d.Path)
+			}
+		}
+		if r.Truncated {
+			fmt.Fprintf(w, "  ...\n")
+		}
+	}
+	fmt.Fprintf(w, "checked %d vertex outputs, %d non-deterministic\n", len(results), n)
+	return n
+}
@@ -0,0 +1,49 @@
+package build
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/moby/buildkit/client/determinism"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterminismOpt(t *testing.T) {
+	opt, err := DeterminismOpt("all")
+	require.NoError(t, err)
+	require.Nil(t, opt.Filter)
+
+	opt, err = DeterminismOpt("^RUN ")
+	require.NoError(t, err)
+	require.True(t, opt.Filter("RUN make"))
+	require.False(t, opt.Filter("COPY . ."))
+
+	_, err = DeterminismOpt("[")
+	require.ErrorContains(t, err, "invalid --check-determinism pattern")
+}
+
+func TestPrintDeterminismReport(t *testing.T) {
+	dgst := digest.FromString("foo")
+	results := []determinism.Result{
+		{Name: "COPY . .", Digests: [2]digest.Digest{dgst, dgst}},
+		{
+			Vertex:  digest.FromString("run"),
+			Name:    "RUN make",
+			Digests: [2]digest.Digest{dgst, digest.FromString("bar")},
+			Diffs: []determinism.Diff{
+				{Path: "/out/app", Type: determinism.DiffModified, Fields: []string{"mtime", "content"}},
+				{Path: "/out/build.log", Type: determinism.DiffAdded},
+			},
+			Truncated: true,
+		},
+	}
+	var buf bytes.Buffer
+	require.Equal(t, 1, PrintDeterminismReport(&buf, results))
+	out := buf.String()
+	require.Contains(t, out, "non-deterministic: RUN make (output 0)\n")
+	require.NotContains(t, out, "COPY")
+	require.Contains(t, out, "  modified /out/app [mtime content]\n")
+	require.Contains(t, out, "  added /out/build.log\n")
+	require.Contains(t, out, "checked 2 vertex outputs, 1 non-deterministic\n")
+}
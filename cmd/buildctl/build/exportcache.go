@@ -1,8 +1,11 @@
 package build
 
 import (
+	"io"
+	"os"
 	"strings"
 
+	"github.com/containerd/console"
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/pkg/errors"
@@ -40,6 +43,15 @@ func parseExportCacheCSV(s string) (client.CacheOptionsEntry, error) {
 	if ex.Type == "gha" {
 		return loadGithubEnv(ex)
 	}
+	if ex.Type == "tarball" && ex.Attrs["dest"] == "-" {
+		if _, err := console.ConsoleFromFile(os.Stdout); err == nil {
+			return ex, errors.New("output file is required for tarball cache exporter. refusing to write to console")
+		}
+		delete(ex.Attrs, "dest")
+		ex.Output = func() (io.WriteCloser, error) {
+			return os.Stdout, nil
+		}
+	}
 	return ex, nil
 }
 
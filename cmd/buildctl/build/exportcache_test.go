@@ -1,8 +1,10 @@
 package build
 
 import (
+	"os"
 	"testing"
 
+	"github.com/containerd/console"
 	"github.com/moby/buildkit/client"
 	"github.com/stretchr/testify/require"
 )
@@ -51,3 +53,21 @@ func TestParseExportCache(t *testing.T) {
 		}
 	}
 }
+
+func TestParseExportCacheTarballStdout(t *testing.T) {
+	ex, err := ParseExportCache([]string{"type=tarball,dest=-"})
+	if _, cerr := console.ConsoleFromFile(os.Stdout); cerr == nil {
+		require.ErrorContains(t, err, "refusing to write to console")
+		return
+	}
+	require.NoError(t, err)
+	require.Len(t, ex, 1)
+	require.Equal(t, "tarball", ex[0].Type)
+	require.Equal(t, map[string]string{"mode": "min"}, ex[0].Attrs)
+	require.NotNil(t, ex[0].Output)
+
+	ex, err = ParseExportCache([]string{"type=tarball,dest=cache.tar"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"dest": "cache.tar", "mode": "min"}, ex[0].Attrs)
+	require.Nil(t, ex[0].Output)
+}
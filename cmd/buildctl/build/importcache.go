@@ -1,6 +1,8 @@
 package build
 
 import (
+	"io"
+	"os"
 	"strings"
 
 	"github.com/moby/buildkit/client"
@@ -37,6 +39,12 @@ func parseImportCacheCSV(s string) (client.CacheOptionsEntry, error) {
 	if im.Type == "gha" {
 		return loadGithubEnv(im)
 	}
+	if im.Type == "tarball" && im.Attrs["src"] == "-" {
+		delete(im.Attrs, "src")
+		im.Input = func() (io.ReadCloser, error) {
+			return io.NopCloser(os.Stdin), nil
+		}
+	}
 	return im, nil
 }
 
@@ -105,3 +105,17 @@ func TestParseImportCache(t *testing.T) {
 		}
 	}
 }
+
+func TestParseImportCacheTarballStdin(t *testing.T) {
+	im, err := ParseImportCache([]string{"type=tarball,src=-"})
+	require.NoError(t, err)
+	require.Len(t, im, 1)
+	require.Equal(t, "tarball", im[0].Type)
+	require.Empty(t, im[0].Attrs)
+	require.NotNil(t, im[0].Input)
+
+	im, err = ParseImportCache([]string{"type=tarball,src=cache.tar"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"src": "cache.tar"}, im[0].Attrs)
+	require.Nil(t, im[0].Input)
+}
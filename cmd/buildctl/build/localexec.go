@@ -0,0 +1,58 @@
+package build
+
+import (
+	"strings"
+
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/localexec/localexecprovider"
+	"github.com/pkg/errors"
+	"github.com/tonistiigi/go-csvvalue"
+)
+
+// ParseLocalExec parses --local-exec
+func ParseLocalExec(sl []string) (session.Attachable, error) {
+	cmds := make([]localexecprovider.Command, 0, len(sl))
+	for _, v := range sl {
+		c, err := parseLocalExec(v)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, *c)
+	}
+	return localexecprovider.NewProvider(cmds)
+}
+
+func parseLocalExec(val string) (*localexecprovider.Command, error) {
+	fields, err := csvvalue.Fields(val, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse csv local exec")
+	}
+
+	var c localexecprovider.Command
+	var cmd string
+	var args []string
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid field '%s' must be a key=value pair", field)
+		}
+		key = strings.ToLower(key)
+		switch key {
+		case "id":
+			c.ID = value
+		case "cmd":
+			cmd = value
+		case "arg":
+			args = append(args, value)
+		case "dir":
+			c.Dir = value
+		default:
+			return nil, errors.Errorf("unexpected key '%s' in '%s'", key, field)
+		}
+	}
+	if cmd == "" {
+		return nil, errors.Errorf("local exec %q requires cmd", val)
+	}
+	c.Args = append([]string{cmd}, args...)
+	return &c, nil
+}
@@ -0,0 +1,27 @@
+package build
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/session/localexec/localexecprovider"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocalExec(t *testing.T) {
+	c, err := parseLocalExec("id=sign,cmd=/usr/local/bin/sign,arg=--slot,arg=1,dir=/tmp")
+	require.NoError(t, err)
+	require.Equal(t, &localexecprovider.Command{
+		ID:   "sign",
+		Args: []string{"/usr/local/bin/sign", "--slot", "1"},
+		Dir:  "/tmp",
+	}, c)
+
+	_, err = parseLocalExec("id=sign")
+	require.ErrorContains(t, err, "requires cmd")
+
+	_, err = parseLocalExec("id=sign,cmd=sign,env=FOO")
+	require.ErrorContains(t, err, "unexpected key 'env'")
+
+	_, err = ParseLocalExec([]string{"cmd=sign"})
+	require.ErrorContains(t, err, "missing ID")
+}
@@ -0,0 +1,54 @@
+package build
+
+import (
+	"os"
+
+	"github.com/containerd/continuity"
+	"github.com/moby/buildkit/client/lockfile"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	"github.com/pkg/errors"
+)
+
+// LockfilePolicy adds the rules pinning the sources of the build to the
+// lockfile at path to pol. A missing lockfile is only an error if locked is
+// set.
+func LockfilePolicy(path string, locked bool, pol *spb.Policy) (*spb.Policy, error) {
+	dt, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && !locked {
+			return pol, nil
+		}
+		return nil, errors.Wrap(err, "failed to read lockfile")
+	}
+	l, err := lockfile.Parse(dt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid lockfile %s", path)
+	}
+	lpol, err := l.SourcePolicy(locked)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid lockfile %s", path)
+	}
+	if pol != nil {
+		// the rules of the source policy file are evaluated last so that
+		// they can still deny pinned sources
+		lpol.Version = pol.Version
+		lpol.Rules = append(lpol.Rules, pol.Rules...)
+	}
+	return lpol, nil
+}
+
+// WriteLockfile writes the lockfile returned by the build to path
+func WriteLockfile(path string, exporterResponse map[string]string) error {
+	l, err := lockfile.FromExporterResponse(exporterResponse)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return errors.New("build did not return a lockfile")
+	}
+	dt, err := l.Marshal()
+	if err != nil {
+		return err
+	}
+	return continuity.AtomicWriteFile(path, dt, 0644)
+}
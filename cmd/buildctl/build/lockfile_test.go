@@ -0,0 +1,57 @@
+package build
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/moby/buildkit/client/lockfile"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockfilePolicy(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "buildkit.lock")
+	userPol := &spb.Policy{
+		Version: 1,
+		Rules: []*spb.Rule{{
+			Action:   spb.PolicyAction_DENY,
+			Selector: &spb.Selector{Identifier: "docker-image://docker.io/library/busybox:*"},
+		}},
+	}
+
+	// a missing lockfile is created by the build
+	pol, err := LockfilePolicy(p, false, userPol)
+	require.NoError(t, err)
+	require.Equal(t, userPol, pol)
+
+	_, err = LockfilePolicy(p, true, nil)
+	require.ErrorContains(t, err, "failed to read lockfile")
+
+	l := &lockfile.Lockfile{
+		Version: lockfile.Version,
+		Images:  []lockfile.Image{{Ref: "docker.io/library/busybox:latest", Digest: digest.FromString("busybox")}},
+	}
+	dt, err := l.Marshal()
+	require.NoError(t, err)
+	resp := map[string]string{lockfile.ExporterResponseKey: base64.StdEncoding.EncodeToString(dt)}
+	require.NoError(t, WriteLockfile(p, resp))
+	dt2, err := os.ReadFile(p)
+	require.NoError(t, err)
+	require.Equal(t, dt, dt2)
+
+	pol, err = LockfilePolicy(p, true, userPol)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pol.Version)
+	// the rules of the source policy file are last
+	require.Equal(t, userPol.Rules[0], pol.Rules[len(pol.Rules)-1])
+	require.Equal(t, spb.PolicyAction_DENY, pol.Rules[0].Action)
+
+	require.NoError(t, os.WriteFile(p, []byte("{}"), 0644))
+	_, err = LockfilePolicy(p, false, nil)
+	require.ErrorContains(t, err, "unsupported lockfile version")
+
+	require.ErrorContains(t, WriteLockfile(p, map[string]string{}), "did not return a lockfile")
+}
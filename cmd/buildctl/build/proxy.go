@@ -0,0 +1,48 @@
+package build
+
+import (
+	"strings"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+	"github.com/tonistiigi/go-csvvalue"
+)
+
+// ParseProxy parses --proxy, e.g.
+// http=http://proxy:3128,https=http://proxy:3128,no=localhost,no=.corp,exec
+func ParseProxy(s string) (*controlapi.ProxyConfig, error) {
+	if s == "" {
+		return nil, nil
+	}
+	fields, err := csvvalue.Fields(s, nil)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &controlapi.ProxyConfig{}
+	var noProxy []string
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		key = strings.ToLower(key)
+		if !ok {
+			if key != "exec" {
+				return nil, errors.Errorf("invalid proxy %s", field)
+			}
+			cfg.Exec = true
+			continue
+		}
+		switch key {
+		case "http":
+			cfg.HTTPProxy = value
+		case "https":
+			cfg.HTTPSProxy = value
+		case "all":
+			cfg.AllProxy = value
+		case "no":
+			noProxy = append(noProxy, value)
+		default:
+			return nil, errors.Errorf("unknown proxy %s", key)
+		}
+	}
+	cfg.NoProxy = strings.Join(noProxy, ",")
+	return cfg, nil
+}
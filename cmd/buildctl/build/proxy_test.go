@@ -0,0 +1,29 @@
+package build
+
+import (
+	"testing"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProxy(t *testing.T) {
+	cfg, err := ParseProxy("")
+	require.NoError(t, err)
+	require.Nil(t, cfg)
+
+	cfg, err = ParseProxy(`http=http://proxy:3128,HTTPS=http://proxy:3129,no=localhost,"no=10.0.0.0/8,.corp",exec`)
+	require.NoError(t, err)
+	require.Equal(t, &controlapi.ProxyConfig{
+		HTTPProxy:  "http://proxy:3128",
+		HTTPSProxy: "http://proxy:3129",
+		NoProxy:    "localhost,10.0.0.0/8,.corp",
+		Exec:       true,
+	}, cfg)
+
+	_, err = ParseProxy("ftp=http://proxy:3128")
+	require.ErrorContains(t, err, "unknown proxy ftp")
+
+	_, err = ParseProxy("http")
+	require.ErrorContains(t, err, "invalid proxy http")
+}
@@ -0,0 +1,28 @@
+package build
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/moby/buildkit/client"
+)
+
+// PrintDiagnostics writes the diagnostics of a validated build request to w.
+// It returns the number of diagnostics that are not warnings.
+func PrintDiagnostics(w io.Writer, diags []client.SolveDiagnostic) int {
+	var n int
+	for _, d := range diags {
+		level := "error"
+		if d.Warning {
+			level = "warning"
+		} else {
+			n++
+		}
+		fmt.Fprintf(w, "%s: %s: %s\n", level, d.Check, d.Message)
+		if d.Vertex != "" {
+			fmt.Fprintf(w, "  vertex: %s\n", d.Vertex)
+		}
+	}
+	fmt.Fprintf(w, "%d errors, %d warnings\n", n, len(diags)-n)
+	return n
+}
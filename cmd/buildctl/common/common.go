@@ -70,6 +70,15 @@ func ResolveClient(c *cli.Context) (*client.Client, error) {
 	if cert != "" || key != "" {
 		opts = append(opts, client.WithCredentials(cert, key))
 	}
+	if tokenFile := c.GlobalString("token-file"); tokenFile != "" {
+		opts = append(opts, client.WithBearerToken(func(context.Context) (string, error) {
+			dt, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(string(dt)), nil
+		}))
+	}
 
 	timeout := time.Duration(c.GlobalInt("timeout")) * time.Second
 	if timeout > 0 {
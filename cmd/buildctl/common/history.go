@@ -0,0 +1,47 @@
+package common
+
+import (
+	"context"
+	"io"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/content/proxy"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// GetBuildRecord returns the build history record of ref
+func GetBuildRecord(ctx context.Context, c *client.Client, ref string) (*controlapi.BuildHistoryRecord, error) {
+	cl, err := c.ControlClient().ListenBuildHistory(ctx, &controlapi.BuildHistoryRequest{
+		Ref:       ref,
+		EarlyExit: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	he, err := cl.Recv()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.Errorf("ref %s not found", ref)
+		}
+		return nil, err
+	}
+	return he.Record, nil
+}
+
+// OpenDescriptor opens a blob of a build history record
+func OpenDescriptor(ctx context.Context, c *client.Client, desc *controlapi.Descriptor) (content.ReaderAt, error) {
+	dgst, err := digest.Parse(desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	store := proxy.NewContentStore(c.ContentClient())
+	return store.ReaderAt(ctx, ocispecs.Descriptor{
+		Digest:    dgst,
+		Size:      desc.Size,
+		MediaType: desc.MediaType,
+	})
+}
@@ -10,8 +10,10 @@ var debugCommand = cli.Command{
 	Usage: "debug utilities",
 	Subcommands: []cli.Command{
 		debug.DumpLLBCommand,
+		debug.ConvertLLBCommand,
 		debug.DumpMetadataCommand,
 		debug.WorkersCommand,
+		debug.SessionsCommand,
 		debug.InfoCommand,
 		debug.MonitorCommand,
 		debug.LogsCommand,
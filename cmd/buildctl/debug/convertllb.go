@@ -0,0 +1,78 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var ConvertLLBCommand = cli.Command{
+	Name:      "convert-llb",
+	Usage:     "convert LLB between the protobuf and the JSON formats. LLB can be also passed via stdin. This command does not require the daemon to be running.",
+	ArgsUsage: "<llbfile>",
+	Action:    convertLLB,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "Output format: json or pb. Defaults to the format the input is not in",
+		},
+	},
+}
+
+func convertLLB(clicontext *cli.Context) error {
+	var r io.Reader
+	if llbFile := clicontext.Args().First(); llbFile != "" && llbFile != "-" {
+		f, err := os.Open(llbFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		r = os.Stdin
+	}
+
+	dt, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	// a protobuf definition is never valid JSON
+	isJSON := json.Valid(dt)
+	def := &llb.Definition{}
+	if isJSON {
+		if err := json.Unmarshal(dt, def); err != nil {
+			return errors.Wrap(err, "failed to parse llb json")
+		}
+	} else {
+		def, err = llb.ReadFrom(bytes.NewReader(dt))
+		if err != nil {
+			return errors.Wrap(err, "failed to parse llb")
+		}
+	}
+
+	to := clicontext.String("to")
+	if to == "" {
+		to = "json"
+		if isJSON {
+			to = "pb"
+		}
+	}
+	switch to {
+	case "json":
+		dt, err := json.MarshalIndent(def, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(append(dt, '\n'))
+		return err
+	case "pb":
+		return llb.WriteTo(def, os.Stdout)
+	default:
+		return errors.Errorf("invalid output format %q, json or pb expected", to)
+	}
+}
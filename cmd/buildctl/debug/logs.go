@@ -1,18 +1,16 @@
 package debug
 
 import (
+	"fmt"
 	"io"
 	"os"
 
 	"github.com/containerd/containerd/v2/core/content"
-	"github.com/containerd/containerd/v2/core/content/proxy"
 	controlapi "github.com/moby/buildkit/api/services/control"
 	"github.com/moby/buildkit/client"
 	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
 	"github.com/moby/buildkit/util/appcontext"
 	"github.com/moby/buildkit/util/progress/progresswriter"
-	digest "github.com/opencontainers/go-digest"
-	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/urfave/cli"
 )
@@ -31,6 +29,14 @@ var LogsCommand = cli.Command{
 			Name:  "trace",
 			Usage: "show opentelemetry trace",
 		},
+		cli.BoolFlag{
+			Name:  "steps",
+			Usage: "show the logs of every step saved with the build record",
+		},
+		cli.StringFlag{
+			Name:  "step",
+			Usage: "only show the saved logs of the step with the specified vertex digest or name",
+		},
 	},
 }
 
@@ -48,33 +54,31 @@ func logs(clicontext *cli.Context) error {
 
 	ctx := appcontext.Context()
 
-	if clicontext.Bool("trace") {
-		cl, err := c.ControlClient().ListenBuildHistory(ctx, &controlapi.BuildHistoryRequest{
-			Ref: ref,
-		})
+	if clicontext.Bool("steps") || clicontext.String("step") != "" {
+		rec, err := bccommon.GetBuildRecord(ctx, c, ref)
 		if err != nil {
 			return err
 		}
-		he, err := cl.Recv()
+		if rec.StepLogs == nil {
+			return errors.Errorf("ref %s does not have step logs", ref)
+		}
+		ra, err := bccommon.OpenDescriptor(ctx, c, rec.StepLogs)
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				return errors.Errorf("ref %s not found", ref)
-			}
 			return err
 		}
-		if he.Record.Trace == nil {
-			return errors.Errorf("ref %s does not have trace", ref)
-		}
-		store := proxy.NewContentStore(c.ContentClient())
-		dgst, err := digest.Parse(he.Record.Trace.Digest)
+		defer ra.Close()
+		return printStepLogs(os.Stdout, content.NewReader(ra), clicontext.String("step"))
+	}
+
+	if clicontext.Bool("trace") {
+		rec, err := bccommon.GetBuildRecord(ctx, c, ref)
 		if err != nil {
 			return err
 		}
-		ra, err := store.ReaderAt(ctx, ocispecs.Descriptor{
-			Digest:    dgst,
-			Size:      he.Record.Trace.Size,
-			MediaType: he.Record.Trace.MediaType,
-		})
+		if rec.Trace == nil {
+			return errors.Errorf("ref %s does not have trace", ref)
+		}
+		ra, err := bccommon.OpenDescriptor(ctx, c, rec.Trace)
 		if err != nil {
 			return err
 		}
@@ -114,3 +118,32 @@ func logs(clicontext *cli.Context) error {
 		pw.Status() <- client.NewSolveStatus(resp)
 	}
 }
+
+// printStepLogs prints the logs of every step in a step logs archive. If
+// step is set only the raw logs of the matching step are printed.
+func printStepLogs(w io.Writer, r io.Reader, step string) error {
+	found := false
+	err := client.ReadStepLogs(r, func(st client.StepLog, rdr io.Reader) error {
+		if step != "" {
+			if step != st.Vertex.String() && step != st.Vertex.Encoded() && step != st.Name {
+				return nil
+			}
+			found = true
+			_, err := io.Copy(w, rdr)
+			return err
+		}
+		fmt.Fprintf(w, "==> %s (%s) <==\n", st.Name, st.Vertex)
+		if _, err := io.Copy(w, rdr); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if step != "" && !found {
+		return errors.Errorf("no logs found for step %s", step)
+	}
+	return nil
+}
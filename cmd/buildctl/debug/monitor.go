@@ -2,7 +2,11 @@ package debug
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"time"
 
+	"github.com/docker/go-units"
 	controlapi "github.com/moby/buildkit/api/services/control"
 	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
 	"github.com/moby/buildkit/util/appcontext"
@@ -54,6 +58,12 @@ func monitor(clicontext *cli.Context) error {
 		if ev.Record.NumWarnings != 0 {
 			fmt.Printf("  warnings: %d\n", ev.Record.NumWarnings)
 		}
+		if ru := ev.Record.ResourceUsage; ru != nil {
+			fmt.Printf("  resources: cpu=%s memory.peak=%s io.read=%s io.write=%s\n", time.Duration(ru.CpuNanos), units.BytesSize(float64(ru.MemoryPeakBytes)), units.BytesSize(float64(ru.IoReadBytes)), units.BytesSize(float64(ru.IoWriteBytes)))
+		}
+		for _, k := range slices.Sorted(maps.Keys(ev.Record.CacheSources)) {
+			fmt.Printf("  cache: %s=%d\n", k, ev.Record.CacheSources[k])
+		}
 		if ev.Record.Logs != nil {
 			fmt.Printf("  logs: %s\n", ev.Record.Logs)
 		}
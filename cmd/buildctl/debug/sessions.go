@@ -0,0 +1,104 @@
+package debug
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/tonistiigi/units"
+	"github.com/urfave/cli"
+)
+
+var SessionsCommand = cli.Command{
+	Name:   "sessions",
+	Usage:  "list active sessions",
+	Action: listSessions,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "verbose, v",
+			Usage: "Verbose output",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Format the output using the given Go template, e.g, '{{json .}}'",
+		},
+	},
+}
+
+func listSessions(clicontext *cli.Context) error {
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := c.ListSessions(commandContext(clicontext))
+	if err != nil {
+		return err
+	}
+	if format := clicontext.String("format"); format != "" {
+		if clicontext.Bool("verbose") {
+			bklog.L.Debug("Ignoring --verbose")
+		}
+		tmpl, err := bccommon.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(clicontext.App.Writer, sessions); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(clicontext.App.Writer, "\n")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
+
+	if clicontext.Bool("verbose") {
+		printSessionsVerbose(tw, sessions)
+	} else {
+		printSessionsTable(tw, sessions)
+	}
+	return nil
+}
+
+func printSessionsVerbose(tw *tabwriter.Writer, sinfo []*client.SessionInfo) {
+	for _, si := range sinfo {
+		fmt.Fprintf(tw, "ID:\t%s\n", si.ID)
+		fmt.Fprintf(tw, "Shared key:\t%s\n", si.SharedKey)
+		fmt.Fprintf(tw, "Created at:\t%s\n", si.CreatedAt.Format(time.RFC3339))
+		fmt.Fprintf(tw, "Sent:\t%.2f\n", units.Bytes(si.BytesSent))
+		fmt.Fprintf(tw, "Received:\t%.2f\n", units.Bytes(si.BytesReceived))
+		if len(si.Builds) > 0 {
+			fmt.Fprintf(tw, "Builds:\t%s\n", strings.Join(si.Builds, " "))
+		}
+		if len(si.Resources) > 0 {
+			fmt.Fprintf(tw, "Resources:\n")
+			for _, r := range si.Resources {
+				fmt.Fprintf(tw, "\t%s:\t%s\n", r.Type, r.ID)
+			}
+		}
+		if len(si.Methods) > 0 {
+			fmt.Fprintf(tw, "Methods:\n")
+			for _, m := range si.Methods {
+				fmt.Fprintf(tw, "\t%s\n", m)
+			}
+		}
+		fmt.Fprintf(tw, "\n")
+	}
+
+	tw.Flush()
+}
+
+func printSessionsTable(tw *tabwriter.Writer, sinfo []*client.SessionInfo) {
+	fmt.Fprintln(tw, "ID\tCREATED AT\tTRANSFERRED\tBUILDS")
+
+	for _, si := range sinfo {
+		fmt.Fprintf(tw, "%s\t%s\t%.2f\t%d\n", si.ID, si.CreatedAt.Format(time.RFC3339), units.Bytes(si.BytesSent+si.BytesReceived), len(si.Builds))
+	}
+
+	tw.Flush()
+}
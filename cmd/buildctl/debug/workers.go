@@ -8,6 +8,7 @@ import (
 	"slices"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/containerd/platforms"
 	"github.com/moby/buildkit/client"
@@ -77,7 +78,36 @@ func printWorkersVerbose(tw *tabwriter.Writer, winfo []*client.WorkerInfo) {
 	for _, wi := range winfo {
 		fmt.Fprintf(tw, "ID:\t%s\n", wi.ID)
 		fmt.Fprintf(tw, "Platforms:\t%s\n", joinPlatforms(wi.Platforms))
+		if len(wi.Emulators) > 0 {
+			fmt.Fprintf(tw, "Emulators:\n")
+			for _, e := range wi.Emulators {
+				method := "binfmt_misc"
+				if e.Path != "" {
+					method = e.Path
+				}
+				fmt.Fprintf(tw, "\t%s:\t%s\n", platforms.Format(e.Platform), method)
+			}
+		}
 		fmt.Fprintf(tw, "BuildKit:\t%s %s %s\n", wi.BuildkitVersion.Package, wi.BuildkitVersion.Version, wi.BuildkitVersion.Revision)
+		fmt.Fprintf(tw, "Active builds:\t%d\n", wi.ActiveBuilds)
+		if st := wi.Status; st != nil {
+			fmt.Fprintf(tw, "Running execs:\t%d\n", st.RunningExecs)
+			fmt.Fprintf(tw, "Disk:\n")
+			fmt.Fprintf(tw, "\tTotal:\t%.2f\n", units.Bytes(st.DiskTotal))
+			fmt.Fprintf(tw, "\tAvailable:\t%.2f\n", units.Bytes(st.DiskAvailable))
+			printPressure(tw, "CPU pressure", st.CPUPressure)
+			printPressure(tw, "Memory pressure", st.MemoryPressure)
+			printPressure(tw, "IO pressure", st.IOPressure)
+			fmt.Fprintf(tw, "GC:\n")
+			fmt.Fprintf(tw, "\tRunning:\t%v\n", st.GC.Running)
+			if st.GC.LastCompleted != nil {
+				fmt.Fprintf(tw, "\tLast completed:\t%s\n", st.GC.LastCompleted.Format(time.RFC3339))
+				fmt.Fprintf(tw, "\tLast freed:\t%.2f\n", units.Bytes(st.GC.LastFreed))
+			}
+			if st.GC.LastError != "" {
+				fmt.Fprintf(tw, "\tLast error:\t%s\n", st.GC.LastError)
+			}
+		}
 		fmt.Fprintf(tw, "Labels:\n")
 		for _, k := range sortedKeys(wi.Labels) {
 			v := wi.Labels[k]
@@ -125,12 +155,27 @@ func printWorkersVerbose(tw *tabwriter.Writer, winfo []*client.WorkerInfo) {
 	tw.Flush()
 }
 
+func printPressure(tw *tabwriter.Writer, name string, p *client.PressureInfo) {
+	if p == nil {
+		return
+	}
+	fmt.Fprintf(tw, "%s:\tavg10=%.2f avg60=%.2f avg300=%.2f\n", name, p.Avg10, p.Avg60, p.Avg300)
+}
+
 func printWorkersTable(tw *tabwriter.Writer, winfo []*client.WorkerInfo) {
-	fmt.Fprintln(tw, "ID\tPLATFORMS")
+	fmt.Fprintln(tw, "ID\tPLATFORMS\tACTIVE\tLABELS")
 
 	for _, wi := range winfo {
 		id := wi.ID
-		fmt.Fprintf(tw, "%s\t%s\n", id, joinPlatforms(wi.Platforms))
+		var labels []string
+		for _, k := range sortedKeys(wi.Labels) {
+			// the builtin labels are only shown in the verbose output
+			if strings.HasPrefix(k, "org.mobyproject.buildkit.") {
+				continue
+			}
+			labels = append(labels, k+"="+wi.Labels[k])
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\n", id, joinPlatforms(wi.Platforms), wi.ActiveBuilds, strings.Join(labels, ","))
 	}
 
 	tw.Flush()
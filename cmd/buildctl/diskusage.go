@@ -1,11 +1,14 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/moby/buildkit/client"
 	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
@@ -31,6 +34,14 @@ var diskUsageCommand = cli.Command{
 			Name:  "format",
 			Usage: "Format the output using the given Go template, e.g, '{{json .}}'",
 		},
+		cli.IntFlag{
+			Name:  "page-size",
+			Usage: "Load the records from the daemon in pages of the given size",
+		},
+		cli.BoolFlag{
+			Name:  "breakdown",
+			Usage: "Print the usage by record type and by the time since the records were last used",
+		},
 	},
 }
 
@@ -40,7 +51,11 @@ func diskUsage(clicontext *cli.Context) error {
 		return err
 	}
 
-	du, err := c.DiskUsage(bccommon.CommandContext(clicontext), client.WithFilter(clicontext.StringSlice("filter")))
+	if clicontext.Bool("breakdown") {
+		return diskUsageBreakdown(clicontext, c)
+	}
+
+	du, err := c.DiskUsage(bccommon.CommandContext(clicontext), client.WithFilter(clicontext.StringSlice("filter")), client.WithPageSize(clicontext.Int("page-size")))
 	if err != nil {
 		return err
 	}
@@ -75,6 +90,101 @@ func diskUsage(clicontext *cli.Context) error {
 	return nil
 }
 
+func diskUsageBreakdown(clicontext *cli.Context, c *client.Client) error {
+	bd, err := c.DiskUsageBreakdown(bccommon.CommandContext(clicontext), client.WithFilter(clicontext.StringSlice("filter")))
+	if err != nil {
+		return err
+	}
+
+	if format := clicontext.String("format"); format != "" {
+		tmpl, err := bccommon.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(clicontext.App.Writer, bd); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(clicontext.App.Writer, "\n")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(clicontext.App.Writer, 1, 8, 1, '\t', 0)
+	printBreakdown(tw, bd)
+	return tw.Flush()
+}
+
+// printBreakdown prints a row per record type with the size of the records
+// in each age bucket
+func printBreakdown(w io.Writer, bd []*client.UsageBreakdown) {
+	var types []client.UsageRecordType
+	var ages []time.Duration
+	byType := map[client.UsageRecordType]map[time.Duration]*client.UsageBreakdown{}
+	for _, b := range bd {
+		if _, ok := byType[b.RecordType]; !ok {
+			types = append(types, b.RecordType)
+			byType[b.RecordType] = map[time.Duration]*client.UsageBreakdown{}
+		}
+		byType[b.RecordType][b.Age] = b
+		if !slices.Contains(ages, b.Age) {
+			ages = append(ages, b.Age)
+		}
+	}
+	// the records older than all the buckets are last
+	slices.SortFunc(ages, func(a, b time.Duration) int {
+		if a == 0 || b == 0 {
+			return cmp.Compare(b, a)
+		}
+		return cmp.Compare(a, b)
+	})
+
+	fmt.Fprint(w, "TYPE\tCOUNT\tSIZE\tRECLAIMABLE")
+	for _, age := range ages {
+		fmt.Fprintf(w, "\t%s", ageLabel(age))
+	}
+	fmt.Fprintln(w)
+
+	var total client.UsageBreakdown
+	totalByAge := map[time.Duration]int64{}
+	for _, t := range types {
+		var row client.UsageBreakdown
+		for _, b := range byType[t] {
+			row.Count += b.Count
+			row.Size += b.Size
+			row.Reclaimable += b.Reclaimable
+			totalByAge[b.Age] += b.Size
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.2f\t%.2f", t, row.Count, units.Bytes(row.Size), units.Bytes(row.Reclaimable))
+		for _, age := range ages {
+			var size int64
+			if b, ok := byType[t][age]; ok {
+				size = b.Size
+			}
+			fmt.Fprintf(w, "\t%.2f", units.Bytes(size))
+		}
+		fmt.Fprintln(w)
+		total.Count += row.Count
+		total.Size += row.Size
+		total.Reclaimable += row.Reclaimable
+	}
+	fmt.Fprintf(w, "total\t%d\t%.2f\t%.2f", total.Count, units.Bytes(total.Size), units.Bytes(total.Reclaimable))
+	for _, age := range ages {
+		fmt.Fprintf(w, "\t%.2f", units.Bytes(totalByAge[age]))
+	}
+	fmt.Fprintln(w)
+}
+
+func ageLabel(d time.Duration) string {
+	switch {
+	case d == 0:
+		return "OLDER"
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("<%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("<%dh", d/time.Hour)
+	}
+	return "<" + d.String()
+}
+
 func printKV(w io.Writer, k string, v any) {
 	fmt.Fprintf(w, "%s:\t%v\n", k, v)
 }
@@ -100,6 +210,9 @@ func printVerbose(tw *tabwriter.Writer, du []*client.UsageInfo) {
 		if di.RecordType != "" {
 			printKV(tw, "Type", di.RecordType)
 		}
+		if di.Client != "" {
+			printKV(tw, "Client", di.Client)
+		}
 
 		fmt.Fprintf(tw, "\n")
 	}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"strings"
 	"testing"
+	"text/tabwriter"
+	"time"
 
+	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/util/testutil/integration"
 	"github.com/stretchr/testify/require"
 )
@@ -12,4 +17,28 @@ func testDiskUsage(t *testing.T, sb integration.Sandbox) {
 	cmd := sb.Cmd("du")
 	err := cmd.Run()
 	require.NoError(t, err)
+
+	cmd = sb.Cmd("du --breakdown")
+	err = cmd.Run()
+	require.NoError(t, err)
+}
+
+func TestPrintBreakdown(t *testing.T) {
+	bd := []*client.UsageBreakdown{
+		{RecordType: client.UsageRecordTypeCacheMount, Age: time.Hour, Count: 1, Size: 1000, Reclaimable: 1000},
+		{RecordType: client.UsageRecordTypeCacheMount, Age: 0, Count: 2, Size: 2000},
+		{RecordType: client.UsageRecordTypeRegular, Age: 7 * 24 * time.Hour, Count: 3, Size: 4000, Reclaimable: 4000},
+	}
+
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 1, 8, 1, '\t', 0)
+	printBreakdown(tw, bd)
+	require.NoError(t, tw.Flush())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	require.Equal(t, []string{"TYPE", "COUNT", "SIZE", "RECLAIMABLE", "<1h", "<7d", "OLDER"}, strings.Fields(lines[0]))
+	require.Equal(t, []string{"exec.cachemount", "3", "3.00kB", "1.00kB", "1.00kB", "0B", "2.00kB"}, strings.Fields(lines[1]))
+	require.Equal(t, []string{"regular", "3", "4.00kB", "4.00kB", "0B", "4.00kB", "0B"}, strings.Fields(lines[2]))
+	require.Equal(t, []string{"total", "6", "7.00kB", "5.00kB", "1.00kB", "4.00kB", "2.00kB"}, strings.Fields(lines[3]))
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/appcontext"
+	"github.com/urfave/cli"
+)
+
+var drainCommand = cli.Command{
+	Name:   "drain",
+	Usage:  "stop buildkitd after the running builds finish, without accepting new ones",
+	Action: drain,
+	Flags: []cli.Flag{
+		cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "Cancel the builds still running after the timeout (default: the drain timeout of buildkitd)",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Format the output using the given Go template, e.g, '{{json .}}'",
+		},
+	},
+}
+
+func drain(clicontext *cli.Context) error {
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.Drain(appcontext.Context(), clicontext.Duration("timeout"))
+	if err != nil {
+		return err
+	}
+
+	if format := clicontext.String("format"); format != "" {
+		tmpl, err := bccommon.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(clicontext.App.Writer, res); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(clicontext.App.Writer, "\n")
+		return err
+	}
+
+	fmt.Fprintf(clicontext.App.Writer, "Completed builds: %d\nCanceled builds: %d\n", res.Completed, res.Canceled)
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"github.com/moby/buildkit/cmd/buildctl/debug"
+	"github.com/moby/buildkit/cmd/buildctl/history"
+	"github.com/urfave/cli"
+)
+
+var historyCommand = cli.Command{
+	Name:  "history",
+	Usage: "inspect the build history",
+	Subcommands: []cli.Command{
+		history.ListCommand,
+		history.InspectCommand,
+		debug.LogsCommand,
+		history.ProvenanceCommand,
+		history.CompareCommand,
+		history.ReplayCommand,
+	},
+}
@@ -0,0 +1,278 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/appcontext"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var CompareCommand = cli.Command{
+	Name:      "compare",
+	Usage:     "compare the steps of two build records",
+	ArgsUsage: "<ref1> <ref2>",
+	Description: `Compares the steps of two builds by vertex digest and name. Steps with
+the same name but a different digest have changed inputs. By default only
+the steps that changed, were added or removed, or whose cache status
+differs are printed.`,
+	Action: compare,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "all",
+			Usage: "Print the steps that are the same in both builds",
+		},
+	},
+}
+
+// step is a vertex of a build as recorded in its status
+type step struct {
+	Digest    digest.Digest
+	Name      string
+	Cached    bool
+	Started   *time.Time
+	Completed *time.Time
+	Error     string
+}
+
+func (s *step) duration() time.Duration {
+	if s.Started == nil || s.Completed == nil {
+		return 0
+	}
+	return s.Completed.Sub(*s.Started)
+}
+
+func (s *step) formatDuration() string {
+	if s.Started == nil || s.Completed == nil {
+		return "-"
+	}
+	if d := s.duration(); d > 0 {
+		return formatDuration(d)
+	}
+	return "0s"
+}
+
+func (s *step) cacheStatus() string {
+	switch {
+	case s.Error != "":
+		return "error"
+	case s.Cached:
+		return "cached"
+	case s.Completed == nil:
+		return "incomplete"
+	default:
+		return "executed"
+	}
+}
+
+const (
+	changeSame    = "same"
+	changeCache   = "cache"
+	changeDigest  = "changed"
+	changeAdded   = "added"
+	changeRemoved = "removed"
+)
+
+// stepDiff is a step of either build. A is nil for the steps added and B is
+// nil for the steps removed in the second build.
+type stepDiff struct {
+	Change string
+	A, B   *step
+}
+
+func compare(clicontext *cli.Context) error {
+	if clicontext.NArg() != 2 {
+		return errors.Errorf("two build refs must be specified")
+	}
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	ctx := appcontext.Context()
+	refs := clicontext.Args()
+	var recs [2]*controlapi.BuildHistoryRecord
+	var steps [2][]*step
+	for i := range 2 {
+		if recs[i], err = bccommon.GetBuildRecord(ctx, c, refs[i]); err != nil {
+			return err
+		}
+		if steps[i], err = readSteps(ctx, c, refs[i]); err != nil {
+			return errors.Wrapf(err, "failed to read the steps of %s", refs[i])
+		}
+	}
+
+	w := clicontext.App.Writer
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	fmt.Fprintf(tw, "\t%s\t%s\n", recs[0].Ref, recs[1].Ref)
+	fmt.Fprintf(tw, "Status:\t%s\t%s\n", recordStatus(recs[0]), recordStatus(recs[1]))
+	var delta string
+	if recs[0].CompletedAt != nil && recs[1].CompletedAt != nil {
+		delta = formatDelta(recordDuration(recs[0]), recordDuration(recs[1]))
+	}
+	fmt.Fprintf(tw, "Duration:\t%s\t%s\t%s\n", formatDuration(recordDuration(recs[0])), formatDuration(recordDuration(recs[1])), delta)
+	fmt.Fprintf(tw, "Steps:\t%d (%d cached)\t%d (%d cached)\n", recs[0].NumTotalSteps, recs[0].NumCachedSteps, recs[1].NumTotalSteps, recs[1].NumCachedSteps)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintln(w)
+	return printStepDiffs(w, compareSteps(steps[0], steps[1]), clicontext.Bool("all"))
+}
+
+// readSteps returns the steps of a build in the order they were started
+func readSteps(ctx context.Context, c *client.Client, ref string) ([]*step, error) {
+	cl, err := c.ControlClient().Status(ctx, &controlapi.StatusRequest{
+		Ref: ref,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var steps []*step
+	byDigest := map[digest.Digest]*step{}
+	for {
+		resp, err := cl.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return steps, nil
+			}
+			return nil, err
+		}
+		for _, v := range client.NewSolveStatus(resp).Vertexes {
+			s, ok := byDigest[v.Digest]
+			if !ok {
+				s = &step{Digest: v.Digest}
+				byDigest[v.Digest] = s
+				steps = append(steps, s)
+			}
+			s.Name = v.Name
+			s.Cached = s.Cached || v.Cached
+			if v.Started != nil {
+				s.Started = v.Started
+			}
+			if v.Completed != nil {
+				s.Completed = v.Completed
+			}
+			if v.Error != "" {
+				s.Error = v.Error
+			}
+		}
+	}
+}
+
+// compareSteps matches the steps of two builds. Steps are matched by digest
+// first and the remaining steps by name, so a step whose inputs changed is
+// reported as changed instead of as removed and added.
+func compareSteps(a, b []*step) []stepDiff {
+	bByDigest := map[digest.Digest]*step{}
+	bByName := map[string][]*step{}
+	for _, s := range b {
+		bByDigest[s.Digest] = s
+	}
+	matched := map[*step]bool{}
+	var out []stepDiff
+	for _, s := range a {
+		if sb, ok := bByDigest[s.Digest]; ok {
+			matched[sb] = true
+			change := changeSame
+			if s.cacheStatus() != sb.cacheStatus() {
+				change = changeCache
+			}
+			out = append(out, stepDiff{Change: change, A: s, B: sb})
+			continue
+		}
+		out = append(out, stepDiff{A: s})
+	}
+	for _, s := range b {
+		if !matched[s] {
+			bByName[s.Name] = append(bByName[s.Name], s)
+		}
+	}
+	for i := range out {
+		if out[i].Change != "" {
+			continue
+		}
+		name := out[i].A.Name
+		if cands := bByName[name]; len(cands) > 0 {
+			out[i].Change = changeDigest
+			out[i].B = cands[0]
+			matched[cands[0]] = true
+			bByName[name] = cands[1:]
+			continue
+		}
+		out[i].Change = changeRemoved
+	}
+	for _, s := range b {
+		if !matched[s] {
+			out = append(out, stepDiff{Change: changeAdded, B: s})
+		}
+	}
+	return out
+}
+
+func printStepDiffs(w io.Writer, diffs []stepDiff, all bool) error {
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "CHANGE\tSTEP\tDIGEST\tCACHE\tDURATION")
+	for _, d := range diffs {
+		if d.Change == changeSame && !all {
+			continue
+		}
+		switch {
+		case d.A == nil:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", d.Change, d.B.Name, shortDigest(d.B), d.B.cacheStatus(), d.B.formatDuration())
+		case d.B == nil:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", d.Change, d.A.Name, shortDigest(d.A), d.A.cacheStatus(), d.A.formatDuration())
+		default:
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", d.Change, d.A.Name,
+				compareField(shortDigest(d.A), shortDigest(d.B)),
+				compareField(d.A.cacheStatus(), d.B.cacheStatus()),
+				compareDuration(d.A, d.B))
+		}
+	}
+	return tw.Flush()
+}
+
+func compareField(a, b string) string {
+	if a == b {
+		return a
+	}
+	return a + " -> " + b
+}
+
+// compareDuration returns the durations of a step in both builds and the
+// difference between them
+func compareDuration(a, b *step) string {
+	v := compareField(a.formatDuration(), b.formatDuration())
+	if a.Completed != nil && b.Completed != nil {
+		if delta := formatDelta(a.duration(), b.duration()); delta != "" {
+			v += " " + delta
+		}
+	}
+	return v
+}
+
+func formatDelta(a, b time.Duration) string {
+	if a == b {
+		return ""
+	}
+	d := b - a
+	if d > 0 {
+		return "(+" + formatDuration(d) + ")"
+	}
+	return "(-" + formatDuration(-d) + ")"
+}
+
+func shortDigest(s *step) string {
+	e := s.Digest.Encoded()
+	if len(e) > 12 {
+		e = e[:12]
+	}
+	return e
+}
@@ -0,0 +1,92 @@
+package history
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareSteps(t *testing.T) {
+	start := time.Now()
+	newStep := func(seed, name string, cached bool, d time.Duration) *step {
+		completed := start.Add(d)
+		return &step{
+			Digest:    digest.FromString(seed),
+			Name:      name,
+			Cached:    cached,
+			Started:   &start,
+			Completed: &completed,
+		}
+	}
+
+	a := []*step{
+		newStep("base", "FROM alpine", false, time.Second),
+		newStep("deps", "RUN apk add git", false, 10*time.Second),
+		newStep("copy1", "COPY . .", false, time.Second),
+		newStep("test", "RUN make test", false, 5*time.Second),
+	}
+	b := []*step{
+		newStep("base", "FROM alpine", false, time.Second),
+		newStep("deps", "RUN apk add git", true, 0),
+		newStep("copy2", "COPY . .", false, 2*time.Second),
+		newStep("lint", "RUN make lint", false, time.Second),
+	}
+
+	diffs := compareSteps(a, b)
+	require.Len(t, diffs, 5)
+	var changes []string
+	for _, d := range diffs {
+		changes = append(changes, d.Change)
+	}
+	require.Equal(t, []string{changeSame, changeCache, changeDigest, changeRemoved, changeAdded}, changes)
+	require.Equal(t, b[2], diffs[2].B)
+	require.Nil(t, diffs[3].B)
+	require.Nil(t, diffs[4].A)
+
+	var buf bytes.Buffer
+	require.NoError(t, printStepDiffs(&buf, diffs, false))
+	out := buf.String()
+	require.NotContains(t, out, "FROM alpine")
+	require.Contains(t, out, "executed -> cached")
+	require.Contains(t, out, "10s -> 0s (-10s)")
+	require.Contains(t, out, "1s -> 2s (+1s)")
+}
+
+func TestProvenanceDescriptor(t *testing.T) {
+	prv := func(dgst string) *controlapi.Descriptor {
+		return &controlapi.Descriptor{
+			Digest:      dgst,
+			Annotations: map[string]string{predicateTypeAnnotation: "https://slsa.dev/provenance/v0.2"},
+		}
+	}
+
+	rec := &controlapi.BuildHistoryRecord{
+		Ref:    "ref1",
+		Result: &controlapi.BuildResultInfo{Attestations: []*controlapi.Descriptor{prv("sha256:aaa")}},
+	}
+	d, err := provenanceDescriptor(rec, "")
+	require.NoError(t, err)
+	require.Equal(t, "sha256:aaa", d.Digest)
+
+	rec = &controlapi.BuildHistoryRecord{
+		Ref: "ref2",
+		Results: map[string]*controlapi.BuildResultInfo{
+			"linux/amd64": {Attestations: []*controlapi.Descriptor{prv("sha256:amd64")}},
+			"linux/arm64": {Attestations: []*controlapi.Descriptor{prv("sha256:arm64")}},
+		},
+	}
+	_, err = provenanceDescriptor(rec, "")
+	require.ErrorContains(t, err, "specify one of: linux/amd64, linux/arm64")
+	d, err = provenanceDescriptor(rec, "linux/arm64")
+	require.NoError(t, err)
+	require.Equal(t, "sha256:arm64", d.Digest)
+	_, err = provenanceDescriptor(rec, "linux/riscv64")
+	require.ErrorContains(t, err, "no result for platform")
+
+	_, err = provenanceDescriptor(&controlapi.BuildHistoryRecord{Ref: "ref3"}, "")
+	require.ErrorContains(t, err, "no provenance attestation")
+}
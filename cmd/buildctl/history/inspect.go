@@ -0,0 +1,161 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	units "github.com/docker/go-units"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/appcontext"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var InspectCommand = cli.Command{
+	Name:      "inspect",
+	Usage:     "show the details of a build record",
+	ArgsUsage: "<ref>",
+	Action:    inspect,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Format the output using the given Go template, e.g, '{{json .}}', or 'json' for the record as JSON",
+		},
+	},
+}
+
+func inspect(clicontext *cli.Context) error {
+	if clicontext.NArg() != 1 {
+		return errors.Errorf("build ref must be specified")
+	}
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	ctx := appcontext.Context()
+	rec, err := bccommon.GetBuildRecord(ctx, c, clicontext.Args().First())
+	if err != nil {
+		return err
+	}
+
+	w := clicontext.App.Writer
+	switch format := clicontext.String("format"); format {
+	case "":
+		return printRecord(w, rec)
+	case "json":
+		dt, err := protojson.MarshalOptions{Multiline: true}.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(dt))
+		return err
+	default:
+		tmpl, err := bccommon.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(w, rec); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w)
+		return err
+	}
+}
+
+func printRecord(w io.Writer, rec *controlapi.BuildHistoryRecord) error {
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	fmt.Fprintf(tw, "Ref:\t%s\n", rec.Ref)
+	if rec.Frontend != "" {
+		fmt.Fprintf(tw, "Frontend:\t%s\n", rec.Frontend)
+	}
+	fmt.Fprintf(tw, "Status:\t%s\n", recordStatus(rec))
+	if rec.Error != nil && rec.Error.Message != "" {
+		fmt.Fprintf(tw, "Error:\t%s\n", rec.Error.Message)
+	}
+	if id := rec.ClientIdentity; id != nil {
+		fmt.Fprintf(tw, "Client:\t%s (%s)\n", id.Name, id.Method)
+	}
+	if rec.CreatedAt != nil {
+		fmt.Fprintf(tw, "Created:\t%s\n", rec.CreatedAt.AsTime().Local().Format(time.RFC3339))
+	}
+	if rec.CompletedAt != nil {
+		fmt.Fprintf(tw, "Completed:\t%s\n", rec.CompletedAt.AsTime().Local().Format(time.RFC3339))
+		fmt.Fprintf(tw, "Duration:\t%s\n", formatDuration(recordDuration(rec)))
+	}
+	fmt.Fprintf(tw, "Steps:\t%d/%d (%d cached)\n", rec.NumCompletedSteps, rec.NumTotalSteps, rec.NumCachedSteps)
+	if rec.NumWarnings > 0 {
+		fmt.Fprintf(tw, "Warnings:\t%d\n", rec.NumWarnings)
+	}
+	if ru := rec.ResourceUsage; ru != nil {
+		fmt.Fprintf(tw, "Resources:\tcpu=%s memory.peak=%s io.read=%s io.write=%s\n",
+			time.Duration(ru.CpuNanos).Round(time.Millisecond),
+			units.BytesSize(float64(ru.MemoryPeakBytes)),
+			units.BytesSize(float64(ru.IoReadBytes)),
+			units.BytesSize(float64(ru.IoWriteBytes)))
+	}
+	if rec.Pinned {
+		fmt.Fprintf(tw, "Pinned:\ttrue\n")
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(rec.CacheSources) > 0 {
+		fmt.Fprintln(w, "\nCache sources:")
+		tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+		for _, k := range slices.Sorted(maps.Keys(rec.CacheSources)) {
+			fmt.Fprintf(tw, "  %s:\t%d\n", k, rec.CacheSources[k])
+		}
+		tw.Flush()
+	}
+	if len(rec.FrontendAttrs) > 0 {
+		fmt.Fprintln(w, "\nFrontend attributes:")
+		printMap(w, rec.FrontendAttrs)
+	}
+	for _, exp := range rec.Exporters {
+		fmt.Fprintf(w, "\nExporter %s:\n", exp.Type)
+		printMap(w, exp.Attrs)
+	}
+	if len(rec.ExporterResponse) > 0 {
+		fmt.Fprintln(w, "\nExporter response:")
+		printMap(w, rec.ExporterResponse)
+	}
+
+	results := map[string]*controlapi.BuildResultInfo{}
+	maps.Copy(results, rec.Results)
+	if rec.Result != nil {
+		results[""] = rec.Result
+	}
+	for _, k := range slices.Sorted(maps.Keys(results)) {
+		if k == "" {
+			fmt.Fprintln(w, "\nResult:")
+		} else {
+			fmt.Fprintf(w, "\nResult %s:\n", k)
+		}
+		res := results[k]
+		for _, i := range slices.Sorted(maps.Keys(res.Results)) {
+			d := res.Results[i]
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", d.Digest, d.MediaType, units.BytesSize(float64(d.Size)))
+		}
+		for _, d := range res.Attestations {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", d.Digest, d.Annotations[predicateTypeAnnotation], units.BytesSize(float64(d.Size)))
+		}
+	}
+	return nil
+}
+
+func printMap(w io.Writer, m map[string]string) {
+	tw := tabwriter.NewWriter(w, 1, 8, 1, '\t', 0)
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		fmt.Fprintf(tw, "  %s:\t%s\n", k, strings.ReplaceAll(m[k], "\n", "\\n"))
+	}
+	tw.Flush()
+}
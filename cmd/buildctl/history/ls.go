@@ -0,0 +1,136 @@
+// Package history implements the buildctl commands over the build history
+// of the daemon.
+package history
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/appcontext"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc/codes"
+)
+
+var ListCommand = cli.Command{
+	Name:    "ls",
+	Aliases: []string{"list"},
+	Usage:   "list build records",
+	Action:  list,
+	Flags: []cli.Flag{
+		cli.StringSliceFlag{
+			Name:  "filter",
+			Usage: "Filter records, e.g. 'status=error', 'ref==<ref>' or 'startedAt>24h'",
+		},
+		cli.IntFlag{
+			Name:  "limit",
+			Usage: "Maximum number of records to list, newest first",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Format the output using the given Go template, e.g, '{{json .}}'",
+		},
+	},
+}
+
+func list(clicontext *cli.Context) error {
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	ctx := appcontext.Context()
+	resp, err := c.ControlClient().ListenBuildHistory(ctx, &controlapi.BuildHistoryRequest{
+		EarlyExit: true,
+		Filter:    clicontext.StringSlice("filter"),
+		Limit:     int32(clicontext.Int("limit")),
+	})
+	if err != nil {
+		return err
+	}
+
+	var records []*controlapi.BuildHistoryRecord
+	for {
+		ev, err := resp.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+		if ev.Record != nil {
+			records = append(records, ev.Record)
+		}
+	}
+
+	if format := clicontext.String("format"); format != "" {
+		tmpl, err := bccommon.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := tmpl.Execute(clicontext.App.Writer, r); err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintf(clicontext.App.Writer, "\n"); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(clicontext.App.Writer, 1, 8, 1, '\t', 0)
+	fmt.Fprintln(tw, "REF\tFRONTEND\tSTATUS\tCREATED\tDURATION\tSTEPS\tPINNED")
+	for _, r := range records {
+		var createdAt, pinned string
+		if r.CreatedAt != nil {
+			createdAt = r.CreatedAt.AsTime().Local().Format(time.RFC3339)
+		}
+		if r.Pinned {
+			pinned = "*"
+		}
+		steps := fmt.Sprintf("%d/%d", r.NumCompletedSteps, r.NumTotalSteps)
+		if r.NumCachedSteps > 0 {
+			steps += fmt.Sprintf(" (%d cached)", r.NumCachedSteps)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", r.Ref, r.Frontend, recordStatus(r), createdAt, formatDuration(recordDuration(r)), steps, pinned)
+	}
+	return tw.Flush()
+}
+
+// recordStatus returns the status of a record like the status filter of
+// the history API: running, completed, error or canceled
+func recordStatus(r *controlapi.BuildHistoryRecord) string {
+	if r.CompletedAt == nil {
+		return "running"
+	}
+	if r.Error != nil && codes.Code(r.Error.Code) != codes.OK {
+		if codes.Code(r.Error.Code) == codes.Canceled || strings.Contains(r.Error.Message, "context canceled") {
+			return "canceled"
+		}
+		return "error"
+	}
+	return "completed"
+}
+
+// recordDuration returns the duration of a completed build
+func recordDuration(r *controlapi.BuildHistoryRecord) time.Duration {
+	if r.CreatedAt == nil || r.CompletedAt == nil {
+		return 0
+	}
+	return r.CompletedAt.AsTime().Sub(r.CreatedAt.AsTime())
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
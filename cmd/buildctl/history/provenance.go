@@ -0,0 +1,87 @@
+package history
+
+import (
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/appcontext"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+const predicateTypeAnnotation = "in-toto.io/predicate-type"
+
+var ProvenanceCommand = cli.Command{
+	Name:      "provenance",
+	Usage:     "print the provenance attestation of a build record",
+	ArgsUsage: "<ref>",
+	Action:    provenance,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "platform",
+			Usage: "Platform of the result of a multi-platform build",
+		},
+	},
+}
+
+func provenance(clicontext *cli.Context) error {
+	if clicontext.NArg() != 1 {
+		return errors.Errorf("build ref must be specified")
+	}
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	ctx := appcontext.Context()
+	rec, err := bccommon.GetBuildRecord(ctx, c, clicontext.Args().First())
+	if err != nil {
+		return err
+	}
+	desc, err := provenanceDescriptor(rec, clicontext.String("platform"))
+	if err != nil {
+		return err
+	}
+	ra, err := bccommon.OpenDescriptor(ctx, c, desc)
+	if err != nil {
+		return err
+	}
+	defer ra.Close()
+	_, err = io.Copy(clicontext.App.Writer, content.NewReader(ra))
+	return err
+}
+
+// provenanceDescriptor returns the provenance attestation of the result of
+// a record. The platform must be set for the records of multi-platform
+// builds.
+func provenanceDescriptor(rec *controlapi.BuildHistoryRecord, platform string) (*controlapi.Descriptor, error) {
+	res := rec.Result
+	if platform != "" || (res == nil && len(rec.Results) > 0) {
+		switch {
+		case platform == "" && len(rec.Results) == 1:
+			for _, r := range rec.Results {
+				res = r
+			}
+		case platform == "" && len(rec.Results) > 1:
+			return nil, errors.Errorf("build %s has results for multiple platforms, specify one of: %s", rec.Ref, strings.Join(slices.Sorted(maps.Keys(rec.Results)), ", "))
+		default:
+			res = rec.Results[platform]
+			if res == nil {
+				return nil, errors.Errorf("build %s has no result for platform %s", rec.Ref, platform)
+			}
+		}
+	}
+	if res != nil {
+		for _, d := range res.Attestations {
+			if strings.HasPrefix(d.Annotations[predicateTypeAnnotation], "https://slsa.dev/provenance/") {
+				return d, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("build %s has no provenance attestation", rec.Ref)
+}
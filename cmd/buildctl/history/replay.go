@@ -0,0 +1,450 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/distribution/reference"
+	"github.com/docker/cli/cli/config"
+	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/client/lockfile"
+	"github.com/moby/buildkit/cmd/buildctl/build"
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/identity"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/auth/authprovider"
+	"github.com/moby/buildkit/session/sshforward/sshprovider"
+	provenancetypes "github.com/moby/buildkit/solver/llbsolver/provenance/types"
+	"github.com/moby/buildkit/solver/pb"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	"github.com/moby/buildkit/util/progress/progresswriter"
+	"github.com/moby/buildkit/util/purl"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"golang.org/x/sync/errgroup"
+)
+
+var ReplayCommand = cli.Command{
+	Name:      "replay",
+	Usage:     "build a build record again from its provenance attestation",
+	ArgsUsage: "<ref>",
+	UsageText: `
+	To build a record again without the cache of its third step:
+	  $ buildctl history replay --local context=. --no-cache step2 <ref>
+	`,
+	Action: replay,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "platform",
+			Usage: "Platform of the result of a multi-platform build",
+		},
+		cli.StringSliceFlag{
+			Name:  "local",
+			Usage: "Allow build access to the local directory, the build needs the local directories of the record",
+		},
+		cli.StringSliceFlag{
+			Name:  "output,o",
+			Usage: "Define exports for build result, e.g. --output type=local,dest=path/to/dir. Nothing is exported by default",
+		},
+		cli.StringSliceFlag{
+			Name:  "no-cache",
+			Usage: "Disable cache for the steps of the LLB definition of the provenance, e.g. --no-cache step2, or all of them with --no-cache all",
+		},
+		cli.BoolFlag{
+			Name:  "rerun-frontend",
+			Usage: "Run the frontend of the record with its options instead of building the LLB definition of the provenance",
+		},
+		cli.BoolFlag{
+			Name:  "no-pin",
+			Usage: "Don't pin the image, Git and HTTP sources to the ones of the provenance",
+		},
+		cli.StringSliceFlag{
+			Name:  "secret",
+			Usage: "Secret value exposed to the build. Format id=secretname,src=filepath",
+		},
+		cli.StringSliceFlag{
+			Name:  "ssh",
+			Usage: "Allow forwarding SSH agent or a raw Unix socket to the builder. Format default|<id>[=<socket>[,raw=false]|<key>[,<key>]]",
+		},
+		cli.StringSliceFlag{
+			Name:  "allow",
+			Usage: "Allow extra privileged entitlement, e.g. network.host, security.insecure, device, local.exec, mount.host",
+		},
+		cli.StringFlag{
+			Name:  "progress",
+			Usage: "Set type of progress (auto, plain, tty, rawjson, ci). Use plain to show container output",
+			Value: "auto",
+		},
+	},
+}
+
+// replayRequest is the build request replaying a record
+type replayRequest struct {
+	def           *pb.Definition
+	frontend      string
+	frontendAttrs map[string]string
+	sourcePolicy  *spb.Policy
+	// locals are the names of the local directories of the build
+	locals []string
+}
+
+type replayOpt struct {
+	noCache       []string
+	rerunFrontend bool
+	noPin         bool
+}
+
+func replay(clicontext *cli.Context) error {
+	if clicontext.NArg() != 1 {
+		return errors.Errorf("build ref must be specified")
+	}
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	ctx := bccommon.CommandContext(clicontext)
+	rec, err := bccommon.GetBuildRecord(ctx, c, clicontext.Args().First())
+	if err != nil {
+		return err
+	}
+	pred, err := readProvenance(ctx, c, rec, clicontext.String("platform"))
+	if err != nil {
+		return err
+	}
+	req, err := newReplayRequest(rec, pred, replayOpt{
+		noCache:       clicontext.StringSlice("no-cache"),
+		rerunFrontend: clicontext.Bool("rerun-frontend"),
+		noPin:         clicontext.Bool("no-pin"),
+	})
+	if err != nil {
+		return err
+	}
+
+	localMounts, err := build.ParseLocal(clicontext.StringSlice("local"))
+	if err != nil {
+		return errors.Wrap(err, "invalid local")
+	}
+	for _, name := range req.locals {
+		if _, ok := localMounts[name]; !ok {
+			return errors.Errorf("build %s uses the local directory %s, set it with --local %s=<path>", rec.Ref, name, name)
+		}
+	}
+	exports, err := build.ParseOutput(clicontext.StringSlice("output"))
+	if err != nil {
+		return err
+	}
+	if err := build.ValidateAllow(clicontext.StringSlice("allow")); err != nil {
+		return err
+	}
+
+	attachable := []session.Attachable{authprovider.NewDockerAuthProvider(authprovider.DockerAuthProviderConfig{
+		ConfigFile: config.LoadDefaultConfigFile(os.Stderr),
+	})}
+	if ssh := clicontext.StringSlice("ssh"); len(ssh) > 0 {
+		configs, err := build.ParseSSH(ssh)
+		if err != nil {
+			return err
+		}
+		sp, err := sshprovider.NewSSHAgentProvider(configs)
+		if err != nil {
+			return err
+		}
+		attachable = append(attachable, sp)
+	}
+	if secrets := clicontext.StringSlice("secret"); len(secrets) > 0 {
+		secretProvider, err := build.ParseSecret(secrets)
+		if err != nil {
+			return err
+		}
+		attachable = append(attachable, secretProvider)
+	}
+
+	solveOpt := client.SolveOpt{
+		Exports:             exports,
+		LocalMounts:         localMounts,
+		Frontend:            req.frontend,
+		FrontendAttrs:       req.frontendAttrs,
+		Session:             attachable,
+		AllowedEntitlements: clicontext.StringSlice("allow"),
+		SourcePolicy:        req.sourcePolicy,
+		Ref:                 identity.NewID(),
+	}
+	var def *llb.Definition
+	if req.def != nil {
+		def = &llb.Definition{}
+		def.FromPB(req.def)
+	}
+
+	// not using shared context to not disrupt display but let is finish reporting errors
+	pw, err := progresswriter.NewPrinter(context.TODO(), os.Stderr, clicontext.String("progress"))
+	if err != nil {
+		return err
+	}
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.Go(func() error {
+		_, err := c.Solve(ctx, def, solveOpt, progresswriter.ResetTime(pw).Status())
+		return err
+	})
+	eg.Go(func() error {
+		<-pw.Done()
+		return pw.Err()
+	})
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(clicontext.App.Writer, "replayed %s as %s\n", rec.Ref, solveOpt.Ref)
+	return err
+}
+
+// readProvenance reads the provenance attestation of the result of a record
+// for the platform
+func readProvenance(ctx context.Context, c *client.Client, rec *controlapi.BuildHistoryRecord, platform string) (*provenancetypes.ProvenancePredicateSLSA02, error) {
+	desc, err := provenanceDescriptor(rec, platform)
+	if err != nil {
+		return nil, err
+	}
+	ra, err := bccommon.OpenDescriptor(ctx, c, desc)
+	if err != nil {
+		return nil, err
+	}
+	defer ra.Close()
+	dt, err := io.ReadAll(content.NewReader(ra))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return parseProvenance(dt)
+}
+
+// parseProvenance parses an in-toto statement with a SLSA v0.2 or v1
+// provenance predicate
+func parseProvenance(dt []byte) (*provenancetypes.ProvenancePredicateSLSA02, error) {
+	var stmt struct {
+		PredicateType string          `json:"predicateType"`
+		Predicate     json.RawMessage `json:"predicate"`
+	}
+	if err := json.Unmarshal(dt, &stmt); err != nil {
+		return nil, errors.Wrap(err, "failed to parse provenance attestation")
+	}
+	switch {
+	case strings.HasPrefix(stmt.PredicateType, "https://slsa.dev/provenance/v0.2"):
+		var pred provenancetypes.ProvenancePredicateSLSA02
+		if err := json.Unmarshal(stmt.Predicate, &pred); err != nil {
+			return nil, errors.Wrap(err, "failed to parse provenance predicate")
+		}
+		return &pred, nil
+	case strings.HasPrefix(stmt.PredicateType, "https://slsa.dev/provenance/v1"):
+		var pred provenancetypes.ProvenancePredicateSLSA1
+		if err := json.Unmarshal(stmt.Predicate, &pred); err != nil {
+			return nil, errors.Wrap(err, "failed to parse provenance predicate")
+		}
+		return pred.ConvertToSLSA02(), nil
+	}
+	return nil, errors.Errorf("unsupported provenance predicate type %q", stmt.PredicateType)
+}
+
+// newReplayRequest returns the build request replaying a record. The LLB
+// definition of the provenance is built unless the frontend is run again or
+// the provenance has none, which is the case with the provenance mode=min.
+func newReplayRequest(rec *controlapi.BuildHistoryRecord, pred *provenancetypes.ProvenancePredicateSLSA02, opt replayOpt) (*replayRequest, error) {
+	req := &replayRequest{
+		frontendAttrs: map[string]string{},
+	}
+	for _, l := range pred.Invocation.Parameters.Locals {
+		req.locals = append(req.locals, l.Name)
+	}
+	allNoCache := slices.Contains(opt.noCache, "all")
+
+	if pred.BuildConfig != nil && len(pred.BuildConfig.Definition) > 0 && !opt.rerunFrontend {
+		def, err := definitionFromSteps(pred.BuildConfig.Definition)
+		if err != nil {
+			return nil, err
+		}
+		if err := ignoreCache(def, pred.BuildConfig.Definition, opt.noCache); err != nil {
+			return nil, err
+		}
+		req.def = def
+		// the attestations are still requested by the frontend attributes
+		for k, v := range rec.FrontendAttrs {
+			if strings.HasPrefix(k, "attest:") {
+				req.frontendAttrs[k] = v
+			}
+		}
+	} else {
+		req.frontend = rec.Frontend
+		if req.frontend == "" {
+			req.frontend = pred.Invocation.Parameters.Frontend
+		}
+		if req.frontend == "" {
+			return nil, errors.Errorf("build %s has no frontend and no LLB definition in its provenance, it needs the provenance attestation mode=max", rec.Ref)
+		}
+		maps.Copy(req.frontendAttrs, rec.FrontendAttrs)
+		if len(opt.noCache) > 0 {
+			if !allNoCache || len(opt.noCache) > 1 {
+				return nil, errors.Errorf("disabling the cache of some steps needs the LLB definition of the provenance attestation mode=max")
+			}
+			req.frontendAttrs["no-cache"] = ""
+		}
+	}
+
+	if !opt.noPin {
+		l, err := lockfileFromMaterials(pred.Materials)
+		if err != nil {
+			return nil, err
+		}
+		if req.sourcePolicy, err = l.SourcePolicy(false); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+// definitionFromSteps returns the LLB definition of the build steps of a
+// provenance. The steps are ordered with their inputs first and the last
+// step is the terminal op of the definition.
+func definitionFromSteps(steps []provenancetypes.BuildStep) (*pb.Definition, error) {
+	def := &pb.Definition{Metadata: map[string]*pb.OpMetadata{}}
+	dgsts := make(map[string]digest.Digest, len(steps))
+	for _, s := range steps {
+		op := &pb.Op{}
+		if s.Op != nil {
+			op = s.Op.CloneVT()
+		}
+		op.Inputs = nil
+		for _, inp := range s.Inputs {
+			id, idx, ok := strings.Cut(inp, ":")
+			if !ok {
+				return nil, errors.Errorf("invalid input %q of step %s", inp, s.ID)
+			}
+			dgst, ok := dgsts[id]
+			if !ok {
+				return nil, errors.Errorf("unknown input %q of step %s", inp, s.ID)
+			}
+			index, err := strconv.ParseInt(idx, 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid input %q of step %s", inp, s.ID)
+			}
+			op.Inputs = append(op.Inputs, &pb.Input{Digest: string(dgst), Index: index})
+		}
+		dt, err := op.Marshal()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		dgst := digest.FromBytes(dt)
+		dgsts[s.ID] = dgst
+		def.Def = append(def.Def, dt)
+		def.Metadata[string(dgst)] = &pb.OpMetadata{}
+	}
+	return def, nil
+}
+
+// ignoreCache disables the cache of the steps of a definition built by
+// definitionFromSteps, all of them if the steps contain "all"
+func ignoreCache(def *pb.Definition, steps []provenancetypes.BuildStep, ids []string) error {
+	all := slices.Contains(ids, "all")
+	for _, id := range ids {
+		if id != "all" && !slices.ContainsFunc(steps, func(s provenancetypes.BuildStep) bool { return s.ID == id }) {
+			return errors.Errorf("no step %s in the LLB definition of the provenance", id)
+		}
+	}
+	for i, s := range steps {
+		if s.Op == nil || s.Op.Op == nil {
+			// the terminal op
+			continue
+		}
+		if all || slices.Contains(ids, s.ID) {
+			def.Metadata[string(digest.FromBytes(def.Def[i]))].IgnoreCache = true
+		}
+	}
+	return nil
+}
+
+// lockfileFromMaterials returns the lockfile of the materials of a
+// provenance. The local images are not pinned, they can't be resolved again.
+func lockfileFromMaterials(materials []slsa.ProvenanceMaterial) (*lockfile.Lockfile, error) {
+	l := &lockfile.Lockfile{Version: lockfile.Version}
+	for _, m := range materials {
+		switch {
+		case strings.HasPrefix(m.URI, "pkg:docker/"):
+			dgst, ok := materialDigest(m)
+			if !ok {
+				continue
+			}
+			ref, platform, err := purl.PURLToRef(m.URI)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid image material %s", m.URI)
+			}
+			if ref, err = lockfileImageRef(ref); err != nil {
+				return nil, err
+			}
+			if ref == "" {
+				continue
+			}
+			l.Images = append(l.Images, lockfile.Image{
+				Ref:      ref,
+				Platform: platform,
+				Digest:   dgst,
+			})
+		case strings.HasPrefix(m.URI, "pkg:"):
+		case m.Digest["sha1"] != "":
+			l.Git = append(l.Git, lockfile.Git{
+				URL:    m.URI,
+				Commit: m.Digest["sha1"],
+			})
+		default:
+			dgst, ok := materialDigest(m)
+			if !ok {
+				continue
+			}
+			l.HTTP = append(l.HTTP, lockfile.HTTP{
+				URL:      m.URI,
+				Checksum: dgst,
+			})
+		}
+	}
+	if err := l.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid materials in the provenance")
+	}
+	l.Sort()
+	return l, nil
+}
+
+func materialDigest(m slsa.ProvenanceMaterial) (digest.Digest, bool) {
+	for _, alg := range []digest.Algorithm{digest.SHA256, digest.SHA384, digest.SHA512} {
+		if v, ok := m.Digest[alg.String()]; ok {
+			return digest.NewDigestFromEncoded(alg, v), true
+		}
+	}
+	return "", false
+}
+
+// lockfileImageRef returns the reference of an image of the lockfile, the
+// images referenced only by digest are already pinned and are skipped
+func lockfileImageRef(ref string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid image reference %s", ref)
+	}
+	if _, ok := named.(reference.Canonical); ok {
+		tagged, ok := named.(reference.Tagged)
+		if !ok {
+			return "", nil
+		}
+		if named, err = reference.WithTag(reference.TrimNamed(named), tagged.Tag()); err != nil {
+			return "", errors.WithStack(err)
+		}
+	}
+	return reference.TagNameOnly(named).String(), nil
+}
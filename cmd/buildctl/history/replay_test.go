@@ -0,0 +1,181 @@
+package history
+
+import (
+	"encoding/json"
+	"testing"
+
+	slsa "github.com/in-toto/in-toto-golang/in_toto/slsa_provenance/common"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	provenancetypes "github.com/moby/buildkit/solver/llbsolver/provenance/types"
+	"github.com/moby/buildkit/solver/pb"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func testSteps() []provenancetypes.BuildStep {
+	return []provenancetypes.BuildStep{
+		{
+			ID: "step0",
+			Op: &pb.Op{Op: &pb.Op_Source{Source: &pb.SourceOp{Identifier: "docker-image://docker.io/library/alpine:latest"}}},
+		},
+		{
+			ID: "step1",
+			Op: &pb.Op{Op: &pb.Op_Source{Source: &pb.SourceOp{Identifier: "local://context"}}},
+		},
+		{
+			ID: "step2",
+			Op: &pb.Op{Op: &pb.Op_Exec{Exec: &pb.ExecOp{
+				Meta: &pb.Meta{Args: []string{"true"}},
+				Mounts: []*pb.Mount{
+					{Input: 0, Dest: "/", Output: 0},
+					{Input: 1, Dest: "/src", Output: -1},
+				},
+			}}},
+			Inputs: []string{"step0:0", "step1:0"},
+		},
+		{
+			ID:     "step3",
+			Op:     &pb.Op{},
+			Inputs: []string{"step2:0"},
+		},
+	}
+}
+
+func TestDefinitionFromSteps(t *testing.T) {
+	steps := testSteps()
+	def, err := definitionFromSteps(steps)
+	require.NoError(t, err)
+	require.Len(t, def.Def, 4)
+
+	var dgsts []digest.Digest
+	var ops []*pb.Op
+	for _, dt := range def.Def {
+		var op pb.Op
+		require.NoError(t, op.UnmarshalVT(dt))
+		dgsts = append(dgsts, digest.FromBytes(dt))
+		ops = append(ops, &op)
+	}
+	require.Equal(t, "true", ops[2].GetExec().Meta.Args[0])
+	require.Equal(t, []*pb.Input{{Digest: string(dgsts[0])}, {Digest: string(dgsts[1])}}, ops[2].Inputs)
+	require.Equal(t, []*pb.Input{{Digest: string(dgsts[2])}}, ops[3].Inputs)
+
+	require.NoError(t, ignoreCache(def, steps, []string{"step2"}))
+	require.False(t, def.Metadata[string(dgsts[0])].IgnoreCache)
+	require.True(t, def.Metadata[string(dgsts[2])].IgnoreCache)
+
+	require.NoError(t, ignoreCache(def, steps, []string{"all"}))
+	require.True(t, def.Metadata[string(dgsts[0])].IgnoreCache)
+	require.False(t, def.Metadata[string(dgsts[3])].IgnoreCache)
+
+	require.ErrorContains(t, ignoreCache(def, steps, []string{"step9"}), "no step step9")
+
+	steps[2].Inputs = []string{"step5:0"}
+	_, err = definitionFromSteps(steps)
+	require.ErrorContains(t, err, "unknown input")
+}
+
+func TestLockfileFromMaterials(t *testing.T) {
+	l, err := lockfileFromMaterials([]slsa.ProvenanceMaterial{
+		{
+			URI:    "pkg:docker/alpine@latest?platform=linux%2Famd64",
+			Digest: slsa.DigestSet{"sha256": "4f36a8356f0e4c4b4b0b0c1cd0f9e09e6f3d0d05f0cbcd77d2adf0c1e7b9a9b1"},
+		},
+		{
+			// pinned by digest already
+			URI:    "pkg:docker/busybox@sha256:4f36a8356f0e4c4b4b0b0c1cd0f9e09e6f3d0d05f0cbcd77d2adf0c1e7b9a9b1",
+			Digest: slsa.DigestSet{"sha256": "4f36a8356f0e4c4b4b0b0c1cd0f9e09e6f3d0d05f0cbcd77d2adf0c1e7b9a9b1"},
+		},
+		{
+			URI:    "pkg:oci/local",
+			Digest: slsa.DigestSet{"sha256": "4f36a8356f0e4c4b4b0b0c1cd0f9e09e6f3d0d05f0cbcd77d2adf0c1e7b9a9b1"},
+		},
+		{
+			URI:    "https://github.com/moby/buildkit.git#v0.12.0",
+			Digest: slsa.DigestSet{"sha1": "18fc875d9bfd6e065cd8211abc639434ba65aa56"},
+		},
+		{
+			URI:    "https://example.com/file.tar",
+			Digest: slsa.DigestSet{"sha256": "4f36a8356f0e4c4b4b0b0c1cd0f9e09e6f3d0d05f0cbcd77d2adf0c1e7b9a9b1"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, l.Images, 1)
+	require.Equal(t, "docker.io/library/alpine:latest", l.Images[0].Ref)
+	require.Equal(t, "amd64", l.Images[0].Platform.Architecture)
+	require.Len(t, l.Git, 1)
+	require.Equal(t, "18fc875d9bfd6e065cd8211abc639434ba65aa56", l.Git[0].Commit)
+	require.Len(t, l.HTTP, 1)
+	require.Equal(t, "https://example.com/file.tar", l.HTTP[0].URL)
+}
+
+func TestNewReplayRequest(t *testing.T) {
+	rec := &controlapi.BuildHistoryRecord{
+		Ref:      "ref1",
+		Frontend: "dockerfile.v0",
+		FrontendAttrs: map[string]string{
+			"target":            "foo",
+			"attest:provenance": "mode=max",
+		},
+	}
+	pred := &provenancetypes.ProvenancePredicateSLSA02{
+		Invocation: provenancetypes.ProvenanceInvocationSLSA02{
+			Parameters: provenancetypes.Parameters{
+				Locals: []*provenancetypes.LocalSource{{Name: "context"}},
+			},
+		},
+		BuildConfig: &provenancetypes.BuildConfig{Definition: testSteps()},
+	}
+	pred.Materials = []slsa.ProvenanceMaterial{{
+		URI:    "pkg:docker/alpine@latest",
+		Digest: slsa.DigestSet{"sha256": "4f36a8356f0e4c4b4b0b0c1cd0f9e09e6f3d0d05f0cbcd77d2adf0c1e7b9a9b1"},
+	}}
+
+	req, err := newReplayRequest(rec, pred, replayOpt{noCache: []string{"step2"}})
+	require.NoError(t, err)
+	require.NotNil(t, req.def)
+	require.Empty(t, req.frontend)
+	require.Equal(t, map[string]string{"attest:provenance": "mode=max"}, req.frontendAttrs)
+	require.Equal(t, []string{"context"}, req.locals)
+	require.Len(t, req.sourcePolicy.Rules, 1)
+	require.Equal(t, spb.PolicyAction_CONVERT, req.sourcePolicy.Rules[0].Action)
+	require.Equal(t, "docker-image://docker.io/library/alpine:latest", req.sourcePolicy.Rules[0].Selector.Identifier)
+
+	req, err = newReplayRequest(rec, pred, replayOpt{rerunFrontend: true, noPin: true, noCache: []string{"all"}})
+	require.NoError(t, err)
+	require.Nil(t, req.def)
+	require.Nil(t, req.sourcePolicy)
+	require.Equal(t, "dockerfile.v0", req.frontend)
+	require.Equal(t, map[string]string{"target": "foo", "attest:provenance": "mode=max", "no-cache": ""}, req.frontendAttrs)
+
+	_, err = newReplayRequest(rec, pred, replayOpt{rerunFrontend: true, noCache: []string{"step2"}})
+	require.ErrorContains(t, err, "mode=max")
+
+	rec.Frontend = ""
+	pred.BuildConfig = nil
+	_, err = newReplayRequest(rec, pred, replayOpt{})
+	require.ErrorContains(t, err, "no frontend")
+}
+
+func TestParseProvenance(t *testing.T) {
+	pred := &provenancetypes.ProvenancePredicateSLSA1{}
+	pred.BuildDefinition.ExternalParameters.Request.Frontend = "dockerfile.v0"
+	pred.BuildDefinition.InternalParameters.BuildConfig = &provenancetypes.BuildConfig{Definition: testSteps()}
+	dt, err := json.Marshal(pred)
+	require.NoError(t, err)
+	dt, err = json.Marshal(map[string]any{
+		"_type":         "https://in-toto.io/Statement/v0.1",
+		"predicateType": "https://slsa.dev/provenance/v1",
+		"predicate":     json.RawMessage(dt),
+	})
+	require.NoError(t, err)
+
+	p, err := parseProvenance(dt)
+	require.NoError(t, err)
+	require.Equal(t, "dockerfile.v0", p.Invocation.Parameters.Frontend)
+	require.Len(t, p.BuildConfig.Definition, 4)
+	require.Equal(t, "local://context", p.BuildConfig.Definition[1].Op.GetSource().Identifier)
+
+	_, err = parseProvenance([]byte(`{"predicateType":"https://spdx.dev/Document"}`))
+	require.ErrorContains(t, err, "unsupported")
+}
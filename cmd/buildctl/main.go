@@ -90,6 +90,11 @@ func main() {
 			Usage: "directory containing CA certificate, client certificate, and client key. Supported file names are (ca.pem, cert.pem, key.pem) or (ca.crt, tls.crt, tls.key)",
 			Value: "",
 		},
+		cli.StringFlag{
+			Name:   "token-file",
+			Usage:  "file containing a bearer token to authenticate with. The file is read for every request so that rotated tokens are picked up",
+			EnvVar: "BUILDKIT_TOKEN_FILE",
+		},
 		cli.IntFlag{
 			Name:  "timeout",
 			Usage: "timeout backend connection after value seconds",
@@ -105,8 +110,13 @@ func main() {
 		diskUsageCommand,
 		pruneCommand,
 		pruneHistoriesCommand,
+		verifyCacheCommand,
+		reloadConfigCommand,
+		drainCommand,
+		resultLeaseCommand,
 		buildCommand,
 		debugCommand,
+		historyCommand,
 		dialStdioCommand,
 	}
 
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/appcontext"
+	"github.com/urfave/cli"
+)
+
+var reloadConfigCommand = cli.Command{
+	Name:   "reload-config",
+	Usage:  "reload the config file of buildkitd without restarting it",
+	Action: reloadConfig,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Format the output using the given Go template, e.g, '{{json .}}'",
+		},
+	},
+}
+
+func reloadConfig(clicontext *cli.Context) error {
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.ReloadConfig(appcontext.Context())
+	if err != nil {
+		return err
+	}
+
+	if format := clicontext.String("format"); format != "" {
+		tmpl, err := bccommon.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(clicontext.App.Writer, res); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(clicontext.App.Writer, "\n")
+		return err
+	}
+
+	if len(res.Applied) == 0 && len(res.RequiresRestart) == 0 {
+		fmt.Fprintln(clicontext.App.Writer, "No changes")
+		return nil
+	}
+	for _, s := range res.Applied {
+		fmt.Fprintf(clicontext.App.Writer, "applied: %s\n", s)
+	}
+	for _, s := range res.RequiresRestart {
+		fmt.Fprintf(clicontext.App.Writer, "requires restart: %s\n", s)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/appcontext"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+var resultLeaseCommand = cli.Command{
+	Name:  "result-lease",
+	Usage: "manage the leases keeping build results from being pruned",
+	Subcommands: []cli.Command{
+		{
+			Name:   "ls",
+			Usage:  "list result leases",
+			Action: listResultLeases,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Usage: "Format the output using the given Go template, e.g, '{{json .}}'",
+				},
+			},
+		},
+		{
+			Name:      "renew",
+			Usage:     "extend a result lease",
+			ArgsUsage: "ID",
+			Action:    renewResultLease,
+			Flags: []cli.Flag{
+				cli.DurationFlag{
+					Name:  "ttl",
+					Usage: "Duration of the lease from now",
+					Value: time.Hour,
+				},
+			},
+		},
+		{
+			Name:      "release",
+			Usage:     "release a result lease",
+			ArgsUsage: "ID",
+			Action:    releaseResultLease,
+		},
+	},
+}
+
+func listResultLeases(clicontext *cli.Context) error {
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	leases, err := c.ListResultLeases(appcontext.Context())
+	if err != nil {
+		return err
+	}
+
+	if format := clicontext.String("format"); format != "" {
+		tmpl, err := bccommon.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(clicontext.App.Writer, leases); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(clicontext.App.Writer, "\n")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
+	printResultLeases(tw, leases)
+	return nil
+}
+
+func printResultLeases(tw *tabwriter.Writer, leases []*client.ResultLease) {
+	fmt.Fprintln(tw, "ID\tBUILD\tEXPIRES\tRECORDS")
+	for _, l := range leases {
+		var records []string
+		for _, r := range l.Records {
+			if r.Key != "" {
+				records = append(records, r.Key+"="+r.ID)
+			} else {
+				records = append(records, r.ID)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", l.ID, l.Ref, l.ExpiresAt.Local().Format(time.RFC3339), strings.Join(records, ","))
+	}
+	tw.Flush()
+}
+
+func renewResultLease(clicontext *cli.Context) error {
+	if clicontext.NArg() != 1 {
+		return errors.New("renew requires exactly one lease ID")
+	}
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+	l, err := c.RenewResultLease(appcontext.Context(), clicontext.Args().First(), clicontext.Duration("ttl"))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(clicontext.App.Writer, "%s expires at %s\n", l.ID, l.ExpiresAt.Local().Format(time.RFC3339))
+	return nil
+}
+
+func releaseResultLease(clicontext *cli.Context) error {
+	if clicontext.NArg() != 1 {
+		return errors.New("release requires exactly one lease ID")
+	}
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+	return c.ReleaseResultLease(appcontext.Context(), clicontext.Args().First())
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/moby/buildkit/client"
+	bccommon "github.com/moby/buildkit/cmd/buildctl/common"
+	"github.com/moby/buildkit/util/appcontext"
+	"github.com/urfave/cli"
+)
+
+var verifyCacheCommand = cli.Command{
+	Name:   "verify-cache",
+	Usage:  "check build cache records against the content store and snapshotter",
+	Action: verifyCache,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "repair",
+			Usage: "Remove inconsistent records and leases that are not in use",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Usage: "Format the output using the given Go template, e.g, '{{json .}}'",
+		},
+	},
+}
+
+func verifyCache(clicontext *cli.Context) error {
+	c, err := bccommon.ResolveClient(clicontext)
+	if err != nil {
+		return err
+	}
+
+	issues, err := c.VerifyCache(appcontext.Context(), clicontext.Bool("repair"))
+	if err != nil {
+		return err
+	}
+
+	if format := clicontext.String("format"); format != "" {
+		tmpl, err := bccommon.ParseTemplate(format)
+		if err != nil {
+			return err
+		}
+		if err := tmpl.Execute(clicontext.App.Writer, issues); err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(clicontext.App.Writer, "\n")
+		return err
+	}
+
+	if len(issues) == 0 {
+		fmt.Fprintln(clicontext.App.Writer, "No inconsistencies found")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
+	printCacheIssues(tw, issues)
+	return nil
+}
+
+func printCacheIssues(tw *tabwriter.Writer, issues []*client.CacheIssue) {
+	fmt.Fprintln(tw, "ID\tTYPE\tREPAIRED\tDESCRIPTION")
+	for _, i := range issues {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\n", i.ID, i.Type, i.Repaired, i.Description)
+	}
+	tw.Flush()
+}
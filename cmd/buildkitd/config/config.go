@@ -12,15 +12,30 @@ type Config struct {
 	// Root is the path to a directory where buildkit will store persistent data
 	Root string `toml:"root"`
 
-	// Entitlements e.g. security.insecure, network.host, device
+	// ReadOnly serves the cache and the content of the daemon to clients
+	// without executing ops or changing the store
+	ReadOnly bool `toml:"readOnly"`
+
+	// DrainTimeout is the duration a drain waits for the running builds
+	// before canceling them, without a timeout if it is 0
+	DrainTimeout Duration `toml:"drainTimeout"`
+
+	// Entitlements e.g. security.insecure, network.host, device, local.exec, mount.host
 	Entitlements []string `toml:"insecure-entitlements"`
 
+	// Identities configures settings per authenticated client identity,
+	// keyed by identity name (e.g. the common name of a TLS client certificate)
+	Identities map[string]IdentityConfig `toml:"identity"`
+
 	// LogFormat is the format of the logs. It can be "json" or "text".
 	Log LogConfig `toml:"log"`
 
 	// GRPC configuration settings
 	GRPC GRPCConfig `toml:"grpc"`
 
+	// Authorization configures an external webhook authorizing control API requests
+	Authorization *AuthorizationConfig `toml:"authorization"`
+
 	OTEL OTELConfig `toml:"otel"`
 
 	CDI CDIConfig `toml:"cdi"`
@@ -31,17 +46,69 @@ type Config struct {
 	} `toml:"worker"`
 
 	Registries map[string]resolverconfig.RegistryConfig `toml:"registry"`
+	// AllowedCredentialHelpers lists the credential helpers that registries
+	// may be configured to use
+	AllowedCredentialHelpers []string `toml:"allowedCredentialHelpers"`
 
 	DNS *DNSConfig `toml:"dns"`
 
 	History *HistoryConfig `toml:"history"`
 
+	Solver *SolverConfig `toml:"solver"`
+
+	CacheVerify *CacheVerifyConfig `toml:"cacheVerify"`
+
+	// LeaderElection elects the daemon running the garbage collection and
+	// prune among the daemons sharing a store
+	LeaderElection *LeaderElectionConfig `toml:"leaderElection"`
+
+	// Webhooks receive build events
+	Webhooks []WebhookConfig `toml:"webhook"`
+
+	// Exporters configures the external exporters by exporter type
+	Exporters map[string]PluginConfig `toml:"exporter"`
+
+	// Sources configures the external sources by identifier scheme
+	Sources map[string]PluginConfig `toml:"source"`
+
 	Frontends struct {
 		Dockerfile DockerfileFrontendConfig `toml:"dockerfile.v0"`
+		Starlark   StarlarkFrontendConfig   `toml:"starlark.v0"`
 		Gateway    GatewayFrontendConfig    `toml:"gateway.v0"`
 	} `toml:"frontend"`
 
 	System *SystemConfig `toml:"system"`
+
+	// Compression configures the compression of the layers by all the
+	// workers of the daemon
+	Compression *CompressionConfig `toml:"compression"`
+
+	// CacheEvents appends the build cache events of the daemon to a log file
+	CacheEvents *CacheEventsConfig `toml:"cacheEvents"`
+}
+
+type CacheEventsConfig struct {
+	// Path is the log file, cache-events.log in the root directory if it is
+	// not set
+	Path string `toml:"path"`
+	// Format is "json" or "otlp", "json" by default
+	Format string `toml:"format"`
+	// MaxSize rotates the file before it grows larger
+	MaxSize DiskSpace `toml:"maxSize"`
+	// MaxAge rotates the file once it has been written for the duration
+	MaxAge Duration `toml:"maxAge"`
+	// MaxFiles is the number of rotated files that are kept, 5 by default
+	MaxFiles int `toml:"maxFiles"`
+}
+
+type CompressionConfig struct {
+	// Parallelism is the maximum number of layers that are compressed or
+	// converted at the same time, the number of CPUs if it is zero and not
+	// limited if it is negative
+	Parallelism int `toml:"parallelism"`
+	// ZstdLevel is the zstd level of the layers exported without a
+	// compression-level
+	ZstdLevel *int `toml:"zstdLevel"`
 }
 
 type SystemConfig struct {
@@ -52,16 +119,55 @@ type SystemConfig struct {
 
 type LogConfig struct {
 	Format string `toml:"format"`
+
+	// Steps configures the limits of the output of the steps sent to clients
+	Steps *StepLogConfig `toml:"steps"`
+
+	// Sinks ship the output of the steps of every build to external log
+	// services
+	Sinks []LogSinkConfig `toml:"sink"`
+}
+
+type LogSinkConfig struct {
+	// Type is "loki", "cloudwatch" or "http"
+	Type string `toml:"type"`
+	// Endpoint is the URL the logs are sent to. It is optional for
+	// cloudwatch sinks.
+	Endpoint string `toml:"endpoint"`
+	// Headers are added to the requests of loki and http sinks
+	Headers map[string]string `toml:"headers"`
+	// Labels are added to the streams of loki sinks
+	Labels map[string]string `toml:"labels"`
+	// Region, LogGroup and LogStream configure cloudwatch sinks
+	Region    string    `toml:"region"`
+	LogGroup  string    `toml:"logGroup"`
+	LogStream string    `toml:"logStream"`
+	Timeout   Duration  `toml:"timeout"`
+	TLS       TLSConfig `toml:"tls"`
+}
+
+type StepLogConfig struct {
+	// MaxSize is the maximum number of bytes of a stream of a step. -1
+	// disables the limit.
+	MaxSize int `toml:"maxSize"`
+	// MaxSpeed is the maximum number of bytes per second of a stream of a
+	// step. -1 disables the limit.
+	MaxSpeed int `toml:"maxSpeed"`
+	// SpillToHistory keeps the output dropped because of the limits in the
+	// step logs of the build history
+	SpillToHistory bool `toml:"spillToHistory"`
 }
 
 type GRPCConfig struct {
 	Address            []string `toml:"address"`
 	DebugAddress       string   `toml:"debugAddress"`
+	MetricsAddress     string   `toml:"metricsAddress"`
 	UID                *int     `toml:"uid"`
 	GID                *int     `toml:"gid"`
 	SecurityDescriptor string   `toml:"securityDescriptor"`
 
-	TLS TLSConfig `toml:"tls"`
+	TLS  TLSConfig   `toml:"tls"`
+	OIDC *OIDCConfig `toml:"oidc"`
 	// MaxRecvMsgSize int    `toml:"max_recv_message_size"`
 	// MaxSendMsgSize int    `toml:"max_send_message_size"`
 }
@@ -72,6 +178,41 @@ type TLSConfig struct {
 	CA   string `toml:"ca"`
 }
 
+type OIDCConfig struct {
+	// Issuer is the URL of the OpenID Connect provider issuing tokens
+	Issuer    string   `toml:"issuer"`
+	Audiences []string `toml:"audiences"`
+	// JWKSURL overrides the key set URL discovered from the issuer
+	JWKSURL string `toml:"jwksURL"`
+	// UsernameClaim is the token claim used as client identity, "sub" by default
+	UsernameClaim string `toml:"usernameClaim"`
+	// Required rejects clients that authenticate with neither a token nor
+	// a TLS client certificate
+	Required bool `toml:"required"`
+}
+
+type AuthorizationConfig struct {
+	// Endpoint is the HTTP(S) URL of the authorization webhook
+	Endpoint string   `toml:"endpoint"`
+	Timeout  Duration `toml:"timeout"`
+	// FailOpen allows requests when the webhook can't be reached
+	FailOpen bool      `toml:"failOpen"`
+	TLS      TLSConfig `toml:"tls"`
+}
+
+type IdentityConfig struct {
+	// Method is the authentication method the identity is established with,
+	// "tls" (default) or "oidc". Settings don't apply to a client with the
+	// same name authenticated with another method.
+	Method string `toml:"method"`
+	// Entitlements are allowed for the identity in addition to the
+	// insecure-entitlements allowed for all clients
+	Entitlements []string `toml:"entitlements"`
+	// Quota is the maximum size of the cache created by the clients with the
+	// name of the identity. Builds of the identity fail when it is reached.
+	Quota DiskSpace `toml:"quota"`
+}
+
 type OTELConfig struct {
 	SocketPath string `toml:"socketPath"`
 }
@@ -118,6 +259,10 @@ type OCIConfig struct {
 	ProxySnapshotterPath string `toml:"proxySnapshotterPath"`
 	DefaultCgroupParent  string `toml:"defaultCgroupParent"`
 
+	// SharedContentRoot is the directory of a content store shared with the
+	// OCI workers of other daemons on the same host.
+	SharedContentRoot string `toml:"sharedContentRoot"`
+
 	// StargzSnapshotterConfig is configuration for stargz snapshotter.
 	// We use a generic map[string]interface{} in order to remove the dependency
 	// on stargz snapshotter's config pkg from our config.
@@ -132,6 +277,10 @@ type OCIConfig struct {
 
 	// MaxParallelism is the maximum number of parallel build steps that can be run at the same time.
 	MaxParallelism int `toml:"max-parallelism"`
+
+	// UnpackConcurrency is the maximum number of layers of an image that are
+	// decompressed while the layers below them are still being extracted.
+	UnpackConcurrency int `toml:"unpack-concurrency"`
 }
 
 type ContainerdConfig struct {
@@ -154,6 +303,10 @@ type ContainerdConfig struct {
 
 	MaxParallelism int `toml:"max-parallelism"`
 
+	// UnpackConcurrency is the maximum number of layers of an image that are
+	// decompressed while the layers below them are still being extracted.
+	UnpackConcurrency int `toml:"unpack-concurrency"`
+
 	DefaultCgroupParent string `toml:"defaultCgroupParent"`
 
 	Rootless bool `toml:"rootless"`
@@ -195,18 +348,164 @@ type DNSConfig struct {
 	Nameservers   []string `toml:"nameservers"`
 	Options       []string `toml:"options"`
 	SearchDomains []string `toml:"searchDomains"`
+
+	// Listen is the IP address the DNS forwarder of the exec ops serves on
+	// port 53. The forwarder replaces the nameservers of the exec ops if
+	// Upstreams or Domains are set.
+	Listen string `toml:"listen"`
+	// Upstreams are the servers the forwarder sends the queries to, e.g.
+	// "tls://1.1.1.1#cloudflare-dns.com" or "https://dns.google/dns-query".
+	// The nameservers of the host are used if only Domains are set.
+	Upstreams []string `toml:"upstreams"`
+	// Domains are the upstreams of the names of specific domains
+	Domains map[string][]string `toml:"domains"`
+	// Resolvers are additional forwarders the builds select by name with the
+	// dns-resolver build option
+	Resolvers map[string]DNSResolverConfig `toml:"resolvers"`
+}
+
+type DNSResolverConfig struct {
+	Listen    string              `toml:"listen"`
+	Upstreams []string            `toml:"upstreams"`
+	Domains   map[string][]string `toml:"domains"`
+}
+
+type WebhookConfig struct {
+	// Endpoint is the HTTP(S) URL events are POSTed to
+	Endpoint string `toml:"endpoint"`
+	// Secret signs the payloads with HMAC-SHA256
+	Secret string `toml:"secret"`
+	// Events limits the delivered event types, all events are delivered by default
+	Events  []string  `toml:"events"`
+	Timeout Duration  `toml:"timeout"`
+	TLS     TLSConfig `toml:"tls"`
+}
+
+type PluginConfig struct {
+	// Address is the unix:// or tcp:// address of the plugin serving the
+	// gRPC service of the plugin type
+	Address string `toml:"address"`
+	// Timeout limits the duration of the calls to the plugin
+	Timeout Duration  `toml:"timeout"`
+	TLS     TLSConfig `toml:"tls"`
 }
 
 type HistoryConfig struct {
 	MaxAge     Duration `toml:"maxAge"`
 	MaxEntries int64    `toml:"maxEntries"`
+	// LogsMaxAge is the duration the logs of every step are kept for with a
+	// build record. Zero keeps the logs for as long as the record.
+	LogsMaxAge Duration `toml:"logsMaxAge"`
+	// Timestamps normalizes the timestamps of the logs and the provenance kept
+	// with the build records: "keep", "strip" or "source-date-epoch"
+	Timestamps string `toml:"timestamps"`
+	// MaxSize is the maximum total size of the blobs of the records. The
+	// oldest records are removed when it is exceeded.
+	MaxSize DiskSpace `toml:"maxSize"`
+	// Outcomes configures the retention of the records by the status of the
+	// build: "completed", "error" or "canceled"
+	Outcomes map[string]HistoryOutcomeConfig `toml:"outcome"`
+	// Clients limits the records kept for every client identity
+	Clients *HistoryClientConfig `toml:"client"`
+}
+
+type HistoryOutcomeConfig struct {
+	// MaxAge removes the records of the outcome that completed before the
+	// duration, whatever the number of records
+	MaxAge Duration `toml:"maxAge"`
+	// MaxEntriesPerFrontend is the maximum number of records of the outcome
+	// kept for every frontend
+	MaxEntriesPerFrontend int64 `toml:"maxEntriesPerFrontend"`
+}
+
+type HistoryClientConfig struct {
+	// MaxEntries is the maximum number of records kept for a client
+	MaxEntries int64 `toml:"maxEntries"`
+	// MaxSize is the maximum total size of the blobs of the records of a
+	// client
+	MaxSize DiskSpace `toml:"maxSize"`
+}
+
+type CacheVerifyConfig struct {
+	// Interval is the time between background verifications of the cache
+	// records against the content store and the snapshotter. Zero disables
+	// the verification.
+	Interval Duration `toml:"interval"`
+	// Repair removes the inconsistent records and leases that are found
+	Repair bool `toml:"repair"`
+}
+
+type LeaderElectionConfig struct {
+	// LockFile is the path of the lock file of the election, on the storage
+	// shared by the daemons
+	LockFile string `toml:"lockFile"`
+	// ID identifies the daemon in the election, the hostname by default
+	ID string `toml:"id"`
+}
+
+type SolverConfig struct {
+	// SpeculativeExecution is the maximum number of vertices that are
+	// started early because they were executed by previous builds of the
+	// same graph. Zero disables speculative execution.
+	SpeculativeExecution int `toml:"speculativeExecution"`
+	// Limits is the maximum number of operations of each type that can run
+	// at the same time. Builds can override the limits.
+	Limits ConcurrencyLimits `toml:"limits"`
+	// Retry configures the retries of the steps that fail with a transient
+	// error
+	Retry RetryConfig `toml:"retry"`
+}
+
+// RetryConfig is the policy for retrying the steps that fail with transient
+// errors such as registry server errors, network timeouts and temporarily
+// unavailable resources
+type RetryConfig struct {
+	// Attempts is the maximum number of retries of a step. Zero disables the
+	// retries.
+	Attempts int `toml:"attempts"`
+	// Backoff is the delay before the first retry. It doubles for every
+	// retry up to MaxBackoff.
+	Backoff    Duration `toml:"backoff"`
+	MaxBackoff Duration `toml:"maxBackoff"`
+}
+
+// ConcurrencyLimits are the maximum number of operations of a type that run
+// at the same time. Zero values are not limited.
+type ConcurrencyLimits struct {
+	Exec      int `toml:"exec"`
+	ImagePull int `toml:"imagePull"`
+	LocalSync int `toml:"localSync"`
 }
 
 type DockerfileFrontendConfig struct {
 	Enabled *bool `toml:"enabled"`
 }
 
+type StarlarkFrontendConfig struct {
+	Enabled *bool `toml:"enabled"`
+}
+
 type GatewayFrontendConfig struct {
 	Enabled             *bool    `toml:"enabled"`
 	AllowedRepositories []string `toml:"allowedRepositories"`
+	// RequirePinned rejects the frontend images that are not pinned by
+	// digest, after the channels are resolved
+	RequirePinned bool `toml:"requirePinned"`
+	// Channels pins the frontend images with a tag, like
+	// "docker/dockerfile:labs", to a vetted digest
+	Channels map[string]string `toml:"channels"`
+	// Grants are the access granted to the frontends of the repositories,
+	// the frontends run without network, local named contexts and
+	// capabilities otherwise
+	Grants map[string]GatewayGrantConfig `toml:"grants"`
+}
+
+type GatewayGrantConfig struct {
+	// Network runs the frontends with the network of the worker
+	Network bool `toml:"network"`
+	// LocalContexts allows the "local:" and "oci-layout://" named contexts
+	// to be passed to the frontends
+	LocalContexts bool `toml:"localContexts"`
+	// Capabilities are the Linux capabilities kept by the frontends
+	Capabilities []string `toml:"capabilities"`
 }
@@ -24,13 +24,44 @@ debugAddress="debug.sock"
 gid=1234
 [grpc.tls]
 cert="mycert.pem"
+[grpc.oidc]
+issuer="https://issuer.example.com"
+audiences=["buildkit"]
+
+[identity."ci-runner"]
+entitlements=["network.host"]
+quota="10GB"
 
 [otel]
 socketPath="/tmp/otel-grpc.sock"
 
+[[webhook]]
+endpoint="https://hooks.example.com/buildkit"
+secret="s3cret"
+events=["build.failed"]
+timeout="5s"
+
+[cacheEvents]
+format="otlp"
+maxSize="10MB"
+maxAge="12h"
+
+[[log.sink]]
+type="loki"
+endpoint="http://loki:3100/loki/api/v1/push"
+labels={ "host"="builder-1" }
+
+[exporter."artifacts"]
+address="unix:///run/artifacts.sock"
+timeout="1m"
+
+[source."hg"]
+address="tcp://localhost:7000"
+
 [worker.oci]
 enabled=true
 snapshotter="overlay"
+proxySnapshotterPath="/run/snapshotter.sock"
 rootless=true
 gc=false
 gckeepstorage=123456789
@@ -76,6 +107,13 @@ cert="cert.pem"
 nameservers=["1.1.1.1","8.8.8.8"]
 options=["edns0"]
 searchDomains=["example.com"]
+listen="10.10.0.1"
+upstreams=["tls://1.1.1.1#cloudflare-dns.com"]
+[dns.domains]
+"corp.example.com"=["10.0.0.53"]
+[dns.resolvers.doh]
+listen="127.0.0.154"
+upstreams=["https://dns.google/dns-query"]
 `
 
 	cfg, err := Load(bytes.NewBuffer([]byte(testConfig)))
@@ -92,13 +130,39 @@ searchDomains=["example.com"]
 	require.NotNil(t, cfg.GRPC.GID)
 	require.Equal(t, 1234, *cfg.GRPC.GID)
 	require.Equal(t, "mycert.pem", cfg.GRPC.TLS.Cert)
+	require.NotNil(t, cfg.GRPC.OIDC)
+	require.Equal(t, "https://issuer.example.com", cfg.GRPC.OIDC.Issuer)
+	require.Equal(t, []string{"buildkit"}, cfg.GRPC.OIDC.Audiences)
+
+	require.Equal(t, []string{"network.host"}, cfg.Identities["ci-runner"].Entitlements)
+	require.Equal(t, int64(10*1024*1024*1024), cfg.Identities["ci-runner"].Quota.Bytes)
 
 	require.Equal(t, "/tmp/otel-grpc.sock", cfg.OTEL.SocketPath)
 
+	require.Len(t, cfg.Webhooks, 1)
+	require.Equal(t, "https://hooks.example.com/buildkit", cfg.Webhooks[0].Endpoint)
+	require.Equal(t, []string{"build.failed"}, cfg.Webhooks[0].Events)
+	require.Equal(t, 5*time.Second, cfg.Webhooks[0].Timeout.Duration)
+
+	require.NotNil(t, cfg.CacheEvents)
+	require.Equal(t, "otlp", cfg.CacheEvents.Format)
+	require.Equal(t, int64(10*1024*1024), cfg.CacheEvents.MaxSize.Bytes)
+	require.Equal(t, 12*time.Hour, cfg.CacheEvents.MaxAge.Duration)
+
+	require.Len(t, cfg.Log.Sinks, 1)
+	require.Equal(t, "loki", cfg.Log.Sinks[0].Type)
+	require.Equal(t, "http://loki:3100/loki/api/v1/push", cfg.Log.Sinks[0].Endpoint)
+	require.Equal(t, map[string]string{"host": "builder-1"}, cfg.Log.Sinks[0].Labels)
+
+	require.Equal(t, "unix:///run/artifacts.sock", cfg.Exporters["artifacts"].Address)
+	require.Equal(t, time.Minute, cfg.Exporters["artifacts"].Timeout.Duration)
+	require.Equal(t, "tcp://localhost:7000", cfg.Sources["hg"].Address)
+
 	require.NotNil(t, cfg.Workers.OCI.Enabled)
 	require.Equal(t, int64(123456789), cfg.Workers.OCI.GCKeepStorage.Bytes)
 	require.Equal(t, true, *cfg.Workers.OCI.Enabled)
 	require.Equal(t, "overlay", cfg.Workers.OCI.Snapshotter)
+	require.Equal(t, "/run/snapshotter.sock", cfg.Workers.OCI.ProxySnapshotterPath)
 	require.Equal(t, true, cfg.Workers.OCI.Rootless)
 	require.Equal(t, false, *cfg.Workers.OCI.GC)
 
@@ -148,4 +212,10 @@ searchDomains=["example.com"]
 	require.Equal(t, []string{"1.1.1.1", "8.8.8.8"}, cfg.DNS.Nameservers)
 	require.Equal(t, []string{"example.com"}, cfg.DNS.SearchDomains)
 	require.Equal(t, []string{"edns0"}, cfg.DNS.Options)
+	require.Equal(t, "10.10.0.1", cfg.DNS.Listen)
+	require.Equal(t, []string{"tls://1.1.1.1#cloudflare-dns.com"}, cfg.DNS.Upstreams)
+	require.Equal(t, map[string][]string{"corp.example.com": {"10.0.0.53"}}, cfg.DNS.Domains)
+	require.Equal(t, map[string]DNSResolverConfig{
+		"doh": {Listen: "127.0.0.154", Upstreams: []string{"https://dns.google/dns-query"}},
+	}, cfg.DNS.Resolvers)
 }
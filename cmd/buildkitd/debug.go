@@ -22,13 +22,17 @@ import (
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/cachedigest"
 	"github.com/moby/buildkit/util/cachestore"
+	"github.com/moby/buildkit/util/sharedcontent"
 	digest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/net/trace"
 )
 
-var cacheStoreForDebug solver.CacheKeyStorage
+var (
+	cacheStoreForDebug    solver.CacheKeyStorage
+	sharedContentForDebug *sharedcontent.Store
+)
 
 func setupDebugHandlers(addr string) error {
 	m := http.NewServeMux()
@@ -44,6 +48,7 @@ func setupDebugHandlers(addr string) error {
 	m.Handle("/debug/cache/lookup", http.HandlerFunc(handleCacheLookup))
 	m.Handle("/debug/cache/store", http.HandlerFunc(handleDebugCacheStore))
 	m.Handle("POST /debug/cache/load", http.HandlerFunc(handleCacheLoad))
+	m.Handle("/debug/content/shared", http.HandlerFunc(handleSharedContent))
 
 	m.Handle("/debug/gc", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		runtime.GC()
@@ -287,6 +292,31 @@ func handleDebugCacheStore(w http.ResponseWriter, r *http.Request) {
 	writeCacheRecordsResponse(w, r, recs)
 }
 
+func handleSharedContent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store := sharedContentForDebug
+	if store == nil {
+		http.Error(w, "Shared content store is not enabled", http.StatusNotFound)
+		return
+	}
+
+	st, err := store.Status(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to get shared content status: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(st); err != nil {
+		http.Error(w, "Failed to encode shared content status: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func writeCacheRecordsResponse(w http.ResponseWriter, r *http.Request, recs []*recordWithDebug) {
 	w.WriteHeader(http.StatusOK)
 
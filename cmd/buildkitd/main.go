@@ -6,8 +6,11 @@ import (
 	"crypto/x509"
 	stderrors "errors"
 	"fmt"
+	"maps"
 	"net"
+	"net/netip"
 	"os"
+	"os/signal"
 	"os/user"
 	"path/filepath"
 	"runtime"
@@ -21,6 +24,7 @@ import (
 	"github.com/containerd/platforms"
 	sddaemon "github.com/coreos/go-systemd/v22/daemon"
 	"github.com/gofrs/flock"
+	controlapi "github.com/moby/buildkit/api/services/control"
 	"github.com/moby/buildkit/cache/remotecache"
 	"github.com/moby/buildkit/cache/remotecache/azblob"
 	"github.com/moby/buildkit/cache/remotecache/gha"
@@ -28,29 +32,46 @@ import (
 	localremotecache "github.com/moby/buildkit/cache/remotecache/local"
 	registryremotecache "github.com/moby/buildkit/cache/remotecache/registry"
 	s3remotecache "github.com/moby/buildkit/cache/remotecache/s3"
+	tarballremotecache "github.com/moby/buildkit/cache/remotecache/tarball"
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/cmd/buildkitd/config"
 	"github.com/moby/buildkit/control"
+	"github.com/moby/buildkit/control/authz"
+	"github.com/moby/buildkit/control/events"
+	"github.com/moby/buildkit/control/logsink"
 	"github.com/moby/buildkit/executor/oci"
+	"github.com/moby/buildkit/exporter"
+	"github.com/moby/buildkit/exporter/plugin"
 	"github.com/moby/buildkit/frontend"
 	dockerfile "github.com/moby/buildkit/frontend/dockerfile/builder"
 	"github.com/moby/buildkit/frontend/gateway"
 	"github.com/moby/buildkit/frontend/gateway/forwarder"
+	starlark "github.com/moby/buildkit/frontend/starlark/builder"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/solver/bboltcachestorage"
 	"github.com/moby/buildkit/solver/llbsolver/cdidevices"
+	sourceplugin "github.com/moby/buildkit/source/plugin"
+	srctypes "github.com/moby/buildkit/source/types"
 	"github.com/moby/buildkit/util/apicaps"
 	"github.com/moby/buildkit/util/appcontext"
 	"github.com/moby/buildkit/util/appdefaults"
 	"github.com/moby/buildkit/util/archutil"
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/cachedigest"
+	"github.com/moby/buildkit/util/cacheevents"
+	"github.com/moby/buildkit/util/clientidentity"
+	"github.com/moby/buildkit/util/compression"
 	"github.com/moby/buildkit/util/db/boltutil"
 	"github.com/moby/buildkit/util/disk"
+	"github.com/moby/buildkit/util/dnsforward"
 	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/moby/buildkit/util/grpcplugin"
 	_ "github.com/moby/buildkit/util/grpcutil/encoding/proto"
+	"github.com/moby/buildkit/util/leader"
+	"github.com/moby/buildkit/util/oidc"
 	"github.com/moby/buildkit/util/profiler"
+	"github.com/moby/buildkit/util/resolvconf"
 	"github.com/moby/buildkit/util/resolver"
 	"github.com/moby/buildkit/util/stack"
 	"github.com/moby/buildkit/util/tracing"
@@ -61,11 +82,13 @@ import (
 	"github.com/moby/buildkit/version"
 	"github.com/moby/buildkit/worker"
 	"github.com/moby/sys/userns"
+	digest "github.com/opencontainers/go-digest"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/propagation"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
@@ -90,15 +113,23 @@ func init() {
 var propagators = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
 
 type workerInitializerOpt struct {
-	config         *config.Config
-	sessionManager *session.Manager
-	traceSocket    string
+	config          *config.Config
+	sessionManager  *session.Manager
+	traceSocket     string
+	exporterPlugins map[string]exporter.Exporter
+	sourcePlugins   []*sourceplugin.Plugin
+	registryHosts   docker.RegistryHosts
 }
 
 type workerInitializer struct {
 	fn func(c *cli.Context, common workerInitializerOpt) ([]worker.Worker, error)
 	// less priority number, more preferred
 	priority int
+	// applyFlags applies the flags of the worker to the config
+	applyFlags func(c *cli.Context, cfg *config.Config) error
+	// workerConfig returns the labels and the GC config of the worker that
+	// are applied when the config is reloaded
+	workerConfig func(cfg *config.Config) (map[string]string, config.GCConfig)
 }
 
 var (
@@ -195,6 +226,12 @@ func main() {
 			Value:  defaultConf.GRPC.DebugAddress,
 			EnvVar: "BUILDKITD_DEBUGADDR",
 		},
+		cli.StringFlag{
+			Name:   "metricsaddr",
+			Usage:  "address for the prometheus metrics endpoint (eg. 0.0.0.0:9090)",
+			Value:  defaultConf.GRPC.MetricsAddress,
+			EnvVar: "BUILDKITD_METRICSADDR",
+		},
 		cli.StringFlag{
 			Name:  "tlscert",
 			Usage: "certificate file to use",
@@ -212,7 +249,7 @@ func main() {
 		},
 		cli.StringSliceFlag{
 			Name:  "allow-insecure-entitlement",
-			Usage: "allows insecure entitlements e.g. network.host, security.insecure, device",
+			Usage: "allows insecure entitlements e.g. network.host, security.insecure, device, local.exec, mount.host",
 		},
 		cli.StringFlag{
 			Name:  "otel-socket-path",
@@ -230,6 +267,10 @@ func main() {
 			Name:  "save-cache-debug",
 			Usage: "enable saving cache debug info",
 		},
+		cli.BoolFlag{
+			Name:  "read-only",
+			Usage: "serve the cache and content without executing ops or changing the store",
+		},
 	)
 	app.Flags = append(app.Flags, appFlags...)
 	app.Flags = append(app.Flags, serviceFlags()...)
@@ -244,16 +285,11 @@ func main() {
 		ctx, cancel := context.WithCancelCause(appcontext.Context())
 		defer func() { cancel(errors.WithStack(context.Canceled)) }()
 
-		cfg, err := config.LoadFile(c.GlobalString("config"))
+		cfg, err := loadConfig(c)
 		if err != nil {
 			return err
 		}
 
-		setDefaultConfig(&cfg)
-		if err := applyMainFlags(c, &cfg); err != nil {
-			return err
-		}
-
 		logFormat := cfg.Log.Format
 		switch logFormat {
 		case "json":
@@ -276,6 +312,20 @@ func main() {
 				archutil.CacheMaxAge = v.Duration
 			}
 		}
+		if err := setCompression(cfg.Compression); err != nil {
+			return err
+		}
+		cacheEventsLog, err := newCacheEventsLog(cfg)
+		if err != nil {
+			return err
+		}
+		if cacheEventsLog != nil {
+			cacheevents.SetLog(cacheEventsLog)
+			closers = append(closers, func(context.Context) error {
+				cacheevents.SetLog(nil)
+				return cacheEventsLog.Close()
+			})
+		}
 
 		if cfg.GRPC.DebugAddress != "" {
 			if err := setupDebugHandlers(cfg.GRPC.DebugAddress); err != nil {
@@ -294,18 +344,56 @@ func main() {
 			return err
 		}
 		closers = append(closers, mp.Shutdown)
+		otel.SetMeterProvider(mp)
+
+		if cfg.GRPC.MetricsAddress != "" {
+			if err := setupMetricsHandler(cfg.GRPC.MetricsAddress); err != nil {
+				return err
+			}
+		}
 
 		statsHandler := tracing.ServerStatsHandler(
 			otelgrpc.WithTracerProvider(tp),
 			otelgrpc.WithMeterProvider(mp),
 			otelgrpc.WithPropagators(propagators),
 		)
+		unaryInterceptors := []grpc.UnaryServerInterceptor{unaryInterceptor, grpcerrors.UnaryServerInterceptor}
+		streamInterceptors := []grpc.StreamServerInterceptor{grpcerrors.StreamServerInterceptor}
+		if oc := cfg.GRPC.OIDC; oc != nil && oc.Issuer != "" {
+			verifier, err := oidc.NewVerifier(oidc.Config{
+				Issuer:        oc.Issuer,
+				Audiences:     oc.Audiences,
+				JWKSURL:       oc.JWKSURL,
+				UsernameClaim: oc.UsernameClaim,
+			})
+			if err != nil {
+				return err
+			}
+			unaryInterceptors = append(unaryInterceptors, oidc.UnaryServerInterceptor(verifier, oc.Required))
+			streamInterceptors = append(streamInterceptors, oidc.StreamServerInterceptor(verifier, oc.Required))
+		}
+		if cfg.Authorization != nil && cfg.Authorization.Endpoint != "" {
+			authorizer, err := authz.NewWebhookAuthorizer(authz.WebhookOpt{
+				Endpoint: cfg.Authorization.Endpoint,
+				Timeout:  cfg.Authorization.Timeout.Duration,
+				FailOpen: cfg.Authorization.FailOpen,
+				CA:       cfg.Authorization.TLS.CA,
+				Cert:     cfg.Authorization.TLS.Cert,
+				Key:      cfg.Authorization.TLS.Key,
+			})
+			if err != nil {
+				return err
+			}
+			unaryInterceptors = append(unaryInterceptors, authz.UnaryServerInterceptor(authorizer))
+			streamInterceptors = append(streamInterceptors, authz.StreamServerInterceptor(authorizer))
+		}
 		opts := []grpc.ServerOption{
 			grpc.StatsHandler(statsHandler),
-			grpc.ChainUnaryInterceptor(unaryInterceptor, grpcerrors.UnaryServerInterceptor),
-			grpc.StreamInterceptor(grpcerrors.StreamServerInterceptor),
+			grpc.ChainUnaryInterceptor(unaryInterceptors...),
+			grpc.ChainStreamInterceptor(streamInterceptors...),
 			grpc.MaxRecvMsgSize(defaults.DefaultMaxRecvMsgSize),
 			grpc.MaxSendMsgSize(defaults.DefaultMaxSendMsgSize),
+			grpc.Creds(clientidentity.ServerCredentials()),
 		}
 		server := grpc.NewServer(opts...)
 
@@ -365,7 +453,8 @@ func main() {
 		}
 		defer controller.Close()
 
-		healthv1.RegisterHealthServer(server, health.NewServer())
+		healthServer := health.NewServer()
+		healthv1.RegisterHealthServer(server, healthServer)
 		controller.Register(server)
 		reflection.Register(server)
 
@@ -380,6 +469,10 @@ func main() {
 					cfg.Entitlements = append(cfg.Entitlements, e)
 				case "device":
 					cfg.Entitlements = append(cfg.Entitlements, e)
+				case "local.exec":
+					cfg.Entitlements = append(cfg.Entitlements, e)
+				case "mount.host":
+					cfg.Entitlements = append(cfg.Entitlements, e)
 				default:
 					return errors.Errorf("invalid entitlement : %s", e)
 				}
@@ -396,10 +489,21 @@ func main() {
 			return err
 		}
 
+		// the load balancers stop sending builds to a draining daemon
+		go func() {
+			select {
+			case <-controller.Draining():
+				healthServer.SetServingStatus("", healthv1.HealthCheckResponse_NOT_SERVING)
+			case <-ctx.Done():
+			}
+		}()
+		go handleDrainSignals(ctx, controller)
+
 		select {
 		case serverErr := <-errCh:
 			err = serverErr
 			cancel(err)
+		case <-controller.Drained():
 		case <-ctx.Done():
 			err = context.Cause(ctx)
 		}
@@ -529,6 +633,19 @@ func setDefaultNetworkConfig(nc config.NetworkConfig) config.NetworkConfig {
 	return nc
 }
 
+// loadConfig loads the config file with the flags of the daemon applied
+func loadConfig(c *cli.Context) (config.Config, error) {
+	cfg, err := config.LoadFile(c.GlobalString("config"))
+	if err != nil {
+		return config.Config{}, err
+	}
+	setDefaultConfig(&cfg)
+	if err := applyMainFlags(c, &cfg); err != nil {
+		return config.Config{}, err
+	}
+	return cfg, nil
+}
+
 func setDefaultConfig(cfg *config.Config) {
 	orig := *cfg
 
@@ -590,6 +707,9 @@ func applyMainFlags(c *cli.Context, cfg *config.Config) error {
 	if c.IsSet("trace") {
 		cfg.Trace = c.Bool("trace")
 	}
+	if c.IsSet("read-only") {
+		cfg.ReadOnly = c.Bool("read-only")
+	}
 	if c.IsSet("root") {
 		cfg.Root = c.String("root")
 	}
@@ -608,6 +728,9 @@ func applyMainFlags(c *cli.Context, cfg *config.Config) error {
 	if c.IsSet("debugaddr") {
 		cfg.GRPC.DebugAddress = c.String("debugaddr")
 	}
+	if c.IsSet("metricsaddr") {
+		cfg.GRPC.MetricsAddress = c.String("metricsaddr")
+	}
 
 	if cfg.GRPC.UID == nil {
 		uid := os.Getuid()
@@ -801,10 +924,33 @@ func newController(ctx context.Context, c *cli.Context, cfg *config.Config) (*co
 		}
 	}
 
-	wc, err := newWorkerController(c, workerInitializerOpt{
-		config:         cfg,
-		sessionManager: sessionManager,
-		traceSocket:    traceSocket,
+	exporterPlugins, err := newExporterPlugins(cfg.Exporters)
+	if err != nil {
+		return nil, err
+	}
+	sourcePlugins, err := newSourcePlugins(cfg.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsResolvers, err := startDNSForwarders(ctx, cfg.DNS)
+	if err != nil {
+		return nil, err
+	}
+
+	reloader, err := newConfigReloader(c)
+	if err != nil {
+		return nil, err
+	}
+	resolverFn := reloader.registryHosts.RegistryHosts
+
+	wc, err := newWorkerController(c, reloader, workerInitializerOpt{
+		config:          cfg,
+		sessionManager:  sessionManager,
+		traceSocket:     traceSocket,
+		exporterPlugins: exporterPlugins,
+		sourcePlugins:   sourcePlugins,
+		registryHosts:   resolverFn,
 	})
 	if err != nil {
 		return nil, err
@@ -814,8 +960,26 @@ func newController(ctx context.Context, c *cli.Context, cfg *config.Config) (*co
 	if cfg.Frontends.Dockerfile.Enabled == nil || *cfg.Frontends.Dockerfile.Enabled {
 		frontends["dockerfile.v0"] = forwarder.NewGatewayForwarder(wc.Infos(), dockerfile.Build)
 	}
+	if cfg.Frontends.Starlark.Enabled == nil || *cfg.Frontends.Starlark.Enabled {
+		frontends["starlark.v0"] = forwarder.NewGatewayForwarder(wc.Infos(), starlark.Build)
+	}
 	if cfg.Frontends.Gateway.Enabled == nil || *cfg.Frontends.Gateway.Enabled {
-		gwfe, err := gateway.NewGatewayFrontend(wc.Infos(), cfg.Frontends.Gateway.AllowedRepositories)
+		pinning := gateway.SourcePinning{
+			RequireDigest: cfg.Frontends.Gateway.RequirePinned,
+			Channels:      map[string]digest.Digest{},
+		}
+		for channel, dgst := range cfg.Frontends.Gateway.Channels {
+			pinning.Channels[channel] = digest.Digest(dgst)
+		}
+		grants := map[string]gateway.Grant{}
+		for repo, grant := range cfg.Frontends.Gateway.Grants {
+			grants[repo] = gateway.Grant{
+				Network:       grant.Network,
+				LocalContexts: grant.LocalContexts,
+				Capabilities:  grant.Capabilities,
+			}
+		}
+		gwfe, err := gateway.NewGatewayFrontend(wc.Infos(), cfg.Frontends.Gateway.AllowedRepositories, pinning, grants)
 		if err != nil {
 			return nil, err
 		}
@@ -828,39 +992,102 @@ func newController(ctx context.Context, c *cli.Context, cfg *config.Config) (*co
 	}
 	cacheStoreForDebug = cacheStorage
 
+	// a read-only daemon keeps serving the cache it has, the versions are
+	// updated by the daemon that adds to it
+	if !cfg.ReadOnly {
+		prevKeyVersions, err := cacheStorage.UpdateKeyVersions(solver.CacheKeyVersions())
+		if err != nil {
+			return nil, err
+		}
+		if prevKeyVersions != nil {
+			for _, m := range solver.CheckCacheKeyVersions(prevKeyVersions) {
+				bklog.L.Warnf("local cache won't match for %s", m)
+			}
+		}
+	}
+
 	historyDB, err := boltutil.Open(filepath.Join(cfg.Root, "history.db"), 0600, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	resolverFn := resolverFunc(cfg)
-
 	w, err := wc.GetDefault()
 	if err != nil {
 		return nil, err
 	}
 
+	cacheTarballsRoot := filepath.Join(cfg.Root, "cache-tarballs")
 	remoteCacheExporterFuncs := map[string]remotecache.ResolveCacheExporterFunc{
 		"registry": registryremotecache.ResolveCacheExporterFunc(sessionManager, resolverFn),
 		"local":    localremotecache.ResolveCacheExporterFunc(sessionManager),
 		"inline":   inlineremotecache.ResolveCacheExporterFunc(),
 		"gha":      gha.ResolveCacheExporterFunc(),
-		"s3":       s3remotecache.ResolveCacheExporterFunc(),
-		"azblob":   azblob.ResolveCacheExporterFunc(),
+		"s3":       s3remotecache.ResolveCacheExporterFunc(sessionManager),
+		"azblob":   azblob.ResolveCacheExporterFunc(sessionManager),
+		"tarball":  tarballremotecache.ResolveCacheExporterFunc(cacheTarballsRoot),
 	}
 	remoteCacheImporterFuncs := map[string]remotecache.ResolveCacheImporterFunc{
 		"registry": registryremotecache.ResolveCacheImporterFunc(sessionManager, w.ContentStore(), resolverFn),
 		"local":    localremotecache.ResolveCacheImporterFunc(sessionManager),
 		"gha":      gha.ResolveCacheImporterFunc(),
-		"s3":       s3remotecache.ResolveCacheImporterFunc(),
-		"azblob":   azblob.ResolveCacheImporterFunc(),
+		"s3":       s3remotecache.ResolveCacheImporterFunc(sessionManager),
+		"azblob":   azblob.ResolveCacheImporterFunc(sessionManager),
+		"tarball":  tarballremotecache.ResolveCacheImporterFunc(cacheTarballsRoot),
+	}
+
+	cfg.Entitlements = daemonEntitlements(cfg)
+
+	identityEntitlements, err := getIdentityEntitlements(cfg.Identities)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateCredentialHelpers(cfg); err != nil {
+		return nil, err
 	}
 
-	if cfg.CDI.Disabled == nil || !*cfg.CDI.Disabled {
-		cfg.Entitlements = append(cfg.Entitlements, "device")
+	eventSinks, err := newEventSinks(cfg.Webhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	logSinks, err := newLogSinks(ctx, cfg.Log.Sinks)
+	if err != nil {
+		return nil, err
+	}
+
+	if h := cfg.History; h != nil {
+		// the history limits can be percentages of the disk of the root
+		dstat, _ := disk.GetDiskStat(cfg.Root)
+		h.MaxSize = config.DiskSpace{Bytes: h.MaxSize.AsBytes(dstat)}
+		if h.Clients != nil {
+			h.Clients.MaxSize = config.DiskSpace{Bytes: h.Clients.MaxSize.AsBytes(dstat)}
+		}
+	}
+
+	var leaderElector *leader.Elector
+	if le := cfg.LeaderElection; le != nil && le.LockFile != "" {
+		id := le.ID
+		if id == "" {
+			if id, err = os.Hostname(); err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		if leaderElector, err = leader.New(le.LockFile, id); err != nil {
+			return nil, err
+		}
+	}
+
+	var speculativeExecution int
+	var concurrencyLimits config.ConcurrencyLimits
+	var retry config.RetryConfig
+	if cfg.Solver != nil {
+		speculativeExecution = cfg.Solver.SpeculativeExecution
+		concurrencyLimits = cfg.Solver.Limits
+		retry = cfg.Solver.Retry
 	}
 
-	return control.NewController(control.Opt{
+	controller, err := control.NewController(control.Opt{
 		SessionManager:            sessionManager,
 		WorkerController:          wc,
 		Frontends:                 frontends,
@@ -868,6 +1095,8 @@ func newController(ctx context.Context, c *cli.Context, cfg *config.Config) (*co
 		ResolveCacheImporterFuncs: remoteCacheImporterFuncs,
 		CacheManager:              solver.NewCacheManager(context.TODO(), "local", cacheStorage, worker.NewCacheResultStorage(wc)),
 		Entitlements:              cfg.Entitlements,
+		IdentityEntitlements:      identityEntitlements,
+		IdentityQuotas:            getIdentityQuotas(cfg.Identities, cfg.Root),
 		TraceCollector:            tc,
 		HistoryDB:                 historyDB,
 		CacheStore:                cacheStorage,
@@ -876,14 +1105,221 @@ func newController(ctx context.Context, c *cli.Context, cfg *config.Config) (*co
 		HistoryConfig:             cfg.History,
 		GarbageCollect:            w.GarbageCollect,
 		GracefulStop:              ctx.Done(),
+		EventSinks:                eventSinks,
+		LogSinks:                  logSinks,
+		SpeculativeExecution:      speculativeExecution,
+		ConcurrencyLimits:         concurrencyLimits,
+		CacheVerify:               cfg.CacheVerify,
+		StepLogs:                  cfg.Log.Steps,
+		StepLogsSpillDir:          filepath.Join(cfg.Root, "history-spill"),
+		ReadOnly:                  cfg.ReadOnly,
+		DNSResolvers:              dnsResolvers,
+		Retry: solver.RetryPolicy{
+			Attempts:   retry.Attempts,
+			Backoff:    retry.Backoff.Duration,
+			MaxBackoff: retry.MaxBackoff.Duration,
+		},
+		ReloadConfig: reloader.reload,
+		DrainTimeout: cfg.DrainTimeout.Duration,
+		Leader:       leaderElector,
 	})
+	if err != nil {
+		return nil, err
+	}
+	reloader.controller = controller
+	go reloader.handleSignals(ctx)
+	return controller, nil
 }
 
-func resolverFunc(cfg *config.Config) docker.RegistryHosts {
-	return resolver.NewRegistryConfig(cfg.Registries)
+// handleDrainSignals drains the daemon on the drain signals until ctx is done
+func handleDrainSignals(ctx context.Context, controller *control.Controller) {
+	if len(drainSignals) == 0 {
+		return
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, drainSignals...)
+	defer signal.Stop(ch)
+	select {
+	case <-ctx.Done():
+	case <-ch:
+		if _, err := controller.Drain(ctx, &controlapi.DrainRequest{}); err != nil {
+			bklog.G(ctx).Errorf("failed to drain: %v", err)
+		}
+	}
 }
 
-func newWorkerController(c *cli.Context, wiOpt workerInitializerOpt) (*worker.Controller, error) {
+func newEventSinks(webhooks []config.WebhookConfig) ([]events.Sink, error) {
+	var sinks []events.Sink
+	for _, wh := range webhooks {
+		var types []events.Type
+		for _, e := range wh.Events {
+			types = append(types, events.Type(e))
+		}
+		sink, err := events.NewWebhookSink(events.WebhookOpt{
+			Endpoint: wh.Endpoint,
+			Secret:   wh.Secret,
+			Events:   types,
+			Timeout:  wh.Timeout.Duration,
+			CA:       wh.TLS.CA,
+			Cert:     wh.TLS.Cert,
+			Key:      wh.TLS.Key,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid webhook %s", wh.Endpoint)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+func newLogSinks(ctx context.Context, cfgs []config.LogSinkConfig) ([]logsink.Sink, error) {
+	var sinks []logsink.Sink
+	for i, cfg := range cfgs {
+		httpOpt := logsink.HTTPOpt{
+			Endpoint: cfg.Endpoint,
+			Headers:  cfg.Headers,
+			Timeout:  cfg.Timeout.Duration,
+			CA:       cfg.TLS.CA,
+			Cert:     cfg.TLS.Cert,
+			Key:      cfg.TLS.Key,
+		}
+		var sink logsink.Sink
+		var err error
+		switch cfg.Type {
+		case "loki":
+			sink, err = logsink.NewLokiSink(logsink.LokiOpt{HTTPOpt: httpOpt, Labels: cfg.Labels})
+		case "cloudwatch":
+			sink, err = logsink.NewCloudWatchSink(ctx, logsink.CloudWatchOpt{
+				Region:    cfg.Region,
+				LogGroup:  cfg.LogGroup,
+				LogStream: cfg.LogStream,
+				Endpoint:  cfg.Endpoint,
+				Timeout:   cfg.Timeout.Duration,
+			})
+		case "http":
+			sink, err = logsink.NewHTTPSink(httpOpt)
+		default:
+			err = errors.Errorf("unknown type %q", cfg.Type)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid log sink %d", i)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+func newExporterPlugins(cfgs map[string]config.PluginConfig) (map[string]exporter.Exporter, error) {
+	out := make(map[string]exporter.Exporter, len(cfgs))
+	for name, cfg := range cfgs {
+		switch name {
+		case client.ExporterImage, client.ExporterLocal, client.ExporterTar, client.ExporterOCI, client.ExporterDocker, client.ExporterArtifact:
+			return nil, errors.Errorf("exporter plugin %s conflicts with a builtin exporter", name)
+		}
+		exp, err := plugin.New(plugin.Opt{
+			Name:    name,
+			Timeout: cfg.Timeout.Duration,
+			Opt: grpcplugin.Opt{
+				Address: cfg.Address,
+				CA:      cfg.TLS.CA,
+				Cert:    cfg.TLS.Cert,
+				Key:     cfg.TLS.Key,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		out[name] = exp
+	}
+	return out, nil
+}
+
+func newSourcePlugins(cfgs map[string]config.PluginConfig) ([]*sourceplugin.Plugin, error) {
+	var out []*sourceplugin.Plugin
+	for _, scheme := range slices.Sorted(maps.Keys(cfgs)) {
+		switch scheme {
+		case srctypes.DockerImageScheme, srctypes.GitScheme, srctypes.LocalScheme, srctypes.HTTPScheme, srctypes.HTTPSScheme, srctypes.OCIScheme, srctypes.SessionRefScheme:
+			return nil, errors.Errorf("source plugin %s conflicts with a builtin source", scheme)
+		}
+		cfg := cfgs[scheme]
+		p, err := sourceplugin.NewPlugin(sourceplugin.PluginOpt{
+			Scheme:  scheme,
+			Timeout: cfg.Timeout.Duration,
+			Opt: grpcplugin.Opt{
+				Address: cfg.Address,
+				CA:      cfg.TLS.CA,
+				Cert:    cfg.TLS.Cert,
+				Key:     cfg.TLS.Key,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+func getIdentityEntitlements(identities map[string]config.IdentityConfig) (map[clientidentity.Identity][]string, error) {
+	out := make(map[clientidentity.Identity][]string, len(identities))
+	for name, id := range identities {
+		method := id.Method
+		switch method {
+		case "":
+			method = clientidentity.MethodTLS
+		case clientidentity.MethodTLS, oidc.MethodOIDC:
+		default:
+			return nil, errors.Errorf("invalid authentication method %s for identity %s", method, name)
+		}
+		for _, e := range id.Entitlements {
+			switch e {
+			case "security.insecure", "network.host", "device", "local.exec", "mount.host":
+			default:
+				return nil, errors.Errorf("invalid entitlement %s for identity %s", e, name)
+			}
+		}
+		out[clientidentity.Identity{Name: name, Method: method}] = id.Entitlements
+	}
+	return out, nil
+}
+
+// getIdentityQuotas returns the cache quotas in bytes of the identities that
+// set one. The identities must have been validated by getIdentityEntitlements.
+func getIdentityQuotas(identities map[string]config.IdentityConfig, root string) map[clientidentity.Identity]int64 {
+	dstat, _ := disk.GetDiskStat(root)
+	out := map[clientidentity.Identity]int64{}
+	for name, id := range identities {
+		quota := id.Quota.AsBytes(dstat)
+		if quota <= 0 {
+			continue
+		}
+		method := id.Method
+		if method == "" {
+			method = clientidentity.MethodTLS
+		}
+		out[clientidentity.Identity{Name: name, Method: method}] = quota
+	}
+	return out
+}
+
+func validateCredentialHelpers(cfg *config.Config) error {
+	for _, name := range cfg.AllowedCredentialHelpers {
+		if err := resolver.ValidateCredentialHelper(name); err != nil {
+			return err
+		}
+	}
+	for host, rc := range cfg.Registries {
+		if rc.CredentialHelper == "" {
+			continue
+		}
+		if !slices.Contains(cfg.AllowedCredentialHelpers, rc.CredentialHelper) {
+			return errors.Errorf("credential helper %q for registry %s is not in allowedCredentialHelpers", rc.CredentialHelper, host)
+		}
+	}
+	return nil
+}
+
+func newWorkerController(c *cli.Context, reloader *configReloader, wiOpt workerInitializerOpt) (*worker.Controller, error) {
 	wc := &worker.Controller{}
 	nWorkers := 0
 	for _, wi := range workerInitializers {
@@ -891,6 +1327,7 @@ func newWorkerController(c *cli.Context, wiOpt workerInitializerOpt) (*worker.Co
 		if err != nil {
 			return nil, err
 		}
+		reloader.addWorkers(wi, ws)
 		for _, w := range ws {
 			p := w.Platforms(false)
 			bklog.L.Infof("found worker %q, labels=%v, platforms=%v", w.ID(), w.Labels(), formatPlatforms(p))
@@ -987,10 +1424,73 @@ func getDNSConfig(cfg *config.DNSConfig) *oci.DNSConfig {
 			Options:       cfg.Options,
 			SearchDomains: cfg.SearchDomains,
 		}
+		if len(cfg.Upstreams) > 0 || len(cfg.Domains) > 0 {
+			dns.Nameservers = []string{cfg.Listen}
+		}
 	}
 	return dns
 }
 
+// startDNSForwarders starts the DNS forwarders of the exec ops until ctx is
+// done. It returns the nameservers of the resolvers the builds select by name.
+func startDNSForwarders(ctx context.Context, cfg *config.DNSConfig) (map[string]string, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	if len(cfg.Upstreams) > 0 || len(cfg.Domains) > 0 {
+		if len(cfg.Nameservers) > 0 {
+			return nil, errors.New("dns nameservers can't be set with upstreams or domains")
+		}
+		if err := startDNSForwarder(ctx, "default", config.DNSResolverConfig{
+			Listen:    cfg.Listen,
+			Upstreams: cfg.Upstreams,
+			Domains:   cfg.Domains,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	resolvers := make(map[string]string, len(cfg.Resolvers))
+	for name, r := range cfg.Resolvers {
+		if err := startDNSForwarder(ctx, name, r); err != nil {
+			return nil, err
+		}
+		resolvers[name] = r.Listen
+	}
+	return resolvers, nil
+}
+
+func startDNSForwarder(ctx context.Context, name string, cfg config.DNSResolverConfig) error {
+	if _, err := netip.ParseAddr(cfg.Listen); err != nil {
+		return errors.Wrapf(err, "invalid listen address of DNS resolver %s", name)
+	}
+	upstreams := cfg.Upstreams
+	if len(upstreams) == 0 {
+		rc, err := resolvconf.Load("/etc/resolv.conf")
+		if err != nil {
+			return errors.Wrapf(err, "failed to load the nameservers of the host for DNS resolver %s", name)
+		}
+		for _, ns := range rc.NameServers() {
+			upstreams = append(upstreams, ns.String())
+		}
+	}
+	f, err := dnsforward.New(dnsforward.Config{
+		Upstreams: upstreams,
+		Domains:   cfg.Domains,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "invalid DNS resolver %s", name)
+	}
+	if err := f.ListenAndServe(ctx, cfg.Listen); err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		f.Close()
+	}()
+	bklog.G(ctx).Infof("DNS resolver %s listening on %s", name, cfg.Listen)
+	return nil
+}
+
 // parseBoolOrAuto returns (nil, nil) if s is "auto"
 func parseBoolOrAuto(s string) (*bool, error) {
 	if s == "" || strings.EqualFold(s, "auto") {
@@ -1089,3 +1589,55 @@ func getCDIManager(cfg config.CDIConfig) (*cdidevices.Manager, error) {
 	}
 	return cdidevices.NewManager(cdiCache, cfg.AutoAllowed), nil
 }
+
+// setCompression limits the layers compressed at the same time by all the
+// workers to the number of CPUs by default
+func setCompression(cfg *config.CompressionConfig) error {
+	if err := validateCompression(cfg); err != nil {
+		return err
+	}
+	parallelism := runtime.NumCPU()
+	var level *int
+	if cfg != nil {
+		if cfg.Parallelism != 0 {
+			parallelism = cfg.Parallelism
+		}
+		level = cfg.ZstdLevel
+	}
+	compression.SetParallelism(parallelism)
+	compression.SetDefaultZstdLevel(level)
+	return nil
+}
+
+// newCacheEventsLog opens the cache events log of the config, it returns nil
+// if the cache events are not logged
+func newCacheEventsLog(cfg config.Config) (*cacheevents.Log, error) {
+	ec := cfg.CacheEvents
+	if ec == nil {
+		return nil, nil
+	}
+	if ec.MaxSize.Percentage != 0 {
+		return nil, errors.New("cacheEvents maxSize must be a size in bytes")
+	}
+	p := ec.Path
+	if p == "" {
+		p = filepath.Join(cfg.Root, "cache-events.log")
+	}
+	return cacheevents.OpenLog(cacheevents.LogOpt{
+		Path:     p,
+		Format:   ec.Format,
+		MaxSize:  ec.MaxSize.Bytes,
+		MaxAge:   ec.MaxAge.Duration,
+		MaxFiles: ec.MaxFiles,
+	})
+}
+
+func validateCompression(cfg *config.CompressionConfig) error {
+	if cfg == nil || cfg.ZstdLevel == nil {
+		return nil
+	}
+	if l := *cfg.ZstdLevel; l < 0 || l > 22 {
+		return errors.Errorf("invalid compression zstdLevel %d, must be between 0 and 22", l)
+	}
+	return nil
+}
@@ -170,7 +170,11 @@ func init() {
 		workerInitializer{
 			fn: containerdWorkerInitializer,
 			// 1 is less preferred than 0 (runcCtor)
-			priority: 1,
+			priority:   1,
+			applyFlags: applyContainerdFlags,
+			workerConfig: func(cfg *config.Config) (map[string]string, config.GCConfig) {
+				return cfg.Workers.Containerd.Labels, cfg.Workers.Containerd.GCConfig
+			},
 		},
 		flags...,
 	)
@@ -361,7 +365,10 @@ func containerdWorkerInitializer(c *cli.Context, common workerInitializerOpt) ([
 	}
 	opt.GCPolicy = getGCPolicy(cfg.GCConfig, common.config.Root)
 	opt.BuildkitVersion = getBuildkitVersion()
-	opt.RegistryHosts = resolverFunc(common.config)
+	opt.RegistryHosts = common.registryHosts
+	opt.UnpackConcurrency = cfg.UnpackConcurrency
+	opt.ExporterPlugins = common.exporterPlugins
+	opt.SourcePlugins = common.sourcePlugins
 
 	if platformsStr := cfg.Platforms; len(platformsStr) != 0 {
 		platforms, err := parsePlatforms(platformsStr)
@@ -37,6 +37,7 @@ import (
 	"github.com/moby/buildkit/util/network/cniprovider"
 	"github.com/moby/buildkit/util/network/netproviders"
 	"github.com/moby/buildkit/util/resolver"
+	"github.com/moby/buildkit/util/sharedcontent"
 	"github.com/moby/buildkit/worker"
 	"github.com/moby/buildkit/worker/base"
 	"github.com/moby/buildkit/worker/runc"
@@ -82,7 +83,7 @@ func init() {
 		},
 		cli.StringFlag{
 			Name:  "oci-worker-proxy-snapshotter-path",
-			Usage: "address of proxy snapshotter socket (do not include 'unix://' prefix)",
+			Usage: "address of proxy snapshotter socket, the name of the proxied snapshotter is set with --oci-worker-snapshotter",
 		},
 		cli.StringSliceFlag{
 			Name:  "oci-worker-platform",
@@ -168,8 +169,12 @@ func init() {
 
 	registerWorkerInitializer(
 		workerInitializer{
-			fn:       ociWorkerInitializer,
-			priority: 0,
+			fn:         ociWorkerInitializer,
+			priority:   0,
+			applyFlags: applyOCIFlags,
+			workerConfig: func(cfg *config.Config) (map[string]string, config.GCConfig) {
+				return cfg.Workers.OCI.Labels, cfg.Workers.OCI.GCConfig
+			},
 		},
 		flags...,
 	)
@@ -281,7 +286,7 @@ func ociWorkerInitializer(c *cli.Context, common workerInitializerOpt) ([]worker
 		return nil, err
 	}
 
-	hosts := resolverFunc(common.config)
+	hosts := common.registryHosts
 	snFactory, err := snapshotterFactory(common.config.Root, cfg, common.sessionManager, hosts)
 	if err != nil {
 		return nil, err
@@ -327,13 +332,25 @@ func ociWorkerInitializer(c *cli.Context, common workerInitializerOpt) ([]worker
 		parallelismSem = semaphore.NewWeighted(int64(cfg.MaxParallelism))
 	}
 
-	opt, err := runc.NewWorkerOpt(common.config.Root, snFactory, cfg.Rootless, processMode, cfg.Labels, idmapping, nc, dns, cfg.Binary, cfg.ApparmorProfile, cfg.SELinux, parallelismSem, common.traceSocket, cfg.DefaultCgroupParent, cdiManager)
+	var sharedContent *sharedcontent.Store
+	if cfg.SharedContentRoot != "" {
+		sharedContent, err = sharedcontent.NewStore(cfg.SharedContentRoot)
+		if err != nil {
+			return nil, err
+		}
+		sharedContentForDebug = sharedContent
+	}
+
+	opt, err := runc.NewWorkerOpt(common.config.Root, snFactory, cfg.Rootless, processMode, cfg.Labels, idmapping, nc, dns, cfg.Binary, cfg.ApparmorProfile, cfg.SELinux, parallelismSem, common.traceSocket, cfg.DefaultCgroupParent, cdiManager, sharedContent)
 	if err != nil {
 		return nil, err
 	}
 	opt.GCPolicy = getGCPolicy(cfg.GCConfig, common.config.Root)
 	opt.BuildkitVersion = getBuildkitVersion()
 	opt.RegistryHosts = hosts
+	opt.UnpackConcurrency = cfg.UnpackConcurrency
+	opt.ExporterPlugins = common.exporterPlugins
+	opt.SourcePlugins = common.sourcePlugins
 
 	if platformsStr := cfg.Platforms; len(platformsStr) != 0 {
 		platforms, err := parsePlatforms(platformsStr)
@@ -355,11 +372,17 @@ func snapshotterFactory(commonRoot string, cfg config.OCIConfig, sm *session.Man
 		address = cfg.ProxySnapshotterPath
 	)
 	if address != "" {
+		address = strings.TrimPrefix(address, "unix://")
+		if name == "auto" {
+			// the name identifies the snapshotters that have specific support,
+			// e.g. overlaybd and stargz
+			bklog.L.Warnf("snapshotter name is not set for the proxy snapshotter on %q, snapshotter specific features are disabled", address)
+		}
 		snFactory := runc.SnapshotterFactory{
 			Name: name,
 		}
 		if _, err := os.Stat(address); os.IsNotExist(err) {
-			return snFactory, errors.Wrapf(err, "snapshotter doesn't exist on %q (Do not include 'unix://' prefix)", address)
+			return snFactory, errors.Wrapf(err, "snapshotter doesn't exist on %q", address)
 		}
 		snFactory.New = func(root string) (ctdsnapshot.Snapshotter, error) {
 			backoffConfig := backoff.DefaultConfig
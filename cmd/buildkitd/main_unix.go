@@ -15,6 +15,9 @@ import (
 
 const socketScheme = "unix://"
 
+// drainSignals make the daemon stop after the running builds finish
+var drainSignals = []os.Signal{syscall.SIGUSR2}
+
 func init() {
 	syscall.Umask(0)
 }
@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"os"
 	"strings"
 
 	"github.com/Microsoft/go-winio"
@@ -16,6 +17,10 @@ import (
 
 const socketScheme = "npipe://"
 
+// drainSignals are not supported on Windows, the daemon is drained with the
+// Drain API
+var drainSignals []os.Signal
+
 func listenFD(_ string, _ *tls.Config) (net.Listener, error) {
 	return nil, errors.New("listening server on fd not supported on windows")
 }
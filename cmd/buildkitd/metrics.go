@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// setupMetricsHandler serves the prometheus metrics of the daemon on a
+// dedicated listener so that they can be scraped without exposing the debug
+// handlers.
+func setupMetricsHandler(addr string) error {
+	m := http.NewServeMux()
+	m.Handle("/metrics", promhttp.Handler())
+
+	if !strings.Contains(addr, "://") {
+		addr = "tcp://" + addr
+	}
+	l, err := getListener(addr, os.Getuid(), os.Getgid(), "", nil, false)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Addr:              l.Addr().String(),
+		Handler:           m,
+		ReadHeaderTimeout: time.Minute,
+	}
+	bklog.L.Debugf("metrics handler listening at %s", addr)
+	go func() {
+		if err := server.Serve(l); err != nil {
+			bklog.L.Errorf("failed to serve metrics handler: %v", err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/signal"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/moby/buildkit/cmd/buildkitd/config"
+	"github.com/moby/buildkit/control"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/resolver"
+	"github.com/moby/buildkit/worker"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+// configReloader applies the registries, GC policies, entitlements and worker
+// labels of the reloaded config file to the running daemon, on SIGHUP or with
+// the ReloadConfig API. The other settings only apply after a restart.
+type configReloader struct {
+	c *cli.Context
+
+	mu sync.Mutex
+	// cfg is the config the daemon runs with, as loaded from the file before
+	// the daemon derives its state from it
+	cfg           config.Config
+	registryHosts *resolver.ReloadableRegistryHosts
+	controller    *control.Controller
+	workers       []reloadableWorkers
+}
+
+// reloadableWorkers are the workers created by a worker initializer
+type reloadableWorkers struct {
+	wi      workerInitializer
+	workers []worker.Worker
+}
+
+func newConfigReloader(c *cli.Context) (*configReloader, error) {
+	cfg, err := loadReloadConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return &configReloader{
+		c:             c,
+		cfg:           cfg,
+		registryHosts: resolver.NewReloadableRegistryConfig(cfg.Registries),
+	}, nil
+}
+
+// loadReloadConfig loads the config file with the flags of the daemon and of
+// its workers applied
+func loadReloadConfig(c *cli.Context) (config.Config, error) {
+	cfg, err := loadConfig(c)
+	if err != nil {
+		return config.Config{}, err
+	}
+	for _, wi := range workerInitializers {
+		if wi.applyFlags == nil {
+			continue
+		}
+		if err := wi.applyFlags(c, &cfg); err != nil {
+			return config.Config{}, err
+		}
+	}
+	return cfg, nil
+}
+
+func (r *configReloader) addWorkers(wi workerInitializer, ws []worker.Worker) {
+	if wi.workerConfig != nil && len(ws) > 0 {
+		r.workers = append(r.workers, reloadableWorkers{wi: wi, workers: ws})
+	}
+}
+
+// handleSignals reloads the config on SIGHUP until ctx is done
+func (r *configReloader) handleSignals(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	// SIGHUP is never sent on Windows, the config is reloaded with the API
+	signal.Notify(ch, syscall.SIGHUP)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			if _, err := r.reload(ctx); err != nil {
+				bklog.G(ctx).Errorf("failed to reload config: %v", err)
+			}
+		}
+	}
+}
+
+// reload loads the config file again and applies the settings that can change
+// without a restart. Nothing is applied if the config is invalid.
+func (r *configReloader) reload(ctx context.Context) (*control.ConfigReload, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := loadReloadConfig(r.c)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load config")
+	}
+	next := withReloadableSettings(r.cfg, cfg)
+	identityEntitlements, err := getIdentityEntitlements(next.Identities)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid config")
+	}
+	if err := validateCredentialHelpers(&next); err != nil {
+		return nil, errors.Wrap(err, "invalid config")
+	}
+	if err := resolver.ValidateRegistryConfig(next.Registries); err != nil {
+		return nil, errors.Wrap(err, "invalid config")
+	}
+	if err := validateCompression(next.Compression); err != nil {
+		return nil, errors.Wrap(err, "invalid config")
+	}
+
+	res := &control.ConfigReload{}
+	var registries, entitlements, comp bool
+	for _, p := range configChanges(r.cfg, cfg) {
+		if !isReloadable(p) {
+			res.RequiresRestart = append(res.RequiresRestart, formatConfigPath(p))
+			continue
+		}
+		res.Applied = append(res.Applied, formatConfigPath(p))
+		switch p[0] {
+		case "registry":
+			registries = true
+		case "insecure-entitlements", "identity":
+			entitlements = true
+		case "compression":
+			comp = true
+		}
+	}
+
+	if registries {
+		r.registryHosts.Update(next.Registries)
+	}
+	if comp {
+		if err := setCompression(next.Compression); err != nil {
+			return nil, err
+		}
+	}
+	if entitlements && r.controller != nil {
+		r.controller.SetEntitlements(daemonEntitlements(&next), identityEntitlements)
+	}
+	for _, rw := range r.workers {
+		prevLabels, prevGC := rw.wi.workerConfig(&r.cfg)
+		labels, gc := rw.wi.workerConfig(&next)
+		for _, w := range rw.workers {
+			rc, ok := w.(worker.Reconfigurable)
+			if !ok {
+				continue
+			}
+			if !maps.Equal(prevLabels, labels) {
+				wl := maps.Clone(w.Labels())
+				maps.DeleteFunc(wl, func(k, _ string) bool {
+					_, ok := prevLabels[k]
+					return ok
+				})
+				maps.Copy(wl, labels)
+				rc.SetLabels(wl)
+			}
+			if !reflect.DeepEqual(prevGC, gc) {
+				rc.SetGCPolicy(getGCPolicy(gc, next.Root))
+			}
+		}
+	}
+	r.cfg = next
+
+	bklog.G(ctx).Infof("reloaded config, applied: %v, requires restart: %v", res.Applied, res.RequiresRestart)
+	return res, nil
+}
+
+// daemonEntitlements returns the entitlements allowed for all the clients
+func daemonEntitlements(cfg *config.Config) []string {
+	ents := slices.Clone(cfg.Entitlements)
+	if cfg.CDI.Disabled == nil || !*cfg.CDI.Disabled {
+		ents = append(ents, "device")
+	}
+	return ents
+}
+
+// reloadableWorkerSettings are the settings of the worker configs that are
+// applied without a restart
+var reloadableWorkerSettings = map[string]bool{
+	"labels":        true,
+	"gc":            true,
+	"gckeepstorage": true,
+	"reservedSpace": true,
+	"maxUsedSpace":  true,
+	"minFreeSpace":  true,
+	"gcpolicy":      true,
+}
+
+// isReloadable returns whether the setting at a config path is applied
+// without a restart. It matches the settings withReloadableSettings copies.
+func isReloadable(p []string) bool {
+	switch p[0] {
+	case "registry", "allowedCredentialHelpers", "insecure-entitlements", "compression":
+		return true
+	case "identity":
+		// the quotas of the identities are set up when the daemon starts
+		return len(p) == 3 && (p[2] == "method" || p[2] == "entitlements")
+	case "worker":
+		return len(p) == 3 && reloadableWorkerSettings[p[2]]
+	}
+	return false
+}
+
+// withReloadableSettings returns the running config with the settings of the
+// loaded config that are applied without a restart
+func withReloadableSettings(running, loaded config.Config) config.Config {
+	next := running
+	next.Registries = loaded.Registries
+	next.AllowedCredentialHelpers = loaded.AllowedCredentialHelpers
+	next.Entitlements = loaded.Entitlements
+	next.Compression = loaded.Compression
+	next.Identities = map[string]config.IdentityConfig{}
+	for name, id := range running.Identities {
+		id.Method = loaded.Identities[name].Method
+		id.Entitlements = loaded.Identities[name].Entitlements
+		next.Identities[name] = id
+	}
+	for name, id := range loaded.Identities {
+		if _, ok := running.Identities[name]; !ok {
+			next.Identities[name] = config.IdentityConfig{Method: id.Method, Entitlements: id.Entitlements}
+		}
+	}
+	next.Workers.OCI.Labels = loaded.Workers.OCI.Labels
+	next.Workers.OCI.GCConfig = loaded.Workers.OCI.GCConfig
+	next.Workers.Containerd.Labels = loaded.Workers.Containerd.Labels
+	next.Workers.Containerd.GCConfig = loaded.Workers.Containerd.GCConfig
+	return next
+}
+
+// configChanges returns the paths of the settings that differ between two
+// configs, by their TOML keys. The keys of the maps of tables are part of the
+// paths, and a missing table is compared as an empty one.
+func configChanges(a, b config.Config) [][]string {
+	var out [][]string
+	diffValues(reflect.ValueOf(a), reflect.ValueOf(b), nil, &out)
+	return out
+}
+
+func diffValues(a, b reflect.Value, path []string, out *[][]string) {
+	switch {
+	case a.Kind() == reflect.Struct && isTable(a.Type()):
+		for i := range a.NumField() {
+			f := a.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if f.Anonymous && f.Tag.Get("toml") == "" {
+				diffValues(a.Field(i), b.Field(i), path, out)
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			diffValues(a.Field(i), b.Field(i), append(slices.Clone(path), name), out)
+		}
+	case a.Kind() == reflect.Pointer && isTable(a.Type().Elem()):
+		diffValues(derefOrZero(a), derefOrZero(b), path, out)
+	case a.Kind() == reflect.Map && a.Type().Key().Kind() == reflect.String && isTable(a.Type().Elem()):
+		keys := map[string]struct{}{}
+		for _, k := range a.MapKeys() {
+			keys[k.String()] = struct{}{}
+		}
+		for _, k := range b.MapKeys() {
+			keys[k.String()] = struct{}{}
+		}
+		for _, k := range slices.Sorted(maps.Keys(keys)) {
+			kv := reflect.ValueOf(k).Convert(a.Type().Key())
+			diffValues(mapValueOrZero(a, kv), mapValueOrZero(b, kv), append(slices.Clone(path), k), out)
+		}
+	default:
+		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+			*out = append(*out, path)
+		}
+	}
+}
+
+// isTable returns whether a type is a struct with TOML keys, as opposed to a
+// value like a duration or a disk space
+func isTable(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Tag.Get("toml") != "" || (f.Anonymous && isTable(f.Type)) {
+			return true
+		}
+	}
+	return false
+}
+
+func derefOrZero(v reflect.Value) reflect.Value {
+	if v.IsNil() {
+		return reflect.Zero(v.Type().Elem())
+	}
+	return v.Elem()
+}
+
+func mapValueOrZero(m, k reflect.Value) reflect.Value {
+	if v := m.MapIndex(k); v.IsValid() {
+		return v
+	}
+	return reflect.Zero(m.Type().Elem())
+}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// formatConfigPath formats a config path as a dotted TOML key
+func formatConfigPath(p []string) string {
+	parts := make([]string, len(p))
+	for i, k := range p {
+		if bareKey.MatchString(k) {
+			parts[i] = k
+		} else {
+			parts[i] = strconv.Quote(k)
+		}
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moby/buildkit/cmd/buildkitd/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigChanges(t *testing.T) {
+	load := func(s string) config.Config {
+		cfg, err := config.Load(strings.NewReader(s))
+		require.NoError(t, err)
+		return cfg
+	}
+	running := load(`
+root = "/var/lib/buildkit"
+insecure-entitlements = ["network.host"]
+
+[registry."docker.io"]
+  mirrors = ["mirror.example.com"]
+
+[identity.ci]
+  method = "tls"
+  entitlements = ["security.insecure"]
+
+[worker.oci]
+  snapshotter = "overlayfs"
+  gckeepstorage = "10GB"
+  [worker.oci.labels]
+    team = "a"
+`)
+	loaded := load(`
+root = "/data/buildkit"
+insecure-entitlements = ["network.host", "security.insecure"]
+
+[registry."docker.io"]
+  mirrors = ["mirror2.example.com"]
+
+[registry."registry.example.com:5000"]
+  http = true
+
+[identity.ci]
+  method = "tls"
+
+[identity.dev]
+  method = "oidc"
+  entitlements = ["network.host"]
+  quota = "1GB"
+
+[worker.oci]
+  snapshotter = "native"
+  gckeepstorage = "20GB"
+  [worker.oci.labels]
+    team = "b"
+
+[compression]
+  parallelism = 2
+`)
+
+	var changes []string
+	for _, p := range configChanges(running, loaded) {
+		changes = append(changes, formatConfigPath(p))
+	}
+	require.Equal(t, []string{
+		"root",
+		"insecure-entitlements",
+		"identity.ci.entitlements",
+		"identity.dev.method",
+		"identity.dev.entitlements",
+		"identity.dev.quota",
+		"worker.oci.labels",
+		"worker.oci.snapshotter",
+		"worker.oci.gckeepstorage",
+		`registry."docker.io".mirrors`,
+		`registry."registry.example.com:5000".http`,
+		"compression.parallelism",
+	}, changes)
+
+	var restart []string
+	for _, p := range configChanges(running, loaded) {
+		if !isReloadable(p) {
+			restart = append(restart, formatConfigPath(p))
+		}
+	}
+	require.Equal(t, []string{"root", "identity.dev.quota", "worker.oci.snapshotter"}, restart)
+
+	// only the settings that require a restart are left
+	next := withReloadableSettings(running, loaded)
+	var left []string
+	for _, p := range configChanges(next, loaded) {
+		require.False(t, isReloadable(p), formatConfigPath(p))
+		left = append(left, formatConfigPath(p))
+	}
+	require.Equal(t, []string{"root", "identity.dev.quota", "worker.oci.snapshotter"}, left)
+	require.Equal(t, "/var/lib/buildkit", next.Root)
+	require.Nil(t, next.Identities["ci"].Entitlements)
+	require.Equal(t, map[string]string{"team": "b"}, next.Workers.OCI.Labels)
+	require.Equal(t, 2, next.Compression.Parallelism)
+	require.Empty(t, configChanges(running, running))
+}
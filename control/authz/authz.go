@@ -0,0 +1,192 @@
+// Package authz authorizes control API requests against an external
+// authorization webhook.
+package authz
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/clientidentity"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Authorizer decides whether a control API request is allowed
+type Authorizer interface {
+	Authorize(ctx context.Context, req *Request) error
+}
+
+// Identity is the authenticated client identity sent to the webhook
+type Identity struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+}
+
+// Request is the summary of a control API request sent to the webhook
+type Request struct {
+	// Method is the control API method, e.g. "Solve", "ValidateSolve" or
+	// "Prune"
+	Method   string    `json:"method"`
+	Identity *Identity `json:"identity,omitempty"`
+
+	Ref          string   `json:"ref,omitempty"`
+	Frontend     string   `json:"frontend,omitempty"`
+	Exporters    []string `json:"exporters,omitempty"`
+	CacheImports []string `json:"cacheImports,omitempty"`
+	CacheExports []string `json:"cacheExports,omitempty"`
+	Entitlements []string `json:"entitlements,omitempty"`
+	Filters      []string `json:"filters,omitempty"`
+	All          bool     `json:"all,omitempty"`
+	Delete       bool     `json:"delete,omitempty"`
+	Pinned       bool     `json:"pinned,omitempty"`
+	Repair       bool     `json:"repair,omitempty"`
+	LeaseID      string   `json:"leaseID,omitempty"`
+	Platforms    []string `json:"platforms,omitempty"`
+}
+
+// Response is the decision returned by the webhook
+type Response struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// WebhookOpt configures the authorization webhook
+type WebhookOpt struct {
+	// Endpoint is the HTTP(S) URL requests are POSTed to
+	Endpoint string
+	Timeout  time.Duration
+	// FailOpen allows requests when the webhook can't be reached or returns
+	// an invalid response. Requests are denied in that case by default.
+	FailOpen bool
+	// CA, Cert and Key configure TLS for HTTPS endpoints
+	CA   string
+	Cert string
+	Key  string
+}
+
+type webhook struct {
+	opt    WebhookOpt
+	client *http.Client
+}
+
+// NewWebhookAuthorizer returns an Authorizer that POSTs a JSON encoded
+// Request to the configured endpoint for every authorized call and expects
+// a JSON encoded Response.
+func NewWebhookAuthorizer(opt WebhookOpt) (Authorizer, error) {
+	if opt.Endpoint == "" {
+		return nil, errors.New("authorization endpoint not set")
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = defaultTimeout
+	}
+	tlsConfig, err := ClientTLSConfig(opt.CA, opt.Cert, opt.Key)
+	if err != nil {
+		return nil, err
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = tlsConfig
+	return &webhook{
+		opt: opt,
+		client: &http.Client{
+			Transport: tr,
+			Timeout:   opt.Timeout,
+		},
+	}, nil
+}
+
+func (w *webhook) Authorize(ctx context.Context, req *Request) error {
+	resp, err := w.call(ctx, req)
+	if err != nil {
+		if w.opt.FailOpen {
+			bklog.G(ctx).Warnf("authorization webhook failed, allowing %s: %v", req.Method, err)
+			return nil
+		}
+		bklog.G(ctx).Errorf("authorization webhook failed, denying %s: %v", req.Method, err)
+		return status.Errorf(codes.PermissionDenied, "authorization failed for %s", req.Method)
+	}
+	if !resp.Allowed {
+		if resp.Reason != "" {
+			return status.Errorf(codes.PermissionDenied, "%s denied: %s", req.Method, resp.Reason)
+		}
+		return status.Errorf(codes.PermissionDenied, "%s denied", req.Method)
+	}
+	return nil
+}
+
+func (w *webhook) call(ctx context.Context, req *Request) (*Response, error) {
+	dt, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	hreq, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodPost, w.opt.Endpoint, bytes.NewReader(dt))
+	if err != nil {
+		return nil, err
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+
+	hresp, err := w.client.Do(hreq)
+	if err != nil {
+		return nil, err
+	}
+	defer hresp.Body.Close()
+
+	if hresp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", hresp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(hresp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	var resp Response
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, errors.Wrap(err, "invalid authorization response")
+	}
+	return &resp, nil
+}
+
+// ClientTLSConfig returns the TLS configuration for a webhook client with
+// the CA and client key pair files. It returns nil if none are set.
+func ClientTLSConfig(ca, cert, key string) (*tls.Config, error) {
+	if ca == "" && cert == "" && key == "" {
+		return nil, nil
+	}
+	tc := &tls.Config{}
+	if ca != "" {
+		dt, err := os.ReadFile(ca)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read ca certificate")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(dt) {
+			return nil, errors.New("failed to append ca cert")
+		}
+		tc.RootCAs = pool
+	}
+	if cert != "" || key != "" {
+		kp, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load client key pair")
+		}
+		tc.Certificates = []tls.Certificate{kp}
+	}
+	return tc, nil
+}
+
+func identityFromContext(ctx context.Context) *Identity {
+	id := clientidentity.FromContext(ctx)
+	if id == nil {
+		return nil
+	}
+	return &Identity{Name: id.Name, Method: id.Method}
+}
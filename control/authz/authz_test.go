@@ -0,0 +1,122 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/util/clientidentity"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWebhookAuthorizer(t *testing.T) {
+	var received []Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		received = append(received, req)
+		resp := Response{Allowed: req.Identity != nil && req.Identity.Name == "admin"}
+		if !resp.Allowed {
+			resp.Reason = "not an admin"
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	defer srv.Close()
+
+	a, err := NewWebhookAuthorizer(WebhookOpt{Endpoint: srv.URL})
+	require.NoError(t, err)
+
+	intercept := UnaryServerInterceptor(a)
+	info := &grpc.UnaryServerInfo{FullMethod: controlapi.Control_Solve_FullMethodName}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return &controlapi.SolveResponse{}, nil
+	}
+	req := &controlapi.SolveRequest{
+		Ref:          "ref1",
+		Frontend:     "dockerfile.v0",
+		Entitlements: []string{"network.host"},
+		Exporters:    []*controlapi.Exporter{{Type: "image"}},
+		Cache: &controlapi.CacheOptions{
+			Imports: []*controlapi.CacheOptionsEntry{{Type: "registry"}},
+		},
+	}
+
+	ctx := clientidentity.WithIdentity(context.TODO(), &clientidentity.Identity{Name: "admin", Method: clientidentity.MethodTLS})
+	_, err = intercept(ctx, req, info, handler)
+	require.NoError(t, err)
+
+	ctx = clientidentity.WithIdentity(context.TODO(), &clientidentity.Identity{Name: "user", Method: clientidentity.MethodTLS})
+	_, err = intercept(ctx, req, info, handler)
+	require.Error(t, err)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Contains(t, err.Error(), "not an admin")
+
+	require.Len(t, received, 2)
+	require.Equal(t, Request{
+		Method:       "Solve",
+		Identity:     &Identity{Name: "admin", Method: "tls"},
+		Ref:          "ref1",
+		Frontend:     "dockerfile.v0",
+		Exporters:    []string{"image"},
+		CacheImports: []string{"registry"},
+		Entitlements: []string{"network.host"},
+	}, received[0])
+
+	// sessions expose the resources of other clients
+	_, err = intercept(ctx, &controlapi.ListSessionsRequest{}, &grpc.UnaryServerInfo{FullMethod: controlapi.Control_ListSessions_FullMethodName}, handler)
+	require.Error(t, err)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Len(t, received, 3)
+	require.Equal(t, "ListSessions", received[2].Method)
+
+	_, err = intercept(ctx, req, &grpc.UnaryServerInfo{FullMethod: controlapi.Control_ValidateSolve_FullMethodName}, handler)
+	require.Error(t, err)
+	require.Len(t, received, 4)
+	require.Equal(t, "ValidateSolve", received[3].Method)
+	require.Equal(t, "ref1", received[3].Ref)
+
+	_, err = intercept(ctx, &controlapi.ReloadConfigRequest{}, &grpc.UnaryServerInfo{FullMethod: controlapi.Control_ReloadConfig_FullMethodName}, handler)
+	require.Error(t, err)
+	require.Len(t, received, 5)
+	require.Equal(t, "ReloadConfig", received[4].Method)
+
+	_, err = intercept(ctx, &controlapi.DrainRequest{}, &grpc.UnaryServerInfo{FullMethod: controlapi.Control_Drain_FullMethodName}, handler)
+	require.Error(t, err)
+	require.Len(t, received, 6)
+	require.Equal(t, "Drain", received[5].Method)
+
+	_, err = intercept(ctx, &controlapi.CancelPlatformsRequest{Ref: "ref1", Platforms: []string{"linux/arm64"}}, &grpc.UnaryServerInfo{FullMethod: controlapi.Control_CancelPlatforms_FullMethodName}, handler)
+	require.Error(t, err)
+	require.Len(t, received, 7)
+	require.Equal(t, "CancelPlatforms", received[6].Method)
+	require.Equal(t, "ref1", received[6].Ref)
+	require.Equal(t, []string{"linux/arm64"}, received[6].Platforms)
+
+	// methods not subject to authorization are not sent to the webhook
+	_, err = intercept(ctx, &controlapi.InfoRequest{}, &grpc.UnaryServerInfo{FullMethod: controlapi.Control_Info_FullMethodName}, handler)
+	require.NoError(t, err)
+	require.Len(t, received, 7)
+}
+
+func TestWebhookAuthorizerFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	a, err := NewWebhookAuthorizer(WebhookOpt{Endpoint: srv.URL})
+	require.NoError(t, err)
+	err = a.Authorize(context.TODO(), &Request{Method: "Prune"})
+	require.Error(t, err)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	a, err = NewWebhookAuthorizer(WebhookOpt{Endpoint: srv.URL, FailOpen: true})
+	require.NoError(t, err)
+	require.NoError(t, a.Authorize(context.TODO(), &Request{Method: "Prune"}))
+}
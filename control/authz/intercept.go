@@ -0,0 +1,174 @@
+package authz
+
+import (
+	"context"
+	"sync"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor returns an interceptor authorizing unary control
+// API calls. Methods that are not subject to authorization pass through.
+func UnaryServerInterceptor(a Authorizer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if r := newRequest(info.FullMethod, req); r != nil {
+			r.Identity = identityFromContext(ctx)
+			if err := a.Authorize(ctx, r); err != nil {
+				return nil, err
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns an interceptor authorizing server streaming
+// control API calls. The request is authorized when it is first received.
+func StreamServerInterceptor(a Authorizer) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if !isAuthorizedMethod(info.FullMethod) {
+			return handler(srv, ss)
+		}
+		return handler(srv, &authorizedStream{ServerStream: ss, method: info.FullMethod, authorizer: a})
+	}
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	method     string
+	authorizer Authorizer
+	once       sync.Once
+	err        error
+}
+
+func (s *authorizedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	s.once.Do(func() {
+		if r := newRequest(s.method, m); r != nil {
+			ctx := s.Context()
+			r.Identity = identityFromContext(ctx)
+			s.err = s.authorizer.Authorize(ctx, r)
+		}
+	})
+	return s.err
+}
+
+func isAuthorizedMethod(method string) bool {
+	switch method {
+	case controlapi.Control_Solve_FullMethodName,
+		controlapi.Control_ValidateSolve_FullMethodName,
+		controlapi.Control_Prune_FullMethodName,
+		controlapi.Control_DiskUsage_FullMethodName,
+		controlapi.Control_VerifyCache_FullMethodName,
+		controlapi.Control_ListResultLeases_FullMethodName,
+		controlapi.Control_RenewResultLease_FullMethodName,
+		controlapi.Control_ReleaseResultLease_FullMethodName,
+		controlapi.Control_ListSessions_FullMethodName,
+		controlapi.Control_ListenBuildHistory_FullMethodName,
+		controlapi.Control_UpdateBuildHistory_FullMethodName,
+		controlapi.Control_ReloadConfig_FullMethodName,
+		controlapi.Control_Drain_FullMethodName,
+		controlapi.Control_CancelPlatforms_FullMethodName:
+		return true
+	}
+	return false
+}
+
+func newRequest(method string, msg any) *Request {
+	if !isAuthorizedMethod(method) {
+		return nil
+	}
+	switch req := msg.(type) {
+	case *controlapi.SolveRequest:
+		// the validations are authorized like the solves they check
+		name := "Solve"
+		if method == controlapi.Control_ValidateSolve_FullMethodName {
+			name = "ValidateSolve"
+		}
+		r := &Request{
+			Method:       name,
+			Ref:          req.Ref,
+			Frontend:     req.Frontend,
+			Entitlements: req.Entitlements,
+		}
+		for _, ex := range req.Exporters {
+			r.Exporters = append(r.Exporters, ex.Type)
+		}
+		if req.ExporterDeprecated != "" {
+			r.Exporters = append(r.Exporters, req.ExporterDeprecated)
+		}
+		if req.Cache != nil {
+			for _, im := range req.Cache.Imports {
+				r.CacheImports = append(r.CacheImports, im.Type)
+			}
+			for _, ex := range req.Cache.Exports {
+				r.CacheExports = append(r.CacheExports, ex.Type)
+			}
+		}
+		return r
+	case *controlapi.PruneRequest:
+		return &Request{
+			Method:  "Prune",
+			Filters: req.Filter,
+			All:     req.All,
+		}
+	case *controlapi.DiskUsageRequest:
+		return &Request{
+			Method:  "DiskUsage",
+			Filters: req.Filter,
+		}
+	case *controlapi.VerifyCacheRequest:
+		return &Request{
+			Method: "VerifyCache",
+			Repair: req.Repair,
+		}
+	case *controlapi.ListResultLeasesRequest:
+		return &Request{
+			Method: "ListResultLeases",
+		}
+	case *controlapi.RenewResultLeaseRequest:
+		return &Request{
+			Method:  "RenewResultLease",
+			LeaseID: req.ID,
+		}
+	case *controlapi.ReleaseResultLeaseRequest:
+		return &Request{
+			Method:  "ReleaseResultLease",
+			LeaseID: req.ID,
+		}
+	case *controlapi.ListSessionsRequest:
+		return &Request{
+			Method: "ListSessions",
+		}
+	case *controlapi.BuildHistoryRequest:
+		return &Request{
+			Method:  "ListenBuildHistory",
+			Ref:     req.Ref,
+			Filters: req.Filter,
+		}
+	case *controlapi.UpdateBuildHistoryRequest:
+		return &Request{
+			Method: "UpdateBuildHistory",
+			Ref:    req.Ref,
+			Delete: req.Delete,
+			Pinned: req.Pinned,
+		}
+	case *controlapi.ReloadConfigRequest:
+		return &Request{
+			Method: "ReloadConfig",
+		}
+	case *controlapi.DrainRequest:
+		return &Request{
+			Method: "Drain",
+		}
+	case *controlapi.CancelPlatformsRequest:
+		return &Request{
+			Method:    "CancelPlatforms",
+			Ref:       req.Ref,
+			Platforms: req.Platforms,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package control
+
+import (
+	"context"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+)
+
+func (c *Controller) CancelPlatforms(ctx context.Context, r *controlapi.CancelPlatformsRequest) (*controlapi.CancelPlatformsResponse, error) {
+	if err := c.solver.CancelPlatforms(r.Ref, r.Platforms); err != nil {
+		return nil, err
+	}
+	return &controlapi.CancelPlatformsResponse{}, nil
+}
@@ -1,11 +1,16 @@
 package control
 
 import (
+	"cmp"
 	"context"
 	stderrors "errors"
 	"fmt"
+	"maps"
+	"math"
 	"runtime/trace"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,7 +26,9 @@ import (
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/client/llb"
 	"github.com/moby/buildkit/cmd/buildkitd/config"
+	"github.com/moby/buildkit/control/events"
 	controlgateway "github.com/moby/buildkit/control/gateway"
+	"github.com/moby/buildkit/control/logsink"
 	"github.com/moby/buildkit/exporter"
 	"github.com/moby/buildkit/exporter/containerimage/exptypes"
 	"github.com/moby/buildkit/exporter/util/epoch"
@@ -37,11 +44,17 @@ import (
 	"github.com/moby/buildkit/solver/llbsolver/proc"
 	provenancetypes "github.com/moby/buildkit/solver/llbsolver/provenance/types"
 	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/archutil"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/cacheevents"
+	"github.com/moby/buildkit/util/clientidentity"
 	"github.com/moby/buildkit/util/db"
 	"github.com/moby/buildkit/util/entitlements"
 	"github.com/moby/buildkit/util/imageutil"
+	"github.com/moby/buildkit/util/leader"
 	"github.com/moby/buildkit/util/leaseutil"
+	"github.com/moby/buildkit/util/progress/logs"
+	"github.com/moby/buildkit/util/proxy"
 	"github.com/moby/buildkit/util/throttle"
 	"github.com/moby/buildkit/util/tracing/transform"
 	"github.com/moby/buildkit/version"
@@ -66,6 +79,8 @@ type Opt struct {
 	ResolveCacheExporterFuncs map[string]remotecache.ResolveCacheExporterFunc
 	ResolveCacheImporterFuncs map[string]remotecache.ResolveCacheImporterFunc
 	Entitlements              []string
+	IdentityEntitlements      map[clientidentity.Identity][]string
+	IdentityQuotas            map[clientidentity.Identity]int64
 	TraceCollector            sdktrace.SpanExporter
 	HistoryDB                 db.DB
 	CacheStore                *bboltcachestorage.Store
@@ -74,6 +89,44 @@ type Opt struct {
 	HistoryConfig             *config.HistoryConfig
 	GarbageCollect            func(context.Context) error
 	GracefulStop              <-chan struct{}
+	// EventSinks receive build and prune events
+	EventSinks []events.Sink
+	// LogSinks receive the output of the steps of every build
+	LogSinks []logsink.Sink
+	// SpeculativeExecution limits the number of vertices executed
+	// speculatively based on previous builds
+	SpeculativeExecution int
+	// ConcurrencyLimits limits the operations of each type running at the
+	// same time
+	ConcurrencyLimits config.ConcurrencyLimits
+	// Retry is the policy for retrying the steps that fail with a transient
+	// error
+	Retry solver.RetryPolicy
+	// CacheVerify configures the background verification of the cache
+	CacheVerify *config.CacheVerifyConfig
+	// StepLogs limits the output of the steps sent to the clients
+	StepLogs *config.StepLogConfig
+	// StepLogsSpillDir keeps the clipped output of the steps until it is
+	// added to the build history if StepLogs spills to the history
+	StepLogsSpillDir string
+	// ReadOnly serves the cache and the content of the daemon without
+	// changing them. Builds are only solved from the cache, and the requests
+	// that would execute ops or change the store are refused.
+	ReadOnly bool
+	// DNSResolvers are the nameservers the builds select by name for their
+	// exec ops
+	DNSResolvers map[string]string
+	// ReloadConfig reloads the config of the daemon for the ReloadConfig
+	// API. The API is unimplemented if it is nil.
+	ReloadConfig func(context.Context) (*ConfigReload, error)
+	// DrainTimeout is the duration Drain waits for the running solves by
+	// default before canceling them, they are waited for without a timeout
+	// if it is 0
+	DrainTimeout time.Duration
+	// Leader elects the daemon running the garbage collection and prune
+	// among the daemons sharing the store. Both run on every daemon if it is
+	// nil.
+	Leader *leader.Elector
 }
 
 type Controller struct { // TODO: ControlService
@@ -87,12 +140,41 @@ type Controller struct { // TODO: ControlService
 	throttledGC                  func()
 	throttledReleaseUnreferenced func()
 	gcmu                         sync.Mutex
+	gcStatusMu                   sync.Mutex
+	gcStatus                     map[string]client.GCStatus
+	sessionBuildsMu              sync.Mutex
+	sessionBuilds                map[string]map[string]struct{}
+	metrics                      *metrics
+	verifyCancel                 context.CancelCauseFunc
+	verifyDone                   chan struct{}
+	drainer                      *drainer
 	tracev1.UnimplementedTraceServiceServer
 }
 
 func NewController(opt Opt) (*Controller, error) {
 	gatewayForwarder := controlgateway.NewGatewayForwarder()
 
+	c := &Controller{
+		opt:              opt,
+		cache:            opt.CacheManager,
+		gatewayForwarder: gatewayForwarder,
+		sessionBuilds:    map[string]map[string]struct{}{},
+		gcStatus:         map[string]client.GCStatus{},
+		drainer:          newDrainer(),
+	}
+
+	var stepLogLimits logs.Limits
+	var spillDir string
+	if lc := opt.StepLogs; lc != nil {
+		stepLogLimits = logs.Limits{
+			MaxSize:  lc.MaxSize,
+			MaxSpeed: lc.MaxSpeed,
+		}
+		if lc.SpillToHistory {
+			spillDir = opt.StepLogsSpillDir
+		}
+	}
+
 	hq, err := llbsolver.NewHistoryQueue(llbsolver.HistoryQueueOpt{
 		DB:             opt.HistoryDB,
 		LeaseManager:   opt.LeaseManager,
@@ -100,36 +182,73 @@ func NewController(opt Opt) (*Controller, error) {
 		CleanConfig:    opt.HistoryConfig,
 		GarbageCollect: opt.GarbageCollect,
 		GracefulStop:   opt.GracefulStop,
+		OnEvent: func(e *controlapi.BuildHistoryEvent) {
+			if m := c.metrics; m != nil {
+				m.recordBuild(context.TODO(), e)
+			}
+			if len(opt.EventSinks) == 0 {
+				return
+			}
+			ev, err := events.FromHistoryEvent(e)
+			if err != nil {
+				bklog.L.Errorf("failed to create build event for %s: %v", e.Record.GetRef(), err)
+				return
+			}
+			sendEvent(opt.EventSinks, ev)
+		},
+		SpillDir: spillDir,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create history queue")
 	}
 
 	s, err := llbsolver.New(llbsolver.Opt{
-		WorkerController: opt.WorkerController,
-		Frontends:        opt.Frontends,
-		CacheManager:     opt.CacheManager,
-		CacheResolvers:   opt.ResolveCacheImporterFuncs,
-		GatewayForwarder: gatewayForwarder,
-		SessionManager:   opt.SessionManager,
-		Entitlements:     opt.Entitlements,
-		HistoryQueue:     hq,
+		WorkerController:     opt.WorkerController,
+		Frontends:            opt.Frontends,
+		CacheManager:         opt.CacheManager,
+		CacheResolvers:       opt.ResolveCacheImporterFuncs,
+		GatewayForwarder:     gatewayForwarder,
+		SessionManager:       opt.SessionManager,
+		Entitlements:         opt.Entitlements,
+		IdentityEntitlements: opt.IdentityEntitlements,
+		IdentityQuotas:       opt.IdentityQuotas,
+		HistoryQueue:         hq,
+		SpeculativeExecution: opt.SpeculativeExecution,
+		ConcurrencyLimits: llbsolver.ConcurrencyLimits{
+			Exec:      opt.ConcurrencyLimits.Exec,
+			ImagePull: opt.ConcurrencyLimits.ImagePull,
+			LocalSync: opt.ConcurrencyLimits.LocalSync,
+		},
+		StepLogLimits: stepLogLimits,
+		Retry:         opt.Retry,
+		LogSinks:      opt.LogSinks,
+		ReadOnly:      opt.ReadOnly,
+		DNSResolvers:  opt.DNSResolvers,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to create solver")
 	}
 
-	c := &Controller{
-		opt:              opt,
-		solver:           s,
-		history:          hq,
-		cache:            opt.CacheManager,
-		gatewayForwarder: gatewayForwarder,
+	c.solver = s
+	c.history = hq
+
+	m, err := newMetrics(c)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create metrics")
 	}
+	c.metrics = m
 	c.throttledGC = throttle.After(time.Minute, c.gc)
 	// use longer interval for releaseUnreferencedCache deleting links quickly is less important
 	c.throttledReleaseUnreferenced = throttle.After(5*time.Minute, func() { c.releaseUnreferencedCache(context.TODO()) })
 
+	if cv := opt.CacheVerify; cv != nil && cv.Interval.Duration > 0 {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		c.verifyCancel = cancel
+		c.verifyDone = make(chan struct{})
+		// a read-only daemon only reports the broken cache
+		go c.verifyCacheLoop(ctx, cv.Interval.Duration, cv.Repair && !opt.ReadOnly)
+	}
+
 	defer func() {
 		time.AfterFunc(time.Second, c.throttledGC)
 	}()
@@ -138,13 +257,27 @@ func NewController(opt Opt) (*Controller, error) {
 }
 
 func (c *Controller) Close() error {
+	if c.verifyCancel != nil {
+		// running verifications are canceled so they don't use closed workers
+		c.verifyCancel(errors.WithStack(context.Canceled))
+		<-c.verifyDone
+	}
 	var errs []error
+	// stop collecting the disk usage before the workers are closed
+	if err := c.metrics.close(); err != nil {
+		errs = append(errs, err)
+	}
 	if err := c.opt.HistoryDB.Close(); err != nil {
 		errs = append(errs, err)
 	}
 	if err := c.opt.WorkerController.Close(); err != nil {
 		errs = append(errs, err)
 	}
+	if c.opt.Leader != nil {
+		if err := c.opt.Leader.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if err := c.opt.CacheStore.Close(); err != nil {
 		errs = append(errs, err)
 	}
@@ -169,14 +302,56 @@ func (c *Controller) DiskUsage(ctx context.Context, r *controlapi.DiskUsageReque
 	if err != nil {
 		return nil, err
 	}
-	for _, w := range workers {
-		du, err := w.DiskUsage(ctx, client.DiskUsageInfo{
+	// the page token is the last returned record prefixed with its worker
+	var pageWorker, after string
+	if r.PageToken != "" {
+		i := strings.LastIndex(r.PageToken, "/")
+		if i < 0 {
+			return nil, errors.Errorf("invalid disk usage page token %q", r.PageToken)
+		}
+		pageWorker, after = r.PageToken[:i], r.PageToken[i+1:]
+		i = slices.IndexFunc(workers, func(w worker.Worker) bool {
+			return w.ID() == pageWorker
+		})
+		if i < 0 {
+			return nil, errors.Errorf("invalid disk usage page token %q: worker %s not found", r.PageToken, pageWorker)
+		}
+		workers = workers[i:]
+	}
+	if r.Breakdown && r.PageToken == "" {
+		var all []*client.UsageInfo
+		for _, w := range workers {
+			du, err := w.DiskUsage(ctx, client.DiskUsageInfo{
+				Filter:   r.Filter,
+				AgeLimit: time.Duration(r.AgeLimit),
+			})
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, du...)
+		}
+		resp.Breakdown = usageBreakdown(all, time.Now())
+	}
+	remaining := int(r.PageSize)
+	for i, w := range workers {
+		info := client.DiskUsageInfo{
 			Filter:   r.Filter,
 			AgeLimit: time.Duration(r.AgeLimit),
-		})
+			PageSize: remaining,
+		}
+		if i == 0 {
+			info.After = after
+		}
+		du, err := w.DiskUsage(ctx, info)
 		if err != nil {
 			return nil, err
 		}
+		if r.PageSize > 0 {
+			remaining -= len(du)
+			if remaining == 0 {
+				resp.NextPageToken = w.ID() + "/" + du[len(du)-1].ID
+			}
+		}
 
 		for _, r := range du {
 			resp.Record = append(resp.Record, &controlapi.UsageRecord{
@@ -196,18 +371,78 @@ func (c *Controller) DiskUsage(ctx context.Context, r *controlapi.DiskUsageReque
 					return nil
 				}(),
 				RecordType: string(r.RecordType),
+				Client:     r.Client,
 				Shared:     r.Shared,
 			})
 		}
+		if resp.NextPageToken != "" {
+			break
+		}
 	}
 	return resp, nil
 }
 
+// usageAgeBuckets are the upper bounds of the time since the records were
+// last used in the usage breakdown
+var usageAgeBuckets = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// usageBreakdown sums the usage of the records by type and age bucket. The
+// records that were never used are aged by their creation time.
+func usageBreakdown(du []*client.UsageInfo, now time.Time) []*controlapi.UsageBreakdown {
+	type key struct {
+		recordType client.UsageRecordType
+		age        time.Duration
+	}
+	m := map[key]*controlapi.UsageBreakdown{}
+	for _, r := range du {
+		last := r.CreatedAt
+		if r.LastUsedAt != nil {
+			last = *r.LastUsedAt
+		}
+		var age time.Duration
+		if i := slices.IndexFunc(usageAgeBuckets, func(d time.Duration) bool { return now.Sub(last) < d }); i >= 0 {
+			age = usageAgeBuckets[i]
+		}
+		k := key{recordType: r.RecordType, age: age}
+		b, ok := m[k]
+		if !ok {
+			b = &controlapi.UsageBreakdown{RecordType: string(r.RecordType), Age: int64(age)}
+			m[k] = b
+		}
+		b.Count++
+		if r.Size > 0 {
+			b.Size += r.Size
+			if !r.InUse {
+				b.Reclaimable += r.Size
+			}
+		}
+	}
+	out := slices.Collect(maps.Values(m))
+	slices.SortFunc(out, func(a, b *controlapi.UsageBreakdown) int {
+		// the oldest bucket is last
+		ageA, ageB := a.Age, b.Age
+		if ageA == 0 {
+			ageA = math.MaxInt64
+		}
+		if ageB == 0 {
+			ageB = math.MaxInt64
+		}
+		return cmp.Or(cmp.Compare(a.RecordType, b.RecordType), cmp.Compare(ageA, ageB))
+	})
+	return out
+}
+
 func (c *Controller) releaseUnreferencedCache(ctx context.Context) error {
 	return c.cache.ReleaseUnreferenced(ctx)
 }
 
 func (c *Controller) Prune(req *controlapi.PruneRequest, stream controlapi.Control_PruneServer) error {
+	if c.opt.ReadOnly {
+		return errReadOnly("prune")
+	}
+	if l := c.opt.Leader; l != nil && !l.IsLeader() {
+		return status.Errorf(codes.FailedPrecondition, "prune runs on the leader of the shared store %s", l.Leader())
+	}
 	if atomic.LoadInt64(&c.buildCount) == 0 {
 		imageutil.CancelCacheLeases()
 	}
@@ -255,6 +490,13 @@ func (c *Controller) Prune(req *controlapi.PruneRequest, stream controlapi.Contr
 		return eg.Wait()
 	})
 
+	var pruned events.PruneSummary
+	defer func() {
+		if didPrune {
+			sendEvent(c.opt.EventSinks, events.NewPruneEvent(pruned))
+		}
+	}()
+
 	eg2.Go(func() error {
 		defer func() {
 			// drain channel on error
@@ -263,6 +505,9 @@ func (c *Controller) Prune(req *controlapi.PruneRequest, stream controlapi.Contr
 		}()
 		for r := range ch {
 			didPrune = true
+			pruned.Records++
+			pruned.Size += r.Size
+			recordEviction(r)
 			if err := stream.Send(&controlapi.UsageRecord{
 				// TODO: add worker info
 				ID:          r.ID,
@@ -280,6 +525,7 @@ func (c *Controller) Prune(req *controlapi.PruneRequest, stream controlapi.Contr
 					return nil
 				}(),
 				RecordType: string(r.RecordType),
+				Client:     r.Client,
 				Shared:     r.Shared,
 			}); err != nil {
 				return err
@@ -291,6 +537,15 @@ func (c *Controller) Prune(req *controlapi.PruneRequest, stream controlapi.Contr
 	return eg2.Wait()
 }
 
+func sendEvent(sinks []events.Sink, ev *events.Event) {
+	if ev == nil {
+		return
+	}
+	for _, s := range sinks {
+		s.Send(ev)
+	}
+}
+
 func (c *Controller) Export(ctx context.Context, req *tracev1.ExportTraceServiceRequest) (*tracev1.ExportTraceServiceResponse, error) {
 	if c.opt.TraceCollector == nil {
 		return nil, status.Errorf(codes.Unavailable, "trace collector not configured")
@@ -316,6 +571,9 @@ func (c *Controller) ListenBuildHistory(req *controlapi.BuildHistoryRequest, srv
 
 func (c *Controller) UpdateBuildHistory(ctx context.Context, req *controlapi.UpdateBuildHistoryRequest) (*controlapi.UpdateBuildHistoryResponse, error) {
 	if req.Delete {
+		if c.opt.ReadOnly {
+			return nil, errReadOnly("build history delete")
+		}
 		c.history.Finalize(ctx, req.Ref) // ignore error
 		err := c.history.Delete(ctx, req.Ref)
 		return &controlapi.UpdateBuildHistoryResponse{}, err
@@ -378,6 +636,11 @@ func translateLegacySolveRequest(req *controlapi.SolveRequest) {
 func (c *Controller) Solve(ctx context.Context, req *controlapi.SolveRequest) (*controlapi.SolveResponse, error) {
 	defer trace.StartRegion(ctx, "Solve").End()
 	trace.Logf(ctx, "Request", "solve request: %v", req.Ref)
+	ctx, done, err := c.drainer.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
 	atomic.AddInt64(&c.buildCount, 1)
 	defer atomic.AddInt64(&c.buildCount, -1)
 
@@ -385,17 +648,30 @@ func (c *Controller) Solve(ctx context.Context, req *controlapi.SolveRequest) (*
 		req.Cache = &controlapi.CacheOptions{} // make sure cache options are initialized
 	}
 	translateLegacySolveRequest(req)
+	if err := c.checkReadOnly(req); err != nil {
+		return nil, err
+	}
+
+	if req.Session != "" {
+		c.addSessionBuild(req.Session, req.Ref)
+		defer c.removeSessionBuild(req.Session, req.Ref)
+	}
 
 	defer func() {
 		time.AfterFunc(time.Second, c.throttledGC)
 	}()
 
-	// TODO: multiworker
-	// This is actually tricky, as the exporter should come from the worker that has the returned reference. We may need to delay this so that the solver loads this.
-	w, err := c.opt.WorkerController.GetDefault()
+	constraints, err := worker.ParseConstraints(req.WorkerConstraints)
+	if err != nil {
+		return nil, err
+	}
+	// The exporters and all the operations of the build use the selected
+	// worker.
+	w, releaseWorker, err := c.opt.WorkerController.Select(constraints)
 	if err != nil {
 		return nil, err
 	}
+	defer releaseWorker()
 
 	// if SOURCE_DATE_EPOCH is set, enable it for the exporters
 	if v, ok := epoch.ParseBuildArgs(req.FrontendAttrs); ok {
@@ -463,14 +739,23 @@ func (c *Controller) Solve(ctx context.Context, req *controlapi.SolveRequest) (*
 	}
 
 	var cacheImports []frontend.CacheOptionsEntry
-	for _, im := range req.Cache.Imports {
+	for i, im := range req.Cache.Imports {
 		if im == nil {
 			continue
 		}
-		cacheImports = append(cacheImports, frontend.CacheOptionsEntry{
+		entry := frontend.CacheOptionsEntry{
 			Type:  im.Type,
 			Attrs: im.Attrs,
-		})
+		}
+		cacheImports = append(cacheImports, entry)
+
+		exp, ok, err := c.resolveCacheBackfill(ctx, req.Session, entry, i)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cacheExporters = append(cacheExporters, exp)
+		}
 	}
 
 	attests, err := attestations.Parse(req.FrontendAttrs)
@@ -478,16 +763,58 @@ func (c *Controller) Solve(ctx context.Context, req *controlapi.SolveRequest) (*
 		return nil, err
 	}
 
+	procs, err := attestationProcessors(req.FrontendAttrs, attests)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.solver.Solve(ctx, req.Ref, req.Session, frontend.SolveRequest{
+		Frontend:       req.Frontend,
+		Definition:     req.Definition,
+		FrontendOpt:    req.FrontendAttrs,
+		FrontendInputs: req.FrontendInputs,
+		CacheImports:   cacheImports,
+	}, llbsolver.ExporterRequest{
+		Exporters:             expis,
+		CacheExporters:        cacheExporters,
+		EnableSessionExporter: req.EnableSessionExporter,
+		Lockfile:              req.Lockfile,
+		SessionRef:            req.SessionRef,
+		ResultLeaseTTL:        time.Duration(req.ResultLeaseTTL),
+	}, entitlementsFromPB(req.Entitlements), procs, req.Internal, req.SourcePolicy, concurrencyLimitsFromPB(req.ConcurrencyLimits), proxyConfigFromPB(req.Proxy), w)
+	if err != nil {
+		return nil, err
+	}
+	var exporterResponses []*controlapi.ExporterResponse
+	for _, r := range resp.ExporterResponses {
+		exporterResponses = append(exporterResponses, &controlapi.ExporterResponse{
+			Metadata: &controlapi.ExporterMetadata{
+				ID:   r.ID,
+				Type: r.Type,
+			},
+			Data: r.Data,
+		})
+	}
+	return &controlapi.SolveResponse{
+		ExporterResponse:  resp.ExporterResponse,
+		ExporterResponses: exporterResponses,
+	}, nil
+}
+
+// attestationProcessors returns the processors adding the attestations
+// requested with the frontend attributes to the result of a build
+func attestationProcessors(attrs map[string]string, attests map[string]map[string]string) ([]llbsolver.Processor, error) {
 	var procs []llbsolver.Processor
 
-	if attrs, ok := attests["sbom"]; ok {
+	if sbomAttrs, ok := attests["sbom"]; ok {
 		var ref reference.Named
 		params := make(map[string]string)
-		for k, v := range attrs {
+		for k, v := range sbomAttrs {
 			if k == "generator" {
 				if v == "" {
 					return nil, errors.Errorf("sbom generator cannot be empty")
 				}
+				var err error
 				ref, err = reference.ParseNormalizedNamed(v)
 				if err != nil {
 					return nil, errors.Wrapf(err, "failed to parse sbom generator %s", v)
@@ -499,22 +826,22 @@ func (c *Controller) Solve(ctx context.Context, req *controlapi.SolveRequest) (*
 		}
 
 		useCache := true
-		if v, ok := req.FrontendAttrs["no-cache"]; ok && v == "" {
+		if v, ok := attrs["no-cache"]; ok && v == "" {
 			// disable cache if cache is disabled for all stages
 			useCache = false
 		}
 		resolveMode := llb.ResolveModeDefault.String()
-		if v, ok := req.FrontendAttrs["image-resolve-mode"]; ok {
+		if v, ok := attrs["image-resolve-mode"]; ok {
 			resolveMode = v
 		}
 
 		procs = append(procs, proc.SBOMProcessor(ref.String(), useCache, resolveMode, params))
 	}
 
-	if attrs, ok := attests["provenance"]; ok {
+	if provAttrs, ok := attests["provenance"]; ok {
 		var slsaVersion provenancetypes.ProvenanceSLSA
 		params := make(map[string]string)
-		for k, v := range attrs {
+		for k, v := range provAttrs {
 			if k == "version" {
 				slsaVersion = provenancetypes.ProvenanceSLSA(v)
 				if err := slsaVersion.Validate(); err != nil {
@@ -526,24 +853,7 @@ func (c *Controller) Solve(ctx context.Context, req *controlapi.SolveRequest) (*
 		}
 		procs = append(procs, proc.ProvenanceProcessor(slsaVersion, params))
 	}
-
-	resp, err := c.solver.Solve(ctx, req.Ref, req.Session, frontend.SolveRequest{
-		Frontend:       req.Frontend,
-		Definition:     req.Definition,
-		FrontendOpt:    req.FrontendAttrs,
-		FrontendInputs: req.FrontendInputs,
-		CacheImports:   cacheImports,
-	}, llbsolver.ExporterRequest{
-		Exporters:             expis,
-		CacheExporters:        cacheExporters,
-		EnableSessionExporter: req.EnableSessionExporter,
-	}, entitlementsFromPB(req.Entitlements), procs, req.Internal, req.SourcePolicy)
-	if err != nil {
-		return nil, err
-	}
-	return &controlapi.SolveResponse{
-		ExporterResponse: resp.ExporterResponse,
-	}, nil
+	return procs, nil
 }
 
 func (c *Controller) Status(req *controlapi.StatusRequest, stream controlapi.Control_StatusServer) error {
@@ -610,6 +920,9 @@ func (c *Controller) ListWorkers(ctx context.Context, r *controlapi.ListWorkersR
 			GCPolicy:        toPBGCPolicy(w.GCPolicy()),
 			BuildkitVersion: toPBBuildkitVersion(w.BuildkitVersion()),
 			CDIDevices:      toPBCDIDevices(w.CDIManager()),
+			ActiveBuilds:    int64(c.opt.WorkerController.ActiveBuilds(w.ID())),
+			Status:          c.workerStatus(ctx, w),
+			Emulators:       toPBEmulators(w.Emulators()),
 		})
 	}
 	return resp, nil
@@ -625,7 +938,65 @@ func (c *Controller) Info(ctx context.Context, r *controlapi.InfoRequest) (*cont
 	}, nil
 }
 
+func (c *Controller) ListSessions(ctx context.Context, r *controlapi.ListSessionsRequest) (*controlapi.ListSessionsResponse, error) {
+	resp := &controlapi.ListSessionsResponse{}
+	for _, s := range c.opt.SessionManager.List() {
+		rec := &controlapi.SessionRecord{
+			ID:            s.ID,
+			SharedKey:     s.SharedKey,
+			CreatedAt:     timestamppb.New(s.CreatedAt),
+			Methods:       s.Methods,
+			BytesSent:     s.BytesSent,
+			BytesReceived: s.BytesReceived,
+			Builds:        c.sessionBuildRefs(s.ID),
+		}
+		for _, r := range s.Resources {
+			rec.Resources = append(rec.Resources, &controlapi.SessionResource{
+				Type: r.Type,
+				ID:   r.ID,
+			})
+		}
+		resp.Record = append(resp.Record, rec)
+	}
+	return resp, nil
+}
+
+func (c *Controller) addSessionBuild(sessionID, ref string) {
+	c.sessionBuildsMu.Lock()
+	defer c.sessionBuildsMu.Unlock()
+	refs, ok := c.sessionBuilds[sessionID]
+	if !ok {
+		refs = map[string]struct{}{}
+		c.sessionBuilds[sessionID] = refs
+	}
+	refs[ref] = struct{}{}
+}
+
+func (c *Controller) removeSessionBuild(sessionID, ref string) {
+	c.sessionBuildsMu.Lock()
+	defer c.sessionBuildsMu.Unlock()
+	if refs, ok := c.sessionBuilds[sessionID]; ok {
+		delete(refs, ref)
+		if len(refs) == 0 {
+			delete(c.sessionBuilds, sessionID)
+		}
+	}
+}
+
+func (c *Controller) sessionBuildRefs(sessionID string) []string {
+	c.sessionBuildsMu.Lock()
+	defer c.sessionBuildsMu.Unlock()
+	return slices.Sorted(maps.Keys(c.sessionBuilds[sessionID]))
+}
+
 func (c *Controller) gc() {
+	if c.opt.ReadOnly {
+		return
+	}
+	if l := c.opt.Leader; l != nil && !l.IsLeader() {
+		bklog.L.Debugf("skipping gc, %s is the leader of the shared store", l.Leader())
+		return
+	}
 	c.gcmu.Lock()
 	defer c.gcmu.Unlock()
 
@@ -637,21 +1008,42 @@ func (c *Controller) gc() {
 	eg, ctx := errgroup.WithContext(context.TODO())
 
 	var size int64
+	pruned := events.PruneSummary{GC: true}
 	ch := make(chan client.UsageInfo)
 	done := make(chan struct{})
 	go func() {
 		for ui := range ch {
 			size += ui.Size
+			pruned.Records++
+			recordEviction(ui)
 		}
 		close(done)
 	}()
 
 	for _, w := range workers {
 		eg.Go(func() error {
-			if policy := w.GCPolicy(); len(policy) > 0 {
-				return w.Prune(ctx, ch, policy...)
+			policy := w.GCPolicy()
+			if len(policy) == 0 {
+				return nil
 			}
-			return nil
+			c.gcStarted(w.ID())
+
+			var freed int64
+			wch := make(chan client.UsageInfo)
+			forwarded := make(chan struct{})
+			go func() {
+				for ui := range wch {
+					freed += ui.Size
+					ch <- ui
+				}
+				close(forwarded)
+			}()
+			err := w.Prune(ctx, wch, policy...)
+			close(wch)
+			<-forwarded
+
+			c.gcCompleted(w.ID(), freed, err)
+			return err
 		})
 	}
 
@@ -661,12 +1053,116 @@ func (c *Controller) gc() {
 		bklog.G(ctx).Errorf("gc error: %+v", err)
 	}
 	<-done
+	c.metrics.recordGC(ctx, size)
+	if pruned.Records > 0 {
+		pruned.Size = size
+		sendEvent(c.opt.EventSinks, events.NewPruneEvent(pruned))
+	}
 	if size > 0 {
 		bklog.G(ctx).Debugf("gc cleaned up %d bytes", size)
 		go c.throttledReleaseUnreferenced()
 	}
 }
 
+// recordEviction logs the cache event of a record removed by a prune or the
+// garbage collection
+func recordEviction(ui client.UsageInfo) {
+	if !cacheevents.Enabled() {
+		return
+	}
+	cacheevents.Record(cacheevents.Event{
+		Type:        cacheevents.Evict,
+		Record:      ui.ID,
+		Size:        ui.Size,
+		Description: ui.Description,
+	})
+}
+
+func (c *Controller) gcStarted(workerID string) {
+	c.gcStatusMu.Lock()
+	defer c.gcStatusMu.Unlock()
+	st := c.gcStatus[workerID]
+	now := time.Now()
+	st.Running = true
+	st.LastStarted = &now
+	c.gcStatus[workerID] = st
+}
+
+func (c *Controller) gcCompleted(workerID string, freed int64, err error) {
+	c.gcStatusMu.Lock()
+	defer c.gcStatusMu.Unlock()
+	st := c.gcStatus[workerID]
+	now := time.Now()
+	st.Running = false
+	st.LastCompleted = &now
+	st.LastFreed = freed
+	st.LastError = ""
+	if err != nil {
+		st.LastError = err.Error()
+	}
+	c.gcStatus[workerID] = st
+}
+
+func (c *Controller) workerStatus(ctx context.Context, w worker.Worker) *apitypes.WorkerStatus {
+	st, err := w.Status(ctx)
+	if err != nil {
+		bklog.G(ctx).Warnf("failed to get status of worker %s: %v", w.ID(), err)
+		st = &client.WorkerStatus{}
+	}
+	c.gcStatusMu.Lock()
+	st.GC = c.gcStatus[w.ID()]
+	c.gcStatusMu.Unlock()
+	return toPBWorkerStatus(st)
+}
+
+// resolveCacheBackfill returns the exporter writing the cache to a tiered
+// cache import with backfill enabled
+func (c *Controller) resolveCacheBackfill(ctx context.Context, sessionID string, im frontend.CacheOptionsEntry, i int) (llbsolver.RemoteCacheExporter, bool, error) {
+	var exp llbsolver.RemoteCacheExporter
+	if _, ok := im.Attrs["tier"]; !ok {
+		return exp, false, nil
+	}
+	v, ok := im.Attrs["backfill"]
+	if !ok {
+		return exp, false, nil
+	}
+	backfill, err := strconv.ParseBool(v)
+	if err != nil {
+		return exp, false, errors.Wrap(err, "failed to parse backfill")
+	}
+	if !backfill {
+		return exp, false, nil
+	}
+
+	attrs := maps.Clone(im.Attrs)
+	delete(attrs, "tier")
+	delete(attrs, "backfill")
+	if im.Type == "local" {
+		attrs["dest"] = attrs["src"]
+		delete(attrs, "src")
+		delete(attrs, "digest")
+	}
+	cacheExporterFunc, ok := c.opt.ResolveCacheExporterFuncs[im.Type]
+	if !ok {
+		return exp, false, errors.Errorf("cache import %q can't be backfilled", im.Type)
+	}
+	exp.Exporter, err = cacheExporterFunc(ctx, session.NewGroup(sessionID), attrs)
+	if err != nil {
+		return exp, false, errors.Wrapf(err, "failed to configure %v cache backfill", im.Type)
+	}
+	if exp.Exporter == nil {
+		return exp, false, nil
+	}
+	exp.CacheExportMode, _ = parseCacheExportMode(attrs["mode"])
+	// backfilling is best effort, the build already has the cache
+	exp.IgnoreError = true
+	exp.Backfill = &llbsolver.CacheBackfill{
+		Import: im,
+		ID:     strconv.Itoa(i),
+	}
+	return exp, true, nil
+}
+
 func parseCacheExportMode(mode string) (solver.CacheExportMode, bool) {
 	switch mode {
 	case "min":
@@ -708,6 +1204,17 @@ func toPBBuildkitVersion(in client.BuildkitVersion) *apitypes.BuildkitVersion {
 	}
 }
 
+func toPBEmulators(emulators []archutil.Emulator) []*apitypes.Emulator {
+	out := make([]*apitypes.Emulator, 0, len(emulators))
+	for _, e := range emulators {
+		out = append(out, &apitypes.Emulator{
+			Platform: pb.PlatformFromSpec(e.Platform),
+			Path:     e.Path,
+		})
+	}
+	return out
+}
+
 func toPBCDIDevices(manager *cdidevices.Manager) []*apitypes.CDIDevice {
 	if manager == nil {
 		return nil
@@ -725,6 +1232,41 @@ func toPBCDIDevices(manager *cdidevices.Manager) []*apitypes.CDIDevice {
 	return out
 }
 
+func toPBWorkerStatus(in *client.WorkerStatus) *apitypes.WorkerStatus {
+	out := &apitypes.WorkerStatus{
+		DiskTotal:      in.DiskTotal,
+		DiskFree:       in.DiskFree,
+		DiskAvailable:  in.DiskAvailable,
+		RunningExecs:   int64(in.RunningExecs),
+		CpuPressure:    toPBPressure(in.CPUPressure),
+		MemoryPressure: toPBPressure(in.MemoryPressure),
+		IoPressure:     toPBPressure(in.IOPressure),
+		Gc: &apitypes.GCStatus{
+			Running:   in.GC.Running,
+			LastFreed: in.GC.LastFreed,
+			LastError: in.GC.LastError,
+		},
+	}
+	if in.GC.LastStarted != nil {
+		out.Gc.LastStarted = timestamppb.New(*in.GC.LastStarted)
+	}
+	if in.GC.LastCompleted != nil {
+		out.Gc.LastCompleted = timestamppb.New(*in.GC.LastCompleted)
+	}
+	return out
+}
+
+func toPBPressure(in *client.PressureInfo) *apitypes.Pressure {
+	if in == nil {
+		return nil
+	}
+	return &apitypes.Pressure{
+		Avg10:  in.Avg10,
+		Avg60:  in.Avg60,
+		Avg300: in.Avg300,
+	}
+}
+
 func findDuplicateCacheOptions(cacheOpts []*controlapi.CacheOptionsEntry) ([]*controlapi.CacheOptionsEntry, error) {
 	seen := map[string]*controlapi.CacheOptionsEntry{}
 	duplicate := map[string]struct{}{}
@@ -790,6 +1332,30 @@ func sendTimestampHeader(srv grpc.ServerStream) error {
 	return srv.SendHeader(metadata.Pairs(timestampKey, time.Now().Format(time.RFC3339Nano)))
 }
 
+func concurrencyLimitsFromPB(l *controlapi.ConcurrencyLimits) *llbsolver.ConcurrencyLimits {
+	if l == nil {
+		return nil
+	}
+	return &llbsolver.ConcurrencyLimits{
+		Exec:      int(l.Exec),
+		ImagePull: int(l.ImagePull),
+		LocalSync: int(l.LocalSync),
+	}
+}
+
+func proxyConfigFromPB(p *controlapi.ProxyConfig) *proxy.Config {
+	if p == nil {
+		return nil
+	}
+	return &proxy.Config{
+		HTTPProxy:  p.HTTPProxy,
+		HTTPSProxy: p.HTTPSProxy,
+		NoProxy:    p.NoProxy,
+		AllProxy:   p.AllProxy,
+		Exec:       p.Exec,
+	}
+}
+
 func entitlementsFromPB(elems []string) []entitlements.Entitlement {
 	clone := make([]entitlements.Entitlement, len(elems))
 	for i, e := range elems {
@@ -797,3 +1363,37 @@ func entitlementsFromPB(elems []string) []entitlements.Entitlement {
 	}
 	return clone
 }
+
+// readOnlyExporters are the exporters that send the result to the client
+// without writing it to the store of the daemon
+var readOnlyExporters = map[string]struct{}{
+	client.ExporterLocal: {},
+	client.ExporterTar:   {},
+}
+
+// checkReadOnly refuses the solve requests that would change the store of a
+// read-only daemon
+func (c *Controller) checkReadOnly(req *controlapi.SolveRequest) error {
+	if !c.opt.ReadOnly {
+		return nil
+	}
+	for _, ex := range req.Exporters {
+		if _, ok := readOnlyExporters[ex.Type]; !ok {
+			return errReadOnly(ex.Type + " exporter")
+		}
+	}
+	if len(req.Cache.Exports) > 0 {
+		return errReadOnly("cache export")
+	}
+	if len(req.Cache.Imports) > 0 {
+		return errReadOnly("cache import")
+	}
+	if req.ResultLeaseTTL > 0 {
+		return errReadOnly("result lease")
+	}
+	return nil
+}
+
+func errReadOnly(what string) error {
+	return status.Errorf(codes.FailedPrecondition, "%s is not allowed, buildkitd is read-only", what)
+}
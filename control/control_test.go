@@ -1,10 +1,17 @@
 package control
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/worker"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestDuplicateCacheOptions(t *testing.T) {
@@ -146,3 +153,139 @@ func TestParseCacheExportIgnoreError(t *testing.T) {
 		})
 	}
 }
+
+type pagedWorker struct {
+	worker.Worker
+	id  string
+	ids []string
+}
+
+func (w *pagedWorker) ID() string {
+	return w.id
+}
+
+func (w *pagedWorker) DiskUsage(ctx context.Context, opt client.DiskUsageInfo) ([]*client.UsageInfo, error) {
+	var du []*client.UsageInfo
+	for _, id := range w.ids {
+		if strings.Compare(id, opt.After) <= 0 {
+			continue
+		}
+		if opt.PageSize > 0 && len(du) == opt.PageSize {
+			break
+		}
+		du = append(du, &client.UsageInfo{ID: id})
+	}
+	return du, nil
+}
+
+func TestDiskUsagePages(t *testing.T) {
+	t.Parallel()
+
+	wc := &worker.Controller{}
+	require.NoError(t, wc.Add(&pagedWorker{id: "w1", ids: []string{"a", "b", "c"}}))
+	require.NoError(t, wc.Add(&pagedWorker{id: "w2", ids: []string{"a", "d"}}))
+	c := &Controller{opt: Opt{WorkerController: wc}}
+
+	ctx := context.TODO()
+	resp, err := c.DiskUsage(ctx, &controlapi.DiskUsageRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.Record, 5)
+	require.Empty(t, resp.NextPageToken)
+
+	var ids, tokens []string
+	req := &controlapi.DiskUsageRequest{PageSize: 2}
+	for {
+		resp, err := c.DiskUsage(ctx, req)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(resp.Record), 2)
+		for _, r := range resp.Record {
+			ids = append(ids, r.ID)
+		}
+		if resp.NextPageToken == "" {
+			break
+		}
+		tokens = append(tokens, resp.NextPageToken)
+		req.PageToken = resp.NextPageToken
+	}
+	require.Equal(t, []string{"a", "b", "c", "a", "d"}, ids)
+	require.Equal(t, []string{"w1/b", "w2/a"}, tokens)
+
+	_, err = c.DiskUsage(ctx, &controlapi.DiskUsageRequest{PageSize: 2, PageToken: "w3/a"})
+	require.ErrorContains(t, err, "worker w3 not found")
+	_, err = c.DiskUsage(ctx, &controlapi.DiskUsageRequest{PageSize: 2, PageToken: "a"})
+	require.ErrorContains(t, err, "invalid disk usage page token")
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	c := &Controller{opt: Opt{ReadOnly: true}}
+	req := func(exporters []string, cache *controlapi.CacheOptions) *controlapi.SolveRequest {
+		r := &controlapi.SolveRequest{Cache: cache}
+		for _, ex := range exporters {
+			r.Exporters = append(r.Exporters, &controlapi.Exporter{Type: ex})
+		}
+		if r.Cache == nil {
+			r.Cache = &controlapi.CacheOptions{}
+		}
+		return r
+	}
+
+	require.NoError(t, c.checkReadOnly(req(nil, nil)))
+	require.NoError(t, c.checkReadOnly(req([]string{client.ExporterLocal, client.ExporterTar}, nil)))
+
+	err := c.checkReadOnly(req([]string{client.ExporterImage}, nil))
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.ErrorContains(t, err, "image exporter is not allowed")
+
+	err = c.checkReadOnly(req(nil, &controlapi.CacheOptions{Exports: []*controlapi.CacheOptionsEntry{{Type: "registry"}}}))
+	require.ErrorContains(t, err, "cache export is not allowed")
+	err = c.checkReadOnly(req(nil, &controlapi.CacheOptions{Imports: []*controlapi.CacheOptionsEntry{{Type: "registry"}}}))
+	require.ErrorContains(t, err, "cache import is not allowed")
+
+	r := req(nil, nil)
+	r.ResultLeaseTTL = int64(time.Hour)
+	err = c.checkReadOnly(r)
+	require.ErrorContains(t, err, "result lease is not allowed")
+
+	c.opt.ReadOnly = false
+	require.NoError(t, c.checkReadOnly(req([]string{client.ExporterImage}, nil)))
+}
+
+func TestReadOnlyRequests(t *testing.T) {
+	c := &Controller{opt: Opt{ReadOnly: true}}
+
+	_, err := c.VerifyCache(context.TODO(), &controlapi.VerifyCacheRequest{Repair: true})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.ErrorContains(t, err, "cache repair is not allowed")
+
+	_, err = c.UpdateBuildHistory(context.TODO(), &controlapi.UpdateBuildHistoryRequest{Ref: "ref1", Delete: true})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.ErrorContains(t, err, "build history delete is not allowed")
+}
+
+func TestUsageBreakdown(t *testing.T) {
+	now := time.Now()
+	lastUsed := now.Add(-2 * time.Hour)
+	du := []*client.UsageInfo{
+		{ID: "a", RecordType: client.UsageRecordTypeRegular, Size: 10, CreatedAt: now.Add(-time.Minute)},
+		{ID: "b", RecordType: client.UsageRecordTypeRegular, Size: 20, InUse: true, CreatedAt: now.Add(-48 * time.Hour), LastUsedAt: &lastUsed},
+		{ID: "c", RecordType: client.UsageRecordTypeRegular, Size: 30, CreatedAt: now.Add(-30 * time.Minute)},
+		{ID: "d", RecordType: client.UsageRecordTypeCacheMount, Size: 40, CreatedAt: now.Add(-60 * 24 * time.Hour)},
+	}
+
+	bd := usageBreakdown(du, now)
+	require.Len(t, bd, 3)
+
+	require.Equal(t, string(client.UsageRecordTypeCacheMount), bd[0].RecordType)
+	require.Equal(t, int64(0), bd[0].Age)
+	require.Equal(t, int64(40), bd[0].Size)
+
+	require.Equal(t, string(client.UsageRecordTypeRegular), bd[1].RecordType)
+	require.Equal(t, int64(time.Hour), bd[1].Age)
+	require.Equal(t, int64(2), bd[1].Count)
+	require.Equal(t, int64(40), bd[1].Size)
+	require.Equal(t, int64(40), bd[1].Reclaimable)
+
+	require.Equal(t, int64(24*time.Hour), bd[2].Age)
+	require.Equal(t, int64(20), bd[2].Size)
+	require.Equal(t, int64(0), bd[2].Reclaimable)
+}
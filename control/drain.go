@@ -0,0 +1,132 @@
+package control
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errDrainTimeout = errors.New("build canceled after the drain timeout of buildkitd")
+
+// drainer tracks the running solves so that the daemon can stop accepting
+// new ones and wait for the running ones before it stops
+type drainer struct {
+	mu       sync.Mutex
+	solves   map[int]context.CancelCauseFunc
+	nextID   int
+	draining bool
+	// idle is closed when no solve runs anymore during the drain
+	idle       chan struct{}
+	drainingCh chan struct{}
+	drainedCh  chan struct{}
+	result     *controlapi.DrainResponse
+}
+
+func newDrainer() *drainer {
+	return &drainer{
+		solves:     map[int]context.CancelCauseFunc{},
+		idle:       make(chan struct{}),
+		drainingCh: make(chan struct{}),
+		drainedCh:  make(chan struct{}),
+	}
+}
+
+// start registers a solve, it fails once the drain started. The returned
+// function must be called when the solve returns.
+func (d *drainer) start(ctx context.Context) (context.Context, func(), error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return nil, nil, status.Error(codes.Unavailable, "buildkitd is draining, no new builds are accepted")
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	id := d.nextID
+	d.nextID++
+	d.solves[id] = cancel
+	return ctx, func() {
+		d.mu.Lock()
+		delete(d.solves, id)
+		if d.draining && len(d.solves) == 0 {
+			close(d.idle)
+		}
+		d.mu.Unlock()
+		cancel(errors.WithStack(context.Canceled))
+	}, nil
+}
+
+// drain stops accepting solves and waits for the running ones, they are
+// canceled after the timeout if it is not 0. The concurrent calls wait for
+// the same drain.
+func (d *drainer) drain(timeout time.Duration) *controlapi.DrainResponse {
+	d.mu.Lock()
+	if d.draining {
+		d.mu.Unlock()
+		<-d.drainedCh
+		return d.result
+	}
+	d.draining = true
+	close(d.drainingCh)
+	running := len(d.solves)
+	if running == 0 {
+		close(d.idle)
+	}
+	d.mu.Unlock()
+
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timeoutCh = t.C
+	}
+	var canceled int
+	select {
+	case <-d.idle:
+	case <-timeoutCh:
+		d.mu.Lock()
+		canceled = len(d.solves)
+		for _, cancel := range d.solves {
+			cancel(errors.WithStack(errDrainTimeout))
+		}
+		d.mu.Unlock()
+		<-d.idle
+	}
+	d.result = &controlapi.DrainResponse{
+		Completed: int32(running - canceled),
+		Canceled:  int32(canceled),
+	}
+	close(d.drainedCh)
+	return d.result
+}
+
+// Drain stops accepting solves and waits for the running ones to finish,
+// including their exports. The daemon stops once Drained is closed.
+func (c *Controller) Drain(ctx context.Context, req *controlapi.DrainRequest) (*controlapi.DrainResponse, error) {
+	timeout := time.Duration(req.Timeout)
+	if timeout == 0 {
+		timeout = c.opt.DrainTimeout
+	}
+	if timeout < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid drain timeout %s", timeout)
+	}
+	bklog.G(ctx).Infof("draining, waiting for %d running builds", atomic.LoadInt64(&c.buildCount))
+	res := c.drainer.drain(timeout)
+	bklog.G(ctx).Infof("drained, %d builds completed, %d builds canceled", res.Completed, res.Canceled)
+	return res, nil
+}
+
+// Draining is closed when the drain starts
+func (c *Controller) Draining() <-chan struct{} {
+	return c.drainer.drainingCh
+}
+
+// Drained is closed when the drain is done
+func (c *Controller) Drained() <-chan struct{} {
+	return c.drainer.drainedCh
+}
@@ -0,0 +1,55 @@
+package control
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDrain(t *testing.T) {
+	d := newDrainer()
+	ctx1, done1, err := d.start(context.TODO())
+	require.NoError(t, err)
+	ctx2, done2, err := d.start(context.TODO())
+	require.NoError(t, err)
+
+	resCh := make(chan *controlapi.DrainResponse, 2)
+	go func() { resCh <- d.drain(200 * time.Millisecond) }()
+	<-d.drainingCh
+	go func() { resCh <- d.drain(0) }()
+
+	_, _, err = d.start(context.TODO())
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	done1()
+	require.ErrorIs(t, context.Cause(ctx1), context.Canceled)
+
+	// the solve running after the timeout is canceled
+	<-ctx2.Done()
+	require.True(t, errors.Is(context.Cause(ctx2), errDrainTimeout))
+	select {
+	case <-d.drainedCh:
+		t.Fatal("drained before the canceled solve returned")
+	default:
+	}
+	done2()
+
+	expected := &controlapi.DrainResponse{Completed: 1, Canceled: 1}
+	require.Equal(t, expected, <-resCh)
+	require.Equal(t, expected, <-resCh)
+	require.Equal(t, expected, d.drain(0))
+}
+
+func TestDrainIdle(t *testing.T) {
+	d := newDrainer()
+	_, done, err := d.start(context.TODO())
+	require.NoError(t, err)
+	done()
+	require.Equal(t, &controlapi.DrainResponse{}, d.drain(0))
+}
@@ -0,0 +1,93 @@
+// Package events delivers build events of buildkitd to external webhooks.
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/identity"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Type is the type of a build event
+type Type string
+
+const (
+	BuildStarted   Type = "build.started"
+	BuildSucceeded Type = "build.succeeded"
+	BuildFailed    Type = "build.failed"
+	CachePruned    Type = "cache.pruned"
+)
+
+// Types lists all event types
+var Types = []Type{BuildStarted, BuildSucceeded, BuildFailed, CachePruned}
+
+// Event is the JSON payload POSTed to webhooks
+type Event struct {
+	// ID uniquely identifies the event and is also sent in the
+	// X-Buildkit-Delivery header
+	ID   string    `json:"id"`
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	// Record is the build history record of build events
+	Record json.RawMessage `json:"record,omitempty"`
+	// Prune summarizes the records removed by cache.pruned events
+	Prune *PruneSummary `json:"prune,omitempty"`
+}
+
+// PruneSummary is the result of a cache prune
+type PruneSummary struct {
+	Records int   `json:"records"`
+	Size    int64 `json:"size"`
+	// GC is set if the records were removed by the automatic garbage
+	// collection of the daemon
+	GC bool `json:"gc,omitempty"`
+}
+
+// Sink receives build events
+type Sink interface {
+	// Send queues the event for delivery. It must not block.
+	Send(ev *Event)
+}
+
+// FromHistoryEvent converts a build history event to a build event. It
+// returns nil for history events that are not delivered.
+func FromHistoryEvent(e *controlapi.BuildHistoryEvent) (*Event, error) {
+	if e.Record == nil {
+		return nil, nil
+	}
+	var typ Type
+	switch e.Type {
+	case controlapi.BuildHistoryEventType_STARTED:
+		typ = BuildStarted
+	case controlapi.BuildHistoryEventType_COMPLETE:
+		typ = BuildSucceeded
+		if e.Record.Error != nil && codes.Code(e.Record.Error.Code) != codes.OK {
+			typ = BuildFailed
+		}
+	default:
+		return nil, nil
+	}
+	dt, err := protojson.Marshal(e.Record)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		ID:     identity.NewID(),
+		Type:   typ,
+		Time:   time.Now().UTC(),
+		Record: dt,
+	}, nil
+}
+
+// NewPruneEvent returns a cache.pruned event
+func NewPruneEvent(summary PruneSummary) *Event {
+	return &Event{
+		ID:    identity.NewID(),
+		Type:  CachePruned,
+		Time:  time.Now().UTC(),
+		Prune: &summary,
+	}
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/moby/buildkit/control/authz"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	queueSize      = 128
+	maxAttempts    = 3
+)
+
+const (
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the request body
+	// computed with the configured secret, prefixed with "sha256="
+	SignatureHeader = "X-Buildkit-Signature-256"
+	EventHeader     = "X-Buildkit-Event"
+	DeliveryHeader  = "X-Buildkit-Delivery"
+)
+
+// WebhookOpt configures an event webhook
+type WebhookOpt struct {
+	// Endpoint is the HTTP(S) URL events are POSTed to
+	Endpoint string
+	// Secret is the key used to sign the payloads. Payloads are not signed
+	// if it is empty.
+	Secret string
+	// Events limits the events that are delivered. All events are delivered
+	// if it is empty.
+	Events  []Type
+	Timeout time.Duration
+	// CA, Cert and Key configure TLS for HTTPS endpoints
+	CA   string
+	Cert string
+	Key  string
+}
+
+type webhook struct {
+	opt    WebhookOpt
+	client *http.Client
+	queue  chan *Event
+	retry  time.Duration
+}
+
+// NewWebhookSink returns a Sink that POSTs every event as JSON to the
+// configured endpoint. Events are delivered in order by a background
+// goroutine and failed deliveries are retried a few times before the event
+// is dropped.
+func NewWebhookSink(opt WebhookOpt) (Sink, error) {
+	if opt.Endpoint == "" {
+		return nil, errors.New("webhook endpoint not set")
+	}
+	for _, t := range opt.Events {
+		if !slices.Contains(Types, t) {
+			return nil, errors.Errorf("invalid webhook event %q", t)
+		}
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = defaultTimeout
+	}
+	tlsConfig, err := authz.ClientTLSConfig(opt.CA, opt.Cert, opt.Key)
+	if err != nil {
+		return nil, err
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = tlsConfig
+	w := &webhook{
+		opt: opt,
+		client: &http.Client{
+			Transport: tr,
+			Timeout:   opt.Timeout,
+		},
+		queue: make(chan *Event, queueSize),
+		retry: time.Second,
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *webhook) Send(ev *Event) {
+	if len(w.opt.Events) > 0 && !slices.Contains(w.opt.Events, ev.Type) {
+		return
+	}
+	select {
+	case w.queue <- ev:
+	default:
+		bklog.L.Warnf("webhook queue for %s is full, dropping %s event %s", w.opt.Endpoint, ev.Type, ev.ID)
+	}
+}
+
+func (w *webhook) run() {
+	for ev := range w.queue {
+		var err error
+		for i := range maxAttempts {
+			if i > 0 {
+				time.Sleep(w.retry << (i - 1))
+			}
+			if err = w.deliver(context.TODO(), ev); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			bklog.L.Errorf("failed to deliver %s event %s to %s: %v", ev.Type, ev.ID, w.opt.Endpoint, err)
+		}
+	}
+}
+
+func (w *webhook) deliver(ctx context.Context, ev *Event) error {
+	dt, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.opt.Endpoint, bytes.NewReader(dt))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(ev.Type))
+	req.Header.Set(DeliveryHeader, ev.ID)
+	if w.opt.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign([]byte(w.opt.Secret), dt))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the value of the signature header for a payload
+func Sign(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/stretchr/testify/require"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+)
+
+func TestWebhookSink(t *testing.T) {
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	ch := make(chan delivery, 10)
+	failures := 1
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dt, _ := io.ReadAll(r.Body)
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		ch <- delivery{header: r.Header, body: dt}
+	}))
+	defer srv.Close()
+
+	s, err := NewWebhookSink(WebhookOpt{
+		Endpoint: srv.URL,
+		Secret:   "s3cret",
+		Events:   []Type{BuildFailed, CachePruned},
+	})
+	require.NoError(t, err)
+	s.(*webhook).retry = time.Millisecond
+
+	started, err := FromHistoryEvent(&controlapi.BuildHistoryEvent{
+		Type:   controlapi.BuildHistoryEventType_STARTED,
+		Record: &controlapi.BuildHistoryRecord{Ref: "ref1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, BuildStarted, started.Type)
+	s.Send(started)
+
+	failed, err := FromHistoryEvent(&controlapi.BuildHistoryEvent{
+		Type: controlapi.BuildHistoryEventType_COMPLETE,
+		Record: &controlapi.BuildHistoryRecord{
+			Ref:   "ref1",
+			Error: &spb.Status{Code: int32(codes.Unknown), Message: "failed to solve"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, BuildFailed, failed.Type)
+	s.Send(failed)
+	s.Send(NewPruneEvent(PruneSummary{Records: 2, Size: 1024}))
+
+	// started event is filtered, the failed event is delivered after a retry
+	d := <-ch
+	require.Equal(t, string(BuildFailed), d.header.Get(EventHeader))
+	require.Equal(t, failed.ID, d.header.Get(DeliveryHeader))
+	require.Equal(t, Sign([]byte("s3cret"), d.body), d.header.Get(SignatureHeader))
+	var ev Event
+	require.NoError(t, json.Unmarshal(d.body, &ev))
+	require.Equal(t, BuildFailed, ev.Type)
+	var rec map[string]any
+	require.NoError(t, json.Unmarshal(ev.Record, &rec))
+	require.Equal(t, "ref1", rec["Ref"])
+
+	d = <-ch
+	require.Equal(t, string(CachePruned), d.header.Get(EventHeader))
+	require.NoError(t, json.Unmarshal(d.body, &ev))
+	require.Equal(t, &PruneSummary{Records: 2, Size: 1024}, ev.Prune)
+}
+
+func TestWebhookInvalidEvent(t *testing.T) {
+	_, err := NewWebhookSink(WebhookOpt{
+		Endpoint: "http://localhost",
+		Events:   []Type{"build.unknown"},
+	})
+	require.ErrorContains(t, err, "invalid webhook event")
+}
+
+func TestSucceededEvent(t *testing.T) {
+	ev, err := FromHistoryEvent(&controlapi.BuildHistoryEvent{
+		Type:   controlapi.BuildHistoryEventType_COMPLETE,
+		Record: &controlapi.BuildHistoryRecord{Ref: "ref1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, BuildSucceeded, ev.Type)
+
+	ev, err = FromHistoryEvent(&controlapi.BuildHistoryEvent{
+		Type:   controlapi.BuildHistoryEventType_DELETED,
+		Record: &controlapi.BuildHistoryRecord{Ref: "ref1"},
+	})
+	require.NoError(t, err)
+	require.Nil(t, ev)
+}
+
+func TestWebhookSinkTLS(t *testing.T) {
+	ch := make(chan string, 1)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ch <- r.Header.Get(EventHeader)
+	}))
+	defer srv.Close()
+
+	ca := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(ca, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0600)
+	require.NoError(t, err)
+
+	s, err := NewWebhookSink(WebhookOpt{
+		Endpoint: srv.URL,
+		CA:       ca,
+	})
+	require.NoError(t, err)
+	s.Send(NewPruneEvent(PruneSummary{Records: 1, GC: true}))
+
+	select {
+	case typ := <-ch:
+		require.Equal(t, string(CachePruned), typ)
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+
+	_, err = NewWebhookSink(WebhookOpt{
+		Endpoint: srv.URL,
+		CA:       filepath.Join(t.TempDir(), "missing.pem"),
+	})
+	require.ErrorContains(t, err, "could not read ca certificate")
+}
@@ -203,3 +203,11 @@ func (gwf *GatewayForwarder) Warn(ctx context.Context, req *gwapi.WarnRequest) (
 	}
 	return fwd.Warn(ctx, req)
 }
+
+func (gwf *GatewayForwarder) CheckSession(ctx context.Context, req *gwapi.CheckSessionRequest) (*gwapi.CheckSessionResponse, error) {
+	fwd, err := gwf.lookupForwarder(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "forwarding CheckSession")
+	}
+	return fwd.CheckSession(ctx, req)
+}
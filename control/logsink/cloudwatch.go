@@ -0,0 +1,191 @@
+package logsink
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	aws_config "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/pkg/errors"
+)
+
+const defaultCloudWatchLogStream = "buildkitd"
+
+// CloudWatchOpt configures a sink putting the logs to Amazon CloudWatch Logs.
+// The credentials are loaded from the default AWS credential chain of the
+// daemon: environment, shared config and instance roles.
+type CloudWatchOpt struct {
+	// Region defaults to the region of the AWS config of the daemon
+	Region string
+	// LogGroup is the existing log group the logs are put to
+	LogGroup string
+	// LogStream is the log stream of the group the logs are put to. It is
+	// created if it doesn't exist. Defaults to "buildkitd".
+	LogStream string
+	// Endpoint overrides the endpoint of the CloudWatch Logs API
+	Endpoint string
+	Timeout  time.Duration
+}
+
+type cloudWatch struct {
+	opt    CloudWatchOpt
+	cfg    aws.Config
+	client *http.Client
+	signer *v4.Signer
+
+	mu            sync.Mutex
+	streamCreated bool
+}
+
+type cloudWatchEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// NewCloudWatchSink returns a Sink that puts the log entries to CloudWatch
+// Logs. CloudWatch has no labels so every event is the JSON encoding of the
+// entry.
+func NewCloudWatchSink(ctx context.Context, opt CloudWatchOpt) (Sink, error) {
+	if opt.LogGroup == "" {
+		return nil, errors.New("cloudwatch log group not set")
+	}
+	if opt.LogStream == "" {
+		opt.LogStream = defaultCloudWatchLogStream
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = defaultTimeout
+	}
+	var optFns []func(*aws_config.LoadOptions) error
+	if opt.Region != "" {
+		optFns = append(optFns, aws_config.WithRegion(opt.Region))
+	}
+	cfg, err := aws_config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load AWS config")
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("cloudwatch region not set")
+	}
+	if opt.Endpoint == "" {
+		opt.Endpoint = "https://logs." + cfg.Region + ".amazonaws.com/"
+	}
+	cw := &cloudWatch{
+		opt:    opt,
+		cfg:    cfg,
+		client: &http.Client{Timeout: opt.Timeout},
+		signer: v4.NewSigner(),
+	}
+	return newBatcher(opt.Endpoint, cw.push), nil
+}
+
+func (cw *cloudWatch) push(ctx context.Context, entries []*Entry) error {
+	if err := cw.createStream(ctx); err != nil {
+		return err
+	}
+	// the events of a batch must be in chronological order
+	events := make([]cloudWatchEvent, 0, len(entries))
+	for _, e := range entries {
+		dt, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		events = append(events, cloudWatchEvent{Timestamp: e.Time.UnixMilli(), Message: string(dt)})
+	}
+	slices.SortStableFunc(events, func(a, b cloudWatchEvent) int {
+		return cmp.Compare(a.Timestamp, b.Timestamp)
+	})
+	err := cw.call(ctx, "PutLogEvents", map[string]any{
+		"logGroupName":  cw.opt.LogGroup,
+		"logStreamName": cw.opt.LogStream,
+		"logEvents":     events,
+	})
+	if isAWSError(err, "ResourceNotFoundException") {
+		// the stream was removed, create it again on the next attempt
+		cw.mu.Lock()
+		cw.streamCreated = false
+		cw.mu.Unlock()
+	}
+	return err
+}
+
+func (cw *cloudWatch) createStream(ctx context.Context) error {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	if cw.streamCreated {
+		return nil
+	}
+	err := cw.call(ctx, "CreateLogStream", map[string]any{
+		"logGroupName":  cw.opt.LogGroup,
+		"logStreamName": cw.opt.LogStream,
+	})
+	if err != nil && !isAWSError(err, "ResourceAlreadyExistsException") {
+		return errors.Wrapf(err, "failed to create log stream %s", cw.opt.LogStream)
+	}
+	cw.streamCreated = true
+	return nil
+}
+
+type awsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+	status  string
+}
+
+func (e *awsError) Error() string {
+	return e.Type + ": " + e.Message + " (" + e.status + ")"
+}
+
+func isAWSError(err error, typ string) bool {
+	var ae *awsError
+	// the type may be prefixed with the namespace of the service
+	return errors.As(err, &ae) && (ae.Type == typ || strings.HasSuffix(ae.Type, "#"+typ))
+}
+
+// call calls an action of the JSON API of CloudWatch Logs
+func (cw *cloudWatch) call(ctx context.Context, action string, in any) error {
+	dt, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cw.opt.Endpoint, bytes.NewReader(dt))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+
+	creds, err := cw.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to retrieve AWS credentials")
+	}
+	sum := sha256.Sum256(dt)
+	if err := cw.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(sum[:]), "logs", cw.cfg.Region, time.Now()); err != nil {
+		return err
+	}
+
+	resp, err := cw.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	ae := &awsError{status: resp.Status}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err := json.Unmarshal(body, ae); err != nil || ae.Type == "" {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return errors.WithStack(ae)
+}
@@ -0,0 +1,81 @@
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/moby/buildkit/control/authz"
+	"github.com/pkg/errors"
+)
+
+// HTTPOpt configures a sink POSTing the logs to an HTTP endpoint
+type HTTPOpt struct {
+	// Endpoint is the HTTP(S) URL the logs are POSTed to
+	Endpoint string
+	// Headers are added to every request, e.g. for authentication
+	Headers map[string]string
+	Timeout time.Duration
+	// CA, Cert and Key configure TLS for HTTPS endpoints
+	CA   string
+	Cert string
+	Key  string
+}
+
+func (opt HTTPOpt) client() (*http.Client, error) {
+	if opt.Endpoint == "" {
+		return nil, errors.New("log sink endpoint not set")
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = defaultTimeout
+	}
+	tlsConfig, err := authz.ClientTLSConfig(opt.CA, opt.Cert, opt.Key)
+	if err != nil {
+		return nil, err
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = tlsConfig
+	return &http.Client{
+		Transport: tr,
+		Timeout:   opt.Timeout,
+	}, nil
+}
+
+// post POSTs the JSON encoding of v to the endpoint of opt
+func post(ctx context.Context, c *http.Client, opt HTTPOpt, v any) error {
+	dt, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, opt.Endpoint, bytes.NewReader(dt))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range opt.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// NewHTTPSink returns a Sink that POSTs the log entries in batches to the
+// endpoint as a JSON array of entries
+func NewHTTPSink(opt HTTPOpt) (Sink, error) {
+	c, err := opt.client()
+	if err != nil {
+		return nil, err
+	}
+	return newBatcher(opt.Endpoint, func(ctx context.Context, entries []*Entry) error {
+		return post(ctx, c, opt, entries)
+	}), nil
+}
@@ -0,0 +1,163 @@
+// Package logsink ships the logs of the build steps of buildkitd to external
+// log services. The logs are shipped from the build on the daemon so clients
+// don't have to stay attached to the build for its logs to be collected.
+package logsink
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/bklog"
+	digest "github.com/opencontainers/go-digest"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	queueSize      = 4096
+	maxBatchSize   = 500
+	flushInterval  = time.Second
+	maxAttempts    = 3
+)
+
+// Entry is a line of the output of a build step
+type Entry struct {
+	Time time.Time `json:"time"`
+	// BuildID is the ref of the build
+	BuildID    string        `json:"build"`
+	Vertex     digest.Digest `json:"vertex"`
+	VertexName string        `json:"vertexName"`
+	// Stream is "stdout" or "stderr"
+	Stream string `json:"stream"`
+	Line   string `json:"line"`
+}
+
+// Sink receives the log entries of the builds
+type Sink interface {
+	// Send queues the entry for delivery. It must not block.
+	Send(e *Entry)
+}
+
+// Forward sends the logs of the status stream of a build to the sinks until
+// ch is closed. The output of the steps is split into lines, a line that is
+// not terminated is sent when the stream of the step ends.
+func Forward(sinks []Sink, buildID string, ch chan *client.SolveStatus) {
+	type streamKey struct {
+		vertex digest.Digest
+		stream int
+	}
+	names := map[digest.Digest]string{}
+	partial := map[streamKey][]byte{}
+
+	send := func(k streamKey, tm time.Time, line []byte) {
+		e := &Entry{
+			Time:       tm,
+			BuildID:    buildID,
+			Vertex:     k.vertex,
+			VertexName: names[k.vertex],
+			Stream:     streamName(k.stream),
+			Line:       string(bytes.TrimSuffix(line, []byte("\r"))),
+		}
+		for _, s := range sinks {
+			s.Send(e)
+		}
+	}
+
+	for ss := range ch {
+		for _, v := range ss.Vertexes {
+			names[v.Digest] = v.Name
+		}
+		for _, l := range ss.Logs {
+			k := streamKey{vertex: l.Vertex, stream: l.Stream}
+			dt := append(partial[k], l.Data...)
+			for {
+				i := bytes.IndexByte(dt, '\n')
+				if i < 0 {
+					break
+				}
+				send(k, l.Timestamp, dt[:i])
+				dt = dt[i+1:]
+			}
+			if len(dt) > 0 {
+				partial[k] = dt
+			} else {
+				delete(partial, k)
+			}
+		}
+	}
+	for k, dt := range partial {
+		send(k, time.Now(), dt)
+	}
+}
+
+func streamName(stream int) string {
+	if stream == 2 {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// batcher delivers the entries queued by Send in batches from a background
+// goroutine. A batch is delivered when it is full or after flushInterval.
+// Failed deliveries are retried a few times before the batch is dropped.
+type batcher struct {
+	name  string
+	queue chan *Entry
+	retry time.Duration
+	push  func(ctx context.Context, entries []*Entry) error
+}
+
+func newBatcher(name string, push func(ctx context.Context, entries []*Entry) error) *batcher {
+	b := &batcher{
+		name:  name,
+		queue: make(chan *Entry, queueSize),
+		retry: time.Second,
+		push:  push,
+	}
+	go b.run()
+	return b
+}
+
+func (b *batcher) Send(e *Entry) {
+	select {
+	case b.queue <- e:
+	default:
+		bklog.L.Warnf("log sink queue for %s is full, dropping logs of build %s", b.name, e.BuildID)
+	}
+}
+
+func (b *batcher) run() {
+	t := time.NewTicker(flushInterval)
+	defer t.Stop()
+
+	var batch []*Entry
+	for {
+		select {
+		case e := <-b.queue:
+			batch = append(batch, e)
+			if len(batch) < maxBatchSize {
+				continue
+			}
+		case <-t.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		b.flush(batch)
+		batch = nil
+	}
+}
+
+func (b *batcher) flush(batch []*Entry) {
+	var err error
+	for i := range maxAttempts {
+		if i > 0 {
+			time.Sleep(b.retry << (i - 1))
+		}
+		if err = b.push(context.TODO(), batch); err == nil {
+			return
+		}
+	}
+	bklog.L.Errorf("failed to ship %d log entries to %s: %v", len(batch), b.name, err)
+}
@@ -0,0 +1,172 @@
+package logsink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+type testSink struct {
+	mu      sync.Mutex
+	entries []*Entry
+}
+
+func (s *testSink) Send(e *Entry) {
+	s.mu.Lock()
+	s.entries = append(s.entries, e)
+	s.mu.Unlock()
+}
+
+func TestForward(t *testing.T) {
+	vtx := digest.FromString("vtx")
+	tm := time.Now()
+
+	ch := make(chan *client.SolveStatus)
+	s := &testSink{}
+	done := make(chan struct{})
+	go func() {
+		Forward([]Sink{s}, "build1", ch)
+		close(done)
+	}()
+
+	ch <- &client.SolveStatus{
+		Vertexes: []*client.Vertex{{Digest: vtx, Name: "[1/2] RUN make"}},
+		Logs: []*client.VertexLog{
+			{Vertex: vtx, Stream: 1, Data: []byte("line1\r\nli"), Timestamp: tm},
+			{Vertex: vtx, Stream: 2, Data: []byte("error\n"), Timestamp: tm},
+		},
+	}
+	ch <- &client.SolveStatus{
+		Logs: []*client.VertexLog{
+			{Vertex: vtx, Stream: 1, Data: []byte("ne2\nline3"), Timestamp: tm},
+		},
+	}
+	close(ch)
+	<-done
+
+	var lines []string
+	for _, e := range s.entries {
+		require.Equal(t, "build1", e.BuildID)
+		require.Equal(t, vtx, e.Vertex)
+		require.Equal(t, "[1/2] RUN make", e.VertexName)
+		lines = append(lines, e.Stream+":"+e.Line)
+	}
+	require.Equal(t, []string{"stdout:line1", "stderr:error", "stdout:line2", "stdout:line3"}, lines)
+}
+
+func TestLokiSink(t *testing.T) {
+	type push struct {
+		header http.Header
+		body   lokiPush
+	}
+	ch := make(chan push, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p push
+		p.header = r.Header
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&p.body))
+		ch <- p
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	s, err := NewLokiSink(LokiOpt{
+		HTTPOpt: HTTPOpt{
+			Endpoint: srv.URL,
+			Headers:  map[string]string{"X-Scope-OrgID": "builds"},
+		},
+		Labels: map[string]string{"host": "builder-1"},
+	})
+	require.NoError(t, err)
+
+	vtx := digest.FromString("vtx")
+	tm := time.Unix(10, 5)
+	s.Send(&Entry{Time: tm, BuildID: "build1", Vertex: vtx, VertexName: "RUN make", Stream: "stdout", Line: "line1"})
+	s.Send(&Entry{Time: tm, BuildID: "build1", Vertex: vtx, VertexName: "RUN make", Stream: "stderr", Line: "error"})
+	s.Send(&Entry{Time: tm, BuildID: "build1", Vertex: vtx, VertexName: "RUN make", Stream: "stdout", Line: "line2"})
+
+	var p push
+	select {
+	case p = <-ch:
+	case <-time.After(10 * time.Second):
+		t.Fatal("timeout waiting for push")
+	}
+	require.Equal(t, "builds", p.header.Get("X-Scope-OrgID"))
+	require.Len(t, p.body.Streams, 2)
+	require.Equal(t, map[string]string{
+		"host":        "builder-1",
+		"build":       "build1",
+		"vertex":      vtx.String(),
+		"vertex_name": "RUN make",
+		"stream":      "stdout",
+	}, p.body.Streams[0].Stream)
+	require.Equal(t, [][2]string{{"10000000005", "line1"}, {"10000000005", "line2"}}, p.body.Streams[0].Values)
+	require.Equal(t, "stderr", p.body.Streams[1].Stream["stream"])
+}
+
+func TestCloudWatchSink(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+
+	type call struct {
+		action string
+		body   map[string]any
+	}
+	ch := make(chan call, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")
+		dt, _ := io.ReadAll(r.Body)
+		c := call{action: action}
+		require.NoError(t, json.Unmarshal(dt, &c.body))
+		ch <- c
+		if action == "CreateLogStream" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceAlreadyExistsException","message":"The specified log stream already exists"}`))
+		}
+	}))
+	defer srv.Close()
+
+	s, err := NewCloudWatchSink(context.TODO(), CloudWatchOpt{
+		Region:   "us-east-1",
+		LogGroup: "builds",
+		Endpoint: srv.URL,
+	})
+	require.NoError(t, err)
+
+	vtx := digest.FromString("vtx")
+	s.Send(&Entry{Time: time.UnixMilli(2000), BuildID: "build1", Vertex: vtx, Stream: "stdout", Line: "line2"})
+	s.Send(&Entry{Time: time.UnixMilli(1000), BuildID: "build1", Vertex: vtx, Stream: "stdout", Line: "line1"})
+
+	var calls []call
+	for len(calls) < 2 {
+		select {
+		case c := <-ch:
+			calls = append(calls, c)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for calls")
+		}
+	}
+	require.Equal(t, "CreateLogStream", calls[0].action)
+	require.Equal(t, "buildkitd", calls[0].body["logStreamName"])
+	require.Equal(t, "PutLogEvents", calls[1].action)
+	events := calls[1].body["logEvents"].([]any)
+	require.Len(t, events, 2)
+	ev := events[0].(map[string]any)
+	require.Equal(t, float64(1000), ev["timestamp"])
+	var e Entry
+	require.NoError(t, json.Unmarshal([]byte(ev["message"].(string)), &e))
+	require.Equal(t, "line1", e.Line)
+	require.Equal(t, "build1", e.BuildID)
+}
@@ -0,0 +1,68 @@
+package logsink
+
+import (
+	"context"
+	"maps"
+	"strconv"
+)
+
+// LokiOpt configures a sink pushing the logs to Grafana Loki
+type LokiOpt struct {
+	// HTTPOpt configures the push API endpoint of Loki, e.g.
+	// http://loki:3100/loki/api/v1/push. The X-Scope-OrgID header sets the
+	// tenant of multi-tenant installations.
+	HTTPOpt
+	// Labels are added to the labels of every stream
+	Labels map[string]string
+}
+
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// NewLokiSink returns a Sink that pushes the log entries to Loki. Every step
+// of a build is a stream labeled with the build ref, the digest and name of
+// the step and the output stream.
+func NewLokiSink(opt LokiOpt) (Sink, error) {
+	c, err := opt.client()
+	if err != nil {
+		return nil, err
+	}
+	return newBatcher(opt.Endpoint, func(ctx context.Context, entries []*Entry) error {
+		return post(ctx, c, opt.HTTPOpt, lokiRequest(opt.Labels, entries))
+	}), nil
+}
+
+func lokiRequest(labels map[string]string, entries []*Entry) *lokiPush {
+	type streamKey struct {
+		build  string
+		vertex string
+		stream string
+	}
+	req := &lokiPush{}
+	streams := map[streamKey]*lokiStream{}
+	for _, e := range entries {
+		k := streamKey{build: e.BuildID, vertex: e.Vertex.String(), stream: e.Stream}
+		s, ok := streams[k]
+		if !ok {
+			l := maps.Clone(labels)
+			if l == nil {
+				l = map[string]string{}
+			}
+			l["build"] = e.BuildID
+			l["vertex"] = e.Vertex.String()
+			l["vertex_name"] = e.VertexName
+			l["stream"] = e.Stream
+			s = &lokiStream{Stream: l}
+			streams[k] = s
+			req.Streams = append(req.Streams, s)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), e.Line})
+	}
+	return req
+}
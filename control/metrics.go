@@ -0,0 +1,208 @@
+package control
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/worker/label"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/codes"
+)
+
+const diskUsageInterval = time.Minute
+
+// metrics holds the instruments of the controller. Counters are updated as
+// builds complete and GC runs while gauges are collected on demand by the
+// configured metric readers. The disk usage is computed in the background
+// because it walks all cache records.
+type metrics struct {
+	builds      metric.Int64Counter
+	steps       metric.Int64Counter
+	gcRuns      metric.Int64Counter
+	gcReclaimed metric.Int64Counter
+
+	totalSteps  atomic.Int64
+	cachedSteps atomic.Int64
+
+	mu      sync.Mutex
+	usage   map[attribute.Set]int64
+	refresh chan struct{}
+	done    chan struct{}
+	cancel  context.CancelCauseFunc
+
+	reg metric.Registration
+}
+
+func newMetrics(c *Controller) (*metrics, error) {
+	meter := otel.Meter("github.com/moby/buildkit/control")
+	m := &metrics{
+		refresh: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+
+	var err error
+	if m.builds, err = meter.Int64Counter("buildkit.builds",
+		metric.WithDescription("Number of completed builds."),
+	); err != nil {
+		return nil, err
+	}
+	if m.steps, err = meter.Int64Counter("buildkit.build.steps",
+		metric.WithDescription("Number of steps of completed builds."),
+	); err != nil {
+		return nil, err
+	}
+	if m.gcRuns, err = meter.Int64Counter("buildkit.gc.runs",
+		metric.WithDescription("Number of garbage collection runs."),
+	); err != nil {
+		return nil, err
+	}
+	if m.gcReclaimed, err = meter.Int64Counter("buildkit.gc.reclaimed",
+		metric.WithDescription("Size of the records removed by garbage collection."),
+		metric.WithUnit("By"),
+	); err != nil {
+		return nil, err
+	}
+
+	inFlight, err := meter.Int64ObservableGauge("buildkit.builds.active",
+		metric.WithDescription("Number of builds in flight."),
+	)
+	if err != nil {
+		return nil, err
+	}
+	hitRatio, err := meter.Float64ObservableGauge("buildkit.cache.hit_ratio",
+		metric.WithDescription("Ratio of cached steps to all steps of completed builds."),
+	)
+	if err != nil {
+		return nil, err
+	}
+	diskUsage, err := meter.Int64ObservableGauge("buildkit.worker.disk_usage",
+		metric.WithDescription("Disk usage of the build cache of each worker."),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	m.reg, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(inFlight, atomic.LoadInt64(&c.buildCount))
+		if total := m.totalSteps.Load(); total > 0 {
+			o.ObserveFloat64(hitRatio, float64(m.cachedSteps.Load())/float64(total))
+		}
+		m.mu.Lock()
+		for set, size := range m.usage {
+			o.ObserveInt64(diskUsage, size, metric.WithAttributeSet(set))
+		}
+		m.mu.Unlock()
+		return nil
+	}, inFlight, hitRatio, diskUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	m.cancel = cancel
+	go m.updateDiskUsage(ctx, c)
+	return m, nil
+}
+
+func (m *metrics) close() error {
+	m.cancel(errors.WithStack(context.Canceled))
+	<-m.done
+	return m.reg.Unregister()
+}
+
+// recordBuild updates the build counters from a history event of a
+// completed build.
+func (m *metrics) recordBuild(ctx context.Context, e *controlapi.BuildHistoryEvent) {
+	if e.Type != controlapi.BuildHistoryEventType_COMPLETE || e.Record == nil {
+		return
+	}
+	rec := e.Record
+	result := "succeeded"
+	if rec.Error != nil && codes.Code(rec.Error.Code) != codes.OK {
+		result = "failed"
+	}
+	m.builds.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+
+	cached := int64(rec.NumCachedSteps)
+	total := int64(rec.NumTotalSteps)
+	m.steps.Add(ctx, cached, metric.WithAttributes(attribute.Bool("cached", true)))
+	m.steps.Add(ctx, total-cached, metric.WithAttributes(attribute.Bool("cached", false)))
+	m.cachedSteps.Add(cached)
+	m.totalSteps.Add(total)
+}
+
+func (m *metrics) recordGC(ctx context.Context, size int64) {
+	m.gcRuns.Add(ctx, 1)
+	m.gcReclaimed.Add(ctx, size)
+	if size > 0 {
+		m.refreshDiskUsage()
+	}
+}
+
+// refreshDiskUsage asks for the disk usage to be computed again without
+// waiting for diskUsageInterval.
+func (m *metrics) refreshDiskUsage() {
+	select {
+	case m.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// updateDiskUsage computes the disk usage every diskUsageInterval, and when
+// a refresh is requested, until ctx is canceled.
+func (m *metrics) updateDiskUsage(ctx context.Context, c *Controller) {
+	defer close(m.done)
+	ticker := time.NewTicker(diskUsageInterval)
+	defer ticker.Stop()
+	for {
+		if usage, err := diskUsage(ctx, c); err == nil {
+			m.mu.Lock()
+			m.usage = usage
+			m.mu.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-m.refresh:
+		}
+	}
+}
+
+// diskUsage returns the size of the build cache of every worker
+func diskUsage(ctx context.Context, c *Controller) (map[attribute.Set]int64, error) {
+	workers, err := c.opt.WorkerController.List()
+	if err != nil {
+		return nil, err
+	}
+	usage := map[attribute.Set]int64{}
+	for _, w := range workers {
+		du, err := w.DiskUsage(ctx, client.DiskUsageInfo{})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, context.Cause(ctx)
+			}
+			bklog.G(ctx).Warnf("failed to get disk usage of worker %s: %v", w.ID(), err)
+			continue
+		}
+		var size int64
+		for _, r := range du {
+			size += r.Size
+		}
+		set := attribute.NewSet(
+			attribute.String("worker", w.ID()),
+			attribute.String("snapshotter", w.Labels()[label.Snapshotter]),
+		)
+		usage[set] = size
+	}
+	return usage, nil
+}
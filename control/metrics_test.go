@@ -0,0 +1,146 @@
+package control
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/worker"
+	"github.com/moby/buildkit/worker/label"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+)
+
+func TestBuildMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	c := &Controller{opt: Opt{WorkerController: &worker.Controller{}}}
+	m, err := newMetrics(c)
+	require.NoError(t, err)
+	defer m.close()
+
+	ctx := context.TODO()
+	c.buildCount = 2
+	m.recordBuild(ctx, &controlapi.BuildHistoryEvent{
+		Type:   controlapi.BuildHistoryEventType_STARTED,
+		Record: &controlapi.BuildHistoryRecord{Ref: "ref1"},
+	})
+	m.recordBuild(ctx, &controlapi.BuildHistoryEvent{
+		Type:   controlapi.BuildHistoryEventType_COMPLETE,
+		Record: &controlapi.BuildHistoryRecord{Ref: "ref1", NumTotalSteps: 4, NumCachedSteps: 3},
+	})
+	m.recordBuild(ctx, &controlapi.BuildHistoryEvent{
+		Type: controlapi.BuildHistoryEventType_COMPLETE,
+		Record: &controlapi.BuildHistoryRecord{
+			Ref:           "ref2",
+			NumTotalSteps: 4,
+			Error:         &spb.Status{Code: int32(codes.Unknown)},
+		},
+	})
+	m.recordGC(ctx, 100)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	values := map[string]map[attribute.Set]float64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, md := range sm.Metrics {
+			v := map[attribute.Set]float64{}
+			switch data := md.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, dp := range data.DataPoints {
+					v[dp.Attributes] = float64(dp.Value)
+				}
+			case metricdata.Gauge[int64]:
+				for _, dp := range data.DataPoints {
+					v[dp.Attributes] = float64(dp.Value)
+				}
+			case metricdata.Gauge[float64]:
+				for _, dp := range data.DataPoints {
+					v[dp.Attributes] = dp.Value
+				}
+			}
+			values[md.Name] = v
+		}
+	}
+
+	result := func(s string) attribute.Set { return attribute.NewSet(attribute.String("result", s)) }
+	cached := func(b bool) attribute.Set { return attribute.NewSet(attribute.Bool("cached", b)) }
+	require.Equal(t, map[attribute.Set]float64{result("succeeded"): 1, result("failed"): 1}, values["buildkit.builds"])
+	require.Equal(t, map[attribute.Set]float64{cached(true): 3, cached(false): 5}, values["buildkit.build.steps"])
+	require.Equal(t, map[attribute.Set]float64{*attribute.EmptySet(): 2}, values["buildkit.builds.active"])
+	require.Equal(t, map[attribute.Set]float64{*attribute.EmptySet(): 0.375}, values["buildkit.cache.hit_ratio"])
+	require.Equal(t, map[attribute.Set]float64{*attribute.EmptySet(): 1}, values["buildkit.gc.runs"])
+	require.Equal(t, map[attribute.Set]float64{*attribute.EmptySet(): 100}, values["buildkit.gc.reclaimed"])
+}
+
+type diskUsageWorker struct {
+	worker.Worker
+	calls atomic.Int64
+}
+
+func (w *diskUsageWorker) ID() string {
+	return "w1"
+}
+
+func (w *diskUsageWorker) Labels() map[string]string {
+	return map[string]string{label.Snapshotter: "overlayfs"}
+}
+
+func (w *diskUsageWorker) DiskUsage(ctx context.Context, opt client.DiskUsageInfo) ([]*client.UsageInfo, error) {
+	n := w.calls.Add(1)
+	return []*client.UsageInfo{{Size: 100 * n}, {Size: 10}}, nil
+}
+
+func TestDiskUsageMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	w := &diskUsageWorker{}
+	wc := &worker.Controller{}
+	require.NoError(t, wc.Add(w))
+	c := &Controller{opt: Opt{WorkerController: wc}}
+	m, err := newMetrics(c)
+	require.NoError(t, err)
+	defer m.close()
+
+	set := attribute.NewSet(attribute.String("worker", "w1"), attribute.String("snapshotter", "overlayfs"))
+	usage := func() int64 {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.TODO(), &rm))
+		for _, sm := range rm.ScopeMetrics {
+			for _, md := range sm.Metrics {
+				if md.Name != "buildkit.worker.disk_usage" {
+					continue
+				}
+				for _, dp := range md.Data.(metricdata.Gauge[int64]).DataPoints {
+					if dp.Attributes.Equals(&set) {
+						return dp.Value
+					}
+				}
+			}
+		}
+		return 0
+	}
+
+	require.Eventually(t, func() bool { return usage() == 110 }, 10*time.Second, 10*time.Millisecond)
+	// collecting the gauge doesn't compute the disk usage again
+	usage()
+	require.Equal(t, int64(1), w.calls.Load())
+
+	// garbage collection refreshes the disk usage
+	m.recordGC(context.TODO(), 100)
+	require.Eventually(t, func() bool { return usage() == 210 }, 10*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,39 @@
+package control
+
+import (
+	"context"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/util/clientidentity"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ConfigReload is the result of reloading the config of the daemon
+type ConfigReload struct {
+	// Applied are the changed settings applied to the running daemon
+	Applied []string
+	// RequiresRestart are the changed settings that only apply after the
+	// daemon restarts
+	RequiresRestart []string
+}
+
+func (c *Controller) ReloadConfig(ctx context.Context, r *controlapi.ReloadConfigRequest) (*controlapi.ReloadConfigResponse, error) {
+	if c.opt.ReloadConfig == nil {
+		return nil, status.Error(codes.Unimplemented, "config reload is not supported by this daemon")
+	}
+	res, err := c.opt.ReloadConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &controlapi.ReloadConfigResponse{
+		Applied:         res.Applied,
+		RequiresRestart: res.RequiresRestart,
+	}, nil
+}
+
+// SetEntitlements replaces the entitlements the builds are allowed to
+// request, for the builds that start after it returns
+func (c *Controller) SetEntitlements(ents []string, identityEntitlements map[clientidentity.Identity][]string) {
+	c.solver.SetEntitlements(ents, identityEntitlements)
+}
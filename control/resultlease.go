@@ -0,0 +1,29 @@
+package control
+
+import (
+	"context"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+)
+
+func (c *Controller) ListResultLeases(ctx context.Context, r *controlapi.ListResultLeasesRequest) (*controlapi.ListResultLeasesResponse, error) {
+	return &controlapi.ListResultLeasesResponse{
+		Leases: c.solver.ListResultLeases(),
+	}, nil
+}
+
+func (c *Controller) RenewResultLease(ctx context.Context, r *controlapi.RenewResultLeaseRequest) (*controlapi.RenewResultLeaseResponse, error) {
+	l, err := c.solver.RenewResultLease(r.ID, time.Duration(r.TTL))
+	if err != nil {
+		return nil, err
+	}
+	return &controlapi.RenewResultLeaseResponse{Lease: l}, nil
+}
+
+func (c *Controller) ReleaseResultLease(ctx context.Context, r *controlapi.ReleaseResultLeaseRequest) (*controlapi.ReleaseResultLeaseResponse, error) {
+	if err := c.solver.ReleaseResultLease(r.ID); err != nil {
+		return nil, err
+	}
+	return &controlapi.ReleaseResultLeaseResponse{}, nil
+}
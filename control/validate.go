@@ -0,0 +1,131 @@
+package control
+
+import (
+	"context"
+	"fmt"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/frontend"
+	"github.com/moby/buildkit/frontend/attestations"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver/llbsolver"
+	"github.com/moby/buildkit/worker"
+	"github.com/pkg/errors"
+)
+
+// ValidateSolve checks a solve request without building it: the worker,
+// exporters, cache exports and attestations of the request here, and the rest
+// of the request in the solver. The problems are returned as diagnostics, the
+// request is valid if none of them is an error.
+func (c *Controller) ValidateSolve(ctx context.Context, req *controlapi.SolveRequest) (*controlapi.ValidateSolveResponse, error) {
+	if req.Cache == nil {
+		req.Cache = &controlapi.CacheOptions{}
+	}
+	translateLegacySolveRequest(req)
+
+	var diags []llbsolver.Diagnostic
+	add := func(check string, err error) {
+		diags = append(diags, llbsolver.Diagnostic{Check: check, Message: err.Error()})
+	}
+	warn := func(check string, msg string) {
+		diags = append(diags, llbsolver.Diagnostic{Check: check, Message: msg, Warning: true})
+	}
+
+	if err := c.checkReadOnly(req); err != nil {
+		add(llbsolver.CheckRequest, err)
+	}
+
+	constraints, err := worker.ParseConstraints(req.WorkerConstraints)
+	if err != nil {
+		add(llbsolver.CheckWorker, err)
+		return toPBDiagnostics(diags), nil
+	}
+	w, releaseWorker, err := c.opt.WorkerController.Select(constraints)
+	if err != nil {
+		add(llbsolver.CheckWorker, err)
+		return toPBDiagnostics(diags), nil
+	}
+	defer releaseWorker()
+
+	for i, ex := range req.Exporters {
+		exp, err := w.Exporter(ex.Type, c.opt.SessionManager)
+		if err != nil {
+			add(llbsolver.CheckExporter, err)
+			continue
+		}
+		if _, err := exp.Resolve(ctx, i, ex.Attrs); err != nil {
+			add(llbsolver.CheckExporter, errors.Wrapf(err, "invalid %s exporter", ex.Type))
+		}
+	}
+
+	if dups, err := findDuplicateCacheOptions(req.Cache.Exports); err != nil {
+		add(llbsolver.CheckCacheExport, err)
+	} else if dups != nil {
+		var types []string
+		for _, d := range dups {
+			types = append(types, d.Type)
+		}
+		add(llbsolver.CheckCacheExport, errors.Errorf("duplicate cache exports %s", types))
+	}
+	for _, e := range req.Cache.Exports {
+		cacheExporterFunc, ok := c.opt.ResolveCacheExporterFuncs[e.Type]
+		if !ok {
+			add(llbsolver.CheckCacheExport, errors.Errorf("unknown cache exporter: %q", e.Type))
+			continue
+		}
+		if _, err := cacheExporterFunc(ctx, session.NewGroup(req.Session), e.Attrs); err != nil {
+			add(llbsolver.CheckCacheExport, errors.Wrapf(err, "failed to configure %v cache exporter", e.Type))
+			continue
+		}
+		if _, supported := parseCacheExportMode(e.Attrs["mode"]); !supported {
+			warn(llbsolver.CheckCacheExport, fmt.Sprintf("invalid %v cache export mode %q is ignored", e.Type, e.Attrs["mode"]))
+		}
+		if v, ok := e.Attrs["ignore-error"]; ok {
+			if _, supported := parseCacheExportIgnoreError(v); !supported {
+				warn(llbsolver.CheckCacheExport, fmt.Sprintf("invalid %v cache export ignore-error %q is ignored", e.Type, v))
+			}
+		}
+	}
+
+	var cacheImports []frontend.CacheOptionsEntry
+	for _, im := range req.Cache.Imports {
+		if im == nil {
+			continue
+		}
+		cacheImports = append(cacheImports, frontend.CacheOptionsEntry{
+			Type:  im.Type,
+			Attrs: im.Attrs,
+		})
+	}
+
+	if attests, err := attestations.Parse(req.FrontendAttrs); err != nil {
+		add(llbsolver.CheckRequest, err)
+	} else if _, err := attestationProcessors(req.FrontendAttrs, attests); err != nil {
+		add(llbsolver.CheckRequest, err)
+	}
+
+	solverDiags, err := c.solver.Validate(ctx, req.Session, frontend.SolveRequest{
+		Frontend:       req.Frontend,
+		Definition:     req.Definition,
+		FrontendOpt:    req.FrontendAttrs,
+		FrontendInputs: req.FrontendInputs,
+		CacheImports:   cacheImports,
+	}, entitlementsFromPB(req.Entitlements), req.SourcePolicy, concurrencyLimitsFromPB(req.ConcurrencyLimits), proxyConfigFromPB(req.Proxy), w)
+	if err != nil {
+		return nil, err
+	}
+	return toPBDiagnostics(append(diags, solverDiags...)), nil
+}
+
+func toPBDiagnostics(diags []llbsolver.Diagnostic) *controlapi.ValidateSolveResponse {
+	resp := &controlapi.ValidateSolveResponse{}
+	for _, d := range diags {
+		resp.Diagnostics = append(resp.Diagnostics, &controlapi.SolveDiagnostic{
+			Check:   d.Check,
+			Message: d.Message,
+			Warning: d.Warning,
+			Vertex:  string(d.Vertex),
+		})
+	}
+	return resp
+}
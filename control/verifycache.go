@@ -0,0 +1,83 @@
+package control
+
+import (
+	"context"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/pkg/errors"
+)
+
+func (c *Controller) VerifyCache(ctx context.Context, r *controlapi.VerifyCacheRequest) (*controlapi.VerifyCacheResponse, error) {
+	if c.opt.ReadOnly && r.Repair {
+		return nil, errReadOnly("cache repair")
+	}
+	issues, err := c.verifyCache(ctx, r.Repair)
+	if err != nil {
+		return nil, err
+	}
+	resp := &controlapi.VerifyCacheResponse{}
+	for _, i := range issues {
+		resp.Issues = append(resp.Issues, &controlapi.CacheIssue{
+			ID:          i.ID,
+			Type:        string(i.Type),
+			Description: i.Description,
+			Repaired:    i.Repaired,
+		})
+	}
+	return resp, nil
+}
+
+func (c *Controller) verifyCache(ctx context.Context, repair bool) ([]client.CacheIssue, error) {
+	workers, err := c.opt.WorkerController.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list workers for cache verification")
+	}
+	var issues []client.CacheIssue
+	for _, w := range workers {
+		wi, err := w.CacheManager().Verify(ctx, repair)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to verify cache of worker %s", w.ID())
+		}
+		issues = append(issues, wi...)
+	}
+	if repair && len(issues) > 0 {
+		// release the cache keys of the removed records
+		if err := c.releaseUnreferencedCache(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to release unreferenced cache keys")
+		}
+	}
+	return issues, nil
+}
+
+// verifyCacheLoop verifies the cache of all workers every interval until ctx
+// is canceled
+func (c *Controller) verifyCacheLoop(ctx context.Context, interval time.Duration, repair bool) {
+	defer close(c.verifyDone)
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		issues, err := c.verifyCache(ctx, repair)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			bklog.G(ctx).Errorf("cache verification failed: %+v", err)
+			continue
+		}
+		for _, i := range issues {
+			bklog.G(ctx).WithFields(map[string]any{
+				"id":       i.ID,
+				"type":     i.Type,
+				"repaired": i.Repaired,
+			}).Warnf("inconsistent cache: %s", i.Description)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package control
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/worker"
+	"github.com/stretchr/testify/require"
+)
+
+type blockingVerifyManager struct {
+	cache.Manager
+	started chan struct{}
+}
+
+func (m *blockingVerifyManager) Verify(ctx context.Context, repair bool) ([]client.CacheIssue, error) {
+	select {
+	case m.started <- struct{}{}:
+	default:
+	}
+	<-ctx.Done()
+	return nil, context.Cause(ctx)
+}
+
+type verifyWorker struct {
+	worker.Worker
+	cm cache.Manager
+}
+
+func (w *verifyWorker) ID() string {
+	return "w1"
+}
+
+func (w *verifyWorker) CacheManager() cache.Manager {
+	return w.cm
+}
+
+func TestVerifyCacheLoopCancel(t *testing.T) {
+	t.Parallel()
+
+	cm := &blockingVerifyManager{started: make(chan struct{}, 1)}
+	wc := &worker.Controller{}
+	require.NoError(t, wc.Add(&verifyWorker{cm: cm}))
+	c := &Controller{opt: Opt{WorkerController: wc}}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	c.verifyCancel = cancel
+	c.verifyDone = make(chan struct{})
+	go c.verifyCacheLoop(ctx, time.Millisecond, true)
+
+	select {
+	case <-cm.started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("verification not started")
+	}
+
+	// canceling stops the running verification
+	c.verifyCancel(context.Canceled)
+	select {
+	case <-c.verifyDone:
+	case <-time.After(10 * time.Second):
+		t.Fatal("verification not canceled")
+	}
+}
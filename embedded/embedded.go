@@ -0,0 +1,267 @@
+// Package embedded runs the buildkitd controller and its workers in the
+// process of a Go program, e.g. a test framework or a development tool, and
+// serves the control API in memory to the clients of the program. The builds
+// use the same client.Client interface as with a buildkitd daemon.
+package embedded
+
+import (
+	"context"
+	stderrors "errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/containerd/containerd/v2/defaults"
+	"github.com/moby/buildkit/cache/remotecache"
+	inlineremotecache "github.com/moby/buildkit/cache/remotecache/inline"
+	localremotecache "github.com/moby/buildkit/cache/remotecache/local"
+	registryremotecache "github.com/moby/buildkit/cache/remotecache/registry"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/control"
+	"github.com/moby/buildkit/frontend"
+	dockerfile "github.com/moby/buildkit/frontend/dockerfile/builder"
+	"github.com/moby/buildkit/frontend/gateway"
+	"github.com/moby/buildkit/frontend/gateway/forwarder"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/bboltcachestorage"
+	"github.com/moby/buildkit/util/db/boltutil"
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/moby/buildkit/util/resolver"
+	resolverconfig "github.com/moby/buildkit/util/resolver/config"
+	"github.com/moby/buildkit/worker"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// Options are the options of an embedded daemon
+type Options struct {
+	// Root is the directory of the state of the daemon. A temporary
+	// directory removed by Close is used if it is empty.
+	Root string
+	// Workers creates the workers of the daemon, the first one is the
+	// default worker. The default is an OCI worker running runc, only
+	// available on Linux.
+	Workers func(ctx context.Context, opt WorkerOpt) ([]worker.Worker, error)
+	// Registries configures the registries like the registry section of
+	// buildkitd.toml
+	Registries map[string]resolverconfig.RegistryConfig
+	// Entitlements are the insecure entitlements the builds can request
+	Entitlements []string
+}
+
+// WorkerOpt are the components of the daemon the workers are created with
+type WorkerOpt struct {
+	// Root is the directory of the state of the daemon
+	Root           string
+	SessionManager *session.Manager
+	RegistryHosts  docker.RegistryHosts
+}
+
+// Daemon is a buildkitd controller running in the process
+type Daemon struct {
+	root       string
+	removeRoot bool
+	controller *control.Controller
+	server     *grpc.Server
+	listener   *memoryListener
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// New starts an embedded daemon, it runs until it is closed
+func New(ctx context.Context, opt Options) (_ *Daemon, err error) {
+	d := &Daemon{root: opt.Root}
+	if d.root == "" {
+		if d.root, err = os.MkdirTemp("", "buildkit-embedded-"); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		d.removeRoot = true
+	} else if err := os.MkdirAll(d.root, 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if d.root, err = filepath.Abs(d.root); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() {
+		if err != nil && d.removeRoot {
+			os.RemoveAll(d.root)
+		}
+	}()
+
+	if d.controller, err = newController(ctx, d.root, opt); err != nil {
+		return nil, err
+	}
+
+	d.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(grpcerrors.UnaryServerInterceptor),
+		grpc.ChainStreamInterceptor(grpcerrors.StreamServerInterceptor),
+		grpc.MaxRecvMsgSize(defaults.DefaultMaxRecvMsgSize),
+		grpc.MaxSendMsgSize(defaults.DefaultMaxSendMsgSize),
+	)
+	d.controller.Register(d.server)
+	d.listener = newMemoryListener()
+	go d.server.Serve(d.listener)
+	return d, nil
+}
+
+func newController(ctx context.Context, root string, opt Options) (_ *control.Controller, err error) {
+	sessionManager, err := session.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	hosts := resolver.NewRegistryConfig(opt.Registries)
+
+	newWorkers := opt.Workers
+	if newWorkers == nil {
+		newWorkers = defaultWorkers
+	}
+	ws, err := newWorkers(ctx, WorkerOpt{
+		Root:           root,
+		SessionManager: sessionManager,
+		RegistryHosts:  hosts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ws) == 0 {
+		return nil, errors.New("no worker for the embedded daemon")
+	}
+	wc := &worker.Controller{}
+	defer func() {
+		if err != nil {
+			wc.Close()
+		}
+	}()
+	for _, w := range ws {
+		if err := wc.Add(w); err != nil {
+			return nil, err
+		}
+	}
+	w := ws[0]
+
+	frontends := map[string]frontend.Frontend{
+		"dockerfile.v0": forwarder.NewGatewayForwarder(wc.Infos(), dockerfile.Build),
+	}
+	if frontends["gateway.v0"], err = gateway.NewGatewayFrontend(wc.Infos(), nil, gateway.SourcePinning{}, nil); err != nil {
+		return nil, err
+	}
+
+	cacheStorage, err := bboltcachestorage.NewStore(filepath.Join(root, "cache.db"))
+	if err != nil {
+		return nil, err
+	}
+	historyDB, err := boltutil.Open(filepath.Join(root, "history.db"), 0600, nil)
+	if err != nil {
+		cacheStorage.Close()
+		return nil, err
+	}
+
+	return control.NewController(control.Opt{
+		SessionManager:   sessionManager,
+		WorkerController: wc,
+		Frontends:        frontends,
+		ResolveCacheExporterFuncs: map[string]remotecache.ResolveCacheExporterFunc{
+			"registry": registryremotecache.ResolveCacheExporterFunc(sessionManager, hosts),
+			"local":    localremotecache.ResolveCacheExporterFunc(sessionManager),
+			"inline":   inlineremotecache.ResolveCacheExporterFunc(),
+		},
+		ResolveCacheImporterFuncs: map[string]remotecache.ResolveCacheImporterFunc{
+			"registry": registryremotecache.ResolveCacheImporterFunc(sessionManager, w.ContentStore(), hosts),
+			"local":    localremotecache.ResolveCacheImporterFunc(sessionManager),
+		},
+		CacheManager:   solver.NewCacheManager(ctx, "local", cacheStorage, worker.NewCacheResultStorage(wc)),
+		Entitlements:   opt.Entitlements,
+		HistoryDB:      historyDB,
+		CacheStore:     cacheStorage,
+		LeaseManager:   w.LeaseManager(),
+		ContentStore:   w.ContentStore(),
+		GarbageCollect: w.GarbageCollect,
+	})
+}
+
+// Root returns the directory of the state of the daemon
+func (d *Daemon) Root() string {
+	return d.root
+}
+
+// Client returns a client of the daemon. The client must be closed before the
+// daemon.
+func (d *Daemon) Client(ctx context.Context, opts ...client.ClientOpt) (*client.Client, error) {
+	opts = append(opts, client.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return d.listener.dial(ctx)
+	}))
+	return client.New(ctx, "embedded://buildkitd", opts...)
+}
+
+// Close stops the builds and the workers of the daemon, and removes the
+// temporary directory of its state
+func (d *Daemon) Close() error {
+	d.closeOnce.Do(func() {
+		d.server.Stop()
+		var errs []error
+		if err := d.controller.Close(); err != nil {
+			errs = append(errs, err)
+		}
+		if d.removeRoot {
+			if err := os.RemoveAll(d.root); err != nil {
+				errs = append(errs, errors.WithStack(err))
+			}
+		}
+		d.closeErr = stderrors.Join(errs...)
+	})
+	return d.closeErr
+}
+
+// memoryListener serves the connections of the clients of the process
+// without a socket
+type memoryListener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newMemoryListener() *memoryListener {
+	return &memoryListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *memoryListener) dial(ctx context.Context) (net.Conn, error) {
+	server, conn := net.Pipe()
+	select {
+	case l.conns <- server:
+		return conn, nil
+	case <-l.closed:
+		return nil, errors.WithStack(net.ErrClosed)
+	case <-ctx.Done():
+		return nil, context.Cause(ctx)
+	}
+}
+
+func (l *memoryListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errors.WithStack(net.ErrClosed)
+	}
+}
+
+func (l *memoryListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *memoryListener) Addr() net.Addr {
+	return memoryAddr{}
+}
+
+type memoryAddr struct{}
+
+func (memoryAddr) Network() string { return "memory" }
+func (memoryAddr) String() string  { return "buildkitd" }
@@ -0,0 +1,73 @@
+package embedded
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/moby/buildkit/worker"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryListener(t *testing.T) {
+	l := newMemoryListener()
+
+	connCh := make(chan net.Conn, 1)
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			connCh <- conn
+		}
+	}()
+	conn, err := l.dial(context.TODO())
+	require.NoError(t, err)
+	defer conn.Close()
+	server := <-connCh
+	defer server.Close()
+
+	go conn.Write([]byte("ping"))
+	dt := make([]byte, 4)
+	_, err = io.ReadFull(server, dt)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(dt))
+
+	require.NoError(t, l.Close())
+	_, err = l.Accept()
+	require.ErrorIs(t, err, net.ErrClosed)
+	_, err = l.dial(context.TODO())
+	require.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestNewWorkersError(t *testing.T) {
+	var root string
+	_, err := New(context.TODO(), Options{
+		Workers: func(_ context.Context, opt WorkerOpt) ([]worker.Worker, error) {
+			root = opt.Root
+			require.NotNil(t, opt.SessionManager)
+			require.NotNil(t, opt.RegistryHosts)
+			return nil, errors.New("no runc")
+		},
+	})
+	require.ErrorContains(t, err, "no runc")
+
+	// the temporary root is removed
+	require.NotEmpty(t, root)
+	_, err = os.Stat(root)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	root = t.TempDir()
+	_, err = New(context.TODO(), Options{
+		Root: root,
+		Workers: func(context.Context, WorkerOpt) ([]worker.Worker, error) {
+			return nil, nil
+		},
+	})
+	require.ErrorContains(t, err, "no worker")
+
+	// the root of the options is kept
+	_, err = os.Stat(root)
+	require.NoError(t, err)
+}
@@ -0,0 +1,49 @@
+package embedded
+
+import (
+	"context"
+
+	ctdsnapshot "github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/plugins/snapshots/native"
+	"github.com/containerd/containerd/v2/plugins/snapshots/overlay"
+	"github.com/containerd/containerd/v2/plugins/snapshots/overlay/overlayutils"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/executor/oci"
+	"github.com/moby/buildkit/util/network/netproviders"
+	"github.com/moby/buildkit/version"
+	"github.com/moby/buildkit/worker"
+	"github.com/moby/buildkit/worker/base"
+	"github.com/moby/buildkit/worker/runc"
+)
+
+// defaultWorkers returns an OCI worker running runc with the overlayfs
+// snapshotter, or the native one if overlayfs is not supported in the root
+func defaultWorkers(ctx context.Context, wopt WorkerOpt) ([]worker.Worker, error) {
+	snFactory := runc.SnapshotterFactory{
+		Name: "native",
+		New:  native.NewSnapshotter,
+	}
+	if err := overlayutils.Supported(wopt.Root); err == nil {
+		snFactory = runc.SnapshotterFactory{
+			Name: "overlayfs",
+			New: func(root string) (ctdsnapshot.Snapshotter, error) {
+				return overlay.NewSnapshotter(root, overlay.AsynchronousRemove)
+			},
+		}
+	}
+	opt, err := runc.NewWorkerOpt(wopt.Root, snFactory, false, oci.ProcessSandbox, nil, nil, netproviders.Opt{Mode: "auto"}, nil, "", "", false, nil, "", "", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	opt.RegistryHosts = wopt.RegistryHosts
+	opt.BuildkitVersion = client.BuildkitVersion{
+		Package:  version.Package,
+		Version:  version.Version,
+		Revision: version.Revision,
+	}
+	w, err := base.NewWorker(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	return []worker.Worker{w}, nil
+}
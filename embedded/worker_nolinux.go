@@ -0,0 +1,14 @@
+//go:build !linux
+
+package embedded
+
+import (
+	"context"
+
+	"github.com/moby/buildkit/worker"
+	"github.com/pkg/errors"
+)
+
+func defaultWorkers(context.Context, WorkerOpt) ([]worker.Worker, error) {
+	return nil, errors.New("no default worker on this platform, set Options.Workers")
+}
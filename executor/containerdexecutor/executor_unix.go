@@ -50,7 +50,11 @@ func (w *containerdExecutor) prepareExecutionEnv(ctx context.Context, rootMount
 		}
 	}
 
-	resolvConf, err := oci.GetResolvConf(ctx, w.root, nil, w.dnsConfig, netMode)
+	dns := w.dnsConfig
+	if len(meta.Nameservers) > 0 {
+		dns = dns.WithNameservers(meta.Nameservers)
+	}
+	resolvConf, err := oci.GetResolvConf(ctx, w.root, nil, dns, netMode)
 	if err != nil {
 		releaseAll()
 		return "", "", nil, err
@@ -13,8 +13,11 @@ import (
 )
 
 type Meta struct {
-	Args           []string
-	Env            []string
+	Args []string
+	Env  []string
+	// SecretEnv are the environment variables loaded from secrets. They are
+	// kept apart from Env so that executors can avoid persisting them.
+	SecretEnv      []string
 	User           string
 	Cwd            string
 	Hostname       string
@@ -27,6 +30,12 @@ type Meta struct {
 	NetMode        pb.NetMode
 	SecurityMode   pb.SecurityMode
 	ValidExitCodes []int
+	// Nameservers override the nameservers of the DNS config of the executor
+	Nameservers []string
+	// RestrictCapabilities limits the capabilities of the process to
+	// Capabilities, among the ones allowed by the security mode
+	RestrictCapabilities bool
+	Capabilities         []string
 
 	RemoveMountStubsRecursive bool
 }
@@ -5,12 +5,14 @@ import (
 	"net/netip"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/flightcontrol"
 	"github.com/moby/buildkit/util/resolvconf"
 	"github.com/moby/sys/user"
+	digest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 )
 
@@ -59,13 +61,32 @@ type DNSConfig struct {
 	Nameservers   []string
 	Options       []string
 	SearchDomains []string
+
+	// variant separates the resolv.conf files of the configs with overridden
+	// nameservers
+	variant string
+}
+
+// WithNameservers returns a copy of the config using the nameservers
+func (c *DNSConfig) WithNameservers(ns []string) *DNSConfig {
+	out := &DNSConfig{}
+	if c != nil {
+		*out = *c
+	}
+	out.Nameservers = ns
+	out.variant = digest.FromString(strings.Join(ns, " ")).Encoded()[:12]
+	return out
 }
 
 func GetResolvConf(ctx context.Context, stateDir string, idmap *user.IdentityMapping, dns *DNSConfig, netMode pb.NetMode) (string, error) {
-	p := filepath.Join(stateDir, "resolv.conf")
+	name := "resolv"
 	if netMode == pb.NetMode_HOST {
-		p = filepath.Join(stateDir, "resolv-host.conf")
+		name = "resolv-host"
+	}
+	if dns != nil && dns.variant != "" {
+		name += "-" + dns.variant
 	}
+	p := filepath.Join(stateDir, name+".conf")
 
 	_, err := g.Do(ctx, p, func(ctx context.Context) (struct{}, error) {
 		generate := !notFirstRun
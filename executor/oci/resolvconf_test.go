@@ -132,3 +132,33 @@ func TestResolvConf(t *testing.T) {
 		})
 	}
 }
+
+func TestResolvConfWithNameservers(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	oldResolvconfPath := resolvconfPath
+	t.Cleanup(func() {
+		resolvconfPath = oldResolvconfPath
+	})
+	rpath := path.Join(t.TempDir(), "resolv.conf")
+	require.NoError(t, os.WriteFile(rpath, []byte(regularResolvConf), 0600))
+	resolvconfPath = func(pb.NetMode) string {
+		return rpath
+	}
+
+	dns := &DNSConfig{Options: []string{"ndots:0"}}
+	p, err := GetResolvConf(ctx, tempDir, nil, dns, pb.NetMode_UNSET)
+	require.NoError(t, err)
+	b, err := os.ReadFile(p)
+	require.NoError(t, err)
+	require.Equal(t, "nameserver 192.168.65.5\noptions ndots:0\n", string(b))
+
+	// the files of the overridden nameservers are kept apart
+	p2, err := GetResolvConf(ctx, tempDir, nil, dns.WithNameservers([]string{"10.10.0.1"}), pb.NetMode_UNSET)
+	require.NoError(t, err)
+	require.NotEqual(t, p, p2)
+	b, err = os.ReadFile(p2)
+	require.NoError(t, err)
+	require.Equal(t, "nameserver 10.10.0.1\noptions ndots:0\n", string(b))
+	require.Empty(t, dns.Nameservers)
+}
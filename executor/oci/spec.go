@@ -6,6 +6,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 
@@ -125,7 +126,7 @@ func GenerateSpec(ctx context.Context, meta executor.Meta, mounts []executor.Mou
 
 	opts = append(opts,
 		withProcessArgs(meta.Args...),
-		oci.WithEnv(meta.Env),
+		oci.WithEnv(slices.Concat(meta.Env, meta.SecretEnv)),
 		oci.WithProcessCwd(meta.Cwd),
 		oci.WithNewPrivileges,
 		oci.WithHostname(hostname),
@@ -155,6 +156,10 @@ func GenerateSpec(ctx context.Context, meta executor.Meta, mounts []executor.Mou
 		s.Process.Rlimits = nil
 	}
 
+	if meta.RestrictCapabilities && s.Process.Capabilities != nil {
+		restrictCapabilities(s.Process.Capabilities, meta.Capabilities)
+	}
+
 	// set the networking information on the spec
 	if err := namespace.Set(s); err != nil {
 		return nil, nil, errors.WithStack(err)
@@ -241,6 +246,21 @@ func GenerateSpec(ctx context.Context, meta executor.Meta, mounts []executor.Mou
 	return s, releaseAll, nil
 }
 
+// restrictCapabilities removes the capabilities that are not in keep from
+// all the sets of caps
+func restrictCapabilities(caps *specs.LinuxCapabilities, keep []string) {
+	filter := func(set []string) []string {
+		return slices.DeleteFunc(set, func(c string) bool {
+			return !slices.Contains(keep, c)
+		})
+	}
+	caps.Bounding = filter(caps.Bounding)
+	caps.Effective = filter(caps.Effective)
+	caps.Inheritable = filter(caps.Inheritable)
+	caps.Permitted = filter(caps.Permitted)
+	caps.Ambient = filter(caps.Ambient)
+}
+
 type mountRef struct {
 	mount   mount.Mount
 	unmount func() error
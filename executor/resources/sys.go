@@ -7,3 +7,9 @@ type SysSampler = Sub[*resourcestypes.SysSample]
 func NewSysSampler() (*Sampler[*resourcestypes.SysSample], error) {
 	return newSysSampler()
 }
+
+// SysPressure returns the pressure stall information of the host. The values
+// are nil if the kernel doesn't support PSI.
+func SysPressure() (cpu, memory, io *resourcestypes.Pressure, err error) {
+	return sysPressure()
+}
@@ -67,27 +67,33 @@ func sampleSys(proc procfs.FS, tm time.Time) (*resourcestypes.SysSample, error)
 		Slab:      mem.Slab,
 	}
 
+	s.CPUPressure, s.MemoryPressure, s.IOPressure, err = sysPressure()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func sysPressure() (cpu, memory, io *resourcestypes.Pressure, err error) {
 	if _, err := os.Lstat("/proc/pressure"); err != nil {
-		return s, nil
+		return nil, nil, nil, nil
 	}
 
-	cp, err := parsePressureFile("/proc/pressure/cpu")
+	cpu, err = parsePressureFile("/proc/pressure/cpu")
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	s.CPUPressure = cp
 
-	mp, err := parsePressureFile("/proc/pressure/memory")
+	memory, err = parsePressureFile("/proc/pressure/memory")
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	s.MemoryPressure = mp
 
-	ip, err := parsePressureFile("/proc/pressure/io")
+	io, err = parsePressureFile("/proc/pressure/io")
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
-	s.IOPressure = ip
 
-	return s, nil
+	return cpu, memory, io, nil
 }
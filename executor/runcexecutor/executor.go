@@ -35,6 +35,7 @@ import (
 	rootlessspecconv "github.com/moby/buildkit/util/rootless/specconv"
 	"github.com/moby/buildkit/util/stack"
 	"github.com/moby/sys/user"
+	"github.com/moby/sys/userns"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
 )
@@ -191,7 +192,11 @@ func (w *runcExecutor) Run(ctx context.Context, id string, root executor.Mount,
 		}
 	}()
 
-	resolvConf, err := oci.GetResolvConf(ctx, w.root, w.idmap, w.dns, meta.NetMode)
+	dns := w.dns
+	if len(meta.Nameservers) > 0 {
+		dns = dns.WithNameservers(meta.Nameservers)
+	}
+	resolvConf, err := oci.GetResolvConf(ctx, w.root, w.idmap, dns, meta.NetMode)
 	if err != nil {
 		return nil, err
 	}
@@ -227,6 +232,15 @@ func (w *runcExecutor) Run(ctx context.Context, id string, root executor.Mount,
 	}
 	defer os.RemoveAll(bundle)
 
+	// the spec with the secret environment variables is only kept in memory
+	if len(meta.SecretEnv) > 0 {
+		unmount, err := mountBundleTmpfs(bundle)
+		if err != nil {
+			return nil, err
+		}
+		defer unmount()
+	}
+
 	var rootUID, rootGID int
 	if w.idmap != nil {
 		rootUID, rootGID = w.idmap.RootPair()
@@ -747,3 +761,22 @@ func handleSignals(ctx context.Context, runcProcess *procHandle, signals <-chan
 		}
 	}
 }
+
+// mountBundleTmpfs mounts a tmpfs on the bundle directory so that the spec
+// isn't written to disk
+func mountBundleTmpfs(bundle string) (func() error, error) {
+	m := mount.Mount{
+		Type:    "tmpfs",
+		Source:  "tmpfs",
+		Options: []string{"nodev", "nosuid", "noexec", "mode=711"},
+	}
+	if userns.RunningInUserNS() {
+		m.Options = nil
+	}
+	if err := mount.All([]mount.Mount{m}, bundle); err != nil {
+		return nil, errors.Wrap(err, "failed to mount tmpfs for the bundle")
+	}
+	return func() error {
+		return mount.Unmount(bundle, 0)
+	}, nil
+}
@@ -0,0 +1,435 @@
+package artifact
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/leases"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	continuityfs "github.com/containerd/continuity/fs"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/exporter"
+	"github.com/moby/buildkit/exporter/containerimage"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/exporter/util/epoch"
+	"github.com/moby/buildkit/identity"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/snapshot"
+	"github.com/moby/buildkit/util/leaseutil"
+	"github.com/moby/buildkit/util/progress"
+	"github.com/moby/buildkit/util/push"
+	"github.com/moby/patternmatcher"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// keyFiles is a comma-separated list of patterns of the files of the
+	// result that are added as layers of the artifact. All regular files are
+	// added by default.
+	keyFiles = "files"
+	// keyMediaType is the media type of the layers
+	keyMediaType = "media-type"
+	// prefixMediaType sets the media type of the layer of a single file,
+	// e.g. media-type.chart.tgz=application/vnd.cncf.helm.chart.content.v1.tar+gzip
+	prefixMediaType = "media-type."
+	// keyConfig is the path of the file of the result used as the config of
+	// the artifact. The empty descriptor is used by default.
+	keyConfig = "config"
+	// keyConfigMediaType is the media type of the config. Required with
+	// keyConfig.
+	keyConfigMediaType = "config-media-type"
+	// prefixAnnotation sets an annotation of the manifest
+	prefixAnnotation = "annotation."
+)
+
+// DefaultLayerMediaType is the media type of the layers if none is set
+const DefaultLayerMediaType = "application/octet-stream"
+
+type Opt struct {
+	SessionManager *session.Manager
+	ContentStore   content.Store
+	RegistryHosts  docker.RegistryHosts
+	LeaseManager   leases.Manager
+}
+
+type artifactExporter struct {
+	opt Opt
+}
+
+// New returns an exporter that pushes the files of the result as an OCI
+// artifact. Each file is a layer of the artifact with its path as the title.
+func New(opt Opt) (exporter.Exporter, error) {
+	return &artifactExporter{opt: opt}, nil
+}
+
+func (e *artifactExporter) Resolve(ctx context.Context, id int, opt map[string]string) (exporter.ExporterInstance, error) {
+	i := &artifactExporterInstance{
+		artifactExporter: e,
+		id:               id,
+		attrs:            opt,
+		push:             true,
+		mediaType:        DefaultLayerMediaType,
+	}
+
+	var err error
+	i.epoch, opt, err = epoch.ParseExporterAttrs(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range opt {
+		switch {
+		case k == string(exptypes.OptKeyName):
+			for _, name := range strings.Split(v, ",") {
+				if name == "" {
+					continue
+				}
+				parsed, err := reference.ParseNormalizedNamed(name)
+				if err != nil {
+					return nil, errors.Wrapf(err, "failed to parse %s", name)
+				}
+				i.names = append(i.names, reference.TagNameOnly(parsed).String())
+			}
+		case k == string(exptypes.OptKeyPush):
+			if i.push, err = parseBool(k, v); err != nil {
+				return nil, err
+			}
+		case k == string(exptypes.OptKeyInsecure):
+			if i.insecure, err = parseBool(k, v); err != nil {
+				return nil, err
+			}
+		case k == string(exptypes.OptKeyArtifactType):
+			i.artifactType = v
+		case k == string(exptypes.OptKeySubject):
+			if i.subject, err = containerimage.ParseSubject([]byte(v)); err != nil {
+				return nil, err
+			}
+		case k == keyFiles:
+			for _, p := range strings.Split(v, ",") {
+				if p = strings.TrimSpace(p); p != "" {
+					i.files = append(i.files, p)
+				}
+			}
+		case k == keyMediaType:
+			i.mediaType = v
+		case strings.HasPrefix(k, prefixMediaType):
+			if i.mediaTypes == nil {
+				i.mediaTypes = map[string]string{}
+			}
+			i.mediaTypes[cleanPath(strings.TrimPrefix(k, prefixMediaType))] = v
+		case k == keyConfig:
+			i.config = cleanPath(v)
+		case k == keyConfigMediaType:
+			i.configMediaType = v
+		case strings.HasPrefix(k, prefixAnnotation):
+			if i.annotations == nil {
+				i.annotations = map[string]string{}
+			}
+			i.annotations[strings.TrimPrefix(k, prefixAnnotation)] = v
+		default:
+			return nil, errors.Errorf("unknown artifact exporter option %s", k)
+		}
+	}
+
+	if i.artifactType == "" {
+		return nil, errors.Errorf("%s is required for artifact exporter", exptypes.OptKeyArtifactType)
+	}
+	if i.push && len(i.names) == 0 {
+		return nil, errors.Errorf("%s is required to push an artifact", exptypes.OptKeyName)
+	}
+	if i.config != "" && i.configMediaType == "" {
+		return nil, errors.Errorf("%s is required with %s", keyConfigMediaType, keyConfig)
+	}
+	if i.files != nil {
+		if i.matcher, err = patternmatcher.New(i.files); err != nil {
+			return nil, errors.Wrapf(err, "invalid %s", keyFiles)
+		}
+	}
+	return i, nil
+}
+
+type artifactExporterInstance struct {
+	*artifactExporter
+	id    int
+	attrs map[string]string
+
+	names           []string
+	push            bool
+	insecure        bool
+	artifactType    string
+	subject         *ocispecs.Descriptor
+	files           []string
+	matcher         *patternmatcher.PatternMatcher
+	mediaType       string
+	mediaTypes      map[string]string
+	config          string
+	configMediaType string
+	annotations     map[string]string
+	epoch           *time.Time
+}
+
+func (e *artifactExporterInstance) ID() int {
+	return e.id
+}
+
+func (e *artifactExporterInstance) Name() string {
+	return "exporting to OCI artifact"
+}
+
+func (e *artifactExporterInstance) Type() string {
+	return client.ExporterArtifact
+}
+
+func (e *artifactExporterInstance) Attrs() map[string]string {
+	return e.attrs
+}
+
+func (e *artifactExporterInstance) Config() *exporter.Config {
+	return exporter.NewConfig()
+}
+
+func (e *artifactExporterInstance) Export(ctx context.Context, src *exporter.Source, _ exptypes.InlineCache, sessionID string) (_ map[string]string, descref exporter.DescriptorReference, err error) {
+	if len(src.Refs) > 0 {
+		return nil, nil, errors.Errorf("artifact exporter does not support multi-platform results")
+	}
+	if src.Ref == nil {
+		return nil, nil, errors.Errorf("artifact exporter requires a result with files")
+	}
+
+	tm := e.epoch
+	if tm == nil {
+		if v, ok, err := epoch.ParseSource(src); err != nil {
+			return nil, nil, err
+		} else if ok {
+			tm = v
+		}
+	}
+
+	ctx, done, err := leaseutil.WithLease(ctx, e.opt.LeaseManager, leaseutil.MakeTemporary)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if descref == nil {
+			done(context.WithoutCancel(ctx))
+		}
+	}()
+
+	mount, err := src.Ref.Mount(ctx, true, session.NewGroup(sessionID))
+	if err != nil {
+		return nil, nil, err
+	}
+	lm := snapshot.LocalMounter(mount)
+	root, err := lm.Mount()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer lm.Unmount()
+
+	mfst, err := e.manifest(ctx, root, tm)
+	if err != nil {
+		return nil, nil, err
+	}
+	desc, err := e.writeManifest(ctx, mfst)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if e.push {
+		for _, name := range e.names {
+			if err := push.Push(ctx, e.opt.SessionManager, sessionID, e.opt.ContentStore, e.opt.ContentStore, desc.Digest, name, e.insecure, e.opt.RegistryHosts, false, nil); err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to push %v", name)
+			}
+		}
+	}
+
+	resp := map[string]string{
+		exptypes.ExporterImageDigestKey: desc.Digest.String(),
+	}
+	if len(e.names) > 0 {
+		resp[exptypes.ExporterImageNameKey] = strings.Join(e.names, ",")
+		if e.push {
+			resp[exptypes.ExporterImagePushedKey] = resp[exptypes.ExporterImageNameKey]
+		}
+	}
+	dtdesc, err := json.Marshal(desc)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp[exptypes.ExporterImageDescriptorKey] = base64.StdEncoding.EncodeToString(dtdesc)
+
+	return resp, containerimage.NewDescriptorReference(*desc, done), nil
+}
+
+// manifest writes the files under root to the content store and returns the
+// manifest of the artifact
+func (e *artifactExporterInstance) manifest(ctx context.Context, root string, tm *time.Time) (*ocispecs.Manifest, error) {
+	mfst := &ocispecs.Manifest{
+		Versioned: specs.Versioned{
+			SchemaVersion: 2,
+		},
+		MediaType:    ocispecs.MediaTypeImageManifest,
+		ArtifactType: e.artifactType,
+		Config:       ocispecs.DescriptorEmptyJSON,
+		Subject:      e.subject,
+		Annotations:  maps.Clone(e.annotations),
+	}
+	if mfst.Annotations == nil {
+		mfst.Annotations = map[string]string{}
+	}
+	if _, ok := mfst.Annotations[ocispecs.AnnotationCreated]; !ok {
+		created := time.Now()
+		if tm != nil {
+			created = *tm
+		}
+		mfst.Annotations[ocispecs.AnnotationCreated] = created.UTC().Format(time.RFC3339)
+	}
+
+	if e.config != "" {
+		p, err := continuityfs.RootPath(root, e.config)
+		if err != nil {
+			return nil, err
+		}
+		desc, err := e.writeFile(ctx, p, e.configMediaType)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read config %s", e.config)
+		}
+		mfst.Config = *desc
+	} else {
+		if err := content.WriteBlob(ctx, e.opt.ContentStore, ocispecs.DescriptorEmptyJSON.Digest.String(), bytes.NewReader(ocispecs.DescriptorEmptyJSON.Data), ocispecs.DescriptorEmptyJSON); err != nil {
+			return nil, errors.Wrap(err, "error writing config blob")
+		}
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == e.config {
+			return nil
+		}
+		if e.matcher != nil {
+			ok, err := e.matcher.MatchesOrParentMatches(rel)
+			if err != nil || !ok {
+				return err
+			}
+		}
+		mediaType := e.mediaType
+		if v, ok := e.mediaTypes[rel]; ok {
+			mediaType = v
+		}
+		desc, err := e.writeFile(ctx, p, mediaType)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", rel)
+		}
+		desc.Annotations = map[string]string{
+			ocispecs.AnnotationTitle: rel,
+		}
+		mfst.Layers = append(mfst.Layers, *desc)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(mfst.Layers) == 0 {
+		if len(e.files) > 0 {
+			return nil, errors.Errorf("no files of the result match %s", strings.Join(e.files, ","))
+		}
+		return nil, errors.Errorf("no files in the result")
+	}
+	return mfst, nil
+}
+
+// writeFile writes the file at p to the content store
+func (e *artifactExporterInstance) writeFile(ctx context.Context, p string, mediaType string) (*ocispecs.Descriptor, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	w, err := content.OpenWriter(ctx, e.opt.ContentStore, content.WithRef("artifact-"+identity.NewID()))
+	if err != nil {
+		return nil, err
+	}
+	defer w.Close()
+	n, err := io.Copy(w, f)
+	if err != nil {
+		return nil, err
+	}
+	dgst := w.Digest()
+	if err := w.Commit(ctx, n, dgst); err != nil && !cerrdefs.IsAlreadyExists(err) {
+		return nil, err
+	}
+	return &ocispecs.Descriptor{
+		MediaType: mediaType,
+		Digest:    dgst,
+		Size:      n,
+	}, nil
+}
+
+func (e *artifactExporterInstance) writeManifest(ctx context.Context, mfst *ocispecs.Manifest) (*ocispecs.Descriptor, error) {
+	labels := map[string]string{
+		"containerd.io/gc.ref.content.0": mfst.Config.Digest.String(),
+	}
+	for i, desc := range mfst.Layers {
+		labels[fmt.Sprintf("containerd.io/gc.ref.content.%d", i+1)] = desc.Digest.String()
+	}
+
+	dt, err := json.MarshalIndent(mfst, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal manifest")
+	}
+	desc := ocispecs.Descriptor{
+		MediaType:    mfst.MediaType,
+		ArtifactType: mfst.ArtifactType,
+		Digest:       digest.FromBytes(dt),
+		Size:         int64(len(dt)),
+	}
+	mfstDone := progress.OneOff(ctx, "exporting manifest "+desc.Digest.String())
+	if err := content.WriteBlob(ctx, e.opt.ContentStore, desc.Digest.String(), bytes.NewReader(dt), desc, content.WithLabels(labels)); err != nil {
+		return nil, mfstDone(errors.Wrapf(err, "error writing manifest blob %s", desc.Digest))
+	}
+	mfstDone(nil)
+	return &desc, nil
+}
+
+func cleanPath(p string) string {
+	return strings.TrimPrefix(filepath.ToSlash(filepath.Clean("/"+p)), "/")
+}
+
+func parseBool(k, v string) (bool, error) {
+	if v == "" {
+		return true, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors.Wrapf(err, "non-bool value specified for %s", k)
+	}
+	return b, nil
+}
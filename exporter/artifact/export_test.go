@@ -0,0 +1,104 @@
+package artifact
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveOptions(t *testing.T) {
+	ctx := context.TODO()
+	e := &artifactExporter{}
+
+	_, err := e.Resolve(ctx, 0, map[string]string{"name": "example.com/foo"})
+	require.ErrorContains(t, err, "artifact-type is required")
+
+	_, err = e.Resolve(ctx, 0, map[string]string{"artifact-type": "application/vnd.example"})
+	require.ErrorContains(t, err, "name is required")
+
+	_, err = e.Resolve(ctx, 0, map[string]string{"artifact-type": "application/vnd.example", "push": "false", "config": "config.json"})
+	require.ErrorContains(t, err, "config-media-type is required")
+
+	_, err = e.Resolve(ctx, 0, map[string]string{"artifact-type": "application/vnd.example", "push": "false", "oci-mediatypes": "true"})
+	require.ErrorContains(t, err, "unknown artifact exporter option oci-mediatypes")
+
+	inst, err := e.Resolve(ctx, 0, map[string]string{
+		"artifact-type":     "application/vnd.example",
+		"name":              "example.com/foo",
+		"media-type./a":     "application/vnd.example.a",
+		"config":            "./dir/../config.json",
+		"config-media-type": "application/vnd.example.config",
+		"annotation.foo":    "bar",
+	})
+	require.NoError(t, err)
+	i := inst.(*artifactExporterInstance)
+	require.Equal(t, []string{"example.com/foo:latest"}, i.names)
+	require.True(t, i.push)
+	require.Equal(t, map[string]string{"a": "application/vnd.example.a"}, i.mediaTypes)
+	require.Equal(t, "config.json", i.config)
+	require.Equal(t, map[string]string{"foo": "bar"}, i.annotations)
+}
+
+func TestManifest(t *testing.T) {
+	ctx := context.TODO()
+	store, err := local.NewStore(t.TempDir())
+	require.NoError(t, err)
+
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "out/sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "out/b.wasm"), []byte("b"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "out/sub/a.wasm"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "out/sub/a.txt"), []byte("txt"), 0644))
+	require.NoError(t, os.Symlink("b.wasm", filepath.Join(root, "out/link.wasm")))
+
+	e := &artifactExporter{opt: Opt{ContentStore: store}}
+	inst, err := e.Resolve(ctx, 0, map[string]string{
+		"artifact-type":           "application/vnd.wasm.module.v1",
+		"push":                    "false",
+		"files":                   "**/*.wasm",
+		"media-type":              "application/wasm",
+		"media-type.out/b.wasm":   "application/vnd.example.b",
+		"annotation.org.foo":      "bar",
+		"annotation.org.foo.more": "baz",
+	})
+	require.NoError(t, err)
+
+	tm := time.Unix(1700000000, 0)
+	mfst, err := inst.(*artifactExporterInstance).manifest(ctx, root, &tm)
+	require.NoError(t, err)
+	require.Equal(t, "application/vnd.wasm.module.v1", mfst.ArtifactType)
+	require.Equal(t, ocispecs.DescriptorEmptyJSON, mfst.Config)
+	require.Equal(t, map[string]string{
+		"org.foo":                  "bar",
+		"org.foo.more":             "baz",
+		ocispecs.AnnotationCreated: "2023-11-14T22:13:20Z",
+	}, mfst.Annotations)
+
+	require.Len(t, mfst.Layers, 2)
+	require.Equal(t, "out/b.wasm", mfst.Layers[0].Annotations[ocispecs.AnnotationTitle])
+	require.Equal(t, "application/vnd.example.b", mfst.Layers[0].MediaType)
+	require.Equal(t, "out/sub/a.wasm", mfst.Layers[1].Annotations[ocispecs.AnnotationTitle])
+	require.Equal(t, "application/wasm", mfst.Layers[1].MediaType)
+
+	dt, err := content.ReadBlob(ctx, store, mfst.Layers[1])
+	require.NoError(t, err)
+	require.Equal(t, "a", string(dt))
+	_, err = content.ReadBlob(ctx, store, mfst.Config)
+	require.NoError(t, err)
+
+	inst, err = e.Resolve(ctx, 0, map[string]string{
+		"artifact-type": "application/vnd.wasm.module.v1",
+		"push":          "false",
+		"files":         "*.js",
+	})
+	require.NoError(t, err)
+	_, err = inst.(*artifactExporterInstance).manifest(ctx, root, nil)
+	require.ErrorContains(t, err, "no files of the result match *.js")
+}
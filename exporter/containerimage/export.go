@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"maps"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -20,6 +22,7 @@ import (
 	"github.com/containerd/containerd/v2/pkg/rootfs"
 	cerrdefs "github.com/containerd/errdefs"
 	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
 	"github.com/moby/buildkit/cache"
 	cacheconfig "github.com/moby/buildkit/cache/config"
 	"github.com/moby/buildkit/client"
@@ -53,6 +56,9 @@ type Opt struct {
 	Images         images.Store
 	RegistryHosts  docker.RegistryHosts
 	LeaseManager   leases.Manager
+	// Namespace returns the stores of a containerd namespace. It is nil
+	// for the workers that don't run on containerd.
+	Namespace func(ns string) (*Namespace, error)
 }
 
 type imageExporter struct {
@@ -109,6 +115,30 @@ func (e *imageExporter) Resolve(ctx context.Context, id int, opt map[string]stri
 				return nil, errors.Wrapf(err, "non-bool value specified for %s", k)
 			}
 			i.pushByDigest = b
+		case exptypes.OptKeyMountFrom:
+			for _, v := range strings.Split(v, ",") {
+				if v == "" {
+					continue
+				}
+				named, err := reference.ParseNormalizedNamed(v)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid repository %q for %s", v, k)
+				}
+				i.mountFrom = append(i.mountFrom, reference.TrimNamed(named))
+			}
+		case exptypes.OptKeyReuseLayersFrom:
+			if v == "" {
+				continue
+			}
+			named, err := reference.ParseNormalizedNamed(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid image %q for %s", v, k)
+			}
+			i.reuseLayersFrom = reference.TagNameOnly(named)
+		case exptypes.OptKeyDockerCompatName:
+			i.dockerCompatName = v
+		case exptypes.OptKeyContainerdNamespace:
+			i.namespace = v
 		case exptypes.OptKeyInsecure:
 			if v == "" {
 				i.insecure = true
@@ -178,6 +208,24 @@ func (e *imageExporter) Resolve(ctx context.Context, id int, opt map[string]stri
 			i.meta[k] = []byte(v)
 		}
 	}
+	if i.unpack && i.opts.RewriteTimestamp {
+		// e.unpackImage cannot be used because src ref does not point to the rewritten image
+		// /
+		// TODO: change e.unpackImage so that it takes Result[Remote] as parameter.
+		// https://github.com/moby/buildkit/pull/4057#discussion_r1324106088
+		return nil, errors.New("exporter option \"rewrite-timestamp\" conflicts with \"unpack\"")
+	}
+	if i.namespace != "" && e.opt.Namespace == nil {
+		return nil, errors.Errorf("%s is only supported by the containerd worker", exptypes.OptKeyContainerdNamespace)
+	}
+	if i.dockerCompatName != "" {
+		if !i.opts.OCITypes {
+			return nil, errors.Errorf("%s requires %s=true", exptypes.OptKeyDockerCompatName, exptypes.OptKeyOCITypes)
+		}
+		if i.opts.RefCfg.Compression.Type.OnlySupportOCITypes() {
+			return nil, errors.Errorf("%s is not supported with %s compression", exptypes.OptKeyDockerCompatName, i.opts.RefCfg.Compression.Type)
+		}
+	}
 	return i, nil
 }
 
@@ -189,6 +237,10 @@ type imageExporterInstance struct {
 	opts                 ImageCommitOpts
 	push                 bool
 	pushByDigest         bool
+	mountFrom            []reference.Named
+	reuseLayersFrom      reference.Named
+	dockerCompatName     string
+	namespace            string
 	unpack               bool
 	store                bool
 	storeAllowIncomplete bool
@@ -243,6 +295,34 @@ func (e *imageExporterInstance) Export(ctx context.Context, src *exporter.Source
 		}
 	}()
 
+	if e.reuseLayersFrom != nil {
+		ps, err := exptypes.ParsePlatforms(src.Metadata)
+		if err != nil {
+			return nil, nil, err
+		}
+		var pps []ocispecs.Platform
+		for _, p := range ps.Platforms {
+			pps = append(pps, p.Platform)
+		}
+		opts.LayerReuse, err = resolveLayerReuse(ctx, e.reuseLayersFrom, pps, e.opt.RegistryHosts, e.opt.SessionManager, sessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var compatDesc *ocispecs.Descriptor
+	if e.dockerCompatName != "" {
+		compatDesc, err = e.commitDockerCompat(ctx, src, sessionID, inlineCache, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		compatAnnotations, err := e.dockerCompatAnnotations(ctx, *compatDesc)
+		if err != nil {
+			return nil, nil, err
+		}
+		opts.Annotations = AnnotationsGroup{}.Merge(opts.Annotations).Merge(compatAnnotations)
+	}
+
 	desc, err := e.opt.ImageWriter.Commit(ctx, src, sessionID, inlineCache, &opts)
 	if err != nil {
 		return nil, nil, err
@@ -253,6 +333,14 @@ func (e *imageExporterInstance) Export(ctx context.Context, src *exporter.Source
 		}
 	}()
 
+	// the stored images need the reused layers, the pushed ones are mounted
+	// or copied from the registry
+	if (e.opt.Images != nil && e.store && !e.storeAllowIncomplete) || e.namespace != "" {
+		if err := opts.LayerReuse.fetch(ctx, e.opt.ImageWriter.ContentStore()); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	resp := make(map[string]string)
 
 	if n, ok := src.Metadata["image.name"]; e.opts.ImageName == "*" && ok {
@@ -275,104 +363,54 @@ func (e *imageExporterInstance) Export(ctx context.Context, src *exporter.Source
 		for _, targetName := range targetNames {
 			if e.opt.Images != nil && e.store {
 				tagDone := progress.OneOff(ctx, "naming to "+targetName)
-
-				// imageClientCtx is used for propagating the epoch to e.opt.Images.Update() and e.opt.Images.Create().
-				//
-				// Ideally, we should be able to propagate the epoch via images.Image.CreatedAt.
-				// However, due to a bug of containerd, we are temporarily stuck with this workaround.
-				// https://github.com/containerd/containerd/issues/8322
-				imageClientCtx := ctx
-				if e.opts.Epoch != nil {
-					imageClientCtx = epoch.WithSourceDateEpoch(imageClientCtx, e.opts.Epoch)
-				}
-				img := images.Image{
-					Target: *desc,
-					// CreatedAt in images.Images is ignored due to a bug of containerd.
-					// See the comment lines for imageClientCtx.
-				}
-
-				sfx := []string{""}
-				if nameCanonical && !strings.ContainsRune(targetName, '@') {
-					sfx = append(sfx, "@"+desc.Digest.String())
-				}
-				for _, sfx := range sfx {
-					img.Name = targetName + sfx
-					if _, err := e.opt.Images.Update(imageClientCtx, img); err != nil {
-						if !errors.Is(err, cerrdefs.ErrNotFound) {
-							return nil, nil, tagDone(err)
-						}
-
-						if _, err := e.opt.Images.Create(imageClientCtx, img); err != nil {
-							return nil, nil, tagDone(err)
-						}
-					}
+				img, err := e.nameImage(ctx, e.opt.Images, targetName, *desc, nameCanonical)
+				if err != nil {
+					return nil, nil, tagDone(err)
 				}
 				tagDone(nil)
 
 				if e.unpack {
-					if opts.RewriteTimestamp {
-						// e.unpackImage cannot be used because src ref does not point to the rewritten image
-						// /
-						// TODO: change e.unpackImage so that it takes Result[Remote] as parameter.
-						// https://github.com/moby/buildkit/pull/4057#discussion_r1324106088
-						return nil, nil, errors.New("exporter option \"rewrite-timestamp\" conflicts with \"unpack\"")
-					}
-					if err := e.unpackImage(ctx, img, src, session.NewGroup(sessionID)); err != nil {
+					if err := e.unpackImage(ctx, img, src, session.NewGroup(sessionID), e.opt.ImageWriter.ContentStore(), e.opt.ImageWriter.Snapshotter()); err != nil {
 						return nil, nil, err
 					}
 				}
 
 				if !e.storeAllowIncomplete {
-					var refs []cache.ImmutableRef
-					if src.Ref != nil {
-						refs = append(refs, src.Ref)
-					}
-					for _, ref := range src.Refs {
-						if ref == nil {
-							continue
-						}
-						refs = append(refs, ref)
-					}
-					eg, ctx := errgroup.WithContext(ctx)
-					for _, ref := range refs {
-						eg.Go(func() error {
-							remotes, err := ref.GetRemotes(ctx, false, e.opts.RefCfg, false, session.NewGroup(sessionID))
-							if err != nil {
-								return err
-							}
-							remote := remotes[0]
-							if unlazier, ok := remote.Provider.(cache.Unlazier); ok {
-								if err := unlazier.Unlazy(ctx); err != nil {
-									return err
-								}
-							}
-							return nil
-						})
-					}
-					if err := eg.Wait(); err != nil {
+					if err := e.unlazyRefs(ctx, src, sessionID); err != nil {
 						return nil, nil, err
 					}
 				}
 			}
-			if e.push {
-				err = e.pushImage(ctx, src, sessionID, targetName, desc.Digest)
-				if err != nil {
-					var statusErr remoteserrors.ErrUnexpectedStatus
-					if errors.As(err, &statusErr) {
-						err = errutil.WithDetails(err)
-					}
-					return nil, nil, errors.Wrapf(err, "failed to push %v", targetName)
-				}
+		}
+		if e.namespace != "" {
+			if err := e.exportToNamespace(ctx, src, sessionID, targetNames, *desc, nameCanonical); err != nil {
+				return nil, nil, err
+			}
+		}
+		if e.push {
+			if err := e.pushImages(ctx, src, sessionID, targetNames, desc.Digest, opts.LayerReuse); err != nil {
+				return nil, nil, err
 			}
+			resp[exptypes.ExporterImagePushedKey] = e.opts.ImageName
 		}
 		resp[exptypes.ExporterImageNameKey] = e.opts.ImageName
 	}
 
+	if compatDesc != nil {
+		if err := e.storeAndPushDockerCompat(ctx, src, sessionID, *compatDesc, opts.LayerReuse); err != nil {
+			return nil, nil, err
+		}
+		resp[exptypes.ExporterImageDockerCompatDigestKey] = compatDesc.Digest.String()
+	}
+
 	resp[exptypes.ExporterImageDigestKey] = desc.Digest.String()
 	if v, ok := desc.Annotations[exptypes.ExporterConfigDigestKey]; ok {
 		resp[exptypes.ExporterImageConfigDigestKey] = v
 		delete(desc.Annotations, exptypes.ExporterConfigDigestKey)
 	}
+	if err := AddManifestsResponse(ctx, resp, e.opt.ImageWriter.ContentStore(), *desc); err != nil {
+		return nil, nil, err
+	}
 
 	dtdesc, err := json.Marshal(desc)
 	if err != nil {
@@ -383,7 +421,154 @@ func (e *imageExporterInstance) Export(ctx context.Context, src *exporter.Source
 	return resp, nil, nil
 }
 
-func (e *imageExporterInstance) pushImage(ctx context.Context, src *exporter.Source, sessionID string, targetName string, dgst digest.Digest) error {
+// AddManifestsResponse sets the manifests of the index desc, including the
+// attestation manifests, in the exporter response resp. It is a no-op if desc
+// is not an index.
+func AddManifestsResponse(ctx context.Context, resp map[string]string, provider content.Provider, desc ocispecs.Descriptor) error {
+	if !images.IsIndexType(desc.MediaType) {
+		return nil
+	}
+	dt, err := content.ReadBlob(ctx, provider, desc)
+	if err != nil {
+		return errors.Wrap(err, "failed to read index")
+	}
+	var idx ocispecs.Index
+	if err := json.Unmarshal(dt, &idx); err != nil {
+		return errors.Wrap(err, "failed to parse index")
+	}
+	dt, err = json.Marshal(idx.Manifests)
+	if err != nil {
+		return err
+	}
+	resp[exptypes.ExporterImageManifestsKey] = base64.StdEncoding.EncodeToString(dt)
+	return nil
+}
+
+// commitDockerCompat commits the variant of the image with Docker media
+// types. Attestations and annotations require the OCI media types, so they
+// are only part of the OCI image.
+func (e *imageExporterInstance) commitDockerCompat(ctx context.Context, src *exporter.Source, sessionID string, inlineCache exptypes.InlineCache, opts ImageCommitOpts) (*ocispecs.Descriptor, error) {
+	if opts.ArtifactType != "" || opts.Subject != nil || src.Metadata[exptypes.ExporterImageArtifactTypeKey] != nil || src.Metadata[exptypes.ExporterImageSubjectKey] != nil {
+		return nil, errors.Errorf("artifacts can't be exported with Docker media types")
+	}
+	src = src.Clone()
+	src.Attestations = nil
+	opts.OCITypes = false
+	opts.OCIArtifact = false
+	opts.Annotations = nil
+	desc, err := e.opt.ImageWriter.Commit(ctx, src, sessionID, inlineCache, &opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to commit docker compatible image")
+	}
+	delete(desc.Annotations, exptypes.ExporterConfigDigestKey)
+	return desc, nil
+}
+
+// dockerCompatAnnotations returns the annotations that point the OCI image to
+// its Docker media types variant desc. The index of a multi-platform image
+// points to the variant index and each platform manifest to the variant
+// manifest of the same platform.
+func (e *imageExporterInstance) dockerCompatAnnotations(ctx context.Context, desc ocispecs.Descriptor) (AnnotationsGroup, error) {
+	if !images.IsIndexType(desc.MediaType) {
+		return AnnotationsGroup{
+			"": &Annotations{
+				Manifest: map[string]string{
+					exptypes.AnnotationDockerCompatManifest: desc.Digest.String(),
+				},
+			},
+		}, nil
+	}
+
+	dt, err := content.ReadBlob(ctx, e.opt.ImageWriter.ContentStore(), desc)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read docker compatible index")
+	}
+	var idx ocispecs.Index
+	if err := json.Unmarshal(dt, &idx); err != nil {
+		return nil, errors.Wrap(err, "failed to parse docker compatible index")
+	}
+	ag := AnnotationsGroup{
+		"": &Annotations{
+			Index: map[string]string{
+				exptypes.AnnotationDockerCompatManifest: desc.Digest.String(),
+			},
+		},
+	}
+	for _, m := range idx.Manifests {
+		if m.Platform == nil || !images.IsManifestType(m.MediaType) {
+			continue
+		}
+		ag[platforms.FormatAll(*m.Platform)] = &Annotations{
+			Manifest: map[string]string{
+				exptypes.AnnotationDockerCompatManifest: m.Digest.String(),
+			},
+		}
+	}
+	return ag, nil
+}
+
+// storeAndPushDockerCompat names the Docker media types variant of the
+// image in the image store and pushes it
+func (e *imageExporterInstance) storeAndPushDockerCompat(ctx context.Context, src *exporter.Source, sessionID string, desc ocispecs.Descriptor, reuse *LayerReuse) error {
+	targetNames := strings.Split(e.dockerCompatName, ",")
+	if e.opt.Images != nil && e.store {
+		for _, targetName := range targetNames {
+			tagDone := progress.OneOff(ctx, "naming to "+targetName)
+			if _, err := e.nameImage(ctx, e.opt.Images, targetName, desc, false); err != nil {
+				return tagDone(err)
+			}
+			tagDone(nil)
+		}
+	}
+	if e.push {
+		return e.pushImages(ctx, src, sessionID, targetNames, desc.Digest, reuse)
+	}
+	return nil
+}
+
+// nameImage names desc as targetName in the image store is. With
+// nameCanonical, the canonical name with the digest of desc is created too.
+func (e *imageExporterInstance) nameImage(ctx context.Context, is images.Store, targetName string, desc ocispecs.Descriptor, nameCanonical bool) (images.Image, error) {
+	// imageClientCtx is used for propagating the epoch to is.Update() and is.Create().
+	//
+	// Ideally, we should be able to propagate the epoch via images.Image.CreatedAt.
+	// However, due to a bug of containerd, we are temporarily stuck with this workaround.
+	// https://github.com/containerd/containerd/issues/8322
+	imageClientCtx := ctx
+	if e.opts.Epoch != nil {
+		imageClientCtx = epoch.WithSourceDateEpoch(imageClientCtx, e.opts.Epoch)
+	}
+	img := images.Image{
+		Target: desc,
+		// CreatedAt in images.Images is ignored due to a bug of containerd.
+		// See the comment lines for imageClientCtx.
+	}
+
+	sfx := []string{""}
+	if nameCanonical && !strings.ContainsRune(targetName, '@') {
+		sfx = append(sfx, "@"+desc.Digest.String())
+	}
+	for _, sfx := range sfx {
+		img.Name = targetName + sfx
+		if _, err := is.Update(imageClientCtx, img); err != nil {
+			if !errors.Is(err, cerrdefs.ErrNotFound) {
+				return images.Image{}, err
+			}
+
+			if _, err := is.Create(imageClientCtx, img); err != nil {
+				return images.Image{}, err
+			}
+		}
+	}
+	return img, nil
+}
+
+// pushImages pushes the image to all target names. Targets on different
+// registries are pushed in parallel. Targets on the same registry are pushed
+// one after another so that blobs uploaded for the first repository can be
+// cross-repository mounted into the others instead of being uploaded again.
+// All targets are attempted even if some of them fail.
+func (e *imageExporterInstance) pushImages(ctx context.Context, src *exporter.Source, sessionID string, targetNames []string, dgst digest.Digest, reuse *LayerReuse) error {
 	var refs []cache.ImmutableRef
 	if src.Ref != nil {
 		refs = append(refs, src.Ref)
@@ -406,12 +591,94 @@ func (e *imageExporterInstance) pushImage(ctx context.Context, src *exporter.Sou
 		for _, desc := range remote.Descriptors {
 			mprovider.Add(desc.Digest, remote.Provider)
 			addAnnotations(annotations, desc)
+			addMountSources(annotations, desc.Digest, e.mountFrom)
+		}
+	}
+	for _, desc := range reuse.usedLayers() {
+		mprovider.Add(desc.Digest, reuse)
+		addMountSources(annotations, desc.Digest, append([]reference.Named{reuse.repo}, e.mountFrom...))
+	}
+
+	var domains []string
+	byDomain := map[string][]string{}
+	for _, targetName := range targetNames {
+		domain := targetName
+		if named, err := reference.ParseNormalizedNamed(targetName); err == nil {
+			domain = reference.Domain(named)
+		}
+		if _, ok := byDomain[domain]; !ok {
+			domains = append(domains, domain)
+		}
+		byDomain[domain] = append(byDomain[domain], targetName)
+	}
+
+	errs := make([][]error, len(domains))
+	var eg errgroup.Group
+	for i, domain := range domains {
+		eg.Go(func() error {
+			for _, targetName := range byDomain[domain] {
+				if err := e.pushImage(ctx, mprovider, annotations, sessionID, targetName, dgst, len(targetNames) > 1); err != nil {
+					errs[i] = append(errs[i], err)
+				}
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+	return stderrors.Join(slices.Concat(errs...)...)
+}
+
+func (e *imageExporterInstance) pushImage(ctx context.Context, provider content.Provider, annotations map[digest.Digest]map[string]string, sessionID string, targetName string, dgst digest.Digest, reportStatus bool) (err error) {
+	if reportStatus {
+		pushDone := progress.OneOff(ctx, "pushing to "+targetName)
+		defer func() {
+			pushDone(err)
+		}()
+	}
+	err = push.Push(ctx, e.opt.SessionManager, sessionID, provider, e.opt.ImageWriter.ContentStore(), dgst, targetName, e.insecure, e.opt.RegistryHosts, e.pushByDigest, annotations)
+	if err != nil {
+		var statusErr remoteserrors.ErrUnexpectedStatus
+		if errors.As(err, &statusErr) {
+			err = errutil.WithDetails(err)
 		}
+		return errors.Wrapf(err, "failed to push %v", targetName)
 	}
-	return push.Push(ctx, e.opt.SessionManager, sessionID, mprovider, e.opt.ImageWriter.ContentStore(), dgst, targetName, e.insecure, e.opt.RegistryHosts, e.pushByDigest, annotations)
+	return nil
 }
 
-func (e *imageExporterInstance) unpackImage(ctx context.Context, img images.Image, src *exporter.Source, s session.Group) (err0 error) {
+// unlazyRefs ensures that the blobs of all the refs of src are in the content
+// store
+func (e *imageExporterInstance) unlazyRefs(ctx context.Context, src *exporter.Source, sessionID string) error {
+	var refs []cache.ImmutableRef
+	if src.Ref != nil {
+		refs = append(refs, src.Ref)
+	}
+	for _, ref := range src.Refs {
+		if ref == nil {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, ref := range refs {
+		eg.Go(func() error {
+			remotes, err := ref.GetRemotes(ctx, false, e.opts.RefCfg, false, session.NewGroup(sessionID))
+			if err != nil {
+				return err
+			}
+			remote := remotes[0]
+			if unlazier, ok := remote.Provider.(cache.Unlazier); ok {
+				if err := unlazier.Unlazy(ctx); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+func (e *imageExporterInstance) unpackImage(ctx context.Context, img images.Image, src *exporter.Source, s session.Group, contentStore content.Store, snapshotter snapshot.Snapshotter) (err0 error) {
 	matcher := platforms.Only(platforms.Normalize(platforms.DefaultSpec()))
 
 	ps, err := exptypes.ParsePlatforms(src.Metadata)
@@ -443,11 +710,7 @@ func (e *imageExporterInstance) unpackImage(ctx context.Context, img images.Imag
 		unpackDone(err0)
 	}()
 
-	var (
-		contentStore = e.opt.ImageWriter.ContentStore()
-		applier      = e.opt.ImageWriter.Applier()
-		snapshotter  = e.opt.ImageWriter.Snapshotter()
-	)
+	applier := e.opt.ImageWriter.Applier()
 
 	// fetch manifest by default platform
 	manifest, err := images.Manifest(ctx, contentStore, img.Target, platforms.Default())
@@ -529,6 +792,31 @@ func addAnnotations(m map[digest.Digest]map[string]string, desc ocispecs.Descrip
 	maps.Copy(a, desc.Annotations)
 }
 
+// addMountSources adds repositories to the distribution sources of a blob so
+// that the pusher tries to mount the blob from them
+func addMountSources(m map[digest.Digest]map[string]string, dgst digest.Digest, repos []reference.Named) {
+	if len(repos) == 0 {
+		return
+	}
+	// annotations may be shared with the source descriptor
+	a := maps.Clone(m[dgst])
+	if a == nil {
+		a = make(map[string]string)
+	}
+	for _, repo := range repos {
+		key := "containerd.io/distribution.source." + reference.Domain(repo)
+		var sources []string
+		if v := a[key]; v != "" {
+			sources = strings.Split(v, ",")
+		}
+		if !slices.Contains(sources, reference.Path(repo)) {
+			sources = append(sources, reference.Path(repo))
+		}
+		a[key] = strings.Join(sources, ",")
+	}
+	m[dgst] = a
+}
+
 func NewDescriptorReference(desc ocispecs.Descriptor, release func(context.Context) error) exporter.DescriptorReference {
 	return &descriptorReference{
 		desc:    desc,
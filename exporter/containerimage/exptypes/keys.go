@@ -18,6 +18,24 @@ var (
 	// Value: bool <true|false>
 	OptKeyPushByDigest ImageExporterOptKey = "push-by-digest"
 
+	// Repositories that blobs can be cross-repository mounted from when
+	// pushing, in addition to the known sources of the blobs.
+	// Value: comma-separated repository names
+	OptKeyMountFrom ImageExporterOptKey = "mount-from"
+
+	// Image whose layers replace the layers of the image with the same
+	// uncompressed digest, so that pushing doesn't upload the layers that
+	// were only compressed again since the image was pushed.
+	// Value: image reference
+	OptKeyReuseLayersFrom ImageExporterOptKey = "reuse-layers-from"
+
+	// Names of a variant of the image with Docker media types, stored and
+	// pushed along with the image for clients that don't support the OCI
+	// media types. The OCI manifests are annotated with the digest of the
+	// variant. Requires OptKeyOCITypes.
+	// Value: comma-separated image names
+	OptKeyDockerCompatName ImageExporterOptKey = "docker-compat-name"
+
 	// Allow pushing to insecure HTTP registry.
 	// Value: bool <true|false>
 	OptKeyInsecure ImageExporterOptKey = "registry.insecure"
@@ -26,6 +44,13 @@ var (
 	// Value: bool <true|false>
 	OptKeyUnpack ImageExporterOptKey = "unpack"
 
+	// Also store the image in the image store of another containerd
+	// namespace (containerd worker only). The blobs are shared with the
+	// namespace of the worker, and the image is unpacked in the namespace
+	// if OptKeyUnpack is set.
+	// Value: string <namespace>
+	OptKeyContainerdNamespace ImageExporterOptKey = "containerd-namespace"
+
 	// Image name prefix to be used for tagging a dangling image.
 	// If used, image will be named as <value>@<digest> in addition
 	// to any other specified names.
@@ -53,6 +78,16 @@ var (
 	// Use OCI artifact format for the attestation manifest.
 	OptKeyOCIArtifact ImageExporterOptKey = "oci-artifact"
 
+	// Artifact type of the manifests, for exporting the result as an OCI
+	// artifact. The image config is replaced with the OCI empty descriptor.
+	// Value: string <media type>
+	OptKeyArtifactType ImageExporterOptKey = "artifact-type"
+
+	// Descriptor of the manifest the exported image or index refers to with
+	// its subject field.
+	// Value: JSON object of an OCI descriptor
+	OptKeySubject ImageExporterOptKey = "subject"
+
 	// Force attestation to be attached.
 	// Value: bool <true|false>
 	OptKeyForceInlineAttestations ImageExporterOptKey = "attestation-inline"
@@ -69,8 +104,9 @@ var (
 	OptKeySourceDateEpoch ImageExporterOptKey = ImageExporterOptKey(commonexptypes.OptKeySourceDateEpoch)
 
 	// Compression type for newly created and cached layers.
-	// estargz should be used with OptKeyOCITypes set to true.
-	// Value: string <uncompressed|gzip|estargz|zstd>
+	// estargz should be used with OptKeyOCITypes set to true. auto chooses
+	// the compression of each layer from a sample of its content.
+	// Value: string <uncompressed|gzip|estargz|zstd|auto>
 	OptKeyLayerCompression ImageExporterOptKey = "compression"
 
 	// Force compression on all (including existing) layers.
@@ -85,4 +121,10 @@ var (
 	// Rewrite timestamps in layers to match SOURCE_DATE_EPOCH
 	// Value: bool <true|false>
 	OptKeyRewriteTimestamp ImageExporterOptKey = "rewrite-timestamp"
+
+	// JSON patch (RFC 6902) applied to the image config of every platform
+	// after the patch set by the frontend. The patch can't change the rootfs
+	// or remove the history entries of the layers.
+	// Value: JSON array of patch operations
+	OptKeyConfigPatch ImageExporterOptKey = "config-patch"
 )
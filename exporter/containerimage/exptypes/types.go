@@ -8,21 +8,40 @@ import (
 )
 
 const (
-	ExporterConfigDigestKey      = "config.digest"
-	ExporterImageNameKey         = "image.name"
-	ExporterImageDigestKey       = "containerimage.digest"
-	ExporterImageConfigKey       = "containerimage.config"
-	ExporterImageConfigDigestKey = "containerimage.config.digest"
-	ExporterImageDescriptorKey   = "containerimage.descriptor"
-	ExporterImageBaseConfigKey   = "containerimage.base.config"
-	ExporterPlatformsKey         = "refs.platforms"
+	ExporterConfigDigestKey            = "config.digest"
+	ExporterImageNameKey               = "image.name"
+	ExporterImageDigestKey             = "containerimage.digest"
+	ExporterImageDockerCompatDigestKey = "containerimage.docker-compat.digest"
+	ExporterImageConfigKey             = "containerimage.config"
+	ExporterImageConfigDigestKey       = "containerimage.config.digest"
+	ExporterImageConfigPatchKey        = "containerimage.config.patch"
+	ExporterImageDescriptorKey         = "containerimage.descriptor"
+	ExporterImageBaseConfigKey         = "containerimage.base.config"
+	ExporterImageArtifactTypeKey       = "containerimage.artifact-type"
+	ExporterImageSubjectKey            = "containerimage.subject"
+	ExporterImagePushedKey             = "containerimage.pushed"
+	ExporterImageManifestsKey          = "containerimage.manifests"
+	ExporterPlatformsKey               = "refs.platforms"
+	// ExporterPlatformsMissingKey lists the platforms of a multi-platform
+	// result that were canceled during the build
+	ExporterPlatformsMissingKey = "refs.platforms.missing"
 )
 
+// AnnotationDockerCompatManifest is set on the manifests of an image exported
+// with a Docker media types variant to the digest of the variant manifest of
+// the same platform, and on the index to the digest of the variant index
+const AnnotationDockerCompatManifest = "moby.buildkit.docker-compat.manifest"
+
+// AnnotationPlatformsMissing is set on the index of a multi-platform image to
+// the platforms that were canceled during the build, separated by commas
+const AnnotationPlatformsMissing = "moby.buildkit.platforms.missing"
+
 // KnownRefMetadataKeys are the subset of exporter keys that can be suffixed by
 // a platform to become platform specific
 var KnownRefMetadataKeys = []string{
 	ExporterImageConfigKey,
 	ExporterImageBaseConfigKey,
+	ExporterImageConfigPatchKey,
 }
 
 type Platforms struct {
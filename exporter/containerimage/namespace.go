@@ -0,0 +1,106 @@
+package containerimage
+
+import (
+	"context"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/leases"
+	"github.com/moby/buildkit/exporter"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/snapshot"
+	"github.com/moby/buildkit/util/contentutil"
+	"github.com/moby/buildkit/util/leaseutil"
+	"github.com/moby/buildkit/util/progress"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// Namespace is a containerd namespace that images can be exported to in
+// addition to the image store of the worker
+type Namespace struct {
+	Images       images.Store
+	ContentStore content.Store
+	LeaseManager leases.Manager
+	Snapshotter  snapshot.Snapshotter
+}
+
+// exportToNamespace stores the image in the image store of another containerd
+// namespace. The blobs and the snapshots are created under a lease of the
+// namespace that is only released once the image references them.
+func (e *imageExporterInstance) exportToNamespace(ctx context.Context, src *exporter.Source, sessionID string, targetNames []string, desc ocispecs.Descriptor, nameCanonical bool) (err error) {
+	ns, err := e.opt.Namespace(e.namespace)
+	if err != nil {
+		return err
+	}
+
+	if err := e.unlazyRefs(ctx, src, sessionID); err != nil {
+		return err
+	}
+
+	ctx, done, err := leaseutil.WithLease(ctx, ns.LeaseManager, leaseutil.MakeTemporary)
+	if err != nil {
+		return err
+	}
+	defer done(context.WithoutCancel(ctx))
+
+	copyDone := progress.OneOff(ctx, "copying to containerd namespace "+e.namespace)
+	if err := copyContentWithLabels(ctx, ns.ContentStore, e.opt.ImageWriter.ContentStore(), desc); err != nil {
+		return copyDone(err)
+	}
+	copyDone(nil)
+
+	var img images.Image
+	for _, targetName := range targetNames {
+		tagDone := progress.OneOff(ctx, "naming to "+targetName+" in containerd namespace "+e.namespace)
+		img, err = e.nameImage(ctx, ns.Images, targetName, desc, nameCanonical)
+		if err != nil {
+			return tagDone(err)
+		}
+		tagDone(nil)
+	}
+
+	if e.unpack {
+		return e.unpackImage(ctx, img, src, session.NewGroup(sessionID), ns.ContentStore, ns.Snapshotter)
+	}
+	return nil
+}
+
+// copyContentWithLabels copies the blobs of desc and their labels. containerd
+// shares the blobs between namespaces by default, so only their metadata is
+// written and the data isn't copied.
+func copyContentWithLabels(ctx context.Context, dst, src content.Store, desc ocispecs.Descriptor) error {
+	visited := map[string]struct{}{}
+	handler := images.HandlerFunc(func(ctx context.Context, desc ocispecs.Descriptor) ([]ocispecs.Descriptor, error) {
+		if _, ok := visited[desc.Digest.String()]; ok {
+			return nil, images.ErrSkipDesc
+		}
+		visited[desc.Digest.String()] = struct{}{}
+
+		if err := contentutil.Copy(ctx, dst, src, desc, "", nil); err != nil {
+			return nil, errors.Wrapf(err, "failed to copy %s", desc.Digest)
+		}
+		info, err := src.Info(ctx, desc.Digest)
+		if err != nil {
+			return nil, err
+		}
+		labels := map[string]string{}
+		var fields []string
+		for k, v := range info.Labels {
+			// the snapshots of the worker namespace are not shared
+			if strings.HasPrefix(k, "containerd.io/gc.ref.snapshot.") {
+				continue
+			}
+			labels[k] = v
+			fields = append(fields, "labels."+k)
+		}
+		if len(fields) > 0 {
+			if _, err := dst.Update(ctx, content.Info{Digest: desc.Digest, Labels: labels}, fields...); err != nil {
+				return nil, errors.Wrapf(err, "failed to update labels of %s", desc.Digest)
+			}
+		}
+		return images.Children(ctx, src, desc)
+	})
+	return images.Walk(ctx, handler, desc)
+}
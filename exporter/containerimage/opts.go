@@ -2,6 +2,7 @@ package containerimage
 
 import (
 	"context"
+	"encoding/json"
 	"strconv"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 	"github.com/moby/buildkit/exporter/util/epoch"
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/compression"
+	"github.com/moby/buildkit/util/jsonpatch"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 )
 
@@ -20,9 +23,16 @@ type ImageCommitOpts struct {
 	OCIArtifact bool
 	Annotations AnnotationsGroup
 	Epoch       *time.Time
+	// ArtifactType exports the manifests as OCI artifacts of the type
+	ArtifactType string
+	// Subject is the manifest the exported image refers to
+	Subject *ocispecs.Descriptor
 
 	ForceInlineAttestations bool // force inline attestations to be attached
 	RewriteTimestamp        bool // rewrite timestamps in layers to match the epoch
+	ConfigPatch             jsonpatch.Patch
+	// LayerReuse replaces the layers with the layers of a pushed image
+	LayerReuse *LayerReuse
 }
 
 func (c *ImageCommitOpts) Load(ctx context.Context, opt map[string]string) (map[string]string, error) {
@@ -58,6 +68,13 @@ func (c *ImageCommitOpts) Load(ctx context.Context, opt map[string]string) (map[
 			err = parseBool(&c.RefCfg.PreferNonDistributable, k, v)
 		case exptypes.OptKeyRewriteTimestamp:
 			err = parseBool(&c.RewriteTimestamp, k, v)
+		case exptypes.OptKeyArtifactType:
+			c.ArtifactType = v
+		case exptypes.OptKeySubject:
+			c.Subject, err = ParseSubject([]byte(v))
+		case exptypes.OptKeyConfigPatch:
+			c.ConfigPatch, err = jsonpatch.Parse([]byte(v))
+			err = errors.Wrapf(err, "invalid %s", k)
 		default:
 			rest[k] = v
 		}
@@ -73,6 +90,15 @@ func (c *ImageCommitOpts) Load(ctx context.Context, opt map[string]string) (map[
 	if c.OCIArtifact && !c.OCITypes {
 		c.EnableOCITypes(ctx, "oci-artifact")
 	}
+	if c.ArtifactType != "" || c.Subject != nil {
+		c.EnableOCITypes(ctx, "artifacts")
+	}
+	if c.RefCfg.Compression.Type == compression.Auto && !c.OCITypes {
+		// zstd layers need the OCI media types
+		auto := *c.RefCfg.Compression.Auto
+		auto.Gzip = true
+		c.RefCfg.Compression.Auto = &auto
+	}
 
 	c.Annotations = c.Annotations.Merge(as)
 
@@ -103,6 +129,21 @@ func (c *ImageCommitOpts) EnableForceCompression(ctx context.Context, reason str
 	}
 }
 
+// ParseSubject parses the JSON descriptor of the subject of an image
+func ParseSubject(dt []byte) (*ocispecs.Descriptor, error) {
+	var desc ocispecs.Descriptor
+	if err := json.Unmarshal(dt, &desc); err != nil {
+		return nil, errors.Wrap(err, "failed to parse subject descriptor")
+	}
+	if err := desc.Digest.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid subject digest")
+	}
+	if desc.MediaType == "" || desc.Size <= 0 {
+		return nil, errors.Errorf("subject %s requires a media type and a size", desc.Digest)
+	}
+	return &desc, nil
+}
+
 func parseBool(dest *bool, key string, value string) error {
 	b, err := strconv.ParseBool(value)
 	if err != nil {
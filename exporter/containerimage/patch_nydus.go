@@ -3,12 +3,23 @@
 package containerimage
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
 
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/nydus-snapshotter/pkg/converter"
 	"github.com/moby/buildkit/cache"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/util/compression"
+	digest "github.com/opencontainers/go-digest"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 )
@@ -16,19 +27,115 @@ import (
 // patchImageLayers appends an extra nydus bootstrap layer
 // to the manifest of nydus image, normalizes layers and
 // history. The nydus bootstrap layer represents the whole
-// metadata of filesystem view for the entire image.
+// metadata of filesystem view for the entire image. The
+// blobs of the chunk dict that the bootstrap references
+// are added before it.
 func patchImageLayers(ctx context.Context, remote *solver.Remote, history []ocispecs.History, ref cache.ImmutableRef, opts *ImageCommitOpts, sg session.Group) (*solver.Remote, []ocispecs.History, error) {
 	if opts.RefCfg.Compression.Type != compression.Nydus {
 		remote, history = normalizeLayersAndHistory(ctx, remote, history, ref, opts.OCITypes)
 		return remote, history, nil
 	}
 
-	desc, err := cache.MergeNydus(ctx, ref, opts.RefCfg.Compression, sg)
+	desc, dictBlobs, err := cache.MergeNydus(ctx, ref, opts.RefCfg.Compression, sg)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "merge nydus layer")
 	}
+	remote.Descriptors = append(remote.Descriptors, dictBlobs...)
 	remote.Descriptors = append(remote.Descriptors, *desc)
 
 	remote, history = normalizeLayersAndHistory(ctx, remote, history, ref, opts.OCITypes)
 	return remote, history, nil
 }
+
+// prepareNydusChunkDict extracts the bootstrap of the chunk dict image of a
+// nydus export, so that the builder skips the chunks that already exist in
+// that image
+func prepareNydusChunkDict(ctx context.Context, cs content.Store, opts *ImageCommitOpts) (func(), error) {
+	nc := opts.RefCfg.Compression.Nydus
+	if opts.RefCfg.Compression.Type != compression.Nydus || nc == nil || nc.ChunkDict == "" {
+		return func() {}, nil
+	}
+	desc, err := nydusBootstrapLayer(ctx, cs, nc.ChunkDict)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to resolve nydus chunk dict %s", nc.ChunkDict)
+	}
+	dir, err := os.MkdirTemp("", "buildkit-nydus-chunk-dict-")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	p := filepath.Join(dir, converter.EntryBootstrap)
+	if err := extractNydusBootstrap(ctx, cs, desc, p); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Wrapf(err, "failed to extract nydus chunk dict %s", nc.ChunkDict)
+	}
+
+	withDict := *nc
+	withDict.ChunkDictPath = p
+	opts.RefCfg.Compression.Nydus = &withDict
+	return func() {
+		opts.RefCfg.Compression.Nydus = nc
+		os.RemoveAll(dir)
+	}, nil
+}
+
+// nydusBootstrapLayer returns the descriptor of the bootstrap layer dgst, or
+// of the bootstrap layer of the manifest dgst
+func nydusBootstrapLayer(ctx context.Context, cs content.Store, dgst digest.Digest) (ocispecs.Descriptor, error) {
+	info, err := cs.Info(ctx, dgst)
+	if err != nil {
+		return ocispecs.Descriptor{}, err
+	}
+	desc := ocispecs.Descriptor{Digest: dgst, Size: info.Size}
+	dt, err := content.ReadBlob(ctx, cs, desc)
+	if err != nil {
+		return ocispecs.Descriptor{}, err
+	}
+	var mfst ocispecs.Manifest
+	if err := json.Unmarshal(dt, &mfst); err != nil || !images.IsManifestType(mfst.MediaType) {
+		// not a manifest, expected to be the bootstrap layer
+		return desc, nil
+	}
+	for _, l := range mfst.Layers {
+		if _, ok := l.Annotations[converter.LayerAnnotationNydusBootstrap]; ok {
+			return l, nil
+		}
+	}
+	return ocispecs.Descriptor{}, errors.Errorf("%s is not a nydus image", dgst)
+}
+
+// extractNydusBootstrap writes the bootstrap of the gzipped bootstrap layer
+// desc to p
+func extractNydusBootstrap(ctx context.Context, cs content.Store, desc ocispecs.Descriptor, p string) error {
+	ra, err := cs.ReaderAt(ctx, desc)
+	if err != nil {
+		return err
+	}
+	defer ra.Close()
+	gr, err := gzip.NewReader(content.NewReader(ra))
+	if err != nil {
+		return errors.Wrap(err, "bootstrap layer is not gzipped")
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.Errorf("no %s in bootstrap layer", converter.EntryBootstrap)
+			}
+			return errors.WithStack(err)
+		}
+		if hdr.Typeflag != tar.TypeReg || path.Base(hdr.Name) != converter.EntryBootstrap {
+			continue
+		}
+		f, err := os.Create(p)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return errors.WithStack(err)
+		}
+		return errors.WithStack(f.Close())
+	}
+}
@@ -0,0 +1,190 @@
+package containerimage
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/containerd/containerd/v2/pkg/labels"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/compression"
+	"github.com/moby/buildkit/util/contentutil"
+	"github.com/moby/buildkit/util/progress"
+	"github.com/moby/buildkit/util/resolver"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// LayerReuse replaces the layers of the exported image with the layers of a
+// previously pushed image that have the same uncompressed digest. The blobs
+// of the layers compressed again since the image was pushed then don't need
+// to be uploaded, they are already in the registry.
+type LayerReuse struct {
+	repo     reference.Named
+	provider content.Provider
+	// layers are the layers of the image by uncompressed digest
+	layers map[digest.Digest]ocispecs.Descriptor
+
+	mu   sync.Mutex
+	used map[digest.Digest]ocispecs.Descriptor
+}
+
+// resolveLayerReuse resolves the layers of the image ref for the platforms.
+// A nil LayerReuse is returned if the image doesn't exist, e.g. before it is
+// pushed for the first time.
+func resolveLayerReuse(ctx context.Context, ref reference.Named, ps []ocispecs.Platform, hosts docker.RegistryHosts, sm *session.Manager, sessionID string) (_ *LayerReuse, err error) {
+	done := progress.OneOff(ctx, "resolving layers of "+ref.String())
+	defer func() {
+		done(err)
+	}()
+
+	r := resolver.DefaultPool.GetResolver(hosts, ref.String(), "pull", sm, session.NewGroup(sessionID))
+	name, desc, err := r.Resolve(ctx, ref.String())
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			bklog.G(ctx).Infof("not reusing the layers of %s: %v", ref, err)
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to resolve %s", ref)
+	}
+	fetcher, err := r.Fetcher(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	lr := &LayerReuse{
+		repo:     reference.TrimNamed(ref),
+		provider: contentutil.FromFetcher(fetcher),
+		layers:   map[digest.Digest]ocispecs.Descriptor{},
+		used:     map[digest.Digest]ocispecs.Descriptor{},
+	}
+	if len(ps) == 0 {
+		ps = []ocispecs.Platform{platforms.DefaultSpec()}
+	}
+	for _, p := range ps {
+		mfst, err := images.Manifest(ctx, lr.provider, desc, platforms.OnlyStrict(p))
+		if err != nil {
+			if cerrdefs.IsNotFound(err) {
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to read the manifest of %s for %s", ref, platforms.Format(p))
+		}
+		if err := lr.addLayers(ctx, mfst); err != nil {
+			return nil, errors.Wrapf(err, "failed to read the config of %s for %s", ref, platforms.Format(p))
+		}
+	}
+	return lr, nil
+}
+
+func (lr *LayerReuse) addLayers(ctx context.Context, mfst ocispecs.Manifest) error {
+	dt, err := content.ReadBlob(ctx, lr.provider, mfst.Config)
+	if err != nil {
+		return err
+	}
+	var img ocispecs.Image
+	if err := json.Unmarshal(dt, &img); err != nil {
+		return errors.WithStack(err)
+	}
+	if len(img.RootFS.DiffIDs) != len(mfst.Layers) {
+		return errors.Errorf("the config has %d layers but the manifest has %d", len(img.RootFS.DiffIDs), len(mfst.Layers))
+	}
+	for i, l := range mfst.Layers {
+		if images.IsNonDistributable(l.MediaType) {
+			continue
+		}
+		lr.layers[img.RootFS.DiffIDs[i]] = l
+	}
+	return nil
+}
+
+// replace returns the layer of the image with the same uncompressed digest
+// as desc, or desc if there is none
+func (lr *LayerReuse) replace(ctx context.Context, cs content.InfoProvider, desc ocispecs.Descriptor, opts *ImageCommitOpts) ocispecs.Descriptor {
+	if lr == nil {
+		return desc
+	}
+	comp := opts.RefCfg.Compression
+	switch comp.Type {
+	case compression.Uncompressed, compression.Gzip, compression.Zstd, compression.Auto:
+	default:
+		// the formats like estargz would be lost
+		return desc
+	}
+	diffID := digest.Digest(desc.Annotations[labels.LabelUncompressed])
+	if diffID == "" {
+		info, err := cs.Info(ctx, desc.Digest)
+		if err != nil {
+			return desc
+		}
+		diffID = digest.Digest(info.Labels[labels.LabelUncompressed])
+	}
+	l, ok := lr.layers[diffID]
+	if !ok || l.Digest == desc.Digest {
+		return desc
+	}
+	if comp.Force {
+		if t, err := compression.FromMediaType(l.MediaType); err != nil || t != comp.Type {
+			return desc
+		}
+	}
+	l = compression.ConvertAllLayerMediaTypes(ctx, opts.OCITypes, l)[0]
+	// the internal annotations of the layer are removed from the manifest
+	l.Annotations = maps.Clone(l.Annotations)
+	if l.Annotations == nil {
+		l.Annotations = map[string]string{}
+	}
+	l.Annotations[labels.LabelUncompressed] = diffID.String()
+
+	lr.mu.Lock()
+	lr.used[l.Digest] = l
+	lr.mu.Unlock()
+	return l
+}
+
+// usedLayers returns the layers of the image that replaced layers of the
+// exported image
+func (lr *LayerReuse) usedLayers() []ocispecs.Descriptor {
+	if lr == nil {
+		return nil
+	}
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	return slices.Collect(maps.Values(lr.used))
+}
+
+func (lr *LayerReuse) ReaderAt(ctx context.Context, desc ocispecs.Descriptor) (content.ReaderAt, error) {
+	return lr.provider.ReaderAt(ctx, desc)
+}
+
+func (lr *LayerReuse) Info(ctx context.Context, dgst digest.Digest) (content.Info, error) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+	l, ok := lr.used[dgst]
+	if !ok {
+		return content.Info{}, errors.Wrapf(cerrdefs.ErrNotFound, "layer %s", dgst)
+	}
+	return content.Info{Digest: l.Digest, Size: l.Size}, nil
+}
+
+// fetch copies the used layers to the content store, for the exports that
+// need all the blobs of the image
+func (lr *LayerReuse) fetch(ctx context.Context, cs content.Store) error {
+	for _, l := range lr.usedLayers() {
+		if _, err := cs.Info(ctx, l.Digest); err == nil {
+			continue
+		}
+		if err := contentutil.Copy(ctx, cs, lr.provider, l, lr.repo.String(), nil); err != nil {
+			return errors.Wrapf(err, "failed to fetch layer %s of %s", l.Digest, lr.repo)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package containerimage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/pkg/labels"
+	"github.com/distribution/reference"
+	cacheconfig "github.com/moby/buildkit/cache/config"
+	"github.com/moby/buildkit/util/compression"
+	"github.com/moby/buildkit/util/contentutil"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayerReuse(t *testing.T) {
+	ctx := context.TODO()
+	buf := contentutil.NewBuffer()
+
+	diffIDs := []digest.Digest{digest.FromString("diff0"), digest.FromString("diff1")}
+	dt, err := json.Marshal(ocispecs.Image{
+		RootFS: ocispecs.RootFS{Type: "layers", DiffIDs: diffIDs},
+	})
+	require.NoError(t, err)
+	cfg := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageConfig,
+		Digest:    digest.FromBytes(dt),
+		Size:      int64(len(dt)),
+	}
+	require.NoError(t, content.WriteBlob(ctx, buf, "config", bytes.NewReader(dt), cfg))
+
+	pushed := []ocispecs.Descriptor{
+		{MediaType: ocispecs.MediaTypeImageLayerGzip, Digest: digest.FromString("pushed0"), Size: 10},
+		{MediaType: ocispecs.MediaTypeImageLayerZstd, Digest: digest.FromString("pushed1"), Size: 11},
+	}
+	repo, err := reference.ParseNormalizedNamed("example.com/app")
+	require.NoError(t, err)
+	lr := &LayerReuse{
+		repo:     repo,
+		provider: buf,
+		layers:   map[digest.Digest]ocispecs.Descriptor{},
+		used:     map[digest.Digest]ocispecs.Descriptor{},
+	}
+	require.NoError(t, lr.addLayers(ctx, ocispecs.Manifest{Config: cfg, Layers: pushed}))
+
+	layer := func(name string, diffID digest.Digest) ocispecs.Descriptor {
+		return ocispecs.Descriptor{
+			MediaType:   ocispecs.MediaTypeImageLayerGzip,
+			Digest:      digest.FromString(name),
+			Size:        12,
+			Annotations: map[string]string{labels.LabelUncompressed: diffID.String()},
+		}
+	}
+	opts := &ImageCommitOpts{
+		RefCfg: cacheconfig.RefConfig{Compression: compression.New(compression.Gzip)},
+	}
+
+	// Docker media types are converted
+	desc := lr.replace(ctx, buf, layer("local0", diffIDs[0]), opts)
+	require.Equal(t, pushed[0].Digest, desc.Digest)
+	require.Equal(t, images.MediaTypeDockerSchema2LayerGzip, desc.MediaType)
+	require.Equal(t, diffIDs[0].String(), desc.Annotations[labels.LabelUncompressed])
+
+	// the layers of other contents are kept
+	local := layer("local2", digest.FromString("diff2"))
+	require.Equal(t, local, lr.replace(ctx, buf, local, opts))
+
+	// forced compression keeps the layers of other compressions
+	force := &ImageCommitOpts{
+		OCITypes: true,
+		RefCfg:   cacheconfig.RefConfig{Compression: compression.New(compression.Gzip).SetForce(true)},
+	}
+	local = layer("local1", diffIDs[1])
+	require.Equal(t, local, lr.replace(ctx, buf, local, force))
+
+	desc = lr.replace(ctx, buf, layer("local1", diffIDs[1]), &ImageCommitOpts{OCITypes: true, RefCfg: opts.RefCfg})
+	require.Equal(t, pushed[1].Digest, desc.Digest)
+	require.Equal(t, ocispecs.MediaTypeImageLayerZstd, desc.MediaType)
+
+	// estargz layers aren't replaced
+	estargz := &ImageCommitOpts{
+		OCITypes: true,
+		RefCfg:   cacheconfig.RefConfig{Compression: compression.New(compression.EStargz)},
+	}
+	local = layer("local0", diffIDs[0])
+	require.Equal(t, local, lr.replace(ctx, buf, local, estargz))
+
+	used := lr.usedLayers()
+	require.Len(t, used, 2)
+	info, err := lr.Info(ctx, pushed[1].Digest)
+	require.NoError(t, err)
+	require.Equal(t, pushed[1].Size, info.Size)
+
+	// without a reuse the layers are kept
+	var nilReuse *LayerReuse
+	require.Equal(t, local, nilReuse.replace(ctx, buf, local, opts))
+	require.Empty(t, nilReuse.usedLayers())
+}
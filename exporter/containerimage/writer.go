@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"reflect"
 	"strconv"
 	"strings"
@@ -31,6 +32,7 @@ import (
 	"github.com/moby/buildkit/util/compression"
 	"github.com/moby/buildkit/util/contentutil"
 	"github.com/moby/buildkit/util/converter"
+	"github.com/moby/buildkit/util/jsonpatch"
 	"github.com/moby/buildkit/util/progress"
 	"github.com/moby/buildkit/util/purl"
 	"github.com/moby/buildkit/util/system"
@@ -100,6 +102,25 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 			opts.Epoch = tm
 		}
 	}
+	if v, ok := inp.Metadata[exptypes.ExporterImageArtifactTypeKey]; ok && opts.ArtifactType == "" {
+		opts.ArtifactType = string(v)
+	}
+	if v, ok := inp.Metadata[exptypes.ExporterImageSubjectKey]; ok && opts.Subject == nil {
+		if opts.Subject, err = ParseSubject(v); err != nil {
+			return nil, errors.Wrap(err, "invalid subject from frontend")
+		}
+	}
+	if opts.ArtifactType != "" || opts.Subject != nil {
+		if !opts.OCITypes {
+			return nil, errors.Errorf("artifact type and subject require OCI media types")
+		}
+	}
+
+	cleanup, err := prepareNydusChunkDict(ctx, ic.opt.ContentStore, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
 
 	for pk, a := range opts.Annotations {
 		if pk != "" {
@@ -112,6 +133,13 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 		}
 	}
 
+	if !isMap {
+		// index annotations are set on an index with the single manifest
+		if a := opts.Annotations.Platform(nil); len(a.Index)+len(a.IndexDescriptor) > 0 {
+			isMap = true
+		}
+	}
+
 	if !isMap {
 		if len(ps.Platforms) > 1 {
 			return nil, errors.Errorf("cannot export multiple platforms without multi-platform enabled")
@@ -128,6 +156,7 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 			ref = inp.Ref
 		}
 		config := exptypes.ParseKey(inp.Metadata, exptypes.ExporterImageConfigKey, p)
+		configPatch := exptypes.ParseKey(inp.Metadata, exptypes.ExporterImageConfigPatchKey, p)
 		baseImgConfig := exptypes.ParseKey(inp.Metadata, exptypes.ExporterImageBaseConfigKey, p)
 		var baseImg *dockerspec.DockerOCIImage
 		if len(baseImgConfig) > 0 {
@@ -151,9 +180,6 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 		}
 
 		annotations := opts.Annotations.Platform(nil)
-		if len(annotations.Index) > 0 || len(annotations.IndexDescriptor) > 0 {
-			return nil, errors.Errorf("index annotations not supported for single platform export")
-		}
 
 		var inlineCacheEntry *exptypes.InlineCacheEntry
 		if inlineCache != nil {
@@ -170,7 +196,7 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 			}
 		}
 
-		mfstDesc, configDesc, err := ic.commitDistributionManifest(ctx, opts, ref, config, remote, annotations, inlineCacheEntry, opts.Epoch, session.NewGroup(sessionID), baseImg)
+		mfstDesc, configDesc, err := ic.commitDistributionManifest(ctx, opts, ref, config, configPatch, remote, annotations, inlineCacheEntry, opts.Epoch, session.NewGroup(sessionID), baseImg, opts.Subject)
 		if err != nil {
 			return nil, err
 		}
@@ -214,8 +240,10 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 	}
 
 	idx := ocispecs.Index{
-		MediaType:   ocispecs.MediaTypeImageIndex,
-		Annotations: opts.Annotations.Platform(nil).Index,
+		MediaType:    ocispecs.MediaTypeImageIndex,
+		ArtifactType: opts.ArtifactType,
+		Subject:      opts.Subject,
+		Annotations:  opts.Annotations.Platform(nil).Index,
 		Versioned: specs.Versioned{
 			SchemaVersion: 2,
 		},
@@ -225,6 +253,22 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 		idx.MediaType = images.MediaTypeDockerSchema2ManifestList
 	}
 
+	if dt, ok := inp.Metadata[exptypes.ExporterPlatformsMissingKey]; ok {
+		var missing exptypes.Platforms
+		if err := json.Unmarshal(dt, &missing); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", exptypes.ExporterPlatformsMissingKey)
+		}
+		ids := make([]string, 0, len(missing.Platforms))
+		for _, p := range missing.Platforms {
+			ids = append(ids, p.ID)
+		}
+		idx.Annotations = maps.Clone(idx.Annotations)
+		if idx.Annotations == nil {
+			idx.Annotations = map[string]string{}
+		}
+		idx.Annotations[exptypes.AnnotationPlatformsMissing] = strings.Join(ids, ",")
+	}
+
 	labels := map[string]string{}
 
 	var attestationManifests []ocispecs.Descriptor
@@ -235,6 +279,7 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 			return nil, errors.Errorf("failed to find ref for ID %s", p.ID)
 		}
 		config := exptypes.ParseKey(inp.Metadata, exptypes.ExporterImageConfigKey, &p)
+		configPatch := exptypes.ParseKey(inp.Metadata, exptypes.ExporterImageConfigPatchKey, &p)
 		baseImgConfig := exptypes.ParseKey(inp.Metadata, exptypes.ExporterImageBaseConfigKey, &p)
 		var baseImg *dockerspec.DockerOCIImage
 		if len(baseImgConfig) > 0 {
@@ -263,7 +308,7 @@ func (ic *ImageWriter) Commit(ctx context.Context, inp *exporter.Source, session
 			inlineCacheEntry, _ = inlineCacheResult.FindRef(p.ID)
 		}
 
-		desc, _, err := ic.commitDistributionManifest(ctx, opts, r, config, remote, opts.Annotations.Platform(&p.Platform), inlineCacheEntry, opts.Epoch, session.NewGroup(sessionID), baseImg)
+		desc, _, err := ic.commitDistributionManifest(ctx, opts, r, config, configPatch, remote, opts.Annotations.Platform(&p.Platform), inlineCacheEntry, opts.Epoch, session.NewGroup(sessionID), baseImg, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -459,7 +504,7 @@ func (ic *ImageWriter) rewriteRemoteWithEpoch(ctx context.Context, opts *ImageCo
 	}, nil
 }
 
-func (ic *ImageWriter) commitDistributionManifest(ctx context.Context, opts *ImageCommitOpts, ref cache.ImmutableRef, config []byte, remote *solver.Remote, annotations *Annotations, inlineCache *exptypes.InlineCacheEntry, epoch *time.Time, sg session.Group, baseImg *dockerspec.DockerOCIImage) (*ocispecs.Descriptor, *ocispecs.Descriptor, error) {
+func (ic *ImageWriter) commitDistributionManifest(ctx context.Context, opts *ImageCommitOpts, ref cache.ImmutableRef, config, configPatch []byte, remote *solver.Remote, annotations *Annotations, inlineCache *exptypes.InlineCacheEntry, epoch *time.Time, sg session.Group, baseImg *dockerspec.DockerOCIImage, subject *ocispecs.Descriptor) (*ocispecs.Descriptor, *ocispecs.Descriptor, error) {
 	if len(config) == 0 {
 		var err error
 		config, err = defaultImageConfig()
@@ -483,6 +528,20 @@ func (ic *ImageWriter) commitDistributionManifest(ctx context.Context, opts *Ima
 		return nil, nil, err
 	}
 
+	if len(configPatch) > 0 || len(opts.ConfigPatch) > 0 {
+		var frontendPatch jsonpatch.Patch
+		if len(configPatch) > 0 {
+			frontendPatch, err = jsonpatch.Parse(configPatch)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "invalid image config patch from frontend")
+			}
+		}
+		config, err = applyConfigPatches(config, frontendPatch, opts.ConfigPatch)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	var (
 		configDigest = digest.FromBytes(config)
 		manifestType = ocispecs.MediaTypeImageManifest
@@ -495,9 +554,18 @@ func (ic *ImageWriter) commitDistributionManifest(ctx context.Context, opts *Ima
 		configType = images.MediaTypeDockerSchema2Config
 	}
 
+	if opts.ArtifactType != "" {
+		// artifacts have no image config
+		config = ocispecs.DescriptorEmptyJSON.Data
+		configDigest = ocispecs.DescriptorEmptyJSON.Digest
+		configType = ocispecs.DescriptorEmptyJSON.MediaType
+	}
+
 	mfst := ocispecs.Manifest{
-		MediaType:   manifestType,
-		Annotations: annotations.Manifest,
+		MediaType:    manifestType,
+		ArtifactType: opts.ArtifactType,
+		Subject:      subject,
+		Annotations:  annotations.Manifest,
 		Versioned: specs.Versioned{
 			SchemaVersion: 2,
 		},
@@ -513,6 +581,7 @@ func (ic *ImageWriter) commitDistributionManifest(ctx context.Context, opts *Ima
 	}
 
 	for i, desc := range remote.Descriptors {
+		desc = opts.LayerReuse.replace(ctx, ic.opt.ContentStore, desc, opts)
 		desc.Annotations = RemoveInternalLayerAnnotations(desc.Annotations, opts.OCITypes)
 		mfst.Layers = append(mfst.Layers, desc)
 		labels[fmt.Sprintf("containerd.io/gc.ref.content.%d", i+1)] = desc.Digest.String()
@@ -811,6 +880,50 @@ func patchImageConfig(dt []byte, descs []ocispecs.Descriptor, history []ocispecs
 	return dt, errors.Wrap(err, "failed to marshal config after patch")
 }
 
+// applyConfigPatches applies the patches to the image config in order. The
+// patches may change any field of the config apart from the layers.
+func applyConfigPatches(dt []byte, patches ...jsonpatch.Patch) ([]byte, error) {
+	var before ocispecs.Image
+	if err := json.Unmarshal(dt, &before); err != nil {
+		return nil, errors.Wrap(err, "invalid image config for patch")
+	}
+	for _, p := range patches {
+		if len(p) == 0 {
+			continue
+		}
+		var err error
+		if dt, err = p.Apply(dt); err != nil {
+			return nil, errors.Wrap(err, "failed to patch image config")
+		}
+	}
+
+	var img ocispecs.Image
+	if err := json.Unmarshal(dt, &img); err != nil {
+		return nil, errors.Wrap(err, "invalid image config after patch")
+	}
+	if img.OS == "" {
+		return nil, errors.Errorf("invalid image config after patch: missing os")
+	}
+	if img.Architecture == "" {
+		return nil, errors.Errorf("invalid image config after patch: missing architecture")
+	}
+	if !reflect.DeepEqual(before.RootFS, img.RootFS) {
+		return nil, errors.Errorf("invalid image config after patch: rootfs can't be changed")
+	}
+	if len(img.History) > 0 || len(before.History) > 0 {
+		var layers int
+		for _, h := range img.History {
+			if !h.EmptyLayer {
+				layers++
+			}
+		}
+		if layers != len(img.RootFS.DiffIDs) {
+			return nil, errors.Errorf("invalid image config after patch: %d history entries for %d layers", layers, len(img.RootFS.DiffIDs))
+		}
+	}
+	return dt, nil
+}
+
 func normalizeLayersAndHistory(ctx context.Context, remote *solver.Remote, history []ocispecs.History, ref cache.ImmutableRef, oci bool) (*solver.Remote, []ocispecs.History) {
 	refMeta := getRefMetadata(ref, len(remote.Descriptors))
 
@@ -2,6 +2,7 @@ package local
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,12 +25,21 @@ import (
 	"github.com/moby/sys/user"
 	digest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/tonistiigi/fsutil"
 	fstypes "github.com/tonistiigi/fsutil/types"
 )
 
 const (
 	keyAttestationPrefix = "attestation-prefix"
+	// keyAttestationsOnly is an exporter option which can be used to export
+	// only the attestations of the result, e.g. to archive them next to an
+	// image pushed to a registry.
+	keyAttestationsOnly = "attestations-only"
+	// keyAttestationFormat is an exporter option which can be used to write
+	// the attestations as in-toto statements (default) or as unsigned DSSE
+	// envelopes.
+	keyAttestationFormat = "attestation-format"
 	// keyPlatformSplit is an exporter option which can be used to split result
 	// in subfolders when multiple platform references are exported.
 	keyPlatformSplit = "platform-split"
@@ -39,8 +49,15 @@ type CreateFSOpts struct {
 	Epoch             *time.Time
 	AttestationPrefix string
 	PlatformSplit     *bool
+	AttestationsOnly  bool
+	AttestationFormat string
 }
 
+const (
+	AttestationFormatStatement = "statement"
+	AttestationFormatDSSE      = "dsse"
+)
+
 func (c *CreateFSOpts) UsePlatformSplit(isMap bool) bool {
 	if c.PlatformSplit == nil {
 		return isMap
@@ -67,6 +84,19 @@ func (c *CreateFSOpts) Load(opt map[string]string) (map[string]string, error) {
 				return nil, errors.Wrapf(err, "non-bool value for %s: %s", keyPlatformSplit, v)
 			}
 			c.PlatformSplit = &b
+		case keyAttestationsOnly:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "non-bool value for %s: %s", keyAttestationsOnly, v)
+			}
+			c.AttestationsOnly = b
+		case keyAttestationFormat:
+			switch v {
+			case "", AttestationFormatStatement, AttestationFormatDSSE:
+				c.AttestationFormat = v
+			default:
+				return nil, errors.Errorf("invalid %s: %s", keyAttestationFormat, v)
+			}
 		default:
 			rest[k] = v
 		}
@@ -147,6 +177,7 @@ func CreateFS(ctx context.Context, sessionID string, k string, ref cache.Immutab
 	if err != nil {
 		return nil, nil, err
 	}
+	var attestationsFS fsutil.FS
 	if len(attestations) > 0 {
 		subjects := []intoto.Subject{}
 		err = outputFS.Walk(ctx, "", func(path string, entry fs.DirEntry, err error) error {
@@ -190,6 +221,17 @@ func CreateFS(ctx context.Context, sessionID string, k string, ref cache.Immutab
 			}
 
 			name := opt.AttestationPrefix + path.Base(attestations[i].Path)
+			if opt.AttestationFormat == AttestationFormatDSSE {
+				dt, err = json.MarshalIndent(dsse.Envelope{
+					PayloadType: intoto.PayloadType,
+					Payload:     base64.StdEncoding.EncodeToString(dt),
+					Signatures:  []dsse.Signature{},
+				}, "", "  ")
+				if err != nil {
+					return nil, nil, errors.Wrap(err, "failed to marshal attestation envelope")
+				}
+				name = strings.TrimSuffix(name, ".json") + ".dsse.json"
+			}
 			if addPlatformToFilename {
 				nameExt := path.Ext(name)
 				namBase := strings.TrimSuffix(name, nameExt)
@@ -211,8 +253,18 @@ func CreateFS(ctx context.Context, sessionID string, k string, ref cache.Immutab
 			stmtFS.Add(name, st, dt)
 		}
 
-		outputFS = staticfs.NewMergeFS(outputFS, stmtFS)
+		attestationsFS = stmtFS
 	}
 
+	if opt.AttestationsOnly {
+		if attestationsFS == nil {
+			cleanup()
+			return nil, nil, errors.Errorf("%s=true requires the result to have attestations", keyAttestationsOnly)
+		}
+		return attestationsFS, cleanup, nil
+	}
+	if attestationsFS != nil {
+		outputFS = staticfs.NewMergeFS(outputFS, attestationsFS)
+	}
 	return outputFS, cleanup, nil
 }
@@ -0,0 +1,143 @@
+package local
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	intoto "github.com/in-toto/in-toto-golang/in_toto"
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/exporter"
+	gatewaypb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/snapshot"
+	"github.com/moby/buildkit/solver/result"
+	"github.com/moby/sys/user"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/require"
+	"github.com/tonistiigi/fsutil"
+)
+
+func TestCreateFSAttestationsOnly(t *testing.T) {
+	ref := newTestRef(t, map[string]string{"foo": "bar"})
+
+	fs, cleanup, err := CreateFS(context.TODO(), "", "", ref, []exporter.Attestation{testAttestation()}, time.Now(), false, CreateFSOpts{})
+	require.NoError(t, err)
+	files := readFS(t, fs)
+	require.NoError(t, cleanup())
+	require.Contains(t, files, "foo")
+	require.Contains(t, files, "sbom.spdx.json")
+
+	fs, cleanup, err = CreateFS(context.TODO(), "", "", ref, []exporter.Attestation{testAttestation()}, time.Now(), false, CreateFSOpts{AttestationsOnly: true})
+	require.NoError(t, err)
+	files = readFS(t, fs)
+	require.NoError(t, cleanup())
+	require.Len(t, files, 1)
+
+	var stmt intoto.Statement
+	require.NoError(t, json.Unmarshal(files["sbom.spdx.json"], &stmt))
+	require.Equal(t, "https://spdx.dev/Document", stmt.PredicateType)
+	require.Len(t, stmt.Subject, 1)
+	require.Equal(t, "foo", stmt.Subject[0].Name)
+
+	_, _, err = CreateFS(context.TODO(), "", "", ref, nil, time.Now(), false, CreateFSOpts{AttestationsOnly: true})
+	require.ErrorContains(t, err, "attestations-only=true requires the result to have attestations")
+}
+
+func TestCreateFSAttestationFormatDSSE(t *testing.T) {
+	ref := newTestRef(t, map[string]string{"foo": "bar"})
+
+	fs, cleanup, err := CreateFS(context.TODO(), "", "", ref, []exporter.Attestation{testAttestation()}, time.Now(), false, CreateFSOpts{
+		AttestationsOnly:  true,
+		AttestationFormat: AttestationFormatDSSE,
+	})
+	require.NoError(t, err)
+	files := readFS(t, fs)
+	require.NoError(t, cleanup())
+	require.Len(t, files, 1)
+
+	var env dsse.Envelope
+	require.NoError(t, json.Unmarshal(files["sbom.spdx.dsse.json"], &env))
+	require.Equal(t, intoto.PayloadType, env.PayloadType)
+	require.Empty(t, env.Signatures)
+
+	dt, err := base64.StdEncoding.DecodeString(env.Payload)
+	require.NoError(t, err)
+	var stmt intoto.Statement
+	require.NoError(t, json.Unmarshal(dt, &stmt))
+	require.Equal(t, "https://spdx.dev/Document", stmt.PredicateType)
+	require.Equal(t, map[string]any{"name": "test"}, stmt.Predicate)
+}
+
+func testAttestation() exporter.Attestation {
+	return exporter.Attestation{
+		Kind: gatewaypb.AttestationKind_InToto,
+		Path: "sbom.spdx.json",
+		ContentFunc: func(context.Context) ([]byte, error) {
+			return []byte(`{"name":"test"}`), nil
+		},
+		InToto: result.InTotoAttestation{
+			PredicateType: "https://spdx.dev/Document",
+		},
+	}
+}
+
+func readFS(t *testing.T, outputFS fsutil.FS) map[string][]byte {
+	files := map[string][]byte{}
+	err := outputFS.Walk(context.TODO(), "", func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		f, err := outputFS.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		dt, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		files[p] = dt
+		return nil
+	})
+	require.NoError(t, err)
+	return files
+}
+
+// testRef is a ref that mounts a directory
+type testRef struct {
+	cache.ImmutableRef
+	dir string
+}
+
+func newTestRef(t *testing.T, files map[string]string) *testRef {
+	dir := t.TempDir()
+	for name, dt := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(dt), 0644))
+	}
+	return &testRef{dir: dir}
+}
+
+func (r *testRef) Mount(context.Context, bool, session.Group) (snapshot.Mountable, error) {
+	return testMountable(r.dir), nil
+}
+
+type testMountable string
+
+func (m testMountable) Mount() ([]mount.Mount, func() error, error) {
+	return []mount.Mount{{Type: "bind", Source: string(m), Options: []string{"rbind"}}}, func() error { return nil }, nil
+}
+
+func (m testMountable) IdentityMapping() *user.IdentityMapping {
+	return nil
+}
@@ -0,0 +1,161 @@
+package oci
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+
+	units "github.com/docker/go-units"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+const (
+	// keyTarCompression compresses the exported tarball with gzip or zstd.
+	// docker load detects the compression of its input.
+	keyTarCompression = "tar-compression"
+	// keyTarCompressionLevel is the compression level of the tarball
+	keyTarCompressionLevel = "tar-compression-level"
+	// keyTarChunkSize ends the compressed stream every time this many bytes
+	// of the tarball have been written and starts a new one. The chunks are
+	// concatenated gzip members or zstd frames that are decompressed as a
+	// single stream, but each of them is sent as soon as it is complete and
+	// the compressor doesn't buffer more than one chunk.
+	keyTarChunkSize = "tar-chunk-size"
+)
+
+const (
+	tarCompressionUncompressed = "uncompressed"
+	tarCompressionGzip         = "gzip"
+	tarCompressionZstd         = "zstd"
+)
+
+type tarCompression struct {
+	typ       string
+	level     *int
+	chunkSize int64
+}
+
+func (c *tarCompression) load(k, v string) error {
+	switch k {
+	case keyTarCompression:
+		switch v {
+		case "", tarCompressionUncompressed:
+			c.typ = ""
+		case tarCompressionGzip, tarCompressionZstd:
+			c.typ = v
+		default:
+			return errors.Errorf("unsupported %s %s", k, v)
+		}
+	case keyTarCompressionLevel:
+		l, err := strconv.Atoi(v)
+		if err != nil {
+			return errors.Wrapf(err, "non-integer value %s specified for %s", v, k)
+		}
+		c.level = &l
+	case keyTarChunkSize:
+		n, err := units.RAMInBytes(v)
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s %s", k, v)
+		}
+		if n <= 0 {
+			return errors.Errorf("invalid %s %s", k, v)
+		}
+		c.chunkSize = n
+	}
+	return nil
+}
+
+func (c *tarCompression) validate() error {
+	if c.typ == "" && (c.level != nil || c.chunkSize > 0) {
+		return errors.Errorf("%s and %s require %s", keyTarCompressionLevel, keyTarChunkSize, keyTarCompression)
+	}
+	return nil
+}
+
+// writer returns a writer compressing the tarball to w. Closing it doesn't
+// close w.
+func (c *tarCompression) writer(w io.Writer) (io.WriteCloser, error) {
+	var newWriter func(io.Writer) (io.WriteCloser, error)
+	switch c.typ {
+	case "":
+		return nopWriteCloser{w}, nil
+	case tarCompressionGzip:
+		level := gzip.DefaultCompression
+		if c.level != nil {
+			level = *c.level
+		}
+		newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		}
+	case tarCompressionZstd:
+		level := zstd.SpeedDefault
+		if c.level != nil {
+			level = zstd.EncoderLevelFromZstd(*c.level)
+		}
+		newWriter = func(w io.Writer) (io.WriteCloser, error) {
+			return zstd.NewWriter(w, zstd.WithEncoderLevel(level))
+		}
+	default:
+		return nil, errors.Errorf("unsupported %s %s", keyTarCompression, c.typ)
+	}
+	if c.chunkSize <= 0 {
+		return newWriter(w)
+	}
+	return &chunkWriter{w: w, size: c.chunkSize, newWriter: newWriter}, nil
+}
+
+// chunkWriter compresses every chunk of size bytes as a separate stream
+type chunkWriter struct {
+	w         io.Writer
+	size      int64
+	newWriter func(io.Writer) (io.WriteCloser, error)
+
+	cw io.WriteCloser
+	n  int64
+}
+
+func (c *chunkWriter) Write(dt []byte) (int, error) {
+	var written int
+	for len(dt) > 0 {
+		if c.cw == nil {
+			cw, err := c.newWriter(c.w)
+			if err != nil {
+				return written, err
+			}
+			c.cw = cw
+			c.n = 0
+		}
+		p := dt[:min(int64(len(dt)), c.size-c.n)]
+		n, err := c.cw.Write(p)
+		written += n
+		c.n += int64(n)
+		if err != nil {
+			return written, err
+		}
+		dt = dt[n:]
+		if c.n >= c.size {
+			if err := c.Close(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+func (c *chunkWriter) Close() error {
+	if c.cw == nil {
+		return nil
+	}
+	err := c.cw.Close()
+	c.cw = nil
+	return err
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
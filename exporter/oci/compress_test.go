@@ -0,0 +1,95 @@
+package oci
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarCompression(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 1000)
+
+	for _, tc := range []struct {
+		typ        string
+		chunkSize  string
+		decompress func(io.Reader) (io.Reader, error)
+		frames     func([]byte) int
+	}{
+		{
+			typ: tarCompressionGzip,
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+			frames: func(dt []byte) int { return bytes.Count(dt, []byte{0x1f, 0x8b, 0x08}) },
+		},
+		{
+			typ:       tarCompressionGzip,
+			chunkSize: "4k",
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return gzip.NewReader(r)
+			},
+			frames: func(dt []byte) int { return bytes.Count(dt, []byte{0x1f, 0x8b, 0x08}) },
+		},
+		{
+			typ:       tarCompressionZstd,
+			chunkSize: "4k",
+			decompress: func(r io.Reader) (io.Reader, error) {
+				return zstd.NewReader(r)
+			},
+			frames: func(dt []byte) int { return bytes.Count(dt, []byte{0x28, 0xb5, 0x2f, 0xfd}) },
+		},
+	} {
+		t.Run(tc.typ+tc.chunkSize, func(t *testing.T) {
+			var c tarCompression
+			require.NoError(t, c.load(keyTarCompression, tc.typ))
+			if tc.chunkSize != "" {
+				require.NoError(t, c.load(keyTarChunkSize, tc.chunkSize))
+			}
+			require.NoError(t, c.validate())
+
+			buf := &bytes.Buffer{}
+			w, err := c.writer(buf)
+			require.NoError(t, err)
+			// writes that don't line up with the chunks
+			for i := 0; i < len(data); i += 3000 {
+				_, err := w.Write(data[i:min(i+3000, len(data))])
+				require.NoError(t, err)
+			}
+			require.NoError(t, w.Close())
+
+			expected := 1
+			if tc.chunkSize != "" {
+				expected = 3
+			}
+			require.Equal(t, expected, tc.frames(buf.Bytes()))
+
+			r, err := tc.decompress(buf)
+			require.NoError(t, err)
+			dt, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, data, dt)
+		})
+	}
+}
+
+func TestTarCompressionOptions(t *testing.T) {
+	var c tarCompression
+	require.ErrorContains(t, c.load(keyTarCompression, "xz"), "unsupported tar-compression xz")
+	require.ErrorContains(t, c.load(keyTarChunkSize, "0"), "invalid tar-chunk-size")
+	require.NoError(t, c.load(keyTarCompressionLevel, "3"))
+	require.ErrorContains(t, c.validate(), "require tar-compression")
+
+	require.NoError(t, c.load(keyTarCompression, tarCompressionUncompressed))
+	buf := &bytes.Buffer{}
+	c.level = nil
+	w, err := c.writer(buf)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("tar"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.Equal(t, "tar", buf.String())
+}
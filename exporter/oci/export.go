@@ -91,6 +91,10 @@ func (e *imageExporter) Resolve(ctx context.Context, id int, opt map[string]stri
 				return nil, errors.Wrapf(err, "non-bool value specified for %s", k)
 			}
 			i.tar = b
+		case keyTarCompression, keyTarCompressionLevel, keyTarChunkSize:
+			if err := i.tarCompression.load(k, v); err != nil {
+				return nil, err
+			}
 		default:
 			if i.meta == nil {
 				i.meta = make(map[string][]byte)
@@ -98,6 +102,12 @@ func (e *imageExporter) Resolve(ctx context.Context, id int, opt map[string]stri
 			i.meta[k] = []byte(v)
 		}
 	}
+	if err := i.tarCompression.validate(); err != nil {
+		return nil, err
+	}
+	if !i.tar && i.tarCompression.typ != "" {
+		return nil, errors.Errorf("%s requires %s=true", keyTarCompression, keyTar)
+	}
 	return i, nil
 }
 
@@ -109,6 +119,8 @@ type imageExporterInstance struct {
 	opts containerimage.ImageCommitOpts
 	tar  bool
 	meta map[string][]byte
+
+	tarCompression tarCompression
 }
 
 func (e *imageExporterInstance) ID() int {
@@ -188,6 +200,10 @@ func (e *imageExporterInstance) Export(ctx context.Context, src *exporter.Source
 		delete(desc.Annotations, exptypes.ExporterConfigDigestKey)
 	}
 
+	if err := containerimage.AddManifestsResponse(ctx, resp, e.opt.ImageWriter.ContentStore(), *desc); err != nil {
+		return nil, nil, err
+	}
+
 	dtdesc, err := json.Marshal(desc)
 	if err != nil {
 		return nil, nil, err
@@ -266,13 +282,23 @@ func (e *imageExporterInstance) Export(ctx context.Context, src *exporter.Source
 		}
 
 		report := progress.OneOff(ctx, "sending tarball")
-		if err := archiveexporter.Export(ctx, mprovider, w, expOpts...); err != nil {
+		cw, err := e.tarCompression.writer(w)
+		if err != nil {
+			w.Close()
+			return nil, nil, report(err)
+		}
+		if err := archiveexporter.Export(ctx, mprovider, cw, expOpts...); err != nil {
+			cw.Close()
 			w.Close()
 			if grpcerrors.Code(err) == codes.AlreadyExists {
 				return resp, nil, report(nil)
 			}
 			return nil, nil, report(err)
 		}
+		if err := cw.Close(); err != nil {
+			w.Close()
+			return nil, nil, report(err)
+		}
 		err = w.Close()
 		if grpcerrors.Code(err) == codes.AlreadyExists {
 			return resp, nil, report(nil)
@@ -0,0 +1,467 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.11.4
+// source: github.com/moby/buildkit/exporter/plugin/pb/plugin.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type InfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InfoRequest) Reset() {
+	*x = InfoRequest{}
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoRequest) ProtoMessage() {}
+
+func (x *InfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoRequest.ProtoReflect.Descriptor instead.
+func (*InfoRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescGZIP(), []int{0}
+}
+
+type InfoResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name is shown in the progress of the builds exporting to the plugin
+	Name          string `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InfoResponse) Reset() {
+	*x = InfoResponse{}
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InfoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InfoResponse) ProtoMessage() {}
+
+func (x *InfoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InfoResponse.ProtoReflect.Descriptor instead.
+func (*InfoResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *InfoResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ExportRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Attrs are the output attributes of the build
+	Attrs map[string]string `protobuf:"bytes,1,rep,name=Attrs,proto3" json:"Attrs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Ref is the result of a single platform build
+	Ref *Ref `protobuf:"bytes,2,opt,name=Ref,proto3" json:"Ref,omitempty"`
+	// Refs are the results of a multi-platform build by platform
+	Refs map[string]*Ref `protobuf:"bytes,3,rep,name=Refs,proto3" json:"Refs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Metadata is the metadata of the result, e.g. the image configs
+	Metadata      map[string][]byte `protobuf:"bytes,4,rep,name=Metadata,proto3" json:"Metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExportRequest) GetAttrs() map[string]string {
+	if x != nil {
+		return x.Attrs
+	}
+	return nil
+}
+
+func (x *ExportRequest) GetRef() *Ref {
+	if x != nil {
+		return x.Ref
+	}
+	return nil
+}
+
+func (x *ExportRequest) GetRefs() map[string]*Ref {
+	if x != nil {
+		return x.Refs
+	}
+	return nil
+}
+
+func (x *ExportRequest) GetMetadata() map[string][]byte {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type Ref struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	ID    string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// Path is the directory the filesystem of the result is mounted on,
+	// read-only and for the duration of the Export call only
+	Path string `protobuf:"bytes,2,opt,name=Path,proto3" json:"Path,omitempty"`
+	// Layers are the layer descriptors of the result in the content store
+	// of the worker, from the lowest one
+	Layers        []*Descriptor `protobuf:"bytes,3,rep,name=Layers,proto3" json:"Layers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ref) Reset() {
+	*x = Ref{}
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ref) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ref) ProtoMessage() {}
+
+func (x *Ref) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ref.ProtoReflect.Descriptor instead.
+func (*Ref) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Ref) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *Ref) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Ref) GetLayers() []*Descriptor {
+	if x != nil {
+		return x.Layers
+	}
+	return nil
+}
+
+type Descriptor struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MediaType     string                 `protobuf:"bytes,1,opt,name=MediaType,proto3" json:"MediaType,omitempty"`
+	Digest        string                 `protobuf:"bytes,2,opt,name=Digest,proto3" json:"Digest,omitempty"`
+	Size          int64                  `protobuf:"varint,3,opt,name=Size,proto3" json:"Size,omitempty"`
+	Annotations   map[string]string      `protobuf:"bytes,4,rep,name=Annotations,proto3" json:"Annotations,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Descriptor) Reset() {
+	*x = Descriptor{}
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Descriptor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Descriptor) ProtoMessage() {}
+
+func (x *Descriptor) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Descriptor.ProtoReflect.Descriptor instead.
+func (*Descriptor) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Descriptor) GetMediaType() string {
+	if x != nil {
+		return x.MediaType
+	}
+	return ""
+}
+
+func (x *Descriptor) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+func (x *Descriptor) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Descriptor) GetAnnotations() map[string]string {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+type ExportResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Response is added to the exporter response of the build
+	Response      map[string]string `protobuf:"bytes,1,rep,name=Response,proto3" json:"Response,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportResponse) Reset() {
+	*x = ExportResponse{}
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportResponse) ProtoMessage() {}
+
+func (x *ExportResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportResponse.ProtoReflect.Descriptor instead.
+func (*ExportResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ExportResponse) GetResponse() map[string]string {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+var File_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto protoreflect.FileDescriptor
+
+const file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDesc = "" +
+	"\n" +
+	"8github.com/moby/buildkit/exporter/plugin/pb/plugin.proto\x12 moby.buildkit.v1.exporter.plugin\"\r\n" +
+	"\vInfoRequest\"\"\n" +
+	"\fInfoResponse\x12\x12\n" +
+	"\x04Name\x18\x01 \x01(\tR\x04Name\"\x9b\x04\n" +
+	"\rExportRequest\x12P\n" +
+	"\x05Attrs\x18\x01 \x03(\v2:.moby.buildkit.v1.exporter.plugin.ExportRequest.AttrsEntryR\x05Attrs\x127\n" +
+	"\x03Ref\x18\x02 \x01(\v2%.moby.buildkit.v1.exporter.plugin.RefR\x03Ref\x12M\n" +
+	"\x04Refs\x18\x03 \x03(\v29.moby.buildkit.v1.exporter.plugin.ExportRequest.RefsEntryR\x04Refs\x12Y\n" +
+	"\bMetadata\x18\x04 \x03(\v2=.moby.buildkit.v1.exporter.plugin.ExportRequest.MetadataEntryR\bMetadata\x1a8\n" +
+	"\n" +
+	"AttrsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a^\n" +
+	"\tRefsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12;\n" +
+	"\x05value\x18\x02 \x01(\v2%.moby.buildkit.v1.exporter.plugin.RefR\x05value:\x028\x01\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"o\n" +
+	"\x03Ref\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x12\n" +
+	"\x04Path\x18\x02 \x01(\tR\x04Path\x12D\n" +
+	"\x06Layers\x18\x03 \x03(\v2,.moby.buildkit.v1.exporter.plugin.DescriptorR\x06Layers\"\xf7\x01\n" +
+	"\n" +
+	"Descriptor\x12\x1c\n" +
+	"\tMediaType\x18\x01 \x01(\tR\tMediaType\x12\x16\n" +
+	"\x06Digest\x18\x02 \x01(\tR\x06Digest\x12\x12\n" +
+	"\x04Size\x18\x03 \x01(\x03R\x04Size\x12_\n" +
+	"\vAnnotations\x18\x04 \x03(\v2=.moby.buildkit.v1.exporter.plugin.Descriptor.AnnotationsEntryR\vAnnotations\x1a>\n" +
+	"\x10AnnotationsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa9\x01\n" +
+	"\x0eExportResponse\x12Z\n" +
+	"\bResponse\x18\x01 \x03(\v2>.moby.buildkit.v1.exporter.plugin.ExportResponse.ResponseEntryR\bResponse\x1a;\n" +
+	"\rResponseEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x012\xde\x01\n" +
+	"\bExporter\x12e\n" +
+	"\x04Info\x12-.moby.buildkit.v1.exporter.plugin.InfoRequest\x1a..moby.buildkit.v1.exporter.plugin.InfoResponse\x12k\n" +
+	"\x06Export\x12/.moby.buildkit.v1.exporter.plugin.ExportRequest\x1a0.moby.buildkit.v1.exporter.plugin.ExportResponseB-Z+github.com/moby/buildkit/exporter/plugin/pbb\x06proto3"
+
+var (
+	file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescOnce sync.Once
+	file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescData []byte
+)
+
+func file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescGZIP() []byte {
+	file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescOnce.Do(func() {
+		file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDesc), len(file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDesc)))
+	})
+	return file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDescData
+}
+
+var file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_goTypes = []any{
+	(*InfoRequest)(nil),    // 0: moby.buildkit.v1.exporter.plugin.InfoRequest
+	(*InfoResponse)(nil),   // 1: moby.buildkit.v1.exporter.plugin.InfoResponse
+	(*ExportRequest)(nil),  // 2: moby.buildkit.v1.exporter.plugin.ExportRequest
+	(*Ref)(nil),            // 3: moby.buildkit.v1.exporter.plugin.Ref
+	(*Descriptor)(nil),     // 4: moby.buildkit.v1.exporter.plugin.Descriptor
+	(*ExportResponse)(nil), // 5: moby.buildkit.v1.exporter.plugin.ExportResponse
+	nil,                    // 6: moby.buildkit.v1.exporter.plugin.ExportRequest.AttrsEntry
+	nil,                    // 7: moby.buildkit.v1.exporter.plugin.ExportRequest.RefsEntry
+	nil,                    // 8: moby.buildkit.v1.exporter.plugin.ExportRequest.MetadataEntry
+	nil,                    // 9: moby.buildkit.v1.exporter.plugin.Descriptor.AnnotationsEntry
+	nil,                    // 10: moby.buildkit.v1.exporter.plugin.ExportResponse.ResponseEntry
+}
+var file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_depIdxs = []int32{
+	6,  // 0: moby.buildkit.v1.exporter.plugin.ExportRequest.Attrs:type_name -> moby.buildkit.v1.exporter.plugin.ExportRequest.AttrsEntry
+	3,  // 1: moby.buildkit.v1.exporter.plugin.ExportRequest.Ref:type_name -> moby.buildkit.v1.exporter.plugin.Ref
+	7,  // 2: moby.buildkit.v1.exporter.plugin.ExportRequest.Refs:type_name -> moby.buildkit.v1.exporter.plugin.ExportRequest.RefsEntry
+	8,  // 3: moby.buildkit.v1.exporter.plugin.ExportRequest.Metadata:type_name -> moby.buildkit.v1.exporter.plugin.ExportRequest.MetadataEntry
+	4,  // 4: moby.buildkit.v1.exporter.plugin.Ref.Layers:type_name -> moby.buildkit.v1.exporter.plugin.Descriptor
+	9,  // 5: moby.buildkit.v1.exporter.plugin.Descriptor.Annotations:type_name -> moby.buildkit.v1.exporter.plugin.Descriptor.AnnotationsEntry
+	10, // 6: moby.buildkit.v1.exporter.plugin.ExportResponse.Response:type_name -> moby.buildkit.v1.exporter.plugin.ExportResponse.ResponseEntry
+	3,  // 7: moby.buildkit.v1.exporter.plugin.ExportRequest.RefsEntry.value:type_name -> moby.buildkit.v1.exporter.plugin.Ref
+	0,  // 8: moby.buildkit.v1.exporter.plugin.Exporter.Info:input_type -> moby.buildkit.v1.exporter.plugin.InfoRequest
+	2,  // 9: moby.buildkit.v1.exporter.plugin.Exporter.Export:input_type -> moby.buildkit.v1.exporter.plugin.ExportRequest
+	1,  // 10: moby.buildkit.v1.exporter.plugin.Exporter.Info:output_type -> moby.buildkit.v1.exporter.plugin.InfoResponse
+	5,  // 11: moby.buildkit.v1.exporter.plugin.Exporter.Export:output_type -> moby.buildkit.v1.exporter.plugin.ExportResponse
+	10, // [10:12] is the sub-list for method output_type
+	8,  // [8:10] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_init() }
+func file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_init() {
+	if File_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDesc), len(file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_goTypes,
+		DependencyIndexes: file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_depIdxs,
+		MessageInfos:      file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_msgTypes,
+	}.Build()
+	File_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto = out.File
+	file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_goTypes = nil
+	file_github_com_moby_buildkit_exporter_plugin_pb_plugin_proto_depIdxs = nil
+}
@@ -0,0 +1,165 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.11.4
+// source: github.com/moby/buildkit/exporter/plugin/pb/plugin.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Exporter_Info_FullMethodName   = "/moby.buildkit.v1.exporter.plugin.Exporter/Info"
+	Exporter_Export_FullMethodName = "/moby.buildkit.v1.exporter.plugin.Exporter/Export"
+)
+
+// ExporterClient is the client API for Exporter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Exporter is the service implemented by the external exporters configured in
+// buildkitd. BuildKit calls Export for every build using one of them as
+// output.
+type ExporterClient interface {
+	Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error)
+	Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error)
+}
+
+type exporterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExporterClient(cc grpc.ClientConnInterface) ExporterClient {
+	return &exporterClient{cc}
+}
+
+func (c *exporterClient) Info(ctx context.Context, in *InfoRequest, opts ...grpc.CallOption) (*InfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(InfoResponse)
+	err := c.cc.Invoke(ctx, Exporter_Info_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exporterClient) Export(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (*ExportResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportResponse)
+	err := c.cc.Invoke(ctx, Exporter_Export_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExporterServer is the server API for Exporter service.
+// All implementations should embed UnimplementedExporterServer
+// for forward compatibility.
+//
+// Exporter is the service implemented by the external exporters configured in
+// buildkitd. BuildKit calls Export for every build using one of them as
+// output.
+type ExporterServer interface {
+	Info(context.Context, *InfoRequest) (*InfoResponse, error)
+	Export(context.Context, *ExportRequest) (*ExportResponse, error)
+}
+
+// UnimplementedExporterServer should be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedExporterServer struct{}
+
+func (UnimplementedExporterServer) Info(context.Context, *InfoRequest) (*InfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Info not implemented")
+}
+func (UnimplementedExporterServer) Export(context.Context, *ExportRequest) (*ExportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedExporterServer) testEmbeddedByValue() {}
+
+// UnsafeExporterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExporterServer will
+// result in compilation errors.
+type UnsafeExporterServer interface {
+	mustEmbedUnimplementedExporterServer()
+}
+
+func RegisterExporterServer(s grpc.ServiceRegistrar, srv ExporterServer) {
+	// If the following call pancis, it indicates UnimplementedExporterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Exporter_ServiceDesc, srv)
+}
+
+func _Exporter_Info_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(InfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExporterServer).Info(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Exporter_Info_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExporterServer).Info(ctx, req.(*InfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Exporter_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExporterServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Exporter_Export_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExporterServer).Export(ctx, req.(*ExportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Exporter_ServiceDesc is the grpc.ServiceDesc for Exporter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Exporter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "moby.buildkit.v1.exporter.plugin.Exporter",
+	HandlerType: (*ExporterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Info",
+			Handler:    _Exporter_Info_Handler,
+		},
+		{
+			MethodName: "Export",
+			Handler:    _Exporter_Export_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "github.com/moby/buildkit/exporter/plugin/pb/plugin.proto",
+}
@@ -0,0 +1,206 @@
+// Package plugin implements the exporters that run as external processes
+// serving the Exporter gRPC service of the pb package. The plugins are
+// configured in buildkitd and receive the results of the builds as read-only
+// mounts and, optionally, as layer descriptors.
+package plugin
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/moby/buildkit/cache"
+	cacheconfig "github.com/moby/buildkit/cache/config"
+	"github.com/moby/buildkit/exporter"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/exporter/plugin/pb"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/snapshot"
+	"github.com/moby/buildkit/util/grpcplugin"
+	"github.com/moby/buildkit/util/progress"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// attrLayers requests the layer descriptors of the results
+	attrLayers = "layers"
+
+	defaultTimeout = 10 * time.Minute
+)
+
+type Opt struct {
+	// Name is the exporter type the plugin is used with
+	Name string
+	// Timeout limits the duration of an export, 10 minutes by default
+	Timeout time.Duration
+	grpcplugin.Opt
+}
+
+type pluginExporter struct {
+	opt    Opt
+	client pb.ExporterClient
+}
+
+// New returns an exporter calling the plugin at opt.Address. The connection
+// is established when the exporter is first resolved for a build.
+func New(opt Opt) (exporter.Exporter, error) {
+	if opt.Name == "" {
+		return nil, errors.New("exporter plugin name is required")
+	}
+	conn, err := grpcplugin.NewClient(opt.Opt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create client for exporter plugin %s", opt.Name)
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = defaultTimeout
+	}
+	return &pluginExporter{
+		opt:    opt,
+		client: pb.NewExporterClient(conn),
+	}, nil
+}
+
+func (e *pluginExporter) Resolve(ctx context.Context, id int, opt map[string]string) (exporter.ExporterInstance, error) {
+	// the plugin is called before the build so that an unavailable plugin
+	// fails the build early
+	info, err := e.client.Info(ctx, &pb.InfoRequest{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get info of exporter plugin %s", e.opt.Name)
+	}
+	i := &pluginExporterInstance{
+		pluginExporter: e,
+		id:             id,
+		attrs:          opt,
+		name:           info.Name,
+	}
+	if v, ok := opt[attrLayers]; ok {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "non-bool value specified for %s", attrLayers)
+		}
+		i.layers = b
+	}
+	return i, nil
+}
+
+type pluginExporterInstance struct {
+	*pluginExporter
+	id     int
+	attrs  map[string]string
+	name   string
+	layers bool
+}
+
+func (e *pluginExporterInstance) ID() int {
+	return e.id
+}
+
+func (e *pluginExporterInstance) Name() string {
+	if e.name != "" {
+		return e.name
+	}
+	return "exporting to " + e.opt.Name
+}
+
+func (e *pluginExporterInstance) Type() string {
+	return e.opt.Name
+}
+
+func (e *pluginExporterInstance) Attrs() map[string]string {
+	return e.attrs
+}
+
+func (e *pluginExporterInstance) Config() *exporter.Config {
+	return exporter.NewConfig()
+}
+
+func (e *pluginExporterInstance) Export(ctx context.Context, src *exporter.Source, _ exptypes.InlineCache, sessionID string) (_ map[string]string, _ exporter.DescriptorReference, err error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx, _ = context.WithTimeoutCause(ctx, e.opt.Timeout, errors.WithStack(context.DeadlineExceeded)) //nolint:govet
+	defer func() { cancel(errors.WithStack(context.Canceled)) }()
+
+	g := session.NewGroup(sessionID)
+	req := &pb.ExportRequest{
+		Attrs:    e.attrs,
+		Metadata: src.Metadata,
+	}
+
+	var mu sync.Mutex
+	var cleanups []func() error
+	defer func() {
+		for _, f := range cleanups {
+			if err1 := f(); err == nil {
+				err = err1
+			}
+		}
+	}()
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	newRef := func(ref cache.ImmutableRef, set func(*pb.Ref)) {
+		if ref == nil {
+			return
+		}
+		eg.Go(func() error {
+			r, cleanup, err := e.mountRef(egCtx, ref, g)
+			if cleanup != nil {
+				mu.Lock()
+				cleanups = append(cleanups, cleanup)
+				mu.Unlock()
+			}
+			if err != nil {
+				return err
+			}
+			mu.Lock()
+			set(r)
+			mu.Unlock()
+			return nil
+		})
+	}
+	newRef(src.Ref, func(r *pb.Ref) { req.Ref = r })
+	for k, ref := range src.Refs {
+		if req.Refs == nil {
+			req.Refs = make(map[string]*pb.Ref, len(src.Refs))
+		}
+		newRef(ref, func(r *pb.Ref) { req.Refs[k] = r })
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, nil, err
+	}
+
+	done := progress.OneOff(ctx, "calling exporter plugin "+e.opt.Name)
+	resp, err := e.client.Export(ctx, req)
+	if err := done(err); err != nil {
+		return nil, nil, errors.Wrapf(err, "exporter plugin %s failed", e.opt.Name)
+	}
+	return resp.Response, nil, nil
+}
+
+func (e *pluginExporterInstance) mountRef(ctx context.Context, ref cache.ImmutableRef, g session.Group) (*pb.Ref, func() error, error) {
+	r := &pb.Ref{ID: ref.ID()}
+	if e.layers {
+		remotes, err := ref.GetRemotes(ctx, true, cacheconfig.RefConfig{Compression: e.Config().Compression()}, false, g)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, desc := range remotes[0].Descriptors {
+			r.Layers = append(r.Layers, &pb.Descriptor{
+				MediaType:   desc.MediaType,
+				Digest:      desc.Digest.String(),
+				Size:        desc.Size,
+				Annotations: desc.Annotations,
+			})
+		}
+	}
+	mount, err := ref.Mount(ctx, true, g)
+	if err != nil {
+		return nil, nil, err
+	}
+	lm := snapshot.LocalMounter(mount)
+	r.Path, err = lm.Mount()
+	if err != nil {
+		return nil, nil, err
+	}
+	return r, lm.Unmount, nil
+}
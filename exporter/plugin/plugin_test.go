@@ -0,0 +1,100 @@
+package plugin
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/moby/buildkit/exporter"
+	"github.com/moby/buildkit/exporter/plugin/pb"
+	"github.com/moby/buildkit/util/grpcplugin"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+type testPlugin struct {
+	pb.UnimplementedExporterServer
+	reqs []*pb.ExportRequest
+}
+
+func (p *testPlugin) Info(context.Context, *pb.InfoRequest) (*pb.InfoResponse, error) {
+	return &pb.InfoResponse{Name: "exporting to test plugin"}, nil
+}
+
+func (p *testPlugin) Export(_ context.Context, req *pb.ExportRequest) (*pb.ExportResponse, error) {
+	p.reqs = append(p.reqs, req)
+	if req.Attrs["fail"] != "" {
+		return nil, errors.New(req.Attrs["fail"])
+	}
+	return &pb.ExportResponse{
+		Response: map[string]string{"artifact.url": "https://artifacts.example.com/" + req.Attrs["name"]},
+	}, nil
+}
+
+func startPlugin(t *testing.T) (*testPlugin, string) {
+	sock := filepath.Join(t.TempDir(), "plugin.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	p := &testPlugin{}
+	srv := grpc.NewServer()
+	pb.RegisterExporterServer(srv, p)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+	return p, "unix://" + sock
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Opt{Opt: grpcplugin.Opt{Address: "unix:///run/plugin.sock"}})
+	require.ErrorContains(t, err, "name is required")
+
+	for _, addr := range []string{"", "/run/plugin.sock", "http://localhost:1234", "tcp://"} {
+		_, err := New(Opt{Name: "test", Opt: grpcplugin.Opt{Address: addr}})
+		require.ErrorContains(t, err, "invalid address", addr)
+	}
+
+	for _, addr := range []string{"unix:///run/plugin.sock", "tcp://localhost:1234"} {
+		_, err := New(Opt{Name: "test", Opt: grpcplugin.Opt{Address: addr}})
+		require.NoError(t, err, addr)
+	}
+}
+
+func TestExport(t *testing.T) {
+	ctx := context.TODO()
+	p, addr := startPlugin(t)
+
+	exp, err := New(Opt{Name: "artifacts", Opt: grpcplugin.Opt{Address: addr}})
+	require.NoError(t, err)
+
+	_, err = exp.Resolve(ctx, 0, map[string]string{"layers": "maybe"})
+	require.ErrorContains(t, err, "non-bool value specified for layers")
+
+	expi, err := exp.Resolve(ctx, 0, map[string]string{"name": "foo"})
+	require.NoError(t, err)
+	require.Equal(t, "artifacts", expi.Type())
+	require.Equal(t, "exporting to test plugin", expi.Name())
+
+	resp, _, err := expi.Export(ctx, &exporter.Source{
+		Metadata: map[string][]byte{"containerimage.config": []byte("{}")},
+	}, nil, "")
+	require.NoError(t, err)
+	require.Equal(t, "https://artifacts.example.com/foo", resp["artifact.url"])
+	require.Len(t, p.reqs, 1)
+	require.Equal(t, "foo", p.reqs[0].Attrs["name"])
+	require.Equal(t, []byte("{}"), p.reqs[0].Metadata["containerimage.config"])
+	require.Nil(t, p.reqs[0].Ref)
+
+	expi, err = exp.Resolve(ctx, 1, map[string]string{"fail": "artifact store is read-only"})
+	require.NoError(t, err)
+	_, _, err = expi.Export(ctx, &exporter.Source{}, nil, "")
+	require.ErrorContains(t, err, "exporter plugin artifacts failed")
+	require.ErrorContains(t, err, "artifact store is read-only")
+}
+
+func TestUnavailable(t *testing.T) {
+	exp, err := New(Opt{Name: "artifacts", Opt: grpcplugin.Opt{Address: "unix://" + filepath.Join(t.TempDir(), "missing.sock")}})
+	require.NoError(t, err)
+	_, err = exp.Resolve(context.TODO(), 0, nil)
+	require.ErrorContains(t, err, "failed to get info of exporter plugin artifacts")
+}
@@ -2,6 +2,7 @@ package builder
 
 import (
 	"context"
+	"path"
 	"strings"
 	"sync"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/moby/buildkit/frontend/dockerui"
 	"github.com/moby/buildkit/frontend/gateway/client"
 	gwpb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/moby/buildkit/frontend/subrequests/analyze"
 	"github.com/moby/buildkit/frontend/subrequests/lint"
 	"github.com/moby/buildkit/frontend/subrequests/outline"
 	"github.com/moby/buildkit/frontend/subrequests/targets"
@@ -96,6 +98,9 @@ func Build(ctx context.Context, c client.Client) (_ *client.Result, err error) {
 		Lint: func(ctx context.Context) (*lint.LintResults, error) {
 			return dockerfile2llb.DockerfileLint(ctx, src.Data, convertOpt)
 		},
+		Analyze: func(ctx context.Context) (*analyze.Report, error) {
+			return dockerfile2llb.DockerfileAnalyze(ctx, src.Data, convertOpt, contextMatcher(bc, c))
+		},
 	}); err != nil {
 		return nil, err
 	} else if ok {
@@ -297,3 +302,47 @@ func wrapSource(err error, sm *llb.SourceMap, ranges []parser.Range) error {
 	}
 	return errdefs.WithSource(err, s)
 }
+
+// contextMatcher checks the patterns against the main build context. Only the
+// paths matching the patterns are transferred.
+func contextMatcher(bc *dockerui.Client, c client.Client) dockerfile2llb.ContextMatcher {
+	return func(ctx context.Context, patterns []string) (map[string]bool, error) {
+		st, err := bc.MainContext(ctx, llb.FollowPaths(patterns))
+		if err != nil {
+			return nil, err
+		}
+		def, err := st.Marshal(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to marshal build context")
+		}
+		res, err := c.Solve(ctx, client.SolveRequest{
+			Definition: def.ToPB(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		ref, err := res.SingleRef()
+		if err != nil {
+			return nil, err
+		}
+
+		matched := make(map[string]bool, len(patterns))
+		for _, p := range patterns {
+			dir, base := path.Split(p)
+			if strings.ContainsAny(dir, "*?[\\") {
+				// wildcards in parent directories can't be listed, assume a match
+				matched[p] = true
+				continue
+			}
+			if ref == nil {
+				continue
+			}
+			entries, err := ref.ReadDir(ctx, client.ReadDirRequest{
+				Path:           "/" + dir,
+				IncludePattern: base,
+			})
+			matched[p] = err == nil && len(entries) > 0
+		}
+		return matched, nil
+	}
+}
@@ -0,0 +1,367 @@
+package dockerfile2llb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/frontend/dockerfile/instructions"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/moby/buildkit/frontend/dockerfile/shell"
+	"github.com/moby/buildkit/frontend/subrequests/analyze"
+	"github.com/moby/buildkit/util/suggest"
+	"github.com/pkg/errors"
+)
+
+// ContextMatcher reports which of the patterns match at least one file of the
+// main build context. Patterns are relative to the root of the context.
+type ContextMatcher func(ctx context.Context, patterns []string) (map[string]bool, error)
+
+// DockerfileAnalyze statically analyzes the Dockerfile for the target stage
+// without resolving any images. COPY sources are only checked against the
+// build context if match is set.
+func DockerfileAnalyze(ctx context.Context, dt []byte, opt ConvertOpt, match ContextMatcher) (*analyze.Report, error) {
+	dockerfile, err := parser.Parse(bytes.NewReader(dt))
+	if err != nil {
+		return nil, err
+	}
+
+	stages, argCmds, err := instructions.Parse(dockerfile.AST, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(stages) == 0 {
+		return nil, errors.New("dockerfile contains no stages to build")
+	}
+
+	a := &analyzer{
+		stages: stages,
+		shlex:  shell.NewLex(dockerfile.EscapeToken),
+		byName: map[string]int{},
+		deps:   make([]map[int]struct{}, len(stages)),
+	}
+	for i, st := range stages {
+		if st.Name != "" {
+			a.byName[st.Name] = i
+		}
+	}
+
+	target := len(stages) - 1
+	if opt.Target != "" {
+		i, ok := a.byName[strings.ToLower(opt.Target)]
+		if !ok {
+			return nil, suggest.WrapError(errors.Errorf("target stage %q could not be found", opt.Target), opt.Target, slices.Sorted(maps.Keys(a.byName)), true)
+		}
+		target = i
+	}
+
+	globalArgs := defaultArgs(buildPlatformOpt(&opt), opt.BuildArgs, stages[target].Name)
+	a.globalArgs, a.allArgs, err = buildMetaArgs(globalArgs, a.shlex, argCmds, opt.BuildArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	a.resolveDeps()
+	reachable := a.reachable(target)
+
+	report := &analyze.Report{
+		Target:  stages[target].Name,
+		Sources: [][]byte{dt},
+	}
+	for i, st := range stages {
+		if _, ok := reachable[i]; !ok {
+			report.UnusedStages = append(report.UnusedStages, analyze.Stage{
+				Name:     st.Name,
+				Index:    i,
+				Location: toSourceLocation(st.Location),
+			})
+		}
+	}
+	report.UnusedArgs = append(a.unusedGlobalArgs(), a.unusedStageArgs()...)
+	report.ParallelGroups = a.parallelGroups(reachable)
+
+	if match != nil {
+		report.EmptyCopySources, err = a.emptyCopySources(ctx, match)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+type analyzer struct {
+	stages     []instructions.Stage
+	shlex      *shell.Lex
+	byName     map[string]int
+	globalArgs *llb.EnvList
+	allArgs    map[string]argInfo
+	// deps are the indexes of the stages each stage depends on
+	deps []map[int]struct{}
+	// usedGlobalArgs are the global args read by FROM or redeclared in a stage
+	usedGlobalArgs map[string]struct{}
+}
+
+// stageRef returns the index of the stage referenced with name by the stage at
+// index i, if any
+func (a *analyzer) stageRef(name string, i int) (int, bool) {
+	if idx, ok := a.byName[strings.ToLower(name)]; ok && idx != i {
+		return idx, true
+	}
+	if idx, err := strconv.Atoi(name); err == nil && idx >= 0 && idx < i {
+		return idx, true
+	}
+	return 0, false
+}
+
+// expand expands the global args in word and returns the names of the args it
+// references. The original word is returned if it can't be expanded.
+func (a *analyzer) expand(word string) (string, map[string]struct{}) {
+	res, err := a.shlex.ProcessWordWithMatches(word, a.globalArgs)
+	if err != nil {
+		return word, nil
+	}
+	refs := maps.Clone(res.Matched)
+	if refs == nil {
+		refs = map[string]struct{}{}
+	}
+	maps.Copy(refs, res.Unmatched)
+	return res.Result, refs
+}
+
+func (a *analyzer) resolveDeps() {
+	a.usedGlobalArgs = map[string]struct{}{}
+	for i, st := range a.stages {
+		deps := map[int]struct{}{}
+		name, matched := a.expand(st.BaseName)
+		maps.Copy(a.usedGlobalArgs, matched)
+		if st.Platform != "" {
+			_, matched := a.expand(st.Platform)
+			maps.Copy(a.usedGlobalArgs, matched)
+		}
+		if idx, ok := a.byName[strings.ToLower(name)]; ok && idx < i {
+			deps[idx] = struct{}{}
+		}
+		for _, cmd := range st.Commands {
+			var froms []string
+			switch c := cmd.(type) {
+			case *instructions.CopyCommand:
+				froms = append(froms, c.From)
+			case *instructions.RunCommand:
+				for _, m := range instructions.GetMounts(c) {
+					froms = append(froms, m.From)
+				}
+			case *instructions.ArgCommand:
+				for _, kp := range c.Args {
+					a.usedGlobalArgs[kp.Key] = struct{}{}
+				}
+			}
+			for _, from := range froms {
+				if from == "" {
+					continue
+				}
+				from, _ = a.expand(from)
+				if idx, ok := a.stageRef(from, i); ok {
+					deps[idx] = struct{}{}
+				}
+			}
+		}
+		a.deps[i] = deps
+	}
+
+	// args read by the defaults of used args are used as well
+	queue := slices.Collect(maps.Keys(a.usedGlobalArgs))
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		for dep := range a.allArgs[k].deps {
+			if _, ok := a.usedGlobalArgs[dep]; !ok {
+				a.usedGlobalArgs[dep] = struct{}{}
+				queue = append(queue, dep)
+			}
+		}
+	}
+}
+
+func (a *analyzer) reachable(target int) map[int]struct{} {
+	out := map[int]struct{}{}
+	var visit func(int)
+	visit = func(i int) {
+		if _, ok := out[i]; ok {
+			return
+		}
+		out[i] = struct{}{}
+		for dep := range a.deps[i] {
+			visit(dep)
+		}
+	}
+	visit(target)
+	return out
+}
+
+func (a *analyzer) unusedGlobalArgs() []analyze.Arg {
+	var out []analyze.Arg
+	for _, k := range slices.Sorted(maps.Keys(a.allArgs)) {
+		if _, ok := a.usedGlobalArgs[k]; ok {
+			continue
+		}
+		out = append(out, analyze.Arg{
+			Name:     k,
+			Location: toSourceLocation(a.allArgs[k].location),
+		})
+	}
+	slices.SortStableFunc(out, func(a, b analyze.Arg) int {
+		if compLocation(a.Location, b.Location) {
+			return -1
+		}
+		if compLocation(b.Location, a.Location) {
+			return 1
+		}
+		return 0
+	})
+	return out
+}
+
+// unusedStageArgs returns the args of the stages that no later instruction of
+// the stage references. Args followed by a RUN instruction are always
+// considered used as they are set in its environment.
+func (a *analyzer) unusedStageArgs() []analyze.Arg {
+	var out []analyze.Arg
+	for _, st := range a.stages {
+		for i, cmd := range st.Commands {
+			argCmd, ok := cmd.(*instructions.ArgCommand)
+			if !ok {
+				continue
+			}
+			for _, kp := range argCmd.Args {
+				if !isArgReferenced(kp.Key, st.Commands[i+1:]) {
+					out = append(out, analyze.Arg{
+						Name:     kp.Key,
+						Stage:    st.Name,
+						Location: toSourceLocation(argCmd.Location()),
+					})
+				}
+			}
+		}
+	}
+	return out
+}
+
+func isArgReferenced(key string, cmds []instructions.Command) bool {
+	re := regexp.MustCompile(`\$\{?` + regexp.QuoteMeta(key) + `\b`)
+	for _, cmd := range cmds {
+		if _, ok := cmd.(*instructions.RunCommand); ok {
+			return true
+		}
+		if s, ok := cmd.(fmt.Stringer); ok && re.MatchString(s.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// parallelGroups groups the stages the target depends on by their depth in the
+// dependency graph. Stages of the same depth don't depend on each other.
+func (a *analyzer) parallelGroups(reachable map[int]struct{}) []analyze.ParallelGroup {
+	depths := map[int]int{}
+	var depth func(int) int
+	depth = func(i int) int {
+		if d, ok := depths[i]; ok {
+			return d
+		}
+		depths[i] = 0
+		d := 0
+		for dep := range a.deps[i] {
+			d = max(d, depth(dep)+1)
+		}
+		depths[i] = d
+		return d
+	}
+
+	groups := map[int][]int{}
+	for _, i := range slices.Sorted(maps.Keys(reachable)) {
+		d := depth(i)
+		groups[d] = append(groups[d], i)
+	}
+
+	var out []analyze.ParallelGroup
+	for _, d := range slices.Sorted(maps.Keys(groups)) {
+		if len(groups[d]) < 2 {
+			continue
+		}
+		g := analyze.ParallelGroup{}
+		for _, i := range groups[d] {
+			name := a.stages[i].Name
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			g.Stages = append(g.Stages, name)
+		}
+		out = append(out, g)
+	}
+	return out
+}
+
+func (a *analyzer) emptyCopySources(ctx context.Context, match ContextMatcher) ([]analyze.CopySource, error) {
+	type copySource struct {
+		analyze.CopySource
+		pattern string
+	}
+	var sources []copySource
+	patterns := map[string]struct{}{}
+	for _, st := range a.stages {
+		for _, cmd := range st.Commands {
+			var srcs []string
+			switch c := cmd.(type) {
+			case *instructions.CopyCommand:
+				if c.From != "" {
+					continue
+				}
+				srcs = c.SourcePaths
+			case *instructions.AddCommand:
+				srcs = c.SourcePaths
+			default:
+				continue
+			}
+			for _, src := range srcs {
+				if isHTTPSource(src) || isGitSource(src) || strings.Contains(src, "$") {
+					continue
+				}
+				p := strings.TrimPrefix(path.Clean("/"+src), "/")
+				if p == "" {
+					continue
+				}
+				patterns[p] = struct{}{}
+				sources = append(sources, copySource{
+					CopySource: analyze.CopySource{
+						Source:   src,
+						Stage:    st.Name,
+						Location: toSourceLocation(cmd.Location()),
+					},
+					pattern: p,
+				})
+			}
+		}
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	matched, err := match(ctx, slices.Sorted(maps.Keys(patterns)))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to match COPY sources")
+	}
+	var out []analyze.CopySource
+	for _, src := range sources {
+		if !matched[src.pattern] {
+			out = append(out, src.CopySource)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,108 @@
+package dockerfile2llb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/buildkit/frontend/subrequests/analyze"
+	"github.com/stretchr/testify/require"
+)
+
+const analyzeDockerfile = `
+ARG GO_VERSION=1.24
+ARG UNUSED=foo
+ARG ALPINE_VERSION
+ARG DEBUG
+FROM golang:${GO_VERSION} AS base
+WORKDIR /src
+
+FROM base AS deps
+COPY go.mod go.sum ./
+RUN go mod download
+
+FROM base AS tools
+ARG TOOL_VERSION
+COPY --from=alpine /bin/sh /bin/sh
+
+FROM deps AS build
+ARG DEBUG
+ARG VERSION
+COPY --from=tools /bin/sh /bin/sh
+COPY *.go ./
+ADD https://example.com/file.txt /
+RUN --mount=from=tools,target=/tools go build -ldflags "-X main.version=$VERSION" -o /out/app .
+
+FROM scratch AS docs
+COPY docs/ /docs/
+
+FROM alpine:${ALPINE_VERSION:-3.20}
+ARG TZ
+COPY --from=build /out/app /usr/bin/app
+COPY config/*.yml /etc/app/
+`
+
+func TestDockerfileAnalyze(t *testing.T) {
+	ctx := context.TODO()
+	var patterns []string
+	match := func(ctx context.Context, p []string) (map[string]bool, error) {
+		patterns = p
+		return map[string]bool{"go.mod": true, "*.go": true, "docs": true}, nil
+	}
+
+	r, err := DockerfileAnalyze(ctx, []byte(analyzeDockerfile), ConvertOpt{}, match)
+	require.NoError(t, err)
+	require.Equal(t, "", r.Target)
+
+	require.Equal(t, []analyze.Stage{{Name: "docs", Index: 4, Location: r.UnusedStages[0].Location}}, r.UnusedStages)
+	require.Equal(t, int32(25), r.UnusedStages[0].Location.Ranges[0].Start.Line)
+
+	var args []string
+	for _, a := range r.UnusedArgs {
+		args = append(args, a.Stage+":"+a.Name)
+	}
+	require.Equal(t, []string{":UNUSED", "tools:TOOL_VERSION", ":TZ"}, args)
+	require.Equal(t, int32(3), r.UnusedArgs[0].Location.Ranges[0].Start.Line)
+
+	require.Equal(t, []string{"*.go", "config/*.yml", "docs", "go.mod", "go.sum"}, patterns)
+	require.Len(t, r.EmptyCopySources, 2)
+	require.Equal(t, "go.sum", r.EmptyCopySources[0].Source)
+	require.Equal(t, "deps", r.EmptyCopySources[0].Stage)
+	require.Equal(t, "config/*.yml", r.EmptyCopySources[1].Source)
+
+	require.Equal(t, []analyze.ParallelGroup{{Stages: []string{"deps", "tools"}}}, r.ParallelGroups)
+}
+
+func TestDockerfileAnalyzeTarget(t *testing.T) {
+	ctx := context.TODO()
+	opt := ConvertOpt{}
+	opt.Target = "docs"
+	r, err := DockerfileAnalyze(ctx, []byte(analyzeDockerfile), opt, nil)
+	require.NoError(t, err)
+	require.Equal(t, "docs", r.Target)
+	var stages []string
+	for _, s := range r.UnusedStages {
+		stages = append(stages, s.Name)
+	}
+	require.Equal(t, []string{"base", "deps", "tools", "build", ""}, stages)
+	require.Nil(t, r.ParallelGroups)
+	require.Nil(t, r.EmptyCopySources)
+
+	opt.Target = "biuld"
+	_, err = DockerfileAnalyze(ctx, []byte(analyzeDockerfile), opt, nil)
+	require.ErrorContains(t, err, `target stage "biuld" could not be found`)
+	require.ErrorContains(t, err, "did you mean build?")
+}
+
+func TestAnalyzeReportResult(t *testing.T) {
+	r, err := DockerfileAnalyze(context.TODO(), []byte(analyzeDockerfile), ConvertOpt{}, nil)
+	require.NoError(t, err)
+	res, err := r.ToResult()
+	require.NoError(t, err)
+	require.Equal(t, []byte("1.0.0"), res.Metadata["version"])
+
+	txt := string(res.Metadata["result.txt"])
+	require.Contains(t, txt, "UNUSED STAGE")
+	require.Contains(t, txt, "docs")
+	require.Contains(t, txt, "deps, tools")
+	require.NotContains(t, txt, "EMPTY COPY SOURCE")
+}
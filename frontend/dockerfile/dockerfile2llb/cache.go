@@ -0,0 +1,126 @@
+package dockerfile2llb
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/instructions"
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/moby/buildkit/util/suggest"
+	"github.com/pkg/errors"
+)
+
+const keyCacheDirective = "cache"
+
+// cacheConfig is the per-stage cache behavior declared with the cache
+// directive, e.g. "# cache=scope=deps:main,build:feature-x;nocache=test"
+type cacheConfig struct {
+	// scopes namespace the cache mounts of the stages
+	scopes map[string]string
+	// noCache stages are never cached
+	noCache map[string]struct{}
+}
+
+func parseCacheDirective(dt []byte) (*cacheConfig, []parser.Range, error) {
+	v, _, loc, ok := parser.ParseDirective(keyCacheDirective, dt)
+	if !ok {
+		return &cacheConfig{}, nil, nil
+	}
+	cfg, err := parseCacheOptions(v)
+	if err != nil {
+		return nil, loc, parser.WithLocation(errors.Wrap(err, "failed to parse cache directive"), loc)
+	}
+	return cfg, loc, nil
+}
+
+func parseCacheOptions(v string) (*cacheConfig, error) {
+	cfg := &cacheConfig{
+		scopes:  map[string]string{},
+		noCache: map[string]struct{}{},
+	}
+	for _, p := range strings.Split(v, ";") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(p, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid cache option %q", p)
+		}
+		switch k = strings.TrimSpace(k); k {
+		case "scope":
+			for _, s := range strings.Split(v, ",") {
+				stage, scope, ok := strings.Cut(strings.TrimSpace(s), ":")
+				stage, scope = strings.ToLower(strings.TrimSpace(stage)), strings.TrimSpace(scope)
+				if !ok || stage == "" || scope == "" {
+					return nil, errors.Errorf("invalid cache scope %q, expected <stage>:<scope>", s)
+				}
+				if _, ok := cfg.scopes[stage]; ok {
+					return nil, errors.Errorf("cache scope of stage %q set multiple times", stage)
+				}
+				cfg.scopes[stage] = scope
+			}
+		case "nocache":
+			for _, s := range strings.Split(v, ",") {
+				stage := strings.ToLower(strings.TrimSpace(s))
+				if stage == "" {
+					return nil, errors.Errorf("invalid nocache stage %q", s)
+				}
+				cfg.noCache[stage] = struct{}{}
+			}
+		default:
+			return nil, errors.Errorf("invalid cache option %q", k)
+		}
+	}
+	return cfg, nil
+}
+
+// validate checks that the stages of the directive exist
+func (cfg *cacheConfig) validate(stages []instructions.Stage, loc []parser.Range) error {
+	names := make([]string, 0, len(stages))
+	exists := map[string]struct{}{}
+	for _, st := range stages {
+		if st.Name != "" {
+			names = append(names, st.Name)
+			exists[st.Name] = struct{}{}
+		}
+	}
+	check := func(stage string) error {
+		if _, ok := exists[stage]; ok {
+			return nil
+		}
+		err := errors.Errorf("cache directive references unknown stage %q", stage)
+		return parser.WithLocation(suggest.WrapError(err, stage, names, true), loc)
+	}
+	for _, stage := range slices.Sorted(maps.Keys(cfg.scopes)) {
+		if err := check(stage); err != nil {
+			return err
+		}
+	}
+	for _, stage := range slices.Sorted(maps.Keys(cfg.noCache)) {
+		if err := check(stage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cfg *cacheConfig) isNoCache(stage string) bool {
+	if stage == "" {
+		return false
+	}
+	_, ok := cfg.noCache[stage]
+	return ok
+}
+
+// cacheIDNamespace returns the namespace of the cache mounts of the stage
+func (cfg *cacheConfig) cacheIDNamespace(ns string, stage string) string {
+	scope, ok := cfg.scopes[stage]
+	if !ok || stage == "" {
+		return ns
+	}
+	if ns == "" {
+		return scope
+	}
+	return ns + "/" + scope
+}
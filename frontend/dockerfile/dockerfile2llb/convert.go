@@ -87,6 +87,16 @@ type SBOMTargets struct {
 }
 
 func Dockerfile2LLB(ctx context.Context, dt []byte, opt ConvertOpt) (st *llb.State, img, baseImg *dockerspec.DockerOCIImage, sbom *SBOMTargets, err error) {
+	// fail before resolving the images if the client doesn't provide the
+	// required secrets and SSH agents
+	requireCfg, requireLoc, err := parseRequireDirective(dt)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if err := requireCfg.check(ctx, opt.Client, requireLoc); err != nil {
+		return nil, nil, nil, nil, err
+	}
+
 	ds, err := toDispatchState(ctx, dt, opt)
 	if err != nil {
 		return nil, nil, nil, nil, err
@@ -257,6 +267,17 @@ func toDispatchState(ctx context.Context, dt []byte, opt ConvertOpt) (*dispatchS
 	validateStageNames(stages, lint)
 	validateCommandCasing(stages, lint)
 
+	cacheCfg, cacheLoc, err := parseCacheDirective(dt)
+	if err != nil {
+		return nil, err
+	}
+	if err := cacheCfg.validate(stages, cacheLoc); err != nil {
+		return nil, err
+	}
+	if _, _, err := parseRequireDirective(dt); err != nil {
+		return nil, err
+	}
+
 	platformOpt := buildPlatformOpt(&opt)
 	targetName := opt.Target
 	if targetName == "" {
@@ -389,6 +410,9 @@ func toDispatchState(ctx context.Context, dt []byte, opt ConvertOpt) (*dispatchS
 		if opt.Client != nil {
 			ds.ignoreCache = opt.Client.IsNoCache(st.Name)
 		}
+		if cacheCfg.isNoCache(st.Name) {
+			ds.ignoreCache = true
+		}
 	}
 
 	var target *dispatchState
@@ -713,7 +737,7 @@ func toDispatchState(ctx context.Context, dt []byte, opt ConvertOpt) (*dispatchS
 			shlex:               shlex,
 			buildContext:        llb.NewState(buildContext),
 			proxyEnv:            proxyEnv,
-			cacheIDNamespace:    opt.CacheIDNamespace,
+			cacheIDNamespace:    cacheCfg.cacheIDNamespace(opt.CacheIDNamespace, d.stage.Name),
 			buildPlatforms:      platformOpt.buildPlatforms,
 			targetPlatform:      platformOpt.targetPlatform,
 			extraHosts:          opt.ExtraHosts,
@@ -934,6 +958,11 @@ func dispatch(d *dispatchState, cmd command, opt dispatchOpt) error {
 		}
 	}
 
+	// any other command ends the group of parallel RUN commands
+	if c, ok := cmd.Command.(*instructions.RunCommand); !ok || !instructions.GetParallel(c) {
+		d.parallel = nil
+	}
+
 	switch c := cmd.Command.(type) {
 	case *instructions.MaintainerCommand:
 		err = dispatchMaintainer(d, c)
@@ -1055,6 +1084,7 @@ type dispatchState struct {
 	// paths marks the paths that are used by this dispatchState.
 	paths          map[string]struct{}
 	ignoreCache    bool
+	parallel       *parallelGroup
 	unregistered   bool
 	stageName      string
 	cmdIndex       int
@@ -1074,6 +1104,15 @@ type dispatchState struct {
 	healthcheck instructionTracker
 }
 
+// parallelGroup is a group of consecutive RUN --parallel commands
+type parallelGroup struct {
+	base  llb.State
+	diffs []llb.State
+	// locations are the locations of the RUN commands of the group, for
+	// the errors of the merge to point to them
+	locations []llb.ConstraintsOpt
+}
+
 func (ds *dispatchState) asyncLocalOpts() []llb.LocalOption {
 	return filterPaths(ds.paths)
 }
@@ -1247,6 +1286,7 @@ func dispatchRun(d *dispatchState, c *instructions.RunCommand, proxy *llb.ProxyE
 					llb.Mkfile(f, 0755, []byte(data)),
 					dockerui.WithInternalName("preparing inline document"),
 					llb.Platform(*d.platform),
+					location(dopt.sourceMap, c.Location()),
 				)
 
 				mount := llb.AddMount(destPath, st, llb.SourcePath(sourcePath), llb.Readonly)
@@ -1361,7 +1401,23 @@ func dispatchRun(d *dispatchState, c *instructions.RunCommand, proxy *llb.ProxyE
 		}
 	}
 
-	d.state = d.state.Run(opt...).Root()
+	// dopt.llbCaps can be nil in unit tests
+	if instructions.GetParallel(c) && dopt.llbCaps != nil && dopt.llbCaps.Supports(pb.CapMergeOp) == nil && dopt.llbCaps.Supports(pb.CapDiffOp) == nil {
+		// consecutive RUN --parallel commands run on the same state and the
+		// changes of each are merged on top of it
+		if d.parallel == nil {
+			d.parallel = &parallelGroup{base: d.state}
+		}
+		base := d.parallel.base
+		loc := location(dopt.sourceMap, c.Location())
+		d.parallel.diffs = append(d.parallel.diffs, llb.Diff(base, base.Run(opt...).Root(), loc))
+		d.parallel.locations = append(d.parallel.locations, loc)
+		inputs := append([]llb.State{base}, d.parallel.diffs...)
+		mergeOpts := append([]llb.ConstraintsOpt{dockerui.WithInternalName("merging parallel RUN")}, d.parallel.locations...)
+		d.state = d.state.WithOutput(llb.Merge(inputs, mergeOpts...).Output())
+	} else {
+		d.state = d.state.Run(opt...).Root()
+	}
 	return commitToHistory(&d.image, "RUN "+runCommandString(args, d.buildArgs, env), true, &d.state, d.epoch)
 }
 
@@ -1665,6 +1721,7 @@ func dispatchCopy(d *dispatchState, cfg copyConfig) error {
 			llb.Mkfile(f, 0644, []byte(data)),
 			dockerui.WithInternalName("preparing inline document"),
 			llb.Platform(*d.platform),
+			location(cfg.opt.sourceMap, cfg.location),
 		)
 
 		opts := append([]llb.CopyOption{&llb.CopyInfo{
@@ -2177,7 +2234,7 @@ func prefixCommand(ds *dispatchState, str string, prefixPlatform bool, platform
 	}
 	out := "["
 	if prefixPlatform && platform != nil {
-		out += platforms.FormatAll(*platform) + formatTargetPlatform(*platform, platformFromEnv(env)) + " "
+		out += platforms.FormatAll(*platform) + formatTargetPlatform(*platform, llb.PlatformFromArgs(env)) + " "
 	}
 	if ds.stageName != "" {
 		out += ds.stageName + " "
@@ -2216,36 +2273,6 @@ func formatTargetPlatform(base ocispecs.Platform, target *ocispecs.Platform) str
 	return ""
 }
 
-// platformFromEnv returns defined platforms based on TARGET* environment variables
-func platformFromEnv(env shell.EnvGetter) *ocispecs.Platform {
-	var p ocispecs.Platform
-	var set bool
-	for _, key := range env.Keys() {
-		switch key {
-		case "TARGETPLATFORM":
-			v, _ := env.Get(key)
-			p, err := platforms.Parse(v)
-			if err != nil {
-				continue
-			}
-			return &p
-		case "TARGETOS":
-			p.OS, _ = env.Get(key)
-			set = true
-		case "TARGETARCH":
-			p.Architecture, _ = env.Get(key)
-			set = true
-		case "TARGETVARIANT":
-			p.Variant, _ = env.Get(key)
-			set = true
-		}
-	}
-	if !set {
-		return nil
-	}
-	return &p
-}
-
 func location(sm *llb.SourceMap, locations []parser.Range) llb.ConstraintsOpt {
 	loc := make([]*pb.Range, 0, len(locations))
 	for _, l := range locations {
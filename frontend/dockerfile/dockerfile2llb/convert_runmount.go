@@ -85,7 +85,7 @@ func dispatchRunMounts(d *dispatchState, c *instructions.RunCommand, sources []*
 			))
 		}
 		if mount.Type == instructions.MountTypeSecret {
-			secret, err := dispatchSecret(d, mount, c.Location())
+			secret, err := dispatchSecret(d, mount, c.Location(), opt.llbCaps)
 			if err != nil {
 				return nil, err
 			}
@@ -7,20 +7,26 @@ import (
 	"github.com/moby/buildkit/frontend/dockerfile/instructions"
 	"github.com/moby/buildkit/frontend/dockerfile/parser"
 	"github.com/moby/buildkit/frontend/dockerfile/shell"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/apicaps"
 	"github.com/pkg/errors"
 )
 
-func dispatchSecret(d *dispatchState, m *instructions.Mount, loc []parser.Range) (llb.RunOption, error) {
+func dispatchSecret(d *dispatchState, m *instructions.Mount, loc []parser.Range, llbCaps *apicaps.CapSet) (llb.RunOption, error) {
 	id := m.CacheID
 	if m.Source != "" {
 		id = m.Source
 	}
 
 	if id == "" {
-		if m.Target == "" {
-			return nil, errors.Errorf("one of source, target required")
+		switch {
+		case m.Target != "":
+			id = path.Base(m.Target)
+		case m.Env != nil && *m.Env != "":
+			id = *m.Env
+		default:
+			return nil, errors.Errorf("one of source, target, env required")
 		}
-		id = path.Base(m.Target)
 	}
 
 	var target *string
@@ -51,6 +57,15 @@ func dispatchSecret(d *dispatchState, m *instructions.Mount, loc []parser.Range)
 	if m.Env != nil {
 		opts = append(opts, llb.SecretAsEnvName(*m.Env))
 	}
+	if m.Directory {
+		// llbCaps can be nil in unit tests
+		if llbCaps != nil {
+			if err := llbCaps.Supports(pb.CapExecMountSecretDirectory); err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, llb.SecretDirectory)
+	}
 
 	if m.UID != nil || m.GID != nil || m.Mode != nil {
 		var uid, gid, mode int
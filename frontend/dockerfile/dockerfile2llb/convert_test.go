@@ -2,11 +2,13 @@ package dockerfile2llb
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/moby/buildkit/frontend/dockerfile/instructions"
 	"github.com/moby/buildkit/frontend/dockerfile/shell"
 	"github.com/moby/buildkit/frontend/dockerui"
+	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/appcontext"
 	digest "github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/assert"
@@ -230,3 +232,141 @@ RUN echo bar
 	assert.Equal(t, []digest.Digest{"sha256:2e112031b4b923a873c8b3d685d48037e4d5ccd967b658743d93a6e56c3064b9"}, baseImg.RootFS.DiffIDs)
 	assert.Equal(t, "2024-01-17 21:49:12 +0000 UTC", baseImg.Created.String())
 }
+
+func TestCacheDirective(t *testing.T) {
+	t.Parallel()
+	df := `# cache=scope=deps:feature-x;nocache=Test
+FROM scratch AS deps
+RUN --mount=type=cache,target=/root/.cache,id=gocache true
+
+FROM deps AS test
+RUN --mount=type=cache,target=/root/.cache,id=gocache true
+`
+	state, _, _, _, err := Dockerfile2LLB(appcontext.Context(), []byte(df), ConvertOpt{
+		Config: dockerui.Config{Target: "test", CacheIDNamespace: "ns"},
+	})
+	require.NoError(t, err)
+
+	def, err := state.Marshal(context.TODO())
+	require.NoError(t, err)
+
+	var cacheIDs []string
+	var ignoreCache int
+	for _, dt := range def.Def {
+		var op pb.Op
+		require.NoError(t, op.Unmarshal(dt))
+		exec := op.GetExec()
+		if exec == nil {
+			continue
+		}
+		for _, m := range exec.Mounts {
+			if m.CacheOpt != nil {
+				cacheIDs = append(cacheIDs, m.CacheOpt.ID)
+			}
+		}
+		if def.Metadata[digest.FromBytes(dt)].IgnoreCache {
+			ignoreCache++
+		}
+	}
+	require.ElementsMatch(t, []string{"ns/feature-x/gocache", "ns/gocache"}, cacheIDs)
+	require.Equal(t, 1, ignoreCache)
+
+	for _, tc := range []struct {
+		directive string
+		err       string
+	}{
+		{"scope=deps", `invalid cache scope "deps", expected <stage>:<scope>`},
+		{"scope=deps:a,deps:b", `cache scope of stage "deps" set multiple times`},
+		{"nocache=tset", `cache directive references unknown stage "tset" (did you mean test?)`},
+		{"ttl=1h", `invalid cache option "ttl"`},
+	} {
+		_, stages, _ := strings.Cut(df, "\n")
+		_, _, _, _, err := Dockerfile2LLB(appcontext.Context(), []byte("# cache="+tc.directive+"\n"+stages), ConvertOpt{})
+		require.ErrorContains(t, err, tc.err, tc.directive)
+	}
+}
+
+func TestRequireDirective(t *testing.T) {
+	t.Parallel()
+	cfg, err := parseRequireOptions("secret=aws, npmrc;ssh=default;secret=aws")
+	require.NoError(t, err)
+	require.Equal(t, []string{"aws", "npmrc"}, cfg.secrets)
+	require.Equal(t, []string{"default"}, cfg.ssh)
+
+	// the requirements are only checked with a client
+	_, _, _, _, err = Dockerfile2LLB(appcontext.Context(), []byte("# require=secret=aws\nFROM scratch\n"), ConvertOpt{})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		directive string
+		err       string
+	}{
+		{"secret", `invalid require option "secret"`},
+		{"secret=a,", `invalid required secret "a,"`},
+		{"env=FOO", `invalid require option "env"`},
+	} {
+		_, _, _, _, err := Dockerfile2LLB(appcontext.Context(), []byte("# require="+tc.directive+"\nFROM scratch\n"), ConvertOpt{})
+		require.ErrorContains(t, err, tc.err, tc.directive)
+	}
+}
+
+func TestRunParallel(t *testing.T) {
+	t.Parallel()
+	df := `FROM scratch
+RUN init
+RUN --parallel setup-a
+RUN --parallel setup-b
+RUN build
+`
+	caps := pb.Caps.CapSet(pb.Caps.All())
+	state, _, _, _, err := Dockerfile2LLB(appcontext.Context(), []byte(df), ConvertOpt{LLBCaps: &caps})
+	require.NoError(t, err)
+
+	def, err := state.Marshal(context.TODO())
+	require.NoError(t, err)
+
+	ops := map[string]*pb.Op{}
+	execs := map[string]*pb.Op{}
+	for _, dt := range def.Def {
+		var op pb.Op
+		require.NoError(t, op.Unmarshal(dt))
+		ops[digest.FromBytes(dt).String()] = &op
+		if exec := op.GetExec(); exec != nil {
+			execs[exec.Meta.Args[2]] = &op
+		}
+	}
+	require.Len(t, execs, 4)
+
+	// both parallel commands run on the state of init
+	initDgst := ""
+	for dgst, op := range ops {
+		if op == execs["init"] {
+			initDgst = dgst
+		}
+	}
+	require.Equal(t, initDgst, execs["setup-a"].Inputs[0].Digest)
+	require.Equal(t, initDgst, execs["setup-b"].Inputs[0].Digest)
+
+	// and their changes are merged on top of it for the following command
+	merge := ops[execs["build"].Inputs[0].Digest]
+	require.NotNil(t, merge.GetMerge())
+	require.Len(t, merge.Inputs, 3)
+	require.Equal(t, initDgst, merge.Inputs[0].Digest)
+	for i, name := range []string{"setup-a", "setup-b"} {
+		diff := ops[merge.Inputs[i+1].Digest]
+		require.NotNil(t, diff.GetDiff())
+		require.Equal(t, initDgst, diff.Inputs[diff.GetDiff().Lower.Input].Digest)
+		require.Equal(t, execs[name], ops[diff.Inputs[diff.GetDiff().Upper.Input].Digest])
+	}
+
+	// without the caps the commands run sequentially
+	state, _, _, _, err = Dockerfile2LLB(appcontext.Context(), []byte(df), ConvertOpt{})
+	require.NoError(t, err)
+	def, err = state.Marshal(context.TODO())
+	require.NoError(t, err)
+	for _, dt := range def.Def {
+		var op pb.Op
+		require.NoError(t, op.Unmarshal(dt))
+		require.Nil(t, op.GetMerge())
+	}
+}
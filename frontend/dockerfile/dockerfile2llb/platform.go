@@ -42,26 +42,14 @@ func defaultArgs(po *platformOpt, overrides map[string]string, target string) *l
 	if target == "" {
 		target = "default"
 	}
-	s := [...][2]string{
-		{"BUILDPLATFORM", platforms.Format(bp)},
-		{"BUILDOS", bp.OS},
-		{"BUILDOSVERSION", bp.OSVersion},
-		{"BUILDARCH", bp.Architecture},
-		{"BUILDVARIANT", bp.Variant},
-		{"TARGETPLATFORM", platforms.FormatAll(tp)},
-		{"TARGETOS", tp.OS},
-		{"TARGETOSVERSION", tp.OSVersion},
-		{"TARGETARCH", tp.Architecture},
-		{"TARGETVARIANT", tp.Variant},
-		{"TARGETSTAGE", target},
-	}
+	args := llb.PlatformArgs(bp, tp).AddOrReplace("TARGETSTAGE", target)
 	env := &llb.EnvList{}
-	for _, kv := range s {
-		v := kv[1]
-		if ov, ok := overrides[kv[0]]; ok {
+	for _, k := range args.Keys() {
+		v, _ := args.Get(k)
+		if ov, ok := overrides[k]; ok {
 			v = ov
 		}
-		env = env.AddOrReplace(kv[0], v)
+		env = env.AddOrReplace(k, v)
 	}
 	return env
 }
@@ -0,0 +1,99 @@
+package dockerfile2llb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/frontend/dockerfile/parser"
+	"github.com/moby/buildkit/frontend/dockerui"
+	"github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/pkg/errors"
+)
+
+const keyRequireDirective = "require"
+
+// requireConfig is the secrets and SSH agents that the build requires from
+// the client, declared with the require directive, e.g.
+// "# require=secret=aws,npmrc;ssh=default"
+type requireConfig struct {
+	secrets []string
+	ssh     []string
+}
+
+func parseRequireDirective(dt []byte) (*requireConfig, []parser.Range, error) {
+	v, _, loc, ok := parser.ParseDirective(keyRequireDirective, dt)
+	if !ok {
+		return &requireConfig{}, nil, nil
+	}
+	cfg, err := parseRequireOptions(v)
+	if err != nil {
+		return nil, loc, parser.WithLocation(errors.Wrap(err, "failed to parse require directive"), loc)
+	}
+	return cfg, loc, nil
+}
+
+func parseRequireOptions(v string) (*requireConfig, error) {
+	cfg := &requireConfig{}
+	for _, p := range strings.Split(v, ";") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(p, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid require option %q", p)
+		}
+		var ids *[]string
+		switch k = strings.TrimSpace(k); k {
+		case "secret":
+			ids = &cfg.secrets
+		case "ssh":
+			ids = &cfg.ssh
+		default:
+			return nil, errors.Errorf("invalid require option %q", k)
+		}
+		for _, id := range strings.Split(v, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" {
+				return nil, errors.Errorf("invalid required %s %q", k, v)
+			}
+			if !slices.Contains(*ids, id) {
+				*ids = append(*ids, id)
+			}
+		}
+	}
+	return cfg, nil
+}
+
+// check fails if the client doesn't provide the secrets and SSH agents of
+// the directive, before the build starts. The requirements are not checked
+// if the gateway doesn't support it, the build fails when the instructions
+// using them run instead.
+func (cfg *requireConfig) check(ctx context.Context, c *dockerui.Client, loc []parser.Range) error {
+	if c == nil || (len(cfg.secrets) == 0 && len(cfg.ssh) == 0) {
+		return nil
+	}
+	resp, err := c.CheckSession(ctx, client.CheckSessionRequest{
+		SecretIDs: cfg.secrets,
+		SSHIDs:    cfg.ssh,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to check required secrets and SSH agents")
+	}
+	if resp == nil {
+		return nil
+	}
+	var missing []string
+	for _, id := range resp.MissingSecretIDs {
+		missing = append(missing, fmt.Sprintf("secret %q", id))
+	}
+	for _, id := range resp.MissingSSHIDs {
+		missing = append(missing, fmt.Sprintf("SSH agent %q", id))
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	err = errors.Errorf("required %s not provided by the client", strings.Join(missing, ", "))
+	return parser.WithLocation(err, loc)
+}
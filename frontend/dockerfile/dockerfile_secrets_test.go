@@ -21,6 +21,7 @@ var secretsTests = integration.TestFuncs(
 	testSecretRequiredWithoutValue,
 	testSecretAsEnviron,
 	testSecretAsEnvironWithFileMount,
+	testSecretAsEnvironWithoutID,
 )
 
 func init() {
@@ -170,3 +171,34 @@ RUN --mount=type=secret,id=mysecret,target=/run/secrets/secret,env=SECRET_ENV [
 	}, nil)
 	require.NoError(t, err)
 }
+
+func testSecretAsEnvironWithoutID(t *testing.T, sb integration.Sandbox) {
+	integration.SkipOnPlatform(t, "windows")
+	f := getFrontend(t, sb)
+
+	dockerfile := []byte(`
+FROM busybox
+RUN --mount=type=secret,env=API_KEY [ "$API_KEY" == "pw" ] && [ ! -e /run/secrets/API_KEY ] || false
+RUN [ -z "$API_KEY" ]
+`)
+
+	dir := integration.Tmpdir(
+		t,
+		fstest.CreateFile("Dockerfile", dockerfile, 0600),
+	)
+
+	c, err := client.New(sb.Context(), sb.Address())
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = f.Solve(sb.Context(), c, client.SolveOpt{
+		LocalMounts: map[string]fsutil.FS{
+			dockerui.DefaultLocalNameDockerfile: dir,
+			dockerui.DefaultLocalNameContext:    dir,
+		},
+		Session: []session.Attachable{secretsprovider.FromMap(map[string][]byte{
+			"API_KEY": []byte("pw"),
+		})},
+	}, nil)
+	require.NoError(t, err)
+}
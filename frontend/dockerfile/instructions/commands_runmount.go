@@ -128,6 +128,8 @@ type Mount struct {
 	Mode *uint64
 	UID  *uint64
 	GID  *uint64
+	// Directory mounts a secret as a directory of files
+	Directory bool
 }
 
 func parseMount(val string, expander SingleWordExpander) (*Mount, error) {
@@ -164,6 +166,13 @@ func parseMount(val string, expander SingleWordExpander) (*Mount, error) {
 				} else {
 					return nil, errors.Errorf("unexpected key '%s' for mount type '%s'", key, m.Type)
 				}
+			case "directory":
+				if m.Type == MountTypeSecret {
+					m.Directory = true
+					continue
+				} else {
+					return nil, errors.Errorf("unexpected key '%s' for mount type '%s'", key, m.Type)
+				}
 			default:
 				// any other option requires a value.
 				return nil, errors.Errorf("invalid field '%s' must be a key=value pair", field)
@@ -220,6 +229,15 @@ func parseMount(val string, expander SingleWordExpander) (*Mount, error) {
 			} else {
 				return nil, errors.Errorf("unexpected key '%s' for mount type '%s'", key, m.Type)
 			}
+		case "directory":
+			if m.Type == MountTypeSecret {
+				m.Directory, err = strconv.ParseBool(value)
+				if err != nil {
+					return nil, errors.Errorf("invalid value for %s: %s", key, value)
+				}
+			} else {
+				return nil, errors.Errorf("unexpected key '%s' for mount type '%s'", key, m.Type)
+			}
 		case "size":
 			if m.Type == MountTypeTmpfs {
 				m.SizeLimit, err = units.RAMInBytes(value)
@@ -259,7 +277,7 @@ func parseMount(val string, expander SingleWordExpander) (*Mount, error) {
 			m.Env = &value
 		default:
 			allKeys := []string{
-				"type", "from", "source", "target", "readonly", "id", "sharing", "required", "size", "mode", "uid", "gid", "src", "dst", "destination", "ro", "rw", "readwrite", "env",
+				"type", "from", "source", "target", "readonly", "id", "sharing", "required", "size", "mode", "uid", "gid", "src", "dst", "destination", "ro", "rw", "readwrite", "env", "directory",
 			}
 			return nil, suggest.WrapError(errors.Errorf("unexpected key '%s' in '%s'", key, field), key, allKeys, true)
 		}
@@ -294,12 +312,15 @@ func parseMount(val string, expander SingleWordExpander) (*Mount, error) {
 		if m.CacheSharing != "" {
 			return nil, errors.Errorf("secret mount should not define sharing")
 		}
-		if m.Source == "" && m.Target == "" && m.CacheID == "" {
-			return nil, errors.Errorf("invalid secret mount. one of source, target required")
+		if m.Source == "" && m.Target == "" && m.CacheID == "" && (m.Env == nil || *m.Env == "") {
+			return nil, errors.Errorf("invalid secret mount. one of source, target, env required")
 		}
 		if m.Source != "" && m.CacheID != "" {
 			return nil, errors.Errorf("both source and id can't be set")
 		}
+		if m.Directory && m.Env != nil {
+			return nil, errors.Errorf("directory secret can't be set as an environment variable")
+		}
 	}
 
 	if m.CacheSharing != "" && m.Type != MountTypeCache {
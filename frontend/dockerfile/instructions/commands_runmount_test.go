@@ -0,0 +1,59 @@
+package instructions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecretMount(t *testing.T) {
+	m, err := parseMount("type=secret,env=API_KEY", expandNone)
+	require.NoError(t, err)
+	require.Equal(t, MountTypeSecret, m.Type)
+	require.Equal(t, "", m.Target)
+	require.NotNil(t, m.Env)
+	require.Equal(t, "API_KEY", *m.Env)
+
+	m, err = parseMount("type=secret,id=key,env=API_KEY", expandNone)
+	require.NoError(t, err)
+	require.Equal(t, "key", m.CacheID)
+	require.Equal(t, "API_KEY", *m.Env)
+
+	_, err = parseMount("type=secret", expandNone)
+	require.ErrorContains(t, err, "one of source, target, env required")
+
+	_, err = parseMount("type=secret,env=", expandNone)
+	require.ErrorContains(t, err, "one of source, target, env required")
+}
+
+func TestParseSecretDirectoryMount(t *testing.T) {
+	m, err := parseMount("type=secret,id=certs,target=/etc/certs,directory", expandNone)
+	require.NoError(t, err)
+	require.Equal(t, MountTypeSecret, m.Type)
+	require.Equal(t, "/etc/certs", m.Target)
+	require.True(t, m.Directory)
+
+	m, err = parseMount("type=secret,id=certs,directory=true", expandNone)
+	require.NoError(t, err)
+	require.True(t, m.Directory)
+
+	m, err = parseMount("type=secret,id=certs,directory=false", expandNone)
+	require.NoError(t, err)
+	require.False(t, m.Directory)
+
+	_, err = parseMount("type=secret,id=certs,directory=maybe", expandNone)
+	require.ErrorContains(t, err, "invalid value for directory: maybe")
+
+	_, err = parseMount("type=cache,target=/cache,directory", expandNone)
+	require.ErrorContains(t, err, "unexpected key 'directory' for mount type 'cache'")
+
+	_, err = parseMount("type=bind,target=/src,directory=true", expandNone)
+	require.ErrorContains(t, err, "unexpected key 'directory' for mount type 'bind'")
+
+	_, err = parseMount("type=secret,id=certs,env=CERTS,directory", expandNone)
+	require.ErrorContains(t, err, "directory secret can't be set as an environment variable")
+}
+
+func expandNone(word string) (string, error) {
+	return word, nil
+}
@@ -0,0 +1,33 @@
+package instructions
+
+var parallelKey = "dockerfile/run/parallel"
+
+func init() {
+	parseRunPreHooks = append(parseRunPreHooks, runParallelPreHook)
+	parseRunPostHooks = append(parseRunPostHooks, runParallelPostHook)
+}
+
+func runParallelPreHook(cmd *RunCommand, req parseRequest) error {
+	st := &parallelState{}
+	st.flag = req.flags.AddBool("parallel", false)
+	cmd.setExternalValue(parallelKey, st)
+	return nil
+}
+
+func runParallelPostHook(cmd *RunCommand, req parseRequest) error {
+	st := cmd.getExternalValue(parallelKey).(*parallelState)
+	st.parallel = st.flag.IsTrue()
+	return nil
+}
+
+// GetParallel returns if the command is part of a group of consecutive
+// RUN --parallel commands that are independent of each other.
+func GetParallel(cmd *RunCommand) bool {
+	st, ok := cmd.getExternalValue(parallelKey).(*parallelState)
+	return ok && st.parallel
+}
+
+type parallelState struct {
+	flag     *Flag
+	parallel bool
+}
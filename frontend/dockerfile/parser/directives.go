@@ -12,15 +12,19 @@ import (
 )
 
 const (
-	keySyntax = "syntax"
-	keyCheck  = "check"
-	keyEscape = "escape"
+	keySyntax  = "syntax"
+	keyCheck   = "check"
+	keyEscape  = "escape"
+	keyCache   = "cache"
+	keyRequire = "require"
 )
 
 var validDirectives = map[string]struct{}{
-	keySyntax: {},
-	keyEscape: {},
-	keyCheck:  {},
+	keySyntax:  {},
+	keyEscape:  {},
+	keyCheck:   {},
+	keyCache:   {},
+	keyRequire: {},
 }
 
 type Directive struct {
@@ -17,6 +17,7 @@ import (
 	"github.com/moby/buildkit/frontend/attestations"
 	"github.com/moby/buildkit/frontend/dockerfile/linter"
 	"github.com/moby/buildkit/frontend/gateway/client"
+	gwpb "github.com/moby/buildkit/frontend/gateway/pb"
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/flightcontrol"
 	"github.com/moby/patternmatcher/ignorefile"
@@ -489,6 +490,15 @@ func (bc *Client) IsNoCache(name string) bool {
 	return false
 }
 
+// CheckSession returns the secrets and SSH agents of req that are not
+// provided by the client. It returns nil if the gateway can't check them.
+func (bc *Client) CheckSession(ctx context.Context, req client.CheckSessionRequest) (*client.CheckSessionResponse, error) {
+	if bc.bopts.Caps.Supports(gwpb.CapGatewayCheckSession) != nil {
+		return nil, nil
+	}
+	return bc.client.CheckSession(ctx, req)
+}
+
 func (bc *Client) DockerIgnorePatterns(ctx context.Context) ([]string, error) {
 	if bc == nil {
 		return nil, nil
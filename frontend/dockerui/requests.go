@@ -7,6 +7,7 @@ import (
 
 	"github.com/moby/buildkit/frontend/gateway/client"
 	"github.com/moby/buildkit/frontend/subrequests"
+	"github.com/moby/buildkit/frontend/subrequests/analyze"
 	"github.com/moby/buildkit/frontend/subrequests/lint"
 	"github.com/moby/buildkit/frontend/subrequests/outline"
 	"github.com/moby/buildkit/frontend/subrequests/targets"
@@ -21,6 +22,7 @@ type RequestHandler struct {
 	Outline     func(context.Context) (*outline.Outline, error)
 	ListTargets func(context.Context) (*targets.List, error)
 	Lint        func(context.Context) (*lint.LintResults, error)
+	Analyze     func(context.Context) (*analyze.Report, error)
 	AllowOther  bool
 }
 
@@ -69,6 +71,18 @@ func (bc *Client) HandleSubrequest(ctx context.Context, h RequestHandler) (*clie
 			res, err := warnings.ToResult(nil)
 			return res, true, err
 		}
+	case analyze.SubrequestAnalyzeDefinition.Name:
+		if f := h.Analyze; f != nil {
+			report, err := f(ctx)
+			if err != nil {
+				return nil, false, err
+			}
+			if report == nil {
+				return nil, true, nil
+			}
+			res, err := report.ToResult()
+			return res, true, err
+		}
 	}
 	if h.AllowOther {
 		return nil, false, nil
@@ -84,6 +98,9 @@ func describe(h RequestHandler) (*client.Result, error) {
 	if h.ListTargets != nil {
 		all = append(all, targets.SubrequestsTargetsDefinition)
 	}
+	if h.Analyze != nil {
+		all = append(all, analyze.SubrequestAnalyzeDefinition)
+	}
 	all = append(all, subrequests.SubrequestsDescribeDefinition)
 	dt, err := json.MarshalIndent(all, "", "  ")
 	if err != nil {
@@ -27,10 +27,19 @@ type Frontend interface {
 	Solve(ctx context.Context, llb FrontendLLBBridge, exec executor.Executor, opt map[string]string, inputs map[string]*pb.Definition, sid string, sm *session.Manager) (*Result, error)
 }
 
+// Validator is implemented by the frontends that can check their options
+// without running, see llbsolver.Solver.Validate
+type Validator interface {
+	Validate(ctx context.Context, llb FrontendLLBBridge, opt map[string]string, sid string) error
+}
+
 type FrontendLLBBridge interface {
 	sourceresolver.MetaResolver
 	Solve(ctx context.Context, req SolveRequest, sid string) (*Result, error)
 	Warn(ctx context.Context, dgst digest.Digest, msg string, opts WarnOpts) error
+	// LogVertex reports a completed step of a frontend in the progress of
+	// the build, cached if its result was reused
+	LogVertex(ctx context.Context, name string, cached bool) error
 }
 
 type SolveRequest = gw.SolveRequest
@@ -10,6 +10,7 @@ import (
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/client/llb"
 	gateway "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/testutil/integration"
 	"github.com/moby/buildkit/util/testutil/workers"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +34,7 @@ func TestFrontendIntegration(t *testing.T) {
 		testRefReadDir,
 		testRefStatFile,
 		testRefEvaluate,
+		testSolvePartialFailure,
 		testReturnNil,
 	))
 }
@@ -342,3 +344,55 @@ func testRefEvaluate(t *testing.T, sb integration.Sandbox) {
 	_, err = c.Build(ctx, client.SolveOpt{}, "", frontend, nil)
 	require.NoError(t, err)
 }
+
+func testSolvePartialFailure(t *testing.T, sb integration.Sandbox) {
+	integration.SkipOnPlatform(t, "windows")
+	ctx := sb.Context()
+
+	c, err := client.New(ctx, sb.Address())
+	require.NoError(t, err)
+	defer c.Close()
+
+	dockerfile := `
+FROM scratch
+ARG TARGETARCH
+COPY ${TARGETARCH}.txt /
+`
+	st := llb.Scratch().File(
+		llb.Mkfile("Dockerfile", 0600, []byte(dockerfile)).
+			Mkfile("amd64.txt", 0600, []byte("amd64")))
+	def, err := st.Marshal(ctx)
+	require.NoError(t, err)
+
+	frontend := func(ctx context.Context, c gateway.Client) (*gateway.Result, error) {
+		req := gateway.SolveRequest{
+			Frontend: "dockerfile.v0",
+			FrontendOpt: map[string]string{
+				"platform": "linux/amd64,linux/arm64",
+			},
+			FrontendInputs: map[string]*pb.Definition{
+				"context":    def.ToPB(),
+				"dockerfile": def.ToPB(),
+			},
+			Evaluate: true,
+		}
+		_, err := c.Solve(ctx, req)
+		require.Error(t, err)
+
+		req.AllowPartialFailure = true
+		res, err := c.Solve(ctx, req)
+		require.NoError(t, err)
+		require.Contains(t, res.Refs, "linux/amd64")
+		require.NotContains(t, res.Refs, "linux/arm64")
+		require.Len(t, res.RefErrors, 1)
+		require.ErrorContains(t, res.RefErrors["linux/arm64"], "arm64.txt")
+
+		dt, err := res.Refs["linux/amd64"].ReadFile(ctx, gateway.ReadRequest{Filename: "amd64.txt"})
+		require.NoError(t, err)
+		require.Equal(t, "amd64", string(dt))
+		return gateway.NewResult(), nil
+	}
+
+	_, err = c.Build(ctx, client.SolveOpt{}, "", frontend, nil)
+	require.NoError(t, err)
+}
@@ -0,0 +1,302 @@
+// Package frontendtest runs frontends in the tests of their authors without a
+// daemon. The frontends build with a gateway client serving fake local
+// directories, images and inputs, which records the LLB definitions they
+// solve so that the tests can assert on them. The references are read from
+// the fake sources, the other definitions are solved by an optional backend,
+// e.g. the gateway client of a build of an embedded daemon.
+package frontendtest
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/client/llb/sourceresolver"
+	"github.com/moby/buildkit/frontend/gateway/client"
+	gwpb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+// Options are the fake sources of a client
+type Options struct {
+	// Opts are the options of the frontend, like the frontend attributes of
+	// a build
+	Opts map[string]string
+	// LocalDirs are the local directories by name, e.g. context and
+	// dockerfile for the Dockerfile frontend
+	LocalDirs map[string]fs.FS
+	// Images are the images that are resolved and read by reference
+	Images map[string]Image
+	// Inputs are the inputs of the frontend
+	Inputs map[string]llb.State
+	// Frontends are the frontends the frontend can solve with by name
+	Frontends map[string]client.BuildFunc
+	// Workers are the workers of the build, one worker of the default
+	// platform if it is empty
+	Workers []client.WorkerInfo
+	// Backend solves the definitions that are not read from the fake
+	// sources, the frontend can only read from the fake sources if it is
+	// nil
+	Backend client.Client
+	// Secrets and SSH are the IDs of the secrets and SSH agents provided by
+	// the fake client session
+	Secrets []string
+	SSH     []string
+}
+
+// Image is a fake image
+type Image struct {
+	Config ocispecs.Image
+	// Digest is the digest of the manifest, the digest of the config if it
+	// is empty
+	Digest digest.Digest
+	// FS is the root filesystem of the image
+	FS fs.FS
+}
+
+// Warning is a warning of the frontend
+type Warning struct {
+	Digest  digest.Digest
+	Message string
+	Opts    client.WarnOpts
+}
+
+// Client is a gateway client running a frontend on fake sources
+type Client struct {
+	opt Options
+
+	mu       sync.Mutex
+	solves   []client.SolveRequest
+	warnings []Warning
+}
+
+var _ client.Client = &Client{}
+
+// New returns a client with the fake sources of the options
+func New(opt Options) *Client {
+	if len(opt.Workers) == 0 {
+		opt.Workers = []client.WorkerInfo{{
+			ID:        "frontendtest",
+			Platforms: []ocispecs.Platform{platforms.Normalize(platforms.DefaultSpec())},
+		}}
+	}
+	images := make(map[string]Image, len(opt.Images))
+	for ref, img := range opt.Images {
+		images[normalizeImage(ref)] = img
+	}
+	opt.Images = images
+	return &Client{opt: opt}
+}
+
+// Run runs the frontend with the client
+func (c *Client) Run(ctx context.Context, f client.BuildFunc) (*client.Result, error) {
+	return f(ctx, c)
+}
+
+// Solves returns the requests that the frontend solved, in order
+func (c *Client) Solves() []client.SolveRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]client.SolveRequest(nil), c.solves...)
+}
+
+// Warnings returns the warnings of the frontend, in order
+func (c *Client) Warnings() []Warning {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Warning(nil), c.warnings...)
+}
+
+func (c *Client) BuildOpts() client.BuildOpts {
+	return client.BuildOpts{
+		Opts:    maps.Clone(c.opt.Opts),
+		Workers: c.opt.Workers,
+		Product: "frontendtest",
+		LLBCaps: pb.Caps.CapSet(pb.Caps.All()),
+		Caps:    gwpb.Caps.CapSet(gwpb.Caps.All()),
+	}
+}
+
+func (c *Client) Inputs(ctx context.Context) (map[string]llb.State, error) {
+	return maps.Clone(c.opt.Inputs), nil
+}
+
+func (c *Client) Solve(ctx context.Context, req client.SolveRequest) (*client.Result, error) {
+	c.mu.Lock()
+	c.solves = append(c.solves, req)
+	c.mu.Unlock()
+
+	if req.Frontend != "" {
+		return c.solveFrontend(ctx, req)
+	}
+	res := client.NewResult()
+	if req.Definition == nil || len(req.Definition.Def) == 0 {
+		return res, nil
+	}
+	if _, err := Ops(req.Definition); err != nil {
+		return nil, err
+	}
+	r := &ref{c: c, def: req.Definition}
+	if req.Evaluate {
+		if err := r.Evaluate(ctx); err != nil {
+			return nil, err
+		}
+	}
+	res.SetRef(r)
+	return res, nil
+}
+
+func (c *Client) solveFrontend(ctx context.Context, req client.SolveRequest) (*client.Result, error) {
+	f, ok := c.opt.Frontends[req.Frontend]
+	if !ok {
+		if c.opt.Backend != nil {
+			return c.opt.Backend.Solve(ctx, req)
+		}
+		return nil, errors.Errorf("frontend %s is not registered in the test", req.Frontend)
+	}
+	opt := c.opt
+	opt.Opts = req.FrontendOpt
+	opt.Inputs = make(map[string]llb.State, len(req.FrontendInputs))
+	for name, def := range req.FrontendInputs {
+		op, err := llb.NewDefinitionOp(def)
+		if err != nil {
+			return nil, err
+		}
+		opt.Inputs[name] = llb.NewState(op)
+	}
+	sub := &Client{opt: opt}
+	res, err := f(ctx, sub)
+
+	c.mu.Lock()
+	c.solves = append(c.solves, sub.solves...)
+	c.warnings = append(c.warnings, sub.warnings...)
+	c.mu.Unlock()
+	return res, err
+}
+
+func (c *Client) ResolveImageConfig(ctx context.Context, ref string, opt sourceresolver.Opt) (string, digest.Digest, []byte, error) {
+	img, ok := c.opt.Images[normalizeImage(ref)]
+	if !ok {
+		if c.opt.Backend != nil {
+			return c.opt.Backend.ResolveImageConfig(ctx, ref, opt)
+		}
+		return "", "", nil, errors.Errorf("image %s is not registered in the test", ref)
+	}
+	dt, err := json.Marshal(img.Config)
+	if err != nil {
+		return "", "", nil, errors.WithStack(err)
+	}
+	dgst := img.Digest
+	if dgst == "" {
+		dgst = digest.FromBytes(dt)
+	}
+	return ref, dgst, dt, nil
+}
+
+func (c *Client) ResolveSourceMetadata(ctx context.Context, op *pb.SourceOp, opt sourceresolver.Opt) (*sourceresolver.MetaResponse, error) {
+	scheme, ref, ok := splitIdentifier(op.Identifier)
+	if ok && scheme == "docker-image" {
+		if _, ok := c.opt.Images[normalizeImage(ref)]; ok {
+			_, dgst, dt, err := c.ResolveImageConfig(ctx, ref, opt)
+			if err != nil {
+				return nil, err
+			}
+			return &sourceresolver.MetaResponse{
+				Op: op,
+				Image: &sourceresolver.ResolveImageResponse{
+					Digest: dgst,
+					Config: dt,
+				},
+			}, nil
+		}
+	}
+	if c.opt.Backend != nil {
+		return c.opt.Backend.ResolveSourceMetadata(ctx, op, opt)
+	}
+	if ok && scheme == "docker-image" {
+		return nil, errors.Errorf("image %s is not registered in the test", ref)
+	}
+	return &sourceresolver.MetaResponse{Op: op}, nil
+}
+
+func (c *Client) NewContainer(ctx context.Context, req client.NewContainerRequest) (client.Container, error) {
+	if c.opt.Backend == nil {
+		return nil, errors.New("containers need a backend in the test")
+	}
+	for i, m := range req.Mounts {
+		if r, ok := m.Ref.(*ref); ok {
+			br, err := r.backendRef(ctx)
+			if err != nil {
+				return nil, err
+			}
+			req.Mounts[i].Ref = br
+		}
+	}
+	return c.opt.Backend.NewContainer(ctx, req)
+}
+
+func (c *Client) Warn(ctx context.Context, dgst digest.Digest, msg string, opts client.WarnOpts) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warnings = append(c.warnings, Warning{Digest: dgst, Message: msg, Opts: opts})
+	return nil
+}
+
+func (c *Client) CheckSession(ctx context.Context, req client.CheckSessionRequest) (*client.CheckSessionResponse, error) {
+	resp := &client.CheckSessionResponse{}
+	for _, id := range req.SecretIDs {
+		if !slices.Contains(c.opt.Secrets, id) {
+			resp.MissingSecretIDs = append(resp.MissingSecretIDs, id)
+		}
+	}
+	for _, id := range req.SSHIDs {
+		if !slices.Contains(c.opt.SSH, id) {
+			resp.MissingSSHIDs = append(resp.MissingSSHIDs, id)
+		}
+	}
+	return resp, nil
+}
+
+// getFS returns the fake filesystem of a source, if it has one
+func (c *Client) getFS(op *pb.SourceOp) (fs.FS, bool) {
+	scheme, name, ok := splitIdentifier(op.Identifier)
+	if !ok {
+		return nil, false
+	}
+	switch scheme {
+	case "local":
+		fsys, ok := c.opt.LocalDirs[name]
+		return fsys, ok
+	case "docker-image":
+		img, ok := c.opt.Images[normalizeImage(name)]
+		if !ok || img.FS == nil {
+			return nil, false
+		}
+		return img.FS, true
+	}
+	return nil, false
+}
+
+// normalizeImage returns the reference of an image without its digest, so
+// that the resolved references are found by the references of the test
+func normalizeImage(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ref
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		if named, err := reference.WithTag(reference.TrimNamed(named), tagged.Tag()); err == nil {
+			return named.String()
+		}
+	}
+	return reference.TagNameOnly(named).String()
+}
@@ -0,0 +1,165 @@
+package frontendtest
+
+import (
+	"context"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/moby/buildkit/client/llb"
+	dockerfile "github.com/moby/buildkit/frontend/dockerfile/builder"
+	"github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerfile(t *testing.T) {
+	c := New(Options{
+		LocalDirs: map[string]fs.FS{
+			"dockerfile": fstest.MapFS{
+				"Dockerfile": {Data: []byte("FROM alpine\nRUN echo hello\n")},
+			},
+			"context": fstest.MapFS{},
+		},
+		Images: map[string]Image{
+			"alpine": {Config: ocispecs.Image{
+				Platform: ocispecs.Platform{OS: "linux", Architecture: "amd64"},
+				Config:   ocispecs.ImageConfig{Env: []string{"PATH=/bin"}},
+				// an image without layers is built from scratch
+				RootFS: ocispecs.RootFS{Type: "layers", DiffIDs: []digest.Digest{digest.FromString("layer")}},
+			}},
+		},
+	})
+	res, err := c.Run(context.TODO(), dockerfile.Build)
+	require.NoError(t, err)
+	require.NotNil(t, res)
+
+	solves := c.Solves()
+	require.NotEmpty(t, solves)
+	def := solves[len(solves)-1].Definition
+	sources, err := Sources(def)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+	require.True(t, strings.HasPrefix(sources[0], "docker-image://docker.io/library/alpine:latest@sha256:"), sources[0])
+
+	execs, err := Execs(def)
+	require.NoError(t, err)
+	require.Len(t, execs, 1)
+	require.Equal(t, []string{"/bin/sh", "-c", "echo hello"}, execs[0].Meta.Args)
+	require.Contains(t, execs[0].Meta.Env, "PATH=/bin")
+}
+
+func TestDockerfileRequire(t *testing.T) {
+	opt := Options{
+		LocalDirs: map[string]fs.FS{
+			"dockerfile": fstest.MapFS{
+				"Dockerfile": {Data: []byte("# require=secret=aws,npmrc;ssh=default\nFROM scratch\nCOPY . /\n")},
+			},
+			"context": fstest.MapFS{},
+		},
+		Secrets: []string{"aws"},
+	}
+	_, err := New(opt).Run(context.TODO(), dockerfile.Build)
+	require.ErrorContains(t, err, `required secret "npmrc", SSH agent "default" not provided by the client`)
+
+	opt.Secrets = []string{"aws", "npmrc"}
+	opt.SSH = []string{"default"}
+	c := New(opt)
+	_, err = c.Run(context.TODO(), dockerfile.Build)
+	require.NoError(t, err)
+	require.NotEmpty(t, c.Solves())
+}
+
+func TestReadSources(t *testing.T) {
+	c := New(Options{
+		Opts: map[string]string{"target": "foo"},
+		LocalDirs: map[string]fs.FS{
+			"context": fstest.MapFS{
+				"a.txt":     {Data: []byte("abcdef")},
+				"b.txt":     {Data: []byte("b")},
+				"sub/c.dat": {Data: []byte("c")},
+			},
+		},
+		Images: map[string]Image{
+			"docker.io/library/busybox:latest": {FS: fstest.MapFS{
+				"etc/os-release": {Data: []byte("busybox")},
+			}},
+		},
+	})
+	_, err := c.Run(context.TODO(), func(ctx context.Context, c client.Client) (*client.Result, error) {
+		require.Equal(t, "foo", c.BuildOpts().Opts["target"])
+
+		ref := solve(ctx, t, c, llb.Local("context"))
+		dt, err := ref.ReadFile(ctx, client.ReadRequest{Filename: "/a.txt", Range: &client.FileRange{Offset: 2, Length: 3}})
+		require.NoError(t, err)
+		require.Equal(t, "cde", string(dt))
+
+		entries, err := ref.ReadDir(ctx, client.ReadDirRequest{Path: "/", IncludePattern: "*.txt"})
+		require.NoError(t, err)
+		require.Len(t, entries, 2)
+		require.Equal(t, "a.txt", entries[0].Path)
+		require.Equal(t, "b.txt", entries[1].Path)
+
+		st, err := ref.StatFile(ctx, client.StatRequest{Path: "sub/c.dat"})
+		require.NoError(t, err)
+		require.Equal(t, int64(1), st.Size)
+
+		ref = solve(ctx, t, c, llb.Image("busybox"))
+		dt, err = ref.ReadFile(ctx, client.ReadRequest{Filename: "etc/os-release"})
+		require.NoError(t, err)
+		require.Equal(t, "busybox", string(dt))
+
+		// reading an exec needs a backend
+		ref = solve(ctx, t, c, llb.Image("busybox").Run(llb.Shlex("true")).Root())
+		_, err = ref.ReadFile(ctx, client.ReadRequest{Filename: "etc/os-release"})
+		require.ErrorContains(t, err, "needs a backend")
+		return nil, nil
+	})
+	require.NoError(t, err)
+	require.Len(t, c.Solves(), 3)
+}
+
+func TestSubFrontend(t *testing.T) {
+	c := New(Options{
+		Frontends: map[string]client.BuildFunc{
+			"sub": func(ctx context.Context, c client.Client) (*client.Result, error) {
+				inputs, err := c.Inputs(ctx)
+				require.NoError(t, err)
+				require.Contains(t, inputs, "context")
+				require.NoError(t, c.Warn(ctx, "", "sub warning", client.WarnOpts{}))
+				def, err := inputs["context"].Marshal(ctx)
+				require.NoError(t, err)
+				return c.Solve(ctx, client.SolveRequest{Definition: def.ToPB()})
+			},
+		},
+	})
+	_, err := c.Run(context.TODO(), func(ctx context.Context, c client.Client) (*client.Result, error) {
+		def, err := llb.Scratch().File(llb.Mkdir("/foo", 0755)).Marshal(ctx)
+		require.NoError(t, err)
+		return c.Solve(ctx, client.SolveRequest{
+			Frontend:       "sub",
+			FrontendInputs: map[string]*pb.Definition{"context": def.ToPB()},
+		})
+	})
+	require.NoError(t, err)
+	require.Len(t, c.Solves(), 2)
+	require.Equal(t, "sub", c.Solves()[0].Frontend)
+	require.Len(t, c.Warnings(), 1)
+	require.Equal(t, "sub warning", c.Warnings()[0].Message)
+
+	_, err = c.Solve(context.TODO(), client.SolveRequest{Frontend: "unknown"})
+	require.ErrorContains(t, err, "not registered")
+}
+
+func solve(ctx context.Context, t *testing.T, c client.Client, st llb.State) client.Reference {
+	def, err := st.Marshal(ctx)
+	require.NoError(t, err)
+	res, err := c.Solve(ctx, client.SolveRequest{Definition: def.ToPB(), Evaluate: true})
+	require.NoError(t, err)
+	ref, err := res.SingleRef()
+	require.NoError(t, err)
+	return ref
+}
@@ -0,0 +1,87 @@
+package frontendtest
+
+import (
+	"strings"
+
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// Ops returns the ops of a definition in the order of the definition, which
+// has the inputs of an op before it. The terminal op of the definition is
+// not returned.
+func Ops(def *pb.Definition) ([]*pb.Op, error) {
+	var ops []*pb.Op
+	for i, dt := range def.GetDef() {
+		var op pb.Op
+		if err := op.UnmarshalVT(dt); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse op %d of the definition", i)
+		}
+		if op.Op == nil {
+			continue
+		}
+		ops = append(ops, &op)
+	}
+	return ops, nil
+}
+
+// Sources returns the identifiers of the sources of a definition, e.g.
+// docker-image://docker.io/library/alpine:latest or local://context
+func Sources(def *pb.Definition) ([]string, error) {
+	ops, err := Ops(def)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, op := range ops {
+		if src := op.GetSource(); src != nil {
+			out = append(out, src.Identifier)
+		}
+	}
+	return out, nil
+}
+
+// Execs returns the exec ops of a definition
+func Execs(def *pb.Definition) ([]*pb.ExecOp, error) {
+	ops, err := Ops(def)
+	if err != nil {
+		return nil, err
+	}
+	var out []*pb.ExecOp
+	for _, op := range ops {
+		if exec := op.GetExec(); exec != nil {
+			out = append(out, exec)
+		}
+	}
+	return out, nil
+}
+
+// rootOp returns the op of the result of a definition
+func rootOp(def *pb.Definition) (*pb.Op, error) {
+	if len(def.GetDef()) == 0 {
+		return nil, errors.New("empty definition")
+	}
+	ops := make(map[digest.Digest]*pb.Op, len(def.Def))
+	var last *pb.Op
+	for i, dt := range def.Def {
+		var op pb.Op
+		if err := op.UnmarshalVT(dt); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse op %d of the definition", i)
+		}
+		ops[digest.FromBytes(dt)] = &op
+		last = &op
+	}
+	if last.Op != nil || len(last.Inputs) != 1 {
+		return nil, errors.New("definition without a terminal op")
+	}
+	op, ok := ops[digest.Digest(last.Inputs[0].Digest)]
+	if !ok {
+		return nil, errors.Errorf("missing op %s of the definition", last.Inputs[0].Digest)
+	}
+	return op, nil
+}
+
+func splitIdentifier(id string) (string, string, bool) {
+	return strings.Cut(id, "://")
+}
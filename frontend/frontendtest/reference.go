@@ -0,0 +1,205 @@
+package frontendtest
+
+import (
+	"context"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/pkg/errors"
+	fstypes "github.com/tonistiigi/fsutil/types"
+)
+
+// ref is a reference to a solved definition. It is read from the fake
+// filesystem of its source if it is a source with one, and from the
+// reference of the backend otherwise.
+type ref struct {
+	c   *Client
+	def *pb.Definition
+
+	mu      sync.Mutex
+	backend client.Reference
+}
+
+var _ client.Reference = &ref{}
+
+func (r *ref) ToState() (llb.State, error) {
+	op, err := llb.NewDefinitionOp(r.def)
+	if err != nil {
+		return llb.State{}, err
+	}
+	return llb.NewState(op), nil
+}
+
+func (r *ref) Evaluate(ctx context.Context) error {
+	if _, ok, err := r.fs(); err != nil || ok {
+		return err
+	}
+	if r.c.opt.Backend == nil {
+		// without a backend the definition is only validated by Solve
+		return nil
+	}
+	br, err := r.backendRef(ctx)
+	if err != nil {
+		return err
+	}
+	return br.Evaluate(ctx)
+}
+
+func (r *ref) ReadFile(ctx context.Context, req client.ReadRequest) ([]byte, error) {
+	fsys, ok, err := r.fs()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		br, err := r.backendRef(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return br.ReadFile(ctx, req)
+	}
+	dt, err := fs.ReadFile(fsys, cleanPath(req.Filename))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if req.Range != nil {
+		start := min(req.Range.Offset, len(dt))
+		end := min(start+req.Range.Length, len(dt))
+		dt = dt[start:end]
+	}
+	return dt, nil
+}
+
+func (r *ref) StatFile(ctx context.Context, req client.StatRequest) (*fstypes.Stat, error) {
+	fsys, ok, err := r.fs()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		br, err := r.backendRef(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return br.StatFile(ctx, req)
+	}
+	p := cleanPath(req.Path)
+	fi, err := fs.Stat(fsys, p)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return toStat(p, fi), nil
+}
+
+func (r *ref) ReadDir(ctx context.Context, req client.ReadDirRequest) ([]*fstypes.Stat, error) {
+	fsys, ok, err := r.fs()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		br, err := r.backendRef(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return br.ReadDir(ctx, req)
+	}
+	entries, err := fs.ReadDir(fsys, cleanPath(req.Path))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var out []*fstypes.Stat
+	for _, e := range entries {
+		if req.IncludePattern != "" {
+			if ok, err := path.Match(req.IncludePattern, e.Name()); err != nil {
+				return nil, errors.WithStack(err)
+			} else if !ok {
+				continue
+			}
+		}
+		fi, err := e.Info()
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		out = append(out, toStat(e.Name(), fi))
+	}
+	return out, nil
+}
+
+// fs returns the fake filesystem of the source of the definition, if it is
+// a source with one
+func (r *ref) fs() (fs.FS, bool, error) {
+	op, err := rootOp(r.def)
+	if err != nil {
+		return nil, false, err
+	}
+	src := op.GetSource()
+	if src == nil {
+		return nil, false, nil
+	}
+	fsys, ok := r.c.getFS(src)
+	return fsys, ok, nil
+}
+
+// backendRef solves the definition with the backend
+func (r *ref) backendRef(ctx context.Context) (client.Reference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.backend != nil {
+		return r.backend, nil
+	}
+	if r.c.opt.Backend == nil {
+		op, err := rootOp(r.def)
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.Errorf("reading the result of %s needs a backend in the test", describeOp(op))
+	}
+	res, err := r.c.opt.Backend.Solve(ctx, client.SolveRequest{Definition: r.def})
+	if err != nil {
+		return nil, err
+	}
+	br, err := res.SingleRef()
+	if err != nil {
+		return nil, err
+	}
+	r.backend = br
+	return br, nil
+}
+
+func describeOp(op *pb.Op) string {
+	switch op := op.Op.(type) {
+	case *pb.Op_Source:
+		return op.Source.Identifier
+	case *pb.Op_Exec:
+		return "exec " + strings.Join(op.Exec.Meta.GetArgs(), " ")
+	case *pb.Op_File:
+		return "file op"
+	case *pb.Op_Merge:
+		return "merge op"
+	case *pb.Op_Diff:
+		return "diff op"
+	case *pb.Op_Build:
+		return "build op"
+	}
+	return "unknown op"
+}
+
+func toStat(p string, fi fs.FileInfo) *fstypes.Stat {
+	return &fstypes.Stat{
+		Path:    p,
+		Mode:    uint32(fi.Mode()),
+		Size:    fi.Size(),
+		ModTime: fi.ModTime().UnixNano(),
+	}
+}
+
+func cleanPath(p string) string {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" {
+		return "."
+	}
+	return p
+}
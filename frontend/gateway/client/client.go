@@ -34,6 +34,23 @@ type Client interface {
 	Inputs(ctx context.Context) (map[string]llb.State, error)
 	NewContainer(ctx context.Context, req NewContainerRequest) (Container, error)
 	Warn(ctx context.Context, dgst digest.Digest, msg string, opts WarnOpts) error
+	// CheckSession returns the secrets and SSH agents of req that are not
+	// provided by the client session
+	CheckSession(ctx context.Context, req CheckSessionRequest) (*CheckSessionResponse, error)
+}
+
+// CheckSessionRequest lists the secrets and SSH agents that a build requires
+// from the client session
+type CheckSessionRequest struct {
+	SecretIDs []string
+	SSHIDs    []string
+}
+
+// CheckSessionResponse lists the secrets and SSH agents of a request that
+// are not provided by the client session
+type CheckSessionResponse struct {
+	MissingSecretIDs []string
+	MissingSSHIDs    []string
 }
 
 // NewContainerRequest encapsulates the requirements for a client to define a
@@ -134,6 +151,9 @@ type SolveRequest struct {
 	FrontendInputs map[string]*pb.Definition
 	CacheImports   []CacheOptionsEntry
 	SourcePolicies []*spb.Policy
+	// AllowPartialFailure returns the errors of the refs of an evaluated
+	// multi-ref result in Result.RefErrors instead of failing the solve
+	AllowPartialFailure bool
 }
 
 type CacheOptionsEntry struct {
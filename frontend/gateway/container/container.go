@@ -13,6 +13,7 @@ import (
 
 	"github.com/moby/buildkit/session/secrets"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/entitlements"
 	"github.com/moby/buildkit/util/system"
 
 	"github.com/moby/buildkit/cache"
@@ -38,6 +39,13 @@ type NewContainerRequest struct {
 	Constraints *opspb.WorkerConstraints
 }
 
+// MountValidator is implemented by the executors of the builds that check
+// the entitlements required by the mounts of the containers. The mounts that
+// require entitlements are denied if the executor doesn't implement it.
+type MountValidator interface {
+	ValidateMounts(mnts []*opspb.Mount) error
+}
+
 // Mount used for the gateway.Container is nearly identical to the client.Mount
 // except is has a RefProxy instead of Ref to allow for a common abstraction
 // between gateway clients.
@@ -84,6 +92,10 @@ func NewContainer(ctx context.Context, cm cache.Manager, exec executor.Executor,
 		}
 	}
 
+	if err := validateMounts(exec, mnts); err != nil {
+		return nil, err
+	}
+
 	name := fmt.Sprintf("container %s", req.ContainerID)
 	mm := mounts.NewMountManager(name, cm, sm)
 	p, err := PrepareMounts(ctx, mm, cm, g, "", mnts, refs, func(m *opspb.Mount, ref cache.ImmutableRef) (cache.MutableRef, error) {
@@ -118,6 +130,21 @@ func NewContainer(ctx context.Context, cm cache.Manager, exec executor.Executor,
 	return ctr, nil
 }
 
+func validateMounts(exec executor.Executor, mnts []*opspb.Mount) error {
+	if mv, ok := exec.(MountValidator); ok {
+		return mv.ValidateMounts(mnts)
+	}
+	for _, m := range mnts {
+		switch m.MountType {
+		case opspb.MountType_LOCALEXEC:
+			return errors.Errorf("%s is not allowed", entitlements.EntitlementLocalExec)
+		case opspb.MountType_HOSTBIND:
+			return errors.Errorf("%s is not allowed", entitlements.EntitlementMountHost)
+		}
+	}
+	return nil
+}
+
 type PreparedMounts struct {
 	Root           executor.Mount
 	ReadonlyRootFS bool
@@ -233,6 +260,21 @@ func PrepareMounts(ctx context.Context, mm *mounts.MountManager, cm cache.Manage
 			if mountable == nil {
 				continue
 			}
+		case opspb.MountType_HOSTBIND:
+			var err error
+			mountable, err = mm.MountableHostBind(m)
+			if err != nil {
+				return p, err
+			}
+		case opspb.MountType_LOCALEXEC:
+			var err error
+			mountable, err = mm.MountableLocalExec(ctx, m, g)
+			if err != nil {
+				return p, err
+			}
+			if mountable == nil {
+				continue
+			}
 
 		default:
 			return p, errors.Errorf("mount type %s not implemented", m.MountType)
@@ -0,0 +1,95 @@
+package container
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/moby/buildkit/executor"
+	resourcestypes "github.com/moby/buildkit/executor/resources/types"
+	opspb "github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/entitlements"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewContainerMountHost(t *testing.T) {
+	t.Parallel()
+
+	req := NewContainerRequest{
+		ContainerID: "test",
+		Mounts: []Mount{{
+			Mount: &opspb.Mount{
+				Dest:        "/host",
+				MountType:   opspb.MountType_HOSTBIND,
+				Readonly:    true,
+				Output:      int64(opspb.SkipOutput),
+				HostBindOpt: &opspb.HostBindOpt{Path: "/tmp"},
+			},
+		}},
+	}
+
+	_, err := NewContainer(context.TODO(), nil, &testExecutor{}, nil, nil, req)
+	require.ErrorContains(t, err, "mount.host is not allowed")
+
+	exec := &testValidatorExecutor{ent: entitlements.Set{}}
+	_, err = NewContainer(context.TODO(), nil, exec, nil, nil, req)
+	require.ErrorContains(t, err, "mount.host is not allowed")
+
+	exec.ent = entitlements.Set{entitlements.EntitlementMountHost: nil}
+	ctr, err := NewContainer(context.TODO(), nil, exec, nil, nil, req)
+	require.NoError(t, err)
+	require.Len(t, ctr.(*gatewayContainer).mounts, 1)
+	require.NoError(t, ctr.Release(context.TODO()))
+}
+
+func TestNewContainerLocalExec(t *testing.T) {
+	t.Parallel()
+
+	req := NewContainerRequest{
+		ContainerID: "test",
+		Mounts: []Mount{{
+			Mount: &opspb.Mount{
+				Dest:         "/run/signature",
+				MountType:    opspb.MountType_LOCALEXEC,
+				Output:       int64(opspb.SkipOutput),
+				LocalExecOpt: &opspb.LocalExecOpt{ID: "sign"},
+			},
+		}},
+	}
+
+	_, err := NewContainer(context.TODO(), nil, &testExecutor{}, nil, nil, req)
+	require.ErrorContains(t, err, "local.exec is not allowed")
+
+	// the command is not run on the client without the entitlement
+	exec := &testValidatorExecutor{ent: entitlements.Set{entitlements.EntitlementMountHost: nil}}
+	_, err = NewContainer(context.TODO(), nil, exec, nil, nil, req)
+	require.ErrorContains(t, err, "local.exec is not allowed")
+}
+
+type testExecutor struct{}
+
+func (*testExecutor) Run(context.Context, string, executor.Mount, []executor.Mount, executor.ProcessInfo, chan<- struct{}) (resourcestypes.Recorder, error) {
+	return nil, nil
+}
+
+func (*testExecutor) Exec(context.Context, string, executor.ProcessInfo) error {
+	return nil
+}
+
+// testValidatorExecutor checks the mounts of the containers like the
+// executors of the builds
+type testValidatorExecutor struct {
+	testExecutor
+	ent entitlements.Set
+}
+
+func (e *testValidatorExecutor) ValidateMounts(mnts []*opspb.Mount) error {
+	return e.ent.Check(entitlements.Values{
+		LocalExec: slices.ContainsFunc(mnts, func(m *opspb.Mount) bool {
+			return m.MountType == opspb.MountType_LOCALEXEC
+		}),
+		MountHost: slices.ContainsFunc(mnts, func(m *opspb.Mount) bool {
+			return m.MountType == opspb.MountType_HOSTBIND
+		}),
+	})
+}
@@ -84,6 +84,12 @@ func (c *BridgeClient) Solve(ctx context.Context, req client.SolveRequest) (*cli
 	if err != nil {
 		return nil, err
 	}
+	if len(res.RefErrors) > 0 {
+		cRes.RefErrors = make(map[string]error, len(res.RefErrors))
+		for k, err := range res.RefErrors {
+			cRes.RefErrors[k] = c.wrapSolveError(err)
+		}
+	}
 
 	return cRes, nil
 }
@@ -132,12 +138,13 @@ func (c *BridgeClient) Inputs(ctx context.Context) (map[string]llb.State, error)
 
 func (c *BridgeClient) wrapSolveError(solveErr error) error {
 	var (
-		ee       *llberrdefs.ExecError
-		fae      *llberrdefs.FileActionError
-		sce      *solver.SlowCacheError
-		inputIDs []string
-		mountIDs []string
-		subject  errdefs.IsSolve_Subject
+		ee          *llberrdefs.ExecError
+		fae         *llberrdefs.FileActionError
+		sce         *solver.SlowCacheError
+		inputIDs    []string
+		mountIDs    []string
+		artifactsID string
+		subject     errdefs.IsSolve_Subject
 	)
 	if errors.As(solveErr, &ee) {
 		var err error
@@ -149,6 +156,13 @@ func (c *BridgeClient) wrapSolveError(solveErr error) error {
 		if err != nil {
 			return err
 		}
+		if ee.Artifacts != nil {
+			ids, err := c.registerResultIDs(ee.Artifacts)
+			if err != nil {
+				return err
+			}
+			artifactsID = ids[0]
+		}
 	}
 	if errors.As(solveErr, &fae) {
 		subject = fae.ToSubject()
@@ -161,7 +175,11 @@ func (c *BridgeClient) wrapSolveError(solveErr error) error {
 		}
 		subject = sce.ToSubject()
 	}
-	return errdefs.WithSolveError(solveErr, subject, inputIDs, mountIDs)
+	err := errdefs.WithSolveError(solveErr, subject, inputIDs, mountIDs)
+	if se, ok := err.(*errdefs.SolveError); ok {
+		se.ArtifactsID = artifactsID
+	}
+	return err
 }
 
 func (c *BridgeClient) registerResultIDs(results ...solver.Result) (ids []string, err error) {
@@ -233,6 +251,10 @@ func (c *BridgeClient) Warn(ctx context.Context, dgst digest.Digest, msg string,
 	return c.FrontendLLBBridge.Warn(ctx, dgst, msg, opts)
 }
 
+func (c *BridgeClient) CheckSession(ctx context.Context, req client.CheckSessionRequest) (*client.CheckSessionResponse, error) {
+	return CheckSession(ctx, c.sm, session.NewGroup(c.sid), req)
+}
+
 func (c *BridgeClient) NewContainer(ctx context.Context, req client.NewContainerRequest) (client.Container, error) {
 	ctrReq := container.NewContainerRequest{
 		ContainerID: identity.NewID(),
@@ -0,0 +1,38 @@
+package forwarder
+
+import (
+	"context"
+
+	"github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/moby/buildkit/session/sshforward"
+	"github.com/pkg/errors"
+)
+
+// CheckSession returns the secrets and SSH agents of req that are not
+// provided by any session of the group
+func CheckSession(ctx context.Context, sm *session.Manager, g session.Group, req client.CheckSessionRequest) (*client.CheckSessionResponse, error) {
+	resp := &client.CheckSessionResponse{}
+	for _, id := range req.SecretIDs {
+		if _, err := secrets.GetSecretFromGroup(ctx, sm, g, id); err != nil {
+			if !errors.Is(err, secrets.ErrNotFound) {
+				return nil, err
+			}
+			resp.MissingSecretIDs = append(resp.MissingSecretIDs, id)
+		}
+	}
+	for _, id := range req.SSHIDs {
+		err := sm.Any(ctx, g, func(ctx context.Context, _ string, c session.Caller) error {
+			return sshforward.CheckSSHID(ctx, c, id)
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, context.Cause(ctx)
+			}
+			// the providers don't return a specific error for the unset IDs
+			resp.MissingSSHIDs = append(resp.MissingSSHIDs, id)
+		}
+	}
+	return resp, nil
+}
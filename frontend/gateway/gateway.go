@@ -9,6 +9,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
@@ -60,13 +61,46 @@ import (
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 const (
 	keyDevel = "gateway-devel"
 )
 
-func NewGatewayFrontend(workers worker.Infos, allowedRepositories []string) (frontend.Frontend, error) {
+// SourcePinning pins the frontend images of the gateway sources, like the
+// images of the syntax directives of the Dockerfiles, to digests
+type SourcePinning struct {
+	// RequireDigest rejects the sources that are not pinned by digest after
+	// the channels are resolved
+	RequireDigest bool
+	// Channels maps tagged references, like "docker/dockerfile:labs", to
+	// the vetted digests that the sources with the tag are pinned to
+	Channels map[string]digest.Digest
+}
+
+// Grant is the access granted to the containers of the frontends of a
+// repository. The frontends run without network, without the named contexts
+// read from the client and without capabilities if they are not granted.
+type Grant struct {
+	// Network runs the frontends with the network of the worker
+	Network bool
+	// LocalContexts allows the named contexts read from the client, the
+	// "local:" and "oci-layout://" contexts, to be passed to the frontends
+	LocalContexts bool
+	// Capabilities are the Linux capabilities kept by the frontends, like
+	// "CAP_CHOWN", among the ones of the sandbox
+	Capabilities []string
+}
+
+// defaultGrants are the grants of the official Dockerfile frontends, that
+// can be replaced by the grants of the repositories in the config
+var defaultGrants = map[string]Grant{
+	"docker.io/docker/dockerfile":          {Network: true, LocalContexts: true},
+	"docker.io/docker/dockerfile-upstream": {Network: true, LocalContexts: true},
+}
+
+func NewGatewayFrontend(workers worker.Infos, allowedRepositories []string, pinning SourcePinning, grants map[string]Grant) (frontend.Frontend, error) {
 	var parsedAllowedRepositories []string
 
 	for _, allowedRepository := range allowedRepositories {
@@ -77,15 +111,64 @@ func NewGatewayFrontend(workers worker.Infos, allowedRepositories []string) (fro
 		parsedAllowedRepositories = append(parsedAllowedRepositories, reference.TrimNamed(sourceRef).Name())
 	}
 
+	channels := make(map[string]digest.Digest, len(pinning.Channels))
+	for channel, dgst := range pinning.Channels {
+		ref, err := reference.ParseNormalizedNamed(channel)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid gateway channel %q", channel)
+		}
+		if _, ok := ref.(reference.Digested); ok {
+			return nil, errors.Errorf("invalid gateway channel %q, must not have a digest", channel)
+		}
+		if err := dgst.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "invalid digest of gateway channel %q", channel)
+		}
+		channels[reference.TagNameOnly(ref).String()] = dgst
+	}
+
+	parsedGrants := maps.Clone(defaultGrants)
+	for repo, grant := range grants {
+		ref, err := reference.ParseNormalizedNamed(repo)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid gateway grant repository %q", repo)
+		}
+		if !reference.IsNameOnly(ref) {
+			return nil, errors.Errorf("invalid gateway grant repository %q, must not have a tag or digest", repo)
+		}
+		caps := make([]string, 0, len(grant.Capabilities))
+		for _, c := range grant.Capabilities {
+			c = strings.ToUpper(c)
+			if !strings.HasPrefix(c, "CAP_") {
+				c = "CAP_" + c
+			}
+			if !capabilityRe.MatchString(c) {
+				return nil, errors.Errorf("invalid capability %q granted to %s", c, repo)
+			}
+			caps = append(caps, c)
+		}
+		grant.Capabilities = caps
+		parsedGrants[ref.Name()] = grant
+	}
+
 	return &gatewayFrontend{
 		workers:             workers,
 		allowedRepositories: parsedAllowedRepositories,
+		requireDigest:       pinning.RequireDigest,
+		channels:            channels,
+		grants:              parsedGrants,
+		memo:                newMemoCache(),
 	}, nil
 }
 
+var capabilityRe = regexp.MustCompile(`^CAP_[A-Z_]+$`)
+
 type gatewayFrontend struct {
 	workers             worker.Infos
 	allowedRepositories []string
+	requireDigest       bool
+	channels            map[string]digest.Digest
+	grants              map[string]Grant
+	memo                *memoCache
 }
 
 func filterPrefix(opts map[string]string, pfx string) map[string]string {
@@ -120,6 +203,102 @@ func (gf *gatewayFrontend) checkSourceIsAllowed(source string) error {
 	return errors.Errorf("'%s' is not an allowed gateway source", source)
 }
 
+// pinSource returns the source pinned to the digest of its channel. It
+// returns an error if the source is not pinned by digest and the digests are
+// required.
+func (gf *gatewayFrontend) pinSource(source string) (string, error) {
+	if !gf.requireDigest && len(gf.channels) == 0 {
+		return source, nil
+	}
+	sourceRef, err := reference.ParseNormalizedNamed(source)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := sourceRef.(reference.Digested); ok {
+		return source, nil
+	}
+	tagged := reference.TagNameOnly(sourceRef)
+	if dgst, ok := gf.channels[tagged.String()]; ok {
+		pinned, err := reference.WithDigest(tagged, dgst)
+		if err != nil {
+			return "", err
+		}
+		return pinned.String(), nil
+	}
+	if gf.requireDigest {
+		return "", errors.Errorf("gateway source %q is not pinned by digest, use %s@sha256:<digest>", source, source)
+	}
+	return source, nil
+}
+
+// grant returns the access granted to the frontends of the repository of
+// source
+func (gf *gatewayFrontend) grant(source string) (Grant, error) {
+	sourceRef, err := reference.ParseNormalizedNamed(source)
+	if err != nil {
+		return Grant{}, err
+	}
+	return gf.grants[sourceRef.Name()], nil
+}
+
+// checkNamedContexts returns an error if the named contexts read from the
+// client are passed to a frontend that is not granted access to them. The
+// context replacing the source of the frontend is loaded by the gateway and
+// is always allowed.
+func checkNamedContexts(source string, grant Grant, opts map[string]string) error {
+	if grant.LocalContexts {
+		return nil
+	}
+	for k, v := range opts {
+		name, ok := strings.CutPrefix(k, "context:")
+		if !ok || name == source {
+			continue
+		}
+		if strings.HasPrefix(v, "local:") || strings.HasPrefix(v, "oci-layout:") {
+			return errors.Errorf("gateway source %q is not granted access to the local named context %q", source, name)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the source of the frontend is allowed, that it is
+// granted the named contexts of opts, and resolves its image config. The
+// source of a development frontend and the sources replaced by a named
+// context are not resolved.
+func (gf *gatewayFrontend) Validate(ctx context.Context, llbBridge frontend.FrontendLLBBridge, opts map[string]string, sid string) error {
+	source, ok := opts[frontend.KeySource]
+	if !ok {
+		return errors.Errorf("no source specified for gateway")
+	}
+	if err := gf.checkSourceIsAllowed(source); err != nil {
+		return err
+	}
+	grant, err := gf.grant(source)
+	if err != nil {
+		return err
+	}
+	if err := checkNamedContexts(source, grant, opts); err != nil {
+		return err
+	}
+	if _, isDevel := opts[keyDevel]; isDevel {
+		return nil
+	}
+	pinned, err := gf.pinSource(source)
+	if err != nil {
+		return err
+	}
+	if _, ok := opts["context:"+source]; ok {
+		return nil
+	}
+	sourceRef, err := reference.ParseNormalizedNamed(pinned)
+	if err != nil {
+		return err
+	}
+	imr := sourceresolver.NewImageMetaResolver(llbBridge)
+	_, _, _, err = imr.ResolveImageConfig(ctx, reference.TagNameOnly(sourceRef).String(), sourceresolver.Opt{})
+	return err
+}
+
 func (gf *gatewayFrontend) Solve(ctx context.Context, llbBridge frontend.FrontendLLBBridge, exec executor.Executor, opts map[string]string, inputs map[string]*opspb.Definition, sid string, sm *session.Manager) (*frontend.Result, error) {
 	source, ok := opts[frontend.KeySource]
 	if !ok {
@@ -133,11 +312,25 @@ func (gf *gatewayFrontend) Solve(ctx context.Context, llbBridge frontend.Fronten
 	var readonly bool // TODO: try to switch to read-only by default.
 
 	var frontendDef *opspb.Definition
+	var memoKey digest.Digest
 
 	err := gf.checkSourceIsAllowed(source)
 	if err != nil {
 		return nil, err
 	}
+	grant, err := gf.grant(source)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNamedContexts(source, grant, opts); err != nil {
+		return nil, err
+	}
+	pinned := source
+	if !isDevel {
+		if pinned, err = gf.pinSource(source); err != nil {
+			return nil, err
+		}
+	}
 
 	if isDevel {
 		devRes, err := llbBridge.Solve(ctx,
@@ -207,7 +400,7 @@ func (gf *gatewayFrontend) Solve(ctx context.Context, llbBridge frontend.Fronten
 			}
 		}
 		if st == nil {
-			sourceRef, err := reference.ParseNormalizedNamed(source)
+			sourceRef, err := reference.ParseNormalizedNamed(pinned)
 			if err != nil {
 				return nil, err
 			}
@@ -240,6 +433,22 @@ func (gf *gatewayFrontend) Solve(ctx context.Context, llbBridge frontend.Fronten
 			st = &src
 		}
 
+		// the frontend container doesn't run if its result for the same
+		// opts and the same files read from the build context is memoized
+		memoKey, err = gf.memoKey(mfstDigest, opts, inputs)
+		if err != nil {
+			return nil, err
+		}
+		if memoKey != "" {
+			res, ok := gf.loadMemo(ctx, memoKey, llbBridge, exec, inputs, sid, sm)
+			if err := llbBridge.LogVertex(ctx, "[internal] memoized result of frontend "+source, ok); err != nil {
+				return nil, err
+			}
+			if ok {
+				return res, nil
+			}
+		}
+
 		def, err := st.Marshal(ctx)
 		if err != nil {
 			return nil, err
@@ -310,8 +519,13 @@ func (gf *gatewayFrontend) Solve(ctx context.Context, llbBridge frontend.Fronten
 		Cwd:                       cwd,
 		ReadonlyRootFS:            readonly,
 		RemoveMountStubsRecursive: true,
+		RestrictCapabilities:      true,
+		Capabilities:              grant.Capabilities,
 	}
 
+	if !grant.Network {
+		meta.NetMode = opspb.NetMode_NONE
+	}
 	if v, ok := img.Config.Labels["moby.buildkit.frontend.network.none"]; ok {
 		if ok, _ := strconv.ParseBool(v); ok {
 			meta.NetMode = opspb.NetMode_NONE
@@ -331,7 +545,11 @@ func (gf *gatewayFrontend) Solve(ctx context.Context, llbBridge frontend.Fronten
 		}
 	}
 
-	lbf, ctx := serveLLBBridgeForwarder(ctx, llbBridge, exec, gf.workers, inputs, sid, sm)
+	var memo *memoRecorder
+	if memoKey != "" {
+		memo = &memoRecorder{}
+	}
+	lbf, ctx := serveLLBBridgeForwarder(ctx, llbBridge, exec, gf.workers, inputs, sid, sm, memo)
 	defer lbf.conn.Close()
 	defer lbf.Discard()
 
@@ -363,7 +581,11 @@ func (gf *gatewayFrontend) Solve(ctx context.Context, llbBridge frontend.Fronten
 		lbf.mu.Unlock()
 	}
 
-	return lbf.Result()
+	res, err := lbf.Result()
+	if err == nil && memo != nil {
+		gf.saveMemo(memoKey, memo, sid, res)
+	}
+	return res, err
 }
 
 func metadataMount(def *opspb.Definition) (*executor.Mount, func(), error) {
@@ -499,9 +721,10 @@ func newBridgeForwarder(ctx context.Context, llbBridge frontend.FrontendLLBBridg
 	return lbf
 }
 
-func serveLLBBridgeForwarder(ctx context.Context, llbBridge frontend.FrontendLLBBridge, exec executor.Executor, workers worker.Infos, inputs map[string]*opspb.Definition, sid string, sm *session.Manager) (*llbBridgeForwarder, context.Context) {
+func serveLLBBridgeForwarder(ctx context.Context, llbBridge frontend.FrontendLLBBridge, exec executor.Executor, workers worker.Infos, inputs map[string]*opspb.Definition, sid string, sm *session.Manager, memo *memoRecorder) (*llbBridgeForwarder, context.Context) {
 	ctx, cancel := context.WithCancelCause(ctx)
 	lbf := newBridgeForwarder(ctx, llbBridge, exec, workers, inputs, sid, sm)
+	lbf.memo = memo
 	serverOpt := []grpc.ServerOption{
 		grpc.UnaryInterceptor(grpcerrors.UnaryServerInterceptor),
 		grpc.StreamInterceptor(grpcerrors.StreamServerInterceptor),
@@ -609,6 +832,9 @@ type llbBridgeForwarder struct {
 	*pipe
 	ctrs   map[string]gwclient.Container
 	ctrsMu sync.Mutex
+	// memo records the requests of the frontend for its result to be
+	// memoized, it is nil if the result is not memoized
+	memo *memoRecorder
 }
 
 func (lbf *llbBridgeForwarder) ResolveSourceMeta(ctx context.Context, req *pb.ResolveSourceMetaRequest) (*pb.ResolveSourceMetaResponse, error) {
@@ -650,6 +876,9 @@ func (lbf *llbBridgeForwarder) ResolveSourceMeta(ctx context.Context, req *pb.Re
 			Config: resp.Image.Config,
 		}
 	}
+	lbf.memo.addDep(lbf, "", r, nil, func(ctx context.Context, lbf *llbBridgeForwarder, _ string) (proto.Message, error) {
+		return lbf.ResolveSourceMeta(ctx, req.CloneVT())
+	})
 	return r, nil
 }
 
@@ -688,21 +917,26 @@ func (lbf *llbBridgeForwarder) ResolveImageConfig(ctx context.Context, req *pb.R
 	if err != nil {
 		return nil, err
 	}
-	return &pb.ResolveImageConfigResponse{
+	resp := &pb.ResolveImageConfigResponse{
 		Ref:    ref,
 		Digest: string(dgst),
 		Config: dt,
-	}, nil
+	}
+	lbf.memo.addDep(lbf, "", resp, nil, func(ctx context.Context, lbf *llbBridgeForwarder, _ string) (proto.Message, error) {
+		return lbf.ResolveImageConfig(ctx, req.CloneVT())
+	})
+	return resp, nil
 }
 
 func (lbf *llbBridgeForwarder) wrapSolveError(solveErr error) error {
 	var (
-		ee       *llberrdefs.ExecError
-		fae      *llberrdefs.FileActionError
-		sce      *solver.SlowCacheError
-		inputIDs []string
-		mountIDs []string
-		subject  errdefs.IsSolve_Subject
+		ee          *llberrdefs.ExecError
+		fae         *llberrdefs.FileActionError
+		sce         *solver.SlowCacheError
+		inputIDs    []string
+		mountIDs    []string
+		artifactsID string
+		subject     errdefs.IsSolve_Subject
 	)
 	if errors.As(solveErr, &ee) {
 		var err error
@@ -714,6 +948,13 @@ func (lbf *llbBridgeForwarder) wrapSolveError(solveErr error) error {
 		if err != nil {
 			return err
 		}
+		if ee.Artifacts != nil {
+			ids, err := lbf.registerResultIDs(ee.Artifacts)
+			if err != nil {
+				return err
+			}
+			artifactsID = ids[0]
+		}
 	}
 	if errors.As(solveErr, &fae) {
 		subject = fae.ToSubject()
@@ -726,7 +967,11 @@ func (lbf *llbBridgeForwarder) wrapSolveError(solveErr error) error {
 		}
 		subject = sce.ToSubject()
 	}
-	return errdefs.WithSolveError(solveErr, subject, inputIDs, mountIDs)
+	err := errdefs.WithSolveError(solveErr, subject, inputIDs, mountIDs)
+	if se, ok := err.(*errdefs.SolveError); ok {
+		se.ArtifactsID = artifactsID
+	}
+	return err
 }
 
 func (lbf *llbBridgeForwarder) registerResultIDs(results ...solver.Result) (ids []string, err error) {
@@ -766,6 +1011,11 @@ func (lbf *llbBridgeForwarder) Solve(ctx context.Context, req *pb.SolveRequest)
 		}
 	}
 
+	if req.Frontend != "" {
+		// the requests of nested frontends are not recorded
+		lbf.memo.disable()
+	}
+
 	ctx = tracing.ContextWithSpanFromContext(ctx, lbf.callCtx)
 	res, err := lbf.llbBridge.Solve(ctx, frontend.SolveRequest{
 		Evaluate:       req.Evaluate,
@@ -775,6 +1025,8 @@ func (lbf *llbBridgeForwarder) Solve(ctx context.Context, req *pb.SolveRequest)
 		FrontendInputs: req.FrontendInputs,
 		CacheImports:   cacheImports,
 		SourcePolicies: req.SourcePolicies,
+
+		AllowPartialFailure: req.AllowPartialFailure,
 	}, lbf.sid)
 	if err != nil {
 		return nil, lbf.wrapSolveError(err)
@@ -788,6 +1040,12 @@ func (lbf *llbBridgeForwarder) Solve(ctx context.Context, req *pb.SolveRequest)
 	pbRes := &pb.Result{
 		Metadata: res.Metadata,
 	}
+	if len(res.RefErrors) > 0 {
+		pbRes.RefErrors = make(map[string]*spb.Status, len(res.RefErrors))
+		for k, err := range res.RefErrors {
+			pbRes.RefErrors[k] = status.Convert(grpcerrors.ToGRPC(ctx, lbf.wrapSolveError(err))).Proto()
+		}
+	}
 	var defaultID string
 
 	lbf.mu.Lock()
@@ -896,6 +1154,11 @@ func (lbf *llbBridgeForwarder) getImmutableRef(ctx context.Context, id string) (
 				ref = r
 			}
 		}
+		// the mounts and artifacts of failed steps returned in solve errors
+		if workerRef, ok := lbf.workerRefByID[id]; ok && ref == nil {
+			lbf.mu.Unlock()
+			return workerRef.ImmutableRef, nil
+		}
 		if ref == nil {
 			lbf.mu.Unlock()
 			return nil, errors.Errorf("no such ref: %s, all %+v", id, maps.Keys(lbf.refs))
@@ -945,12 +1208,19 @@ func (lbf *llbBridgeForwarder) ReadFile(ctx context.Context, req *pb.ReadFileReq
 		}
 	}
 
+	var resp *pb.ReadFileResponse
 	dt, err := cacheutil.ReadFile(ctx, m, newReq)
 	if err != nil {
-		return nil, lbf.wrapSolveError(err)
+		err = lbf.wrapSolveError(err)
+	} else {
+		resp = &pb.ReadFileResponse{Data: dt}
 	}
-
-	return &pb.ReadFileResponse{Data: dt}, nil
+	lbf.memo.addDep(lbf, req.Ref, resp, err, func(ctx context.Context, lbf *llbBridgeForwarder, ref string) (proto.Message, error) {
+		req := req.CloneVT()
+		req.Ref = ref
+		return lbf.ReadFile(ctx, req)
+	})
+	return resp, err
 }
 
 func (lbf *llbBridgeForwarder) ReadDir(ctx context.Context, req *pb.ReadDirRequest) (*pb.ReadDirResponse, error) {
@@ -972,12 +1242,19 @@ func (lbf *llbBridgeForwarder) ReadDir(ctx context.Context, req *pb.ReadDirReque
 			return nil, err
 		}
 	}
+	var resp *pb.ReadDirResponse
 	entries, err := cacheutil.ReadDir(ctx, m, newReq)
 	if err != nil {
-		return nil, lbf.wrapSolveError(err)
+		err = lbf.wrapSolveError(err)
+	} else {
+		resp = &pb.ReadDirResponse{Entries: entries}
 	}
-
-	return &pb.ReadDirResponse{Entries: entries}, nil
+	lbf.memo.addDep(lbf, req.Ref, resp, err, func(ctx context.Context, lbf *llbBridgeForwarder, ref string) (proto.Message, error) {
+		req := req.CloneVT()
+		req.Ref = ref
+		return lbf.ReadDir(ctx, req)
+	})
+	return resp, err
 }
 
 func (lbf *llbBridgeForwarder) StatFile(ctx context.Context, req *pb.StatFileRequest) (*pb.StatFileResponse, error) {
@@ -994,12 +1271,17 @@ func (lbf *llbBridgeForwarder) StatFile(ctx context.Context, req *pb.StatFileReq
 			return nil, err
 		}
 	}
+	var resp *pb.StatFileResponse
 	st, err := cacheutil.StatFile(ctx, m, req.Path)
-	if err != nil {
-		return nil, err
+	if err == nil {
+		resp = &pb.StatFileResponse{Stat: st}
 	}
-
-	return &pb.StatFileResponse{Stat: st}, nil
+	lbf.memo.addDep(lbf, req.Ref, resp, err, func(ctx context.Context, lbf *llbBridgeForwarder, ref string) (proto.Message, error) {
+		req := req.CloneVT()
+		req.Ref = ref
+		return lbf.StatFile(ctx, req)
+	})
+	return resp, err
 }
 
 func (lbf *llbBridgeForwarder) Evaluate(ctx context.Context, req *pb.EvaluateRequest) (*pb.EvaluateResponse, error) {
@@ -1103,6 +1385,8 @@ func (lbf *llbBridgeForwarder) Inputs(ctx context.Context, in *pb.InputsRequest)
 
 func (lbf *llbBridgeForwarder) NewContainer(ctx context.Context, in *pb.NewContainerRequest) (_ *pb.NewContainerResponse, err error) {
 	bklog.G(ctx).Debugf("|<--- NewContainer %s", in.ContainerID)
+	// the processes of the containers are not recorded
+	lbf.memo.disable()
 	ctrReq := container.NewContainerRequest{
 		ContainerID: in.ContainerID,
 		NetMode:     in.Network,
@@ -1211,9 +1495,26 @@ func (lbf *llbBridgeForwarder) Warn(ctx context.Context, in *pb.WarnRequest) (*p
 	if err != nil {
 		return nil, err
 	}
+	lbf.memo.addWarning(in)
 	return &pb.WarnResponse{}, nil
 }
 
+func (lbf *llbBridgeForwarder) CheckSession(ctx context.Context, in *pb.CheckSessionRequest) (*pb.CheckSessionResponse, error) {
+	// the clients of the later builds may not provide the same secrets
+	lbf.memo.disable()
+	resp, err := forwarder.CheckSession(ctx, lbf.sm, session.NewGroup(lbf.sid), gwclient.CheckSessionRequest{
+		SecretIDs: in.SecretIDs,
+		SSHIDs:    in.SshIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CheckSessionResponse{
+		MissingSecretIDs: resp.MissingSecretIDs,
+		MissingSSHIDs:    resp.MissingSSHIDs,
+	}, nil
+}
+
 type processIO struct {
 	id       string
 	mu       sync.Mutex
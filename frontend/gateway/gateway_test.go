@@ -3,12 +3,13 @@ package gateway
 import (
 	"testing"
 
+	digest "github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/require"
 )
 
 func TestCheckSourceIsAllowed(t *testing.T) {
 	makeGatewayFrontend := func(sources []string) (*gatewayFrontend, error) {
-		gw, err := NewGatewayFrontend(nil, sources)
+		gw, err := NewGatewayFrontend(nil, sources, SourcePinning{}, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -43,3 +44,101 @@ func TestCheckSourceIsAllowed(t *testing.T) {
 	err = gw.checkSourceIsAllowed("docker.io/library/alpine")
 	require.NoError(t, err)
 }
+
+func TestPinSource(t *testing.T) {
+	labs := digest.FromString("labs")
+	stable := digest.FromString("stable")
+	pinned := digest.FromString("pinned")
+
+	gw, err := NewGatewayFrontend(nil, nil, SourcePinning{}, nil)
+	require.NoError(t, err)
+	source, err := gw.(*gatewayFrontend).pinSource("docker/dockerfile:1")
+	require.NoError(t, err)
+	require.Equal(t, "docker/dockerfile:1", source)
+
+	gw, err = NewGatewayFrontend(nil, nil, SourcePinning{
+		Channels: map[string]digest.Digest{
+			"docker/dockerfile:labs":                 labs,
+			"docker.io/docker/dockerfile":            stable,
+			"registry.example.com/frontend:unvetted": "",
+		},
+	}, nil)
+	require.Error(t, err)
+
+	gw, err = NewGatewayFrontend(nil, nil, SourcePinning{
+		RequireDigest: true,
+		Channels: map[string]digest.Digest{
+			"docker/dockerfile:labs":      labs,
+			"docker.io/docker/dockerfile": stable,
+		},
+	}, nil)
+	require.NoError(t, err)
+	gf := gw.(*gatewayFrontend)
+
+	for _, tc := range []struct {
+		source string
+		pinned string
+		err    string
+	}{
+		{"docker/dockerfile:labs", "docker.io/docker/dockerfile:labs@" + labs.String(), ""},
+		{"docker.io/docker/dockerfile:labs", "docker.io/docker/dockerfile:labs@" + labs.String(), ""},
+		// channels without a tag are the latest tag
+		{"docker/dockerfile", "docker.io/docker/dockerfile:latest@" + stable.String(), ""},
+		{"docker/dockerfile:1@" + pinned.String(), "docker/dockerfile:1@" + pinned.String(), ""},
+		{"docker/dockerfile:1", "", `gateway source "docker/dockerfile:1" is not pinned by digest`},
+	} {
+		source, err := gf.pinSource(tc.source)
+		if tc.err != "" {
+			require.ErrorContains(t, err, tc.err, tc.source)
+			continue
+		}
+		require.NoError(t, err, tc.source)
+		require.Equal(t, tc.pinned, source, tc.source)
+	}
+
+	_, err = NewGatewayFrontend(nil, nil, SourcePinning{
+		Channels: map[string]digest.Digest{"docker/dockerfile:1@" + pinned.String(): stable},
+	}, nil)
+	require.ErrorContains(t, err, "must not have a digest")
+}
+
+func TestGrants(t *testing.T) {
+	gw, err := NewGatewayFrontend(nil, nil, SourcePinning{}, map[string]Grant{
+		"registry.example.com/frontend": {Network: true, Capabilities: []string{"chown", "CAP_FOWNER"}},
+		"docker/dockerfile-upstream":    {},
+	})
+	require.NoError(t, err)
+	gf := gw.(*gatewayFrontend)
+
+	grant, err := gf.grant("registry.example.com/frontend:v1")
+	require.NoError(t, err)
+	require.True(t, grant.Network)
+	require.False(t, grant.LocalContexts)
+	require.Equal(t, []string{"CAP_CHOWN", "CAP_FOWNER"}, grant.Capabilities)
+
+	// the default grants are replaced by the configured ones
+	grant, err = gf.grant("docker/dockerfile:1")
+	require.NoError(t, err)
+	require.Equal(t, Grant{Network: true, LocalContexts: true}, grant)
+	grant, err = gf.grant("docker/dockerfile-upstream:master")
+	require.NoError(t, err)
+	require.Equal(t, Grant{Capabilities: []string{}}, grant)
+
+	grant, err = gf.grant("example/frontend")
+	require.NoError(t, err)
+	require.Equal(t, Grant{}, grant)
+
+	opts := map[string]string{
+		"context:base":             "docker-image://alpine",
+		"context:example/frontend": "oci-layout://frontend",
+	}
+	require.NoError(t, checkNamedContexts("example/frontend", grant, opts))
+	opts["context:src"] = "local:src"
+	require.ErrorContains(t, checkNamedContexts("example/frontend", grant, opts), `not granted access to the local named context "src"`)
+	require.NoError(t, checkNamedContexts("docker/dockerfile", Grant{LocalContexts: true}, opts))
+
+	_, err = NewGatewayFrontend(nil, nil, SourcePinning{}, map[string]Grant{"example/frontend:v1": {}})
+	require.ErrorContains(t, err, "must not have a tag or digest")
+	_, err = NewGatewayFrontend(nil, nil, SourcePinning{}, map[string]Grant{"example/frontend": {Capabilities: []string{"CAP_SYS-ADMIN"}}})
+	require.ErrorContains(t, err, "invalid capability")
+}
@@ -339,6 +339,23 @@ func (c *grpcClient) Warn(ctx context.Context, dgst digest.Digest, msg string, o
 	return err
 }
 
+func (c *grpcClient) CheckSession(ctx context.Context, req client.CheckSessionRequest) (*client.CheckSessionResponse, error) {
+	if err := c.caps.Supports(pb.CapGatewayCheckSession); err != nil {
+		return nil, err
+	}
+	resp, err := c.client.CheckSession(ctx, &pb.CheckSessionRequest{
+		SecretIDs: req.SecretIDs,
+		SshIDs:    req.SSHIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &client.CheckSessionResponse{
+		MissingSecretIDs: resp.MissingSecretIDs,
+		MissingSSHIDs:    resp.MissingSSHIDs,
+	}, nil
+}
+
 func (c *grpcClient) Solve(ctx context.Context, creq client.SolveRequest) (res *client.Result, err error) {
 	if creq.Definition != nil {
 		for _, md := range creq.Definition.Metadata {
@@ -409,6 +426,22 @@ func (c *grpcClient) Solve(ctx context.Context, creq client.SolveRequest) (res *
 		}
 	}
 
+	if creq.AllowPartialFailure && req.Evaluate {
+		if c.caps.Supports(pb.CapGatewaySolvePartialFailure) == nil {
+			req.AllowPartialFailure = true
+		} else if c.caps.Supports(pb.CapGatewayEvaluate) == nil {
+			// If partial failure is not supported, evaluate the refs one by
+			// one after the solve.
+			req.Evaluate = false
+			defer func() {
+				if res == nil {
+					return
+				}
+				err = evaluatePartial(ctx, res)
+			}()
+		}
+	}
+
 	resp, err := c.client.Solve(ctx, req)
 	if err != nil {
 		return nil, err
@@ -449,6 +482,10 @@ func (c *grpcClient) Solve(ctx context.Context, creq client.SolveRequest) (res *
 			}
 		}
 
+		for k, st := range resp.Result.RefErrors {
+			res.AddRefError(k, grpcerrors.FromGRPC(status.ErrorProto(st)))
+		}
+
 		if resp.Result.Attestations != nil {
 			for p, as := range resp.Result.Attestations {
 				for _, a := range as.Attestation {
@@ -468,6 +505,40 @@ func (c *grpcClient) Solve(ctx context.Context, creq client.SolveRequest) (res *
 	return res, nil
 }
 
+// evaluatePartial evaluates the result of a solve, returning the errors of
+// the refs of a multi-ref result in res.RefErrors
+func evaluatePartial(ctx context.Context, res *client.Result) error {
+	if res.Refs == nil {
+		return res.EachRef(func(ref client.Reference) error {
+			return ref.Evaluate(ctx)
+		})
+	}
+	for k, ref := range res.Refs {
+		if ref == nil {
+			continue
+		}
+		if err := ref.Evaluate(ctx); err != nil {
+			if err := context.Cause(ctx); err != nil {
+				return err
+			}
+			delete(res.Refs, k)
+			delete(res.Attestations, k)
+			res.AddRefError(k, err)
+		}
+	}
+	for _, atts := range res.Attestations {
+		for _, att := range atts {
+			if att.Ref == nil {
+				continue
+			}
+			if err := att.Ref.Evaluate(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *grpcClient) ResolveSourceMetadata(ctx context.Context, op *opspb.SourceOp, opt sourceresolver.Opt) (*sourceresolver.MetaResponse, error) {
 	if c.caps.Supports(pb.CapSourceMetaResolver) != nil {
 		var ref string
@@ -0,0 +1,411 @@
+package gateway
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/moby/buildkit/executor"
+	"github.com/moby/buildkit/frontend"
+	pb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/moby/buildkit/identity"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver"
+	opspb "github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/moby/buildkit/worker"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	maxMemoEntries = 128
+	keyNoCache     = "no-cache"
+)
+
+// memoCall repeats a request of the frontend with the ref of the rebuilt
+// definition it was made for
+type memoCall func(ctx context.Context, lbf *llbBridgeForwarder, ref string) (proto.Message, error)
+
+// memoDep is a request of the frontend that its result depends on, like a
+// file read from the build context or a resolved image config
+type memoDep struct {
+	def    *opspb.Definition
+	call   memoCall
+	digest digest.Digest
+}
+
+// memoRecorder records the requests of a frontend run, for its result to be
+// memoized. The result is not memoized if the frontend makes requests that
+// can't be repeated, like running containers or nested frontends.
+type memoRecorder struct {
+	mu       sync.Mutex
+	disabled bool
+	deps     []memoDep
+	warnings []*pb.WarnRequest
+}
+
+func (r *memoRecorder) disable() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.disabled = true
+	r.mu.Unlock()
+}
+
+// addDep records a request made for the ref of lbf, or for no ref if ref is
+// empty, and the digest of its response. The failed reads of the files of the
+// ref, like a missing .dockerignore, are recorded with the digest of their
+// error, for the result not to be reused once the file is created.
+func (r *memoRecorder) addDep(lbf *llbBridgeForwarder, ref string, resp proto.Message, respErr error, call memoCall) {
+	if r == nil {
+		return
+	}
+	var def *opspb.Definition
+	if ref != "" {
+		lbf.mu.Lock()
+		rp, ok := lbf.refs[ref]
+		if !ok && lbf.result != nil {
+			rp, ok = lbf.result.FindRef(ref)
+		}
+		lbf.mu.Unlock()
+		if !ok || rp == nil || rp.Definition() == nil {
+			// refs of failed steps
+			r.disable()
+			return
+		}
+		def = rp.Definition().CloneVT()
+	}
+	dgst, err := digestResponse(resp, respErr)
+	if err != nil {
+		r.disable()
+		return
+	}
+	r.mu.Lock()
+	r.deps = append(r.deps, memoDep{def: def, call: call, digest: dgst})
+	r.mu.Unlock()
+}
+
+func (r *memoRecorder) addWarning(in *pb.WarnRequest) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.warnings = append(r.warnings, in.CloneVT())
+	r.mu.Unlock()
+}
+
+// memoEntry is a memoized result of a frontend, reused as long as the
+// responses to the requests of the frontend don't change
+type memoEntry struct {
+	key      digest.Digest
+	sid      string
+	deps     []memoDep
+	warnings []*pb.WarnRequest
+	ref      *opspb.Definition
+	refs     map[string]*opspb.Definition
+	metadata map[string][]byte
+}
+
+// memoCache keeps the most recently used memoized results of the frontends
+type memoCache struct {
+	mu      sync.Mutex
+	entries map[digest.Digest]*list.Element
+	lru     *list.List
+}
+
+func newMemoCache() *memoCache {
+	return &memoCache{
+		entries: map[digest.Digest]*list.Element{},
+		lru:     list.New(),
+	}
+}
+
+func (c *memoCache) get(key digest.Digest) *memoEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*memoEntry)
+}
+
+func (c *memoCache) add(e *memoEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[e.key]; ok {
+		c.lru.Remove(el)
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.lru.Len() > maxMemoEntries {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*memoEntry).key)
+	}
+}
+
+func (c *memoCache) remove(key digest.Digest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.lru.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// memoKey returns the key of the results of the frontend image with the
+// digest, the opts and the inputs. It returns an empty key if the result
+// can't be memoized.
+func (gf *gatewayFrontend) memoKey(mfstDigest digest.Digest, opts map[string]string, inputs map[string]*opspb.Definition) (digest.Digest, error) {
+	if mfstDigest == "" {
+		return "", nil
+	}
+	if _, ok := opts[keyNoCache]; ok {
+		return "", nil
+	}
+	workers, err := json.Marshal(gf.workers.WorkerInfos())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to marshal workers array")
+	}
+	inputDigests := make(map[string]digest.Digest, len(inputs))
+	for name, def := range inputs {
+		if inputDigests[name], err = digestMessage(def); err != nil {
+			return "", err
+		}
+	}
+	dt, err := json.Marshal(struct {
+		Frontend digest.Digest
+		Opts     map[string]string
+		Inputs   map[string]digest.Digest
+		Workers  json.RawMessage
+	}{mfstDigest, opts, inputDigests, workers})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return digest.FromBytes(dt), nil
+}
+
+// loadMemo returns the memoized result of key if the requests of the
+// frontend that it depends on have the same responses in the build of sid
+func (gf *gatewayFrontend) loadMemo(ctx context.Context, key digest.Digest, llbBridge frontend.FrontendLLBBridge, exec executor.Executor, inputs map[string]*opspb.Definition, sid string, sm *session.Manager) (*frontend.Result, bool) {
+	e := gf.memo.get(key)
+	if e == nil {
+		return nil, false
+	}
+	res, err := e.replay(ctx, llbBridge, exec, gf.workers, inputs, sid, sm)
+	if err != nil {
+		bklog.G(ctx).Debugf("memoized frontend result %s not reused: %v", key, err)
+	}
+	if res == nil {
+		gf.memo.remove(key)
+		return nil, false
+	}
+	return res, true
+}
+
+// saveMemo memoizes res if the requests of the frontend that it depends on
+// were all recorded
+func (gf *gatewayFrontend) saveMemo(key digest.Digest, r *memoRecorder, sid string, res *frontend.Result) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.disabled || len(res.Attestations) > 0 {
+		return
+	}
+	e := &memoEntry{
+		key:      key,
+		sid:      sid,
+		deps:     r.deps,
+		warnings: r.warnings,
+		metadata: maps.Clone(res.Metadata),
+	}
+	if res.Ref != nil {
+		e.ref = res.Ref.Definition()
+	}
+	if res.Refs != nil {
+		e.refs = make(map[string]*opspb.Definition, len(res.Refs))
+		for k, ref := range res.Refs {
+			if ref != nil {
+				e.refs[k] = ref.Definition()
+			} else {
+				e.refs[k] = nil
+			}
+		}
+	}
+	gf.memo.add(e)
+}
+
+// replay repeats the requests that the memoized result depends on and
+// returns the result if they all have the same responses. It returns a nil
+// result if a response changed.
+func (e *memoEntry) replay(ctx context.Context, llbBridge frontend.FrontendLLBBridge, exec executor.Executor, workers worker.Infos, inputs map[string]*opspb.Definition, sid string, sm *session.Manager) (*frontend.Result, error) {
+	lbf := newBridgeForwarder(ctx, llbBridge, exec, workers, inputs, sid, sm)
+	defer lbf.Discard()
+	rw := &sessionRewriter{from: e.sid, to: sid, digests: map[string]string{}}
+
+	solve := func(def *opspb.Definition) (solver.ResultProxy, error) {
+		if def == nil {
+			return nil, nil
+		}
+		def, err := rw.rewrite(def)
+		if err != nil {
+			return nil, err
+		}
+		res, err := llbBridge.Solve(ctx, frontend.SolveRequest{Definition: def}, sid)
+		if err != nil {
+			return nil, err
+		}
+		return res.Ref, nil
+	}
+
+	for _, dep := range e.deps {
+		var ref string
+		if dep.def != nil {
+			rp, err := solve(dep.def)
+			if err != nil {
+				return nil, err
+			}
+			ref = identity.NewID()
+			lbf.mu.Lock()
+			lbf.refs[ref] = rp
+			lbf.mu.Unlock()
+		}
+		resp, respErr := dep.call(ctx, lbf, ref)
+		dgst, err := digestResponse(resp, respErr)
+		if err != nil {
+			return nil, err
+		}
+		if dgst != dep.digest {
+			return nil, respErr
+		}
+	}
+
+	res := &frontend.Result{Metadata: maps.Clone(e.metadata)}
+	release := func() {
+		res.EachRef(func(ref solver.ResultProxy) error {
+			return ref.Release(context.WithoutCancel(ctx))
+		})
+	}
+	if e.ref != nil {
+		rp, err := solve(e.ref)
+		if err != nil {
+			return nil, err
+		}
+		res.SetRef(rp)
+	}
+	for _, k := range slices.Sorted(maps.Keys(e.refs)) {
+		rp, err := solve(e.refs[k])
+		if err != nil {
+			release()
+			return nil, err
+		}
+		res.AddRef(k, rp)
+	}
+
+	for _, w := range e.warnings {
+		w = w.CloneVT()
+		if dgst, ok := rw.digests[w.Digest]; ok {
+			w.Digest = dgst
+		}
+		if _, err := lbf.Warn(ctx, w); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// sessionRewriter replaces the session ID of the build that recorded a
+// memoized result in the source ops of its definitions, updating the
+// digests of the ops that depend on them
+type sessionRewriter struct {
+	from, to string
+	digests  map[string]string
+}
+
+func (rw *sessionRewriter) rewrite(def *opspb.Definition) (*opspb.Definition, error) {
+	if rw.from == rw.to {
+		return def, nil
+	}
+	out := &opspb.Definition{
+		Def:      make([][]byte, 0, len(def.Def)),
+		Metadata: make(map[string]*opspb.OpMetadata, len(def.Metadata)),
+	}
+	for _, dt := range def.Def {
+		var op opspb.Op
+		if err := op.UnmarshalVT(dt); err != nil {
+			return nil, errors.Wrap(err, "failed to parse memoized definition")
+		}
+		changed := false
+		for _, inp := range op.Inputs {
+			if dgst, ok := rw.digests[inp.Digest]; ok {
+				inp.Digest = dgst
+				changed = true
+			}
+		}
+		if src := op.GetSource(); src != nil {
+			for k, v := range src.Attrs {
+				if v == rw.from {
+					src.Attrs[k] = rw.to
+					changed = true
+				}
+			}
+		}
+		if changed {
+			ndt, err := proto.MarshalOptions{Deterministic: true}.Marshal(&op)
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+			rw.digests[digest.FromBytes(dt).String()] = digest.FromBytes(ndt).String()
+			dt = ndt
+		}
+		out.Def = append(out.Def, dt)
+	}
+	for k, md := range def.Metadata {
+		if dgst, ok := rw.digests[k]; ok {
+			k = dgst
+		}
+		out.Metadata[k] = md
+	}
+	if def.Source != nil {
+		out.Source = &opspb.Source{
+			Locations: make(map[string]*opspb.Locations, len(def.Source.Locations)),
+			Infos:     def.Source.Infos,
+		}
+		for k, locs := range def.Source.Locations {
+			if dgst, ok := rw.digests[k]; ok {
+				k = dgst
+			}
+			out.Source.Locations[k] = locs
+		}
+	}
+	return out, nil
+}
+
+// digestResponse returns the digest of the response of a request, or of the
+// code and the message of its error if it failed
+func digestResponse(resp proto.Message, err error) (digest.Digest, error) {
+	if err != nil {
+		return digestMessage(&spb.Status{
+			Code:    int32(grpcerrors.Code(err)),
+			Message: err.Error(),
+		})
+	}
+	return digestMessage(resp)
+}
+
+func digestMessage(m proto.Message) (digest.Digest, error) {
+	dt, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return digest.FromBytes(dt), nil
+}
@@ -0,0 +1,194 @@
+package gateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/mount"
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/frontend"
+	pb "github.com/moby/buildkit/frontend/gateway/pb"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/snapshot"
+	"github.com/moby/buildkit/solver"
+	opspb "github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/worker"
+	"github.com/moby/sys/user"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionRewriter(t *testing.T) {
+	build := func(sid string) *opspb.Definition {
+		src := llb.Local("context", llb.SessionID(sid), llb.WithCustomName("load context"))
+		st := llb.Scratch().File(llb.Copy(src, "/", "/"), llb.WithCustomName("copy context"))
+		def, err := st.Marshal(context.TODO())
+		require.NoError(t, err)
+		return def.ToPB()
+	}
+	recorded := build("session1")
+	expected := build("session2")
+
+	rw := &sessionRewriter{from: "session1", to: "session1", digests: map[string]string{}}
+	def, err := rw.rewrite(recorded)
+	require.NoError(t, err)
+	require.Equal(t, recorded, def)
+
+	rw.to = "session2"
+	def, err = rw.rewrite(recorded)
+	require.NoError(t, err)
+	require.Equal(t, expected.Def, def.Def)
+	require.Len(t, def.Metadata, len(expected.Metadata))
+	for k := range expected.Metadata {
+		require.Contains(t, def.Metadata, k)
+	}
+	// the source and the copy ops, but not the terminal op without attrs
+	require.Len(t, rw.digests, 3)
+}
+
+func TestMemoCache(t *testing.T) {
+	c := newMemoCache()
+	for i := range maxMemoEntries + 1 {
+		c.add(&memoEntry{key: digest.FromBytes([]byte{byte(i)})})
+		if i == 0 {
+			// the first entry is used, the second one is evicted
+			continue
+		}
+		require.NotNil(t, c.get(digest.FromBytes([]byte{0})))
+	}
+	require.Len(t, c.entries, maxMemoEntries)
+	require.NotNil(t, c.get(digest.FromBytes([]byte{0})))
+	require.Nil(t, c.get(digest.FromBytes([]byte{1})))
+	c.remove(digest.FromBytes([]byte{0}))
+	require.Nil(t, c.get(digest.FromBytes([]byte{0})))
+}
+
+type testWorkerInfos struct{}
+
+func (testWorkerInfos) DefaultCacheManager() (cache.Manager, error) {
+	return nil, nil
+}
+
+func (testWorkerInfos) WorkerInfos() []client.WorkerInfo {
+	return []client.WorkerInfo{{ID: "worker"}}
+}
+
+func TestMemoKey(t *testing.T) {
+	gw, err := NewGatewayFrontend(testWorkerInfos{}, nil, SourcePinning{}, nil)
+	require.NoError(t, err)
+	gf := gw.(*gatewayFrontend)
+
+	frontendDigest := digest.FromString("frontend")
+	opts := map[string]string{"source": "docker/dockerfile:1", "build-arg:A": "1"}
+	key, err := gf.memoKey(frontendDigest, opts, nil)
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+
+	key2, err := gf.memoKey(frontendDigest, map[string]string{"build-arg:A": "1", "source": "docker/dockerfile:1"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, key, key2)
+
+	key2, err = gf.memoKey(frontendDigest, map[string]string{"source": "docker/dockerfile:1", "build-arg:A": "2"}, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, key, key2)
+
+	def, err := llb.Image("alpine").Marshal(context.TODO())
+	require.NoError(t, err)
+	key2, err = gf.memoKey(frontendDigest, opts, map[string]*opspb.Definition{"base": def.ToPB()})
+	require.NoError(t, err)
+	require.NotEqual(t, key, key2)
+
+	// frontends without an image digest and builds without cache are not
+	// memoized
+	key, err = gf.memoKey("", opts, nil)
+	require.NoError(t, err)
+	require.Empty(t, key)
+	key, err = gf.memoKey(frontendDigest, map[string]string{"no-cache": ""}, nil)
+	require.NoError(t, err)
+	require.Empty(t, key)
+}
+
+func TestMemoMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	def, err := llb.Local("context").Marshal(context.TODO())
+	require.NoError(t, err)
+	rp := &testResultProxy{def: def.ToPB(), dir: dir}
+	llbBridge := &testLLBBridge{ref: rp}
+
+	// the frontend ignores the error of reading the missing .dockerignore
+	r := &memoRecorder{}
+	lbf := newBridgeForwarder(context.TODO(), llbBridge, nil, testWorkerInfos{}, nil, "session1", nil)
+	lbf.memo = r
+	lbf.refs["ref1"] = rp
+	_, err = lbf.ReadFile(context.TODO(), &pb.ReadFileRequest{Ref: "ref1", FilePath: ".dockerignore"})
+	require.Error(t, err)
+	require.Len(t, r.deps, 1)
+
+	e := &memoEntry{key: digest.FromString("key"), sid: "session1", deps: r.deps}
+	res, err := e.replay(context.TODO(), llbBridge, nil, testWorkerInfos{}, nil, "session1", nil)
+	require.NoError(t, err)
+	require.NotNil(t, res)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".dockerignore"), []byte("node_modules\n"), 0600))
+	res, err = e.replay(context.TODO(), llbBridge, nil, testWorkerInfos{}, nil, "session1", nil)
+	require.NoError(t, err)
+	require.Nil(t, res)
+}
+
+type testLLBBridge struct {
+	frontend.FrontendLLBBridge
+	ref solver.ResultProxy
+}
+
+func (b *testLLBBridge) Solve(context.Context, frontend.SolveRequest, string) (*frontend.Result, error) {
+	return &frontend.Result{Ref: b.ref}, nil
+}
+
+// testResultProxy is a result with the files of dir
+type testResultProxy struct {
+	def *opspb.Definition
+	dir string
+}
+
+func (rp *testResultProxy) ID() string {
+	return "test"
+}
+
+func (rp *testResultProxy) Result(context.Context) (solver.CachedResult, error) {
+	return solver.NewCachedResult(worker.NewWorkerRefResult(&testRef{dir: rp.dir}, nil), nil), nil
+}
+
+func (rp *testResultProxy) Release(context.Context) error {
+	return nil
+}
+
+func (rp *testResultProxy) Definition() *opspb.Definition {
+	return rp.def
+}
+
+func (rp *testResultProxy) Provenance() any {
+	return nil
+}
+
+type testRef struct {
+	cache.ImmutableRef
+	dir string
+}
+
+func (r *testRef) Mount(context.Context, bool, session.Group) (snapshot.Mountable, error) {
+	return testMountable(r.dir), nil
+}
+
+type testMountable string
+
+func (m testMountable) Mount() ([]mount.Mount, func() error, error) {
+	return []mount.Mount{{Type: "bind", Source: string(m), Options: []string{"rbind"}}}, func() error { return nil }, nil
+}
+
+func (m testMountable) IdentityMapping() *user.IdentityMapping {
+	return nil
+}
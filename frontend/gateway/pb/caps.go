@@ -62,6 +62,10 @@ const (
 
 	CapGatewayEvaluate apicaps.CapID = "gateway.evaluate"
 
+	// CapGatewaySolvePartialFailure is a capability to return the errors of
+	// the refs of an evaluated solve result instead of failing the solve
+	CapGatewaySolvePartialFailure apicaps.CapID = "gateway.solve.partialfailure"
+
 	// CapGatewayWarnings is the capability to log warnings from frontend
 	CapGatewayWarnings apicaps.CapID = "gateway.warnings"
 
@@ -72,6 +76,10 @@ const (
 	// CapSourceMetaResolver is the capability to indicates support for ResolveSourceMetadata
 	// function in gateway API
 	CapSourceMetaResolver apicaps.CapID = "source.metaresolver"
+
+	// CapGatewayCheckSession is the capability to check the secrets and SSH
+	// agents provided by the client session
+	CapGatewayCheckSession apicaps.CapID = "gateway.checksession"
 )
 
 func init() {
@@ -222,6 +230,13 @@ func init() {
 		Status:  apicaps.CapStatusExperimental,
 	})
 
+	Caps.Init(apicaps.Cap{
+		ID:      CapGatewaySolvePartialFailure,
+		Name:    "gateway solve partial failure",
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
 	Caps.Init(apicaps.Cap{
 		ID:      CapGatewayWarnings,
 		Name:    "logging warnings",
@@ -242,4 +257,11 @@ func init() {
 		Enabled: true,
 		Status:  apicaps.CapStatusExperimental,
 	})
+
+	Caps.Init(apicaps.Cap{
+		ID:      CapGatewayCheckSession,
+		Name:    "check session",
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
 }
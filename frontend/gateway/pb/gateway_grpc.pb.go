@@ -33,6 +33,7 @@ const (
 	LLBBridge_ReleaseContainer_FullMethodName   = "/moby.buildkit.v1.frontend.LLBBridge/ReleaseContainer"
 	LLBBridge_ExecProcess_FullMethodName        = "/moby.buildkit.v1.frontend.LLBBridge/ExecProcess"
 	LLBBridge_Warn_FullMethodName               = "/moby.buildkit.v1.frontend.LLBBridge/Warn"
+	LLBBridge_CheckSession_FullMethodName       = "/moby.buildkit.v1.frontend.LLBBridge/CheckSession"
 )
 
 // LLBBridgeClient is the client API for LLBBridge service.
@@ -62,6 +63,8 @@ type LLBBridgeClient interface {
 	ExecProcess(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ExecMessage, ExecMessage], error)
 	// apicaps:CapGatewayWarnings
 	Warn(ctx context.Context, in *WarnRequest, opts ...grpc.CallOption) (*WarnResponse, error)
+	// apicaps:CapGatewayCheckSession
+	CheckSession(ctx context.Context, in *CheckSessionRequest, opts ...grpc.CallOption) (*CheckSessionResponse, error)
 }
 
 type lLBBridgeClient struct {
@@ -215,6 +218,16 @@ func (c *lLBBridgeClient) Warn(ctx context.Context, in *WarnRequest, opts ...grp
 	return out, nil
 }
 
+func (c *lLBBridgeClient) CheckSession(ctx context.Context, in *CheckSessionRequest, opts ...grpc.CallOption) (*CheckSessionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckSessionResponse)
+	err := c.cc.Invoke(ctx, LLBBridge_CheckSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LLBBridgeServer is the server API for LLBBridge service.
 // All implementations should embed UnimplementedLLBBridgeServer
 // for forward compatibility.
@@ -242,6 +255,8 @@ type LLBBridgeServer interface {
 	ExecProcess(grpc.BidiStreamingServer[ExecMessage, ExecMessage]) error
 	// apicaps:CapGatewayWarnings
 	Warn(context.Context, *WarnRequest) (*WarnResponse, error)
+	// apicaps:CapGatewayCheckSession
+	CheckSession(context.Context, *CheckSessionRequest) (*CheckSessionResponse, error)
 }
 
 // UnimplementedLLBBridgeServer should be embedded to have
@@ -293,6 +308,9 @@ func (UnimplementedLLBBridgeServer) ExecProcess(grpc.BidiStreamingServer[ExecMes
 func (UnimplementedLLBBridgeServer) Warn(context.Context, *WarnRequest) (*WarnResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Warn not implemented")
 }
+func (UnimplementedLLBBridgeServer) CheckSession(context.Context, *CheckSessionRequest) (*CheckSessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSession not implemented")
+}
 func (UnimplementedLLBBridgeServer) testEmbeddedByValue() {}
 
 // UnsafeLLBBridgeServer may be embedded to opt out of forward compatibility for this service.
@@ -554,6 +572,24 @@ func _LLBBridge_Warn_Handler(srv interface{}, ctx context.Context, dec func(inte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _LLBBridge_CheckSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckSessionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LLBBridgeServer).CheckSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LLBBridge_CheckSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LLBBridgeServer).CheckSession(ctx, req.(*CheckSessionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // LLBBridge_ServiceDesc is the grpc.ServiceDesc for LLBBridge service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -613,6 +649,10 @@ var LLBBridge_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Warn",
 			Handler:    _LLBBridge_Warn_Handler,
 		},
+		{
+			MethodName: "CheckSession",
+			Handler:    _LLBBridge_CheckSession_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
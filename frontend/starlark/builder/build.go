@@ -0,0 +1,96 @@
+package builder
+
+import (
+	"context"
+	"maps"
+
+	"github.com/moby/buildkit/frontend/dockerui"
+	"github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/frontend/starlark/starlark2llb"
+	"github.com/moby/buildkit/frontend/subrequests/targets"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+const keyFilename = "filename"
+
+// Build is the entrypoint of the starlark.v0 frontend. It reads the build
+// definition from the dockerfile local, build.star by default, and builds the
+// target function for each of the target platforms.
+func Build(ctx context.Context, c client.Client) (_ *client.Result, err error) {
+	bc, err := dockerui.NewClient(&withDefaultFilename{Client: c})
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := bc.ReadEntrypoint(ctx, "Starlark")
+	if err != nil {
+		return nil, err
+	}
+
+	if res, ok, err := bc.HandleSubrequest(ctx, dockerui.RequestHandler{
+		ListTargets: func(ctx context.Context) (*targets.List, error) {
+			return starlark2llb.ListTargets(ctx, src.Data)
+		},
+	}); err != nil {
+		return nil, err
+	} else if ok {
+		return res, nil
+	}
+
+	rb, err := bc.Build(ctx, func(ctx context.Context, platform *ocispecs.Platform, idx int) (client.Reference, *dockerspec.DockerOCIImage, *dockerspec.DockerOCIImage, error) {
+		st, img, baseImg, err := starlark2llb.Starlark2LLB(ctx, src.Data, starlark2llb.ConvertOpt{
+			Config:         bc.Config,
+			Client:         bc,
+			SourceMap:      src.SourceMap,
+			TargetPlatform: platform,
+			MetaResolver:   c,
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		def, err := st.Marshal(ctx)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "failed to marshal LLB definition")
+		}
+
+		r, err := c.Solve(ctx, client.SolveRequest{
+			Definition:   def.ToPB(),
+			CacheImports: bc.CacheImports,
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		ref, err := r.SingleRef()
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return ref, img, baseImg, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rb.Finalize()
+}
+
+// withDefaultFilename reads the build definition from build.star instead of
+// the Dockerfile when no filename is set
+type withDefaultFilename struct {
+	client.Client
+}
+
+func (c *withDefaultFilename) BuildOpts() client.BuildOpts {
+	bopts := c.Client.BuildOpts()
+	if _, ok := bopts.Opts[keyFilename]; !ok {
+		bopts.Opts = maps.Clone(bopts.Opts)
+		if bopts.Opts == nil {
+			bopts.Opts = map[string]string{}
+		}
+		bopts.Opts[keyFilename] = starlark2llb.DefaultFilename
+	}
+	return bopts
+}
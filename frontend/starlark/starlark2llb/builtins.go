@@ -0,0 +1,421 @@
+package starlark2llb
+
+import (
+	"encoding/json"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/distribution/reference"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/client/llb/sourceresolver"
+	"github.com/moby/buildkit/frontend/dockerui"
+	"github.com/moby/buildkit/util/system"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"go.starlark.net/starlark"
+)
+
+// image(ref, platform=None) returns the state of an image. Named contexts
+// passed to the build take precedence over the registry.
+func (c *converter) image(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var ref, platformStr string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "ref", &ref, "platform?", &platformStr); err != nil {
+		return nil, err
+	}
+	platform, err := c.parsePlatform(platformStr)
+	if err != nil {
+		return nil, err
+	}
+
+	key := ref + "::" + platforms.FormatAll(platform)
+	if st, ok := c.images[key]; ok {
+		return st, nil
+	}
+
+	if c.opt.Client != nil {
+		nc, err := c.opt.Client.NamedContext(ref, dockerui.ContextOpt{
+			Platform:    &platform,
+			ResolveMode: c.opt.ImageResolveMode.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if nc != nil {
+			st, img, err := nc.Load(c.ctx)
+			if err != nil {
+				return nil, err
+			}
+			s := &State{c: c, st: *st}
+			if img != nil {
+				s.img = cloneImage(img)
+				s.base = cloneImage(img)
+			} else {
+				s.img = emptyImage(platform)
+			}
+			c.images[key] = s
+			return s, nil
+		}
+	}
+
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse image reference %q", ref)
+	}
+	named = reference.TagNameOnly(named)
+
+	s := &State{c: c, img: emptyImage(platform)}
+	var dt []byte
+	if c.opt.MetaResolver != nil {
+		var mutRef string
+		var dgst digest.Digest
+		mutRef, dgst, dt, err = c.opt.MetaResolver.ResolveImageConfig(c.ctx, named.String(), sourceresolver.Opt{
+			LogName:  "[internal] load metadata for " + named.String(),
+			Platform: &platform,
+			ImageOpt: &sourceresolver.ResolveImageOpt{
+				ResolveMode: c.opt.ImageResolveMode.String(),
+			},
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, ref)
+		}
+		if named.String() != mutRef {
+			named, err = reference.ParseNormalizedNamed(mutRef)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse ref %q", mutRef)
+			}
+		}
+		if dgst != "" {
+			named, err = reference.WithDigest(named, dgst)
+			if err != nil {
+				return nil, err
+			}
+		}
+		var img dockerspec.DockerOCIImage
+		if err := json.Unmarshal(dt, &img); err != nil {
+			return nil, errors.Wrap(err, "failed to parse image config")
+		}
+		s.base = cloneImage(&img)
+		img.Created = nil
+		s.img = &img
+	}
+
+	st := llb.Image(named.String(),
+		llb.Platform(platform),
+		c.opt.ImageResolveMode,
+		c.customName("image "+ref),
+		c.location(thread),
+	)
+	if dt != nil {
+		st, err = st.WithImageConfig(dt)
+		if err != nil {
+			return nil, err
+		}
+		if s.img.Config.User != "" {
+			st = st.User(s.img.Config.User)
+		}
+	}
+	s.st = st
+	c.images[key] = s
+	return s, nil
+}
+
+// scratch() returns an empty state
+func (c *converter) scratch(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs); err != nil {
+		return nil, err
+	}
+	return c.newState(llb.Scratch()), nil
+}
+
+// git(url, ref="", keep_git_dir=False) returns the state of a git repository
+func (c *converter) git(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var url, ref string
+	var keepGitDir bool
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "url", &url, "ref?", &ref, "keep_git_dir?", &keepGitDir); err != nil {
+		return nil, err
+	}
+	opts := []llb.GitOption{c.customName("git " + url), c.location(thread)}
+	if keepGitDir {
+		opts = append(opts, llb.KeepGitDir())
+	}
+	return c.newState(llb.Git(url, ref, opts...)), nil
+}
+
+// http(url, filename="", checksum="") returns a state with the file at url
+func (c *converter) http(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var url, filename, checksum string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "url", &url, "filename?", &filename, "checksum?", &checksum); err != nil {
+		return nil, err
+	}
+	opts := []llb.HTTPOption{c.customName("http " + url), c.location(thread)}
+	if filename != "" {
+		opts = append(opts, llb.Filename(filename))
+	}
+	if checksum != "" {
+		dgst, err := digest.Parse(checksum)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid checksum %q", checksum)
+		}
+		opts = append(opts, llb.Checksum(dgst))
+	}
+	return c.newState(llb.HTTP(url, opts...)), nil
+}
+
+// ctx.context(name="") returns the main build context or the named context
+// passed to the build
+func (c *converter) context(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name?", &name); err != nil {
+		return nil, err
+	}
+	if name == "" {
+		if c.opt.Client == nil {
+			return c.newState(llb.Local(dockerui.DefaultLocalNameContext)), nil
+		}
+		st, err := c.opt.Client.MainContext(c.ctx)
+		if err != nil {
+			return nil, err
+		}
+		return c.newState(*st), nil
+	}
+	if c.opt.Client != nil {
+		platform := c.platform
+		nc, err := c.opt.Client.NamedContext(name, dockerui.ContextOpt{
+			Platform:    &platform,
+			ResolveMode: c.opt.ImageResolveMode.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if nc != nil {
+			st, img, err := nc.Load(c.ctx)
+			if err != nil {
+				return nil, err
+			}
+			s := c.newState(*st)
+			if img != nil {
+				s.img = cloneImage(img)
+				s.base = cloneImage(img)
+			}
+			return s, nil
+		}
+	}
+	return nil, errors.Errorf("context %q was not passed to the build", name)
+}
+
+func (c *converter) newState(st llb.State) *State {
+	return &State{
+		c:   c,
+		st:  st.Platform(c.platform),
+		img: emptyImage(c.platform),
+	}
+}
+
+func (c *converter) parsePlatform(v string) (ocispecs.Platform, error) {
+	if v == "" {
+		return c.platform, nil
+	}
+	p, err := platforms.Parse(v)
+	if err != nil {
+		return ocispecs.Platform{}, errors.Wrapf(err, "failed to parse platform %s", v)
+	}
+	return platforms.Normalize(p), nil
+}
+
+// Mount is a mount of a run, created by the cache, secret, ssh, bind and
+// tmpfs builtins
+type Mount struct {
+	kind   string
+	target string
+	opt    llb.RunOption
+}
+
+var _ starlark.Value = &Mount{}
+
+func (m *Mount) String() string        { return m.kind + "(" + m.target + ")" }
+func (m *Mount) Type() string          { return "mount" }
+func (m *Mount) Freeze()               {}
+func (m *Mount) Truth() starlark.Bool  { return starlark.True }
+func (m *Mount) Hash() (uint32, error) { return 0, errors.New("unhashable type: mount") }
+
+// cache(target, id="", sharing="shared") returns a persistent cache mount
+func (c *converter) cacheMount(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var target, id string
+	sharing := "shared"
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "target", &target, "id?", &id, "sharing?", &sharing); err != nil {
+		return nil, err
+	}
+	if id == "" {
+		id = target
+	}
+	if c.opt.CacheIDNamespace != "" {
+		id = c.opt.CacheIDNamespace + "/" + id
+	}
+	var mode llb.CacheMountSharingMode
+	switch sharing {
+	case "shared":
+		mode = llb.CacheMountShared
+	case "private":
+		mode = llb.CacheMountPrivate
+	case "locked":
+		mode = llb.CacheMountLocked
+	default:
+		return nil, errors.Errorf("%s: invalid sharing mode %q", b.Name(), sharing)
+	}
+	return &Mount{
+		kind:   b.Name(),
+		target: target,
+		opt:    llb.AddMount(target, llb.Scratch(), llb.AsPersistentCacheDir(id, mode)),
+	}, nil
+}
+
+// secret(id, target="", env="", required=False) mounts a secret as a file,
+// by default /run/secrets/<id>, or exposes it as an environment variable
+func (c *converter) secretMount(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var id, target, env string
+	var required bool
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id", &id, "target?", &target, "env?", &env, "required?", &required); err != nil {
+		return nil, err
+	}
+	var opts []llb.SecretOption
+	if !required {
+		opts = append(opts, llb.SecretOptional)
+	}
+	var dest *string
+	switch {
+	case env != "":
+		opts = append(opts, llb.SecretAsEnvName(env))
+		if target != "" {
+			dest = &target
+		}
+	case target == "":
+		target = "/run/secrets/" + id
+		dest = &target
+	default:
+		dest = &target
+	}
+	name := env
+	if dest != nil {
+		name = *dest
+	}
+	return &Mount{
+		kind:   b.Name(),
+		target: name,
+		opt:    llb.AddSecretWithDest(id, dest, opts...),
+	}, nil
+}
+
+// ssh(id="default", target="", required=False) forwards an SSH agent socket
+func (c *converter) sshMount(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var target string
+	var required bool
+	id := "default"
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "id?", &id, "target?", &target, "required?", &required); err != nil {
+		return nil, err
+	}
+	opts := []llb.SSHOption{llb.SSHID(id)}
+	if target != "" {
+		opts = append(opts, llb.SSHSocketTarget(target))
+	}
+	if !required {
+		opts = append(opts, llb.SSHOptional)
+	}
+	return &Mount{
+		kind:   b.Name(),
+		target: id,
+		opt:    llb.AddSSHSocket(opts...),
+	}, nil
+}
+
+// bind(target, state, source="/", readonly=True) mounts a directory of another
+// state
+func (c *converter) bindMount(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var target string
+	var st *State
+	source := "/"
+	readonly := true
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "target", &target, "state", &st, "source?", &source, "readonly?", &readonly); err != nil {
+		return nil, err
+	}
+	opts := []llb.MountOption{llb.SourcePath(source)}
+	if readonly {
+		opts = append(opts, llb.Readonly)
+	}
+	return &Mount{
+		kind:   b.Name(),
+		target: target,
+		opt:    llb.AddMount(target, st.st, opts...),
+	}, nil
+}
+
+// tmpfs(target, size=0) mounts a tmpfs
+func (c *converter) tmpfsMount(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var target string
+	var size int64
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "target", &target, "size?", &size); err != nil {
+		return nil, err
+	}
+	var opts []llb.TmpfsOption
+	if size > 0 {
+		opts = append(opts, llb.TmpfsSize(size))
+	}
+	return &Mount{
+		kind:   b.Name(),
+		target: target,
+		opt:    llb.AddMount(target, llb.Scratch(), llb.Tmpfs(opts...)),
+	}, nil
+}
+
+func emptyImage(platform ocispecs.Platform) *dockerspec.DockerOCIImage {
+	img := &dockerspec.DockerOCIImage{}
+	img.Architecture = platform.Architecture
+	img.OS = platform.OS
+	img.OSVersion = platform.OSVersion
+	if platform.OSFeatures != nil {
+		img.OSFeatures = slices.Clone(platform.OSFeatures)
+	}
+	img.Variant = platform.Variant
+	img.RootFS.Type = "layers"
+	img.Config.WorkingDir = "/"
+	if platform.OS != "windows" {
+		img.Config.Env = []string{"PATH=" + system.DefaultPathEnv(platform.OS)}
+	}
+	return img
+}
+
+func cloneImage(src *dockerspec.DockerOCIImage) *dockerspec.DockerOCIImage {
+	if src == nil {
+		return nil
+	}
+	img := *src
+	img.Config.Env = slices.Clone(src.Config.Env)
+	img.Config.Cmd = slices.Clone(src.Config.Cmd)
+	img.Config.Entrypoint = slices.Clone(src.Config.Entrypoint)
+	img.Config.OnBuild = slices.Clone(src.Config.OnBuild)
+	img.Config.Labels = maps.Clone(src.Config.Labels)
+	img.Config.ExposedPorts = maps.Clone(src.Config.ExposedPorts)
+	img.Config.Volumes = maps.Clone(src.Config.Volumes)
+	return &img
+}
+
+func setEnv(env []string, k, v string) []string {
+	for i, e := range env {
+		if ek, _, _ := strings.Cut(e, "="); ek == k {
+			env[i] = k + "=" + v
+			return env
+		}
+	}
+	return append(env, k+"="+v)
+}
+
+func resolveDir(wd, dir string) string {
+	if !path.IsAbs(dir) {
+		dir = path.Join("/", wd, dir)
+	}
+	return path.Clean(dir)
+}
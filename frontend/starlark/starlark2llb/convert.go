@@ -0,0 +1,293 @@
+// Package starlark2llb converts build definitions written in Starlark into
+// LLB. A definition is a file of Starlark functions, each of them a build
+// target, that are called with a build context and return the state to export.
+package starlark2llb
+
+import (
+	"context"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/frontend/dockerui"
+	"github.com/moby/buildkit/frontend/subrequests/targets"
+	"github.com/moby/buildkit/solver/errdefs"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/suggest"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+const (
+	// DefaultFilename is the name of the build definition read from the
+	// dockerfile local when no filename is set
+	DefaultFilename = "build.star"
+	// DefaultTarget is the function called when no target is set
+	DefaultTarget = "build"
+)
+
+var fileOptions = &syntax.FileOptions{
+	Set:             true,
+	TopLevelControl: true,
+}
+
+type ConvertOpt struct {
+	dockerui.Config
+	Client         *dockerui.Client
+	SourceMap      *llb.SourceMap
+	TargetPlatform *ocispecs.Platform
+	MetaResolver   llb.ImageMetaResolver
+}
+
+// Starlark2LLB evaluates the build definition dt and calls the target function
+// of opt. It returns the state returned by the function, the image config
+// accumulated for it and the config of the image it is based on, if any.
+func Starlark2LLB(ctx context.Context, dt []byte, opt ConvertOpt) (st *llb.State, img, baseImg *dockerspec.DockerOCIImage, err error) {
+	c := newConverter(ctx, opt)
+	defer func() {
+		err = c.wrapSource(err)
+	}()
+
+	thread := c.newThread()
+	stop := context.AfterFunc(ctx, func() {
+		thread.Cancel(context.Cause(ctx).Error())
+	})
+	defer stop()
+
+	globals, err := starlark.ExecFileOptions(fileOptions, thread, c.filename(), dt, c.builtins())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	target := opt.Target
+	if target == "" {
+		target = DefaultTarget
+	}
+	fn, ok := globals[target].(*starlark.Function)
+	if !ok || strings.HasPrefix(target, "_") {
+		var names []string
+		for _, name := range globals.Keys() {
+			if _, ok := globals[name].(*starlark.Function); ok && !strings.HasPrefix(name, "_") {
+				names = append(names, name)
+			}
+		}
+		return nil, nil, nil, suggest.WrapError(errors.Errorf("target function %q could not be found", target), target, names, true)
+	}
+
+	var args starlark.Tuple
+	if fn.NumParams() > 0 {
+		args = starlark.Tuple{c.buildContext(target)}
+	}
+	v, err := starlark.Call(thread, fn, args, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	res, ok := v.(*State)
+	if !ok {
+		return nil, nil, nil, errors.Errorf("target function %q returned %s, want state", target, v.Type())
+	}
+
+	img = cloneImage(res.img)
+	for k, v := range opt.Labels {
+		if img.Config.Labels == nil {
+			img.Config.Labels = map[string]string{}
+		}
+		img.Config.Labels[k] = v
+	}
+	return &res.st, img, cloneImage(res.base), nil
+}
+
+// ListTargets returns the functions of the build definition that can be
+// built. Functions starting with an underscore are private and not listed.
+func ListTargets(ctx context.Context, dt []byte) (*targets.List, error) {
+	f, err := fileOptions.Parse(DefaultFilename, dt, syntax.RetainComments)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &targets.List{
+		Sources: [][]byte{dt},
+	}
+	for _, stmt := range f.Stmts {
+		def, ok := stmt.(*syntax.DefStmt)
+		if !ok || strings.HasPrefix(def.Name.Name, "_") {
+			continue
+		}
+		start, end := def.Span()
+		l.Targets = append(l.Targets, targets.Target{
+			Name:        def.Name.Name,
+			Description: docString(def),
+			Default:     def.Name.Name == DefaultTarget,
+			Location: &pb.Location{
+				Ranges: []*pb.Range{toRange(start, end)},
+			},
+		})
+	}
+	return l, nil
+}
+
+func docString(def *syntax.DefStmt) string {
+	if len(def.Body) == 0 {
+		return ""
+	}
+	expr, ok := def.Body[0].(*syntax.ExprStmt)
+	if !ok {
+		return ""
+	}
+	lit, ok := expr.X.(*syntax.Literal)
+	if !ok || lit.Token != syntax.STRING {
+		return ""
+	}
+	s, _ := lit.Value.(string)
+	return strings.TrimSpace(s)
+}
+
+type converter struct {
+	ctx      context.Context
+	opt      ConvertOpt
+	platform ocispecs.Platform
+	images   map[string]*State
+}
+
+func newConverter(ctx context.Context, opt ConvertOpt) *converter {
+	c := &converter{
+		ctx:    ctx,
+		opt:    opt,
+		images: map[string]*State{},
+	}
+	if opt.TargetPlatform != nil {
+		c.platform = platforms.Normalize(*opt.TargetPlatform)
+	} else {
+		c.platform = platforms.Normalize(platforms.DefaultSpec())
+	}
+	return c
+}
+
+func (c *converter) filename() string {
+	if c.opt.SourceMap != nil && c.opt.SourceMap.Filename != "" {
+		return c.opt.SourceMap.Filename
+	}
+	return DefaultFilename
+}
+
+func (c *converter) newThread() *starlark.Thread {
+	return &starlark.Thread{
+		Name: "build",
+		Print: func(_ *starlark.Thread, msg string) {
+			bklog.G(c.ctx).Debugf("%s: %s", c.filename(), msg)
+		},
+		Load: func(*starlark.Thread, string) (starlark.StringDict, error) {
+			return nil, errors.New("load statements are not supported")
+		},
+	}
+}
+
+func (c *converter) builtins() starlark.StringDict {
+	return starlark.StringDict{
+		"image":   starlark.NewBuiltin("image", c.image),
+		"scratch": starlark.NewBuiltin("scratch", c.scratch),
+		"git":     starlark.NewBuiltin("git", c.git),
+		"http":    starlark.NewBuiltin("http", c.http),
+		"cache":   starlark.NewBuiltin("cache", c.cacheMount),
+		"secret":  starlark.NewBuiltin("secret", c.secretMount),
+		"ssh":     starlark.NewBuiltin("ssh", c.sshMount),
+		"bind":    starlark.NewBuiltin("bind", c.bindMount),
+		"tmpfs":   starlark.NewBuiltin("tmpfs", c.tmpfsMount),
+		"struct":  starlark.NewBuiltin("struct", starlarkstruct.Make),
+	}
+}
+
+// buildContext returns the value passed to the target function
+func (c *converter) buildContext(target string) starlark.Value {
+	args := starlark.NewDict(len(c.opt.BuildArgs))
+	for k, v := range c.opt.BuildArgs {
+		_ = args.SetKey(starlark.String(k), starlark.String(v))
+	}
+	args.Freeze()
+
+	buildPlatform := platforms.DefaultSpec()
+	if len(c.opt.BuildPlatforms) > 0 {
+		buildPlatform = c.opt.BuildPlatforms[0]
+	}
+
+	return starlarkstruct.FromStringDict(starlark.String("ctx"), starlark.StringDict{
+		"args":           args,
+		"target":         starlark.String(target),
+		"platform":       platformValue(c.platform),
+		"build_platform": platformValue(platforms.Normalize(buildPlatform)),
+		"context":        starlark.NewBuiltin("context", c.context),
+	})
+}
+
+func platformValue(p ocispecs.Platform) starlark.Value {
+	return starlarkstruct.FromStringDict(starlark.String("platform"), starlark.StringDict{
+		"os":      starlark.String(p.OS),
+		"arch":    starlark.String(p.Architecture),
+		"variant": starlark.String(p.Variant),
+		"spec":    starlark.String(platforms.FormatAll(p)),
+	})
+}
+
+// location returns the source location of the call to the current builtin
+func (c *converter) location(thread *starlark.Thread) llb.ConstraintsOpt {
+	pos := thread.CallFrame(1).Pos
+	return c.opt.SourceMap.Location([]*pb.Range{toRange(pos, pos)})
+}
+
+// customName prefixes the vertex name with the platform in multi-platform
+// builds, like the Dockerfile frontend does
+func (c *converter) customName(name string) llb.ConstraintsOpt {
+	if c.opt.MultiPlatformRequested {
+		name = "[" + platforms.FormatAll(c.platform) + "] " + name
+	}
+	return llb.WithCustomName(name)
+}
+
+func (c *converter) wrapSource(err error) error {
+	if err == nil || c.opt.SourceMap == nil {
+		return err
+	}
+	var pos syntax.Position
+	var evalErr *starlark.EvalError
+	var syntaxErr syntax.Error
+	var resolveErrs resolve.ErrorList
+	switch {
+	case errors.As(err, &evalErr):
+		for i := range evalErr.CallStack {
+			if fr := evalErr.CallStack.At(i); fr.Pos.Filename() == c.filename() {
+				pos = fr.Pos
+				break
+			}
+		}
+	case errors.As(err, &syntaxErr):
+		pos = syntaxErr.Pos
+	case errors.As(err, &resolveErrs) && len(resolveErrs) > 0:
+		pos = resolveErrs[0].Pos
+	}
+	if !pos.IsValid() {
+		return err
+	}
+	sm := c.opt.SourceMap
+	return errdefs.WithSource(err, &errdefs.Source{
+		Info: &pb.SourceInfo{
+			Data:       sm.Data,
+			Filename:   sm.Filename,
+			Language:   sm.Language,
+			Definition: sm.Definition.ToPB(),
+		},
+		Ranges: []*pb.Range{toRange(pos, pos)},
+	})
+}
+
+func toRange(start, end syntax.Position) *pb.Range {
+	return &pb.Range{
+		Start: &pb.Position{Line: start.Line, Character: start.Col},
+		End:   &pb.Position{Line: end.Line, Character: end.Col},
+	}
+}
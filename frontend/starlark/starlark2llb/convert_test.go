@@ -0,0 +1,229 @@
+package starlark2llb
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/client/llb/sourceresolver"
+	"github.com/moby/buildkit/frontend/dockerui"
+	"github.com/moby/buildkit/solver/errdefs"
+	"github.com/moby/buildkit/solver/pb"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+type testResolver struct {
+	reqs []string
+}
+
+func (r *testResolver) ResolveImageConfig(ctx context.Context, ref string, opt sourceresolver.Opt) (string, digest.Digest, []byte, error) {
+	r.reqs = append(r.reqs, ref+"::"+opt.Platform.Architecture)
+	img := dockerspec.DockerOCIImage{
+		Image: ocispecs.Image{
+			Platform: *opt.Platform,
+		},
+		Config: dockerspec.DockerOCIImageConfig{
+			ImageConfig: ocispecs.ImageConfig{
+				Env:        []string{"PATH=/usr/local/go/bin:/usr/bin:/bin", "GOLANG_VERSION=1.24"},
+				WorkingDir: "/go",
+				Cmd:        []string{"bash"},
+			},
+		},
+	}
+	dt, err := json.Marshal(img)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return ref, digest.FromBytes(dt), dt, nil
+}
+
+func parseOps(t *testing.T, def [][]byte) []*pb.Op {
+	ops := make([]*pb.Op, 0, len(def))
+	for _, dt := range def {
+		var op pb.Op
+		require.NoError(t, op.Unmarshal(dt))
+		ops = append(ops, &op)
+	}
+	return ops
+}
+
+const testDefinition = `
+def _golang(ctx):
+    return image("golang:1.24").env(CGO_ENABLED = "0")
+
+def test():
+    """Runs the unit tests."""
+    return scratch().mkfile("/ok", "ok")
+
+def build(ctx):
+    """Builds the app."""
+    src = ctx.context()
+    out = _golang(ctx).workdir("src").run(
+        "go build -o /out/app .",
+        mounts = [bind("/go/src", src), cache("/root/.cache/go-build")],
+        env = {"GOARCH": ctx.platform.arch, "VERSION": ctx.args.get("VERSION", "dev")},
+    )
+    return scratch().copy(out, "/out/app", "/usr/bin/").config(
+        entrypoint = ["/usr/bin/app"],
+        expose = ["8080"],
+    )
+`
+
+func TestStarlark2LLB(t *testing.T) {
+	ctx := context.TODO()
+	resolver := &testResolver{}
+	platform := ocispecs.Platform{OS: "linux", Architecture: "arm64"}
+	st, img, baseImg, err := Starlark2LLB(ctx, []byte(testDefinition), ConvertOpt{
+		Config: dockerui.Config{
+			BuildArgs:        map[string]string{"VERSION": "1.0"},
+			CacheIDNamespace: "ns",
+			Labels:           map[string]string{"foo": "bar"},
+		},
+		TargetPlatform: &platform,
+		MetaResolver:   resolver,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"docker.io/library/golang:1.24::arm64"}, resolver.reqs)
+
+	require.Equal(t, "arm64", img.Architecture)
+	require.Equal(t, []string{"/usr/bin/app"}, img.Config.Entrypoint)
+	require.Nil(t, img.Config.Cmd)
+	require.Equal(t, "/", img.Config.WorkingDir)
+	require.Contains(t, img.Config.ExposedPorts, "8080/tcp")
+	require.Equal(t, map[string]string{"foo": "bar"}, img.Config.Labels)
+	require.Nil(t, baseImg)
+
+	def, err := st.Marshal(ctx)
+	require.NoError(t, err)
+	var exec *pb.ExecOp
+	var copyOp *pb.FileActionCopy
+	var source *pb.SourceOp
+	for _, op := range parseOps(t, def.Def) {
+		switch {
+		case op.GetExec() != nil:
+			exec = op.GetExec()
+		case op.GetFile() != nil:
+			copyOp = op.GetFile().Actions[0].GetCopy()
+		case op.GetSource() != nil && op.GetSource().Identifier != "local://context":
+			source = op.GetSource()
+		}
+	}
+	require.NotNil(t, source)
+	require.Contains(t, source.Identifier, "docker-image://docker.io/library/golang:1.24@sha256:")
+
+	require.NotNil(t, exec)
+	require.Equal(t, []string{"/bin/sh", "-c", "go build -o /out/app ."}, exec.Meta.Args)
+	require.Equal(t, "/go/src", exec.Meta.Cwd)
+	require.Contains(t, exec.Meta.Env, "CGO_ENABLED=0")
+	require.Contains(t, exec.Meta.Env, "GOARCH=arm64")
+	require.Contains(t, exec.Meta.Env, "VERSION=1.0")
+	require.Contains(t, exec.Meta.Env, "GOLANG_VERSION=1.24")
+	var cacheID string
+	var bindSelector string
+	for _, m := range exec.Mounts {
+		switch m.Dest {
+		case "/root/.cache/go-build":
+			cacheID = m.CacheOpt.ID
+		case "/go/src":
+			bindSelector = m.Selector
+			require.True(t, m.Readonly)
+		}
+	}
+	require.Equal(t, "ns//root/.cache/go-build", cacheID)
+	require.Equal(t, "/", bindSelector)
+
+	require.NotNil(t, copyOp)
+	require.Equal(t, "/out/app", copyOp.Src)
+	require.Equal(t, "/usr/bin/", copyOp.Dest)
+	require.True(t, copyOp.AllowWildcard)
+	require.True(t, copyOp.CreateDestPath)
+}
+
+func TestStarlark2LLBTargets(t *testing.T) {
+	ctx := context.TODO()
+
+	st, img, _, err := Starlark2LLB(ctx, []byte(testDefinition), ConvertOpt{
+		Config: dockerui.Config{Target: "test"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "/", img.Config.WorkingDir)
+	_, err = st.Marshal(ctx)
+	require.NoError(t, err)
+
+	_, _, _, err = Starlark2LLB(ctx, []byte(testDefinition), ConvertOpt{
+		Config: dockerui.Config{Target: "tset"},
+	})
+	require.ErrorContains(t, err, `target function "tset" could not be found`)
+	require.ErrorContains(t, err, "did you mean test?")
+
+	_, _, _, err = Starlark2LLB(ctx, []byte(testDefinition), ConvertOpt{
+		Config: dockerui.Config{Target: "_golang"},
+	})
+	require.ErrorContains(t, err, `target function "_golang" could not be found`)
+
+	l, err := ListTargets(ctx, []byte(testDefinition))
+	require.NoError(t, err)
+	require.Len(t, l.Targets, 2)
+	require.Equal(t, "test", l.Targets[0].Name)
+	require.Equal(t, "Runs the unit tests.", l.Targets[0].Description)
+	require.False(t, l.Targets[0].Default)
+	require.Equal(t, "build", l.Targets[1].Name)
+	require.True(t, l.Targets[1].Default)
+	require.Equal(t, int32(9), l.Targets[1].Location.Ranges[0].Start.Line)
+}
+
+func TestStarlark2LLBErrors(t *testing.T) {
+	ctx := context.TODO()
+	for _, tc := range []struct {
+		def string
+		err string
+	}{
+		{
+			def: "def build(ctx):\n    return 1\n",
+			err: `target function "build" returned int, want state`,
+		},
+		{
+			def: "def build(ctx):\n    return scratch().run(1)\n",
+			err: "run: got int for command, want string or list",
+		},
+		{
+			def: "def build(ctx):\n    return scratch().run(\"true\", network = \"vpn\")\n",
+			err: `run: invalid network mode "vpn"`,
+		},
+		{
+			def: "def build(ctx):\n    return scratch().run(\"true\", mounts = [\"/tmp\"])\n",
+			err: "run: mounts[0] is string, want mount",
+		},
+		{
+			def: "def build(ctx):\n    return ctx.context(\"missing\")\n",
+			err: `context "missing" was not passed to the build`,
+		},
+		{
+			def: "load(\"lib.star\", \"x\")\ndef build(ctx):\n    return scratch()\n",
+			err: "load statements are not supported",
+		},
+		{
+			def: "def build(ctx)\n",
+			err: "got newline, want ':'",
+		},
+	} {
+		_, _, _, err := Starlark2LLB(ctx, []byte(tc.def), ConvertOpt{})
+		require.ErrorContains(t, err, tc.err, tc.def)
+	}
+}
+
+func TestStarlark2LLBErrorSource(t *testing.T) {
+	dt := []byte("def build(ctx):\n    st = scratch()\n    return st.run(\"true\", network = \"vpn\")\n")
+	sm := llb.NewSourceMap(nil, "build.star", "Starlark", dt)
+	sm.Definition = &llb.Definition{}
+	_, _, _, err := Starlark2LLB(context.TODO(), dt, ConvertOpt{SourceMap: sm})
+	require.ErrorContains(t, err, `invalid network mode "vpn"`)
+	srcs := errdefs.Sources(err)
+	require.Len(t, srcs, 1)
+	require.Equal(t, "build.star", srcs[0].Info.Filename)
+	require.Equal(t, int32(3), srcs[0].Ranges[0].Start.Line)
+}
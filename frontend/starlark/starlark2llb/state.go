@@ -0,0 +1,396 @@
+package starlark2llb
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/solver/pb"
+	dockerspec "github.com/moby/docker-image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"go.starlark.net/starlark"
+)
+
+// State is the Starlark value of an LLB state and the image config that is
+// exported with it. States are immutable, all methods return a new State.
+type State struct {
+	c    *converter
+	st   llb.State
+	img  *dockerspec.DockerOCIImage
+	base *dockerspec.DockerOCIImage
+}
+
+var (
+	_ starlark.Value    = &State{}
+	_ starlark.HasAttrs = &State{}
+)
+
+type stateMethod func(s *State, thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error)
+
+var stateMethods = map[string]stateMethod{
+	"run":     (*State).run,
+	"copy":    (*State).copy,
+	"mkdir":   (*State).mkdir,
+	"mkfile":  (*State).mkfile,
+	"rm":      (*State).rm,
+	"env":     (*State).env,
+	"workdir": (*State).workdir,
+	"user":    (*State).user,
+	"config":  (*State).config,
+}
+
+func (s *State) String() string        { return "state" }
+func (s *State) Type() string          { return "state" }
+func (s *State) Freeze()               {}
+func (s *State) Truth() starlark.Bool  { return starlark.True }
+func (s *State) Hash() (uint32, error) { return 0, errors.New("unhashable type: state") }
+
+func (s *State) Attr(name string) (starlark.Value, error) {
+	m, ok := stateMethods[name]
+	if !ok {
+		return nil, nil
+	}
+	return starlark.NewBuiltin(name, func(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		return m(b.Receiver().(*State), thread, b, args, kwargs)
+	}).BindReceiver(s), nil
+}
+
+func (s *State) AttrNames() []string {
+	names := make([]string, 0, len(stateMethods))
+	for name := range stateMethods {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (s *State) with(st llb.State, img *dockerspec.DockerOCIImage) *State {
+	return &State{
+		c:    s.c,
+		st:   st,
+		img:  img,
+		base: s.base,
+	}
+}
+
+// run(cmd, mounts=[], env={}, workdir="", user="", network="", security="")
+// runs a command in the state. A string command is run with /bin/sh -c.
+func (s *State) run(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var cmd starlark.Value
+	var mounts *starlark.List
+	var env *starlark.Dict
+	var workdir, user, network, security string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "cmd", &cmd, "mounts?", &mounts, "env?", &env, "workdir?", &workdir, "user?", &user, "network?", &network, "security?", &security); err != nil {
+		return nil, err
+	}
+	cmdArgs, err := command(b.Name(), cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []llb.RunOption{
+		llb.Args(cmdArgs),
+		s.c.customName(strings.Join(cmdArgs, " ")),
+		s.c.location(thread),
+	}
+	envs, err := stringMap(b.Name(), "env", env)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range envs {
+		opts = append(opts, llb.AddEnv(kv[0], kv[1]))
+	}
+	if workdir != "" {
+		opts = append(opts, llb.Dir(resolveDir(s.img.Config.WorkingDir, workdir)))
+	}
+	if user != "" {
+		opts = append(opts, llb.User(user))
+	}
+
+	netMode := s.c.opt.NetworkMode
+	switch network {
+	case "":
+	case "default", "sandbox":
+		netMode = pb.NetMode_UNSET
+	case "none":
+		netMode = pb.NetMode_NONE
+	case "host":
+		netMode = pb.NetMode_HOST
+	default:
+		return nil, errors.Errorf("%s: invalid network mode %q", b.Name(), network)
+	}
+	opts = append(opts, llb.Network(netMode))
+
+	switch security {
+	case "", "sandbox":
+	case "insecure":
+		opts = append(opts, llb.Security(pb.SecurityMode_INSECURE))
+	default:
+		return nil, errors.Errorf("%s: invalid security mode %q", b.Name(), security)
+	}
+
+	for _, h := range s.c.opt.ExtraHosts {
+		opts = append(opts, llb.AddExtraHost(h.Host, h.IP))
+	}
+	if s.c.opt.Hostname != "" {
+		opts = append(opts, llb.Hostname(s.c.opt.Hostname))
+	}
+	if s.c.opt.CgroupParent != "" {
+		opts = append(opts, llb.WithCgroupParent(s.c.opt.CgroupParent))
+	}
+
+	if mounts != nil {
+		for i := range mounts.Len() {
+			m, ok := mounts.Index(i).(*Mount)
+			if !ok {
+				return nil, errors.Errorf("%s: mounts[%d] is %s, want mount", b.Name(), i, mounts.Index(i).Type())
+			}
+			opts = append(opts, m.opt)
+		}
+	}
+
+	return s.with(s.st.Run(opts...).Root(), s.img), nil
+}
+
+// copy(src, source="/", dest="/", include=[], exclude=[], chown="", chmod=None)
+// copies files from another state
+func (s *State) copy(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var src *State
+	var include, exclude *starlark.List
+	var chown string
+	var chmod starlark.Value = starlark.None
+	source, dest := "/", "/"
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "src", &src, "source?", &source, "dest?", &dest, "include?", &include, "exclude?", &exclude, "chown?", &chown, "chmod?", &chmod); err != nil {
+		return nil, err
+	}
+	info := &llb.CopyInfo{
+		FollowSymlinks:      true,
+		CopyDirContentsOnly: true,
+		CreateDestPath:      true,
+		AllowWildcard:       true,
+		AllowEmptyWildcard:  true,
+	}
+	var err error
+	if info.IncludePatterns, err = stringList(b.Name(), "include", include); err != nil {
+		return nil, err
+	}
+	if info.ExcludePatterns, err = stringList(b.Name(), "exclude", exclude); err != nil {
+		return nil, err
+	}
+	opts := []llb.CopyOption{info}
+	if chown != "" {
+		opts = append(opts, llb.WithUser(chown))
+	}
+	if chmod != starlark.None {
+		mode, err := starlark.AsInt32(chmod)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: invalid chmod", b.Name())
+		}
+		info.Mode = &llb.ChmodOpt{Mode: os.FileMode(mode)}
+	}
+	// a trailing slash copies into the destination directory
+	isDir := strings.HasSuffix(dest, "/")
+	dest = resolveDir(s.img.Config.WorkingDir, dest)
+	if isDir && dest != "/" {
+		dest += "/"
+	}
+	st := s.st.File(llb.Copy(src.st, source, dest, opts...), s.c.customName("copy "+source+" "+dest), s.c.location(thread))
+	return s.with(st, s.img), nil
+}
+
+// mkdir(path, mode=0o755, parents=True) creates a directory
+func (s *State) mkdir(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p string
+	mode := 0o755
+	parents := true
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &p, "mode?", &mode, "parents?", &parents); err != nil {
+		return nil, err
+	}
+	p = resolveDir(s.img.Config.WorkingDir, p)
+	st := s.st.File(llb.Mkdir(p, os.FileMode(mode), llb.WithParents(parents)), s.c.customName("mkdir "+p), s.c.location(thread))
+	return s.with(st, s.img), nil
+}
+
+// mkfile(path, data, mode=0o644) creates a file
+func (s *State) mkfile(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p, data string
+	mode := 0o644
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &p, "data", &data, "mode?", &mode); err != nil {
+		return nil, err
+	}
+	p = resolveDir(s.img.Config.WorkingDir, p)
+	st := s.st.File(llb.Mkfile(p, os.FileMode(mode), []byte(data)), s.c.customName("mkfile "+p), s.c.location(thread))
+	return s.with(st, s.img), nil
+}
+
+// rm(path) removes a path, wildcards are allowed and missing paths ignored
+func (s *State) rm(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &p); err != nil {
+		return nil, err
+	}
+	p = resolveDir(s.img.Config.WorkingDir, p)
+	st := s.st.File(llb.Rm(p, llb.WithAllowNotFound(true), llb.WithAllowWildcard(true)), s.c.customName("rm "+p), s.c.location(thread))
+	return s.with(st, s.img), nil
+}
+
+// env(**kwargs) sets environment variables for the following runs and in the
+// image config
+func (s *State) env(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) > 0 {
+		return nil, errors.Errorf("%s: unexpected positional arguments", b.Name())
+	}
+	st := s.st
+	img := cloneImage(s.img)
+	for _, kv := range kwargs {
+		k := string(kv[0].(starlark.String))
+		v, ok := starlark.AsString(kv[1])
+		if !ok {
+			return nil, errors.Errorf("%s: value of %s is %s, want string", b.Name(), k, kv[1].Type())
+		}
+		st = st.AddEnv(k, v)
+		img.Config.Env = setEnv(img.Config.Env, k, v)
+	}
+	return s.with(st, img), nil
+}
+
+// workdir(path) sets the working directory of the following operations and in
+// the image config. A relative path is resolved against the current one.
+func (s *State) workdir(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var p string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "path", &p); err != nil {
+		return nil, err
+	}
+	img := cloneImage(s.img)
+	img.Config.WorkingDir = resolveDir(s.img.Config.WorkingDir, p)
+	return s.with(s.st.Dir(img.Config.WorkingDir), img), nil
+}
+
+// user(name) sets the user of the following runs and in the image config
+func (s *State) user(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "name", &name); err != nil {
+		return nil, err
+	}
+	img := cloneImage(s.img)
+	img.Config.User = name
+	return s.with(s.st.User(name), img), nil
+}
+
+// config(entrypoint=None, cmd=None, labels={}, expose=[], volumes=[],
+// stop_signal="") sets fields of the image config that don't affect the build
+func (s *State) config(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var entrypoint, cmd starlark.Value = starlark.None, starlark.None
+	var labels *starlark.Dict
+	var expose, volumes *starlark.List
+	var stopSignal string
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "entrypoint?", &entrypoint, "cmd?", &cmd, "labels?", &labels, "expose?", &expose, "volumes?", &volumes, "stop_signal?", &stopSignal); err != nil {
+		return nil, err
+	}
+	img := cloneImage(s.img)
+	if entrypoint != starlark.None {
+		v, err := command(b.Name(), entrypoint)
+		if err != nil {
+			return nil, err
+		}
+		img.Config.Entrypoint = v
+		// like in Dockerfiles, setting the entrypoint resets the command
+		// inherited from the base image
+		if cmd == starlark.None {
+			img.Config.Cmd = nil
+		}
+	}
+	if cmd != starlark.None {
+		v, err := command(b.Name(), cmd)
+		if err != nil {
+			return nil, err
+		}
+		img.Config.Cmd = v
+	}
+	kvs, err := stringMap(b.Name(), "labels", labels)
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range kvs {
+		if img.Config.Labels == nil {
+			img.Config.Labels = map[string]string{}
+		}
+		img.Config.Labels[kv[0]] = kv[1]
+	}
+	ports, err := stringList(b.Name(), "expose", expose)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ports {
+		if !strings.Contains(p, "/") {
+			p += "/tcp"
+		}
+		if img.Config.ExposedPorts == nil {
+			img.Config.ExposedPorts = map[string]struct{}{}
+		}
+		img.Config.ExposedPorts[p] = struct{}{}
+	}
+	vols, err := stringList(b.Name(), "volumes", volumes)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vols {
+		if img.Config.Volumes == nil {
+			img.Config.Volumes = map[string]struct{}{}
+		}
+		img.Config.Volumes[v] = struct{}{}
+	}
+	if stopSignal != "" {
+		img.Config.StopSignal = stopSignal
+	}
+	return s.with(s.st, img), nil
+}
+
+// command converts a command value, a string run with /bin/sh -c or a list
+// of arguments
+func command(fnname string, v starlark.Value) ([]string, error) {
+	if s, ok := starlark.AsString(v); ok {
+		return []string{"/bin/sh", "-c", s}, nil
+	}
+	l, ok := v.(*starlark.List)
+	if !ok {
+		return nil, errors.Errorf("%s: got %s for command, want string or list", fnname, v.Type())
+	}
+	return stringList(fnname, "command", l)
+}
+
+func stringList(fnname, name string, l *starlark.List) ([]string, error) {
+	if l == nil {
+		return nil, nil
+	}
+	out := make([]string, 0, l.Len())
+	for i := range l.Len() {
+		s, ok := starlark.AsString(l.Index(i))
+		if !ok {
+			return nil, errors.Errorf("%s: %s[%d] is %s, want string", fnname, name, i, l.Index(i).Type())
+		}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// stringMap returns the items of a dict of strings in insertion order
+func stringMap(fnname, name string, d *starlark.Dict) ([][2]string, error) {
+	if d == nil {
+		return nil, nil
+	}
+	out := make([][2]string, 0, d.Len())
+	for _, item := range d.Items() {
+		k, ok := starlark.AsString(item[0])
+		if !ok {
+			return nil, errors.Errorf("%s: %s key is %s, want string", fnname, name, item[0].Type())
+		}
+		v, ok := starlark.AsString(item[1])
+		if !ok {
+			return nil, errors.Errorf("%s: %s[%q] is %s, want string", fnname, name, k, item[1].Type())
+		}
+		out = append(out, [2]string{k, v})
+	}
+	return out, nil
+}
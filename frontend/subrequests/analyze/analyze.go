@@ -0,0 +1,150 @@
+package analyze
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/moby/buildkit/frontend/subrequests"
+	"github.com/moby/buildkit/solver/pb"
+)
+
+const RequestAnalyze = "frontend.analyze"
+
+var SubrequestAnalyzeDefinition = subrequests.Request{
+	Name:        RequestAnalyze,
+	Version:     "1.0.0",
+	Type:        subrequests.TypeRPC,
+	Description: "Report unused stages and build args, COPY sources matching no files and stages that can run in parallel",
+	Opts: []subrequests.Named{
+		{
+			Name:        "target",
+			Description: "Target build stage",
+		},
+	},
+	Metadata: []subrequests.Named{
+		{Name: "result.json"},
+		{Name: "result.txt"},
+	},
+}
+
+type Report struct {
+	Target           string          `json:"target,omitempty"`
+	UnusedStages     []Stage         `json:"unusedStages,omitempty"`
+	UnusedArgs       []Arg           `json:"unusedArgs,omitempty"`
+	EmptyCopySources []CopySource    `json:"emptyCopySources,omitempty"`
+	ParallelGroups   []ParallelGroup `json:"parallelGroups,omitempty"`
+	Sources          [][]byte        `json:"sources,omitempty"`
+}
+
+func (r Report) ToResult() (*client.Result, error) {
+	res := client.NewResult()
+	dt, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	res.AddMeta("result.json", dt)
+
+	b := bytes.NewBuffer(nil)
+	if err := PrintReport(dt, b); err != nil {
+		return nil, err
+	}
+	res.AddMeta("result.txt", b.Bytes())
+
+	res.AddMeta("version", []byte(SubrequestAnalyzeDefinition.Version))
+	return res, nil
+}
+
+// Stage is a build stage the target does not depend on
+type Stage struct {
+	Name     string       `json:"name,omitempty"`
+	Index    int          `json:"index"`
+	Location *pb.Location `json:"location,omitempty"`
+}
+
+// Arg is a build arg that is declared but never read. Stage is empty for
+// args declared before the first FROM.
+type Arg struct {
+	Name     string       `json:"name"`
+	Stage    string       `json:"stage,omitempty"`
+	Location *pb.Location `json:"location,omitempty"`
+}
+
+// CopySource is a source of a COPY or ADD instruction that matches no files
+// in the build context
+type CopySource struct {
+	Source   string       `json:"source"`
+	Stage    string       `json:"stage,omitempty"`
+	Location *pb.Location `json:"location,omitempty"`
+}
+
+// ParallelGroup is a set of stages of the target that do not depend on each
+// other and can be built at the same time
+type ParallelGroup struct {
+	Stages []string `json:"stages"`
+}
+
+func PrintReport(dt []byte, w io.Writer) error {
+	var r Report
+
+	if err := json.Unmarshal(dt, &r); err != nil {
+		return err
+	}
+
+	if len(r.UnusedStages) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(tw, "UNUSED STAGE\tLINE\n")
+		for _, s := range r.UnusedStages {
+			name := s.Name
+			if name == "" {
+				name = fmt.Sprintf("%d", s.Index)
+			}
+			fmt.Fprintf(tw, "%s\t%s\n", name, line(s.Location))
+		}
+		tw.Flush()
+		fmt.Fprintln(tw)
+	}
+
+	if len(r.UnusedArgs) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(tw, "UNUSED ARG\tSTAGE\tLINE\n")
+		for _, a := range r.UnusedArgs {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", a.Name, a.Stage, line(a.Location))
+		}
+		tw.Flush()
+		fmt.Fprintln(tw)
+	}
+
+	if len(r.EmptyCopySources) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(tw, "EMPTY COPY SOURCE\tSTAGE\tLINE\n")
+		for _, c := range r.EmptyCopySources {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Source, c.Stage, line(c.Location))
+		}
+		tw.Flush()
+		fmt.Fprintln(tw)
+	}
+
+	if len(r.ParallelGroups) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		fmt.Fprintf(tw, "PARALLEL STAGES\n")
+		for _, g := range r.ParallelGroups {
+			fmt.Fprintf(tw, "%s\n", strings.Join(g.Stages, ", "))
+		}
+		tw.Flush()
+		fmt.Fprintln(tw)
+	}
+
+	return nil
+}
+
+func line(loc *pb.Location) string {
+	if loc == nil || len(loc.Ranges) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", loc.Ranges[0].Start.Line)
+}
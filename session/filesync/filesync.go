@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"net/url"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
@@ -68,6 +70,18 @@ func (sp *fsSyncProvider) Register(server *grpc.Server) {
 	RegisterFileSyncServer(server, sp)
 }
 
+func (sp *fsSyncProvider) Resources() []session.Resource {
+	dirs, ok := sp.dirs.(StaticDirSource)
+	if !ok {
+		return nil
+	}
+	out := make([]session.Resource, 0, len(dirs))
+	for _, name := range slices.Sorted(maps.Keys(dirs)) {
+		out = append(out, session.Resource{Type: "filesync", ID: name})
+	}
+	return out
+}
+
 func (sp *fsSyncProvider) DiffCopy(stream FileSync_DiffCopyServer) error {
 	return sp.handle("diffcopy", stream)
 }
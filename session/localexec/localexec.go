@@ -0,0 +1,45 @@
+package localexec
+
+import (
+	"context"
+	"io"
+
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+)
+
+// MaxOutputSize is the maximum byte length of the output of a command
+const MaxOutputSize = 10 * 1024 * 1024 // 10MB
+
+var ErrNotFound = errors.Errorf("not found")
+
+// Exec runs the command id registered by the client of c and returns its
+// output
+func Exec(ctx context.Context, c session.Caller, id string) ([]byte, error) {
+	client := NewLocalExecClient(c.Conn())
+	stream, err := client.Exec(ctx, &ExecRequest{
+		ID: id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var dt []byte
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return dt, nil
+			}
+			if code := grpcerrors.Code(err); code == codes.Unimplemented || code == codes.NotFound {
+				return nil, errors.Wrapf(ErrNotFound, "local exec %s", id)
+			}
+			return nil, err
+		}
+		if len(dt)+len(resp.Data) > MaxOutputSize {
+			return nil, errors.Errorf("output of local exec %s exceeds the maximum size %d", id, MaxOutputSize)
+		}
+		dt = append(dt, resp.Data...)
+	}
+}
@@ -0,0 +1,175 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.11.4
+// source: github.com/moby/buildkit/session/localexec/localexec.proto
+
+package localexec
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ExecRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the command registered by the client
+	ID            string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecRequest) Reset() {
+	*x = ExecRequest{}
+	mi := &file_github_com_moby_buildkit_session_localexec_localexec_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecRequest) ProtoMessage() {}
+
+func (x *ExecRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_session_localexec_localexec_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecRequest.ProtoReflect.Descriptor instead.
+func (*ExecRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ExecRequest) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+// ExecResponse contains a chunk of the stdout of the command
+type ExecResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecResponse) Reset() {
+	*x = ExecResponse{}
+	mi := &file_github_com_moby_buildkit_session_localexec_localexec_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecResponse) ProtoMessage() {}
+
+func (x *ExecResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_session_localexec_localexec_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecResponse.ProtoReflect.Descriptor instead.
+func (*ExecResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ExecResponse) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+var File_github_com_moby_buildkit_session_localexec_localexec_proto protoreflect.FileDescriptor
+
+const file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDesc = "" +
+	"\n" +
+	":github.com/moby/buildkit/session/localexec/localexec.proto\x12\x1amoby.buildkit.localexec.v1\"\x1d\n" +
+	"\vExecRequest\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\"\"\n" +
+	"\fExecResponse\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data2h\n" +
+	"\tLocalExec\x12[\n" +
+	"\x04Exec\x12'.moby.buildkit.localexec.v1.ExecRequest\x1a(.moby.buildkit.localexec.v1.ExecResponse0\x01B,Z*github.com/moby/buildkit/session/localexecb\x06proto3"
+
+var (
+	file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDescOnce sync.Once
+	file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDescData []byte
+)
+
+func file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDescGZIP() []byte {
+	file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDescOnce.Do(func() {
+		file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDesc), len(file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDesc)))
+	})
+	return file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDescData
+}
+
+var file_github_com_moby_buildkit_session_localexec_localexec_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_github_com_moby_buildkit_session_localexec_localexec_proto_goTypes = []any{
+	(*ExecRequest)(nil),  // 0: moby.buildkit.localexec.v1.ExecRequest
+	(*ExecResponse)(nil), // 1: moby.buildkit.localexec.v1.ExecResponse
+}
+var file_github_com_moby_buildkit_session_localexec_localexec_proto_depIdxs = []int32{
+	0, // 0: moby.buildkit.localexec.v1.LocalExec.Exec:input_type -> moby.buildkit.localexec.v1.ExecRequest
+	1, // 1: moby.buildkit.localexec.v1.LocalExec.Exec:output_type -> moby.buildkit.localexec.v1.ExecResponse
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_github_com_moby_buildkit_session_localexec_localexec_proto_init() }
+func file_github_com_moby_buildkit_session_localexec_localexec_proto_init() {
+	if File_github_com_moby_buildkit_session_localexec_localexec_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDesc), len(file_github_com_moby_buildkit_session_localexec_localexec_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_github_com_moby_buildkit_session_localexec_localexec_proto_goTypes,
+		DependencyIndexes: file_github_com_moby_buildkit_session_localexec_localexec_proto_depIdxs,
+		MessageInfos:      file_github_com_moby_buildkit_session_localexec_localexec_proto_msgTypes,
+	}.Build()
+	File_github_com_moby_buildkit_session_localexec_localexec_proto = out.File
+	file_github_com_moby_buildkit_session_localexec_localexec_proto_goTypes = nil
+	file_github_com_moby_buildkit_session_localexec_localexec_proto_depIdxs = nil
+}
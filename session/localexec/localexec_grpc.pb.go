@@ -0,0 +1,128 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.11.4
+// source: github.com/moby/buildkit/session/localexec/localexec.proto
+
+package localexec
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LocalExec_Exec_FullMethodName = "/moby.buildkit.localexec.v1.LocalExec/Exec"
+)
+
+// LocalExecClient is the client API for LocalExec service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// LocalExec runs commands registered by the client on the client side for
+// the mounts of exec ops
+type LocalExecClient interface {
+	Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExecResponse], error)
+}
+
+type localExecClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLocalExecClient(cc grpc.ClientConnInterface) LocalExecClient {
+	return &localExecClient{cc}
+}
+
+func (c *localExecClient) Exec(ctx context.Context, in *ExecRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExecResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LocalExec_ServiceDesc.Streams[0], LocalExec_Exec_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExecRequest, ExecResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LocalExec_ExecClient = grpc.ServerStreamingClient[ExecResponse]
+
+// LocalExecServer is the server API for LocalExec service.
+// All implementations should embed UnimplementedLocalExecServer
+// for forward compatibility.
+//
+// LocalExec runs commands registered by the client on the client side for
+// the mounts of exec ops
+type LocalExecServer interface {
+	Exec(*ExecRequest, grpc.ServerStreamingServer[ExecResponse]) error
+}
+
+// UnimplementedLocalExecServer should be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLocalExecServer struct{}
+
+func (UnimplementedLocalExecServer) Exec(*ExecRequest, grpc.ServerStreamingServer[ExecResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Exec not implemented")
+}
+func (UnimplementedLocalExecServer) testEmbeddedByValue() {}
+
+// UnsafeLocalExecServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LocalExecServer will
+// result in compilation errors.
+type UnsafeLocalExecServer interface {
+	mustEmbedUnimplementedLocalExecServer()
+}
+
+func RegisterLocalExecServer(s grpc.ServiceRegistrar, srv LocalExecServer) {
+	// If the following call pancis, it indicates UnimplementedLocalExecServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LocalExec_ServiceDesc, srv)
+}
+
+func _LocalExec_Exec_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExecRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LocalExecServer).Exec(m, &grpc.GenericServerStream[ExecRequest, ExecResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LocalExec_ExecServer = grpc.ServerStreamingServer[ExecResponse]
+
+// LocalExec_ServiceDesc is the grpc.ServiceDesc for LocalExec service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LocalExec_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "moby.buildkit.localexec.v1.LocalExec",
+	HandlerType: (*LocalExecServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Exec",
+			Handler:       _LocalExec_Exec_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "github.com/moby/buildkit/session/localexec/localexec.proto",
+}
@@ -0,0 +1,146 @@
+package localexecprovider
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/localexec"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxStderrSize is the length of the stderr of a failed command kept for the
+// error
+const maxStderrSize = 4096
+
+// Command is a command that exec ops of the build can run on the client
+type Command struct {
+	ID string
+	// Args are the path and the arguments of the command
+	Args []string
+	// Env is the environment of the command. If empty, the command inherits
+	// the environment of the client.
+	Env []string
+	// Dir is the working directory of the command
+	Dir string
+}
+
+// NewProvider creates a session provider that runs the commands for the exec
+// ops that mount their output. The exec ops also need the local.exec
+// entitlement.
+func NewProvider(cmds []Command) (session.Attachable, error) {
+	m := make(map[string]Command, len(cmds))
+	for _, c := range cmds {
+		if c.ID == "" {
+			return nil, errors.Errorf("local exec command missing ID")
+		}
+		if len(c.Args) == 0 {
+			return nil, errors.Errorf("local exec command %s missing args", c.ID)
+		}
+		if _, ok := m[c.ID]; ok {
+			return nil, errors.Errorf("duplicate local exec command ID %q", c.ID)
+		}
+		m[c.ID] = c
+	}
+	return &provider{m: m}, nil
+}
+
+type provider struct {
+	m map[string]Command
+}
+
+func (p *provider) Register(server *grpc.Server) {
+	localexec.RegisterLocalExecServer(server, p)
+}
+
+func (p *provider) Resources() []session.Resource {
+	var out []session.Resource
+	for _, id := range slices.Sorted(maps.Keys(p.m)) {
+		out = append(out, session.Resource{Type: "localexec", ID: id})
+	}
+	return out
+}
+
+func (p *provider) Exec(req *localexec.ExecRequest, stream localexec.LocalExec_ExecServer) error {
+	c, ok := p.m[req.ID]
+	if !ok {
+		return status.Errorf(codes.NotFound, "local exec command %s not found", req.ID)
+	}
+	return run(stream.Context(), c, &streamWriter{stream: stream})
+}
+
+func run(ctx context.Context, c Command, w io.Writer) error {
+	cmd := exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
+	if len(c.Env) > 0 {
+		cmd.Env = c.Env
+	}
+	cmd.Dir = c.Dir
+	stderr := &tailBuffer{max: maxStderrSize}
+	stdout := &limitWriter{w: w, n: localexec.MaxOutputSize}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if stdout.exceeded {
+		return errors.Errorf("output of local exec command %s exceeds the maximum size %d", c.ID, localexec.MaxOutputSize)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return errors.Wrapf(err, "local exec command %s failed: %s", c.ID, msg)
+		}
+		return errors.Wrapf(err, "local exec command %s failed", c.ID)
+	}
+	return nil
+}
+
+type streamWriter struct {
+	stream localexec.LocalExec_ExecServer
+}
+
+func (w *streamWriter) Write(dt []byte) (int, error) {
+	if err := w.stream.Send(&localexec.ExecResponse{Data: dt}); err != nil {
+		return 0, err
+	}
+	return len(dt), nil
+}
+
+// limitWriter fails the command once its output exceeds n bytes
+type limitWriter struct {
+	w        io.Writer
+	n        int
+	exceeded bool
+}
+
+func (w *limitWriter) Write(dt []byte) (int, error) {
+	if len(dt) > w.n {
+		w.exceeded = true
+		return 0, errors.Errorf("output exceeds the maximum size %d", localexec.MaxOutputSize)
+	}
+	w.n -= len(dt)
+	return w.w.Write(dt)
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *tailBuffer) Write(dt []byte) (int, error) {
+	n := len(dt)
+	if len(dt) > b.max {
+		dt = dt[len(dt)-b.max:]
+	}
+	if over := b.Len() + len(dt) - b.max; over > 0 {
+		b.Next(over)
+	}
+	b.Buffer.Write(dt)
+	return n, nil
+}
@@ -0,0 +1,89 @@
+//go:build !windows
+
+package localexecprovider
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/localexec"
+	"github.com/moby/buildkit/session/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider([]Command{{Args: []string{"true"}}})
+	require.ErrorContains(t, err, "missing ID")
+
+	_, err = NewProvider([]Command{{ID: "sign"}})
+	require.ErrorContains(t, err, "missing args")
+
+	_, err = NewProvider([]Command{{ID: "sign", Args: []string{"true"}}, {ID: "sign", Args: []string{"false"}}})
+	require.ErrorContains(t, err, "duplicate")
+
+	p, err := NewProvider([]Command{{ID: "sign", Args: []string{"true"}}, {ID: "attest", Args: []string{"true"}}})
+	require.NoError(t, err)
+	res := p.(*provider).Resources()
+	require.Len(t, res, 2)
+	require.Equal(t, "localexec=attest", res[0].String())
+	require.Equal(t, "localexec=sign", res[1].String())
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.TODO()
+
+	buf := &bytes.Buffer{}
+	err := run(ctx, Command{ID: "echo", Args: []string{"sh", "-c", "echo $MSG; pwd"}, Env: []string{"MSG=signed"}, Dir: "/"}, buf)
+	require.NoError(t, err)
+	require.Equal(t, "signed\n/\n", buf.String())
+
+	buf.Reset()
+	err = run(ctx, Command{ID: "fail", Args: []string{"sh", "-c", "echo out; echo no key >&2; exit 3"}}, buf)
+	require.ErrorContains(t, err, "local exec command fail failed: no key: exit status 3")
+	require.Equal(t, "out\n", buf.String())
+}
+
+func TestExecSession(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sm, err := session.NewManager()
+	require.NoError(t, err)
+
+	s, err := session.NewSession(ctx, "localexec")
+	require.NoError(t, err)
+	p, err := NewProvider([]Command{
+		{ID: "sign", Args: []string{"sh", "-c", "printf signature"}},
+		{ID: "big", Args: []string{"head", "-c", "20971520", "/dev/zero"}},
+	})
+	require.NoError(t, err)
+	s.Allow(p)
+	dialer := session.Dialer(testutil.TestStream(testutil.Handler(sm.HandleConn)))
+	go s.Run(ctx, dialer)
+	defer s.Close()
+
+	c, err := sm.Get(ctx, s.ID(), false)
+	require.NoError(t, err)
+
+	dt, err := localexec.Exec(ctx, c, "sign")
+	require.NoError(t, err)
+	require.Equal(t, "signature", string(dt))
+
+	_, err = localexec.Exec(ctx, c, "missing")
+	require.ErrorIs(t, err, localexec.ErrNotFound)
+
+	_, err = localexec.Exec(ctx, c, "big")
+	require.ErrorContains(t, err, "maximum size")
+}
+
+func TestTailBuffer(t *testing.T) {
+	b := &tailBuffer{max: 4}
+	b.Write([]byte("ab"))
+	b.Write([]byte("cdef"))
+	require.Equal(t, "cdef", b.String())
+	b.Write([]byte(strings.Repeat("x", 10)))
+	require.Equal(t, "xxxx", b.String())
+}
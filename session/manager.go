@@ -4,8 +4,11 @@ import (
 	"context"
 	"net"
 	"net/http"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -23,6 +26,21 @@ type client struct {
 	Session
 	cc        *grpc.ClientConn
 	supported map[string]struct{}
+	methods   []string
+	resources []Resource
+	createdAt time.Time
+	conn      *countingConn
+}
+
+// Info describes an active session
+type Info struct {
+	ID            string
+	SharedKey     string
+	CreatedAt     time.Time
+	Methods       []string
+	Resources     []Resource
+	BytesSent     int64
+	BytesReceived int64
 }
 
 // Manager is a controller for accessing currently active sessions
@@ -107,7 +125,8 @@ func (sm *Manager) handleConn(ctx context.Context, conn net.Conn, opts map[strin
 	id := h.Get(headerSessionID)
 	sharedKey := h.Get(headerSessionSharedKey)
 
-	ctx, cc, err := grpcClientConn(ctx, conn)
+	cconn := &countingConn{Conn: conn}
+	ctx, cc, err := grpcClientConn(ctx, cconn)
 	if err != nil {
 		sm.mu.Unlock()
 		return err
@@ -123,11 +142,19 @@ func (sm *Manager) handleConn(ctx context.Context, conn net.Conn, opts map[strin
 		},
 		cc:        cc,
 		supported: make(map[string]struct{}),
+		methods:   opts[headerSessionMethod],
+		createdAt: time.Now(),
+		conn:      cconn,
 	}
 
 	for _, m := range opts[headerSessionMethod] {
 		c.supported[strings.ToLower(m)] = struct{}{}
 	}
+	for _, v := range opts[headerSessionResource] {
+		if r, ok := parseResource(v); ok {
+			c.resources = append(c.resources, r)
+		}
+	}
 	sm.sessions[id] = c
 	sm.updateCondition.Broadcast()
 	sm.mu.Unlock()
@@ -190,6 +217,32 @@ func (sm *Manager) Get(ctx context.Context, id string, noWait bool) (Caller, err
 	return c, nil
 }
 
+// List returns information about all active sessions
+func (sm *Manager) List() []Info {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	out := make([]Info, 0, len(sm.sessions))
+	for _, c := range sm.sessions {
+		if c.closed() {
+			continue
+		}
+		out = append(out, Info{
+			ID:            c.id,
+			SharedKey:     c.sharedKey,
+			CreatedAt:     c.createdAt,
+			Methods:       c.methods,
+			Resources:     c.resources,
+			BytesSent:     c.conn.written.Load(),
+			BytesReceived: c.conn.read.Load(),
+		})
+	}
+	slices.SortFunc(out, func(a, b Info) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return out
+}
+
 func (c *client) Context() context.Context {
 	return c.context()
 }
@@ -206,6 +259,24 @@ func (c *client) Conn() *grpc.ClientConn {
 	return c.cc
 }
 
+type countingConn struct {
+	net.Conn
+	read    atomic.Int64
+	written atomic.Int64
+}
+
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.written.Add(int64(n))
+	return n, err
+}
+
 func canonicalHeaders(in map[string][]string) map[string][]string {
 	out := map[string][]string{}
 	for k := range in {
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/moby/buildkit/session/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestManagerList(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sm, err := NewManager()
+	require.NoError(t, err)
+	require.Empty(t, sm.List())
+
+	s, err := NewSession(ctx, "sharedkey")
+	require.NoError(t, err)
+	s.Allow(&testAttachable{resources: []Resource{{Type: "secrets", ID: "mysecret"}}})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Run(ctx, Dialer(testutil.TestStream(testutil.Handler(sm.HandleConn))))
+	}()
+
+	c, err := sm.Get(ctx, s.ID(), false)
+	require.NoError(t, err)
+	_, err = grpc_health_v1.NewHealthClient(c.Conn()).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	list := sm.List()
+	require.Len(t, list, 1)
+	require.Equal(t, s.ID(), list[0].ID)
+	require.Equal(t, "sharedkey", list[0].SharedKey)
+	require.Equal(t, []Resource{{Type: "secrets", ID: "mysecret"}}, list[0].Resources)
+	require.False(t, list[0].CreatedAt.IsZero())
+	require.Positive(t, list[0].BytesSent)
+	require.Positive(t, list[0].BytesReceived)
+
+	require.NoError(t, s.Close())
+	<-done
+}
+
+func TestCountingConn(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	cc := &countingConn{Conn: c1}
+	defer cc.Close()
+
+	go func() {
+		buf := make([]byte, 5)
+		c2.Read(buf)
+		c2.Write([]byte("hi"))
+	}()
+
+	n, err := cc.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.Equal(t, 5, n)
+
+	buf := make([]byte, 2)
+	n, err = cc.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+
+	require.Equal(t, int64(5), cc.written.Load())
+	require.Equal(t, int64(2), cc.read.Load())
+}
+
+type testAttachable struct {
+	resources []Resource
+}
+
+func (a *testAttachable) Register(*grpc.Server) {}
+
+func (a *testAttachable) Resources() []Resource {
+	return a.resources
+}
@@ -28,3 +28,15 @@ func GetSecret(ctx context.Context, c session.Caller, id string) ([]byte, error)
 	}
 	return resp.Data, nil
 }
+
+// GetSecretFromGroup returns the secret id from the first session of the group
+// that provides secrets
+func GetSecretFromGroup(ctx context.Context, sm *session.Manager, g session.Group, id string) ([]byte, error) {
+	var dt []byte
+	err := sm.Any(ctx, g, func(ctx context.Context, _ string, c session.Caller) error {
+		var err error
+		dt, err = GetSecret(ctx, c, id)
+		return err
+	})
+	return dt, err
+}
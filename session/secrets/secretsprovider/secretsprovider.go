@@ -2,6 +2,8 @@ package secretsprovider
 
 import (
 	"context"
+	"maps"
+	"slices"
 
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/session/secrets"
@@ -28,6 +30,18 @@ func (sp *secretProvider) Register(server *grpc.Server) {
 	secrets.RegisterSecretsServer(server, sp)
 }
 
+func (sp *secretProvider) Resources() []session.Resource {
+	ids, ok := sp.store.(interface{ secretIDs() []string })
+	if !ok {
+		return nil
+	}
+	var out []session.Resource
+	for _, id := range ids.secretIDs() {
+		out = append(out, session.Resource{Type: "secrets", ID: id})
+	}
+	return out
+}
+
 func (sp *secretProvider) GetSecret(ctx context.Context, req *secrets.GetSecretRequest) (*secrets.GetSecretResponse, error) {
 	dt, err := sp.store.GetSecret(ctx, req.ID)
 	if err != nil {
@@ -51,6 +65,10 @@ func FromMap(m map[string][]byte) session.Attachable {
 
 type mapStore map[string][]byte
 
+func (m mapStore) secretIDs() []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
 func (m mapStore) GetSecret(ctx context.Context, id string) ([]byte, error) {
 	v, ok := m[id]
 	if !ok {
@@ -1,8 +1,15 @@
 package secretsprovider
 
 import (
+	"archive/tar"
+	"bytes"
 	"context"
+	"io/fs"
+	"maps"
 	"os"
+	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/moby/buildkit/session/secrets"
 	"github.com/pkg/errors"
@@ -33,7 +40,7 @@ func NewStore(files []Source) (secrets.SecretStore, error) {
 			if err != nil {
 				return nil, errors.Wrapf(err, "failed to stat %s", f.FilePath)
 			}
-			if fi.Size() > MaxSecretSize {
+			if !fi.IsDir() && fi.Size() > MaxSecretSize {
 				return nil, errors.Errorf("secret %s too big. max size %#.f", f.ID, MaxSecretSize*units.B)
 			}
 		}
@@ -48,6 +55,10 @@ type fileStore struct {
 	m map[string]Source
 }
 
+func (fs *fileStore) secretIDs() []string {
+	return slices.Sorted(maps.Keys(fs.m))
+}
+
 func (fs *fileStore) GetSecret(ctx context.Context, id string) ([]byte, error) {
 	v, ok := fs.m[id]
 	if !ok {
@@ -56,9 +67,80 @@ func (fs *fileStore) GetSecret(ctx context.Context, id string) ([]byte, error) {
 	if v.Env != "" {
 		return []byte(os.Getenv(v.Env)), nil
 	}
+	fi, err := os.Stat(v.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	if fi.IsDir() {
+		return tarDir(v.FilePath)
+	}
 	dt, err := os.ReadFile(v.FilePath)
 	if err != nil {
 		return nil, err
 	}
 	return dt, nil
 }
+
+// tarDir returns the tar archive of a directory secret. Only regular files,
+// directories and symlinks are added.
+func tarDir(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := filepath.WalkDir(dir, func(fp string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		p, err := filepath.Rel(dir, fp)
+		if err != nil {
+			return err
+		}
+		if p == "." {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		switch {
+		case fi.Mode().IsRegular(), fi.IsDir():
+		case fi.Mode()&fs.ModeSymlink != 0:
+			if link, err = os.Readlink(fp); err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(fi, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(p)
+		// ownership is set by the mount
+		hdr.Uid, hdr.Gid, hdr.Uname, hdr.Gname = 0, 0, "", ""
+		hdr.ModTime = time.Time{}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if fi.Mode().IsRegular() {
+			dt, err := os.ReadFile(fp)
+			if err != nil {
+				return err
+			}
+			if _, err := tw.Write(dt); err != nil {
+				return err
+			}
+		}
+		if buf.Len() > MaxSecretSize {
+			return errors.Errorf("secret directory %s too big. max size %#.f", dir, MaxSecretSize*units.B)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
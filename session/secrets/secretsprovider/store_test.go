@@ -0,0 +1,87 @@
+//go:build !windows
+
+package secretsprovider
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTarDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("ca"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "keys"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "keys", "key.pem"), []byte("key"), 0600))
+	require.NoError(t, os.Symlink("ca.pem", filepath.Join(dir, "current.pem")))
+	// other file types are not added
+	require.NoError(t, syscall.Mkfifo(filepath.Join(dir, "fifo"), 0600))
+
+	dt, err := tarDir(dir)
+	require.NoError(t, err)
+
+	type entry struct {
+		typ  byte
+		mode int64
+		link string
+		data string
+	}
+	entries := map[string]entry{}
+	tr := tar.NewReader(bytes.NewReader(dt))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		// ownership and timestamps are not kept
+		require.Equal(t, 0, hdr.Uid)
+		require.Equal(t, 0, hdr.Gid)
+		require.Empty(t, hdr.Uname)
+		require.Empty(t, hdr.Gname)
+		require.True(t, hdr.ModTime.Equal(time.Unix(0, 0)) || hdr.ModTime.IsZero(), hdr.ModTime)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries[hdr.Name] = entry{typ: hdr.Typeflag, mode: hdr.Mode & 0777, link: hdr.Linkname, data: string(data)}
+	}
+	require.Equal(t, map[string]entry{
+		"ca.pem":       {typ: tar.TypeReg, mode: 0644, data: "ca"},
+		"current.pem":  {typ: tar.TypeSymlink, mode: 0777, link: "ca.pem"},
+		"keys":         {typ: tar.TypeDir, mode: 0700},
+		"keys/key.pem": {typ: tar.TypeReg, mode: 0600, data: "key"},
+	}, entries)
+}
+
+func TestTarDirTooBig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a"), bytes.Repeat([]byte("a"), MaxSecretSize/2), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b"), bytes.Repeat([]byte("b"), MaxSecretSize/2), 0644))
+
+	_, err := tarDir(dir)
+	require.ErrorContains(t, err, "too big")
+}
+
+func TestStoreSecretDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ca.pem"), []byte("ca"), 0644))
+
+	store, err := NewStore([]Source{{ID: "certs", FilePath: dir}})
+	require.NoError(t, err)
+
+	dt, err := store.GetSecret(context.TODO(), "certs")
+	require.NoError(t, err)
+	tr := tar.NewReader(bytes.NewReader(dt))
+	hdr, err := tr.Next()
+	require.NoError(t, err)
+	require.Equal(t, "ca.pem", hdr.Name)
+	_, err = tr.Next()
+	require.ErrorIs(t, err, io.EOF)
+}
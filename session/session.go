@@ -3,6 +3,8 @@ package session
 import (
 	"context"
 	"net"
+	"net/url"
+	"strings"
 	"sync"
 
 	"github.com/moby/buildkit/identity"
@@ -22,6 +24,7 @@ const (
 	headerSessionName      = "X-Docker-Expose-Session-Name"
 	headerSessionSharedKey = "X-Docker-Expose-Session-Sharedkey"
 	headerSessionMethod    = "X-Docker-Expose-Session-Grpc-Method"
+	headerSessionResource  = "X-Docker-Expose-Session-Resource"
 )
 
 var propagators = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
@@ -34,6 +37,36 @@ type Attachable interface {
 	Register(*grpc.Server)
 }
 
+// Resource is a named resource exposed by an attachable, like a synced
+// directory or a secret ID
+type Resource struct {
+	Type string
+	ID   string
+}
+
+func (r Resource) String() string {
+	return r.Type + "=" + url.QueryEscape(r.ID)
+}
+
+func parseResource(v string) (Resource, bool) {
+	typ, id, ok := strings.Cut(v, "=")
+	if !ok || typ == "" {
+		return Resource{}, false
+	}
+	id, err := url.QueryUnescape(id)
+	if err != nil {
+		return Resource{}, false
+	}
+	return Resource{Type: typ, ID: id}, true
+}
+
+// ResourceLister can be implemented by an Attachable to announce the named
+// resources it exposes. Resources are only used for inspecting active
+// sessions and are not validated by the daemon.
+type ResourceLister interface {
+	Resources() []Resource
+}
+
 // Session is a long running connection between client and a daemon
 type Session struct {
 	mu          sync.Mutex // synchronizes conn run and close
@@ -45,6 +78,7 @@ type Session struct {
 	grpcServer  *grpc.Server
 	conn        net.Conn
 	closeCalled bool
+	resources   []Resource
 }
 
 // NewSession returns a new long running session
@@ -78,6 +112,9 @@ func NewSession(ctx context.Context, sharedKey string) (*Session, error) {
 // Allow enables a given service to be reachable through the grpc session
 func (s *Session) Allow(a Attachable) {
 	a.Register(s.grpcServer)
+	if rl, ok := a.(ResourceLister); ok {
+		s.resources = append(s.resources, rl.Resources()...)
+	}
 }
 
 // ID returns unique identifier for the session
@@ -108,6 +145,9 @@ func (s *Session) Run(ctx context.Context, dialer Dialer) error {
 			meta[headerSessionMethod] = append(meta[headerSessionMethod], MethodURL(name, method.Name))
 		}
 	}
+	for _, r := range s.resources {
+		meta[headerSessionResource] = append(meta[headerSessionResource], r.String())
+	}
 	conn, err := dialer(ctx, "h2c", meta)
 	if err != nil {
 		s.mu.Unlock()
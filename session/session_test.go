@@ -0,0 +1,24 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceRoundTrip(t *testing.T) {
+	for _, r := range []Resource{
+		{Type: "filesync", ID: "context"},
+		{Type: "secrets", ID: "my=secret"},
+		{Type: "filesync", ID: "input:foo bar/ü"},
+	} {
+		parsed, ok := parseResource(r.String())
+		require.True(t, ok)
+		require.Equal(t, r, parsed)
+	}
+
+	_, ok := parseResource("invalid")
+	require.False(t, ok)
+	_, ok = parseResource("=id")
+	require.False(t, ok)
+}
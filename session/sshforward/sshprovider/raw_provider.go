@@ -2,8 +2,11 @@ package sshprovider
 
 import (
 	"context"
+	"maps"
 	"net"
+	"slices"
 
+	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/session/sshforward"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -56,3 +59,11 @@ func (p *socketProvider) ForwardAgent(stream sshforward.SSH_ForwardAgentServer)
 func (p *socketProvider) Register(srv *grpc.Server) {
 	sshforward.RegisterSSHServer(srv, p)
 }
+
+func (p *socketProvider) Resources() []session.Resource {
+	out := make([]session.Resource, 0, len(p.m))
+	for _, id := range slices.Sorted(maps.Keys(p.m)) {
+		out = append(out, session.Resource{Type: "ssh", ID: id})
+	}
+	return out
+}
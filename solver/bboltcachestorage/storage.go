@@ -21,6 +21,9 @@ const (
 	linksBucket     = "_links"
 	byResultBucket  = "_byresult"
 	backlinksBucket = "_backlinks"
+	metaBucket      = "_meta"
+
+	keyVersionsKey = "keyVersions"
 )
 
 type Store struct {
@@ -37,7 +40,7 @@ func NewStore(dbPath string) (*Store, error) {
 
 	// Initialize the database with the needed buckets if they do not exist.
 	if err := db.Update(func(tx *bolt.Tx) error {
-		for _, b := range []string{resultBucket, linksBucket, byResultBucket, backlinksBucket} {
+		for _, b := range []string{resultBucket, linksBucket, byResultBucket, backlinksBucket, metaBucket} {
 			if _, err := tx.CreateBucketIfNotExists([]byte(b)); err != nil {
 				return err
 			}
@@ -62,6 +65,33 @@ func (s *Store) Exists(id string) bool {
 	return exists
 }
 
+// UpdateKeyVersions stores the versions of the cache keys of the new records
+// and returns the versions the existing records were created with. The
+// returned versions are nil if the store has no records and empty if the
+// records were created before the versions were stored.
+func (s *Store) UpdateKeyVersions(versions map[string]int) (map[string]int, error) {
+	var prev map[string]int
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if k, _ := tx.Bucket([]byte(linksBucket)).Cursor().First(); k != nil {
+			prev = map[string]int{}
+			if dt := tx.Bucket([]byte(metaBucket)).Get([]byte(keyVersionsKey)); dt != nil {
+				if err := json.Unmarshal(dt, &prev); err != nil {
+					return errors.WithStack(err)
+				}
+			}
+		}
+		dt, err := json.Marshal(versions)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		return tx.Bucket([]byte(metaBucket)).Put([]byte(keyVersionsKey), dt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prev, nil
+}
+
 func (s *Store) Close() error {
 	return s.db.Close()
 }
@@ -88,6 +118,37 @@ func (s *Store) Walk(fn func(id string) error) error {
 	return nil
 }
 
+func (s *Store) WalkPage(start string, limit int, fn func(id string) error) (string, error) {
+	if limit <= 0 {
+		return "", errors.Errorf("invalid page size %d", limit)
+	}
+	ids := make([]string, 0, limit)
+	var next string
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(linksBucket))
+		c := b.Cursor()
+		for k, v := c.Seek([]byte(start)); k != nil; k, v = c.Next() {
+			if v != nil {
+				continue
+			}
+			if len(ids) == limit {
+				next = string(k)
+				break
+			}
+			ids = append(ids, string(k))
+		}
+		return nil
+	}); err != nil {
+		return "", err
+	}
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			return "", err
+		}
+	}
+	return next, nil
+}
+
 func (s *Store) WalkResults(id string, fn func(solver.CacheResult) error) error {
 	var list []solver.CacheResult
 	if err := s.db.View(func(tx *bolt.Tx) error {
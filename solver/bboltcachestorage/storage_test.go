@@ -3,6 +3,7 @@ package bboltcachestorage
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/solver/testutil"
@@ -22,3 +23,27 @@ func TestBoltCacheStorage(t *testing.T) {
 		return st
 	})
 }
+
+func TestUpdateKeyVersions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "cache.db")
+	st, err := NewStore(dbPath)
+	require.NoError(t, err)
+
+	prev, err := st.UpdateKeyVersions(map[string]int{"exec": 0})
+	require.NoError(t, err)
+	require.Nil(t, prev)
+
+	require.NoError(t, st.AddResult("foo", solver.CacheResult{ID: "bar", CreatedAt: time.Now()}))
+
+	prev, err = st.UpdateKeyVersions(map[string]int{"exec": 1})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"exec": 0}, prev)
+	require.NoError(t, st.Close())
+
+	st, err = NewStore(dbPath)
+	require.NoError(t, err)
+	defer st.Close()
+	prev, err = st.UpdateKeyVersions(map[string]int{"exec": 1})
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"exec": 1}, prev)
+}
@@ -0,0 +1,76 @@
+package solver
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// CacheKeyVersion is the version of the schema the cache keys of an op type
+// are computed with.
+type CacheKeyVersion struct {
+	Version int
+	// Compatible are the older versions whose keys are still produced by the
+	// op, e.g. for the common cases of the op, so that the cache created with
+	// them keeps matching after an upgrade.
+	Compatible []int
+}
+
+// CacheKeyMismatch is an op type whose cache keys created with Version don't
+// match the keys of the current version.
+type CacheKeyMismatch struct {
+	Op      string
+	Version int
+	Current int
+}
+
+func (m CacheKeyMismatch) String() string {
+	reason := "cache key schema changed"
+	if m.Version > m.Current {
+		reason = "created by a newer version"
+	}
+	return fmt.Sprintf("%s: cache key version %d, current %d: %s", m.Op, m.Version, m.Current, reason)
+}
+
+var (
+	cacheKeyVersionsMu sync.RWMutex
+	cacheKeyVersions   = map[string]CacheKeyVersion{}
+)
+
+// RegisterCacheKeyVersion sets the version of the cache keys of an op type.
+func RegisterCacheKeyVersion(op string, v CacheKeyVersion) {
+	cacheKeyVersionsMu.Lock()
+	defer cacheKeyVersionsMu.Unlock()
+	cacheKeyVersions[op] = v
+}
+
+// CacheKeyVersions returns the current versions of the cache keys by op type.
+func CacheKeyVersions() map[string]int {
+	cacheKeyVersionsMu.RLock()
+	defer cacheKeyVersionsMu.RUnlock()
+	m := make(map[string]int, len(cacheKeyVersions))
+	for op, v := range cacheKeyVersions {
+		m[op] = v.Version
+	}
+	return m
+}
+
+// CheckCacheKeyVersions returns the op types whose cache keys created with
+// versions don't match the current ones, sorted by op type. Op types missing
+// from versions are considered to be at version 0, the version of the cache
+// created before the versions were recorded.
+func CheckCacheKeyVersions(versions map[string]int) []CacheKeyMismatch {
+	cacheKeyVersionsMu.RLock()
+	defer cacheKeyVersionsMu.RUnlock()
+	var out []CacheKeyMismatch
+	for _, op := range slices.Sorted(maps.Keys(cacheKeyVersions)) {
+		cur := cacheKeyVersions[op]
+		v := versions[op]
+		if v == cur.Version || slices.Contains(cur.Compatible, v) {
+			continue
+		}
+		out = append(out, CacheKeyMismatch{Op: op, Version: v, Current: cur.Version})
+	}
+	return out
+}
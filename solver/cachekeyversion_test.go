@@ -0,0 +1,27 @@
+package solver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCacheKeyVersions(t *testing.T) {
+	RegisterCacheKeyVersion("test.a", CacheKeyVersion{Version: 0})
+	RegisterCacheKeyVersion("test.b", CacheKeyVersion{Version: 2, Compatible: []int{1}})
+	t.Cleanup(func() {
+		cacheKeyVersionsMu.Lock()
+		delete(cacheKeyVersions, "test.a")
+		delete(cacheKeyVersions, "test.b")
+		cacheKeyVersionsMu.Unlock()
+	})
+
+	require.Equal(t, []CacheKeyMismatch{{Op: "test.b", Version: 0, Current: 2}}, CheckCacheKeyVersions(nil))
+	require.Empty(t, CheckCacheKeyVersions(map[string]int{"test.b": 1}))
+	require.Empty(t, CheckCacheKeyVersions(map[string]int{"test.b": 2, "test.c": 5}))
+
+	mismatches := CheckCacheKeyVersions(map[string]int{"test.a": 1, "test.b": 0})
+	require.Len(t, mismatches, 2)
+	require.Equal(t, "test.a: cache key version 1, current 0: created by a newer version", mismatches[0].String())
+	require.Equal(t, "test.b: cache key version 0, current 2: cache key schema changed", mismatches[1].String())
+}
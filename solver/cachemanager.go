@@ -44,7 +44,7 @@ type cacheManager struct {
 
 func (c *cacheManager) ReleaseUnreferenced(ctx context.Context) error {
 	visited := map[string]struct{}{}
-	return c.backend.Walk(func(id string) error {
+	return WalkPaged(ctx, c.backend, DefaultWalkPageSize, func(id string) error {
 		return c.backend.WalkResults(id, func(cr CacheResult) error {
 			if _, ok := visited[cr.ID]; ok {
 				return nil
@@ -29,6 +29,47 @@ type CacheKeyStorage interface {
 	WalkBacklinks(id string, fn func(id string, link CacheInfoLink) error) error
 }
 
+// CacheKeyStoragePager is implemented by a CacheKeyStorage that can walk its
+// keys in pages without loading all of them at once
+type CacheKeyStoragePager interface {
+	// WalkPage calls fn for at most limit keys in order, starting from the
+	// key start. It returns the first key of the next page or an empty string
+	// if there are no more keys.
+	WalkPage(start string, limit int, fn func(id string) error) (string, error)
+}
+
+// DefaultWalkPageSize is the number of keys loaded at once by WalkPaged
+const DefaultWalkPageSize = 1000
+
+// WalkPaged walks all the keys of the storage in pages of pageSize keys and
+// stops between pages if the context is canceled. Storages that don't
+// implement CacheKeyStoragePager are walked at once.
+func WalkPaged(ctx context.Context, s CacheKeyStorage, pageSize int, fn func(id string) error) error {
+	p, ok := s.(CacheKeyStoragePager)
+	if !ok {
+		return s.Walk(func(id string) error {
+			if err := ctx.Err(); err != nil {
+				return context.Cause(ctx)
+			}
+			return fn(id)
+		})
+	}
+	var start string
+	for {
+		next, err := p.WalkPage(start, pageSize, fn)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return context.Cause(ctx)
+		}
+		start = next
+	}
+}
+
 // CacheResult is a record for a single solve result
 type CacheResult struct {
 	CreatedAt time.Time
@@ -7,6 +7,7 @@ import (
 
 	"github.com/moby/buildkit/solver/internal/pipe"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/cacheevents"
 	digest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 )
@@ -86,6 +87,8 @@ type dep struct {
 	slowCacheFoundKey bool
 	slowCacheKey      *ExportableCacheKey
 	err               error
+	// missing is set if the dependency is a weak input that failed
+	missing bool
 }
 
 // expDep holds secondary exporter info for dependency
@@ -638,6 +641,10 @@ func (e *edge) processExecReq() {
 func (e *edge) processDepReq(dep *dep) (depChanged bool) {
 	upt := dep.req
 	if err := upt.Status().Err; !upt.Status().Canceled && upt.Status().Completed && err != nil {
+		if e.edge.Vertex.Inputs()[int(dep.index)].Weak {
+			e.markDepMissing(dep, err)
+			return true
+		}
 		if e.err == nil {
 			e.err = err
 		}
@@ -681,6 +688,31 @@ func (e *edge) processDepReq(dep *dep) (depChanged bool) {
 	return depChanged
 }
 
+// missingInputDigest is the cache key of the weak inputs that failed, so the
+// cache of the dependant vertex is only matched by builds where the input
+// failed as well
+var missingInputDigest = digest.FromBytes([]byte("buildkit.input.missing"))
+
+// markDepMissing completes a failed weak dependency with an empty result
+func (e *edge) markDepMissing(dep *dep, err error) {
+	bklog.G(context.TODO()).Debugf("weak input %d of %s failed: %v", dep.index, e.edge.Vertex.Name(), err)
+	k := NewCacheKey(missingInputDigest, "", -1)
+	ek := ExportableCacheKey{CacheKey: k, Exporter: &exporter{k: k}}
+	dep.missing = true
+	dep.slowCacheComplete = true
+	dep.keyMap = map[string]*CacheKey{}
+	dep.edgeState = edgeState{
+		state:  edgeStatusComplete,
+		result: NewSharedCachedResult(NewCachedResult(missingResult{}, []ExportableCacheKey{ek})),
+		keys:   []ExportableCacheKey{ek},
+	}
+	e.keysDidChange = true
+	if e.cacheMap != nil {
+		e.probeCache(dep, withSelector(dep.keys, e.cacheMap.Deps[dep.index].Selector))
+		e.checkDepMatchPossible(dep)
+	}
+}
+
 func (e *edge) processDepSlowCacheReq(index int, dep *dep) {
 	upt := dep.slowCacheReq
 	if err := upt.Status().Err; err != nil {
@@ -883,7 +915,7 @@ func (e *edge) createOutgoingRequest(dep *dep, desiredStateDep edgeStatusType, f
 }
 
 func (e *edge) computeCacheKeyFromDep(dep *dep, f *pipeFactory) (addedNew bool) {
-	if dep.state != edgeStatusComplete || dep.slowCacheReq != nil || e.cacheMap == nil {
+	if dep.state != edgeStatusComplete || dep.slowCacheReq != nil || e.cacheMap == nil || dep.missing {
 		return false
 	}
 
@@ -958,7 +990,13 @@ func (e *edge) loadCache(ctx context.Context) (any, error) {
 // execOp creates a request to execute the vertex operation
 func (e *edge) execOp(ctx context.Context) (any, error) {
 	cacheKeys, inputs := e.commitOptions()
-	results, subExporters, err := e.op.Exec(ctx, toResultSlice(inputs))
+	execInputs := toResultSlice(inputs)
+	for i, dep := range e.deps {
+		if dep.missing {
+			execInputs[i] = nil
+		}
+	}
+	results, subExporters, err := e.op.Exec(ctx, execInputs)
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
@@ -983,6 +1021,15 @@ func (e *edge) execOp(ctx context.Context) (any, error) {
 		if err != nil {
 			return nil, err
 		}
+		if cacheevents.Enabled() {
+			cacheevents.Record(cacheevents.Event{
+				Type:        cacheevents.Insert,
+				Digest:      e.edge.Vertex.Digest(),
+				CacheKey:    cacheKey.Digest(),
+				Record:      res.ID(),
+				Description: e.edge.Vertex.Name(),
+			})
+		}
 
 		if exp, ok := ck.Exporter.(*exporter); ok {
 			exp.edge = e
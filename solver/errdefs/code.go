@@ -0,0 +1,71 @@
+package errdefs
+
+import (
+	"context"
+
+	"github.com/containerd/typeurl/v2"
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	typeurl.Register((*ErrorCode)(nil), "github.com/moby/buildkit", "errdefs.ErrorCode+json")
+}
+
+// Code is a stable machine-readable code of the class of a build error. The
+// codes are sent to the clients as details of the gRPC status of the error
+// so that they don't have to match the error messages.
+type Code string
+
+const (
+	// CodeSourceFetch is the code of the errors fetching or resolving the
+	// sources of the build: images, git repositories, HTTP and local files
+	CodeSourceFetch Code = "SOURCE_FETCH_FAILED"
+	// CodeExecFailed is the code of the errors of the processes of the build
+	// that exited with a non-zero status
+	CodeExecFailed Code = "EXEC_FAILED"
+	// CodeCacheImport is the code of the errors importing the build cache
+	CodeCacheImport Code = "CACHE_IMPORT_FAILED"
+	// CodeExporter is the code of the errors exporting the build result
+	CodeExporter Code = "EXPORTER_FAILED"
+	// CodePolicyDenied is the code of the errors of the sources denied by
+	// the source policy of the build
+	CodePolicyDenied Code = "POLICY_DENIED"
+)
+
+// CodedError is an error with a stable code
+type CodedError struct {
+	*ErrorCode
+	error
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.error
+}
+
+func (e *CodedError) ToProto() grpcerrors.TypedErrorProto {
+	return e.ErrorCode
+}
+
+func (v *ErrorCode) WrapError(err error) error {
+	return &CodedError{error: err, ErrorCode: v}
+}
+
+// WithCode sets the code of err. The code of an error that already has one
+// is not replaced, as the code set closest to the failure is the most
+// specific. Errors caused by the cancellation of the build have no code.
+func WithCode(err error, code Code) error {
+	if err == nil || GetCode(err) != "" || errors.Is(err, context.Canceled) {
+		return err
+	}
+	return &CodedError{error: err, ErrorCode: &ErrorCode{Code: string(code)}}
+}
+
+// GetCode returns the code of err or an empty string if err has no code
+func GetCode(err error) Code {
+	var ce *CodedError
+	if errors.As(err, &ce) {
+		return Code(ce.Code)
+	}
+	return ""
+}
@@ -0,0 +1,33 @@
+package errdefs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCode(t *testing.T) {
+	require.Equal(t, Code(""), GetCode(errors.New("failed")))
+	require.NoError(t, WithCode(nil, CodeExporter))
+
+	err := WithCode(errors.New("failed to push"), CodeExporter)
+	require.Equal(t, CodeExporter, GetCode(err))
+	require.Equal(t, "failed to push", err.Error())
+
+	// the innermost code is kept
+	err = WithCode(errors.Wrap(WithCode(errors.New("denied"), CodePolicyDenied), "failed to load"), CodeSourceFetch)
+	require.Equal(t, CodePolicyDenied, GetCode(err))
+
+	err = WithCode(errors.Wrap(context.Canceled, "failed to pull"), CodeSourceFetch)
+	require.Equal(t, Code(""), GetCode(err))
+}
+
+func TestErrorCodeGRPC(t *testing.T) {
+	err := WithCode(errors.New("process exited"), CodeExecFailed)
+	err = grpcerrors.FromGRPC(grpcerrors.ToGRPC(context.TODO(), errors.Wrap(err, "build failed")))
+	require.Equal(t, CodeExecFailed, GetCode(err))
+	require.Equal(t, "build failed: process exited", err.Error())
+}
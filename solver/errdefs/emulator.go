@@ -0,0 +1,61 @@
+package errdefs
+
+import (
+	fmt "fmt"
+	"strings"
+
+	"github.com/containerd/platforms"
+	"github.com/containerd/typeurl/v2"
+	"github.com/moby/buildkit/util/grpcerrors"
+)
+
+func init() {
+	typeurl.Register((*MissingEmulators)(nil), "github.com/moby/buildkit", "errdefs.MissingEmulators+json")
+}
+
+// maxEmulatedVertexNames is the number of vertices named in the message of a
+// MissingEmulatorsError for each platform
+const maxEmulatedVertexNames = 3
+
+type MissingEmulatorsError struct {
+	*MissingEmulators
+	error
+}
+
+func (e *MissingEmulatorsError) Error() string {
+	var parts []string
+	for _, m := range e.Emulators {
+		var names []string
+		for i, v := range m.Vertices {
+			if i == maxEmulatedVertexNames {
+				names = append(names, fmt.Sprintf("%d more", len(m.Vertices)-i))
+				break
+			}
+			names = append(names, fmt.Sprintf("%q", v.Name))
+		}
+		parts = append(parts, fmt.Sprintf("%s required by %s", platforms.FormatAll(m.Platform.Spec()), strings.Join(names, ", ")))
+	}
+	msg := "no emulator available for " + strings.Join(parts, "; ") + ", install the QEMU emulators of the platforms with binfmt_misc"
+	if e.error != nil {
+		msg += ": " + e.error.Error()
+	}
+	return msg
+}
+
+func (e *MissingEmulatorsError) Unwrap() error {
+	return e.error
+}
+
+func (e *MissingEmulatorsError) ToProto() grpcerrors.TypedErrorProto {
+	return e.MissingEmulators
+}
+
+// NewMissingEmulatorsError returns an error listing the platforms without
+// emulator and the vertices running processes of them
+func NewMissingEmulatorsError(emulators []*MissingEmulator) error {
+	return &MissingEmulatorsError{MissingEmulators: &MissingEmulators{Emulators: emulators}}
+}
+
+func (v *MissingEmulators) WrapError(err error) error {
+	return &MissingEmulatorsError{error: err, MissingEmulators: v}
+}
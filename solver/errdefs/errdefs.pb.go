@@ -267,8 +267,10 @@ type Solve struct {
 	//
 	//	*Solve_File
 	//	*Solve_Cache
-	Subject       isSolve_Subject   `protobuf_oneof:"subject"`
-	Description   map[string]string `protobuf:"bytes,6,rep,name=description,proto3" json:"description,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Subject     isSolve_Subject   `protobuf_oneof:"subject"`
+	Description map[string]string `protobuf:"bytes,6,rep,name=description,proto3" json:"description,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// artifactsID is the ref of the artifacts captured from the failed exec
+	ArtifactsID   string `protobuf:"bytes,7,opt,name=artifactsID,proto3" json:"artifactsID,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -356,6 +358,13 @@ func (x *Solve) GetDescription() map[string]string {
 	return nil
 }
 
+func (x *Solve) GetArtifactsID() string {
+	if x != nil {
+		return x.ArtifactsID
+	}
+	return ""
+}
+
 type isSolve_Subject interface {
 	isSolve_Subject()
 }
@@ -462,6 +471,203 @@ func (x *ContentCache) GetIndex() int64 {
 	return 0
 }
 
+// ErrorCode is the stable machine-readable code of the class of an error,
+// see the Code constants of the errdefs package.
+type ErrorCode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ErrorCode) Reset() {
+	*x = ErrorCode{}
+	mi := &file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ErrorCode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorCode) ProtoMessage() {}
+
+func (x *ErrorCode) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorCode.ProtoReflect.Descriptor instead.
+func (*ErrorCode) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ErrorCode) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+// MissingEmulators lists the platforms of the processes of a build that
+// can't be run by the worker because no emulator is installed for them.
+type MissingEmulators struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Emulators     []*MissingEmulator     `protobuf:"bytes,1,rep,name=emulators,proto3" json:"emulators,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MissingEmulators) Reset() {
+	*x = MissingEmulators{}
+	mi := &file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MissingEmulators) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MissingEmulators) ProtoMessage() {}
+
+func (x *MissingEmulators) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MissingEmulators.ProtoReflect.Descriptor instead.
+func (*MissingEmulators) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MissingEmulators) GetEmulators() []*MissingEmulator {
+	if x != nil {
+		return x.Emulators
+	}
+	return nil
+}
+
+type MissingEmulator struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Platform *pb.Platform           `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"`
+	// vertices are the vertices running processes of the platform
+	Vertices      []*EmulatedVertex `protobuf:"bytes,2,rep,name=vertices,proto3" json:"vertices,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MissingEmulator) Reset() {
+	*x = MissingEmulator{}
+	mi := &file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MissingEmulator) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MissingEmulator) ProtoMessage() {}
+
+func (x *MissingEmulator) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MissingEmulator.ProtoReflect.Descriptor instead.
+func (*MissingEmulator) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MissingEmulator) GetPlatform() *pb.Platform {
+	if x != nil {
+		return x.Platform
+	}
+	return nil
+}
+
+func (x *MissingEmulator) GetVertices() []*EmulatedVertex {
+	if x != nil {
+		return x.Vertices
+	}
+	return nil
+}
+
+type EmulatedVertex struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Digest        string                 `protobuf:"bytes,1,opt,name=digest,proto3" json:"digest,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmulatedVertex) Reset() {
+	*x = EmulatedVertex{}
+	mi := &file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmulatedVertex) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmulatedVertex) ProtoMessage() {}
+
+func (x *EmulatedVertex) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmulatedVertex.ProtoReflect.Descriptor instead.
+func (*EmulatedVertex) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EmulatedVertex) GetDigest() string {
+	if x != nil {
+		return x.Digest
+	}
+	return ""
+}
+
+func (x *EmulatedVertex) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
 var File_github_com_moby_buildkit_solver_errdefs_errdefs_proto protoreflect.FileDescriptor
 
 const file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDesc = "" +
@@ -479,14 +685,15 @@ const file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDesc = "" +
 	"\x04name\x18\x01 \x01(\tR\x04name\" \n" +
 	"\n" +
 	"Subrequest\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\"\xbf\x02\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\xe1\x02\n" +
 	"\x05Solve\x12\x1a\n" +
 	"\binputIDs\x18\x01 \x03(\tR\binputIDs\x12\x1a\n" +
 	"\bmountIDs\x18\x02 \x03(\tR\bmountIDs\x12\x16\n" +
 	"\x02op\x18\x03 \x01(\v2\x06.pb.OpR\x02op\x12)\n" +
 	"\x04file\x18\x04 \x01(\v2\x13.errdefs.FileActionH\x00R\x04file\x12-\n" +
 	"\x05cache\x18\x05 \x01(\v2\x15.errdefs.ContentCacheH\x00R\x05cache\x12A\n" +
-	"\vdescription\x18\x06 \x03(\v2\x1f.errdefs.Solve.DescriptionEntryR\vdescription\x1a>\n" +
+	"\vdescription\x18\x06 \x03(\v2\x1f.errdefs.Solve.DescriptionEntryR\vdescription\x12 \n" +
+	"\vartifactsID\x18\a \x01(\tR\vartifactsID\x1a>\n" +
 	"\x10DescriptionEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\t\n" +
@@ -495,7 +702,17 @@ const file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDesc = "" +
 	"FileAction\x12\x14\n" +
 	"\x05index\x18\x01 \x01(\x03R\x05index\"$\n" +
 	"\fContentCache\x12\x14\n" +
-	"\x05index\x18\x01 \x01(\x03R\x05indexB)Z'github.com/moby/buildkit/solver/errdefsb\x06proto3"
+	"\x05index\x18\x01 \x01(\x03R\x05index\"\x1f\n" +
+	"\tErrorCode\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\"J\n" +
+	"\x10MissingEmulators\x126\n" +
+	"\temulators\x18\x01 \x03(\v2\x18.errdefs.MissingEmulatorR\temulators\"p\n" +
+	"\x0fMissingEmulator\x12(\n" +
+	"\bplatform\x18\x01 \x01(\v2\f.pb.PlatformR\bplatform\x123\n" +
+	"\bvertices\x18\x02 \x03(\v2\x17.errdefs.EmulatedVertexR\bvertices\"<\n" +
+	"\x0eEmulatedVertex\x12\x16\n" +
+	"\x06digest\x18\x01 \x01(\tR\x06digest\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04nameB)Z'github.com/moby/buildkit/solver/errdefsb\x06proto3"
 
 var (
 	file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDescOnce sync.Once
@@ -509,33 +726,41 @@ func file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDescGZIP() []
 	return file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDescData
 }
 
-var file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_goTypes = []any{
-	(*Vertex)(nil),        // 0: errdefs.Vertex
-	(*Source)(nil),        // 1: errdefs.Source
-	(*Frontend)(nil),      // 2: errdefs.Frontend
-	(*FrontendCap)(nil),   // 3: errdefs.FrontendCap
-	(*Subrequest)(nil),    // 4: errdefs.Subrequest
-	(*Solve)(nil),         // 5: errdefs.Solve
-	(*FileAction)(nil),    // 6: errdefs.FileAction
-	(*ContentCache)(nil),  // 7: errdefs.ContentCache
-	nil,                   // 8: errdefs.Solve.DescriptionEntry
-	(*pb.SourceInfo)(nil), // 9: pb.SourceInfo
-	(*pb.Range)(nil),      // 10: pb.Range
-	(*pb.Op)(nil),         // 11: pb.Op
+	(*Vertex)(nil),           // 0: errdefs.Vertex
+	(*Source)(nil),           // 1: errdefs.Source
+	(*Frontend)(nil),         // 2: errdefs.Frontend
+	(*FrontendCap)(nil),      // 3: errdefs.FrontendCap
+	(*Subrequest)(nil),       // 4: errdefs.Subrequest
+	(*Solve)(nil),            // 5: errdefs.Solve
+	(*FileAction)(nil),       // 6: errdefs.FileAction
+	(*ContentCache)(nil),     // 7: errdefs.ContentCache
+	(*ErrorCode)(nil),        // 8: errdefs.ErrorCode
+	(*MissingEmulators)(nil), // 9: errdefs.MissingEmulators
+	(*MissingEmulator)(nil),  // 10: errdefs.MissingEmulator
+	(*EmulatedVertex)(nil),   // 11: errdefs.EmulatedVertex
+	nil,                      // 12: errdefs.Solve.DescriptionEntry
+	(*pb.SourceInfo)(nil),    // 13: pb.SourceInfo
+	(*pb.Range)(nil),         // 14: pb.Range
+	(*pb.Op)(nil),            // 15: pb.Op
+	(*pb.Platform)(nil),      // 16: pb.Platform
 }
 var file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_depIdxs = []int32{
-	9,  // 0: errdefs.Source.info:type_name -> pb.SourceInfo
-	10, // 1: errdefs.Source.ranges:type_name -> pb.Range
-	11, // 2: errdefs.Solve.op:type_name -> pb.Op
+	13, // 0: errdefs.Source.info:type_name -> pb.SourceInfo
+	14, // 1: errdefs.Source.ranges:type_name -> pb.Range
+	15, // 2: errdefs.Solve.op:type_name -> pb.Op
 	6,  // 3: errdefs.Solve.file:type_name -> errdefs.FileAction
 	7,  // 4: errdefs.Solve.cache:type_name -> errdefs.ContentCache
-	8,  // 5: errdefs.Solve.description:type_name -> errdefs.Solve.DescriptionEntry
-	6,  // [6:6] is the sub-list for method output_type
-	6,  // [6:6] is the sub-list for method input_type
-	6,  // [6:6] is the sub-list for extension type_name
-	6,  // [6:6] is the sub-list for extension extendee
-	0,  // [0:6] is the sub-list for field type_name
+	12, // 5: errdefs.Solve.description:type_name -> errdefs.Solve.DescriptionEntry
+	10, // 6: errdefs.MissingEmulators.emulators:type_name -> errdefs.MissingEmulator
+	16, // 7: errdefs.MissingEmulator.platform:type_name -> pb.Platform
+	11, // 8: errdefs.MissingEmulator.vertices:type_name -> errdefs.EmulatedVertex
+	9,  // [9:9] is the sub-list for method output_type
+	9,  // [9:9] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_init() }
@@ -553,7 +778,7 @@ func file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDesc), len(file_github_com_moby_buildkit_solver_errdefs_errdefs_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   13,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
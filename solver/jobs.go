@@ -12,6 +12,7 @@ import (
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver/errdefs"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/cacheevents"
 	"github.com/moby/buildkit/util/flightcontrol"
 	"github.com/moby/buildkit/util/progress"
 	"github.com/moby/buildkit/util/progress/controller"
@@ -26,6 +27,10 @@ import (
 // ResolveOpFunc finds an Op implementation for a Vertex
 type ResolveOpFunc func(Vertex, Builder) (Op, error)
 
+// AcquireFunc waits until a vertex may be executed. The vertex is executed
+// with the returned context.
+type AcquireFunc func(context.Context, Vertex, Builder) (context.Context, ReleaseFunc, error)
+
 type Builder interface {
 	Build(ctx context.Context, e Edge) (CachedResultWithProvenance, error)
 	InContext(ctx context.Context, f func(ctx context.Context, g session.Group) error) error
@@ -45,6 +50,7 @@ type Solver struct {
 	updateCond *sync.Cond
 	s          *scheduler
 	index      *edgeIndex
+	speculator *speculator
 }
 
 type state struct {
@@ -61,6 +67,7 @@ type state struct {
 	vtx          Vertex
 	clientVertex client.Vertex
 	origDigest   digest.Digest // original LLB digest. TODO: probably better to use string ID so this isn't needed
+	scope        string        // vertex scope of the jobs that loaded the vertex
 
 	mu    sync.Mutex
 	op    *sharedOp
@@ -313,12 +320,32 @@ type Job struct {
 	progressCloser func(error)
 	SessionID      string
 	uniqueID       string // unique ID is used for provenance. We use a different field that client can't control
+	vertexScope    string
 }
 
 type SolverOpt struct {
 	ResolveOpFunc ResolveOpFunc
 	DefaultCache  CacheManager
-}
+	// Acquire is called before the resources of an op are acquired for
+	// execution. It can limit the number of operations that run at the same
+	// time across all builds.
+	Acquire AcquireFunc
+	// Speculate selects the vertices that are built speculatively
+	Speculate SpeculateFunc
+	// SpeculativeExecution is the maximum number of speculative builds
+	// running at a time. Zero disables speculative execution.
+	SpeculativeExecution int
+	// Retry is the policy for retrying the vertices that fail with a
+	// transient error
+	Retry RetryPolicy
+	// ReadOnly only loads the vertices from the cache. The vertices that
+	// are not cached fail with ErrReadOnly instead of being executed.
+	ReadOnly bool
+}
+
+// ErrReadOnly is returned for the vertices that a read-only solver would
+// need to execute
+var ErrReadOnly = errors.New("solver is read-only")
 
 func NewSolver(opts SolverOpt) *Solver {
 	if opts.DefaultCache == nil {
@@ -331,6 +358,7 @@ func NewSolver(opts SolverOpt) *Solver {
 		index:   newEdgeIndex(),
 	}
 	jl.s = newScheduler(jl)
+	jl.speculator = newSpeculator(opts, jl.s)
 	jl.updateCond = sync.NewCond(jl.mu.RLocker())
 	return jl
 }
@@ -466,10 +494,24 @@ func (jl *Solver) loadUnlocked(ctx context.Context, v, parent Vertex, j *Job, ca
 		if err != nil {
 			return nil, err
 		}
-		inputs[i] = Edge{Index: e.Index, Vertex: v}
+		inputs[i] = Edge{Index: e.Index, Vertex: v, Weak: e.Weak}
+	}
+
+	// vertices of jobs in different scopes are not shared, so they get a
+	// different digest per scope. Sub-builds get the scope of their parent.
+	var scope string
+	if j != nil {
+		scope = j.vertexScope
+	} else if parent != nil {
+		if pst, ok := jl.actives[parent.Digest()]; ok {
+			scope = pst.scope
+		}
 	}
 
 	dgst := v.Digest()
+	if scope != "" {
+		dgst = digest.FromBytes(fmt.Appendf(nil, "%s-scope-%s", dgst, scope))
+	}
 
 	dgstWithoutCache := digest.FromBytes(fmt.Appendf(nil, "%s-ignorecache", dgst))
 
@@ -517,6 +559,7 @@ func (jl *Solver) loadUnlocked(ctx context.Context, v, parent Vertex, j *Job, ca
 			cache:        map[string]CacheManager{},
 			solver:       jl,
 			origDigest:   origVtx.Digest(),
+			scope:        scope,
 		}
 		jl.actives[dgst] = st
 
@@ -714,6 +757,15 @@ func (j *Job) Build(ctx context.Context, e Edge) (CachedResultWithProvenance, er
 	}
 	e.Vertex = v
 
+	if sp := j.list.speculator; sp != nil && speculationEnabled(ctx) {
+		sctx, cancel := context.WithCancelCause(ctx)
+		wait := sp.start(sctx, e)
+		defer func() {
+			cancel(errors.WithStack(context.Canceled))
+			wait()
+		}()
+	}
+
 	res, err := j.list.s.build(ctx, e)
 	if err != nil {
 		return nil, err
@@ -824,6 +876,14 @@ func (j *Job) InContext(ctx context.Context, f func(context.Context, session.Gro
 	return f(progress.WithProgress(ctx, j.pw), session.NewGroup(j.SessionID))
 }
 
+// SetVertexScope keeps the vertices loaded by the job from being shared with
+// the jobs of other scopes. Jobs with the same scope, or without a scope,
+// share the vertices they load. It needs to be called before the job loads
+// any vertex.
+func (j *Job) SetVertexScope(scope string) {
+	j.vertexScope = scope
+}
+
 func (j *Job) SetValue(key string, v any) {
 	j.values.Store(key, v)
 }
@@ -918,9 +978,21 @@ func (s *sharedOp) LoadCache(ctx context.Context, rec *CacheRecord) (Result, err
 	span, ctx := tracing.StartSpan(ctx, "load cache: "+s.st.vtx.Name(), trace.WithAttributes(attribute.String("vertex", s.st.vtx.Digest().String())))
 	s.st.execSpan = span
 	notifyCompleted := notifyStarted(ctx, &s.st.clientVertex, true)
+	if rec.cacheManager != nil {
+		s.st.clientVertex.CacheSource = rec.cacheManager.ID()
+	}
 	res, err := s.Cache().Load(withAncestorCacheOpts(ctx, s.st), rec)
 	tracing.FinishWithError(span, err)
 	notifyCompleted(err, true)
+	if err == nil && cacheevents.Enabled() {
+		cacheevents.Record(cacheevents.Event{
+			Type:        cacheevents.Hit,
+			Digest:      s.st.vtx.Digest(),
+			Record:      rec.ID,
+			Source:      s.st.clientVertex.CacheSource,
+			Description: s.st.vtx.Name(),
+		})
+	}
 	return res, err
 }
 
@@ -1037,7 +1109,15 @@ func (s *sharedOp) CacheMap(ctx context.Context, index int) (resp *cacheMapResp,
 				notifyCompleted(retErr, false)
 			}()
 		}
-		res, done, err := op.CacheMap(ctx, s.st, len(s.cacheRes))
+		type cacheMapRes struct {
+			res  *CacheMap
+			done bool
+		}
+		cm, err := retry(ctx, s.st.opts.Retry, op, func() (cacheMapRes, error) {
+			res, done, err := op.CacheMap(ctx, s.st, len(s.cacheRes))
+			return cacheMapRes{res: res, done: done}, err
+		})
+		res, done := cm.res, cm.done
 		complete := true
 		if err != nil {
 			select {
@@ -1084,6 +1164,9 @@ func (s *sharedOp) Exec(ctx context.Context, inputs []Result) (outputs []Result,
 		err = errdefs.WithOp(err, s.st.vtx.Sys(), s.st.vtx.Options().Description)
 		err = errdefs.WrapVertex(err, s.st.origDigest)
 	}()
+	if s.st.opts.ReadOnly {
+		return nil, nil, errors.Wrap(ErrReadOnly, "vertex is not cached")
+	}
 	op, err := s.getOp()
 	if err != nil {
 		return nil, nil, err
@@ -1096,6 +1179,17 @@ func (s *sharedOp) Exec(ctx context.Context, inputs []Result) (outputs []Result,
 			}
 			return s.execRes, nil
 		}
+		if acquire := s.st.opts.Acquire; acquire != nil {
+			var (
+				release ReleaseFunc
+				err     error
+			)
+			ctx, release, err = acquire(ctx, s.st.vtx, s.subBuilder)
+			if err != nil {
+				return nil, errors.Wrap(err, "acquire op concurrency limit")
+			}
+			defer release()
+		}
 		release, err := op.Acquire(ctx)
 		if err != nil {
 			return nil, errors.Wrap(err, "acquire op resources")
@@ -1117,7 +1211,14 @@ func (s *sharedOp) Exec(ctx context.Context, inputs []Result) (outputs []Result,
 			notifyCompleted(retErr, false)
 		}()
 
-		res, err := op.Exec(ctx, s.st, inputs)
+		start := time.Now()
+		res, err := retry(ctx, s.st.opts.Retry, op, func() ([]Result, error) {
+			return op.Exec(ctx, s.st, inputs)
+		})
+		recordOpDuration(ctx, s.st.vtx, start, err)
+		if ru, ok := op.(ResourceUsageOp); ok {
+			s.st.clientVertex.ResourceUsage = ru.ResourceUsage()
+		}
 		complete := true
 		if err != nil {
 			select {
@@ -1223,6 +1324,8 @@ func notifyStarted(ctx context.Context, v *client.Vertex, cached bool) func(err
 	v.Started = &start
 	v.Completed = nil
 	v.Cached = cached
+	v.ResourceUsage = nil
+	v.CacheSource = ""
 	id := identity.NewID()
 	pw.Write(id, *v)
 	return func(err error, cached bool) {
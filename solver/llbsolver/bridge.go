@@ -1,8 +1,12 @@
 package llbsolver
 
 import (
+	"cmp"
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -42,12 +46,28 @@ type llbBridge struct {
 	cms                       map[string]solver.CacheManager
 	cmsMu                     sync.Mutex
 	sm                        *session.Manager
+	readOnly                  bool
 
 	executorOnce sync.Once
 	executorErr  error
 	executor     executor.Executor
 }
 
+func (b *llbBridge) LogVertex(ctx context.Context, name string, cached bool) error {
+	return b.builder.InContext(ctx, func(ctx context.Context, g session.Group) error {
+		pw, _, _ := progress.NewFromContext(ctx)
+		now := time.Now()
+		pw.Write(identity.NewID(), client.Vertex{
+			Digest:    digest.FromBytes([]byte(name)),
+			Name:      name,
+			Started:   &now,
+			Completed: &now,
+			Cached:    cached,
+		})
+		return pw.Close()
+	})
+}
+
 func (b *llbBridge) Warn(ctx context.Context, dgst digest.Digest, msg string, opts frontend.WarnOpts) error {
 	return b.builder.InContext(ctx, func(ctx context.Context, g session.Group) error {
 		pw, ok, _ := progress.NewFromContext(ctx, progress.WithMetadata("vertex", dgst))
@@ -71,27 +91,29 @@ func (b *llbBridge) Warn(ctx context.Context, dgst digest.Digest, msg string, op
 	})
 }
 
-func (b *llbBridge) loadResult(ctx context.Context, def *pb.Definition, cacheImports []gw.CacheOptionsEntry, pol []*spb.Policy) (solver.CachedResultWithProvenance, error) {
+// loadResult builds def. It also returns the source locations of the loaded
+// vertices, for the errors of the build to be mapped to the sources.
+func (b *llbBridge) loadResult(ctx context.Context, def *pb.Definition, cacheImports []gw.CacheOptionsEntry, pol []*spb.Policy) (solver.CachedResultWithProvenance, map[digest.Digest][]*pb.Location, error) {
 	w, err := b.resolveWorker()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	ent, err := loadEntitlements(b.builder)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	srcPol, err := loadSourcePolicy(b.builder)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var polEngine SourcePolicyEvaluator
 	if srcPol != nil || len(pol) > 0 {
 		for _, p := range pol {
 			if p == nil {
-				return nil, errors.Errorf("invalid nil policy")
+				return nil, nil, errors.Errorf("invalid nil policy")
 			}
 			if err := validateSourcePolicy(p); err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 		}
 		if srcPol != nil {
@@ -100,10 +122,15 @@ func (b *llbBridge) loadResult(ctx context.Context, def *pb.Definition, cacheImp
 		polEngine = sourcepolicy.NewEngine(pol)
 	}
 	var cms []solver.CacheManager
+	var tiers []cacheTier
 	for _, im := range cacheImports {
 		cmID, err := cmKey(im)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		tier, isTier, err := parseCacheTier(im)
+		if err != nil {
+			return nil, nil, err
 		}
 		b.cmsMu.Lock()
 		var cm solver.CacheManager
@@ -120,11 +147,13 @@ func (b *llbBridge) loadResult(ctx context.Context, def *pb.Definition, cacheImp
 						if err != nil {
 							return errors.Wrapf(err, "failed to configure %v cache importer", im.Type)
 						}
-						cmNew, err = ci.Resolve(ctx, desc, cmID, w)
+						// the records of the import are attributed to its
+						// name in the cache source of the vertices
+						cmNew, err = ci.Resolve(ctx, desc, cacheSourceName(im), w)
 						return err
 					}); err != nil {
 						bklog.G(ctx).Debugf("error while importing cache manifest from cmId=%s: %v", cmID, err)
-						return nil, err
+						return nil, errdefs.WithCode(err, errdefs.CodeCacheImport)
 					}
 					return cmNew, nil
 				})
@@ -133,29 +162,43 @@ func (b *llbBridge) loadResult(ctx context.Context, def *pb.Definition, cacheImp
 		} else {
 			cm = prevCm
 		}
-		cms = append(cms, cm)
+		if isTier {
+			tiers = append(tiers, cacheTier{tier: tier, cm: cm})
+		} else {
+			cms = append(cms, cm)
+		}
 		b.cmsMu.Unlock()
 	}
+	if len(tiers) > 0 {
+		cms = append(cms, b.tieredCacheManager(tiers))
+	}
 	dpc := &detectPrunedCacheID{}
 
 	edge, err := Load(ctx, def, polEngine, dpc.Load, ValidateEntitlements(ent, w.CDIManager()), WithCacheSources(cms), NormalizeRuntimePlatforms(), WithValidateCaps())
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to load LLB")
+		return nil, nil, errors.Wrap(err, "failed to load LLB")
+	}
+	locs, err := sourceLocations(def, edge)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkEmulators(edge, platforms.Normalize(platforms.DefaultSpec()), w.Emulators); err != nil {
+		return nil, locs, err
 	}
 
 	if len(dpc.ids) > 0 {
 		if err := b.eachWorker(func(w worker.Worker) error {
 			return w.PruneCacheMounts(ctx, dpc.ids)
 		}); err != nil {
-			return nil, err
+			return nil, locs, err
 		}
 	}
 
 	res, err := b.builder.Build(ctx, edge)
 	if err != nil {
-		return nil, err
+		return nil, locs, err
 	}
-	return res, nil
+	return res, locs, nil
 }
 
 func (b *llbBridge) validateEntitlements(p executor.ProcessInfo) error {
@@ -170,6 +213,24 @@ func (b *llbBridge) validateEntitlements(p executor.ProcessInfo) error {
 	return ent.Check(v)
 }
 
+// ValidateMounts checks that the entitlements of the build allow the mounts
+// of a container before they are prepared
+func (b *llbBridge) ValidateMounts(mnts []*pb.Mount) error {
+	ent, err := loadEntitlements(b.builder)
+	if err != nil {
+		return err
+	}
+	v := entitlements.Values{
+		LocalExec: slices.ContainsFunc(mnts, func(m *pb.Mount) bool {
+			return m.MountType == pb.MountType_LOCALEXEC
+		}),
+		MountHost: slices.ContainsFunc(mnts, func(m *pb.Mount) bool {
+			return m.MountType == pb.MountType_HOSTBIND
+		}),
+	}
+	return ent.Check(v)
+}
+
 func (b *llbBridge) Run(ctx context.Context, id string, rootfs executor.Mount, mounts []executor.Mount, process executor.ProcessInfo, started chan<- struct{}) (resourcestypes.Recorder, error) {
 	if err := b.validateEntitlements(process); err != nil {
 		return nil, err
@@ -193,6 +254,9 @@ func (b *llbBridge) Exec(ctx context.Context, id string, process executor.Proces
 }
 
 func (b *llbBridge) loadExecutor() error {
+	if b.readOnly {
+		return errors.Wrap(solver.ErrReadOnly, "containers can't be run")
+	}
 	b.executorOnce.Do(func() {
 		w, err := b.resolveWorker()
 		if err != nil {
@@ -215,6 +279,8 @@ type resultProxy struct {
 	err        error
 	errResults []solver.Result
 	provenance *provenance.Capture
+	// locations are the source locations of the loaded vertices
+	locations map[digest.Digest][]*pb.Location
 }
 
 func newResultProxy(b *provenanceBridge, req frontend.SolveRequest) *resultProxy {
@@ -264,15 +330,23 @@ func (rp *resultProxy) wrapError(err error) error {
 	}
 	var ve *errdefs.VertexError
 	if errors.As(err, &ve) {
-		if rp.req.Definition.Source != nil {
-			locs, ok := rp.req.Definition.Source.Locations[ve.Digest]
-			if ok {
-				for _, loc := range locs.Locations {
-					err = errdefs.WithSource(err, &errdefs.Source{
-						Info:   rp.req.Definition.Source.Infos[loc.SourceIndex],
-						Ranges: loc.Ranges,
-					})
+		if src := rp.req.Definition.Source; src != nil {
+			rp.mu.Lock()
+			locs, ok := rp.locations[digest.Digest(ve.Digest)]
+			rp.mu.Unlock()
+			if !ok {
+				if l, ok := src.Locations[ve.Digest]; ok {
+					locs = l.Locations
+				}
+			}
+			for _, loc := range locs {
+				if loc.SourceIndex < 0 || int(loc.SourceIndex) >= len(src.Infos) {
+					continue
 				}
+				err = errdefs.WithSource(err, &errdefs.Source{
+					Info:   src.Infos[loc.SourceIndex],
+					Ranges: loc.Ranges,
+				})
 			}
 		}
 	}
@@ -280,7 +354,13 @@ func (rp *resultProxy) wrapError(err error) error {
 }
 
 func (rp *resultProxy) loadResult(ctx context.Context) (solver.CachedResultWithProvenance, error) {
-	res, err := rp.b.loadResult(ctx, rp.req.Definition, rp.req.CacheImports, rp.req.SourcePolicies)
+	// only the builds of the top-level frontend are speculated, not the
+	// builds of frontends it calls
+	ctx = solver.WithSpeculation(ctx, rp.b.req == nil)
+	res, locs, err := rp.b.loadResult(ctx, rp.req.Definition, rp.req.CacheImports, rp.req.SourcePolicies)
+	rp.mu.Lock()
+	rp.locations = locs
+	rp.mu.Unlock()
 	var ee *llberrdefs.ExecError
 	if errors.As(err, &ee) {
 		ee.EachRef(func(res solver.Result) error {
@@ -451,6 +531,92 @@ func newLazyCacheManager(id string, fn func() (solver.CacheManager, error)) solv
 	return lcm
 }
 
+const attrCacheTier = "tier"
+
+type cacheTier struct {
+	tier int
+	cm   solver.CacheManager
+}
+
+// parseCacheTier returns the tier of a cache import. The imports with a tier
+// are queried in the order of their tiers, stopping at the first one that
+// has a record.
+func parseCacheTier(im gw.CacheOptionsEntry) (int, bool, error) {
+	v, ok := im.Attrs[attrCacheTier]
+	if !ok {
+		return 0, false, nil
+	}
+	tier, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "invalid cache tier %q", v)
+	}
+	return tier, true, nil
+}
+
+// tieredCacheManager returns the cache manager of the tiers, reusing the one
+// of previous requests with the same tiers so that the misses of the tiers
+// are tracked for the whole build
+func (b *llbBridge) tieredCacheManager(tiers []cacheTier) solver.CacheManager {
+	slices.SortStableFunc(tiers, func(a, b cacheTier) int {
+		return cmp.Compare(a.tier, b.tier)
+	})
+	cms := make([]solver.CacheManager, len(tiers))
+	for i, t := range tiers {
+		cms[i] = t.cm
+	}
+	tcm := solver.NewTieredCacheManager(cms)
+
+	b.cmsMu.Lock()
+	defer b.cmsMu.Unlock()
+	if prev, ok := b.cms[tcm.ID()]; ok {
+		return prev
+	}
+	b.cms[tcm.ID()] = tcm
+	return tcm
+}
+
+// cacheTierMissed returns true if the tiered cache import im didn't have
+// records that were found in a slower tier
+func (b *llbBridge) cacheTierMissed(im gw.CacheOptionsEntry) bool {
+	cmID, err := cmKey(im)
+	if err != nil {
+		return false
+	}
+	b.cmsMu.Lock()
+	defer b.cmsMu.Unlock()
+	for _, cm := range b.cms {
+		if tcm, ok := cm.(*solver.TieredCacheManager); ok && tcm.Missed(cmID) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheSourceAttrs are the attributes of the cache imports of every type
+// that identify the source in the cache source of the vertices
+var cacheSourceAttrs = map[string][]string{
+	"registry": {"ref"},
+	"local":    {"src"},
+	"gha":      {"scope"},
+	"s3":       {"bucket", "name"},
+	"azblob":   {"name"},
+}
+
+// cacheSourceName returns the name of a cache import, e.g.
+// "registry:docker.io/org/app:cache" or "gha:main"
+func cacheSourceName(im gw.CacheOptionsEntry) string {
+	var parts []string
+	for _, k := range cacheSourceAttrs[im.Type] {
+		if v := im.Attrs[k]; v != "" {
+			parts = append(parts, v)
+		}
+	}
+	if len(parts) == 0 {
+		return im.Type
+	}
+	return im.Type + ":" + strings.Join(parts, "/")
+}
+
 func cmKey(im gw.CacheOptionsEntry) (string, error) {
 	if im.Type == "registry" && im.Attrs["ref"] != "" {
 		return im.Attrs["ref"], nil
@@ -0,0 +1,24 @@
+package llbsolver
+
+import (
+	"testing"
+
+	gw "github.com/moby/buildkit/frontend/gateway/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheSourceName(t *testing.T) {
+	for _, tc := range []struct {
+		im       gw.CacheOptionsEntry
+		expected string
+	}{
+		{gw.CacheOptionsEntry{Type: "registry", Attrs: map[string]string{"ref": "docker.io/org/app:cache", "mode": "max"}}, "registry:docker.io/org/app:cache"},
+		{gw.CacheOptionsEntry{Type: "local", Attrs: map[string]string{"src": "/tmp/cache", "digest": "sha256:abc"}}, "local:/tmp/cache"},
+		{gw.CacheOptionsEntry{Type: "gha", Attrs: map[string]string{"scope": "main", "token": "secret"}}, "gha:main"},
+		{gw.CacheOptionsEntry{Type: "s3", Attrs: map[string]string{"bucket": "cache", "name": "app", "region": "us-east-1"}}, "s3:cache/app"},
+		{gw.CacheOptionsEntry{Type: "gha", Attrs: map[string]string{"token": "secret"}}, "gha"},
+		{gw.CacheOptionsEntry{Type: "custom", Attrs: map[string]string{"ref": "foo"}}, "custom"},
+	} {
+		require.Equal(t, tc.expected, cacheSourceName(tc.im))
+	}
+}
@@ -0,0 +1,206 @@
+package llbsolver
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	"github.com/containerd/platforms"
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/frontend"
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+)
+
+// errPlatformCanceled is the cause of the cancellation of the evaluation of a
+// platform canceled with CancelPlatforms
+var errPlatformCanceled = errors.New("platform canceled")
+
+// platformCancels tracks the platforms canceled in the running builds
+type platformCancels struct {
+	mu     sync.Mutex
+	builds map[string]*buildPlatforms
+}
+
+func newPlatformCancels() *platformCancels {
+	return &platformCancels{builds: map[string]*buildPlatforms{}}
+}
+
+// buildPlatforms are the canceled platforms of a single build
+type buildPlatforms struct {
+	mu       sync.Mutex
+	canceled map[string]struct{}
+	cancels  map[string]context.CancelCauseFunc
+}
+
+func (p *platformCancels) register(ref string) (*buildPlatforms, func()) {
+	bp := &buildPlatforms{
+		canceled: map[string]struct{}{},
+		cancels:  map[string]context.CancelCauseFunc{},
+	}
+	p.mu.Lock()
+	p.builds[ref] = bp
+	p.mu.Unlock()
+	return bp, func() {
+		p.mu.Lock()
+		if p.builds[ref] == bp {
+			delete(p.builds, ref)
+		}
+		p.mu.Unlock()
+	}
+}
+
+func (p *platformCancels) cancel(ref string, ps []string) error {
+	normalized := make([]string, 0, len(ps))
+	for _, v := range ps {
+		pp, err := platforms.Parse(v)
+		if err != nil {
+			return grpcerrors.WrapCode(errors.Wrapf(err, "invalid platform %s", v), codes.InvalidArgument)
+		}
+		normalized = append(normalized, formatPlatform(pp))
+	}
+	p.mu.Lock()
+	bp, ok := p.builds[ref]
+	p.mu.Unlock()
+	if !ok {
+		return grpcerrors.WrapCode(errors.Errorf("build %s not found", ref), codes.NotFound)
+	}
+
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	for _, v := range normalized {
+		bp.canceled[v] = struct{}{}
+		if cancel, ok := bp.cancels[v]; ok {
+			cancel(errors.WithStack(errPlatformCanceled))
+		}
+	}
+	return nil
+}
+
+// withPlatform returns a context that is canceled when platform is canceled
+func (bp *buildPlatforms) withPlatform(ctx context.Context, platform string) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	bp.mu.Lock()
+	defer bp.mu.Unlock()
+	if _, ok := bp.canceled[platform]; ok {
+		cancel(errors.WithStack(errPlatformCanceled))
+	} else {
+		bp.cancels[platform] = cancel
+	}
+	return ctx, func() {
+		bp.mu.Lock()
+		delete(bp.cancels, platform)
+		bp.mu.Unlock()
+		cancel(errors.WithStack(context.Canceled))
+	}
+}
+
+// CancelPlatforms cancels the platforms ps of the multi-platform result of
+// the build ref. The other platforms are still built and exported, and the
+// canceled ones are listed in the exporter metadata.
+func (s *Solver) CancelPlatforms(ref string, ps []string) error {
+	return s.platformCancels.cancel(ref, ps)
+}
+
+// evaluateResult evaluates the refs of the result of the frontend. The refs
+// of the platforms canceled with CancelPlatforms are removed from res and the
+// platforms are listed in its exptypes.ExporterPlatformsMissingKey metadata.
+func evaluateResult(ctx context.Context, res *frontend.Result, bp *buildPlatforms) error {
+	var ps exptypes.Platforms
+	if len(res.Refs) > 0 {
+		if p, err := exptypes.ParsePlatforms(res.Metadata); err == nil {
+			ps = p
+		}
+	}
+	platformOf := func(id string) string {
+		for _, p := range ps.Platforms {
+			if p.ID == id {
+				return formatPlatform(p.Platform)
+			}
+		}
+		if p, err := platforms.Parse(id); err == nil {
+			return formatPlatform(p)
+		}
+		return id
+	}
+
+	var mu sync.Mutex
+	var canceled []string
+	eg, ctx := errgroup.WithContext(ctx)
+	if res.Ref != nil {
+		eg.Go(func() error {
+			_, err := res.Ref.Result(ctx)
+			return err
+		})
+	}
+	for k, ref := range res.Refs {
+		if ref == nil {
+			continue
+		}
+		eg.Go(func() error {
+			pctx, done := bp.withPlatform(ctx, platformOf(k))
+			defer done()
+			_, err := ref.Result(pctx)
+			if err != nil && ctx.Err() == nil && errors.Is(context.Cause(pctx), errPlatformCanceled) {
+				mu.Lock()
+				canceled = append(canceled, k)
+				mu.Unlock()
+				return nil
+			}
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return err
+	}
+	if len(canceled) == 0 {
+		return nil
+	}
+
+	var remaining int
+	for _, ref := range res.Refs {
+		if ref != nil {
+			remaining++
+		}
+	}
+	if remaining == len(canceled) {
+		return errors.Wrap(errPlatformCanceled, "all platforms of the build were canceled")
+	}
+
+	slices.Sort(canceled)
+	missing := exptypes.Platforms{}
+	for _, k := range canceled {
+		ref := res.Refs[k]
+		delete(res.Refs, k)
+		delete(res.Attestations, k)
+		go ref.Release(context.TODO())
+
+		i := slices.IndexFunc(ps.Platforms, func(p exptypes.Platform) bool { return p.ID == k })
+		if i >= 0 {
+			missing.Platforms = append(missing.Platforms, ps.Platforms[i])
+			ps.Platforms = slices.Delete(ps.Platforms, i, i+1)
+		} else if p, err := platforms.Parse(k); err == nil {
+			missing.Platforms = append(missing.Platforms, exptypes.Platform{ID: k, Platform: platforms.Normalize(p)})
+		}
+	}
+	if len(ps.Platforms) > 0 {
+		dt, err := json.Marshal(ps)
+		if err != nil {
+			return err
+		}
+		res.AddMeta(exptypes.ExporterPlatformsKey, dt)
+	}
+	dt, err := json.Marshal(missing)
+	if err != nil {
+		return err
+	}
+	res.AddMeta(exptypes.ExporterPlatformsMissingKey, dt)
+	return nil
+}
+
+func formatPlatform(p platforms.Platform) string {
+	return platforms.FormatAll(platforms.Normalize(p))
+}
@@ -0,0 +1,111 @@
+package llbsolver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/moby/buildkit/exporter/containerimage/exptypes"
+	"github.com/moby/buildkit/frontend"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/pb"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingResult blocks until its context is canceled if block is set
+type blockingResult struct {
+	id      string
+	block   bool
+	started chan struct{}
+}
+
+func (r *blockingResult) ID() string                    { return r.id }
+func (r *blockingResult) Release(context.Context) error { return nil }
+func (r *blockingResult) Definition() *pb.Definition    { return nil }
+func (r *blockingResult) Provenance() any               { return nil }
+
+func (r *blockingResult) Result(ctx context.Context) (solver.CachedResult, error) {
+	if r.started != nil {
+		close(r.started)
+	}
+	if !r.block {
+		return nil, nil
+	}
+	<-ctx.Done()
+	return nil, context.Cause(ctx)
+}
+
+func multiPlatformResult(t *testing.T, refs map[string]solver.ResultProxy) *frontend.Result {
+	res := &frontend.Result{}
+	ps := exptypes.Platforms{}
+	for _, id := range []string{"linux/amd64", "linux/arm64"} {
+		res.AddRef(id, refs[id])
+		ps.Platforms = append(ps.Platforms, exptypes.Platform{
+			ID:       id,
+			Platform: ocispecs.Platform{OS: "linux", Architecture: id[len("linux/"):]},
+		})
+	}
+	dt, err := json.Marshal(ps)
+	require.NoError(t, err)
+	res.AddMeta(exptypes.ExporterPlatformsKey, dt)
+	return res
+}
+
+func TestEvaluateResultCancelPlatform(t *testing.T) {
+	pc := newPlatformCancels()
+	bp, unregister := pc.register("build")
+	defer unregister()
+
+	arm64 := &blockingResult{id: "arm64", block: true, started: make(chan struct{})}
+	res := multiPlatformResult(t, map[string]solver.ResultProxy{
+		"linux/amd64": &blockingResult{id: "amd64"},
+		"linux/arm64": arm64,
+	})
+
+	go func() {
+		<-arm64.started
+		require.NoError(t, pc.cancel("build", []string{"linux/aarch64"}))
+	}()
+	require.NoError(t, evaluateResult(context.TODO(), res, bp))
+
+	require.Len(t, res.Refs, 1)
+	require.Contains(t, res.Refs, "linux/amd64")
+
+	var ps exptypes.Platforms
+	require.NoError(t, json.Unmarshal(res.Metadata[exptypes.ExporterPlatformsKey], &ps))
+	require.Len(t, ps.Platforms, 1)
+	require.Equal(t, "linux/amd64", ps.Platforms[0].ID)
+
+	var missing exptypes.Platforms
+	require.NoError(t, json.Unmarshal(res.Metadata[exptypes.ExporterPlatformsMissingKey], &missing))
+	require.Len(t, missing.Platforms, 1)
+	require.Equal(t, "linux/arm64", missing.Platforms[0].ID)
+}
+
+func TestEvaluateResultCancelAllPlatforms(t *testing.T) {
+	pc := newPlatformCancels()
+	bp, unregister := pc.register("build")
+	defer unregister()
+
+	// platforms canceled before the result is evaluated
+	require.NoError(t, pc.cancel("build", []string{"linux/amd64", "linux/arm64"}))
+	res := multiPlatformResult(t, map[string]solver.ResultProxy{
+		"linux/amd64": &blockingResult{id: "amd64", block: true},
+		"linux/arm64": &blockingResult{id: "arm64", block: true},
+	})
+	err := evaluateResult(context.TODO(), res, bp)
+	require.ErrorContains(t, err, "all platforms of the build were canceled")
+	require.True(t, errors.Is(err, errPlatformCanceled))
+}
+
+func TestCancelPlatformsErrors(t *testing.T) {
+	pc := newPlatformCancels()
+	require.ErrorContains(t, pc.cancel("missing", []string{"linux/arm64"}), "build missing not found")
+
+	_, unregister := pc.register("build")
+	require.ErrorContains(t, pc.cancel("build", []string{"linux/arm64/v8/extra"}), "invalid platform")
+	unregister()
+	require.ErrorContains(t, pc.cancel("build", []string{"linux/arm64"}), "build build not found")
+}
@@ -0,0 +1,179 @@
+package llbsolver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/pb"
+	srctypes "github.com/moby/buildkit/source/types"
+	"github.com/moby/buildkit/util/progress/logs"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/semaphore"
+)
+
+const keyConcurrencyLimits = "llb.concurrencylimits"
+
+// ConcurrencyLimits is the maximum number of operations of each type that can
+// run at the same time. Zero values are not limited.
+type ConcurrencyLimits struct {
+	Exec      int
+	ImagePull int
+	LocalSync int
+}
+
+func (l ConcurrencyLimits) validate() error {
+	if l.Exec < 0 || l.ImagePull < 0 || l.LocalSync < 0 {
+		return errors.Errorf("invalid negative concurrency limit %+v", l)
+	}
+	return nil
+}
+
+type opClass int
+
+const (
+	opClassExec opClass = iota
+	opClassImagePull
+	opClassLocalSync
+	numOpClasses
+)
+
+// concurrencyLimiter holds a semaphore for every limited class of
+// operations. Limiters created for a single solve are capped at the limits
+// of the daemon and operations also take a slot of the daemon limiter.
+type concurrencyLimiter struct {
+	limits [numOpClasses]int
+	sems   [numOpClasses]*semaphore.Weighted
+	parent *concurrencyLimiter
+}
+
+func newConcurrencyLimiter(l ConcurrencyLimits, parent *concurrencyLimiter) *concurrencyLimiter {
+	cl := &concurrencyLimiter{parent: parent}
+	for class, n := range [numOpClasses]int{
+		opClassExec:      l.Exec,
+		opClassImagePull: l.ImagePull,
+		opClassLocalSync: l.LocalSync,
+	} {
+		if n <= 0 {
+			continue
+		}
+		if parent != nil && parent.limits[class] > 0 {
+			n = min(n, parent.limits[class])
+		}
+		cl.limits[class] = n
+		cl.sems[class] = semaphore.NewWeighted(int64(n))
+	}
+	return cl
+}
+
+// acquire waits for a slot of class in the limiter and all its parents
+func (cl *concurrencyLimiter) acquire(ctx context.Context, class opClass) (solver.ReleaseFunc, error) {
+	var sems []*semaphore.Weighted
+	release := func() {
+		for i := len(sems) - 1; i >= 0; i-- {
+			sems[i].Release(1)
+		}
+	}
+	for l := cl; l != nil; l = l.parent {
+		sem := l.sems[class]
+		if sem == nil {
+			continue
+		}
+		if err := sem.Acquire(ctx, 1); err != nil {
+			release()
+			return nil, err
+		}
+		sems = append(sems, sem)
+	}
+	return release, nil
+}
+
+func classifyOp(v solver.Vertex) (opClass, bool) {
+	op, ok := v.Sys().(*pb.Op)
+	if !ok {
+		return 0, false
+	}
+	switch op := op.Op.(type) {
+	case *pb.Op_Exec:
+		return opClassExec, true
+	case *pb.Op_Source:
+		scheme, _, _ := strings.Cut(op.Source.Identifier, "://")
+		switch scheme {
+		case srctypes.DockerImageScheme:
+			return opClassImagePull, true
+		case srctypes.LocalScheme:
+			return opClassLocalSync, true
+		}
+	}
+	return 0, false
+}
+
+// acquireOp waits for a slot of the class of the operation. The limits of
+// the first build of the vertex that sets them apply in addition to the
+// limits of the daemon. Lazy blobs downloaded by the operation wait for a
+// slot of the image pull limit. The cache refs created by the operation are
+// attributed to the client identity of its first build, its processes use
+// the DNS resolver of its first build that selects one, and its fetches use
+// the proxy configuration of its first build that sets one.
+func (s *Solver) acquireOp(ctx context.Context, v solver.Vertex, b solver.Builder) (context.Context, solver.ReleaseFunc, error) {
+	ctx = logs.WithLimits(ctx, s.stepLogLimits)
+	ctx, err := withBuilderClient(ctx, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if ctx, err = withBuilderNameservers(ctx, b); err != nil {
+		return nil, nil, err
+	}
+	if ctx, err = withBuilderProxy(ctx, b); err != nil {
+		return nil, nil, err
+	}
+	cl, err := s.builderLimiter(ctx, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cl == nil {
+		return ctx, func() {}, nil
+	}
+	class, ok := classifyOp(v)
+	if !ok {
+		return withFetchLimiter(ctx, cl), func() {}, nil
+	}
+	release, err := cl.acquire(ctx, class)
+	if err != nil {
+		return nil, nil, err
+	}
+	if class == opClassImagePull {
+		// image pulls already hold a slot for downloading their layers
+		ctx = cache.WithFetchLimiter(ctx, nil)
+	} else {
+		ctx = withFetchLimiter(ctx, cl)
+	}
+	return ctx, release, nil
+}
+
+// builderLimiter returns the limiter of the first build of b that has
+// concurrency limits, or the limiter of the daemon
+func (s *Solver) builderLimiter(ctx context.Context, b solver.Builder) (*concurrencyLimiter, error) {
+	cl := s.limiter
+	err := b.EachValue(ctx, keyConcurrencyLimits, func(v any) error {
+		x, ok := v.(*concurrencyLimiter)
+		if !ok {
+			return errors.Errorf("invalid concurrency limits %T", v)
+		}
+		cl = x
+		return errStopEach
+	})
+	if err != nil && !errors.Is(err, errStopEach) {
+		return nil, err
+	}
+	return cl, nil
+}
+
+func withFetchLimiter(ctx context.Context, cl *concurrencyLimiter) context.Context {
+	return cache.WithFetchLimiter(ctx, func(ctx context.Context) (func(), error) {
+		return cl.acquire(ctx, opClassImagePull)
+	})
+}
+
+var errStopEach = errors.New("stop")
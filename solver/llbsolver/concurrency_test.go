@@ -0,0 +1,93 @@
+package llbsolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireOpLimits(t *testing.T) {
+	ctx := context.TODO()
+	s := &Solver{limiter: newConcurrencyLimiter(ConcurrencyLimits{Exec: 1, ImagePull: 1}, nil)}
+
+	exec := &testVertex{op: &pb.Op{Op: &pb.Op_Exec{Exec: &pb.ExecOp{}}}}
+	pull := &testVertex{op: &pb.Op{Op: &pb.Op_Source{Source: &pb.SourceOp{Identifier: "docker-image://docker.io/library/alpine:latest"}}}}
+	local := &testVertex{op: &pb.Op{Op: &pb.Op_Source{Source: &pb.SourceOp{Identifier: "local://context"}}}}
+	file := &testVertex{op: &pb.Op{Op: &pb.Op_File{File: &pb.FileOp{}}}}
+
+	b := &testBuilder{}
+	_, release, err := s.acquireOp(ctx, exec, b)
+	require.NoError(t, err)
+
+	// pulls are limited separately from execs
+	_, releasePull, err := s.acquireOp(ctx, pull, b)
+	require.NoError(t, err)
+	releasePull()
+
+	// local syncs and file ops are not limited
+	for _, v := range []solver.Vertex{local, local, file, file} {
+		_, r, err := s.acquireOp(ctx, v, b)
+		require.NoError(t, err)
+		defer r()
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = s.acquireOp(tctx, exec, b)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+
+	// the limits of a build are capped at the limits of the daemon
+	cl := newConcurrencyLimiter(ConcurrencyLimits{Exec: 2, LocalSync: 1}, s.limiter)
+	require.Equal(t, 1, cl.limits[opClassExec])
+	require.Equal(t, 1, cl.limits[opClassLocalSync])
+	b.values = map[string][]any{keyConcurrencyLimits: {cl}}
+	_, r1, err := s.acquireOp(ctx, exec, b)
+	require.NoError(t, err)
+
+	// builds share the slots of the daemon
+	tctx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = s.acquireOp(tctx, exec, &testBuilder{})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	r1()
+
+	// a build can't use the slots of the daemon beyond its own limit
+	_, r1, err = s.acquireOp(ctx, local, b)
+	require.NoError(t, err)
+	defer r1()
+	tctx, cancel = context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = s.acquireOp(tctx, local, b)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	_, r2, err := s.acquireOp(ctx, local, &testBuilder{})
+	require.NoError(t, err)
+	defer r2()
+}
+
+type testVertex struct {
+	solver.Vertex
+	op *pb.Op
+}
+
+func (v *testVertex) Sys() any {
+	return v.op
+}
+
+type testBuilder struct {
+	solver.Builder
+	values map[string][]any
+}
+
+func (b *testBuilder) EachValue(ctx context.Context, key string, fn func(any) error) error {
+	for _, v := range b.values[key] {
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
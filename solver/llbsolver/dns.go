@@ -0,0 +1,52 @@
+package llbsolver
+
+import (
+	"context"
+
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/llbsolver/ops"
+	"github.com/pkg/errors"
+)
+
+const (
+	keyDNSResolver = "llb.dnsresolver"
+	// keyDNSResolverOpt is the frontend option selecting the DNS resolver of
+	// the exec ops of a build by name
+	keyDNSResolverOpt = "dns-resolver"
+)
+
+// dnsResolver returns the nameserver of the DNS resolver selected by the
+// frontend options of a build, or an empty string if the build uses the
+// default one
+func (s *Solver) dnsResolver(opt map[string]string) (string, error) {
+	name := opt[keyDNSResolverOpt]
+	if name == "" {
+		return "", nil
+	}
+	ns, ok := s.dnsResolvers[name]
+	if !ok {
+		return "", errors.Errorf("unknown DNS resolver %q", name)
+	}
+	return ns, nil
+}
+
+// withBuilderNameservers returns a ctx with which the exec ops use the DNS
+// resolver of the first build of b that selects one
+func withBuilderNameservers(ctx context.Context, b solver.Builder) (context.Context, error) {
+	var ns string
+	err := b.EachValue(ctx, keyDNSResolver, func(v any) error {
+		x, ok := v.(string)
+		if !ok {
+			return errors.Errorf("invalid DNS resolver %T", v)
+		}
+		ns = x
+		return errStopEach
+	})
+	if err != nil && !errors.Is(err, errStopEach) {
+		return nil, err
+	}
+	if ns == "" {
+		return ctx, nil
+	}
+	return ops.WithNameservers(ctx, []string{ns}), nil
+}
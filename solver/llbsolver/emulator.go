@@ -0,0 +1,70 @@
+package llbsolver
+
+import (
+	"github.com/containerd/platforms"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/errdefs"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/archutil"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// checkEmulators returns a MissingEmulatorsError listing the exec vertices of
+// the graph of e whose processes can't be run by the worker because no
+// emulator is installed for their platform
+func checkEmulators(e solver.Edge, native ocispecs.Platform, emulators func() []archutil.Emulator) error {
+	var (
+		missing []*errdefs.MissingEmulator
+		loaded  []archutil.Emulator
+		done    bool
+		seen    = map[digest.Digest]struct{}{}
+	)
+	var walk func(v solver.Vertex)
+	walk = func(v solver.Vertex) {
+		if _, ok := seen[v.Digest()]; ok {
+			return
+		}
+		seen[v.Digest()] = struct{}{}
+		for _, in := range v.Inputs() {
+			walk(in.Vertex)
+		}
+		op, ok := v.Sys().(*pb.Op)
+		if !ok || op.Platform == nil {
+			return
+		}
+		if _, ok := op.Op.(*pb.Op_Exec); !ok {
+			return
+		}
+		p := op.Platform.Spec()
+		if p.OS != native.OS || platforms.Only(native).Match(p) {
+			return
+		}
+		if !done {
+			loaded, done = emulators(), true
+		}
+		if archutil.CanRun(p, native, loaded) {
+			return
+		}
+		missing = addMissingEmulator(missing, op.Platform, &errdefs.EmulatedVertex{
+			Digest: v.Digest().String(),
+			Name:   v.Name(),
+		})
+	}
+	walk(e.Vertex)
+	if len(missing) > 0 {
+		return errdefs.NewMissingEmulatorsError(missing)
+	}
+	return nil
+}
+
+func addMissingEmulator(missing []*errdefs.MissingEmulator, p *pb.Platform, v *errdefs.EmulatedVertex) []*errdefs.MissingEmulator {
+	key := platforms.FormatAll(p.Spec())
+	for _, m := range missing {
+		if platforms.FormatAll(m.Platform.Spec()) == key {
+			m.Vertices = append(m.Vertices, v)
+			return missing
+		}
+	}
+	return append(missing, &errdefs.MissingEmulator{Platform: p, Vertices: []*errdefs.EmulatedVertex{v}})
+}
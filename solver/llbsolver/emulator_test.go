@@ -0,0 +1,82 @@
+package llbsolver
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/errdefs"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/archutil"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+type graphVertex struct {
+	testVertex
+	name   string
+	inputs []solver.Edge
+}
+
+func (v *graphVertex) Digest() digest.Digest {
+	return digest.FromString(v.name)
+}
+
+func (v *graphVertex) Name() string {
+	return v.name
+}
+
+func (v *graphVertex) Inputs() []solver.Edge {
+	return v.inputs
+}
+
+func TestCheckEmulators(t *testing.T) {
+	native := ocispecs.Platform{OS: "linux", Architecture: "amd64"}
+	newVertex := func(name, arch string, exec bool, inputs ...solver.Vertex) *graphVertex {
+		op := &pb.Op{Platform: &pb.Platform{OS: "linux", Architecture: arch}}
+		if exec {
+			op.Op = &pb.Op_Exec{Exec: &pb.ExecOp{}}
+		} else {
+			op.Op = &pb.Op_File{File: &pb.FileOp{}}
+		}
+		v := &graphVertex{testVertex: testVertex{op: op}, name: name}
+		for _, in := range inputs {
+			v.inputs = append(v.inputs, solver.Edge{Vertex: in})
+		}
+		return v
+	}
+
+	arm := newVertex("RUN make arm64", "arm64", true)
+	riscv := newVertex("RUN make riscv64", "riscv64", true)
+	riscvCopy := newVertex("COPY riscv64", "riscv64", false, riscv)
+	s390x := newVertex("RUN make s390x", "s390x", true)
+	host := newVertex("RUN merge", "amd64", true, arm, riscvCopy, s390x, riscv)
+
+	var loaded int
+	emulators := func() []archutil.Emulator {
+		loaded++
+		return []archutil.Emulator{
+			{Platform: ocispecs.Platform{OS: "linux", Architecture: "arm64"}},
+			{Platform: ocispecs.Platform{OS: "linux", Architecture: "s390x"}, Path: "/usr/bin/buildkit-qemu-s390x"},
+		}
+	}
+
+	err := checkEmulators(solver.Edge{Vertex: host}, native, emulators)
+	require.Error(t, err)
+	require.Equal(t, 1, loaded)
+
+	var me *errdefs.MissingEmulatorsError
+	require.ErrorAs(t, err, &me)
+	require.Len(t, me.Emulators, 1)
+	require.Equal(t, "riscv64", me.Emulators[0].Platform.Architecture)
+	require.Len(t, me.Emulators[0].Vertices, 1)
+	require.Equal(t, riscv.Digest().String(), me.Emulators[0].Vertices[0].Digest)
+	require.Contains(t, err.Error(), `linux/riscv64 required by "RUN make riscv64"`)
+
+	// the emulators are only loaded for graphs with processes of other
+	// platforms, other ops don't run processes
+	loaded = 0
+	copyOnly := newVertex("COPY --platform=riscv64", "riscv64", false)
+	require.NoError(t, checkEmulators(solver.Edge{Vertex: newVertex("RUN make", "amd64", true, copyOnly)}, native, emulators))
+	require.Equal(t, 0, loaded)
+}
@@ -3,6 +3,7 @@ package errdefs
 import (
 	"context"
 	"runtime"
+	"slices"
 
 	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/util/bklog"
@@ -11,8 +12,11 @@ import (
 // ExecError will be returned when an error is encountered when evaluating an op.
 type ExecError struct {
 	error
-	Inputs        []solver.Result
-	Mounts        []solver.Result
+	Inputs []solver.Result
+	Mounts []solver.Result
+	// Artifacts are the artifacts captured from the failed exec, if it
+	// declared any
+	Artifacts     solver.Result
 	OwnerBorrowed bool
 }
 
@@ -34,7 +38,7 @@ func (e *ExecError) EachRef(fn func(solver.Result) error) (err error) {
 			err = err1
 		}
 	}
-	for _, res := range e.Mounts {
+	for _, res := range append(slices.Clip(e.Mounts), e.Artifacts) {
 		if res == nil {
 			continue
 		}
@@ -28,6 +28,7 @@ import (
 	"github.com/moby/buildkit/util/grpcerrors"
 	"github.com/moby/buildkit/util/iohelper"
 	"github.com/moby/buildkit/util/leaseutil"
+	"github.com/moby/buildkit/util/throttle"
 	digest "github.com/opencontainers/go-digest"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
@@ -58,6 +59,12 @@ type HistoryQueueOpt struct {
 	CleanConfig    *config.HistoryConfig
 	GarbageCollect func(context.Context) error
 	GracefulStop   <-chan struct{}
+	// OnEvent is called when a build starts and completes. It must not block.
+	OnEvent func(*controlapi.BuildHistoryEvent)
+	// SpillDir is the directory of the output of the steps clipped because
+	// of the step log limits until it is added to the step logs. Clipped
+	// output is not kept if it is empty.
+	SpillDir string
 }
 
 type HistoryQueue struct {
@@ -72,6 +79,8 @@ type HistoryQueue struct {
 	deleted       map[string]struct{}
 	hContentStore *containerdsnapshot.Store
 	hLeaseManager *leaseutil.Manager
+	vertexStats   *vertexStats
+	spill         *spillStore
 }
 
 // finalizer controls completion of saving traces for a
@@ -87,6 +96,14 @@ type StatusImportResult struct {
 	NumCompletedSteps int
 	NumTotalSteps     int
 	NumWarnings       int
+	// StepLogs is the archive with the logs of every step. It is nil if the
+	// build did not produce any logs.
+	StepLogs *ocispecs.Descriptor
+	// ResourceUsage is the total of the resources used by the steps. It is
+	// nil if no step reported its usage.
+	ResourceUsage *client.ResourceUsage
+	// CacheSources is the number of cached steps loaded from every cache
+	CacheSources map[string]int
 }
 
 func NewHistoryQueue(opt HistoryQueueOpt) (*HistoryQueue, error) {
@@ -101,11 +118,24 @@ func NewHistoryQueue(opt HistoryQueueOpt) (*HistoryQueue, error) {
 		ps: &pubsub[*controlapi.BuildHistoryEvent]{
 			m: map[*channel[*controlapi.BuildHistoryEvent]]struct{}{},
 		},
-		active:     map[string]*controlapi.BuildHistoryRecord{},
-		refs:       map[string]int{},
-		deleted:    map[string]struct{}{},
-		finalizers: map[string]*finalizer{},
+		active:      map[string]*controlapi.BuildHistoryRecord{},
+		refs:        map[string]int{},
+		deleted:     map[string]struct{}{},
+		finalizers:  map[string]*finalizer{},
+		vertexStats: &vertexStats{},
+	}
+	if opt.SpillDir != "" {
+		spill, err := newSpillStore(opt.SpillDir)
+		if err != nil {
+			return nil, err
+		}
+		h.spill = spill
 	}
+	h.vertexStats.flush = throttle.Throttle(vertexStatsFlushInterval, func() {
+		if err := h.flushVertexStats(); err != nil {
+			bklog.L.Warnf("failed to write vertex stats: %v", err)
+		}
+	})
 
 	ns := h.opt.ContentStore.Namespace()
 	// double check invalid configuration
@@ -150,6 +180,9 @@ func NewHistoryQueue(opt HistoryQueueOpt) (*HistoryQueue, error) {
 
 	go func() {
 		<-h.opt.GracefulStop
+		if err := h.flushVertexStats(); err != nil {
+			bklog.L.Warnf("failed to write vertex stats: %v", err)
+		}
 		h.mu.Lock()
 		defer h.mu.Unlock()
 		// if active builds then close will happen in finalizer
@@ -329,29 +362,14 @@ func (h *HistoryQueue) gc() error {
 		return err
 	}
 
-	// in order for record to get deleted by gc it exceed both maxentries and maxage criteria
-	if len(records) < int(h.opt.CleanConfig.MaxEntries) {
-		return nil
+	if err := h.gcStepLogs(records); err != nil {
+		return err
 	}
-
-	// sort array by newest records first
-	slices.SortFunc(records, func(a, b *controlapi.BuildHistoryRecord) int {
-		return -a.CompletedAt.AsTime().Compare(b.CompletedAt.AsTime())
-	})
-
-	h.mu.Lock()
-	defer h.mu.Unlock()
-
-	now := time.Now()
-	for _, r := range records[h.opt.CleanConfig.MaxEntries:] {
-		if now.Add(-h.opt.CleanConfig.MaxAge.Duration).After(r.CompletedAt.AsTime()) {
-			if _, err := h.delete(r.Ref); err != nil {
-				return err
-			}
-		}
+	if err := h.gcVertexStats(); err != nil {
+		return err
 	}
 
-	return nil
+	return h.applyRetention(records)
 }
 
 func (h *HistoryQueue) clearOrphans() error {
@@ -605,6 +623,9 @@ func (h *HistoryQueue) update(ctx context.Context, rec *controlapi.BuildHistoryR
 		if err := h.addResource(ctx, l, rec.Trace, false); err != nil {
 			return err
 		}
+		if err := h.addResource(ctx, l, rec.StepLogs, false); err != nil {
+			return err
+		}
 		if err := h.addResource(ctx, l, rec.ExternalError, false); err != nil {
 			return err
 		}
@@ -687,11 +708,20 @@ func (h *HistoryQueue) Finalize(ctx context.Context, ref string) error {
 
 func (h *HistoryQueue) Update(ctx context.Context, e *controlapi.BuildHistoryEvent) error {
 	h.init()
+	e = e.CloneVT()
+	if err := h.applyEvent(ctx, e); err != nil {
+		return err
+	}
+	if h.opt.OnEvent != nil {
+		h.opt.OnEvent(e)
+	}
+	return nil
+}
+
+func (h *HistoryQueue) applyEvent(ctx context.Context, e *controlapi.BuildHistoryEvent) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	e = e.CloneVT()
-
 	if e.Type == controlapi.BuildHistoryEventType_STARTED {
 		h.active[e.Record.Ref] = e.Record
 		h.ps.Send(e)
@@ -833,7 +863,9 @@ func (h *HistoryQueue) ImportError(ctx context.Context, err error) (_ *spb.Statu
 	}, release, nil
 }
 
-func (h *HistoryQueue) ImportStatus(ctx context.Context, ch chan *client.SolveStatus) (_ *StatusImportResult, _ func(), err error) {
+// ImportStatus writes the status stream of a build to the history blobs. The
+// timestamps of the stream are normalized with ts.
+func (h *HistoryQueue) ImportStatus(ctx context.Context, ch chan *client.SolveStatus, ts *Timestamps) (_ *StatusImportResult, _ func(), err error) {
 	defer func() {
 		if ch == nil {
 			return
@@ -858,13 +890,22 @@ func (h *HistoryQueue) ImportStatus(ctx context.Context, ch chan *client.SolveSt
 	type vtxInfo struct {
 		cached    bool
 		completed bool
+		usage     *client.ResourceUsage
+		source    string
 	}
 	vtxMap := make(map[digest.Digest]*vtxInfo)
 	var numWarnings int
+	logs := newStepLogs(h.spill)
+	logs.timestamps = ts
+	defer logs.release()
 
 	buf := make([]byte, 32*1024)
 	for st := range ch {
+		st = ts.Status(st)
 		numWarnings += len(st.Warnings)
+		if err := logs.update(st); err != nil {
+			return nil, nil, err
+		}
 		for _, vtx := range st.Vertexes {
 			if _, ok := vtxMap[vtx.Digest]; !ok {
 				vtxMap[vtx.Digest] = &vtxInfo{}
@@ -875,6 +916,12 @@ func (h *HistoryQueue) ImportStatus(ctx context.Context, ch chan *client.SolveSt
 			if vtx.Completed != nil {
 				vtxMap[vtx.Digest].completed = true
 			}
+			if vtx.ResourceUsage != nil {
+				vtxMap[vtx.Digest].usage = vtx.ResourceUsage
+			}
+			if vtx.CacheSource != "" {
+				vtxMap[vtx.Digest].source = vtx.CacheSource
+			}
 		}
 
 		hdr := make([]byte, 4)
@@ -904,15 +951,49 @@ func (h *HistoryQueue) ImportStatus(ctx context.Context, ch chan *client.SolveSt
 		return nil, nil, err
 	}
 
+	var stepLogsDesc *ocispecs.Descriptor
+	if !logs.empty() {
+		d, releaseLogs, err := h.importStepLogs(ctx, logs)
+		if err != nil {
+			release()
+			return nil, nil, err
+		}
+		stepLogsDesc = d
+		releaseStatus := release
+		release = func() {
+			releaseStatus()
+			releaseLogs()
+		}
+	}
+
 	numCached := 0
 	numCompleted := 0
-	for _, info := range vtxMap {
+	var usage *client.ResourceUsage
+	var sources map[string]int
+	executed := make(map[digest.Digest]bool, len(vtxMap))
+	for dgst, info := range vtxMap {
+		if info.usage != nil {
+			if usage == nil {
+				usage = &client.ResourceUsage{}
+			}
+			usage.Add(info.usage)
+		}
 		if info.cached {
 			numCached++
+			if info.source != "" {
+				if sources == nil {
+					sources = map[string]int{}
+				}
+				sources[info.source]++
+			}
 		}
 		if info.completed {
 			numCompleted++
 		}
+		executed[dgst] = info.completed && !info.cached
+	}
+	if err := h.updateVertexStats(executed); err != nil {
+		bklog.G(ctx).Warnf("failed to update vertex stats: %v", err)
 	}
 
 	return &StatusImportResult{
@@ -921,6 +1002,9 @@ func (h *HistoryQueue) ImportStatus(ctx context.Context, ch chan *client.SolveSt
 		NumCompletedSteps: numCompleted,
 		NumTotalSteps:     len(vtxMap),
 		NumWarnings:       numWarnings,
+		StepLogs:          stepLogsDesc,
+		ResourceUsage:     usage,
+		CacheSources:      sources,
 	}, release, nil
 }
 
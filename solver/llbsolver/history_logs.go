@@ -0,0 +1,478 @@
+package llbsolver
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/leases"
+	cerrdefs "github.com/containerd/errdefs"
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/identity"
+	"github.com/moby/buildkit/util/bklog"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+// maxStepLogsSize is the maximum compressed size of the logs kept for a
+// build. Output of steps that exceeds it is dropped.
+const maxStepLogsSize = 64 << 20
+
+// spillStore keeps the output of the steps clipped because of the step log
+// limits in compressed files until it is added to the step logs of the build
+// history. A file is removed once it was released by its writer and by the
+// step logs of the build.
+type spillStore struct {
+	dir     string
+	maxSize int64
+
+	mu    sync.Mutex
+	files map[string]*spillFile
+}
+
+type spillFile struct {
+	refs      int
+	closed    bool
+	size      int64
+	csize     int64
+	truncated bool
+}
+
+func newSpillStore(dir string) (*spillStore, error) {
+	// spill files of a previous daemon run are never added to the history
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, errors.Wrapf(err, "failed to clean step log spill dir %s", dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrapf(err, "failed to create step log spill dir %s", dir)
+	}
+	return &spillStore{
+		dir:     dir,
+		maxSize: maxStepLogsSize,
+		files:   map[string]*spillFile{},
+	}, nil
+}
+
+func (s *spillStore) path(id string) string {
+	return filepath.Join(s.dir, id+".log.gz")
+}
+
+// Spill implements logs.Spiller
+func (s *spillStore) Spill() (string, io.WriteCloser, error) {
+	id := identity.NewID()
+	f, err := os.OpenFile(s.path(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", nil, errors.WithStack(err)
+	}
+	s.mu.Lock()
+	s.files[id] = &spillFile{refs: 1}
+	s.mu.Unlock()
+	cw := &countingWriter{w: f}
+	return id, &spillWriter{
+		s:  s,
+		id: id,
+		f:  f,
+		cw: cw,
+		gz: gzip.NewWriter(cw),
+	}, nil
+}
+
+// acquire adds a reference to the spill file until it is released
+func (s *spillStore) acquire(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, ok := s.files[id]
+	if !ok {
+		return false
+	}
+	f.refs++
+	return true
+}
+
+func (s *spillStore) release(id string) {
+	s.mu.Lock()
+	f, ok := s.files[id]
+	if ok {
+		f.refs--
+		if f.refs > 0 {
+			ok = false
+		} else {
+			delete(s.files, id)
+		}
+	}
+	s.mu.Unlock()
+	if ok {
+		os.Remove(s.path(id))
+	}
+}
+
+func (s *spillStore) info(id string) spillFile {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.files[id]; ok {
+		return *f
+	}
+	return spillFile{}
+}
+
+type spillWriter struct {
+	s         *spillStore
+	id        string
+	f         *os.File
+	cw        *countingWriter
+	gz        *gzip.Writer
+	size      int64
+	truncated bool
+}
+
+func (w *spillWriter) Write(dt []byte) (int, error) {
+	if w.truncated {
+		return len(dt), nil
+	}
+	if w.cw.n >= w.s.maxSize {
+		w.truncated = true
+		return len(dt), nil
+	}
+	n, err := w.gz.Write(dt)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *spillWriter) Close() error {
+	err := w.gz.Close()
+	if err1 := w.f.Close(); err == nil {
+		err = errors.WithStack(err1)
+	}
+	w.s.mu.Lock()
+	if f, ok := w.s.files[w.id]; ok && err == nil {
+		f.closed = true
+		f.size = w.size
+		f.csize = w.cw.n
+		f.truncated = w.truncated
+	}
+	w.s.mu.Unlock()
+	w.s.release(w.id)
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(dt []byte) (int, error) {
+	n, err := w.w.Write(dt)
+	w.n += int64(n)
+	return n, err
+}
+
+// stepLogs collects the logs of every step of a build. Logs are compressed
+// as they arrive and kept in memory, up to maxSize, until they are written
+// to the history content store. Output clipped because of the step log
+// limits is read from the spill files.
+type stepLogs struct {
+	names   map[digest.Digest]string
+	order   []digest.Digest
+	steps   map[digest.Digest]*stepLog
+	size    int
+	maxSize int
+	spill   *spillStore
+	// timestamps normalizes the modification time of the archive entries
+	timestamps *Timestamps
+}
+
+type stepLog struct {
+	buf       bytes.Buffer
+	gz        *gzip.Writer
+	size      int64
+	truncated bool
+	dropped   bool
+	spills    []string
+}
+
+func newStepLogs(spill *spillStore) *stepLogs {
+	return &stepLogs{
+		names:   map[digest.Digest]string{},
+		steps:   map[digest.Digest]*stepLog{},
+		maxSize: maxStepLogsSize,
+		spill:   spill,
+	}
+}
+
+// release releases the spill files of the steps
+func (s *stepLogs) release() {
+	if s.spill == nil {
+		return
+	}
+	for _, sl := range s.steps {
+		for _, id := range sl.spills {
+			s.spill.release(id)
+		}
+		sl.spills = nil
+	}
+}
+
+func (s *stepLogs) update(st *client.SolveStatus) error {
+	for _, l := range st.Logs {
+		// replayed output is read from the spill file
+		if l.Replay {
+			continue
+		}
+		sl, ok := s.steps[l.Vertex]
+		if !ok {
+			sl = &stepLog{}
+			s.steps[l.Vertex] = sl
+			s.order = append(s.order, l.Vertex)
+		}
+		if l.Spill != "" {
+			if s.spill != nil && s.spill.acquire(l.Spill) {
+				sl.spills = append(sl.spills, l.Spill)
+			} else {
+				sl.dropped = true
+			}
+			continue
+		}
+		// the truncation markers are recorded in the index instead
+		if l.Truncated {
+			sl.truncated = true
+			continue
+		}
+		if s.size >= s.maxSize {
+			sl.dropped = true
+			continue
+		}
+		if sl.gz == nil {
+			// a new gzip member is started if the step produces output
+			// after it was completed
+			sl.gz = gzip.NewWriter(&sl.buf)
+		}
+		n := sl.buf.Len()
+		if _, err := sl.gz.Write(l.Data); err != nil {
+			return err
+		}
+		s.size += sl.buf.Len() - n
+		sl.size += int64(len(l.Data))
+	}
+	for _, v := range st.Vertexes {
+		s.names[v.Digest] = v.Name
+		// release the compressor of completed steps
+		if sl, ok := s.steps[v.Digest]; ok && v.Completed != nil {
+			if err := sl.close(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (sl *stepLog) close() error {
+	if sl.gz == nil {
+		return nil
+	}
+	err := sl.gz.Close()
+	sl.gz = nil
+	return err
+}
+
+func (s *stepLogs) empty() bool {
+	return len(s.order) == 0
+}
+
+// writeTo writes the step logs archive. The index is written first so that
+// readers can stream the archive. The spilled output of a step follows the
+// output kept in memory as separate gzip members.
+func (s *stepLogs) writeTo(w io.Writer) error {
+	var idx client.StepLogsIndex
+	spills := make([][]string, len(s.order))
+	for i, dgst := range s.order {
+		sl := s.steps[dgst]
+		size := sl.size
+		// the output is complete if the clipped output was spilled
+		truncated := sl.truncated && len(sl.spills) == 0 || sl.dropped
+		for _, id := range sl.spills {
+			f := s.spill.info(id)
+			if !f.closed {
+				// the step is still writing to the spill file
+				truncated = true
+				continue
+			}
+			truncated = truncated || f.truncated
+			size += f.size
+			spills[i] = append(spills[i], id)
+		}
+		idx.Steps = append(idx.Steps, client.StepLog{
+			Vertex:    dgst,
+			Name:      s.names[dgst],
+			Path:      dgst.Encoded() + ".log.gz",
+			Size:      size,
+			Truncated: truncated,
+		})
+	}
+	dt, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	now := s.timestamps.Time(time.Now())
+	tw := tar.NewWriter(w)
+	if err := writeTarFile(tw, client.StepLogsIndexPath, dt, now); err != nil {
+		return err
+	}
+	for i, dgst := range s.order {
+		sl := s.steps[dgst]
+		if sl.buf.Len() == 0 && sl.gz == nil {
+			// all output of the step was dropped
+			sl.gz = gzip.NewWriter(&sl.buf)
+		}
+		if err := sl.close(); err != nil {
+			return err
+		}
+		if len(spills[i]) == 0 {
+			if err := writeTarFile(tw, idx.Steps[i].Path, sl.buf.Bytes(), now); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.writeSpilledTarFile(tw, idx.Steps[i].Path, sl.buf.Bytes(), spills[i], now); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func (s *stepLogs) writeSpilledTarFile(tw *tar.Writer, name string, dt []byte, spills []string, modTime time.Time) error {
+	size := int64(len(dt))
+	for _, id := range spills {
+		size += s.spill.info(id).csize
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  modTime,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(dt); err != nil {
+		return err
+	}
+	for _, id := range spills {
+		if err := s.copySpill(tw, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *stepLogs) copySpill(w io.Writer, id string) error {
+	f, err := os.Open(s.spill.path(id))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	_, err = io.CopyN(w, f, s.spill.info(id).csize)
+	return errors.Wrapf(err, "failed to copy spilled step output %s", id)
+}
+
+func writeTarFile(tw *tar.Writer, name string, dt []byte, modTime time.Time) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(dt)),
+		ModTime:  modTime,
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(dt)
+	return err
+}
+
+func (h *HistoryQueue) importStepLogs(ctx context.Context, s *stepLogs) (_ *ocispecs.Descriptor, _ func(), err error) {
+	w, err := h.OpenBlobWriter(ctx, client.StepLogsMediaType)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err != nil {
+			w.Discard()
+		}
+	}()
+	if err := s.writeTo(w); err != nil {
+		return nil, nil, err
+	}
+	return w.Commit(ctx)
+}
+
+// gcStepLogs removes step logs from records that have completed before the
+// retention period configured for step logs. The lock is only held while a
+// single record is updated.
+func (h *HistoryQueue) gcStepLogs(records []*controlapi.BuildHistoryRecord) error {
+	maxAge := h.opt.CleanConfig.LogsMaxAge.Duration
+	if maxAge <= 0 {
+		return nil
+	}
+	ctx := context.TODO()
+
+	now := time.Now()
+	for _, r := range records {
+		if r.StepLogs == nil || r.CompletedAt == nil || now.Add(-maxAge).Before(r.CompletedAt.AsTime()) {
+			continue
+		}
+		if err := h.removeStepLogs(ctx, r.Ref); err != nil {
+			return err
+		}
+		bklog.G(ctx).Debugf("removed step logs of build record %s", r.Ref)
+	}
+	return nil
+}
+
+func (h *HistoryQueue) removeStepLogs(ctx context.Context, ref string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.opt.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(recordsBucket))
+		if b == nil {
+			return nil
+		}
+		var br controlapi.BuildHistoryRecord
+		dt := b.Get([]byte(ref))
+		if dt == nil {
+			return nil
+		}
+		if err := br.UnmarshalVT(dt); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal build record %s", ref)
+		}
+		desc := br.StepLogs
+		if desc == nil {
+			return nil
+		}
+		br.StepLogs = nil
+		dt, err := br.MarshalVT()
+		if err != nil {
+			return err
+		}
+		if err := b.Put([]byte(ref), dt); err != nil {
+			return err
+		}
+		if err := h.hLeaseManager.DeleteResource(ctx, leases.Lease{ID: h.leaseID(ref)}, leases.Resource{
+			ID:   desc.Digest,
+			Type: "content",
+		}); err != nil && !cerrdefs.IsNotFound(err) {
+			return err
+		}
+		return nil
+	})
+}
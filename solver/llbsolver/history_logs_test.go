@@ -0,0 +1,182 @@
+package llbsolver
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepLogsArchive(t *testing.T) {
+	vtx1 := digest.FromString("vtx1")
+	vtx2 := digest.FromString("vtx2")
+
+	logs := newStepLogs(nil)
+	require.True(t, logs.empty())
+
+	err := logs.update(&client.SolveStatus{
+		Vertexes: []*client.Vertex{
+			{Digest: vtx1, Name: "[1/2] RUN make"},
+			{Digest: vtx2, Name: "[2/2] RUN make test"},
+		},
+		Logs: []*client.VertexLog{
+			{Vertex: vtx2, Data: []byte("ok\n")},
+			{Vertex: vtx1, Data: []byte("building\n")},
+		},
+	})
+	require.NoError(t, err)
+	err = logs.update(&client.SolveStatus{
+		Logs: []*client.VertexLog{
+			{Vertex: vtx1, Stream: 2, Data: []byte("done\n")},
+		},
+	})
+	require.NoError(t, err)
+	require.False(t, logs.empty())
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, logs.writeTo(buf))
+
+	var steps []client.StepLog
+	var data []string
+	err = client.ReadStepLogs(buf, func(st client.StepLog, r io.Reader) error {
+		dt, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, st)
+		data = append(data, string(dt))
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []client.StepLog{
+		{Vertex: vtx2, Name: "[2/2] RUN make test", Path: vtx2.Encoded() + ".log.gz", Size: 3},
+		{Vertex: vtx1, Name: "[1/2] RUN make", Path: vtx1.Encoded() + ".log.gz", Size: 14},
+	}, steps)
+	require.Equal(t, []string{"ok\n", "building\ndone\n"}, data)
+}
+
+func TestStepLogsTruncated(t *testing.T) {
+	vtx1 := digest.FromString("vtx1")
+	vtx2 := digest.FromString("vtx2")
+
+	vtx3 := digest.FromString("vtx3")
+
+	spill, err := newSpillStore(filepath.Join(t.TempDir(), "spill"))
+	require.NoError(t, err)
+	id2, w2, err := spill.Spill()
+	require.NoError(t, err)
+	id3, w3, err := spill.Spill()
+	require.NoError(t, err)
+
+	logs := newStepLogs(spill)
+	err = logs.update(&client.SolveStatus{
+		Logs: []*client.VertexLog{
+			{Vertex: vtx1, Data: []byte("abc")},
+			{Vertex: vtx1, Data: []byte("[output clipped]"), Truncated: true},
+			{Vertex: vtx2, Spill: id2},
+			{Vertex: vtx2, Data: []byte("abc")},
+			{Vertex: vtx2, Data: []byte("[output clipped]"), Truncated: true},
+			{Vertex: vtx2, Data: []byte("cdef"), Replay: true},
+			{Vertex: vtx3, Spill: id3},
+			{Vertex: vtx3, Data: []byte("abc")},
+			{Vertex: vtx3, Data: []byte("[output clipped]"), Truncated: true},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = w2.Write([]byte("def"))
+	require.NoError(t, err)
+	require.NoError(t, w2.Close())
+	// the spill file of vtx3 is still written to
+	_, err = w3.Write([]byte("def"))
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, logs.writeTo(buf))
+
+	var steps []client.StepLog
+	var data []string
+	err = client.ReadStepLogs(buf, func(st client.StepLog, r io.Reader) error {
+		dt, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, st)
+		data = append(data, string(dt))
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []client.StepLog{
+		{Vertex: vtx1, Path: vtx1.Encoded() + ".log.gz", Size: 3, Truncated: true},
+		{Vertex: vtx2, Path: vtx2.Encoded() + ".log.gz", Size: 6},
+		{Vertex: vtx3, Path: vtx3.Encoded() + ".log.gz", Size: 3, Truncated: true},
+	}, steps)
+	require.Equal(t, []string{"abc", "abcdef", "abc"}, data)
+
+	// the spill files are removed once released by the writer and the logs
+	logs.release()
+	_, err = os.Stat(spill.path(id2))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(spill.path(id3))
+	require.NoError(t, err)
+	require.NoError(t, w3.Close())
+	_, err = os.Stat(spill.path(id3))
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestStepLogsLimit(t *testing.T) {
+	vtx1 := digest.FromString("vtx1")
+	vtx2 := digest.FromString("vtx2")
+
+	logs := newStepLogs(nil)
+	logs.maxSize = 1
+	now := time.Now()
+	err := logs.update(&client.SolveStatus{
+		Vertexes: []*client.Vertex{
+			{Digest: vtx1, Name: "[1/2] RUN make", Completed: &now},
+		},
+		Logs: []*client.VertexLog{
+			{Vertex: vtx1, Data: []byte("abc")},
+		},
+	})
+	require.NoError(t, err)
+	// the compressor of the completed step was released
+	require.Nil(t, logs.steps[vtx1].gz)
+
+	err = logs.update(&client.SolveStatus{
+		Logs: []*client.VertexLog{
+			{Vertex: vtx2, Data: []byte("def")},
+		},
+	})
+	require.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, logs.writeTo(buf))
+
+	var steps []client.StepLog
+	var data []string
+	err = client.ReadStepLogs(buf, func(st client.StepLog, r io.Reader) error {
+		dt, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, st)
+		data = append(data, string(dt))
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []client.StepLog{
+		{Vertex: vtx1, Name: "[1/2] RUN make", Path: vtx1.Encoded() + ".log.gz", Size: 3},
+		{Vertex: vtx2, Path: vtx2.Encoded() + ".log.gz", Truncated: true},
+	}, steps)
+	require.Equal(t, []string{"abc", ""}, data)
+}
@@ -0,0 +1,153 @@
+package llbsolver
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/cmd/buildkitd/config"
+	"github.com/moby/buildkit/util/bklog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// reasons of the removal of records by the retention policy
+const (
+	evictMaxEntries    = "max-entries"
+	evictOutcomeMaxAge = "outcome-max-age"
+	evictFrontend      = "frontend-max-entries"
+	evictClientEntries = "client-max-entries"
+	evictClientSize    = "client-max-size"
+	evictMaxSize       = "max-size"
+)
+
+var historyMeter = otel.Meter("github.com/moby/buildkit/solver/llbsolver")
+
+var historyEvictedRecords, _ = historyMeter.Int64Counter("buildkit.history.evictions",
+	metric.WithDescription("Number of build records removed by the history retention policy."),
+)
+
+var historyEvictedBytes, _ = historyMeter.Int64Counter("buildkit.history.evicted",
+	metric.WithDescription("Size of the blobs of the build records removed by the history retention policy."),
+	metric.WithUnit("By"),
+)
+
+type historyEviction struct {
+	rec    *controlapi.BuildHistoryRecord
+	reason string
+}
+
+// historyEvictions returns the records removed by the retention policy of
+// cfg. The records must be sorted newest first and must not contain pinned
+// records. Newer records take precedence over older ones for every limit.
+func historyEvictions(cfg *config.HistoryConfig, records []*controlapi.BuildHistoryRecord, now time.Time) []historyEviction {
+	type frontendKey struct {
+		outcome  string
+		frontend string
+	}
+	type clientUsage struct {
+		entries int64
+		size    int64
+	}
+	frontends := map[frontendKey]int64{}
+	clients := map[string]*clientUsage{}
+	var size int64
+
+	var out []historyEviction
+	for i, r := range records {
+		reason := ""
+		sz := recordSize(r)
+		outcome, _ := adaptHistoryRecord(r).Field([]string{"status"})
+		oc := cfg.Outcomes[outcome]
+		fk := frontendKey{outcome: outcome, frontend: r.Frontend}
+
+		var cu *clientUsage
+		if id := r.ClientIdentity; id != nil && id.Name != "" && cfg.Clients != nil {
+			if cu = clients[id.Name]; cu == nil {
+				cu = &clientUsage{}
+				clients[id.Name] = cu
+			}
+		}
+
+		switch {
+		// records exceeding both the max entries and the max age
+		case i >= int(cfg.MaxEntries) && now.Add(-cfg.MaxAge.Duration).After(r.CompletedAt.AsTime()):
+			reason = evictMaxEntries
+		case oc.MaxAge.Duration > 0 && r.CompletedAt != nil && now.Add(-oc.MaxAge.Duration).After(r.CompletedAt.AsTime()):
+			reason = evictOutcomeMaxAge
+		case oc.MaxEntriesPerFrontend > 0 && frontends[fk] >= oc.MaxEntriesPerFrontend:
+			reason = evictFrontend
+		case cu != nil && cfg.Clients.MaxEntries > 0 && cu.entries >= cfg.Clients.MaxEntries:
+			reason = evictClientEntries
+		case cu != nil && cfg.Clients.MaxSize.Bytes > 0 && cu.size+sz > cfg.Clients.MaxSize.Bytes:
+			reason = evictClientSize
+		case cfg.MaxSize.Bytes > 0 && size+sz > cfg.MaxSize.Bytes:
+			reason = evictMaxSize
+		}
+		if reason != "" {
+			out = append(out, historyEviction{rec: r, reason: reason})
+			continue
+		}
+		frontends[fk]++
+		if cu != nil {
+			cu.entries++
+			cu.size += sz
+		}
+		size += sz
+	}
+	return out
+}
+
+// applyRetention removes the records evicted by the retention policy.
+// records must not contain pinned records.
+func (h *HistoryQueue) applyRetention(records []*controlapi.BuildHistoryRecord) error {
+	// sort array by newest records first
+	slices.SortFunc(records, func(a, b *controlapi.BuildHistoryRecord) int {
+		return -a.CompletedAt.AsTime().Compare(b.CompletedAt.AsTime())
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ctx := context.TODO()
+	for _, ev := range historyEvictions(h.opt.CleanConfig, records, time.Now()) {
+		if _, err := h.delete(ev.rec.Ref); err != nil {
+			return err
+		}
+		bklog.G(ctx).Debugf("removed build record %s: %s", ev.rec.Ref, ev.reason)
+		attrs := metric.WithAttributes(attribute.String("reason", ev.reason))
+		historyEvictedRecords.Add(ctx, 1, attrs)
+		historyEvictedBytes.Add(ctx, recordSize(ev.rec), attrs)
+	}
+	return nil
+}
+
+// recordSize returns the total size of the blobs referenced by a record
+func recordSize(r *controlapi.BuildHistoryRecord) int64 {
+	var size int64
+	add := func(descs ...*controlapi.Descriptor) {
+		for _, d := range descs {
+			if d != nil {
+				size += d.Size
+			}
+		}
+	}
+	addResult := func(res *controlapi.BuildResultInfo) {
+		if res == nil {
+			return
+		}
+		add(res.ResultDeprecated)
+		add(res.Attestations...)
+		for _, d := range res.Results {
+			add(d)
+		}
+	}
+	add(r.Logs, r.Trace, r.ExternalError, r.StepLogs)
+	addResult(r.Result)
+	for _, res := range r.Results {
+		addResult(res)
+	}
+	return size
+}
@@ -0,0 +1,110 @@
+package llbsolver
+
+import (
+	"testing"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/cmd/buildkitd/config"
+	"github.com/stretchr/testify/require"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestHistoryEvictions(t *testing.T) {
+	now := time.Now()
+	rec := func(ref, frontend, client string, age time.Duration, failed bool, size int64) *controlapi.BuildHistoryRecord {
+		r := &controlapi.BuildHistoryRecord{
+			Ref:         ref,
+			Frontend:    frontend,
+			CompletedAt: timestamppb.New(now.Add(-age)),
+			Logs:        &controlapi.Descriptor{Size: size},
+		}
+		if failed {
+			r.Error = &spb.Status{Code: 2, Message: "failed to solve"}
+		}
+		if client != "" {
+			r.ClientIdentity = &controlapi.ClientIdentity{Name: client}
+		}
+		return r
+	}
+	evicted := func(evs []historyEviction) map[string]string {
+		m := map[string]string{}
+		for _, ev := range evs {
+			m[ev.rec.Ref] = ev.reason
+		}
+		return m
+	}
+	base := config.HistoryConfig{
+		MaxAge:     config.Duration{Duration: 48 * time.Hour},
+		MaxEntries: 50,
+	}
+
+	// records sorted newest first
+	records := []*controlapi.BuildHistoryRecord{
+		rec("r0", "dockerfile.v0", "ci", time.Minute, true, 10),
+		rec("r1", "dockerfile.v0", "ci", 2*time.Minute, true, 10),
+		rec("r2", "gateway.v0", "ci", 3*time.Minute, true, 10),
+		rec("r3", "dockerfile.v0", "dev", 4*time.Minute, false, 10),
+		rec("r4", "dockerfile.v0", "ci", 5*time.Minute, true, 10),
+		rec("r5", "dockerfile.v0", "", 72*time.Hour, false, 10),
+	}
+
+	require.Empty(t, historyEvictions(&base, records, now))
+
+	cfg := base
+	cfg.MaxEntries = 2
+	require.Equal(t, map[string]string{"r5": evictMaxEntries}, evicted(historyEvictions(&cfg, records, now)))
+
+	cfg = base
+	cfg.Outcomes = map[string]config.HistoryOutcomeConfig{
+		statusError:     {MaxEntriesPerFrontend: 1},
+		statusCompleted: {MaxAge: config.Duration{Duration: time.Hour}},
+	}
+	require.Equal(t, map[string]string{
+		"r1": evictFrontend,
+		"r4": evictFrontend,
+		"r5": evictOutcomeMaxAge,
+	}, evicted(historyEvictions(&cfg, records, now)))
+
+	cfg = base
+	cfg.Clients = &config.HistoryClientConfig{MaxEntries: 2}
+	require.Equal(t, map[string]string{
+		"r2": evictClientEntries,
+		"r4": evictClientEntries,
+	}, evicted(historyEvictions(&cfg, records, now)))
+
+	cfg = base
+	cfg.Clients = &config.HistoryClientConfig{MaxSize: config.DiskSpace{Bytes: 25}}
+	require.Equal(t, map[string]string{
+		"r2": evictClientSize,
+		"r4": evictClientSize,
+	}, evicted(historyEvictions(&cfg, records, now)))
+
+	// evicted records don't count toward the total size
+	cfg = base
+	cfg.MaxSize = config.DiskSpace{Bytes: 35}
+	cfg.Outcomes = map[string]config.HistoryOutcomeConfig{
+		statusError: {MaxEntriesPerFrontend: 1},
+	}
+	require.Equal(t, map[string]string{
+		"r1": evictFrontend,
+		"r4": evictFrontend,
+		"r5": evictMaxSize,
+	}, evicted(historyEvictions(&cfg, records, now)))
+}
+
+func TestRecordSize(t *testing.T) {
+	r := &controlapi.BuildHistoryRecord{
+		Logs:  &controlapi.Descriptor{Size: 1},
+		Trace: &controlapi.Descriptor{Size: 2},
+		Result: &controlapi.BuildResultInfo{
+			Attestations: []*controlapi.Descriptor{{Size: 4}},
+			Results:      map[int64]*controlapi.Descriptor{0: {Size: 8}},
+		},
+		Results: map[string]*controlapi.BuildResultInfo{
+			"linux/arm64": {Attestations: []*controlapi.Descriptor{{Size: 16}}},
+		},
+	}
+	require.Equal(t, int64(31), recordSize(r))
+}
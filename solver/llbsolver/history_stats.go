@@ -0,0 +1,206 @@
+package llbsolver
+
+import (
+	"encoding/json"
+	"math/bits"
+	"sync"
+	"time"
+
+	"github.com/moby/buildkit/util/bklog"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	vertexStatsBucket = "_vertexstats"
+
+	// vertexStatsBuilds is the number of most recent builds of a vertex that
+	// are considered when predicting if it will be executed
+	vertexStatsBuilds = 8
+
+	// vertexStatsFlushInterval is the delay before updated statistics are
+	// written to the database so that the updates of builds completing
+	// together are written in one transaction
+	vertexStatsFlushInterval = 5 * time.Second
+)
+
+// vertexStat records whether a vertex was executed in its most recent
+// builds. Bit 0 of Executed is the latest build.
+type vertexStat struct {
+	Builds   int       `json:"builds"`
+	Executed uint8     `json:"executed"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+func (st *vertexStat) add(executed bool, now time.Time) {
+	st.Executed <<= 1
+	if executed {
+		st.Executed |= 1
+	}
+	st.Builds = min(st.Builds+1, vertexStatsBuilds)
+	st.LastSeen = now
+}
+
+// likely returns true if the vertex was executed in the last two builds and
+// in at least three quarters of the recent builds it was part of.
+func (st *vertexStat) likely() bool {
+	if st.Builds < 2 || st.Executed&0b11 != 0b11 {
+		return false
+	}
+	mask := uint8(1<<st.Builds - 1)
+	return bits.OnesCount8(st.Executed&mask)*4 >= st.Builds*3
+}
+
+// vertexStats keeps the execution statistics of the vertices of previous
+// builds so that the solver can start vertices that are likely to miss the
+// cache early.
+type vertexStats struct {
+	mu     sync.Mutex
+	loaded bool
+	stats  map[digest.Digest]*vertexStat
+	dirty  map[digest.Digest]struct{}
+	flush  func()
+}
+
+func (h *HistoryQueue) loadVertexStats() error {
+	s := h.vertexStats
+	if s.loaded {
+		return nil
+	}
+	s.stats = map[digest.Digest]*vertexStat{}
+	s.dirty = map[digest.Digest]struct{}{}
+	if err := h.opt.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(vertexStatsBucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(key, dt []byte) error {
+			var st vertexStat
+			if err := json.Unmarshal(dt, &st); err != nil {
+				return errors.Wrapf(err, "failed to unmarshal vertex stats %s", key)
+			}
+			s.stats[digest.Digest(key)] = &st
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+	s.loaded = true
+	return nil
+}
+
+// updateVertexStats records the vertices of a completed build. executed is
+// true for the vertices that did not match the cache. The statistics are
+// written to the database in the background.
+func (h *HistoryQueue) updateVertexStats(executed map[digest.Digest]bool) error {
+	s := h.vertexStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := h.loadVertexStats(); err != nil {
+		return err
+	}
+	now := time.Now()
+	for dgst, ex := range executed {
+		st, ok := s.stats[dgst]
+		if !ok {
+			st = &vertexStat{}
+			s.stats[dgst] = st
+		}
+		st.add(ex, now)
+		s.dirty[dgst] = struct{}{}
+	}
+	if s.flush != nil {
+		s.flush()
+	}
+	return nil
+}
+
+// flushVertexStats writes the statistics updated since the last flush
+func (h *HistoryQueue) flushVertexStats() error {
+	s := h.vertexStats
+	s.mu.Lock()
+	updates := make(map[digest.Digest][]byte, len(s.dirty))
+	for dgst := range s.dirty {
+		dt, err := json.Marshal(s.stats[dgst])
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		updates[dgst] = dt
+	}
+	clear(s.dirty)
+	s.mu.Unlock()
+
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := h.opt.DB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(vertexStatsBucket))
+		if err != nil {
+			return err
+		}
+		for dgst, dt := range updates {
+			if err := b.Put([]byte(dgst), dt); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		// retry with the next flush
+		s.mu.Lock()
+		for dgst := range updates {
+			s.dirty[dgst] = struct{}{}
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// LikelyExecuted returns true if the vertex missed the cache in most of its
+// previous builds
+func (h *HistoryQueue) LikelyExecuted(dgst digest.Digest) bool {
+	s := h.vertexStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := h.loadVertexStats(); err != nil {
+		bklog.L.Warnf("failed to load vertex stats: %v", err)
+		return false
+	}
+	st, ok := s.stats[dgst]
+	return ok && st.likely()
+}
+
+// gcVertexStats removes the statistics of vertices that have not been built
+// for longer than build records are kept.
+func (h *HistoryQueue) gcVertexStats() error {
+	maxAge := h.opt.CleanConfig.MaxAge.Duration
+	if maxAge <= 0 {
+		return nil
+	}
+	s := h.vertexStats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := h.loadVertexStats(); err != nil {
+		return err
+	}
+	now := time.Now()
+	return h.opt.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(vertexStatsBucket))
+		if b == nil {
+			return nil
+		}
+		for dgst, st := range s.stats {
+			if now.Sub(st.LastSeen) < maxAge {
+				continue
+			}
+			if err := b.Delete([]byte(dgst)); err != nil {
+				return err
+			}
+			delete(s.stats, dgst)
+			delete(s.dirty, dgst)
+		}
+		return nil
+	})
+}
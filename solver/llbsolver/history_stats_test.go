@@ -0,0 +1,90 @@
+package llbsolver
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestVertexStatLikely(t *testing.T) {
+	now := time.Now()
+	var st vertexStat
+
+	st.add(true, now)
+	require.False(t, st.likely(), "single build")
+
+	st.add(true, now)
+	require.True(t, st.likely())
+
+	st.add(false, now)
+	require.False(t, st.likely(), "cached in the latest build")
+
+	st.add(true, now)
+	st.add(true, now)
+	require.True(t, st.likely(), "4 of 5 builds executed")
+
+	for range 3 {
+		st.add(false, now)
+	}
+	st.add(true, now)
+	st.add(true, now)
+	require.Equal(t, vertexStatsBuilds, st.Builds)
+	require.False(t, st.likely(), "4 of 8 builds executed")
+}
+
+func TestVertexStatsFlush(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "history.db"), 0600, nil)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var flushes int
+	h := &HistoryQueue{
+		opt:         HistoryQueueOpt{DB: db},
+		vertexStats: &vertexStats{flush: func() { flushes++ }},
+	}
+	vtx1 := digest.FromString("vtx1")
+	vtx2 := digest.FromString("vtx2")
+	require.NoError(t, h.updateVertexStats(map[digest.Digest]bool{vtx1: true, vtx2: false}))
+	require.NoError(t, h.updateVertexStats(map[digest.Digest]bool{vtx1: true}))
+	require.Equal(t, 2, flushes)
+	require.True(t, h.LikelyExecuted(vtx1))
+
+	// nothing is written until the stats are flushed
+	stored := func() map[digest.Digest]vertexStat {
+		m := map[digest.Digest]vertexStat{}
+		err := db.View(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(vertexStatsBucket))
+			if b == nil {
+				return nil
+			}
+			return b.ForEach(func(k, v []byte) error {
+				var st vertexStat
+				if err := json.Unmarshal(v, &st); err != nil {
+					return err
+				}
+				m[digest.Digest(k)] = st
+				return nil
+			})
+		})
+		require.NoError(t, err)
+		return m
+	}
+	require.Empty(t, stored())
+
+	require.NoError(t, h.flushVertexStats())
+	st := stored()
+	require.Len(t, st, 2)
+	require.Equal(t, 2, st[vtx1].Builds)
+	require.Equal(t, uint8(0b11), st[vtx1].Executed)
+	require.Equal(t, 1, st[vtx2].Builds)
+	require.Empty(t, h.vertexStats.dirty)
+
+	// the stats are loaded again from the database
+	h2 := &HistoryQueue{opt: HistoryQueueOpt{DB: db}, vertexStats: &vertexStats{}}
+	require.True(t, h2.LikelyExecuted(vtx1))
+}
@@ -1,6 +1,7 @@
 package llbsolver
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -147,3 +148,27 @@ func TestHistoryFilters(t *testing.T) {
 		})
 	}
 }
+
+func TestHistoryOnEventUnlocked(t *testing.T) {
+	var events []controlapi.BuildHistoryEventType
+	h := &HistoryQueue{
+		ps: &pubsub[*controlapi.BuildHistoryEvent]{
+			m: map[*channel[*controlapi.BuildHistoryEvent]]struct{}{},
+		},
+		active: map[string]*controlapi.BuildHistoryRecord{},
+	}
+	h.initOnce.Do(func() {})
+	h.opt.OnEvent = func(e *controlapi.BuildHistoryEvent) {
+		// sinks can call back into the queue
+		require.True(t, h.mu.TryLock())
+		h.mu.Unlock()
+		events = append(events, e.Type)
+	}
+	err := h.Update(context.TODO(), &controlapi.BuildHistoryEvent{
+		Type:   controlapi.BuildHistoryEventType_STARTED,
+		Record: &controlapi.BuildHistoryRecord{Ref: "ref1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []controlapi.BuildHistoryEventType{controlapi.BuildHistoryEventType_STARTED}, events)
+	require.Contains(t, h.active, "ref1")
+}
@@ -0,0 +1,81 @@
+package llbsolver
+
+import (
+	"encoding/base64"
+
+	"github.com/distribution/reference"
+	"github.com/moby/buildkit/client/lockfile"
+	"github.com/moby/buildkit/solver/llbsolver/provenance"
+	"github.com/pkg/errors"
+)
+
+// lockfileFromProvenance returns the lockfile of the sources captured for the
+// provenance of a build result. Images referenced only by digest are already
+// pinned and local images can't be resolved again, so they are not added.
+func lockfileFromProvenance(res *provenance.Result) (*lockfile.Lockfile, error) {
+	c := &provenance.Capture{}
+	if err := c.Merge(res.Ref); err != nil {
+		return nil, err
+	}
+	for _, r := range res.Refs {
+		if err := c.Merge(r); err != nil {
+			return nil, err
+		}
+	}
+
+	l := &lockfile.Lockfile{
+		Version: lockfile.Version,
+	}
+	for _, img := range c.Sources.Images {
+		if img.Local {
+			continue
+		}
+		ref, err := reference.ParseNormalizedNamed(img.Ref)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid image reference %s", img.Ref)
+		}
+		if _, ok := ref.(reference.Canonical); ok {
+			// the sources pinned by a previous lockfile keep their tag
+			tagged, ok := ref.(reference.Tagged)
+			if !ok {
+				continue
+			}
+			ref, err = reference.WithTag(reference.TrimNamed(ref), tagged.Tag())
+			if err != nil {
+				return nil, errors.WithStack(err)
+			}
+		}
+		l.Images = append(l.Images, lockfile.Image{
+			Ref:      reference.TagNameOnly(ref).String(),
+			Platform: img.Platform,
+			Digest:   img.Digest,
+		})
+	}
+	for _, g := range c.Sources.Git {
+		l.Git = append(l.Git, lockfile.Git{
+			URL:    g.URL,
+			Commit: g.Commit,
+		})
+	}
+	for _, h := range c.Sources.HTTP {
+		l.HTTP = append(l.HTTP, lockfile.HTTP{
+			URL:      h.URL,
+			Checksum: h.Digest,
+		})
+	}
+	l.Sort()
+	return l, nil
+}
+
+// encodeLockfile returns the lockfile of res for the exporter response
+func encodeLockfile(res *provenance.Result) (string, error) {
+	l, err := lockfileFromProvenance(res)
+	if err != nil {
+		return "", err
+	}
+	dt, err := l.Marshal()
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(dt), nil
+}
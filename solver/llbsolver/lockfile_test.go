@@ -0,0 +1,59 @@
+package llbsolver
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/client/lockfile"
+	"github.com/moby/buildkit/solver/llbsolver/provenance"
+	provenancetypes "github.com/moby/buildkit/solver/llbsolver/provenance/types"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockfileFromProvenance(t *testing.T) {
+	dgst := digest.FromString("image")
+	dgst2 := digest.FromString("other")
+	res := &provenance.Result{
+		Ref: &provenance.Capture{
+			Sources: provenancetypes.Sources{
+				Images: []provenancetypes.ImageSource{
+					{Ref: "docker.io/library/busybox:latest", Digest: dgst},
+					// pinned by a previous lockfile
+					{Ref: "docker.io/library/alpine:3@" + dgst2.String(), Digest: dgst2},
+					// already pinned by the build
+					{Ref: "docker.io/library/debian@" + dgst2.String(), Digest: dgst2},
+					{Ref: "docker.io/library/local:latest", Digest: dgst, Local: true},
+				},
+				Git: []provenancetypes.GitSource{
+					{URL: "https://github.com/moby/buildkit.git#master", Commit: "6b1c3fe1c8b8e0a5c6a0b6b4a2f1b3c0e3c7c0de"},
+				},
+			},
+		},
+		Refs: map[string]*provenance.Capture{
+			"linux/arm64": {
+				Sources: provenancetypes.Sources{
+					HTTP: []provenancetypes.HTTPSource{
+						{URL: "https://example.com/foo.tar.gz", Digest: dgst2},
+					},
+				},
+			},
+		},
+	}
+
+	l, err := lockfileFromProvenance(res)
+	require.NoError(t, err)
+	require.NoError(t, l.Validate())
+	require.Equal(t, &lockfile.Lockfile{
+		Version: lockfile.Version,
+		Images: []lockfile.Image{
+			{Ref: "docker.io/library/alpine:3", Digest: dgst2},
+			{Ref: "docker.io/library/busybox:latest", Digest: dgst},
+		},
+		Git: []lockfile.Git{
+			{URL: "https://github.com/moby/buildkit.git#master", Commit: "6b1c3fe1c8b8e0a5c6a0b6b4a2f1b3c0e3c7c0de"},
+		},
+		HTTP: []lockfile.HTTP{
+			{URL: "https://example.com/foo.tar.gz", Checksum: dgst2},
+		},
+	}, l)
+}
@@ -14,12 +14,14 @@ import (
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/localexec"
 	"github.com/moby/buildkit/session/secrets"
 	"github.com/moby/buildkit/session/sshforward"
 	"github.com/moby/buildkit/snapshot"
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/moby/buildkit/util/progress/logs"
 	"github.com/moby/locker"
 	"github.com/moby/sys/user"
 	"github.com/moby/sys/userns"
@@ -267,12 +269,62 @@ func (mm *MountManager) getSecretMountable(ctx context.Context, m *pb.Mount, g s
 		}
 		return nil, err
 	}
-	return &secretMount{mount: m, data: dt, idmap: mm.cm.IdentityMapping()}, nil
+	sm := &secretMount{mount: m, data: dt, idmap: mm.cm.IdentityMapping()}
+	if m.SecretOpt.Directory {
+		sm.files, err = parseSecretDir(dt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid directory secret %s", id)
+		}
+		sm.data = nil
+		for _, f := range sm.files {
+			logs.AddSecret(ctx, f.data)
+		}
+	} else {
+		logs.AddSecret(ctx, dt)
+	}
+	return sm, nil
+}
+
+// getLocalExecMountable runs the command of the mount on the client and
+// mounts its output like a secret file
+func (mm *MountManager) getLocalExecMountable(ctx context.Context, m *pb.Mount, g session.Group) (cache.Mountable, error) {
+	opt := m.LocalExecOpt
+	if opt == nil {
+		return nil, errors.Errorf("invalid local exec mount options")
+	}
+	if opt.ID == "" {
+		return nil, errors.Errorf("local exec ID missing from mount options")
+	}
+	var dt []byte
+	err := mm.sm.Any(ctx, g, func(ctx context.Context, _ string, caller session.Caller) error {
+		var err error
+		dt, err = localexec.Exec(ctx, caller, opt.ID)
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, localexec.ErrNotFound) && opt.Optional {
+			return nil, nil
+		}
+		return nil, err
+	}
+	sm := &pb.Mount{
+		Dest:      m.Dest,
+		MountType: pb.MountType_SECRET,
+		SecretOpt: &pb.SecretOpt{
+			ID:   opt.ID,
+			Uid:  opt.Uid,
+			Gid:  opt.Gid,
+			Mode: opt.Mode,
+		},
+	}
+	logs.AddSecret(ctx, dt)
+	return &secretMount{mount: sm, data: dt, idmap: mm.cm.IdentityMapping()}, nil
 }
 
 type secretMount struct {
 	mount *pb.Mount
 	data  []byte
+	files []secretFile
 	idmap *user.IdentityMapping
 }
 
@@ -300,8 +352,9 @@ func (sm *secretMountInstance) Mount() ([]mount.Mount, func() error, error) {
 		return nil, nil, err
 	}
 
+	opt := sm.sm.mount.SecretOpt
 	var mountOpts []string
-	if sm.sm.mount.SecretOpt.Mode&0o111 == 0 {
+	if opt.Mode&0o111 == 0 && !hasExecutable(sm.sm.files) {
 		mountOpts = append(mountOpts, "noexec")
 	}
 
@@ -328,15 +381,8 @@ func (sm *secretMountInstance) Mount() ([]mount.Mount, func() error, error) {
 		return cleanupDir()
 	}
 
-	randID := identity.NewID()
-	fp := filepath.Join(dir, randID)
-	if err := os.WriteFile(fp, sm.sm.data, 0600); err != nil {
-		cleanup()
-		return nil, nil, err
-	}
-
-	uid := int(sm.sm.mount.SecretOpt.Uid)
-	gid := int(sm.sm.mount.SecretOpt.Gid)
+	uid := int(opt.Uid)
+	gid := int(opt.Gid)
 
 	if sm.idmap != nil {
 		uid, gid, err = sm.idmap.ToHost(uid, gid)
@@ -346,12 +392,31 @@ func (sm *secretMountInstance) Mount() ([]mount.Mount, func() error, error) {
 		}
 	}
 
+	randID := identity.NewID()
+	fp := filepath.Join(dir, randID)
+	mode := os.FileMode(opt.Mode & 0777)
+	if opt.Directory {
+		if err := os.Mkdir(fp, 0700); err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		if err := writeSecretDir(fp, sm.sm.files, uid, gid); err != nil {
+			cleanup()
+			return nil, nil, errors.Wrap(err, "failed to write directory secret")
+		}
+		// the directory can be listed by the users that can read it
+		mode |= (mode & 0444) >> 2
+	} else if err := os.WriteFile(fp, sm.sm.data, 0600); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
 	if err := os.Chown(fp, uid, gid); err != nil {
 		cleanup()
 		return nil, nil, err
 	}
 
-	if err := os.Chmod(fp, os.FileMode(sm.sm.mount.SecretOpt.Mode&0777)); err != nil {
+	if err := os.Chmod(fp, mode); err != nil {
 		cleanup()
 		return nil, nil, err
 	}
@@ -382,10 +447,49 @@ func (mm *MountManager) MountableSecret(ctx context.Context, m *pb.Mount, g sess
 	return mm.getSecretMountable(ctx, m, g)
 }
 
+func (mm *MountManager) MountableLocalExec(ctx context.Context, m *pb.Mount, g session.Group) (cache.Mountable, error) {
+	return mm.getLocalExecMountable(ctx, m, g)
+}
+
 func (mm *MountManager) MountableSSH(ctx context.Context, m *pb.Mount, g session.Group) (cache.Mountable, error) {
 	return mm.getSSHMountable(ctx, m, g)
 }
 
+func (mm *MountManager) MountableHostBind(m *pb.Mount) (cache.Mountable, error) {
+	if m.HostBindOpt == nil || m.HostBindOpt.Path == "" {
+		return nil, errors.Errorf("invalid host bind mount options")
+	}
+	return &hostBind{path: m.HostBindOpt.Path}, nil
+}
+
+type hostBind struct {
+	path string
+}
+
+func (b *hostBind) Mount(ctx context.Context, readonly bool, g session.Group) (snapshot.Mountable, error) {
+	return &hostBindMount{path: b.path}, nil
+}
+
+type hostBindMount struct {
+	path string
+}
+
+// Mount bind mounts the host path read-only regardless of the mount options
+func (b *hostBindMount) Mount() ([]mount.Mount, func() error, error) {
+	if _, err := os.Stat(b.path); err != nil {
+		return nil, nil, errors.Wrapf(err, "invalid host bind mount source")
+	}
+	return []mount.Mount{{
+		Type:    "bind",
+		Source:  b.path,
+		Options: []string{"ro", "rbind", "nodev", "nosuid"},
+	}}, func() error { return nil }, nil
+}
+
+func (b *hostBindMount) IdentityMapping() *user.IdentityMapping {
+	return nil
+}
+
 func newTmpfs(idmap *user.IdentityMapping, opt *pb.TmpfsOpt) cache.Mountable {
 	return &tmpfs{idmap: idmap, opt: opt}
 }
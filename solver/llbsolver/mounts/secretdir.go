@@ -0,0 +1,119 @@
+package mounts
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// secretFile is an entry of the tar archive of a directory secret
+type secretFile struct {
+	path     string
+	typ      byte
+	mode     os.FileMode
+	linkname string
+	data     []byte
+}
+
+// parseSecretDir reads the entries of the tar archive of a directory secret.
+// Only regular files, directories and symlinks are allowed. Entries can't be
+// written through a symlink of the archive.
+func parseSecretDir(dt []byte) ([]secretFile, error) {
+	var files []secretFile
+	symlinks := map[string]struct{}{}
+	tr := tar.NewReader(bytes.NewReader(dt))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read secret directory archive")
+		}
+		p := path.Clean("/" + hdr.Name)
+		if p == "/" {
+			continue
+		}
+		for parent := p; parent != "/"; parent = path.Dir(parent) {
+			if _, ok := symlinks[parent]; ok {
+				return nil, errors.Errorf("invalid path %s through symlink %s in secret directory archive", hdr.Name, parent[1:])
+			}
+		}
+		f := secretFile{
+			path: p[1:],
+			typ:  hdr.Typeflag,
+			mode: os.FileMode(hdr.Mode) & os.ModePerm,
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			f.data, err = io.ReadAll(tr)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s from secret directory archive", hdr.Name)
+			}
+		case tar.TypeDir:
+		case tar.TypeSymlink:
+			f.linkname = hdr.Linkname
+			symlinks[p] = struct{}{}
+		default:
+			return nil, errors.Errorf("invalid type %q of %s in secret directory archive", hdr.Typeflag, hdr.Name)
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// hasExecutable returns true if any of the regular files is executable
+func hasExecutable(files []secretFile) bool {
+	for _, f := range files {
+		if f.typ == tar.TypeReg && f.mode&0o111 != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSecretDir writes the files of a directory secret to dir. Parent
+// directories missing from the archive are created with mode 0755.
+func writeSecretDir(dir string, files []secretFile, uid, gid int) error {
+	for _, f := range files {
+		fp := filepath.Join(dir, filepath.FromSlash(f.path))
+		if parent := filepath.Dir(fp); parent != dir {
+			if _, err := os.Lstat(parent); errors.Is(err, os.ErrNotExist) {
+				if err := os.MkdirAll(parent, 0755); err != nil {
+					return err
+				}
+				if err := os.Lchown(parent, uid, gid); err != nil {
+					return err
+				}
+			}
+		}
+		switch f.typ {
+		case tar.TypeReg:
+			if err := os.WriteFile(fp, f.data, 0600); err != nil {
+				return err
+			}
+		case tar.TypeDir:
+			if err := os.Mkdir(fp, 0700); err != nil && !errors.Is(err, os.ErrExist) {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := os.Symlink(f.linkname, fp); err != nil {
+				return err
+			}
+		}
+		if err := os.Lchown(fp, uid, gid); err != nil {
+			return err
+		}
+		if f.typ != tar.TypeSymlink {
+			if err := os.Chmod(fp, f.mode); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package mounts
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func secretDirArchive(t *testing.T, hdrs ...*tar.Header) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, hdr := range hdrs {
+		var dt []byte
+		if hdr.Typeflag == tar.TypeReg {
+			dt = []byte("data of " + hdr.Name)
+			hdr.Size = int64(len(dt))
+		}
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write(dt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestSecretDir(t *testing.T) {
+	t.Parallel()
+
+	dt := secretDirArchive(t,
+		&tar.Header{Name: "ca.pem", Typeflag: tar.TypeReg, Mode: 0644},
+		&tar.Header{Name: "private/", Typeflag: tar.TypeDir, Mode: 0700},
+		&tar.Header{Name: "private/key.pem", Typeflag: tar.TypeReg, Mode: 0400},
+		&tar.Header{Name: "bin/hook", Typeflag: tar.TypeReg, Mode: 0755},
+		&tar.Header{Name: "current.pem", Typeflag: tar.TypeSymlink, Linkname: "ca.pem"},
+	)
+	files, err := parseSecretDir(dt)
+	require.NoError(t, err)
+	require.Len(t, files, 5)
+	require.True(t, hasExecutable(files))
+
+	dir := t.TempDir()
+	require.NoError(t, writeSecretDir(dir, files, os.Getuid(), os.Getgid()))
+
+	for p, mode := range map[string]os.FileMode{
+		"ca.pem":          0644,
+		"private":         0700 | os.ModeDir,
+		"private/key.pem": 0400,
+		"bin":             0755 | os.ModeDir,
+		"bin/hook":        0755,
+	} {
+		fi, err := os.Lstat(filepath.Join(dir, p))
+		require.NoError(t, err, p)
+		require.Equal(t, mode, fi.Mode(), p)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "private/key.pem"))
+	require.NoError(t, err)
+	require.Equal(t, "data of private/key.pem", string(data))
+	link, err := os.Readlink(filepath.Join(dir, "current.pem"))
+	require.NoError(t, err)
+	require.Equal(t, "ca.pem", link)
+}
+
+func TestSecretDirInvalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := parseSecretDir(secretDirArchive(t,
+		&tar.Header{Name: "etc", Typeflag: tar.TypeSymlink, Linkname: "/etc"},
+		&tar.Header{Name: "etc/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+	))
+	require.ErrorContains(t, err, "through symlink etc")
+
+	_, err = parseSecretDir(secretDirArchive(t,
+		&tar.Header{Name: "null", Typeflag: tar.TypeChar, Mode: 0644},
+	))
+	require.ErrorContains(t, err, "invalid type")
+
+	files, err := parseSecretDir(secretDirArchive(t,
+		&tar.Header{Name: "../../key.pem", Typeflag: tar.TypeReg, Mode: 0400},
+	))
+	require.NoError(t, err)
+	require.Equal(t, "key.pem", files[0].path)
+	require.False(t, hasExecutable(files))
+}
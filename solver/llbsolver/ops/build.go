@@ -19,7 +19,7 @@ import (
 	"github.com/pkg/errors"
 )
 
-const buildCacheType = "buildkit.build.v0"
+var buildCacheType = cacheType("build")
 
 type BuildOp struct {
 	op *pb.BuildOp
@@ -82,6 +82,9 @@ func (b *BuildOp) Exec(ctx context.Context, g session.Group, inputs []solver.Res
 		return nil, errors.Errorf("invalid index %v", i) // TODO: this should be validated before
 	}
 	inp := inputs[i]
+	if inp == nil {
+		return nil, errors.Errorf("build definition input %d is missing", i)
+	}
 
 	ref, ok := inp.Sys().(*worker.WorkerRef)
 	if !ok {
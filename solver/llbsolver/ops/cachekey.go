@@ -0,0 +1,32 @@
+package ops
+
+import (
+	"fmt"
+
+	"github.com/moby/buildkit/solver"
+)
+
+// cacheKeyVersions are the versions of the cache keys of the ops. Bump the
+// version of an op whenever the way its cache key is computed changes, and
+// list the previous version as compatible if the op still produces the old
+// keys for the common cases, so that the cache that no longer matches is
+// reported instead of being invalidated silently.
+var cacheKeyVersions = map[string]solver.CacheKeyVersion{
+	"source": {Version: 0},
+	"exec":   {Version: 0},
+	"file":   {Version: 0},
+	"build":  {Version: 0},
+	"merge":  {Version: 0},
+	"diff":   {Version: 0},
+}
+
+func init() {
+	for op, v := range cacheKeyVersions {
+		solver.RegisterCacheKeyVersion(op, v)
+	}
+}
+
+// cacheType returns the type that is part of the cache keys of an op
+func cacheType(op string) string {
+	return fmt.Sprintf("buildkit.%s.v%d", op, cacheKeyVersions[op].Version)
+}
@@ -0,0 +1,25 @@
+package ops
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/solver"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCacheKeyVersions(t *testing.T) {
+	// changing a cache type invalidates all the cache of the op, the version
+	// of the op must be bumped instead
+	require.Equal(t, "buildkit.source.v0", sourceCacheType)
+	require.Equal(t, "buildkit.exec.v0", execCacheType)
+	require.Equal(t, "buildkit.file.v0", fileCacheType)
+	require.Equal(t, "buildkit.build.v0", buildCacheType)
+	require.Equal(t, "buildkit.merge.v0", mergeCacheType)
+	require.Equal(t, "buildkit.diff.v0", diffCacheType)
+
+	versions := solver.CacheKeyVersions()
+	for op, v := range cacheKeyVersions {
+		require.Equal(t, v.Version, versions[op])
+	}
+	require.Empty(t, solver.CheckCacheKeyVersions(nil))
+}
@@ -15,7 +15,7 @@ import (
 	digest "github.com/opencontainers/go-digest"
 )
 
-const diffCacheType = "buildkit.diff.v0"
+var diffCacheType = cacheType("diff")
 
 type diffOp struct {
 	op     *pb.DiffOp
@@ -69,6 +69,8 @@ func (d *diffOp) CacheMap(ctx context.Context, group session.Group, index int) (
 func (d *diffOp) Exec(ctx context.Context, g session.Group, inputs []solver.Result) ([]solver.Result, error) {
 	var curInput int
 
+	// the inputs are nil for the weak inputs that failed, which are diffed
+	// as scratch
 	var lowerRef cache.ImmutableRef
 	if d.op.Lower.Input != int64(pb.Empty) {
 		if lowerInp := inputs[curInput]; lowerInp != nil {
@@ -77,8 +79,6 @@ func (d *diffOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 				return nil, errors.Errorf("invalid lower reference for diff op %T", lowerInp.Sys())
 			}
 			lowerRef = wref.ImmutableRef
-		} else {
-			return nil, errors.New("invalid nil lower input for diff op")
 		}
 		curInput++
 	}
@@ -91,8 +91,6 @@ func (d *diffOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 				return nil, errors.Errorf("invalid upper reference for diff op %T", upperInp.Sys())
 			}
 			upperRef = wref.ImmutableRef
-		} else {
-			return nil, errors.New("invalid nil upper input for diff op")
 		}
 	}
 
@@ -5,26 +5,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
 	"runtime"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/containerd/platforms"
 	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/executor"
 	resourcestypes "github.com/moby/buildkit/executor/resources/types"
 	"github.com/moby/buildkit/frontend/gateway/container"
+	gatewayapi "github.com/moby/buildkit/frontend/gateway/pb"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/session/secrets"
 	"github.com/moby/buildkit/solver"
+	serrdefs "github.com/moby/buildkit/solver/errdefs"
 	"github.com/moby/buildkit/solver/llbsolver/errdefs"
 	"github.com/moby/buildkit/solver/llbsolver/mounts"
 	"github.com/moby/buildkit/solver/llbsolver/ops/opsutils"
 	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/cachedigest"
+	"github.com/moby/buildkit/util/progress"
 	"github.com/moby/buildkit/util/progress/logs"
+	"github.com/moby/buildkit/util/proxy"
 	utilsystem "github.com/moby/buildkit/util/system"
 	"github.com/moby/buildkit/worker"
 	digest "github.com/opencontainers/go-digest"
@@ -34,7 +43,7 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-const execCacheType = "buildkit.exec.v0"
+var execCacheType = cacheType("exec")
 
 type ExecOp struct {
 	op          *pb.ExecOp
@@ -51,6 +60,8 @@ type ExecOp struct {
 }
 
 var _ solver.Op = &ExecOp{}
+var _ solver.RetryableOp = &ExecOp{}
+var _ solver.ResourceUsageOp = &ExecOp{}
 
 func NewExecOp(v solver.Vertex, op *pb.Op_Exec, platform *pb.Platform, cm cache.Manager, parallelism *semaphore.Weighted, sm *session.Manager, exec executor.Executor, w worker.Worker) (*ExecOp, error) {
 	if err := opsutils.Validate(&pb.Op{Op: op}); err != nil {
@@ -104,6 +115,83 @@ func checkShouldClearCacheOpts(m *pb.Mount) bool {
 	return true
 }
 
+// hermeticEnv returns the environment variables declared as inputs of a
+// hermetic exec
+func hermeticEnv(env []string, h *pb.HermeticOpt) []string {
+	var out []string
+	for _, kv := range env {
+		k, _, _ := strings.Cut(kv, "=")
+		if slices.Contains(h.Env, k) {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// execMeta returns the process config of the exec for args
+func (e *ExecOp) execMeta(args []string, readonlyRootFS bool) (executor.Meta, error) {
+	extraHosts, err := container.ParseExtraHosts(e.op.Meta.ExtraHosts)
+	if err != nil {
+		return executor.Meta{}, err
+	}
+
+	meta := executor.Meta{
+		Args:                      args,
+		Env:                       e.op.Meta.Env,
+		Cwd:                       e.op.Meta.Cwd,
+		User:                      e.op.Meta.User,
+		Hostname:                  e.op.Meta.Hostname,
+		ReadonlyRootFS:            readonlyRootFS,
+		ExtraHosts:                extraHosts,
+		Ulimit:                    e.op.Meta.Ulimit,
+		CDIDevices:                e.op.CdiDevices,
+		CgroupParent:              e.op.Meta.CgroupParent,
+		NetMode:                   e.op.Network,
+		SecurityMode:              e.op.Security,
+		RemoveMountStubsRecursive: e.op.Meta.RemoveMountStubsRecursive,
+	}
+
+	if h := e.op.Hermetic; h != nil && h.Strict {
+		strictHermeticMeta(&meta, h)
+	} else if e.op.Meta.ProxyEnv != nil {
+		meta.Env = append(meta.Env, proxyEnvList(e.op.Meta.ProxyEnv)...)
+	}
+	var currentOS string
+	if e.platform != nil {
+		currentOS = e.platform.OS
+	}
+	// don't set PATH for Windows. #5445
+	if currentOS != "windows" {
+		meta.Env = addDefaultEnvvar(meta.Env, "PATH", utilsystem.DefaultPathEnv(currentOS))
+	}
+	return meta, nil
+}
+
+// missingMountsEnv is set in the environment of the process to the mount
+// points of the weak inputs that failed
+const missingMountsEnv = "BUILDKIT_MISSING_MOUNTS"
+
+// missingMounts returns the mount points of the weak inputs that failed
+func (e *ExecOp) missingMounts(inputs []solver.Result) []string {
+	var out []string
+	for _, m := range e.op.Mounts {
+		if m.Input != int64(pb.Empty) && int(m.Input) < len(inputs) && inputs[m.Input] == nil {
+			out = append(out, m.Dest)
+		}
+	}
+	return out
+}
+
+// strictHermeticMeta removes the inputs that are not declared by a strict
+// hermetic exec from the process config. The process runs with the default
+// hostname, without extra hosts and without network.
+func strictHermeticMeta(meta *executor.Meta, h *pb.HermeticOpt) {
+	meta.Env = hermeticEnv(meta.Env, h)
+	meta.Hostname = ""
+	meta.ExtraHosts = nil
+	meta.NetMode = pb.NetMode_NONE
+}
+
 func (e *ExecOp) CacheMap(ctx context.Context, g session.Group, index int) (*solver.CacheMap, bool, error) {
 	op := cloneExecOp(e.op)
 
@@ -123,6 +211,15 @@ func (e *ExecOp) CacheMap(ctx context.Context, g session.Group, index int) (*sol
 		}
 	}
 	op.Meta.ProxyEnv = nil
+	// the timeout and the retry policy don't change the result
+	op.Timeout = 0
+	op.Retry = nil
+
+	if h := op.Hermetic; h != nil {
+		op.Meta.Env = hermeticEnv(op.Meta.Env, h)
+		op.Meta.Hostname = ""
+		op.Meta.ExtraHosts = nil
+	}
 
 	var p ocispecs.Platform
 	if e.platform != nil {
@@ -153,14 +250,20 @@ func (e *ExecOp) CacheMap(ctx context.Context, g session.Group, index int) (*sol
 		op.Mounts = nil
 	}
 
+	hostBinds, err := hostBindDigests(op.Mounts)
+	if err != nil {
+		return nil, false, err
+	}
+
 	dt, err := json.Marshal(struct {
 		Type       string
 		Exec       *pb.ExecOp
 		OS         string
 		Arch       string
-		Variant    string   `json:",omitempty"`
-		OSVersion  string   `json:",omitempty"`
-		OSFeatures []string `json:",omitempty"`
+		Variant    string                   `json:",omitempty"`
+		OSVersion  string                   `json:",omitempty"`
+		OSFeatures []string                 `json:",omitempty"`
+		HostBinds  map[string]digest.Digest `json:",omitempty"`
 	}{
 		Type:       execCacheType,
 		Exec:       op,
@@ -169,6 +272,7 @@ func (e *ExecOp) CacheMap(ctx context.Context, g session.Group, index int) (*sol
 		Variant:    p.Variant,
 		OSVersion:  p.OSVersion,
 		OSFeatures: p.OSFeatures,
+		HostBinds:  hostBinds,
 	})
 	if err != nil {
 		return nil, false, err
@@ -276,6 +380,63 @@ func toSelectors(p []string) []opsutils.Selector {
 	return sel
 }
 
+// hostBindDigests returns the digests of the host paths mounted by the op by
+// their destinations. The host paths aren't snapshots, so their files are
+// checksummed by their metadata for the cache key of the op to change with
+// their content.
+func hostBindDigests(mounts []*pb.Mount) (map[string]digest.Digest, error) {
+	var out map[string]digest.Digest
+	for _, m := range mounts {
+		if m.MountType != pb.MountType_HOSTBIND || m.HostBindOpt == nil {
+			continue
+		}
+		dgst, err := hostPathDigest(m.HostBindOpt.Path)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = map[string]digest.Digest{}
+		}
+		out[m.Dest] = dgst
+	}
+	return out, nil
+}
+
+// hostPathDigest returns the digest of the paths, modes, sizes, modification
+// times and link targets of the files under root
+func hostPathDigest(root string) (digest.Digest, error) {
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve host mount path")
+	}
+	h := digest.Canonical.Digester()
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		var link string
+		if fi.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(p); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(h.Hash(), "%s\x00%o\x00%d\x00%d\x00%s\x00", filepath.ToSlash(rel), fi.Mode(), fi.Size(), fi.ModTime().UnixNano(), link)
+		return nil
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to checksum host mount path %s", root)
+	}
+	return h.Digest(), nil
+}
+
 type dep struct {
 	Selectors []string
 
@@ -288,7 +449,7 @@ func (e *ExecOp) getMountDeps() ([]dep, error) {
 	deps := make([]dep, e.numInputs)
 	for _, m := range e.op.Mounts {
 		switch m.MountType {
-		case pb.MountType_SECRET, pb.MountType_SSH, pb.MountType_TMPFS:
+		case pb.MountType_SECRET, pb.MountType_SSH, pb.MountType_TMPFS, pb.MountType_LOCALEXEC, pb.MountType_HOSTBIND:
 			continue
 		}
 
@@ -362,11 +523,89 @@ func addDefaultEnvvar(env []string, k, v string) []string {
 	return append(env, k+"="+v)
 }
 
-func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Result) (results []solver.Result, err error) {
+func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Result) ([]solver.Result, error) {
 	trace.SpanFromContext(ctx).AddEvent("ExecOp started")
 
+	for attempt := 0; ; attempt++ {
+		results, err := e.execAttempt(ctx, g, inputs)
+		if err == nil || !e.shouldRetry(ctx, err, attempt) {
+			var exitErr *gatewayapi.ExitError
+			if errors.As(err, &exitErr) {
+				err = serrdefs.WithCode(err, serrdefs.CodeExecFailed)
+			}
+			return results, err
+		}
+		// the mounts of a failed attempt are only kept for the last error
+		var ee *errdefs.ExecError
+		if errors.As(err, &ee) {
+			ee.Release()
+		}
+
+		done := progress.OneOff(ctx, fmt.Sprintf("retrying after error: %v (attempt %d/%d)", err, attempt+2, e.op.Retry.Attempts+1))
+		if backoff := retryBackoff(e.op.Retry, attempt); backoff > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, done(context.Cause(ctx))
+			case <-time.After(backoff):
+			}
+		}
+		done(nil)
+	}
+}
+
+// maxRetryBackoff is the maximum delay before a retry of an exec
+const maxRetryBackoff = 5 * time.Minute
+
+// retryBackoff returns the delay before the retry following attempt. The
+// backoff doubles for every attempt up to maxRetryBackoff.
+func retryBackoff(r *pb.RetryPolicy, attempt int) time.Duration {
+	d := time.Duration(r.Backoff)
+	for i := 0; i < attempt && d > 0 && d < maxRetryBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryBackoff)
+}
+
+// shouldRetry returns true if the retry policy of the exec allows another
+// attempt after err
+func (e *ExecOp) shouldRetry(ctx context.Context, err error, attempt int) bool {
+	r := e.op.Retry
+	if r == nil || attempt >= int(r.Attempts) || ctx.Err() != nil {
+		return false
+	}
+	if len(r.ExitCodes) == 0 {
+		return true
+	}
+	var exitErr *gatewayapi.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	return slices.Contains(r.ExitCodes, int32(exitErr.ExitCode))
+}
+
+// CanRetry implements solver.RetryableOp. The exec is not retried by the
+// solver once its process has run, as the process may have changed its cache
+// mounts, or if the exec has its own retry policy.
+func (e *ExecOp) CanRetry(err error) bool {
+	if e.op.Retry != nil {
+		return false
+	}
+	var exitErr *gatewayapi.ExitError
+	return !errors.As(err, &exitErr)
+}
+
+func (e *ExecOp) execAttempt(ctx context.Context, g session.Group, inputs []solver.Result) (results []solver.Result, err error) {
+	// the secrets mounted or set in the environment of the process are
+	// redacted from its output
+	ctx = logs.WithRedactor(ctx)
+
 	refs := make([]*worker.WorkerRef, len(inputs))
 	for i, inp := range inputs {
+		if inp == nil {
+			// weak input that failed, mounted empty
+			refs[i] = &worker.WorkerRef{Worker: e.w}
+			continue
+		}
 		var ok bool
 		refs[i], ok = inp.Sys().(*worker.WorkerRef)
 		if !ok {
@@ -378,6 +617,7 @@ func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 	if e.platform != nil {
 		platformOS = e.platform.OS
 	}
+	var artifacts solver.Result
 	p, err := container.PrepareMounts(ctx, e.mm, e.cm, g, e.op.Meta.Cwd, e.op.Mounts, refs, func(m *pb.Mount, ref cache.ImmutableRef) (cache.MutableRef, error) {
 		desc := fmt.Sprintf("mount %s from exec %s", m.Dest, strings.Join(e.op.Meta.Args, " "))
 		return e.cm.New(ctx, ref, g, cache.WithDescription(desc))
@@ -386,7 +626,7 @@ func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 		if err != nil {
 			execInputs := make([]solver.Result, len(e.op.Mounts))
 			for i, m := range e.op.Mounts {
-				if m.Input == -1 {
+				if m.Input == -1 || inputs[m.Input] == nil {
 					continue
 				}
 				execInputs[i] = inputs[m.Input].Clone()
@@ -409,6 +649,10 @@ func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 				}
 			}
 			err = errdefs.WithExecError(err, execInputs, execMounts)
+			var ee *errdefs.ExecError
+			if artifacts != nil && errors.As(err, &ee) {
+				ee.Artifacts = artifacts
+			}
 		} else {
 			// Only release actives if err is nil.
 			for i := len(p.Actives) - 1; i >= 0; i-- { // call in LIFO order
@@ -425,17 +669,13 @@ func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 		return nil, err
 	}
 
-	extraHosts, err := container.ParseExtraHosts(e.op.Meta.ExtraHosts)
-	if err != nil {
-		return nil, err
-	}
-
 	emu, err := getEmulator(ctx, e.platform)
 	if err != nil {
 		return nil, err
 	}
+	args := e.op.Meta.Args
 	if emu != nil {
-		e.op.Meta.Args = append([]string{qemuMountName}, e.op.Meta.Args...)
+		args = append([]string{qemuMountName}, args...)
 
 		p.Mounts = append(p.Mounts, executor.Mount{
 			Readonly: true,
@@ -444,39 +684,23 @@ func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 		})
 	}
 
-	meta := executor.Meta{
-		Args:                      e.op.Meta.Args,
-		Env:                       e.op.Meta.Env,
-		Cwd:                       e.op.Meta.Cwd,
-		User:                      e.op.Meta.User,
-		Hostname:                  e.op.Meta.Hostname,
-		ReadonlyRootFS:            p.ReadonlyRootFS,
-		ExtraHosts:                extraHosts,
-		Ulimit:                    e.op.Meta.Ulimit,
-		CDIDevices:                e.op.CdiDevices,
-		CgroupParent:              e.op.Meta.CgroupParent,
-		NetMode:                   e.op.Network,
-		SecurityMode:              e.op.Security,
-		RemoveMountStubsRecursive: e.op.Meta.RemoveMountStubsRecursive,
-	}
-
-	if e.op.Meta.ProxyEnv != nil {
-		meta.Env = append(meta.Env, proxyEnvList(e.op.Meta.ProxyEnv)...)
+	meta, err := e.execMeta(args, p.ReadonlyRootFS)
+	if err != nil {
+		return nil, err
 	}
-	var currentOS string
-	if e.platform != nil {
-		currentOS = e.platform.OS
+	meta.Nameservers = nameserversFromContext(ctx)
+	if env := e.buildProxyEnv(ctx); len(env) > 0 {
+		meta.Env = append(slices.Clone(meta.Env), env...)
 	}
-	// don't set PATH for Windows. #5445
-	if currentOS != "windows" {
-		meta.Env = addDefaultEnvvar(meta.Env, "PATH", utilsystem.DefaultPathEnv(currentOS))
+	if missing := e.missingMounts(inputs); len(missing) > 0 {
+		meta.Env = append(slices.Clone(meta.Env), missingMountsEnv+"="+strings.Join(missing, ":"))
 	}
 
 	secretEnv, err := e.loadSecretEnv(ctx, g)
 	if err != nil {
 		return nil, err
 	}
-	meta.Env = append(meta.Env, secretEnv...)
+	meta.SecretEnv = secretEnv
 
 	if e.op.Meta.ValidExitCodes != nil {
 		meta.ValidExitCodes = make([]int, len(e.op.Meta.ValidExitCodes))
@@ -494,12 +718,36 @@ func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 		}
 	}()
 
-	rec, execErr := e.exec.Run(ctx, "", p.Root, p.Mounts, executor.ProcessInfo{
+	runCtx := ctx
+	if e.op.Timeout > 0 {
+		timeout := time.Duration(e.op.Timeout)
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeoutCause(ctx, timeout, errors.Errorf("process timed out after %s", timeout))
+		defer cancel()
+	}
+
+	rec, execErr := e.exec.Run(runCtx, "", p.Root, p.Mounts, executor.ProcessInfo{
 		Meta:   meta,
 		Stdin:  nil,
 		Stdout: stdout,
 		Stderr: stderr,
 	}, nil)
+	if execErr != nil && runCtx.Err() != nil && ctx.Err() == nil {
+		execErr = errors.Wrap(execErr, context.Cause(runCtx).Error())
+	}
+
+	if len(e.op.Artifacts) > 0 {
+		ref, err := e.captureArtifacts(ctx, g, p.Root, p.Mounts)
+		if err != nil {
+			if execErr == nil {
+				return nil, err
+			}
+			// the error of the process is more relevant than a failed capture
+			bklog.G(ctx).WithError(err).Warn("failed to capture artifacts of failed exec")
+		} else {
+			artifacts = worker.NewWorkerRefResult(ref, e.w)
+		}
+	}
 
 	for i, out := range p.OutputRefs {
 		if mutable, ok := out.Ref.(cache.MutableRef); ok {
@@ -515,7 +763,24 @@ func (e *ExecOp) Exec(ctx context.Context, g session.Group, inputs []solver.Resu
 		p.OutputRefs[i].Ref = nil
 	}
 	e.rec = rec
-	return results, errors.Wrapf(execErr, "process %q did not complete successfully", strings.Join(e.op.Meta.Args, " "))
+	if execErr == nil && artifacts != nil {
+		results = append(results, artifacts)
+	}
+	return results, errors.Wrapf(execErr, "process %q did not complete successfully", strings.Join(args, " "))
+}
+
+// buildProxyEnv returns the proxy environment variables of the build of the
+// op if the build sets them for the exec ops, unless the op sets its own or
+// is strictly hermetic
+func (e *ExecOp) buildProxyEnv(ctx context.Context) []string {
+	c := proxy.FromContext(ctx)
+	if c == nil || !c.Exec || e.op.Meta.ProxyEnv != nil {
+		return nil
+	}
+	if h := e.op.Hermetic; h != nil && h.Strict {
+		return nil
+	}
+	return c.Env()
 }
 
 func proxyEnvList(p *pb.ProxyEnv) []string {
@@ -562,18 +827,11 @@ func (e *ExecOp) loadSecretEnv(ctx context.Context, g session.Group) ([]string,
 		if id == "" {
 			return nil, errors.Errorf("secret ID missing for %q environment variable", sopt.Name)
 		}
-		var dt []byte
-		var err error
-		err = e.sm.Any(ctx, g, func(ctx context.Context, _ string, caller session.Caller) error {
-			dt, err = secrets.GetSecret(ctx, caller, id)
-			if err != nil {
-				return err
-			}
-			return nil
-		})
+		dt, err := secrets.GetSecretFromGroup(ctx, e.sm, g, id)
 		if err != nil && (!errors.Is(err, secrets.ErrNotFound) || !sopt.Optional) {
 			return nil, err
 		}
+		logs.AddSecret(ctx, dt)
 		out = append(out, fmt.Sprintf("%s=%s", sopt.Name, string(dt)))
 	}
 	return out, nil
@@ -588,3 +846,48 @@ func (e *ExecOp) Samples() (*resourcestypes.Samples, error) {
 	}
 	return e.rec.Samples()
 }
+
+// ResourceUsage implements solver.ResourceUsageOp. The usage is computed from
+// the cgroup samples of the last run of the process.
+func (e *ExecOp) ResourceUsage() *client.ResourceUsage {
+	samples, err := e.Samples()
+	if err != nil || samples == nil {
+		return nil
+	}
+	return resourceUsage(samples.Samples)
+}
+
+// resourceUsage summarizes the cgroup samples of a process. The CPU and IO
+// counters of the cgroup only grow so the usage is their largest value.
+func resourceUsage(samples []*resourcestypes.Sample) *client.ResourceUsage {
+	if len(samples) == 0 {
+		return nil
+	}
+	ru := &client.ResourceUsage{}
+	for _, s := range samples {
+		if c := s.CPUStat; c != nil && c.UsageNanos != nil {
+			ru.CPUNanos = max(ru.CPUNanos, *c.UsageNanos)
+		}
+		if m := s.MemoryStat; m != nil {
+			if m.Peak != nil {
+				ru.MemoryPeakBytes = max(ru.MemoryPeakBytes, *m.Peak)
+			} else if m.Anon != nil {
+				// kernels before 5.19 don't report the peak
+				used := *m.Anon
+				if m.File != nil {
+					used += *m.File
+				}
+				ru.MemoryPeakBytes = max(ru.MemoryPeakBytes, used)
+			}
+		}
+		if io := s.IOStat; io != nil {
+			if io.ReadBytes != nil {
+				ru.IOReadBytes = max(ru.IOReadBytes, *io.ReadBytes)
+			}
+			if io.WriteBytes != nil {
+				ru.IOWriteBytes = max(ru.IOWriteBytes, *io.WriteBytes)
+			}
+		}
+	}
+	return ru
+}
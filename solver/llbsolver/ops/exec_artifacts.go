@@ -0,0 +1,159 @@
+package ops
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/continuity/fs"
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/executor"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/snapshot"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/pkg/errors"
+	copy "github.com/tonistiigi/fsutil/copy"
+)
+
+// artifactMount is a mount of the process that artifacts can be captured from
+type artifactMount struct {
+	executor.Mount
+	dest string
+	lm   snapshot.Mounter
+}
+
+// artifactMounts returns the mounts of the process that artifacts can be
+// captured from. Secrets, SSH sockets and tmpfs mounts are never captured.
+func (e *ExecOp) artifactMounts(root executor.Mount, mounts []executor.Mount) []artifactMount {
+	var out []artifactMount
+	for _, m := range e.op.Mounts {
+		if m.MountType != pb.MountType_BIND && m.MountType != pb.MountType_CACHE {
+			continue
+		}
+		dest := path.Clean("/" + m.Dest)
+		if dest == "/" {
+			out = append(out, artifactMount{Mount: root, dest: dest})
+			continue
+		}
+		for _, em := range mounts {
+			if path.Clean("/"+em.Dest) == dest {
+				out = append(out, artifactMount{Mount: em, dest: dest})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// resolveArtifact returns the mount that p is captured from and the path of p
+// relative to the mount
+func resolveArtifact(mounts []artifactMount, p string) (*artifactMount, string, bool) {
+	var match *artifactMount
+	for i, m := range mounts {
+		if p != m.dest && m.dest != "/" && !strings.HasPrefix(p, m.dest+"/") {
+			continue
+		}
+		if match == nil || len(m.dest) > len(match.dest) {
+			match = &mounts[i]
+		}
+	}
+	if match == nil {
+		return nil, "", false
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(p, match.dest), "/")
+	return match, path.Join("/", match.Selector, rel), true
+}
+
+// captureArtifacts copies the artifacts of the exec from the mounts of the
+// process to a new ref. The artifacts keep their path in the container.
+func (e *ExecOp) captureArtifacts(ctx context.Context, g session.Group, root executor.Mount, mounts []executor.Mount) (_ cache.ImmutableRef, err error) {
+	desc := "artifacts of exec " + strings.Join(e.op.Meta.Args, " ")
+	ref, err := e.cm.New(ctx, nil, g, cache.WithDescription(desc))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			ref.Release(context.TODO())
+		}
+	}()
+
+	mountable, err := ref.Mount(ctx, false, g)
+	if err != nil {
+		return nil, err
+	}
+	lm := snapshot.LocalMounter(mountable)
+	dest, err := lm.Mount()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if lm != nil {
+			lm.Unmount()
+		}
+	}()
+
+	ams := e.artifactMounts(root, mounts)
+	srcs := map[*artifactMount]string{}
+	defer func() {
+		for m := range srcs {
+			m.unmount()
+		}
+	}()
+	for _, p := range e.op.Artifacts {
+		if !path.IsAbs(p) {
+			p = path.Join("/", e.op.Meta.Cwd, p)
+		}
+		p = path.Clean(p)
+		m, rel, ok := resolveArtifact(ams, p)
+		if !ok {
+			continue
+		}
+		src, ok := srcs[m]
+		if !ok {
+			if src, err = m.mount(ctx); err != nil {
+				m.unmount()
+				return nil, errors.Wrapf(err, "failed to mount %s to capture artifacts", m.dest)
+			}
+			srcs[m] = src
+		}
+		fp, err := fs.RootPath(src, filepath.FromSlash(rel))
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Lstat(fp); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, errors.WithStack(err)
+		}
+		if err := copy.Copy(ctx, src, filepath.FromSlash(rel), dest, filepath.FromSlash(p), copy.WithCopyInfo(copy.CopyInfo{CopyDirContents: true})); err != nil {
+			return nil, errors.Wrapf(err, "failed to capture artifact %s", p)
+		}
+	}
+
+	err = lm.Unmount()
+	lm = nil
+	if err != nil {
+		return nil, err
+	}
+	return ref.Commit(ctx)
+}
+
+// mount mounts the source of m read-only and returns its local path
+func (m *artifactMount) mount(ctx context.Context) (string, error) {
+	mref, err := m.Src.Mount(ctx, true)
+	if err != nil {
+		return "", err
+	}
+	m.lm = snapshot.LocalMounter(mref)
+	return m.lm.Mount()
+}
+
+func (m *artifactMount) unmount() {
+	if m.lm != nil {
+		m.lm.Unmount()
+	}
+}
@@ -0,0 +1,37 @@
+package ops
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/executor"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveArtifact(t *testing.T) {
+	mounts := []artifactMount{
+		{dest: "/"},
+		{dest: "/src", Mount: executor.Mount{Selector: "app"}},
+		{dest: "/src/out"},
+	}
+
+	for _, tc := range []struct {
+		path  string
+		dest  string
+		rel   string
+		found bool
+	}{
+		{path: "/out/junit.xml", dest: "/", rel: "/out/junit.xml", found: true},
+		{path: "/src/report.xml", dest: "/src", rel: "/app/report.xml", found: true},
+		{path: "/src/out/junit.xml", dest: "/src/out", rel: "/junit.xml", found: true},
+		{path: "/src/out", dest: "/src/out", rel: "/", found: true},
+		{path: "/srcfoo/junit.xml", dest: "/", rel: "/srcfoo/junit.xml", found: true},
+	} {
+		m, rel, ok := resolveArtifact(mounts, tc.path)
+		require.Equal(t, tc.found, ok, tc.path)
+		require.Equal(t, tc.dest, m.dest, tc.path)
+		require.Equal(t, tc.rel, rel, tc.path)
+	}
+
+	_, _, ok := resolveArtifact(mounts[1:], "/out/junit.xml")
+	require.False(t, ok)
+}
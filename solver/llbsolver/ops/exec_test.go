@@ -2,11 +2,25 @@ package ops
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/moby/buildkit/client"
+	resourcestypes "github.com/moby/buildkit/executor/resources/types"
+	gatewayapi "github.com/moby/buildkit/frontend/gateway/pb"
 	"github.com/moby/buildkit/identity"
 	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/moby/buildkit/session/testutil"
+	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/proxy"
+	utilsystem "github.com/moby/buildkit/util/system"
+	"github.com/moby/buildkit/worker"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -103,6 +117,36 @@ func TestExecOpCacheMap(t *testing.T) {
 			op2:    newExecOp(withNewMount("/foo", withCache(&pb.CacheOpt{ID: "someOtherID", Sharing: 1}))),
 			xMatch: true,
 		},
+		{
+			name:   "different env should not match",
+			op1:    newExecOp(withEnv("CI_BRANCH=main")),
+			op2:    newExecOp(withEnv("CI_BRANCH=feature")),
+			xMatch: false,
+		},
+		{
+			name:   "hermetic ops with different undeclared env should match",
+			op1:    newExecOp(withEnv("CI_BRANCH=main", "CC=gcc"), withHermetic("CC")),
+			op2:    newExecOp(withEnv("CC=gcc", "CI_BRANCH=feature"), withHermetic("CC")),
+			xMatch: true,
+		},
+		{
+			name:   "hermetic ops with different declared env should not match",
+			op1:    newExecOp(withEnv("CC=gcc"), withHermetic("CC")),
+			op2:    newExecOp(withEnv("CC=clang"), withHermetic("CC")),
+			xMatch: false,
+		},
+		{
+			name:   "hermetic ops with different hostname should match",
+			op1:    newExecOp(withHostname("runner1"), withHermetic()),
+			op2:    newExecOp(withHostname("runner2"), withHermetic()),
+			xMatch: true,
+		},
+		{
+			name:   "timeout and retry policy should not affect the cache key",
+			op1:    newExecOp(),
+			op2:    newExecOp(withTimeout(time.Minute), withRetry(&pb.RetryPolicy{Attempts: 3, ExitCodes: []int32{1}})),
+			xMatch: true,
+		},
 	}
 
 	ctx := context.Background()
@@ -244,6 +288,48 @@ func newExecOp(opts ...func(*ExecOp)) *ExecOp {
 	return op
 }
 
+func withEnv(env ...string) func(*ExecOp) {
+	return func(op *ExecOp) {
+		op.op.Meta.Env = env
+	}
+}
+
+func withHostname(hostname string) func(*ExecOp) {
+	return func(op *ExecOp) {
+		op.op.Meta.Hostname = hostname
+	}
+}
+
+func withHermetic(env ...string) func(*ExecOp) {
+	return func(op *ExecOp) {
+		op.op.Hermetic = &pb.HermeticOpt{Env: env}
+	}
+}
+
+func withTimeout(d time.Duration) func(*ExecOp) {
+	return func(op *ExecOp) {
+		op.op.Timeout = int64(d)
+	}
+}
+
+func withRetry(r *pb.RetryPolicy) func(*ExecOp) {
+	return func(op *ExecOp) {
+		op.op.Retry = r
+	}
+}
+
+func withSecretEnv(name, id string) func(*ExecOp) {
+	return func(op *ExecOp) {
+		op.op.Secretenv = append(op.op.Secretenv, &pb.SecretEnv{Name: name, ID: id})
+	}
+}
+
+func withHermeticStrict(env ...string) func(*ExecOp) {
+	return func(op *ExecOp) {
+		op.op.Hermetic = &pb.HermeticOpt{Env: env, Strict: true}
+	}
+}
+
 func withEmptyMounts(op *ExecOp) {
 	op.op.Mounts = []*pb.Mount{}
 }
@@ -289,3 +375,225 @@ func withoutOutput() func(*pb.Mount) {
 		m.Output = int64(pb.SkipOutput)
 	}
 }
+
+func TestExecOpHostBindCacheMap(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gcc"), []byte("v1"), 0755))
+
+	e := newExecOp(func(op *ExecOp) {
+		op.op.Mounts = []*pb.Mount{{
+			Input:       int64(pb.Empty),
+			Dest:        "/opt/toolchain",
+			MountType:   pb.MountType_HOSTBIND,
+			Readonly:    true,
+			HostBindOpt: &pb.HostBindOpt{Path: dir},
+		}}
+	})
+	cacheMap := func() digest.Digest {
+		m, ok, err := e.CacheMap(context.TODO(), session.NewGroup(t.Name()), 1)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return m.Digest
+	}
+
+	dgst := cacheMap()
+	require.Equal(t, dgst, cacheMap())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "gcc"), []byte("v2.0"), 0755))
+	changed := cacheMap()
+	require.NotEqual(t, dgst, changed)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "lib"), 0755))
+	require.NotEqual(t, changed, cacheMap())
+
+	require.NoError(t, os.RemoveAll(dir))
+	_, _, err := e.CacheMap(context.TODO(), session.NewGroup(t.Name()), 1)
+	require.ErrorContains(t, err, "failed to resolve host mount path")
+}
+
+func TestExecOpShouldRetry(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	exit := func(code uint32) error {
+		return errors.Wrap(&gatewayapi.ExitError{ExitCode: code}, "process did not complete successfully")
+	}
+
+	op := newExecOp()
+	require.False(t, op.shouldRetry(ctx, exit(1), 0))
+
+	op = newExecOp(withRetry(&pb.RetryPolicy{Attempts: 2}))
+	require.True(t, op.shouldRetry(ctx, exit(1), 0))
+	require.True(t, op.shouldRetry(ctx, errors.New("timed out"), 1))
+	require.False(t, op.shouldRetry(ctx, exit(1), 2))
+
+	op = newExecOp(withRetry(&pb.RetryPolicy{Attempts: 2, ExitCodes: []int32{100}}))
+	require.True(t, op.shouldRetry(ctx, exit(100), 0))
+	require.False(t, op.shouldRetry(ctx, exit(1), 0))
+	require.False(t, op.shouldRetry(ctx, errors.New("timed out"), 0))
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	require.False(t, op.shouldRetry(cctx, exit(100), 0))
+}
+
+func TestRetryBackoff(t *testing.T) {
+	t.Parallel()
+
+	r := &pb.RetryPolicy{Attempts: 10, Backoff: int64(time.Second)}
+	require.Equal(t, time.Second, retryBackoff(r, 0))
+	require.Equal(t, 4*time.Second, retryBackoff(r, 2))
+	require.Equal(t, maxRetryBackoff, retryBackoff(r, 9))
+
+	r.Backoff = int64(time.Hour)
+	require.Equal(t, maxRetryBackoff, retryBackoff(r, 0))
+
+	r.Backoff = 0
+	require.Equal(t, time.Duration(0), retryBackoff(r, 3))
+}
+
+func TestExecOpSecretEnv(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sm, err := session.NewManager()
+	require.NoError(t, err)
+
+	newSession := func(value string) string {
+		s, err := session.NewSession(ctx, "secretenv")
+		require.NoError(t, err)
+		s.Allow(secretsprovider.FromMap(map[string][]byte{"key": []byte(value)}))
+		dialer := session.Dialer(testutil.TestStream(testutil.Handler(sm.HandleConn)))
+		go s.Run(ctx, dialer)
+		t.Cleanup(func() { s.Close() })
+		return s.ID()
+	}
+
+	op1 := newExecOp(withEnv("FOO=bar"), withSecretEnv("API_KEY", "key"))
+	op1.sm = sm
+	op2 := newExecOp(withEnv("FOO=bar"), withSecretEnv("API_KEY", "key"))
+	op2.sm = sm
+
+	g1 := session.NewGroup(newSession("hunter2"))
+	g2 := session.NewGroup(newSession("swordfish"))
+
+	env, err := op1.loadSecretEnv(ctx, g1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"API_KEY=hunter2"}, env)
+
+	env, err = op2.loadSecretEnv(ctx, g2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"API_KEY=swordfish"}, env)
+
+	// the secret value is only loaded for the process and never reaches
+	// the cache key or the op definition recorded in the provenance
+	m1, ok, err := op1.CacheMap(ctx, g1, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	m2, ok, err := op2.CacheMap(ctx, g2, 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, m1.Digest, m2.Digest)
+
+	dt, err := op1.Proto().MarshalVT()
+	require.NoError(t, err)
+	require.NotContains(t, string(dt), "hunter2")
+	require.Equal(t, []string{"FOO=bar"}, op1.Proto().Meta.Env)
+}
+
+func TestExecOpHermeticMeta(t *testing.T) {
+	t.Parallel()
+
+	opts := []func(*ExecOp){
+		withEnv("CC=gcc", "CI_BRANCH=main"),
+		withHostname("runner1"),
+		func(op *ExecOp) {
+			op.op.Meta.ExtraHosts = []*pb.HostIP{{Host: "registry", IP: "10.0.0.1"}}
+			op.op.Meta.ProxyEnv = &pb.ProxyEnv{HttpProxy: "http://proxy:3128"}
+			op.op.Network = pb.NetMode_HOST
+		},
+	}
+
+	meta, err := newExecOp(append(opts, withHermetic("CC"))...).execMeta([]string{"make"}, false)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"CC=gcc", "CI_BRANCH=main", "HTTP_PROXY=http://proxy:3128", "http_proxy=http://proxy:3128", "PATH=" + utilsystem.DefaultPathEnv("linux")}, meta.Env)
+	require.Equal(t, "runner1", meta.Hostname)
+	require.Len(t, meta.ExtraHosts, 1)
+	require.Equal(t, pb.NetMode_HOST, meta.NetMode)
+
+	meta, err = newExecOp(append(opts, withHermeticStrict("CC"))...).execMeta([]string{"make"}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{"CC=gcc", "PATH=" + utilsystem.DefaultPathEnv("linux")}, meta.Env)
+	require.Empty(t, meta.Hostname)
+	require.Empty(t, meta.ExtraHosts)
+	require.Equal(t, pb.NetMode_NONE, meta.NetMode)
+}
+
+func TestExecOpBuildProxyEnv(t *testing.T) {
+	t.Parallel()
+
+	cfg := &proxy.Config{HTTPSProxy: "http://proxy:3128", NoProxy: "localhost"}
+	ctx := proxy.WithConfig(context.TODO(), cfg)
+	require.Empty(t, newExecOp().buildProxyEnv(ctx))
+
+	cfg.Exec = true
+	require.Empty(t, newExecOp().buildProxyEnv(context.TODO()))
+	require.Equal(t, []string{"HTTPS_PROXY=http://proxy:3128", "https_proxy=http://proxy:3128", "NO_PROXY=localhost", "no_proxy=localhost"}, newExecOp().buildProxyEnv(ctx))
+
+	// the proxies of the op override the ones of the build
+	require.Empty(t, newExecOp(func(op *ExecOp) {
+		op.op.Meta.ProxyEnv = &pb.ProxyEnv{HttpProxy: "http://other:3128"}
+	}).buildProxyEnv(ctx))
+	require.Empty(t, newExecOp(withHermeticStrict()).buildProxyEnv(ctx))
+	require.NotEmpty(t, newExecOp(withHermetic()).buildProxyEnv(ctx))
+}
+
+func TestExecOpMissingMounts(t *testing.T) {
+	t.Parallel()
+
+	e := newExecOp(func(op *ExecOp) {
+		op.op.Mounts = []*pb.Mount{
+			{Input: 0, Dest: "/"},
+			{Input: int64(pb.Empty), Dest: "/tmp", MountType: pb.MountType_TMPFS},
+			{Input: 1, Dest: "/prev"},
+			{Input: 2, Dest: "/cache"},
+			{Input: 1, Dest: "/prev2"},
+		}
+	})
+	res := worker.NewWorkerRefResult(nil, nil)
+	require.Empty(t, e.missingMounts([]solver.Result{res, res, res}))
+	require.Equal(t, []string{"/prev", "/prev2"}, e.missingMounts([]solver.Result{res, nil, res}))
+}
+
+func TestResourceUsage(t *testing.T) {
+	u := func(v uint64) *uint64 { return &v }
+
+	require.Nil(t, resourceUsage(nil))
+
+	ru := resourceUsage([]*resourcestypes.Sample{
+		{
+			CPUStat:    &resourcestypes.CPUStat{UsageNanos: u(100)},
+			MemoryStat: &resourcestypes.MemoryStat{Anon: u(10), File: u(5)},
+			IOStat:     &resourcestypes.IOStat{ReadBytes: u(1000)},
+		},
+		{
+			CPUStat:    &resourcestypes.CPUStat{UsageNanos: u(300)},
+			MemoryStat: &resourcestypes.MemoryStat{Anon: u(8)},
+			IOStat:     &resourcestypes.IOStat{ReadBytes: u(2000), WriteBytes: u(50)},
+		},
+		{},
+	})
+	require.Equal(t, &client.ResourceUsage{
+		CPUNanos:        300,
+		MemoryPeakBytes: 15,
+		IOReadBytes:     2000,
+		IOWriteBytes:    50,
+	}, ru)
+
+	ru = resourceUsage([]*resourcestypes.Sample{
+		{MemoryStat: &resourcestypes.MemoryStat{Peak: u(4096), Anon: u(8192)}},
+	})
+	require.Equal(t, uint64(4096), ru.MemoryPeakBytes)
+}
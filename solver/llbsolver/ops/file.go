@@ -28,7 +28,7 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-const fileCacheType = "buildkit.file.v0"
+var fileCacheType = cacheType("file")
 
 type fileOp struct {
 	op          *pb.FileOp
@@ -176,6 +176,11 @@ func (f *fileOp) CacheMap(ctx context.Context, g session.Group, index int) (*sol
 func (f *fileOp) Exec(ctx context.Context, g session.Group, inputs []solver.Result) ([]solver.Result, error) {
 	inpRefs := make([]fileoptypes.Ref, 0, len(inputs))
 	for _, inp := range inputs {
+		if inp == nil {
+			// weak input that failed, used as scratch
+			inpRefs = append(inpRefs, nil)
+			continue
+		}
 		workerRef, ok := inp.Sys().(*worker.WorkerRef)
 		if !ok {
 			return nil, errors.Errorf("invalid reference for exec %T", inp.Sys())
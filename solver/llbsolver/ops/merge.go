@@ -16,7 +16,7 @@ import (
 	digest "github.com/opencontainers/go-digest"
 )
 
-const mergeCacheType = "buildkit.merge.v0"
+var mergeCacheType = cacheType("merge")
 
 type mergeOp struct {
 	op     *pb.MergeOp
@@ -0,0 +1,16 @@
+package ops
+
+import "context"
+
+type nameserversKey struct{}
+
+// WithNameservers returns a context with which the exec ops resolve names
+// with the nameservers instead of the nameservers of the executor
+func WithNameservers(ctx context.Context, ns []string) context.Context {
+	return context.WithValue(ctx, nameserversKey{}, ns)
+}
+
+func nameserversFromContext(ctx context.Context) []string {
+	ns, _ := ctx.Value(nameserversKey{}).([]string)
+	return ns
+}
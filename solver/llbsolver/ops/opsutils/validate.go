@@ -1,10 +1,16 @@
 package opsutils
 
 import (
+	"path"
+	"strings"
+
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/pkg/errors"
 )
 
+// MaxExecRetryAttempts is the maximum number of retries of a failed exec
+const MaxExecRetryAttempts = 10
+
 func Validate(op *pb.Op) error {
 	if op == nil {
 		return errors.Errorf("invalid nil op")
@@ -39,6 +45,47 @@ func Validate(op *pb.Op) error {
 		if !isRoot {
 			return errors.Errorf("invalid exec op with no rootfs")
 		}
+		if h := op.Exec.Hermetic; h != nil && h.Strict {
+			if len(op.Exec.Secretenv) > 0 {
+				return errors.Errorf("invalid strict hermetic exec op with secret env")
+			}
+			if op.Exec.Security == pb.SecurityMode_INSECURE {
+				return errors.Errorf("invalid strict hermetic exec op with insecure security mode")
+			}
+			for _, m := range op.Exec.Mounts {
+				switch m.MountType {
+				case pb.MountType_SECRET, pb.MountType_SSH, pb.MountType_CACHE, pb.MountType_LOCALEXEC, pb.MountType_HOSTBIND:
+					return errors.Errorf("invalid strict hermetic exec op with %s mount at %s", strings.ToLower(m.MountType.String()), m.Dest)
+				}
+			}
+		}
+		for _, m := range op.Exec.Mounts {
+			if m.MountType != pb.MountType_HOSTBIND {
+				continue
+			}
+			if p := m.HostBindOpt.GetPath(); !path.IsAbs(p) || path.Clean(p) != p {
+				return errors.Errorf("invalid host bind mount at %s with non-absolute or non-clean path %q", m.Dest, p)
+			}
+			if !m.Readonly || m.Output != int64(pb.SkipOutput) || m.Input != int64(pb.Empty) {
+				return errors.Errorf("invalid host bind mount at %s, host bind mounts need to be read-only without input and output", m.Dest)
+			}
+		}
+		if op.Exec.Timeout < 0 {
+			return errors.Errorf("invalid exec op with negative timeout")
+		}
+		if r := op.Exec.Retry; r != nil {
+			if r.Attempts < 0 || r.Backoff < 0 {
+				return errors.Errorf("invalid exec op retry policy with negative attempts or backoff")
+			}
+			if r.Attempts > MaxExecRetryAttempts {
+				return errors.Errorf("invalid exec op retry policy with %d attempts, maximum is %d", r.Attempts, MaxExecRetryAttempts)
+			}
+		}
+		for _, p := range op.Exec.Artifacts {
+			if p == "" {
+				return errors.Errorf("invalid exec op with empty artifact path")
+			}
+		}
 	case *pb.Op_File:
 		if op.File == nil {
 			return errors.Errorf("invalid nil file op")
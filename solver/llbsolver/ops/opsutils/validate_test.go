@@ -0,0 +1,153 @@
+package opsutils
+
+import (
+	"testing"
+
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStrictHermetic(t *testing.T) {
+	t.Parallel()
+
+	newOp := func(f func(*pb.ExecOp)) *pb.Op {
+		e := &pb.ExecOp{
+			Meta: &pb.Meta{Args: []string{"make"}},
+			Mounts: []*pb.Mount{
+				{Dest: pb.RootMount, Input: 0},
+			},
+			Hermetic: &pb.HermeticOpt{Env: []string{"CC"}, Strict: true},
+		}
+		if f != nil {
+			f(e)
+		}
+		return &pb.Op{Op: &pb.Op_Exec{Exec: e}}
+	}
+
+	tcases := []struct {
+		name string
+		f    func(*pb.ExecOp)
+		err  string
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "secret env",
+			f: func(e *pb.ExecOp) {
+				e.Secretenv = []*pb.SecretEnv{{ID: "token", Name: "TOKEN"}}
+			},
+			err: "secret env",
+		},
+		{
+			name: "insecure",
+			f: func(e *pb.ExecOp) {
+				e.Security = pb.SecurityMode_INSECURE
+			},
+			err: "insecure security mode",
+		},
+		{
+			name: "secret mount",
+			f: func(e *pb.ExecOp) {
+				e.Mounts = append(e.Mounts, &pb.Mount{Dest: "/run/secrets/token", Input: -1, MountType: pb.MountType_SECRET})
+			},
+			err: "secret mount at /run/secrets/token",
+		},
+		{
+			name: "ssh mount",
+			f: func(e *pb.ExecOp) {
+				e.Mounts = append(e.Mounts, &pb.Mount{Dest: "/run/ssh", Input: -1, MountType: pb.MountType_SSH})
+			},
+			err: "ssh mount at /run/ssh",
+		},
+		{
+			name: "cache mount",
+			f: func(e *pb.ExecOp) {
+				e.Mounts = append(e.Mounts, &pb.Mount{Dest: "/root/.cache", Input: -1, MountType: pb.MountType_CACHE})
+			},
+			err: "cache mount at /root/.cache",
+		},
+		{
+			name: "tmpfs mount",
+			f: func(e *pb.ExecOp) {
+				e.Mounts = append(e.Mounts, &pb.Mount{Dest: "/tmp", Input: -1, MountType: pb.MountType_TMPFS})
+			},
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(newOp(tc.f))
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	t.Run("not strict", func(t *testing.T) {
+		err := Validate(newOp(func(e *pb.ExecOp) {
+			e.Hermetic.Strict = false
+			e.Security = pb.SecurityMode_INSECURE
+			e.Mounts = append(e.Mounts, &pb.Mount{Dest: "/root/.cache", Input: -1, MountType: pb.MountType_CACHE})
+		}))
+		require.NoError(t, err)
+	})
+}
+
+func TestValidateRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	newOp := func(r *pb.RetryPolicy) *pb.Op {
+		return &pb.Op{Op: &pb.Op_Exec{Exec: &pb.ExecOp{
+			Meta: &pb.Meta{Args: []string{"make"}},
+			Mounts: []*pb.Mount{
+				{Dest: pb.RootMount, Input: 0},
+			},
+			Retry: r,
+		}}}
+	}
+
+	require.NoError(t, Validate(newOp(&pb.RetryPolicy{Attempts: MaxExecRetryAttempts, Backoff: 1})))
+	require.ErrorContains(t, Validate(newOp(&pb.RetryPolicy{Attempts: -1})), "negative attempts")
+	require.ErrorContains(t, Validate(newOp(&pb.RetryPolicy{Backoff: -1})), "negative attempts or backoff")
+	require.ErrorContains(t, Validate(newOp(&pb.RetryPolicy{Attempts: MaxExecRetryAttempts + 1})), "maximum is 10")
+}
+
+func TestValidateHostBind(t *testing.T) {
+	t.Parallel()
+
+	newOp := func(m *pb.Mount) *pb.Op {
+		return &pb.Op{Op: &pb.Op_Exec{Exec: &pb.ExecOp{
+			Meta: &pb.Meta{Args: []string{"make"}},
+			Mounts: []*pb.Mount{
+				{Dest: pb.RootMount, Input: 0},
+				m,
+			},
+		}}}
+	}
+	hostBind := func(p string) *pb.Mount {
+		return &pb.Mount{
+			Dest:        "/opt/toolchain",
+			Input:       int64(pb.Empty),
+			Output:      int64(pb.SkipOutput),
+			Readonly:    true,
+			MountType:   pb.MountType_HOSTBIND,
+			HostBindOpt: &pb.HostBindOpt{Path: p},
+		}
+	}
+
+	require.NoError(t, Validate(newOp(hostBind("/srv/toolchains"))))
+	require.ErrorContains(t, Validate(newOp(hostBind("srv/toolchains"))), "non-absolute or non-clean path")
+	require.ErrorContains(t, Validate(newOp(hostBind("/srv/../etc"))), "non-absolute or non-clean path")
+	require.ErrorContains(t, Validate(newOp(&pb.Mount{Dest: "/opt", Input: int64(pb.Empty), Output: int64(pb.SkipOutput), MountType: pb.MountType_HOSTBIND})), "non-absolute")
+
+	m := hostBind("/srv/toolchains")
+	m.Readonly = false
+	require.ErrorContains(t, Validate(newOp(m)), "need to be read-only")
+
+	m = hostBind("/srv/toolchains")
+	m.Output = 1
+	require.ErrorContains(t, Validate(newOp(m)), "need to be read-only")
+}
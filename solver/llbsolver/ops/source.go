@@ -7,6 +7,7 @@ import (
 
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/errdefs"
 	"github.com/moby/buildkit/solver/llbsolver/ops/opsutils"
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/source"
@@ -16,7 +17,7 @@ import (
 	"golang.org/x/sync/semaphore"
 )
 
-const sourceCacheType = "buildkit.source.v0"
+var sourceCacheType = cacheType("source")
 
 type SourceOp struct {
 	mu          sync.Mutex
@@ -82,7 +83,7 @@ func (s *SourceOp) CacheMap(ctx context.Context, g session.Group, index int) (*s
 
 	k, pin, cacheOpts, done, err := src.CacheKey(ctx, g, index)
 	if err != nil {
-		return nil, false, err
+		return nil, false, errdefs.WithCode(err, errdefs.CodeSourceFetch)
 	}
 
 	if s.pin == "" {
@@ -111,7 +112,7 @@ func (s *SourceOp) Exec(ctx context.Context, g session.Group, _ []solver.Result)
 	}
 	ref, err := src.Snapshot(ctx, g)
 	if err != nil {
-		return nil, err
+		return nil, errdefs.WithCode(err, errdefs.CodeSourceFetch)
 	}
 	return []solver.Result{worker.NewWorkerRefResult(ref, s.w)}, nil
 }
@@ -191,6 +191,9 @@ func (b *provenanceBridge) Solve(ctx context.Context, req frontend.SolveRequest,
 		return &frontend.Result{}, nil
 	}
 	if req.Evaluate {
+		if req.AllowPartialFailure && len(res.Refs) > 0 {
+			return res, evaluatePartial(ctx, res)
+		}
 		err = res.EachRef(func(ref solver.ResultProxy) error {
 			_, err := ref.Result(ctx)
 			return err
@@ -199,6 +202,51 @@ func (b *provenanceBridge) Solve(ctx context.Context, req frontend.SolveRequest,
 	return
 }
 
+// evaluatePartial evaluates the refs of a multi-ref result concurrently. The
+// refs that fail are removed from the result, together with their
+// attestations, and their errors are added to the result instead.
+func evaluatePartial(ctx context.Context, res *frontend.Result) error {
+	var wg sync.WaitGroup
+	errs := make(map[string]error, len(res.Refs))
+	var mu sync.Mutex
+	for k, ref := range res.Refs {
+		if ref == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ref.Result(ctx); err != nil {
+				mu.Lock()
+				errs[k] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if err := context.Cause(ctx); err != nil {
+		return err
+	}
+
+	for k, err := range errs {
+		delete(res.Refs, k)
+		delete(res.Attestations, k)
+		res.AddRefError(k, err)
+	}
+	// a failed attestation of a ref that succeeded still fails the solve
+	for _, atts := range res.Attestations {
+		for _, att := range atts {
+			if att.Ref == nil {
+				continue
+			}
+			if _, err := att.Ref.Result(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 type resultRequests struct {
 	ref       *resultWithBridge
 	refs      map[string]*resultWithBridge
@@ -337,6 +385,7 @@ type ProvenanceCreator struct {
 	j           *solver.Job
 	sampler     *resources.SysSampler
 	addLayers   func(context.Context) error
+	timestamps  *Timestamps
 }
 
 func NewProvenanceCreator(ctx context.Context, slsaVersion provenancetypes.ProvenanceSLSA, cp *provenance.Capture, res solver.ResultProxy, attrs map[string]string, j *solver.Job, usage *resources.SysSampler) (*ProvenanceCreator, error) {
@@ -367,14 +416,17 @@ func NewProvenanceCreator(ctx context.Context, slsaVersion provenancetypes.Prove
 		withUsage = err == nil && b
 	}
 
-	pr, err := provenance.NewPredicate(cp)
+	timestamps, err := ParseTimestamps(attrs["timestamps"], cp.Args)
 	if err != nil {
 		return nil, err
 	}
 
-	st := j.StartedTime()
+	pr, err := provenance.NewPredicate(cp)
+	if err != nil {
+		return nil, err
+	}
 
-	pr.Metadata.BuildStartedOn = &st
+	pr.Metadata.BuildStartedOn = timestamps.buildTime(j.StartedTime())
 	pr.Metadata.Reproducible = reproducible
 	pr.Metadata.BuildInvocationID = j.UniqueID()
 
@@ -456,6 +508,7 @@ func NewProvenanceCreator(ctx context.Context, slsaVersion provenancetypes.Prove
 		slsaVersion: slsaVersion,
 		j:           j,
 		addLayers:   addLayers,
+		timestamps:  timestamps,
 	}
 	if withUsage {
 		pc.sampler = usage
@@ -471,8 +524,7 @@ func (p *ProvenanceCreator) PredicateType() string {
 }
 
 func (p *ProvenanceCreator) Predicate(ctx context.Context) (any, error) {
-	end := p.j.RegisterCompleteTime()
-	p.pr.Metadata.BuildFinishedOn = &end
+	p.pr.Metadata.BuildFinishedOn = p.timestamps.buildTime(p.j.RegisterCompleteTime())
 
 	if p.addLayers != nil {
 		if err := p.addLayers(ctx); err != nil {
@@ -0,0 +1,32 @@
+package llbsolver
+
+import (
+	"context"
+
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/util/proxy"
+	"github.com/pkg/errors"
+)
+
+const keyProxy = "llb.proxy"
+
+// withBuilderProxy returns a ctx with which the fetches of an op use the
+// proxy configuration of the first build of b that sets one
+func withBuilderProxy(ctx context.Context, b solver.Builder) (context.Context, error) {
+	var cfg *proxy.Config
+	err := b.EachValue(ctx, keyProxy, func(v any) error {
+		x, ok := v.(*proxy.Config)
+		if !ok {
+			return errors.Errorf("invalid proxy config %T", v)
+		}
+		cfg = x
+		return errStopEach
+	})
+	if err != nil && !errors.Is(err, errStopEach) {
+		return nil, err
+	}
+	if cfg == nil {
+		return ctx, nil
+	}
+	return proxy.WithConfig(ctx, cfg), nil
+}
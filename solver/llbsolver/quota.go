@@ -0,0 +1,78 @@
+package llbsolver
+
+import (
+	"context"
+
+	"github.com/docker/go-units"
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/util/clientidentity"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const keyClient = "llb.client"
+
+// checkQuota returns an error if the cache records created by the client
+// identity of ctx use more than the quota of the identity. The usage counts
+// the records created by all the clients with the name of the identity.
+func (s *Solver) checkQuota(ctx context.Context) error {
+	id := clientidentity.FromContext(ctx)
+	if id == nil {
+		return nil
+	}
+	quota, ok := s.identityQuotas[*id]
+	if !ok || quota <= 0 {
+		return nil
+	}
+	workers, err := s.workerController.List()
+	if err != nil {
+		return err
+	}
+	var used int64
+	for _, w := range workers {
+		du, err := w.DiskUsage(ctx, client.DiskUsageInfo{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to compute the cache usage of %s", id.Name)
+		}
+		used += clientUsage(du, id.Name)
+	}
+	if used >= quota {
+		return status.Errorf(codes.ResourceExhausted, "client %s is over its cache quota: %s used of %s", id.Name, units.HumanSize(float64(used)), units.HumanSize(float64(quota)))
+	}
+	return nil
+}
+
+// clientUsage returns the size of the records created by the named client
+func clientUsage(du []*client.UsageInfo, name string) int64 {
+	var size int64
+	for _, r := range du {
+		if r.Client == name && r.Size > 0 {
+			size += r.Size
+		}
+	}
+	return size
+}
+
+// withBuilderClient attributes the cache refs created with ctx to the client
+// identity of the first build of b that has one
+func withBuilderClient(ctx context.Context, b solver.Builder) (context.Context, error) {
+	var name string
+	err := b.EachValue(ctx, keyClient, func(v any) error {
+		x, ok := v.(string)
+		if !ok {
+			return errors.Errorf("invalid client %T", v)
+		}
+		name = x
+		return errStopEach
+	})
+	if err != nil && !errors.Is(err, errStopEach) {
+		return nil, err
+	}
+	if name == "" {
+		return ctx, nil
+	}
+	return cache.WithClient(ctx, name), nil
+}
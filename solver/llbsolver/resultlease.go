@@ -0,0 +1,220 @@
+package llbsolver
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/identity"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/result"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/moby/buildkit/worker"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// resultLeases are the leases taken by clients on the result refs of builds.
+// The refs are held open so that they are not pruned until the lease expires
+// or is released. The leases don't survive a restart of the daemon.
+type resultLeases struct {
+	mu     sync.Mutex
+	leases map[string]*resultLease
+}
+
+type resultLease struct {
+	id        string
+	buildRef  string
+	createdAt time.Time
+	expiresAt time.Time
+	refs      map[string]*worker.WorkerRef
+	timer     *time.Timer
+}
+
+func newResultLeases() *resultLeases {
+	return &resultLeases{
+		leases: map[string]*resultLease{},
+	}
+}
+
+func (l *resultLeases) add(buildRef string, ttl time.Duration, refs map[string]*worker.WorkerRef) string {
+	now := time.Now()
+	lease := &resultLease{
+		id:        identity.NewID(),
+		buildRef:  buildRef,
+		createdAt: now,
+		expiresAt: now.Add(ttl),
+		refs:      refs,
+	}
+	l.mu.Lock()
+	l.leases[lease.id] = lease
+	lease.timer = time.AfterFunc(ttl, func() {
+		l.expire(lease)
+	})
+	l.mu.Unlock()
+	return lease.id
+}
+
+func (l *resultLeases) renew(id string, ttl time.Duration) (*controlapi.ResultLease, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lease, ok := l.leases[id]
+	// a lease whose timer already fired is being released
+	if !ok || !lease.timer.Stop() {
+		return nil, grpcerrors.WrapCode(errors.Errorf("result lease %s not found", id), codes.NotFound)
+	}
+	lease.expiresAt = time.Now().Add(ttl)
+	lease.timer = time.AfterFunc(ttl, func() {
+		l.expire(lease)
+	})
+	return lease.toPB(), nil
+}
+
+func (l *resultLeases) release(id string) error {
+	l.mu.Lock()
+	lease, ok := l.leases[id]
+	if !ok || !lease.timer.Stop() {
+		l.mu.Unlock()
+		return grpcerrors.WrapCode(errors.Errorf("result lease %s not found", id), codes.NotFound)
+	}
+	delete(l.leases, id)
+	l.mu.Unlock()
+	return lease.releaseRefs(context.TODO())
+}
+
+func (l *resultLeases) list() []*controlapi.ResultLease {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]*controlapi.ResultLease, 0, len(l.leases))
+	for _, lease := range l.leases {
+		out = append(out, lease.toPB())
+	}
+	slices.SortFunc(out, func(a, b *controlapi.ResultLease) int {
+		return a.CreatedAt.AsTime().Compare(b.CreatedAt.AsTime())
+	})
+	return out
+}
+
+func (l *resultLeases) expire(lease *resultLease) {
+	l.mu.Lock()
+	if l.leases[lease.id] != lease {
+		l.mu.Unlock()
+		return
+	}
+	delete(l.leases, lease.id)
+	l.mu.Unlock()
+	if err := lease.releaseRefs(context.TODO()); err != nil {
+		bklog.L.Errorf("failed to release expired result lease %s: %v", lease.id, err)
+	}
+}
+
+// close releases all the leases
+func (l *resultLeases) close() {
+	l.mu.Lock()
+	leases := l.leases
+	l.leases = map[string]*resultLease{}
+	l.mu.Unlock()
+	for _, lease := range leases {
+		lease.timer.Stop()
+		if err := lease.releaseRefs(context.TODO()); err != nil {
+			bklog.L.Errorf("failed to release result lease %s: %v", lease.id, err)
+		}
+	}
+}
+
+func (lease *resultLease) releaseRefs(ctx context.Context) error {
+	var rerr error
+	for _, ref := range lease.refs {
+		if err := ref.Release(ctx); err != nil && rerr == nil {
+			rerr = err
+		}
+	}
+	return rerr
+}
+
+func (lease *resultLease) toPB() *controlapi.ResultLease {
+	pbLease := &controlapi.ResultLease{
+		ID:        lease.id,
+		Ref:       lease.buildRef,
+		CreatedAt: timestamppb.New(lease.createdAt),
+		ExpiresAt: timestamppb.New(lease.expiresAt),
+	}
+	for k, ref := range lease.refs {
+		pbLease.Records = append(pbLease.Records, &controlapi.ResultLeaseRecord{
+			Key:        k,
+			ID:         ref.ImmutableRef.ID(),
+			WorkerID:   ref.Worker.ID(),
+			SnapshotID: ref.ImmutableRef.GetSnapshotID(),
+		})
+	}
+	slices.SortFunc(pbLease.Records, func(a, b *controlapi.ResultLeaseRecord) int {
+		return cmp.Compare(a.Key, b.Key)
+	})
+	return pbLease
+}
+
+// leaseResult takes a lease on the refs of the result of a build. The refs
+// are extracted so that their snapshots can be used by external tools.
+func (s *Solver) leaseResult(ctx context.Context, buildRef, sessionID string, ttl time.Duration, res *result.Result[solver.CachedResult]) (string, error) {
+	refs := map[string]*worker.WorkerRef{}
+	release := func() {
+		for _, ref := range refs {
+			ref.Release(context.WithoutCancel(ctx))
+		}
+	}
+	add := func(k string, r solver.CachedResult) error {
+		if r == nil {
+			return nil
+		}
+		workerRef, ok := r.Sys().(*worker.WorkerRef)
+		if !ok {
+			return errors.Errorf("invalid reference: %T", r.Sys())
+		}
+		if workerRef.ImmutableRef == nil {
+			return nil
+		}
+		if err := workerRef.ImmutableRef.Extract(ctx, session.NewGroup(sessionID)); err != nil {
+			return err
+		}
+		refs[k] = &worker.WorkerRef{
+			ImmutableRef: workerRef.ImmutableRef.Clone(),
+			Worker:       workerRef.Worker,
+		}
+		return nil
+	}
+	if err := add("", res.Ref); err != nil {
+		release()
+		return "", err
+	}
+	for k, r := range res.Refs {
+		if err := add(k, r); err != nil {
+			release()
+			return "", err
+		}
+	}
+	return s.resultLeases.add(buildRef, ttl, refs), nil
+}
+
+// ListResultLeases returns the result leases taken by the clients
+func (s *Solver) ListResultLeases() []*controlapi.ResultLease {
+	return s.resultLeases.list()
+}
+
+// RenewResultLease extends a result lease to expire after ttl from now
+func (s *Solver) RenewResultLease(id string, ttl time.Duration) (*controlapi.ResultLease, error) {
+	if ttl <= 0 {
+		return nil, errors.Errorf("invalid result lease ttl %s", ttl)
+	}
+	return s.resultLeases.renew(id, ttl)
+}
+
+// ReleaseResultLease releases a result lease before it expires
+func (s *Solver) ReleaseResultLease(id string) error {
+	return s.resultLeases.release(id)
+}
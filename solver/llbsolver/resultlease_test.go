@@ -0,0 +1,98 @@
+package llbsolver
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/moby/buildkit/worker"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+type leaseTestRef struct {
+	cache.ImmutableRef
+	id       string
+	released atomic.Bool
+}
+
+func (r *leaseTestRef) ID() string            { return r.id }
+func (r *leaseTestRef) GetSnapshotID() string { return "snap-" + r.id }
+func (r *leaseTestRef) Release(context.Context) error {
+	r.released.Store(true)
+	return nil
+}
+
+type leaseTestWorker struct {
+	worker.Worker
+}
+
+func (*leaseTestWorker) ID() string { return "worker0" }
+
+func newLeaseTestRefs(ids ...string) (map[string]*worker.WorkerRef, []*leaseTestRef) {
+	refs := map[string]*worker.WorkerRef{}
+	var testRefs []*leaseTestRef
+	for i, id := range ids {
+		r := &leaseTestRef{id: id}
+		k := ""
+		if i > 0 {
+			k = "linux/" + id
+		}
+		refs[k] = &worker.WorkerRef{ImmutableRef: r, Worker: &leaseTestWorker{}}
+		testRefs = append(testRefs, r)
+	}
+	return refs, testRefs
+}
+
+func TestResultLeases(t *testing.T) {
+	l := newResultLeases()
+
+	refs, testRefs := newLeaseTestRefs("foo", "arm64")
+	id := l.add("build1", time.Hour, refs)
+
+	leases := l.list()
+	require.Len(t, leases, 1)
+	require.Equal(t, id, leases[0].ID)
+	require.Equal(t, "build1", leases[0].Ref)
+	require.Len(t, leases[0].Records, 2)
+	require.Equal(t, "", leases[0].Records[0].Key)
+	require.Equal(t, "foo", leases[0].Records[0].ID)
+	require.Equal(t, "snap-foo", leases[0].Records[0].SnapshotID)
+	require.Equal(t, "worker0", leases[0].Records[0].WorkerID)
+	require.Equal(t, "linux/arm64", leases[0].Records[1].Key)
+
+	expires := leases[0].ExpiresAt.AsTime()
+	renewed, err := l.renew(id, 2*time.Hour)
+	require.NoError(t, err)
+	require.True(t, renewed.ExpiresAt.AsTime().After(expires))
+
+	require.NoError(t, l.release(id))
+	require.True(t, testRefs[0].released.Load())
+	require.True(t, testRefs[1].released.Load())
+	require.Empty(t, l.list())
+
+	_, err = l.renew(id, time.Hour)
+	require.Equal(t, codes.NotFound, grpcerrors.Code(err))
+	err = l.release(id)
+	require.Equal(t, codes.NotFound, grpcerrors.Code(err))
+}
+
+func TestResultLeasesExpire(t *testing.T) {
+	l := newResultLeases()
+
+	refs, testRefs := newLeaseTestRefs("foo")
+	l.add("build1", 50*time.Millisecond, refs)
+	require.Eventually(t, func() bool {
+		return testRefs[0].released.Load()
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Empty(t, l.list())
+
+	refs, testRefs = newLeaseTestRefs("bar")
+	l.add("build2", time.Hour, refs)
+	l.close()
+	require.True(t, testRefs[0].released.Load())
+	require.Empty(t, l.list())
+}
@@ -0,0 +1,48 @@
+package llbsolver
+
+import (
+	"context"
+	"time"
+
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/result"
+	"github.com/moby/buildkit/source/sessionref"
+	"github.com/moby/buildkit/worker"
+	"github.com/pkg/errors"
+)
+
+// retainSessionRef retains the result of a build as name in the session ref
+// store of its worker until the session closes
+func (s *Solver) retainSessionRef(ctx context.Context, sessionID, name string, res *result.Result[solver.CachedResult]) error {
+	ref, err := res.SingleRef()
+	if err != nil {
+		return errors.Wrapf(err, "failed to retain session ref %s", name)
+	}
+	if ref == nil {
+		return errors.Errorf("failed to retain session ref %s: build returned an empty result", name)
+	}
+	workerRef, ok := ref.Sys().(*worker.WorkerRef)
+	if !ok {
+		return errors.Errorf("invalid reference: %T", ref.Sys())
+	}
+	if workerRef.ImmutableRef == nil {
+		return errors.Errorf("failed to retain session ref %s: build returned an empty result", name)
+	}
+	w, ok := workerRef.Worker.(interface {
+		SessionRefs() *sessionref.Store
+	})
+	if !ok {
+		return errors.Errorf("worker %s does not support session refs", workerRef.Worker.ID())
+	}
+
+	timeoutCtx, cancel := context.WithCancelCause(ctx)
+	timeoutCtx, _ = context.WithTimeoutCause(timeoutCtx, 5*time.Second, errors.WithStack(context.DeadlineExceeded)) //nolint:govet
+	defer func() { cancel(errors.WithStack(context.Canceled)) }()
+
+	caller, err := s.sm.Get(timeoutCtx, sessionID, false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retain session ref %s", name)
+	}
+	w.SessionRefs().Set(caller.Context(), sessionID, name, workerRef.ImmutableRef.Clone())
+	return nil
+}
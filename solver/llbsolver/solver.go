@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"maps"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,7 +18,9 @@ import (
 	cacheconfig "github.com/moby/buildkit/cache/config"
 	"github.com/moby/buildkit/cache/remotecache"
 	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/client/lockfile"
 	controlgateway "github.com/moby/buildkit/control/gateway"
+	"github.com/moby/buildkit/control/logsink"
 	"github.com/moby/buildkit/errdefs"
 	"github.com/moby/buildkit/executor/resources"
 	resourcestypes "github.com/moby/buildkit/executor/resources/types"
@@ -31,16 +34,20 @@ import (
 	"github.com/moby/buildkit/session"
 	sessionexporter "github.com/moby/buildkit/session/exporter"
 	"github.com/moby/buildkit/solver"
+	serrdefs "github.com/moby/buildkit/solver/errdefs"
 	"github.com/moby/buildkit/solver/llbsolver/provenance"
 	provenancetypes "github.com/moby/buildkit/solver/llbsolver/provenance/types"
 	"github.com/moby/buildkit/solver/result"
 	spb "github.com/moby/buildkit/sourcepolicy/pb"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/clientidentity"
 	"github.com/moby/buildkit/util/compression"
 	"github.com/moby/buildkit/util/entitlements"
 	"github.com/moby/buildkit/util/grpcerrors"
 	"github.com/moby/buildkit/util/leaseutil"
 	"github.com/moby/buildkit/util/progress"
+	"github.com/moby/buildkit/util/progress/logs"
+	"github.com/moby/buildkit/util/proxy"
 	"github.com/moby/buildkit/util/tracing"
 	"github.com/moby/buildkit/util/tracing/detect"
 	"github.com/moby/buildkit/worker"
@@ -54,18 +61,39 @@ import (
 const (
 	keyEntitlements = "llb.entitlements"
 	keySourcePolicy = "llb.sourcepolicy"
+	keyWorker       = "llb.worker"
 )
 
 type ExporterRequest struct {
 	Exporters             []exporter.ExporterInstance
 	CacheExporters        []RemoteCacheExporter
 	EnableSessionExporter bool
+	// Lockfile adds the lockfile of the resolved sources to the exporter
+	// response
+	Lockfile bool
+	// SessionRef retains the result of the build for the later builds of the
+	// session under this name
+	SessionRef string
+	// ResultLeaseTTL takes a lease on the result refs of the build for this
+	// duration
+	ResultLeaseTTL time.Duration
 }
 
 type RemoteCacheExporter struct {
 	remotecache.Exporter
 	solver.CacheExportMode
 	IgnoreError bool
+	// Backfill is set for the exporters writing to a tiered cache import.
+	// They only run if the import missed records found in a slower tier.
+	Backfill *CacheBackfill
+}
+
+// CacheBackfill is the tiered cache import written by a cache exporter
+type CacheBackfill struct {
+	Import frontend.CacheOptionsEntry
+	// ID identifies the exporter response, returned with the keys prefixed
+	// with "cache.backfill.<ID>."
+	ID string
 }
 
 // ResolveWorkerFunc returns default worker for the temporary default non-distributed use cases
@@ -73,15 +101,40 @@ type ResolveWorkerFunc func() (worker.Worker, error)
 
 // Opt defines options for new Solver.
 type Opt struct {
-	CacheManager     solver.CacheManager
-	CacheResolvers   map[string]remotecache.ResolveCacheImporterFunc
-	Entitlements     []string
-	Frontends        map[string]frontend.Frontend
-	GatewayForwarder *controlgateway.GatewayForwarder
-	SessionManager   *session.Manager
-	WorkerController *worker.Controller
-	HistoryQueue     *HistoryQueue
-	ResourceMonitor  *resources.Monitor
+	CacheManager         solver.CacheManager
+	CacheResolvers       map[string]remotecache.ResolveCacheImporterFunc
+	Entitlements         []string
+	IdentityEntitlements map[clientidentity.Identity][]string
+	IdentityQuotas       map[clientidentity.Identity]int64
+	Frontends            map[string]frontend.Frontend
+	GatewayForwarder     *controlgateway.GatewayForwarder
+	SessionManager       *session.Manager
+	WorkerController     *worker.Controller
+	HistoryQueue         *HistoryQueue
+	ResourceMonitor      *resources.Monitor
+	// SpeculativeExecution is the maximum number of vertices that are
+	// started before the build requests them because previous builds
+	// executed them. Zero disables speculative execution.
+	SpeculativeExecution int
+	// ConcurrencyLimits are the default limits of the number of operations
+	// of each type running at the same time
+	ConcurrencyLimits ConcurrencyLimits
+	// Retry is the policy for retrying the vertices that fail with a
+	// transient error
+	Retry solver.RetryPolicy
+	// StepLogLimits are the limits of the output of the steps sent to the
+	// clients. Clipped output is kept in the build history if the history
+	// queue has a spill dir.
+	StepLogLimits logs.Limits
+	// LogSinks receive the output of the steps of every build, whether a
+	// client is attached to the build or not
+	LogSinks []logsink.Sink
+	// ReadOnly solves the builds only from the cache. Ops and containers
+	// are not run.
+	ReadOnly bool
+	// DNSResolvers are the addresses of the nameservers the builds select
+	// by name with the dns-resolver frontend option for their exec ops
+	DNSResolvers map[string]string
 }
 
 type Solver struct {
@@ -93,9 +146,19 @@ type Solver struct {
 	resolveCacheImporterFuncs map[string]remotecache.ResolveCacheImporterFunc
 	gatewayForwarder          *controlgateway.GatewayForwarder
 	sm                        *session.Manager
+	entitlementsMu            sync.RWMutex
 	entitlements              []string
+	identityEntitlements      map[clientidentity.Identity][]string
+	identityQuotas            map[clientidentity.Identity]int64
 	history                   *HistoryQueue
 	sysSampler                *resources.Sampler[*resourcestypes.SysSample]
+	limiter                   *concurrencyLimiter
+	stepLogLimits             logs.Limits
+	resultLeases              *resultLeases
+	platformCancels           *platformCancels
+	logSinks                  []logsink.Sink
+	readOnly                  bool
+	dnsResolvers              map[string]string
 }
 
 // Processor defines a processing function to be applied after solving, but
@@ -112,7 +175,18 @@ func New(opt Opt) (*Solver, error) {
 		gatewayForwarder:          opt.GatewayForwarder,
 		sm:                        opt.SessionManager,
 		entitlements:              opt.Entitlements,
+		identityEntitlements:      opt.IdentityEntitlements,
+		identityQuotas:            opt.IdentityQuotas,
 		history:                   opt.HistoryQueue,
+		stepLogLimits:             opt.StepLogLimits,
+		resultLeases:              newResultLeases(),
+		platformCancels:           newPlatformCancels(),
+		logSinks:                  opt.LogSinks,
+		readOnly:                  opt.ReadOnly,
+		dnsResolvers:              opt.DNSResolvers,
+	}
+	if h := opt.HistoryQueue; h != nil && h.spill != nil {
+		s.stepLogLimits.Spill = h.spill
 	}
 
 	sampler, err := resources.NewSysSampler()
@@ -121,14 +195,30 @@ func New(opt Opt) (*Solver, error) {
 	}
 	s.sysSampler = sampler
 
-	s.solver = solver.NewSolver(solver.SolverOpt{
+	if err := opt.ConcurrencyLimits.validate(); err != nil {
+		return nil, err
+	}
+	s.limiter = newConcurrencyLimiter(opt.ConcurrencyLimits, nil)
+
+	sopt := solver.SolverOpt{
 		ResolveOpFunc: s.resolver(),
 		DefaultCache:  opt.CacheManager,
-	})
+		Acquire:       s.acquireOp,
+		Retry:         opt.Retry,
+		ReadOnly:      opt.ReadOnly,
+	}
+	if opt.SpeculativeExecution > 0 && opt.HistoryQueue != nil && !opt.ReadOnly {
+		sopt.Speculate = func(v solver.Vertex) bool {
+			return opt.HistoryQueue.LikelyExecuted(v.Digest())
+		}
+		sopt.SpeculativeExecution = opt.SpeculativeExecution
+	}
+	s.solver = solver.NewSolver(sopt)
 	return s, nil
 }
 
 func (s *Solver) Close() error {
+	s.resultLeases.close()
 	s.solver.Close()
 	if s.sysSampler != nil {
 		return s.sysSampler.Close()
@@ -138,7 +228,7 @@ func (s *Solver) Close() error {
 
 func (s *Solver) resolver() solver.ResolveOpFunc {
 	return func(v solver.Vertex, b solver.Builder) (solver.Op, error) {
-		w, err := s.resolveWorker()
+		w, err := s.builderWorker(b)
 		if err != nil {
 			return nil, err
 		}
@@ -146,15 +236,32 @@ func (s *Solver) resolver() solver.ResolveOpFunc {
 	}
 }
 
+// builderWorker returns the worker selected for the build. The vertices of
+// builds on different workers are not shared, see Job.SetVertexScope.
+func (s *Solver) builderWorker(b solver.Builder) (worker.Worker, error) {
+	var w worker.Worker
+	b.EachValue(context.TODO(), keyWorker, func(v any) error {
+		if w2, ok := v.(worker.Worker); ok && w == nil {
+			w = w2
+		}
+		return nil
+	})
+	if w != nil {
+		return w, nil
+	}
+	return s.resolveWorker()
+}
+
 func (s *Solver) bridge(b solver.Builder) *provenanceBridge {
 	return &provenanceBridge{llbBridge: &llbBridge{
 		builder:                   b,
 		frontends:                 s.frontends,
-		resolveWorker:             s.resolveWorker,
+		resolveWorker:             func() (worker.Worker, error) { return s.builderWorker(b) },
 		eachWorker:                s.eachWorker,
 		resolveCacheImporterFuncs: s.resolveCacheImporterFuncs,
 		cms:                       map[string]solver.CacheManager{},
 		sm:                        s.sm,
+		readOnly:                  s.readOnly,
 	}}
 }
 
@@ -163,6 +270,15 @@ func (s *Solver) Bridge(b solver.Builder) frontend.FrontendLLBBridge {
 }
 
 func (s *Solver) recordBuildHistory(ctx context.Context, id string, req frontend.SolveRequest, exp ExporterRequest, j *solver.Job, usage *resources.SysSampler) (func(context.Context, *Result, []exporter.DescriptorReference, error) error, error) {
+	timestampsMode := s.history.opt.CleanConfig.Timestamps
+	if v, ok := req.FrontendOpt[keyHistoryTimestamps]; ok {
+		timestampsMode = v
+	}
+	timestamps, err := ParseTimestamps(timestampsMode, req.FrontendOpt)
+	if err != nil {
+		return nil, err
+	}
+
 	stopTrace, err := detect.Recorder.Record(ctx)
 	if err != nil {
 		return nil, errdefs.Internal(err)
@@ -174,6 +290,12 @@ func (s *Solver) recordBuildHistory(ctx context.Context, id string, req frontend
 		FrontendAttrs: req.FrontendOpt,
 		CreatedAt:     timestamppb.Now(),
 	}
+	if ci := clientidentity.FromContext(ctx); ci != nil {
+		rec.ClientIdentity = &controlapi.ClientIdentity{
+			Name:   ci.Name,
+			Method: ci.Method,
+		}
+	}
 
 	for _, e := range exp.Exporters {
 		rec.Exporters = append(rec.Exporters, &controlapi.Exporter{
@@ -219,6 +341,7 @@ func (s *Solver) recordBuildHistory(ctx context.Context, id string, req frontend
 		attrs := map[string]string{
 			"mode":          "max",
 			"capture-usage": "true",
+			"timestamps":    timestampsMode,
 		}
 
 		// infer builder-id from user input if available
@@ -327,7 +450,7 @@ func (s *Solver) recordBuildHistory(ctx context.Context, id string, req frontend
 		}
 
 		eg.Go(func() error {
-			st, releaseStatus, err := s.history.ImportStatus(ctx2, ch)
+			st, releaseStatus, err := s.history.ImportStatus(ctx2, ch, timestamps)
 			if err != nil {
 				return err
 			}
@@ -342,6 +465,25 @@ func (s *Solver) recordBuildHistory(ctx context.Context, id string, req frontend
 			rec.NumCompletedSteps = int32(st.NumCompletedSteps)
 			rec.NumTotalSteps = int32(st.NumTotalSteps)
 			rec.NumWarnings = int32(st.NumWarnings)
+			rec.ResourceUsage = st.ResourceUsage.ToPB()
+			if len(st.CacheSources) > 0 || len(req.CacheImports) > 0 {
+				// the imports no step was loaded from are kept with a count
+				// of zero
+				rec.CacheSources = map[string]int32{}
+				for _, im := range req.CacheImports {
+					rec.CacheSources[cacheSourceName(im)] = 0
+				}
+				for src, n := range st.CacheSources {
+					rec.CacheSources[src] = int32(n)
+				}
+			}
+			if st.StepLogs != nil {
+				rec.StepLogs = &controlapi.Descriptor{
+					Digest:    string(st.StepLogs.Digest),
+					Size:      st.StepLogs.Size,
+					MediaType: st.StepLogs.MediaType,
+				}
+			}
 			mu.Unlock()
 			return nil
 		})
@@ -474,7 +616,7 @@ func (s *Solver) recordBuildHistory(ctx context.Context, id string, req frontend
 	}, nil
 }
 
-func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req frontend.SolveRequest, exp ExporterRequest, ent []entitlements.Entitlement, post []Processor, internal bool, srcPol *spb.Policy) (_ *client.SolveResponse, err error) {
+func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req frontend.SolveRequest, exp ExporterRequest, ent []entitlements.Entitlement, post []Processor, internal bool, srcPol *spb.Policy, limits *ConcurrencyLimits, proxyCfg *proxy.Config, w worker.Worker) (_ *client.SolveResponse, err error) {
 	j, err := s.solver.NewJob(id)
 	if err != nil {
 		return nil, err
@@ -482,6 +624,9 @@ func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req fro
 
 	defer j.Discard()
 
+	bp, unregister := s.platformCancels.register(id)
+	defer unregister()
+
 	var usage *resources.Sub[*resourcestypes.SysSample]
 	if s.sysSampler != nil {
 		usage = s.sysSampler.Record()
@@ -506,14 +651,30 @@ func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req fro
 
 	if internal {
 		defer j.CloseProgress()
+	} else if len(s.logSinks) > 0 {
+		ch := make(chan *client.SolveStatus)
+		go logsink.Forward(s.logSinks, id, ch)
+		go func() {
+			if err := j.Status(context.WithoutCancel(ctx), ch); err != nil {
+				bklog.G(ctx).Warnf("failed to read the logs of build %s for the log sinks: %v", id, err)
+			}
+		}()
 	}
 
-	set, err := entitlements.WhiteList(ent, supportedEntitlements(s.entitlements))
+	set, err := entitlements.WhiteList(ent, supportedEntitlements(s.allowedEntitlements(ctx)))
 	if err != nil {
 		return nil, err
 	}
 	j.SetValue(keyEntitlements, set)
 
+	if err := s.checkQuota(ctx); err != nil {
+		return nil, err
+	}
+	if ci := clientidentity.FromContext(ctx); ci != nil {
+		j.SetValue(keyClient, ci.Name)
+		ctx = cache.WithClient(ctx, ci.Name)
+	}
+
 	if srcPol != nil {
 		if err := validateSourcePolicy(srcPol); err != nil {
 			return nil, err
@@ -521,6 +682,44 @@ func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req fro
 		j.SetValue(keySourcePolicy, srcPol)
 	}
 
+	if limits != nil {
+		if err := limits.validate(); err != nil {
+			return nil, err
+		}
+		j.SetValue(keyConcurrencyLimits, newConcurrencyLimiter(*limits, s.limiter))
+	}
+	if ns, err := s.dnsResolver(req.FrontendOpt); err != nil {
+		return nil, err
+	} else if ns != "" {
+		j.SetValue(keyDNSResolver, ns)
+	}
+	if proxyCfg != nil {
+		if err := proxyCfg.Validate(); err != nil {
+			return nil, err
+		}
+		j.SetValue(keyProxy, proxyCfg)
+		ctx = proxy.WithConfig(ctx, proxyCfg)
+	}
+	// lazy blobs downloaded by the exporters are limited like image pulls
+	if cl, err := s.builderLimiter(ctx, j); err != nil {
+		return nil, err
+	} else if cl != nil {
+		ctx = withFetchLimiter(ctx, cl)
+	}
+
+	if w == nil {
+		if w, err = s.resolveWorker(); err != nil {
+			return nil, err
+		}
+	} else if dw, err := s.resolveWorker(); err != nil {
+		return nil, err
+	} else if dw.ID() != w.ID() {
+		// the vertices of the build run on its worker, so they can't be
+		// shared with the builds on other workers
+		j.SetVertexScope(w.ID())
+	}
+	j.SetValue(keyWorker, w)
+
 	j.SessionID = sessionID
 
 	br := s.bridge(j)
@@ -582,15 +781,7 @@ func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req fro
 		})
 	})
 
-	eg, ctx2 := errgroup.WithContext(ctx)
-	res.EachRef(func(ref solver.ResultProxy) error {
-		eg.Go(func() error {
-			_, err := ref.Result(ctx2)
-			return err
-		})
-		return nil
-	})
-	if err := eg.Wait(); err != nil {
+	if err := evaluateResult(ctx, res, bp); err != nil {
 		return nil, err
 	}
 
@@ -642,9 +833,12 @@ func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req fro
 	})
 
 	cacheExporters, inlineCacheExporter := splitCacheExporters(exp.CacheExporters)
+	cacheExporters = slices.DeleteFunc(cacheExporters, func(e RemoteCacheExporter) bool {
+		return e.Backfill != nil && !br.cacheTierMissed(e.Backfill.Import)
+	})
 
 	if exp.EnableSessionExporter {
-		exporters, err := s.getSessionExporters(ctx, j.SessionID, len(exp.Exporters), inp)
+		exporters, err := s.getSessionExporters(ctx, w, j.SessionID, len(exp.Exporters), inp)
 		if err != nil {
 			return nil, err
 		}
@@ -652,7 +846,8 @@ func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req fro
 	}
 
 	var exporterResponse map[string]string
-	exporterResponse, descrefs, err = s.runExporters(ctx, exp.Exporters, inlineCacheExporter, j, cached, inp)
+	var exporterResponses []client.ExporterResponse
+	exporterResponse, exporterResponses, descrefs, err = s.runExporters(ctx, exp.Exporters, inlineCacheExporter, j, cached, inp)
 	if err != nil {
 		return nil, err
 	}
@@ -676,13 +871,33 @@ func (s *Solver) Solve(ctx context.Context, id string, sessionID string, req fro
 			exporterResponse[k] = v
 		}
 	}
+	if exp.Lockfile {
+		dt, err := encodeLockfile(resProv.Provenance)
+		if err != nil {
+			return nil, err
+		}
+		exporterResponse[lockfile.ExporterResponseKey] = dt
+	}
+	if exp.ResultLeaseTTL > 0 {
+		leaseID, err := s.leaseResult(ctx, id, sessionID, exp.ResultLeaseTTL, cached)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to lease result")
+		}
+		exporterResponse[client.ExporterResponseResultLease] = leaseID
+	}
+	if exp.SessionRef != "" {
+		if err := s.retainSessionRef(ctx, sessionID, exp.SessionRef, cached); err != nil {
+			return nil, err
+		}
+	}
 
 	return &client.SolveResponse{
-		ExporterResponse: exporterResponse,
+		ExporterResponse:  exporterResponse,
+		ExporterResponses: exporterResponses,
 	}, nil
 }
 
-func (s *Solver) getSessionExporters(ctx context.Context, sessionID string, id int, inp *exporter.Source) ([]exporter.ExporterInstance, error) {
+func (s *Solver) getSessionExporters(ctx context.Context, w worker.Worker, sessionID string, id int, inp *exporter.Source) ([]exporter.ExporterInstance, error) {
 	timeoutCtx, cancel := context.WithCancelCause(ctx)
 	timeoutCtx, _ = context.WithTimeoutCause(timeoutCtx, 5*time.Second, errors.WithStack(context.DeadlineExceeded)) //nolint:govet
 	defer func() { cancel(errors.WithStack(context.Canceled)) }()
@@ -715,11 +930,6 @@ func (s *Solver) getSessionExporters(ctx context.Context, sessionID string, id i
 		}
 	}
 
-	w, err := defaultResolver(s.workerController)()
-	if err != nil {
-		return nil, err
-	}
-
 	var out []exporter.ExporterInstance
 	for i, req := range res.Exporters {
 		exp, err := w.Exporter(req.Type, s.sm)
@@ -795,10 +1005,18 @@ func runCacheExporters(ctx context.Context, exporters []RemoteCacheExporter, j *
 
 	// TODO: separate these out, and return multiple cache exporter responses
 	// to the client
-	for _, resp := range resps {
+	for i, resp := range resps {
 		if cacheExporterResponse == nil {
 			cacheExporterResponse = make(map[string]string)
 		}
+		if b := exporters[i].Backfill; b != nil {
+			for k, v := range resp {
+				if k, ok := strings.CutPrefix(k, "cache."); ok {
+					cacheExporterResponse["cache.backfill."+b.ID+"."+k] = v
+				}
+			}
+			continue
+		}
 		maps.Copy(cacheExporterResponse, resp)
 	}
 	return cacheExporterResponse, nil
@@ -823,10 +1041,10 @@ func runInlineCacheExporter(ctx context.Context, e exporter.ExporterInstance, in
 	return res, done(err)
 }
 
-func (s *Solver) runExporters(ctx context.Context, exporters []exporter.ExporterInstance, inlineCacheExporter inlineCacheExporter, job *solver.Job, cached *result.Result[solver.CachedResult], inp *exporter.Source) (exporterResponse map[string]string, descrefs []exporter.DescriptorReference, err error) {
+func (s *Solver) runExporters(ctx context.Context, exporters []exporter.ExporterInstance, inlineCacheExporter inlineCacheExporter, job *solver.Job, cached *result.Result[solver.CachedResult], inp *exporter.Source) (exporterResponse map[string]string, exporterResponses []client.ExporterResponse, descrefs []exporter.DescriptorReference, err error) {
 	warnings, err := verifier.CheckInvalidPlatforms(ctx, inp)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	eg, ctx := errgroup.WithContext(ctx)
@@ -855,14 +1073,14 @@ func (s *Solver) runExporters(ctx context.Context, exporters []exporter.Exporter
 
 				resps[i], descs[i], err = exp.Export(ctx, inp, inlineCache, job.SessionID)
 				if err != nil {
-					return err
+					return serrdefs.WithCode(err, serrdefs.CodeExporter)
 				}
 				return nil
 			})
 		})
 	}
 	if err := eg.Wait(); err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	if len(exporters) == 0 && len(warnings) > 0 {
@@ -874,22 +1092,27 @@ func (s *Solver) runExporters(ctx context.Context, exporters []exporter.Exporter
 			return pw.Close()
 		})
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
-	// TODO: separate these out, and return multiple exporter responses to the
-	// client
-	for _, resp := range resps {
+	// the responses of all exporters are also merged for the clients that
+	// don't read the response of each exporter
+	for i, resp := range resps {
 		for k, v := range resp {
 			if exporterResponse == nil {
 				exporterResponse = make(map[string]string)
 			}
 			exporterResponse[k] = v
 		}
+		exporterResponses = append(exporterResponses, client.ExporterResponse{
+			ID:   strconv.Itoa(exporters[i].ID()),
+			Type: exporters[i].Type(),
+			Data: resp,
+		})
 	}
 
-	return exporterResponse, descs, nil
+	return exporterResponse, exporterResponses, descs, nil
 }
 
 func (s *Solver) leaseManager() (*leaseutil.Manager, error) {
@@ -1175,6 +1398,25 @@ func notifyStarted(ctx context.Context, v *client.Vertex) func(err error) {
 	}
 }
 
+// SetEntitlements replaces the entitlements the builds are allowed to
+// request, for the builds that start after it returns
+func (s *Solver) SetEntitlements(ents []string, identityEntitlements map[clientidentity.Identity][]string) {
+	s.entitlementsMu.Lock()
+	defer s.entitlementsMu.Unlock()
+	s.entitlements = ents
+	s.identityEntitlements = identityEntitlements
+}
+
+func (s *Solver) allowedEntitlements(ctx context.Context) []string {
+	s.entitlementsMu.RLock()
+	defer s.entitlementsMu.RUnlock()
+	id := clientidentity.FromContext(ctx)
+	if id == nil || len(s.identityEntitlements[*id]) == 0 {
+		return s.entitlements
+	}
+	return append(slices.Clone(s.entitlements), s.identityEntitlements[*id]...)
+}
+
 func supportedEntitlements(ents []string) []entitlements.Entitlement {
 	out := []entitlements.Entitlement{} // nil means no filter
 	for _, e := range ents {
@@ -1187,6 +1429,12 @@ func supportedEntitlements(ents []string) []entitlements.Entitlement {
 		if e == string(entitlements.EntitlementDevice) {
 			out = append(out, entitlements.EntitlementDevice)
 		}
+		if e == string(entitlements.EntitlementLocalExec) {
+			out = append(out, entitlements.EntitlementLocalExec)
+		}
+		if e == string(entitlements.EntitlementMountHost) {
+			out = append(out, entitlements.EntitlementMountHost)
+		}
 	}
 	return out
 }
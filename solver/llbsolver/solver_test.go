@@ -0,0 +1,43 @@
+package llbsolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/util/clientidentity"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllowedEntitlements(t *testing.T) {
+	s := &Solver{
+		entitlements: []string{"device"},
+		identityEntitlements: map[clientidentity.Identity][]string{
+			{Name: "ci-runner", Method: clientidentity.MethodTLS}: {"network.host"},
+		},
+	}
+
+	ctx := context.TODO()
+	require.Equal(t, []string{"device"}, s.allowedEntitlements(ctx))
+
+	tlsCtx := clientidentity.WithIdentity(ctx, &clientidentity.Identity{Name: "ci-runner", Method: clientidentity.MethodTLS})
+	require.Equal(t, []string{"device", "network.host"}, s.allowedEntitlements(tlsCtx))
+
+	// an identity with the same name from another authentication method
+	// doesn't get the entitlements
+	oidcCtx := clientidentity.WithIdentity(ctx, &clientidentity.Identity{Name: "ci-runner", Method: "oidc"})
+	require.Equal(t, []string{"device"}, s.allowedEntitlements(oidcCtx))
+}
+
+func TestClientUsage(t *testing.T) {
+	du := []*client.UsageInfo{
+		{ID: "a", Client: "team-a", Size: 100},
+		{ID: "b", Client: "team-a", Size: 50},
+		{ID: "c", Client: "team-b", Size: 1000},
+		{ID: "d", Client: "team-a", Size: -1},
+		{ID: "e", Size: 10},
+	}
+	require.Equal(t, int64(150), clientUsage(du, "team-a"))
+	require.Equal(t, int64(1000), clientUsage(du, "team-b"))
+	require.Equal(t, int64(0), clientUsage(du, "team-c"))
+}
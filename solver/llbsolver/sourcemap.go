@@ -0,0 +1,181 @@
+package llbsolver
+
+import (
+	"slices"
+
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// sourceLocations returns the source locations of the vertices of edge,
+// loaded from def, by their digests. The digests of the vertices differ from
+// the digests of the ops of def once the source policies are applied, so the
+// vertices are matched with the ops by walking both graphs.
+//
+// The vertices of the ops without locations are traced back to the
+// instructions they were created for: merge and diff ops to the locations of
+// the ops they transform, and the other ops to the locations of the ops that
+// use them, like the inline files copied by an instruction. Ops used by
+// several instructions, like the build context, are not traced to any of them.
+func sourceLocations(def *pb.Definition, edge solver.Edge) (map[digest.Digest][]*pb.Location, error) {
+	if def.Source == nil || len(def.Source.Locations) == 0 || edge.Vertex == nil || len(def.Def) == 0 {
+		return nil, nil
+	}
+
+	ops := make(map[digest.Digest]*pb.Op, len(def.Def))
+	var last *pb.Op
+	for _, dt := range def.Def {
+		var op pb.Op
+		if err := op.UnmarshalVT(dt); err != nil {
+			return nil, errors.Wrap(err, "failed to parse llb proto op")
+		}
+		ops[digest.FromBytes(dt)] = &op
+		last = &op
+	}
+	if len(last.Inputs) == 0 {
+		return nil, nil
+	}
+
+	sl := &sourceLocator{
+		def:       def,
+		ops:       ops,
+		consumers: map[digest.Digest][]digest.Digest{},
+		composed:  map[digest.Digest][]*pb.Location{},
+		visiting:  map[digest.Digest]bool{},
+	}
+	for dgst, op := range ops {
+		for _, in := range op.Inputs {
+			sl.consumers[digest.Digest(in.Digest)] = append(sl.consumers[digest.Digest(in.Digest)], dgst)
+		}
+	}
+
+	// the vertex digests of the op digests
+	vertices := map[digest.Digest]digest.Digest{}
+	var match func(dgst digest.Digest, v solver.Vertex)
+	match = func(dgst digest.Digest, v solver.Vertex) {
+		if _, ok := vertices[v.Digest()]; ok {
+			return
+		}
+		vertices[v.Digest()] = dgst
+		op, ok := ops[dgst]
+		if !ok || len(op.Inputs) != len(v.Inputs()) {
+			return
+		}
+		for i, in := range v.Inputs() {
+			match(digest.Digest(op.Inputs[i].Digest), in.Vertex)
+		}
+	}
+	match(digest.Digest(last.Inputs[0].Digest), edge.Vertex)
+
+	locs := make(map[digest.Digest][]*pb.Location, len(vertices))
+	for vdgst, dgst := range vertices {
+		if l := sl.locations(dgst); len(l) > 0 {
+			locs[vdgst] = l
+		}
+	}
+	return locs, nil
+}
+
+type sourceLocator struct {
+	def       *pb.Definition
+	ops       map[digest.Digest]*pb.Op
+	consumers map[digest.Digest][]digest.Digest
+	composed  map[digest.Digest][]*pb.Location
+	visiting  map[digest.Digest]bool
+}
+
+func (sl *sourceLocator) own(dgst digest.Digest) []*pb.Location {
+	if l, ok := sl.def.Source.Locations[string(dgst)]; ok {
+		return l.Locations
+	}
+	return nil
+}
+
+// locations returns the locations of the op, or the composed locations of
+// the ops it was created from or for if it has none
+func (sl *sourceLocator) locations(dgst digest.Digest) []*pb.Location {
+	if l := sl.own(dgst); len(l) > 0 {
+		return l
+	}
+	if l, ok := sl.composed[dgst]; ok {
+		return l
+	}
+	if sl.visiting[dgst] {
+		return nil
+	}
+	sl.visiting[dgst] = true
+	defer delete(sl.visiting, dgst)
+
+	var out []*pb.Location
+	op := sl.ops[dgst]
+	switch {
+	case op == nil:
+	case op.GetMerge() != nil:
+		for _, in := range op.Inputs {
+			out = appendLocations(out, sl.locations(digest.Digest(in.Digest))...)
+		}
+	case op.GetDiff() != nil:
+		// the changes of a diff are made by its upper input
+		if upper := op.GetDiff().Upper; upper != nil && upper.Input >= 0 && int(upper.Input) < len(op.Inputs) {
+			out = sl.locations(digest.Digest(op.Inputs[upper.Input].Digest))
+		}
+	}
+	if len(out) == 0 {
+		out = sl.sharedConsumerLocations(dgst)
+	}
+	sl.composed[dgst] = out
+	return out
+}
+
+// sharedConsumerLocations returns the locations of the ops using an op if
+// they all have the same locations
+func (sl *sourceLocator) sharedConsumerLocations(dgst digest.Digest) []*pb.Location {
+	var out []*pb.Location
+	for i, c := range sl.consumers[dgst] {
+		l := sl.consumerLocations(c)
+		if len(l) == 0 || (i > 0 && !sameLocations(out, l)) {
+			return nil
+		}
+		out = l
+	}
+	return out
+}
+
+// consumerLocations returns the locations of an op using another op that
+// has no locations. The locations of merge and diff ops without locations
+// are the locations of the ops using them.
+func (sl *sourceLocator) consumerLocations(dgst digest.Digest) []*pb.Location {
+	if l := sl.own(dgst); len(l) > 0 {
+		return l
+	}
+	if sl.visiting[dgst] {
+		return nil
+	}
+	sl.visiting[dgst] = true
+	defer delete(sl.visiting, dgst)
+
+	return sl.sharedConsumerLocations(dgst)
+}
+
+func sameLocations(a, b []*pb.Location) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, l := range b {
+		if !slices.ContainsFunc(a, l.EqualVT) {
+			return false
+		}
+	}
+	return true
+}
+
+func appendLocations(locs []*pb.Location, add ...*pb.Location) []*pb.Location {
+	for _, l := range add {
+		if !slices.ContainsFunc(locs, l.EqualVT) {
+			locs = append(locs, l)
+		}
+	}
+	return locs
+}
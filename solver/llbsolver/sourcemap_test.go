@@ -0,0 +1,92 @@
+package llbsolver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/sourcepolicy"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceLocations(t *testing.T) {
+	ctx := context.TODO()
+	sm := llb.NewSourceMap(nil, "Dockerfile", "Dockerfile", []byte("FROM alpine\nCOPY <<EOF /f\n"))
+	line := func(l int32) []*pb.Range {
+		return []*pb.Range{{Start: &pb.Position{Line: l}, End: &pb.Position{Line: l}}}
+	}
+
+	base := llb.Image("alpine", sm.Location(line(1)))
+	inline := llb.Scratch().File(llb.Mkfile("/f", 0644, []byte("data")))
+	cp := base.File(llb.Copy(inline, "/f", "/f"), sm.Location(line(2)))
+	st := llb.Merge([]llb.State{base, llb.Diff(base, cp)})
+	def, err := st.Marshal(ctx)
+	require.NoError(t, err)
+
+	// the source policy changes the digests of the vertices
+	pol := sourcepolicy.NewEngine([]*spb.Policy{{
+		Rules: []*spb.Rule{{
+			Action:   spb.PolicyAction_CONVERT,
+			Selector: &spb.Selector{Identifier: "docker-image://docker.io/library/alpine:latest"},
+			Updates:  &spb.Update{Identifier: "docker-image://docker.io/library/alpine:3.20"},
+		}},
+	}})
+	edge, err := Load(ctx, def.ToPB(), pol)
+	require.NoError(t, err)
+	locs, err := sourceLocations(def.ToPB(), edge)
+	require.NoError(t, err)
+
+	merge := edge.Vertex
+	require.NotContains(t, def.ToPB().Source.Locations, string(merge.Digest()))
+	imgV, diffV := merge.Inputs()[0].Vertex, merge.Inputs()[1].Vertex
+	copyV := diffV.Inputs()[1].Vertex
+	inlineV := copyV.Inputs()[1].Vertex
+	require.NotContains(t, def.ToPB().Source.Locations, string(imgV.Digest()))
+
+	lines := func(v interface{ Digest() digest.Digest }) []int32 {
+		var out []int32
+		for _, l := range locs[v.Digest()] {
+			out = append(out, l.Ranges[0].Start.Line)
+		}
+		return out
+	}
+	require.Equal(t, []int32{1}, lines(imgV))
+	require.Equal(t, []int32{2}, lines(copyV))
+	// the inline file is traced to the COPY using it
+	require.Equal(t, []int32{2}, lines(inlineV))
+	// the diff to its upper input, the merge to all its inputs
+	require.Equal(t, []int32{2}, lines(diffV))
+	require.Equal(t, []int32{1, 2}, lines(merge))
+
+	// the context copied by two instructions is not traced to either of them
+	sm = llb.NewSourceMap(nil, "Dockerfile", "Dockerfile", []byte("FROM scratch\nCOPY a /a\nCOPY b /b\n"))
+	local := llb.Local("context")
+	st = llb.Scratch().
+		File(llb.Copy(local, "a", "/a"), sm.Location(line(2))).
+		File(llb.Copy(local, "b", "/b"), sm.Location(line(3)))
+	def, err = st.Marshal(ctx)
+	require.NoError(t, err)
+	edge, err = Load(ctx, def.ToPB(), nil)
+	require.NoError(t, err)
+	locs, err = sourceLocations(def.ToPB(), edge)
+	require.NoError(t, err)
+
+	copyB := edge.Vertex
+	copyA, localV := copyB.Inputs()[0].Vertex, copyB.Inputs()[1].Vertex
+	require.Equal(t, localV.Digest(), copyA.Inputs()[0].Vertex.Digest())
+	require.Equal(t, []int32{3}, lines(copyB))
+	require.Equal(t, []int32{2}, lines(copyA))
+	require.Empty(t, lines(localV))
+
+	// definitions without source maps
+	def, err = llb.Image("alpine").Marshal(ctx)
+	require.NoError(t, err)
+	edge, err = Load(ctx, def.ToPB(), nil)
+	require.NoError(t, err)
+	locs, err = sourceLocations(def.ToPB(), edge)
+	require.NoError(t, err)
+	require.Empty(t, locs)
+}
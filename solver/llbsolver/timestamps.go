@@ -0,0 +1,113 @@
+package llbsolver
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/exporter/util/epoch"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TimestampsKeep keeps the timestamps of the build
+	TimestampsKeep = "keep"
+	// TimestampsStrip sets the timestamps of the logs to the Unix epoch and
+	// removes the build timestamps from the provenance
+	TimestampsStrip = "strip"
+	// TimestampsSourceDateEpoch sets all timestamps to SOURCE_DATE_EPOCH
+	TimestampsSourceDateEpoch = "source-date-epoch"
+)
+
+const keyHistoryTimestamps = "build-arg:BUILDKIT_HISTORY_TIMESTAMPS"
+
+// Timestamps normalizes the timestamps of the logs kept in the build history
+// and of the provenance so that they can be compared across builds. A nil
+// Timestamps keeps them.
+type Timestamps struct {
+	mode  string
+	epoch time.Time
+}
+
+// ParseTimestamps returns the normalization of the timestamps for mode.
+// SOURCE_DATE_EPOCH is read from the build args of the frontend attributes.
+func ParseTimestamps(mode string, frontendAttrs map[string]string) (*Timestamps, error) {
+	switch mode {
+	case "", TimestampsKeep:
+		return nil, nil
+	case TimestampsStrip:
+		return &Timestamps{mode: mode, epoch: time.Unix(0, 0).UTC()}, nil
+	case TimestampsSourceDateEpoch:
+		v, ok := epoch.ParseBuildArgs(frontendAttrs)
+		if !ok || v == "" {
+			return nil, errors.Errorf("%s timestamps require SOURCE_DATE_EPOCH to be set", mode)
+		}
+		sde, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid SOURCE_DATE_EPOCH: %s", v)
+		}
+		return &Timestamps{mode: mode, epoch: time.Unix(sde, 0).UTC()}, nil
+	default:
+		return nil, errors.Errorf("invalid timestamps mode %q, expected %s, %s or %s", mode, TimestampsKeep, TimestampsStrip, TimestampsSourceDateEpoch)
+	}
+}
+
+// Time returns the normalized value of tm
+func (ts *Timestamps) Time(tm time.Time) time.Time {
+	if ts == nil {
+		return tm
+	}
+	return ts.epoch
+}
+
+func (ts *Timestamps) timePtr(tm *time.Time) *time.Time {
+	if ts == nil || tm == nil {
+		return tm
+	}
+	out := ts.epoch
+	return &out
+}
+
+// buildTime returns the normalized build start or completion time for the
+// provenance, nil if it is stripped
+func (ts *Timestamps) buildTime(tm time.Time) *time.Time {
+	if ts != nil && ts.mode == TimestampsStrip {
+		return nil
+	}
+	tm = ts.Time(tm)
+	return &tm
+}
+
+// Status returns a copy of st with normalized timestamps. The timestamps that
+// are not set are kept unset as they mark the steps that did not start or
+// complete.
+func (ts *Timestamps) Status(st *client.SolveStatus) *client.SolveStatus {
+	if ts == nil || st == nil {
+		return st
+	}
+	out := &client.SolveStatus{
+		Vertexes: make([]*client.Vertex, len(st.Vertexes)),
+		Statuses: make([]*client.VertexStatus, len(st.Statuses)),
+		Logs:     make([]*client.VertexLog, len(st.Logs)),
+		Warnings: st.Warnings,
+	}
+	for i, v := range st.Vertexes {
+		v2 := *v
+		v2.Started = ts.timePtr(v.Started)
+		v2.Completed = ts.timePtr(v.Completed)
+		out.Vertexes[i] = &v2
+	}
+	for i, s := range st.Statuses {
+		s2 := *s
+		s2.Timestamp = ts.Time(s.Timestamp)
+		s2.Started = ts.timePtr(s.Started)
+		s2.Completed = ts.timePtr(s.Completed)
+		out.Statuses[i] = &s2
+	}
+	for i, l := range st.Logs {
+		l2 := *l
+		l2.Timestamp = ts.Time(l.Timestamp)
+		out.Logs[i] = &l2
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package llbsolver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimestamps(t *testing.T) {
+	ts, err := ParseTimestamps("", nil)
+	require.NoError(t, err)
+	require.Nil(t, ts)
+
+	ts, err = ParseTimestamps(TimestampsKeep, nil)
+	require.NoError(t, err)
+	require.Nil(t, ts)
+
+	_, err = ParseTimestamps("wall", nil)
+	require.ErrorContains(t, err, `invalid timestamps mode "wall"`)
+
+	_, err = ParseTimestamps(TimestampsSourceDateEpoch, nil)
+	require.ErrorContains(t, err, "require SOURCE_DATE_EPOCH")
+
+	_, err = ParseTimestamps(TimestampsSourceDateEpoch, map[string]string{"build-arg:SOURCE_DATE_EPOCH": "yesterday"})
+	require.ErrorContains(t, err, "invalid SOURCE_DATE_EPOCH")
+
+	ts, err = ParseTimestamps(TimestampsSourceDateEpoch, map[string]string{"build-arg:SOURCE_DATE_EPOCH": "1700000000"})
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), ts.Time(time.Now()))
+	require.Equal(t, time.Unix(1700000000, 0).UTC(), *ts.buildTime(time.Now()))
+
+	ts, err = ParseTimestamps(TimestampsStrip, nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(0, 0).UTC(), ts.Time(time.Now()))
+	require.Nil(t, ts.buildTime(time.Now()))
+}
+
+func TestTimestampsStatus(t *testing.T) {
+	now := time.Now()
+	dgst := digest.FromString("vertex")
+	st := &client.SolveStatus{
+		Vertexes: []*client.Vertex{{Digest: dgst, Started: &now}},
+		Statuses: []*client.VertexStatus{{ID: "pull", Vertex: dgst, Timestamp: now, Started: &now, Completed: &now}},
+		Logs:     []*client.VertexLog{{Vertex: dgst, Timestamp: now, Data: []byte("log")}},
+	}
+
+	var ts *Timestamps
+	require.Same(t, st, ts.Status(st))
+
+	ts, err := ParseTimestamps(TimestampsStrip, nil)
+	require.NoError(t, err)
+	out := ts.Status(st)
+	zero := time.Unix(0, 0).UTC()
+
+	require.Equal(t, zero, *out.Vertexes[0].Started)
+	require.Nil(t, out.Vertexes[0].Completed)
+	require.Equal(t, zero, out.Statuses[0].Timestamp)
+	require.Equal(t, zero, *out.Statuses[0].Started)
+	require.Equal(t, zero, *out.Statuses[0].Completed)
+	require.Equal(t, zero, out.Logs[0].Timestamp)
+	require.Equal(t, []byte("log"), out.Logs[0].Data)
+
+	// the original status is not modified
+	require.Equal(t, now, *st.Vertexes[0].Started)
+	require.Equal(t, now, st.Logs[0].Timestamp)
+}
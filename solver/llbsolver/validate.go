@@ -0,0 +1,217 @@
+package llbsolver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/containerd/platforms"
+	"github.com/moby/buildkit/frontend"
+	"github.com/moby/buildkit/identity"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/errdefs"
+	"github.com/moby/buildkit/solver/llbsolver/ops/opsutils"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/sourcepolicy"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	"github.com/moby/buildkit/util/entitlements"
+	"github.com/moby/buildkit/util/proxy"
+	"github.com/moby/buildkit/worker"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// The checks of the diagnostics of a solve request
+const (
+	CheckRequest      = "request"
+	CheckWorker       = "worker"
+	CheckQuota        = "quota"
+	CheckFrontend     = "frontend"
+	CheckLLB          = "llb"
+	CheckEntitlements = "entitlements"
+	CheckSourcePolicy = "source-policy"
+	CheckEmulators    = "emulators"
+	CheckExporter     = "exporter"
+	CheckCacheExport  = "cache-export"
+	CheckCacheImport  = "cache-import"
+)
+
+// Diagnostic is a problem found by validating a solve request
+type Diagnostic struct {
+	Check   string
+	Message string
+	// Warning is set for the problems that don't fail the build
+	Warning bool
+	// Vertex is the digest of the op of the definition with the problem
+	Vertex digest.Digest
+}
+
+// Validate checks a solve request the way Solve does before building it,
+// without solving any vertex or running the frontend: the entitlements,
+// source policy, concurrency limits, DNS resolver, proxy and quota of the
+// build, the frontend, the ops of the definitions and the emulators of their
+// platforms, and the cache imports. It returns all the problems found instead of the
+// first one.
+func (s *Solver) Validate(ctx context.Context, sessionID string, req frontend.SolveRequest, ent []entitlements.Entitlement, srcPol *spb.Policy, limits *ConcurrencyLimits, proxyCfg *proxy.Config, w worker.Worker) ([]Diagnostic, error) {
+	j, err := s.solver.NewJob(identity.NewID())
+	if err != nil {
+		return nil, err
+	}
+	defer j.Discard()
+	j.SessionID = sessionID
+
+	var diags []Diagnostic
+	add := func(check string, err error) {
+		diags = append(diags, Diagnostic{Check: check, Message: err.Error()})
+	}
+
+	set, err := entitlements.WhiteList(ent, supportedEntitlements(s.allowedEntitlements(ctx)))
+	if err != nil {
+		add(CheckEntitlements, err)
+		set = entitlements.Set{}
+	}
+	j.SetValue(keyEntitlements, set)
+
+	var polEngine SourcePolicyEvaluator
+	if srcPol != nil {
+		if err := validateSourcePolicy(srcPol); err != nil {
+			add(CheckSourcePolicy, err)
+		} else {
+			j.SetValue(keySourcePolicy, srcPol)
+			polEngine = sourcepolicy.NewEngine([]*spb.Policy{srcPol})
+		}
+	}
+
+	if limits != nil {
+		if err := limits.validate(); err != nil {
+			add(CheckRequest, err)
+		}
+	}
+	if _, err := s.dnsResolver(req.FrontendOpt); err != nil {
+		add(CheckRequest, err)
+	}
+	if proxyCfg != nil {
+		if err := proxyCfg.Validate(); err != nil {
+			add(CheckRequest, err)
+		} else {
+			ctx = proxy.WithConfig(ctx, proxyCfg)
+		}
+	}
+	if err := s.checkQuota(ctx); err != nil {
+		add(CheckQuota, err)
+	}
+
+	if w == nil {
+		if w, err = s.resolveWorker(); err != nil {
+			return nil, err
+		}
+	}
+	j.SetValue(keyWorker, w)
+
+	switch {
+	case req.Definition != nil && req.Frontend != "":
+		add(CheckFrontend, errors.New("cannot solve with both Definition and Frontend specified"))
+	case req.Frontend != "":
+		f, ok := s.frontends[req.Frontend]
+		if !ok {
+			add(CheckFrontend, errors.Errorf("invalid frontend: %s", req.Frontend))
+		} else if v, ok := f.(frontend.Validator); ok {
+			if err := v.Validate(ctx, s.bridge(j), req.FrontendOpt, sessionID); err != nil {
+				add(CheckFrontend, err)
+			}
+		}
+	}
+
+	if req.Definition != nil {
+		diags = append(diags, validateDefinition(ctx, req.Definition, polEngine, set, w)...)
+	}
+	for _, name := range slices.Sorted(maps.Keys(req.FrontendInputs)) {
+		for _, d := range validateDefinition(ctx, req.FrontendInputs[name], polEngine, set, w) {
+			d.Message = fmt.Sprintf("frontend input %s: %s", name, d.Message)
+			diags = append(diags, d)
+		}
+	}
+
+	// the builds continue without the cache they fail to import
+	g := session.NewGroup(sessionID)
+	for _, im := range req.CacheImports {
+		if _, _, err := parseCacheTier(im); err != nil {
+			diags = append(diags, Diagnostic{Check: CheckCacheImport, Message: err.Error(), Warning: true})
+			continue
+		}
+		resolveCI, ok := s.resolveCacheImporterFuncs[im.Type]
+		if !ok {
+			diags = append(diags, Diagnostic{Check: CheckCacheImport, Message: fmt.Sprintf("unknown cache importer: %s", im.Type), Warning: true})
+			continue
+		}
+		if _, _, err := resolveCI(ctx, g, im.Attrs); err != nil {
+			diags = append(diags, Diagnostic{Check: CheckCacheImport, Message: errors.Wrapf(err, "failed to configure %v cache importer", im.Type).Error(), Warning: true})
+		}
+	}
+	return diags, nil
+}
+
+// validateDefinition checks the ops of a definition one by one, and the
+// definition as a whole if none of its ops has a problem
+func validateDefinition(ctx context.Context, def *pb.Definition, polEngine SourcePolicyEvaluator, ent entitlements.Set, w worker.Worker) []Diagnostic {
+	var diags []Diagnostic
+	add := func(check string, err error, dgst digest.Digest) {
+		diags = append(diags, Diagnostic{Check: check, Message: err.Error(), Vertex: dgst})
+	}
+
+	validateCaps := WithValidateCaps()
+	validateEntitlements := ValidateEntitlements(ent, w.CDIManager())
+	for _, dt := range def.Def {
+		var pbop pb.Op
+		if err := pbop.Unmarshal(dt); err != nil {
+			add(CheckLLB, errors.Wrap(err, "failed to parse llb proto op"), "")
+			continue
+		}
+		dgst := digest.FromBytes(dt)
+		if pbop.Op == nil {
+			// the last op only references the result
+			continue
+		}
+		md := def.Metadata[string(dgst)]
+		if polEngine != nil {
+			if _, err := polEngine.Evaluate(ctx, pbop.GetSource()); err != nil {
+				add(CheckSourcePolicy, err, dgst)
+				continue
+			}
+		}
+		if err := opsutils.Validate(&pbop); err != nil {
+			add(CheckLLB, err, dgst)
+			continue
+		}
+		if err := validateCaps(&pbop, md, &solver.VertexOptions{}); err != nil {
+			add(CheckLLB, err, dgst)
+		}
+		if err := validateEntitlements(&pbop, md, &solver.VertexOptions{}); err != nil {
+			add(CheckEntitlements, err, dgst)
+		}
+	}
+	if len(diags) > 0 {
+		return diags
+	}
+
+	edge, err := Load(ctx, def, polEngine, NormalizeRuntimePlatforms())
+	if err != nil {
+		add(CheckLLB, errors.Wrap(err, "failed to load LLB"), "")
+		return diags
+	}
+	if err := checkEmulators(edge, platforms.Normalize(platforms.DefaultSpec()), w.Emulators); err != nil {
+		var me *errdefs.MissingEmulatorsError
+		if !errors.As(err, &me) {
+			add(CheckEmulators, err, "")
+			return diags
+		}
+		for _, m := range me.Emulators {
+			for _, v := range m.Vertices {
+				add(CheckEmulators, errors.Errorf("no emulator available for %s", platforms.FormatAll(m.Platform.Spec())), digest.Digest(v.Digest))
+			}
+		}
+	}
+	return diags
+}
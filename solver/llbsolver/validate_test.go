@@ -0,0 +1,88 @@
+package llbsolver
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/moby/buildkit/client/llb"
+	"github.com/moby/buildkit/solver/llbsolver/cdidevices"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/sourcepolicy"
+	spb "github.com/moby/buildkit/sourcepolicy/pb"
+	"github.com/moby/buildkit/util/archutil"
+	"github.com/moby/buildkit/util/entitlements"
+	"github.com/moby/buildkit/worker"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+type validateTestWorker struct {
+	worker.Worker
+}
+
+func (w *validateTestWorker) CDIManager() *cdidevices.Manager {
+	return nil
+}
+
+func (w *validateTestWorker) Emulators() []archutil.Emulator {
+	return nil
+}
+
+func TestValidateDefinition(t *testing.T) {
+	ctx := context.TODO()
+	w := &validateTestWorker{}
+
+	marshal := func(st llb.State, opts ...llb.ConstraintsOpt) (*pb.Definition, digest.Digest) {
+		def, err := st.Marshal(ctx, opts...)
+		require.NoError(t, err)
+		pbDef := def.ToPB()
+		var execDgst digest.Digest
+		for _, dt := range pbDef.Def {
+			var op pb.Op
+			require.NoError(t, op.Unmarshal(dt))
+			if op.GetExec() != nil {
+				execDgst = digest.FromBytes(dt)
+			}
+		}
+		return pbDef, execDgst
+	}
+
+	hostNet := llb.Image("alpine").Run(llb.Shlex("true"), llb.Network(llb.NetModeHost)).Root()
+	def, execDgst := marshal(hostNet)
+	diags := validateDefinition(ctx, def, nil, entitlements.Set{}, w)
+	require.Equal(t, []Diagnostic{{
+		Check:   CheckEntitlements,
+		Message: "network.host is not allowed",
+		Vertex:  execDgst,
+	}}, diags)
+
+	set, err := entitlements.WhiteList([]entitlements.Entitlement{entitlements.EntitlementNetworkHost}, nil)
+	require.NoError(t, err)
+	require.Empty(t, validateDefinition(ctx, def, nil, set, w))
+
+	pol := sourcepolicy.NewEngine([]*spb.Policy{{
+		Rules: []*spb.Rule{{
+			Action:   spb.PolicyAction_DENY,
+			Selector: &spb.Selector{Identifier: "docker-image://docker.io/library/alpine:latest"},
+		}},
+	}})
+	diags = validateDefinition(ctx, def, pol, set, w)
+	require.Len(t, diags, 1)
+	require.Equal(t, CheckSourcePolicy, diags[0].Check)
+	require.NotEmpty(t, diags[0].Vertex)
+
+	arch := "riscv64"
+	if runtime.GOARCH == arch {
+		arch = "s390x"
+	}
+	emulated := llb.Image("alpine").Run(llb.Shlex("true")).Root()
+	def, execDgst = marshal(emulated, llb.Platform(ocispecs.Platform{OS: runtime.GOOS, Architecture: arch}))
+	diags = validateDefinition(ctx, def, nil, set, w)
+	require.Equal(t, []Diagnostic{{
+		Check:   CheckEmulators,
+		Message: "no emulator available for " + runtime.GOOS + "/" + arch,
+		Vertex:  execDgst,
+	}}, diags)
+}
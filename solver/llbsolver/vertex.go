@@ -118,6 +118,12 @@ func ValidateEntitlements(ent entitlements.Set, cdiManager *cdidevices.Manager)
 			v := entitlements.Values{
 				NetworkHost:      op.Exec.Network == pb.NetMode_HOST,
 				SecurityInsecure: op.Exec.Security == pb.SecurityMode_INSECURE,
+				LocalExec: slices.ContainsFunc(op.Exec.Mounts, func(m *pb.Mount) bool {
+					return m.MountType == pb.MountType_LOCALEXEC
+				}),
+				MountHost: slices.ContainsFunc(op.Exec.Mounts, func(m *pb.Mount) bool {
+					return m.MountType == pb.MountType_HOSTBIND
+				}),
 			}
 			if err := ent.Check(v); err != nil {
 				return err
@@ -268,7 +274,7 @@ func newVertex(dgst digest.Digest, op *pb.Op, opMeta *pb.OpMetadata, load func(d
 		if err != nil {
 			return nil, err
 		}
-		vtx.inputs = append(vtx.inputs, solver.Edge{Index: solver.Index(in.Index), Vertex: sub})
+		vtx.inputs = append(vtx.inputs, solver.Edge{Index: solver.Index(in.Index), Vertex: sub, Weak: in.Weak})
 	}
 	return vtx, nil
 }
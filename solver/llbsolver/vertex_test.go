@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/entitlements"
 	digest "github.com/opencontainers/go-digest"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -114,3 +115,43 @@ func TestIngestDigest(t *testing.T) {
 		require.Equal(t, op1Digest, newDgst)
 	}
 }
+
+func TestValidateEntitlementsLocalExec(t *testing.T) {
+	op := &pb.Op{
+		Op: &pb.Op_Exec{
+			Exec: &pb.ExecOp{
+				Meta: &pb.Meta{Args: []string{"verify"}},
+				Mounts: []*pb.Mount{
+					{Dest: pb.RootMount, Input: 0},
+					{Dest: "/run/sig", Input: int64(pb.Empty), MountType: pb.MountType_LOCALEXEC, LocalExecOpt: &pb.LocalExecOpt{ID: "sign"}},
+				},
+			},
+		},
+	}
+
+	err := ValidateEntitlements(entitlements.Set{}, nil)(op, nil, nil)
+	require.ErrorContains(t, err, "local.exec is not allowed")
+
+	err = ValidateEntitlements(entitlements.Set{entitlements.EntitlementLocalExec: nil}, nil)(op, nil, nil)
+	require.NoError(t, err)
+}
+
+func TestValidateEntitlementsMountHost(t *testing.T) {
+	op := &pb.Op{
+		Op: &pb.Op_Exec{
+			Exec: &pb.ExecOp{
+				Meta: &pb.Meta{Args: []string{"make"}},
+				Mounts: []*pb.Mount{
+					{Dest: pb.RootMount, Input: 0},
+					{Dest: "/opt/toolchain", Input: int64(pb.Empty), Readonly: true, MountType: pb.MountType_HOSTBIND, HostBindOpt: &pb.HostBindOpt{Path: "/srv/toolchains"}},
+				},
+			},
+		},
+	}
+
+	err := ValidateEntitlements(entitlements.Set{}, nil)(op, nil, nil)
+	require.ErrorContains(t, err, "mount.host is not allowed")
+
+	err = ValidateEntitlements(entitlements.Set{entitlements.EntitlementMountHost: nil}, nil)(op, nil, nil)
+	require.NoError(t, err)
+}
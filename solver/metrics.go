@@ -0,0 +1,55 @@
+package solver
+
+import (
+	"context"
+	"time"
+
+	"github.com/moby/buildkit/solver/pb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var opDuration, _ = otel.Meter("github.com/moby/buildkit/solver").Float64Histogram("buildkit.solver.op.duration",
+	metric.WithDescription("Duration of executed build operations."),
+	metric.WithUnit("s"),
+)
+
+// recordOpDuration records the execution time of a vertex that was not
+// cached, labelled by the LLB operation type and the result.
+func recordOpDuration(ctx context.Context, vtx Vertex, start time.Time, err error) {
+	result := "completed"
+	if err != nil {
+		result = "error"
+		if context.Cause(ctx) != nil {
+			result = "canceled"
+		}
+	}
+	opDuration.Record(context.WithoutCancel(ctx), time.Since(start).Seconds(), metric.WithAttributes(
+		attribute.String("op", opType(vtx.Sys())),
+		attribute.String("result", result),
+	))
+}
+
+func opType(sys any) string {
+	op, ok := sys.(*pb.Op)
+	if !ok {
+		return "unknown"
+	}
+	switch op.Op.(type) {
+	case *pb.Op_Exec:
+		return "exec"
+	case *pb.Op_Source:
+		return "source"
+	case *pb.Op_File:
+		return "file"
+	case *pb.Op_Build:
+		return "build"
+	case *pb.Op_Merge:
+		return "merge"
+	case *pb.Op_Diff:
+		return "diff"
+	default:
+		return "unknown"
+	}
+}
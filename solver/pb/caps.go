@@ -43,6 +43,10 @@ const (
 
 	CapSourceOCILayout apicaps.CapID = "source.ocilayout"
 
+	// CapSourceSessionRef is the capability to use the refs retained by the
+	// previous solves of the session as sources
+	CapSourceSessionRef apicaps.CapID = "source.sessionref"
+
 	CapBuildOpLLBFileName apicaps.CapID = "source.buildop.llbfilename"
 
 	CapExecMetaBase                      apicaps.CapID = "exec.meta.base"
@@ -63,11 +67,18 @@ const (
 	CapExecMountTmpfs                    apicaps.CapID = "exec.mount.tmpfs"
 	CapExecMountTmpfsSize                apicaps.CapID = "exec.mount.tmpfs.size"
 	CapExecMountSecret                   apicaps.CapID = "exec.mount.secret"
+	CapExecMountSecretDirectory          apicaps.CapID = "exec.mount.secret.directory"
 	CapExecMountSSH                      apicaps.CapID = "exec.mount.ssh"
+	CapExecMountLocalExec                apicaps.CapID = "exec.mount.localexec"
+	CapExecMountHostBind                 apicaps.CapID = "exec.mount.hostbind"
 	CapExecMountContentCache             apicaps.CapID = "exec.mount.cache.content"
 	CapExecCgroupsMounted                apicaps.CapID = "exec.cgroup"
 	CapExecSecretEnv                     apicaps.CapID = "exec.secretenv"
 	CapExecValidExitCode                 apicaps.CapID = "exec.validexitcode"
+	CapExecHermetic                      apicaps.CapID = "exec.hermetic"
+	CapExecTimeout                       apicaps.CapID = "exec.timeout"
+	CapExecRetry                         apicaps.CapID = "exec.retry"
+	CapExecArtifacts                     apicaps.CapID = "exec.artifacts"
 
 	CapFileBase                               apicaps.CapID = "file.base"
 	CapFileRmWildcard                         apicaps.CapID = "file.rm.wildcard"
@@ -107,6 +118,9 @@ const (
 
 	// ListenBuildHistory requests support server-side filters
 	CapHistoryFilters apicaps.CapID = "history.filter"
+
+	// CapInputWeak is the capability to mark inputs as best-effort
+	CapInputWeak apicaps.CapID = "input.weak"
 )
 
 func init() {
@@ -278,6 +292,12 @@ func init() {
 		Status:  apicaps.CapStatusExperimental,
 	})
 
+	Caps.Init(apicaps.Cap{
+		ID:      CapSourceSessionRef,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
 	Caps.Init(apicaps.Cap{
 		ID:      CapBuildOpLLBFileName,
 		Enabled: true,
@@ -386,6 +406,24 @@ func init() {
 		Status:  apicaps.CapStatusExperimental,
 	})
 
+	Caps.Init(apicaps.Cap{
+		ID:      CapExecMountSecretDirectory,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
+	Caps.Init(apicaps.Cap{
+		ID:      CapExecMountLocalExec,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
+	Caps.Init(apicaps.Cap{
+		ID:      CapExecMountHostBind,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
 	Caps.Init(apicaps.Cap{
 		ID:      CapExecMountSSH,
 		Enabled: true,
@@ -416,6 +454,30 @@ func init() {
 		Status:  apicaps.CapStatusExperimental,
 	})
 
+	Caps.Init(apicaps.Cap{
+		ID:      CapExecHermetic,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
+	Caps.Init(apicaps.Cap{
+		ID:      CapExecTimeout,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
+	Caps.Init(apicaps.Cap{
+		ID:      CapExecRetry,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
+	Caps.Init(apicaps.Cap{
+		ID:      CapExecArtifacts,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
+
 	Caps.Init(apicaps.Cap{
 		ID:      CapFileBase,
 		Enabled: true,
@@ -564,4 +626,10 @@ func init() {
 		Enabled: true,
 		Status:  apicaps.CapStatusExperimental,
 	})
+
+	Caps.Init(apicaps.Cap{
+		ID:      CapInputWeak,
+		Enabled: true,
+		Status:  apicaps.CapStatusExperimental,
+	})
 }
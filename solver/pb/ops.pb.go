@@ -123,11 +123,13 @@ func (SecurityMode) EnumDescriptor() ([]byte, []int) {
 type MountType int32
 
 const (
-	MountType_BIND   MountType = 0
-	MountType_SECRET MountType = 1
-	MountType_SSH    MountType = 2
-	MountType_CACHE  MountType = 3
-	MountType_TMPFS  MountType = 4
+	MountType_BIND      MountType = 0
+	MountType_SECRET    MountType = 1
+	MountType_SSH       MountType = 2
+	MountType_CACHE     MountType = 3
+	MountType_TMPFS     MountType = 4
+	MountType_LOCALEXEC MountType = 5
+	MountType_HOSTBIND  MountType = 6
 )
 
 // Enum value maps for MountType.
@@ -138,13 +140,17 @@ var (
 		2: "SSH",
 		3: "CACHE",
 		4: "TMPFS",
+		5: "LOCALEXEC",
+		6: "HOSTBIND",
 	}
 	MountType_value = map[string]int32{
-		"BIND":   0,
-		"SECRET": 1,
-		"SSH":    2,
-		"CACHE":  3,
-		"TMPFS":  4,
+		"BIND":      0,
+		"SECRET":    1,
+		"SSH":       2,
+		"CACHE":     3,
+		"TMPFS":     4,
+		"LOCALEXEC": 5,
+		"HOSTBIND":  6,
 	}
 )
 
@@ -534,7 +540,10 @@ type Input struct {
 	// digest of the marshaled input Op
 	Digest string `protobuf:"bytes,1,opt,name=digest,proto3" json:"digest,omitempty"`
 	// output index of the input Op
-	Index         int64 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Index int64 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	// weak inputs are best-effort: if the input Op fails, the Op gets an
+	// empty input instead of failing
+	Weak          bool `protobuf:"varint,3,opt,name=weak,proto3" json:"weak,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -583,15 +592,33 @@ func (x *Input) GetIndex() int64 {
 	return 0
 }
 
+func (x *Input) GetWeak() bool {
+	if x != nil {
+		return x.Weak
+	}
+	return false
+}
+
 // ExecOp executes a command in a container.
 type ExecOp struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Meta          *Meta                  `protobuf:"bytes,1,opt,name=meta,proto3" json:"meta,omitempty"`
-	Mounts        []*Mount               `protobuf:"bytes,2,rep,name=mounts,proto3" json:"mounts,omitempty"`
-	Network       NetMode                `protobuf:"varint,3,opt,name=network,proto3,enum=pb.NetMode" json:"network,omitempty"`
-	Security      SecurityMode           `protobuf:"varint,4,opt,name=security,proto3,enum=pb.SecurityMode" json:"security,omitempty"`
-	Secretenv     []*SecretEnv           `protobuf:"bytes,5,rep,name=secretenv,proto3" json:"secretenv,omitempty"`
-	CdiDevices    []*CDIDevice           `protobuf:"bytes,6,rep,name=cdiDevices,proto3" json:"cdiDevices,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Meta       *Meta                  `protobuf:"bytes,1,opt,name=meta,proto3" json:"meta,omitempty"`
+	Mounts     []*Mount               `protobuf:"bytes,2,rep,name=mounts,proto3" json:"mounts,omitempty"`
+	Network    NetMode                `protobuf:"varint,3,opt,name=network,proto3,enum=pb.NetMode" json:"network,omitempty"`
+	Security   SecurityMode           `protobuf:"varint,4,opt,name=security,proto3,enum=pb.SecurityMode" json:"security,omitempty"`
+	Secretenv  []*SecretEnv           `protobuf:"bytes,5,rep,name=secretenv,proto3" json:"secretenv,omitempty"`
+	CdiDevices []*CDIDevice           `protobuf:"bytes,6,rep,name=cdiDevices,proto3" json:"cdiDevices,omitempty"`
+	Hermetic   *HermeticOpt           `protobuf:"bytes,7,opt,name=hermetic,proto3" json:"hermetic,omitempty"`
+	// timeout is the maximum duration of a run of the process in
+	// nanoseconds. Zero disables the timeout.
+	Timeout int64        `protobuf:"varint,8,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	Retry   *RetryPolicy `protobuf:"bytes,9,opt,name=retry,proto3" json:"retry,omitempty"`
+	// artifacts are the paths of files and directories, such as test
+	// reports, that are copied to an extra output of the exec after the
+	// process exits, even if it fails. The artifacts output follows the
+	// outputs of the mounts. Relative paths are resolved from the working
+	// directory and missing paths are skipped.
+	Artifacts     []string `protobuf:"bytes,10,rep,name=artifacts,proto3" json:"artifacts,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -668,6 +695,164 @@ func (x *ExecOp) GetCdiDevices() []*CDIDevice {
 	return nil
 }
 
+func (x *ExecOp) GetHermetic() *HermeticOpt {
+	if x != nil {
+		return x.Hermetic
+	}
+	return nil
+}
+
+func (x *ExecOp) GetTimeout() int64 {
+	if x != nil {
+		return x.Timeout
+	}
+	return 0
+}
+
+func (x *ExecOp) GetRetry() *RetryPolicy {
+	if x != nil {
+		return x.Retry
+	}
+	return nil
+}
+
+func (x *ExecOp) GetArtifacts() []string {
+	if x != nil {
+		return x.Artifacts
+	}
+	return nil
+}
+
+// RetryPolicy reruns a failed exec from its inputs. The timeout and the retry
+// policy are not part of the cache key of the exec.
+type RetryPolicy struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// attempts is the number of times the exec is retried after a failure
+	Attempts int32 `protobuf:"varint,1,opt,name=attempts,proto3" json:"attempts,omitempty"`
+	// backoff is the delay before the first retry in nanoseconds. The delay
+	// doubles for every following retry.
+	Backoff int64 `protobuf:"varint,2,opt,name=backoff,proto3" json:"backoff,omitempty"`
+	// exitCodes limits the retries to the processes exiting with one of the
+	// codes. If empty, any failure including a timeout is retried.
+	ExitCodes     []int32 `protobuf:"varint,3,rep,packed,name=exitCodes,proto3" json:"exitCodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RetryPolicy) Reset() {
+	*x = RetryPolicy{}
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RetryPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetryPolicy) ProtoMessage() {}
+
+func (x *RetryPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetryPolicy.ProtoReflect.Descriptor instead.
+func (*RetryPolicy) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *RetryPolicy) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetBackoff() int64 {
+	if x != nil {
+		return x.Backoff
+	}
+	return 0
+}
+
+func (x *RetryPolicy) GetExitCodes() []int32 {
+	if x != nil {
+		return x.ExitCodes
+	}
+	return nil
+}
+
+// HermeticOpt declares an exec a pure function of its mounts, args and the
+// listed environment variables.
+type HermeticOpt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// env lists the names of the environment variables that are inputs of the
+	// exec. Other variables, the hostname and extra hosts are excluded from
+	// the cache key.
+	Env []string `protobuf:"bytes,1,rep,name=env,proto3" json:"env,omitempty"`
+	// strict blocks the inputs that are not declared. Undeclared environment
+	// variables are not set, the hostname and extra hosts are not applied, the
+	// network is disabled and secrets, SSH sockets, cache mounts and insecure
+	// security mode are rejected. Without strict the exec runs with all of
+	// its inputs and a cached result may have been created with different
+	// values for the undeclared ones.
+	Strict        bool `protobuf:"varint,2,opt,name=strict,proto3" json:"strict,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HermeticOpt) Reset() {
+	*x = HermeticOpt{}
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HermeticOpt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HermeticOpt) ProtoMessage() {}
+
+func (x *HermeticOpt) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HermeticOpt.ProtoReflect.Descriptor instead.
+func (*HermeticOpt) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *HermeticOpt) GetEnv() []string {
+	if x != nil {
+		return x.Env
+	}
+	return nil
+}
+
+func (x *HermeticOpt) GetStrict() bool {
+	if x != nil {
+		return x.Strict
+	}
+	return false
+}
+
 // Meta is a set of arguments for ExecOp.
 // Meta is unrelated to LLB metadata.
 // FIXME: rename (ExecContext? ExecArgs?)
@@ -690,7 +875,7 @@ type Meta struct {
 
 func (x *Meta) Reset() {
 	*x = Meta{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[4]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -702,7 +887,7 @@ func (x *Meta) String() string {
 func (*Meta) ProtoMessage() {}
 
 func (x *Meta) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[4]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -715,7 +900,7 @@ func (x *Meta) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Meta.ProtoReflect.Descriptor instead.
 func (*Meta) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{4}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Meta) GetArgs() []string {
@@ -805,7 +990,7 @@ type HostIP struct {
 
 func (x *HostIP) Reset() {
 	*x = HostIP{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[5]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -817,7 +1002,7 @@ func (x *HostIP) String() string {
 func (*HostIP) ProtoMessage() {}
 
 func (x *HostIP) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[5]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -830,7 +1015,7 @@ func (x *HostIP) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HostIP.ProtoReflect.Descriptor instead.
 func (*HostIP) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{5}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *HostIP) GetHost() string {
@@ -858,7 +1043,7 @@ type Ulimit struct {
 
 func (x *Ulimit) Reset() {
 	*x = Ulimit{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[6]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -870,7 +1055,7 @@ func (x *Ulimit) String() string {
 func (*Ulimit) ProtoMessage() {}
 
 func (x *Ulimit) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[6]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -883,7 +1068,7 @@ func (x *Ulimit) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Ulimit.ProtoReflect.Descriptor instead.
 func (*Ulimit) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{6}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Ulimit) GetName() string {
@@ -919,7 +1104,7 @@ type SecretEnv struct {
 
 func (x *SecretEnv) Reset() {
 	*x = SecretEnv{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[7]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -931,7 +1116,7 @@ func (x *SecretEnv) String() string {
 func (*SecretEnv) ProtoMessage() {}
 
 func (x *SecretEnv) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[7]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -944,7 +1129,7 @@ func (x *SecretEnv) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SecretEnv.ProtoReflect.Descriptor instead.
 func (*SecretEnv) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{7}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *SecretEnv) GetID() string {
@@ -982,7 +1167,7 @@ type CDIDevice struct {
 
 func (x *CDIDevice) Reset() {
 	*x = CDIDevice{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[8]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -994,7 +1179,7 @@ func (x *CDIDevice) String() string {
 func (*CDIDevice) ProtoMessage() {}
 
 func (x *CDIDevice) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[8]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1007,7 +1192,7 @@ func (x *CDIDevice) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CDIDevice.ProtoReflect.Descriptor instead.
 func (*CDIDevice) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{8}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *CDIDevice) GetName() string {
@@ -1039,13 +1224,15 @@ type Mount struct {
 	SSHOpt        *SSHOpt                `protobuf:"bytes,22,opt,name=SSHOpt,proto3" json:"SSHOpt,omitempty"`
 	ResultID      string                 `protobuf:"bytes,23,opt,name=resultID,proto3" json:"resultID,omitempty"`
 	ContentCache  MountContentCache      `protobuf:"varint,24,opt,name=contentCache,proto3,enum=pb.MountContentCache" json:"contentCache,omitempty"`
+	LocalExecOpt  *LocalExecOpt          `protobuf:"bytes,25,opt,name=localExecOpt,proto3" json:"localExecOpt,omitempty"`
+	HostBindOpt   *HostBindOpt           `protobuf:"bytes,26,opt,name=hostBindOpt,proto3" json:"hostBindOpt,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Mount) Reset() {
 	*x = Mount{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[9]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1057,7 +1244,7 @@ func (x *Mount) String() string {
 func (*Mount) ProtoMessage() {}
 
 func (x *Mount) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[9]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1070,7 +1257,7 @@ func (x *Mount) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Mount.ProtoReflect.Descriptor instead.
 func (*Mount) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{9}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *Mount) GetInput() int64 {
@@ -1157,6 +1344,20 @@ func (x *Mount) GetContentCache() MountContentCache {
 	return MountContentCache_DEFAULT
 }
 
+func (x *Mount) GetLocalExecOpt() *LocalExecOpt {
+	if x != nil {
+		return x.LocalExecOpt
+	}
+	return nil
+}
+
+func (x *Mount) GetHostBindOpt() *HostBindOpt {
+	if x != nil {
+		return x.HostBindOpt
+	}
+	return nil
+}
+
 // TmpfsOpt defines options describing tpmfs mounts
 type TmpfsOpt struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -1168,7 +1369,7 @@ type TmpfsOpt struct {
 
 func (x *TmpfsOpt) Reset() {
 	*x = TmpfsOpt{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[10]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1180,7 +1381,7 @@ func (x *TmpfsOpt) String() string {
 func (*TmpfsOpt) ProtoMessage() {}
 
 func (x *TmpfsOpt) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[10]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1193,7 +1394,7 @@ func (x *TmpfsOpt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TmpfsOpt.ProtoReflect.Descriptor instead.
 func (*TmpfsOpt) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{10}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *TmpfsOpt) GetSize() int64 {
@@ -1216,7 +1417,7 @@ type CacheOpt struct {
 
 func (x *CacheOpt) Reset() {
 	*x = CacheOpt{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[11]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1228,7 +1429,7 @@ func (x *CacheOpt) String() string {
 func (*CacheOpt) ProtoMessage() {}
 
 func (x *CacheOpt) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[11]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1241,7 +1442,7 @@ func (x *CacheOpt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CacheOpt.ProtoReflect.Descriptor instead.
 func (*CacheOpt) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{11}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *CacheOpt) GetID() string {
@@ -1271,14 +1472,18 @@ type SecretOpt struct {
 	Mode uint32 `protobuf:"varint,4,opt,name=mode,proto3" json:"mode,omitempty"`
 	// Optional defines if secret value is required. Error is produced
 	// if value is not found and optional is false.
-	Optional      bool `protobuf:"varint,5,opt,name=optional,proto3" json:"optional,omitempty"`
+	Optional bool `protobuf:"varint,5,opt,name=optional,proto3" json:"optional,omitempty"`
+	// Directory mounts the secret as a directory. The value of the secret is
+	// a tar archive of the files of the directory. The modes of the files in
+	// the archive are kept and the uid and gid apply to all of them.
+	Directory     bool `protobuf:"varint,6,opt,name=directory,proto3" json:"directory,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SecretOpt) Reset() {
 	*x = SecretOpt{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[12]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1290,7 +1495,7 @@ func (x *SecretOpt) String() string {
 func (*SecretOpt) ProtoMessage() {}
 
 func (x *SecretOpt) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[12]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1303,7 +1508,7 @@ func (x *SecretOpt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SecretOpt.ProtoReflect.Descriptor instead.
 func (*SecretOpt) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{12}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *SecretOpt) GetID() string {
@@ -1341,6 +1546,144 @@ func (x *SecretOpt) GetOptional() bool {
 	return false
 }
 
+func (x *SecretOpt) GetDirectory() bool {
+	if x != nil {
+		return x.Directory
+	}
+	return false
+}
+
+// LocalExecOpt defines options describing mounts of the output of a command
+// run by the client
+type LocalExecOpt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ID of the command registered by the client
+	ID string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// UID of the output file
+	Uid uint32 `protobuf:"varint,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	// GID of the output file
+	Gid uint32 `protobuf:"varint,3,opt,name=gid,proto3" json:"gid,omitempty"`
+	// Mode is the filesystem mode of the output file
+	Mode uint32 `protobuf:"varint,4,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Optional defines if the command is required. Error is produced if
+	// the command is not found and optional is false.
+	Optional      bool `protobuf:"varint,5,opt,name=optional,proto3" json:"optional,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LocalExecOpt) Reset() {
+	*x = LocalExecOpt{}
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LocalExecOpt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LocalExecOpt) ProtoMessage() {}
+
+func (x *LocalExecOpt) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LocalExecOpt.ProtoReflect.Descriptor instead.
+func (*LocalExecOpt) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *LocalExecOpt) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *LocalExecOpt) GetUid() uint32 {
+	if x != nil {
+		return x.Uid
+	}
+	return 0
+}
+
+func (x *LocalExecOpt) GetGid() uint32 {
+	if x != nil {
+		return x.Gid
+	}
+	return 0
+}
+
+func (x *LocalExecOpt) GetMode() uint32 {
+	if x != nil {
+		return x.Mode
+	}
+	return 0
+}
+
+func (x *LocalExecOpt) GetOptional() bool {
+	if x != nil {
+		return x.Optional
+	}
+	return false
+}
+
+// HostBindOpt defines options describing read-only bind mounts of a path of
+// the host of the daemon
+type HostBindOpt struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Path is the absolute path on the host
+	Path          string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HostBindOpt) Reset() {
+	*x = HostBindOpt{}
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HostBindOpt) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HostBindOpt) ProtoMessage() {}
+
+func (x *HostBindOpt) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HostBindOpt.ProtoReflect.Descriptor instead.
+func (*HostBindOpt) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *HostBindOpt) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
 // SSHOpt defines options describing ssh mounts
 type SSHOpt struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -1361,7 +1704,7 @@ type SSHOpt struct {
 
 func (x *SSHOpt) Reset() {
 	*x = SSHOpt{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[13]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1373,7 +1716,7 @@ func (x *SSHOpt) String() string {
 func (*SSHOpt) ProtoMessage() {}
 
 func (x *SSHOpt) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[13]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1386,7 +1729,7 @@ func (x *SSHOpt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SSHOpt.ProtoReflect.Descriptor instead.
 func (*SSHOpt) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{13}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *SSHOpt) GetID() string {
@@ -1438,7 +1781,7 @@ type SourceOp struct {
 
 func (x *SourceOp) Reset() {
 	*x = SourceOp{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[14]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1450,7 +1793,7 @@ func (x *SourceOp) String() string {
 func (*SourceOp) ProtoMessage() {}
 
 func (x *SourceOp) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[14]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1463,7 +1806,7 @@ func (x *SourceOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SourceOp.ProtoReflect.Descriptor instead.
 func (*SourceOp) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{14}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *SourceOp) GetIdentifier() string {
@@ -1494,7 +1837,7 @@ type BuildOp struct {
 
 func (x *BuildOp) Reset() {
 	*x = BuildOp{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[15]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1506,7 +1849,7 @@ func (x *BuildOp) String() string {
 func (*BuildOp) ProtoMessage() {}
 
 func (x *BuildOp) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[15]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1519,7 +1862,7 @@ func (x *BuildOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildOp.ProtoReflect.Descriptor instead.
 func (*BuildOp) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{15}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *BuildOp) GetBuilder() int64 {
@@ -1560,7 +1903,7 @@ type BuildInput struct {
 
 func (x *BuildInput) Reset() {
 	*x = BuildInput{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[16]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1572,7 +1915,7 @@ func (x *BuildInput) String() string {
 func (*BuildInput) ProtoMessage() {}
 
 func (x *BuildInput) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[16]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1585,7 +1928,7 @@ func (x *BuildInput) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BuildInput.ProtoReflect.Descriptor instead.
 func (*BuildInput) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{16}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *BuildInput) GetInput() int64 {
@@ -1613,7 +1956,7 @@ type OpMetadata struct {
 
 func (x *OpMetadata) Reset() {
 	*x = OpMetadata{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[17]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1625,7 +1968,7 @@ func (x *OpMetadata) String() string {
 func (*OpMetadata) ProtoMessage() {}
 
 func (x *OpMetadata) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[17]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1638,7 +1981,7 @@ func (x *OpMetadata) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use OpMetadata.ProtoReflect.Descriptor instead.
 func (*OpMetadata) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{17}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *OpMetadata) GetIgnoreCache() bool {
@@ -1687,7 +2030,7 @@ type Source struct {
 
 func (x *Source) Reset() {
 	*x = Source{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[18]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[22]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1699,7 +2042,7 @@ func (x *Source) String() string {
 func (*Source) ProtoMessage() {}
 
 func (x *Source) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[18]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[22]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1712,7 +2055,7 @@ func (x *Source) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Source.ProtoReflect.Descriptor instead.
 func (*Source) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{18}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{22}
 }
 
 func (x *Source) GetLocations() map[string]*Locations {
@@ -1739,7 +2082,7 @@ type Locations struct {
 
 func (x *Locations) Reset() {
 	*x = Locations{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[19]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[23]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1751,7 +2094,7 @@ func (x *Locations) String() string {
 func (*Locations) ProtoMessage() {}
 
 func (x *Locations) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[19]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[23]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1764,7 +2107,7 @@ func (x *Locations) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Locations.ProtoReflect.Descriptor instead.
 func (*Locations) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{19}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{23}
 }
 
 func (x *Locations) GetLocations() []*Location {
@@ -1787,7 +2130,7 @@ type SourceInfo struct {
 
 func (x *SourceInfo) Reset() {
 	*x = SourceInfo{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[20]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[24]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1799,7 +2142,7 @@ func (x *SourceInfo) String() string {
 func (*SourceInfo) ProtoMessage() {}
 
 func (x *SourceInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[20]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[24]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1812,7 +2155,7 @@ func (x *SourceInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SourceInfo.ProtoReflect.Descriptor instead.
 func (*SourceInfo) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{20}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{24}
 }
 
 func (x *SourceInfo) GetFilename() string {
@@ -1854,7 +2197,7 @@ type Location struct {
 
 func (x *Location) Reset() {
 	*x = Location{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[21]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[25]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1866,7 +2209,7 @@ func (x *Location) String() string {
 func (*Location) ProtoMessage() {}
 
 func (x *Location) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[21]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[25]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1879,7 +2222,7 @@ func (x *Location) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Location.ProtoReflect.Descriptor instead.
 func (*Location) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{21}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{25}
 }
 
 func (x *Location) GetSourceIndex() int32 {
@@ -1907,7 +2250,7 @@ type Range struct {
 
 func (x *Range) Reset() {
 	*x = Range{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[22]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[26]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1919,7 +2262,7 @@ func (x *Range) String() string {
 func (*Range) ProtoMessage() {}
 
 func (x *Range) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[22]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[26]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1932,7 +2275,7 @@ func (x *Range) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Range.ProtoReflect.Descriptor instead.
 func (*Range) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{22}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{26}
 }
 
 func (x *Range) GetStart() *Position {
@@ -1960,7 +2303,7 @@ type Position struct {
 
 func (x *Position) Reset() {
 	*x = Position{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[23]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[27]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1972,7 +2315,7 @@ func (x *Position) String() string {
 func (*Position) ProtoMessage() {}
 
 func (x *Position) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[23]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[27]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1985,7 +2328,7 @@ func (x *Position) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Position.ProtoReflect.Descriptor instead.
 func (*Position) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{23}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *Position) GetLine() int32 {
@@ -2011,7 +2354,7 @@ type ExportCache struct {
 
 func (x *ExportCache) Reset() {
 	*x = ExportCache{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[24]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[28]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2023,7 +2366,7 @@ func (x *ExportCache) String() string {
 func (*ExportCache) ProtoMessage() {}
 
 func (x *ExportCache) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[24]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[28]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2036,7 +2379,7 @@ func (x *ExportCache) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExportCache.ProtoReflect.Descriptor instead.
 func (*ExportCache) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{24}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *ExportCache) GetValue() bool {
@@ -2057,7 +2400,7 @@ type ProgressGroup struct {
 
 func (x *ProgressGroup) Reset() {
 	*x = ProgressGroup{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[25]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[29]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2069,7 +2412,7 @@ func (x *ProgressGroup) String() string {
 func (*ProgressGroup) ProtoMessage() {}
 
 func (x *ProgressGroup) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[25]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[29]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2082,7 +2425,7 @@ func (x *ProgressGroup) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProgressGroup.ProtoReflect.Descriptor instead.
 func (*ProgressGroup) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{25}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{29}
 }
 
 func (x *ProgressGroup) GetId() string {
@@ -2119,7 +2462,7 @@ type ProxyEnv struct {
 
 func (x *ProxyEnv) Reset() {
 	*x = ProxyEnv{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[26]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[30]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2131,7 +2474,7 @@ func (x *ProxyEnv) String() string {
 func (*ProxyEnv) ProtoMessage() {}
 
 func (x *ProxyEnv) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[26]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[30]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2144,7 +2487,7 @@ func (x *ProxyEnv) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProxyEnv.ProtoReflect.Descriptor instead.
 func (*ProxyEnv) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{26}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *ProxyEnv) GetHttpProxy() string {
@@ -2192,7 +2535,7 @@ type WorkerConstraints struct {
 
 func (x *WorkerConstraints) Reset() {
 	*x = WorkerConstraints{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[27]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[31]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2204,7 +2547,7 @@ func (x *WorkerConstraints) String() string {
 func (*WorkerConstraints) ProtoMessage() {}
 
 func (x *WorkerConstraints) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[27]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[31]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2217,7 +2560,7 @@ func (x *WorkerConstraints) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WorkerConstraints.ProtoReflect.Descriptor instead.
 func (*WorkerConstraints) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{27}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{31}
 }
 
 func (x *WorkerConstraints) GetFilter() []string {
@@ -2243,7 +2586,7 @@ type Definition struct {
 
 func (x *Definition) Reset() {
 	*x = Definition{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[28]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[32]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2255,7 +2598,7 @@ func (x *Definition) String() string {
 func (*Definition) ProtoMessage() {}
 
 func (x *Definition) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[28]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[32]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2268,7 +2611,7 @@ func (x *Definition) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Definition.ProtoReflect.Descriptor instead.
 func (*Definition) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{28}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{32}
 }
 
 func (x *Definition) GetDef() [][]byte {
@@ -2301,7 +2644,7 @@ type FileOp struct {
 
 func (x *FileOp) Reset() {
 	*x = FileOp{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[29]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[33]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2313,7 +2656,7 @@ func (x *FileOp) String() string {
 func (*FileOp) ProtoMessage() {}
 
 func (x *FileOp) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[29]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[33]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2326,7 +2669,7 @@ func (x *FileOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileOp.ProtoReflect.Descriptor instead.
 func (*FileOp) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{29}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{33}
 }
 
 func (x *FileOp) GetActions() []*FileAction {
@@ -2356,7 +2699,7 @@ type FileAction struct {
 
 func (x *FileAction) Reset() {
 	*x = FileAction{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[30]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[34]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2368,7 +2711,7 @@ func (x *FileAction) String() string {
 func (*FileAction) ProtoMessage() {}
 
 func (x *FileAction) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[30]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[34]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2381,7 +2724,7 @@ func (x *FileAction) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileAction.ProtoReflect.Descriptor instead.
 func (*FileAction) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{30}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{34}
 }
 
 func (x *FileAction) GetInput() int64 {
@@ -2534,7 +2877,7 @@ type FileActionCopy struct {
 
 func (x *FileActionCopy) Reset() {
 	*x = FileActionCopy{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[31]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2546,7 +2889,7 @@ func (x *FileActionCopy) String() string {
 func (*FileActionCopy) ProtoMessage() {}
 
 func (x *FileActionCopy) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[31]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2559,7 +2902,7 @@ func (x *FileActionCopy) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileActionCopy.ProtoReflect.Descriptor instead.
 func (*FileActionCopy) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{31}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{35}
 }
 
 func (x *FileActionCopy) GetSrc() string {
@@ -2685,7 +3028,7 @@ type FileActionMkFile struct {
 
 func (x *FileActionMkFile) Reset() {
 	*x = FileActionMkFile{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[32]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2697,7 +3040,7 @@ func (x *FileActionMkFile) String() string {
 func (*FileActionMkFile) ProtoMessage() {}
 
 func (x *FileActionMkFile) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[32]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2710,7 +3053,7 @@ func (x *FileActionMkFile) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileActionMkFile.ProtoReflect.Descriptor instead.
 func (*FileActionMkFile) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{32}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{36}
 }
 
 func (x *FileActionMkFile) GetPath() string {
@@ -2764,7 +3107,7 @@ type FileActionSymlink struct {
 
 func (x *FileActionSymlink) Reset() {
 	*x = FileActionSymlink{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[33]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2776,7 +3119,7 @@ func (x *FileActionSymlink) String() string {
 func (*FileActionSymlink) ProtoMessage() {}
 
 func (x *FileActionSymlink) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[33]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2789,7 +3132,7 @@ func (x *FileActionSymlink) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileActionSymlink.ProtoReflect.Descriptor instead.
 func (*FileActionSymlink) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{33}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{37}
 }
 
 func (x *FileActionSymlink) GetOldpath() string {
@@ -2838,7 +3181,7 @@ type FileActionMkDir struct {
 
 func (x *FileActionMkDir) Reset() {
 	*x = FileActionMkDir{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[34]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2850,7 +3193,7 @@ func (x *FileActionMkDir) String() string {
 func (*FileActionMkDir) ProtoMessage() {}
 
 func (x *FileActionMkDir) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[34]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2863,7 +3206,7 @@ func (x *FileActionMkDir) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileActionMkDir.ProtoReflect.Descriptor instead.
 func (*FileActionMkDir) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{34}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{38}
 }
 
 func (x *FileActionMkDir) GetPath() string {
@@ -2915,7 +3258,7 @@ type FileActionRm struct {
 
 func (x *FileActionRm) Reset() {
 	*x = FileActionRm{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[35]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2927,7 +3270,7 @@ func (x *FileActionRm) String() string {
 func (*FileActionRm) ProtoMessage() {}
 
 func (x *FileActionRm) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[35]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2940,7 +3283,7 @@ func (x *FileActionRm) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FileActionRm.ProtoReflect.Descriptor instead.
 func (*FileActionRm) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{35}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{39}
 }
 
 func (x *FileActionRm) GetPath() string {
@@ -2974,7 +3317,7 @@ type ChownOpt struct {
 
 func (x *ChownOpt) Reset() {
 	*x = ChownOpt{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[36]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2986,7 +3329,7 @@ func (x *ChownOpt) String() string {
 func (*ChownOpt) ProtoMessage() {}
 
 func (x *ChownOpt) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[36]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[40]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2999,7 +3342,7 @@ func (x *ChownOpt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ChownOpt.ProtoReflect.Descriptor instead.
 func (*ChownOpt) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{36}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{40}
 }
 
 func (x *ChownOpt) GetUser() *UserOpt {
@@ -3031,7 +3374,7 @@ type UserOpt struct {
 
 func (x *UserOpt) Reset() {
 	*x = UserOpt{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[37]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[41]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3043,7 +3386,7 @@ func (x *UserOpt) String() string {
 func (*UserOpt) ProtoMessage() {}
 
 func (x *UserOpt) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[37]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[41]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3056,7 +3399,7 @@ func (x *UserOpt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserOpt.ProtoReflect.Descriptor instead.
 func (*UserOpt) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{37}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *UserOpt) GetUser() isUserOpt_User {
@@ -3110,7 +3453,7 @@ type NamedUserOpt struct {
 
 func (x *NamedUserOpt) Reset() {
 	*x = NamedUserOpt{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[38]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3122,7 +3465,7 @@ func (x *NamedUserOpt) String() string {
 func (*NamedUserOpt) ProtoMessage() {}
 
 func (x *NamedUserOpt) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[38]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3135,7 +3478,7 @@ func (x *NamedUserOpt) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NamedUserOpt.ProtoReflect.Descriptor instead.
 func (*NamedUserOpt) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{38}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *NamedUserOpt) GetName() string {
@@ -3161,7 +3504,7 @@ type MergeInput struct {
 
 func (x *MergeInput) Reset() {
 	*x = MergeInput{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[39]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[43]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3173,7 +3516,7 @@ func (x *MergeInput) String() string {
 func (*MergeInput) ProtoMessage() {}
 
 func (x *MergeInput) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[39]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3186,7 +3529,7 @@ func (x *MergeInput) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MergeInput.ProtoReflect.Descriptor instead.
 func (*MergeInput) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{39}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *MergeInput) GetInput() int64 {
@@ -3205,7 +3548,7 @@ type MergeOp struct {
 
 func (x *MergeOp) Reset() {
 	*x = MergeOp{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[40]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3217,7 +3560,7 @@ func (x *MergeOp) String() string {
 func (*MergeOp) ProtoMessage() {}
 
 func (x *MergeOp) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[40]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3230,7 +3573,7 @@ func (x *MergeOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MergeOp.ProtoReflect.Descriptor instead.
 func (*MergeOp) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{40}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *MergeOp) GetInputs() []*MergeInput {
@@ -3249,7 +3592,7 @@ type LowerDiffInput struct {
 
 func (x *LowerDiffInput) Reset() {
 	*x = LowerDiffInput{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[41]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3261,7 +3604,7 @@ func (x *LowerDiffInput) String() string {
 func (*LowerDiffInput) ProtoMessage() {}
 
 func (x *LowerDiffInput) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[41]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3274,7 +3617,7 @@ func (x *LowerDiffInput) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LowerDiffInput.ProtoReflect.Descriptor instead.
 func (*LowerDiffInput) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{41}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *LowerDiffInput) GetInput() int64 {
@@ -3293,7 +3636,7 @@ type UpperDiffInput struct {
 
 func (x *UpperDiffInput) Reset() {
 	*x = UpperDiffInput{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[42]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3305,7 +3648,7 @@ func (x *UpperDiffInput) String() string {
 func (*UpperDiffInput) ProtoMessage() {}
 
 func (x *UpperDiffInput) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[42]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3318,7 +3661,7 @@ func (x *UpperDiffInput) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpperDiffInput.ProtoReflect.Descriptor instead.
 func (*UpperDiffInput) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{42}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *UpperDiffInput) GetInput() int64 {
@@ -3338,7 +3681,7 @@ type DiffOp struct {
 
 func (x *DiffOp) Reset() {
 	*x = DiffOp{}
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[43]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3350,7 +3693,7 @@ func (x *DiffOp) String() string {
 func (*DiffOp) ProtoMessage() {}
 
 func (x *DiffOp) ProtoReflect() protoreflect.Message {
-	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[43]
+	mi := &file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3363,7 +3706,7 @@ func (x *DiffOp) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DiffOp.ProtoReflect.Descriptor instead.
 func (*DiffOp) Descriptor() ([]byte, []int) {
-	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{43}
+	return file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *DiffOp) GetLower() *LowerDiffInput {
@@ -3407,10 +3750,11 @@ const file_github_com_moby_buildkit_solver_pb_ops_proto_rawDesc = "" +
 	"\tOSVersion\x18\x04 \x01(\tR\tOSVersion\x12\x1e\n" +
 	"\n" +
 	"OSFeatures\x18\x05 \x03(\tR\n" +
-	"OSFeatures\"5\n" +
+	"OSFeatures\"I\n" +
 	"\x05Input\x12\x16\n" +
 	"\x06digest\x18\x01 \x01(\tR\x06digest\x12\x14\n" +
-	"\x05index\x18\x02 \x01(\x03R\x05index\"\xfa\x01\n" +
+	"\x05index\x18\x02 \x01(\x03R\x05index\x12\x12\n" +
+	"\x04weak\x18\x03 \x01(\bR\x04weak\"\x86\x03\n" +
 	"\x06ExecOp\x12\x1c\n" +
 	"\x04meta\x18\x01 \x01(\v2\b.pb.MetaR\x04meta\x12!\n" +
 	"\x06mounts\x18\x02 \x03(\v2\t.pb.MountR\x06mounts\x12%\n" +
@@ -3419,7 +3763,19 @@ const file_github_com_moby_buildkit_solver_pb_ops_proto_rawDesc = "" +
 	"\tsecretenv\x18\x05 \x03(\v2\r.pb.SecretEnvR\tsecretenv\x12-\n" +
 	"\n" +
 	"cdiDevices\x18\x06 \x03(\v2\r.pb.CDIDeviceR\n" +
-	"cdiDevices\"\xf3\x02\n" +
+	"cdiDevices\x12+\n" +
+	"\bhermetic\x18\a \x01(\v2\x0f.pb.HermeticOptR\bhermetic\x12\x18\n" +
+	"\atimeout\x18\b \x01(\x03R\atimeout\x12%\n" +
+	"\x05retry\x18\t \x01(\v2\x0f.pb.RetryPolicyR\x05retry\x12\x1c\n" +
+	"\tartifacts\x18\n" +
+	" \x03(\tR\tartifacts\"a\n" +
+	"\vRetryPolicy\x12\x1a\n" +
+	"\battempts\x18\x01 \x01(\x05R\battempts\x12\x18\n" +
+	"\abackoff\x18\x02 \x01(\x03R\abackoff\x12\x1c\n" +
+	"\texitCodes\x18\x03 \x03(\x05R\texitCodes\"7\n" +
+	"\vHermeticOpt\x12\x10\n" +
+	"\x03env\x18\x01 \x03(\tR\x03env\x12\x16\n" +
+	"\x06strict\x18\x02 \x01(\bR\x06strict\"\xf3\x02\n" +
 	"\x04Meta\x12\x12\n" +
 	"\x04args\x18\x01 \x03(\tR\x04args\x12\x10\n" +
 	"\x03env\x18\x02 \x03(\tR\x03env\x12\x10\n" +
@@ -3450,7 +3806,7 @@ const file_github_com_moby_buildkit_solver_pb_ops_proto_rawDesc = "" +
 	"\boptional\x18\x03 \x01(\bR\boptional\";\n" +
 	"\tCDIDevice\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
-	"\boptional\x18\x02 \x01(\bR\boptional\"\xaa\x03\n" +
+	"\boptional\x18\x02 \x01(\bR\boptional\"\x93\x04\n" +
 	"\x05Mount\x12\x14\n" +
 	"\x05input\x18\x01 \x01(\x03R\x05input\x12\x1a\n" +
 	"\bselector\x18\x02 \x01(\tR\bselector\x12\x12\n" +
@@ -3464,18 +3820,29 @@ const file_github_com_moby_buildkit_solver_pb_ops_proto_rawDesc = "" +
 	"\x06SSHOpt\x18\x16 \x01(\v2\n" +
 	".pb.SSHOptR\x06SSHOpt\x12\x1a\n" +
 	"\bresultID\x18\x17 \x01(\tR\bresultID\x129\n" +
-	"\fcontentCache\x18\x18 \x01(\x0e2\x15.pb.MountContentCacheR\fcontentCache\"\x1e\n" +
+	"\fcontentCache\x18\x18 \x01(\x0e2\x15.pb.MountContentCacheR\fcontentCache\x124\n" +
+	"\flocalExecOpt\x18\x19 \x01(\v2\x10.pb.LocalExecOptR\flocalExecOpt\x121\n" +
+	"\vhostBindOpt\x18\x1a \x01(\v2\x0f.pb.HostBindOptR\vhostBindOpt\"\x1e\n" +
 	"\bTmpfsOpt\x12\x12\n" +
 	"\x04size\x18\x01 \x01(\x03R\x04size\"I\n" +
 	"\bCacheOpt\x12\x0e\n" +
 	"\x02ID\x18\x01 \x01(\tR\x02ID\x12-\n" +
-	"\asharing\x18\x02 \x01(\x0e2\x13.pb.CacheSharingOptR\asharing\"o\n" +
+	"\asharing\x18\x02 \x01(\x0e2\x13.pb.CacheSharingOptR\asharing\"\x8d\x01\n" +
 	"\tSecretOpt\x12\x0e\n" +
 	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x10\n" +
 	"\x03uid\x18\x02 \x01(\rR\x03uid\x12\x10\n" +
 	"\x03gid\x18\x03 \x01(\rR\x03gid\x12\x12\n" +
 	"\x04mode\x18\x04 \x01(\rR\x04mode\x12\x1a\n" +
-	"\boptional\x18\x05 \x01(\bR\boptional\"l\n" +
+	"\boptional\x18\x05 \x01(\bR\boptional\x12\x1c\n" +
+	"\tdirectory\x18\x06 \x01(\bR\tdirectory\"r\n" +
+	"\fLocalExecOpt\x12\x0e\n" +
+	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x10\n" +
+	"\x03uid\x18\x02 \x01(\rR\x03uid\x12\x10\n" +
+	"\x03gid\x18\x03 \x01(\rR\x03gid\x12\x12\n" +
+	"\x04mode\x18\x04 \x01(\rR\x04mode\x12\x1a\n" +
+	"\boptional\x18\x05 \x01(\bR\boptional\"!\n" +
+	"\vHostBindOpt\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\"l\n" +
 	"\x06SSHOpt\x12\x0e\n" +
 	"\x02ID\x18\x01 \x01(\tR\x02ID\x12\x10\n" +
 	"\x03uid\x18\x02 \x01(\rR\x03uid\x12\x10\n" +
@@ -3648,14 +4015,16 @@ const file_github_com_moby_buildkit_solver_pb_ops_proto_rawDesc = "" +
 	"\x04NONE\x10\x02*)\n" +
 	"\fSecurityMode\x12\v\n" +
 	"\aSANDBOX\x10\x00\x12\f\n" +
-	"\bINSECURE\x10\x01*@\n" +
+	"\bINSECURE\x10\x01*]\n" +
 	"\tMountType\x12\b\n" +
 	"\x04BIND\x10\x00\x12\n" +
 	"\n" +
 	"\x06SECRET\x10\x01\x12\a\n" +
 	"\x03SSH\x10\x02\x12\t\n" +
 	"\x05CACHE\x10\x03\x12\t\n" +
-	"\x05TMPFS\x10\x04*1\n" +
+	"\x05TMPFS\x10\x04\x12\r\n" +
+	"\tLOCALEXEC\x10\x05\x12\f\n" +
+	"\bHOSTBIND\x10\x06*1\n" +
 	"\x11MountContentCache\x12\v\n" +
 	"\aDEFAULT\x10\x00\x12\x06\n" +
 	"\x02ON\x10\x01\x12\a\n" +
@@ -3680,7 +4049,7 @@ func file_github_com_moby_buildkit_solver_pb_ops_proto_rawDescGZIP() []byte {
 }
 
 var file_github_com_moby_buildkit_solver_pb_ops_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
-var file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes = make([]protoimpl.MessageInfo, 51)
+var file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes = make([]protoimpl.MessageInfo, 55)
 var file_github_com_moby_buildkit_solver_pb_ops_proto_goTypes = []any{
 	(NetMode)(0),              // 0: pb.NetMode
 	(SecurityMode)(0),         // 1: pb.SecurityMode
@@ -3691,121 +4060,129 @@ var file_github_com_moby_buildkit_solver_pb_ops_proto_goTypes = []any{
 	(*Platform)(nil),          // 6: pb.Platform
 	(*Input)(nil),             // 7: pb.Input
 	(*ExecOp)(nil),            // 8: pb.ExecOp
-	(*Meta)(nil),              // 9: pb.Meta
-	(*HostIP)(nil),            // 10: pb.HostIP
-	(*Ulimit)(nil),            // 11: pb.Ulimit
-	(*SecretEnv)(nil),         // 12: pb.SecretEnv
-	(*CDIDevice)(nil),         // 13: pb.CDIDevice
-	(*Mount)(nil),             // 14: pb.Mount
-	(*TmpfsOpt)(nil),          // 15: pb.TmpfsOpt
-	(*CacheOpt)(nil),          // 16: pb.CacheOpt
-	(*SecretOpt)(nil),         // 17: pb.SecretOpt
-	(*SSHOpt)(nil),            // 18: pb.SSHOpt
-	(*SourceOp)(nil),          // 19: pb.SourceOp
-	(*BuildOp)(nil),           // 20: pb.BuildOp
-	(*BuildInput)(nil),        // 21: pb.BuildInput
-	(*OpMetadata)(nil),        // 22: pb.OpMetadata
-	(*Source)(nil),            // 23: pb.Source
-	(*Locations)(nil),         // 24: pb.Locations
-	(*SourceInfo)(nil),        // 25: pb.SourceInfo
-	(*Location)(nil),          // 26: pb.Location
-	(*Range)(nil),             // 27: pb.Range
-	(*Position)(nil),          // 28: pb.Position
-	(*ExportCache)(nil),       // 29: pb.ExportCache
-	(*ProgressGroup)(nil),     // 30: pb.ProgressGroup
-	(*ProxyEnv)(nil),          // 31: pb.ProxyEnv
-	(*WorkerConstraints)(nil), // 32: pb.WorkerConstraints
-	(*Definition)(nil),        // 33: pb.Definition
-	(*FileOp)(nil),            // 34: pb.FileOp
-	(*FileAction)(nil),        // 35: pb.FileAction
-	(*FileActionCopy)(nil),    // 36: pb.FileActionCopy
-	(*FileActionMkFile)(nil),  // 37: pb.FileActionMkFile
-	(*FileActionSymlink)(nil), // 38: pb.FileActionSymlink
-	(*FileActionMkDir)(nil),   // 39: pb.FileActionMkDir
-	(*FileActionRm)(nil),      // 40: pb.FileActionRm
-	(*ChownOpt)(nil),          // 41: pb.ChownOpt
-	(*UserOpt)(nil),           // 42: pb.UserOpt
-	(*NamedUserOpt)(nil),      // 43: pb.NamedUserOpt
-	(*MergeInput)(nil),        // 44: pb.MergeInput
-	(*MergeOp)(nil),           // 45: pb.MergeOp
-	(*LowerDiffInput)(nil),    // 46: pb.LowerDiffInput
-	(*UpperDiffInput)(nil),    // 47: pb.UpperDiffInput
-	(*DiffOp)(nil),            // 48: pb.DiffOp
-	nil,                       // 49: pb.SourceOp.AttrsEntry
-	nil,                       // 50: pb.BuildOp.InputsEntry
-	nil,                       // 51: pb.BuildOp.AttrsEntry
-	nil,                       // 52: pb.OpMetadata.DescriptionEntry
-	nil,                       // 53: pb.OpMetadata.CapsEntry
-	nil,                       // 54: pb.Source.LocationsEntry
-	nil,                       // 55: pb.Definition.MetadataEntry
+	(*RetryPolicy)(nil),       // 9: pb.RetryPolicy
+	(*HermeticOpt)(nil),       // 10: pb.HermeticOpt
+	(*Meta)(nil),              // 11: pb.Meta
+	(*HostIP)(nil),            // 12: pb.HostIP
+	(*Ulimit)(nil),            // 13: pb.Ulimit
+	(*SecretEnv)(nil),         // 14: pb.SecretEnv
+	(*CDIDevice)(nil),         // 15: pb.CDIDevice
+	(*Mount)(nil),             // 16: pb.Mount
+	(*TmpfsOpt)(nil),          // 17: pb.TmpfsOpt
+	(*CacheOpt)(nil),          // 18: pb.CacheOpt
+	(*SecretOpt)(nil),         // 19: pb.SecretOpt
+	(*LocalExecOpt)(nil),      // 20: pb.LocalExecOpt
+	(*HostBindOpt)(nil),       // 21: pb.HostBindOpt
+	(*SSHOpt)(nil),            // 22: pb.SSHOpt
+	(*SourceOp)(nil),          // 23: pb.SourceOp
+	(*BuildOp)(nil),           // 24: pb.BuildOp
+	(*BuildInput)(nil),        // 25: pb.BuildInput
+	(*OpMetadata)(nil),        // 26: pb.OpMetadata
+	(*Source)(nil),            // 27: pb.Source
+	(*Locations)(nil),         // 28: pb.Locations
+	(*SourceInfo)(nil),        // 29: pb.SourceInfo
+	(*Location)(nil),          // 30: pb.Location
+	(*Range)(nil),             // 31: pb.Range
+	(*Position)(nil),          // 32: pb.Position
+	(*ExportCache)(nil),       // 33: pb.ExportCache
+	(*ProgressGroup)(nil),     // 34: pb.ProgressGroup
+	(*ProxyEnv)(nil),          // 35: pb.ProxyEnv
+	(*WorkerConstraints)(nil), // 36: pb.WorkerConstraints
+	(*Definition)(nil),        // 37: pb.Definition
+	(*FileOp)(nil),            // 38: pb.FileOp
+	(*FileAction)(nil),        // 39: pb.FileAction
+	(*FileActionCopy)(nil),    // 40: pb.FileActionCopy
+	(*FileActionMkFile)(nil),  // 41: pb.FileActionMkFile
+	(*FileActionSymlink)(nil), // 42: pb.FileActionSymlink
+	(*FileActionMkDir)(nil),   // 43: pb.FileActionMkDir
+	(*FileActionRm)(nil),      // 44: pb.FileActionRm
+	(*ChownOpt)(nil),          // 45: pb.ChownOpt
+	(*UserOpt)(nil),           // 46: pb.UserOpt
+	(*NamedUserOpt)(nil),      // 47: pb.NamedUserOpt
+	(*MergeInput)(nil),        // 48: pb.MergeInput
+	(*MergeOp)(nil),           // 49: pb.MergeOp
+	(*LowerDiffInput)(nil),    // 50: pb.LowerDiffInput
+	(*UpperDiffInput)(nil),    // 51: pb.UpperDiffInput
+	(*DiffOp)(nil),            // 52: pb.DiffOp
+	nil,                       // 53: pb.SourceOp.AttrsEntry
+	nil,                       // 54: pb.BuildOp.InputsEntry
+	nil,                       // 55: pb.BuildOp.AttrsEntry
+	nil,                       // 56: pb.OpMetadata.DescriptionEntry
+	nil,                       // 57: pb.OpMetadata.CapsEntry
+	nil,                       // 58: pb.Source.LocationsEntry
+	nil,                       // 59: pb.Definition.MetadataEntry
 }
 var file_github_com_moby_buildkit_solver_pb_ops_proto_depIdxs = []int32{
 	7,  // 0: pb.Op.inputs:type_name -> pb.Input
 	8,  // 1: pb.Op.exec:type_name -> pb.ExecOp
-	19, // 2: pb.Op.source:type_name -> pb.SourceOp
-	34, // 3: pb.Op.file:type_name -> pb.FileOp
-	20, // 4: pb.Op.build:type_name -> pb.BuildOp
-	45, // 5: pb.Op.merge:type_name -> pb.MergeOp
-	48, // 6: pb.Op.diff:type_name -> pb.DiffOp
+	23, // 2: pb.Op.source:type_name -> pb.SourceOp
+	38, // 3: pb.Op.file:type_name -> pb.FileOp
+	24, // 4: pb.Op.build:type_name -> pb.BuildOp
+	49, // 5: pb.Op.merge:type_name -> pb.MergeOp
+	52, // 6: pb.Op.diff:type_name -> pb.DiffOp
 	6,  // 7: pb.Op.platform:type_name -> pb.Platform
-	32, // 8: pb.Op.constraints:type_name -> pb.WorkerConstraints
-	9,  // 9: pb.ExecOp.meta:type_name -> pb.Meta
-	14, // 10: pb.ExecOp.mounts:type_name -> pb.Mount
+	36, // 8: pb.Op.constraints:type_name -> pb.WorkerConstraints
+	11, // 9: pb.ExecOp.meta:type_name -> pb.Meta
+	16, // 10: pb.ExecOp.mounts:type_name -> pb.Mount
 	0,  // 11: pb.ExecOp.network:type_name -> pb.NetMode
 	1,  // 12: pb.ExecOp.security:type_name -> pb.SecurityMode
-	12, // 13: pb.ExecOp.secretenv:type_name -> pb.SecretEnv
-	13, // 14: pb.ExecOp.cdiDevices:type_name -> pb.CDIDevice
-	31, // 15: pb.Meta.proxy_env:type_name -> pb.ProxyEnv
-	10, // 16: pb.Meta.extraHosts:type_name -> pb.HostIP
-	11, // 17: pb.Meta.ulimit:type_name -> pb.Ulimit
-	2,  // 18: pb.Mount.mountType:type_name -> pb.MountType
-	15, // 19: pb.Mount.TmpfsOpt:type_name -> pb.TmpfsOpt
-	16, // 20: pb.Mount.cacheOpt:type_name -> pb.CacheOpt
-	17, // 21: pb.Mount.secretOpt:type_name -> pb.SecretOpt
-	18, // 22: pb.Mount.SSHOpt:type_name -> pb.SSHOpt
-	3,  // 23: pb.Mount.contentCache:type_name -> pb.MountContentCache
-	4,  // 24: pb.CacheOpt.sharing:type_name -> pb.CacheSharingOpt
-	49, // 25: pb.SourceOp.attrs:type_name -> pb.SourceOp.AttrsEntry
-	50, // 26: pb.BuildOp.inputs:type_name -> pb.BuildOp.InputsEntry
-	33, // 27: pb.BuildOp.def:type_name -> pb.Definition
-	51, // 28: pb.BuildOp.attrs:type_name -> pb.BuildOp.AttrsEntry
-	52, // 29: pb.OpMetadata.description:type_name -> pb.OpMetadata.DescriptionEntry
-	29, // 30: pb.OpMetadata.export_cache:type_name -> pb.ExportCache
-	53, // 31: pb.OpMetadata.caps:type_name -> pb.OpMetadata.CapsEntry
-	30, // 32: pb.OpMetadata.progress_group:type_name -> pb.ProgressGroup
-	54, // 33: pb.Source.locations:type_name -> pb.Source.LocationsEntry
-	25, // 34: pb.Source.infos:type_name -> pb.SourceInfo
-	26, // 35: pb.Locations.locations:type_name -> pb.Location
-	33, // 36: pb.SourceInfo.definition:type_name -> pb.Definition
-	27, // 37: pb.Location.ranges:type_name -> pb.Range
-	28, // 38: pb.Range.start:type_name -> pb.Position
-	28, // 39: pb.Range.end:type_name -> pb.Position
-	55, // 40: pb.Definition.metadata:type_name -> pb.Definition.MetadataEntry
-	23, // 41: pb.Definition.Source:type_name -> pb.Source
-	35, // 42: pb.FileOp.actions:type_name -> pb.FileAction
-	36, // 43: pb.FileAction.copy:type_name -> pb.FileActionCopy
-	37, // 44: pb.FileAction.mkfile:type_name -> pb.FileActionMkFile
-	39, // 45: pb.FileAction.mkdir:type_name -> pb.FileActionMkDir
-	40, // 46: pb.FileAction.rm:type_name -> pb.FileActionRm
-	38, // 47: pb.FileAction.symlink:type_name -> pb.FileActionSymlink
-	41, // 48: pb.FileActionCopy.owner:type_name -> pb.ChownOpt
-	41, // 49: pb.FileActionMkFile.owner:type_name -> pb.ChownOpt
-	41, // 50: pb.FileActionSymlink.owner:type_name -> pb.ChownOpt
-	41, // 51: pb.FileActionMkDir.owner:type_name -> pb.ChownOpt
-	42, // 52: pb.ChownOpt.user:type_name -> pb.UserOpt
-	42, // 53: pb.ChownOpt.group:type_name -> pb.UserOpt
-	43, // 54: pb.UserOpt.byName:type_name -> pb.NamedUserOpt
-	44, // 55: pb.MergeOp.inputs:type_name -> pb.MergeInput
-	46, // 56: pb.DiffOp.lower:type_name -> pb.LowerDiffInput
-	47, // 57: pb.DiffOp.upper:type_name -> pb.UpperDiffInput
-	21, // 58: pb.BuildOp.InputsEntry.value:type_name -> pb.BuildInput
-	24, // 59: pb.Source.LocationsEntry.value:type_name -> pb.Locations
-	22, // 60: pb.Definition.MetadataEntry.value:type_name -> pb.OpMetadata
-	61, // [61:61] is the sub-list for method output_type
-	61, // [61:61] is the sub-list for method input_type
-	61, // [61:61] is the sub-list for extension type_name
-	61, // [61:61] is the sub-list for extension extendee
-	0,  // [0:61] is the sub-list for field type_name
+	14, // 13: pb.ExecOp.secretenv:type_name -> pb.SecretEnv
+	15, // 14: pb.ExecOp.cdiDevices:type_name -> pb.CDIDevice
+	10, // 15: pb.ExecOp.hermetic:type_name -> pb.HermeticOpt
+	9,  // 16: pb.ExecOp.retry:type_name -> pb.RetryPolicy
+	35, // 17: pb.Meta.proxy_env:type_name -> pb.ProxyEnv
+	12, // 18: pb.Meta.extraHosts:type_name -> pb.HostIP
+	13, // 19: pb.Meta.ulimit:type_name -> pb.Ulimit
+	2,  // 20: pb.Mount.mountType:type_name -> pb.MountType
+	17, // 21: pb.Mount.TmpfsOpt:type_name -> pb.TmpfsOpt
+	18, // 22: pb.Mount.cacheOpt:type_name -> pb.CacheOpt
+	19, // 23: pb.Mount.secretOpt:type_name -> pb.SecretOpt
+	22, // 24: pb.Mount.SSHOpt:type_name -> pb.SSHOpt
+	3,  // 25: pb.Mount.contentCache:type_name -> pb.MountContentCache
+	20, // 26: pb.Mount.localExecOpt:type_name -> pb.LocalExecOpt
+	21, // 27: pb.Mount.hostBindOpt:type_name -> pb.HostBindOpt
+	4,  // 28: pb.CacheOpt.sharing:type_name -> pb.CacheSharingOpt
+	53, // 29: pb.SourceOp.attrs:type_name -> pb.SourceOp.AttrsEntry
+	54, // 30: pb.BuildOp.inputs:type_name -> pb.BuildOp.InputsEntry
+	37, // 31: pb.BuildOp.def:type_name -> pb.Definition
+	55, // 32: pb.BuildOp.attrs:type_name -> pb.BuildOp.AttrsEntry
+	56, // 33: pb.OpMetadata.description:type_name -> pb.OpMetadata.DescriptionEntry
+	33, // 34: pb.OpMetadata.export_cache:type_name -> pb.ExportCache
+	57, // 35: pb.OpMetadata.caps:type_name -> pb.OpMetadata.CapsEntry
+	34, // 36: pb.OpMetadata.progress_group:type_name -> pb.ProgressGroup
+	58, // 37: pb.Source.locations:type_name -> pb.Source.LocationsEntry
+	29, // 38: pb.Source.infos:type_name -> pb.SourceInfo
+	30, // 39: pb.Locations.locations:type_name -> pb.Location
+	37, // 40: pb.SourceInfo.definition:type_name -> pb.Definition
+	31, // 41: pb.Location.ranges:type_name -> pb.Range
+	32, // 42: pb.Range.start:type_name -> pb.Position
+	32, // 43: pb.Range.end:type_name -> pb.Position
+	59, // 44: pb.Definition.metadata:type_name -> pb.Definition.MetadataEntry
+	27, // 45: pb.Definition.Source:type_name -> pb.Source
+	39, // 46: pb.FileOp.actions:type_name -> pb.FileAction
+	40, // 47: pb.FileAction.copy:type_name -> pb.FileActionCopy
+	41, // 48: pb.FileAction.mkfile:type_name -> pb.FileActionMkFile
+	43, // 49: pb.FileAction.mkdir:type_name -> pb.FileActionMkDir
+	44, // 50: pb.FileAction.rm:type_name -> pb.FileActionRm
+	42, // 51: pb.FileAction.symlink:type_name -> pb.FileActionSymlink
+	45, // 52: pb.FileActionCopy.owner:type_name -> pb.ChownOpt
+	45, // 53: pb.FileActionMkFile.owner:type_name -> pb.ChownOpt
+	45, // 54: pb.FileActionSymlink.owner:type_name -> pb.ChownOpt
+	45, // 55: pb.FileActionMkDir.owner:type_name -> pb.ChownOpt
+	46, // 56: pb.ChownOpt.user:type_name -> pb.UserOpt
+	46, // 57: pb.ChownOpt.group:type_name -> pb.UserOpt
+	47, // 58: pb.UserOpt.byName:type_name -> pb.NamedUserOpt
+	48, // 59: pb.MergeOp.inputs:type_name -> pb.MergeInput
+	50, // 60: pb.DiffOp.lower:type_name -> pb.LowerDiffInput
+	51, // 61: pb.DiffOp.upper:type_name -> pb.UpperDiffInput
+	25, // 62: pb.BuildOp.InputsEntry.value:type_name -> pb.BuildInput
+	28, // 63: pb.Source.LocationsEntry.value:type_name -> pb.Locations
+	26, // 64: pb.Definition.MetadataEntry.value:type_name -> pb.OpMetadata
+	65, // [65:65] is the sub-list for method output_type
+	65, // [65:65] is the sub-list for method input_type
+	65, // [65:65] is the sub-list for extension type_name
+	65, // [65:65] is the sub-list for extension extendee
+	0,  // [0:65] is the sub-list for field type_name
 }
 
 func init() { file_github_com_moby_buildkit_solver_pb_ops_proto_init() }
@@ -3821,14 +4198,14 @@ func file_github_com_moby_buildkit_solver_pb_ops_proto_init() {
 		(*Op_Merge)(nil),
 		(*Op_Diff)(nil),
 	}
-	file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[30].OneofWrappers = []any{
+	file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[34].OneofWrappers = []any{
 		(*FileAction_Copy)(nil),
 		(*FileAction_Mkfile)(nil),
 		(*FileAction_Mkdir)(nil),
 		(*FileAction_Rm)(nil),
 		(*FileAction_Symlink)(nil),
 	}
-	file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[37].OneofWrappers = []any{
+	file_github_com_moby_buildkit_solver_pb_ops_proto_msgTypes[41].OneofWrappers = []any{
 		(*UserOpt_ByName)(nil),
 		(*UserOpt_ByID)(nil),
 	}
@@ -3838,7 +4215,7 @@ func file_github_com_moby_buildkit_solver_pb_ops_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_solver_pb_ops_proto_rawDesc), len(file_github_com_moby_buildkit_solver_pb_ops_proto_rawDesc)),
 			NumEnums:      5,
-			NumMessages:   51,
+			NumMessages:   55,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -90,7 +90,7 @@ func (j *Job) Status(ctx context.Context, ch chan *client.SolveStatus) error {
 		slices.SortFunc(ss.Statuses, func(a, b *client.VertexStatus) int {
 			return a.Timestamp.Compare(b.Timestamp)
 		})
-		slices.SortFunc(ss.Logs, func(a, b *client.VertexLog) int {
+		slices.SortStableFunc(ss.Logs, func(a, b *client.VertexLog) int {
 			return a.Timestamp.Compare(b.Timestamp)
 		})
 
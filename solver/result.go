@@ -131,3 +131,12 @@ func SplitResultProxy(res ResultProxy) (ResultProxy, ResultProxy) {
 	sem := int64(0)
 	return &splitResultProxy{ResultProxy: res, sem: &sem}, &splitResultProxy{ResultProxy: res, sem: &sem}
 }
+
+// missingResult is the result of a weak input that failed. It is passed to
+// ops as a nil result.
+type missingResult struct{}
+
+func (missingResult) ID() string                    { return "" }
+func (missingResult) Release(context.Context) error { return nil }
+func (missingResult) Sys() any                      { return nil }
+func (missingResult) Clone() Result                 { return missingResult{} }
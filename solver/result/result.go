@@ -13,6 +13,9 @@ type Result[T comparable] struct {
 	Refs         map[string]T
 	Metadata     map[string][]byte
 	Attestations map[string][]Attestation[T]
+	// RefErrors are the errors of the refs that failed to evaluate in a solve
+	// allowing partial failure. The failed refs are not in Refs.
+	RefErrors map[string]error
 }
 
 func (r *Result[T]) Clone() *Result[T] {
@@ -21,6 +24,7 @@ func (r *Result[T]) Clone() *Result[T] {
 		Refs:         maps.Clone(r.Refs),
 		Metadata:     maps.Clone(r.Metadata),
 		Attestations: maps.Clone(r.Attestations),
+		RefErrors:    maps.Clone(r.RefErrors),
 	}
 }
 
@@ -42,6 +46,15 @@ func (r *Result[T]) AddRef(k string, ref T) {
 	r.mu.Unlock()
 }
 
+func (r *Result[T]) AddRefError(k string, err error) {
+	r.mu.Lock()
+	if r.RefErrors == nil {
+		r.RefErrors = map[string]error{}
+	}
+	r.RefErrors[k] = err
+	r.mu.Unlock()
+}
+
 func (r *Result[T]) AddAttestation(k string, v Attestation[T]) {
 	r.mu.Lock()
 	if r.Attestations == nil {
@@ -212,6 +225,7 @@ func ConvertResult[U comparable, V comparable](r *Result[U], fn func(U) (V, erro
 	}
 
 	r2.Metadata = r.Metadata
+	r2.RefErrors = r.RefErrors
 
 	return r2, nil
 }
@@ -0,0 +1,116 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	remoteserrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	"github.com/moby/buildkit/util/progress"
+	"github.com/pkg/errors"
+)
+
+// defaultRetryBackoff is the delay before the first retry if the policy
+// doesn't set one
+const defaultRetryBackoff = time.Second
+
+// defaultMaxRetryBackoff is the maximum delay between retries if the policy
+// doesn't set one
+const defaultMaxRetryBackoff = 30 * time.Second
+
+// RetryPolicy configures the automatic retries of the vertices that fail
+// with a transient error, see IsTransientError.
+type RetryPolicy struct {
+	// Attempts is the maximum number of retries of a vertex. Zero disables
+	// the retries.
+	Attempts int
+	// Backoff is the delay before the first retry. The delay doubles for
+	// every retry up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// RetryableOp is implemented by the ops that can't always be executed again
+// after a transient error, e.g. because the failed attempt already had side
+// effects that the op can't undo.
+type RetryableOp interface {
+	// CanRetry returns true if the op can be executed again after it failed
+	// with err
+	CanRetry(err error) bool
+}
+
+// IsTransientError returns true if err is a failure that is likely to go away
+// if the operation is attempted again: server errors and rate limiting of
+// registries, network timeouts and interrupted connections and temporarily
+// unavailable resources.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var errUnexpectedStatus remoteserrors.ErrUnexpectedStatus
+	if errors.As(err, &errUnexpectedStatus) {
+		return errUnexpectedStatus.StatusCode >= 500 || errUnexpectedStatus.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ETIMEDOUT) || errors.Is(err, syscall.EPIPE) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	if ne := net.Error(nil); errors.As(err, &ne) && ne.Timeout() {
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before the retry following attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	if d <= 0 {
+		d = defaultRetryBackoff
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxRetryBackoff
+	}
+	for i := 0; i < attempt && d < maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, maxBackoff)
+}
+
+// shouldRetry returns true if op can be attempted again after it failed with
+// err on attempt
+func (p RetryPolicy) shouldRetry(ctx context.Context, op Op, err error, attempt int) bool {
+	if attempt >= p.Attempts || ctx.Err() != nil || !IsTransientError(err) {
+		return false
+	}
+	if rop, ok := op.(RetryableOp); ok && !rop.CanRetry(err) {
+		return false
+	}
+	return true
+}
+
+// retry calls f until it succeeds, fails with an error that isn't transient
+// or the attempts of the policy are exhausted. The retries are reported in
+// the progress of the vertex.
+func retry[T any](ctx context.Context, p RetryPolicy, op Op, f func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		v, err := f()
+		if err == nil || !p.shouldRetry(ctx, op, err, attempt) {
+			return v, err
+		}
+		releaseError(err)
+
+		done := progress.OneOff(ctx, fmt.Sprintf("retrying after transient error: %v (attempt %d/%d)", err, attempt+2, p.Attempts+1))
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, done(context.Cause(ctx))
+		case <-time.After(p.backoff(attempt)):
+		}
+		done(nil)
+	}
+}
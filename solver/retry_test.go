@@ -0,0 +1,95 @@
+package solver
+
+import (
+	"context"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	remoteserrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTransientError(t *testing.T) {
+	for _, tc := range []struct {
+		err       error
+		transient bool
+	}{
+		{err: remoteserrors.ErrUnexpectedStatus{StatusCode: 503}, transient: true},
+		{err: remoteserrors.ErrUnexpectedStatus{StatusCode: 429}, transient: true},
+		{err: remoteserrors.ErrUnexpectedStatus{StatusCode: 404}},
+		{err: errors.Wrap(&os.PathError{Op: "mount", Path: "/x", Err: syscall.EAGAIN}, "failed to prepare"), transient: true},
+		{err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}, transient: true},
+		{err: &net.DNSError{Err: "timeout", IsTimeout: true}, transient: true},
+		{err: errors.Wrap(context.Canceled, "canceled")},
+		{err: errors.Wrap(context.DeadlineExceeded, "timeout")},
+		{err: errors.New("exit code: 1")},
+	} {
+		require.Equal(t, tc.transient, IsTransientError(tc.err), "%v", tc.err)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+	require.Equal(t, time.Second, p.backoff(0))
+	require.Equal(t, 4*time.Second, p.backoff(2))
+	require.Equal(t, 5*time.Second, p.backoff(3))
+	require.Equal(t, defaultRetryBackoff, RetryPolicy{}.backoff(0))
+	require.Equal(t, defaultMaxRetryBackoff, RetryPolicy{}.backoff(10))
+}
+
+func TestRetryTransientVertexError(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name     string
+		err      error
+		attempts int
+		execs    int
+		fails    bool
+	}{
+		{name: "transient", err: syscall.ECONNRESET, attempts: 3, execs: 3},
+		{name: "exhausted", err: syscall.ECONNRESET, attempts: 1, execs: 2, fails: true},
+		{name: "permanent", err: errors.New("invalid input"), attempts: 3, execs: 1, fails: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewSolver(SolverOpt{
+				ResolveOpFunc: testOpResolver,
+				Retry:         RetryPolicy{Attempts: tc.attempts, Backoff: time.Millisecond},
+			})
+			defer s.Close()
+
+			j0, err := s.NewJob("job0")
+			require.NoError(t, err)
+			defer j0.Discard()
+
+			var execs int
+			g0 := Edge{
+				Vertex: vtx(vtxOpt{
+					name:         "v0",
+					cacheKeySeed: "seed0",
+					value:        "result0",
+					execPreFunc: func(context.Context) error {
+						execs++
+						if execs < 3 {
+							return errors.WithStack(tc.err)
+						}
+						return nil
+					},
+				}),
+			}
+			res, err := j0.Build(context.TODO(), g0)
+			require.Equal(t, tc.execs, execs)
+			if tc.fails {
+				require.Error(t, err)
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "result0", unwrap(res))
+		})
+	}
+}
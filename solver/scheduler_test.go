@@ -207,6 +207,120 @@ func TestSingleLevelActiveGraph(t *testing.T) {
 	require.Equal(t, int64(1), *g4.Vertex.(*vertex).execCallCount)
 }
 
+func TestVertexScope(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+
+	s := NewSolver(SolverOpt{
+		ResolveOpFunc: testOpResolver,
+	})
+	defer s.Close()
+
+	release := make(chan struct{})
+	build := func(name, scope, value string) (*Job, *vertex) {
+		j, err := s.NewJob(name)
+		require.NoError(t, err)
+		j.SetVertexScope(scope)
+		v := vtx(vtxOpt{
+			name:  "v0",
+			value: value,
+			execPreFunc: func(context.Context) error {
+				<-release
+				return nil
+			},
+		})
+		v.setupCallCounters()
+		return j, v
+	}
+
+	j0, g0 := build("job0", "", "result0")
+	defer j0.Discard()
+	j1, g1 := build("job1", "worker1", "result1")
+	defer j1.Discard()
+	j2, g2 := build("job2", "worker1", "result2")
+	defer j2.Discard()
+
+	eg, egctx := errgroup.WithContext(ctx)
+	results := make([]string, 3)
+	for i, b := range []struct {
+		j *Job
+		v *vertex
+	}{{j0, g0}, {j1, g1}, {j2, g2}} {
+		// the vertices of the jobs are loaded before any of them is
+		// executed
+		_, err := b.j.list.load(ctx, b.v, nil, b.j)
+		require.NoError(t, err)
+		eg.Go(func() error {
+			res, err := b.j.Build(egctx, Edge{Vertex: b.v})
+			if err != nil {
+				return err
+			}
+			results[i] = unwrap(res)
+			return nil
+		})
+	}
+	close(release)
+	require.NoError(t, eg.Wait())
+
+	// the job in another scope doesn't share the vertex of job0, the jobs in
+	// the same scope do
+	require.Equal(t, []string{"result0", "result1", "result1"}, results)
+	require.Equal(t, int64(1), *g0.execCallCount)
+	require.Equal(t, int64(1), *g1.execCallCount)
+	require.Equal(t, int64(0), *g2.execCallCount)
+}
+
+func TestWeakInputs(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+
+	s := NewSolver(SolverOpt{
+		ResolveOpFunc: testOpResolver,
+	})
+	defer s.Close()
+
+	build := func(name string, fail bool) (int, int64) {
+		j, err := s.NewJob(name)
+		require.NoError(t, err)
+		defer j.Discard()
+
+		var execPreFunc func(context.Context) error
+		if fail {
+			execPreFunc = func(context.Context) error {
+				return errors.New("input not available")
+			}
+		}
+		g := Edge{
+			Vertex: vtxSum(1, vtxOpt{
+				name: "sum",
+				inputs: []Edge{
+					{Vertex: vtxConst(3, vtxOpt{name: "const3"})},
+					{Vertex: vtxConst(5, vtxOpt{name: "const5", execPreFunc: execPreFunc}), Weak: true},
+				},
+			}),
+		}
+		g.Vertex.(*vertexSum).setupCallCounters()
+
+		res, err := j.Build(ctx, g)
+		require.NoError(t, err)
+		return unwrapInt(res), *g.Vertex.(*vertexSum).execCallCount
+	}
+
+	// the failed weak input is passed as nil
+	v, execs := build("job0", true)
+	require.Equal(t, 4, v)
+	require.Equal(t, int64(2), execs)
+
+	// the result is cached for the builds where the input fails again
+	v, execs = build("job1", true)
+	require.Equal(t, 4, v)
+	require.Equal(t, int64(0), execs)
+
+	v, execs = build("job2", false)
+	require.Equal(t, 9, v)
+	require.Equal(t, int64(2), execs)
+}
+
 func TestSingleLevelCache(t *testing.T) {
 	t.Parallel()
 	ctx := context.TODO()
@@ -3839,6 +3953,9 @@ func (v *vertexSum) Exec(ctx context.Context, g session.Group, inputs []Result)
 	}
 	s := v.value
 	for _, inp := range inputs {
+		if inp == nil {
+			continue
+		}
 		r, ok := inp.Sys().(*dummyResult)
 		if !ok {
 			return nil, errors.Errorf("invalid input type: %T", inp.Sys())
@@ -4088,3 +4205,66 @@ func (r *testExporterRecord) LinkFrom(src CacheExporterRecord, index int, select
 		}
 	}
 }
+
+func TestReadOnly(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+
+	cacheManager := NewInMemoryCacheManager()
+
+	s := NewSolver(SolverOpt{
+		ResolveOpFunc: testOpResolver,
+		DefaultCache:  cacheManager,
+	})
+	defer s.Close()
+
+	j0, err := s.NewJob("job0")
+	require.NoError(t, err)
+	g0 := Edge{
+		Vertex: vtx(vtxOpt{
+			name:         "v0",
+			cacheKeySeed: "seed0",
+			value:        "result0",
+		}),
+	}
+	res, err := j0.Build(ctx, g0)
+	require.NoError(t, err)
+	require.Equal(t, "result0", unwrap(res))
+	require.NoError(t, j0.Discard())
+
+	s2 := NewSolver(SolverOpt{
+		ResolveOpFunc: testOpResolver,
+		DefaultCache:  cacheManager,
+		ReadOnly:      true,
+	})
+	defer s2.Close()
+
+	j1, err := s2.NewJob("job1")
+	require.NoError(t, err)
+	defer j1.Discard()
+
+	g1 := Edge{
+		Vertex: vtx(vtxOpt{
+			name:         "v1",
+			cacheKeySeed: "seed0",
+			value:        "result1",
+		}),
+	}
+	g1.Vertex.(*vertex).setupCallCounters()
+	res, err = j1.Build(ctx, g1)
+	require.NoError(t, err)
+	require.Equal(t, "result0", unwrap(res))
+	require.Equal(t, int64(0), *g1.Vertex.(*vertex).execCallCount)
+
+	g2 := Edge{
+		Vertex: vtx(vtxOpt{
+			name:         "v2",
+			cacheKeySeed: "seed2",
+			value:        "result2",
+		}),
+	}
+	g2.Vertex.(*vertex).setupCallCounters()
+	_, err = j1.Build(ctx, g2)
+	require.ErrorIs(t, err, ErrReadOnly)
+	require.Equal(t, int64(0), *g2.Vertex.(*vertex).execCallCount)
+}
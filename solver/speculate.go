@@ -0,0 +1,102 @@
+package solver
+
+import (
+	"context"
+	"sync"
+
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/pkg/errors"
+)
+
+// SpeculateFunc reports whether a vertex is likely to miss the cache and be
+// executed by the build, usually based on the results of previous builds.
+type SpeculateFunc func(Vertex) bool
+
+type speculationKey struct{}
+
+// WithSpeculation sets whether Job.Build can start building the ancestors of
+// the edge speculatively. Speculation is disabled by default.
+func WithSpeculation(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, speculationKey{}, enabled)
+}
+
+func speculationEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(speculationKey{}).(bool)
+	return enabled
+}
+
+// speculator starts building the inputs of a build request that are expected
+// to be executed without waiting for the cache checks of the vertices that
+// depend on them. Vertices that turn out not to be needed are still executed
+// so the number of speculative builds running at a time is bounded.
+type speculator struct {
+	fn  SpeculateFunc
+	sem chan struct{}
+	s   *scheduler
+}
+
+func newSpeculator(opts SolverOpt, s *scheduler) *speculator {
+	if opts.Speculate == nil || opts.SpeculativeExecution <= 0 {
+		return nil
+	}
+	return &speculator{
+		fn:  opts.Speculate,
+		sem: make(chan struct{}, opts.SpeculativeExecution),
+		s:   s,
+	}
+}
+
+// start speculatively builds the likely executed ancestors of the loaded
+// target until ctx is canceled. Ancestors are started before the vertices
+// depending on them. The returned function waits for the speculative builds
+// to return.
+func (sp *speculator) start(ctx context.Context, target Edge) func() {
+	var edges []Edge
+	visited := map[Edge]struct{}{}
+	var walk func(e Edge)
+	walk = func(e Edge) {
+		if _, ok := visited[e]; ok {
+			return
+		}
+		visited[e] = struct{}{}
+		for _, inp := range e.Vertex.Inputs() {
+			walk(inp)
+		}
+		if e != target && sp.fn(e.Vertex) {
+			edges = append(edges, e)
+		}
+	}
+	walk(target)
+	if len(edges) == 0 {
+		return func() {}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, e := range edges {
+			select {
+			case sp.sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sp.sem }()
+				// the ancestors were loaded with target by Job.Build,
+				// loading them again would scope their digests twice
+				res, err := sp.s.build(ctx, e)
+				if err != nil {
+					if !errors.Is(err, context.Canceled) {
+						bklog.G(ctx).Debugf("speculative build of %s failed: %v", e.Vertex.Name(), err)
+					}
+					return
+				}
+				res.Release(context.WithoutCancel(ctx))
+			}()
+		}
+	}()
+	return wg.Wait
+}
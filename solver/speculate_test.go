@@ -0,0 +1,175 @@
+package solver
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpeculativeExecution(t *testing.T) {
+	t.Parallel()
+	ctx := WithSpeculation(context.TODO(), true)
+
+	started := make(chan struct{})
+	execPreFunc := func(context.Context) error {
+		close(started)
+		return nil
+	}
+	// the cache key of the target can't be computed until its ancestor has
+	// been executed so the build only completes if the ancestor is started
+	// speculatively
+	cachePreFunc := func(ctx context.Context) error {
+		select {
+		case <-started:
+			return nil
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+
+	s := NewSolver(SolverOpt{
+		ResolveOpFunc: testOpResolver,
+		Speculate: func(v Vertex) bool {
+			return v.Name() == "v0"
+		},
+		SpeculativeExecution: 1,
+	})
+	defer s.Close()
+
+	j0, err := s.NewJob("job0")
+	require.NoError(t, err)
+	defer j0.Discard()
+
+	g0 := Edge{
+		Vertex: vtx(vtxOpt{
+			name:         "v2",
+			cacheKeySeed: "seed2",
+			value:        "result2",
+			cachePreFunc: cachePreFunc,
+			inputs: []Edge{
+				{Vertex: vtx(vtxOpt{
+					name:         "v1",
+					cacheKeySeed: "seed1",
+					inputs: []Edge{
+						{Vertex: vtx(vtxOpt{
+							name:         "v0",
+							cacheKeySeed: "seed0",
+							execPreFunc:  execPreFunc,
+						})},
+					},
+				})},
+			},
+		}),
+	}
+	g0.Vertex.(*vertex).setupCallCounters()
+
+	res, err := j0.Build(ctx, g0)
+	require.NoError(t, err)
+	require.Equal(t, "result2", unwrap(res))
+	require.Equal(t, int64(3), *g0.Vertex.(*vertex).execCallCount)
+}
+
+func TestSpeculativeExecutionScope(t *testing.T) {
+	t.Parallel()
+	ctx := WithSpeculation(context.TODO(), true)
+
+	var once sync.Once
+	started := make(chan struct{})
+	execPreFunc := func(context.Context) error {
+		once.Do(func() { close(started) })
+		return nil
+	}
+	cachePreFunc := func(ctx context.Context) error {
+		select {
+		case <-started:
+			return nil
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+
+	s := NewSolver(SolverOpt{
+		ResolveOpFunc: testOpResolver,
+		Speculate: func(v Vertex) bool {
+			return v.Name() == "v0"
+		},
+		SpeculativeExecution: 1,
+	})
+	defer s.Close()
+
+	j0, err := s.NewJob("job0")
+	require.NoError(t, err)
+	defer j0.Discard()
+	j0.SetVertexScope("worker1")
+
+	g0 := Edge{
+		Vertex: vtx(vtxOpt{
+			name:         "v1",
+			cacheKeySeed: "seed1",
+			value:        "result1",
+			cachePreFunc: cachePreFunc,
+			inputs: []Edge{
+				{Vertex: vtx(vtxOpt{
+					name:         "v0",
+					cacheKeySeed: "seed0",
+					execPreFunc:  execPreFunc,
+				})},
+			},
+		}),
+	}
+	g0.Vertex.(*vertex).setupCallCounters()
+
+	// the speculative build of v0 shares the scoped vertex of the build
+	res, err := j0.Build(ctx, g0)
+	require.NoError(t, err)
+	require.Equal(t, "result1", unwrap(res))
+	require.Equal(t, int64(2), *g0.Vertex.(*vertex).execCallCount)
+	s.mu.RLock()
+	actives := len(s.actives)
+	s.mu.RUnlock()
+	require.Equal(t, 2, actives)
+}
+
+func TestSpeculativeExecutionDisabled(t *testing.T) {
+	t.Parallel()
+
+	var speculated atomic.Int64
+	s := NewSolver(SolverOpt{
+		ResolveOpFunc: testOpResolver,
+		Speculate: func(v Vertex) bool {
+			speculated.Add(1)
+			return true
+		},
+		SpeculativeExecution: 1,
+	})
+	defer s.Close()
+
+	j0, err := s.NewJob("job0")
+	require.NoError(t, err)
+	defer j0.Discard()
+
+	g0 := Edge{
+		Vertex: vtx(vtxOpt{
+			name:         "v1",
+			cacheKeySeed: "seed1",
+			value:        "result1",
+			inputs: []Edge{
+				{Vertex: vtx(vtxOpt{
+					name:         "v0",
+					cacheKeySeed: "seed0",
+				})},
+			},
+		}),
+	}
+
+	// nested builds are not speculated
+	for _, ctx := range []context.Context{context.TODO(), WithSpeculation(context.TODO(), false)} {
+		res, err := j0.Build(ctx, g0)
+		require.NoError(t, err)
+		require.Equal(t, "result1", unwrap(res))
+	}
+	require.Equal(t, int64(0), speculated.Load())
+}
@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"runtime"
@@ -22,6 +23,7 @@ func RunCacheStorageTests(t *testing.T, st func() solver.CacheKeyStorage) {
 		testResultReleaseMultiLevel,
 		testBacklinks,
 		testWalkIDsByResult,
+		testWalkPaged,
 	} {
 		runStorageTest(t, tc, st)
 	}
@@ -382,6 +384,45 @@ func testWalkIDsByResult(t *testing.T, st solver.CacheKeyStorage) {
 	require.False(t, ok)
 }
 
+func testWalkPaged(t *testing.T, st solver.CacheKeyStorage) {
+	t.Parallel()
+
+	ids := []string{"a", "b", "c", "d", "e"}
+	for _, id := range ids {
+		err := st.AddResult(id, solver.CacheResult{
+			ID:        id + "-result",
+			CreatedAt: time.Now(),
+		})
+		require.NoError(t, err)
+	}
+
+	var walked []string
+	err := solver.WalkPaged(context.TODO(), st, 2, func(id string) error {
+		walked = append(walked, id)
+		return nil
+	})
+	require.NoError(t, err)
+	require.ElementsMatch(t, ids, walked)
+
+	if p, ok := st.(solver.CacheKeyStoragePager); ok {
+		walked = nil
+		next, err := p.WalkPage("b", 2, func(id string) error {
+			walked = append(walked, id)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"b", "c"}, walked)
+		require.Equal(t, "d", next)
+	}
+
+	ctx, cancel := context.WithCancelCause(context.TODO())
+	cancel(errors.New("canceled"))
+	err = solver.WalkPaged(ctx, st, 2, func(id string) error {
+		return nil
+	})
+	require.Error(t, err)
+}
+
 func getFunctionName(i any) string {
 	fullname := runtime.FuncForPC(reflect.ValueOf(i).Pointer()).Name()
 	dot := strings.LastIndex(fullname, ".") + 1
@@ -0,0 +1,101 @@
+package solver
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	"golang.org/x/sync/errgroup"
+)
+
+// NewTieredCacheManager returns a cache manager that queries the tiers in
+// order and stops at the first tier that has matching keys. The slower tiers
+// are only queried for the keys missing from the faster ones.
+func NewTieredCacheManager(tiers []CacheManager) *TieredCacheManager {
+	ids := make([]string, len(tiers))
+	for i, c := range tiers {
+		ids[i] = c.ID()
+	}
+	return &TieredCacheManager{
+		tiers:  tiers,
+		id:     "tiered:" + digest.FromBytes([]byte(strings.Join(ids, ","))).String(),
+		missed: map[string]struct{}{},
+	}
+}
+
+type TieredCacheManager struct {
+	tiers []CacheManager
+	id    string
+
+	mu     sync.Mutex
+	missed map[string]struct{}
+}
+
+func (cm *TieredCacheManager) ID() string {
+	return cm.id
+}
+
+// Missed returns true if the tier with the ID didn't have keys that were
+// found in a slower tier
+func (cm *TieredCacheManager) Missed(id string) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	_, ok := cm.missed[id]
+	return ok
+}
+
+func (cm *TieredCacheManager) miss(tiers []CacheManager) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	for _, c := range tiers {
+		cm.missed[c.ID()] = struct{}{}
+	}
+}
+
+func (cm *TieredCacheManager) Query(inp []CacheKeyWithSelector, inputIndex Index, dgst digest.Digest, outputIndex Index) ([]*CacheKey, error) {
+	for i, c := range cm.tiers {
+		keys, err := c.Query(inp, inputIndex, dgst, outputIndex)
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) > 0 {
+			cm.miss(cm.tiers[:i])
+			return keys, nil
+		}
+	}
+	return nil, nil
+}
+
+func (cm *TieredCacheManager) Records(ctx context.Context, ck *CacheKey) ([]*CacheRecord, error) {
+	for i, c := range cm.tiers {
+		recs, err := c.Records(ctx, ck)
+		if err != nil {
+			return nil, err
+		}
+		if len(recs) > 0 {
+			cm.miss(cm.tiers[:i])
+			return recs, nil
+		}
+	}
+	return nil, nil
+}
+
+func (cm *TieredCacheManager) Load(ctx context.Context, rec *CacheRecord) (Result, error) {
+	return rec.cacheManager.Load(ctx, rec)
+}
+
+func (cm *TieredCacheManager) Save(key *CacheKey, s Result, createdAt time.Time) (*ExportableCacheKey, error) {
+	return cm.tiers[0].Save(key, s, createdAt)
+}
+
+func (cm *TieredCacheManager) ReleaseUnreferenced(ctx context.Context) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	for _, c := range cm.tiers {
+		eg.Go(func() error {
+			return c.ReleaseUnreferenced(ctx)
+		})
+	}
+	return eg.Wait()
+}
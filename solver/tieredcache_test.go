@@ -0,0 +1,60 @@
+package solver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTieredCache(t *testing.T) {
+	ctx := context.TODO()
+
+	fast := NewInMemoryCacheManager()
+	shared := NewInMemoryCacheManager()
+	cold := NewInMemoryCacheManager()
+
+	_, err := fast.Save(NewCacheKey(dgst("foo"), "", 0), testResult("fast-foo"), time.Now())
+	require.NoError(t, err)
+	_, err = shared.Save(NewCacheKey(dgst("foo"), "", 0), testResult("shared-foo"), time.Now())
+	require.NoError(t, err)
+	_, err = cold.Save(NewCacheKey(dgst("bar"), "", 0), testResult("cold-bar"), time.Now())
+	require.NoError(t, err)
+
+	m := NewTieredCacheManager([]CacheManager{fast, shared, cold})
+
+	// the first tier with the key wins
+	keys, err := m.Query(nil, 0, dgst("foo"), 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(keys))
+	matches, err := m.Records(ctx, keys[0])
+	require.NoError(t, err)
+	require.Equal(t, 1, len(matches))
+	res, err := m.Load(ctx, matches[0])
+	require.NoError(t, err)
+	require.Equal(t, "fast-foo", unwrap(res))
+
+	for _, c := range []CacheManager{fast, shared, cold} {
+		require.False(t, m.Missed(c.ID()))
+	}
+
+	keys, err = m.Query(nil, 0, dgst("bar"), 0)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(keys))
+	matches, err = m.Records(ctx, keys[0])
+	require.NoError(t, err)
+	require.Equal(t, 1, len(matches))
+	res, err = m.Load(ctx, matches[0])
+	require.NoError(t, err)
+	require.Equal(t, "cold-bar", unwrap(res))
+
+	// the faster tiers missed the record of the cold tier
+	require.True(t, m.Missed(fast.ID()))
+	require.True(t, m.Missed(shared.ID()))
+	require.False(t, m.Missed(cold.ID()))
+
+	keys, err = m.Query(nil, 0, dgst("baz"), 0)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(keys))
+}
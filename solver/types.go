@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/containerd/containerd/v2/core/content"
+	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/solver/pb"
 	"github.com/moby/buildkit/util/compression"
@@ -44,6 +45,10 @@ type Index int
 type Edge struct {
 	Index  Index
 	Vertex Vertex
+	// Weak marks a best-effort input. If the vertex of a weak input fails,
+	// the op of the dependant vertex gets a nil result for it instead of
+	// failing.
+	Weak bool
 }
 
 // VertexOptions define optional metadata for a vertex that doesn't change the
@@ -146,6 +151,25 @@ type CacheExporterRecord interface {
 type Remote struct {
 	Descriptors []ocispecs.Descriptor
 	Provider    content.InfoReaderProvider
+	// Composition describes how the result was built from other results when
+	// it is a merge or a diff. It allows the result to be reconstructed from
+	// its inputs when the blobs of Descriptors are not available.
+	Composition *RemoteComposition
+}
+
+type RemoteCompositionKind string
+
+const (
+	RemoteMerge RemoteCompositionKind = "merge"
+	RemoteDiff  RemoteCompositionKind = "diff"
+)
+
+// RemoteComposition is a merge of its inputs or a diff between the first
+// (lower) and second (upper) input. An input without descriptors or
+// composition is scratch.
+type RemoteComposition struct {
+	Kind   RemoteCompositionKind
+	Inputs []*Remote
 }
 
 // CacheLink is a link between two cache records
@@ -175,6 +199,14 @@ type Op interface {
 	Acquire(ctx context.Context) (release ReleaseFunc, err error)
 }
 
+// ResourceUsageOp is implemented by the ops that record the resources used by
+// their processes. The usage is reported on the completion of the vertex.
+type ResourceUsageOp interface {
+	// ResourceUsage returns the usage of the last execution of the op or nil
+	// if it was not recorded
+	ResourceUsage() *client.ResourceUsage
+}
+
 type ProvenanceProvider interface {
 	IsProvenanceProvider()
 }
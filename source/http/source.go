@@ -29,6 +29,7 @@ import (
 	srctypes "github.com/moby/buildkit/source/types"
 	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/cachedigest"
+	"github.com/moby/buildkit/util/proxy"
 	"github.com/moby/buildkit/util/tracing"
 	"github.com/moby/buildkit/version"
 	digest "github.com/opencontainers/go-digest"
@@ -60,7 +61,9 @@ type httpSource struct {
 func NewSource(opt Opt) (source.Source, error) {
 	transport := opt.Transport
 	if transport == nil {
-		transport = tracing.DefaultTransport
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = proxy.FromRequest
+		transport = tracing.NewTransport(t)
 	}
 	hs := &httpSource{
 		cache:     opt.CacheAccessor,
@@ -0,0 +1,28 @@
+package plugin
+
+import (
+	"github.com/moby/buildkit/solver/llbsolver/provenance"
+	"github.com/moby/buildkit/source"
+)
+
+type PluginIdentifier struct {
+	// SourceScheme is the scheme the plugin is registered for
+	SourceScheme string
+	// Identifier is the full identifier of the source op
+	Identifier string
+	Attrs      map[string]string
+	// Platform is the formatted platform of the build
+	Platform string
+}
+
+func (id *PluginIdentifier) Scheme() string {
+	return id.SourceScheme
+}
+
+var _ source.Identifier = (*PluginIdentifier)(nil)
+
+// Capture records nothing as the provenance has no type for the sources
+// implemented by plugins.
+func (id *PluginIdentifier) Capture(c *provenance.Capture, pin string) error {
+	return nil
+}
@@ -0,0 +1,390 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.11.4
+// source: github.com/moby/buildkit/source/plugin/pb/plugin.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ResolveRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identifier is the identifier of the source op, e.g. hg://host/repo#tip
+	Identifier string `protobuf:"bytes,1,opt,name=Identifier,proto3" json:"Identifier,omitempty"`
+	// Attrs are the attributes of the source op
+	Attrs map[string]string `protobuf:"bytes,2,rep,name=Attrs,proto3" json:"Attrs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Platform is the platform of the build, e.g. linux/amd64
+	Platform string `protobuf:"bytes,3,opt,name=Platform,proto3" json:"Platform,omitempty"`
+	// Secrets are the secrets requested by the previous response
+	Secrets       map[string][]byte `protobuf:"bytes,4,rep,name=Secrets,proto3" json:"Secrets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveRequest) Reset() {
+	*x = ResolveRequest{}
+	mi := &file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveRequest) ProtoMessage() {}
+
+func (x *ResolveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveRequest.ProtoReflect.Descriptor instead.
+func (*ResolveRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ResolveRequest) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *ResolveRequest) GetAttrs() map[string]string {
+	if x != nil {
+		return x.Attrs
+	}
+	return nil
+}
+
+func (x *ResolveRequest) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *ResolveRequest) GetSecrets() map[string][]byte {
+	if x != nil {
+		return x.Secrets
+	}
+	return nil
+}
+
+type ResolveResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// CacheKey identifies the content of the source. Builds resolving the
+	// same cache key reuse the content fetched by a previous build.
+	CacheKey string `protobuf:"bytes,1,opt,name=CacheKey,proto3" json:"CacheKey,omitempty"`
+	// Pin is the immutable version the identifier resolved to, e.g. a
+	// commit. It is passed to Fetch.
+	Pin string `protobuf:"bytes,2,opt,name=Pin,proto3" json:"Pin,omitempty"`
+	// Secrets are the IDs of the secrets of the client session the source
+	// needs. If they were not sent with the request, Resolve is called again
+	// with them and the other fields of the response are ignored.
+	Secrets       []string `protobuf:"bytes,3,rep,name=Secrets,proto3" json:"Secrets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResolveResponse) Reset() {
+	*x = ResolveResponse{}
+	mi := &file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResolveResponse) ProtoMessage() {}
+
+func (x *ResolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResolveResponse.ProtoReflect.Descriptor instead.
+func (*ResolveResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ResolveResponse) GetCacheKey() string {
+	if x != nil {
+		return x.CacheKey
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetPin() string {
+	if x != nil {
+		return x.Pin
+	}
+	return ""
+}
+
+func (x *ResolveResponse) GetSecrets() []string {
+	if x != nil {
+		return x.Secrets
+	}
+	return nil
+}
+
+type FetchRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Identifier string                 `protobuf:"bytes,1,opt,name=Identifier,proto3" json:"Identifier,omitempty"`
+	Attrs      map[string]string      `protobuf:"bytes,2,rep,name=Attrs,proto3" json:"Attrs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Platform   string                 `protobuf:"bytes,3,opt,name=Platform,proto3" json:"Platform,omitempty"`
+	Secrets    map[string][]byte      `protobuf:"bytes,4,rep,name=Secrets,proto3" json:"Secrets,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Pin        string                 `protobuf:"bytes,5,opt,name=Pin,proto3" json:"Pin,omitempty"`
+	// Path is the empty directory the content has to be written to. It is
+	// only valid for the duration of the Fetch call.
+	Path          string `protobuf:"bytes,6,opt,name=Path,proto3" json:"Path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchRequest) Reset() {
+	*x = FetchRequest{}
+	mi := &file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchRequest) ProtoMessage() {}
+
+func (x *FetchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchRequest.ProtoReflect.Descriptor instead.
+func (*FetchRequest) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FetchRequest) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *FetchRequest) GetAttrs() map[string]string {
+	if x != nil {
+		return x.Attrs
+	}
+	return nil
+}
+
+func (x *FetchRequest) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *FetchRequest) GetSecrets() map[string][]byte {
+	if x != nil {
+		return x.Secrets
+	}
+	return nil
+}
+
+func (x *FetchRequest) GetPin() string {
+	if x != nil {
+		return x.Pin
+	}
+	return ""
+}
+
+func (x *FetchRequest) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type FetchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FetchResponse) Reset() {
+	*x = FetchResponse{}
+	mi := &file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FetchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FetchResponse) ProtoMessage() {}
+
+func (x *FetchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FetchResponse.ProtoReflect.Descriptor instead.
+func (*FetchResponse) Descriptor() ([]byte, []int) {
+	return file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescGZIP(), []int{3}
+}
+
+var File_github_com_moby_buildkit_source_plugin_pb_plugin_proto protoreflect.FileDescriptor
+
+const file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDesc = "" +
+	"\n" +
+	"6github.com/moby/buildkit/source/plugin/pb/plugin.proto\x12\x1emoby.buildkit.v1.source.plugin\"\xea\x02\n" +
+	"\x0eResolveRequest\x12\x1e\n" +
+	"\n" +
+	"Identifier\x18\x01 \x01(\tR\n" +
+	"Identifier\x12O\n" +
+	"\x05Attrs\x18\x02 \x03(\v29.moby.buildkit.v1.source.plugin.ResolveRequest.AttrsEntryR\x05Attrs\x12\x1a\n" +
+	"\bPlatform\x18\x03 \x01(\tR\bPlatform\x12U\n" +
+	"\aSecrets\x18\x04 \x03(\v2;.moby.buildkit.v1.source.plugin.ResolveRequest.SecretsEntryR\aSecrets\x1a8\n" +
+	"\n" +
+	"AttrsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a:\n" +
+	"\fSecretsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"Y\n" +
+	"\x0fResolveResponse\x12\x1a\n" +
+	"\bCacheKey\x18\x01 \x01(\tR\bCacheKey\x12\x10\n" +
+	"\x03Pin\x18\x02 \x01(\tR\x03Pin\x12\x18\n" +
+	"\aSecrets\x18\x03 \x03(\tR\aSecrets\"\x8a\x03\n" +
+	"\fFetchRequest\x12\x1e\n" +
+	"\n" +
+	"Identifier\x18\x01 \x01(\tR\n" +
+	"Identifier\x12M\n" +
+	"\x05Attrs\x18\x02 \x03(\v27.moby.buildkit.v1.source.plugin.FetchRequest.AttrsEntryR\x05Attrs\x12\x1a\n" +
+	"\bPlatform\x18\x03 \x01(\tR\bPlatform\x12S\n" +
+	"\aSecrets\x18\x04 \x03(\v29.moby.buildkit.v1.source.plugin.FetchRequest.SecretsEntryR\aSecrets\x12\x10\n" +
+	"\x03Pin\x18\x05 \x01(\tR\x03Pin\x12\x12\n" +
+	"\x04Path\x18\x06 \x01(\tR\x04Path\x1a8\n" +
+	"\n" +
+	"AttrsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a:\n" +
+	"\fSecretsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\"\x0f\n" +
+	"\rFetchResponse2\xda\x01\n" +
+	"\x06Source\x12j\n" +
+	"\aResolve\x12..moby.buildkit.v1.source.plugin.ResolveRequest\x1a/.moby.buildkit.v1.source.plugin.ResolveResponse\x12d\n" +
+	"\x05Fetch\x12,.moby.buildkit.v1.source.plugin.FetchRequest\x1a-.moby.buildkit.v1.source.plugin.FetchResponseB+Z)github.com/moby/buildkit/source/plugin/pbb\x06proto3"
+
+var (
+	file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescOnce sync.Once
+	file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescData []byte
+)
+
+func file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescGZIP() []byte {
+	file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescOnce.Do(func() {
+		file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDesc), len(file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDesc)))
+	})
+	return file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDescData
+}
+
+var file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_goTypes = []any{
+	(*ResolveRequest)(nil),  // 0: moby.buildkit.v1.source.plugin.ResolveRequest
+	(*ResolveResponse)(nil), // 1: moby.buildkit.v1.source.plugin.ResolveResponse
+	(*FetchRequest)(nil),    // 2: moby.buildkit.v1.source.plugin.FetchRequest
+	(*FetchResponse)(nil),   // 3: moby.buildkit.v1.source.plugin.FetchResponse
+	nil,                     // 4: moby.buildkit.v1.source.plugin.ResolveRequest.AttrsEntry
+	nil,                     // 5: moby.buildkit.v1.source.plugin.ResolveRequest.SecretsEntry
+	nil,                     // 6: moby.buildkit.v1.source.plugin.FetchRequest.AttrsEntry
+	nil,                     // 7: moby.buildkit.v1.source.plugin.FetchRequest.SecretsEntry
+}
+var file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_depIdxs = []int32{
+	4, // 0: moby.buildkit.v1.source.plugin.ResolveRequest.Attrs:type_name -> moby.buildkit.v1.source.plugin.ResolveRequest.AttrsEntry
+	5, // 1: moby.buildkit.v1.source.plugin.ResolveRequest.Secrets:type_name -> moby.buildkit.v1.source.plugin.ResolveRequest.SecretsEntry
+	6, // 2: moby.buildkit.v1.source.plugin.FetchRequest.Attrs:type_name -> moby.buildkit.v1.source.plugin.FetchRequest.AttrsEntry
+	7, // 3: moby.buildkit.v1.source.plugin.FetchRequest.Secrets:type_name -> moby.buildkit.v1.source.plugin.FetchRequest.SecretsEntry
+	0, // 4: moby.buildkit.v1.source.plugin.Source.Resolve:input_type -> moby.buildkit.v1.source.plugin.ResolveRequest
+	2, // 5: moby.buildkit.v1.source.plugin.Source.Fetch:input_type -> moby.buildkit.v1.source.plugin.FetchRequest
+	1, // 6: moby.buildkit.v1.source.plugin.Source.Resolve:output_type -> moby.buildkit.v1.source.plugin.ResolveResponse
+	3, // 7: moby.buildkit.v1.source.plugin.Source.Fetch:output_type -> moby.buildkit.v1.source.plugin.FetchResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_init() }
+func file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_init() {
+	if File_github_com_moby_buildkit_source_plugin_pb_plugin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDesc), len(file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_goTypes,
+		DependencyIndexes: file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_depIdxs,
+		MessageInfos:      file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_msgTypes,
+	}.Build()
+	File_github_com_moby_buildkit_source_plugin_pb_plugin_proto = out.File
+	file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_goTypes = nil
+	file_github_com_moby_buildkit_source_plugin_pb_plugin_proto_depIdxs = nil
+}
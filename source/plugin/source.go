@@ -0,0 +1,222 @@
+// Package plugin implements the sources of the schemes served by external
+// plugins configured in buildkitd. The plugins serve the Source gRPC service
+// of the pb package: they resolve the cache key of an identifier and write
+// its content to a directory of the buildkit host.
+package plugin
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/containerd/platforms"
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/secrets"
+	"github.com/moby/buildkit/snapshot"
+	"github.com/moby/buildkit/solver"
+	solverpb "github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/source"
+	"github.com/moby/buildkit/source/plugin/pb"
+	"github.com/moby/buildkit/util/grpcplugin"
+	"github.com/pkg/errors"
+)
+
+const defaultTimeout = 10 * time.Minute
+
+type PluginOpt struct {
+	// Scheme is the identifier scheme the plugin implements
+	Scheme string
+	// Timeout limits the duration of each call to the plugin, 10 minutes by
+	// default
+	Timeout time.Duration
+	grpcplugin.Opt
+}
+
+// Plugin is the connection to a source plugin, shared by the sources of all
+// the workers.
+type Plugin struct {
+	opt    PluginOpt
+	client pb.SourceClient
+}
+
+func NewPlugin(opt PluginOpt) (*Plugin, error) {
+	if opt.Scheme == "" {
+		return nil, errors.New("source plugin scheme is required")
+	}
+	conn, err := grpcplugin.NewClient(opt.Opt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create client for source plugin %s", opt.Scheme)
+	}
+	if opt.Timeout == 0 {
+		opt.Timeout = defaultTimeout
+	}
+	return &Plugin{
+		opt:    opt,
+		client: pb.NewSourceClient(conn),
+	}, nil
+}
+
+type Opt struct {
+	Plugin        *Plugin
+	CacheAccessor cache.Accessor
+}
+
+type pluginSource struct {
+	*Plugin
+	cache cache.Accessor
+}
+
+func NewSource(opt Opt) (source.Source, error) {
+	return &pluginSource{
+		Plugin: opt.Plugin,
+		cache:  opt.CacheAccessor,
+	}, nil
+}
+
+func (ps *pluginSource) Schemes() []string {
+	return []string{ps.opt.Scheme}
+}
+
+func (ps *pluginSource) Identifier(scheme, ref string, attrs map[string]string, platform *solverpb.Platform) (source.Identifier, error) {
+	if ref == "" {
+		return nil, errors.Errorf("invalid empty %s identifier", scheme)
+	}
+	id := &PluginIdentifier{
+		SourceScheme: scheme,
+		Identifier:   scheme + "://" + ref,
+		Attrs:        attrs,
+	}
+	if platform != nil {
+		id.Platform = platforms.Format(platform.Spec())
+	}
+	return id, nil
+}
+
+func (ps *pluginSource) Resolve(ctx context.Context, id source.Identifier, sm *session.Manager, _ solver.Vertex) (source.SourceInstance, error) {
+	pluginIdentifier, ok := id.(*PluginIdentifier)
+	if !ok {
+		return nil, errors.Errorf("invalid %s identifier %v", ps.opt.Scheme, id)
+	}
+	return &pluginSourceHandler{
+		pluginSource: ps,
+		src:          *pluginIdentifier,
+		sm:           sm,
+	}, nil
+}
+
+type pluginSourceHandler struct {
+	*pluginSource
+	src PluginIdentifier
+	sm  *session.Manager
+
+	cacheKey string
+	pin      string
+	secrets  map[string][]byte
+}
+
+func (h *pluginSourceHandler) withTimeout(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	ctx, _ = context.WithTimeoutCause(ctx, h.opt.Timeout, errors.WithStack(context.DeadlineExceeded)) //nolint:govet
+	return ctx, func() { cancel(errors.WithStack(context.Canceled)) }
+}
+
+func (h *pluginSourceHandler) CacheKey(ctx context.Context, g session.Group, index int) (string, string, solver.CacheOpts, bool, error) {
+	ctx, cancel := h.withTimeout(ctx)
+	defer cancel()
+
+	req := &pb.ResolveRequest{
+		Identifier: h.src.Identifier,
+		Attrs:      h.src.Attrs,
+		Platform:   h.src.Platform,
+	}
+	resp, err := h.client.Resolve(ctx, req)
+	if err != nil {
+		return "", "", nil, false, errors.Wrapf(err, "failed to resolve %s", h.src.Identifier)
+	}
+	if len(resp.Secrets) > 0 {
+		req.Secrets = make(map[string][]byte, len(resp.Secrets))
+		for _, id := range resp.Secrets {
+			dt, err := secrets.GetSecretFromGroup(ctx, h.sm, g, id)
+			if err != nil {
+				return "", "", nil, false, errors.Wrapf(err, "failed to get secret %s for %s", id, h.src.Identifier)
+			}
+			req.Secrets[id] = dt
+		}
+		resp, err = h.client.Resolve(ctx, req)
+		if err != nil {
+			return "", "", nil, false, errors.Wrapf(err, "failed to resolve %s", h.src.Identifier)
+		}
+		for _, id := range resp.Secrets {
+			if _, ok := req.Secrets[id]; !ok {
+				return "", "", nil, false, errors.Errorf("source plugin %s requested secrets %v after receiving %v", h.opt.Scheme, resp.Secrets, slices.Sorted(maps.Keys(req.Secrets)))
+			}
+		}
+	}
+	if resp.CacheKey == "" {
+		return "", "", nil, false, errors.Errorf("source plugin %s returned no cache key for %s", h.opt.Scheme, h.src.Identifier)
+	}
+	h.cacheKey = resp.CacheKey
+	h.pin = resp.Pin
+	h.secrets = req.Secrets
+	return "source-plugin:" + h.opt.Scheme + ":" + resp.CacheKey, resp.Pin, nil, true, nil
+}
+
+func (h *pluginSourceHandler) Snapshot(ctx context.Context, g session.Group) (cache.ImmutableRef, error) {
+	if h.cacheKey == "" {
+		if _, _, _, _, err := h.CacheKey(ctx, g, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	newRef, err := h.cache.New(ctx, nil, g, cache.CachePolicyRetain, cache.WithDescription("source plugin "+h.src.Identifier))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if newRef != nil {
+			newRef.Release(context.WithoutCancel(ctx))
+		}
+	}()
+
+	mount, err := newRef.Mount(ctx, false, g)
+	if err != nil {
+		return nil, err
+	}
+	lm := snapshot.LocalMounter(mount)
+	dir, err := lm.Mount()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if lm != nil {
+			lm.Unmount()
+		}
+	}()
+
+	fetchCtx, cancel := h.withTimeout(ctx)
+	defer cancel()
+	if _, err := h.client.Fetch(fetchCtx, &pb.FetchRequest{
+		Identifier: h.src.Identifier,
+		Attrs:      h.src.Attrs,
+		Platform:   h.src.Platform,
+		Secrets:    h.secrets,
+		Pin:        h.pin,
+		Path:       dir,
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch %s", h.src.Identifier)
+	}
+
+	if err := lm.Unmount(); err != nil {
+		return nil, err
+	}
+	lm = nil
+
+	ref, err := newRef.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	newRef = nil
+	return ref, nil
+}
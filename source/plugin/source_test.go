@@ -0,0 +1,165 @@
+package plugin
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/diff/apply"
+	ctdmetadata "github.com/containerd/containerd/v2/core/metadata"
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	"github.com/containerd/containerd/v2/plugins/diff/walking"
+	"github.com/containerd/containerd/v2/plugins/snapshots/native"
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/cache/metadata"
+	"github.com/moby/buildkit/snapshot"
+	containerdsnapshot "github.com/moby/buildkit/snapshot/containerd"
+	solverpb "github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/source/plugin/pb"
+	"github.com/moby/buildkit/util/grpcplugin"
+	"github.com/moby/buildkit/util/leaseutil"
+	"github.com/moby/buildkit/util/winlayers"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/grpc"
+)
+
+// testPlugin serves hg://host/repo#rev identifiers, resolving the revisions
+// from a map
+type testPlugin struct {
+	pb.UnimplementedSourceServer
+	revs    map[string]string
+	fetches int
+}
+
+func (p *testPlugin) Resolve(_ context.Context, req *pb.ResolveRequest) (*pb.ResolveResponse, error) {
+	rev, ok := p.revs[req.Identifier]
+	if !ok {
+		return &pb.ResolveResponse{}, nil
+	}
+	return &pb.ResolveResponse{CacheKey: rev + ":" + req.Platform, Pin: rev}, nil
+}
+
+func (p *testPlugin) Fetch(_ context.Context, req *pb.FetchRequest) (*pb.FetchResponse, error) {
+	p.fetches++
+	if req.Pin == "" {
+		return nil, errors.New("missing pin")
+	}
+	if err := os.WriteFile(filepath.Join(req.Path, "rev"), []byte(req.Pin), 0644); err != nil {
+		return nil, err
+	}
+	return &pb.FetchResponse{}, nil
+}
+
+func TestPluginSource(t *testing.T) {
+	ctx := context.TODO()
+
+	sock := filepath.Join(t.TempDir(), "plugin.sock")
+	l, err := net.Listen("unix", sock)
+	require.NoError(t, err)
+	tp := &testPlugin{revs: map[string]string{
+		"hg://example.com/repo#tip": "8a1b2c",
+	}}
+	srv := grpc.NewServer()
+	pb.RegisterSourceServer(srv, tp)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	p, err := NewPlugin(PluginOpt{Scheme: "hg", Opt: grpcplugin.Opt{Address: "unix://" + sock}})
+	require.NoError(t, err)
+	ps, err := NewSource(Opt{Plugin: p, CacheAccessor: newCacheManager(t)})
+	require.NoError(t, err)
+	require.Equal(t, []string{"hg"}, ps.Schemes())
+
+	_, err = ps.Identifier("hg", "", nil, nil)
+	require.ErrorContains(t, err, "invalid empty hg identifier")
+
+	id, err := ps.Identifier("hg", "example.com/repo#tip", nil, &solverpb.Platform{OS: "linux", Architecture: "arm64"})
+	require.NoError(t, err)
+	require.Equal(t, "hg://example.com/repo#tip", id.(*PluginIdentifier).Identifier)
+
+	h, err := ps.Resolve(ctx, id, nil, nil)
+	require.NoError(t, err)
+	k, pin, _, done, err := h.CacheKey(ctx, nil, 0)
+	require.NoError(t, err)
+	require.Equal(t, "source-plugin:hg:8a1b2c:linux/arm64", k)
+	require.Equal(t, "8a1b2c", pin)
+	require.True(t, done)
+
+	ref, err := h.Snapshot(ctx, nil)
+	require.NoError(t, err)
+	defer ref.Release(context.TODO())
+	require.Equal(t, 1, tp.fetches)
+	require.Equal(t, "8a1b2c", readFile(ctx, t, ref, "rev"))
+
+	id, err = ps.Identifier("hg", "example.com/repo#missing", nil, nil)
+	require.NoError(t, err)
+	h, err = ps.Resolve(ctx, id, nil, nil)
+	require.NoError(t, err)
+	_, _, _, _, err = h.CacheKey(ctx, nil, 0)
+	require.ErrorContains(t, err, "source plugin hg returned no cache key for hg://example.com/repo#missing")
+}
+
+func readFile(ctx context.Context, t *testing.T, ref cache.ImmutableRef, fp string) string {
+	mount, err := ref.Mount(ctx, true, nil)
+	require.NoError(t, err)
+	lm := snapshot.LocalMounter(mount)
+	dir, err := lm.Mount()
+	require.NoError(t, err)
+	defer lm.Unmount()
+	dt, err := os.ReadFile(filepath.Join(dir, fp))
+	require.NoError(t, err)
+	return string(dt)
+}
+
+func newCacheManager(t *testing.T) cache.Accessor {
+	tmpdir := t.TempDir()
+
+	snapshotter, err := native.NewSnapshotter(filepath.Join(tmpdir, "snapshots"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, snapshotter.Close())
+	})
+
+	store, err := local.NewStore(tmpdir)
+	require.NoError(t, err)
+
+	db, err := bolt.Open(filepath.Join(tmpdir, "containerdmeta.db"), 0644, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, db.Close())
+	})
+
+	mdb := ctdmetadata.NewDB(db, store, map[string]snapshots.Snapshotter{
+		"native": snapshotter,
+	})
+
+	md, err := metadata.NewStore(filepath.Join(tmpdir, "metadata.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, md.Close())
+	})
+
+	lm := leaseutil.WithNamespace(ctdmetadata.NewLeaseManager(mdb), "buildkit")
+	c := mdb.ContentStore()
+	cm, err := cache.NewManager(cache.ManagerOpt{
+		Snapshotter:    snapshot.FromContainerdSnapshotter("native", containerdsnapshot.NSSnapshotter("buildkit", mdb.Snapshotter("native")), nil),
+		MetadataStore:  md,
+		LeaseManager:   lm,
+		ContentStore:   c,
+		Applier:        winlayers.NewFileSystemApplierWithWindows(c, apply.NewFileSystemApplier(c)),
+		Differ:         winlayers.NewWalkingDiffWithWindows(c, walking.NewWalkingDiff(c)),
+		GarbageCollect: mdb.GarbageCollect,
+		Root:           tmpdir,
+		MountPoolRoot:  filepath.Join(tmpdir, "cachemounts"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, cm.Close())
+	})
+	return cm
+}
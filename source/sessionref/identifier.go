@@ -0,0 +1,27 @@
+package sessionref
+
+import (
+	"github.com/moby/buildkit/solver/llbsolver/provenance"
+	"github.com/moby/buildkit/source"
+	srctypes "github.com/moby/buildkit/source/types"
+)
+
+type SessionRefIdentifier struct {
+	Name string
+}
+
+func NewSessionRefIdentifier(str string) (*SessionRefIdentifier, error) {
+	return &SessionRefIdentifier{Name: str}, nil
+}
+
+func (*SessionRefIdentifier) Scheme() string {
+	return srctypes.SessionRefScheme
+}
+
+var _ source.Identifier = (*SessionRefIdentifier)(nil)
+
+// Capture records nothing: the sources of the ref were captured by the solve
+// that retained it.
+func (id *SessionRefIdentifier) Capture(c *provenance.Capture, pin string) error {
+	return nil
+}
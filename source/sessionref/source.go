@@ -0,0 +1,99 @@
+// Package sessionref implements the source of the refs retained by name by
+// the solves of a session, so that later solves of the same session can use
+// them as inputs without solving them again.
+package sessionref
+
+import (
+	"context"
+	"slices"
+
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/solver"
+	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/source"
+	srctypes "github.com/moby/buildkit/source/types"
+	"github.com/pkg/errors"
+)
+
+type Opt struct {
+	Store *Store
+}
+
+func NewSource(opt Opt) (source.Source, error) {
+	return &sessionRefSource{
+		store: opt.Store,
+	}, nil
+}
+
+type sessionRefSource struct {
+	store *Store
+}
+
+func (rs *sessionRefSource) Schemes() []string {
+	return []string{srctypes.SessionRefScheme}
+}
+
+func (rs *sessionRefSource) Identifier(scheme, ref string, attrs map[string]string, platform *pb.Platform) (source.Identifier, error) {
+	if ref == "" {
+		return nil, errors.New("session ref name is required")
+	}
+	return NewSessionRefIdentifier(ref)
+}
+
+func (rs *sessionRefSource) Resolve(ctx context.Context, id source.Identifier, sm *session.Manager, _ solver.Vertex) (source.SourceInstance, error) {
+	sessionRefIdentifier, ok := id.(*SessionRefIdentifier)
+	if !ok {
+		return nil, errors.Errorf("invalid session ref identifier %v", id)
+	}
+	return &sessionRefSourceHandler{
+		src:   *sessionRefIdentifier,
+		store: rs.store,
+	}, nil
+}
+
+type sessionRefSourceHandler struct {
+	src   SessionRefIdentifier
+	store *Store
+	// refID is the ID of the ref the cache key was computed for
+	refID string
+}
+
+func (h *sessionRefSourceHandler) get(g session.Group) (cache.ImmutableRef, error) {
+	var ids []string
+	if g != nil {
+		iter := g.SessionIterator()
+		for id := iter.NextSession(); id != ""; id = iter.NextSession() {
+			ids = append(ids, id)
+		}
+	}
+	ref, ok := h.store.Get(slices.Compact(ids), h.src.Name)
+	if !ok {
+		return nil, errors.Errorf("session ref %q not found", h.src.Name)
+	}
+	return ref, nil
+}
+
+func (h *sessionRefSourceHandler) CacheKey(ctx context.Context, g session.Group, index int) (string, string, solver.CacheOpts, bool, error) {
+	ref, err := h.get(g)
+	if err != nil {
+		return "", "", nil, false, err
+	}
+	h.refID = ref.ID()
+	if err := ref.Release(context.WithoutCancel(ctx)); err != nil {
+		return "", "", nil, false, err
+	}
+	return "session-ref:" + h.src.Name + ":" + h.refID, h.refID, nil, true, nil
+}
+
+func (h *sessionRefSourceHandler) Snapshot(ctx context.Context, g session.Group) (cache.ImmutableRef, error) {
+	ref, err := h.get(g)
+	if err != nil {
+		return nil, err
+	}
+	if h.refID != "" && ref.ID() != h.refID {
+		ref.Release(context.WithoutCancel(ctx))
+		return nil, errors.Errorf("session ref %q was replaced during the solve", h.src.Name)
+	}
+	return ref, nil
+}
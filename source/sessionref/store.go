@@ -0,0 +1,75 @@
+package sessionref
+
+import (
+	"context"
+	"sync"
+
+	"github.com/moby/buildkit/cache"
+	"github.com/moby/buildkit/util/bklog"
+)
+
+// Store holds the refs that the solves of a session retained by name. The
+// refs are released when the session closes.
+type Store struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]cache.ImmutableRef
+}
+
+// NewStore returns an empty store
+func NewStore() *Store {
+	return &Store{
+		sessions: map[string]map[string]cache.ImmutableRef{},
+	}
+}
+
+// Set retains ref in the session as name, replacing the ref previously
+// retained with the same name. sessionCtx is the context of the session
+// connection; the refs of the session are released once it is done. Set
+// takes ownership of ref.
+func (s *Store) Set(sessionCtx context.Context, sessionID, name string, ref cache.ImmutableRef) {
+	s.mu.Lock()
+	refs, ok := s.sessions[sessionID]
+	if !ok {
+		refs = map[string]cache.ImmutableRef{}
+		s.sessions[sessionID] = refs
+		go func() {
+			<-sessionCtx.Done()
+			s.release(sessionID)
+		}()
+	}
+	prev := refs[name]
+	refs[name] = ref
+	s.mu.Unlock()
+
+	if prev != nil {
+		if err := prev.Release(context.TODO()); err != nil {
+			bklog.G(sessionCtx).Errorf("failed to release session ref %s: %v", name, err)
+		}
+	}
+}
+
+// Get returns a clone of the ref retained as name by one of the sessions.
+// The caller needs to release it.
+func (s *Store) Get(sessionIDs []string, name string) (cache.ImmutableRef, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range sessionIDs {
+		if ref, ok := s.sessions[id][name]; ok {
+			return ref.Clone(), true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) release(sessionID string) {
+	s.mu.Lock()
+	refs := s.sessions[sessionID]
+	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+
+	for name, ref := range refs {
+		if err := ref.Release(context.TODO()); err != nil {
+			bklog.L.Errorf("failed to release session ref %s: %v", name, err)
+		}
+	}
+}
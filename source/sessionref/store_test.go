@@ -0,0 +1,74 @@
+package sessionref
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/cache"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type testRef struct {
+	cache.ImmutableRef
+	id   string
+	refs *atomic.Int32
+}
+
+func newTestRef(id string) *testRef {
+	r := &testRef{id: id, refs: &atomic.Int32{}}
+	r.refs.Add(1)
+	return r
+}
+
+func (r *testRef) ID() string {
+	return r.id
+}
+
+func (r *testRef) Clone() cache.ImmutableRef {
+	r.refs.Add(1)
+	return &testRef{id: r.id, refs: r.refs}
+}
+
+func (r *testRef) Release(context.Context) error {
+	r.refs.Add(-1)
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	s := NewStore()
+	ctx, cancel := context.WithCancelCause(context.TODO())
+
+	foo := newTestRef("foo")
+	s.Set(ctx, "session1", "step", foo)
+
+	ref, ok := s.Get([]string{"session2", "session1"}, "step")
+	require.True(t, ok)
+	require.Equal(t, "foo", ref.ID())
+	require.Equal(t, int32(2), foo.refs.Load())
+	require.NoError(t, ref.Release(context.TODO()))
+
+	_, ok = s.Get([]string{"session2"}, "step")
+	require.False(t, ok)
+	_, ok = s.Get([]string{"session1"}, "other")
+	require.False(t, ok)
+
+	// replacing a ref releases the previous one
+	bar := newTestRef("bar")
+	s.Set(ctx, "session1", "step", bar)
+	require.Equal(t, int32(0), foo.refs.Load())
+	ref, ok = s.Get([]string{"session1"}, "step")
+	require.True(t, ok)
+	require.Equal(t, "bar", ref.ID())
+	require.NoError(t, ref.Release(context.TODO()))
+
+	// the refs are released when the session closes
+	cancel(errors.WithStack(context.Canceled))
+	require.Eventually(t, func() bool {
+		return bar.refs.Load() == 0
+	}, time.Second, 10*time.Millisecond)
+	_, ok = s.Get([]string{"session1"}, "step")
+	require.False(t, ok)
+}
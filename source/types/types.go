@@ -7,4 +7,5 @@ const (
 	HTTPScheme        = "http"
 	HTTPSScheme       = "https"
 	OCIScheme         = "oci-layout"
+	SessionRefScheme  = "session-ref"
 )
@@ -3,6 +3,7 @@ package sourcepolicy
 import (
 	"context"
 
+	"github.com/moby/buildkit/solver/errdefs"
 	"github.com/moby/buildkit/solver/pb"
 	spb "github.com/moby/buildkit/sourcepolicy/pb"
 	"github.com/moby/buildkit/util/bklog"
@@ -154,7 +155,7 @@ func (e *Engine) evaluatePolicy(ctx context.Context, pol *spb.Policy, srcOp *pb.
 	}
 
 	if deny {
-		return false, errors.Wrapf(ErrSourceDenied, "source %q denied by policy", ident)
+		return false, errdefs.WithCode(errors.Wrapf(ErrSourceDenied, "source %q denied by policy", ident), errdefs.CodePolicyDenied)
 	}
 	return false, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/moby/buildkit/solver/errdefs"
 	"github.com/moby/buildkit/solver/pb"
 	spb "github.com/moby/buildkit/sourcepolicy/pb"
 	"github.com/moby/buildkit/util/bklog"
@@ -89,6 +90,7 @@ func testMultiplePolicies(t *testing.T) {
 		Identifier: "docker-image://docker.io/library/busybox:latest",
 	})
 	require.ErrorIs(t, err, ErrSourceDenied)
+	require.Equal(t, errdefs.CodePolicyDenied, errdefs.GetCode(err))
 	require.False(t, mut)
 }
 
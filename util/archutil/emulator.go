@@ -0,0 +1,86 @@
+package archutil
+
+import (
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/containerd/platforms"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+var qemuArchMap = map[string]string{
+	"arm64":   "aarch64",
+	"amd64":   "x86_64",
+	"riscv64": "riscv64",
+	"arm":     "arm",
+	"s390x":   "s390x",
+	"ppc64le": "ppc64le",
+	"386":     "i386",
+}
+
+// Emulator is an emulator running the processes of a platform that the host
+// doesn't run natively
+type Emulator struct {
+	Platform ocispecs.Platform
+	// Path is the QEMU user emulator binary mounted in the containers of the
+	// platform. It is empty for the platforms run by the binfmt_misc handlers
+	// registered in the kernel.
+	Path string
+}
+
+// QEMUBinary returns the name of the QEMU user emulator binary of an
+// architecture looked up in PATH
+func QEMUBinary(arch string) string {
+	if a, ok := qemuArchMap[arch]; ok {
+		arch = a
+	}
+	return "buildkit-qemu-" + arch
+}
+
+// Emulators returns the emulators available on the host, sorted by platform
+func Emulators() []Emulator {
+	native := nativePlatform()
+	var out []Emulator
+	for _, p := range SupportedPlatforms(false) {
+		if p.Architecture != native.Architecture {
+			out = append(out, Emulator{Platform: p})
+		}
+	}
+	if native.OS == "linux" {
+		for arch := range qemuArchMap {
+			if arch == native.Architecture || slices.ContainsFunc(out, func(e Emulator) bool {
+				return e.Platform.Architecture == arch
+			}) {
+				continue
+			}
+			if path, err := exec.LookPath(QEMUBinary(arch)); err == nil {
+				out = append(out, Emulator{Platform: linux(arch), Path: path})
+			}
+		}
+	}
+	slices.SortFunc(out, func(a, b Emulator) int {
+		return strings.Compare(platforms.Format(a.Platform), platforms.Format(b.Platform))
+	})
+	return out
+}
+
+// CanRun returns true if the processes of the platform can run on a host with
+// the native platform and emulators, natively or emulated. Platforms of
+// another operating system than the native one are never emulated and are
+// left to the executor to validate.
+func CanRun(p, native ocispecs.Platform, emulators []Emulator) bool {
+	if p.OS != native.OS || platforms.Only(native).Match(p) {
+		return true
+	}
+	for _, e := range emulators {
+		if e.Path != "" && p.Architecture == "amd64" && p.Variant != "" && p.Variant != "v2" {
+			// the QEMU binaries don't run the amd64 microarchitecture levels
+			continue
+		}
+		if e.Path != "" && e.Platform.Architecture == p.Architecture || platforms.Only(e.Platform).Match(p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+// Package cacheevents writes an append-only log of the build cache events of
+// the daemon, for the external systems analyzing the cache efficiency.
+package cacheevents
+
+import (
+	"sync/atomic"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+)
+
+// Type is the type of a cache event
+type Type string
+
+const (
+	// Insert is logged when the result of a vertex is saved to the cache
+	Insert Type = "cache.insert"
+	// Hit is logged when the result of a vertex is loaded from the cache
+	Hit Type = "cache.hit"
+	// Evict is logged for every record removed by a prune or the garbage
+	// collection
+	Evict Type = "cache.evict"
+	// Export is logged when a cache is exported to a cache backend
+	Export Type = "cache.export"
+	// Import is logged when a cache is imported from a cache backend
+	Import Type = "cache.import"
+)
+
+// Event is a cache event. The fields that don't apply to the type of the
+// event, or that are not known, are empty.
+type Event struct {
+	Time time.Time `json:"time"`
+	Type Type      `json:"type"`
+	// Digest is the digest of the vertex for insert and hit events, and the
+	// digest of the cache manifest for export and import events
+	Digest digest.Digest `json:"digest,omitempty"`
+	// CacheKey is the digest of the cache key of insert events
+	CacheKey digest.Digest `json:"cacheKey,omitempty"`
+	// Record is the ID of the cache record
+	Record string `json:"record,omitempty"`
+	// Size is the size of the evicted record, or the total size of the
+	// layers of the exported or imported cache
+	Size int64 `json:"size,omitempty"`
+	// Layers is the number of layers of the exported or imported cache
+	Layers int `json:"layers,omitempty"`
+	// Source is the ID of the cache manager of hits, the ref of the exported
+	// or imported cache
+	Source      string `json:"source,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+var current atomic.Pointer[Log]
+
+// SetLog sets the log the events of the daemon are written to. The events
+// are not logged if l is nil.
+func SetLog(l *Log) {
+	current.Store(l)
+}
+
+// Enabled returns true if the events are logged, so that the callers can
+// skip computing the events otherwise
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Record logs ev if a log is set. It doesn't block.
+func Record(ev Event) {
+	l := current.Load()
+	if l == nil {
+		return
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now().UTC()
+	}
+	l.Send(ev)
+}
@@ -0,0 +1,269 @@
+package cacheevents
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/pkg/errors"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// FormatJSON writes every event as a JSON object by line
+	FormatJSON = "json"
+	// FormatOTLP writes every event as a JSON encoded OTLP LogsData by line,
+	// that can be read by the OpenTelemetry collectors
+	FormatOTLP = "otlp"
+
+	defaultMaxFiles = 5
+	queueSize       = 1024
+	scopeName       = "github.com/moby/buildkit/util/cacheevents"
+)
+
+// LogOpt configures a cache events log
+type LogOpt struct {
+	// Path is the file the events are appended to
+	Path string
+	// Format is FormatJSON or FormatOTLP, FormatJSON by default
+	Format string
+	// MaxSize rotates the file before it grows larger than MaxSize bytes.
+	// The file is not rotated by size if it is zero.
+	MaxSize int64
+	// MaxAge rotates the file once it has been written for MaxAge. The file
+	// is not rotated by age if it is zero.
+	MaxAge time.Duration
+	// MaxFiles is the number of rotated files that are kept, suffixed with
+	// their number from the most recent one. It defaults to 5.
+	MaxFiles int
+}
+
+// Log appends events to a file, rotating it once it grows too large or too
+// old. The events are written in order by a background goroutine.
+type Log struct {
+	opt   LogOpt
+	queue chan Event
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	f       *os.File
+	size    int64
+	started time.Time
+	dropped atomic.Int64
+}
+
+// OpenLog opens the log file of opt, creating it if it doesn't exist
+func OpenLog(opt LogOpt) (*Log, error) {
+	if opt.Path == "" {
+		return nil, errors.New("cache events log path not set")
+	}
+	switch opt.Format {
+	case "":
+		opt.Format = FormatJSON
+	case FormatJSON, FormatOTLP:
+	default:
+		return nil, errors.Errorf("invalid cache events log format %q", opt.Format)
+	}
+	if opt.MaxSize < 0 || opt.MaxAge < 0 || opt.MaxFiles < 0 {
+		return nil, errors.New("cache events log rotation settings must not be negative")
+	}
+	if opt.MaxFiles == 0 {
+		opt.MaxFiles = defaultMaxFiles
+	}
+	if err := os.MkdirAll(filepath.Dir(opt.Path), 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	l := &Log{
+		opt:   opt,
+		queue: make(chan Event, queueSize),
+		done:  make(chan struct{}),
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	l.wg.Add(1)
+	go l.run()
+	return l, nil
+}
+
+// Send queues ev to be written. The event is dropped if the queue is full
+// or the log is closed.
+func (l *Log) Send(ev Event) {
+	select {
+	case <-l.done:
+		return
+	default:
+	}
+	select {
+	case l.queue <- ev:
+	default:
+		if l.dropped.Add(1) == 1 {
+			bklog.L.Warnf("cache events log %s queue is full, dropping events", l.opt.Path)
+		}
+	}
+}
+
+// Close writes the queued events and closes the file
+func (l *Log) Close() error {
+	close(l.done)
+	l.wg.Wait()
+	return errors.WithStack(l.f.Close())
+}
+
+func (l *Log) run() {
+	defer l.wg.Done()
+	for {
+		select {
+		case ev := <-l.queue:
+			l.write(ev)
+		case <-l.done:
+			for {
+				select {
+				case ev := <-l.queue:
+					l.write(ev)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (l *Log) write(ev Event) {
+	dt, err := l.marshal(ev)
+	if err != nil {
+		bklog.L.Errorf("failed to marshal %s cache event: %v", ev.Type, err)
+		return
+	}
+	dt = append(dt, '\n')
+	if l.needsRotate(int64(len(dt))) {
+		if err := l.rotate(); err != nil {
+			bklog.L.Errorf("failed to rotate cache events log %s: %v", l.opt.Path, err)
+		}
+	}
+	n, err := l.f.Write(dt)
+	l.size += int64(n)
+	if err != nil {
+		bklog.L.Errorf("failed to write cache events log %s: %v", l.opt.Path, err)
+	}
+}
+
+func (l *Log) needsRotate(n int64) bool {
+	if l.size == 0 {
+		return false
+	}
+	if l.opt.MaxSize > 0 && l.size+n > l.opt.MaxSize {
+		return true
+	}
+	return l.opt.MaxAge > 0 && time.Since(l.started) >= l.opt.MaxAge
+}
+
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.opt.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.WithStack(err)
+	}
+	l.f = f
+	l.size = st.Size()
+	l.started = time.Now()
+	return nil
+}
+
+// rotate renames the file to Path.1 after renaming the older files to the
+// next number, removing the oldest one. The file is opened again even if the
+// files can't be renamed.
+func (l *Log) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return errors.WithStack(err)
+	}
+	err := l.renameFiles()
+	if err2 := l.open(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+func (l *Log) renameFiles() error {
+	if err := os.Remove(l.rotatedPath(l.opt.MaxFiles)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.WithStack(err)
+	}
+	for i := l.opt.MaxFiles - 1; i >= 1; i-- {
+		if err := os.Rename(l.rotatedPath(i), l.rotatedPath(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return errors.WithStack(err)
+		}
+	}
+	return errors.WithStack(os.Rename(l.opt.Path, l.rotatedPath(1)))
+}
+
+func (l *Log) rotatedPath(i int) string {
+	return l.opt.Path + "." + strconv.Itoa(i)
+}
+
+func (l *Log) marshal(ev Event) ([]byte, error) {
+	if l.opt.Format == FormatOTLP {
+		return protojson.Marshal(toOTLP(ev))
+	}
+	return json.Marshal(ev)
+}
+
+func toOTLP(ev Event) *logspb.LogsData {
+	var attrs []*commonpb.KeyValue
+	addString := func(k, v string) {
+		if v != "" {
+			attrs = append(attrs, &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}})
+		}
+	}
+	addInt := func(k string, v int64) {
+		if v != 0 {
+			attrs = append(attrs, &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}})
+		}
+	}
+	addString("cache.digest", ev.Digest.String())
+	addString("cache.key", ev.CacheKey.String())
+	addString("cache.record", ev.Record)
+	addInt("cache.size", ev.Size)
+	addInt("cache.layers", int64(ev.Layers))
+	addString("cache.source", ev.Source)
+
+	var body *commonpb.AnyValue
+	if ev.Description != "" {
+		body = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: ev.Description}}
+	}
+
+	ts := uint64(ev.Time.UnixNano())
+	return &logspb.LogsData{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{{
+					Key:   "service.name",
+					Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "buildkitd"}},
+				}},
+			},
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope: &commonpb.InstrumentationScope{Name: scopeName},
+				LogRecords: []*logspb.LogRecord{{
+					TimeUnixNano:         ts,
+					ObservedTimeUnixNano: ts,
+					SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+					SeverityText:         "INFO",
+					EventName:            string(ev.Type),
+					Body:                 body,
+					Attributes:           attrs,
+				}},
+			}},
+		}},
+	}
+}
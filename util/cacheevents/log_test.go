@@ -0,0 +1,149 @@
+package cacheevents
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func readLines(t *testing.T, p string) []string {
+	f, err := os.Open(p)
+	require.NoError(t, err)
+	defer f.Close()
+	var lines []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, s.Text())
+	}
+	require.NoError(t, s.Err())
+	return lines
+}
+
+func TestLogJSON(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "events", "cache.log")
+	l, err := OpenLog(LogOpt{Path: p})
+	require.NoError(t, err)
+	SetLog(l)
+	t.Cleanup(func() {
+		SetLog(nil)
+	})
+	require.True(t, Enabled())
+
+	dgst := digest.FromString("vertex")
+	Record(Event{Type: Hit, Digest: dgst, Record: "rec1", Source: "local"})
+	Record(Event{Type: Evict, Record: "rec1", Size: 1024, Description: "mount /src"})
+	SetLog(nil)
+	require.False(t, Enabled())
+	Record(Event{Type: Insert})
+	require.NoError(t, l.Close())
+	// events sent after close are dropped
+	l.Send(Event{Type: Insert})
+
+	lines := readLines(t, p)
+	require.Len(t, lines, 2)
+	var ev Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &ev))
+	require.Equal(t, Hit, ev.Type)
+	require.Equal(t, dgst, ev.Digest)
+	require.Equal(t, "rec1", ev.Record)
+	require.Equal(t, "local", ev.Source)
+	require.False(t, ev.Time.IsZero())
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &ev))
+	require.Equal(t, Evict, ev.Type)
+	require.Equal(t, int64(1024), ev.Size)
+
+	// the log is appended to
+	l, err = OpenLog(LogOpt{Path: p})
+	require.NoError(t, err)
+	l.Send(Event{Type: Insert, Time: time.Now()})
+	require.NoError(t, l.Close())
+	require.Len(t, readLines(t, p), 3)
+}
+
+func TestLogOTLP(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cache.log")
+	l, err := OpenLog(LogOpt{Path: p, Format: FormatOTLP})
+	require.NoError(t, err)
+	now := time.Now()
+	dgst := digest.FromString("manifest")
+	l.Send(Event{Time: now, Type: Export, Digest: dgst, Size: 4096, Layers: 2, Source: "registry.example.com/cache"})
+	require.NoError(t, l.Close())
+
+	lines := readLines(t, p)
+	require.Len(t, lines, 1)
+	var ld logspb.LogsData
+	require.NoError(t, protojson.Unmarshal([]byte(lines[0]), &ld))
+	require.Len(t, ld.ResourceLogs, 1)
+	require.Equal(t, "buildkitd", ld.ResourceLogs[0].Resource.Attributes[0].Value.GetStringValue())
+	rec := ld.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	require.Equal(t, string(Export), rec.EventName)
+	require.Equal(t, uint64(now.UnixNano()), rec.TimeUnixNano)
+	require.Equal(t, dgst.String(), attrValue(rec, "cache.digest").GetStringValue())
+	require.Equal(t, int64(4096), attrValue(rec, "cache.size").GetIntValue())
+	require.Equal(t, int64(2), attrValue(rec, "cache.layers").GetIntValue())
+	require.Nil(t, attrValue(rec, "cache.record"))
+}
+
+func attrValue(rec *logspb.LogRecord, key string) interface {
+	GetStringValue() string
+	GetIntValue() int64
+} {
+	for _, kv := range rec.Attributes {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return nil
+}
+
+func TestLogRotate(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cache.log")
+	l, err := OpenLog(LogOpt{Path: p, MaxSize: 300, MaxFiles: 2})
+	require.NoError(t, err)
+	for i := range 10 {
+		// write in order, every event is about 100 bytes
+		l.write(Event{Type: Insert, Record: string(rune('a' + i)), Digest: digest.FromString("x")})
+	}
+	require.NoError(t, l.Close())
+
+	for _, name := range []string{p, p + ".1", p + ".2"} {
+		st, err := os.Stat(name)
+		require.NoError(t, err)
+		require.LessOrEqual(t, st.Size(), int64(300))
+	}
+	_, err = os.Stat(p + ".3")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	var ev Event
+	require.NoError(t, json.Unmarshal([]byte(readLines(t, p+".2")[0]), &ev))
+	last := readLines(t, p)
+	require.NoError(t, json.Unmarshal([]byte(last[len(last)-1]), &ev))
+	require.Equal(t, "j", ev.Record)
+
+	_, err = OpenLog(LogOpt{Path: p, Format: "xml"})
+	require.Error(t, err)
+	_, err = OpenLog(LogOpt{Path: p, MaxSize: -1})
+	require.Error(t, err)
+}
+
+func TestLogRotateAge(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "cache.log")
+	l, err := OpenLog(LogOpt{Path: p, MaxAge: time.Hour})
+	require.NoError(t, err)
+	l.write(Event{Type: Hit})
+	l.write(Event{Type: Hit})
+	l.started = time.Now().Add(-2 * time.Hour)
+	l.write(Event{Type: Evict})
+	require.NoError(t, l.Close())
+
+	require.Len(t, readLines(t, p+".1"), 2)
+	require.Len(t, readLines(t, p), 1)
+}
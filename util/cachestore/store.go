@@ -42,7 +42,7 @@ func Records(ctx context.Context, store solver.CacheKeyStorage) ([]*Record, erro
 	}
 
 	roots := []string{}
-	if err := store.Walk(func(id string) error {
+	if err := solver.WalkPaged(ctx, store, solver.DefaultWalkPageSize, func(id string) error {
 		if strings.HasPrefix(string(id), "random:") || strings.HasPrefix(string(id), "sha256:") {
 			roots = append(roots, id)
 		}
@@ -0,0 +1,53 @@
+package clientidentity
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// ServerCredentials returns gRPC transport credentials for servers that
+// accept connections from listeners created with tls.NewListener. The
+// credentials perform no handshake of their own but expose the TLS
+// connection state to the request context so that the client certificate
+// can be used as identity. Non-TLS connections are passed through.
+func ServerCredentials() credentials.TransportCredentials {
+	return &tlsInfoCredentials{}
+}
+
+type tlsInfoCredentials struct{}
+
+func (c *tlsInfoCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return nil, nil, errors.New("client handshake not supported")
+}
+
+func (c *tlsInfoCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return conn, nil, nil
+	}
+	if err := tc.Handshake(); err != nil {
+		return nil, nil, errors.Wrap(err, "tls handshake failed")
+	}
+	return conn, credentials.TLSInfo{
+		State: tc.ConnectionState(),
+		CommonAuthInfo: credentials.CommonAuthInfo{
+			SecurityLevel: credentials.PrivacyAndIntegrity,
+		},
+	}, nil
+}
+
+func (c *tlsInfoCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{}
+}
+
+func (c *tlsInfoCredentials) Clone() credentials.TransportCredentials {
+	return &tlsInfoCredentials{}
+}
+
+func (c *tlsInfoCredentials) OverrideServerName(string) error {
+	return nil
+}
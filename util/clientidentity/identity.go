@@ -0,0 +1,74 @@
+// Package clientidentity resolves the identity of authenticated clients
+// connected to the daemon.
+package clientidentity
+
+import (
+	"context"
+	"crypto/x509"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	// MethodTLS is used for identities established with a verified TLS
+	// client certificate
+	MethodTLS = "tls"
+)
+
+// Identity describes an authenticated client
+type Identity struct {
+	// Name is the principal name of the client
+	Name string
+	// Method is the authentication method that established the identity
+	Method string
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the client identity
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// FromContext returns the identity of the client that made the request
+// associated with the context. Identities set with WithIdentity take
+// precedence over the verified TLS client certificate of the gRPC peer.
+// Nil is returned for unauthenticated clients.
+func FromContext(ctx context.Context) *Identity {
+	if id, ok := ctx.Value(identityKey{}).(*Identity); ok {
+		return id
+	}
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	for _, chain := range info.State.VerifiedChains {
+		if len(chain) > 0 {
+			if name := certificateName(chain[0]); name != "" {
+				return &Identity{Name: name, Method: MethodTLS}
+			}
+		}
+	}
+	return nil
+}
+
+// certificateName returns the principal name of a client certificate. The
+// subject common name is preferred, falling back to the first URI (e.g.
+// SPIFFE ID) and DNS subject alternative names.
+func certificateName(cert *x509.Certificate) string {
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName
+	}
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package clientidentity
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+func TestFromContext(t *testing.T) {
+	ctx := context.TODO()
+	require.Nil(t, FromContext(ctx))
+
+	withCert := func(cert *x509.Certificate) context.Context {
+		return peer.NewContext(ctx, &peer.Peer{
+			AuthInfo: credentials.TLSInfo{
+				State: tls.ConnectionState{
+					VerifiedChains: [][]*x509.Certificate{{cert}},
+				},
+			},
+		})
+	}
+
+	id := FromContext(withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "ci-runner"}}))
+	require.Equal(t, &Identity{Name: "ci-runner", Method: MethodTLS}, id)
+
+	u, err := url.Parse("spiffe://example.org/builder")
+	require.NoError(t, err)
+	id = FromContext(withCert(&x509.Certificate{URIs: []*url.URL{u}}))
+	require.Equal(t, &Identity{Name: "spiffe://example.org/builder", Method: MethodTLS}, id)
+
+	require.Nil(t, FromContext(withCert(&x509.Certificate{})))
+
+	// unverified peer certificates are not used as identity
+	require.Nil(t, FromContext(peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{
+				PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "foo"}}},
+			},
+		},
+	})))
+
+	explicit := &Identity{Name: "bar", Method: "test"}
+	require.Equal(t, explicit, FromContext(WithIdentity(withCert(&x509.Certificate{Subject: pkix.Name{CommonName: "ci-runner"}}), explicit)))
+}
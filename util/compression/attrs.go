@@ -1,8 +1,11 @@
 package compression
 
 import (
+	"fmt"
 	"strconv"
 
+	units "github.com/docker/go-units"
+	digest "github.com/opencontainers/go-digest"
 	"github.com/pkg/errors"
 )
 
@@ -10,6 +13,15 @@ const (
 	attrLayerCompression = "compression"
 	attrForceCompression = "force-compression"
 	attrCompressionLevel = "compression-level"
+	attrCanonicalGzip    = "canonical-gzip"
+	attrNydusFsVersion   = "nydus-fs-version"
+	attrNydusCompressor  = "nydus-compressor"
+	attrNydusChunkSize   = "nydus-chunk-size"
+	attrNydusChunkDict   = "nydus-chunk-dict"
+
+	attrAutoSampleSize          = "compression-auto-sample-size"
+	attrAutoIncompressibleRatio = "compression-auto-incompressible-ratio"
+	attrAutoCompressibleRatio   = "compression-auto-compressible-ratio"
 )
 
 func ParseAttributes(attrs map[string]string) (Config, error) {
@@ -44,5 +56,133 @@ func ParseAttributes(attrs map[string]string) (Config, error) {
 		}
 		compressionConfig = compressionConfig.SetLevel(int(ii))
 	}
+	if v, ok := attrs[attrCanonicalGzip]; ok {
+		canonical := true
+		if v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return Config{}, errors.Wrapf(err, "non-bool value %s specified for %s", v, attrCanonicalGzip)
+			}
+			canonical = b
+		}
+		if canonical {
+			if compressionType != Gzip {
+				return Config{}, errors.Errorf("%s requires %s=gzip", attrCanonicalGzip, attrLayerCompression)
+			}
+			if compressionConfig.Level != nil {
+				return Config{}, errors.Errorf("%s can't be used with %s", attrCanonicalGzip, attrCompressionLevel)
+			}
+		}
+		compressionConfig.CanonicalGzip = canonical
+	}
+	nydusConfig, err := parseNydusAttributes(attrs)
+	if err != nil {
+		return Config{}, err
+	}
+	if nydusConfig != nil {
+		if compressionType.String() != "nydus" {
+			return Config{}, errors.Errorf("nydus options require %s=nydus", attrLayerCompression)
+		}
+		compressionConfig.Nydus = nydusConfig
+	}
+	autoConfig, err := parseAutoAttributes(attrs)
+	if err != nil {
+		return Config{}, err
+	}
+	if autoConfig != nil && compressionType != Auto {
+		return Config{}, errors.Errorf("auto compression options require %s=auto", attrLayerCompression)
+	}
+	if compressionType == Auto {
+		if compressionConfig.Force {
+			return Config{}, errors.Errorf("%s can't be used with %s=auto", attrForceCompression, attrLayerCompression)
+		}
+		if autoConfig == nil {
+			autoConfig = DefaultAutoConfig()
+		}
+		compressionConfig.Auto = autoConfig
+	}
 	return compressionConfig, nil
 }
+
+func parseAutoAttributes(attrs map[string]string) (*AutoConfig, error) {
+	var c *AutoConfig
+	config := func() *AutoConfig {
+		if c == nil {
+			c = DefaultAutoConfig()
+		}
+		return c
+	}
+	if v, ok := attrs[attrAutoSampleSize]; ok {
+		n, err := units.RAMInBytes(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", attrAutoSampleSize)
+		}
+		if n <= 0 {
+			return nil, errors.Errorf("invalid %s %q, must be positive", attrAutoSampleSize, v)
+		}
+		config().SampleSize = n
+	}
+	for k, dst := range map[string]func(*AutoConfig) *float64{
+		attrAutoIncompressibleRatio: func(c *AutoConfig) *float64 { return &c.IncompressibleRatio },
+		attrAutoCompressibleRatio:   func(c *AutoConfig) *float64 { return &c.CompressibleRatio },
+	} {
+		v, ok := attrs[k]
+		if !ok {
+			continue
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "non-float value %s specified for %s", v, k)
+		}
+		if f <= 0 || f > 1 {
+			return nil, errors.Errorf("invalid %s %q, must be between 0 and 1", k, v)
+		}
+		*dst(config()) = f
+	}
+	if c != nil && c.CompressibleRatio > c.IncompressibleRatio {
+		return nil, errors.Errorf("%s can't be greater than %s", attrAutoCompressibleRatio, attrAutoIncompressibleRatio)
+	}
+	return c, nil
+}
+
+func parseNydusAttributes(attrs map[string]string) (*NydusConfig, error) {
+	var c *NydusConfig
+	config := func() *NydusConfig {
+		if c == nil {
+			c = &NydusConfig{}
+		}
+		return c
+	}
+	if v, ok := attrs[attrNydusFsVersion]; ok {
+		if v != "5" && v != "6" {
+			return nil, errors.Errorf("invalid %s %q, must be 5 or 6", attrNydusFsVersion, v)
+		}
+		config().FsVersion = v
+	}
+	if v, ok := attrs[attrNydusCompressor]; ok {
+		switch v {
+		case "none", "lz4_block", "zstd":
+		default:
+			return nil, errors.Errorf("invalid %s %q, must be none, lz4_block or zstd", attrNydusCompressor, v)
+		}
+		config().Compressor = v
+	}
+	if v, ok := attrs[attrNydusChunkSize]; ok {
+		n, err := units.RAMInBytes(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", attrNydusChunkSize)
+		}
+		if n < 0x1000 || n > 0x1000000 || n&(n-1) != 0 {
+			return nil, errors.Errorf("invalid %s %q, must be a power of two between 4KiB and 16MiB", attrNydusChunkSize, v)
+		}
+		config().ChunkSize = fmt.Sprintf("0x%x", n)
+	}
+	if v, ok := attrs[attrNydusChunkDict]; ok {
+		dgst, err := digest.Parse(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %s", attrNydusChunkDict)
+		}
+		config().ChunkDict = dgst
+	}
+	return c, nil
+}
@@ -0,0 +1,180 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/klauspost/compress/zstd"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+type autoType struct{}
+
+// Auto chooses the compression of each layer from a sample of its content.
+// The media types of the blobs are detected after they are written.
+var Auto = autoType{}
+
+// AutoConfig configures how Auto chooses the compression of a layer. The
+// sample of the layer is compressed with the fastest zstd level and the ratio
+// of the compressed size to the size of the sample chooses the compression.
+type AutoConfig struct {
+	// SampleSize is the size of the start of the layer that is sampled
+	SampleSize int64
+	// IncompressibleRatio is the ratio from which the layer is not
+	// compressed, e.g. a layer of compressed archives
+	IncompressibleRatio float64
+	// CompressibleRatio is the ratio up to which the layer is compressed
+	// with the compression level of the config. The layers between the
+	// ratios are compressed with the fastest level.
+	CompressibleRatio float64
+	// Gzip compresses the layers with gzip instead of zstd, for the images
+	// with Docker media types
+	Gzip bool
+}
+
+const (
+	defaultAutoSampleSize          = 1 << 20
+	defaultAutoIncompressibleRatio = 0.95
+	defaultAutoCompressibleRatio   = 0.5
+)
+
+// DefaultAutoConfig returns the config of Auto without attributes
+func DefaultAutoConfig() *AutoConfig {
+	return &AutoConfig{
+		SampleSize:          defaultAutoSampleSize,
+		IncompressibleRatio: defaultAutoIncompressibleRatio,
+		CompressibleRatio:   defaultAutoCompressibleRatio,
+	}
+}
+
+func (c autoType) Compress(ctx context.Context, comp Config) (compressorFunc Compressor, finalize Finalizer) {
+	return func(dest io.Writer, _ string) (io.WriteCloser, error) {
+		cfg := comp.Auto
+		if cfg == nil {
+			cfg = DefaultAutoConfig()
+		}
+		return &autoWriter{ctx: ctx, dest: dest, comp: comp, cfg: cfg}, nil
+	}, nil
+}
+
+func (c autoType) Decompress(ctx context.Context, cs content.Store, desc ocispecs.Descriptor) (io.ReadCloser, error) {
+	return decompress(ctx, cs, desc)
+}
+
+// NeedsConversion returns false, the blobs of any compression are accepted
+func (c autoType) NeedsConversion(ctx context.Context, cs content.Store, desc ocispecs.Descriptor) (bool, error) {
+	return false, nil
+}
+
+func (c autoType) NeedsComputeDiffBySelf(comp Config) bool {
+	return true
+}
+
+func (c autoType) OnlySupportOCITypes() bool {
+	return false
+}
+
+// MediaType returns the media type the differs write the blobs with. The
+// media type of the written blob is detected from its content.
+func (c autoType) MediaType() string {
+	return ocispecs.MediaTypeImageLayerZstd
+}
+
+func (c autoType) String() string {
+	return "auto"
+}
+
+// Choose returns the compression of a layer starting with sample
+func (c *AutoConfig) Choose(comp Config, sample []byte) (Config, error) {
+	ratio, err := zstdRatio(sample)
+	if err != nil {
+		return Config{}, err
+	}
+	if len(sample) > 0 && ratio >= c.IncompressibleRatio {
+		return New(Uncompressed), nil
+	}
+	t := Type(Zstd)
+	if c.Gzip {
+		t = Gzip
+	}
+	out := New(t)
+	if ratio > c.CompressibleRatio {
+		if c.Gzip {
+			return out.SetLevel(gzip.BestSpeed), nil
+		}
+		return out.SetLevel(1), nil
+	}
+	out.Level = comp.Level
+	return out, nil
+}
+
+// zstdRatio returns the ratio of the size of dt compressed with the fastest
+// zstd level to the size of dt
+func zstdRatio(dt []byte) (float64, error) {
+	if len(dt) == 0 {
+		return 0, nil
+	}
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer enc.Close()
+	return float64(len(enc.EncodeAll(dt, nil))) / float64(len(dt)), nil
+}
+
+// autoWriter buffers the sample of a layer, then writes the layer with the
+// compression chosen from the sample
+type autoWriter struct {
+	ctx  context.Context
+	dest io.Writer
+	comp Config
+	cfg  *AutoConfig
+
+	buf bytes.Buffer
+	w   io.WriteCloser
+}
+
+func (w *autoWriter) Write(p []byte) (int, error) {
+	if w.w != nil {
+		return w.w.Write(p)
+	}
+	w.buf.Write(p)
+	if int64(w.buf.Len()) >= w.cfg.SampleSize {
+		if err := w.choose(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *autoWriter) Close() error {
+	if w.w == nil {
+		if err := w.choose(); err != nil {
+			return err
+		}
+	}
+	return w.w.Close()
+}
+
+func (w *autoWriter) choose() error {
+	comp, err := w.cfg.Choose(w.comp, w.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	compress, _ := comp.Type.Compress(w.ctx, comp)
+	zw, err := compress(w.dest, comp.Type.MediaType())
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(w.buf.Bytes()); err != nil {
+		zw.Close()
+		return err
+	}
+	w.w = zw
+	w.buf = bytes.Buffer{}
+	return nil
+}
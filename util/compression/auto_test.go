@@ -0,0 +1,113 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAutoAttributes(t *testing.T) {
+	c, err := ParseAttributes(map[string]string{"compression": "auto"})
+	require.NoError(t, err)
+	require.Equal(t, Auto, c.Type)
+	require.Equal(t, DefaultAutoConfig(), c.Auto)
+
+	c, err = ParseAttributes(map[string]string{
+		"compression":                           "auto",
+		"compression-auto-sample-size":          "64k",
+		"compression-auto-incompressible-ratio": "0.9",
+		"compression-auto-compressible-ratio":   "0.4",
+	})
+	require.NoError(t, err)
+	require.Equal(t, &AutoConfig{SampleSize: 64 << 10, IncompressibleRatio: 0.9, CompressibleRatio: 0.4}, c.Auto)
+
+	for _, attrs := range []map[string]string{
+		{"compression": "zstd", "compression-auto-sample-size": "64k"},
+		{"compression": "auto", "force-compression": "true"},
+		{"compression": "auto", "compression-auto-sample-size": "0"},
+		{"compression": "auto", "compression-auto-compressible-ratio": "2"},
+		{"compression": "auto", "compression-auto-incompressible-ratio": "0.3"},
+	} {
+		_, err := ParseAttributes(attrs)
+		require.Error(t, err, "%v", attrs)
+	}
+}
+
+func TestAutoChoose(t *testing.T) {
+	random := make([]byte, 64<<10)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+	text := bytes.Repeat([]byte("compressible layer content "), 4<<10)
+	mixed := append(append([]byte{}, random[:48<<10]...), text[:16<<10]...)
+
+	cfg := DefaultAutoConfig()
+	comp := New(Auto).SetLevel(9)
+
+	c, err := cfg.Choose(comp, random)
+	require.NoError(t, err)
+	require.Equal(t, Uncompressed, c.Type)
+
+	c, err = cfg.Choose(comp, text)
+	require.NoError(t, err)
+	require.Equal(t, Zstd, c.Type)
+	require.Equal(t, 9, *c.Level)
+
+	c, err = cfg.Choose(comp, mixed)
+	require.NoError(t, err)
+	require.Equal(t, Zstd, c.Type)
+	require.Equal(t, 1, *c.Level)
+
+	c, err = (&AutoConfig{IncompressibleRatio: 0.95, CompressibleRatio: 0.5, Gzip: true}).Choose(comp, text)
+	require.NoError(t, err)
+	require.Equal(t, Gzip, c.Type)
+
+	// empty layers are compressed
+	c, err = cfg.Choose(comp, nil)
+	require.NoError(t, err)
+	require.Equal(t, Zstd, c.Type)
+}
+
+func TestAutoWriter(t *testing.T) {
+	random := make([]byte, 256<<10)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+	text := bytes.Repeat([]byte("compressible layer content "), 16<<10)
+
+	for _, tc := range []struct {
+		name string
+		dt   []byte
+		typ  Type
+	}{
+		{"random", random, Uncompressed},
+		{"text", text, Zstd},
+		{"small", text[:2<<10], Zstd},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			comp := New(Auto)
+			comp.Auto = &AutoConfig{SampleSize: 64 << 10, IncompressibleRatio: 0.95, CompressibleRatio: 0.5}
+			compress, _ := Auto.Compress(context.TODO(), comp)
+			var buf bytes.Buffer
+			w, err := compress(&buf, Auto.MediaType())
+			require.NoError(t, err)
+			// written in chunks not aligned with the sample size
+			for dt := tc.dt; len(dt) > 0; {
+				n := min(len(dt), 10000)
+				_, err := w.Write(dt[:n])
+				require.NoError(t, err)
+				dt = dt[n:]
+			}
+			require.NoError(t, w.Close())
+
+			typ, err := detectCompressionType(io.NewSectionReader(bytes.NewReader(buf.Bytes()), 0, int64(buf.Len())))
+			require.NoError(t, err)
+			require.Equal(t, tc.typ, typ)
+			if typ == Uncompressed {
+				require.Equal(t, tc.dt, buf.Bytes())
+			}
+		})
+	}
+}
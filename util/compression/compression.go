@@ -3,7 +3,9 @@ package compression
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"strings"
 
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/images"
@@ -57,6 +59,77 @@ type Config struct {
 	Type  Type
 	Force bool
 	Level *int
+	Nydus *NydusConfig
+	// Auto configures the Auto compression
+	Auto *AutoConfig
+	// CanonicalGzip writes the gzip layers with the canonical encoder, whose
+	// output doesn't change across versions
+	CanonicalGzip bool
+}
+
+// NydusConfig configures the nydus layers. It is only used by builds with
+// the nydus build tag.
+type NydusConfig struct {
+	// FsVersion is the RAFS version, 5 or 6
+	FsVersion string
+	// Compressor compresses the chunks of the blobs
+	Compressor string
+	// ChunkSize is the size of the chunks as a hexadecimal number
+	ChunkSize string
+	// ChunkDict is the digest of the bootstrap layer or the manifest of a
+	// nydus image in the content store. The chunks that already exist in
+	// that image are not added to the new blobs.
+	ChunkDict digest.Digest
+	// ChunkDictPath is the path of the bootstrap of ChunkDict, extracted by
+	// the exporter
+	ChunkDictPath string
+}
+
+// NydusOptionsLabel is the content label of the nydus blobs that records the
+// options they were built with
+const NydusOptionsLabel = "buildkit.io/compression/nydus-options"
+
+// String returns the canonical form of the options that change the blobs
+func (c *NydusConfig) String() string {
+	if c == nil {
+		return ""
+	}
+	var opts []string
+	for _, kv := range [][2]string{
+		{"fs-version", c.FsVersion},
+		{"compressor", c.Compressor},
+		{"chunk-size", c.ChunkSize},
+		{"chunk-dict", c.ChunkDict.String()},
+	} {
+		if kv[1] != "" {
+			opts = append(opts, fmt.Sprintf("%s=%s", kv[0], kv[1]))
+		}
+	}
+	return strings.Join(opts, ",")
+}
+
+// MatchesOptions returns true if the blob desc was built with the options of
+// c. Only the options of nydus blobs and the canonical gzip encoding are
+// recorded.
+func (c Config) MatchesOptions(ctx context.Context, cs content.Store, desc ocispecs.Descriptor) bool {
+	if c.Type == nil || !images.IsLayerType(desc.MediaType) {
+		return true
+	}
+	switch {
+	case c.Type.String() == "nydus":
+		info, err := cs.Info(ctx, desc.Digest)
+		if err != nil {
+			return false
+		}
+		return info.Labels[NydusOptionsLabel] == c.Nydus.String()
+	case c.Type == Gzip && c.CanonicalGzip:
+		info, err := cs.Info(ctx, desc.Digest)
+		if err != nil {
+			return false
+		}
+		return info.Labels[CanonicalGzipLabel] == canonicalGzipVersion
+	}
+	return true
 }
 
 func New(t Type) Config {
@@ -91,6 +164,8 @@ func parse(t string) (Type, error) {
 		return EStargz, nil
 	case Zstd.String():
 		return Zstd, nil
+	case Auto.String():
+		return Auto, nil
 	default:
 		return nil, errors.Errorf("unsupported compression type %s", t)
 	}
@@ -141,6 +216,11 @@ func DetectLayerMediaType(ctx context.Context, cs content.Store, id digest.Diges
 			return ocispecs.MediaTypeImageLayerGzip, nil
 		}
 		return images.MediaTypeDockerSchema2LayerGzip, nil
+	case Zstd:
+		if oci {
+			return ocispecs.MediaTypeImageLayerZstd, nil
+		}
+		return mediaTypeDockerSchema2LayerZstd, nil
 
 	default:
 		return "", errors.Errorf("failed to detect layer %v compression type", id)
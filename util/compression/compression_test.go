@@ -0,0 +1,21 @@
+package compression
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNydusConfigString(t *testing.T) {
+	var c *NydusConfig
+	require.Equal(t, "", c.String())
+	require.Equal(t, "", (&NydusConfig{ChunkDictPath: "/tmp/bootstrap"}).String())
+
+	c = &NydusConfig{
+		FsVersion:     "6",
+		ChunkSize:     "0x100000",
+		ChunkDict:     "sha256:0b0f6e8556a8356e09a28f80d0b8ab3b58b142d20e7fd0f2aa3aa8df6d8047df",
+		ChunkDictPath: "/tmp/bootstrap",
+	}
+	require.Equal(t, "fs-version=6,chunk-size=0x100000,chunk-dict=sha256:0b0f6e8556a8356e09a28f80d0b8ab3b58b142d20e7fd0f2aa3aa8df6d8047df", c.String())
+}
@@ -11,6 +11,9 @@ import (
 )
 
 func (c gzipType) Compress(ctx context.Context, comp Config) (compressorFunc Compressor, finalize Finalizer) {
+	if comp.CanonicalGzip {
+		return compressCanonicalGzip()
+	}
 	return func(dest io.Writer, _ string) (io.WriteCloser, error) {
 		return gzipWriter(comp)(dest)
 	}, nil
@@ -40,7 +43,7 @@ func (c gzipType) NeedsConversion(ctx context.Context, cs content.Store, desc oc
 
 func (c gzipType) NeedsComputeDiffBySelf(comp Config) bool {
 	// we allow compressing it with a customized compression level that containerd differ doesn't support so we compress it by self.
-	return comp.Level != nil
+	return comp.Level != nil || comp.CanonicalGzip
 }
 
 func (c gzipType) OnlySupportOCITypes() bool {
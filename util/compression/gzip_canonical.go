@@ -0,0 +1,343 @@
+package compression
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"io"
+	"math/bits"
+
+	"github.com/containerd/containerd/v2/core/content"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// CanonicalGzipLabel is the content label of the blobs written by the
+// canonical gzip encoder, set to the version of the encoder
+const CanonicalGzipLabel = "buildkit.io/compression/gzip-canonical"
+
+// canonicalGzipVersion is the version of the canonical gzip encoder. It
+// must change with every change of its output.
+const canonicalGzipVersion = "v1"
+
+// The canonical gzip encoder writes the same bytes for the same input
+// whatever the version of buildkit, Go or the compression libraries, so that
+// the digests of the layers don't change across upgrades. It is implemented
+// here instead of using compress/flate, whose output may change. The gzip
+// header has no name, mtime or extra flags. The input is split in chunks of
+// a fixed size, independently of the sizes of the writes, and each chunk is
+// deflated by greedy LZ77 matching within the chunk into a block with the
+// fixed Huffman codes, or stored if that is smaller.
+const (
+	canonicalChunkSize = 1 << 16
+	canonicalHashBits  = 15
+	canonicalMaxChain  = 64
+	canonicalMinMatch  = 3
+	canonicalMaxMatch  = 258
+	canonicalMaxDist   = 1 << 15
+	canonicalMaxStored = 1<<16 - 1
+)
+
+var (
+	lengthBase  = [...]int{3, 4, 5, 6, 7, 8, 9, 10, 11, 13, 15, 17, 19, 23, 27, 31, 35, 43, 51, 59, 67, 83, 99, 115, 131, 163, 195, 227, 258}
+	lengthExtra = [...]uint{0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 2, 2, 2, 2, 3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 0}
+	distBase    = [...]int{1, 2, 3, 4, 5, 7, 9, 13, 17, 25, 33, 49, 65, 97, 129, 193, 257, 385, 513, 769, 1025, 1537, 2049, 3073, 4097, 6145, 8193, 12289, 16385, 24577}
+	distExtra   = [...]uint{0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, 5, 6, 6, 7, 7, 8, 8, 9, 9, 10, 10, 11, 11, 12, 12, 13, 13}
+)
+
+// canonicalToken is a literal byte if dist is zero, a match otherwise
+type canonicalToken struct {
+	lit  byte
+	len  int
+	dist int
+}
+
+type canonicalGzipWriter struct {
+	w    *bufio.Writer
+	crc  hash.Hash32
+	size uint32
+	buf  []byte
+	err  error
+
+	bits  uint64
+	nbits uint
+
+	head   []int32
+	prev   []int32
+	tokens []canonicalToken
+	closed bool
+}
+
+func newCanonicalGzipWriter(dest io.Writer) *canonicalGzipWriter {
+	w := &canonicalGzipWriter{
+		w:    bufio.NewWriterSize(dest, 1<<16),
+		crc:  crc32.NewIEEE(),
+		head: make([]int32, 1<<canonicalHashBits),
+		prev: make([]int32, canonicalChunkSize),
+	}
+	// magic, deflate, no flags, no mtime, no extra flags, unknown OS
+	_, w.err = w.w.Write([]byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 0xff})
+	return w
+}
+
+func (w *canonicalGzipWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.closed {
+		return 0, errors.New("write to closed canonical gzip writer")
+	}
+	w.crc.Write(p)
+	w.size += uint32(len(p))
+	w.buf = append(w.buf, p...)
+	// a chunk is written once the next one starts, so that the last chunk
+	// is the final block
+	for len(w.buf) > canonicalChunkSize {
+		w.writeChunk(w.buf[:canonicalChunkSize], false)
+		w.buf = append(w.buf[:0], w.buf[canonicalChunkSize:]...)
+	}
+	return len(p), w.err
+}
+
+func (w *canonicalGzipWriter) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+	w.writeChunk(w.buf, true)
+	w.buf = nil
+	w.flushBits()
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[:4], w.crc.Sum32())
+	binary.LittleEndian.PutUint32(trailer[4:], w.size)
+	if w.err == nil {
+		_, w.err = w.w.Write(trailer[:])
+	}
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	return w.err
+}
+
+func (w *canonicalGzipWriter) writeChunk(dt []byte, final bool) {
+	if w.err != nil {
+		return
+	}
+	fixedBits := w.tokenize(dt)
+	// the stored blocks are aligned to bytes after their 3 header bits
+	storedBits := 0
+	offset := int(w.nbits)
+	for i := 0; i == 0 || i < len(dt); i += canonicalMaxStored {
+		storedBits += 3
+		offset += 3
+		pad := (8 - offset%8) % 8
+		n := min(len(dt)-i, canonicalMaxStored)
+		storedBits += pad + 32 + 8*n
+		offset = 0
+	}
+	if fixedBits <= storedBits {
+		w.writeFixed(final)
+	} else {
+		w.writeStored(dt, final)
+	}
+}
+
+// tokenize splits dt in literals and matches, and returns the size of the
+// fixed Huffman block of the tokens in bits
+func (w *canonicalGzipWriter) tokenize(dt []byte) int {
+	for i := range w.head {
+		w.head[i] = -1
+	}
+	w.tokens = w.tokens[:0]
+	size := 3
+	insert := func(p int) {
+		if p+canonicalMinMatch > len(dt) {
+			return
+		}
+		h := hash3(dt[p:])
+		w.prev[p] = w.head[h]
+		w.head[h] = int32(p)
+	}
+	for i := 0; i < len(dt); {
+		var bestLen, bestDist int
+		if i+canonicalMinMatch <= len(dt) {
+			maxLen := min(canonicalMaxMatch, len(dt)-i)
+			j := w.head[hash3(dt[i:])]
+			for chain := 0; j >= 0 && chain < canonicalMaxChain && i-int(j) <= canonicalMaxDist; chain++ {
+				l := matchLen(dt[j:], dt[i:], maxLen)
+				if l > bestLen {
+					bestLen, bestDist = l, i-int(j)
+					if l == maxLen {
+						break
+					}
+				}
+				j = w.prev[j]
+			}
+		}
+		if bestLen >= canonicalMinMatch {
+			w.tokens = append(w.tokens, canonicalToken{len: bestLen, dist: bestDist})
+			lc := lengthCode(bestLen)
+			dc := distCode(bestDist)
+			size += litLenBits(257+lc) + int(lengthExtra[lc]) + 5 + int(distExtra[dc])
+			for p := i; p < i+bestLen; p++ {
+				insert(p)
+			}
+			i += bestLen
+			continue
+		}
+		w.tokens = append(w.tokens, canonicalToken{lit: dt[i]})
+		size += litLenBits(int(dt[i]))
+		insert(i)
+		i++
+	}
+	return size + litLenBits(256)
+}
+
+func (w *canonicalGzipWriter) writeFixed(final bool) {
+	w.writeBits(boolBit(final), 1)
+	w.writeBits(1, 2)
+	for _, t := range w.tokens {
+		if t.dist == 0 {
+			w.writeLitLen(int(t.lit))
+			continue
+		}
+		lc := lengthCode(t.len)
+		w.writeLitLen(257 + lc)
+		w.writeBits(uint64(t.len-lengthBase[lc]), lengthExtra[lc])
+		dc := distCode(t.dist)
+		w.writeBits(uint64(bits.Reverse8(uint8(dc))>>3), 5)
+		w.writeBits(uint64(t.dist-distBase[dc]), distExtra[dc])
+	}
+	w.writeLitLen(256)
+}
+
+func (w *canonicalGzipWriter) writeStored(dt []byte, final bool) {
+	for i := 0; i == 0 || i < len(dt); i += canonicalMaxStored {
+		n := min(len(dt)-i, canonicalMaxStored)
+		w.writeBits(boolBit(final && i+n == len(dt)), 1)
+		w.writeBits(0, 2)
+		w.flushBits()
+		var hdr [4]byte
+		binary.LittleEndian.PutUint16(hdr[:2], uint16(n))
+		binary.LittleEndian.PutUint16(hdr[2:], ^uint16(n))
+		if w.err == nil {
+			_, w.err = w.w.Write(hdr[:])
+		}
+		if w.err == nil {
+			_, w.err = w.w.Write(dt[i : i+n])
+		}
+	}
+}
+
+// writeLitLen writes the fixed Huffman code of a literal or length symbol
+func (w *canonicalGzipWriter) writeLitLen(v int) {
+	var code uint16
+	var n uint
+	switch {
+	case v < 144:
+		code, n = uint16(0x30+v), 8
+	case v < 256:
+		code, n = uint16(0x190+v-144), 9
+	case v < 280:
+		code, n = uint16(v-256), 7
+	default:
+		code, n = uint16(0xc0+v-280), 8
+	}
+	// the Huffman codes are written from their most significant bit
+	w.writeBits(uint64(bits.Reverse16(code)>>(16-n)), n)
+}
+
+func (w *canonicalGzipWriter) writeBits(v uint64, n uint) {
+	w.bits |= v << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		if w.err == nil {
+			w.err = w.w.WriteByte(byte(w.bits))
+		}
+		w.bits >>= 8
+		w.nbits -= 8
+	}
+}
+
+// flushBits writes the pending bits padded to a byte
+func (w *canonicalGzipWriter) flushBits() {
+	if w.nbits > 0 {
+		w.writeBits(0, 8-w.nbits)
+	}
+}
+
+func litLenBits(v int) int {
+	switch {
+	case v < 144:
+		return 8
+	case v < 256:
+		return 9
+	case v < 280:
+		return 7
+	default:
+		return 8
+	}
+}
+
+func lengthCode(l int) int {
+	c := len(lengthBase) - 1
+	for lengthBase[c] > l {
+		c--
+	}
+	return c
+}
+
+func distCode(d int) int {
+	c := len(distBase) - 1
+	for distBase[c] > d {
+		c--
+	}
+	return c
+}
+
+func hash3(b []byte) uint32 {
+	return (uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])) * 0x9e3779b1 >> (32 - canonicalHashBits)
+}
+
+func matchLen(a, b []byte, maxLen int) int {
+	n := 0
+	for n < maxLen && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func boolBit(v bool) uint64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// compressCanonicalGzip returns a compressor writing canonical gzip blobs and
+// a finalizer labeling the last written blob with the encoder version
+func compressCanonicalGzip() (Compressor, Finalizer) {
+	var dgstr digest.Digester
+	return func(dest io.Writer, _ string) (io.WriteCloser, error) {
+			// the differs write the blob again if they fail
+			dgstr = digest.Canonical.Digester()
+			return newCanonicalGzipWriter(io.MultiWriter(dest, dgstr.Hash())), nil
+		}, func(ctx context.Context, cs content.Store) (map[string]string, error) {
+			if dgstr == nil {
+				return nil, nil
+			}
+			info := content.Info{
+				Digest: dgstr.Digest(),
+				Labels: map[string]string{CanonicalGzipLabel: canonicalGzipVersion},
+			}
+			if _, err := cs.Update(ctx, info, "labels."+CanonicalGzipLabel); err != nil {
+				return nil, errors.Wrap(err, "failed to label canonical gzip blob")
+			}
+			return nil, nil
+		}
+}
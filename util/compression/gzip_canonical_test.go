@@ -0,0 +1,105 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"math/rand"
+	"testing"
+
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func canonicalGzip(t *testing.T, dt []byte, chunk int) []byte {
+	var buf bytes.Buffer
+	w := newCanonicalGzipWriter(&buf)
+	for p := dt; len(p) > 0; {
+		n := min(len(p), chunk)
+		_, err := w.Write(p[:n])
+		require.NoError(t, err)
+		p = p[n:]
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func testLayerContent() []byte {
+	r := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test data
+	var buf bytes.Buffer
+	words := []string{"usr", "bin", "lib", "share", "etc", "buildkit", "layer", "content", "\n"}
+	for buf.Len() < 300<<10 {
+		buf.WriteString(words[r.Intn(len(words))])
+		if r.Intn(50) == 0 {
+			// incompressible runs
+			rnd := make([]byte, r.Intn(2000))
+			r.Read(rnd)
+			buf.Write(rnd)
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestCanonicalGzip(t *testing.T) {
+	random := make([]byte, 200<<10)
+	rand.New(rand.NewSource(2)).Read(random) //nolint:gosec // deterministic test data
+
+	for _, tc := range []struct {
+		name string
+		dt   []byte
+	}{
+		{"empty", nil},
+		{"small", []byte("hello hello hello")},
+		{"layer", testLayerContent()},
+		{"random", random},
+		{"zeros", make([]byte, 1<<20)},
+		{"chunk", bytes.Repeat([]byte{'a'}, canonicalChunkSize)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := canonicalGzip(t, tc.dt, 1<<20)
+			// the output doesn't depend on the sizes of the writes
+			require.Equal(t, out, canonicalGzip(t, tc.dt, 1))
+			require.Equal(t, out, canonicalGzip(t, tc.dt, 4095))
+
+			zr, err := gzip.NewReader(bytes.NewReader(out))
+			require.NoError(t, err)
+			require.True(t, zr.ModTime.IsZero())
+			require.Empty(t, zr.Name)
+			dt, err := io.ReadAll(zr)
+			require.NoError(t, err)
+			require.NoError(t, zr.Close())
+			require.Equal(t, len(tc.dt), len(dt))
+			require.True(t, bytes.Equal(tc.dt, dt))
+		})
+	}
+
+	// random data is stored
+	out := canonicalGzip(t, random, 1<<20)
+	require.Less(t, len(out), len(random)+len(random)/canonicalChunkSize*10+64)
+	// repeated data is compressed
+	require.Less(t, len(canonicalGzip(t, make([]byte, 1<<20), 1<<20)), 10<<10)
+}
+
+// TestCanonicalGzipStable fails if the output of the encoder changes, which
+// requires a new canonicalGzipVersion
+func TestCanonicalGzipStable(t *testing.T) {
+	out := canonicalGzip(t, testLayerContent(), 1<<20)
+	require.Equal(t, "sha256:2827f256aa5170106ae2f943baeceb0637e8856bcb38e1b94d0533a8d230abe2", digest.FromBytes(out).String())
+}
+
+func TestParseCanonicalGzip(t *testing.T) {
+	c, err := ParseAttributes(map[string]string{"canonical-gzip": "true"})
+	require.NoError(t, err)
+	require.Equal(t, Gzip, c.Type)
+	require.True(t, c.CanonicalGzip)
+	require.True(t, c.Type.NeedsComputeDiffBySelf(c))
+
+	_, err = ParseAttributes(map[string]string{"compression": "zstd", "canonical-gzip": "true"})
+	require.Error(t, err)
+	_, err = ParseAttributes(map[string]string{"canonical-gzip": "true", "compression-level": "9"})
+	require.Error(t, err)
+
+	c, err = ParseAttributes(map[string]string{"compression": "zstd", "canonical-gzip": "false"})
+	require.NoError(t, err)
+	require.False(t, c.CanonicalGzip)
+}
@@ -45,7 +45,7 @@ func (c nydusType) Compress(ctx context.Context, comp Config) (compressorFunc Co
 	digester := digest.Canonical.Digester()
 	return func(dest io.Writer, requiredMediaType string) (io.WriteCloser, error) {
 			writer := io.MultiWriter(dest, digester.Hash())
-			return nydusify.Pack(ctx, writer, nydusify.PackOption{})
+			return nydusify.Pack(ctx, writer, NydusPackOption(comp))
 		}, func(ctx context.Context, cs content.Store) (map[string]string, error) {
 			// Fill necessary labels
 			uncompressedDgst := digester.Digest().String()
@@ -57,7 +57,12 @@ func (c nydusType) Compress(ctx context.Context, comp Config) (compressorFunc Co
 				info.Labels = make(map[string]string)
 			}
 			info.Labels[labels.LabelUncompressed] = uncompressedDgst
-			if _, err := cs.Update(ctx, info, "labels."+labels.LabelUncompressed); err != nil {
+			fields := []string{"labels." + labels.LabelUncompressed}
+			if opts := comp.Nydus.String(); opts != "" {
+				info.Labels[NydusOptionsLabel] = opts
+				fields = append(fields, "labels."+NydusOptionsLabel)
+			}
+			if _, err := cs.Update(ctx, info, fields...); err != nil {
 				return nil, errors.Wrap(err, "update info to content store")
 			}
 
@@ -71,6 +76,18 @@ func (c nydusType) Compress(ctx context.Context, comp Config) (compressorFunc Co
 		}
 }
 
+// NydusPackOption returns the options of the nydus builder for comp
+func NydusPackOption(comp Config) nydusify.PackOption {
+	var opt nydusify.PackOption
+	if c := comp.Nydus; c != nil {
+		opt.FsVersion = c.FsVersion
+		opt.Compressor = c.Compressor
+		opt.ChunkSize = c.ChunkSize
+		opt.ChunkDictPath = c.ChunkDictPath
+	}
+	return opt
+}
+
 func (c nydusType) Decompress(ctx context.Context, cs content.Store, desc ocispecs.Descriptor) (io.ReadCloser, error) {
 	ra, err := cs.ReaderAt(ctx, desc)
 	if err != nil {
@@ -0,0 +1,123 @@
+package compression
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/moby/buildkit/util/progress"
+	"golang.org/x/sync/semaphore"
+)
+
+var (
+	// pool limits the number of layers compressed at the same time by the
+	// daemon. It is not limited if it is nil.
+	pool             atomic.Pointer[semaphore.Weighted]
+	defaultZstdLevel atomic.Pointer[int]
+)
+
+// SetParallelism sets the maximum number of layers that are compressed or
+// converted at the same time by the daemon, for all the workers and builds.
+// The layers are not limited if n is not positive.
+func SetParallelism(n int) {
+	if n <= 0 {
+		pool.Store(nil)
+		return
+	}
+	pool.Store(semaphore.NewWeighted(int64(n)))
+}
+
+// SetDefaultZstdLevel sets the zstd level of the layers that are compressed
+// without a compression level, the zstd default if level is nil
+func SetDefaultZstdLevel(level *int) {
+	defaultZstdLevel.Store(level)
+}
+
+// Acquire waits until the daemon can compress another layer. The returned
+// function releases the layer.
+func Acquire(ctx context.Context) (func(), error) {
+	sem := pool.Load()
+	if sem == nil {
+		return func() {}, nil
+	}
+	if err := sem.Acquire(ctx, 1); err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			sem.Release(1)
+		})
+	}, nil
+}
+
+// WithProgress returns a compressor reporting the uncompressed bytes that are
+// written to the compressors of c as the progress status id. The returned
+// function completes the status.
+func WithProgress(ctx context.Context, id, action string, c Compressor) (Compressor, func()) {
+	pw, _, _ := progress.NewFromContext(ctx)
+	p := &compressProgress{
+		pw: pw,
+		id: id,
+		st: progress.Status{Action: action},
+	}
+	return func(dest io.Writer, mediaType string) (io.WriteCloser, error) {
+		w, err := c(dest, mediaType)
+		if err != nil {
+			return nil, err
+		}
+		p.start()
+		return &progressWriter{WriteCloser: w, p: p}, nil
+	}, p.complete
+}
+
+// compressProgress is the progress status of a compressed layer
+type compressProgress struct {
+	mu sync.Mutex
+	pw progress.Writer
+	id string
+	st progress.Status
+}
+
+func (p *compressProgress) start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.st.Started == nil {
+		now := time.Now()
+		p.st.Started = &now
+	}
+	// the differs start again from the beginning if they fail
+	p.st.Current = 0
+	p.pw.Write(p.id, p.st)
+}
+
+func (p *compressProgress) add(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.st.Current += n
+	p.pw.Write(p.id, p.st)
+}
+
+func (p *compressProgress) complete() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.st.Started != nil && p.st.Completed == nil {
+		now := time.Now()
+		p.st.Completed = &now
+		p.pw.Write(p.id, p.st)
+	}
+	p.pw.Close()
+}
+
+type progressWriter struct {
+	io.WriteCloser
+	p *compressProgress
+}
+
+func (w *progressWriter) Write(dt []byte) (int, error) {
+	n, err := w.WriteCloser.Write(dt)
+	w.p.add(n)
+	return n, err
+}
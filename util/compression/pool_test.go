@@ -0,0 +1,62 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquire(t *testing.T) {
+	t.Cleanup(func() {
+		SetParallelism(0)
+	})
+
+	// not limited by default
+	for range 3 {
+		_, err := Acquire(context.TODO())
+		require.NoError(t, err)
+	}
+
+	SetParallelism(1)
+	release, err := Acquire(context.TODO())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	_, err = Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	release()
+	// releasing twice doesn't free another layer
+	release()
+	release, err = Acquire(context.TODO())
+	require.NoError(t, err)
+	ctx, cancel = context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	_, err = Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+}
+
+func TestWithProgress(t *testing.T) {
+	compress, _ := Zstd.Compress(context.TODO(), New(Zstd))
+	compress, done := WithProgress(context.TODO(), "compressing layer", "compressing", compress)
+
+	var buf bytes.Buffer
+	w, err := compress(&buf, Zstd.MediaType())
+	require.NoError(t, err)
+	dt := bytes.Repeat([]byte("layer"), 1000)
+	n, err := w.Write(dt)
+	require.NoError(t, err)
+	require.Equal(t, len(dt), n)
+	require.NoError(t, w.Close())
+	done()
+
+	pw := w.(*progressWriter)
+	require.Equal(t, len(dt), pw.p.st.Current)
+	require.NotNil(t, pw.p.st.Started)
+	require.NotNil(t, pw.p.st.Completed)
+}
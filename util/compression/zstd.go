@@ -55,6 +55,8 @@ func zstdWriter(comp Config) func(io.Writer) (io.WriteCloser, error) {
 		level := zstd.SpeedDefault
 		if comp.Level != nil {
 			level = toZstdEncoderLevel(*comp.Level)
+		} else if l := defaultZstdLevel.Load(); l != nil {
+			level = toZstdEncoderLevel(*l)
 		}
 		return zstd.NewWriter(dest, zstd.WithEncoderLevel(level))
 	}
@@ -0,0 +1,299 @@
+package contentutil
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	cerrdefs "github.com/containerd/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+const ociLayoutIndexFile = "index.json"
+
+// WriteOCILayoutTar writes desc, the blobs it references and an index.json
+// pointing to desc with the name tag to w as a tarball of an OCI layout.
+func WriteOCILayoutTar(ctx context.Context, p content.Provider, desc ocispecs.Descriptor, tag string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	dt, err := json.Marshal(ocispecs.ImageLayout{Version: ocispecs.ImageLayoutVersion})
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, ocispecs.ImageLayoutFile, dt); err != nil {
+		return err
+	}
+
+	idxDesc := desc
+	idxDesc.Annotations = maps.Clone(desc.Annotations)
+	if idxDesc.Annotations == nil {
+		idxDesc.Annotations = map[string]string{}
+	}
+	idxDesc.Annotations[ocispecs.AnnotationRefName] = tag
+	dt, err = json.Marshal(ocispecs.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispecs.MediaTypeImageIndex,
+		Manifests: []ocispecs.Descriptor{idxDesc},
+	})
+	if err != nil {
+		return err
+	}
+	if err := writeTarFile(tw, ociLayoutIndexFile, dt); err != nil {
+		return err
+	}
+
+	if err := writeTarBlobs(ctx, tw, p, desc, map[digest.Digest]struct{}{}); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func writeTarBlobs(ctx context.Context, tw *tar.Writer, p content.Provider, desc ocispecs.Descriptor, seen map[digest.Digest]struct{}) error {
+	if _, ok := seen[desc.Digest]; ok {
+		return nil
+	}
+	seen[desc.Digest] = struct{}{}
+
+	var children []ocispecs.Descriptor
+	switch desc.MediaType {
+	case images.MediaTypeDockerSchema2ManifestList, ocispecs.MediaTypeImageIndex,
+		images.MediaTypeDockerSchema2Manifest, ocispecs.MediaTypeImageManifest:
+		dt, err := content.ReadBlob(ctx, p, desc)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", desc.Digest)
+		}
+		var mfst struct {
+			Config    *ocispecs.Descriptor  `json:"config,omitempty"`
+			Layers    []ocispecs.Descriptor `json:"layers,omitempty"`
+			Manifests []ocispecs.Descriptor `json:"manifests,omitempty"`
+		}
+		if err := json.Unmarshal(dt, &mfst); err != nil {
+			return errors.Wrapf(err, "failed to parse %s", desc.Digest)
+		}
+		if mfst.Config != nil {
+			children = append(children, *mfst.Config)
+		}
+		children = append(children, mfst.Manifests...)
+		children = append(children, mfst.Layers...)
+		if err := writeTarFile(tw, blobPath(desc.Digest), dt); err != nil {
+			return err
+		}
+	default:
+		ra, err := p.ReaderAt(ctx, desc)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read %s", desc.Digest)
+		}
+		defer ra.Close()
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     blobPath(desc.Digest),
+			Mode:     0444,
+			Size:     ra.Size(),
+		}); err != nil {
+			return errors.WithStack(err)
+		}
+		if _, err := io.Copy(tw, content.NewReader(ra)); err != nil {
+			return errors.Wrapf(err, "failed to write %s", desc.Digest)
+		}
+	}
+
+	for _, c := range children {
+		if err := writeTarBlobs(ctx, tw, p, c, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTarFile(tw *tar.Writer, name string, dt []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0444,
+		Size:     int64(len(dt)),
+	}); err != nil {
+		return errors.WithStack(err)
+	}
+	_, err := tw.Write(dt)
+	return errors.WithStack(err)
+}
+
+func blobPath(dgst digest.Digest) string {
+	return path.Join(ocispecs.ImageBlobsDir, dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// ExtractOCILayoutTar extracts a tarball written by WriteOCILayoutTar to dir.
+// Files that are not part of an OCI layout are ignored.
+func ExtractOCILayoutTar(r io.Reader, dir string) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return errors.Wrap(err, "failed to read OCI layout tarball")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, ok := ociLayoutFile(hdr.Name)
+		if !ok {
+			continue
+		}
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return errors.WithStack(err)
+		}
+		f, err := os.OpenFile(p, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		_, err = io.Copy(f, tr)
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to extract %s", name)
+		}
+	}
+}
+
+// ociLayoutFile returns the clean name of a file of an OCI layout, or false
+// if the name doesn't belong to a layout.
+func ociLayoutFile(name string) (string, bool) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	switch name {
+	case ocispecs.ImageLayoutFile, ociLayoutIndexFile:
+		return name, true
+	}
+	alg, enc, ok := strings.Cut(strings.TrimPrefix(name, ocispecs.ImageBlobsDir+"/"), "/")
+	if !ok || !strings.HasPrefix(name, ocispecs.ImageBlobsDir+"/") {
+		return "", false
+	}
+	if err := digest.NewDigestFromEncoded(digest.Algorithm(alg), enc).Validate(); err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// OCILayoutTar is a content provider reading blobs from an OCI layout
+// tarball in place. The tarball must not be compressed.
+type OCILayoutTar struct {
+	path  string
+	index ocispecs.Index
+	blobs map[digest.Digest]tarEntry
+}
+
+type tarEntry struct {
+	offset int64
+	size   int64
+}
+
+// OpenOCILayoutTar indexes the blobs of the OCI layout tarball at p
+func OpenOCILayoutTar(p string) (*OCILayoutTar, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+
+	t := &OCILayoutTar{
+		path:  p,
+		blobs: map[digest.Digest]tarEntry{},
+	}
+	var hasIndex bool
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Wrapf(err, "failed to read OCI layout tarball %s", p)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, ok := ociLayoutFile(hdr.Name)
+		if !ok {
+			continue
+		}
+		if name == ociLayoutIndexFile {
+			if err := json.NewDecoder(tr).Decode(&t.index); err != nil {
+				return nil, errors.Wrapf(err, "failed to parse %s of %s", ociLayoutIndexFile, p)
+			}
+			hasIndex = true
+			continue
+		}
+		if name == ocispecs.ImageLayoutFile {
+			continue
+		}
+		// archive/tar doesn't read ahead, so the file offset is the start
+		// of the data of the current entry
+		offset, err := f.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		parts := strings.Split(name, "/")
+		dgst := digest.NewDigestFromEncoded(digest.Algorithm(parts[1]), parts[2])
+		t.blobs[dgst] = tarEntry{offset: offset, size: hdr.Size}
+	}
+	if !hasIndex {
+		return nil, errors.Errorf("%s is not an OCI layout tarball: missing %s", p, ociLayoutIndexFile)
+	}
+	return t, nil
+}
+
+// Get returns the descriptor of the manifest named tag in the index.json
+// of the layout
+func (t *OCILayoutTar) Get(tag string) (*ocispecs.Descriptor, error) {
+	for _, m := range t.index.Manifests {
+		if m.Annotations[ocispecs.AnnotationRefName] == tag {
+			return &m, nil
+		}
+	}
+	return nil, errors.Wrapf(cerrdefs.ErrNotFound, "tag %s not found in %s", tag, t.path)
+}
+
+// Info returns the size of a blob of the layout
+func (t *OCILayoutTar) Info(ctx context.Context, dgst digest.Digest) (content.Info, error) {
+	e, ok := t.blobs[dgst]
+	if !ok {
+		return content.Info{}, errors.Wrapf(cerrdefs.ErrNotFound, "blob %s not found in %s", dgst, t.path)
+	}
+	return content.Info{Digest: dgst, Size: e.size}, nil
+}
+
+// ReaderAt returns a reader for a blob of the layout
+func (t *OCILayoutTar) ReaderAt(ctx context.Context, desc ocispecs.Descriptor) (content.ReaderAt, error) {
+	e, ok := t.blobs[desc.Digest]
+	if !ok {
+		return nil, errors.Wrapf(cerrdefs.ErrNotFound, "blob %s not found in %s", desc.Digest, t.path)
+	}
+	f, err := os.Open(t.path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &tarReaderAt{SectionReader: io.NewSectionReader(f, e.offset, e.size), f: f}, nil
+}
+
+type tarReaderAt struct {
+	*io.SectionReader
+	f *os.File
+}
+
+func (r *tarReaderAt) Close() error {
+	return r.f.Close()
+}
@@ -0,0 +1,91 @@
+package contentutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/buildkit/client/ociindex"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOCILayoutTar(t *testing.T) {
+	t.Parallel()
+	ctx := context.TODO()
+
+	b := NewBuffer()
+	writeBlob := func(mediaType string, dt []byte) ocispecs.Descriptor {
+		desc := ocispecs.Descriptor{
+			MediaType: mediaType,
+			Digest:    digest.FromBytes(dt),
+			Size:      int64(len(dt)),
+		}
+		require.NoError(t, content.WriteBlob(ctx, b, desc.Digest.String(), bytes.NewReader(dt), desc))
+		return desc
+	}
+
+	layer := writeBlob(ocispecs.MediaTypeImageLayerGzip, []byte("layer"))
+	config := writeBlob("application/vnd.buildkit.cacheconfig.v0", []byte("{}"))
+	dt, err := json.Marshal(ocispecs.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispecs.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispecs.Descriptor{layer, layer},
+	})
+	require.NoError(t, err)
+	mfst := writeBlob(ocispecs.MediaTypeImageManifest, dt)
+
+	tmpdir := t.TempDir()
+	p := filepath.Join(tmpdir, "cache.tar")
+	f, err := os.Create(p)
+	require.NoError(t, err)
+	require.NoError(t, WriteOCILayoutTar(ctx, b, mfst, "v1", f))
+	require.NoError(t, f.Close())
+
+	lt, err := OpenOCILayoutTar(p)
+	require.NoError(t, err)
+
+	desc, err := lt.Get("v1")
+	require.NoError(t, err)
+	require.Equal(t, mfst.Digest, desc.Digest)
+
+	_, err = lt.Get("latest")
+	require.ErrorIs(t, err, cerrdefs.ErrNotFound)
+
+	for _, d := range []ocispecs.Descriptor{mfst, config, layer} {
+		info, err := lt.Info(ctx, d.Digest)
+		require.NoError(t, err)
+		require.Equal(t, d.Size, info.Size)
+
+		dt, err := content.ReadBlob(ctx, lt, d)
+		require.NoError(t, err)
+		require.Equal(t, d.Digest, digest.FromBytes(dt))
+	}
+
+	_, err = lt.Info(ctx, digest.FromBytes([]byte("missing")))
+	require.ErrorIs(t, err, cerrdefs.ErrNotFound)
+
+	// the tarball is an OCI layout directory once extracted
+	f, err = os.Open(p)
+	require.NoError(t, err)
+	defer f.Close()
+	dir := filepath.Join(tmpdir, "layout")
+	require.NoError(t, ExtractOCILayoutTar(f, dir))
+
+	desc, err = ociindex.NewStoreIndex(dir).Get("v1")
+	require.NoError(t, err)
+	require.NotNil(t, desc)
+	require.Equal(t, mfst.Digest, desc.Digest)
+
+	dt, err = os.ReadFile(filepath.Join(dir, "blobs", layer.Digest.Algorithm().String(), layer.Digest.Encoded()))
+	require.NoError(t, err)
+	require.Equal(t, "layer", string(dt))
+}
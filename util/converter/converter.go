@@ -37,6 +37,9 @@ func NewWithRewriteTimestamp(ctx context.Context, cs content.Store, desc ocispec
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to determine conversion needs")
 	}
+	if !needs {
+		needs = !comp.MatchesOptions(ctx, cs, desc)
+	}
 	if !needs && rewriteTimestamp != nil {
 		needs = desc.Annotations[labelRewrittenTimestamp] != fmt.Sprintf("%d", rewriteTimestamp.UTC().Unix())
 	}
@@ -90,6 +93,14 @@ func rewriteTimestampInTarHeader(epoch time.Time) tarconverter.HeaderConverter {
 
 func (c *conversion) convert(ctx context.Context, cs content.Store, desc ocispecs.Descriptor) (*ocispecs.Descriptor, error) {
 	bklog.G(ctx).WithField("blob", desc).WithField("target", c.target).Debugf("converting blob to the target compression")
+	release, err := compression.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	compress, done := compression.WithProgress(ctx, "converting "+desc.Digest.String(), "converting to "+c.target.Type.String(), c.compress)
+	defer done()
+
 	// prepare the source and destination
 	labelz := make(map[string]string)
 	ref := fmt.Sprintf("convert-from-%s-to-%s-%s", desc.Digest, c.target.Type.String(), identity.NewID())
@@ -110,7 +121,7 @@ func (c *conversion) convert(ctx context.Context, cs content.Store, desc ocispec
 		bufW = bufio.NewWriterSize(w, 128*1024)
 	}
 	defer bufioPool.Put(bufW)
-	zw, err := c.compress(&iohelper.NopWriteCloser{Writer: bufW}, c.target.Type.MediaType())
+	zw, err := compress(&iohelper.NopWriteCloser{Writer: bufW}, c.target.Type.MediaType())
 	if err != nil {
 		return nil, err
 	}
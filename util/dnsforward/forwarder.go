@@ -0,0 +1,346 @@
+// Package dnsforward implements a DNS forwarder serving the plain DNS queries
+// of the exec ops and forwarding them to upstreams over UDP, TCP, DNS over
+// TLS or DNS over HTTPS, with the upstreams of specific domains overridden for
+// split DNS.
+package dnsforward
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	stderrors "errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	maxMessageSize = 65535
+	mediaType      = "application/dns-message"
+)
+
+// Config is the configuration of a forwarder
+type Config struct {
+	// Upstreams are the servers the queries are forwarded to, in order until
+	// one of them answers
+	Upstreams []string
+	// Domains are the upstreams of the names of specific domains and their
+	// subdomains, the longest matching domain wins
+	Domains map[string][]string
+	// Timeout limits the duration of a query to an upstream
+	Timeout time.Duration
+}
+
+type route struct {
+	domain    string
+	upstreams []Upstream
+}
+
+// Forwarder forwards DNS queries to the upstreams of their names
+type Forwarder struct {
+	upstreams  []Upstream
+	routes     []route
+	timeout    time.Duration
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	closers []io.Closer
+}
+
+// New returns a forwarder for the config
+func New(cfg Config) (*Forwarder, error) {
+	f := &Forwarder{
+		timeout:    cfg.Timeout,
+		httpClient: &http.Client{},
+	}
+	if f.timeout <= 0 {
+		f.timeout = defaultTimeout
+	}
+	var err error
+	if f.upstreams, err = parseUpstreams(cfg.Upstreams); err != nil {
+		return nil, err
+	}
+	if len(f.upstreams) == 0 {
+		return nil, errors.New("no DNS upstreams")
+	}
+	for domain, upstreams := range cfg.Domains {
+		r := route{domain: strings.ToLower(strings.Trim(domain, "."))}
+		if r.domain == "" {
+			return nil, errors.Errorf("invalid DNS domain %q", domain)
+		}
+		if r.upstreams, err = parseUpstreams(upstreams); err != nil {
+			return nil, err
+		}
+		if len(r.upstreams) == 0 {
+			return nil, errors.Errorf("no DNS upstreams for domain %s", r.domain)
+		}
+		f.routes = append(f.routes, r)
+	}
+	slices.SortFunc(f.routes, func(a, b route) int {
+		return len(b.domain) - len(a.domain)
+	})
+	return f, nil
+}
+
+func parseUpstreams(ss []string) ([]Upstream, error) {
+	var out []Upstream
+	for _, s := range ss {
+		u, err := ParseUpstream(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+// upstreamsOf returns the upstreams of a name
+func (f *Forwarder) upstreamsOf(name string) []Upstream {
+	for _, r := range f.routes {
+		if name == r.domain || strings.HasSuffix(name, "."+r.domain) {
+			return r.upstreams
+		}
+	}
+	return f.upstreams
+}
+
+// Exchange forwards a query to the upstreams of its name and returns the
+// response of the first one that answers. The response is a server failure if
+// none of them answers.
+func (f *Forwarder) Exchange(ctx context.Context, msg []byte) []byte {
+	q, err := parseQuestion(msg)
+	if err != nil {
+		return reply(msg, headerSize, rcodeFormat)
+	}
+	for _, u := range f.upstreamsOf(q.name) {
+		resp, err := f.exchange(ctx, u, msg)
+		if err == nil {
+			return resp
+		}
+		bklog.G(ctx).Debugf("failed to forward DNS query for %s to %s: %v", q.name, u, err)
+	}
+	return reply(msg, q.end, rcodeFail)
+}
+
+func (f *Forwarder) exchange(ctx context.Context, u Upstream, msg []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeoutCause(ctx, f.timeout, errors.WithStack(context.DeadlineExceeded))
+	defer cancel()
+
+	var resp []byte
+	var err error
+	switch u.Protocol {
+	case ProtocolUDP:
+		resp, err = exchangeUDP(ctx, u.Address, msg)
+		if err == nil && binary.BigEndian.Uint16(resp[2:4])&flagTC != 0 {
+			resp, err = exchangeStream(ctx, u, msg)
+		}
+	case ProtocolTCP, ProtocolTLS:
+		resp, err = exchangeStream(ctx, u, msg)
+	case ProtocolHTTPS:
+		resp, err = f.exchangeHTTPS(ctx, u.Address, msg)
+	default:
+		return nil, errors.Errorf("unsupported protocol %s", u.Protocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) < headerSize || !bytes.Equal(resp[:2], msg[:2]) {
+		return nil, errors.New("invalid DNS response")
+	}
+	return resp, nil
+}
+
+func exchangeUDP(ctx context.Context, addr string, msg []byte) ([]byte, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// skip the responses to other queries
+		if n >= headerSize && bytes.Equal(buf[:2], msg[:2]) {
+			return buf[:n], nil
+		}
+	}
+}
+
+func exchangeStream(ctx context.Context, u Upstream, msg []byte) ([]byte, error) {
+	var conn net.Conn
+	var err error
+	if u.Protocol == ProtocolTLS {
+		d := &tls.Dialer{Config: &tls.Config{ServerName: u.ServerName, MinVersion: tls.VersionTLS12}}
+		conn, err = d.DialContext(ctx, "tcp", u.Address)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", u.Address)
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+	if err := writeStreamMessage(conn, msg); err != nil {
+		return nil, err
+	}
+	return readStreamMessage(conn)
+}
+
+func (f *Forwarder) exchangeHTTPS(ctx context.Context, url string, msg []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", mediaType)
+	req.Header.Set("Accept", mediaType)
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+	dt, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize+1))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(dt) > maxMessageSize {
+		return nil, errors.New("DNS response too large")
+	}
+	return dt, nil
+}
+
+// writeStreamMessage writes a DNS message prefixed with its length, the way
+// the messages are sent over TCP
+func writeStreamMessage(w io.Writer, msg []byte) error {
+	buf := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(buf, uint16(len(msg)))
+	_, err := w.Write(append(buf, msg...))
+	return errors.WithStack(err)
+}
+
+func readStreamMessage(r io.Reader) ([]byte, error) {
+	var l [2]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	msg := make([]byte, binary.BigEndian.Uint16(l[:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return msg, nil
+}
+
+// ListenAndServe serves DNS over UDP and TCP on the address, on port 53 if
+// the address has no port, until the forwarder is closed. The address doesn't
+// need to be assigned to an interface yet on Linux, e.g. for the address of a
+// bridge created by the first build.
+func (f *Forwarder) ListenAndServe(ctx context.Context, addr string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), "53")
+	}
+	lc := net.ListenConfig{Control: freebind}
+	pc, err := lc.ListenPacket(ctx, "udp", addr)
+	if err != nil {
+		return errors.Wrapf(err, "failed to listen for DNS on %s", addr)
+	}
+	l, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		pc.Close()
+		return errors.Wrapf(err, "failed to listen for DNS on %s", addr)
+	}
+	f.serve(context.WithoutCancel(ctx), pc, l)
+	return nil
+}
+
+func (f *Forwarder) serve(ctx context.Context, pc net.PacketConn, l net.Listener) {
+	f.mu.Lock()
+	f.closers = append(f.closers, pc, l)
+	f.mu.Unlock()
+	go f.serveUDP(ctx, pc)
+	go f.serveTCP(ctx, l)
+}
+
+func (f *Forwarder) serveUDP(ctx context.Context, pc net.PacketConn) {
+	for {
+		buf := make([]byte, maxMessageSize)
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				bklog.G(ctx).Errorf("failed to read DNS query: %v", err)
+			}
+			return
+		}
+		go func(msg []byte) {
+			resp := f.Exchange(ctx, msg)
+			if q, err := parseQuestion(msg); err == nil && len(resp) > maxUDPSize(msg, q) {
+				resp = truncate(resp)
+			}
+			if _, err := pc.WriteTo(resp, addr); err != nil {
+				bklog.G(ctx).Debugf("failed to write DNS response to %s: %v", addr, err)
+			}
+		}(buf[:n])
+	}
+}
+
+func (f *Forwarder) serveTCP(ctx context.Context, l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				bklog.G(ctx).Errorf("failed to accept DNS connection: %v", err)
+			}
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				conn.SetReadDeadline(time.Now().Add(f.timeout))
+				msg, err := readStreamMessage(conn)
+				if err != nil {
+					return
+				}
+				if err := writeStreamMessage(conn, f.Exchange(ctx, msg)); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Close stops serving DNS
+func (f *Forwarder) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var errs []error
+	for _, c := range f.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	f.closers = nil
+	return stderrors.Join(errs...)
+}
@@ -0,0 +1,170 @@
+package dnsforward
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUpstream(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected Upstream
+		err      string
+	}{
+		{in: "1.1.1.1", expected: Upstream{Protocol: "udp", Address: "1.1.1.1:53"}},
+		{in: "udp://10.0.0.2:5353", expected: Upstream{Protocol: "udp", Address: "10.0.0.2:5353"}},
+		{in: "tcp://[2606:4700::1111]", expected: Upstream{Protocol: "tcp", Address: "[2606:4700::1111]:53"}},
+		{in: "tls://1.1.1.1#cloudflare-dns.com", expected: Upstream{Protocol: "tls", Address: "1.1.1.1:853", ServerName: "cloudflare-dns.com"}},
+		{in: "tls://dns.google", expected: Upstream{Protocol: "tls", Address: "dns.google:853", ServerName: "dns.google"}},
+		{in: "https://cloudflare-dns.com/dns-query", expected: Upstream{Protocol: "https", Address: "https://cloudflare-dns.com/dns-query"}},
+		{in: "https://dns.google", expected: Upstream{Protocol: "https", Address: "https://dns.google/dns-query"}},
+		{in: "quic://1.1.1.1", err: "unsupported protocol quic"},
+		{in: "udp://1.1.1.1#one.one.one.one", err: "server name is only supported with tls"},
+		{in: "tcp://", err: "no host"},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			u, err := ParseUpstream(tc.in)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, u)
+		})
+	}
+}
+
+// query returns a query of the A record of the name
+func query(id uint16, name string) []byte {
+	msg := make([]byte, headerSize)
+	binary.BigEndian.PutUint16(msg[0:2], id)
+	binary.BigEndian.PutUint16(msg[4:6], 1)
+	for _, l := range strings.Split(name, ".") {
+		msg = append(msg, byte(len(l)))
+		msg = append(msg, l...)
+	}
+	return append(msg, 0, 0, 1, 0, 1)
+}
+
+// answer returns the response of a test upstream to a query, with the name of
+// the upstream as payload
+func answer(msg []byte, upstream string) []byte {
+	resp := reply(msg, len(msg), 0)
+	return append(resp, upstream...)
+}
+
+func newUDPUpstream(t *testing.T, name string) string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, maxMessageSize)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(answer(buf[:n], name), addr)
+		}
+	}()
+	return "udp://" + pc.LocalAddr().String()
+}
+
+func TestForwarderExchange(t *testing.T) {
+	ctx := context.TODO()
+	def := newUDPUpstream(t, "default")
+	corp := newUDPUpstream(t, "corp")
+
+	doh := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, mediaType, r.Header.Get("Content-Type"))
+		dt, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", mediaType)
+		w.Write(answer(dt, "doh"))
+	}))
+	defer doh.Close()
+
+	// the closed port doesn't answer
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down := "udp://" + pc.LocalAddr().String()
+	pc.Close()
+
+	f, err := New(Config{
+		Upstreams: []string{down, def},
+		Domains: map[string][]string{
+			"corp.example.com":     {corp},
+			"dev.corp.example.com": {doh.URL},
+			"down.example.com.":    {down},
+		},
+	})
+	require.NoError(t, err)
+	f.httpClient = doh.Client()
+
+	for _, tc := range []struct {
+		name     string
+		upstream string
+	}{
+		{name: "example.com", upstream: "default"},
+		{name: "corp.example.com", upstream: "corp"},
+		{name: "git.CORP.example.com", upstream: "corp"},
+		{name: "notcorp.example.com", upstream: "default"},
+		{name: "api.dev.corp.example.com", upstream: "doh"},
+	} {
+		msg := query(42, tc.name)
+		resp := f.Exchange(ctx, msg)
+		require.Equal(t, tc.upstream, string(resp[len(msg):]), tc.name)
+		require.Equal(t, msg[:2], resp[:2])
+	}
+
+	msg := query(7, "a.down.example.com")
+	resp := f.Exchange(ctx, msg)
+	require.Len(t, resp, len(msg))
+	require.Equal(t, uint16(flagQR|rcodeFail), binary.BigEndian.Uint16(resp[2:4]))
+
+	resp = f.Exchange(ctx, []byte{0, 1, 2})
+	require.Equal(t, uint16(flagQR|rcodeFormat), binary.BigEndian.Uint16(resp[2:4])&(flagQR|rcodeMask))
+}
+
+func TestForwarderServe(t *testing.T) {
+	ctx := context.TODO()
+	f, err := New(Config{Upstreams: []string{newUDPUpstream(t, strings.Repeat("x", 600))}})
+	require.NoError(t, err)
+	defer f.Close()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	f.serve(ctx, pc, l)
+
+	// the responses larger than 512 bytes are truncated over UDP
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	msg := query(1, "example.com")
+	_, err = conn.Write(msg)
+	require.NoError(t, err)
+	buf := make([]byte, maxMessageSize)
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, len(msg), n)
+	require.NotZero(t, binary.BigEndian.Uint16(buf[2:4])&flagTC)
+
+	tconn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer tconn.Close()
+	require.NoError(t, writeStreamMessage(tconn, msg))
+	resp, err := readStreamMessage(tconn)
+	require.NoError(t, err)
+	require.Len(t, resp, len(msg)+600)
+	require.Zero(t, binary.BigEndian.Uint16(resp[2:4])&flagTC)
+}
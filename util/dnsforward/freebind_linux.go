@@ -0,0 +1,24 @@
+package dnsforward
+
+import (
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// freebind allows binding the addresses that are not assigned yet
+func freebind(network, address string, c syscall.RawConn) error {
+	var err error
+	if cerr := c.Control(func(fd uintptr) {
+		level := unix.SOL_IP
+		if network == "udp6" || network == "tcp6" {
+			level = unix.SOL_IPV6
+			err = unix.SetsockoptInt(int(fd), level, unix.IPV6_FREEBIND, 1)
+			return
+		}
+		err = unix.SetsockoptInt(int(fd), level, unix.IP_FREEBIND, 1)
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package dnsforward
+
+import "syscall"
+
+func freebind(network, address string, c syscall.RawConn) error {
+	return nil
+}
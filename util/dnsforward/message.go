@@ -0,0 +1,116 @@
+package dnsforward
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	headerSize = 12
+	// minUDPSize is the size of the UDP responses of the clients that don't
+	// advertise a larger one with EDNS
+	minUDPSize = 512
+	typeOPT    = 41
+
+	flagQR      = 0x8000
+	flagTC      = 0x0200
+	rcodeMask   = 0x000f
+	rcodeFormat = 1
+	rcodeFail   = 2
+)
+
+// question is the first question of a DNS message
+type question struct {
+	// name is the lower case name, without the trailing dot
+	name string
+	// end is the offset of the end of the question in the message
+	end int
+}
+
+// parseQuestion returns the first question of a DNS query. The names of the
+// queries are not compressed.
+func parseQuestion(msg []byte) (question, error) {
+	if len(msg) < headerSize {
+		return question{}, errors.New("short DNS message")
+	}
+	if binary.BigEndian.Uint16(msg[4:6]) == 0 {
+		return question{}, errors.New("DNS message has no question")
+	}
+	var labels []string
+	off := headerSize
+	for {
+		if off >= len(msg) {
+			return question{}, errors.New("short DNS question")
+		}
+		n := int(msg[off])
+		off++
+		if n == 0 {
+			break
+		}
+		if n&0xc0 != 0 {
+			return question{}, errors.New("compressed DNS question")
+		}
+		if off+n > len(msg) {
+			return question{}, errors.New("short DNS question")
+		}
+		labels = append(labels, strings.ToLower(string(msg[off:off+n])))
+		off += n
+	}
+	// type and class
+	off += 4
+	if off > len(msg) {
+		return question{}, errors.New("short DNS question")
+	}
+	return question{name: strings.Join(labels, "."), end: off}, nil
+}
+
+// maxUDPSize returns the size of the UDP responses the client of a query
+// accepts, from the OPT record following its question
+func maxUDPSize(msg []byte, q question) int {
+	if binary.BigEndian.Uint16(msg[6:8]) != 0 || binary.BigEndian.Uint16(msg[8:10]) != 0 || binary.BigEndian.Uint16(msg[10:12]) == 0 {
+		return minUDPSize
+	}
+	// root name, type, class
+	if len(msg) < q.end+5 || msg[q.end] != 0 || binary.BigEndian.Uint16(msg[q.end+1:q.end+3]) != typeOPT {
+		return minUDPSize
+	}
+	return max(int(binary.BigEndian.Uint16(msg[q.end+3:q.end+5])), minUDPSize)
+}
+
+// reply returns a response to the query with only its header and question,
+// with the flags added to the flags of the query
+func reply(msg []byte, end int, flags uint16) []byte {
+	if end < headerSize || end > len(msg) {
+		end = min(headerSize, len(msg))
+	}
+	resp := make([]byte, headerSize)
+	copy(resp, msg[:end])
+	if end > headerSize {
+		resp = append(resp, msg[headerSize:end]...)
+		binary.BigEndian.PutUint16(resp[4:6], 1)
+	} else {
+		binary.BigEndian.PutUint16(resp[4:6], 0)
+	}
+	f := binary.BigEndian.Uint16(resp[2:4])&^rcodeMask | flagQR | flags
+	binary.BigEndian.PutUint16(resp[2:4], f)
+	binary.BigEndian.PutUint16(resp[6:8], 0)
+	binary.BigEndian.PutUint16(resp[8:10], 0)
+	binary.BigEndian.PutUint16(resp[10:12], 0)
+	return resp
+}
+
+// truncate returns the header and question of the response flagged as
+// truncated, for the client to query again over TCP
+func truncate(resp []byte) []byte {
+	if len(resp) < headerSize {
+		return resp
+	}
+	flags := flagTC | binary.BigEndian.Uint16(resp[2:4])&rcodeMask
+	q, err := parseQuestion(resp)
+	if err != nil {
+		return reply(resp, headerSize, flags)
+	}
+	return reply(resp, q.end, flags)
+}
@@ -0,0 +1,94 @@
+package dnsforward
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ProtocolUDP   = "udp"
+	ProtocolTCP   = "tcp"
+	ProtocolTLS   = "tls"
+	ProtocolHTTPS = "https"
+)
+
+var defaultPorts = map[string]string{
+	ProtocolUDP: "53",
+	ProtocolTCP: "53",
+	ProtocolTLS: "853",
+}
+
+// Upstream is a DNS server the queries are forwarded to
+type Upstream struct {
+	// Protocol is "udp", "tcp", "tls" for DNS over TLS or "https" for DNS
+	// over HTTPS
+	Protocol string
+	// Address is the host:port of the server, or its URL for DNS over HTTPS
+	Address string
+	// ServerName is the name the TLS certificate of a DNS over TLS server is
+	// verified with
+	ServerName string
+}
+
+// ParseUpstream parses an upstream in the "1.1.1.1", "udp://1.1.1.1:53",
+// "tcp://1.1.1.1", "tls://1.1.1.1#cloudflare-dns.com" or
+// "https://cloudflare-dns.com/dns-query" forms. The server name of a DNS over
+// TLS server defaults to its host.
+func ParseUpstream(s string) (Upstream, error) {
+	proto, addr, ok := strings.Cut(s, "://")
+	if !ok {
+		proto, addr = ProtocolUDP, s
+	}
+	switch proto {
+	case ProtocolHTTPS:
+		u, err := url.Parse(s)
+		if err != nil {
+			return Upstream{}, errors.Wrapf(err, "invalid DNS upstream %q", s)
+		}
+		if u.Host == "" {
+			return Upstream{}, errors.Errorf("invalid DNS upstream %q: no host", s)
+		}
+		if u.Path == "" {
+			u.Path = "/dns-query"
+		}
+		return Upstream{Protocol: proto, Address: u.String()}, nil
+	case ProtocolUDP, ProtocolTCP, ProtocolTLS:
+	default:
+		return Upstream{}, errors.Errorf("invalid DNS upstream %q: unsupported protocol %s", s, proto)
+	}
+
+	addr, serverName, _ := strings.Cut(addr, "#")
+	if serverName != "" && proto != ProtocolTLS {
+		return Upstream{}, errors.Errorf("invalid DNS upstream %q: server name is only supported with tls", s)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		// the address has no port, or is an IPv6 address without brackets
+		host, port = strings.Trim(addr, "[]"), defaultPorts[proto]
+	}
+	if host == "" {
+		return Upstream{}, errors.Errorf("invalid DNS upstream %q: no host", s)
+	}
+	if proto == ProtocolTLS && serverName == "" {
+		serverName = host
+	}
+	return Upstream{
+		Protocol:   proto,
+		Address:    net.JoinHostPort(host, port),
+		ServerName: serverName,
+	}, nil
+}
+
+func (u Upstream) String() string {
+	switch u.Protocol {
+	case ProtocolHTTPS:
+		return u.Address
+	case ProtocolTLS:
+		return u.Protocol + "://" + u.Address + "#" + u.ServerName
+	default:
+		return u.Protocol + "://" + u.Address
+	}
+}
@@ -17,12 +17,16 @@ const (
 	EntitlementSecurityInsecure Entitlement = "security.insecure"
 	EntitlementNetworkHost      Entitlement = "network.host"
 	EntitlementDevice           Entitlement = "device"
+	EntitlementLocalExec        Entitlement = "local.exec"
+	EntitlementMountHost        Entitlement = "mount.host"
 )
 
 var all = map[Entitlement]struct{}{
 	EntitlementSecurityInsecure: {},
 	EntitlementNetworkHost:      {},
 	EntitlementDevice:           {},
+	EntitlementLocalExec:        {},
+	EntitlementMountHost:        {},
 }
 
 type EntitlementsConfig interface {
@@ -165,11 +169,25 @@ func (s Set) Check(v Values) error {
 			return errors.Errorf("%s is not allowed", EntitlementSecurityInsecure)
 		}
 	}
+
+	if v.LocalExec {
+		if !s.Allowed(EntitlementLocalExec) {
+			return errors.Errorf("%s is not allowed", EntitlementLocalExec)
+		}
+	}
+
+	if v.MountHost {
+		if !s.Allowed(EntitlementMountHost) {
+			return errors.Errorf("%s is not allowed", EntitlementMountHost)
+		}
+	}
 	return nil
 }
 
 type Values struct {
 	NetworkHost      bool
 	SecurityInsecure bool
+	LocalExec        bool
+	MountHost        bool
 	Devices          map[string]struct{}
 }
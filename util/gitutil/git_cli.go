@@ -9,6 +9,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/moby/buildkit/util/proxy"
 	"github.com/pkg/errors"
 )
 
@@ -135,11 +136,6 @@ func (cli *GitCLI) Run(ctx context.Context, args ...string) (_ []byte, err error
 	if cli.git != "" {
 		gitBinary = cli.git
 	}
-	proxyEnvVars := [...]string{
-		"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY",
-		"http_proxy", "https_proxy", "no_proxy", "all_proxy",
-	}
-
 	for {
 		var cmd *exec.Cmd
 		if cli.exec == nil {
@@ -195,11 +191,7 @@ func (cli *GitCLI) Run(ctx context.Context, args ...string) (_ []byte, err error
 			"HOME=/dev/null",        // Disable reading from user gitconfig.
 			"LC_ALL=C",              // Ensure consistent output.
 		}
-		for _, ev := range proxyEnvVars {
-			if v, ok := os.LookupEnv(ev); ok {
-				cmd.Env = append(cmd.Env, ev+"="+v)
-			}
-		}
+		cmd.Env = append(cmd.Env, proxy.Env(ctx)...)
 		if cli.sshAuthSock != "" {
 			cmd.Env = append(cmd.Env, "SSH_AUTH_SOCK="+cli.sshAuthSock)
 		}
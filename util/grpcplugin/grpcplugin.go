@@ -0,0 +1,90 @@
+// Package grpcplugin connects to the plugins configured in buildkitd that
+// extend buildkit by serving a gRPC service.
+package grpcplugin
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"os"
+
+	"github.com/moby/buildkit/util/grpcerrors"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type Opt struct {
+	// Address is the address of the plugin, unix:// or tcp://
+	Address string
+	// CA, Cert and Key configure TLS for tcp addresses
+	CA   string
+	Cert string
+	Key  string
+}
+
+// NewClient returns a connection to the plugin. The connection is
+// established with the first call.
+func NewClient(opt Opt) (*grpc.ClientConn, error) {
+	t, err := target(opt.Address)
+	if err != nil {
+		return nil, err
+	}
+	creds := insecure.NewCredentials()
+	if opt.CA != "" || opt.Cert != "" {
+		cfg, err := tlsConfig(opt)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(cfg)
+	}
+	conn, err := grpc.NewClient(t,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUnaryInterceptor(grpcerrors.UnaryClientInterceptor),
+	)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return conn, nil
+}
+
+func target(address string) (string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid address")
+	}
+	switch u.Scheme {
+	case "unix":
+		return address, nil
+	case "tcp":
+		if u.Host == "" {
+			return "", errors.Errorf("invalid address %q, missing host", address)
+		}
+		return u.Host, nil
+	default:
+		return "", errors.Errorf("invalid address %q, unix:// or tcp:// expected", address)
+	}
+}
+
+func tlsConfig(opt Opt) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if opt.CA != "" {
+		dt, err := os.ReadFile(opt.CA)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read ca certificate")
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(dt) {
+			return nil, errors.New("failed to append ca certs")
+		}
+	}
+	if opt.Cert != "" || opt.Key != "" {
+		cert, err := tls.LoadX509KeyPair(opt.Cert, opt.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read certificate/key")
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
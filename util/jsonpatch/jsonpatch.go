@@ -0,0 +1,292 @@
+// Package jsonpatch applies JSON patches (RFC 6902) to JSON documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Operation is a single operation of a patch
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is a list of operations applied in order
+type Patch []Operation
+
+// Parse parses and validates a patch
+func Parse(dt []byte) (Patch, error) {
+	var p Patch
+	if err := json.Unmarshal(dt, &p); err != nil {
+		return nil, errors.Wrap(err, "failed to parse JSON patch")
+	}
+	for i, op := range p {
+		if _, err := parsePointer(op.Path); err != nil {
+			return nil, errors.Wrapf(err, "invalid operation %d", i)
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, errors.Errorf("invalid operation %d: %s requires a value", i, op.Op)
+			}
+		case "remove":
+		case "move", "copy":
+			if _, err := parsePointer(op.From); err != nil {
+				return nil, errors.Wrapf(err, "invalid operation %d", i)
+			}
+		default:
+			return nil, errors.Errorf("invalid operation %d: unknown op %q", i, op.Op)
+		}
+	}
+	return p, nil
+}
+
+// Apply returns the document dt with the patch applied
+func (p Patch) Apply(dt []byte) ([]byte, error) {
+	doc, err := decode(dt)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse document")
+	}
+	for i, op := range p {
+		doc, err = op.apply(doc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply operation %d (%s %s)", i, op.Op, op.Path)
+		}
+	}
+	return json.Marshal(doc)
+}
+
+func (op Operation) apply(doc any) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		v, err := decode(op.Value)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid value")
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, path, v)
+		case "replace":
+			return replace(doc, path, v)
+		default:
+			cur, err := get(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(cur, v) {
+				return nil, errors.New("test failed")
+			}
+			return doc, nil
+		}
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		v, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			dt, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			if v, err = decode(dt); err != nil {
+				return nil, err
+			}
+			return add(doc, path, v)
+		}
+		if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return nil, errors.New("can't move a value into itself")
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, v)
+	}
+	return nil, errors.Errorf("unknown op %q", op.Op)
+}
+
+func decode(dt []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(dt))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// parsePointer parses a JSON pointer (RFC 6901)
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, errors.Errorf("invalid JSON pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func arrayIndex(token string, n int, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return n, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, errors.Errorf("invalid array index %q", token)
+	}
+	if i > n || (!allowEnd && i == n) {
+		return 0, errors.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
+
+func get(node any, path []string) (any, error) {
+	for _, t := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[t]
+			if !ok {
+				return nil, errors.Errorf("%q not found", t)
+			}
+			node = v
+		case []any:
+			i, err := arrayIndex(t, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, errors.Errorf("can't get %q of a scalar value", t)
+		}
+	}
+	return node, nil
+}
+
+// update returns node with the parent container of path replaced by the
+// result of f
+func update(node any, path []string, f func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return f(node, path[0])
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[path[0]]
+		if !ok {
+			return nil, errors.Errorf("%q not found", path[0])
+		}
+		c, err := update(child, path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		n[path[0]] = c
+		return n, nil
+	case []any:
+		i, err := arrayIndex(path[0], len(n), false)
+		if err != nil {
+			return nil, err
+		}
+		c, err := update(n[i], path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = c
+		return n, nil
+	default:
+		return nil, errors.Errorf("can't get %q of a scalar value", path[0])
+	}
+}
+
+func add(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return update(doc, path, func(parent any, key string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			n[key] = v
+			return n, nil
+		case []any:
+			i, err := arrayIndex(key, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = v
+			return n, nil
+		default:
+			return nil, errors.Errorf("can't add %q to a scalar value", key)
+		}
+	})
+}
+
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, errors.New("can't remove the document")
+	}
+	return update(doc, path, func(parent any, key string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			if _, ok := n[key]; !ok {
+				return nil, errors.Errorf("%q not found", key)
+			}
+			delete(n, key)
+			return n, nil
+		case []any:
+			i, err := arrayIndex(key, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:i], n[i+1:]...), nil
+		default:
+			return nil, errors.Errorf("can't remove %q of a scalar value", key)
+		}
+	})
+}
+
+func replace(doc any, path []string, v any) (any, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return update(doc, path, func(parent any, key string) (any, error) {
+		switch n := parent.(type) {
+		case map[string]any:
+			if _, ok := n[key]; !ok {
+				return nil, errors.Errorf("%q not found", key)
+			}
+			n[key] = v
+			return n, nil
+		case []any:
+			i, err := arrayIndex(key, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			n[i] = v
+			return n, nil
+		default:
+			return nil, errors.Errorf("can't replace %q of a scalar value", key)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	doc := `{"config":{"Labels":{"a":"1","b/c":"2"},"Entrypoint":["/bin/sh"]},"history":[{"created_by":"one"},{"created_by":"two"}],"size":10}`
+
+	tcs := []struct {
+		name     string
+		patch    string
+		expected string
+		err      string
+	}{
+		{
+			name:     "add",
+			patch:    `[{"op":"add","path":"/config/Labels/c","value":"3"},{"op":"add","path":"/history/1","value":{"comment":"x"}},{"op":"add","path":"/history/-","value":{}}]`,
+			expected: `{"config":{"Labels":{"a":"1","b/c":"2","c":"3"},"Entrypoint":["/bin/sh"]},"history":[{"created_by":"one"},{"comment":"x"},{"created_by":"two"},{}],"size":10}`,
+		},
+		{
+			name:     "remove",
+			patch:    `[{"op":"remove","path":"/config/Labels/b~1c"},{"op":"remove","path":"/history/0"}]`,
+			expected: `{"config":{"Labels":{"a":"1"},"Entrypoint":["/bin/sh"]},"history":[{"created_by":"two"}],"size":10}`,
+		},
+		{
+			name:     "replace",
+			patch:    `[{"op":"replace","path":"/config/Entrypoint","value":["/app"]},{"op":"replace","path":"/history/1/created_by","value":"2"}]`,
+			expected: `{"config":{"Labels":{"a":"1","b/c":"2"},"Entrypoint":["/app"]},"history":[{"created_by":"one"},{"created_by":"2"}],"size":10}`,
+		},
+		{
+			name:     "move and copy",
+			patch:    `[{"op":"move","from":"/config/Labels/a","path":"/config/Labels/d"},{"op":"copy","from":"/history/0","path":"/history/-"}]`,
+			expected: `{"config":{"Labels":{"b/c":"2","d":"1"},"Entrypoint":["/bin/sh"]},"history":[{"created_by":"one"},{"created_by":"two"},{"created_by":"one"}],"size":10}`,
+		},
+		{
+			name:     "test",
+			patch:    `[{"op":"test","path":"/size","value":10},{"op":"test","path":"/config/Entrypoint","value":["/bin/sh"]}]`,
+			expected: doc,
+		},
+		{
+			name:  "test failed",
+			patch: `[{"op":"add","path":"/size","value":11},{"op":"test","path":"/size","value":10}]`,
+			err:   "failed to apply operation 1 (test /size): test failed",
+		},
+		{
+			name:  "missing parent",
+			patch: `[{"op":"add","path":"/missing/a","value":1}]`,
+			err:   `"missing" not found`,
+		},
+		{
+			name:  "replace missing",
+			patch: `[{"op":"replace","path":"/config/User","value":"root"}]`,
+			err:   `"User" not found`,
+		},
+		{
+			name:  "index out of range",
+			patch: `[{"op":"remove","path":"/history/2"}]`,
+			err:   "array index 2 out of range",
+		},
+		{
+			name:  "move into itself",
+			patch: `[{"op":"move","from":"/config","path":"/config/Labels/x"}]`,
+			err:   "can't move a value into itself",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Parse([]byte(tc.patch))
+			require.NoError(t, err)
+			dt, err := p.Apply([]byte(doc))
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expected, string(dt))
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, patch := range []string{
+		`{"op":"add"}`,
+		`[{"op":"add","path":"/a"}]`,
+		`[{"op":"append","path":"/a","value":1}]`,
+		`[{"op":"remove","path":"a"}]`,
+		`[{"op":"copy","from":"a","path":"/a"}]`,
+	} {
+		_, err := Parse([]byte(patch))
+		require.Error(t, err, patch)
+	}
+
+	// an explicit null is a value
+	p, err := Parse([]byte(`[{"op":"add","path":"/a","value":null}]`))
+	require.NoError(t, err)
+	dt, err := p.Apply([]byte(`{}`))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"a":null}`, string(dt))
+}
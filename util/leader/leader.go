@@ -0,0 +1,89 @@
+// Package leader elects one leader among the daemons sharing a store, so that
+// the operations deleting from the store, like the garbage collection and
+// prune, only run on one of them.
+package leader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gofrs/flock"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/pkg/errors"
+)
+
+// Elector takes part in the election of the leader of a shared store. The
+// leader holds a lock on a file of the store until it closes its elector or
+// exits, the other daemons take the lock over when they try to become the
+// leader after that.
+type Elector struct {
+	id string
+
+	mu     sync.Mutex
+	lock   *flock.Flock
+	leader bool
+}
+
+// New returns the elector of the daemon id for the lock file at path. The
+// directory of the lock file must be on the storage shared by the daemons.
+func New(path, id string) (*Elector, error) {
+	if id == "" {
+		return nil, errors.New("empty leader election id")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &Elector{
+		id:   id,
+		lock: flock.New(path),
+	}, nil
+}
+
+// ID returns the id of the daemon of the elector
+func (e *Elector) ID() string {
+	return e.id
+}
+
+// IsLeader returns whether the daemon is the leader, trying to become the
+// leader if no other daemon is
+func (e *Elector) IsLeader() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.leader {
+		return true
+	}
+	ok, err := e.lock.TryLock()
+	if err != nil {
+		bklog.L.Warnf("failed to lock %s for the leader election: %v", e.lock.Path(), err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	// the id is only informative, the lock decides the leader
+	if err := os.WriteFile(e.lock.Path(), []byte(e.id+"\n"), 0600); err != nil {
+		bklog.L.Warnf("failed to record the leader in %s: %v", e.lock.Path(), err)
+	}
+	e.leader = true
+	bklog.L.Infof("%s became the leader of the shared store", e.id)
+	return true
+}
+
+// Leader returns the id of the last daemon that became the leader
+func (e *Elector) Leader() string {
+	dt, err := os.ReadFile(e.lock.Path())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(dt))
+}
+
+// Close gives up the leadership
+func (e *Elector) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.leader = false
+	return errors.WithStack(e.lock.Close())
+}
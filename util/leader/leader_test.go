@@ -0,0 +1,31 @@
+package leader
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestElector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared", "leader.lock")
+	a, err := New(path, "a")
+	require.NoError(t, err)
+	b, err := New(path, "b")
+	require.NoError(t, err)
+
+	require.True(t, a.IsLeader())
+	require.True(t, a.IsLeader())
+	require.False(t, b.IsLeader())
+	require.Equal(t, "a", b.Leader())
+
+	// the other daemon takes over once the leader stops
+	require.NoError(t, a.Close())
+	require.True(t, b.IsLeader())
+	require.False(t, a.IsLeader())
+	require.Equal(t, "b", a.Leader())
+	require.NoError(t, b.Close())
+
+	_, err = New(path, "")
+	require.ErrorContains(t, err, "empty leader election id")
+}
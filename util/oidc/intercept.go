@@ -0,0 +1,79 @@
+package oidc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/moby/buildkit/util/clientidentity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor returns an interceptor that authenticates bearer
+// tokens sent with unary calls. If required is set, calls without a token or
+// a verified TLS client certificate are rejected.
+func UnaryServerInterceptor(v *Verifier, required bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := v.authenticate(ctx, info.FullMethod, required)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of UnaryServerInterceptor
+func StreamServerInterceptor(v *Verifier, required bool) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := v.authenticate(ss.Context(), info.FullMethod, required)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+func (v *Verifier) authenticate(ctx context.Context, method string, required bool) (context.Context, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		if required && !isExempt(method) && clientidentity.FromContext(ctx) == nil {
+			return nil, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		return ctx, nil
+	}
+	id, err := v.Verify(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return clientidentity.WithIdentity(ctx, id), nil
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, v := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(v, " ")
+		if ok && strings.EqualFold(scheme, "bearer") && token != "" {
+			return strings.TrimSpace(token), true
+		}
+	}
+	return "", false
+}
+
+func isExempt(method string) bool {
+	return strings.HasPrefix(method, "/"+grpc_health_v1.Health_ServiceDesc.ServiceName+"/")
+}
+
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *wrappedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,107 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// publicKeys returns the signing keys of the set indexed by key ID. Keys of
+// unsupported types are skipped.
+func (ks jsonWebKeySet) publicKeys() (map[string]any, error) {
+	keys := make(map[string]any, len(ks.Keys))
+	for _, k := range ks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var pub any
+		var err error
+		switch k.Kty {
+		case "RSA":
+			pub, err = k.rsaKey()
+		case "EC":
+			pub, err = k.ecKey()
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid key %q", k.Kid)
+		}
+		keys[k.Kid] = pub
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("key set contains no supported signing keys")
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	var c elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		c = elliptic.P256()
+	case "P-384":
+		c = elliptic.P384()
+	case "P-521":
+		c = elliptic.P521()
+	default:
+		return nil, errors.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := decodeInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if !c.IsOnCurve(x, y) {
+		return nil, errors.New("point is not on curve")
+	}
+	return &ecdsa.PublicKey{Curve: c, X: x, Y: y}, nil
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing key parameter")
+	}
+	dt, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid key parameter")
+	}
+	return new(big.Int).SetBytes(dt), nil
+}
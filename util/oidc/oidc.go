@@ -0,0 +1,192 @@
+// Package oidc authenticates clients with bearer tokens issued by an OpenID
+// Connect provider, e.g. CI workload identity tokens.
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/moby/buildkit/util/clientidentity"
+	"github.com/moby/buildkit/util/flightcontrol"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MethodOIDC is used for identities established with an OIDC token
+	MethodOIDC = "oidc"
+
+	defaultUsernameClaim = "sub"
+	// minRefreshInterval limits how often the key set is refetched when a
+	// token is signed with an unknown key
+	minRefreshInterval = time.Minute
+)
+
+var validMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Config configures token validation
+type Config struct {
+	// Issuer is the expected "iss" claim and the base URL for discovery
+	Issuer string
+	// Audiences are accepted "aud" claim values. Any audience is accepted if empty.
+	Audiences []string
+	// JWKSURL overrides the key set URL discovered from the issuer
+	JWKSURL string
+	// UsernameClaim is the claim used as the identity name, "sub" by default
+	UsernameClaim string
+	// Client is used for discovery and key set requests
+	Client *http.Client
+}
+
+// Verifier validates tokens against the key set of an issuer
+type Verifier struct {
+	cfg Config
+
+	mu          sync.Mutex
+	keys        map[string]any
+	lastRefresh time.Time
+	refresh     flightcontrol.Group[struct{}]
+}
+
+// NewVerifier returns a new token verifier. Keys are fetched lazily on
+// first use so that an unavailable issuer doesn't block daemon startup.
+func NewVerifier(cfg Config) (*Verifier, error) {
+	if cfg.Issuer == "" {
+		return nil, errors.New("oidc issuer not set")
+	}
+	if cfg.UsernameClaim == "" {
+		cfg.UsernameClaim = defaultUsernameClaim
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Verifier{cfg: cfg}, nil
+}
+
+// Verify validates a raw token and returns the identity it establishes
+func (v *Verifier) Verify(ctx context.Context, raw string) (*clientidentity.Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	}, jwt.WithValidMethods(validMethods), jwt.WithIssuer(v.cfg.Issuer), jwt.WithExpirationRequired(), jwt.WithLeeway(time.Minute))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid token")
+	}
+	if len(v.cfg.Audiences) > 0 {
+		aud, err := claims.GetAudience()
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid token audience")
+		}
+		if !slices.ContainsFunc(aud, func(a string) bool {
+			return slices.Contains(v.cfg.Audiences, a)
+		}) {
+			return nil, errors.Errorf("token audience %v not allowed", []string(aud))
+		}
+	}
+	name, ok := claims[v.cfg.UsernameClaim].(string)
+	if !ok || name == "" {
+		return nil, errors.Errorf("token has no %q claim", v.cfg.UsernameClaim)
+	}
+	return &clientidentity.Identity{Name: name, Method: MethodOIDC}, nil
+}
+
+func (v *Verifier) key(ctx context.Context, kid string) (any, error) {
+	v.mu.Lock()
+	k, ok := v.lookup(kid)
+	v.mu.Unlock()
+	if ok {
+		return k, nil
+	}
+
+	// the key set is fetched without holding the lock so that tokens signed
+	// with known keys are not blocked by a slow issuer
+	if _, err := v.refresh.Do(ctx, "", func(ctx context.Context) (struct{}, error) {
+		v.mu.Lock()
+		recent := !v.lastRefresh.IsZero() && time.Since(v.lastRefresh) < minRefreshInterval
+		v.mu.Unlock()
+		if recent {
+			return struct{}{}, nil
+		}
+		keys, err := v.fetchKeys(ctx)
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.lastRefresh = time.Now()
+		if err != nil {
+			return struct{}{}, err
+		}
+		v.keys = keys
+		return struct{}{}, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if k, ok := v.lookup(kid); ok {
+		return k, nil
+	}
+	return nil, errors.Errorf("unknown signing key %q", kid)
+}
+
+// lookup must be called with v.mu held
+func (v *Verifier) lookup(kid string) (any, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, true
+		}
+	}
+	k, ok := v.keys[kid]
+	return k, ok
+}
+
+func (v *Verifier) fetchKeys(ctx context.Context) (map[string]any, error) {
+	jwksURL := v.cfg.JWKSURL
+	if jwksURL == "" {
+		var discovery struct {
+			JWKSURI string `json:"jwks_uri"`
+		}
+		u := strings.TrimSuffix(v.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+		if err := v.getJSON(ctx, u, &discovery); err != nil {
+			return nil, errors.Wrap(err, "oidc discovery failed")
+		}
+		if discovery.JWKSURI == "" {
+			return nil, errors.Errorf("no jwks_uri in discovery document of %s", v.cfg.Issuer)
+		}
+		jwksURL = discovery.JWKSURI
+	}
+	var ks jsonWebKeySet
+	if err := v.getJSON(ctx, jwksURL, &ks); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch key set")
+	}
+	return ks.publicKeys()
+}
+
+func (v *Verifier) getJSON(ctx context.Context, u string, out any) error {
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("unexpected status %s from %s", resp.Status, u)
+	}
+	dt, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(dt, out); err != nil {
+		return errors.Wrapf(err, "invalid response from %s", u)
+	}
+	return nil
+}
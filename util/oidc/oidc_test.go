@@ -0,0 +1,210 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/moby/buildkit/util/clientidentity"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testIssuer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	jwksHits atomic.Int64
+	// block delays key set responses while set, fetching is signaled
+	// when a delayed response starts
+	block    chan struct{}
+	fetching chan struct{}
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ti := &testIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   ti.URL,
+			"jwks_uri": ti.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		ti.jwksHits.Add(1)
+		if ti.block != nil {
+			ti.fetching <- struct{}{}
+			<-ti.block
+		}
+		json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{{
+			Kty: "RSA",
+			Kid: "key1",
+			Use: "sig",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	ti.Server = httptest.NewServer(mux)
+	t.Cleanup(ti.Close)
+	return ti
+}
+
+func (ti *testIssuer) token(t *testing.T, kid string, claims jwt.MapClaims) string {
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	tok.Header["kid"] = kid
+	s, err := tok.SignedString(ti.key)
+	require.NoError(t, err)
+	return s
+}
+
+func (ti *testIssuer) claims(sub string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss": ti.URL,
+		"sub": sub,
+		"aud": "buildkit",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ti := newTestIssuer(t)
+	v, err := NewVerifier(Config{Issuer: ti.URL, Audiences: []string{"buildkit"}})
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	id, err := v.Verify(ctx, ti.token(t, "key1", ti.claims("repo:moby/buildkit")))
+	require.NoError(t, err)
+	require.Equal(t, &clientidentity.Identity{Name: "repo:moby/buildkit", Method: MethodOIDC}, id)
+
+	c := ti.claims("foo")
+	c["aud"] = "other"
+	_, err = v.Verify(ctx, ti.token(t, "key1", c))
+	require.ErrorContains(t, err, "audience")
+
+	c = ti.claims("foo")
+	c["iss"] = "https://evil.example.com"
+	_, err = v.Verify(ctx, ti.token(t, "key1", c))
+	require.Error(t, err)
+
+	c = ti.claims("foo")
+	c["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = v.Verify(ctx, ti.token(t, "key1", c))
+	require.Error(t, err)
+
+	c = ti.claims("foo")
+	delete(c, "exp")
+	_, err = v.Verify(ctx, ti.token(t, "key1", c))
+	require.Error(t, err)
+
+	// unknown key doesn't refetch the key set more than once per interval
+	_, err = v.Verify(ctx, ti.token(t, "key2", ti.claims("foo")))
+	require.ErrorContains(t, err, "unknown signing key")
+	require.Equal(t, int64(1), ti.jwksHits.Load())
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, ti.claims("foo"))
+	tok.Header["kid"] = "key1"
+	s, err := tok.SignedString(other)
+	require.NoError(t, err)
+	_, err = v.Verify(ctx, s)
+	require.Error(t, err)
+}
+
+func TestVerifySlowIssuer(t *testing.T) {
+	ti := newTestIssuer(t)
+	v, err := NewVerifier(Config{Issuer: ti.URL, Audiences: []string{"buildkit"}})
+	require.NoError(t, err)
+	ctx := context.TODO()
+
+	_, err = v.Verify(ctx, ti.token(t, "key1", ti.claims("foo")))
+	require.NoError(t, err)
+
+	// allow refetching the key set and make the issuer hang
+	v.mu.Lock()
+	v.lastRefresh = time.Time{}
+	v.mu.Unlock()
+	ti.block = make(chan struct{})
+	ti.fetching = make(chan struct{}, 1)
+
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := v.Verify(ctx, ti.token(t, "key2", ti.claims("foo")))
+			errs <- err
+		}()
+	}
+
+	// tokens signed with known keys don't wait for the key set refresh
+	<-ti.fetching
+	_, err = v.Verify(ctx, ti.token(t, "key1", ti.claims("foo")))
+	require.NoError(t, err)
+
+	close(ti.block)
+	for range 2 {
+		require.ErrorContains(t, <-errs, "unknown signing key")
+	}
+	require.Equal(t, int64(2), ti.jwksHits.Load())
+}
+
+func TestVerifyUsernameClaim(t *testing.T) {
+	ti := newTestIssuer(t)
+	v, err := NewVerifier(Config{Issuer: ti.URL, JWKSURL: ti.URL + "/jwks", UsernameClaim: "email"})
+	require.NoError(t, err)
+
+	c := ti.claims("foo")
+	_, err = v.Verify(context.TODO(), ti.token(t, "key1", c))
+	require.ErrorContains(t, err, `"email"`)
+
+	c["email"] = "dev@example.com"
+	id, err := v.Verify(context.TODO(), ti.token(t, "key1", c))
+	require.NoError(t, err)
+	require.Equal(t, "dev@example.com", id.Name)
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	ti := newTestIssuer(t)
+	v, err := NewVerifier(Config{Issuer: ti.URL})
+	require.NoError(t, err)
+
+	var got *clientidentity.Identity
+	handler := func(ctx context.Context, req any) (any, error) {
+		got = clientidentity.FromContext(ctx)
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/moby.buildkit.v1.Control/Solve"}
+
+	ctx := metadata.NewIncomingContext(context.TODO(), metadata.Pairs("authorization", "Bearer "+ti.token(t, "key1", ti.claims("ci"))))
+	_, err = UnaryServerInterceptor(v, true)(ctx, nil, info, handler)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	require.Equal(t, "ci", got.Name)
+
+	ctx = metadata.NewIncomingContext(context.TODO(), metadata.Pairs("authorization", "Bearer invalid"))
+	_, err = UnaryServerInterceptor(v, false)(ctx, nil, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = UnaryServerInterceptor(v, true)(context.TODO(), nil, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	got = nil
+	_, err = UnaryServerInterceptor(v, false)(context.TODO(), nil, info, handler)
+	require.NoError(t, err)
+	require.Nil(t, got)
+
+	_, err = UnaryServerInterceptor(v, true)(context.TODO(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	require.NoError(t, err)
+}
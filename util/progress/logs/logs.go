@@ -14,6 +14,7 @@ import (
 	"github.com/armon/circbuf"
 	"github.com/moby/buildkit/client"
 	"github.com/moby/buildkit/identity"
+	"github.com/moby/buildkit/util/bklog"
 	"github.com/moby/buildkit/util/progress"
 	"github.com/pkg/errors"
 	"github.com/tonistiigi/units"
@@ -29,10 +30,72 @@ const (
 
 var configCheckOnce sync.Once
 
+// Limits are the limits of the output of a step sent to the clients
+type Limits struct {
+	// MaxSize is the maximum number of bytes of a stream. -1 disables the
+	// limit. Zero uses the BUILDKIT_STEP_LOG_MAX_SIZE environment variable or
+	// the default limit.
+	MaxSize int
+	// MaxSpeed is the maximum number of bytes per second of a stream. -1
+	// disables the limit. Zero uses the BUILDKIT_STEP_LOG_MAX_SPEED
+	// environment variable or the default limit.
+	MaxSpeed int
+	// Spill keeps the output dropped because of the limits, if set
+	Spill Spiller
+}
+
+// Spiller keeps the output of a stream dropped because of the limits
+type Spiller interface {
+	// Spill returns the writer of the output of a stream from the point it
+	// was first clipped. The ID is logged instead of that output.
+	Spill() (id string, w io.WriteCloser, err error)
+}
+
+type limitsKey struct{}
+
+// WithLimits sets the limits of the log streams created with ctx
+func WithLimits(ctx context.Context, l Limits) context.Context {
+	return context.WithValue(ctx, limitsKey{}, l)
+}
+
+func limitsFromContext(ctx context.Context) Limits {
+	configCheckOnce.Do(loadEnvLimits)
+	l, _ := ctx.Value(limitsKey{}).(Limits)
+	if l.MaxSize == 0 {
+		l.MaxSize = defaultMaxLogSize
+	}
+	if l.MaxSpeed == 0 {
+		l.MaxSpeed = defaultMaxLogSpeed
+	}
+	return l
+}
+
+func loadEnvLimits() {
+	maxLogSize, err := strconv.ParseInt(os.Getenv("BUILDKIT_STEP_LOG_MAX_SIZE"), 10, 32)
+	if err == nil {
+		defaultMaxLogSize = int(maxLogSize)
+	}
+	maxLogSpeed, err := strconv.ParseInt(os.Getenv("BUILDKIT_STEP_LOG_MAX_SPEED"), 10, 32)
+	if err == nil {
+		defaultMaxLogSpeed = int(maxLogSpeed)
+	}
+}
+
 func NewLogStreams(ctx context.Context, printOutput bool) (io.WriteCloser, io.WriteCloser, func()) {
 	stdout := newStreamWriter(ctx, stdout, printOutput)
 	stderr := newStreamWriter(ctx, stderr, printOutput)
-	return stdout, stderr, func() {
+	r := redactorFromContext(ctx)
+	if r == nil {
+		return stdout, stderr, func() {
+			stdout.flushBuffer()
+			stderr.flushBuffer()
+		}
+	}
+	rStdout := &redactWriter{WriteCloser: stdout, r: r}
+	rStderr := &redactWriter{WriteCloser: stderr, r: r}
+	return rStdout, rStderr, func() {
+		_ = rStdout.flush()
+		_ = rStderr.flush()
 		stdout.flushBuffer()
 		stderr.flushBuffer()
 	}
@@ -41,14 +104,17 @@ func NewLogStreams(ctx context.Context, printOutput bool) (io.WriteCloser, io.Wr
 func newStreamWriter(ctx context.Context, stream int, printOutput bool) *streamWriter {
 	pw, _, _ := progress.NewFromContext(ctx)
 	return &streamWriter{
+		ctx:         ctx,
 		pw:          pw,
 		stream:      stream,
 		printOutput: printOutput,
 		created:     time.Now(),
+		limits:      limitsFromContext(ctx),
 	}
 }
 
 type streamWriter struct {
+	ctx             context.Context
 	pw              progress.Writer
 	stream          int
 	printOutput     bool
@@ -57,30 +123,21 @@ type streamWriter struct {
 	clipping        bool
 	clipReasonSpeed bool
 	buf             *circbuf.Buffer
+	limits          Limits
+	spill           io.WriteCloser
 }
 
 func (sw *streamWriter) checkLimit(n int) int {
-	configCheckOnce.Do(func() {
-		maxLogSize, err := strconv.ParseInt(os.Getenv("BUILDKIT_STEP_LOG_MAX_SIZE"), 10, 32)
-		if err == nil {
-			defaultMaxLogSize = int(maxLogSize)
-		}
-		maxLogSpeed, err := strconv.ParseInt(os.Getenv("BUILDKIT_STEP_LOG_MAX_SPEED"), 10, 32)
-		if err == nil {
-			defaultMaxLogSpeed = int(maxLogSpeed)
-		}
-	})
-
 	oldSize := sw.size
 	sw.size += n
 
 	maxSize := -1
-	if defaultMaxLogSpeed != -1 {
-		maxSize = int(math.Ceil(time.Since(sw.created).Seconds())) * defaultMaxLogSpeed
+	if sw.limits.MaxSpeed != -1 {
+		maxSize = int(math.Ceil(time.Since(sw.created).Seconds())) * sw.limits.MaxSpeed
 		sw.clipReasonSpeed = true
 	}
-	if maxSize == -1 || maxSize > defaultMaxLogSize {
-		maxSize = defaultMaxLogSize
+	if maxSize == -1 || maxSize > sw.limits.MaxSize {
+		maxSize = sw.limits.MaxSize
 		sw.clipReasonSpeed = false
 	}
 
@@ -98,9 +155,26 @@ func (sw *streamWriter) checkLimit(n int) int {
 
 func (sw *streamWriter) clipLimitMessage() string {
 	if sw.clipReasonSpeed {
-		return fmt.Sprintf("%#g/s", units.Bytes(defaultMaxLogSpeed))
+		return fmt.Sprintf("%#g/s", units.Bytes(sw.limits.MaxSpeed))
+	}
+	return fmt.Sprintf("%#g", units.Bytes(sw.limits.MaxSize))
+}
+
+// startSpill opens the spill writer of the stream. The output is still logged
+// if it can't be opened.
+func (sw *streamWriter) startSpill() error {
+	if sw.limits.Spill == nil || sw.spill != nil {
+		return nil
+	}
+	id, w, err := sw.limits.Spill.Spill()
+	if err != nil {
+		bklog.G(sw.ctx).WithError(err).Warn("failed to spill clipped step output")
+		sw.limits.Spill = nil
+		return nil
 	}
-	return fmt.Sprintf("%#g", units.Bytes(defaultMaxLogSize))
+	sw.spill = w
+	_, err = sw.write(client.VertexLog{Spill: id})
+	return err
 }
 
 func (sw *streamWriter) Write(dt []byte) (int, error) {
@@ -117,32 +191,52 @@ func (sw *streamWriter) Write(dt []byte) (int, error) {
 		sw.buf.Write(dt)
 	}
 
+	spilling := sw.spill != nil
+	spilled := dt
+	if !spilling {
+		spilled = dt[limit:]
+	}
 	dt = slices.Clone(dt[:limit])
 
 	if sw.clipping && oldSize == len(dt) {
 		sw.clipping = false
 	}
-	if !sw.clipping && oldSize != len(dt) {
-		dt = append(dt, fmt.Appendf(nil, "\n[output clipped, log limit %s reached]\n", sw.clipLimitMessage())...)
-		sw.clipping = true
+	if limit < oldSize {
+		if err := sw.startSpill(); err != nil {
+			return 0, err
+		}
 	}
-
-	_, err := sw.write(dt)
-	if err != nil {
+	// once the stream is spilled, its output is only added to the build
+	// history from the spill writer
+	if sw.spill != nil {
+		if _, err := sw.spill.Write(spilled); err != nil {
+			bklog.G(sw.ctx).WithError(err).Warn("failed to spill clipped step output")
+		}
+	}
+	if _, err := sw.write(client.VertexLog{Data: dt, Replay: spilling}); err != nil {
 		return 0, err
 	}
+	if !sw.clipping && oldSize != len(dt) {
+		msg := fmt.Sprintf("\n[output clipped, log limit %s reached]\n", sw.clipLimitMessage())
+		if sw.spill != nil {
+			msg = fmt.Sprintf("\n[output clipped, log limit %s reached, full output is kept in the build history]\n", sw.clipLimitMessage())
+		}
+		if _, err := sw.write(client.VertexLog{Data: []byte(msg), Truncated: true}); err != nil {
+			return 0, err
+		}
+		sw.clipping = true
+	}
 	return oldSize, nil
 }
 
-func (sw *streamWriter) write(dt []byte) (int, error) {
-	if len(dt) == 0 {
+func (sw *streamWriter) write(l client.VertexLog) (int, error) {
+	dt := l.Data
+	if len(dt) == 0 && l.Spill == "" {
 		return 0, nil
 	}
-	sw.pw.Write(identity.NewID(), client.VertexLog{
-		Stream: sw.stream,
-		Data:   dt,
-	})
-	if sw.printOutput {
+	l.Stream = sw.stream
+	sw.pw.Write(identity.NewID(), l)
+	if sw.printOutput && len(dt) > 0 {
 		switch sw.stream {
 		case 1:
 			return os.Stdout.Write(dt)
@@ -159,11 +253,17 @@ func (sw *streamWriter) flushBuffer() {
 	if sw.buf == nil {
 		return
 	}
-	_, _ = sw.write(sw.buf.Bytes())
+	// with spill the buffered output is already in the spill writer
+	_, _ = sw.write(client.VertexLog{Data: sw.buf.Bytes(), Replay: sw.spill != nil})
 	sw.buf = nil
 }
 
 func (sw *streamWriter) Close() error {
+	if sw.spill != nil {
+		if err := sw.spill.Close(); err != nil {
+			bklog.G(sw.ctx).WithError(err).Warn("failed to spill clipped step output")
+		}
+	}
 	return sw.pw.Close()
 }
 
@@ -0,0 +1,167 @@
+package logs
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"slices"
+	"sync"
+)
+
+// RedactedPlaceholder replaces the secret values in the log streams
+const RedactedPlaceholder = "***"
+
+// minRedactLength is the length of the shortest secret value that is
+// redacted. Shorter values would make most of the output unreadable.
+const minRedactLength = 4
+
+// Redactor keeps the secret values that are replaced in the log streams
+// created with a context it is attached to
+type Redactor struct {
+	mu     sync.RWMutex
+	values [][]byte
+	first  [256]bool
+}
+
+type redactorKey struct{}
+
+// WithRedactor attaches a new redactor to ctx. The secrets added with
+// AddSecret on the returned context are redacted from its log streams.
+func WithRedactor(ctx context.Context) context.Context {
+	return context.WithValue(ctx, redactorKey{}, &Redactor{})
+}
+
+func redactorFromContext(ctx context.Context) *Redactor {
+	r, _ := ctx.Value(redactorKey{}).(*Redactor)
+	return r
+}
+
+// AddSecret redacts the secret value dt from the log streams created with
+// ctx. Every line of a multi-line value is redacted as well. It is a no-op if
+// ctx has no redactor attached.
+func AddSecret(ctx context.Context, dt []byte) {
+	if r := redactorFromContext(ctx); r != nil {
+		r.Add(dt)
+	}
+}
+
+// Add redacts the secret value dt and its lines
+func (r *Redactor) Add(dt []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(dt)
+	if bytes.ContainsRune(dt, '\n') {
+		for _, l := range bytes.Split(dt, []byte("\n")) {
+			r.add(bytes.TrimSpace(l))
+		}
+	}
+	// longest values first so that a value containing another one is
+	// replaced as a whole
+	slices.SortFunc(r.values, func(a, b []byte) int {
+		return cmp.Compare(len(b), len(a))
+	})
+}
+
+func (r *Redactor) add(dt []byte) {
+	if len(dt) < minRedactLength {
+		return
+	}
+	for _, v := range r.values {
+		if bytes.Equal(v, dt) {
+			return
+		}
+	}
+	r.values = append(r.values, bytes.Clone(dt))
+	r.first[dt[0]] = true
+}
+
+// redact replaces the secret values in dt. The end of dt that may be the
+// beginning of a secret value is returned separately so that it can be
+// completed with the next write.
+func (r *Redactor) redact(dt []byte) (out []byte, rest []byte) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.values) == 0 {
+		return dt, nil
+	}
+	out = make([]byte, 0, len(dt))
+	for i := 0; i < len(dt); i++ {
+		if !r.first[dt[i]] {
+			out = append(out, dt[i])
+			continue
+		}
+		if n := r.match(dt[i:]); n > 0 {
+			out = append(out, RedactedPlaceholder...)
+			i += n - 1
+			continue
+		}
+		if r.isPrefix(dt[i:]) {
+			return out, dt[i:]
+		}
+		out = append(out, dt[i])
+	}
+	return out, nil
+}
+
+// match returns the length of the secret value dt begins with
+func (r *Redactor) match(dt []byte) int {
+	for _, v := range r.values {
+		if bytes.HasPrefix(dt, v) {
+			return len(v)
+		}
+	}
+	return 0
+}
+
+// isPrefix returns true if dt is the beginning of a secret value
+func (r *Redactor) isPrefix(dt []byte) bool {
+	for _, v := range r.values {
+		if len(dt) < len(v) && bytes.HasPrefix(v, dt) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactWriter redacts the secret values of a redactor before writing to
+// the underlying stream
+type redactWriter struct {
+	io.WriteCloser
+	r    *Redactor
+	mu   sync.Mutex
+	rest []byte
+}
+
+func (rw *redactWriter) Write(dt []byte) (int, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	out, rest := rw.r.redact(append(rw.rest, dt...))
+	rw.rest = bytes.Clone(rest)
+	if len(out) > 0 {
+		if _, err := rw.WriteCloser.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(dt), nil
+}
+
+// flush writes the output held back as the possible beginning of a secret
+// value
+func (rw *redactWriter) flush() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if len(rw.rest) == 0 {
+		return nil
+	}
+	_, err := rw.WriteCloser.Write(rw.rest)
+	rw.rest = nil
+	return err
+}
+
+func (rw *redactWriter) Close() error {
+	if err := rw.flush(); err != nil {
+		return err
+	}
+	return rw.WriteCloser.Close()
+}
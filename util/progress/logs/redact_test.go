@@ -0,0 +1,51 @@
+package logs
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type nopCloser struct {
+	bytes.Buffer
+}
+
+func (*nopCloser) Close() error {
+	return nil
+}
+
+func TestRedactWriter(t *testing.T) {
+	ctx := WithRedactor(context.TODO())
+	AddSecret(ctx, []byte("s3cr3t-token"))
+	AddSecret(ctx, []byte("abc"))
+	AddSecret(ctx, []byte("-----BEGIN KEY-----\nMIIEvQIBADANBg\n-----END KEY-----\n"))
+
+	buf := &nopCloser{}
+	w := &redactWriter{WriteCloser: buf, r: redactorFromContext(ctx)}
+
+	for _, dt := range []string{
+		"token is s3cr3t-",
+		"token, abc\n",
+		"key line MIIEvQIBADANBg\n",
+		"ends with s3cr",
+	} {
+		n, err := w.Write([]byte(dt))
+		require.NoError(t, err)
+		require.Equal(t, len(dt), n)
+	}
+	require.Equal(t, "token is ***, abc\nkey line ***\nends with ", buf.String())
+
+	require.NoError(t, w.Close())
+	require.Equal(t, "token is ***, abc\nkey line ***\nends with s3cr", buf.String())
+}
+
+func TestRedactWithoutSecrets(t *testing.T) {
+	AddSecret(context.TODO(), []byte("s3cr3t-token"))
+
+	r := &Redactor{}
+	out, rest := r.redact([]byte("no secrets"))
+	require.Equal(t, "no secrets", string(out))
+	require.Nil(t, rest)
+}
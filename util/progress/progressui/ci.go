@@ -0,0 +1,213 @@
+package progressui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	"github.com/tonistiigi/units"
+	"golang.org/x/time/rate"
+)
+
+// ciSlowestSteps is the number of steps listed in the summary of the ci
+// display.
+const ciSlowestSteps = 5
+
+// pushStatusAction is the action of the statuses reported for the blobs
+// uploaded to a registry. Resumed uploads append the resumed size to it.
+const pushStatusAction = "pushing"
+
+type ciDisplay struct {
+	t         *trace
+	w         io.Writer
+	desc      string
+	github    bool
+	printed   map[*vertex]struct{}
+	nextIndex int
+}
+
+// newCIDisplay creates a new Display that prints a single line for every
+// completed step and a summary of the build when it finishes. When running
+// in GitHub Actions the logs of every step are folded into a collapsible
+// group.
+func newCIDisplay(w io.Writer, opts ...DisplayOpt) Display {
+	dsso := newDisplayOpts(opts...)
+	return Display{
+		disp: &ciDisplay{
+			t:       newTrace(w, false),
+			w:       w,
+			desc:    dsso.textDesc,
+			github:  os.Getenv("GITHUB_ACTIONS") == "true",
+			printed: map[*vertex]struct{}{},
+		},
+	}
+}
+
+func (d *ciDisplay) init(displayLimiter *rate.Limiter) {
+	if d.desc != "" {
+		fmt.Fprintf(d.w, "#0 %s\n", d.desc)
+	}
+}
+
+func (d *ciDisplay) update(ss *client.SolveStatus) {
+	d.t.update(ss, 80)
+	d.printCompleted()
+}
+
+func (d *ciDisplay) refresh() {
+	// Output is only written when steps complete.
+}
+
+func (d *ciDisplay) done() {
+	d.printCompleted()
+	d.t.printErrorLogs(d.w)
+	d.printSummary()
+}
+
+func (d *ciDisplay) printCompleted() {
+	var completed []*vertex
+	for _, v := range d.t.vertexes {
+		if _, ok := d.printed[v]; ok || v.hidden || !v.isCompleted() {
+			continue
+		}
+		completed = append(completed, v)
+	}
+	sort.SliceStable(completed, func(i, j int) bool {
+		return completed[i].mostRecentInterval().stop.Before(*completed[j].mostRecentInterval().stop)
+	})
+	for _, v := range completed {
+		d.printVtx(v)
+	}
+}
+
+func (d *ciDisplay) printVtx(v *vertex) {
+	d.printed[v] = struct{}{}
+	d.nextIndex++
+	v.index = d.nextIndex
+
+	var line string
+	switch {
+	case v.Error != "" && strings.HasSuffix(v.Error, context.Canceled.Error()):
+		line = fmt.Sprintf("#%d CANCELED       %s", v.index, v.Name)
+	case v.Error != "":
+		line = fmt.Sprintf("#%d ERROR  %6.1fs %s", v.index, vertexDuration(v).Seconds(), v.Name)
+	case v.Cached:
+		line = fmt.Sprintf("#%d CACHED         %s", v.index, v.Name)
+	default:
+		line = fmt.Sprintf("#%d DONE   %6.1fs %s", v.index, vertexDuration(v).Seconds(), v.Name)
+	}
+
+	if !d.github || (len(v.logs) == 0 && v.Error == "") {
+		fmt.Fprintln(d.w, line)
+		if v.Error == "" {
+			v.logs = nil
+		}
+		return
+	}
+
+	fmt.Fprintf(d.w, "::group::%s\n", line)
+	for _, l := range v.logs {
+		fmt.Fprintf(d.w, "%s\n", l)
+	}
+	fmt.Fprintln(d.w, "::endgroup::")
+	if v.Error != "" && !strings.HasSuffix(v.Error, context.Canceled.Error()) {
+		fmt.Fprintf(d.w, "::error title=%s::%s\n", githubEscapeProperty(v.Name), githubEscape(v.Error))
+	}
+	// logs of failed steps are printed again with the error summary
+	if v.Error == "" {
+		v.logs = nil
+	}
+}
+
+func (d *ciDisplay) printSummary() {
+	var (
+		steps, cached int
+		pushed        int64
+		durations     []*vertex
+		start, stop   *time.Time
+	)
+	for _, v := range d.t.vertexes {
+		if v.hidden || !v.isStarted() {
+			continue
+		}
+		steps++
+		if v.Cached {
+			cached++
+		} else {
+			durations = append(durations, v)
+		}
+		for _, ival := range v.mergedIntervals {
+			if start == nil || ival.start.Before(*start) {
+				start = ival.start
+			}
+			if ival.stop != nil && (stop == nil || ival.stop.After(*stop)) {
+				stop = ival.stop
+			}
+		}
+		for _, s := range v.statuses {
+			if strings.HasPrefix(s.Name, pushStatusAction) && s.Completed != nil {
+				pushed += s.Current
+			}
+		}
+	}
+	if steps == 0 {
+		return
+	}
+
+	sort.SliceStable(durations, func(i, j int) bool {
+		return vertexDuration(durations[i]) > vertexDuration(durations[j])
+	})
+	if len(durations) > ciSlowestSteps {
+		durations = durations[:ciSlowestSteps]
+	}
+
+	var total time.Duration
+	if start != nil && stop != nil {
+		total = stop.Sub(*start)
+	}
+
+	fmt.Fprintln(d.w, "")
+	fmt.Fprintln(d.w, "SUMMARY")
+	tw := tabwriter.NewWriter(d.w, 1, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "  duration:\t%.1fs\n", total.Seconds())
+	fmt.Fprintf(tw, "  steps:\t%d\n", steps)
+	fmt.Fprintf(tw, "  cache hits:\t%d (%.1f%%)\n", cached, float64(cached)*100/float64(steps))
+	fmt.Fprintf(tw, "  pushed:\t%.2f\n", units.Bytes(pushed))
+	tw.Flush()
+
+	if len(durations) == 0 {
+		return
+	}
+	fmt.Fprintln(d.w, "")
+	fmt.Fprintln(d.w, "SLOWEST STEPS")
+	tw = tabwriter.NewWriter(d.w, 1, 8, 2, ' ', 0)
+	for _, v := range durations {
+		fmt.Fprintf(tw, "  %.1fs\t%s\n", vertexDuration(v).Seconds(), v.Name)
+	}
+	tw.Flush()
+}
+
+func vertexDuration(v *vertex) time.Duration {
+	var dt time.Duration
+	for _, ival := range v.mergedIntervals {
+		dt += ival.duration()
+	}
+	return dt
+}
+
+// githubEscape escapes the message of a GitHub Actions workflow command.
+func githubEscape(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// githubEscapeProperty escapes a property value of a GitHub Actions
+// workflow command.
+func githubEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,79 @@
+package progressui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCIDisplay(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+
+	buf := &bytes.Buffer{}
+	disp := newCIDisplay(buf)
+	d := disp.disp.(*ciDisplay)
+	d.init(nil)
+
+	pushed := time.Unix(13, 0)
+	d.update(&client.SolveStatus{
+		Vertexes: []*client.Vertex{
+			mkVertex("[1/3] FROM busybox", 0, 1, true),
+			mkVertex("[2/3] RUN make", 1, 11, false),
+			mkVertex("exporting to image", 11, 14, false),
+		},
+		Logs: []*client.VertexLog{{
+			Vertex:    digest.FromString("[2/3] RUN make"),
+			Data:      []byte("build output\n"),
+			Timestamp: time.Unix(2, 0),
+		}},
+		Statuses: []*client.VertexStatus{{
+			ID:        "pushing docker.io/library/a@sha256:abc",
+			Vertex:    digest.FromString("exporting to image"),
+			Name:      pushStatusAction,
+			Total:     2000,
+			Current:   2000,
+			Started:   &pushed,
+			Completed: &pushed,
+		}, {
+			ID:        "pushing docker.io/library/b@sha256:def",
+			Vertex:    digest.FromString("exporting to image"),
+			Name:      "pushing, resumed 512B",
+			Total:     1000,
+			Current:   1000,
+			Started:   &pushed,
+			Completed: &pushed,
+		}, {
+			// failed upload only counts the uploaded bytes
+			ID:        "pushing docker.io/library/b@sha256:abc",
+			Vertex:    digest.FromString("exporting to image"),
+			Name:      pushStatusAction,
+			Total:     2000,
+			Current:   500,
+			Started:   &pushed,
+			Completed: &pushed,
+		}},
+	})
+	failed := mkVertex("[3/3] RUN false", 11, 12, false)
+	failed.Error = "exit code: 1"
+	d.update(&client.SolveStatus{
+		Vertexes: []*client.Vertex{failed},
+	})
+	d.done()
+
+	out := buf.String()
+	lines := strings.Split(out, "\n")
+	require.Equal(t, "#1 CACHED         [1/3] FROM busybox", lines[0])
+	require.Equal(t, "::group::#2 DONE     10.0s [2/3] RUN make", lines[1])
+	require.Contains(t, lines[2], "build output")
+	require.Equal(t, "::endgroup::", lines[3])
+	require.Contains(t, out, "#3 DONE      3.0s exporting to image\n")
+	require.Contains(t, out, "::error title=[3/3] RUN false::exit code: 1\n")
+	require.Contains(t, out, "cache hits:  1 (25.0%)\n")
+	require.Contains(t, out, "pushed:      3.50kB\n")
+	require.Contains(t, out, "SLOWEST STEPS\n  10.0s  [2/3] RUN make\n  3.0s   exporting to image\n")
+}
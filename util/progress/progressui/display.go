@@ -28,6 +28,7 @@ type displayOpts struct {
 	phase       string
 	textDesc    string
 	consoleDesc string
+	history     *History
 }
 
 func newDisplayOpts(opts ...DisplayOpt) *displayOpts {
@@ -53,6 +54,14 @@ func WithDesc(text string, console string) DisplayOpt {
 	}
 }
 
+// WithHistory sets the timings of a previous build that the tty display
+// uses to show the estimated remaining time of the build and its steps.
+func WithHistory(h *History) DisplayOpt {
+	return func(b *displayOpts) {
+		b.history = h
+	}
+}
+
 type Display struct {
 	disp display
 }
@@ -136,6 +145,10 @@ const (
 	// RawJSONMode is the raw JSON text output. It will marshal the various solve status events
 	// to JSON to be read by an external program.
 	RawJSONMode DisplayMode = "rawjson"
+	// CIMode prints a single line for every completed step with its duration and
+	// cache status, followed by a summary of the build. When running in GitHub
+	// Actions the logs of every step are folded into a collapsible group.
+	CIMode DisplayMode = "ci"
 )
 
 // NewDisplay constructs a Display that outputs to the given io.Writer with the given DisplayMode.
@@ -157,6 +170,8 @@ func NewDisplay(out io.Writer, mode DisplayMode, opts ...DisplayOpt) (Display, e
 		return newPlainDisplay(out, opts...), nil
 	case RawJSONMode:
 		return newRawJSONDisplay(out), nil
+	case CIMode:
+		return newCIDisplay(out, opts...), nil
 	case QuietMode:
 		return newDiscardDisplay(), nil
 	default:
@@ -198,9 +213,11 @@ func newConsoleDisplay(c console.Console, opts ...DisplayOpt) Display {
 	if dsso.phase == "" {
 		dsso.phase = "Building"
 	}
+	t := newTrace(c, true)
+	t.history = dsso.history
 	return Display{
 		disp: &consoleDisplay{
-			t:    newTrace(c, true),
+			t:    t,
 			disp: &ttyDisplay{c: c, phase: dsso.phase, desc: dsso.consoleDesc},
 		},
 	}
@@ -317,6 +334,8 @@ type displayInfo struct {
 	jobs           []*job
 	countTotal     int
 	countCompleted int
+	eta            time.Duration
+	hasETA         bool
 }
 
 type job struct {
@@ -329,6 +348,7 @@ type job struct {
 	isCanceled  bool
 	vertex      *vertex
 	showTerm    bool
+	history     *StepTiming
 }
 
 type trace struct {
@@ -340,6 +360,7 @@ type trace struct {
 	updates       map[digest.Digest]struct{}
 	modeConsole   bool
 	groups        map[string]*vertexGroup // group id -> group
+	history       *History
 }
 
 type vertex struct {
@@ -822,6 +843,9 @@ func (t *trace) displayInfo() (d displayInfo) {
 			d.countCompleted++
 		}
 	}
+	if t.history != nil {
+		d.eta, d.hasETA = t.history.remaining(t)
+	}
 
 	for _, v := range t.vertexes {
 		if v.jobCached {
@@ -856,6 +880,11 @@ func (t *trace) displayInfo() (d displayInfo) {
 		if v.Cached {
 			j.name = "CACHED " + j.name
 		}
+		if t.history != nil {
+			if st, ok := t.history.Lookup(v.Digest, v.Name); ok {
+				j.history = &st
+			}
+		}
 		j.name = v.indent + j.name
 		jobs = append(jobs, j)
 		for _, s := range v.statuses {
@@ -1001,6 +1030,10 @@ func (disp *ttyDisplay) print(d displayInfo, width, height int, all bool) {
 	fmt.Fprint(disp.c, aec.Hide)
 	defer fmt.Fprint(disp.c, aec.Show)
 
+	if statusStr == "" && d.hasETA {
+		statusStr = fmt.Sprintf("ETA %.1fs", d.eta.Seconds())
+	}
+
 	out := fmt.Sprintf("[+] %s %.1fs (%d/%d) %s", disp.phase, time.Since(d.startTime).Seconds(), d.countCompleted, d.countTotal, statusStr)
 	if disp.desc != "" {
 		out = align(out, disp.desc, width-1)
@@ -1026,6 +1059,9 @@ func (disp *ttyDisplay) print(d displayInfo, width, height int, all bool) {
 		pfx := " => "
 		timer := fmt.Sprintf(" %3.1fs\n", dt)
 		status := j.status
+		if status == "" && j.history != nil {
+			status = historyStatus(*j.history, time.Duration(dt*float64(time.Second)), j.isCompleted)
+		}
 		showStatus := false
 
 		left := width - len(pfx) - len(timer) - 1
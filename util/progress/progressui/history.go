@@ -0,0 +1,200 @@
+package progressui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	controlapi "github.com/moby/buildkit/api/services/control"
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// StepTiming is the timing of a single vertex recorded in a previous build.
+type StepTiming struct {
+	Duration time.Duration
+	Cached   bool
+}
+
+type historyStep struct {
+	StepTiming
+	intervals map[int64]interval
+}
+
+// History holds per-vertex timings of a previous build. The tty display
+// uses it to estimate the remaining time of running steps and of the whole
+// build. Vertexes are matched by digest first and by name if the digest has
+// changed since the previous build.
+type History struct {
+	steps  map[digest.Digest]*historyStep
+	byName map[string]digest.Digest
+
+	start, stop *time.Time
+}
+
+// NewHistory returns an empty History that can be filled with the status
+// updates of a build with Update.
+func NewHistory() *History {
+	return &History{
+		steps:  map[digest.Digest]*historyStep{},
+		byName: map[string]digest.Digest{},
+	}
+}
+
+// LoadHistory replays the progress of a completed build from the build
+// history of the daemon and collects the timings of its vertexes.
+func LoadHistory(ctx context.Context, c *client.Client, ref string) (*History, error) {
+	cl, err := c.ControlClient().Status(ctx, &controlapi.StatusRequest{
+		Ref: ref,
+	})
+	if err != nil {
+		return nil, err
+	}
+	h := NewHistory()
+	for {
+		resp, err := cl.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Wrapf(err, "failed to load progress of build %s", ref)
+		}
+		h.Update(client.NewSolveStatus(resp))
+	}
+	if len(h.steps) == 0 {
+		return nil, errors.Errorf("no progress recorded for build %s", ref)
+	}
+	return h, nil
+}
+
+// Update records the vertexes of a status update.
+func (h *History) Update(ss *client.SolveStatus) {
+	for _, v := range ss.Vertexes {
+		if v.Started == nil {
+			continue
+		}
+		st, ok := h.steps[v.Digest]
+		if !ok {
+			st = &historyStep{intervals: map[int64]interval{}}
+			h.steps[v.Digest] = st
+			if _, ok := h.byName[v.Name]; !ok && v.Name != "" {
+				h.byName[v.Name] = v.Digest
+			}
+		}
+		st.Cached = v.Cached
+		st.intervals[v.Started.UnixNano()] = interval{
+			start: v.Started,
+			stop:  v.Completed,
+		}
+		var ivals []interval
+		for _, ival := range st.intervals {
+			ivals = append(ivals, ival)
+		}
+		st.Duration = 0
+		for _, ival := range mergeIntervals(ivals) {
+			if ival.stop != nil {
+				st.Duration += ival.stop.Sub(*ival.start)
+			}
+		}
+
+		if h.start == nil || v.Started.Before(*h.start) {
+			h.start = v.Started
+		}
+		if v.Completed != nil && (h.stop == nil || v.Completed.After(*h.stop)) {
+			h.stop = v.Completed
+		}
+	}
+}
+
+// Duration returns the wall clock duration of the previous build.
+func (h *History) Duration() time.Duration {
+	if h.start == nil || h.stop == nil {
+		return 0
+	}
+	return h.stop.Sub(*h.start)
+}
+
+// Lookup returns the timing of the vertex in the previous build.
+func (h *History) Lookup(dgst digest.Digest, name string) (StepTiming, bool) {
+	_, st, ok := h.lookup(dgst, name)
+	if !ok {
+		return StepTiming{}, false
+	}
+	return st.StepTiming, true
+}
+
+func (h *History) lookup(dgst digest.Digest, name string) (digest.Digest, *historyStep, bool) {
+	if st, ok := h.steps[dgst]; ok {
+		return dgst, st, true
+	}
+	if d, ok := h.byName[name]; ok {
+		return d, h.steps[d], true
+	}
+	return "", nil, false
+}
+
+// remaining estimates how long it takes for the build in t to complete.
+// Work that is left is the previous duration of every step that has not
+// completed yet, divided by the parallelism observed in the previous build.
+func (h *History) remaining(t *trace) (time.Duration, bool) {
+	wall := h.Duration()
+	if wall <= 0 {
+		return 0, false
+	}
+	var total time.Duration
+	for _, st := range h.steps {
+		total += st.Duration
+	}
+	parallelism := max(float64(total)/float64(wall), 1)
+
+	seen := map[digest.Digest]struct{}{}
+	var left time.Duration
+	for dgst, v := range t.byDigest {
+		if v.Vertex == nil {
+			continue
+		}
+		hd, st, ok := h.lookup(dgst, v.Name)
+		if !ok {
+			continue
+		}
+		seen[hd] = struct{}{}
+		if v.isCompleted() || v.Cached || st.Cached {
+			continue
+		}
+		var elapsed time.Duration
+		for _, ival := range v.mergedIntervals {
+			elapsed += interval{
+				start: addTime(ival.start, t.localTimeDiff),
+				stop:  addTime(ival.stop, t.localTimeDiff),
+			}.duration()
+		}
+		if st.Duration > elapsed {
+			left += st.Duration - elapsed
+		}
+	}
+	for dgst, st := range h.steps {
+		if _, ok := seen[dgst]; ok || st.Cached {
+			continue
+		}
+		left += st.Duration
+	}
+	return time.Duration(float64(left) / parallelism), true
+}
+
+// historyStatus returns the status shown next to a vertex in the tty
+// display. Running steps show their estimated remaining time and completed
+// steps show how long they took in the previous build.
+func historyStatus(st StepTiming, elapsed time.Duration, completed bool) string {
+	if !completed {
+		if rem := st.Duration - elapsed; rem > 0 && !st.Cached {
+			return fmt.Sprintf("ETA %.1fs", rem.Seconds())
+		}
+		return ""
+	}
+	if st.Cached {
+		return "last run: cached"
+	}
+	return fmt.Sprintf("last run: %.1fs", st.Duration.Seconds())
+}
@@ -0,0 +1,94 @@
+package progressui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/moby/buildkit/client"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/require"
+)
+
+func mkVertex(name string, start, stop int64, cached bool) *client.Vertex {
+	started := time.Unix(start, 0)
+	v := &client.Vertex{
+		Digest:  digest.FromString(name),
+		Name:    name,
+		Started: &started,
+		Cached:  cached,
+	}
+	if stop >= 0 {
+		completed := time.Unix(stop, 0)
+		v.Completed = &completed
+	}
+	return v
+}
+
+func TestHistoryLookup(t *testing.T) {
+	h := NewHistory()
+	h.Update(&client.SolveStatus{
+		Vertexes: []*client.Vertex{
+			mkVertex("a", 0, 4, false),
+			mkVertex("b", 0, 0, true),
+		},
+	})
+	// a second interval of the same vertex is added to its duration
+	h.Update(&client.SolveStatus{
+		Vertexes: []*client.Vertex{
+			mkVertex("a", 6, 8, false),
+		},
+	})
+	require.Equal(t, 8*time.Second, h.Duration())
+
+	st, ok := h.Lookup(digest.FromString("a"), "a")
+	require.True(t, ok)
+	require.Equal(t, StepTiming{Duration: 6 * time.Second}, st)
+
+	st, ok = h.Lookup(digest.FromString("b"), "b")
+	require.True(t, ok)
+	require.True(t, st.Cached)
+
+	// falls back to the vertex name if the digest changed
+	st, ok = h.Lookup(digest.FromString("c"), "a")
+	require.True(t, ok)
+	require.Equal(t, 6*time.Second, st.Duration)
+
+	_, ok = h.Lookup(digest.FromString("c"), "c")
+	require.False(t, ok)
+}
+
+func TestHistoryRemaining(t *testing.T) {
+	h := NewHistory()
+	// a and b ran in parallel, c ran after them
+	h.Update(&client.SolveStatus{
+		Vertexes: []*client.Vertex{
+			mkVertex("a", 0, 10, false),
+			mkVertex("b", 0, 10, false),
+			mkVertex("c", 10, 20, false),
+		},
+	})
+
+	tr := newTrace(nil, false)
+	tr.history = h
+	now := time.Now().Unix()
+	tr.update(&client.SolveStatus{
+		Vertexes: []*client.Vertex{
+			mkVertex("a", now-20, now-10, false),
+			mkVertex("b", now-10, -1, false),
+		},
+	}, 80)
+	tr.localTimeDiff = 0
+
+	// b has 0s left and c 10s, previous build ran 30s of work in 20s
+	eta, ok := h.remaining(tr)
+	require.True(t, ok)
+	require.InDelta(t, (20 * time.Second / 3).Seconds(), eta.Seconds(), 1)
+}
+
+func TestHistoryStatus(t *testing.T) {
+	st := StepTiming{Duration: 12 * time.Second}
+	require.Equal(t, "last run: 12.0s", historyStatus(st, 3*time.Second, true))
+	require.Equal(t, "ETA 9.0s", historyStatus(st, 3*time.Second, false))
+	require.Empty(t, historyStatus(st, 15*time.Second, false))
+	require.Equal(t, "last run: cached", historyStatus(StepTiming{Cached: true}, 0, true))
+}
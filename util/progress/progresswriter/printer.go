@@ -56,7 +56,7 @@ func Tee(w Writer, ch chan *client.SolveStatus) Writer {
 	return t
 }
 
-func NewPrinter(ctx context.Context, out console.File, mode string) (Writer, error) {
+func NewPrinter(ctx context.Context, out console.File, mode string, opts ...progressui.DisplayOpt) (Writer, error) {
 	statusCh := make(chan *client.SolveStatus)
 	doneCh := make(chan struct{})
 
@@ -69,7 +69,7 @@ func NewPrinter(ctx context.Context, out console.File, mode string) (Writer, err
 		mode = v
 	}
 
-	d, err := progressui.NewDisplay(out, progressui.DisplayMode(mode))
+	d, err := progressui.NewDisplay(out, progressui.DisplayMode(mode), opts...)
 	if err != nil {
 		return nil, err
 	}
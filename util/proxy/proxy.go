@@ -0,0 +1,130 @@
+// Package proxy holds the HTTP proxy configuration of a build. The source
+// fetches of the daemon use the configuration of the context of their build
+// instead of the proxy environment variables of the daemon.
+package proxy
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// Config is the proxy configuration of a build, in the format of the
+// HTTP_PROXY, HTTPS_PROXY, NO_PROXY and ALL_PROXY environment variables
+type Config struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+	// AllProxy is the proxy of the requests without a proxy of their scheme
+	AllProxy string
+	// Exec also sets the proxy environment variables of the exec ops that
+	// don't set their own
+	Exec bool
+}
+
+// Validate checks the proxy URLs of the config
+func (c *Config) Validate() error {
+	for _, kv := range [][2]string{
+		{"http", c.HTTPProxy},
+		{"https", c.HTTPSProxy},
+		{"all", c.AllProxy},
+	} {
+		if kv[1] == "" {
+			continue
+		}
+		// the URLs without a scheme are http proxies, like in the
+		// environment variables
+		s := kv[1]
+		if !strings.Contains(s, "://") {
+			s = "http://" + s
+		}
+		u, err := url.Parse(s)
+		if err == nil && u.Host == "" {
+			err = errors.New("no host")
+		}
+		if err != nil {
+			return errors.Wrapf(err, "invalid %s proxy %q", kv[0], kv[1])
+		}
+	}
+	return nil
+}
+
+// ProxyURL returns the proxy of a request URL, or nil if the request has no
+// proxy
+func (c *Config) ProxyURL(u *url.URL) (*url.URL, error) {
+	cfg := httpproxy.Config{
+		HTTPProxy:  cmp.Or(c.HTTPProxy, c.AllProxy),
+		HTTPSProxy: cmp.Or(c.HTTPSProxy, c.AllProxy),
+		NoProxy:    c.NoProxy,
+	}
+	return cfg.ProxyFunc()(u)
+}
+
+// Env returns the environment variables of the config, in upper and lower
+// case
+func (c *Config) Env() []string {
+	var env []string
+	for _, kv := range [][2]string{
+		{"HTTP_PROXY", c.HTTPProxy},
+		{"HTTPS_PROXY", c.HTTPSProxy},
+		{"NO_PROXY", c.NoProxy},
+		{"ALL_PROXY", c.AllProxy},
+	} {
+		if kv[1] != "" {
+			env = append(env, kv[0]+"="+kv[1], strings.ToLower(kv[0])+"="+kv[1])
+		}
+	}
+	return env
+}
+
+var envVars = [...]string{
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY",
+	"http_proxy", "https_proxy", "no_proxy", "all_proxy",
+}
+
+type configKey struct{}
+
+// WithConfig returns a context with which the source fetches use the proxy
+// configuration
+func WithConfig(ctx context.Context, c *Config) context.Context {
+	return context.WithValue(ctx, configKey{}, c)
+}
+
+// FromContext returns the proxy configuration of a context, or nil if the
+// fetches use the proxy environment variables of the daemon
+func FromContext(ctx context.Context) *Config {
+	c, _ := ctx.Value(configKey{}).(*Config)
+	return c
+}
+
+// FromRequest returns the proxy of a request with the configuration of its
+// context, or of the environment if it has none. It is meant for the Proxy
+// of an http.Transport.
+func FromRequest(req *http.Request) (*url.URL, error) {
+	if c := FromContext(req.Context()); c != nil {
+		return c.ProxyURL(req.URL)
+	}
+	return http.ProxyFromEnvironment(req)
+}
+
+// Env returns the proxy environment variables of the commands run by the
+// daemon for the fetches of a context: the variables of its configuration,
+// or the ones of the daemon if it has none
+func Env(ctx context.Context) []string {
+	if c := FromContext(ctx); c != nil {
+		return c.Env()
+	}
+	var env []string
+	for _, ev := range envVars {
+		if v, ok := os.LookupEnv(ev); ok {
+			env = append(env, ev+"="+v)
+		}
+	}
+	return env
+}
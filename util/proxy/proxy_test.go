@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromRequest(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://daemon:3128")
+	t.Setenv("NO_PROXY", "")
+
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "https://registry.example.com/v2/", nil)
+	require.NoError(t, err)
+	u, err := FromRequest(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://daemon:3128", u.String())
+
+	cfg := &Config{HTTPSProxy: "build:3128", AllProxy: "http://all:3128", NoProxy: ".corp.example.com"}
+	ctx := WithConfig(context.TODO(), cfg)
+	for _, tc := range []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://registry.example.com/v2/", expected: "http://build:3128"},
+		{url: "http://example.com/file", expected: "http://all:3128"},
+		{url: "https://git.corp.example.com/repo.git"},
+	} {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, tc.url, nil)
+		require.NoError(t, err)
+		u, err := FromRequest(req)
+		require.NoError(t, err)
+		if tc.expected == "" {
+			require.Nil(t, u, tc.url)
+		} else {
+			require.Equal(t, tc.expected, u.String(), tc.url)
+		}
+	}
+}
+
+func TestEnv(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "http://daemon:3128")
+	require.Contains(t, Env(context.TODO()), "HTTP_PROXY=http://daemon:3128")
+
+	ctx := WithConfig(context.TODO(), &Config{HTTPSProxy: "http://build:3128", AllProxy: "socks5://all:1080"})
+	require.Equal(t, []string{
+		"HTTPS_PROXY=http://build:3128", "https_proxy=http://build:3128",
+		"ALL_PROXY=socks5://all:1080", "all_proxy=socks5://all:1080",
+	}, Env(ctx))
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, (&Config{HTTPProxy: "proxy:3128", HTTPSProxy: "https://proxy", AllProxy: "socks5://proxy:1080"}).Validate())
+	require.ErrorContains(t, (&Config{HTTPSProxy: "http://"}).Validate(), `invalid https proxy "http://"`)
+	require.ErrorContains(t, (&Config{AllProxy: "http://proxy:port"}).Validate(), "invalid all proxy")
+}
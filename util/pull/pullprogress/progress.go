@@ -13,6 +13,13 @@ import (
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+var pulledBytes, _ = otel.Meter("github.com/moby/buildkit/util/pull/pullprogress").Int64Counter("buildkit.pull.bytes",
+	metric.WithDescription("Amount of data fetched from registries."),
+	metric.WithUnit("By"),
 )
 
 type PullManager interface {
@@ -78,6 +85,14 @@ type readerWithCancel struct {
 	logger *logrus.Entry
 }
 
+func (r readerWithCancel) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		pulledBytes.Add(context.Background(), int64(n))
+	}
+	return n, err
+}
+
 func (r readerWithCancel) Close() error {
 	r.cancel(errors.WithStack(context.Canceled))
 	select {
@@ -0,0 +1,401 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/images"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	remoteserrors "github.com/containerd/containerd/v2/core/remotes/errors"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/distribution/reference"
+	"github.com/docker/go-units"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/progress/logs"
+	"github.com/moby/buildkit/util/resolver"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+)
+
+type hostsResolver interface {
+	HostsFunc(host string) ([]docker.RegistryHost, error)
+	Tracker() docker.StatusTrackLocker
+}
+
+// chunkedPusher uploads large blobs in chunks and lets other content be
+// pushed by the wrapped pusher. Uploads are recorded in the tracker of the
+// resolver like the uploads of the wrapped pusher.
+type chunkedPusher struct {
+	remotes.Pusher
+	cfg     resolver.ChunkedUploadConfig
+	host    docker.RegistryHost
+	tracker docker.StatusTrackLocker
+	domain  string
+	repo    string
+}
+
+// newChunkedPusher wraps p with a chunkedPusher if chunked uploads are
+// configured for the push host of ref
+func newChunkedPusher(p remotes.Pusher, hr hostsResolver, ref string) (remotes.Pusher, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, err
+	}
+	domain := reference.Domain(named)
+	hosts, err := hr.HostsFunc(domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hosts {
+		if h.Capabilities.Has(docker.HostCapabilityPush) {
+			cfg := resolver.ChunkedUploads(h)
+			if cfg == nil {
+				return p, nil
+			}
+			return &chunkedPusher{
+				Pusher:  p,
+				cfg:     *cfg,
+				host:    h,
+				tracker: hr.Tracker(),
+				domain:  domain,
+				repo:    reference.Path(named),
+			}, nil
+		}
+	}
+	return p, nil
+}
+
+func (p *chunkedPusher) Push(ctx context.Context, desc ocispecs.Descriptor) (content.Writer, error) {
+	if images.IsManifestType(desc.MediaType) || images.IsIndexType(desc.MediaType) || desc.Size < p.cfg.ChunkSize {
+		return p.Pusher.Push(ctx, desc)
+	}
+	// same key and locking as the wrapped pusher, so that a blob is not
+	// uploaded twice by concurrent pushes
+	ref := remotes.MakeRefKey(ctx, desc)
+	p.tracker.Lock(ref)
+	defer p.tracker.Unlock(ref)
+	if status, err := p.tracker.GetStatus(ref); err == nil && status.Committed && status.Offset == status.Total {
+		return nil, errors.Wrapf(cerrdefs.ErrAlreadyExists, "ref %s", ref)
+	}
+
+	ctx = docker.WithScope(ctx, "repository:"+p.repo+":pull,push")
+
+	resp, err := p.do(ctx, http.MethodHead, p.url("blobs", desc.Digest.String()), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		p.setCommitted(ref, desc, docker.PushStatus{Exists: true})
+		return nil, errors.Wrapf(cerrdefs.ErrAlreadyExists, "blob %s", desc.Digest)
+	}
+
+	location, err := p.startUpload(ctx, ref, desc)
+	if err != nil || location == nil {
+		return nil, err
+	}
+	now := time.Now()
+	p.tracker.SetStatus(ref, docker.Status{
+		Status: content.Status{
+			Ref:       ref,
+			Total:     desc.Size,
+			Expected:  desc.Digest,
+			StartedAt: now,
+			UpdatedAt: now,
+		},
+	})
+	return &chunkedWriter{
+		ctx:      ctx,
+		p:        p,
+		ref:      ref,
+		desc:     desc,
+		location: location,
+		backoff:  p.cfg.Backoff,
+		progress: pushProgressFromContext(ctx),
+	}, nil
+}
+
+func (p *chunkedPusher) setCommitted(ref string, desc ocispecs.Descriptor, ps docker.PushStatus) {
+	now := time.Now()
+	p.tracker.SetStatus(ref, docker.Status{
+		Committed: true,
+		Status: content.Status{
+			Ref:       ref,
+			Offset:    desc.Size,
+			Total:     desc.Size,
+			Expected:  desc.Digest,
+			StartedAt: now,
+			UpdatedAt: now,
+		},
+		PushStatus: ps,
+	})
+}
+
+func (p *chunkedPusher) setOffset(ref string, offset int64) {
+	status, err := p.tracker.GetStatus(ref)
+	if err != nil {
+		return
+	}
+	status.Offset = offset
+	status.UpdatedAt = time.Now()
+	p.tracker.SetStatus(ref, status)
+}
+
+// startUpload starts a new upload session. If the blob is known to exist in
+// another repository of the registry it is mounted instead and nil is
+// returned.
+func (p *chunkedPusher) startUpload(ctx context.Context, ref string, desc ocispecs.Descriptor) (*url.URL, error) {
+	u := p.url("blobs", "uploads") + "/"
+	for _, from := range strings.Split(desc.Annotations["containerd.io/distribution.source."+p.domain], ",") {
+		if from == "" || from == p.repo {
+			continue
+		}
+		mctx := docker.WithScope(ctx, "repository:"+from+":pull")
+		resp, err := p.do(mctx, http.MethodPost, u+"?"+url.Values{"mount": {desc.Digest.String()}, "from": {from}}.Encode(), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusCreated:
+			p.setCommitted(ref, desc, docker.PushStatus{MountedFrom: from})
+			return nil, errors.Wrapf(cerrdefs.ErrAlreadyExists, "blob %s mounted from %s", desc.Digest, from)
+		case http.StatusAccepted:
+			// registry started an upload instead of mounting
+			return location(resp)
+		}
+	}
+	resp, err := p.do(ctx, http.MethodPost, u, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, remoteserrors.NewUnexpectedStatusErr(resp)
+	}
+	return location(resp)
+}
+
+func (p *chunkedPusher) url(elem ...string) string {
+	return p.host.Scheme + "://" + p.host.Host + path.Join(append([]string{p.host.Path, p.repo}, elem...)...)
+}
+
+// do sends a request to the registry, authorizing again if the registry asks
+// for new credentials
+func (p *chunkedPusher) do(ctx context.Context, method, u string, body []byte, hdr http.Header) (*http.Response, error) {
+	for i := 0; ; i++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = int64(len(body))
+		for k, v := range hdr {
+			req.Header[k] = v
+		}
+		if p.host.Authorizer != nil {
+			if err := p.host.Authorizer.Authorize(ctx, req); err != nil {
+				return nil, err
+			}
+		}
+		client := p.host.Client
+		if client == nil {
+			client = http.DefaultClient
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && i == 0 && p.host.Authorizer != nil {
+			if err := p.host.Authorizer.AddResponses(ctx, []*http.Response{resp}); err == nil {
+				resp.Body.Close()
+				continue
+			}
+		}
+		return resp, nil
+	}
+}
+
+// chunkedWriter implements content.Writer for chunked uploads. If a chunk
+// fails to upload, the offset acknowledged by the registry is queried and
+// content.ErrReset is returned so that content.Copy continues from there.
+type chunkedWriter struct {
+	ctx      context.Context
+	p        *chunkedPusher
+	ref      string
+	desc     ocispecs.Descriptor
+	location *url.URL
+	progress *pushProgress
+
+	offset  int64
+	buf     []byte
+	resumes int
+	resumed int64
+	backoff time.Duration
+}
+
+func (w *chunkedWriter) Write(dt []byte) (int, error) {
+	w.buf = append(w.buf, dt...)
+	for int64(len(w.buf)) >= w.p.cfg.ChunkSize {
+		if err := w.uploadChunk(w.buf[:w.p.cfg.ChunkSize]); err != nil {
+			return 0, err
+		}
+	}
+	return len(dt), nil
+}
+
+func (w *chunkedWriter) uploadChunk(chunk []byte) error {
+	hdr := http.Header{}
+	hdr.Set("Content-Type", "application/octet-stream")
+	hdr.Set("Content-Range", fmt.Sprintf("%d-%d", w.offset, w.offset+int64(len(chunk))-1))
+	resp, err := w.p.do(w.ctx, http.MethodPatch, w.location.String(), chunk, hdr)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusAccepted {
+			if w.location, err = location(resp); err != nil {
+				return err
+			}
+			w.offset += int64(len(chunk))
+			w.buf = append(w.buf[:0], w.buf[len(chunk):]...)
+			w.p.setOffset(w.ref, w.offset)
+			w.progress.update(w.offset, w.resumed)
+			return nil
+		}
+		err = remoteserrors.NewUnexpectedStatusErr(resp)
+	}
+	return w.resume(err)
+}
+
+// resume queries the upload status after err and returns content.ErrReset
+// if the upload can continue from the acknowledged offset
+func (w *chunkedWriter) resume(err error) error {
+	if w.ctx.Err() != nil || w.resumes >= w.p.cfg.MaxResumes {
+		return err
+	}
+	w.resumes++
+	select {
+	case <-w.ctx.Done():
+		return err
+	case <-time.After(w.backoff):
+	}
+	w.backoff *= 2
+
+	resp, serr := w.p.do(w.ctx, http.MethodGet, w.location.String(), nil, nil)
+	if serr != nil {
+		return errors.Wrapf(err, "failed to get upload status: %v", serr)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return errors.Wrapf(err, "failed to get upload status: %v", remoteserrors.NewUnexpectedStatusErr(resp))
+	}
+	offset, perr := parseUploadRange(resp.Header.Get("Range"))
+	if perr != nil {
+		return errors.Wrapf(err, "invalid upload status: %v", perr)
+	}
+	if l, lerr := location(resp); lerr == nil {
+		w.location = l
+	}
+	w.offset = offset
+	w.buf = nil
+	w.resumed += offset
+	w.p.setOffset(w.ref, offset)
+	w.progress.update(offset, w.resumed)
+	msg := fmt.Sprintf("resuming upload of %s at %s of %s after error: %v\n", w.desc.Digest, units.HumanSize(float64(offset)), units.HumanSize(float64(w.desc.Size)), err)
+	if logger := logs.LoggerFromContext(w.ctx); logger != nil {
+		logger([]byte(msg))
+	}
+	bklog.G(w.ctx).Debug(strings.TrimSpace(msg))
+	return content.ErrReset
+}
+
+func (w *chunkedWriter) Commit(ctx context.Context, size int64, expected digest.Digest, _ ...content.Opt) error {
+	if size > 0 && w.offset+int64(len(w.buf)) != size {
+		return errors.Errorf("unexpected commit size %d, expected %d", w.offset+int64(len(w.buf)), size)
+	}
+	if expected == "" {
+		expected = w.desc.Digest
+	}
+	u := *w.location
+	q := u.Query()
+	q.Set("digest", expected.String())
+	u.RawQuery = q.Encode()
+
+	hdr := http.Header{}
+	hdr.Set("Content-Type", "application/octet-stream")
+	if len(w.buf) > 0 {
+		hdr.Set("Content-Range", fmt.Sprintf("%d-%d", w.offset, w.offset+int64(len(w.buf))-1))
+	}
+	resp, err := w.p.do(w.ctx, http.MethodPut, u.String(), w.buf, hdr)
+	if err == nil {
+		resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusCreated, http.StatusNoContent, http.StatusOK:
+			w.p.setCommitted(w.ref, w.desc, docker.PushStatus{})
+			w.progress.update(w.desc.Size, w.resumed)
+			if w.resumed > 0 {
+				bklog.G(ctx).Debugf("upload of %s completed after %d resumes, %d bytes not uploaded again", w.desc.Digest, w.resumes, w.resumed)
+			}
+			return nil
+		}
+		err = remoteserrors.NewUnexpectedStatusErr(resp)
+	}
+	return w.resume(err)
+}
+
+func (w *chunkedWriter) Status() (content.Status, error) {
+	return content.Status{
+		Ref:    w.desc.Digest.String(),
+		Offset: w.offset + int64(len(w.buf)),
+		Total:  w.desc.Size,
+	}, nil
+}
+
+func (w *chunkedWriter) Digest() digest.Digest {
+	return w.desc.Digest
+}
+
+func (w *chunkedWriter) Truncate(size int64) error {
+	return errors.Wrap(cerrdefs.ErrNotImplemented, "truncate is not supported for chunked uploads")
+}
+
+func (w *chunkedWriter) Close() error {
+	return nil
+}
+
+func location(resp *http.Response) (*url.URL, error) {
+	l := resp.Header.Get("Location")
+	if l == "" {
+		return nil, errors.Errorf("no upload location returned from %s", resp.Request.URL)
+	}
+	return resp.Request.URL.Parse(l)
+}
+
+// parseUploadRange returns the next offset from a Range header of an upload
+// status response. The header is in the "0-<last byte>" format. As some
+// registries also return "0-0" for empty uploads, it is handled as offset 0.
+func parseUploadRange(v string) (int64, error) {
+	v = strings.TrimPrefix(v, "bytes=")
+	if v == "" || v == "0-0" {
+		return 0, nil
+	}
+	_, end, ok := strings.Cut(v, "-")
+	if !ok {
+		return 0, errors.Errorf("invalid range %q", v)
+	}
+	n, err := strconv.ParseInt(end, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid range %q", v)
+	}
+	return n + 1, nil
+}
+
+var _ content.Writer = &chunkedWriter{}
@@ -0,0 +1,224 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/remotes"
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/buildkit/util/progress"
+	"github.com/moby/buildkit/util/resolver"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+// testRegistry implements the chunked blob upload API for a single upload.
+// The patch request with index failPatch only stores half of the chunk
+// before the connection is dropped.
+type testRegistry struct {
+	mu        sync.Mutex
+	data      []byte
+	blobs     map[digest.Digest][]byte
+	heads     int
+	patches   int
+	failPatch int
+	statuses  int
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case req.Method == http.MethodHead && strings.Contains(req.URL.Path, "/blobs/sha256:"):
+		r.heads++
+		dgst := digest.Digest(req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:])
+		if _, ok := r.blobs[dgst]; ok {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	case req.Method == http.MethodPost && strings.HasSuffix(req.URL.Path, "/blobs/uploads/"):
+		w.Header().Set("Location", "/v2/test/repo/blobs/uploads/upload1?state=0")
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodPatch:
+		var start, end int
+		_, err := fmt.Sscanf(req.Header.Get("Content-Range"), "%d-%d", &start, &end)
+		if err != nil || start != len(r.data) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		r.patches++
+		if r.patches == r.failPatch {
+			half := make([]byte, (end-start+1)/2)
+			n, _ := io.ReadFull(req.Body, half)
+			r.data = append(r.data, half[:n]...)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		dt, _ := io.ReadAll(req.Body)
+		r.data = append(r.data, dt...)
+		w.Header().Set("Location", fmt.Sprintf("/v2/test/repo/blobs/uploads/upload1?state=%d", len(r.data)))
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(r.data)-1))
+		w.WriteHeader(http.StatusAccepted)
+	case req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/blobs/uploads/"):
+		r.statuses++
+		w.Header().Set("Location", req.URL.String())
+		w.Header().Set("Range", fmt.Sprintf("0-%d", len(r.data)-1))
+		w.WriteHeader(http.StatusNoContent)
+	case req.Method == http.MethodPut:
+		dt, _ := io.ReadAll(req.Body)
+		r.data = append(r.data, dt...)
+		dgst := digest.Digest(req.URL.Query().Get("digest"))
+		if digest.FromBytes(r.data) != dgst {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		r.blobs[dgst] = r.data
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestChunkedPusher(t *testing.T, reg *testRegistry, chunkSize int64) *chunkedPusher {
+	srv := httptest.NewServer(reg)
+	t.Cleanup(srv.Close)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	return &chunkedPusher{
+		cfg:     resolver.ChunkedUploadConfig{ChunkSize: chunkSize, MaxResumes: 3},
+		tracker: docker.NewInMemoryTracker(),
+		host: docker.RegistryHost{
+			Client:       srv.Client(),
+			Scheme:       "http",
+			Host:         u.Host,
+			Path:         "/v2",
+			Capabilities: docker.HostCapabilityPush,
+		},
+		domain: u.Host,
+		repo:   "test/repo",
+	}
+}
+
+func TestChunkedUploadResume(t *testing.T) {
+	data := make([]byte, 10*1024+100)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+	desc := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageLayerGzip,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+
+	reg := &testRegistry{blobs: map[digest.Digest][]byte{}, failPatch: 3}
+	p := newTestChunkedPusher(t, reg, 1024)
+
+	pw := &testProgressWriter{}
+	pp := &pushProgress{pw: pw, id: "pushing " + desc.Digest.String(), st: progress.Status{Action: "pushing", Total: int(desc.Size)}}
+	ctx := context.WithValue(context.TODO(), pushProgressKey{}, pp)
+	cw, err := p.Push(ctx, desc)
+	require.NoError(t, err)
+	err = content.Copy(ctx, cw, io.NewSectionReader(bytes.NewReader(data), 0, desc.Size), desc.Size, desc.Digest)
+	require.NoError(t, err)
+
+	require.Equal(t, data, reg.blobs[desc.Digest])
+	require.Equal(t, 1, reg.statuses)
+	require.Equal(t, int64(2*1024+512), cw.(*chunkedWriter).resumed)
+
+	// resumed bytes are shown in progress
+	require.Equal(t, int(desc.Size), pw.last.Current)
+	require.Equal(t, "pushing, resumed 2.56kB", pw.last.Action)
+
+	// completed upload is recorded in the tracker, the registry isn't
+	// checked again
+	status, err := p.tracker.GetStatus(remotes.MakeRefKey(ctx, desc))
+	require.NoError(t, err)
+	require.True(t, status.Committed)
+	require.Equal(t, desc.Size, status.Offset)
+	heads := reg.heads
+	_, err = p.Push(ctx, desc)
+	require.ErrorIs(t, err, cerrdefs.ErrAlreadyExists)
+	require.Equal(t, heads, reg.heads)
+
+	// blob now exists in the repository
+	p.tracker = docker.NewInMemoryTracker()
+	_, err = p.Push(ctx, desc)
+	require.ErrorIs(t, err, cerrdefs.ErrAlreadyExists)
+	status, err = p.tracker.GetStatus(remotes.MakeRefKey(ctx, desc))
+	require.NoError(t, err)
+	require.True(t, status.Exists)
+}
+
+type testProgressWriter struct {
+	last progress.Status
+}
+
+func (w *testProgressWriter) Write(id string, v any) error {
+	w.last = v.(progress.Status)
+	return nil
+}
+
+func (w *testProgressWriter) Close() error {
+	return nil
+}
+
+func TestChunkedUploadMaxResumes(t *testing.T) {
+	data := make([]byte, 4096)
+	desc := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageLayerGzip,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+
+	reg := &testRegistry{blobs: map[digest.Digest][]byte{}, failPatch: 1}
+	p := newTestChunkedPusher(t, reg, 1024)
+	p.cfg.MaxResumes = 0
+
+	ctx := context.TODO()
+	cw, err := p.Push(ctx, desc)
+	require.NoError(t, err)
+	err = content.Copy(ctx, cw, io.NewSectionReader(bytes.NewReader(data), 0, desc.Size), desc.Size, desc.Digest)
+	require.Error(t, err)
+	require.Equal(t, 0, reg.statuses)
+}
+
+func TestParseUploadRange(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected int64
+		err      bool
+	}{
+		{in: "", expected: 0},
+		{in: "0-0", expected: 0},
+		{in: "0-1023", expected: 1024},
+		{in: "bytes=0-99", expected: 100},
+		{in: "100", err: true},
+		{in: "0-abc", err: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			n, err := parseUploadRange(tc.in)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, n)
+		})
+	}
+}
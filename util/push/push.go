@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/images"
@@ -13,6 +14,7 @@ import (
 	"github.com/containerd/containerd/v2/core/remotes/docker"
 	cerrdefs "github.com/containerd/errdefs"
 	"github.com/distribution/reference"
+	"github.com/docker/go-units"
 	intoto "github.com/in-toto/in-toto-golang/in_toto"
 	"github.com/moby/buildkit/session"
 	"github.com/moby/buildkit/util/bklog"
@@ -28,10 +30,13 @@ import (
 	digest "github.com/opencontainers/go-digest"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
 )
 
 type pusher struct {
 	remotes.Pusher
+	name string
 }
 
 // Pusher creates and new pusher instance for resolver
@@ -42,7 +47,39 @@ func Pusher(ctx context.Context, resolver remotes.Resolver, ref string) (remotes
 	if err != nil {
 		return nil, err
 	}
-	return &pusher{Pusher: p}, nil
+	if hr, ok := resolver.(hostsResolver); ok {
+		p, err = newChunkedPusher(p, hr, ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+	name := ref
+	if named, err := reference.ParseNormalizedNamed(ref); err == nil {
+		name = named.Name()
+	}
+	return &pusher{Pusher: p, name: name}, nil
+}
+
+// Push reports a progress status for desc if its content is uploaded. Content
+// that already exists in the registry or is mounted from another repository
+// is not reported.
+func (p *pusher) Push(ctx context.Context, desc ocispecs.Descriptor) (content.Writer, error) {
+	pw, _, _ := progress.NewFromContext(ctx)
+	pp := &pushProgress{
+		pw: pw,
+		id: "pushing " + p.name + "@" + desc.Digest.String(),
+		st: progress.Status{
+			Action: "pushing",
+			Total:  int(desc.Size),
+		},
+	}
+	w, err := p.Pusher.Push(context.WithValue(ctx, pushProgressKey{}, pp), desc)
+	if err != nil {
+		pw.Close()
+		return nil, err
+	}
+	pp.start()
+	return &progressWriter{Writer: w, pp: pp, size: desc.Size}, nil
 }
 
 func Push(ctx context.Context, sm *session.Manager, sid string, provider content.Provider, manager content.Manager, dgst digest.Digest, ref string, insecure bool, hosts docker.RegistryHosts, byDigest bool, annotations map[digest.Digest]map[string]string) error {
@@ -323,3 +360,99 @@ func dedupeHandler(h images.HandlerFunc) images.HandlerFunc {
 		})
 	})
 }
+
+var pushedBytes, _ = otel.Meter("github.com/moby/buildkit/util/push").Int64Counter("buildkit.push.bytes",
+	metric.WithDescription("Size of the blobs and manifests uploaded to registries."),
+	metric.WithUnit("By"),
+)
+
+type pushProgressKey struct{}
+
+// pushProgress is the progress status of a pushed descriptor. Content writers
+// that know how much data was uploaded can update it from the context.
+type pushProgress struct {
+	mu sync.Mutex
+	pw progress.Writer
+	id string
+	st progress.Status
+}
+
+func pushProgressFromContext(ctx context.Context) *pushProgress {
+	pp, _ := ctx.Value(pushProgressKey{}).(*pushProgress)
+	return pp
+}
+
+func (pp *pushProgress) start() {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	started := time.Now()
+	pp.st.Started = &started
+	pp.pw.Write(pp.id, pp.st)
+}
+
+func (pp *pushProgress) complete() {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	completed := time.Now()
+	pp.st.Current = pp.st.Total
+	pp.st.Completed = &completed
+	pp.pw.Write(pp.id, pp.st)
+}
+
+// close marks an upload that was not committed as completed with the offset
+// that was reached
+func (pp *pushProgress) close() {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	if pp.st.Completed == nil {
+		completed := time.Now()
+		pp.st.Completed = &completed
+		pp.pw.Write(pp.id, pp.st)
+	}
+	pp.pw.Close()
+}
+
+// update sets the uploaded offset. Bytes that were kept by the registry when
+// an upload was resumed are shown in the action.
+func (pp *pushProgress) update(offset, resumed int64) {
+	if pp == nil {
+		return
+	}
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	pp.st.Current = int(offset)
+	if resumed > 0 {
+		pp.st.Action = "pushing, resumed " + units.HumanSize(float64(resumed))
+	}
+	pp.pw.Write(pp.id, pp.st)
+}
+
+// progressWriter updates the progress status of an upload. Only committed
+// uploads are counted as pushed bytes.
+type progressWriter struct {
+	content.Writer
+	pp   *pushProgress
+	size int64
+}
+
+func (w *progressWriter) Write(dt []byte) (int, error) {
+	n, err := w.Writer.Write(dt)
+	if st, serr := w.Writer.Status(); serr == nil {
+		w.pp.update(st.Offset, 0)
+	}
+	return n, err
+}
+
+func (w *progressWriter) Commit(ctx context.Context, size int64, expected digest.Digest, opts ...content.Opt) error {
+	if err := w.Writer.Commit(ctx, size, expected, opts...); err != nil {
+		return err
+	}
+	w.pp.complete()
+	pushedBytes.Add(context.WithoutCancel(ctx), w.size)
+	return nil
+}
+
+func (w *progressWriter) Close() error {
+	w.pp.close()
+	return w.Writer.Close()
+}
@@ -0,0 +1,85 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/buildkit/util/contentutil"
+	"github.com/moby/buildkit/util/progress"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// testPusher writes pushed content to a buffer. Content in the buffer is
+// reported to exist.
+type testPusher struct {
+	buf contentutil.Buffer
+}
+
+func (p *testPusher) Push(ctx context.Context, desc ocispecs.Descriptor) (content.Writer, error) {
+	if _, err := p.buf.Info(ctx, desc.Digest); err == nil {
+		return nil, errors.Wrapf(cerrdefs.ErrAlreadyExists, "blob %s", desc.Digest)
+	}
+	return p.buf.Writer(ctx, content.WithRef(desc.Digest.String()), content.WithDescriptor(desc))
+}
+
+func TestPusherProgress(t *testing.T) {
+	pr, ctx, done := progress.NewContext(context.TODO())
+
+	buf := contentutil.NewBuffer()
+	push := func(ref string, dt []byte) {
+		desc := ocispecs.Descriptor{
+			MediaType: ocispecs.MediaTypeImageLayer,
+			Digest:    digest.FromBytes(dt),
+			Size:      int64(len(dt)),
+		}
+		p := &pusher{Pusher: &testPusher{buf: buf}, name: ref}
+		cw, err := p.Push(ctx, desc)
+		if errors.Is(err, cerrdefs.ErrAlreadyExists) {
+			return
+		}
+		require.NoError(t, err)
+		defer cw.Close()
+		err = content.Copy(ctx, cw, bytes.NewReader(dt), desc.Size, desc.Digest)
+		require.NoError(t, err)
+	}
+
+	existing := []byte("existing")
+	content.WriteBlob(ctx, buf, "existing", bytes.NewReader(existing), ocispecs.Descriptor{Digest: digest.FromBytes(existing), Size: int64(len(existing))})
+
+	push("docker.io/library/a", []byte("layer"))
+	push("docker.io/library/a", existing)
+	push("docker.io/library/b", []byte("other"))
+	done(nil)
+
+	statuses := map[string]progress.Status{}
+	for {
+		p, err := pr.Read(context.TODO())
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		for _, p := range p {
+			statuses[p.ID] = p.Sys.(progress.Status)
+		}
+	}
+
+	// existing content is not reported, statuses are keyed by the push
+	// target
+	require.Len(t, statuses, 2)
+	st, ok := statuses["pushing docker.io/library/a@"+digest.FromBytes([]byte("layer")).String()]
+	require.True(t, ok)
+	require.Equal(t, "pushing", st.Action)
+	require.Equal(t, 5, st.Total)
+	require.Equal(t, 5, st.Current)
+	require.NotNil(t, st.Started)
+	require.NotNil(t, st.Completed)
+	_, ok = statuses["pushing docker.io/library/b@"+digest.FromBytes([]byte("other")).String()]
+	require.True(t, ok)
+}
@@ -109,24 +109,41 @@ type dockerAuthorizer struct {
 	sm       *session.Manager
 	session  session.Group
 	handlers *authHandlerNS
+	// helpers are credential helpers run by the daemon, indexed by host
+	helpers map[string]*credentialHelper
 }
 
-func newDockerAuthorizer(client *http.Client, handlers *authHandlerNS, sm *session.Manager, group session.Group) *dockerAuthorizer {
+func newDockerAuthorizer(client *http.Client, handlers *authHandlerNS, sm *session.Manager, group session.Group, helpers map[string]*credentialHelper) *dockerAuthorizer {
 	return &dockerAuthorizer{
 		client:   client,
 		handlers: handlers,
 		sm:       sm,
 		session:  group,
+		helpers:  helpers,
 	}
 }
 
+// getHandler returns the auth handler for host. Handlers using credentials
+// from a daemon side credential helper don't depend on the client session.
+func (a *dockerAuthorizer) getHandler(ctx context.Context, host string) *authHandler {
+	if ch, ok := a.helpers[host]; ok {
+		h, ok := a.handlers.handlers[host+"/"+ch.sessionKey()]
+		if !ok {
+			return nil
+		}
+		h.lastUsed = time.Now()
+		return h
+	}
+	return a.handlers.get(ctx, host, a.sm, a.session)
+}
+
 // Authorize handles auth request.
 func (a *dockerAuthorizer) Authorize(ctx context.Context, req *http.Request) error {
 	a.handlers.muHandlers.Lock()
 	defer a.handlers.muHandlers.Unlock()
 
 	// skip if there is no auth handler
-	ah := a.handlers.get(ctx, req.URL.Host, a.sm, a.session)
+	ah := a.getHandler(ctx, req.URL.Host)
 	if ah == nil {
 		return nil
 	}
@@ -144,14 +161,38 @@ func (a *dockerAuthorizer) getCredentials(host string) (sessionID, username, sec
 	return sessionauth.CredentialsFunc(a.sm, a.session)(host)
 }
 
-func (a *dockerAuthorizer) AddResponses(ctx context.Context, responses []*http.Response) error {
-	a.handlers.muHandlers.Lock()
-	defer a.handlers.muHandlers.Unlock()
+// helperCredentials runs the credential helper for host, if any. Helpers are
+// external programs, so they are run before the handlers lock is taken.
+func (a *dockerAuthorizer) helperCredentials(ctx context.Context, host string, responses []*http.Response) (func(string) (string, string, string, error), bool) {
+	ch, ok := a.helpers[host]
+	if !ok {
+		return nil, false
+	}
+	for _, c := range auth.ParseAuthHeader(responses[len(responses)-1].Header) {
+		if c.Scheme == auth.BearerAuth && invalidAuthorization(c, responses) != nil {
+			ch.invalidate()
+			break
+		}
+	}
+	username, secret, err := ch.credentials(ctx)
+	return func(string) (string, string, string, error) {
+		return ch.sessionKey(), username, secret, err
+	}, true
+}
 
+func (a *dockerAuthorizer) AddResponses(ctx context.Context, responses []*http.Response) error {
 	last := responses[len(responses)-1]
 	host := last.Request.URL.Host
 
-	handler := a.handlers.get(ctx, host, a.sm, a.session)
+	getCredentials, useHelper := a.helperCredentials(ctx, host, responses)
+	if !useHelper {
+		getCredentials = a.getCredentials
+	}
+
+	a.handlers.muHandlers.Lock()
+	defer a.handlers.muHandlers.Unlock()
+
+	handler := a.getHandler(ctx, host)
 
 	for _, c := range auth.ParseAuthHeader(last.Header) {
 		switch c.Scheme {
@@ -181,13 +222,17 @@ func (a *dockerAuthorizer) AddResponses(ctx context.Context, responses []*http.R
 				return nil
 			}
 
-			var username, secret string
-			sessionID, pubKey, err := sessionauth.GetTokenAuthority(ctx, host, a.sm, a.session)
-			if err != nil {
-				return err
+			var username, secret, sessionID string
+			var pubKey *[32]byte
+			var err error
+			if !useHelper {
+				sessionID, pubKey, err = sessionauth.GetTokenAuthority(ctx, host, a.sm, a.session)
+				if err != nil {
+					return err
+				}
 			}
 			if pubKey == nil {
-				sessionID, username, secret, err = a.getCredentials(host)
+				sessionID, username, secret, err = getCredentials(host)
 				if err != nil {
 					return err
 				}
@@ -203,7 +248,7 @@ func (a *dockerAuthorizer) AddResponses(ctx context.Context, responses []*http.R
 
 			return nil
 		case auth.BasicAuth:
-			sessionID, username, secret, err := a.getCredentials(host)
+			sessionID, username, secret, err := getCredentials(host)
 			if err != nil {
 				return err
 			}
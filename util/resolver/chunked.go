@@ -0,0 +1,81 @@
+package resolver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/moby/buildkit/util/resolver/config"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultMaxResumes    = 5
+	defaultResumeBackoff = time.Second
+)
+
+// ChunkedUploadConfig configures chunked blob uploads to a registry host
+// that are resumed after a failure
+type ChunkedUploadConfig struct {
+	// ChunkSize is the size of a single upload request
+	ChunkSize int64
+	// MaxResumes is the maximum number of times a single upload is resumed
+	MaxResumes int
+	// Backoff is the delay before the first resume
+	Backoff time.Duration
+}
+
+// chunkedUploadTransport is set on the client of registry hosts that are
+// configured for chunked uploads so that pushers can find the configuration
+type chunkedUploadTransport struct {
+	http.RoundTripper
+	cfg ChunkedUploadConfig
+}
+
+func withChunkedUploads(h *docker.RegistryHost, c *config.ChunkedUploadConfig) error {
+	if c == nil || c.ChunkSize <= 0 {
+		return nil
+	}
+	cfg := ChunkedUploadConfig{
+		ChunkSize:  c.ChunkSize,
+		MaxResumes: defaultMaxResumes,
+		Backoff:    defaultResumeBackoff,
+	}
+	if c.MaxResumes != nil {
+		cfg.MaxResumes = *c.MaxResumes
+	}
+	if c.ResumeBackoff != "" {
+		d, err := time.ParseDuration(c.ResumeBackoff)
+		if err != nil {
+			return errors.Wrapf(err, "invalid resumeBackoff for %s", h.Host)
+		}
+		cfg.Backoff = d
+	}
+	client := http.Client{}
+	if h.Client != nil {
+		client = *h.Client
+	}
+	client.Transport = &chunkedUploadTransport{RoundTripper: client.Transport, cfg: cfg}
+	h.Client = &client
+	return nil
+}
+
+// ChunkedUploads returns the chunked upload configuration of a registry
+// host, or nil if chunked uploads are not enabled for it
+func ChunkedUploads(h docker.RegistryHost) *ChunkedUploadConfig {
+	if h.Client == nil {
+		return nil
+	}
+	if t, ok := h.Client.Transport.(*chunkedUploadTransport); ok {
+		cfg := t.cfg
+		return &cfg
+	}
+	return nil
+}
+
+func (t *chunkedUploadTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.RoundTripper == nil {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	return t.RoundTripper.RoundTrip(req)
+}
@@ -7,6 +7,28 @@ type RegistryConfig struct {
 	RootCAs      []string     `toml:"ca"`
 	KeyPairs     []TLSKeyPair `toml:"keypair"`
 	TLSConfigDir []string     `toml:"tlsconfigdir"`
+	// CredentialHelper is run by the daemon to get credentials for the
+	// registry instead of requesting them from the client session
+	CredentialHelper string `toml:"credentialHelper"`
+	// ChunkedUpload enables resumable chunked uploads of large blobs
+	ChunkedUpload *ChunkedUploadConfig `toml:"chunkedUpload"`
+	// Proxy is the socks5://, socks5h://, http:// or https:// proxy of the
+	// requests to the registry, instead of the proxy of the build or of the
+	// daemon
+	Proxy string `toml:"proxy"`
+}
+
+// ChunkedUploadConfig configures chunked blob uploads that are resumed from
+// the last offset acknowledged by the registry after a failure
+type ChunkedUploadConfig struct {
+	// ChunkSize is the size of a single upload request. Blobs smaller than
+	// ChunkSize are uploaded in one request.
+	ChunkSize int64 `toml:"chunkSize"`
+	// MaxResumes is the maximum number of times a single upload is resumed
+	MaxResumes *int `toml:"maxResumes"`
+	// ResumeBackoff is the delay before the first resume, doubled for every
+	// following attempt
+	ResumeBackoff string `toml:"resumeBackoff"`
 }
 
 type TLSKeyPair struct {
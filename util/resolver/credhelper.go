@@ -0,0 +1,154 @@
+package resolver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/v2/core/remotes/docker"
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/pkg/errors"
+)
+
+const (
+	credentialHelperPrefix = "docker-credential-"
+	// credentialHelperTTL is how long credentials returned by a helper are
+	// reused before the helper is run again
+	credentialHelperTTL = 10 * time.Minute
+	// credentialHelperTimeout limits how long a helper can run
+	credentialHelperTimeout = 30 * time.Second
+	dockerHubServerURL      = "https://index.docker.io/v1/"
+)
+
+var credentialHelperNameRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// ValidateCredentialHelper checks that name can be used as a credential
+// helper. Helpers are always looked up as docker-credential-<name> in PATH.
+func ValidateCredentialHelper(name string) error {
+	if !credentialHelperNameRe.MatchString(name) {
+		return errors.Errorf("invalid credential helper name %q", name)
+	}
+	return nil
+}
+
+type helperCredentials struct {
+	username string
+	secret   string
+	expires  time.Time
+}
+
+// credentialHelper runs a docker credential helper in the daemon so that
+// registry credentials don't need to be requested from the client session.
+// It is set as the Authorizer of the registry hosts it is configured for.
+type credentialHelper struct {
+	name      string
+	serverURL string
+	program   func(ctx context.Context, args ...string) client.Program
+
+	mu    sync.Mutex
+	creds *helperCredentials
+
+	authorizerOnce sync.Once
+	authorizer     docker.Authorizer
+}
+
+func newCredentialHelper(name, registry string) *credentialHelper {
+	serverURL := registry
+	if registry == "docker.io" {
+		serverURL = dockerHubServerURL
+	}
+	return &credentialHelper{
+		name:      name,
+		serverURL: serverURL,
+		program: func(ctx context.Context, args ...string) client.Program {
+			cmd := exec.CommandContext(ctx, credentialHelperPrefix+name, args...)
+			cmd.Stderr = os.Stderr
+			return &helperProgram{cmd: cmd}
+		},
+	}
+}
+
+// helperProgram is a client.Program that is killed when its context is done
+type helperProgram struct {
+	cmd *exec.Cmd
+}
+
+func (p *helperProgram) Output() ([]byte, error) {
+	return p.cmd.Output()
+}
+
+func (p *helperProgram) Input(in io.Reader) {
+	p.cmd.Stdin = in
+}
+
+// sessionKey identifies auth handlers created from the helper credentials
+func (h *credentialHelper) sessionKey() string {
+	return "credential-helper:" + h.name
+}
+
+// credentials returns the cached credentials or runs the helper to get new
+// ones. An empty username and secret are returned if the helper has no
+// credentials for the registry.
+func (h *credentialHelper) credentials(ctx context.Context) (string, string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.creds != nil && time.Now().Before(h.creds.expires) {
+		return h.creds.username, h.creds.secret, nil
+	}
+	ctx, cancel := context.WithTimeoutCause(ctx, credentialHelperTimeout, errors.Errorf("credential helper %s timed out", h.name))
+	defer cancel()
+	c := &helperCredentials{expires: time.Now().Add(credentialHelperTTL)}
+	res, err := client.Get(func(args ...string) client.Program {
+		return h.program(ctx, args...)
+	}, h.serverURL)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", "", errors.Wrapf(context.Cause(ctx), "credential helper %s failed", h.name)
+		}
+		if !credentials.IsErrCredentialsNotFound(err) {
+			return "", "", errors.Wrapf(err, "credential helper %s failed", h.name)
+		}
+	} else {
+		c.username, c.secret = res.Username, res.Secret
+		// identity tokens are used as refresh tokens
+		if c.username == "<token>" {
+			c.username = ""
+		}
+	}
+	h.creds = c
+	return c.username, c.secret, nil
+}
+
+// invalidate drops cached credentials after they were rejected by the registry
+func (h *credentialHelper) invalidate() {
+	h.mu.Lock()
+	h.creds = nil
+	h.mu.Unlock()
+}
+
+func (h *credentialHelper) getAuthorizer() docker.Authorizer {
+	h.authorizerOnce.Do(func() {
+		h.authorizer = docker.NewDockerAuthorizer(docker.WithAuthCreds(func(string) (string, string, error) {
+			return h.credentials(context.TODO())
+		}))
+	})
+	return h.authorizer
+}
+
+// Authorize implements docker.Authorizer for hosts used without a Resolver
+// from the pool
+func (h *credentialHelper) Authorize(ctx context.Context, req *http.Request) error {
+	return h.getAuthorizer().Authorize(ctx, req)
+}
+
+// AddResponses implements docker.Authorizer
+func (h *credentialHelper) AddResponses(ctx context.Context, responses []*http.Response) error {
+	return h.getAuthorizer().AddResponses(ctx, responses)
+}
@@ -0,0 +1,164 @@
+package resolver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker-credential-helpers/client"
+	"github.com/docker/docker-credential-helpers/credentials"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeHelperProgram struct {
+	ctx   context.Context
+	creds map[string]*credentials.Credentials
+	calls *int
+	input string
+	// hang makes the helper run until it is killed
+	hang bool
+}
+
+func (p *fakeHelperProgram) Input(in io.Reader) {
+	dt, _ := io.ReadAll(in)
+	p.input = string(dt)
+}
+
+func (p *fakeHelperProgram) Output() ([]byte, error) {
+	*p.calls++
+	if p.hang {
+		<-p.ctx.Done()
+		return nil, errors.New("signal: killed")
+	}
+	c, ok := p.creds[p.input]
+	if !ok {
+		return []byte(credentials.NewErrCredentialsNotFound().Error()), errors.New("exit status 1")
+	}
+	return json.Marshal(c)
+}
+
+func newFakeHelper(t *testing.T, registry string, creds map[string]*credentials.Credentials) (*credentialHelper, *int) {
+	t.Helper()
+	var calls int
+	h := newCredentialHelper("fake", registry)
+	h.program = func(ctx context.Context, args ...string) client.Program {
+		require.Equal(t, []string{credentials.ActionGet}, args)
+		return &fakeHelperProgram{ctx: ctx, creds: creds, calls: &calls}
+	}
+	return h, &calls
+}
+
+func TestCredentialHelper(t *testing.T) {
+	h, calls := newFakeHelper(t, "docker.io", map[string]*credentials.Credentials{
+		dockerHubServerURL: {Username: "user", Secret: "pass"},
+	})
+
+	username, secret, err := h.credentials(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, "user", username)
+	require.Equal(t, "pass", secret)
+
+	_, _, err = h.credentials(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, 1, *calls)
+
+	h.invalidate()
+	_, _, err = h.credentials(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, 2, *calls)
+}
+
+func TestCredentialHelperIdentityToken(t *testing.T) {
+	h, _ := newFakeHelper(t, "example.com", map[string]*credentials.Credentials{
+		"example.com": {Username: "<token>", Secret: "refresh"},
+	})
+	username, secret, err := h.credentials(context.TODO())
+	require.NoError(t, err)
+	require.Empty(t, username)
+	require.Equal(t, "refresh", secret)
+}
+
+func TestCredentialHelperNotFound(t *testing.T) {
+	h, calls := newFakeHelper(t, "example.com", nil)
+	username, secret, err := h.credentials(context.TODO())
+	require.NoError(t, err)
+	require.Empty(t, username)
+	require.Empty(t, secret)
+
+	// missing credentials are cached as well
+	_, _, err = h.credentials(context.TODO())
+	require.NoError(t, err)
+	require.Equal(t, 1, *calls)
+}
+
+func TestCredentialHelperCanceled(t *testing.T) {
+	var calls int
+	h := newCredentialHelper("fake", "example.com")
+	h.program = func(ctx context.Context, args ...string) client.Program {
+		return &fakeHelperProgram{ctx: ctx, calls: &calls, hang: true}
+	}
+
+	ctx, cancel := context.WithCancelCause(context.TODO())
+	cancel(errors.New("build canceled"))
+	_, _, err := h.credentials(ctx)
+	require.ErrorContains(t, err, "build canceled")
+
+	// failed runs are not cached
+	_, _, err = h.credentials(ctx)
+	require.Error(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestValidateCredentialHelper(t *testing.T) {
+	require.NoError(t, ValidateCredentialHelper("ecr-login"))
+	require.NoError(t, ValidateCredentialHelper("gcr"))
+	require.Error(t, ValidateCredentialHelper(""))
+	require.Error(t, ValidateCredentialHelper("../bin/sh"))
+	require.Error(t, ValidateCredentialHelper("/usr/bin/helper"))
+}
+
+func TestDockerAuthorizerCredentialHelper(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+
+	h, _ := newFakeHelper(t, u.Host, map[string]*credentials.Credentials{
+		u.Host: {Username: "user", Secret: "pass"},
+	})
+	a := newDockerAuthorizer(srv.Client(), newAuthHandlerNS(nil), nil, nil, map[string]*credentialHelper{u.Host: h})
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/v2/", nil)
+	require.NoError(t, err)
+	resp, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	require.NoError(t, a.AddResponses(context.TODO(), []*http.Response{resp}))
+
+	req, err = http.NewRequest(http.MethodGet, srv.URL+"/v2/", nil)
+	require.NoError(t, err)
+	require.NoError(t, a.Authorize(context.TODO(), req))
+	require.True(t, strings.HasPrefix(req.Header.Get("Authorization"), "Basic "))
+
+	resp, err = srv.Client().Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
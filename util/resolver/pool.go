@@ -134,11 +134,13 @@ func newResolver(hosts docker.RegistryHosts, handler *authHandlerNS, sm *session
 		g:       g,
 		handler: handler,
 		headers: headers,
+		tracker: docker.NewInMemoryTracker(),
 	}
 
 	r.Resolver = docker.NewResolver(docker.ResolverOptions{
 		Hosts:   r.HostsFunc,
 		Headers: headers,
+		Tracker: r.tracker,
 	})
 	return r
 }
@@ -152,6 +154,7 @@ type Resolver struct {
 	g       session.Group
 	handler *authHandlerNS
 	auth    *dockerAuthorizer
+	tracker docker.StatusTrackLocker
 
 	is   images.Store
 	mode ResolveMode
@@ -186,7 +189,16 @@ func (r *Resolver) HostsFunc(host string) ([]docker.RegistryHost, error) {
 		// make a copy so authorizer is set on unique instance
 		res := make([]docker.RegistryHost, len(v))
 		copy(res, v)
-		auth := newDockerAuthorizer(res[0].Client, r.handler, r.sm, r.g)
+		var helpers map[string]*credentialHelper
+		for _, h := range res {
+			if ch, ok := h.Authorizer.(*credentialHelper); ok {
+				if helpers == nil {
+					helpers = map[string]*credentialHelper{}
+				}
+				helpers[h.Host] = ch
+			}
+		}
+		auth := newDockerAuthorizer(res[0].Client, r.handler, r.sm, r.g, helpers)
 		for i := range res {
 			res[i].Authorizer = auth
 		}
@@ -202,10 +214,16 @@ func (r *Resolver) WithSession(s session.Group) *Resolver {
 	r2.Resolver = docker.NewResolver(docker.ResolverOptions{
 		Hosts:   r2.HostsFunc, // this refers to the newly-configured session so we need to recreate the resolver.
 		Headers: r2.headers.Clone(),
+		Tracker: r2.tracker,
 	})
 	return &r2
 }
 
+// Tracker returns the tracker of the uploads done with the resolver
+func (r *Resolver) Tracker() docker.StatusTrackLocker {
+	return r.tracker
+}
+
 // WithImageStore returns new resolver that can also resolve from local images store
 func (r *Resolver) WithImageStore(is images.Store, mode ResolveMode) *Resolver {
 	r2 := *r
@@ -3,12 +3,15 @@ package resolver
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"maps"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
@@ -17,6 +20,7 @@ import (
 	"github.com/containerd/containerd/v2/core/remotes/docker"
 	"github.com/pkg/errors"
 
+	"github.com/moby/buildkit/util/proxy"
 	"github.com/moby/buildkit/util/resolver/config"
 	"github.com/moby/buildkit/util/tracing"
 )
@@ -25,11 +29,7 @@ const (
 	defaultPath = "/v2"
 )
 
-func fillInsecureOpts(host string, c config.RegistryConfig, h docker.RegistryHost) (*docker.RegistryHost, error) {
-	tc, err := loadTLSConfig(c)
-	if err != nil {
-		return nil, err
-	}
+func fillInsecureOpts(host string, c config.RegistryConfig, h docker.RegistryHost, tc *tls.Config) (*docker.RegistryHost, error) {
 	var isHTTP bool
 
 	if c.PlainHTTP != nil && *c.PlainHTTP {
@@ -43,6 +43,13 @@ func fillInsecureOpts(host string, c config.RegistryConfig, h docker.RegistryHos
 
 	httpsTransport := newDefaultTransport()
 	httpsTransport.TLSClientConfig = tc
+	if c.Proxy != "" {
+		u, err := parseRegistryProxy(c.Proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid proxy for registry %s", host)
+		}
+		httpsTransport.Proxy = http.ProxyURL(u)
+	}
 
 	if c.Insecure != nil && *c.Insecure {
 		h2 := h
@@ -61,6 +68,11 @@ func fillInsecureOpts(host string, c config.RegistryConfig, h docker.RegistryHos
 	} else if isHTTP {
 		h2 := h
 		h2.Scheme = "http"
+		if c.Proxy != "" {
+			h2.Client = &http.Client{
+				Transport: tracing.NewTransport(httpsTransport),
+			}
+		}
 		return &h2, nil
 	}
 
@@ -70,6 +82,23 @@ func fillInsecureOpts(host string, c config.RegistryConfig, h docker.RegistryHos
 	return &h, nil
 }
 
+// parseRegistryProxy parses the proxy of a registry
+func parseRegistryProxy(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h", "http", "https":
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.Errorf("no proxy host in %q", s)
+	}
+	return u, nil
+}
+
 func loadTLSConfig(c config.RegistryConfig) (*tls.Config, error) {
 	for _, d := range c.TLSConfigDir {
 		fs, err := os.ReadDir(d)
@@ -107,7 +136,9 @@ func loadTLSConfig(c config.RegistryConfig) (*tls.Config, error) {
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to read %s", p)
 		}
-		tc.RootCAs.AppendCertsFromPEM(dt)
+		if !tc.RootCAs.AppendCertsFromPEM(dt) {
+			return nil, errors.Errorf("no certificates found in %s", p)
+		}
 	}
 
 	for _, kp := range c.KeyPairs {
@@ -122,6 +153,68 @@ func loadTLSConfig(c config.RegistryConfig) (*tls.Config, error) {
 
 // NewRegistryConfig converts registry config to docker.RegistryHosts callback
 func NewRegistryConfig(m map[string]config.RegistryConfig) docker.RegistryHosts {
+	return newRegistryHosts(m, newTLSConfigCache(m))
+}
+
+// ValidateRegistryConfig checks the TLS files, proxies and chunked uploads of
+// the registries of a registry config
+func ValidateRegistryConfig(m map[string]config.RegistryConfig) error {
+	hosts := newRegistryHosts(m, newTLSConfigCache(nil))
+	for _, host := range slices.Sorted(maps.Keys(m)) {
+		if _, err := hosts(host); err != nil {
+			return errors.Wrapf(err, "invalid config for registry %s", host)
+		}
+	}
+	return nil
+}
+
+// ReloadableRegistryHosts are the registry hosts of a registry config that
+// can be replaced while they are used, e.g. when the config of the daemon is
+// reloaded
+type ReloadableRegistryHosts struct {
+	mu    sync.RWMutex
+	hosts docker.RegistryHosts
+	tcc   *tlsConfigCache
+}
+
+// NewReloadableRegistryConfig returns the reloadable registry hosts of a
+// registry config
+func NewReloadableRegistryConfig(m map[string]config.RegistryConfig) *ReloadableRegistryHosts {
+	r := &ReloadableRegistryHosts{}
+	r.Update(m)
+	return r
+}
+
+// RegistryHosts is the docker.RegistryHosts of the current registry config
+func (r *ReloadableRegistryHosts) RegistryHosts(host string) ([]docker.RegistryHost, error) {
+	r.mu.RLock()
+	hosts := r.hosts
+	r.mu.RUnlock()
+	return hosts(host)
+}
+
+// Update replaces the registry config. The resolvers created before keep
+// using the previous one.
+func (r *ReloadableRegistryHosts) Update(m map[string]config.RegistryConfig) {
+	tcc := newTLSConfigCache(m)
+	r.mu.Lock()
+	prev := r.tcc
+	r.hosts, r.tcc = newRegistryHosts(m, tcc), tcc
+	r.mu.Unlock()
+	if prev != nil {
+		prev.close()
+	}
+}
+
+func newRegistryHosts(m map[string]config.RegistryConfig, tcc *tlsConfigCache) docker.RegistryHosts {
+	// helpers are created upfront so that cached credentials are shared
+	// between lookups
+	helpers := map[string]*credentialHelper{}
+	for host, c := range m {
+		if c.CredentialHelper != "" {
+			helpers[host] = newCredentialHelper(c.CredentialHelper, host)
+		}
+	}
 	return docker.Registries(
 		func(host string) ([]docker.RegistryHost, error) {
 			c, ok := m[host]
@@ -134,14 +227,22 @@ func NewRegistryConfig(m map[string]config.RegistryConfig) docker.RegistryHosts
 			for _, rawMirror := range c.Mirrors {
 				h := newMirrorRegistryHost(rawMirror)
 				mirrorHost := h.Host
-				host, err := fillInsecureOpts(mirrorHost, m[mirrorHost], h)
+				tc, err := tcc.get(mirrorHost, m[mirrorHost])
 				if err != nil {
 					return nil, err
 				}
+				host, err := fillInsecureOpts(mirrorHost, m[mirrorHost], h, tc)
+				if err != nil {
+					return nil, err
+				}
+				if ch, ok := helpers[mirrorHost]; ok {
+					host.Authorizer = ch
+				}
 
 				out = append(out, *host)
 			}
 
+			registry := host
 			if host == "docker.io" {
 				host = "registry-1.docker.io"
 			}
@@ -154,10 +255,20 @@ func NewRegistryConfig(m map[string]config.RegistryConfig) docker.RegistryHosts
 				Capabilities: docker.HostCapabilityPush | docker.HostCapabilityPull | docker.HostCapabilityResolve,
 			}
 
-			hosts, err := fillInsecureOpts(host, c, h)
+			tc, err := tcc.get(registry, c)
+			if err != nil {
+				return nil, err
+			}
+			hosts, err := fillInsecureOpts(host, c, h, tc)
 			if err != nil {
 				return nil, err
 			}
+			if ch, ok := helpers[registry]; ok {
+				hosts.Authorizer = ch
+			}
+			if err := withChunkedUploads(hosts, c.ChunkedUpload); err != nil {
+				return nil, err
+			}
 
 			out = append(out, *hosts)
 
@@ -198,7 +309,7 @@ func newDefaultClient() *http.Client {
 // REF: https://github.com/golang/go/issues/14077
 func newDefaultTransport() *http.Transport {
 	return &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+		Proxy: proxy.FromRequest,
 		DialContext: (&net.Dialer{
 			Timeout:   30 * time.Second,
 			KeepAlive: 60 * time.Second,
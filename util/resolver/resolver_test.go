@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"path"
 	"testing"
+	"time"
 
 	"github.com/moby/buildkit/cmd/buildkitd/config"
+	resolverconfig "github.com/moby/buildkit/util/resolver/config"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,3 +68,55 @@ mirrors = ["https://url/", "https://url/path/"]
 		}
 	}
 }
+
+func TestChunkedUploads(t *testing.T) {
+	maxResumes := 2
+	hosts := NewRegistryConfig(map[string]resolverconfig.RegistryConfig{
+		"example.com": {
+			ChunkedUpload: &resolverconfig.ChunkedUploadConfig{ChunkSize: 1024, MaxResumes: &maxResumes, ResumeBackoff: "5s"},
+		},
+		"other.example.com": {},
+		"invalid.example.com": {
+			ChunkedUpload: &resolverconfig.ChunkedUploadConfig{ChunkSize: 1024, ResumeBackoff: "foo"},
+		},
+	})
+
+	h, err := hosts("example.com")
+	require.NoError(t, err)
+	require.Len(t, h, 1)
+	require.Equal(t, &ChunkedUploadConfig{ChunkSize: 1024, MaxResumes: 2, Backoff: 5 * time.Second}, ChunkedUploads(h[0]))
+
+	h, err = hosts("other.example.com")
+	require.NoError(t, err)
+	require.Len(t, h, 1)
+	require.Nil(t, ChunkedUploads(h[0]))
+
+	_, err = hosts("invalid.example.com")
+	require.ErrorContains(t, err, "invalid resumeBackoff")
+}
+
+func TestReloadableRegistryConfig(t *testing.T) {
+	r := NewReloadableRegistryConfig(map[string]resolverconfig.RegistryConfig{
+		"docker.io": {Mirrors: []string{"mirror.example.com"}},
+	})
+	h, err := r.RegistryHosts("docker.io")
+	require.NoError(t, err)
+	require.Len(t, h, 2)
+	require.Equal(t, "mirror.example.com", h[0].Host)
+
+	invalid := map[string]resolverconfig.RegistryConfig{
+		"docker.io": {Mirrors: []string{"mirror2.example.com"}},
+		"example.com": {
+			ChunkedUpload: &resolverconfig.ChunkedUploadConfig{ChunkSize: 1024, ResumeBackoff: "foo"},
+		},
+	}
+	require.ErrorContains(t, ValidateRegistryConfig(invalid), "invalid config for registry example.com")
+
+	delete(invalid, "example.com")
+	require.NoError(t, ValidateRegistryConfig(invalid))
+	r.Update(invalid)
+	h, err = r.RegistryHosts("docker.io")
+	require.NoError(t, err)
+	require.Len(t, h, 2)
+	require.Equal(t, "mirror2.example.com", h[0].Host)
+}
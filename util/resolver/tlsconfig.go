@@ -0,0 +1,136 @@
+package resolver
+
+import (
+	"crypto/tls"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/resolver/config"
+)
+
+// tlsConfigCache caches the TLS configs of the registry hosts until the files
+// in their directories change, so that the CA bundles and key pairs can be
+// rotated without restarting the daemon. The configs of the hosts with a
+// directory that can't be watched are loaded again for every lookup.
+type tlsConfigCache struct {
+	mu      sync.Mutex
+	watcher *fsnotify.Watcher
+	configs map[string]*tls.Config
+	// cached are the hosts with all their directories watched
+	cached map[string]bool
+}
+
+func newTLSConfigCache(m map[string]config.RegistryConfig) *tlsConfigCache {
+	tcc := &tlsConfigCache{
+		configs: map[string]*tls.Config{},
+		cached:  map[string]bool{},
+	}
+	var w *fsnotify.Watcher
+	watched := map[string]bool{}
+	for host, c := range m {
+		dirs := tlsConfigDirs(c)
+		if len(dirs) == 0 {
+			tcc.cached[host] = true
+			continue
+		}
+		if w == nil {
+			var err error
+			if w, err = fsnotify.NewWatcher(); err != nil {
+				bklog.L.Warnf("failed to watch the TLS configs of the registries, they are reloaded for every lookup: %v", err)
+				return tcc
+			}
+		}
+		ok := true
+		for _, d := range dirs {
+			added, known := watched[d]
+			if !known {
+				err := w.Add(d)
+				if err != nil {
+					bklog.L.Debugf("failed to watch %s, the TLS config of %s is reloaded for every lookup: %v", d, host, err)
+				}
+				added = err == nil
+				watched[d] = added
+			}
+			ok = ok && added
+		}
+		tcc.cached[host] = ok
+	}
+	if w != nil {
+		tcc.watcher = w
+		go tcc.watch(w)
+	}
+	return tcc
+}
+
+// close stops watching the TLS files
+func (tcc *tlsConfigCache) close() {
+	if tcc.watcher != nil {
+		tcc.watcher.Close()
+	}
+}
+
+// tlsConfigDirs returns the directories of the TLS files of a registry
+func tlsConfigDirs(c config.RegistryConfig) []string {
+	var dirs []string
+	add := func(d string) {
+		if !slices.Contains(dirs, d) {
+			dirs = append(dirs, d)
+		}
+	}
+	for _, d := range c.TLSConfigDir {
+		add(filepath.Clean(d))
+	}
+	for _, p := range c.RootCAs {
+		add(filepath.Dir(p))
+	}
+	for _, kp := range c.KeyPairs {
+		add(filepath.Dir(kp.Certificate))
+		add(filepath.Dir(kp.Key))
+	}
+	return dirs
+}
+
+// watch drops the cached configs when the watched files change, until the
+// cache is closed
+func (tcc *tlsConfigCache) watch(w *fsnotify.Watcher) {
+	for {
+		select {
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) {
+				continue
+			}
+			bklog.L.Debugf("reloading the TLS configs of the registries after %s", ev)
+			tcc.mu.Lock()
+			clear(tcc.configs)
+			tcc.mu.Unlock()
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			bklog.L.Warnf("failed to watch the TLS configs of the registries: %v", err)
+		}
+	}
+}
+
+// get returns a copy of the TLS config of a registry host
+func (tcc *tlsConfigCache) get(host string, c config.RegistryConfig) (*tls.Config, error) {
+	tcc.mu.Lock()
+	defer tcc.mu.Unlock()
+	if tc, ok := tcc.configs[host]; ok {
+		return tc.Clone(), nil
+	}
+	tc, err := loadTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	if tcc.cached[host] {
+		tcc.configs[host] = tc
+	}
+	return tc.Clone(), nil
+}
@@ -0,0 +1,337 @@
+// Package sharedcontent implements a content store that is shared by the
+// workers on the same host so that a blob pulled by one of them is not stored
+// or fetched again by the others.
+package sharedcontent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/plugins/content/local"
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/gofrs/flock"
+	digest "github.com/opencontainers/go-digest"
+	"github.com/pkg/errors"
+)
+
+// Store is a content store shared by several workers. The workers access it
+// through views that keep a reference on each blob the worker uses. A blob is
+// only deleted from the store once no worker references it anymore. The
+// references are kept on disk and guarded by a file lock so that the workers
+// of different daemons can share the same store.
+type Store struct {
+	root  string
+	store content.Store
+
+	mu   sync.Mutex
+	lock *flock.Flock
+}
+
+// Status is the sharing status of a store
+type Status struct {
+	// Blobs is the number of blobs in the store
+	Blobs int `json:"blobs"`
+	// Size is the total size of the blobs in the store
+	Size int64 `json:"size"`
+	// SharedBlobs is the number of blobs referenced by more than one worker
+	SharedBlobs int `json:"sharedBlobs"`
+	// SavedSize is the size that the workers would store in addition if they
+	// did not share the blobs
+	SavedSize int64 `json:"savedSize"`
+	// Workers maps the IDs of the workers to the number of blobs they
+	// reference
+	Workers map[string]int `json:"workers"`
+}
+
+// NewStore opens the shared content store at root
+func NewStore(root string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(root, "refs"), 0700); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	cs, err := local.NewStore(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		root:  root,
+		store: cs,
+		lock:  flock.New(filepath.Join(root, "refs.lock")),
+	}, nil
+}
+
+// Root returns the directory of the store
+func (s *Store) Root() string {
+	return s.root
+}
+
+// View returns the content store of the worker id. The backend of the
+// containerd metadata store of the worker should be the view so that the
+// garbage collection of the worker only releases its own references.
+func (s *Store) View(id string) content.Store {
+	return &view{
+		Store: s.store,
+		s:     s,
+		id:    id,
+	}
+}
+
+// Status returns the sharing status of the store
+func (s *Store) Status(ctx context.Context) (Status, error) {
+	st := Status{
+		Workers: map[string]int{},
+	}
+	unlock, err := s.acquire()
+	if err != nil {
+		return st, err
+	}
+	defer unlock()
+	err = s.store.Walk(ctx, func(info content.Info) error {
+		ids, err := s.refs(info.Digest)
+		if err != nil {
+			return err
+		}
+		st.Blobs++
+		st.Size += info.Size
+		if len(ids) > 1 {
+			st.SharedBlobs++
+			st.SavedSize += int64(len(ids)-1) * info.Size
+		}
+		for _, id := range ids {
+			st.Workers[id]++
+		}
+		return nil
+	})
+	return st, err
+}
+
+// acquire locks the references of the store
+func (s *Store) acquire() (func(), error) {
+	s.mu.Lock()
+	if err := s.lock.Lock(); err != nil {
+		s.mu.Unlock()
+		return nil, errors.Wrapf(err, "failed to lock %s", s.lock.Path())
+	}
+	return func() {
+		s.lock.Unlock()
+		s.mu.Unlock()
+	}, nil
+}
+
+func (s *Store) refDir(dgst digest.Digest) string {
+	return filepath.Join(s.root, "refs", dgst.Algorithm().String(), dgst.Encoded())
+}
+
+// refs returns the IDs of the workers that reference dgst. The caller needs to
+// hold the lock.
+func (s *Store) refs(dgst digest.Digest) ([]string, error) {
+	ents, err := os.ReadDir(s.refDir(dgst))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	ids := make([]string, 0, len(ents))
+	for _, ent := range ents {
+		ids = append(ids, ent.Name())
+	}
+	return ids, nil
+}
+
+// addRef adds the reference of the worker id on dgst
+func (s *Store) addRef(ctx context.Context, id string, dgst digest.Digest) (content.Info, error) {
+	unlock, err := s.acquire()
+	if err != nil {
+		return content.Info{}, err
+	}
+	defer unlock()
+	info, err := s.store.Info(ctx, dgst)
+	if err != nil {
+		return content.Info{}, err
+	}
+	dir := s.refDir(dgst)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return content.Info{}, errors.WithStack(err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, id), os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return content.Info{}, errors.WithStack(err)
+	}
+	return info, f.Close()
+}
+
+func (s *Store) hasRef(id string, dgst digest.Digest) (bool, error) {
+	_, err := os.Stat(filepath.Join(s.refDir(dgst), id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+	return true, nil
+}
+
+// removeRef removes the reference of the worker id on dgst and deletes the
+// blob if no worker references it anymore
+func (s *Store) removeRef(ctx context.Context, id string, dgst digest.Digest) error {
+	unlock, err := s.acquire()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	dir := s.refDir(dgst)
+	if err := os.Remove(filepath.Join(dir, id)); err != nil {
+		if os.IsNotExist(err) {
+			return errors.Wrapf(cerrdefs.ErrNotFound, "content %v", dgst)
+		}
+		return errors.WithStack(err)
+	}
+	ids, err := s.refs(dgst)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		return nil
+	}
+	if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	if err := s.store.Delete(ctx, dgst); err != nil && !cerrdefs.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// view is the content store of a single worker. The ingests of the worker are
+// prefixed with its ID so that the workers don't resume each others writes.
+type view struct {
+	content.Store
+	s  *Store
+	id string
+}
+
+// Info adds a reference on the blob. The containerd metadata store only asks
+// the backend for the blobs that it doesn't know yet before adding them, so
+// the blob is referenced before the metadata store starts using it. A
+// reference that the worker doesn't use is removed by its next garbage
+// collection.
+func (v *view) Info(ctx context.Context, dgst digest.Digest) (content.Info, error) {
+	return v.s.addRef(ctx, v.id, dgst)
+}
+
+func (v *view) Update(ctx context.Context, info content.Info, fieldpaths ...string) (content.Info, error) {
+	ok, err := v.s.hasRef(v.id, info.Digest)
+	if err != nil {
+		return content.Info{}, err
+	}
+	if !ok {
+		return content.Info{}, errors.Wrapf(cerrdefs.ErrNotFound, "content %v", info.Digest)
+	}
+	return v.Store.Update(ctx, info, fieldpaths...)
+}
+
+// Walk only walks the blobs referenced by the worker
+func (v *view) Walk(ctx context.Context, fn content.WalkFunc, fs ...string) error {
+	return v.Store.Walk(ctx, func(info content.Info) error {
+		ok, err := v.s.hasRef(v.id, info.Digest)
+		if err != nil || !ok {
+			return err
+		}
+		return fn(info)
+	}, fs...)
+}
+
+// Delete removes the reference of the worker on the blob
+func (v *view) Delete(ctx context.Context, dgst digest.Digest) error {
+	return v.s.removeRef(ctx, v.id, dgst)
+}
+
+func (v *view) Status(ctx context.Context, ref string) (content.Status, error) {
+	st, err := v.Store.Status(ctx, v.ref(ref))
+	if err != nil {
+		return st, err
+	}
+	st.Ref = ref
+	return st, nil
+}
+
+// ListStatuses only lists the ingests of the worker
+func (v *view) ListStatuses(ctx context.Context, fs ...string) ([]content.Status, error) {
+	sts, err := v.Store.ListStatuses(ctx, fs...)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]content.Status, 0, len(sts))
+	for _, st := range sts {
+		ref, ok := strings.CutPrefix(st.Ref, v.ref(""))
+		if !ok {
+			continue
+		}
+		st.Ref = ref
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+func (v *view) Abort(ctx context.Context, ref string) error {
+	return v.Store.Abort(ctx, v.ref(ref))
+}
+
+func (v *view) Writer(ctx context.Context, opts ...content.WriterOpt) (content.Writer, error) {
+	var wOpts content.WriterOpts
+	for _, opt := range opts {
+		if err := opt(&wOpts); err != nil {
+			return nil, err
+		}
+	}
+	if wOpts.Ref == "" {
+		return nil, errors.Wrap(cerrdefs.ErrInvalidArgument, "ref must not be empty")
+	}
+	if wOpts.Desc.Digest != "" {
+		// the blob was already pulled by another worker
+		if _, err := v.s.addRef(ctx, v.id, wOpts.Desc.Digest); err == nil {
+			return nil, errors.Wrapf(cerrdefs.ErrAlreadyExists, "content %v", wOpts.Desc.Digest)
+		} else if !cerrdefs.IsNotFound(err) {
+			return nil, err
+		}
+	}
+	w, err := v.Store.Writer(ctx, content.WithRef(v.ref(wOpts.Ref)), content.WithDescriptor(wOpts.Desc))
+	if err != nil {
+		return nil, err
+	}
+	return &writer{Writer: w, v: v}, nil
+}
+
+func (v *view) ref(ref string) string {
+	return v.id + "-" + ref
+}
+
+// writer adds the reference of the worker on the blob once it is committed
+type writer struct {
+	content.Writer
+	v *view
+}
+
+func (w *writer) Commit(ctx context.Context, size int64, expected digest.Digest, opts ...content.Opt) error {
+	err := w.Writer.Commit(ctx, size, expected, opts...)
+	if err != nil && !cerrdefs.IsAlreadyExists(err) {
+		return err
+	}
+	if _, err := w.v.s.addRef(ctx, w.v.id, w.Digest()); err != nil {
+		return err
+	}
+	return err
+}
+
+func (w *writer) Status() (content.Status, error) {
+	st, err := w.Writer.Status()
+	if err != nil {
+		return st, err
+	}
+	st.Ref = strings.TrimPrefix(st.Ref, w.v.ref(""))
+	return st, nil
+}
@@ -0,0 +1,162 @@
+package sharedcontent
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/containerd/v2/core/content"
+	"github.com/containerd/containerd/v2/core/leases"
+	ctdmetadata "github.com/containerd/containerd/v2/core/metadata"
+	"github.com/containerd/containerd/v2/core/snapshots"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
+	cerrdefs "github.com/containerd/errdefs"
+	digest "github.com/opencontainers/go-digest"
+	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+type testWorker struct {
+	mdb *ctdmetadata.DB
+	cs  content.Store
+	lm  leases.Manager
+}
+
+func newTestWorker(t *testing.T, s *Store, id string) *testWorker {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "containerdmeta.db"), 0644, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	mdb := ctdmetadata.NewDB(db, s.View(id), map[string]snapshots.Snapshotter{})
+	require.NoError(t, mdb.Init(context.TODO()))
+	return &testWorker{
+		mdb: mdb,
+		cs:  mdb.ContentStore(),
+		lm:  ctdmetadata.NewLeaseManager(mdb),
+	}
+}
+
+// pull writes dt to the content store of the worker under a lease
+func (w *testWorker) pull(ctx context.Context, t *testing.T, dt []byte) (ocispecs.Descriptor, leases.Lease) {
+	l, err := w.lm.Create(ctx, leases.WithRandomID())
+	require.NoError(t, err)
+	desc := ocispecs.Descriptor{
+		MediaType: ocispecs.MediaTypeImageLayer,
+		Digest:    digest.FromBytes(dt),
+		Size:      int64(len(dt)),
+	}
+	err = content.WriteBlob(leases.WithLease(ctx, l.ID), w.cs, "pull-"+desc.Digest.String(), bytes.NewReader(dt), desc)
+	require.NoError(t, err)
+	return desc, l
+}
+
+func (w *testWorker) release(ctx context.Context, t *testing.T, l leases.Lease) {
+	require.NoError(t, w.lm.Delete(ctx, l))
+	_, err := w.mdb.GarbageCollect(ctx)
+	require.NoError(t, err)
+}
+
+func TestSharedStore(t *testing.T) {
+	ctx := namespaces.WithNamespace(context.Background(), "buildkit")
+
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	w1 := newTestWorker(t, s, "w1")
+	w2 := newTestWorker(t, s, "w2")
+
+	desc, l1 := w1.pull(ctx, t, []byte("layer"))
+	other, l1other := w1.pull(ctx, t, []byte("other"))
+
+	st, err := s.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Status{
+		Blobs:   2,
+		Size:    desc.Size + other.Size,
+		Workers: map[string]int{"w1": 2},
+	}, st)
+
+	// the second worker doesn't write the blob again
+	_, l2 := w2.pull(ctx, t, []byte("layer"))
+	_, err = w2.cs.Info(ctx, desc.Digest)
+	require.NoError(t, err)
+	sts, err := s.store.ListStatuses(ctx)
+	require.NoError(t, err)
+	require.Empty(t, sts)
+
+	st, err = s.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Status{
+		Blobs:       2,
+		Size:        desc.Size + other.Size,
+		SharedBlobs: 1,
+		SavedSize:   desc.Size,
+		Workers:     map[string]int{"w1": 2, "w2": 1},
+	}, st)
+
+	// the garbage collection of a worker only releases its own references
+	w1.release(ctx, t, l1)
+	_, err = w1.cs.Info(ctx, desc.Digest)
+	require.ErrorIs(t, err, cerrdefs.ErrNotFound)
+	ra, err := w2.cs.ReaderAt(ctx, desc)
+	require.NoError(t, err)
+	dt, err := content.ReadBlob(ctx, w2.cs, desc)
+	require.NoError(t, err)
+	require.Equal(t, "layer", string(dt))
+	ra.Close()
+
+	st, err = s.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"w1": 1, "w2": 1}, st.Workers)
+
+	// the blob is deleted once no worker references it
+	w2.release(ctx, t, l2)
+	_, err = s.store.Info(ctx, desc.Digest)
+	require.ErrorIs(t, err, cerrdefs.ErrNotFound)
+	_, err = s.store.Info(ctx, other.Digest)
+	require.NoError(t, err)
+
+	w1.release(ctx, t, l1other)
+	st, err = s.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Status{Workers: map[string]int{}}, st)
+}
+
+func TestSharedStoreIngests(t *testing.T) {
+	ctx := namespaces.WithNamespace(context.Background(), "buildkit")
+
+	s, err := NewStore(t.TempDir())
+	require.NoError(t, err)
+	v1 := s.View("w1")
+	v2 := s.View("w2")
+
+	w, err := v1.Writer(ctx, content.WithRef("ref"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("partial"))
+	require.NoError(t, err)
+	ws, err := w.Status()
+	require.NoError(t, err)
+	require.Equal(t, "ref", ws.Ref)
+	require.NoError(t, w.Close())
+
+	// the workers don't see the ingests of each other
+	st, err := v1.Status(ctx, "ref")
+	require.NoError(t, err)
+	require.Equal(t, "ref", st.Ref)
+	require.Equal(t, int64(7), st.Offset)
+	_, err = v2.Status(ctx, "ref")
+	require.ErrorIs(t, err, cerrdefs.ErrNotFound)
+
+	sts, err := v1.ListStatuses(ctx)
+	require.NoError(t, err)
+	require.Len(t, sts, 1)
+	require.Equal(t, "ref", sts[0].Ref)
+	sts, err = v2.ListStatuses(ctx)
+	require.NoError(t, err)
+	require.Empty(t, sts)
+
+	require.NoError(t, v1.Abort(ctx, "ref"))
+	sts, err = v1.ListStatuses(ctx)
+	require.NoError(t, err)
+	require.Empty(t, sts)
+}
@@ -0,0 +1,32 @@
+package base
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/moby/buildkit/executor"
+	resourcestypes "github.com/moby/buildkit/executor/resources/types"
+)
+
+// countingExecutor keeps track of the number of running processes of the
+// executor it wraps
+type countingExecutor struct {
+	executor.Executor
+	running atomic.Int64
+}
+
+func (e *countingExecutor) Run(ctx context.Context, id string, rootfs executor.Mount, mounts []executor.Mount, process executor.ProcessInfo, started chan<- struct{}) (resourcestypes.Recorder, error) {
+	e.running.Add(1)
+	defer e.running.Add(-1)
+	return e.Executor.Run(ctx, id, rootfs, mounts, process, started)
+}
+
+func (e *countingExecutor) Exec(ctx context.Context, id string, process executor.ProcessInfo) error {
+	e.running.Add(1)
+	defer e.running.Add(-1)
+	return e.Executor.Exec(ctx, id, process)
+}
+
+func (e *countingExecutor) Running() int {
+	return int(e.running.Load())
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/containerd/containerd/v2/core/content"
@@ -20,7 +21,9 @@ import (
 	"github.com/moby/buildkit/client/llb/sourceresolver"
 	"github.com/moby/buildkit/executor"
 	"github.com/moby/buildkit/executor/resources"
+	resourcestypes "github.com/moby/buildkit/executor/resources/types"
 	"github.com/moby/buildkit/exporter"
+	artifactexporter "github.com/moby/buildkit/exporter/artifact"
 	imageexporter "github.com/moby/buildkit/exporter/containerimage"
 	localexporter "github.com/moby/buildkit/exporter/local"
 	ociexporter "github.com/moby/buildkit/exporter/oci"
@@ -41,8 +44,11 @@ import (
 	"github.com/moby/buildkit/source/git"
 	"github.com/moby/buildkit/source/http"
 	"github.com/moby/buildkit/source/local"
+	sourceplugin "github.com/moby/buildkit/source/plugin"
+	"github.com/moby/buildkit/source/sessionref"
 	"github.com/moby/buildkit/util/archutil"
 	"github.com/moby/buildkit/util/bklog"
+	"github.com/moby/buildkit/util/disk"
 	"github.com/moby/buildkit/util/leaseutil"
 	"github.com/moby/buildkit/util/network"
 	"github.com/moby/buildkit/util/progress"
@@ -84,6 +90,14 @@ type WorkerOpt struct {
 	MountPoolRoot    string
 	ResourceMonitor  *resources.Monitor
 	CDIManager       *cdidevices.Manager
+	ImageNamespace   func(ns string) (*imageexporter.Namespace, error) // optional
+	// UnpackConcurrency is the maximum number of layers of an image
+	// decompressed while the layers below them are extracted
+	UnpackConcurrency int
+	// ExporterPlugins are the external exporters by exporter type
+	ExporterPlugins map[string]exporter.Exporter
+	// SourcePlugins are the external sources
+	SourcePlugins []*sourceplugin.Plugin
 }
 
 // Worker is a local worker instance with dedicated snapshotter, cache, and so on.
@@ -95,6 +109,12 @@ type Worker struct {
 	imageWriter     *imageexporter.ImageWriter
 	ImageSource     *containerimage.Source
 	OCILayoutSource *containerimage.Source
+	sessionRefs     *sessionref.Store
+	execs           *countingExecutor
+
+	// mu guards the labels and GC policy of WorkerOpt, which are replaced
+	// when the config of the daemon is reloaded
+	mu sync.RWMutex
 }
 
 // NewWorker instantiates a local worker
@@ -105,16 +125,17 @@ func NewWorker(ctx context.Context, opt WorkerOpt) (*Worker, error) {
 	})
 
 	cm, err := cache.NewManager(cache.ManagerOpt{
-		Snapshotter:     opt.Snapshotter,
-		PruneRefChecker: imageRefChecker,
-		Applier:         opt.Applier,
-		GarbageCollect:  opt.GarbageCollect,
-		LeaseManager:    opt.LeaseManager,
-		ContentStore:    opt.ContentStore,
-		Differ:          opt.Differ,
-		MetadataStore:   opt.MetadataStore,
-		Root:            opt.Root,
-		MountPoolRoot:   opt.MountPoolRoot,
+		Snapshotter:       opt.Snapshotter,
+		PruneRefChecker:   imageRefChecker,
+		Applier:           opt.Applier,
+		GarbageCollect:    opt.GarbageCollect,
+		LeaseManager:      opt.LeaseManager,
+		ContentStore:      opt.ContentStore,
+		Differ:            opt.Differ,
+		MetadataStore:     opt.MetadataStore,
+		Root:              opt.Root,
+		MountPoolRoot:     opt.MountPoolRoot,
+		UnpackConcurrency: opt.UnpackConcurrency,
 	})
 	if err != nil {
 		return nil, err
@@ -185,6 +206,26 @@ func NewWorker(ctx context.Context, opt WorkerOpt) (*Worker, error) {
 
 	sm.Register(os)
 
+	sessionRefs := sessionref.NewStore()
+	srs, err := sessionref.NewSource(sessionref.Opt{
+		Store: sessionRefs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sm.Register(srs)
+
+	for _, p := range opt.SourcePlugins {
+		ps, err := sourceplugin.NewSource(sourceplugin.Opt{
+			Plugin:        p,
+			CacheAccessor: cm,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sm.Register(ps)
+	}
+
 	iw, err := imageexporter.NewImageWriter(imageexporter.WriterOpt{
 		Snapshotter:  opt.Snapshotter,
 		ContentStore: opt.ContentStore,
@@ -203,6 +244,12 @@ func NewWorker(ctx context.Context, opt WorkerOpt) (*Worker, error) {
 		opt.LeaseManager.Delete(ctx, l)
 	}
 
+	var execs *countingExecutor
+	if opt.Executor != nil {
+		execs = &countingExecutor{Executor: opt.Executor}
+		opt.Executor = execs
+	}
+
 	return &Worker{
 		WorkerOpt:       opt,
 		CacheMgr:        cm,
@@ -210,6 +257,8 @@ func NewWorker(ctx context.Context, opt WorkerOpt) (*Worker, error) {
 		imageWriter:     iw,
 		ImageSource:     is,
 		OCILayoutSource: os,
+		sessionRefs:     sessionRefs,
+		execs:           execs,
 	}, nil
 }
 
@@ -239,6 +288,46 @@ func (w *Worker) Close() error {
 	return stderrors.Join(errs...)
 }
 
+func (w *Worker) Status(ctx context.Context) (*client.WorkerStatus, error) {
+	st, err := disk.GetDiskStat(w.WorkerOpt.Root)
+	if err != nil {
+		return nil, err
+	}
+	status := &client.WorkerStatus{
+		DiskTotal:     st.Total,
+		DiskFree:      st.Free,
+		DiskAvailable: st.Available,
+	}
+	if w.execs != nil {
+		status.RunningExecs = w.execs.Running()
+	}
+	cpu, memory, io, err := resources.SysPressure()
+	if err != nil {
+		return nil, err
+	}
+	status.CPUPressure = toPressureInfo(cpu)
+	status.MemoryPressure = toPressureInfo(memory)
+	status.IOPressure = toPressureInfo(io)
+	return status, nil
+}
+
+func toPressureInfo(p *resourcestypes.Pressure) *client.PressureInfo {
+	if p == nil || p.Some == nil {
+		return nil
+	}
+	var out client.PressureInfo
+	if v := p.Some.Avg10; v != nil {
+		out.Avg10 = *v
+	}
+	if v := p.Some.Avg60; v != nil {
+		out.Avg60 = *v
+	}
+	if v := p.Some.Avg300; v != nil {
+		out.Avg300 = *v
+	}
+	return &out
+}
+
 func (w *Worker) ContentStore() *containerdsnapshot.Store {
 	return w.WorkerOpt.ContentStore
 }
@@ -251,14 +340,27 @@ func (w *Worker) CDIManager() *cdidevices.Manager {
 	return w.WorkerOpt.CDIManager
 }
 
+func (w *Worker) Emulators() []archutil.Emulator {
+	return archutil.Emulators()
+}
+
 func (w *Worker) ID() string {
 	return w.WorkerOpt.ID
 }
 
 func (w *Worker) Labels() map[string]string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 	return w.WorkerOpt.Labels
 }
 
+// SetLabels replaces the labels of the worker
+func (w *Worker) SetLabels(labels map[string]string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.WorkerOpt.Labels = labels
+}
+
 func (w *Worker) Platforms(noCache bool) []ocispecs.Platform {
 	if noCache {
 		matchers := make([]platforms.MatchComparer, len(w.WorkerOpt.Platforms))
@@ -282,9 +384,19 @@ func (w *Worker) Platforms(noCache bool) []ocispecs.Platform {
 }
 
 func (w *Worker) GCPolicy() []client.PruneInfo {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 	return w.WorkerOpt.GCPolicy
 }
 
+// SetGCPolicy replaces the GC policy of the worker, used from the next
+// garbage collection on
+func (w *Worker) SetGCPolicy(policy []client.PruneInfo) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.WorkerOpt.GCPolicy = policy
+}
+
 func (w *Worker) BuildkitVersion() client.BuildkitVersion {
 	return w.WorkerOpt.BuildkitVersion
 }
@@ -335,6 +447,11 @@ func (w *Worker) CacheManager() cache.Manager {
 	return w.CacheMgr
 }
 
+// SessionRefs returns the store of the refs retained by the sessions
+func (w *Worker) SessionRefs() *sessionref.Store {
+	return w.sessionRefs
+}
+
 func (w *Worker) ResolveOp(v solver.Vertex, s frontend.FrontendLLBBridge, sm *session.Manager) (solver.Op, error) {
 	if baseOp, ok := v.Sys().(*pb.Op); ok {
 		switch op := baseOp.Op.(type) {
@@ -463,6 +580,7 @@ func (w *Worker) Exporter(name string, sm *session.Manager) (exporter.Exporter,
 			ImageWriter:    w.imageWriter,
 			RegistryHosts:  w.RegistryHosts,
 			LeaseManager:   w.LeaseManager(),
+			Namespace:      w.ImageNamespace,
 		})
 	case client.ExporterLocal:
 		return localexporter.New(localexporter.Opt{
@@ -486,7 +604,17 @@ func (w *Worker) Exporter(name string, sm *session.Manager) (exporter.Exporter,
 			Variant:        ociexporter.VariantDocker,
 			LeaseManager:   w.LeaseManager(),
 		})
+	case client.ExporterArtifact:
+		return artifactexporter.New(artifactexporter.Opt{
+			SessionManager: sm,
+			ContentStore:   w.ContentStore(),
+			RegistryHosts:  w.RegistryHosts,
+			LeaseManager:   w.LeaseManager(),
+		})
 	default:
+		if exp, ok := w.ExporterPlugins[name]; ok {
+			return exp, nil
+		}
 		return nil, errors.Errorf("exporter %q could not be found", name)
 	}
 }
@@ -1,9 +1,11 @@
 package base
 
 import (
+	"context"
 	"os"
 	"testing"
 
+	"github.com/moby/buildkit/executor"
 	"github.com/stretchr/testify/require"
 )
 
@@ -28,3 +30,42 @@ func TestID(t *testing.T) {
 
 	require.NotEqual(t, id0, id2)
 }
+
+type blockingExecutor struct {
+	executor.Executor
+	started chan struct{}
+	release chan struct{}
+}
+
+func (e *blockingExecutor) Exec(ctx context.Context, id string, process executor.ProcessInfo) error {
+	e.started <- struct{}{}
+	<-e.release
+	return nil
+}
+
+func TestCountingExecutor(t *testing.T) {
+	t.Parallel()
+	be := &blockingExecutor{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	e := &countingExecutor{Executor: be}
+
+	done := make(chan struct{})
+	for range 2 {
+		go func() {
+			e.Exec(context.TODO(), "", executor.ProcessInfo{})
+			done <- struct{}{}
+		}()
+		<-be.started
+	}
+	require.Equal(t, 2, e.Running())
+
+	be.release <- struct{}{}
+	<-done
+	require.Equal(t, 1, e.Running())
+
+	be.release <- struct{}{}
+	<-done
+	require.Equal(t, 0, e.Running())
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	constraintID          = "worker.id"
+	constraintLabelPrefix = "worker.label."
+)
+
+// Constraint is a condition of a build on the worker it runs on
+type Constraint struct {
+	// Label is the label of the worker to match, the ID of the worker if empty
+	Label string
+	Value string
+}
+
+// ParseConstraints parses constraints in the "worker.label.<key>=<value>" and
+// "worker.id=<id>" forms
+func ParseConstraints(in []string) ([]Constraint, error) {
+	out := make([]Constraint, 0, len(in))
+	for _, s := range in {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid worker constraint %q, expected key=value", s)
+		}
+		switch {
+		case k == constraintID:
+			out = append(out, Constraint{Value: v})
+		case strings.HasPrefix(k, constraintLabelPrefix) && len(k) > len(constraintLabelPrefix):
+			out = append(out, Constraint{Label: strings.TrimPrefix(k, constraintLabelPrefix), Value: v})
+		default:
+			return nil, errors.Errorf("invalid worker constraint %q, expected %s<key> or %s", s, constraintLabelPrefix, constraintID)
+		}
+	}
+	return out, nil
+}
+
+func (c Constraint) Match(w Worker) bool {
+	if c.Label == "" {
+		return w.ID() == c.Value
+	}
+	v, ok := w.Labels()[c.Label]
+	return ok && v == c.Value
+}
+
+func (c Constraint) String() string {
+	if c.Label == "" {
+		return constraintID + "=" + c.Value
+	}
+	return constraintLabelPrefix + c.Label + "=" + c.Value
+}
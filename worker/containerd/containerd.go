@@ -9,13 +9,17 @@ import (
 	"strings"
 
 	ctd "github.com/containerd/containerd/v2/client"
+	"github.com/containerd/containerd/v2/core/images"
 	"github.com/containerd/containerd/v2/core/leases"
 	"github.com/containerd/containerd/v2/pkg/gc"
+	"github.com/containerd/containerd/v2/pkg/identifiers"
+	"github.com/containerd/containerd/v2/pkg/namespaces"
 	"github.com/containerd/platforms"
 	"github.com/moby/buildkit/cache"
 	"github.com/moby/buildkit/cache/metadata"
 	"github.com/moby/buildkit/executor/containerdexecutor"
 	"github.com/moby/buildkit/executor/oci"
+	imageexporter "github.com/moby/buildkit/exporter/containerimage"
 	containerdsnapshot "github.com/moby/buildkit/snapshot/containerd"
 	"github.com/moby/buildkit/solver/llbsolver/cdidevices"
 	"github.com/moby/buildkit/util/leaseutil"
@@ -185,6 +189,42 @@ func newContainerd(client *ctd.Client, workerOpts WorkerOptions) (base.WorkerOpt
 		ParallelismSem:   workerOpts.ParallelismSem,
 		MountPoolRoot:    filepath.Join(root, "cachemounts"),
 		CDIManager:       workerOpts.CDIManager,
+		ImageNamespace: func(ns string) (*imageexporter.Namespace, error) {
+			if err := identifiers.Validate(ns); err != nil {
+				return nil, err
+			}
+			return &imageexporter.Namespace{
+				Images:       &nsImageStore{ns: ns, Store: client.ImageService()},
+				ContentStore: cs.WithNamespace(ns),
+				LeaseManager: leaseutil.WithNamespace(client.LeasesService(), ns),
+				Snapshotter:  containerdsnapshot.NewSnapshotter(workerOpts.SnapshotterName, client.SnapshotService(workerOpts.SnapshotterName), ns, nil),
+			}, nil
+		},
 	}
 	return opt, nil
 }
+
+type nsImageStore struct {
+	ns string
+	images.Store
+}
+
+func (s *nsImageStore) Get(ctx context.Context, name string) (images.Image, error) {
+	return s.Store.Get(namespaces.WithNamespace(ctx, s.ns), name)
+}
+
+func (s *nsImageStore) List(ctx context.Context, filters ...string) ([]images.Image, error) {
+	return s.Store.List(namespaces.WithNamespace(ctx, s.ns), filters...)
+}
+
+func (s *nsImageStore) Create(ctx context.Context, image images.Image) (images.Image, error) {
+	return s.Store.Create(namespaces.WithNamespace(ctx, s.ns), image)
+}
+
+func (s *nsImageStore) Update(ctx context.Context, image images.Image, fieldpaths ...string) (images.Image, error) {
+	return s.Store.Update(namespaces.WithNamespace(ctx, s.ns), image, fieldpaths...)
+}
+
+func (s *nsImageStore) Delete(ctx context.Context, name string, opts ...images.DeleteOpt) error {
+	return s.Store.Delete(namespaces.WithNamespace(ctx, s.ns), name, opts...)
+}
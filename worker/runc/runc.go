@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strconv"
 
+	"github.com/containerd/containerd/v2/core/content"
 	"github.com/containerd/containerd/v2/core/diff/apply"
 	ctdmetadata "github.com/containerd/containerd/v2/core/metadata"
 	ctdsnapshot "github.com/containerd/containerd/v2/core/snapshots"
@@ -24,11 +25,13 @@ import (
 	"github.com/moby/buildkit/solver/llbsolver/cdidevices"
 	"github.com/moby/buildkit/util/leaseutil"
 	"github.com/moby/buildkit/util/network/netproviders"
+	"github.com/moby/buildkit/util/sharedcontent"
 	"github.com/moby/buildkit/util/winlayers"
 	"github.com/moby/buildkit/worker/base"
 	wlabel "github.com/moby/buildkit/worker/label"
 	"github.com/moby/sys/user"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/pkg/errors"
 	bolt "go.etcd.io/bbolt"
 	"golang.org/x/sync/semaphore"
 )
@@ -40,7 +43,7 @@ type SnapshotterFactory struct {
 }
 
 // NewWorkerOpt creates a WorkerOpt.
-func NewWorkerOpt(root string, snFactory SnapshotterFactory, rootless bool, processMode oci.ProcessMode, labels map[string]string, idmap *user.IdentityMapping, nopt netproviders.Opt, dns *oci.DNSConfig, binary, apparmorProfile string, selinux bool, parallelismSem *semaphore.Weighted, traceSocket, defaultCgroupParent string, cdiManager *cdidevices.Manager) (base.WorkerOpt, error) {
+func NewWorkerOpt(root string, snFactory SnapshotterFactory, rootless bool, processMode oci.ProcessMode, labels map[string]string, idmap *user.IdentityMapping, nopt netproviders.Opt, dns *oci.DNSConfig, binary, apparmorProfile string, selinux bool, parallelismSem *semaphore.Weighted, traceSocket, defaultCgroupParent string, cdiManager *cdidevices.Manager, sharedContent *sharedcontent.Store) (base.WorkerOpt, error) {
 	var opt base.WorkerOpt
 	name := "runc-" + snFactory.Name
 	root = filepath.Join(root, name)
@@ -90,7 +93,12 @@ func NewWorkerOpt(root string, snFactory SnapshotterFactory, rootless bool, proc
 		return opt, err
 	}
 
-	localstore, err := local.NewStore(filepath.Join(root, "content"))
+	id, err := base.ID(root)
+	if err != nil {
+		return opt, err
+	}
+
+	contentStore, err := newContentStore(root, id, sharedContent)
 	if err != nil {
 		return opt, err
 	}
@@ -100,7 +108,7 @@ func NewWorkerOpt(root string, snFactory SnapshotterFactory, rootless bool, proc
 		return opt, err
 	}
 
-	mdb := ctdmetadata.NewDB(db, localstore, map[string]ctdsnapshot.Snapshotter{
+	mdb := ctdmetadata.NewDB(db, contentStore, map[string]ctdsnapshot.Snapshotter{
 		snFactory.Name: s,
 	})
 	if err := mdb.Init(context.TODO()); err != nil {
@@ -109,10 +117,6 @@ func NewWorkerOpt(root string, snFactory SnapshotterFactory, rootless bool, proc
 
 	c := containerdsnapshot.NewContentStore(mdb.ContentStore(), "buildkit")
 
-	id, err := base.ID(root)
-	if err != nil {
-		return opt, err
-	}
 	hostname, err := os.Hostname()
 	if err != nil {
 		hostname = "unknown"
@@ -128,6 +132,9 @@ func NewWorkerOpt(root string, snFactory SnapshotterFactory, rootless bool, proc
 	if apparmorProfile != "" {
 		xlabels[wlabel.ApparmorProfile] = apparmorProfile
 	}
+	if sharedContent != nil {
+		xlabels[wlabel.SharedContent] = sharedContent.Root()
+	}
 
 	maps.Copy(xlabels, labels)
 	lm := leaseutil.WithNamespace(ctdmetadata.NewLeaseManager(mdb), "buildkit")
@@ -171,3 +178,35 @@ func NewWorkerOpt(root string, snFactory SnapshotterFactory, rootless bool, proc
 	}
 	return opt, nil
 }
+
+// newContentStore returns the content store of the worker. A worker keeps
+// using the content store it was created with because its metadata only
+// refers to the blobs of that store.
+func newContentStore(root, id string, sharedContent *sharedcontent.Store) (content.Store, error) {
+	markerPath := filepath.Join(root, "content-shared")
+	marker, err := os.ReadFile(markerPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, errors.WithStack(err)
+	}
+	if sharedContent == nil {
+		if marker != nil {
+			return nil, errors.Errorf("worker at %s uses the shared content store %s", root, marker)
+		}
+		return local.NewStore(filepath.Join(root, "content"))
+	}
+	if marker == nil {
+		blobs, err := os.ReadDir(filepath.Join(root, "content", "blobs"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, errors.WithStack(err)
+		}
+		if len(blobs) > 0 {
+			return nil, errors.Errorf("worker at %s already has its own content store and cannot use the shared content store %s", root, sharedContent.Root())
+		}
+		if err := os.WriteFile(markerPath, []byte(sharedContent.Root()), 0600); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	} else if string(marker) != sharedContent.Root() {
+		return nil, errors.Errorf("worker at %s uses the shared content store %s, not %s", root, marker, sharedContent.Root())
+	}
+	return sharedContent.View(id), nil
+}
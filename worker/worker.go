@@ -15,6 +15,7 @@ import (
 	"github.com/moby/buildkit/solver"
 	"github.com/moby/buildkit/solver/llbsolver/cdidevices"
 	"github.com/moby/buildkit/solver/pb"
+	"github.com/moby/buildkit/util/archutil"
 	"github.com/moby/buildkit/util/leaseutil"
 	ocispecs "github.com/opencontainers/image-spec/specs-go/v1"
 )
@@ -43,6 +44,18 @@ type Worker interface {
 	LeaseManager() *leaseutil.Manager
 	GarbageCollect(context.Context) error
 	CDIManager() *cdidevices.Manager
+	// Emulators returns the emulators of the platforms the processes of the
+	// worker can run on in addition to the native platform
+	Emulators() []archutil.Emulator
+	// Status returns the current resource usage of the worker
+	Status(ctx context.Context) (*client.WorkerStatus, error)
+}
+
+// Reconfigurable is implemented by the workers whose labels and GC policy can
+// be changed while they run
+type Reconfigurable interface {
+	SetLabels(map[string]string)
+	SetGCPolicy([]client.PruneInfo)
 }
 
 type Infos interface {
@@ -2,6 +2,7 @@ package worker
 
 import (
 	stderrors "errors"
+	"sync"
 
 	"github.com/containerd/containerd/v2/pkg/filters"
 	"github.com/moby/buildkit/cache"
@@ -14,6 +15,9 @@ import (
 type Controller struct {
 	// TODO: define worker interface and support remote ones
 	workers []Worker
+
+	mu     sync.Mutex
+	active map[string]int
 }
 
 func (c *Controller) Close() error {
@@ -67,7 +71,55 @@ func (c *Controller) Get(id string) (Worker, error) {
 	return nil, errors.Errorf("worker %s not found", id)
 }
 
-// TODO: add Get(Constraint) (*Worker, error)
+// Select returns the worker for a build with the constraints and counts the
+// build as active on the worker until release is called. The default worker
+// is returned if there are no constraints, otherwise the matching worker with
+// the fewest active builds.
+func (c *Controller) Select(constraints []Constraint) (_ Worker, release func(), _ error) {
+	var w Worker
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(constraints) == 0 {
+		var err error
+		if w, err = c.GetDefault(); err != nil {
+			return nil, nil, err
+		}
+	} else {
+	loop:
+		for _, w2 := range c.workers {
+			for _, cs := range constraints {
+				if !cs.Match(w2) {
+					continue loop
+				}
+			}
+			if w == nil || c.active[w2.ID()] < c.active[w.ID()] {
+				w = w2
+			}
+		}
+		if w == nil {
+			return nil, nil, errors.Errorf("no worker matches the constraints %v", constraints)
+		}
+	}
+	if c.active == nil {
+		c.active = map[string]int{}
+	}
+	c.active[w.ID()]++
+	var once sync.Once
+	return w, func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.active[w.ID()]--
+		})
+	}, nil
+}
+
+// ActiveBuilds returns the number of builds running on the worker
+func (c *Controller) ActiveBuilds(id string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.active[id]
+}
 
 // WorkerInfos returns slice of WorkerInfo.
 // The first item is the default worker.
@@ -79,6 +131,7 @@ func (c *Controller) WorkerInfos() []client.WorkerInfo {
 			Labels:          w.Labels(),
 			Platforms:       w.Platforms(false),
 			BuildkitVersion: w.BuildkitVersion(),
+			ActiveBuilds:    c.ActiveBuilds(w.ID()),
 		})
 	}
 	return out
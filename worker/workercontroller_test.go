@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testWorker struct {
+	Worker
+	id     string
+	labels map[string]string
+}
+
+func (w *testWorker) ID() string {
+	return w.id
+}
+
+func (w *testWorker) Labels() map[string]string {
+	return w.labels
+}
+
+func TestSelect(t *testing.T) {
+	c := &Controller{}
+	require.NoError(t, c.Add(&testWorker{id: "w0"}))
+	require.NoError(t, c.Add(&testWorker{id: "w1", labels: map[string]string{"gpu": "true"}}))
+	require.NoError(t, c.Add(&testWorker{id: "w2", labels: map[string]string{"gpu": "true", "zone": "b"}}))
+
+	w, release, err := c.Select(nil)
+	require.NoError(t, err)
+	require.Equal(t, "w0", w.ID())
+	release()
+
+	gpu, err := ParseConstraints([]string{"worker.label.gpu=true"})
+	require.NoError(t, err)
+
+	w, release1, err := c.Select(gpu)
+	require.NoError(t, err)
+	require.Equal(t, "w1", w.ID())
+	require.Equal(t, 1, c.ActiveBuilds("w1"))
+
+	// least loaded
+	w, release2, err := c.Select(gpu)
+	require.NoError(t, err)
+	require.Equal(t, "w2", w.ID())
+
+	release1()
+	release1()
+	require.Equal(t, 0, c.ActiveBuilds("w1"))
+	release2()
+
+	cs, err := ParseConstraints([]string{"worker.label.gpu=true", "worker.id=w2"})
+	require.NoError(t, err)
+	w, release, err = c.Select(cs)
+	require.NoError(t, err)
+	require.Equal(t, "w2", w.ID())
+	release()
+
+	cs, err = ParseConstraints([]string{"worker.label.zone=a"})
+	require.NoError(t, err)
+	_, _, err = c.Select(cs)
+	require.ErrorContains(t, err, "no worker matches the constraints [worker.label.zone=a]")
+
+	for _, s := range []string{"gpu=true", "worker.label.=x", "worker.label.gpu"} {
+		_, err := ParseConstraints([]string{s})
+		require.Error(t, err, s)
+	}
+}